@@ -1,7 +1,14 @@
 // internal/app/bootstrap/appconfig.go
 package bootstrap
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	filesfeature "github.com/dalemusser/stratasave/internal/app/features/files"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/waffle/config"
+)
 
 // AppConfig holds service-specific configuration for this WAFFLE app.
 //
@@ -39,27 +46,99 @@ type AppConfig struct {
 	SessionDomain string        // Cookie domain (blank means current host)
 	SessionMaxAge time.Duration // Maximum session cookie lifetime (default: 24h)
 
+	// Session lifetimes (0 idle = no idle limit; per-role 0 = use the defaults above)
+	SessionIdleTimeout          time.Duration // End sessions after this long without a request (default: 0)
+	SessionAdminMaxAge          time.Duration // Absolute session lifetime for admins
+	SessionAdminIdleTimeout     time.Duration // Idle timeout for admins
+	SessionDeveloperMaxAge      time.Duration // Absolute session lifetime for developers
+	SessionDeveloperIdleTimeout time.Duration // Idle timeout for developers
+
 	// Idle logout configuration
 	IdleLogoutEnabled bool          // Enable automatic logout after idle time
 	IdleLogoutTimeout time.Duration // Duration of inactivity before logout (default: 30m)
 	IdleLogoutWarning time.Duration // Time before logout to show warning (default: 5m)
 
+	// Impersonation configuration
+	ImpersonationTimeout time.Duration // How long an admin can act as another user (default: 30m)
+
+	// Step-up re-authentication
+	ReauthWindow time.Duration // How recently a user must have authenticated for sensitive actions (default: 10m, 0 = off)
+
 	// Rate limiting configuration
 	RateLimitEnabled       bool          // Enable rate limiting for login attempts (default: true)
 	RateLimitLoginAttempts int           // Max failed login attempts before lockout (default: 5)
 	RateLimitLoginWindow   time.Duration // Time window for counting failed attempts (default: 15m)
 	RateLimitLoginLockout  time.Duration // Lockout duration after exceeding limit (default: 15m)
 
+	// Suspicious activity detection
+	DetectFailedLoginAccounts int           // Accounts failing from one IP within the window that raise an alert (default: 10, 0 = off)
+	DetectFailedLoginWindow   time.Duration // Window for counting failed logins from one IP (default: 15m)
+	DetectBlockDuration       time.Duration // How long a flagged IP is blocked from logging in (default: 0 = alert only)
+	DetectTravelSpeedKmh      int           // Fastest believable travel between logins, in km/h (default: 1000, 0 = off)
+	DetectLatitudeHeader      string        // Proxy header with the client's latitude; empty disables travel checks
+	DetectLongitudeHeader     string        // Proxy header with the client's longitude; empty disables travel checks
+	SecurityAlertEmails       string        // Comma-separated recipients; empty = all active admins
+
+	// Password policy configuration
+	PasswordBreachCheck   bool          // Reject new passwords found in known breaches (default: false)
+	PasswordMaxAge        time.Duration // How long a password lasts before it must be changed (default: 0 = never)
+	PasswordExpiryWarning time.Duration // Email users this long before their password expires (default: 168h)
+
+	// Deleted user accounts
+	UserRestoreDays int // Days a deleted user can be restored before being purged (0 = until deleted by hand)
+
+	// Self-service account deletion
+	AccountSelfDelete      bool // Users can delete their own account from their profile
+	AccountDeleteGraceDays int  // Days before a requested deletion happens (0 = at once)
+
+	// Scheduled user deactivation
+	UserDisableWarning time.Duration // Email users this long before their scheduled disable date (default: 168h)
+
+	// User invitations
+	InviteReminder time.Duration // Remind invitees this long before their invitation expires (default: 48h)
+
+	// CAPTCHA configuration (empty provider disables CAPTCHA)
+	CaptchaProvider  string // hcaptcha, recaptcha, or turnstile
+	CaptchaSiteKey   string // Public site key rendered in forms
+	CaptchaSecretKey string // Secret key for siteverify requests
+
 	// CSRF protection configuration
 	CSRFKey string // Secret key for CSRF token signing (32 bytes, must be strong in production)
 
+	// Encryption of secrets saved on the settings page (the SMTP password).
+	// Empty disables editing SMTP settings there.
+	SettingsEncryptionKey string
+
 	// API key authentication (for external API consumers)
 	// When set, enables Bearer token authentication for /api/* routes.
 	// Leave empty to disable API key authentication.
 	APIKey string
 
+	// HMAC request signing (alternative to bearer API keys for game clients)
+	// Clients sign each request with this shared secret and a timestamp, which
+	// provides replay protection. Leave empty to disable request signing.
+	APISigningSecret  string
+	APISigningMaxSkew time.Duration // Allowed clock difference for signed requests (default: 5m)
+
+	// Short-lived API tokens (JWTs scoped to one user_id and game)
+	// Game servers exchange their API key for a token at /api/token so the
+	// long-lived key never ships in the client. Leave empty to disable.
+	APITokenSecret string
+	APITokenTTL    time.Duration // Lifetime of issued tokens (default: 15m)
+
+	// Reverse proxies whose X-Forwarded-For and X-Real-IP headers are believed
+	// when checking API key IP allowlists. Comma-separated IPs or CIDR ranges;
+	// empty checks allowlists against the connection address.
+	TrustedProxies string
+
+	// API key notifications (emailed on create, revoke, expiry, and error spikes)
+	APIKeyAlertEmails    string        // Comma-separated recipients; empty = all active admins
+	APIKeyExpiryWarning  time.Duration // Warn this long before a key expires (default: 168h)
+	APIKeyErrorThreshold int           // Errors within the window that trigger an alert (0 = disabled)
+	APIKeyErrorWindow    time.Duration // Window for counting errors (default: 15m)
+
 	// File storage configuration
-	StorageType      string // Storage backend: "local" or "s3"
+	StorageType      string // Storage backend: "local", "s3", "gcs", or "azure"
 	StorageLocalPath string // Local storage path (e.g., "./uploads")
 	StorageLocalURL  string // URL prefix for serving local files (e.g., "/files")
 
@@ -71,6 +150,46 @@ type AppConfig struct {
 	StorageCFKeyPairID string // CloudFront key pair ID
 	StorageCFKeyPath   string // Path to CloudFront private key file
 
+	StorageS3DirectUploads bool // Browsers upload library files straight to S3 (default: false)
+
+	// Signed downloads: library views and downloads redirect to CloudFront
+	// signed URLs (when StorageCFURL is set) or S3 presigned GETs
+	StorageS3PresignedDownloads bool          // Presigned GETs without CloudFront (default: false)
+	StorageDownloadURLTTL       time.Duration // How long signed URLs stay valid (default: 1h)
+
+	// Settings for uploaded files
+	StorageS3Encryption    string // "AES256" or "aws:kms" (empty = bucket default)
+	StorageS3StorageClass  string // S3 storage class (empty = STANDARD)
+	StorageS3UploadOptions bool   // Uploaders can choose the settings per upload (default: false)
+	StorageCacheControl    string // Cache-Control stored with uploaded files (S3, GCS, Azure)
+
+	// Google Cloud Storage configuration (only used if StorageType is "gcs")
+	StorageGCSBucket          string // GCS bucket name
+	StorageGCSPrefix          string // Object name prefix (e.g., "uploads/")
+	StorageGCSCredentialsFile string // Service account JSON key; empty uses Application Default Credentials
+	StorageGCSProjectID       string // Google Cloud project ID
+
+	// Azure Blob Storage configuration (only used if StorageType is "azure")
+	StorageAzureAccount          string // Storage account name
+	StorageAzureKey              string // Account key; empty uses DefaultAzureCredential
+	StorageAzureConnectionString string // Connection string, instead of account and key
+	StorageAzureContainer        string // Blob container name
+	StorageAzurePrefix           string // Blob name prefix (e.g., "uploads/")
+	StorageAzureEndpoint         string // Custom endpoint (emulators, sovereign clouds)
+
+	// Library upload limits
+	StorageMaxUploadMB  int    // Largest file accepted, in MB (default: 2048)
+	StorageAllowedTypes string // Comma-separated content types accepted (empty = any)
+	StorageDeniedTypes  string // Comma-separated content types rejected
+	StorageTrashDays    int    // Days deleted items stay in the trash (0 = until deleted by hand)
+	StorageQuotaMB      int    // Most MB the library may store, trash included (0 = no limit)
+
+	// Virus scanning of library uploads (empty VirusScan disables scanning)
+	VirusScan         string // clamav or http
+	VirusScanAddress  string // clamd address or scanning API URL
+	VirusScanToken    string // Bearer token for the scanning API
+	VirusScanFailOpen bool   // Accept uploads unscanned while the scanner is down (default: false)
+
 	// Email/SMTP configuration
 	MailSMTPHost string // SMTP server host (e.g., localhost for Mailpit, email-smtp.us-east-1.amazonaws.com for SES)
 	MailSMTPPort int    // SMTP server port (e.g., 1025 for Mailpit, 587 for SES)
@@ -79,17 +198,54 @@ type AppConfig struct {
 	MailFrom     string // From email address (e.g., noreply@example.com)
 	MailFromName string // From display name (e.g., Strata)
 
+	// Email queue settings
+	MailQueueEnabled    bool          // Deliver email from the background job queue with retries
+	MailMaxAttempts     int           // Delivery attempts before a queued email is marked failed (default: 5)
+	MailOutboxRetention time.Duration // How long sent emails stay in the outbox; 0 keeps them (default: 720h)
+	MailLogRetention    time.Duration // How long delivery log entries are kept; 0 keeps them (default: 2160h)
+	MailRateLimit       int           // Maximum emails delivered per second; 0 for no limit (default: 10)
+	MailBatchSize       int           // Emails per batch in bulk sends (default: 50)
+	MailWebhookSecret   string        // Shared secret for the bounce/complaint webhook; empty disables it
+
+	// Outgoing webhook settings
+	WebhookMaxAttempts       int           // Delivery attempts before a webhook delivery is marked failed (default: 5)
+	WebhookTimeout           time.Duration // How long to wait for an endpoint to respond (default: 10s)
+	WebhookDeliveryRetention time.Duration // How long successful deliveries are kept; 0 keeps them (default: 720h)
+
+	// Background job queue settings
+	JobRetryDelay        time.Duration // Base retry delay for failed jobs, doubled after each failed attempt (default: 30s)
+	JobMaxRetryDelay     time.Duration // Cap on the retry delay; 0 for no cap (default: 1h)
+	ScheduleRunRetention time.Duration // How long scheduled job run history is kept; 0 keeps it (default: 336h)
+
+	// Prometheus metrics
+	MetricsToken string // Bearer token required to scrape /metrics; empty disables the endpoint
+
+	// Health checks
+	HealthProbeStorage  bool          // Probe file storage with a canary object (default: true)
+	HealthProbeSMTP     bool          // Probe the SMTP server (default: true)
+	HealthProbeInterval time.Duration // How long a probe result is reused (default: 30s)
+	HealthReadyRequire  string        // Comma-separated components that must pass for readiness, besides MongoDB
+
 	// Base URL for email links (magic links, password reset, etc.)
 	BaseURL string // e.g., "https://example.com" or "http://localhost:3000"
 
 	// Email verification settings
-	EmailVerifyExpiry time.Duration // How long email verification codes/links are valid (default: 10m)
+	EmailVerifyExpiry       time.Duration // How long email verification codes/links are valid (default: 10m)
+	EmailCodeSingleActive   bool          // A new login code invalidates earlier unused ones (default: true)
+	EmailCodeResendInterval time.Duration // Minimum time between login codes for an address (default: 60s, 0 = no limit)
+	EmailCodeMaxAttempts    int           // Wrong codes allowed before a login code stops working (default: 5, 0 = no limit)
 
 	// Audit logging configuration
 	// Values: "all" (MongoDB + zap), "db" (MongoDB only), "log" (zap only), "off" (disabled)
 	AuditLogAuth  string // Authentication events (login, logout, password, verification)
 	AuditLogAdmin string // Admin actions (user CRUD, settings changes)
 
+	// Audit forwarding to a SIEM
+	AuditForward        string // "syslog", "http", or "" (disabled)
+	AuditForwardAddress string // Syslog address or collector URL
+	AuditForwardAuth    string // Authorization header for the HTTP collector
+	AuditRetentionDays  int    // Days before audit events are archived to storage and deleted (0 = keep forever)
+
 	// Google OAuth configuration
 	GoogleClientID     string // Google OAuth2 client ID
 	GoogleClientSecret string // Google OAuth2 client secret
@@ -101,6 +257,60 @@ type AppConfig struct {
 	// Save retention configuration
 	MaxSavesPerUser string // Max saves per user per game ("all" or a number like "5")
 
+	// State load cache (in-memory, per instance; invalidated on save)
+	StateCacheTTL        time.Duration // How long load responses are cached (0 = disabled)
+	StateCacheMaxEntries int           // Max user/game pairs held (default: 10000)
+
+	// Request ledger retention
+	LedgerRetention time.Duration // How long entries are kept; 0 keeps them (default: 2160h)
+	LedgerArchive   bool          // Archive entries to file storage past the retention instead of deleting them
+
+	// Request ledger success sampling (baseline of normal traffic)
+	LedgerSampleOneIn int // Log one in this many successful API requests; 0 = errors only
+
+	// Request ledger full capture (debugging integrations)
+	LedgerCapturePaths string        // Comma-separated path prefixes captured in full; empty = none
+	LedgerCaptureTTL   time.Duration // How long captured bodies are kept; 0 keeps them (default: 24h)
+	LedgerMaskFields   string        // Comma-separated field and header names masked in the ledger
+
 	// API stats configuration
-	APIStatsBucket time.Duration // Bucket duration for API stats (default: 1h)
+	APIStatsBucket    time.Duration // Bucket duration for API stats (default: 1h)
+	APIStatsRetention time.Duration // How long buckets finer than a day are kept before daily roll-up; 0 keeps them (default: 720h)
+
+	// values are the raw config values this AppConfig was built from,
+	// which a config reload compares against.
+	values config.AppConfigValues
+}
+
+// signedDownloads reports whether library views and downloads are sent to
+// signed CloudFront or S3 URLs instead of streamed through the app.
+func (c AppConfig) signedDownloads() bool {
+	return c.StorageType == "s3" && (c.StorageCFURL != "" || c.StorageS3PresignedDownloads)
+}
+
+// storageOptions returns the storage settings for uploaded library files.
+// Encryption and storage class apply only to S3.
+func (c AppConfig) storageOptions() filesfeature.StorageOptions {
+	opts := filesfeature.StorageOptions{CacheControl: c.StorageCacheControl}
+	if c.StorageType == "s3" {
+		opts.Encryption = c.StorageS3Encryption
+		opts.StorageClass = c.StorageS3StorageClass
+	}
+	return opts
+}
+
+// healthReadyRequire returns the components that must pass for readiness.
+func (c AppConfig) healthReadyRequire() []string {
+	var names []string
+	for _, name := range strings.Split(c.HealthReadyRequire, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// trustedProxies returns the trusted_proxies ranges as CIDRs.
+func (c AppConfig) trustedProxies() ([]string, error) {
+	return network.ParseCIDRList(strings.Split(c.TrustedProxies, ","))
 }
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/healthcheck"
+	"github.com/dalemusser/stratasave/internal/app/system/virusscan"
 	"github.com/dalemusser/waffle/config"
 	wafflemongo "github.com/dalemusser/waffle/pantry/mongo"
 	"go.uber.org/zap"
@@ -29,25 +33,78 @@ var appConfigKeys = []config.AppKey{
 	{Name: "session_name", Default: "stratasave-session", Desc: "Session cookie name"},
 	{Name: "session_domain", Default: "", Desc: "Session cookie domain (blank means current host)"},
 	{Name: "session_max_age", Default: "24h", Desc: "Session cookie max age (e.g., 24h, 720h, 30m)"},
+	{Name: "session_idle_timeout", Default: "0s", Desc: "End a session after this long without a request (0 = no idle limit)"},
+	{Name: "session_admin_max_age", Default: "", Desc: "Absolute session lifetime for admins (empty = session_max_age)"},
+	{Name: "session_admin_idle_timeout", Default: "", Desc: "Idle timeout for admin sessions (empty = session_idle_timeout)"},
+	{Name: "session_developer_max_age", Default: "", Desc: "Absolute session lifetime for developers (empty = session_max_age)"},
+	{Name: "session_developer_idle_timeout", Default: "", Desc: "Idle timeout for developer sessions (empty = session_idle_timeout)"},
 
 	// Idle logout configuration
 	{Name: "idle_logout_enabled", Default: false, Desc: "Enable automatic logout after idle time"},
 	{Name: "idle_logout_timeout", Default: "30m", Desc: "Idle timeout duration before logout"},
 	{Name: "idle_logout_warning", Default: "5m", Desc: "Warning time before idle logout"},
 
+	// Impersonation
+	{Name: "impersonation_timeout", Default: "30m", Desc: "How long an admin can act as another user before returning to their own account"},
+
+	// Step-up re-authentication for sensitive admin actions
+	{Name: "reauth_window", Default: "10m", Desc: "How recently a user must have logged in or confirmed their identity before sensitive actions (0 = never ask)"},
+
 	// Rate limiting configuration
 	{Name: "rate_limit_enabled", Default: true, Desc: "Enable rate limiting for login attempts"},
 	{Name: "rate_limit_login_attempts", Default: 5, Desc: "Max failed login attempts before lockout"},
 	{Name: "rate_limit_login_window", Default: "15m", Desc: "Time window for counting failed attempts"},
 	{Name: "rate_limit_login_lockout", Default: "15m", Desc: "Lockout duration after exceeding limit"},
 
+	// Suspicious activity detection
+	{Name: "detect_failed_login_accounts", Default: 10, Desc: "Alert when this many different accounts fail to log in from one IP within the window (0 = off)"},
+	{Name: "detect_failed_login_window", Default: "15m", Desc: "Time window for counting failed logins from one IP"},
+	{Name: "detect_block_duration", Default: "0", Desc: "How long an IP that trips the failed login rule is blocked from logging in (0 = alert only)"},
+	{Name: "detect_travel_speed_kmh", Default: 1000, Desc: "Alert when a user's logins imply travel faster than this, in km/h (0 = off)"},
+	{Name: "detect_latitude_header", Default: "", Desc: "Request header holding the client's latitude, set by your CDN or proxy (e.g., CloudFront-Viewer-Latitude)"},
+	{Name: "detect_longitude_header", Default: "", Desc: "Request header holding the client's longitude, set by your CDN or proxy (e.g., CloudFront-Viewer-Longitude)"},
+	{Name: "security_alert_emails", Default: "", Desc: "Comma-separated recipients for security alerts (empty = all admins)"},
+
+	// Password policy
+	{Name: "password_breach_check", Default: false, Desc: "Reject new passwords found in known data breaches (Have I Been Pwned range API)"},
+	{Name: "password_max_age", Default: "0", Desc: "How long a password can be used before it must be changed (e.g., 2160h; 0 = never expires)"},
+	{Name: "password_expiry_warning", Default: "168h", Desc: "How far ahead of a password's expiry to email the user (0 = no email)"},
+
+	// Deleted user accounts
+	{Name: "user_restore_days", Default: 30, Desc: "Days a deleted user account can be restored before it is purged (0 = keep until deleted by hand)"},
+
+	// Self-service account deletion
+	{Name: "account_self_delete", Default: true, Desc: "Let users delete their own account from their profile page"},
+	{Name: "account_delete_grace_days", Default: 14, Desc: "Days after a user asks to delete their account before it is deleted; they can cancel until then (0 = deleted at once)"},
+
+	// Scheduled user deactivation
+	{Name: "user_disable_warning", Default: "168h", Desc: "How far ahead of a user's scheduled disable date to email them (0 = no email)"},
+
+	// User invitations
+	{Name: "invite_reminder", Default: "48h", Desc: "How far ahead of an invitation's expiry to email the invitee a reminder (0 = no reminder)"},
+
+	// CAPTCHA on public forms (login, forgot password, invitation accept, registration)
+	{Name: "captcha_provider", Default: "", Desc: "CAPTCHA provider: 'hcaptcha', 'recaptcha', 'turnstile', or empty to disable"},
+	{Name: "captcha_site_key", Default: "", Desc: "CAPTCHA site key (public, rendered in the page)"},
+	{Name: "captcha_secret_key", Default: "", Desc: "CAPTCHA secret key for server-side verification"},
+
 	{Name: "csrf_key", Default: "dev-only-csrf-key-please-change-0123456789", Desc: "CSRF token signing key (32+ chars in production)"},
+	{Name: "settings_encryption_key", Default: "", Desc: "Key that encrypts secrets saved on the settings page, such as the SMTP password (empty disables SMTP settings there)"},
 
 	// API key configuration (for external API consumers using Bearer token auth)
 	{Name: "api_key", Default: "", Desc: "API key for external API access (leave empty to disable API key auth)"},
+	{Name: "api_signing_secret", Default: "", Desc: "Shared secret for HMAC-signed API requests (leave empty to disable request signing)"},
+	{Name: "api_signing_max_skew", Default: "5m", Desc: "Maximum clock difference allowed for signed API request timestamps"},
+	{Name: "api_token_secret", Default: "", Desc: "Signing secret for short-lived API tokens issued at /api/token (leave empty to disable)"},
+	{Name: "api_token_ttl", Default: "15m", Desc: "Lifetime of issued API tokens"},
+	{Name: "trusted_proxies", Default: "", Desc: "Comma-separated reverse proxy IPs or CIDR ranges whose X-Forwarded-For headers are trusted for API key IP allowlists (empty = use the connection address)"},
+	{Name: "api_key_alert_emails", Default: "", Desc: "Comma-separated recipients for API key notifications (empty = all admins)"},
+	{Name: "api_key_expiry_warning", Default: "168h", Desc: "How far ahead of an API key's expiry to send a warning"},
+	{Name: "api_key_error_threshold", Default: 50, Desc: "Errors for one API key within the window that trigger an alert (0 = disabled)"},
+	{Name: "api_key_error_window", Default: "15m", Desc: "Time window for counting API key errors"},
 
 	// File storage configuration
-	{Name: "storage_type", Default: "local", Desc: "Storage backend: 'local' or 's3'"},
+	{Name: "storage_type", Default: "local", Desc: "Storage backend: 'local', 's3', 'gcs', or 'azure'"},
 	{Name: "storage_local_path", Default: "./uploads", Desc: "Local storage path for uploaded files"},
 	{Name: "storage_local_url", Default: "/files", Desc: "URL prefix for serving local files"},
 
@@ -58,6 +115,38 @@ var appConfigKeys = []config.AppKey{
 	{Name: "storage_cf_url", Default: "", Desc: "CloudFront distribution URL"},
 	{Name: "storage_cf_keypair_id", Default: "", Desc: "CloudFront key pair ID"},
 	{Name: "storage_cf_key_path", Default: "", Desc: "Path to CloudFront private key file"},
+	{Name: "storage_s3_direct_uploads", Default: false, Desc: "Upload library files from the browser straight to S3 with presigned URLs"},
+	{Name: "storage_s3_presigned_downloads", Default: false, Desc: "Send library views and downloads to S3 presigned URLs instead of through the app (always on with storage_cf_url)"},
+	{Name: "storage_download_url_ttl", Default: "1h", Desc: "How long signed CloudFront or S3 download URLs stay valid"},
+	{Name: "storage_s3_encryption", Default: "", Desc: "S3 server-side encryption for uploads: 'AES256' or 'aws:kms' (empty = bucket default)"},
+	{Name: "storage_s3_storage_class", Default: "", Desc: "S3 storage class for uploads, such as 'STANDARD_IA' (empty = STANDARD)"},
+	{Name: "storage_s3_upload_options", Default: false, Desc: "Let uploaders choose the storage class, encryption, and Cache-Control for each upload"},
+	{Name: "storage_cache_control", Default: "", Desc: "Cache-Control header stored with uploaded files (S3, GCS, and Azure)"},
+
+	// Google Cloud Storage configuration
+	{Name: "storage_gcs_bucket", Default: "", Desc: "GCS bucket name"},
+	{Name: "storage_gcs_prefix", Default: "uploads/", Desc: "GCS object name prefix"},
+	{Name: "storage_gcs_credentials_file", Default: "", Desc: "Path to a service account JSON key (empty = Application Default Credentials)"},
+	{Name: "storage_gcs_project_id", Default: "", Desc: "Google Cloud project ID"},
+
+	// Azure Blob Storage configuration
+	{Name: "storage_azure_account", Default: "", Desc: "Azure storage account name"},
+	{Name: "storage_azure_key", Default: "", Desc: "Azure storage account key (empty = DefaultAzureCredential)"},
+	{Name: "storage_azure_connection_string", Default: "", Desc: "Azure storage connection string (instead of account and key)"},
+	{Name: "storage_azure_container", Default: "", Desc: "Azure blob container name"},
+	{Name: "storage_azure_prefix", Default: "uploads/", Desc: "Azure blob name prefix"},
+	{Name: "storage_azure_endpoint", Default: "", Desc: "Custom Azure Blob endpoint, for emulators such as Azurite or sovereign clouds"},
+	{Name: "storage_max_upload_mb", Default: 2048, Desc: "Largest library file accepted, in MB"},
+	{Name: "storage_allowed_types", Default: "", Desc: "Comma-separated content types the library accepts, e.g. 'video/*,application/pdf' (empty = any)"},
+	{Name: "storage_denied_types", Default: "", Desc: "Comma-separated content types the library rejects, checked against the declared and sniffed type"},
+	{Name: "storage_trash_days", Default: 30, Desc: "Days deleted library files and folders stay in the trash before being purged (0 = keep until deleted by hand)"},
+	{Name: "storage_quota_mb", Default: 0, Desc: "Most MB the library may take up in storage, trash included; uploads past it are refused (0 = no limit)"},
+
+	// Virus scanning of library uploads
+	{Name: "virus_scan", Default: "", Desc: "Scan library uploads before they are saved: 'clamav', 'http', or empty to disable"},
+	{Name: "virus_scan_address", Default: "", Desc: "clamd address ('tcp://host:3310' or 'unix:///path/clamd.ctl') or scanning API URL"},
+	{Name: "virus_scan_token", Default: "", Desc: "Bearer token sent to the scanning API"},
+	{Name: "virus_scan_fail_open", Default: false, Desc: "Accept uploads, marked unscanned, while the scanner is unavailable"},
 
 	// Email/SMTP configuration
 	{Name: "mail_smtp_host", Default: "localhost", Desc: "SMTP server host"},
@@ -66,16 +155,49 @@ var appConfigKeys = []config.AppKey{
 	{Name: "mail_smtp_pass", Default: "", Desc: "SMTP password"},
 	{Name: "mail_from", Default: "noreply@example.com", Desc: "From email address"},
 	{Name: "mail_from_name", Default: "StrataSave", Desc: "From display name"},
+	{Name: "mail_queue_enabled", Default: true, Desc: "Queue outbound email and deliver it in the background with retries"},
+	{Name: "mail_max_attempts", Default: 5, Desc: "Delivery attempts before a queued email is marked failed"},
+	{Name: "mail_outbox_retention", Default: "720h", Desc: "How long sent emails are kept in the outbox (0 keeps them forever)"},
+	{Name: "mail_log_retention", Default: "2160h", Desc: "How long email delivery log entries are kept (0 keeps them forever)"},
+	{Name: "mail_rate_limit", Default: 10, Desc: "Maximum emails delivered per second (0 for no limit)"},
+	{Name: "mail_batch_size", Default: 50, Desc: "Emails sent per batch by bulk sends, between progress updates"},
+	{Name: "mail_webhook_secret", Default: "", Desc: "Shared secret for the bounce/complaint webhook at /webhooks/email (empty disables it)"},
+
+	// Outgoing webhooks
+	{Name: "webhook_max_attempts", Default: 5, Desc: "Delivery attempts before a webhook delivery is marked failed"},
+	{Name: "webhook_timeout", Default: "10s", Desc: "How long to wait for a webhook endpoint to respond"},
+	{Name: "webhook_delivery_retention", Default: "720h", Desc: "How long successful webhook deliveries are kept (0 keeps them forever)"},
+
+	// Background job queue
+	{Name: "job_retry_delay", Default: "30s", Desc: "Base delay before retrying a failed background job; doubled after each failed attempt"},
+	{Name: "job_max_retry_delay", Default: "1h", Desc: "Longest delay between retries of a failed background job (0 for no limit)"},
+	{Name: "schedule_run_retention", Default: "336h", Desc: "How long the run history of scheduled jobs is kept (0 keeps it forever)"},
+
+	// Prometheus metrics
+	{Name: "metrics_token", Default: "", Desc: "Bearer token required to scrape /metrics (empty disables the endpoint)"},
+
+	// Health checks
+	{Name: "health_probe_storage", Default: true, Desc: "Probe file storage (write, read, and delete a canary object) in the health checks"},
+	{Name: "health_probe_smtp", Default: true, Desc: "Probe the SMTP server in the health checks"},
+	{Name: "health_probe_interval", Default: "30s", Desc: "How long a probe result is reused before the component is probed again"},
+	{Name: "health_ready_require", Default: "", Desc: "Comma-separated components that must pass for /health/ready: 'storage', 'smtp' (empty = MongoDB only)"},
 
 	// Base URL for email links (magic links, etc.)
 	{Name: "base_url", Default: "http://localhost:8080", Desc: "Base URL for email links"},
 
 	// Email verification settings
 	{Name: "email_verify_expiry", Default: "10m", Desc: "Email verification code/link expiry (e.g., 10m, 1h, 90s)"},
+	{Name: "email_code_single_active", Default: true, Desc: "Issuing a login code invalidates earlier unused codes and links for the address"},
+	{Name: "email_code_resend_interval", Default: "60s", Desc: "Minimum time between login codes sent to the same address (0 = no limit)"},
+	{Name: "email_code_max_attempts", Default: 5, Desc: "Wrong codes that may be entered before a login code stops working (0 = no limit)"},
 
 	// Audit logging settings
 	{Name: "audit_log_auth", Default: "all", Desc: "Auth event logging: 'all' (db+log), 'db', 'log', or 'off'"},
 	{Name: "audit_log_admin", Default: "all", Desc: "Admin event logging: 'all' (db+log), 'db', 'log', or 'off'"},
+	{Name: "audit_forward", Default: "", Desc: "Stream audit events to a SIEM: 'syslog', 'http', or empty to disable"},
+	{Name: "audit_forward_address", Default: "", Desc: "Syslog address ('udp://host:514', 'tcp://host:514', 'tls://host:6514') or collector URL"},
+	{Name: "audit_forward_auth", Default: "", Desc: "Authorization header sent to the HTTP collector (e.g. 'Splunk <token>')"},
+	{Name: "audit_retention_days", Default: 0, Desc: "Days audit events stay in the database before being archived to storage and deleted (0 = keep forever)"},
 
 	// Google OAuth configuration
 	{Name: "google_client_id", Default: "", Desc: "Google OAuth2 client ID"},
@@ -88,8 +210,21 @@ var appConfigKeys = []config.AppKey{
 	// Save retention configuration
 	{Name: "max_saves_per_user", Default: "5", Desc: "Max saves per user per game ('all' or a number)"},
 
+	// State load cache configuration
+	{Name: "state_cache_ttl", Default: "0s", Desc: "How long to cache state load responses in memory (0 = disabled)"},
+	{Name: "state_cache_max_entries", Default: 10000, Desc: "Max user/game pairs held in the state load cache"},
+
+	// Request ledger
+	{Name: "ledger_retention", Default: "2160h", Desc: "How long request ledger entries are kept (0 keeps them forever)"},
+	{Name: "ledger_success_sample_one_in", Default: 0, Desc: "Also log one in this many successful API requests to the ledger as a baseline (e.g., 100 = 1%; 0 = errors only)"},
+	{Name: "ledger_archive", Default: false, Desc: "Archive ledger entries to file storage as gzip NDJSON when they pass ledger_retention, instead of deleting them"},
+	{Name: "ledger_capture_paths", Default: "", Desc: "Comma-separated API path prefixes whose requests and responses are captured in full (e.g., /api/v1/state/save)"},
+	{Name: "ledger_capture_ttl", Default: "24h", Desc: "How long captured request and response bodies are kept (0 keeps them)"},
+	{Name: "ledger_mask_fields", Default: "password,token,secret,api_key,apikey", Desc: "Comma-separated body field and header names masked in the ledger"},
+
 	// API stats configuration
 	{Name: "api_stats_bucket", Default: "1h", Desc: "API stats bucket duration (e.g., '1m', '15m', '1h', '24h')"},
+	{Name: "api_stats_retention", Default: "720h", Desc: "How long API stats buckets finer than a day are kept before they are rolled into daily summaries (0 keeps them)"},
 }
 
 // LoadConfig loads WAFFLE core config and app-specific config.
@@ -110,8 +245,13 @@ func LoadConfig(logger *zap.Logger) (*config.CoreConfig, AppConfig, error) {
 	if err != nil {
 		return nil, AppConfig{}, err
 	}
+	return coreCfg, appConfigFromValues(appValues), nil
+}
 
-	appCfg := AppConfig{
+// appConfigFromValues builds the AppConfig from loaded config values,
+// applying the defaults for durations.
+func appConfigFromValues(appValues config.AppConfigValues) AppConfig {
+	return AppConfig{
 		MongoURI:         appValues.String("mongo_uri"),
 		MongoDatabase:    appValues.String("mongo_database"),
 		MongoMaxPoolSize: uint64(appValues.Int("mongo_max_pool_size")),
@@ -121,19 +261,76 @@ func LoadConfig(logger *zap.Logger) (*config.CoreConfig, AppConfig, error) {
 		SessionDomain:    appValues.String("session_domain"),
 		SessionMaxAge:    appValues.Duration("session_max_age", 24*time.Hour),
 
+		// Session lifetimes
+		SessionIdleTimeout:          appValues.Duration("session_idle_timeout", 0),
+		SessionAdminMaxAge:          appValues.Duration("session_admin_max_age", 0),
+		SessionAdminIdleTimeout:     appValues.Duration("session_admin_idle_timeout", 0),
+		SessionDeveloperMaxAge:      appValues.Duration("session_developer_max_age", 0),
+		SessionDeveloperIdleTimeout: appValues.Duration("session_developer_idle_timeout", 0),
+
 		// Idle logout
 		IdleLogoutEnabled: appValues.Bool("idle_logout_enabled"),
 		IdleLogoutTimeout: appValues.Duration("idle_logout_timeout", 30*time.Minute),
 		IdleLogoutWarning: appValues.Duration("idle_logout_warning", 5*time.Minute),
 
+		// Impersonation
+		ImpersonationTimeout: appValues.Duration("impersonation_timeout", 30*time.Minute),
+
+		// Step-up re-authentication
+		ReauthWindow: appValues.Duration("reauth_window", 10*time.Minute),
+
 		// Rate limiting
 		RateLimitEnabled:       appValues.Bool("rate_limit_enabled"),
 		RateLimitLoginAttempts: appValues.Int("rate_limit_login_attempts"),
 		RateLimitLoginWindow:   appValues.Duration("rate_limit_login_window", 15*time.Minute),
 		RateLimitLoginLockout:  appValues.Duration("rate_limit_login_lockout", 15*time.Minute),
 
-		CSRFKey: appValues.String("csrf_key"),
+		// Suspicious activity detection
+		DetectFailedLoginAccounts: appValues.Int("detect_failed_login_accounts"),
+		DetectFailedLoginWindow:   appValues.Duration("detect_failed_login_window", 15*time.Minute),
+		DetectBlockDuration:       appValues.Duration("detect_block_duration", 0),
+		DetectTravelSpeedKmh:      appValues.Int("detect_travel_speed_kmh"),
+		DetectLatitudeHeader:      appValues.String("detect_latitude_header"),
+		DetectLongitudeHeader:     appValues.String("detect_longitude_header"),
+		SecurityAlertEmails:       appValues.String("security_alert_emails"),
+
+		// Password policy
+		PasswordBreachCheck:   appValues.Bool("password_breach_check"),
+		PasswordMaxAge:        appValues.Duration("password_max_age", 0),
+		PasswordExpiryWarning: appValues.Duration("password_expiry_warning", 7*24*time.Hour),
+
+		// Deleted user accounts
+		UserRestoreDays: appValues.Int("user_restore_days"),
+
+		// Self-service account deletion
+		AccountSelfDelete:      appValues.Bool("account_self_delete"),
+		AccountDeleteGraceDays: appValues.Int("account_delete_grace_days"),
+
+		// Scheduled user deactivation
+		UserDisableWarning: appValues.Duration("user_disable_warning", 7*24*time.Hour),
+
+		// User invitations
+		InviteReminder: appValues.Duration("invite_reminder", 48*time.Hour),
+
+		// CAPTCHA
+		CaptchaProvider:  appValues.String("captcha_provider"),
+		CaptchaSiteKey:   appValues.String("captcha_site_key"),
+		CaptchaSecretKey: appValues.String("captcha_secret_key"),
+
+		CSRFKey:               appValues.String("csrf_key"),
+		SettingsEncryptionKey: appValues.String("settings_encryption_key"),
 		APIKey:           appValues.String("api_key"),
+		APISigningSecret:  appValues.String("api_signing_secret"),
+		APISigningMaxSkew: appValues.Duration("api_signing_max_skew", 5*time.Minute),
+		APITokenSecret:    appValues.String("api_token_secret"),
+		APITokenTTL:       appValues.Duration("api_token_ttl", 15*time.Minute),
+
+		// API key notifications
+		TrustedProxies:       appValues.String("trusted_proxies"),
+		APIKeyAlertEmails:    appValues.String("api_key_alert_emails"),
+		APIKeyExpiryWarning:  appValues.Duration("api_key_expiry_warning", 7*24*time.Hour),
+		APIKeyErrorThreshold: appValues.Int("api_key_error_threshold"),
+		APIKeyErrorWindow:    appValues.Duration("api_key_error_window", 15*time.Minute),
 
 		// File storage
 		StorageType:      appValues.String("storage_type"),
@@ -148,6 +345,43 @@ func LoadConfig(logger *zap.Logger) (*config.CoreConfig, AppConfig, error) {
 		StorageCFKeyPairID: appValues.String("storage_cf_keypair_id"),
 		StorageCFKeyPath:   appValues.String("storage_cf_key_path"),
 
+		StorageS3DirectUploads: appValues.Bool("storage_s3_direct_uploads"),
+
+		StorageS3PresignedDownloads: appValues.Bool("storage_s3_presigned_downloads"),
+		StorageDownloadURLTTL:       appValues.Duration("storage_download_url_ttl", time.Hour),
+
+		StorageS3Encryption:    appValues.String("storage_s3_encryption"),
+		StorageS3StorageClass:  appValues.String("storage_s3_storage_class"),
+		StorageS3UploadOptions: appValues.Bool("storage_s3_upload_options"),
+		StorageCacheControl:    appValues.String("storage_cache_control"),
+
+		// Google Cloud Storage
+		StorageGCSBucket:          appValues.String("storage_gcs_bucket"),
+		StorageGCSPrefix:          appValues.String("storage_gcs_prefix"),
+		StorageGCSCredentialsFile: appValues.String("storage_gcs_credentials_file"),
+		StorageGCSProjectID:       appValues.String("storage_gcs_project_id"),
+
+		// Azure Blob Storage
+		StorageAzureAccount:          appValues.String("storage_azure_account"),
+		StorageAzureKey:              appValues.String("storage_azure_key"),
+		StorageAzureConnectionString: appValues.String("storage_azure_connection_string"),
+		StorageAzureContainer:        appValues.String("storage_azure_container"),
+		StorageAzurePrefix:           appValues.String("storage_azure_prefix"),
+		StorageAzureEndpoint:         appValues.String("storage_azure_endpoint"),
+
+		// Library upload limits
+		StorageMaxUploadMB:  appValues.Int("storage_max_upload_mb"),
+		StorageAllowedTypes: appValues.String("storage_allowed_types"),
+		StorageDeniedTypes:  appValues.String("storage_denied_types"),
+		StorageTrashDays:    appValues.Int("storage_trash_days"),
+		StorageQuotaMB:      appValues.Int("storage_quota_mb"),
+
+		// Virus scanning
+		VirusScan:         appValues.String("virus_scan"),
+		VirusScanAddress:  appValues.String("virus_scan_address"),
+		VirusScanToken:    appValues.String("virus_scan_token"),
+		VirusScanFailOpen: appValues.Bool("virus_scan_fail_open"),
+
 		// Email/SMTP
 		MailSMTPHost: appValues.String("mail_smtp_host"),
 		MailSMTPPort: appValues.Int("mail_smtp_port"),
@@ -156,16 +390,53 @@ func LoadConfig(logger *zap.Logger) (*config.CoreConfig, AppConfig, error) {
 		MailFrom:     appValues.String("mail_from"),
 		MailFromName: appValues.String("mail_from_name"),
 
+		// Email queue
+		MailQueueEnabled:    appValues.Bool("mail_queue_enabled"),
+		MailMaxAttempts:     appValues.Int("mail_max_attempts"),
+		MailOutboxRetention: appValues.Duration("mail_outbox_retention", 30*24*time.Hour),
+		MailLogRetention:    appValues.Duration("mail_log_retention", 90*24*time.Hour),
+		MailRateLimit:       appValues.Int("mail_rate_limit"),
+		MailBatchSize:       appValues.Int("mail_batch_size"),
+		MailWebhookSecret:   appValues.String("mail_webhook_secret"),
+
+		// Outgoing webhooks
+		WebhookMaxAttempts:       appValues.Int("webhook_max_attempts"),
+		WebhookTimeout:           appValues.Duration("webhook_timeout", 10*time.Second),
+		WebhookDeliveryRetention: appValues.Duration("webhook_delivery_retention", 30*24*time.Hour),
+
+		// Background job queue
+		JobRetryDelay:        appValues.Duration("job_retry_delay", 30*time.Second),
+		JobMaxRetryDelay:     appValues.Duration("job_max_retry_delay", time.Hour),
+		ScheduleRunRetention: appValues.Duration("schedule_run_retention", 14*24*time.Hour),
+
+		// Prometheus metrics
+		MetricsToken: appValues.String("metrics_token"),
+
+		// Health checks
+		HealthProbeStorage:  appValues.Bool("health_probe_storage"),
+		HealthProbeSMTP:     appValues.Bool("health_probe_smtp"),
+		HealthProbeInterval: appValues.Duration("health_probe_interval", 30*time.Second),
+		HealthReadyRequire:  appValues.String("health_ready_require"),
+
 		// Base URL
 		BaseURL: appValues.String("base_url"),
 
 		// Email verification
-		EmailVerifyExpiry: appValues.Duration("email_verify_expiry", 10*time.Minute),
+		EmailVerifyExpiry:       appValues.Duration("email_verify_expiry", 10*time.Minute),
+		EmailCodeSingleActive:   appValues.Bool("email_code_single_active"),
+		EmailCodeResendInterval: appValues.Duration("email_code_resend_interval", 60*time.Second),
+		EmailCodeMaxAttempts:    appValues.Int("email_code_max_attempts"),
 
 		// Audit logging
 		AuditLogAuth:  appValues.String("audit_log_auth"),
 		AuditLogAdmin: appValues.String("audit_log_admin"),
 
+		// Audit forwarding
+		AuditForward:        appValues.String("audit_forward"),
+		AuditForwardAddress: appValues.String("audit_forward_address"),
+		AuditForwardAuth:    appValues.String("audit_forward_auth"),
+		AuditRetentionDays:  appValues.Int("audit_retention_days"),
+
 		// Google OAuth
 		GoogleClientID:     appValues.String("google_client_id"),
 		GoogleClientSecret: appValues.String("google_client_secret"),
@@ -177,11 +448,24 @@ func LoadConfig(logger *zap.Logger) (*config.CoreConfig, AppConfig, error) {
 		// Save retention
 		MaxSavesPerUser: appValues.String("max_saves_per_user"),
 
+		// State load cache
+		StateCacheTTL:        appValues.Duration("state_cache_ttl", 0),
+		StateCacheMaxEntries: appValues.Int("state_cache_max_entries"),
+
+		// Request ledger
+		LedgerRetention:    appValues.Duration("ledger_retention", 90*24*time.Hour),
+		LedgerArchive:      appValues.Bool("ledger_archive"),
+		LedgerSampleOneIn:  appValues.Int("ledger_success_sample_one_in"),
+		LedgerCapturePaths: appValues.String("ledger_capture_paths"),
+		LedgerCaptureTTL:   appValues.Duration("ledger_capture_ttl", 24*time.Hour),
+		LedgerMaskFields:   appValues.String("ledger_mask_fields"),
+
 		// API stats
-		APIStatsBucket: appValues.Duration("api_stats_bucket", 1*time.Hour),
-	}
+		APIStatsBucket:    appValues.Duration("api_stats_bucket", 1*time.Hour),
+		APIStatsRetention: appValues.Duration("api_stats_retention", 30*24*time.Hour),
 
-	return coreCfg, appCfg, nil
+		values: appValues,
+	}
 }
 
 // ValidateConfig performs app-specific config validation.
@@ -195,5 +479,105 @@ func ValidateConfig(coreCfg *config.CoreConfig, appCfg AppConfig, logger *zap.Lo
 		return fmt.Errorf("invalid MongoDB URI: %w", err)
 	}
 
+	if appCfg.CaptchaProvider != "" {
+		if !captcha.ValidProvider(appCfg.CaptchaProvider) {
+			return fmt.Errorf("invalid captcha_provider %q: must be hcaptcha, recaptcha, or turnstile", appCfg.CaptchaProvider)
+		}
+		if appCfg.CaptchaSiteKey == "" || appCfg.CaptchaSecretKey == "" {
+			return fmt.Errorf("captcha_provider %q requires captcha_site_key and captcha_secret_key", appCfg.CaptchaProvider)
+		}
+	}
+
+	switch appCfg.StorageType {
+	case "", "local":
+	case "s3":
+		if appCfg.signedDownloads() && appCfg.StorageDownloadURLTTL <= 0 {
+			return fmt.Errorf("invalid storage_download_url_ttl %s: must be more than 0", appCfg.StorageDownloadURLTTL)
+		}
+	case "gcs":
+		if appCfg.StorageGCSBucket == "" {
+			return fmt.Errorf("storage_type gcs requires storage_gcs_bucket")
+		}
+	case "azure":
+		if appCfg.StorageAzureContainer == "" {
+			return fmt.Errorf("storage_type azure requires storage_azure_container")
+		}
+		if appCfg.StorageAzureAccount == "" && appCfg.StorageAzureConnectionString == "" {
+			return fmt.Errorf("storage_type azure requires storage_azure_account or storage_azure_connection_string")
+		}
+	default:
+		return fmt.Errorf("invalid storage_type %q: must be local, s3, gcs, or azure", appCfg.StorageType)
+	}
+	if err := appCfg.storageOptions().Validate(); err != nil {
+		return fmt.Errorf("invalid storage settings for uploads: %w", err)
+	}
+
+	if appCfg.VirusScan != "" {
+		if !virusscan.ValidKind(appCfg.VirusScan) {
+			return fmt.Errorf("invalid virus_scan %q: must be clamav or http", appCfg.VirusScan)
+		}
+		if appCfg.VirusScanAddress == "" {
+			return fmt.Errorf("virus_scan %q requires virus_scan_address", appCfg.VirusScan)
+		}
+	}
+
+	if appCfg.AuditForward != "" {
+		if !auditforward.ValidKind(appCfg.AuditForward) {
+			return fmt.Errorf("invalid audit_forward %q: must be syslog or http", appCfg.AuditForward)
+		}
+		if appCfg.AuditForwardAddress == "" {
+			return fmt.Errorf("audit_forward %q requires audit_forward_address", appCfg.AuditForward)
+		}
+	}
+
+	if _, err := appCfg.trustedProxies(); err != nil {
+		return fmt.Errorf("invalid trusted_proxies: %w", err)
+	}
+
+	if appCfg.HealthProbeInterval <= 0 {
+		return fmt.Errorf("invalid health_probe_interval %s: must be more than 0", appCfg.HealthProbeInterval)
+	}
+	for _, name := range appCfg.healthReadyRequire() {
+		if name != healthcheck.ComponentStorage && name != healthcheck.ComponentSMTP {
+			return fmt.Errorf("invalid health_ready_require component %q: must be storage or smtp", name)
+		}
+	}
+
+	if appCfg.AccountDeleteGraceDays < 0 {
+		return fmt.Errorf("invalid account_delete_grace_days %d: must be 0 or more", appCfg.AccountDeleteGraceDays)
+	}
+	if appCfg.AuditRetentionDays < 0 {
+		return fmt.Errorf("invalid audit_retention_days %d: must be 0 or more", appCfg.AuditRetentionDays)
+	}
+
+	if appCfg.WebhookMaxAttempts < 1 {
+		return fmt.Errorf("invalid webhook_max_attempts %d: must be 1 or more", appCfg.WebhookMaxAttempts)
+	}
+	if appCfg.WebhookTimeout <= 0 {
+		return fmt.Errorf("invalid webhook_timeout %s: must be more than 0", appCfg.WebhookTimeout)
+	}
+
+	if appCfg.JobMaxRetryDelay < 0 {
+		return fmt.Errorf("invalid job_max_retry_delay %s: must be 0 or more", appCfg.JobMaxRetryDelay)
+	}
+
+	if appCfg.ScheduleRunRetention < 0 {
+		return fmt.Errorf("invalid schedule_run_retention %s: must be 0 or more", appCfg.ScheduleRunRetention)
+	}
+
+	if appCfg.APIStatsRetention != 0 && appCfg.APIStatsRetention < 24*time.Hour {
+		return fmt.Errorf("invalid api_stats_retention %s: must be 0 or at least 24h", appCfg.APIStatsRetention)
+	}
+
+	if appCfg.LedgerRetention < 0 {
+		return fmt.Errorf("invalid ledger_retention %s: must be 0 or more", appCfg.LedgerRetention)
+	}
+	if appCfg.LedgerSampleOneIn < 0 {
+		return fmt.Errorf("invalid ledger_success_sample_one_in %d: must be 0 or more", appCfg.LedgerSampleOneIn)
+	}
+	if appCfg.LedgerArchive && appCfg.LedgerRetention == 0 {
+		return fmt.Errorf("ledger_archive requires ledger_retention")
+	}
+
 	return nil
 }
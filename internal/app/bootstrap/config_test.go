@@ -0,0 +1,70 @@
+package bootstrap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/waffle/config"
+	"go.uber.org/zap"
+)
+
+// validAppConfig returns the smallest AppConfig that ValidateConfig accepts.
+func validAppConfig() AppConfig {
+	return AppConfig{
+		MongoURI:            "mongodb://localhost:27017",
+		HealthProbeInterval: 30 * time.Second,
+		WebhookMaxAttempts:  1,
+		WebhookTimeout:      10 * time.Second,
+	}
+}
+
+func TestValidateConfig_Storage(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(c *AppConfig)
+		wantErr string // Empty if the config is valid
+	}{
+		{"local", func(c *AppConfig) { c.StorageType = "local" }, ""},
+		{"unknown type", func(c *AppConfig) { c.StorageType = "ftp" }, "invalid storage_type"},
+		{"gcs", func(c *AppConfig) {
+			c.StorageType = "gcs"
+			c.StorageGCSBucket = "saves"
+		}, ""},
+		{"gcs without bucket", func(c *AppConfig) { c.StorageType = "gcs" }, "requires storage_gcs_bucket"},
+		{"azure with account", func(c *AppConfig) {
+			c.StorageType = "azure"
+			c.StorageAzureContainer = "saves"
+			c.StorageAzureAccount = "strata"
+		}, ""},
+		{"azure with connection string", func(c *AppConfig) {
+			c.StorageType = "azure"
+			c.StorageAzureContainer = "saves"
+			c.StorageAzureConnectionString = "DefaultEndpointsProtocol=https;AccountName=strata"
+		}, ""},
+		{"azure without container", func(c *AppConfig) {
+			c.StorageType = "azure"
+			c.StorageAzureAccount = "strata"
+		}, "requires storage_azure_container"},
+		{"azure without credentials", func(c *AppConfig) {
+			c.StorageType = "azure"
+			c.StorageAzureContainer = "saves"
+		}, "requires storage_azure_account or storage_azure_connection_string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validAppConfig()
+			tt.set(&cfg)
+			err := ValidateConfig(&config.CoreConfig{}, cfg, zap.NewNop())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateConfig() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateConfig() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
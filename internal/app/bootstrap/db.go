@@ -71,6 +71,36 @@ func ConnectDB(ctx context.Context, coreCfg *config.CoreConfig, appCfg AppConfig
 			zap.String("bucket", appCfg.StorageS3Bucket),
 			zap.String("prefix", appCfg.StorageS3Prefix),
 		)
+	case "gcs":
+		store, err = storage.NewGCS(ctx, storage.GCSConfig{
+			Bucket:          appCfg.StorageGCSBucket,
+			Prefix:          appCfg.StorageGCSPrefix,
+			CredentialsFile: appCfg.StorageGCSCredentialsFile,
+			ProjectID:       appCfg.StorageGCSProjectID,
+		})
+		if err != nil {
+			return DBDeps{}, fmt.Errorf("failed to initialize GCS storage: %w", err)
+		}
+		logger.Info("initialized Google Cloud Storage file storage",
+			zap.String("bucket", appCfg.StorageGCSBucket),
+			zap.String("prefix", appCfg.StorageGCSPrefix),
+		)
+	case "azure":
+		store, err = storage.NewAzure(ctx, storage.AzureConfig{
+			AccountName:      appCfg.StorageAzureAccount,
+			AccountKey:       appCfg.StorageAzureKey,
+			ConnectionString: appCfg.StorageAzureConnectionString,
+			Container:        appCfg.StorageAzureContainer,
+			Prefix:           appCfg.StorageAzurePrefix,
+			Endpoint:         appCfg.StorageAzureEndpoint,
+		})
+		if err != nil {
+			return DBDeps{}, fmt.Errorf("failed to initialize Azure Blob storage: %w", err)
+		}
+		logger.Info("initialized Azure Blob file storage",
+			zap.String("container", appCfg.StorageAzureContainer),
+			zap.String("prefix", appCfg.StorageAzurePrefix),
+		)
 	case "local", "":
 		store, err = storage.NewLocal(storage.LocalConfig{
 			BasePath: appCfg.StorageLocalPath,
@@ -89,12 +119,14 @@ func ConnectDB(ctx context.Context, coreCfg *config.CoreConfig, appCfg AppConfig
 
 	// Initialize email mailer
 	mail := mailer.New(mailer.Config{
-		Host:     appCfg.MailSMTPHost,
-		Port:     appCfg.MailSMTPPort,
-		User:     appCfg.MailSMTPUser,
-		Pass:     appCfg.MailSMTPPass,
-		From:     appCfg.MailFrom,
-		FromName: appCfg.MailFromName,
+		Host:      appCfg.MailSMTPHost,
+		Port:      appCfg.MailSMTPPort,
+		User:      appCfg.MailSMTPUser,
+		Pass:      appCfg.MailSMTPPass,
+		From:      appCfg.MailFrom,
+		FromName:  appCfg.MailFromName,
+		RateLimit: float64(appCfg.MailRateLimit),
+		BatchSize: appCfg.MailBatchSize,
 	}, logger)
 	logger.Info("initialized email mailer",
 		zap.String("host", appCfg.MailSMTPHost),
@@ -141,6 +173,16 @@ func EnsureSchema(ctx context.Context, coreCfg *config.CoreConfig, appCfg AppCon
 		return err
 	}
 
+	// Expire old ledger entries with a TTL index, unless they are archived
+	ledgerTTL := appCfg.LedgerRetention
+	if appCfg.LedgerArchive {
+		ledgerTTL = 0
+	}
+	if err := indexes.EnsureLedgerTTL(ctx, db, ledgerTTL); err != nil {
+		logger.Error("failed to ensure ledger TTL index", zap.Error(err))
+		return err
+	}
+
 	// Seed default data (pages, settings)
 	logger.Info("seeding default data")
 	if err := seeding.SeedAll(ctx, db, logger); err != nil {
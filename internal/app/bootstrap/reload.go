@@ -0,0 +1,92 @@
+// internal/app/bootstrap/reload.go
+package bootstrap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/system/configreload"
+	"github.com/dalemusser/waffle/config"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// reloadableKeys are the app config keys a reload applies without a
+// restart. Everything else that changes is reported as needing one.
+var reloadableKeys = []string{
+	// Login rate limiting (only when rate_limit_enabled was on at startup)
+	"rate_limit_login_attempts",
+	"rate_limit_login_window",
+	"rate_limit_login_lockout",
+
+	// Session idle timeouts
+	"session_idle_timeout",
+	"session_admin_idle_timeout",
+	"session_developer_idle_timeout",
+	"idle_logout_enabled",
+	"idle_logout_timeout",
+	"idle_logout_warning",
+
+	// Game state API limits
+	"max_saves_per_user",
+}
+
+// configReloader applies config reloads; it stops watching for SIGHUP
+// on shutdown.
+var configReloader *configreload.Reloader
+
+// reloadAppValues reads the app config keys again from the config.*
+// files, the environment, and any flags given at startup, with the same
+// precedence as LoadConfig: flags > env > files > defaults. Changes to a
+// .env file are not read, as its values are already in the environment.
+func reloadAppValues() (config.AppConfigValues, error) {
+	files := viper.New()
+	for _, ext := range [...]string{"yaml", "yml", "json", "toml"} {
+		file := "config." + ext
+		b, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+		files.SetConfigType(ext)
+		if err := files.MergeConfig(bytes.NewReader(b)); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", file, err)
+		}
+	}
+
+	v := viper.New()
+	v.SetEnvPrefix(EnvVarPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	values := make(config.AppConfigValues, len(appConfigKeys))
+	for _, key := range appConfigKeys {
+		v.SetDefault(key.Name, key.Default)
+		_ = v.BindEnv(key.Name)
+		if files.IsSet(key.Name) {
+			v.Set(key.Name, files.Get(key.Name))
+		}
+		if f := pflag.Lookup(key.Name); f != nil && f.Changed {
+			_ = v.BindPFlag(key.Name, f)
+		}
+		values[key.Name] = v.Get(key.Name)
+	}
+	return values, nil
+}
+
+// newConfigReloader creates the reloader for the config appCfg was built
+// from. Each reload builds a new AppConfig and passes it to every apply
+// function; only the reloadable keys differ from the running config.
+func newConfigReloader(appCfg AppConfig, logger *zap.Logger, apply ...func(AppConfig)) *configreload.Reloader {
+	return configreload.New(appCfg.values, reloadableKeys, reloadAppValues, func(values config.AppConfigValues) {
+		cfg := appConfigFromValues(values)
+		for _, fn := range apply {
+			fn(cfg)
+		}
+	}, logger)
+}
@@ -8,47 +8,74 @@ import (
 	"time"
 
 	activityfeature "github.com/dalemusser/stratasave/internal/app/features/activity"
-	apistatsfeature "github.com/dalemusser/stratasave/internal/app/features/apistats"
 	announcementsfeature "github.com/dalemusser/stratasave/internal/app/features/announcements"
+	apidocsfeature "github.com/dalemusser/stratasave/internal/app/features/apidocs"
 	apikeysfeature "github.com/dalemusser/stratasave/internal/app/features/apikeys"
-	saveapifeature "github.com/dalemusser/stratasave/internal/app/features/saveapi"
-	savebrowserfeature "github.com/dalemusser/stratasave/internal/app/features/savebrowser"
-	settingsapifeature "github.com/dalemusser/stratasave/internal/app/features/settingsapi"
-	settingsbrowserfeature "github.com/dalemusser/stratasave/internal/app/features/settingsbrowser"
+	apistatsfeature "github.com/dalemusser/stratasave/internal/app/features/apistats"
 	auditlogfeature "github.com/dalemusser/stratasave/internal/app/features/auditlog"
 	authgooglefeature "github.com/dalemusser/stratasave/internal/app/features/authgoogle"
 	dashboardfeature "github.com/dalemusser/stratasave/internal/app/features/dashboard"
+	emaillogfeature "github.com/dalemusser/stratasave/internal/app/features/emaillog"
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	featureflagsfeature "github.com/dalemusser/stratasave/internal/app/features/featureflags"
 	filesfeature "github.com/dalemusser/stratasave/internal/app/features/files"
+	groupsfeature "github.com/dalemusser/stratasave/internal/app/features/groups"
 	healthfeature "github.com/dalemusser/stratasave/internal/app/features/health"
 	heartbeatfeature "github.com/dalemusser/stratasave/internal/app/features/heartbeat"
 	homefeature "github.com/dalemusser/stratasave/internal/app/features/home"
+	impersonationfeature "github.com/dalemusser/stratasave/internal/app/features/impersonation"
 	invitationsfeature "github.com/dalemusser/stratasave/internal/app/features/invitations"
 	jobsfeature "github.com/dalemusser/stratasave/internal/app/features/jobs"
 	ledgerfeature "github.com/dalemusser/stratasave/internal/app/features/ledger"
 	loginfeature "github.com/dalemusser/stratasave/internal/app/features/login"
 	logoutfeature "github.com/dalemusser/stratasave/internal/app/features/logout"
+	outboxfeature "github.com/dalemusser/stratasave/internal/app/features/outbox"
 	pagesfeature "github.com/dalemusser/stratasave/internal/app/features/pages"
 	profilefeature "github.com/dalemusser/stratasave/internal/app/features/profile"
+	ratelimitsfeature "github.com/dalemusser/stratasave/internal/app/features/ratelimits"
+	reauthfeature "github.com/dalemusser/stratasave/internal/app/features/reauth"
+	registrationfeature "github.com/dalemusser/stratasave/internal/app/features/registration"
+	saveapifeature "github.com/dalemusser/stratasave/internal/app/features/saveapi"
+	savebrowserfeature "github.com/dalemusser/stratasave/internal/app/features/savebrowser"
 	settingsfeature "github.com/dalemusser/stratasave/internal/app/features/settings"
+	settingsapifeature "github.com/dalemusser/stratasave/internal/app/features/settingsapi"
+	settingsbrowserfeature "github.com/dalemusser/stratasave/internal/app/features/settingsbrowser"
 	statsfeature "github.com/dalemusser/stratasave/internal/app/features/stats"
 	statusfeature "github.com/dalemusser/stratasave/internal/app/features/status"
+	suppressionsfeature "github.com/dalemusser/stratasave/internal/app/features/suppressions"
 	systemusersfeature "github.com/dalemusser/stratasave/internal/app/features/systemusers"
+	tokenapifeature "github.com/dalemusser/stratasave/internal/app/features/tokenapi"
+	webhooksfeature "github.com/dalemusser/stratasave/internal/app/features/webhooks"
 	appresources "github.com/dalemusser/stratasave/internal/app/resources"
 	"github.com/dalemusser/stratasave/internal/app/store/activity"
+	announcementstore "github.com/dalemusser/stratasave/internal/app/store/announcement"
+	announcementackstore "github.com/dalemusser/stratasave/internal/app/store/announcementack"
+	announcementdismissalstore "github.com/dalemusser/stratasave/internal/app/store/announcementdismissal"
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
 	apistatsstore "github.com/dalemusser/stratasave/internal/app/store/apistats"
+	"github.com/dalemusser/stratasave/internal/app/store/emailverify"
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
 	ledgerstore "github.com/dalemusser/stratasave/internal/app/store/ledger"
-	"github.com/dalemusser/stratasave/internal/app/system/apistats"
-	"github.com/dalemusser/stratasave/internal/app/system/ledger"
-	announcementstore "github.com/dalemusser/stratasave/internal/app/store/announcement"
-	"github.com/dalemusser/stratasave/internal/app/store/audit"
 	"github.com/dalemusser/stratasave/internal/app/store/oauthstate"
 	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/apistats"
+	"github.com/dalemusser/stratasave/internal/app/system/apiversion"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
-	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
+	"github.com/dalemusser/stratasave/internal/app/system/invitationmail"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/newdevice"
+	"github.com/dalemusser/stratasave/internal/app/system/passwordexpiry"
+	"github.com/dalemusser/stratasave/internal/app/system/pwned"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/app/system/sessionrotate"
+	"github.com/dalemusser/stratasave/internal/app/system/statecache"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/virusscan"
 	"github.com/dalemusser/waffle/config"
 	"github.com/dalemusser/waffle/middleware"
 	"github.com/dalemusser/waffle/pantry/fileserver"
@@ -56,6 +83,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/gorilla/csrf"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -101,6 +129,10 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 	// This ensures role changes, disabled accounts, and profile updates take effect immediately.
 	sessionMgr.SetUserFetcher(userstore.NewFetcher(deps.MongoDatabase, logger))
 
+	// Per-role session lifetimes. A role without its own max age or idle
+	// timeout uses session_max_age and session_idle_timeout.
+	sessionMgr.SetSessionLifetimes(sessionLifetimes(appCfg))
+
 	// Set up inline forbidden page rendering so RequireRole renders at the
 	// current URL instead of redirecting to /forbidden.
 	sessionMgr.SetForbiddenRenderer(func(w http.ResponseWriter, r *http.Request, msg string) {
@@ -121,22 +153,46 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 
 	// Set up announcement loader for viewdata.
 	// This allows BaseVM to include active announcements for banner display.
+	// Announcements the user has to acknowledge are shown until they do;
+	// those they have dismissed are left out.
 	annStore := announcementstore.New(deps.MongoDatabase)
-	viewdata.SetAnnouncementLoader(func(ctx context.Context) []viewdata.AnnouncementVM {
+	ackStore := announcementackstore.New(deps.MongoDatabase)
+	dismissalStore := announcementdismissalstore.New(deps.MongoDatabase)
+	viewdata.SetAnnouncementLoader(func(ctx context.Context, userID primitive.ObjectID) []viewdata.AnnouncementVM {
 		announcements, err := annStore.GetActive(ctx)
 		if err != nil {
 			logger.Warn("failed to load active announcements", zap.Error(err))
 			return nil
 		}
-		result := make([]viewdata.AnnouncementVM, len(announcements))
-		for i, ann := range announcements {
-			result[i] = viewdata.AnnouncementVM{
+		var ackIDs, dismissIDs []primitive.ObjectID
+		for _, ann := range announcements {
+			if ann.RequireAck {
+				ackIDs = append(ackIDs, ann.ID)
+			} else if ann.Dismissible {
+				dismissIDs = append(dismissIDs, ann.ID)
+			}
+		}
+		acked, err := ackStore.Acknowledged(ctx, userID, ackIDs)
+		if err != nil {
+			logger.Warn("failed to load announcement acknowledgements", zap.Error(err))
+		}
+		dismissed, err := dismissalStore.Dismissed(ctx, userID, dismissIDs)
+		if err != nil {
+			logger.Warn("failed to load announcement dismissals", zap.Error(err))
+		}
+		result := make([]viewdata.AnnouncementVM, 0, len(announcements))
+		for _, ann := range announcements {
+			if acked[ann.ID] || dismissed[ann.ID] {
+				continue
+			}
+			result = append(result, viewdata.AnnouncementVM{
 				ID:          ann.ID.Hex(),
 				Title:       ann.Title,
 				Content:     ann.Content,
 				Type:        string(ann.Type),
-				Dismissible: ann.Dismissible,
-			}
+				Dismissible: ann.Dismissible && !ann.RequireAck,
+				RequireAck:  ann.RequireAck,
+			})
 		}
 		return result
 	})
@@ -144,17 +200,31 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 	// Create error logger for handlers.
 	errLog := errorsfeature.NewErrorLogger(logger)
 
-	// Create audit store and logger for security event tracking.
-	auditStore := audit.New(deps.MongoDatabase)
-	auditConfig := auditlog.Config{
-		Auth:  appCfg.AuditLogAuth,
-		Admin: appCfg.AuditLogAdmin,
-	}
-	auditLogger := auditlog.New(auditStore, logger, auditConfig)
+	// Create audit logger for security event tracking.
+	auditLogger := newAuditLogger(appCfg, deps, logger)
+
+	// Outgoing webhooks: handlers publish platform events to it, and the
+	// console invalidates its endpoint cache on changes.
+	webhookDispatcher := newWebhookDispatcher(appCfg, deps, logger)
+
+	// Feature flags checked at request time (trust login, self-registration,
+	// API versions); admins change them on the Feature Flags page.
+	flags := featureflags.New(deps.MongoDatabase, coreCfg.Env, logger)
 
 	// Create sessions store for activity tracking.
 	sessionsStore := sessions.New(deps.MongoDatabase)
 
+	// Close the tracked session when its cookie reaches its idle or absolute
+	// limit. The record may already be gone if its TTL ran out first.
+	sessionMgr.SetSessionExpiredHook(func(r *http.Request, userID, token, reason string) {
+		if token == "" {
+			return
+		}
+		if err := sessionsStore.Close(r.Context(), token, reason); err != nil {
+			logger.Debug("expired session not closed", zap.Error(err), zap.String("user_id", userID))
+		}
+	})
+
 	// Create activity store for logging user events.
 	activityStore := activity.New(deps.MongoDatabase)
 
@@ -168,6 +238,10 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 	// Global Middleware (applies to ALL routes)
 	// ─────────────────────────────────────────────────────────────────────────────
 
+	// Request ID middleware: first, so the ID is in the context of everything
+	// below it and ties the request's ledger entry, audit events, and logs together.
+	r.Use(requestid.Middleware)
+
 	// Request timeout middleware: prevents requests from hanging indefinitely.
 	r.Use(chimw.Timeout(30 * time.Second))
 
@@ -249,11 +323,16 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			path := req.URL.Path
 			// Skip CSRF for:
-			// - Game API routes (use API key auth)
+			// - Game API routes, versioned and unversioned (use API key auth)
 			// - Heartbeat API (internal JS calls with session auth)
 			// - Invitation acceptance (the invitation token itself provides CSRF protection)
+			// - Email provider webhook (authenticated by shared secret)
 			switch path {
-			case "/save", "/load", "/api/state/save", "/api/state/load", "/api/settings/save", "/api/settings/load", "/api/heartbeat", "/invite":
+			case "/save", "/load", "/api/state/save", "/api/state/load", "/api/settings/save", "/api/settings/load", "/api/token", "/api/heartbeat", "/invite", "/webhooks/email":
+				next.ServeHTTP(w, req)
+				return
+			}
+			if apiversion.IsVersionedPath(path) {
 				next.ServeHTTP(w, req)
 				return
 			}
@@ -270,6 +349,8 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 	// API Error Ledger
 	// Logs API errors (status >= 400) for debugging integration issues.
 	// View errors at /ledger with filter for status >= 400.
+	// Paths in ledger_capture_paths are logged in full, successes included,
+	// and ledger_success_sample_one_in keeps a sample of other successes.
 	// ─────────────────────────────────────────────────────────────────────────────
 	apiLedgerStore := ledgerstore.New(deps.MongoDatabase)
 	apiLedgerConfig := ledger.Config{
@@ -282,48 +363,127 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 			"User-Agent",
 			"X-Request-ID",
 		},
-		CaptureErrors: true,
-		OnlyErrors:    true, // Only log requests that result in errors (status >= 400)
+		CaptureErrors:   true,
+		OnlyErrors:      true, // Only log requests that result in errors (status >= 400)
+		SampleSuccesses: appCfg.LedgerSampleOneIn,
+		CapturePaths:    commaList(appCfg.LedgerCapturePaths),
+		MaxCaptureBody:  1024 * 1024,
+		CaptureTTL:      appCfg.LedgerCaptureTTL,
+		MaskFields:      commaList(appCfg.LedgerMaskFields),
 	}
 
 	// ─────────────────────────────────────────────────────────────────────────────
-	// Game State API Routes
+	// Game API Routes (state, settings, token)
 	// These routes use API key authentication. CSRF is handled above via path exemption.
 	// API errors are logged to the ledger for debugging.
 	// ─────────────────────────────────────────────────────────────────────────────
+	// Database-managed API keys (created at /api-keys) are accepted alongside
+	// the static configured key, subject to each key's IP allowlist.
+	apiKeyValidator := apikeystore.NewValidator(deps.MongoDatabase, logger)
+
+	// HMAC request signing (nil when api_signing_secret is not set). One
+	// verifier is shared so the replay cache covers every API route.
+	signatureVerifier := auth.NewSignatureVerifier(appCfg.APISigningSecret, appCfg.APISigningMaxSkew)
+	signatureVerifier.SetMaxBody(coreCfg.MaxRequestBodyBytes)
+
+	// Short-lived API tokens scoped to one user and game (nil when
+	// api_token_secret is not set).
+	tokenIssuer := auth.NewTokenIssuer(appCfg.APITokenSecret, appCfg.APITokenTTL)
+
+	// Proxies trusted to report the client address for API key allowlists
+	// (validated in ValidateConfig).
+	trustedProxies, _ := appCfg.trustedProxies()
+
+	// In-memory cache of state load responses (nil when state_cache_ttl is 0).
+	// Shared with the state browser so console edits invalidate it too.
+	stateLoadCache := statecache.New(appCfg.StateCacheTTL, appCfg.StateCacheMaxEntries)
+
 	saveapiHandler := saveapifeature.NewHandler(deps.MongoDatabase, logger, appCfg.MaxSavesPerUser)
+	saveapiHandler.SetLoadCache(stateLoadCache)
+	saveapiHandler.SetAPIKeyValidator(apiKeyValidator)
+	saveapiHandler.SetSignatureVerifier(signatureVerifier)
+	saveapiHandler.SetTokenIssuer(tokenIssuer)
+	saveapiHandler.SetTrustedProxies(trustedProxies)
+	saveapiHandler.SetWebhooks(webhookDispatcher)
+
+	settingsapiHandler := settingsapifeature.NewHandler(deps.MongoDatabase, logger)
+	settingsapiHandler.SetAPIKeyValidator(apiKeyValidator)
+	settingsapiHandler.SetSignatureVerifier(signatureVerifier)
+	settingsapiHandler.SetTokenIssuer(tokenIssuer)
+	settingsapiHandler.SetTrustedProxies(trustedProxies)
+
+	// POST /api/token - game servers exchange an API key for a short-lived token
+	tokenapiHandler := tokenapifeature.NewHandler(tokenIssuer, logger)
+	tokenapiHandler.SetAPIKeyValidator(apiKeyValidator)
+	tokenapiHandler.SetSignatureVerifier(signatureVerifier)
+	tokenapiHandler.SetTrustedProxies(trustedProxies)
+
+	// Versioned API: /api/v1/{state,settings,token} and /api/v2/...
+	// All versions currently share the same handlers. When a breaking change
+	// is made to a request or response, mount the new behavior under the
+	// newest version only (or branch on apiversion.FromContext) so shipped
+	// game builds keep working against the version they were built for.
+	for _, version := range apiversion.Supported {
+		r.Route("/api/"+version, func(r chi.Router) {
+			r.Use(apiversion.Middleware(version))
+			if version == apiversion.V2 {
+				r.Use(flags.Require(featureflags.APIV2))
+			}
+			r.Use(ledger.Middleware(apiLedgerConfig))
+			r.Mount("/state", saveapifeature.Routes(saveapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
+			r.Mount("/settings", settingsapifeature.Routes(settingsapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
+			r.Mount("/token", tokenapifeature.Routes(tokenapiHandler, appCfg.APIKey, logger))
+		})
+	}
 
-	// New API endpoints: POST /api/state/save and POST /api/state/load
+	// ─────────────────────────────────────────────────────────────────────────────
+	// API Compatibility Routes
+	// The original unversioned and legacy paths are aliases of v1, kept so
+	// game builds that have already shipped keep working. Responses carry
+	// Deprecation and Link headers pointing at the versioned path.
+	// ─────────────────────────────────────────────────────────────────────────────
+	apiV1 := apiversion.Middleware(apiversion.V1)
+
+	// POST /api/state/save and POST /api/state/load
 	r.Route("/api/state", func(r chi.Router) {
+		r.Use(apiV1, apiversion.Deprecated("/api/v1/state"))
 		r.Use(ledger.Middleware(apiLedgerConfig))
 		r.Mount("/", saveapifeature.Routes(saveapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
 	})
 
-	// Legacy endpoints for backward compatibility: POST /save and POST /load
+	// Legacy endpoints: POST /save and POST /load
 	r.Route("/save", func(r chi.Router) {
+		r.Use(apiV1, apiversion.Deprecated("/api/v1/state/save"))
 		r.Use(ledger.Middleware(apiLedgerConfig))
 		r.Mount("/", saveapifeature.LegacyRoutes(saveapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
 	})
 	r.Route("/load", func(r chi.Router) {
+		r.Use(apiV1, apiversion.Deprecated("/api/v1/state/load"))
 		r.Use(ledger.Middleware(apiLedgerConfig))
 		r.Mount("/", saveapifeature.LegacyLoadRoutes(saveapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
 	})
 
-	// ─────────────────────────────────────────────────────────────────────────────
-	// Player Settings API Routes
 	// POST /api/settings/save and POST /api/settings/load
-	// API errors are logged to the ledger for debugging.
-	// ─────────────────────────────────────────────────────────────────────────────
-	settingsapiHandler := settingsapifeature.NewHandler(deps.MongoDatabase, logger)
 	r.Route("/api/settings", func(r chi.Router) {
+		r.Use(apiV1, apiversion.Deprecated("/api/v1/settings"))
 		r.Use(ledger.Middleware(apiLedgerConfig))
 		r.Mount("/", settingsapifeature.Routes(settingsapiHandler, apiStatsRecorder, appCfg.APIKey, logger))
 	})
 
+	// POST /api/token
+	r.Route("/api/token", func(r chi.Router) {
+		r.Use(apiV1, apiversion.Deprecated("/api/v1/token"))
+		r.Use(ledger.Middleware(apiLedgerConfig))
+		r.Mount("/", tokenapifeature.Routes(tokenapiHandler, appCfg.APIKey, logger))
+	})
+
 	// Health check endpoints for load balancers and orchestrators
+	healthChecker := newHealthChecker(appCfg, deps, logger)
 	healthHandler := healthfeature.NewHandler(deps.MongoClient, logger)
+	healthHandler.SetChecker(healthChecker)
 	r.Mount("/health", healthfeature.Routes(healthHandler))
 	healthfeature.MountRootEndpoints(r, healthHandler)
+	healthfeature.MountMetrics(r, appCfg.MetricsToken)
 
 	// Static assets with pre-compressed file support (gzip/brotli)
 	// /static/* serves files from disk (static directory)
@@ -344,12 +504,28 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 
 	// Dynamic content pages (about, contact, terms, privacy)
 	pagesHandler := pagesfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	pagesHandler.SetAuditLogger(auditLogger)
+	viewdata.SetNavLinkLoader(pagesHandler.NavLinks)
 	r.Mount("/about", pagesHandler.AboutRouter())
 	r.Mount("/contact", pagesHandler.ContactRouter())
 	r.Mount("/terms", pagesHandler.TermsRouter())
 	r.Mount("/privacy", pagesHandler.PrivacyRouter())
 	r.Mount("/pages", pagesfeature.EditRoutes(pagesHandler, sessionMgr))
 
+	// CAPTCHA on public forms (optional; nil when captcha_provider is empty)
+	captchaVerifier, err := captcha.New(appCfg.CaptchaProvider, appCfg.CaptchaSiteKey, appCfg.CaptchaSecretKey, 5*time.Second, logger)
+	if err != nil {
+		logger.Error("captcha init failed", zap.Error(err))
+		return nil, err
+	}
+
+	// Login device tracking and new-device alerts (emails are controlled by
+	// the notify_user_on_new_device site setting)
+	newDevices := newdevice.New(deps.MongoDatabase, deps.Mailer, auditLogger, appCfg.BaseURL, logger)
+
+	// Suspicious activity detection (nil when every rule is off)
+	detector := newSuspiciousDetector(appCfg, deps, auditLogger, logger)
+
 	// User Invitations (public accept route)
 	invitationsHandler := invitationsfeature.NewHandler(
 		deps.MongoDatabase,
@@ -359,15 +535,37 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 		deps.Mailer,
 		auditLogger,
 		appCfg.BaseURL,
-		7*24*time.Hour, // 7 days expiry
+		inviteExpiry,
 		logger,
 	)
+	invitationsHandler.SetCaptcha(captchaVerifier)
+	invitationsHandler.SetNewDeviceNotifier(newDevices)
+	invitationsHandler.SetWebhooks(webhookDispatcher)
+	if deps.Mailer != nil {
+		invitationsHandler.SetBulkSender(invitationmail.New(deps.MongoDatabase, deps.Mailer, appCfg.BaseURL, inviteExpiry, logger))
+	}
 	r.Mount("/invite", invitationsfeature.AcceptRoutes(invitationsHandler))
 
+	// Self-service registration (public signup, off unless enabled in settings)
+	registrationHandler := registrationfeature.NewHandler(
+		deps.MongoDatabase,
+		sessionMgr,
+		sessionsStore,
+		deps.Mailer,
+		appCfg.BaseURL,
+		appCfg.EmailVerifyExpiry,
+		errLog,
+		auditLogger,
+		logger,
+	)
+	registrationHandler.Captcha = captchaVerifier
+	registrationHandler.NewDevices = newDevices
+	registrationHandler.Webhooks = webhookDispatcher
+	registrationHandler.Flags = flags
+	r.Mount("/register", registrationfeature.Routes(registrationHandler))
+
 	// Authentication
 	googleEnabled := appCfg.GoogleClientID != "" && appCfg.GoogleClientSecret != ""
-	// Trust login is only enabled in dev mode for security - it allows passwordless login
-	trustLoginEnabled := coreCfg.Env == "dev"
 
 	// Rate limiting for login attempts (nil if disabled)
 	var rateLimitStore *ratelimit.Store
@@ -391,11 +589,51 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 		rateLimitStore,
 		appCfg.BaseURL,
 		appCfg.EmailVerifyExpiry,
-		trustLoginEnabled,
 		logger,
 	)
+	// Breached password check for new passwords (optional)
+	var breachChecker *pwned.Checker
+	if appCfg.PasswordBreachCheck {
+		breachChecker = pwned.New(3*time.Second, logger)
+	}
+	loginHandler.SetBreachChecker(breachChecker)
+
+	// Invalidates earlier sessions after password, role, or backup code changes
+	sessionRotator := sessionrotate.New(deps.MongoDatabase, sessionMgr, logger)
+	loginHandler.SetSessionRotator(sessionRotator)
+	loginHandler.SetCaptcha(captchaVerifier)
+	loginHandler.SetNewDeviceNotifier(newDevices)
+	loginHandler.SetSuspiciousDetector(detector)
+	loginHandler.SetPasswordExpiry(passwordexpiry.Policy{
+		MaxAge:  appCfg.PasswordMaxAge,
+		Warning: appCfg.PasswordExpiryWarning,
+	})
+	loginHandler.SetFlags(flags)
+	emailCodePolicy := emailverify.Policy{
+		SingleActive:   appCfg.EmailCodeSingleActive,
+		ResendInterval: appCfg.EmailCodeResendInterval,
+		MaxAttempts:    appCfg.EmailCodeMaxAttempts,
+	}
+	loginHandler.SetEmailCodePolicy(emailCodePolicy)
 	r.Mount("/login", loginfeature.Routes(loginHandler))
 
+	// Identity confirmation before sensitive actions (see RequireRecentAuth)
+	sessionMgr.SetReauthWindow(appCfg.ReauthWindow)
+	reauthHandler := reauthfeature.NewHandler(
+		deps.MongoDatabase,
+		sessionMgr,
+		rateLimitStore,
+		deps.Mailer,
+		appCfg.BaseURL,
+		appCfg.EmailVerifyExpiry,
+		flags,
+		errLog,
+		auditLogger,
+		logger,
+	)
+	reauthHandler.SetEmailCodePolicy(emailCodePolicy)
+	r.Mount("/reauth", reauthfeature.Routes(reauthHandler, sessionMgr))
+
 	logoutHandler := logoutfeature.NewHandler(sessionMgr, auditLogger, sessionsStore, logger)
 	r.Mount("/logout", logoutfeature.Routes(logoutHandler, sessionMgr))
 
@@ -419,12 +657,20 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 			appCfg.BaseURL,
 			logger,
 		)
+		googleHandler.SetNewDeviceNotifier(newDevices)
+		googleHandler.SetSuspiciousDetector(detector)
 		r.Mount("/auth/google", authgooglefeature.Routes(googleHandler))
 		logger.Info("Google OAuth enabled", zap.String("redirect_url", appCfg.BaseURL+"/auth/google/callback"))
 	}
 
 	// User profile (admin and developer users)
 	profileHandler := profilefeature.NewHandler(deps.MongoDatabase, sessionsStore, errLog, logger)
+	profileHandler.SetBreachChecker(breachChecker)
+	profileHandler.SetAuditLogger(auditLogger)
+	profileHandler.SetSessionRotator(sessionRotator)
+	profileHandler.SetFileStorage(deps.FileStorage)
+	profileHandler.SetGoogleLinking(googleEnabled)
+	profileHandler.SetAccountDeletion(appCfg.AccountSelfDelete, time.Duration(appCfg.AccountDeleteGraceDays)*24*time.Hour)
 	r.Route("/profile", func(sr chi.Router) {
 		sr.Use(sessionMgr.RequireRole("admin", "developer"))
 		sr.Mount("/", profilefeature.Routes(profileHandler, sessionMgr))
@@ -460,17 +706,39 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 
 	// System user management (admin only)
 	sysUsersHandler := systemusersfeature.NewHandler(deps.MongoDatabase, deps.Mailer, errLog, auditLogger, logger)
+	sysUsersHandler.SetRateLimitStore(rateLimitStore)
+	sysUsersHandler.SetSessionRotator(sessionRotator)
+	sysUsersHandler.SetPurger(newUserPurger(appCfg, deps, logger))
+	sysUsersHandler.SetPasswordResetStore(newPasswordResetStore(appCfg, deps))
+	sysUsersHandler.SetBaseURL(appCfg.BaseURL)
+	sysUsersHandler.SetInvitationStore(invitation.New(deps.MongoDatabase, 7*24*time.Hour))
+	sysUsersHandler.SetWebhooks(webhookDispatcher)
 	r.Mount("/system-users", systemusersfeature.Routes(sysUsersHandler, sessionMgr))
 
+	// Login lockouts and manual unlock (admin only)
+	rateLimitsHandler := ratelimitsfeature.NewHandler(deps.MongoDatabase, rateLimitStore, errLog, auditLogger, logger)
+	r.Mount("/rate-limits", ratelimitsfeature.Routes(rateLimitsHandler, sessionMgr))
+
+	// Admin impersonation ("log in as user")
+	impersonationHandler := impersonationfeature.NewHandler(deps.MongoDatabase, sessionMgr, appCfg.ImpersonationTimeout, errLog, auditLogger, logger)
+	sessionMgr.SetImpersonationExpiredHook(impersonationHandler.Expired)
+	r.Mount("/impersonate", impersonationfeature.Routes(impersonationHandler, sessionMgr))
+
 	// Audit log (admin only)
 	auditLogHandler := auditlogfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	auditLogHandler.SetAuditLogger(auditLogger)
 	r.Mount("/audit", auditlogfeature.Routes(auditLogHandler, sessionMgr))
 
 	// User Invitations management (admin only)
 	r.Mount("/invitations", invitationsfeature.AdminRoutes(invitationsHandler, sessionMgr))
 
+	// Self-registered accounts awaiting approval (admin only)
+	r.Mount("/registrations", registrationfeature.ApprovalRoutes(registrationHandler, sessionMgr))
+
 	// Announcements management (admin only)
 	announcementsHandler := announcementsfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	announcementsHandler.SetWebhooks(webhookDispatcher)
+	announcementsHandler.SetEmailEnabled(deps.Mailer != nil)
 	r.Mount("/announcements", announcementsfeature.Routes(announcementsHandler, sessionMgr))
 
 	// User-facing announcements view (authenticated users)
@@ -478,59 +746,77 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 
 	// Files feature (all authenticated users can browse, admins can manage)
 	filesHandler := filesfeature.NewHandler(deps.MongoDatabase, deps.FileStorage, errLog, auditLogger, logger)
+	filesHandler.SetResumableUploads(newResumableUploads(appCfg, deps, logger))
+	filesHandler.SetUploadPolicy(filesfeature.UploadPolicy{
+		MaxSize: int64(appCfg.StorageMaxUploadMB) << 20,
+		Allowed: filesfeature.ParseTypeList(appCfg.StorageAllowedTypes),
+		Denied:  filesfeature.ParseTypeList(appCfg.StorageDeniedTypes),
+	})
+	filesHandler.SetDirectUploads(appCfg.StorageType == "s3" && appCfg.StorageS3DirectUploads)
+	if appCfg.signedDownloads() {
+		filesHandler.SetSignedDownloads(appCfg.StorageDownloadURLTTL)
+	}
+	filesHandler.SetStorageOptions(appCfg.storageOptions(), appCfg.StorageType == "s3" && appCfg.StorageS3UploadOptions)
+	filesHandler.SetTrash(newLibraryTrash(appCfg, deps, logger))
+	filesHandler.SetStorageQuota(int64(appCfg.StorageQuotaMB) << 20)
+	filesHandler.SetBaseURL(appCfg.BaseURL)
+	virusScanner, err := virusscan.New(appCfg.VirusScan, appCfg.VirusScanAddress, appCfg.VirusScanToken, appCfg.VirusScanFailOpen)
+	if err != nil {
+		logger.Error("virus scanner init failed", zap.Error(err))
+		return nil, err
+	}
+	filesHandler.SetVirusScanner(virusScanner)
 	r.Mount("/library", filesfeature.Routes(filesHandler, sessionMgr))
 
+	// Public share links for library files (the token is the credential)
+	r.Mount("/share", filesfeature.ShareRoutes(filesHandler))
+
+	// User groups and the library resources assigned to them
+	groupsHandler := groupsfeature.NewHandler(deps.MongoDatabase, deps.Mailer, errLog, auditLogger, logger)
+	groupsHandler.SetBaseURL(appCfg.BaseURL)
+	r.Mount("/groups", groupsfeature.Routes(groupsHandler, sessionMgr))
+
 	// Site Settings (admin only)
-	settingsHandler := settingsfeature.NewHandler(deps.MongoDatabase, deps.FileStorage, errLog, logger)
+	settingsHandler := settingsfeature.NewHandler(deps.MongoDatabase, deps.FileStorage, deps.Mailer, errLog, logger)
+	settingsHandler.SetAuditLogger(auditLogger)
+	if box, err := secretbox.New(appCfg.SettingsEncryptionKey); err == nil {
+		settingsHandler.SetSecretBox(box)
+	}
 	r.Route("/settings", func(sr chi.Router) {
 		sr.Use(sessionMgr.RequireRole("admin"))
-		settingsHandler.MountRoutes(sr)
+		settingsHandler.MountRoutes(sr, sessionMgr)
 	})
 
 	// System status page (admin only)
-	statusAppCfg := statusfeature.AppConfig{
-		MongoURI:           appCfg.MongoURI,
-		MongoDatabase:      appCfg.MongoDatabase,
-		MongoMaxPoolSize:   appCfg.MongoMaxPoolSize,
-		MongoMinPoolSize:   appCfg.MongoMinPoolSize,
-		SessionKey:         appCfg.SessionKey,
-		SessionName:        appCfg.SessionName,
-		SessionDomain:      appCfg.SessionDomain,
-		SessionMaxAge:      appCfg.SessionMaxAge,
-		IdleLogoutEnabled:      appCfg.IdleLogoutEnabled,
-		IdleLogoutTimeout:      appCfg.IdleLogoutTimeout,
-		IdleLogoutWarning:      appCfg.IdleLogoutWarning,
-		RateLimitEnabled:       appCfg.RateLimitEnabled,
-		RateLimitLoginAttempts: appCfg.RateLimitLoginAttempts,
-		RateLimitLoginWindow:   appCfg.RateLimitLoginWindow,
-		RateLimitLoginLockout:  appCfg.RateLimitLoginLockout,
-		CSRFKey:                appCfg.CSRFKey,
-		APIKey:                 appCfg.APIKey,
-		StorageType:        appCfg.StorageType,
-		StorageLocalPath:   appCfg.StorageLocalPath,
-		StorageLocalURL:    appCfg.StorageLocalURL,
-		StorageS3Region:    appCfg.StorageS3Region,
-		StorageS3Bucket:    appCfg.StorageS3Bucket,
-		StorageS3Prefix:    appCfg.StorageS3Prefix,
-		StorageCFURL:       appCfg.StorageCFURL,
-		StorageCFKeyPairID: appCfg.StorageCFKeyPairID,
-		StorageCFKeyPath:   appCfg.StorageCFKeyPath,
-		MailSMTPHost:       appCfg.MailSMTPHost,
-		MailSMTPPort:       appCfg.MailSMTPPort,
-		MailSMTPUser:       appCfg.MailSMTPUser,
-		MailSMTPPass:       appCfg.MailSMTPPass,
-		MailFrom:           appCfg.MailFrom,
-		MailFromName:       appCfg.MailFromName,
-		BaseURL:            appCfg.BaseURL,
-		EmailVerifyExpiry:  appCfg.EmailVerifyExpiry,
-		AuditLogAuth:       appCfg.AuditLogAuth,
-		AuditLogAdmin:      appCfg.AuditLogAdmin,
-		GoogleClientID:     appCfg.GoogleClientID,
-		GoogleClientSecret: appCfg.GoogleClientSecret,
-		SeedAdminEmail:     appCfg.SeedAdminEmail,
-		SeedAdminName:      appCfg.SeedAdminName,
-	}
-	statusHandler := statusfeature.NewHandler(deps.MongoClient, appCfg.BaseURL, coreCfg, statusAppCfg, logger)
+	statusHandler := statusfeature.NewHandler(deps.MongoClient, appCfg.BaseURL, coreCfg, statusAppConfig(appCfg), logger)
+	statusHandler.Storage = deps.FileStorage
+	statusHandler.Mailer = deps.Mailer
+	statusHandler.Health = healthChecker
+
+	// Config reload, from the status page or on SIGHUP. Only the keys in
+	// reloadableKeys change; the rest need a restart.
+	configReloader = newConfigReloader(appCfg, logger,
+		func(cfg AppConfig) {
+			if rateLimitStore != nil {
+				rateLimitStore.SetLimits(cfg.RateLimitLoginAttempts, cfg.RateLimitLoginWindow, cfg.RateLimitLoginLockout)
+			}
+		},
+		func(cfg AppConfig) {
+			def, byRole := sessionLifetimes(cfg)
+			idle := make(map[string]time.Duration, len(byRole))
+			for role, l := range byRole {
+				idle[role] = l.Idle
+			}
+			sessionMgr.SetIdleTimeouts(def.Idle, idle)
+		},
+		func(cfg AppConfig) {
+			heartbeatHandler.SetIdleLogoutConfig(cfg.IdleLogoutEnabled, cfg.IdleLogoutTimeout, cfg.IdleLogoutWarning)
+		},
+		func(cfg AppConfig) { saveapiHandler.SetMaxSaves(cfg.MaxSavesPerUser) },
+		func(cfg AppConfig) { statusHandler.SetAppConfig(statusAppConfig(cfg)) },
+	)
+	configReloader.WatchSignals()
+	statusHandler.Reloader = configReloader
 	r.Mount("/admin/status", statusfeature.Routes(statusHandler, sessionMgr))
 
 	// Activity dashboard (admin only)
@@ -551,12 +837,38 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 
 	// API Keys management (admin only)
 	apikeysHandler := apikeysfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	apikeysHandler.SetNotifier(apiKeyNotifier)
+	apikeysHandler.SetWebhooks(webhookDispatcher)
 	r.Mount("/api-keys", apikeysfeature.Routes(apikeysHandler, sessionMgr))
 
+	// Outgoing webhook endpoints and delivery history (admin only). Mounted
+	// apart from /webhooks, which holds inbound provider webhooks.
+	webhooksHandler := webhooksfeature.NewHandler(deps.MongoDatabase, webhookDispatcher, errLog, auditLogger, logger)
+	r.Mount("/webhook-endpoints", webhooksfeature.Routes(webhooksHandler, sessionMgr))
+
 	// Jobs monitoring (admin and developer)
 	jobsHandler := jobsfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	jobsHandler.SetScheduler(jobScheduler)
 	r.Mount("/jobs", jobsfeature.Routes(jobsHandler, sessionMgr))
 
+	// Email outbox (admin and developer)
+	outboxHandler := outboxfeature.NewHandler(deps.MongoDatabase, newEmailOutbox(appCfg, deps, logger), errLog, logger)
+	r.Mount("/email-outbox", outboxfeature.Routes(outboxHandler, sessionMgr))
+
+	// Email suppression list (admin and developer) and the provider
+	// bounce/complaint webhook, which authenticates with a shared secret
+	suppressionsHandler := suppressionsfeature.NewHandler(deps.MongoDatabase, appCfg.MailWebhookSecret, errLog, auditLogger, logger)
+	r.Mount("/email-suppressions", suppressionsfeature.Routes(suppressionsHandler, sessionMgr))
+	r.Post("/webhooks/email", suppressionsHandler.HandleWebhook)
+
+	// Feature flags (admin only)
+	featureFlagsHandler := featureflagsfeature.NewHandler(deps.MongoDatabase, flags, errLog, auditLogger, logger)
+	r.Mount("/feature-flags", featureflagsfeature.Routes(featureFlagsHandler, sessionMgr))
+
+	// Email delivery log (admin and developer)
+	emailLogHandler := emaillogfeature.NewHandler(deps.MongoDatabase, errLog, logger)
+	r.Mount("/email-log", emaillogfeature.Routes(emailLogHandler, sessionMgr))
+
 	// Statistics (admin and developer)
 	statsHandler := statsfeature.NewHandler(deps.MongoDatabase, errLog, logger)
 	r.Mount("/stats", statsfeature.Routes(statsHandler, sessionMgr))
@@ -580,6 +892,9 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 		appCfg.APIKey,
 		logger,
 	)
+	stateBrowserHandler.SetLoadCache(stateLoadCache)
+	stateBrowserHandler.SetRetention(saveapiHandler)
+	stateBrowserHandler.SetAuditLogger(auditLogger)
 	r.Mount("/console/api/state", savebrowserfeature.Routes(stateBrowserHandler, sessionMgr))
 
 	// Settings API Console (admin and developer)
@@ -589,10 +904,182 @@ func BuildHandler(coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, log
 		appCfg.APIKey,
 		logger,
 	)
+	settingsBrowserHandler.SetAuditLogger(auditLogger)
 	r.Mount("/console/api/settings", settingsbrowserfeature.Routes(settingsBrowserHandler, sessionMgr))
 
-	// 404 catch-all for unmatched routes
-	r.NotFound(errorsHandler.NotFound)
+	// API Reference: OpenAPI document and Swagger UI (admin and developer)
+	apidocsHandler := apidocsfeature.NewHandler(deps.MongoDatabase, logger)
+	r.Mount("/console/api/docs", apidocsfeature.Routes(apidocsHandler, sessionMgr))
+
+	// 404 catch-all for unmatched routes, which first looks for a custom
+	// page so custom pages can never hide a route
+	r.NotFound(pagesHandler.CustomPages(errorsHandler.NotFound))
+	pagesHandler.SetReservedRoutes(r)
 
 	return r, nil
 }
+
+// sessionLifetimes returns the default session lifetime and the per-role
+// overrides from config. Role settings left at zero inherit the default.
+func sessionLifetimes(appCfg AppConfig) (auth.SessionLifetime, map[string]auth.SessionLifetime) {
+	def := auth.SessionLifetime{
+		Idle:     appCfg.SessionIdleTimeout,
+		Absolute: appCfg.SessionMaxAge,
+	}
+	byRole := map[string]auth.SessionLifetime{
+		"admin":     {Idle: appCfg.SessionAdminIdleTimeout, Absolute: appCfg.SessionAdminMaxAge},
+		"developer": {Idle: appCfg.SessionDeveloperIdleTimeout, Absolute: appCfg.SessionDeveloperMaxAge},
+	}
+	for role, l := range byRole {
+		if l.Idle == 0 {
+			l.Idle = def.Idle
+		}
+		if l.Absolute == 0 {
+			l.Absolute = def.Absolute
+		}
+		byRole[role] = l
+	}
+	return def, byRole
+}
+
+// statusAppConfig returns the config shown on the status page.
+func statusAppConfig(appCfg AppConfig) statusfeature.AppConfig {
+	return statusfeature.AppConfig{
+		MongoURI:                     appCfg.MongoURI,
+		MongoDatabase:                appCfg.MongoDatabase,
+		MongoMaxPoolSize:             appCfg.MongoMaxPoolSize,
+		MongoMinPoolSize:             appCfg.MongoMinPoolSize,
+		SessionKey:                   appCfg.SessionKey,
+		SessionName:                  appCfg.SessionName,
+		SessionDomain:                appCfg.SessionDomain,
+		SessionMaxAge:                appCfg.SessionMaxAge,
+		SessionIdleTimeout:           appCfg.SessionIdleTimeout,
+		SessionAdminMaxAge:           appCfg.SessionAdminMaxAge,
+		SessionAdminIdleTimeout:      appCfg.SessionAdminIdleTimeout,
+		SessionDeveloperMaxAge:       appCfg.SessionDeveloperMaxAge,
+		SessionDeveloperIdleTimeout:  appCfg.SessionDeveloperIdleTimeout,
+		IdleLogoutEnabled:            appCfg.IdleLogoutEnabled,
+		IdleLogoutTimeout:            appCfg.IdleLogoutTimeout,
+		IdleLogoutWarning:            appCfg.IdleLogoutWarning,
+		ImpersonationTimeout:         appCfg.ImpersonationTimeout,
+		ReauthWindow:                 appCfg.ReauthWindow,
+		RateLimitEnabled:             appCfg.RateLimitEnabled,
+		RateLimitLoginAttempts:       appCfg.RateLimitLoginAttempts,
+		RateLimitLoginWindow:         appCfg.RateLimitLoginWindow,
+		RateLimitLoginLockout:        appCfg.RateLimitLoginLockout,
+		DetectFailedLoginAccounts:    appCfg.DetectFailedLoginAccounts,
+		DetectFailedLoginWindow:      appCfg.DetectFailedLoginWindow,
+		DetectBlockDuration:          appCfg.DetectBlockDuration,
+		DetectTravelSpeedKmh:         appCfg.DetectTravelSpeedKmh,
+		DetectLatitudeHeader:         appCfg.DetectLatitudeHeader,
+		DetectLongitudeHeader:        appCfg.DetectLongitudeHeader,
+		SecurityAlertEmails:          appCfg.SecurityAlertEmails,
+		PasswordBreachCheck:          appCfg.PasswordBreachCheck,
+		PasswordMaxAge:               appCfg.PasswordMaxAge,
+		PasswordExpiryWarning:        appCfg.PasswordExpiryWarning,
+		UserRestoreDays:              appCfg.UserRestoreDays,
+		AccountSelfDelete:            appCfg.AccountSelfDelete,
+		AccountDeleteGraceDays:       appCfg.AccountDeleteGraceDays,
+		UserDisableWarning:           appCfg.UserDisableWarning,
+		InviteReminder:               appCfg.InviteReminder,
+		CaptchaProvider:              appCfg.CaptchaProvider,
+		CaptchaSiteKey:               appCfg.CaptchaSiteKey,
+		CaptchaSecretKey:             appCfg.CaptchaSecretKey,
+		CSRFKey:                      appCfg.CSRFKey,
+		SettingsEncryptionKey:        appCfg.SettingsEncryptionKey,
+		APIKey:                       appCfg.APIKey,
+		APISigningSecret:             appCfg.APISigningSecret,
+		APISigningMaxSkew:            appCfg.APISigningMaxSkew,
+		APITokenSecret:               appCfg.APITokenSecret,
+		APITokenTTL:                  appCfg.APITokenTTL,
+		APIKeyAlertEmails:            appCfg.APIKeyAlertEmails,
+		APIKeyExpiryWarning:          appCfg.APIKeyExpiryWarning,
+		APIKeyErrorThreshold:         appCfg.APIKeyErrorThreshold,
+		APIKeyErrorWindow:            appCfg.APIKeyErrorWindow,
+		StorageType:                  appCfg.StorageType,
+		StorageLocalPath:             appCfg.StorageLocalPath,
+		StorageLocalURL:              appCfg.StorageLocalURL,
+		StorageS3Region:              appCfg.StorageS3Region,
+		StorageS3Bucket:              appCfg.StorageS3Bucket,
+		StorageS3Prefix:              appCfg.StorageS3Prefix,
+		StorageCFURL:                 appCfg.StorageCFURL,
+		StorageCFKeyPairID:           appCfg.StorageCFKeyPairID,
+		StorageCFKeyPath:             appCfg.StorageCFKeyPath,
+		StorageMaxUploadMB:           appCfg.StorageMaxUploadMB,
+		StorageS3Direct:              appCfg.StorageS3DirectUploads,
+		StorageS3PresignedDownloads:  appCfg.StorageS3PresignedDownloads,
+		StorageDownloadURLTTL:        appCfg.StorageDownloadURLTTL,
+		StorageS3Encryption:          appCfg.StorageS3Encryption,
+		StorageS3StorageClass:        appCfg.StorageS3StorageClass,
+		StorageS3UploadOptions:       appCfg.StorageS3UploadOptions,
+		StorageCacheControl:          appCfg.StorageCacheControl,
+		StorageGCSBucket:             appCfg.StorageGCSBucket,
+		StorageGCSPrefix:             appCfg.StorageGCSPrefix,
+		StorageGCSCredentialsFile:    appCfg.StorageGCSCredentialsFile,
+		StorageGCSProjectID:          appCfg.StorageGCSProjectID,
+		StorageAzureAccount:          appCfg.StorageAzureAccount,
+		StorageAzureKey:              appCfg.StorageAzureKey,
+		StorageAzureConnectionString: appCfg.StorageAzureConnectionString,
+		StorageAzureContainer:        appCfg.StorageAzureContainer,
+		StorageAzurePrefix:           appCfg.StorageAzurePrefix,
+		StorageAzureEndpoint:         appCfg.StorageAzureEndpoint,
+		StorageAllowedTypes:          appCfg.StorageAllowedTypes,
+		StorageDeniedTypes:           appCfg.StorageDeniedTypes,
+		StorageTrashDays:             appCfg.StorageTrashDays,
+		StorageQuotaMB:               appCfg.StorageQuotaMB,
+		VirusScan:                    appCfg.VirusScan,
+		VirusScanAddress:             appCfg.VirusScanAddress,
+		VirusScanToken:               appCfg.VirusScanToken,
+		VirusScanFailOpen:            appCfg.VirusScanFailOpen,
+		MailSMTPHost:                 appCfg.MailSMTPHost,
+		MailSMTPPort:                 appCfg.MailSMTPPort,
+		MailSMTPUser:                 appCfg.MailSMTPUser,
+		MailSMTPPass:                 appCfg.MailSMTPPass,
+		MailFrom:                     appCfg.MailFrom,
+		MailFromName:                 appCfg.MailFromName,
+		BaseURL:                      appCfg.BaseURL,
+		EmailVerifyExpiry:            appCfg.EmailVerifyExpiry,
+		EmailCodeSingleActive:        appCfg.EmailCodeSingleActive,
+		EmailCodeResendInterval:      appCfg.EmailCodeResendInterval,
+		EmailCodeMaxAttempts:         appCfg.EmailCodeMaxAttempts,
+		MailQueueEnabled:             appCfg.MailQueueEnabled,
+		MailMaxAttempts:              appCfg.MailMaxAttempts,
+		MailOutboxRetention:          appCfg.MailOutboxRetention,
+		MailLogRetention:             appCfg.MailLogRetention,
+		MailRateLimit:                appCfg.MailRateLimit,
+		MailBatchSize:                appCfg.MailBatchSize,
+		MailWebhookSecret:            appCfg.MailWebhookSecret,
+		JobRetryDelay:                appCfg.JobRetryDelay,
+		JobMaxRetryDelay:             appCfg.JobMaxRetryDelay,
+		WebhookMaxAttempts:           appCfg.WebhookMaxAttempts,
+		WebhookTimeout:               appCfg.WebhookTimeout,
+		WebhookDeliveryRetention:     appCfg.WebhookDeliveryRetention,
+		ScheduleRunRetention:         appCfg.ScheduleRunRetention,
+		MetricsToken:                 appCfg.MetricsToken,
+		HealthProbeStorage:           appCfg.HealthProbeStorage,
+		HealthProbeSMTP:              appCfg.HealthProbeSMTP,
+		HealthProbeInterval:          appCfg.HealthProbeInterval,
+		HealthReadyRequire:           appCfg.HealthReadyRequire,
+		AuditLogAuth:                 appCfg.AuditLogAuth,
+		AuditLogAdmin:                appCfg.AuditLogAdmin,
+		AuditForward:                 appCfg.AuditForward,
+		AuditForwardAddress:          appCfg.AuditForwardAddress,
+		AuditForwardAuth:             appCfg.AuditForwardAuth,
+		AuditRetentionDays:           appCfg.AuditRetentionDays,
+		GoogleClientID:               appCfg.GoogleClientID,
+		GoogleClientSecret:           appCfg.GoogleClientSecret,
+		SeedAdminEmail:               appCfg.SeedAdminEmail,
+		SeedAdminName:                appCfg.SeedAdminName,
+		MaxSavesPerUser:              appCfg.MaxSavesPerUser,
+		StateCacheTTL:                appCfg.StateCacheTTL,
+		StateCacheMaxEntries:         appCfg.StateCacheMaxEntries,
+		LedgerRetention:              appCfg.LedgerRetention,
+		LedgerArchive:                appCfg.LedgerArchive,
+		LedgerSampleOneIn:            appCfg.LedgerSampleOneIn,
+		LedgerCapturePaths:           appCfg.LedgerCapturePaths,
+		LedgerCaptureTTL:             appCfg.LedgerCaptureTTL,
+		LedgerMaskFields:             appCfg.LedgerMaskFields,
+		APIStatsBucket:               appCfg.APIStatsBucket,
+		APIStatsRetention:            appCfg.APIStatsRetention,
+	}
+}
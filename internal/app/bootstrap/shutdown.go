@@ -30,11 +30,44 @@ import (
 func Shutdown(ctx context.Context, coreCfg *config.CoreConfig, appCfg AppConfig, deps DBDeps, logger *zap.Logger) error {
 	var firstErr error
 
-	// Stop background task runner with context timeout
-	if taskRunner != nil {
-		logger.Info("stopping background task runner")
-		if err := taskRunner.Stop(ctx); err != nil {
-			logger.Warn("background task runner did not stop cleanly", zap.Error(err))
+	// Stop reloading config on SIGHUP
+	configReloader.Stop()
+
+	// Stop the queue job runner first so in-flight jobs can finish
+	if jobRunner != nil {
+		logger.Info("stopping job runner")
+		if err := jobRunner.Stop(ctx); err != nil {
+			logger.Warn("job runner did not stop cleanly", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	// Stop the job scheduler with context timeout
+	if jobScheduler != nil {
+		logger.Info("stopping job scheduler")
+		if err := jobScheduler.Stop(ctx); err != nil {
+			logger.Warn("job scheduler did not stop cleanly", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	// Give up leadership so another instance takes over without waiting
+	// for the lease to run out
+	if leaderElector != nil {
+		if err := leaderElector.Stop(ctx); err != nil {
+			logger.Warn("failed to give up leadership", zap.Error(err))
+		}
+	}
+
+	// Send audit events still queued for the SIEM
+	if auditForwarder != nil {
+		logger.Info("draining audit forwarder")
+		if err := auditForwarder.Close(ctx); err != nil {
+			logger.Warn("audit forwarder did not drain", zap.Error(err))
 			if firstErr == nil {
 				firstErr = err
 			}
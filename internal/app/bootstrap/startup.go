@@ -11,7 +11,43 @@ import (
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/resources"
+	apistatsstore "github.com/dalemusser/stratasave/internal/app/store/apistats"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	ledgerstore "github.com/dalemusser/stratasave/internal/app/store/ledger"
+	"github.com/dalemusser/stratasave/internal/app/store/passwordreset"
+	suppressionstore "github.com/dalemusser/stratasave/internal/app/store/suppression"
+	"github.com/dalemusser/stratasave/internal/app/system/accountdelete"
+	"github.com/dalemusser/stratasave/internal/app/system/announcementschedule"
+	"github.com/dalemusser/stratasave/internal/app/system/apikeyalerts"
+	"github.com/dalemusser/stratasave/internal/app/system/apistats"
+	"github.com/dalemusser/stratasave/internal/app/system/auditalerts"
+	"github.com/dalemusser/stratasave/internal/app/system/auditarchive"
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/distlock"
+	"github.com/dalemusser/stratasave/internal/app/system/emailbrand"
+	"github.com/dalemusser/stratasave/internal/app/system/emaillog"
+	"github.com/dalemusser/stratasave/internal/app/system/emailoutbox"
+	"github.com/dalemusser/stratasave/internal/app/system/emailsmtp"
+	"github.com/dalemusser/stratasave/internal/app/system/expirycleanup"
+	"github.com/dalemusser/stratasave/internal/app/system/healthcheck"
+	"github.com/dalemusser/stratasave/internal/app/system/invitationexpiry"
+	"github.com/dalemusser/stratasave/internal/app/system/invitationmail"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/ledgerarchive"
+	"github.com/dalemusser/stratasave/internal/app/system/librarytrash"
+	"github.com/dalemusser/stratasave/internal/app/system/passwordexpiry"
+	"github.com/dalemusser/stratasave/internal/app/system/reports"
+	"github.com/dalemusser/stratasave/internal/app/system/resumable"
+	"github.com/dalemusser/stratasave/internal/app/system/scheduler"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/app/system/suspicious"
 	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/app/system/userdisable"
+	"github.com/dalemusser/stratasave/internal/app/system/userpurge"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/waffle/config"
 	"github.com/dalemusser/waffle/pantry/text"
@@ -56,31 +92,318 @@ func Startup(ctx context.Context, coreCfg *config.CoreConfig, appCfg AppConfig,
 		}
 	}
 
-	// Start background task runner
-	startTaskRunner(deps.MongoDatabase, logger)
+	// Brand email and take the SMTP server from site settings, refuse email
+	// to addresses that have bounced or complained, log every delivery
+	// attempt, and queue the rest for delivery from the job runner
+	var deliveryLog *emaillog.Log
+	if deps.Mailer != nil {
+		deps.Mailer.SetBrandProvider(emailbrand.New(deps.MongoDatabase, deps.FileStorage, appCfg.BaseURL))
+		if box, err := secretbox.New(appCfg.SettingsEncryptionKey); err == nil {
+			deps.Mailer.SetSMTPProvider(emailsmtp.New(deps.MongoDatabase, box))
+		}
+		deps.Mailer.SetSuppressor(suppressionstore.New(deps.MongoDatabase))
+		deliveryLog = emaillog.New(deps.MongoDatabase, appCfg.MailLogRetention, logger)
+		deps.Mailer.SetRecorder(deliveryLog)
+	}
+	outbox := newEmailOutbox(appCfg, deps, logger)
+	if outbox != nil {
+		deps.Mailer.SetQueue(outbox)
+	}
+	trash := newLibraryTrash(appCfg, deps, logger)
+	cleaner := expirycleanup.New(deps.MongoDatabase, logger)
+	archiver := newAuditArchiver(appCfg, deps, logger)
+	ledgerArchiver := newLedgerArchiver(appCfg, deps, logger)
+	webhookDispatcher := newWebhookDispatcher(appCfg, deps, logger)
+	announcements := announcementschedule.New(deps.MongoDatabase, webhookDispatcher, deps.Mailer, appCfg.BaseURL, logger)
+	invitationMail := invitationmail.New(deps.MongoDatabase, deps.Mailer, appCfg.BaseURL, inviteExpiry, logger)
+	if err := startJobRunner(deps.MongoDatabase, appCfg, outbox, trash, cleaner, archiver, ledgerArchiver, webhookDispatcher, announcements, invitationMail, logger); err != nil {
+		return err
+	}
+
+	// Forward audit events to a SIEM when configured. Created here so
+	// background checks that record audit events forward them too.
+	var err error
+	auditForwarder, err = auditforward.New(appCfg.AuditForward, appCfg.AuditForwardAddress, appCfg.AuditForwardAuth, logger)
+	if err != nil {
+		logger.Error("audit forwarder init failed", zap.Error(err))
+		return err
+	}
+
+	// Start the job scheduler, including API key expiry checks when email
+	// notifications are available and error spike checks
+	apiKeyNotifier = newAPIKeyNotifier(appCfg, deps, newAuditLogger(appCfg, deps, logger), logger)
+	extra := apiKeyNotifier.Jobs()
+	extra = append(extra, outbox.Jobs()...)
+	extra = append(extra, deliveryLog.Jobs()...)
+	extra = append(extra, passwordexpiry.New(deps.MongoDatabase, deps.Mailer, passwordexpiry.Policy{
+		MaxAge:  appCfg.PasswordMaxAge,
+		Warning: appCfg.PasswordExpiryWarning,
+	}, appCfg.BaseURL, logger).Jobs()...)
+	extra = append(extra, newResumableUploads(appCfg, deps, logger).Jobs()...)
+	extra = append(extra, trash.Jobs()...)
+	extra = append(extra, cleaner.Jobs()...)
+	extra = append(extra, archiver.Jobs()...)
+	extra = append(extra, ledgerArchiver.Jobs()...)
+	extra = append(extra, webhookDispatcher.Jobs()...)
+	extra = append(extra, ledger.CaptureExpiryJob(ledgerstore.New(deps.MongoDatabase), logger))
+	extra = append(extra, apistats.NewRollup(apistatsstore.New(deps.MongoDatabase), appCfg.APIStatsRetention, logger).Jobs()...)
+	extra = append(extra, auditalerts.New(deps.MongoDatabase, deps.Mailer, appCfg.BaseURL, logger).Jobs()...)
+	extra = append(extra, reports.New(deps.MongoDatabase, deps.Mailer, appCfg.BaseURL, logger).Jobs()...)
+	extra = append(extra, newUserPurger(appCfg, deps, logger).Jobs()...)
+	extra = append(extra, userdisable.New(deps.MongoDatabase, deps.Mailer, appCfg.UserDisableWarning, logger).Jobs()...)
+	extra = append(extra, newAccountDeleter(appCfg, deps, logger).Jobs()...)
+	extra = append(extra, invitationexpiry.New(deps.MongoDatabase, deps.Mailer, appCfg.InviteReminder, appCfg.BaseURL, logger).Jobs()...)
+	extra = append(extra, announcements.Jobs()...)
+	if err := startScheduler(ctx, deps.MongoDatabase, appCfg, logger, extra...); err != nil {
+		logger.Error("job scheduler start failed", zap.Error(err))
+		return err
+	}
 
 	return nil
 }
 
-// taskRunner is the global task runner instance, used for graceful shutdown.
-var taskRunner *tasks.Runner
+// auditForwarder streams audit events to a SIEM when configured. It is
+// created in Startup and drained during graceful shutdown.
+var auditForwarder *auditforward.Forwarder
+
+// apiKeyNotifier sends API key alerts. It is created in Startup for the
+// scheduled checks and shared with the API keys feature, so both record
+// alerts through the same notifier and audit logger.
+var apiKeyNotifier *apikeyalerts.Notifier
+
+// jobRunner is the global queue job runner instance, used for graceful shutdown.
+var jobRunner *jobrunner.Runner
+
+// leaderElector picks the instance that runs work meant for only one
+// instance, such as the job runner's cleanup. It gives up leadership
+// during graceful shutdown so another instance takes over promptly.
+var leaderElector *distlock.Elector
+
+// inviteExpiry is how long an invitation can be accepted. The invitations
+// feature and the job that emails bulk invitations both use it.
+const inviteExpiry = 7 * 24 * time.Hour
+
+// leaderTTL is the lease on leadership; another instance takes over this
+// long after the leader stops without giving it up.
+const leaderTTL = 30 * time.Second
+
+// startJobRunner initializes and starts the queue job runner with the
+// handlers for each enabled queue.
+func startJobRunner(db *mongo.Database, appCfg AppConfig, outbox *emailoutbox.Outbox, trash *librarytrash.Trash, cleaner *expirycleanup.Cleaner, archiver *auditarchive.Archiver, ledgerArchiver *ledgerarchive.Archiver, webhookDispatcher *webhooks.Dispatcher, announcements *announcementschedule.Scheduler, invitationMail *invitationmail.Sender, logger *zap.Logger) error {
+	cfg := jobrunner.DefaultConfig()
+	cfg.RetryDelay = appCfg.JobRetryDelay
+	cfg.MaxRetryDelay = appCfg.JobMaxRetryDelay
+	jobRunner = jobrunner.New(jobstore.New(db), logger, cfg)
+
+	leaderElector = distlock.New(db, logger).NewElector("leader", leaderTTL)
+	leaderElector.Start()
+	jobRunner.SetElector(leaderElector)
+
+	if outbox != nil {
+		outbox.Register(jobRunner)
+	}
+	trash.Register(jobRunner)
+	cleaner.Register(jobRunner)
+	archiver.Register(jobRunner)
+	ledgerArchiver.Register(jobRunner)
+	webhookDispatcher.Register(jobRunner)
+	announcements.Register(jobRunner)
+	invitationMail.Register(jobRunner)
+
+	return jobRunner.Start()
+}
+
+// newWebhookDispatcher creates the outgoing webhook dispatcher from
+// configuration.
+func newWebhookDispatcher(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *webhooks.Dispatcher {
+	return webhooks.New(deps.MongoDatabase, webhooks.Config{
+		MaxAttempts:   appCfg.WebhookMaxAttempts,
+		RetryDelay:    appCfg.JobRetryDelay,
+		MaxRetryDelay: appCfg.JobMaxRetryDelay,
+		Timeout:       appCfg.WebhookTimeout,
+		Retention:     appCfg.WebhookDeliveryRetention,
+	}, logger)
+}
+
+// newEmailOutbox creates the email outbox from configuration.
+// Returns nil when the queue is disabled or no mailer is configured.
+func newEmailOutbox(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *emailoutbox.Outbox {
+	if !appCfg.MailQueueEnabled || deps.Mailer == nil {
+		return nil
+	}
+	return emailoutbox.New(deps.MongoDatabase, deps.Mailer, emailoutbox.Config{
+		MaxAttempts:   appCfg.MailMaxAttempts,
+		RetryDelay:    appCfg.JobRetryDelay,
+		MaxRetryDelay: appCfg.JobMaxRetryDelay,
+		Retention:     appCfg.MailOutboxRetention,
+	}, logger)
+}
+
+// jobScheduler is the global job scheduler, used for graceful shutdown and
+// by the jobs console to pause and run scheduled jobs.
+var jobScheduler *scheduler.Scheduler
 
-// startTaskRunner initializes and starts the background task runner.
-func startTaskRunner(db *mongo.Database, logger *zap.Logger) {
-	taskRunner = tasks.New(logger)
+// startScheduler registers the recurring background jobs with the
+// scheduler and starts it. Any extra jobs are registered after the
+// built-in cleanup jobs.
+func startScheduler(ctx context.Context, db *mongo.Database, appCfg AppConfig, logger *zap.Logger, extra ...tasks.Job) error {
+	jobScheduler = scheduler.New(db, appCfg.ScheduleRunRetention, logger)
 
-	// Register cleanup jobs
-	taskRunner.Register(tasks.SessionCleanupJob(db, logger))
-	taskRunner.Register(tasks.InvitationCleanupJob(db, logger))
-	taskRunner.Register(tasks.PasswordResetCleanupJob(db, logger))
-	taskRunner.Register(tasks.OAuthStateCleanupJob(db, logger))
-	taskRunner.Register(tasks.EmailVerificationCleanupJob(db, logger))
+	jobs := []tasks.Job{
+		tasks.InvitationCleanupJob(db, logger),
+		tasks.OAuthStateCleanupJob(db, logger),
+		// Close sessions inactive for 30 minutes (checked every 5 minutes)
+		tasks.InactiveSessionCleanupJob(db, logger, 30*time.Minute),
+	}
+	for _, job := range append(jobs, extra...) {
+		if err := jobScheduler.Register(job); err != nil {
+			return err
+		}
+	}
 
-	// Close sessions inactive for 30 minutes (checked every 5 minutes)
-	taskRunner.Register(tasks.InactiveSessionCleanupJob(db, logger, 30*time.Minute))
+	return jobScheduler.Start(ctx)
+}
 
-	// Start running jobs
-	taskRunner.Start()
+// commaList splits a comma-separated config value, dropping blank items.
+func commaList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// newResumableUploads creates the manager for chunked library uploads.
+func newResumableUploads(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *resumable.Manager {
+	return resumable.New(deps.MongoDatabase, deps.FileStorage, int64(appCfg.StorageMaxUploadMB)<<20, logger)
+}
+
+// newHealthChecker creates the checker that probes file storage and the
+// SMTP server for the health checks and the status page.
+func newHealthChecker(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *healthcheck.Checker {
+	required := make(map[string]bool)
+	for _, name := range appCfg.healthReadyRequire() {
+		required[name] = true
+	}
+
+	checker := healthcheck.New(appCfg.HealthProbeInterval, logger)
+	if appCfg.HealthProbeStorage {
+		checker.Add(healthcheck.Component{
+			Name:     healthcheck.ComponentStorage,
+			Critical: required[healthcheck.ComponentStorage],
+			Probe:    healthcheck.StorageProbe(deps.FileStorage),
+		})
+	}
+	if appCfg.HealthProbeSMTP && deps.Mailer != nil {
+		checker.Add(healthcheck.Component{
+			Name:     healthcheck.ComponentSMTP,
+			Critical: required[healthcheck.ComponentSMTP],
+			Probe:    healthcheck.MailerProbe(deps.Mailer),
+		})
+	}
+	return checker
+}
+
+// newLibraryTrash creates the trash that purges deleted library items.
+func newLibraryTrash(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *librarytrash.Trash {
+	retention := time.Duration(appCfg.StorageTrashDays) * 24 * time.Hour
+	return librarytrash.New(deps.MongoDatabase, deps.FileStorage, retention, logger)
+}
+
+// newAuditArchiver creates the archiver that moves audit events past their
+// retention to file storage.
+func newAuditArchiver(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *auditarchive.Archiver {
+	retention := time.Duration(appCfg.AuditRetentionDays) * 24 * time.Hour
+	return auditarchive.New(deps.MongoDatabase, deps.FileStorage, retention, logger)
+}
+
+// newLedgerArchiver creates the archiver that moves ledger entries past
+// their retention to file storage. Archiving is off unless ledger_archive
+// is set; the TTL index removes old entries instead.
+func newLedgerArchiver(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *ledgerarchive.Archiver {
+	var retention time.Duration
+	if appCfg.LedgerArchive {
+		retention = appCfg.LedgerRetention
+	}
+	return ledgerarchive.New(deps.MongoDatabase, deps.FileStorage, retention, logger)
+}
+
+// newUserPurger creates the purger that permanently deletes deleted users.
+func newUserPurger(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *userpurge.Purger {
+	retention := time.Duration(appCfg.UserRestoreDays) * 24 * time.Hour
+	purger := userpurge.New(deps.MongoDatabase, retention, logger)
+	purger.SetFileStorage(deps.FileStorage)
+	return purger
+}
+
+// newAccountDeleter creates the scheduler that deletes accounts users
+// asked to have deleted, recording the deletions in the audit log.
+func newAccountDeleter(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *accountdelete.Scheduler {
+	deleter := accountdelete.New(deps.MongoDatabase, logger)
+	deleter.SetAuditLogger(newAuditLogger(appCfg, deps, logger))
+	return deleter
+}
+
+// newPasswordResetStore creates the store for admin-sent password reset
+// links. Tokens last as long as the ones users request from the login page.
+func newPasswordResetStore(appCfg AppConfig, deps DBDeps) *passwordreset.Store {
+	expiry := appCfg.EmailVerifyExpiry
+	if expiry == 0 {
+		expiry = 10 * time.Minute
+	}
+	return passwordreset.New(deps.MongoDatabase, expiry)
+}
+
+// newAuditLogger creates the audit logger from configuration. Events are
+// forwarded to the SIEM set up in Startup, if any.
+func newAuditLogger(appCfg AppConfig, deps DBDeps, logger *zap.Logger) *auditlog.Logger {
+	l := auditlog.New(audit.New(deps.MongoDatabase), logger, auditlog.Config{
+		Auth:  appCfg.AuditLogAuth,
+		Admin: appCfg.AuditLogAdmin,
+	})
+	l.SetForwarder(auditForwarder)
+	return l
+}
+
+// newAPIKeyNotifier creates the API key notifier from configuration.
+// Returns nil when neither a mailer nor an audit logger is available.
+func newAPIKeyNotifier(appCfg AppConfig, deps DBDeps, auditLogger *auditlog.Logger, logger *zap.Logger) *apikeyalerts.Notifier {
+	var recipients []string
+	for _, addr := range strings.Split(appCfg.APIKeyAlertEmails, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return apikeyalerts.New(deps.MongoDatabase, deps.Mailer, auditLogger, apikeyalerts.Config{
+		BaseURL:             appCfg.BaseURL,
+		Recipients:          recipients,
+		ExpiryWarning:       appCfg.APIKeyExpiryWarning,
+		ErrorSpikeThreshold: appCfg.APIKeyErrorThreshold,
+		ErrorSpikeWindow:    appCfg.APIKeyErrorWindow,
+	}, logger)
+}
+
+// newSuspiciousDetector creates the suspicious activity detector from
+// configuration. Returns nil when every detection rule is off.
+func newSuspiciousDetector(appCfg AppConfig, deps DBDeps, auditLogger *auditlog.Logger, logger *zap.Logger) *suspicious.Detector {
+	var recipients []string
+	for _, addr := range strings.Split(appCfg.SecurityAlertEmails, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			recipients = append(recipients, addr)
+		}
+	}
+	return suspicious.New(deps.MongoDatabase, deps.Mailer, auditLogger, suspicious.Config{
+		FailedLoginAccounts: appCfg.DetectFailedLoginAccounts,
+		FailedLoginWindow:   appCfg.DetectFailedLoginWindow,
+		BlockDuration:       appCfg.DetectBlockDuration,
+		TravelSpeedKmh:      float64(appCfg.DetectTravelSpeedKmh),
+		LatitudeHeader:      appCfg.DetectLatitudeHeader,
+		LongitudeHeader:     appCfg.DetectLongitudeHeader,
+		Recipients:          recipients,
+		BaseURL:             appCfg.BaseURL,
+	}, logger)
 }
 
 // ensureAdminUser ensures an admin user exists with the given login_id.
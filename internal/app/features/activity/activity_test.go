@@ -486,29 +486,6 @@ func TestFindMostActiveDay(t *testing.T) {
 	}
 }
 
-func TestSanitizeCSVField(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"normal text", "normal text"},
-		{"=formula", "'=formula"},
-		{"+formula", "'+formula"},
-		{"-formula", "'-formula"},
-		{"@formula", "'@formula"},
-		{"", ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := sanitizeCSVField(tt.input)
-			if got != tt.expected {
-				t.Errorf("sanitizeCSVField(%q) = %q, want %q", tt.input, got, tt.expected)
-			}
-		})
-	}
-}
-
 func TestFormatMinutes(t *testing.T) {
 	tests := []struct {
 		mins     int
@@ -558,6 +535,29 @@ func TestFilterUsersBySearch(t *testing.T) {
 	}
 }
 
+func TestParseDashboardFilters(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/activity/export/online.csv?status=online&sort=time&dir=desc", nil)
+	f := parseDashboardFilters(r)
+	if f.Status != "online" || f.Search != "" || f.Sort != "time" || f.Dir != "desc" {
+		t.Errorf("parseDashboardFilters() = %+v", f)
+	}
+
+	users := []userRow{
+		{Name: "Alice", LoginID: "alice", Status: StatusOnline, TimeTodayMins: 10},
+		{Name: "Bob", LoginID: "bob", Status: StatusOffline, TimeTodayMins: 90},
+		{Name: "Carol", LoginID: "carol", Status: StatusOnline, TimeTodayMins: 45},
+	}
+	got := f.apply(users)
+	if len(got) != 2 || got[0].Name != "Carol" || got[1].Name != "Alice" {
+		t.Errorf("apply() = %+v, want Carol then Alice", got)
+	}
+
+	defaults := parseDashboardFilters(httptest.NewRequest(http.MethodGet, "/activity", nil))
+	if defaults != (dashboardFilters{Status: "all", Sort: "name", Dir: "asc"}) {
+		t.Errorf("parseDashboardFilters() defaults = %+v", defaults)
+	}
+}
+
 func TestFilterUsersByStatus(t *testing.T) {
 	users := []userRow{
 		{Name: "Online User", Status: StatusOnline},
@@ -31,19 +31,7 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Parse query parameters
-	statusFilter := query.Get(r, "status")
-	if statusFilter == "" {
-		statusFilter = "all"
-	}
-	searchQuery := query.Get(r, "search")
-	sortBy := query.Get(r, "sort")
-	if sortBy == "" {
-		sortBy = "name"
-	}
-	sortDir := query.Get(r, "dir")
-	if sortDir == "" {
-		sortDir = "asc"
-	}
+	f := parseDashboardFilters(r)
 	page := 1
 	if p := query.Get(r, "page"); p != "" {
 		if n, err := strconv.Atoi(p); err == nil && n > 0 {
@@ -74,14 +62,8 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Filter by search query
-	filteredUsers := filterUsersBySearch(allUsers, searchQuery)
-
-	// Filter by status
-	filteredUsers = filterUsersByStatus(filteredUsers, statusFilter)
-
-	// Sort users
-	sortUsers(filteredUsers, sortBy, sortDir)
+	// Filter and sort users
+	filteredUsers := f.apply(allUsers)
 
 	// Paginate
 	total := len(filteredUsers)
@@ -105,10 +87,10 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 
 	data := dashboardData{
 		BaseVM:       viewdata.NewBaseVM(r, h.DB, "Activity Dashboard", "/"),
-		StatusFilter: statusFilter,
-		SearchQuery:  searchQuery,
-		SortBy:       sortBy,
-		SortDir:      sortDir,
+		StatusFilter: f.Status,
+		SearchQuery:  f.Search,
+		SortBy:       f.Sort,
+		SortDir:      f.Dir,
 		Page:         page,
 		Total:        total,
 		RangeStart:   rangeStart,
@@ -134,19 +116,7 @@ func (h *Handler) ServeOnlineTable(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Parse query parameters
-	statusFilter := query.Get(r, "status")
-	if statusFilter == "" {
-		statusFilter = "all"
-	}
-	searchQuery := query.Get(r, "search")
-	sortBy := query.Get(r, "sort")
-	if sortBy == "" {
-		sortBy = "name"
-	}
-	sortDir := query.Get(r, "dir")
-	if sortDir == "" {
-		sortDir = "asc"
-	}
+	f := parseDashboardFilters(r)
 	page := 1
 	if p := query.Get(r, "page"); p != "" {
 		if n, err := strconv.Atoi(p); err == nil && n > 0 {
@@ -176,14 +146,8 @@ func (h *Handler) ServeOnlineTable(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Filter by search query
-	filteredUsers := filterUsersBySearch(allUsers, searchQuery)
-
-	// Filter by status
-	filteredUsers = filterUsersByStatus(filteredUsers, statusFilter)
-
-	// Sort users
-	sortUsers(filteredUsers, sortBy, sortDir)
+	// Filter and sort users
+	filteredUsers := f.apply(allUsers)
 
 	// Paginate
 	total := len(filteredUsers)
@@ -207,10 +171,10 @@ func (h *Handler) ServeOnlineTable(w http.ResponseWriter, r *http.Request) {
 
 	data := dashboardData{
 		BaseVM:       viewdata.NewBaseVM(r, h.DB, "Activity Dashboard", "/"),
-		StatusFilter: statusFilter,
-		SearchQuery:  searchQuery,
-		SortBy:       sortBy,
-		SortDir:      sortDir,
+		StatusFilter: f.Status,
+		SearchQuery:  f.Search,
+		SortBy:       f.Sort,
+		SortDir:      f.Dir,
 		Page:         page,
 		Total:        total,
 		RangeStart:   rangeStart,
@@ -229,6 +193,44 @@ func (h *Handler) ServeOnlineTable(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "activity_online_table", data)
 }
 
+// dashboardFilters holds the dashboard's status filter, search, and sort
+// order, shared by the page, its table refresh, and its exports.
+type dashboardFilters struct {
+	Status string // "all", "online", "idle", "offline"
+	Search string
+	Sort   string
+	Dir    string
+}
+
+// parseDashboardFilters reads the dashboard filters from the query string,
+// defaulting to all users sorted by name.
+func parseDashboardFilters(r *http.Request) dashboardFilters {
+	f := dashboardFilters{
+		Status: query.Get(r, "status"),
+		Search: query.Get(r, "search"),
+		Sort:   query.Get(r, "sort"),
+		Dir:    query.Get(r, "dir"),
+	}
+	if f.Status == "" {
+		f.Status = "all"
+	}
+	if f.Sort == "" {
+		f.Sort = "name"
+	}
+	if f.Dir == "" {
+		f.Dir = "asc"
+	}
+	return f
+}
+
+// apply returns the users matching the filters, sorted.
+func (f dashboardFilters) apply(users []userRow) []userRow {
+	users = filterUsersBySearch(users, f.Search)
+	users = filterUsersByStatus(users, f.Status)
+	sortUsers(users, f.Sort, f.Dir)
+	return users
+}
+
 // fetchAllUsersWithActivity gets all active users with their activity status.
 func (h *Handler) fetchAllUsersWithActivity(ctx context.Context, now time.Time) ([]userRow, error) {
 	// Query all active users
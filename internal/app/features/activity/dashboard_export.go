@@ -0,0 +1,246 @@
+// internal/app/features/activity/dashboard_export.go
+package activity
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"go.uber.org/zap"
+)
+
+// onlineExportRow is one dashboard user for CSV/JSON export.
+type onlineExportRow struct {
+	UserID          string     `json:"user_id"`
+	Name            string     `json:"name"`
+	LoginID         string     `json:"login_id"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	CurrentActivity string     `json:"current_activity"`
+	TimeTodayMins   int        `json:"time_today_mins"`
+	LastActiveAt    *time.Time `json:"last_active_at"`
+}
+
+// summaryExportRow is one user's weekly summary for CSV/JSON export.
+type summaryExportRow struct {
+	UserID       string `json:"user_id"`
+	Name         string `json:"name"`
+	LoginID      string `json:"login_id"`
+	Email        string `json:"email"`
+	WeekStart    string `json:"week_start"`
+	SessionCount int    `json:"session_count"`
+	TotalMins    int    `json:"total_mins"`
+	OutsideHours int    `json:"outside_hours"`
+}
+
+// ServeOnlineCSV exports the dashboard's users as CSV, honoring its status
+// filter, search, and sort order. Every matching user is exported, not
+// just the current page.
+func (h *Handler) ServeOnlineCSV(w http.ResponseWriter, r *http.Request) {
+	rows, ok := h.onlineExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("activity_users_%s.csv", time.Now().UTC().Format("20060102_150405"))
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename)
+
+	// UTF-8 BOM for Excel
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		h.Log.Error("CSV write failed (BOM)", zap.Error(err))
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	defer cw.Flush()
+
+	// Header
+	if err := cw.Write([]string{"user_id", "name", "login_id", "email", "role", "status", "current_activity", "time_today_mins", "last_active_at"}); err != nil {
+		h.Log.Error("CSV write failed (header)", zap.Error(err))
+		return
+	}
+
+	// Rows
+	for _, row := range rows {
+		lastActive := ""
+		if row.LastActiveAt != nil {
+			lastActive = row.LastActiveAt.Format(time.RFC3339)
+		}
+		if err := cw.Write([]string{
+			row.UserID,
+			csvutil.SafeField(row.Name),
+			csvutil.SafeField(row.LoginID),
+			csvutil.SafeField(row.Email),
+			row.Role,
+			row.Status,
+			csvutil.SafeField(row.CurrentActivity),
+			strconv.Itoa(row.TimeTodayMins),
+			lastActive,
+		}); err != nil {
+			h.Log.Error("CSV write failed (row)", zap.Error(err))
+			return
+		}
+	}
+
+	h.Log.Info("activity users CSV exported", zap.Int("rows", len(rows)))
+}
+
+// ServeOnlineJSON exports the dashboard's users as JSON, honoring its
+// status filter, search, and sort order.
+func (h *Handler) ServeOnlineJSON(w http.ResponseWriter, r *http.Request) {
+	rows, ok := h.onlineExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("activity_users_%s.json", time.Now().UTC().Format("20060102_150405"))
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		h.Log.Error("JSON encode failed", zap.Error(err))
+	}
+
+	h.Log.Info("activity users JSON exported", zap.Int("rows", len(rows)))
+}
+
+// ServeSummaryCSV exports the weekly summary for the "week" parameter as CSV.
+func (h *Handler) ServeSummaryCSV(w http.ResponseWriter, r *http.Request) {
+	rows, weekStart, ok := h.summaryExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("activity_summary_%s.csv", weekStart.Format("20060102"))
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename)
+
+	// UTF-8 BOM for Excel
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		h.Log.Error("CSV write failed (BOM)", zap.Error(err))
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	defer cw.Flush()
+
+	// Header
+	if err := cw.Write([]string{"user_id", "name", "login_id", "email", "week_start", "session_count", "total_mins", "outside_hours"}); err != nil {
+		h.Log.Error("CSV write failed (header)", zap.Error(err))
+		return
+	}
+
+	// Rows
+	for _, row := range rows {
+		if err := cw.Write([]string{
+			row.UserID,
+			csvutil.SafeField(row.Name),
+			csvutil.SafeField(row.LoginID),
+			csvutil.SafeField(row.Email),
+			row.WeekStart,
+			strconv.Itoa(row.SessionCount),
+			strconv.Itoa(row.TotalMins),
+			strconv.Itoa(row.OutsideHours),
+		}); err != nil {
+			h.Log.Error("CSV write failed (row)", zap.Error(err))
+			return
+		}
+	}
+
+	h.Log.Info("activity summary CSV exported", zap.Int("rows", len(rows)))
+}
+
+// ServeSummaryJSON exports the weekly summary for the "week" parameter as
+// JSON.
+func (h *Handler) ServeSummaryJSON(w http.ResponseWriter, r *http.Request) {
+	rows, weekStart, ok := h.summaryExportRows(w, r)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("activity_summary_%s.json", weekStart.Format("20060102"))
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		h.Log.Error("JSON encode failed", zap.Error(err))
+	}
+
+	h.Log.Info("activity summary JSON exported", zap.Int("rows", len(rows)))
+}
+
+// onlineExportRows loads the dashboard users matching the request's
+// filters. It reports an error to the client and returns false if they
+// can't be loaded.
+func (h *Handler) onlineExportRows(w http.ResponseWriter, r *http.Request) ([]onlineExportRow, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Long())
+	defer cancel()
+
+	allUsers, err := h.fetchAllUsersWithActivity(ctx, time.Now().UTC())
+	if err != nil {
+		h.ErrLog.Log(r, "fetch users for export failed", err)
+		http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	users := parseDashboardFilters(r).apply(allUsers)
+	rows := make([]onlineExportRow, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, onlineExportRow{
+			UserID:          u.ID,
+			Name:            u.Name,
+			LoginID:         u.LoginID,
+			Email:           u.Email,
+			Role:            u.Role,
+			Status:          string(u.Status),
+			CurrentActivity: u.CurrentActivity,
+			TimeTodayMins:   u.TimeTodayMins,
+			LastActiveAt:    u.LastActiveAt,
+		})
+	}
+	return rows, true
+}
+
+// summaryExportRows loads the weekly summary for the request's week. It
+// reports an error to the client and returns false if it can't be loaded.
+func (h *Handler) summaryExportRows(w http.ResponseWriter, r *http.Request) ([]summaryExportRow, time.Time, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Long())
+	defer cancel()
+
+	weekStart := parseWeek(r, time.Now().UTC())
+	users, err := h.fetchWeeklySummary(ctx, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		h.ErrLog.Log(r, "fetch summary for export failed", err)
+		http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		return nil, weekStart, false
+	}
+
+	rows := make([]summaryExportRow, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, summaryExportRow{
+			UserID:       u.ID,
+			Name:         u.Name,
+			LoginID:      u.LoginID,
+			Email:        u.Email,
+			WeekStart:    weekStart.Format("2006-01-02"),
+			SessionCount: u.SessionCount,
+			TotalMins:    u.TotalMins,
+			OutsideHours: u.OutsideHours,
+		})
+	}
+	return rows, weekStart, true
+}
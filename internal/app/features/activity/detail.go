@@ -95,7 +95,7 @@ func (h *Handler) ServeUserDetail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build session blocks with events (timestamps will be formatted client-side)
-	sessionBlocks := h.buildSessionBlocks(sessions, events)
+	sessionBlocks := h.buildSessionBlocks(sessions, events, viewdata.Location(r))
 
 	// Get timezone groups for selector
 	tzGroups, _ := timezones.Groups()
@@ -182,7 +182,7 @@ func (h *Handler) ServeUserDetailContent(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Build session blocks (timestamps will be formatted client-side)
-	sessionBlocks := h.buildSessionBlocks(sessions, events)
+	sessionBlocks := h.buildSessionBlocks(sessions, events, viewdata.Location(r))
 
 	data := userDetailData{
 		UserID:         userIDStr,
@@ -302,8 +302,9 @@ func (h *Handler) getEventsForSessions(ctx context.Context, sessionIDs []primiti
 
 // buildSessionBlocks organizes sessions and events into display blocks.
 // Events are matched to sessions by timestamp range (login_at to logout_at) or by session_id.
-// Timestamps are provided in ISO format for client-side timezone formatting.
-func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activitystore.Event) []sessionBlock {
+// Timestamps are provided in ISO format for client-side timezone formatting,
+// with labels in loc for when scripts don't run.
+func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activitystore.Event, loc *time.Location) []sessionBlock {
 	// First, try to group events by session_id (direct match)
 	eventsBySession := make(map[primitive.ObjectID][]activitystore.Event)
 	unmatchedEvents := make([]activitystore.Event, 0)
@@ -353,15 +354,15 @@ func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activity
 
 	var blocks []sessionBlock
 	for _, s := range sessions {
-		// Format times in UTC as fallback (client-side JS will format in selected timezone)
-		date := s.LoginAt.UTC().Format("Jan 2, 2006")
-		loginTime := s.LoginAt.UTC().Format("3:04 PM")
+		// Format times in loc as fallback (client-side JS will format in selected timezone)
+		date := s.LoginAt.In(loc).Format("Jan 2, 2006")
+		loginTime := s.LoginAt.In(loc).Format("3:04 PM")
 		loginTimeISO := s.LoginAt.UTC().Format(time.RFC3339)
 
 		logoutTime := ""
 		logoutTimeISO := ""
 		if s.LogoutAt != nil {
-			logoutTime = s.LogoutAt.UTC().Format("3:04 PM")
+			logoutTime = s.LogoutAt.In(loc).Format("3:04 PM")
 			logoutTimeISO = s.LogoutAt.UTC().Format(time.RFC3339)
 		}
 
@@ -385,7 +386,7 @@ func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activity
 		loginEventType := "login"
 		activityEvents = append(activityEvents, activityEvent{
 			Time:        s.LoginAt,
-			TimeLabel:   s.LoginAt.UTC().Format("3:04 PM"),
+			TimeLabel:   s.LoginAt.In(loc).Format("3:04 PM"),
 			TimeISO:     s.LoginAt.UTC().Format(time.RFC3339),
 			EventType:   loginEventType,
 			Description: loginDesc,
@@ -399,7 +400,7 @@ func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activity
 
 			ae := activityEvent{
 				Time:      e.Timestamp,
-				TimeLabel: e.Timestamp.UTC().Format("3:04 PM"),
+				TimeLabel: e.Timestamp.In(loc).Format("3:04 PM"),
 				TimeISO:   e.Timestamp.UTC().Format(time.RFC3339),
 				EventType: e.EventType,
 			}
@@ -422,7 +423,7 @@ func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activity
 			}
 			activityEvents = append(activityEvents, activityEvent{
 				Time:        *s.LogoutAt,
-				TimeLabel:   s.LogoutAt.UTC().Format("3:04 PM"),
+				TimeLabel:   s.LogoutAt.In(loc).Format("3:04 PM"),
 				TimeISO:     s.LogoutAt.UTC().Format(time.RFC3339),
 				EventType:   "logout",
 				Description: logoutDesc,
@@ -445,7 +446,7 @@ func (h *Handler) buildSessionBlocks(sessions []sessionRecord, events []activity
 			// Prepend status event showing last activity
 			idleEvent := activityEvent{
 				Time:        lastActivityTime,
-				TimeLabel:   lastActivityTime.UTC().Format("3:04 PM"),
+				TimeLabel:   lastActivityTime.In(loc).Format("3:04 PM"),
 				TimeISO:     lastActivityTime.UTC().Format(time.RFC3339),
 				EventType:   "idle",
 				Description: "Last activity",
@@ -11,9 +11,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"go.mongodb.org/mongo-driver/bson"
@@ -78,8 +78,7 @@ func (h *Handler) ServeSessionsCSV(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := fmt.Sprintf("sessions_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
-	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(filename)))
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename)
 
 	// UTF-8 BOM for Excel
 	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
@@ -101,7 +100,7 @@ func (h *Handler) ServeSessionsCSV(w http.ResponseWriter, r *http.Request) {
 	for _, row := range rows {
 		if err := cw.Write([]string{
 			row.UserID,
-			sanitizeCSVField(row.UserName),
+			csvutil.SafeField(row.UserName),
 			row.Email,
 			row.Role,
 			row.LoginAt.Format(time.RFC3339),
@@ -133,8 +132,7 @@ func (h *Handler) ServeSessionsJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := fmt.Sprintf("sessions_%s_%s.json", startDate.Format("20060102"), endDate.Format("20060102"))
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(filename)))
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename)
 
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -160,8 +158,7 @@ func (h *Handler) ServeEventsCSV(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := fmt.Sprintf("activity_events_%s_%s.csv", startDate.Format("20060102"), endDate.Format("20060102"))
-	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(filename)))
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename)
 
 	// UTF-8 BOM for Excel
 	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
@@ -189,7 +186,7 @@ func (h *Handler) ServeEventsCSV(w http.ResponseWriter, r *http.Request) {
 		}
 		if err := cw.Write([]string{
 			row.UserID,
-			sanitizeCSVField(row.UserName),
+			csvutil.SafeField(row.UserName),
 			row.SessionID,
 			row.Timestamp.Format(time.RFC3339),
 			row.EventType,
@@ -219,8 +216,7 @@ func (h *Handler) ServeEventsJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := fmt.Sprintf("activity_events_%s_%s.json", startDate.Format("20060102"), endDate.Format("20060102"))
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(filename)))
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename)
 
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -500,18 +496,6 @@ func findMostActiveDay(dayCounts map[string]int) string {
 	return maxDay
 }
 
-// sanitizeCSVField prevents CSV formula injection.
-func sanitizeCSVField(s string) string {
-	if len(s) == 0 {
-		return s
-	}
-	switch s[0] {
-	case '=', '+', '-', '@':
-		return "'" + s
-	}
-	return s
-}
-
 // formatMinutes formats a duration in minutes as "Xh Ym" or "X min".
 func formatMinutes(mins int) string {
 	if mins >= 60 {
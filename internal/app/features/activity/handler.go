@@ -4,6 +4,7 @@ package activity
 import (
 	uierrors "github.com/dalemusser/stratasave/internal/app/features/errors"
 	"github.com/dalemusser/stratasave/internal/app/store/activity"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
@@ -16,6 +17,7 @@ type Handler struct {
 	DB         *mongo.Database
 	Sessions   *sessions.Store
 	Activity   *activity.Store
+	Audit      *audit.Store
 	Users      *userstore.Store
 	SessionMgr *auth.SessionManager
 	Log        *zap.Logger
@@ -28,6 +30,7 @@ func NewHandler(db *mongo.Database, sessStore *sessions.Store, activityStore *ac
 		DB:         db,
 		Sessions:   sessStore,
 		Activity:   activityStore,
+		Audit:      audit.New(db),
 		Users:      userStore,
 		SessionMgr: sessionMgr,
 		ErrLog:     errLog,
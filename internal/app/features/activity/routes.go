@@ -31,6 +31,9 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 		// HTMX partial for refreshing user detail content
 		pr.Get("/user/{userID}/content", h.ServeUserDetailContent)
 
+		// Timeline combining sessions, audit events, and activity
+		pr.Get("/user/{userID}/timeline", h.ServeUserTimeline)
+
 		// Export UI
 		pr.Get("/export", h.ServeExport)
 
@@ -39,6 +42,10 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 		pr.Get("/export/sessions.json", h.ServeSessionsJSON)
 		pr.Get("/export/events.csv", h.ServeEventsCSV)
 		pr.Get("/export/events.json", h.ServeEventsJSON)
+		pr.Get("/export/online.csv", h.ServeOnlineCSV)
+		pr.Get("/export/online.json", h.ServeOnlineJSON)
+		pr.Get("/export/summary.csv", h.ServeSummaryCSV)
+		pr.Get("/export/summary.json", h.ServeSummaryJSON)
 	})
 
 	return r
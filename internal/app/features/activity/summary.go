@@ -25,16 +25,9 @@ func (h *Handler) ServeSummary(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	weekParam := query.Get(r, "week") // Format: 2025-01-13
-
 	// Calculate week range
 	now := time.Now().UTC()
-	weekStart := getWeekStart(now)
-	if weekParam != "" {
-		if parsed, err := time.Parse("2006-01-02", weekParam); err == nil {
-			weekStart = getWeekStart(parsed)
-		}
-	}
+	weekStart := parseWeek(r, now)
 	weekEnd := weekStart.AddDate(0, 0, 7)
 
 	// Get summary data for all users
@@ -64,6 +57,17 @@ func (h *Handler) ServeSummary(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "activity_summary", data)
 }
 
+// parseWeek returns the start of the week named by the "week" query
+// parameter (format: 2025-01-13), or of the week containing now.
+func parseWeek(r *http.Request, now time.Time) time.Time {
+	if weekParam := query.Get(r, "week"); weekParam != "" {
+		if parsed, err := time.Parse("2006-01-02", weekParam); err == nil {
+			return getWeekStart(parsed)
+		}
+	}
+	return getWeekStart(now)
+}
+
 // getWeekStart returns the Monday of the week containing the given time.
 func getWeekStart(t time.Time) time.Time {
 	t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
@@ -127,6 +131,7 @@ func (h *Handler) fetchWeeklySummary(ctx context.Context, weekStart, weekEnd tim
 			LoginID:      u.LoginID,
 			Email:        u.Email,
 			SessionCount: stats.SessionCount,
+			TotalMins:    stats.TotalMins,
 			TotalTimeStr: formatMins(stats.TotalMins),
 			OutsideHours: stats.OutsideHours,
 		})
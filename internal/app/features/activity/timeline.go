@@ -0,0 +1,382 @@
+// internal/app/features/activity/timeline.go
+package activity
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	activitystore "github.com/dalemusser/stratasave/internal/app/store/activity"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// timelineDefaultDays is how many days the timeline covers when no
+	// range is given, counting today.
+	timelineDefaultDays = 7
+
+	// timelineMaxDays is the longest range the timeline can cover.
+	timelineMaxDays = 90
+
+	// timelineLimit caps the entries read from each source, so a busy user
+	// can't make the page unbounded.
+	timelineLimit = 500
+)
+
+// Timeline entry kinds.
+const (
+	timelineSession  = "session"
+	timelineAudit    = "audit"
+	timelineActivity = "activity"
+)
+
+// timelineEntry is one thing that happened in a user's timeline.
+type timelineEntry struct {
+	Time      time.Time
+	TimeLabel string // Formatted time in UTC (fallback)
+	TimeISO   string // ISO 8601 format for client-side formatting
+	Kind      string // session, audit, or activity
+	Title     string
+	Detail    string
+	Failed    bool // An unsuccessful attempt, such as a failed login
+}
+
+// timelineDay is the timeline entries for one day.
+type timelineDay struct {
+	Date    string
+	Entries []timelineEntry
+}
+
+// timelineData is the view model for the user timeline.
+type timelineData struct {
+	viewdata.BaseVM
+
+	UserID   string
+	UserName string
+	LoginID  string
+
+	// Date filter
+	From  string // YYYY-MM-DD
+	To    string // YYYY-MM-DD
+	Error string
+
+	Days      []timelineDay
+	Count     int
+	Truncated bool // Some entries in the range weren't shown
+}
+
+// ServeUserTimeline renders a user's sessions, audit events, and feature
+// activity as one chronological timeline, newest first.
+// GET /activity/user/{userID}/timeline?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *Handler) ServeUserTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	userIDStr := chi.URLParam(r, "userID")
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.Users.GetByID(ctx, userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	q := r.URL.Query()
+	tr, rangeErr := parseTimelineRange(q.Get("from"), q.Get("to"), time.Now().UTC())
+
+	entries, truncated, err := h.timelineEntries(ctx, userID, tr.start, tr.end)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to build activity timeline", err)
+		http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		return
+	}
+
+	loginID := ""
+	if user.LoginID != nil {
+		loginID = *user.LoginID
+	}
+
+	data := timelineData{
+		BaseVM:    viewdata.NewBaseVM(r, h.DB, "Activity Timeline", "/system-users/"+userIDStr),
+		UserID:    userIDStr,
+		UserName:  user.FullName,
+		LoginID:   loginID,
+		From:      tr.from,
+		To:        tr.to,
+		Error:     rangeErr,
+		Days:      groupTimelineDays(entries),
+		Count:     len(entries),
+		Truncated: truncated,
+	}
+
+	templates.Render(w, r, "activity_user_timeline", data)
+}
+
+// timelineRange is the span of days a timeline covers.
+type timelineRange struct {
+	from, to   string    // YYYY-MM-DD, for the filter form
+	start, end time.Time // start of from through the end of to, in UTC
+}
+
+// parseTimelineRange reads the from and to dates of the filter form. Either
+// may be empty. An invalid range is replaced by the default (the last
+// timelineDefaultDays days), with a message saying why.
+func parseTimelineRange(from, to string, now time.Time) (timelineRange, string) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	def := timelineRange{
+		start: today.AddDate(0, 0, -(timelineDefaultDays - 1)),
+		end:   today,
+	}
+
+	tr := def
+	msg := ""
+	if to != "" {
+		d, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			msg = "Enter dates as YYYY-MM-DD."
+		} else {
+			tr.end = d
+			tr.start = d.AddDate(0, 0, -(timelineDefaultDays - 1))
+		}
+	}
+	if from != "" && msg == "" {
+		d, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			msg = "Enter dates as YYYY-MM-DD."
+		} else {
+			tr.start = d
+		}
+	}
+
+	switch {
+	case msg != "":
+		tr = def
+	case tr.start.After(tr.end):
+		tr, msg = def, "The start date must be on or before the end date."
+	case tr.end.Sub(tr.start) >= timelineMaxDays*24*time.Hour:
+		tr, msg = def, fmt.Sprintf("The timeline can cover at most %d days.", timelineMaxDays)
+	}
+
+	tr.from = tr.start.Format("2006-01-02")
+	tr.to = tr.end.Format("2006-01-02")
+	tr.end = tr.end.Add(24*time.Hour - time.Nanosecond)
+	return tr, msg
+}
+
+// timelineEntries reads everything that happened to or was done by userID
+// between start and end. truncated reports whether any source had more
+// than timelineLimit entries in the range.
+func (h *Handler) timelineEntries(ctx context.Context, userID primitive.ObjectID, start, end time.Time) ([]timelineEntry, bool, error) {
+	var entries []timelineEntry
+	truncated := false
+
+	// Sessions that started or ended in the range
+	cur, err := h.DB.Collection("sessions").Find(ctx, bson.M{
+		"user_id": userID,
+		"$or": []bson.M{
+			{"login_at": bson.M{"$gte": start, "$lte": end}},
+			{"logout_at": bson.M{"$gte": start, "$lte": end}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "login_at", Value: -1}}).SetLimit(timelineLimit))
+	if err != nil {
+		return nil, false, err
+	}
+	var sess []sessions.Session
+	if err := cur.All(ctx, &sess); err != nil {
+		return nil, false, err
+	}
+	truncated = truncated || len(sess) == timelineLimit
+	for _, s := range sess {
+		entries = append(entries, sessionEntries(s, start, end)...)
+	}
+
+	// Audit events about the user, and those the user performed
+	var events []audit.Event
+	seen := make(map[primitive.ObjectID]bool)
+	for _, f := range []audit.QueryFilter{
+		{UserID: &userID, StartTime: &start, EndTime: &end, Limit: timelineLimit},
+		{ActorID: &userID, StartTime: &start, EndTime: &end, Limit: timelineLimit},
+	} {
+		batch, err := h.Audit.Query(ctx, f)
+		if err != nil {
+			return nil, false, err
+		}
+		truncated = truncated || len(batch) == timelineLimit
+		for _, e := range batch {
+			if !seen[e.ID] {
+				seen[e.ID] = true
+				events = append(events, e)
+			}
+		}
+	}
+	names := h.userNames(ctx, userID, events)
+	for _, e := range events {
+		entries = append(entries, auditEntry(e, userID, names))
+	}
+
+	// Feature activity, such as page views
+	if h.Activity != nil {
+		acts, err := h.Activity.GetByUserInTimeRange(ctx, userID, start, end)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(acts) > timelineLimit {
+			acts, truncated = acts[len(acts)-timelineLimit:], true
+		}
+		for _, a := range acts {
+			entries = append(entries, activityEntry(a))
+		}
+	}
+
+	// Newest first
+	slices.SortStableFunc(entries, func(a, b timelineEntry) int {
+		return b.Time.Compare(a.Time)
+	})
+	return entries, truncated, nil
+}
+
+// userNames returns the names of the other users involved in events, by ID.
+func (h *Handler) userNames(ctx context.Context, userID primitive.ObjectID, events []audit.Event) map[primitive.ObjectID]string {
+	var ids []primitive.ObjectID
+	for _, e := range events {
+		for _, id := range []*primitive.ObjectID{e.UserID, e.ActorID} {
+			if id != nil && *id != userID && !slices.Contains(ids, *id) {
+				ids = append(ids, *id)
+			}
+		}
+	}
+
+	names := make(map[primitive.ObjectID]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+	users, _ := h.Users.GetByIDs(ctx, ids)
+	for _, u := range users {
+		names[u.ID] = u.FullName
+	}
+	return names
+}
+
+// sessionEntries returns the login and, if the session ended, the logout of
+// s, leaving out whichever falls outside start and end.
+func sessionEntries(s sessions.Session, start, end time.Time) []timelineEntry {
+	var out []timelineEntry
+
+	detail := s.Device().String()
+	if s.IPAddress != "" {
+		detail += " from " + s.IPAddress
+	}
+	if !s.LoginAt.Before(start) && !s.LoginAt.After(end) {
+		out = append(out, newTimelineEntry(s.LoginAt, timelineSession, "Logged in", detail, true))
+	}
+
+	if s.LogoutAt != nil && !s.LogoutAt.Before(start) && !s.LogoutAt.After(end) {
+		title := "Logged out"
+		switch s.EndReason {
+		case sessions.EndReasonInactive:
+			title = "Session timed out"
+		case sessions.EndReasonExpired:
+			title = "Session expired"
+		case sessions.EndReasonRotated:
+			title = "Session signed out after a security change"
+		case sessions.EndReasonDisabled:
+			title = "Session ended when the account was disabled"
+		}
+		if s.DurationSecs > 0 {
+			detail = "After " + formatDuration(s.DurationSecs)
+		} else {
+			detail = ""
+		}
+		out = append(out, newTimelineEntry(*s.LogoutAt, timelineSession, title, detail, true))
+	}
+	return out
+}
+
+// auditEntry describes an audit event from the point of view of userID.
+func auditEntry(e audit.Event, userID primitive.ObjectID, names map[primitive.ObjectID]string) timelineEntry {
+	var details []string
+	if e.ActorID != nil && *e.ActorID != userID {
+		details = append(details, "By "+nameOr(names, *e.ActorID, "another user"))
+	}
+	if e.UserID != nil && *e.UserID != userID {
+		details = append(details, "For "+nameOr(names, *e.UserID, "another user"))
+	}
+	if e.FailureReason != "" {
+		details = append(details, e.FailureReason)
+	}
+	if e.IP != "" {
+		details = append(details, "IP "+e.IP)
+	}
+	return newTimelineEntry(e.CreatedAt, timelineAudit, humanizeEventType(e.EventType), strings.Join(details, " · "), e.Success)
+}
+
+// activityEntry describes a feature activity event.
+func activityEntry(a activitystore.Event) timelineEntry {
+	if a.EventType == activitystore.EventPageView {
+		return newTimelineEntry(a.Timestamp, timelineActivity, "Viewed a page", a.PagePath, true)
+	}
+	return newTimelineEntry(a.Timestamp, timelineActivity, humanizeEventType(a.EventType), a.PagePath, true)
+}
+
+func newTimelineEntry(t time.Time, kind, title, detail string, success bool) timelineEntry {
+	t = t.UTC()
+	return timelineEntry{
+		Time:      t,
+		TimeLabel: t.Format("3:04:05 PM"),
+		TimeISO:   t.Format(time.RFC3339),
+		Kind:      kind,
+		Title:     title,
+		Detail:    detail,
+		Failed:    !success,
+	}
+}
+
+// nameOr returns the name of id, or fallback if it isn't known.
+func nameOr(names map[primitive.ObjectID]string, id primitive.ObjectID, fallback string) string {
+	if n := names[id]; n != "" {
+		return n
+	}
+	return fallback
+}
+
+// humanizeEventType turns an event type such as "password_changed" into
+// "Password changed".
+func humanizeEventType(t string) string {
+	s := strings.ReplaceAll(t, "_", " ")
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// groupTimelineDays splits entries, which are sorted newest first, into
+// one group per UTC day.
+func groupTimelineDays(entries []timelineEntry) []timelineDay {
+	var days []timelineDay
+	for _, e := range entries {
+		date := e.Time.Format("Monday, January 2, 2006")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, timelineDay{Date: date})
+		}
+		days[len(days)-1].Entries = append(days[len(days)-1].Entries, e)
+	}
+	return days
+}
@@ -0,0 +1,129 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseTimelineRange(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{"default", "", "", "2026-03-08", "2026-03-14", false},
+		{"both", "2026-02-01", "2026-02-10", "2026-02-01", "2026-02-10", false},
+		{"to only", "", "2026-02-10", "2026-02-04", "2026-02-10", false},
+		{"from only", "2026-03-01", "", "2026-03-01", "2026-03-14", false},
+		{"single day", "2026-03-01", "2026-03-01", "2026-03-01", "2026-03-01", false},
+		{"bad date", "March 1", "", "2026-03-08", "2026-03-14", true},
+		{"reversed", "2026-03-10", "2026-03-01", "2026-03-08", "2026-03-14", true},
+		{"too long", "2025-01-01", "2026-03-14", "2026-03-08", "2026-03-14", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, msg := parseTimelineRange(tt.from, tt.to, now)
+			if tr.from != tt.wantFrom || tr.to != tt.wantTo {
+				t.Errorf("range = %s..%s, want %s..%s", tr.from, tr.to, tt.wantFrom, tt.wantTo)
+			}
+			if (msg != "") != tt.wantErr {
+				t.Errorf("message = %q, want error: %v", msg, tt.wantErr)
+			}
+			if tr.end.Format("2006-01-02") != tr.to || tr.end.Hour() != 23 {
+				t.Errorf("end = %v, want the end of %s", tr.end, tr.to)
+			}
+		})
+	}
+}
+
+func TestSessionEntries(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 1, 23, 59, 59, 0, time.UTC)
+	logout := time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC)
+
+	s := sessions.Session{
+		UserAgent:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0",
+		IPAddress:    "203.0.113.42",
+		LoginAt:      time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC),
+		LogoutAt:     &logout,
+		EndReason:    sessions.EndReasonInactive,
+		DurationSecs: 3 * 3600,
+	}
+
+	// The logout falls after the range, so only the login is shown
+	got := sessionEntries(s, start, end)
+	if len(got) != 1 {
+		t.Fatalf("got %d entries, want 1", len(got))
+	}
+	if got[0].Title != "Logged in" || got[0].Detail != "Chrome on Windows from 203.0.113.42" {
+		t.Errorf("login entry = %q / %q", got[0].Title, got[0].Detail)
+	}
+
+	got = sessionEntries(s, start, logout)
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if got[1].Title != "Session timed out" || got[1].Detail != "After 3h" {
+		t.Errorf("logout entry = %q / %q", got[1].Title, got[1].Detail)
+	}
+}
+
+func TestAuditEntry(t *testing.T) {
+	userID := primitive.NewObjectID()
+	adminID := primitive.NewObjectID()
+	names := map[primitive.ObjectID]string{adminID: "Sam Rivera"}
+
+	e := audit.Event{
+		CreatedAt: time.Now(),
+		EventType: audit.EventUserDisabled,
+		UserID:    &userID,
+		ActorID:   &adminID,
+		IP:        "203.0.113.7",
+		Success:   true,
+	}
+	got := auditEntry(e, userID, names)
+	if got.Title != "User disabled" || got.Detail != "By Sam Rivera · IP 203.0.113.7" || got.Failed {
+		t.Errorf("entry = %+v", got)
+	}
+
+	e = audit.Event{
+		CreatedAt:     time.Now(),
+		EventType:     audit.EventLoginFailedWrongPassword,
+		UserID:        &userID,
+		FailureReason: "wrong password",
+	}
+	got = auditEntry(e, userID, names)
+	if !got.Failed || got.Detail != "wrong password" {
+		t.Errorf("entry = %+v", got)
+	}
+}
+
+func TestGroupTimelineDays(t *testing.T) {
+	day1 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	day0 := time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC)
+	entries := []timelineEntry{
+		newTimelineEntry(day1.Add(time.Hour), timelineAudit, "b", "", true),
+		newTimelineEntry(day1, timelineSession, "a", "", true),
+		newTimelineEntry(day0, timelineActivity, "c", "", true),
+	}
+
+	days := groupTimelineDays(entries)
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	if days[0].Date != "Monday, March 2, 2026" || len(days[0].Entries) != 2 {
+		t.Errorf("day 0 = %s with %d entries", days[0].Date, len(days[0].Entries))
+	}
+	if days[1].Date != "Sunday, March 1, 2026" || len(days[1].Entries) != 1 {
+		t.Errorf("day 1 = %s with %d entries", days[1].Date, len(days[1].Entries))
+	}
+}
@@ -82,6 +82,7 @@ type summaryRow struct {
 	Email        string
 	Role         string
 	SessionCount int
+	TotalMins    int    // For export
 	TotalTimeStr string // Pre-formatted "Xh Ym" or "X min"
 	OutsideHours int    // Sessions at unusual times
 }
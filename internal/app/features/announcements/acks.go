@@ -0,0 +1,147 @@
+package announcements
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/announcement"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/dalemusser/waffle/pantry/urlutil"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ackRow is a user in an announcement's acknowledgement report.
+type ackRow struct {
+	Name           string
+	LoginID        string
+	Email          string
+	AcknowledgedAt string // empty if they haven't
+}
+
+// AcksVM is the view model for an announcement's acknowledgement report.
+type AcksVM struct {
+	viewdata.BaseVM
+	ID           string
+	AnnTitle     string
+	RequireAck   bool
+	Acknowledged []ackRow
+	Pending      []ackRow
+	Total        int
+	Percent      int
+}
+
+// acknowledgements shows which users have acknowledged an announcement
+// and which haven't. Users who acknowledged are listed earliest first;
+// active users who haven't are listed by name.
+func (h *Handler) acknowledgements(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	ann, err := h.announcementStore.GetByID(r.Context(), objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	acks, err := h.acks.ListByAnnouncement(r.Context(), objID)
+	if err != nil {
+		h.errLog.Log(r, "failed to list announcement acknowledgements", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	users, err := h.users.Find(r.Context(), bson.M{"status": status.Active})
+	if err != nil {
+		h.errLog.Log(r, "failed to list users for acknowledgement report", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	rows := make(map[primitive.ObjectID]ackRow, len(users))
+	for _, u := range users {
+		row := ackRow{Name: u.FullName}
+		if u.LoginID != nil {
+			row.LoginID = *u.LoginID
+		}
+		if u.Email != nil {
+			row.Email = *u.Email
+		}
+		rows[u.ID] = row
+	}
+
+	vm := AcksVM{
+		BaseVM:     viewdata.New(r),
+		ID:         id,
+		AnnTitle:   ann.Title,
+		RequireAck: ann.RequireAck,
+	}
+	for _, a := range acks {
+		row, ok := rows[a.UserID]
+		if !ok {
+			continue // no longer an active user
+		}
+		row.AcknowledgedAt = a.AcknowledgedAt.Format("Jan 2, 2006 3:04 PM")
+		vm.Acknowledged = append(vm.Acknowledged, row)
+		delete(rows, a.UserID)
+	}
+	for _, row := range rows {
+		vm.Pending = append(vm.Pending, row)
+	}
+	sort.Slice(vm.Pending, func(i, j int) bool {
+		return strings.ToLower(vm.Pending[i].Name) < strings.ToLower(vm.Pending[j].Name)
+	})
+
+	vm.Total = len(vm.Acknowledged) + len(vm.Pending)
+	if vm.Total > 0 {
+		vm.Percent = len(vm.Acknowledged) * 100 / vm.Total
+	}
+	vm.Title = "Acknowledgements"
+	vm.BackURL = urlutil.SafeReturn(r.URL.Query().Get("return"), "", "/announcements")
+
+	templates.Render(w, r, "announcements/acks", vm)
+}
+
+// acknowledge records that the signed-in user acknowledged an announcement,
+// then returns them to the page they were on.
+func (h *Handler) acknowledge(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ann, err := h.announcementStore.GetByID(r.Context(), objID)
+	if err != nil || !ann.RequireAck {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.acks.Acknowledge(r.Context(), objID, user.UserID(), time.Now()); err != nil {
+		h.errLog.Log(r, "failed to acknowledge announcement", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, urlutil.SafeReturn(r.FormValue("return"), "", "/my-announcements"), http.StatusSeeOther)
+}
+
+// requireAck reports whether the form asks for acknowledgement. Only
+// critical announcements can require it.
+func requireAck(r *http.Request, annType announcement.Type) bool {
+	return annType == announcement.TypeCritical && r.FormValue("require_ack") == "on"
+}
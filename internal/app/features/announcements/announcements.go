@@ -9,8 +9,12 @@ import (
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	"github.com/dalemusser/stratasave/internal/app/store/announcement"
+	"github.com/dalemusser/stratasave/internal/app/store/announcementack"
+	"github.com/dalemusser/stratasave/internal/app/store/announcementdismissal"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/csrf"
@@ -22,8 +26,13 @@ import (
 // Handler provides announcement handlers.
 type Handler struct {
 	announcementStore *announcement.Store
+	acks              *announcementack.Store
+	dismissals        *announcementdismissal.Store
+	users             *userstore.Store
 	errLog            *errorsfeature.ErrorLogger
 	logger            *zap.Logger
+	webhooks          *webhooks.Dispatcher
+	emailEnabled      bool
 }
 
 // NewHandler creates a new announcements Handler.
@@ -34,22 +43,100 @@ func NewHandler(
 ) *Handler {
 	return &Handler{
 		announcementStore: announcement.New(db),
+		acks:              announcementack.New(db),
+		dismissals:        announcementdismissal.New(db),
+		users:             userstore.New(db),
 		errLog:            errLog,
 		logger:            logger,
 	}
 }
 
+// SetWebhooks enables announcement.published webhook events, sent when an
+// announcement starts showing because it was created, edited, or switched
+// on. Announcements scheduled to start later are published by the
+// announcement scheduler when their start time arrives.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
+}
+
+// SetEmailEnabled offers to email announcements to every user when they are
+// published. The announcement scheduler sends the emails.
+func (h *Handler) SetEmailEnabled(enabled bool) {
+	h.emailEnabled = enabled
+}
+
+// publish sends the announcement.published event for the announcement
+// with the given ID.
+func (h *Handler) publish(ctx context.Context, id primitive.ObjectID) {
+	if h.webhooks == nil {
+		return
+	}
+	ann, err := h.announcementStore.GetByID(ctx, id)
+	if err != nil {
+		h.logger.Warn("announcement webhook not sent", zap.String("id", id.Hex()), zap.Error(err))
+		return
+	}
+	h.webhooks.Publish(webhooks.EventAnnouncementPublished, webhooks.AnnouncementData(ann))
+}
+
 // announcementRow represents an announcement in the list.
 type announcementRow struct {
 	ID          string
 	Title       string
 	Type        announcement.Type
 	Active      bool
+	Status      string // live, scheduled, expired, or inactive
 	Dismissible bool
+	RequireAck  bool
 	StartsAt    string
 	EndsAt      string
 }
 
+// scheduleStatus describes whether ann is showing at now, for the list.
+func scheduleStatus(ann *announcement.Announcement, now time.Time) string {
+	switch {
+	case ann.Live(now):
+		return "live"
+	case ann.Expired(now):
+		return "expired"
+	case ann.Active:
+		return "scheduled"
+	default:
+		return "inactive"
+	}
+}
+
+// formatTime formats an optional time for display, or "" if it is nil.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("Jan 2, 2006 3:04 PM")
+}
+
+// scheduleFormat is the layout of datetime-local form inputs.
+const scheduleFormat = "2006-01-02T15:04"
+
+// parseSchedule reads the optional publish and expire times from the form.
+// A blank field is nil. It returns a message for the user if the expire
+// time isn't after the publish time.
+func parseSchedule(r *http.Request) (startsAt, endsAt *time.Time, errMsg string) {
+	if v := r.FormValue("starts_at"); v != "" {
+		if t, err := time.ParseInLocation(scheduleFormat, v, time.Local); err == nil {
+			startsAt = &t
+		}
+	}
+	if v := r.FormValue("ends_at"); v != "" {
+		if t, err := time.ParseInLocation(scheduleFormat, v, time.Local); err == nil {
+			endsAt = &t
+		}
+	}
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return startsAt, endsAt, "Expire time must be after the publish time"
+	}
+	return startsAt, endsAt, ""
+}
+
 // ListVM is the view model for the announcements list.
 type ListVM struct {
 	viewdata.BaseVM
@@ -69,6 +156,7 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Get("/{id}", h.show)
 	r.Get("/{id}/manage_modal", h.manageModal)
 	r.Get("/{id}/edit", h.showEdit)
+	r.Get("/{id}/acknowledgements", h.acknowledgements)
 	r.Post("/{id}", h.update)
 	r.Post("/{id}/toggle", h.toggle)
 	r.Post("/{id}/delete", h.delete)
@@ -85,6 +173,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	now := time.Now()
 	rows := make([]announcementRow, 0, len(announcements))
 	for _, ann := range announcements {
 		startsAt := ""
@@ -100,7 +189,9 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 			Title:       ann.Title,
 			Type:        ann.Type,
 			Active:      ann.Active,
+			Status:      scheduleStatus(&ann, now),
 			Dismissible: ann.Dismissible,
+			RequireAck:  ann.RequireAck,
 			StartsAt:    startsAt,
 			EndsAt:      endsAt,
 		})
@@ -129,23 +220,28 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 // NewVM is the view model for creating a new announcement.
 type NewVM struct {
 	viewdata.BaseVM
-	AnnTitle    string // renamed to avoid conflict with BaseVM.Title
-	Content     string
-	Type        string
-	Dismissible bool
-	Active      bool
-	StartsAt    string
-	EndsAt      string
-	Error       string
+	AnnTitle     string // renamed to avoid conflict with BaseVM.Title
+	Content      string
+	Type         string
+	Dismissible  bool
+	RequireAck   bool
+	EmailUsers   bool
+	EmailEnabled bool   // whether the email option is offered
+	EmailedAt    string // when users were emailed, if they have been
+	Active       bool
+	StartsAt     string
+	EndsAt       string
+	Error        string
 }
 
 // showNew displays the new announcement form.
 func (h *Handler) showNew(w http.ResponseWriter, r *http.Request) {
 	vm := NewVM{
-		BaseVM:      viewdata.New(r),
-		Type:        "info",
-		Dismissible: true,
-		Active:      true,
+		BaseVM:       viewdata.New(r),
+		Type:         "info",
+		Dismissible:  true,
+		EmailEnabled: h.emailEnabled,
+		Active:       true,
 	}
 	vm.BaseVM.Title = "New Announcement"
 	vm.BackURL = "/announcements"
@@ -165,17 +261,29 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 	content := strings.TrimSpace(r.FormValue("content"))
 	annType := announcement.Type(r.FormValue("type"))
 	dismissible := r.FormValue("dismissible") == "on"
+	ack := requireAck(r, annType)
+	emailUsers := h.emailEnabled && r.FormValue("email_users") == "on"
 	active := r.FormValue("active") == "on"
+	startsAt, endsAt, scheduleErr := parseSchedule(r)
 
+	errMsg := scheduleErr
 	if title == "" {
+		errMsg = "Title is required"
+	}
+	if errMsg != "" {
 		vm := NewVM{
-			BaseVM:      viewdata.New(r),
-			AnnTitle:    title,
-			Content:     content,
-			Type:        string(annType),
-			Dismissible: dismissible,
-			Active:      active,
-			Error:       "Title is required",
+			BaseVM:       viewdata.New(r),
+			AnnTitle:     title,
+			Content:      content,
+			Type:         string(annType),
+			Dismissible:  dismissible,
+			RequireAck:   ack,
+			EmailUsers:   emailUsers,
+			EmailEnabled: h.emailEnabled,
+			Active:       active,
+			StartsAt:     r.FormValue("starts_at"),
+			EndsAt:       r.FormValue("ends_at"),
+			Error:        errMsg,
 		}
 		vm.BaseVM.Title = "New Announcement"
 		vm.BackURL = "/announcements"
@@ -188,37 +296,34 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 		Content:     content,
 		Type:        annType,
 		Dismissible: dismissible,
+		RequireAck:  ack,
+		EmailUsers:  emailUsers,
 		Active:      active,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
 	}
 
-	// Parse optional start/end times
-	if startsAt := r.FormValue("starts_at"); startsAt != "" {
-		if t, err := time.ParseInLocation("2006-01-02T15:04", startsAt, time.Local); err == nil {
-			input.StartsAt = &t
-		}
-	}
-	if endsAt := r.FormValue("ends_at"); endsAt != "" {
-		if t, err := time.ParseInLocation("2006-01-02T15:04", endsAt, time.Local); err == nil {
-			input.EndsAt = &t
-		}
-	}
-
-	if _, err := h.announcementStore.Create(r.Context(), input); err != nil {
+	ann, err := h.announcementStore.Create(r.Context(), input)
+	if err != nil {
 		h.errLog.Log(r, "failed to create announcement", err)
 		vm := NewVM{
-			BaseVM:      viewdata.New(r),
-			AnnTitle:    title,
-			Content:     content,
-			Type:        string(annType),
-			Dismissible: dismissible,
-			Active:      active,
-			Error:       "Failed to create announcement",
+			BaseVM:       viewdata.New(r),
+			AnnTitle:     title,
+			Content:      content,
+			Type:         string(annType),
+			Dismissible:  dismissible,
+			Active:       active,
+			EmailEnabled: h.emailEnabled,
+			Error:        "Failed to create announcement",
 		}
 		vm.BaseVM.Title = "New Announcement"
 		vm.BackURL = "/announcements"
 		templates.Render(w, r, "announcements/new", vm)
 		return
 	}
+	if ann.Live(time.Now()) {
+		h.webhooks.Publish(webhooks.EventAnnouncementPublished, webhooks.AnnouncementData(ann))
+	}
 
 	http.Redirect(w, r, "/announcements?success=created", http.StatusSeeOther)
 }
@@ -226,25 +331,30 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 // EditVM is the view model for editing an announcement.
 type EditVM struct {
 	viewdata.BaseVM
-	ID          string
-	AnnTitle    string // renamed to avoid conflict with BaseVM.Title
-	Content     string
-	Type        string
-	Dismissible bool
-	Active      bool
-	StartsAt    string
-	EndsAt      string
-	Error       string
+	ID           string
+	AnnTitle     string // renamed to avoid conflict with BaseVM.Title
+	Content      string
+	Type         string
+	Dismissible  bool
+	RequireAck   bool
+	EmailUsers   bool
+	EmailEnabled bool   // whether the email option is offered
+	EmailedAt    string // when users were emailed, if they have been
+	Active       bool
+	StartsAt     string
+	EndsAt       string
+	Error        string
 }
 
 // ManageModalVM is the view model for the manage modal.
 type ManageModalVM struct {
-	ID        string
-	Title     string
-	Type      string
-	Active    bool
-	BackURL   string
-	CSRFToken string
+	ID         string
+	Title      string
+	Type       string
+	Active     bool
+	RequireAck bool
+	BackURL    string
+	CSRFToken  string
 }
 
 // ShowVM is the view model for viewing an announcement.
@@ -255,9 +365,13 @@ type ShowVM struct {
 	Content     string
 	Type        string
 	Dismissible bool
+	RequireAck  bool
+	EmailUsers  bool
+	EmailedAt   string
 	Active      bool
 	StartsAt    string
 	EndsAt      string
+	DismissalRate
 }
 
 // show displays a single announcement.
@@ -296,10 +410,20 @@ func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 		Content:     ann.Content,
 		Type:        string(ann.Type),
 		Dismissible: ann.Dismissible,
+		RequireAck:  ann.RequireAck,
+		EmailUsers:  ann.EmailUsers,
+		EmailedAt:   formatTime(ann.EmailedAt),
 		Active:      ann.Active,
 		StartsAt:    startsAt,
 		EndsAt:      endsAt,
 	}
+	if ann.Dismissible && !ann.RequireAck {
+		if vm.DismissalRate, err = h.dismissalRate(r.Context(), objID); err != nil {
+			h.errLog.Log(r, "failed to count announcement dismissals", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
 	vm.Title = "View Announcement"
 	vm.BackURL = backURL
 
@@ -327,12 +451,13 @@ func (h *Handler) manageModal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vm := ManageModalVM{
-		ID:        id,
-		Title:     ann.Title,
-		Type:      string(ann.Type),
-		Active:    ann.Active,
-		BackURL:   backURL,
-		CSRFToken: csrf.Token(r),
+		ID:         id,
+		Title:      ann.Title,
+		Type:       string(ann.Type),
+		Active:     ann.Active,
+		RequireAck: ann.RequireAck,
+		BackURL:    backURL,
+		CSRFToken:  csrf.Token(r),
 	}
 
 	templates.RenderSnippet(w, "announcements/manage_modal", vm)
@@ -355,23 +480,27 @@ func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
 
 	startsAt := ""
 	if ann.StartsAt != nil {
-		startsAt = ann.StartsAt.Format("2006-01-02T15:04")
+		startsAt = ann.StartsAt.Format(scheduleFormat)
 	}
 	endsAt := ""
 	if ann.EndsAt != nil {
-		endsAt = ann.EndsAt.Format("2006-01-02T15:04")
+		endsAt = ann.EndsAt.Format(scheduleFormat)
 	}
 
 	vm := EditVM{
-		BaseVM:      viewdata.New(r),
-		ID:          id,
-		AnnTitle:    ann.Title,
-		Content:     ann.Content,
-		Type:        string(ann.Type),
-		Dismissible: ann.Dismissible,
-		Active:      ann.Active,
-		StartsAt:    startsAt,
-		EndsAt:      endsAt,
+		BaseVM:       viewdata.New(r),
+		ID:           id,
+		AnnTitle:     ann.Title,
+		Content:      ann.Content,
+		Type:         string(ann.Type),
+		Dismissible:  ann.Dismissible,
+		RequireAck:   ann.RequireAck,
+		EmailUsers:   ann.EmailUsers,
+		EmailEnabled: h.emailEnabled,
+		EmailedAt:    formatTime(ann.EmailedAt),
+		Active:       ann.Active,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
 	}
 	vm.Title = "Edit Announcement"
 	vm.BackURL = "/announcements"
@@ -398,60 +527,82 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	content := strings.TrimSpace(r.FormValue("content"))
 	annType := announcement.Type(r.FormValue("type"))
 	dismissible := r.FormValue("dismissible") == "on"
+	ack := requireAck(r, annType)
+	emailUsers := h.emailEnabled && r.FormValue("email_users") == "on"
 	active := r.FormValue("active") == "on"
+	startsAt, endsAt, scheduleErr := parseSchedule(r)
 
+	errMsg := scheduleErr
 	if title == "" {
+		errMsg = "Title is required"
+	}
+	if errMsg != "" {
 		vm := EditVM{
-			BaseVM:      viewdata.New(r),
-			ID:          id,
-			AnnTitle:    title,
-			Content:     content,
-			Type:        string(annType),
-			Dismissible: dismissible,
-			Active:      active,
-			Error:       "Title is required",
+			BaseVM:       viewdata.New(r),
+			ID:           id,
+			AnnTitle:     title,
+			Content:      content,
+			Type:         string(annType),
+			Dismissible:  dismissible,
+			RequireAck:   ack,
+			EmailUsers:   emailUsers,
+			EmailEnabled: h.emailEnabled,
+			Active:       active,
+			StartsAt:     r.FormValue("starts_at"),
+			EndsAt:       r.FormValue("ends_at"),
+			Error:        errMsg,
 		}
 		vm.BackURL = "/announcements"
 		templates.Render(w, r, "announcements/edit", vm)
 		return
 	}
 
+	now := time.Now()
+	pending := active && announcement.Pending(startsAt, now)
 	input := announcement.UpdateInput{
-		Title:       &title,
-		Content:     &content,
-		Type:        &annType,
-		Dismissible: &dismissible,
-		Active:      &active,
-	}
-
-	// Parse optional start/end times
-	if startsAt := r.FormValue("starts_at"); startsAt != "" {
-		if t, err := time.ParseInLocation("2006-01-02T15:04", startsAt, time.Local); err == nil {
-			input.StartsAt = &t
-		}
-	}
-	if endsAt := r.FormValue("ends_at"); endsAt != "" {
-		if t, err := time.ParseInLocation("2006-01-02T15:04", endsAt, time.Local); err == nil {
-			input.EndsAt = &t
-		}
+		Title:          &title,
+		Content:        &content,
+		Type:           &annType,
+		Dismissible:    &dismissible,
+		RequireAck:     &ack,
+		Active:         &active,
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+		ClearStartsAt:  startsAt == nil,
+		ClearEndsAt:    endsAt == nil,
+		PublishPending: &pending,
+	}
+	if h.emailEnabled {
+		input.EmailUsers = &emailUsers
+	}
+
+	before, err := h.announcementStore.GetByID(r.Context(), objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
 	}
 
 	if err := h.announcementStore.Update(r.Context(), objID, input); err != nil {
 		h.errLog.Log(r, "failed to update announcement", err)
 		vm := EditVM{
-			BaseVM:      viewdata.New(r),
-			ID:          id,
-			AnnTitle:    title,
-			Content:     content,
-			Type:        string(annType),
-			Dismissible: dismissible,
-			Active:      active,
-			Error:       "Failed to update announcement",
+			BaseVM:       viewdata.New(r),
+			ID:           id,
+			AnnTitle:     title,
+			Content:      content,
+			Type:         string(annType),
+			Dismissible:  dismissible,
+			Active:       active,
+			EmailEnabled: h.emailEnabled,
+			Error:        "Failed to update announcement",
 		}
 		vm.BackURL = "/announcements"
 		templates.Render(w, r, "announcements/edit", vm)
 		return
 	}
+	after := announcement.Announcement{Active: active, StartsAt: startsAt, EndsAt: endsAt}
+	if after.Live(now) && !before.Live(now) {
+		h.publish(r.Context(), objID)
+	}
 
 	http.Redirect(w, r, "/announcements?success=updated", http.StatusSeeOther)
 }
@@ -476,6 +627,10 @@ func (h *Handler) toggle(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/announcements?error=toggle_failed", http.StatusSeeOther)
 		return
 	}
+	ann.Active = !ann.Active
+	if ann.Live(time.Now()) {
+		h.publish(r.Context(), objID)
+	}
 
 	http.Redirect(w, r, "/announcements?success=toggled", http.StatusSeeOther)
 }
@@ -494,6 +649,12 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/announcements?error=delete_failed", http.StatusSeeOther)
 		return
 	}
+	if err := h.acks.DeleteByAnnouncement(r.Context(), objID); err != nil {
+		h.logger.Warn("failed to delete announcement acknowledgements", zap.String("id", objID.Hex()), zap.Error(err))
+	}
+	if err := h.dismissals.DeleteByAnnouncement(r.Context(), objID); err != nil {
+		h.logger.Warn("failed to delete announcement dismissals", zap.String("id", objID.Hex()), zap.Error(err))
+	}
 
 	http.Redirect(w, r, "/announcements?success=deleted", http.StatusSeeOther)
 }
@@ -516,11 +677,14 @@ type ViewVM struct {
 
 // viewAnnouncementRow represents an announcement in the user view.
 type viewAnnouncementRow struct {
-	ID          string
-	Title       string
-	Content     string
-	Type        string // info, warning, critical
-	Dismissible bool
+	ID           string
+	Title        string
+	Content      string
+	Type         string // info, warning, critical
+	Dismissible  bool
+	RequireAck   bool
+	Acknowledged bool
+	Dismissed    bool
 }
 
 // ViewRoutes returns routes for the user-facing announcements view.
@@ -530,6 +694,8 @@ func ViewRoutes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Use(sessionMgr.RequireAuth)
 
 	r.Get("/", h.viewAnnouncements)
+	r.Post("/{id}/acknowledge", h.acknowledge)
+	r.Post("/{id}/dismiss", h.dismiss)
 
 	return r
 }
@@ -543,14 +709,39 @@ func (h *Handler) viewAnnouncements(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var ackIDs, dismissIDs []primitive.ObjectID
+	for _, ann := range announcements {
+		if ann.RequireAck {
+			ackIDs = append(ackIDs, ann.ID)
+		} else if ann.Dismissible {
+			dismissIDs = append(dismissIDs, ann.ID)
+		}
+	}
+	var acked, dismissed map[primitive.ObjectID]bool
+	if user, ok := auth.CurrentUser(r); ok {
+		if acked, err = h.acks.Acknowledged(r.Context(), user.UserID(), ackIDs); err != nil {
+			h.errLog.Log(r, "failed to get announcement acknowledgements", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if dismissed, err = h.dismissals.Dismissed(r.Context(), user.UserID(), dismissIDs); err != nil {
+			h.errLog.Log(r, "failed to get announcement dismissals", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	rows := make([]viewAnnouncementRow, 0, len(announcements))
 	for _, ann := range announcements {
 		rows = append(rows, viewAnnouncementRow{
-			ID:          ann.ID.Hex(),
-			Title:       ann.Title,
-			Content:     ann.Content,
-			Type:        string(ann.Type),
-			Dismissible: ann.Dismissible,
+			ID:           ann.ID.Hex(),
+			Title:        ann.Title,
+			Content:      ann.Content,
+			Type:         string(ann.Type),
+			Dismissible:  ann.Dismissible && !ann.RequireAck,
+			RequireAck:   ann.RequireAck,
+			Acknowledged: acked[ann.ID],
+			Dismissed:    dismissed[ann.ID],
 		})
 	}
 
@@ -417,3 +417,16 @@ func TestListVM(t *testing.T) {
 		t.Errorf("len(Items) = %d, want 1", len(vm.Items))
 	}
 }
+
+func TestRequireAck(t *testing.T) {
+	form := url.Values{"require_ack": {"on"}}
+	req := httptest.NewRequest(http.MethodPost, "/announcements/new", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if !requireAck(req, announcement.TypeCritical) {
+		t.Error("requireAck() = false for a critical announcement")
+	}
+	if requireAck(req, announcement.TypeWarning) {
+		t.Error("requireAck() = true for a warning; only critical announcements can require it")
+	}
+}
@@ -0,0 +1,68 @@
+package announcements
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DismissalRate is how many active users have dismissed an announcement.
+type DismissalRate struct {
+	Dismissed    int
+	ActiveUsers  int
+	DismissedPct int
+}
+
+// dismissalRate counts an announcement's dismissals against the number of
+// active users.
+func (h *Handler) dismissalRate(ctx context.Context, id primitive.ObjectID) (DismissalRate, error) {
+	counts, err := h.dismissals.CountByAnnouncement(ctx, []primitive.ObjectID{id})
+	if err != nil {
+		return DismissalRate{}, err
+	}
+	active, err := h.users.Count(ctx, bson.M{"status": status.Active})
+	if err != nil {
+		return DismissalRate{}, err
+	}
+	rate := DismissalRate{Dismissed: counts[id], ActiveUsers: int(active)}
+	if rate.ActiveUsers > 0 {
+		rate.DismissedPct = min(rate.Dismissed*100/rate.ActiveUsers, 100)
+	}
+	return rate, nil
+}
+
+// dismiss records that the signed-in user dismissed an announcement's
+// banner, so it stays hidden on all their devices. Banners call it in the
+// background, so it responds with 204 No Content.
+func (h *Handler) dismiss(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ann, err := h.announcementStore.GetByID(r.Context(), objID)
+	if err != nil || !ann.Dismissible || ann.RequireAck {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.dismissals.Dismiss(r.Context(), objID, user.UserID(), time.Now()); err != nil {
+		h.errLog.Log(r, "failed to dismiss announcement", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
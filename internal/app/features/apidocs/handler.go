@@ -0,0 +1,100 @@
+// internal/app/features/apidocs/handler.go
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+
+	saveapifeature "github.com/dalemusser/stratasave/internal/app/features/saveapi"
+	settingsapifeature "github.com/dalemusser/stratasave/internal/app/features/settingsapi"
+	tokenapifeature "github.com/dalemusser/stratasave/internal/app/features/tokenapi"
+	"github.com/dalemusser/stratasave/internal/app/system/apiversion"
+	"github.com/dalemusser/stratasave/internal/app/system/openapi"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// specVersion is the version reported in each OpenAPI document's info block.
+const specVersion = "1.0.0"
+
+// Handler serves the OpenAPI documents and the Swagger UI page.
+type Handler struct {
+	db     *mongo.Database
+	specs  map[string][]byte // API version -> encoded document
+	logger *zap.Logger
+}
+
+// NewHandler creates a new API docs handler. The OpenAPI documents are
+// built once here since they only depend on the handler definitions.
+func NewHandler(db *mongo.Database, logger *zap.Logger) *Handler {
+	specs := make(map[string][]byte, len(apiversion.Supported))
+	for _, version := range apiversion.Supported {
+		spec, err := json.MarshalIndent(BuildSpec(version), "", "  ")
+		if err != nil {
+			// Only possible if a schema contains an unencodable value
+			logger.Error("failed to encode openapi document", zap.String("version", version), zap.Error(err))
+			continue
+		}
+		specs[version] = spec
+	}
+	return &Handler{
+		db:     db,
+		specs:  specs,
+		logger: logger,
+	}
+}
+
+// BuildSpec builds the OpenAPI document for one version of the state,
+// settings, and token APIs.
+func BuildSpec(version string) *openapi.Document {
+	doc := openapi.New(
+		"StrataSave API "+version,
+		"Save and load game state and player settings.\n\n"+
+			"Authenticate with `Authorization: Bearer <key>` using an API key, or a short-lived token from `/token`. "+
+			"Clients that cannot keep a key secret may instead sign requests with the `X-Signature` and `X-Signature-Timestamp` headers when request signing is enabled.\n\n"+
+			"The unversioned paths `/api/state/*`, `/api/settings/*`, and `/api/token`, and the legacy `/save` and `/load`, are deprecated aliases of v1.",
+		specVersion,
+	)
+	doc.Servers = []openapi.Server{{URL: "/api/" + version}}
+	doc.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "API key, or a token issued by /token",
+	}
+	doc.Security = []openapi.SecurityRequirement{{"bearerAuth": {}}}
+
+	saveapifeature.DescribeAPI(doc)
+	settingsapifeature.DescribeAPI(doc)
+	tokenapifeature.DescribeAPI(doc)
+	return doc
+}
+
+// ServeDocs renders the Swagger UI page.
+func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
+	data := DocsVM{
+		BaseVM: viewdata.NewBaseVM(r, h.db, "API Reference", "/dashboard"),
+	}
+	// Newest version first so it is selected by default
+	for i := len(apiversion.Supported) - 1; i >= 0; i-- {
+		v := apiversion.Supported[i]
+		data.Specs = append(data.Specs, SpecLink{
+			Name: v,
+			URL:  "/console/api/docs/" + v + "/openapi.json",
+		})
+	}
+	templates.Render(w, r, "apidocs/docs", data)
+}
+
+// ServeSpec serves the OpenAPI document for the version in the URL as JSON.
+func (h *Handler) ServeSpec(w http.ResponseWriter, r *http.Request) {
+	spec, ok := h.specs[chi.URLParam(r, "version")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(spec)
+}
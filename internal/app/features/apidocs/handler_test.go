@@ -0,0 +1,60 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/apiversion"
+	"github.com/dalemusser/stratasave/internal/app/system/openapi"
+)
+
+func TestBuildSpec(t *testing.T) {
+	for _, version := range apiversion.Supported {
+		t.Run(version, func(t *testing.T) {
+			doc := BuildSpec(version)
+
+			if len(doc.Servers) != 1 || doc.Servers[0].URL != "/api/"+version {
+				t.Errorf("Servers = %+v, want /api/%s", doc.Servers, version)
+			}
+			for _, path := range []string{"/state/save", "/state/load", "/settings/save", "/settings/load", "/token"} {
+				item := doc.Paths[path]
+				if item == nil || item.Post == nil {
+					t.Errorf("missing POST %s", path)
+				}
+			}
+
+			// Every $ref must point at a registered component schema
+			raw, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			var walk func(v any)
+			walk = func(v any) {
+				switch v := v.(type) {
+				case map[string]any:
+					if ref, ok := v["$ref"].(string); ok {
+						name := strings.TrimPrefix(ref, "#/components/schemas/")
+						if doc.Components.Schemas[name] == nil {
+							t.Errorf("unresolved $ref %q", ref)
+						}
+					}
+					for _, child := range v {
+						walk(child)
+					}
+				case []any:
+					for _, child := range v {
+						walk(child)
+					}
+				}
+			}
+			var generic any
+			_ = json.Unmarshal(raw, &generic)
+			walk(generic)
+
+			if doc.OpenAPI != openapi.Version {
+				t.Errorf("OpenAPI = %q, want %q", doc.OpenAPI, openapi.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package apidocs
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the API documentation feature.
+// Access is restricted to admin and developer roles.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin", "developer"))
+
+	// Swagger UI
+	r.Get("/", h.ServeDocs)
+
+	// OpenAPI document per API version, e.g. /v1/openapi.json
+	r.Get("/{version}/openapi.json", h.ServeSpec)
+
+	return r
+}
@@ -0,0 +1,16 @@
+// internal/app/features/apidocs/types.go
+package apidocs
+
+import "github.com/dalemusser/stratasave/internal/app/system/viewdata"
+
+// SpecLink identifies the OpenAPI document for one API version.
+type SpecLink struct {
+	Name string
+	URL  string
+}
+
+// DocsVM is the view model for the Swagger UI page.
+type DocsVM struct {
+	viewdata.BaseVM
+	Specs []SpecLink // Newest version first
+}
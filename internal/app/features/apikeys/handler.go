@@ -3,14 +3,19 @@ package apikeysfeature
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"github.com/dalemusser/stratasave/internal/app/system/apikeyalerts"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -23,6 +28,9 @@ type Handler struct {
 	DB     *mongo.Database
 	ErrLog *errorsfeature.ErrorLogger
 	Log    *zap.Logger
+
+	notifier *apikeyalerts.Notifier
+	webhooks *webhooks.Dispatcher
 }
 
 // NewHandler creates a new API keys handler.
@@ -34,6 +42,16 @@ func NewHandler(db *mongo.Database, errLog *errorsfeature.ErrorLogger, logger *z
 	}
 }
 
+// SetNotifier enables email notifications when keys are created or revoked.
+func (h *Handler) SetNotifier(n *apikeyalerts.Notifier) {
+	h.notifier = n
+}
+
+// SetWebhooks enables apikey.revoked webhook events.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
+}
+
 // ServeList handles GET /api-keys - list all API keys.
 func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
@@ -83,15 +101,49 @@ func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	description := strings.TrimSpace(r.FormValue("description"))
+	cidrText := r.FormValue("allowed_cidrs")
+	expiresText := strings.TrimSpace(r.FormValue("expires_at"))
 
 	// Validate
 	if name == "" {
 		base := viewdata.NewBaseVM(r, h.DB, "Create API Key", "/api-keys")
 		data := APIKeyFormVM{
-			BaseVM:      base,
-			Name:        name,
-			Description: description,
-			Error:       "Name is required",
+			BaseVM:       base,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			Error:        "Name is required",
+		}
+		templates.Render(w, r, "apikeys/new", data)
+		return
+	}
+
+	allowedCIDRs, err := parseAllowedCIDRs(cidrText)
+	if err != nil {
+		base := viewdata.NewBaseVM(r, h.DB, "Create API Key", "/api-keys")
+		data := APIKeyFormVM{
+			BaseVM:       base,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			Error:        "Allowed IPs: " + err.Error(),
+		}
+		templates.Render(w, r, "apikeys/new", data)
+		return
+	}
+
+	expiresAt, err := parseExpiresAt(expiresText)
+	if err != nil {
+		base := viewdata.NewBaseVM(r, h.DB, "Create API Key", "/api-keys")
+		data := APIKeyFormVM{
+			BaseVM:       base,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			Error:        "Expires: " + err.Error(),
 		}
 		templates.Render(w, r, "apikeys/new", data)
 		return
@@ -123,19 +175,23 @@ func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 
 	store := apikeystore.New(h.DB)
 	result, err := store.Create(ctx, apikeystore.CreateInput{
-		Name:        name,
-		Description: description,
-		CreatedBy:   user.UserID(),
-		Scopes:      scopes,
+		Name:         name,
+		Description:  description,
+		CreatedBy:    user.UserID(),
+		Scopes:       scopes,
+		AllowedCIDRs: allowedCIDRs,
+		ExpiresAt:    expiresAt,
 	})
 	if err != nil {
 		if err == apikeystore.ErrDuplicateName {
 			base := viewdata.NewBaseVM(r, h.DB, "Create API Key", "/api-keys")
 			data := APIKeyFormVM{
-				BaseVM:      base,
-				Name:        name,
-				Description: description,
-				Error:       "An API key with this name already exists",
+				BaseVM:       base,
+				Name:         name,
+				Description:  description,
+				AllowedCIDRs: cidrText,
+				ExpiresAt:    expiresText,
+				Error:        "An API key with this name already exists",
 			}
 			templates.Render(w, r, "apikeys/new", data)
 			return
@@ -150,6 +206,8 @@ func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 		zap.String("name", name),
 		zap.String("created_by", user.ID))
 
+	h.notifier.KeyCreated(result.Key, user.Name)
+
 	// Show the key once
 	base := viewdata.NewBaseVM(r, h.DB, "API Key Created", "/api-keys")
 	data := APIKeyCreatedVM{
@@ -218,12 +276,14 @@ func (h *Handler) ServeEdit(w http.ResponseWriter, r *http.Request) {
 
 	base := viewdata.NewBaseVM(r, h.DB, "Edit API Key", "/api-keys/"+idStr)
 	data := APIKeyFormVM{
-		BaseVM:      base,
-		ID:          key.ID.Hex(),
-		Name:        key.Name,
-		Description: key.Description,
-		IsEdit:      true,
-		IsActive:    key.Status == apikeystore.StatusActive,
+		BaseVM:       base,
+		ID:           key.ID.Hex(),
+		Name:         key.Name,
+		Description:  key.Description,
+		AllowedCIDRs: strings.Join(key.AllowedCIDRs, "\n"),
+		ExpiresAt:    formatExpiresAt(key.ExpiresAt),
+		IsEdit:       true,
+		IsActive:     key.Status == apikeystore.StatusActive,
 	}
 	templates.Render(w, r, "apikeys/edit", data)
 }
@@ -247,6 +307,8 @@ func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	description := strings.TrimSpace(r.FormValue("description"))
+	cidrText := r.FormValue("allowed_cidrs")
+	expiresText := strings.TrimSpace(r.FormValue("expires_at"))
 
 	store := apikeystore.New(h.DB)
 
@@ -266,22 +328,71 @@ func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	if name == "" {
 		base := viewdata.NewBaseVM(r, h.DB, "Edit API Key", "/api-keys/"+idStr)
 		data := APIKeyFormVM{
-			BaseVM:      base,
-			ID:          idStr,
-			Name:        name,
-			Description: description,
-			IsEdit:      true,
-			IsActive:    isActive,
-			Error:       "Name is required",
+			BaseVM:       base,
+			ID:           idStr,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			IsEdit:       true,
+			IsActive:     isActive,
+			Error:        "Name is required",
 		}
 		templates.Render(w, r, "apikeys/edit", data)
 		return
 	}
 
-	err = store.Update(ctx, id, apikeystore.UpdateInput{
-		Name:        &name,
-		Description: &description,
-	})
+	allowedCIDRs, err := parseAllowedCIDRs(cidrText)
+	if err != nil {
+		base := viewdata.NewBaseVM(r, h.DB, "Edit API Key", "/api-keys/"+idStr)
+		data := APIKeyFormVM{
+			BaseVM:       base,
+			ID:           idStr,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			IsEdit:       true,
+			IsActive:     isActive,
+			Error:        "Allowed IPs: " + err.Error(),
+		}
+		templates.Render(w, r, "apikeys/edit", data)
+		return
+	}
+
+	expiresAt, err := parseExpiresAt(expiresText)
+	if err != nil {
+		base := viewdata.NewBaseVM(r, h.DB, "Edit API Key", "/api-keys/"+idStr)
+		data := APIKeyFormVM{
+			BaseVM:       base,
+			ID:           idStr,
+			Name:         name,
+			Description:  description,
+			AllowedCIDRs: cidrText,
+			ExpiresAt:    expiresText,
+			IsEdit:       true,
+			IsActive:     isActive,
+			Error:        "Expires: " + err.Error(),
+		}
+		templates.Render(w, r, "apikeys/edit", data)
+		return
+	}
+
+	input := apikeystore.UpdateInput{
+		Name:         &name,
+		Description:  &description,
+		AllowedCIDRs: &allowedCIDRs,
+	}
+	// Only touch the expiry when it changed, so an unchanged form doesn't
+	// reset the expiry warning
+	if formatExpiresAt(expiresAt) != formatExpiresAt(key.ExpiresAt) {
+		if expiresAt == nil {
+			expiresAt = &time.Time{} // zero clears the expiry
+		}
+		input.ExpiresAt = expiresAt
+	}
+
+	err = store.Update(ctx, id, input)
 	if err != nil {
 		if err == apikeystore.ErrNotFound {
 			http.Error(w, "Not Found", http.StatusNotFound)
@@ -290,13 +401,15 @@ func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 		if err == apikeystore.ErrDuplicateName {
 			base := viewdata.NewBaseVM(r, h.DB, "Edit API Key", "/api-keys/"+idStr)
 			data := APIKeyFormVM{
-				BaseVM:      base,
-				ID:          idStr,
-				Name:        name,
-				Description: description,
-				IsEdit:      true,
-				IsActive:    isActive,
-				Error:       "An API key with this name already exists",
+				BaseVM:       base,
+				ID:           idStr,
+				Name:         name,
+				Description:  description,
+				AllowedCIDRs: cidrText,
+				ExpiresAt:    expiresText,
+				IsEdit:       true,
+				IsActive:     isActive,
+				Error:        "An API key with this name already exists",
 			}
 			templates.Render(w, r, "apikeys/edit", data)
 			return
@@ -347,6 +460,11 @@ func (h *Handler) HandleRevoke(w http.ResponseWriter, r *http.Request) {
 		zap.String("key_id", idStr),
 		zap.String("revoked_by", user.ID))
 
+	if key, err := store.GetByID(ctx, id); err == nil {
+		h.notifier.KeyRevoked(*key, user.Name)
+		h.webhooks.Publish(webhooks.EventAPIKeyRevoked, webhooks.APIKeyData(key))
+	}
+
 	w.Header().Set("HX-Redirect", "/api-keys")
 	w.WriteHeader(http.StatusOK)
 }
@@ -420,19 +538,60 @@ func (h *Handler) ServeManageModal(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "apikeys/manage_modal", data)
 }
 
+// parseAllowedCIDRs parses the allowlist textarea. Entries may be separated
+// by newlines, commas, or spaces; bare IPs are converted to single-host CIDRs.
+func parseAllowedCIDRs(text string) ([]string, error) {
+	entries := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ' ' || r == '\t'
+	})
+	cidrs, err := network.ParseCIDRList(entries)
+	if err != nil {
+		return nil, err
+	}
+	if cidrs == nil {
+		cidrs = []string{}
+	}
+	return cidrs, nil
+}
+
+// expiresAtLayout is the format of the expiry date form field (<input type="date">).
+const expiresAtLayout = "2006-01-02"
+
+// parseExpiresAt parses the optional expiry date field. Keys expire at the
+// start of the given day (UTC). Returns nil if the field is empty.
+func parseExpiresAt(text string) (*time.Time, error) {
+	if text == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(expiresAtLayout, text)
+	if err != nil {
+		return nil, errors.New("invalid date")
+	}
+	return &t, nil
+}
+
+// formatExpiresAt formats an expiry for the date form field.
+func formatExpiresAt(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(expiresAtLayout)
+}
+
 // toAPIKeyVM converts a store APIKey to a view model.
 func toAPIKeyVM(k apikeystore.APIKey) APIKeyVM {
 	vm := APIKeyVM{
-		ID:          k.ID.Hex(),
-		KeyPrefix:   k.KeyPrefix,
-		Name:        k.Name,
-		Description: k.Description,
-		CreatedBy:   k.CreatedBy.Hex(),
-		Status:      k.Status,
-		UsageCount:  k.UsageCount,
-		CreatedAt:   k.CreatedAt.Format("2006-01-02 15:04"),
-		UpdatedAt:   k.UpdatedAt.Format("2006-01-02 15:04"),
-		IsActive:    k.Status == apikeystore.StatusActive,
+		ID:           k.ID.Hex(),
+		KeyPrefix:    k.KeyPrefix,
+		Name:         k.Name,
+		Description:  k.Description,
+		CreatedBy:    k.CreatedBy.Hex(),
+		Status:       k.Status,
+		AllowedCIDRs: k.AllowedCIDRs,
+		UsageCount:   k.UsageCount,
+		CreatedAt:    k.CreatedAt.Format("2006-01-02 15:04"),
+		UpdatedAt:    k.UpdatedAt.Format("2006-01-02 15:04"),
+		IsActive:     k.Status == apikeystore.StatusActive,
 	}
 
 	if k.LastUsedAt != nil {
@@ -441,6 +600,10 @@ func toAPIKeyVM(k apikeystore.APIKey) APIKeyVM {
 	if k.RevokedAt != nil {
 		vm.RevokedAt = k.RevokedAt.Format("2006-01-02 15:04")
 	}
+	if k.ExpiresAt != nil {
+		vm.ExpiresAt = k.ExpiresAt.UTC().Format(expiresAtLayout)
+		vm.IsExpired = k.IsExpired()
+	}
 
 	// Convert scopes
 	for _, s := range k.Scopes {
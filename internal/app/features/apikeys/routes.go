@@ -7,20 +7,21 @@ import (
 )
 
 // Routes returns the router for the API keys feature.
-// Access is restricted to admin role only.
+// Access is restricted to admin role only. Issuing, revoking, and deleting
+// keys need a recent identity confirmation.
 func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 	r := chi.NewRouter()
 	r.Use(sm.RequireRole("admin"))
 
 	r.Get("/", h.ServeList)
-	r.Get("/new", h.ServeNew)
-	r.Post("/", h.HandleCreate)
+	r.With(sm.RequireRecentAuth).Get("/new", h.ServeNew)
+	r.With(sm.RequireRecentAuth).Post("/", h.HandleCreate)
 	r.Get("/{id}", h.ServeDetail)
 	r.Get("/{id}/edit", h.ServeEdit)
 	r.Get("/{id}/manage_modal", h.ServeManageModal)
 	r.Post("/{id}/edit", h.HandleUpdate)
-	r.Post("/{id}/revoke", h.HandleRevoke)
-	r.Post("/{id}/delete", h.HandleDelete)
+	r.With(sm.RequireRecentAuth).Post("/{id}/revoke", h.HandleRevoke)
+	r.With(sm.RequireRecentAuth).Post("/{id}/delete", h.HandleDelete)
 
 	return r
 }
@@ -11,19 +11,22 @@ type ScopeVM struct {
 
 // APIKeyVM is the view model for a single API key.
 type APIKeyVM struct {
-	ID          string
-	KeyPrefix   string
-	Name        string
-	Description string
-	CreatedBy   string
-	Status      string
-	Scopes      []ScopeVM
-	LastUsedAt  string
-	UsageCount  int64
-	CreatedAt   string
-	UpdatedAt   string
-	RevokedAt   string
-	IsActive    bool
+	ID           string
+	KeyPrefix    string
+	Name         string
+	Description  string
+	CreatedBy    string
+	Status       string
+	Scopes       []ScopeVM
+	AllowedCIDRs []string
+	LastUsedAt   string
+	UsageCount   int64
+	CreatedAt    string
+	UpdatedAt    string
+	RevokedAt    string
+	ExpiresAt    string // YYYY-MM-DD (UTC), empty = never
+	IsActive     bool
+	IsExpired    bool
 }
 
 // APIKeyListVM is the view model for the API keys list page.
@@ -36,13 +39,15 @@ type APIKeyListVM struct {
 // APIKeyFormVM is the view model for API key create/edit forms.
 type APIKeyFormVM struct {
 	viewdata.BaseVM
-	ID          string
-	Name        string
-	Description string
-	Scopes      []ScopeVM
-	IsEdit      bool
-	IsActive    bool
-	Error       string
+	ID           string
+	Name         string
+	Description  string
+	Scopes       []ScopeVM
+	AllowedCIDRs string // One CIDR or IP per line
+	ExpiresAt    string // YYYY-MM-DD, empty = never
+	IsEdit       bool
+	IsActive     bool
+	Error        string
 }
 
 // APIKeyCreatedVM is the view model shown after creating an API key.
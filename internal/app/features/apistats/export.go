@@ -0,0 +1,146 @@
+package apistats
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"go.uber.org/zap"
+)
+
+// exportRow is one stats bucket for CSV/JSON export.
+type exportRow struct {
+	Bucket         time.Time `json:"bucket"`
+	BucketDuration string    `json:"bucket_duration"`
+	StatType       string    `json:"stat_type"`
+	Requests       int64     `json:"requests"`
+	Errors         int64     `json:"errors"`
+	ErrorRate      float64   `json:"error_rate"` // Percentage
+	AvgMs          float64   `json:"avg_ms"`
+	MinMs          int64     `json:"min_ms"`
+	MaxMs          int64     `json:"max_ms"`
+	P50Ms          float64   `json:"p50_ms"`
+	P95Ms          float64   `json:"p95_ms"`
+	P99Ms          float64   `json:"p99_ms"`
+}
+
+// ServeExportCSV handles GET /api-stats/export.csv - the buckets for the
+// page's time range, API filter, and resolution as CSV.
+func (h *Handler) ServeExportCSV(w http.ResponseWriter, r *http.Request) {
+	rows, filename, ok := h.exportRows(w, r)
+	if !ok {
+		return
+	}
+
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename+".csv")
+
+	// UTF-8 BOM for Excel
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		h.logger.Error("CSV write failed (BOM)", zap.Error(err))
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	defer cw.Flush()
+
+	if err := cw.Write([]string{"bucket", "bucket_duration", "stat_type", "requests", "errors", "error_rate", "avg_ms", "min_ms", "max_ms", "p50_ms", "p95_ms", "p99_ms"}); err != nil {
+		h.logger.Error("CSV write failed (header)", zap.Error(err))
+		return
+	}
+	for _, row := range rows {
+		if err := cw.Write([]string{
+			row.Bucket.Format(time.RFC3339),
+			row.BucketDuration,
+			row.StatType,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatInt(row.Errors, 10),
+			formatFloat(row.ErrorRate),
+			formatFloat(row.AvgMs),
+			strconv.FormatInt(row.MinMs, 10),
+			strconv.FormatInt(row.MaxMs, 10),
+			formatFloat(row.P50Ms),
+			formatFloat(row.P95Ms),
+			formatFloat(row.P99Ms),
+		}); err != nil {
+			h.logger.Error("CSV write failed (row)", zap.Error(err))
+			return
+		}
+	}
+}
+
+// ServeExportJSON handles GET /api-stats/export.json - the buckets for the
+// page's time range, API filter, and resolution as a JSON array.
+func (h *Handler) ServeExportJSON(w http.ResponseWriter, r *http.Request) {
+	rows, filename, ok := h.exportRows(w, r)
+	if !ok {
+		return
+	}
+
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename+".json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		h.logger.Error("JSON encode failed", zap.Error(err))
+	}
+}
+
+// exportRows loads the buckets to export for the request's filters. It
+// reports an error to the client and returns false if they can't be
+// loaded.
+func (h *Handler) exportRows(w http.ResponseWriter, r *http.Request) ([]exportRow, string, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Long())
+	defer cancel()
+
+	timeRange, startTime, endTime := parseTimeRange(r)
+	apiFilter := parseAPIFilter(r)
+	bucketFilter := r.URL.Query().Get("bucket")
+
+	buckets, err := h.store.GetRangeAllTypes(ctx, startTime, endTime, bucketFilter)
+	if err != nil {
+		h.errLog.Log(r, "failed to load API stats for export", err)
+		http.Error(w, "Failed to load stats", http.StatusInternalServerError)
+		return nil, "", false
+	}
+
+	rows := []exportRow{}
+	for _, b := range buckets {
+		if !inAPIFilter(b.StatType, apiFilter) {
+			continue
+		}
+		rows = append(rows, exportRow{
+			Bucket:         b.Bucket,
+			BucketDuration: b.BucketDuration,
+			StatType:       string(b.StatType),
+			Requests:       b.Requests,
+			Errors:         b.Errors,
+			ErrorRate:      b.ErrorRate(),
+			AvgMs:          b.AvgMs(),
+			MinMs:          b.MinMs,
+			MaxMs:          b.MaxMs,
+			P50Ms:          b.PercentileMs(0.50),
+			P95Ms:          b.PercentileMs(0.95),
+			P99Ms:          b.PercentileMs(0.99),
+		})
+	}
+
+	filename := "api_stats"
+	if apiFilter != "" {
+		filename += "_" + apiFilter
+	}
+	filename += fmt.Sprintf("_%s_%s", timeRange, endTime.Format("20060102"))
+
+	return rows, filename, true
+}
+
+// formatFloat formats a float for CSV with up to two decimal places.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -43,38 +43,9 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
 	defer cancel()
 
-	// Parse query params
-	timeRange := r.URL.Query().Get("range")
-	if timeRange == "" {
-		timeRange = "24h"
-	}
-
+	timeRange, startTime, endTime := parseTimeRange(r)
 	bucketFilter := r.URL.Query().Get("bucket")
-
-	// API filter: "state", "settings", or "" (all)
-	apiFilter := r.URL.Query().Get("api")
-	if apiFilter != "" && apiFilter != "state" && apiFilter != "settings" {
-		apiFilter = "" // Invalid filter, reset to all
-	}
-
-	// Calculate time range
-	endTime := time.Now().UTC()
-	var startTime time.Time
-	switch timeRange {
-	case "1h":
-		startTime = endTime.Add(-1 * time.Hour)
-	case "6h":
-		startTime = endTime.Add(-6 * time.Hour)
-	case "24h":
-		startTime = endTime.Add(-24 * time.Hour)
-	case "7d":
-		startTime = endTime.Add(-7 * 24 * time.Hour)
-	case "30d":
-		startTime = endTime.Add(-30 * 24 * time.Hour)
-	default:
-		startTime = endTime.Add(-24 * time.Hour)
-		timeRange = "24h"
-	}
+	apiFilter := parseAPIFilter(r)
 
 	// Get current bucket duration
 	currentBucket := h.recorder.GetBucketDuration().String()
@@ -87,18 +58,15 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		h.logger.Warn("failed to get API stats summary", zap.Error(err))
 	}
+	latency, err := h.store.LatencyByType(ctx, startTime, endTime)
+	if err != nil {
+		h.logger.Warn("failed to get API latency percentiles", zap.Error(err))
+	}
 
 	// Convert to view models and filter based on apiFilter
 	var summaryVMs []SummaryVM
 	for _, s := range summaries {
-		// Filter based on API type
-		isStateType := s.StatType == apistatsstore.StatTypeSaveState || s.StatType == apistatsstore.StatTypeLoadState
-		isSettingsType := s.StatType == apistatsstore.StatTypeSaveSettings || s.StatType == apistatsstore.StatTypeLoadSettings
-
-		if apiFilter == "state" && !isStateType {
-			continue
-		}
-		if apiFilter == "settings" && !isSettingsType {
+		if !inAPIFilter(s.StatType, apiFilter) {
 			continue
 		}
 
@@ -115,6 +83,14 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 		if s.TotalRequests == 0 {
 			vm.ErrorRate = 0
 		}
+		if hist := latency[s.StatType]; hist.Count() > 0 {
+			// Bins are coarse at the top, so keep estimates within the observed max
+			clamp := func(p float64) float64 { return min(p, float64(s.MaxMs)) }
+			vm.HasPercentiles = true
+			vm.P50Ms = clamp(hist.Percentile(0.50))
+			vm.P95Ms = clamp(hist.Percentile(0.95))
+			vm.P99Ms = clamp(hist.Percentile(0.99))
+		}
 		summaryVMs = append(summaryVMs, vm)
 	}
 
@@ -166,6 +142,59 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "apistats/list", data)
 }
 
+// parseTimeRange returns the requested time range ("1h", "6h", "24h",
+// "7d", "30d", "90d", or "365d"; default "24h") and the times it covers,
+// ending now.
+func parseTimeRange(r *http.Request) (string, time.Time, time.Time) {
+	timeRange := r.URL.Query().Get("range")
+
+	endTime := time.Now().UTC()
+	var startTime time.Time
+	switch timeRange {
+	case "1h":
+		startTime = endTime.Add(-1 * time.Hour)
+	case "6h":
+		startTime = endTime.Add(-6 * time.Hour)
+	case "24h":
+		startTime = endTime.Add(-24 * time.Hour)
+	case "7d":
+		startTime = endTime.Add(-7 * 24 * time.Hour)
+	case "30d":
+		startTime = endTime.Add(-30 * 24 * time.Hour)
+	case "90d":
+		startTime = endTime.Add(-90 * 24 * time.Hour)
+	case "365d":
+		startTime = endTime.Add(-365 * 24 * time.Hour)
+	default:
+		startTime = endTime.Add(-24 * time.Hour)
+		timeRange = "24h"
+	}
+	return timeRange, startTime, endTime
+}
+
+// parseAPIFilter returns the requested API filter: "state", "settings",
+// or "" for all.
+func parseAPIFilter(r *http.Request) string {
+	apiFilter := r.URL.Query().Get("api")
+	if apiFilter != "state" && apiFilter != "settings" {
+		return "" // Invalid filter, reset to all
+	}
+	return apiFilter
+}
+
+// inAPIFilter reports whether a stat type belongs to the API selected by
+// apiFilter.
+func inAPIFilter(st apistatsstore.StatType, apiFilter string) bool {
+	switch apiFilter {
+	case "state":
+		return st == apistatsstore.StatTypeSaveState || st == apistatsstore.StatTypeLoadState
+	case "settings":
+		return st == apistatsstore.StatTypeSaveSettings || st == apistatsstore.StatTypeLoadSettings
+	default:
+		return true
+	}
+}
+
 // getTimeSeriesData retrieves time series data for a stat type.
 func (h *Handler) getTimeSeriesData(ctx context.Context, statType apistatsstore.StatType, startTime, endTime time.Time, bucketFilter string) []DataPointVM {
 	buckets, err := h.store.GetRange(ctx, statType, startTime, endTime, bucketFilter)
@@ -183,9 +212,13 @@ func (h *Handler) getTimeSeriesData(ctx context.Context, statType apistatsstore.
 			Timestamp: b.Bucket,
 			Requests:  b.Requests,
 			Errors:    b.Errors,
+			ErrorRate: b.ErrorRate(),
 			AvgMs:     b.AvgMs(),
 			MinMs:     b.MinMs,
 			MaxMs:     b.MaxMs,
+			P50Ms:     b.PercentileMs(0.50),
+			P95Ms:     b.PercentileMs(0.95),
+			P99Ms:     b.PercentileMs(0.99),
 		}
 	}
 	return data
@@ -228,33 +261,10 @@ func (h *Handler) ServeChartData(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
 	defer cancel()
 
-	// Parse query params
-	timeRange := r.URL.Query().Get("range")
-	if timeRange == "" {
-		timeRange = "24h"
-	}
-
+	_, startTime, endTime := parseTimeRange(r)
 	statType := r.URL.Query().Get("type")
 	bucketFilter := r.URL.Query().Get("bucket")
 
-	// Calculate time range
-	endTime := time.Now().UTC()
-	var startTime time.Time
-	switch timeRange {
-	case "1h":
-		startTime = endTime.Add(-1 * time.Hour)
-	case "6h":
-		startTime = endTime.Add(-6 * time.Hour)
-	case "24h":
-		startTime = endTime.Add(-24 * time.Hour)
-	case "7d":
-		startTime = endTime.Add(-7 * 24 * time.Hour)
-	case "30d":
-		startTime = endTime.Add(-30 * 24 * time.Hour)
-	default:
-		startTime = endTime.Add(-24 * time.Hour)
-	}
-
 	var data []DataPointVM
 	if statType != "" {
 		data = h.getTimeSeriesData(ctx, apistatsstore.StatType(statType), startTime, endTime, bucketFilter)
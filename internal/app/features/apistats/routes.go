@@ -22,6 +22,10 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	// Chart data API - viewable by admin and developer
 	r.Get("/chart-data", h.ServeChartData)
 
+	// Exports - viewable by admin and developer
+	r.Get("/export.csv", h.ServeExportCSV)
+	r.Get("/export.json", h.ServeExportJSON)
+
 	// Admin-only operations
 	r.Group(func(r chi.Router) {
 		r.Use(sessionMgr.RequireRole("admin"))
@@ -23,7 +23,7 @@ type ListVM struct {
 	// Time range
 	StartTime time.Time
 	EndTime   time.Time
-	TimeRange string // "1h", "24h", "7d", "30d"
+	TimeRange string // "1h", "24h", "7d", "30d", "90d", "365d"
 
 	// API filter: "", "state", or "settings"
 	APIFilter string
@@ -54,6 +54,12 @@ type SummaryVM struct {
 	AvgMs         float64
 	MinMs         int64
 	MaxMs         int64
+
+	// Response time percentiles, when the range has latency histograms
+	HasPercentiles bool
+	P50Ms          float64
+	P95Ms          float64
+	P99Ms          float64
 }
 
 // DataPointVM represents a single data point for charting.
@@ -61,9 +67,13 @@ type DataPointVM struct {
 	Timestamp time.Time
 	Requests  int64
 	Errors    int64
+	ErrorRate float64 // Percentage of requests that failed
 	AvgMs     float64
 	MinMs     int64
 	MaxMs     int64
+	P50Ms     float64
+	P95Ms     float64
+	P99Ms     float64
 }
 
 // BucketVM represents a bucket option for the UI.
@@ -102,6 +112,8 @@ func TimeRangeOptions() []struct {
 		{Value: "24h", Label: "Last 24 hours"},
 		{Value: "7d", Label: "Last 7 days"},
 		{Value: "30d", Label: "Last 30 days"},
+		{Value: "90d", Label: "Last 90 days"},
+		{Value: "365d", Label: "Last year"},
 	}
 }
 
@@ -13,7 +13,9 @@ import (
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	savedfilterstore "github.com/dalemusser/stratasave/internal/app/store/savedfilters"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/timezones"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
@@ -28,10 +30,12 @@ const pageSize = 50
 
 // Handler provides audit log handlers.
 type Handler struct {
-	auditStore *audit.Store
-	userStore  *userstore.Store
-	errLog     *errorsfeature.ErrorLogger
-	logger     *zap.Logger
+	auditStore   *audit.Store
+	userStore    *userstore.Store
+	savedFilters *savedfilterstore.Store
+	auditLogger  *auditlog.Logger
+	errLog       *errorsfeature.ErrorLogger
+	logger       *zap.Logger
 }
 
 // NewHandler creates a new audit log Handler.
@@ -41,23 +45,32 @@ func NewHandler(
 	logger *zap.Logger,
 ) *Handler {
 	return &Handler{
-		auditStore: audit.New(db),
-		userStore:  userstore.New(db),
-		errLog:     errLog,
-		logger:     logger,
+		auditStore:   audit.New(db),
+		userStore:    userstore.New(db),
+		savedFilters: savedfilterstore.New(db),
+		errLog:       errLog,
+		logger:       logger,
 	}
 }
 
+// SetAuditLogger records audit log exports in the audit log itself.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
 // listItem represents a single audit event row for display.
 type listItem struct {
-	ID        string
-	Timestamp time.Time
-	Category  string
-	EventType string
-	ActorName string // Resolved from ActorID
-	IP        string
-	Success   bool
-	Details   map[string]string
+	ID          string
+	Timestamp   time.Time
+	Category    string
+	EventType   string
+	ActorName   string          // Resolved from ActorID
+	ActorAvatar viewdata.Avatar // The actor's profile photo
+	IP          string
+	RequestID   string // Links to the request's ledger entry
+	Success     bool
+	Details     map[string]string
+	Changes     []audit.Change // Fields an admin edit changed, shown expandable
 }
 
 // listData is the view model for the audit log list page.
@@ -76,6 +89,10 @@ type listData struct {
 	// Filter options
 	Categories []categoryOption
 	EventTypes []string
+	Patterns   []string // Wildcard event types, e.g. "login_failed_*"
+
+	// The current admin's saved searches
+	SavedSearches []searchLink
 
 	// Timezone selector
 	TimezoneGroups []timezones.ZoneGroup
@@ -104,6 +121,7 @@ func allCategories() []categoryOption {
 	return []categoryOption{
 		{Value: audit.CategoryAuth, Label: "Authentication"},
 		{Value: audit.CategoryAdmin, Label: "Administration"},
+		{Value: audit.CategorySecurity, Label: "Security Alerts"},
 	}
 }
 
@@ -115,12 +133,23 @@ func eventTypesForCategory(category string) []string {
 		audit.EventLoginFailedUserNotFound,
 		audit.EventLoginFailedWrongPassword,
 		audit.EventLoginFailedUserDisabled,
+		audit.EventLoginRateLimited,
+		audit.EventLoginLockedOut,
 		audit.EventLogout,
 		audit.EventPasswordChanged,
+		audit.EventPasswordExpired,
 		audit.EventVerificationCodeSent,
 		audit.EventVerificationCodeResent,
 		audit.EventVerificationCodeFailed,
 		audit.EventMagicLinkUsed,
+		audit.EventUserRegistered,
+		audit.EventCaptchaFailed,
+		audit.EventNewDeviceLogin,
+		audit.EventReauthSuccess,
+		audit.EventReauthFailed,
+		audit.EventBackupCodesGenerated,
+		audit.EventBackupCodeUsed,
+		audit.EventBackupCodeFailed,
 	}
 
 	adminEvents := []string{
@@ -131,6 +160,21 @@ func eventTypesForCategory(category string) []string {
 		audit.EventUserDeleted,
 		audit.EventSettingsUpdated,
 		audit.EventPageUpdated,
+		audit.EventImpersonationStarted,
+		audit.EventImpersonationEnded,
+		audit.EventRegistrationApproved,
+		audit.EventRegistrationRejected,
+		audit.EventRateLimitCleared,
+		audit.EventIPUnblocked,
+		audit.EventAuditLogExported,
+	}
+
+	securityEvents := []string{
+		audit.EventSuspiciousFailedLogins,
+		audit.EventImpossibleTravel,
+		audit.EventIPBlocked,
+		audit.EventLoginBlockedIP,
+		audit.EventAPIKeyErrorSpike,
 	}
 
 	switch category {
@@ -138,56 +182,74 @@ func eventTypesForCategory(category string) []string {
 		return authEvents
 	case audit.CategoryAdmin:
 		return adminEvents
+	case audit.CategorySecurity:
+		return securityEvents
 	case "":
 		// Return all event types when no category selected
-		all := make([]string, 0, len(authEvents)+len(adminEvents))
+		all := make([]string, 0, len(authEvents)+len(adminEvents)+len(securityEvents))
 		all = append(all, authEvents...)
 		all = append(all, adminEvents...)
+		all = append(all, securityEvents...)
 		return all
 	default:
 		return nil
 	}
 }
 
+// eventTypePatterns returns wildcard event types matching families of
+// related events in a category, or in all categories if category is empty.
+func eventTypePatterns(category string) []string {
+	authPatterns := []string{"login_failed_*", "verification_code_*", "reauth_*", "backup_code*"}
+	adminPatterns := []string{"user_*", "impersonation_*", "registration_*", "file_*", "folder_*", "group_*", "player_*", "webhook_*"}
+
+	switch category {
+	case audit.CategoryAuth:
+		return authPatterns
+	case audit.CategoryAdmin:
+		return adminPatterns
+	case "":
+		return append(authPatterns, adminPatterns...)
+	default:
+		return nil
+	}
+}
+
 // Routes returns a chi.Router with audit log routes mounted.
 func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r := chi.NewRouter()
 	r.Use(sessionMgr.RequireRole("admin"))
 
 	r.Get("/", h.list)
+	r.Get("/export.csv", h.exportCSV)
+	r.Get("/export.ndjson", h.exportNDJSON)
+	r.Get("/verify", h.verify)
+	r.Get("/searches", h.searches)
+	r.Post("/searches", h.createSearch)
+	r.Post("/searches/{id}/delete", h.deleteSearch)
 
 	return r
 }
 
-// list displays the audit log with filtering and pagination.
-func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
-	// Get filter parameters
-	category := strings.TrimSpace(r.URL.Query().Get("category"))
-	eventType := strings.TrimSpace(r.URL.Query().Get("event_type"))
-	startDate := strings.TrimSpace(r.URL.Query().Get("start_date"))
-	endDate := strings.TrimSpace(r.URL.Query().Get("end_date"))
-	tzParam := strings.TrimSpace(r.URL.Query().Get("tz"))
-	pageStr := r.URL.Query().Get("page")
-
-	page := 1
-	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-		page = p
-	}
-
-	// Load timezone location for date parsing (fall back to Local if invalid)
-	loc := time.Local
+// queryFilter returns the filter for the request's category, event type,
+// and date range. Dates are whole days in the selected timezone.
+func queryFilter(r *http.Request) audit.QueryFilter {
+	q := r.URL.Query()
+	startDate := strings.TrimSpace(q.Get("start_date"))
+	endDate := strings.TrimSpace(q.Get("end_date"))
+	tzParam := strings.TrimSpace(q.Get("tz"))
+
+	// Load timezone location for date parsing (fall back to the user's
+	// timezone if missing or invalid)
+	loc := viewdata.Location(r)
 	if tzParam != "" {
 		if parsedLoc, err := time.LoadLocation(tzParam); err == nil {
 			loc = parsedLoc
 		}
 	}
 
-	// Build query filter
 	filter := audit.QueryFilter{
-		Category:  category,
-		EventType: eventType,
-		Limit:     pageSize,
-		Offset:    int64((page - 1) * pageSize),
+		Category:  strings.TrimSpace(q.Get("category")),
+		EventType: strings.TrimSpace(q.Get("event_type")),
 	}
 
 	// Parse dates in user's selected timezone
@@ -203,6 +265,27 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 			filter.EndTime = &endOfDay
 		}
 	}
+	return filter
+}
+
+// list displays the audit log with filtering and pagination.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	// Get filter parameters
+	category := strings.TrimSpace(r.URL.Query().Get("category"))
+	eventType := strings.TrimSpace(r.URL.Query().Get("event_type"))
+	startDate := strings.TrimSpace(r.URL.Query().Get("start_date"))
+	endDate := strings.TrimSpace(r.URL.Query().Get("end_date"))
+	tzParam := strings.TrimSpace(r.URL.Query().Get("tz"))
+	pageStr := r.URL.Query().Get("page")
+
+	page := 1
+	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+		page = p
+	}
+
+	filter := queryFilter(r)
+	filter.Limit = pageSize
+	filter.Offset = int64((page - 1) * pageSize)
 
 	// Query audit store
 	events, err := h.auditStore.Query(r.Context(), filter)
@@ -230,8 +313,9 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Batch fetch user names
+	// Batch fetch user names and photos
 	userNames := make(map[primitive.ObjectID]string)
+	avatarPaths := make(map[primitive.ObjectID]string)
 	if len(userIDs) > 0 {
 		ids := make([]primitive.ObjectID, 0, len(userIDs))
 		for id := range userIDs {
@@ -243,6 +327,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		} else {
 			for _, u := range users {
 				userNames[u.ID] = u.FullName
+				avatarPaths[u.ID] = u.AvatarPath
 			}
 		}
 	}
@@ -256,19 +341,23 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 			Category:  e.Category,
 			EventType: e.EventType,
 			IP:        e.IP,
+			RequestID: e.RequestID,
 			Success:   e.Success,
 			Details:   e.Details,
+			Changes:   e.Changes,
 		}
 		// Resolve actor name
 		if e.ActorID != nil {
 			if name, ok := userNames[*e.ActorID]; ok {
 				item.ActorName = name
+				item.ActorAvatar = viewdata.NewAvatar(name, avatarPaths[*e.ActorID])
 			}
 			// Don't show raw ObjectID for deleted users - leave blank
 		} else if e.UserID != nil && e.Category == audit.CategoryAuth {
 			// For auth events, the user is the actor (they're logging in/out themselves)
 			if name, ok := userNames[*e.UserID]; ok {
 				item.ActorName = name
+				item.ActorAvatar = viewdata.NewAvatar(name, avatarPaths[*e.UserID])
 			}
 			// Don't show raw ObjectID for deleted users - leave blank
 		}
@@ -292,6 +381,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 
 	// Get event types for selected category (or all if no category selected)
 	eventTypes := eventTypesForCategory(category)
+	patterns := eventTypePatterns(category)
 
 	// Get timezone groups for selector
 	tzGroups, _ := timezones.Groups()
@@ -314,6 +404,8 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		Timezone:       tzParam,
 		Categories:     allCategories(),
 		EventTypes:     eventTypes,
+		Patterns:       patterns,
+		SavedSearches:  h.savedSearchLinks(r),
 		TimezoneGroups: tzGroups,
 		Page:           page,
 		TotalPages:     totalPages,
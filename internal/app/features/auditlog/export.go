@@ -0,0 +1,220 @@
+// internal/app/features/auditlog/export.go
+package auditlog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// exportChunk is how many events are read before their user names are
+// looked up and the rows written.
+const exportChunk = 500
+
+// exportColumns is the CSV header, in the order of csvRecord's fields.
+var exportColumns = []string{"id", "time", "category", "event_type", "actor_id", "actor_name", "user_id", "user_name", "ip", "user_agent", "request_id", "success", "failure_reason", "details", "changes", "seq", "hash"}
+
+// csvRecord returns an event as CSV fields. names maps user IDs to names;
+// details are written as a JSON object and changes as a JSON array. Free-text fields are guarded
+// against formula injection when the file is opened in a spreadsheet.
+func csvRecord(e audit.Event, names map[primitive.ObjectID]string) []string {
+	rec := auditforward.NewRecord(e)
+	var actorName, userName string
+	if e.ActorID != nil {
+		actorName = names[*e.ActorID]
+	}
+	if e.UserID != nil {
+		userName = names[*e.UserID]
+	}
+	details := ""
+	if len(e.Details) > 0 {
+		if b, err := json.Marshal(e.Details); err == nil {
+			details = string(b)
+		}
+	}
+	changes := ""
+	if len(e.Changes) > 0 {
+		if b, err := json.Marshal(e.Changes); err == nil {
+			changes = string(b)
+		}
+	}
+	seq := ""
+	if rec.Seq > 0 {
+		seq = strconv.FormatInt(rec.Seq, 10)
+	}
+	return []string{
+		rec.ID,
+		rec.Time.Format(time.RFC3339),
+		rec.Category,
+		rec.EventType,
+		rec.ActorID,
+		csvutil.SafeField(actorName),
+		rec.UserID,
+		csvutil.SafeField(userName),
+		csvutil.SafeField(rec.IP),
+		csvutil.SafeField(rec.UserAgent),
+		rec.RequestID,
+		strconv.FormatBool(rec.Success),
+		csvutil.SafeField(rec.FailureReason),
+		csvutil.SafeField(details),
+		csvutil.SafeField(changes),
+		seq,
+		rec.Hash,
+	}
+}
+
+// exportCSV streams the events matching the list's current filters as CSV,
+// newest first.
+func (h *Handler) exportCSV(w http.ResponseWriter, r *http.Request) {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	// Nothing is written until the first chunk is read, so a failed query
+	// can still be reported as an error
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", exportFilename("csv"))
+
+		// UTF-8 BOM for Excel
+		if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return err
+		}
+		return cw.Write(exportColumns)
+	}
+
+	chunk := make([]audit.Event, 0, exportChunk)
+	writeChunk := func() error {
+		if err := start(); err != nil {
+			return err
+		}
+		names := h.userNames(r, chunk)
+		for _, e := range chunk {
+			if err := cw.Write(csvRecord(e, names)); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		cw.Flush()
+		return cw.Error()
+	}
+
+	n := 0
+	err := h.auditStore.Each(r.Context(), queryFilter(r), func(e audit.Event) error {
+		n++
+		chunk = append(chunk, e)
+		if len(chunk) < exportChunk {
+			return nil
+		}
+		return writeChunk()
+	})
+	if err == nil {
+		err = writeChunk()
+	}
+	h.finishExport(w, r, "csv", n, started, err)
+}
+
+// exportNDJSON streams the events matching the list's current filters as
+// newline-delimited JSON, newest first, one event per line in the same
+// form they are forwarded to a SIEM in.
+func (h *Handler) exportNDJSON(w http.ResponseWriter, r *http.Request) {
+	enc := json.NewEncoder(w)
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			csvutil.SetDownloadHeaders(w, "application/x-ndjson; charset=utf-8", exportFilename("ndjson"))
+		}
+	}
+
+	n := 0
+	err := h.auditStore.Each(r.Context(), queryFilter(r), func(e audit.Event) error {
+		n++
+		start()
+		return enc.Encode(auditforward.NewRecord(e))
+	})
+	if err == nil {
+		start()
+	}
+	h.finishExport(w, r, "ndjson", n, started, err)
+}
+
+// userNames returns the names of the actors and users of events, keyed by
+// user ID. Lookup failures are logged and leave the names blank.
+func (h *Handler) userNames(r *http.Request, events []audit.Event) map[primitive.ObjectID]string {
+	seen := make(map[primitive.ObjectID]struct{})
+	ids := make([]primitive.ObjectID, 0)
+	add := func(id *primitive.ObjectID) {
+		if id == nil {
+			return
+		}
+		if _, ok := seen[*id]; !ok {
+			seen[*id] = struct{}{}
+			ids = append(ids, *id)
+		}
+	}
+	for _, e := range events {
+		add(e.ActorID)
+		add(e.UserID)
+	}
+
+	names := make(map[primitive.ObjectID]string, len(ids))
+	if len(ids) == 0 {
+		return names
+	}
+	users, err := h.userStore.GetByIDs(r.Context(), ids)
+	if err != nil {
+		h.logger.Warn("failed to fetch user names for audit export", zap.Error(err))
+		return names
+	}
+	for _, u := range users {
+		names[u.ID] = u.FullName
+	}
+	return names
+}
+
+// finishExport logs and audits an export. If the export failed before
+// anything was written an error page is sent; after that the response is
+// already under way, so the failure is only logged and the download ends
+// short.
+func (h *Handler) finishExport(w http.ResponseWriter, r *http.Request, format string, rows int, started bool, err error) {
+	if err != nil {
+		h.errLog.Log(r, "audit log export failed", err)
+		if !started {
+			http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	q := r.URL.Query()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, audit.EventAuditLogExported, map[string]string{
+		"format":     format,
+		"rows":       strconv.Itoa(rows),
+		"category":   strings.TrimSpace(q.Get("category")),
+		"event_type": strings.TrimSpace(q.Get("event_type")),
+		"start_date": strings.TrimSpace(q.Get("start_date")),
+		"end_date":   strings.TrimSpace(q.Get("end_date")),
+	})
+	h.logger.Info("audit log exported", zap.String("format", format), zap.Int("rows", rows))
+}
+
+// exportFilename names an export file after the current date.
+func exportFilename(ext string) string {
+	return fmt.Sprintf("audit_log_%s.%s", time.Now().UTC().Format("20060102"), ext)
+}
@@ -0,0 +1,297 @@
+// internal/app/features/auditlog/searches.go
+package auditlog
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	savedfilterstore "github.com/dalemusser/stratasave/internal/app/store/savedfilters"
+	"github.com/dalemusser/stratasave/internal/app/system/auditalerts"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxSavedSearches is how many searches one admin may save.
+const maxSavedSearches = 50
+
+// maxSearchName is the longest a saved search name may be.
+const maxSearchName = 100
+
+// maxAlertThreshold is the highest alert threshold accepted.
+const maxAlertThreshold = 1000000
+
+// eventTypePattern matches an event type filter: an event type, or a
+// prefix followed by "*".
+var eventTypePattern = regexp.MustCompile(`^[a-z_]*\*?$`)
+
+// searchLink is a saved search shown on the audit log page.
+type searchLink struct {
+	Name string
+	URL  string
+}
+
+// searchItem is a saved search for display.
+type searchItem struct {
+	ID        string
+	Name      string
+	Category  string
+	EventType string
+	URL       string
+
+	HasAlert    bool
+	Threshold   int64
+	Window      string
+	LastCount   int64
+	LastChecked *time.Time
+	LastAlert   *time.Time
+}
+
+// windowOption is an alert window choice.
+type windowOption struct {
+	Mins  int
+	Label string
+}
+
+// searchesData is the view model for the saved searches page.
+type searchesData struct {
+	viewdata.BaseVM
+
+	Searches []searchItem
+
+	// New search form
+	Name       string
+	Category   string
+	EventType  string
+	Threshold  string
+	WindowMins int
+	Categories []categoryOption
+	EventTypes []string
+	Windows    []windowOption
+
+	Error  string
+	Notice string
+}
+
+// searchURL returns the audit log URL for a saved search's filters.
+func searchURL(filters map[string]string) string {
+	q := url.Values{}
+	for _, k := range []string{"category", "event_type"} {
+		if v := filters[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	if len(q) == 0 {
+		return "/audit"
+	}
+	return "/audit?" + q.Encode()
+}
+
+// savedSearchLinks returns the current admin's saved searches for the
+// audit log page. Failures are logged and show no searches.
+func (h *Handler) savedSearchLinks(r *http.Request) []searchLink {
+	actor, ok := auth.CurrentUser(r)
+	if !ok {
+		return nil
+	}
+	saved, err := h.savedFilters.ListForUser(r.Context(), actor.UserID(), auditalerts.Feature)
+	if err != nil {
+		h.errLog.Log(r, "failed to list saved audit searches", err)
+		return nil
+	}
+	links := make([]searchLink, len(saved))
+	for i, s := range saved {
+		links[i] = searchLink{Name: s.Name, URL: searchURL(s.Filters)}
+	}
+	return links
+}
+
+// searches lists the current admin's saved searches, with a form to save
+// a new one prefilled from the category and event type in the URL.
+func (h *Handler) searches(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	vm := searchesData{
+		Category:   strings.TrimSpace(q.Get("category")),
+		EventType:  strings.TrimSpace(q.Get("event_type")),
+		WindowMins: 60,
+	}
+	switch q.Get("notice") {
+	case "saved":
+		vm.Notice = "Search saved."
+	case "deleted":
+		vm.Notice = "Search deleted."
+	}
+	h.renderSearches(w, r, vm)
+}
+
+// renderSearches shows the saved searches page with vm's form values.
+func (h *Handler) renderSearches(w http.ResponseWriter, r *http.Request, vm searchesData) {
+	actor, _ := auth.CurrentUser(r)
+	saved, err := h.savedFilters.ListForUser(r.Context(), actor.UserID(), auditalerts.Feature)
+	if err != nil {
+		h.errLog.Log(r, "failed to list saved audit searches", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, s := range saved {
+		item := searchItem{
+			ID:        s.ID.Hex(),
+			Name:      s.Name,
+			Category:  s.Filters["category"],
+			EventType: s.Filters["event_type"],
+			URL:       searchURL(s.Filters),
+		}
+		if s.Alert != nil {
+			item.HasAlert = true
+			item.Threshold = s.Alert.Threshold
+			item.Window = auditalerts.WindowLabel(s.Alert.WindowMins)
+			item.LastCount = s.Alert.LastCount
+			item.LastChecked = s.Alert.LastCheckedAt
+			item.LastAlert = s.Alert.LastAlertAt
+		}
+		vm.Searches = append(vm.Searches, item)
+	}
+
+	vm.BaseVM = viewdata.New(r)
+	vm.Title = "Saved Audit Searches"
+	vm.BackURL = "/audit"
+	vm.Categories = allCategories()
+	vm.EventTypes = append(eventTypePatterns(""), eventTypesForCategory("")...)
+	for _, m := range auditalerts.Windows {
+		vm.Windows = append(vm.Windows, windowOption{Mins: m, Label: auditalerts.WindowLabel(m)})
+	}
+
+	templates.Render(w, r, "auditlog/searches", vm)
+}
+
+// createSearch saves a search, with an alert if a threshold was given.
+func (h *Handler) createSearch(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	actor, _ := auth.CurrentUser(r)
+
+	vm := searchesData{
+		Name:      strings.TrimSpace(r.FormValue("name")),
+		Category:  strings.TrimSpace(r.FormValue("category")),
+		EventType: strings.TrimSpace(r.FormValue("event_type")),
+		Threshold: strings.TrimSpace(r.FormValue("threshold")),
+	}
+	vm.WindowMins, _ = strconv.Atoi(r.FormValue("window_mins"))
+
+	alert, msg := validateSearch(vm)
+	if msg == "" {
+		count, err := h.savedFilters.CountForUser(r.Context(), actor.UserID())
+		if err != nil {
+			h.errLog.Log(r, "failed to count saved filters", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if count >= maxSavedSearches {
+			msg = "You have saved the most searches allowed. Delete one to save another."
+		}
+	}
+	if msg != "" {
+		vm.Error = msg
+		h.renderSearches(w, r, vm)
+		return
+	}
+
+	filters := map[string]string{}
+	if vm.Category != "" {
+		filters["category"] = vm.Category
+	}
+	if vm.EventType != "" {
+		filters["event_type"] = vm.EventType
+	}
+	_, err := h.savedFilters.Create(r.Context(), savedfilterstore.CreateInput{
+		UserID:  actor.UserID(),
+		Feature: auditalerts.Feature,
+		Name:    vm.Name,
+		Filters: filters,
+		Alert:   alert,
+	})
+	if errors.Is(err, savedfilterstore.ErrDuplicateName) {
+		vm.Error = "You already have a saved search with this name."
+		h.renderSearches(w, r, vm)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to save audit search", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/audit/searches?notice=saved", http.StatusSeeOther)
+}
+
+// validateSearch checks a new search's form values, returning its alert
+// (nil when no threshold was given) or a message saying what is wrong.
+func validateSearch(vm searchesData) (*savedfilterstore.Alert, string) {
+	if vm.Name == "" {
+		return nil, "Enter a name for the search."
+	}
+	if len([]rune(vm.Name)) > maxSearchName {
+		return nil, "The name can be at most 100 characters."
+	}
+	if vm.Category != "" && !validCategory(vm.Category) {
+		return nil, "Choose a category from the list."
+	}
+	if !eventTypePattern.MatchString(vm.EventType) {
+		return nil, "The event type can only contain lowercase letters and underscores, optionally ending in *."
+	}
+
+	if vm.Threshold == "" {
+		return nil, ""
+	}
+	threshold, err := strconv.ParseInt(vm.Threshold, 10, 64)
+	if err != nil || threshold < 1 || threshold > maxAlertThreshold {
+		return nil, "The alert threshold must be a whole number from 1 to 1000000."
+	}
+	if !auditalerts.ValidWindow(vm.WindowMins) {
+		return nil, "Choose an alert window from the list."
+	}
+	return &savedfilterstore.Alert{Threshold: threshold, WindowMins: vm.WindowMins}, ""
+}
+
+// validCategory reports whether category is one of the audit categories.
+func validCategory(category string) bool {
+	for _, c := range allCategories() {
+		if c.Value == category {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteSearch deletes one of the current admin's saved searches.
+func (h *Handler) deleteSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	actor, _ := auth.CurrentUser(r)
+
+	err = h.savedFilters.Delete(r.Context(), id, actor.UserID())
+	if errors.Is(err, savedfilterstore.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to delete saved audit search", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/audit/searches?notice=deleted", http.StatusSeeOther)
+}
@@ -19,6 +19,9 @@ import (
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/newdevice"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/suspicious"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -37,6 +40,8 @@ type Handler struct {
 	oauthStateStore *oauthstate.Store
 	oauthConfig     *oauth2.Config
 	logger          *zap.Logger
+	newDevices      *newdevice.Notifier  // nil if login devices aren't tracked
+	suspicious      *suspicious.Detector // nil if detection is off
 }
 
 // NewHandler creates a new Google OAuth Handler.
@@ -70,11 +75,23 @@ func NewHandler(
 	}
 }
 
+// SetNewDeviceNotifier records the device behind each login and alerts the
+// user to logins from devices they haven't used before.
+func (h *Handler) SetNewDeviceNotifier(n *newdevice.Notifier) {
+	h.newDevices = n
+}
+
+// SetSuspiciousDetector checks each login for impossible travel.
+func (h *Handler) SetSuspiciousDetector(d *suspicious.Detector) {
+	h.suspicious = d
+}
+
 // Routes returns a chi.Router with Google OAuth routes mounted.
 func Routes(h *Handler) http.Handler {
 	r := chi.NewRouter()
 	r.Get("/", h.startAuth)
 	r.Get("/callback", h.handleCallback)
+	r.With(h.sessionMgr.RequireSignedIn, h.sessionMgr.RequireNotImpersonating, h.sessionMgr.RequireRecentAuth).Get("/link", h.startLink)
 	return r
 }
 
@@ -103,39 +120,31 @@ func (h *Handler) startAuth(w http.ResponseWriter, r *http.Request) {
 // handleCallback processes the Google OAuth callback.
 func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
 	// Verify state
-	state := r.URL.Query().Get("state")
-	if !h.oauthStateStore.Verify(r.Context(), state) {
+	st, ok := h.oauthStateStore.Consume(r.Context(), r.URL.Query().Get("state"))
+	if !ok {
 		h.logger.Warn("invalid oauth state")
 		http.Redirect(w, r, "/login?error=invalid_state", http.StatusSeeOther)
 		return
 	}
-
-	// Check for error from Google
-	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
-		h.logger.Warn("oauth error from google", zap.String("error", errMsg))
-		http.Redirect(w, r, "/login?error="+errMsg, http.StatusSeeOther)
+	if st.UserID != nil {
+		h.finishLink(w, r, *st.UserID)
 		return
 	}
 
-	// Exchange code for token
-	code := r.URL.Query().Get("code")
-	token, err := h.oauthConfig.Exchange(r.Context(), code)
-	if err != nil {
-		h.errLog.Log(r, "failed to exchange code", err)
-		http.Redirect(w, r, "/login?error=token_exchange_failed", http.StatusSeeOther)
+	userInfo, failure := h.fetchUserInfo(r)
+	if failure != "" {
+		http.Redirect(w, r, "/login?error="+failure, http.StatusSeeOther)
 		return
 	}
 
-	// Get user info from Google
-	userInfo, err := h.getUserInfo(r.Context(), token)
-	if err != nil {
-		h.errLog.Log(r, "failed to get user info", err)
-		http.Redirect(w, r, "/login?error=userinfo_failed", http.StatusSeeOther)
-		return
+	// Find the user the Google account is linked to
+	user, err := h.userStore.GetByGoogleID(r.Context(), userInfo.ID)
+	if err == mongo.ErrNoDocuments {
+		user, err = h.userStore.GetByEmail(r.Context(), userInfo.Email)
+	}
+	if err == nil && user.Status == status.Deleted {
+		err = mongo.ErrNoDocuments // deleted accounts can't sign in
 	}
-
-	// Find or create user
-	user, err := h.userStore.GetByEmail(r.Context(), userInfo.Email)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// User doesn't exist - redirect to login with error
@@ -149,7 +158,25 @@ func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account must have Google sign-in, and a different Google account
+	// with the same email address can't stand in for the linked one
+	if !user.HasMethod("google") || (user.GoogleID != "" && user.GoogleID != userInfo.ID) {
+		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_method_not_linked", false, "google not linked")
+		http.Redirect(w, r, "/login?error=google_not_linked", http.StatusSeeOther)
+		return
+	}
+	if user.GoogleID == "" {
+		if err := h.userStore.SetGoogleID(r.Context(), user.ID, userInfo.ID); err != nil {
+			h.logger.Warn("failed to record google account", zap.String("user_id", user.ID.Hex()), zap.Error(err))
+		}
+	}
+
 	// Check if user is active
+	if user.Status == status.Pending {
+		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_user_disabled", false, "awaiting approval")
+		http.Redirect(w, r, "/login?error=account_pending", http.StatusSeeOther)
+		return
+	}
 	if user.Status != "active" {
 		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_user_disabled", false, "user disabled")
 		http.Redirect(w, r, "/login?error=account_disabled", http.StatusSeeOther)
@@ -168,6 +195,89 @@ func (h *Handler) handleCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
 }
 
+// fetchUserInfo exchanges the callback's code for a token and fetches the
+// Google account's details. On failure it returns the error code to show.
+func (h *Handler) fetchUserInfo(r *http.Request) (*GoogleUserInfo, string) {
+	// Check for error from Google
+	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+		h.logger.Warn("oauth error from google", zap.String("error", errMsg))
+		return nil, errMsg
+	}
+
+	// Exchange code for token
+	code := r.URL.Query().Get("code")
+	token, err := h.oauthConfig.Exchange(r.Context(), code)
+	if err != nil {
+		h.errLog.Log(r, "failed to exchange code", err)
+		return nil, "token_exchange_failed"
+	}
+
+	// Get user info from Google
+	userInfo, err := h.getUserInfo(r.Context(), token)
+	if err != nil {
+		h.errLog.Log(r, "failed to get user info", err)
+		return nil, "userinfo_failed"
+	}
+	return userInfo, ""
+}
+
+// startLink starts connecting a Google account to the signed-in user's
+// account, for signing in with Google as well as their other methods.
+func (h *Handler) startLink(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	state, err := generateState()
+	if err != nil {
+		h.errLog.Log(r, "failed to generate state", err)
+		http.Redirect(w, r, "/profile?error=google_failed", http.StatusSeeOther)
+		return
+	}
+	if err := h.oauthStateStore.CreateForUser(r.Context(), state, sessionUser.UserID()); err != nil {
+		h.errLog.Log(r, "failed to store state", err)
+		http.Redirect(w, r, "/profile?error=google_failed", http.StatusSeeOther)
+		return
+	}
+
+	// Let the user pick which Google account to connect
+	url := h.oauthConfig.AuthCodeURL(state, oauth2.SetAuthURLParam("prompt", "select_account"))
+	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+}
+
+// finishLink connects the Google account from the callback to the user who
+// started linking it, and returns them to their profile.
+func (h *Handler) finishLink(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) {
+	// The link must finish in the session that started it
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok || sessionUser.UserID() != userID {
+		h.logger.Warn("google link finished outside the session that started it", zap.String("user_id", userID.Hex()))
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	userInfo, failure := h.fetchUserInfo(r)
+	if failure != "" {
+		http.Redirect(w, r, "/profile?error=google_failed", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.userStore.LinkGoogle(r.Context(), userID, userInfo.ID); err != nil {
+		if err == userstore.ErrGoogleAccountInUse {
+			http.Redirect(w, r, "/profile?error=google_in_use", http.StatusSeeOther)
+			return
+		}
+		h.errLog.Log(r, "failed to link google account", err)
+		http.Redirect(w, r, "/profile?error=google_failed", http.StatusSeeOther)
+		return
+	}
+	h.auditLogger.AuthMethodLinked(r.Context(), r, userID, "google")
+
+	http.Redirect(w, r, "/profile?success=google_linked", http.StatusSeeOther)
+}
+
 // GoogleUserInfo represents user info from Google.
 type GoogleUserInfo struct {
 	ID            string `json:"id"`
@@ -234,7 +344,7 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		UserAgent:    r.UserAgent(),
 		LoginAt:      now,
 		LastActivity: now,
-		ExpiresAt:    now.Add(24 * 30 * time.Hour), // 30 days
+		ExpiresAt:    now.Add(h.sessionMgr.Lifetime(role).Absolute),
 	}
 
 	// Best effort - don't fail login if tracking fails
@@ -242,6 +352,13 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		h.logger.Warn("failed to track session", zap.Error(err))
 	}
 
+	if err := h.userStore.RecordLogin(r.Context(), userID, now); err != nil {
+		h.logger.Warn("failed to record last login", zap.Error(err))
+	}
+
+	h.newDevices.Check(r, userID)
+	h.suspicious.LoginSucceeded(r, userID)
+
 	return nil
 }
 
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	devicestore "github.com/dalemusser/stratasave/internal/app/store/devices"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
@@ -23,6 +24,7 @@ type SessionsHandler struct {
 	db        *mongo.Database
 	sessions  *sessions.Store
 	userStore *userstore.Store
+	devices   *devicestore.Store
 	logger    *zap.Logger
 }
 
@@ -32,6 +34,7 @@ func NewSessionsHandler(db *mongo.Database, sessionsStore *sessions.Store, logge
 		db:        db,
 		sessions:  sessionsStore,
 		userStore: userstore.New(db),
+		devices:   devicestore.New(db),
 		logger:    logger,
 	}
 }
@@ -42,6 +45,7 @@ func SessionsRoutes(h *SessionsHandler, sessionMgr *auth.SessionManager) http.Ha
 	r.Use(sessionMgr.RequireRole("admin"))
 	r.Get("/", h.listSessions)
 	r.Get("/table", h.listSessionsTable)
+	r.Get("/events", h.sessionEvents)
 	r.Post("/{id}/terminate", h.terminateSession)
 	return r
 }
@@ -52,11 +56,13 @@ type SessionVM struct {
 	UserID           string
 	UserName         string
 	UserEmail        string
+	Avatar           viewdata.Avatar
 	CurrentPage      string
 	LastActivity     time.Time
 	LastActivityAgo  string
 	IPAddress        string
-	DeviceInfo       string
+	DeviceName       string // Name the user gave the device, if any
+	DeviceInfo       string // Browser and OS, e.g. "Chrome on Windows"
 	LoginAt          time.Time
 	LoginAtFormatted string
 	IsCurrentSession bool
@@ -69,8 +75,9 @@ type SessionsListVM struct {
 	CurrentToken string
 }
 
-// listSessions displays all active sessions.
-func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+// loadSessions builds the view models for all active sessions, marking the
+// one belonging to the current request.
+func (h *SessionsHandler) loadSessions(r *http.Request) ([]SessionVM, string, error) {
 	ctx := r.Context()
 
 	// Get current user's session token
@@ -83,9 +90,7 @@ func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
 	// Get all active sessions
 	activeSessions, err := h.sessions.GetActiveSessions(ctx, 100)
 	if err != nil {
-		h.logger.Error("failed to get active sessions", zap.Error(err))
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
+		return nil, currentToken, err
 	}
 
 	// Build user lookup map
@@ -98,6 +103,10 @@ func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
 	for i := range users {
 		userMap[users[i].ID] = &users[i]
 	}
+	deviceNames, err := h.devices.Names(ctx, userIDs)
+	if err != nil {
+		h.logger.Warn("failed to load device names", zap.Error(err))
+	}
 
 	// Build view models
 	sessionVMs := make([]SessionVM, 0, len(activeSessions))
@@ -110,24 +119,39 @@ func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
 			LastActivity:     sess.LastActivity,
 			LastActivityAgo:  formatTimeAgo(sess.LastActivity, now),
 			IPAddress:        sess.IPAddress,
-			DeviceInfo:       parseUserAgent(sess.UserAgent),
+			DeviceName:       deviceNames[sess.UserID][sess.Fingerprint()],
+			DeviceInfo:       sess.Device().String(),
 			LoginAt:          sess.LoginAt,
-			LoginAtFormatted: sess.LoginAt.Format("Jan 2 3:04 PM"),
+			LoginAtFormatted: viewdata.FormatTime(r, sess.LoginAt, "Jan 2 3:04 PM"),
 			IsCurrentSession: sess.Token == currentToken,
 		}
 
 		if user, ok := userMap[sess.UserID]; ok {
 			vm.UserName = user.FullName
+			vm.Avatar = viewdata.NewAvatar(user.FullName, user.AvatarPath)
 			if user.Email != nil {
 				vm.UserEmail = *user.Email
 			}
 		} else {
 			vm.UserName = "Unknown User"
+			vm.Avatar = viewdata.NewAvatar(vm.UserName, "")
 		}
 
 		sessionVMs = append(sessionVMs, vm)
 	}
 
+	return sessionVMs, currentToken, nil
+}
+
+// listSessions displays all active sessions.
+func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessionVMs, currentToken, err := h.loadSessions(r)
+	if err != nil {
+		h.logger.Error("failed to get active sessions", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
 	vm := SessionsListVM{
 		BaseVM:       viewdata.New(r),
 		Sessions:     sessionVMs,
@@ -141,63 +165,13 @@ func (h *SessionsHandler) listSessions(w http.ResponseWriter, r *http.Request) {
 
 // listSessionsTable returns just the sessions table for HTMX refresh.
 func (h *SessionsHandler) listSessionsTable(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	// Get current user's session token
-	currentUser, _ := auth.CurrentUser(r)
-	currentToken := ""
-	if currentUser != nil {
-		currentToken = currentUser.SessionToken()
-	}
-
-	// Get all active sessions
-	activeSessions, err := h.sessions.GetActiveSessions(ctx, 100)
+	sessionVMs, currentToken, err := h.loadSessions(r)
 	if err != nil {
 		h.logger.Error("failed to get active sessions", zap.Error(err))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	// Build user lookup map
-	userIDs := make([]primitive.ObjectID, 0, len(activeSessions))
-	for _, sess := range activeSessions {
-		userIDs = append(userIDs, sess.UserID)
-	}
-	users, _ := h.userStore.GetByIDs(ctx, userIDs)
-	userMap := make(map[primitive.ObjectID]*models.User, len(users))
-	for i := range users {
-		userMap[users[i].ID] = &users[i]
-	}
-
-	// Build view models
-	sessionVMs := make([]SessionVM, 0, len(activeSessions))
-	now := time.Now()
-	for _, sess := range activeSessions {
-		vm := SessionVM{
-			ID:               sess.ID.Hex(),
-			UserID:           sess.UserID.Hex(),
-			CurrentPage:      sess.CurrentPage,
-			LastActivity:     sess.LastActivity,
-			LastActivityAgo:  formatTimeAgo(sess.LastActivity, now),
-			IPAddress:        sess.IPAddress,
-			DeviceInfo:       parseUserAgent(sess.UserAgent),
-			LoginAt:          sess.LoginAt,
-			LoginAtFormatted: sess.LoginAt.Format("Jan 2 3:04 PM"),
-			IsCurrentSession: sess.Token == currentToken,
-		}
-
-		if user, ok := userMap[sess.UserID]; ok {
-			vm.UserName = user.FullName
-			if user.Email != nil {
-				vm.UserEmail = *user.Email
-			}
-		} else {
-			vm.UserName = "Unknown User"
-		}
-
-		sessionVMs = append(sessionVMs, vm)
-	}
-
 	vm := SessionsListVM{
 		Sessions:     sessionVMs,
 		CurrentToken: currentToken,
@@ -285,41 +259,3 @@ func formatPlural(n int, unit string) string {
 	}
 	return strconv.Itoa(n) + " " + unit + "s"
 }
-
-// parseUserAgent extracts device info from user agent string.
-func parseUserAgent(ua string) string {
-	if ua == "" {
-		return "Unknown"
-	}
-
-	// Simple parsing - could be enhanced with a proper UA parser
-	switch {
-	case contains(ua, "iPhone"):
-		return "iPhone"
-	case contains(ua, "iPad"):
-		return "iPad"
-	case contains(ua, "Android"):
-		return "Android"
-	case contains(ua, "Mac OS"):
-		return "Mac"
-	case contains(ua, "Windows"):
-		return "Windows"
-	case contains(ua, "Linux"):
-		return "Linux"
-	default:
-		return "Browser"
-	}
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsAt(s, substr, 0))
-}
-
-func containsAt(s, substr string, start int) bool {
-	for i := start; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,152 @@
+// internal/app/features/dashboard/sessions_events.go
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionsPollInterval is how often the event stream re-reads active
+	// sessions. Logins, expirations, and heartbeats all show up within one
+	// interval.
+	sessionsPollInterval = 5 * time.Second
+
+	// sessionsKeepAlive is the longest the stream stays silent; a comment
+	// line is sent after that so proxies don't close an idle connection.
+	sessionsKeepAlive = 15 * time.Second
+
+	// sessionsStreamMargin is how long before the request deadline the
+	// stream is closed, so the browser reconnects instead of the request
+	// timing out.
+	sessionsStreamMargin = 3 * time.Second
+
+	// sessionsRetry is the reconnect delay sent to the browser.
+	sessionsRetry = time.Second
+)
+
+// sessionEvents streams the sessions table over Server-Sent Events. A
+// "sessions" event carrying the rendered table is sent when the stream
+// opens and again whenever the table changes.
+//
+// Every request is bound by the global request timeout, so the stream ends
+// shortly before its deadline and the browser's EventSource reconnects.
+func (h *SessionsHandler) sessionEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sessionsRetry.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		h.logger.Warn("sessions event stream not supported", zap.Error(err))
+		return
+	}
+
+	var stop <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		timer := time.NewTimer(time.Until(deadline) - sessionsStreamMargin)
+		defer timer.Stop()
+		stop = timer.C
+	}
+
+	ticker := time.NewTicker(sessionsPollInterval)
+	defer ticker.Stop()
+
+	var last string
+	lastWrite := time.Now()
+	for {
+		wrote := false
+		table, err := h.renderSessionsTable(r)
+		switch {
+		case err != nil:
+			h.logger.Error("failed to render sessions table", zap.Error(err))
+		case table != last:
+			writeEvent(w, "sessions", table)
+			last = table
+			wrote = true
+		case time.Since(lastWrite) >= sessionsKeepAlive:
+			io.WriteString(w, ": keep-alive\n\n")
+			wrote = true
+		}
+		if wrote {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderSessionsTable renders the sessions table snippet to a string.
+func (h *SessionsHandler) renderSessionsTable(r *http.Request) (string, error) {
+	sessionVMs, currentToken, err := h.loadSessions(r)
+	if err != nil {
+		return "", err
+	}
+
+	vm := SessionsListVM{
+		Sessions:     sessionVMs,
+		CurrentToken: currentToken,
+	}
+
+	var buf snippetBuffer
+	templates.RenderSnippet(&buf, "dashboard/sessions_table", vm)
+	if buf.status >= http.StatusBadRequest {
+		return "", fmt.Errorf("render sessions table: status %d", buf.status)
+	}
+	return buf.String(), nil
+}
+
+// writeEvent writes a named Server-Sent Event. Each line of data gets its
+// own "data:" field so multi-line HTML arrives intact.
+func writeEvent(w io.Writer, event, data string) {
+	var b strings.Builder
+	b.WriteString("event: ")
+	b.WriteString(event)
+	b.WriteString("\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	io.WriteString(w, b.String())
+}
+
+// snippetBuffer is an http.ResponseWriter that captures a rendered snippet
+// instead of sending it.
+type snippetBuffer struct {
+	bytes.Buffer
+	header http.Header
+	status int
+}
+
+func (b *snippetBuffer) Header() http.Header {
+	if b.header == nil {
+		b.header = make(http.Header)
+	}
+	return b.header
+}
+
+func (b *snippetBuffer) WriteHeader(status int) {
+	b.status = status
+}
@@ -0,0 +1,34 @@
+package dashboard
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteEvent(t *testing.T) {
+	var b strings.Builder
+	writeEvent(&b, "sessions", "<div>\r\n  <p>3 active</p>\n</div>")
+
+	want := "event: sessions\n" +
+		"data: <div>\n" +
+		"data:   <p>3 active</p>\n" +
+		"data: </div>\n" +
+		"\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeEvent() = %q, want %q", got, want)
+	}
+}
+
+func TestSnippetBuffer(t *testing.T) {
+	var buf snippetBuffer
+	buf.Header().Set("Content-Type", "text/html")
+	buf.WriteHeader(500)
+	buf.WriteString("failed")
+
+	if buf.status != 500 {
+		t.Errorf("status = %d, want 500", buf.status)
+	}
+	if buf.String() != "failed" {
+		t.Errorf("body = %q, want %q", buf.String(), "failed")
+	}
+}
@@ -0,0 +1,153 @@
+// internal/app/features/emaillog/handler.go
+package emaillogfeature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	emaillogstore "github.com/dalemusser/stratasave/internal/app/store/emaillog"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Handler handles email delivery log HTTP requests.
+type Handler struct {
+	DB     *mongo.Database
+	ErrLog *errorsfeature.ErrorLogger
+	Log    *zap.Logger
+}
+
+// NewHandler creates a new email log handler.
+func NewHandler(db *mongo.Database, errLog *errorsfeature.ErrorLogger, logger *zap.Logger) *Handler {
+	return &Handler{
+		DB:     db,
+		ErrLog: errLog,
+		Log:    logger,
+	}
+}
+
+// ServeList handles GET /email-log - list delivery attempts, optionally for
+// one user (?user=<id>).
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	fvm := FilterVM{
+		To:       q.Get("to"),
+		Status:   q.Get("status"),
+		Template: q.Get("template"),
+	}
+	filter := emaillogstore.ListFilter{
+		To:       fvm.To,
+		Status:   fvm.Status,
+		Template: fvm.Template,
+	}
+
+	var userName string
+	if userID, err := primitive.ObjectIDFromHex(q.Get("user")); err == nil {
+		user, err := userstore.New(h.DB).GetByID(ctx, userID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			h.ErrLog.Log(r, "failed to load user for email log", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		fvm.User = userID.Hex()
+		filter.UserID = userID
+		userName = userID.Hex()
+		if user != nil {
+			userName = user.FullName
+			if user.Email != nil {
+				filter.UserEmail = *user.Email
+			}
+		}
+	}
+
+	result, err := emaillogstore.New(h.DB).List(ctx, filter, page, 50)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load email log", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vms := make([]EntryVM, len(result.Entries))
+	for i, e := range result.Entries {
+		vms[i] = toEntryVM(e)
+	}
+
+	prevPage := result.Page - 1
+	if prevPage < 1 {
+		prevPage = 1
+	}
+	nextPage := result.Page + 1
+	if nextPage > result.TotalPages {
+		nextPage = result.TotalPages
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Email Log", "/dashboard")
+	data := ListVM{
+		BaseVM:     base,
+		Entries:    vms,
+		Filter:     fvm,
+		UserName:   userName,
+		Templates:  mailer.Samples(),
+		Page:       result.Page,
+		TotalPages: result.TotalPages,
+		TotalCount: result.TotalCount,
+		PrevPage:   prevPage,
+		NextPage:   nextPage,
+	}
+
+	if r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Target") == "email-log-table" {
+		templates.RenderSnippet(w, "email_log_table", data)
+		return
+	}
+
+	templates.Render(w, r, "emaillog/list", data)
+}
+
+// toEntryVM converts a store Entry to a view model.
+func toEntryVM(e emaillogstore.Entry) EntryVM {
+	vm := EntryVM{
+		To:          e.To,
+		Template:    e.Template,
+		Subject:     e.Subject,
+		Status:      e.Status,
+		MessageID:   e.MessageID,
+		Error:       e.Error,
+		CreatedAt:   e.CreatedAt.Format("2006-01-02 15:04:05"),
+		StatusClass: getStatusClass(e.Status),
+	}
+	if e.UserID != nil {
+		vm.UserID = e.UserID.Hex()
+	}
+	return vm
+}
+
+// getStatusClass returns the CSS class for a status badge.
+func getStatusClass(status string) string {
+	switch status {
+	case emaillogstore.StatusSent:
+		return "bg-green-100 text-green-800 dark:bg-green-900/40 dark:text-green-400"
+	case emaillogstore.StatusFailed:
+		return "bg-red-100 text-red-800 dark:bg-red-900/40 dark:text-red-400"
+	case emaillogstore.StatusSuppressed:
+		return "bg-gray-100 text-gray-700 dark:bg-gray-600 dark:text-gray-300"
+	default:
+		return "bg-gray-100 text-gray-700 dark:bg-gray-600 dark:text-gray-300"
+	}
+}
@@ -0,0 +1,18 @@
+// internal/app/features/emaillog/routes.go
+package emaillogfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the email delivery log.
+// Access is restricted to admin and developer roles.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin", "developer"))
+
+	r.Get("/", h.ServeList)
+
+	return r
+}
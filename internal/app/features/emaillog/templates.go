@@ -0,0 +1,19 @@
+// internal/app/features/emaillog/templates.go
+package emaillogfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "emaillog",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
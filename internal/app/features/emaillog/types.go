@@ -0,0 +1,42 @@
+// internal/app/features/emaillog/types.go
+package emaillogfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// EntryVM is the view model for a single delivery log entry.
+type EntryVM struct {
+	To          string
+	UserID      string
+	Template    string
+	Subject     string
+	Status      string
+	MessageID   string
+	Error       string
+	CreatedAt   string
+	StatusClass string // CSS class for status badge
+}
+
+// FilterVM holds the current list filters.
+type FilterVM struct {
+	To       string
+	Status   string
+	Template string
+	User     string // User ID (hex)
+}
+
+// ListVM is the view model for the delivery log page.
+type ListVM struct {
+	viewdata.BaseVM
+	Entries    []EntryVM
+	Filter     FilterVM
+	UserName   string // Set when filtered to one user
+	Templates  []mailer.Sample
+	Page       int
+	TotalPages int
+	TotalCount int64
+	PrevPage   int
+	NextPage   int
+}
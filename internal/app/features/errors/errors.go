@@ -4,6 +4,7 @@ package errors
 import (
 	"net/http"
 
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"go.uber.org/zap"
@@ -25,6 +26,7 @@ func (e *ErrorLogger) Log(r *http.Request, msg string, err error) {
 		zap.Error(err),
 		zap.String("path", r.URL.Path),
 		zap.String("method", r.Method),
+		requestid.Field(r.Context()),
 	)
 }
 
@@ -34,6 +36,7 @@ func (e *ErrorLogger) LogWithFields(r *http.Request, msg string, err error, fiel
 		zap.Error(err),
 		zap.String("path", r.URL.Path),
 		zap.String("method", r.Method),
+		requestid.Field(r.Context()),
 	}, fields...)
 	e.logger.Error(msg, allFields...)
 }
@@ -0,0 +1,217 @@
+// internal/app/features/featureflags/handler.go
+package featureflagsfeature
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	featureflagstore "github.com/dalemusser/stratasave/internal/app/store/featureflag"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Handler serves the feature flags admin page.
+type Handler struct {
+	DB          *mongo.Database
+	Store       *featureflagstore.Store
+	Flags       *featureflags.Flags
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+}
+
+// NewHandler creates a new feature flags handler. Changes are applied to
+// flags right away.
+func NewHandler(db *mongo.Database, flags *featureflags.Flags, errLog *errorsfeature.ErrorLogger, auditLogger *auditlog.Logger, logger *zap.Logger) *Handler {
+	return &Handler{
+		DB:          db,
+		Store:       featureflagstore.New(db),
+		Flags:       flags,
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// ServeList handles GET /feature-flags - list flags and their settings.
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	var success string
+	switch r.URL.Query().Get("saved") {
+	case "1":
+		success = "Flag saved."
+	case "reset":
+		success = "Flag reset to its default."
+	}
+	h.renderList(w, r, success, "")
+}
+
+func (h *Handler) renderList(w http.ResponseWriter, r *http.Request, success, formErr string) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	list, err := h.Store.List(ctx)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load feature flags", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	stored := make(map[string]models.FeatureFlag, len(list))
+	for _, f := range list {
+		stored[f.Key] = f
+	}
+
+	env := h.Flags.Env()
+	subject := featureflags.Subject(r)
+	vms := make([]FlagVM, 0, len(featureflags.Definitions))
+	for _, def := range featureflags.Definitions {
+		flag, customized := stored[def.Key]
+		if !customized {
+			flag = def.Default
+		}
+		vm := FlagVM{
+			Key:          def.Key,
+			Name:         def.Name,
+			Description:  def.Description,
+			Enabled:      flag.Enabled,
+			Environments: make(map[string]bool),
+			Rollout:      flag.Rollout,
+			Summary:      summary(flag),
+			Customized:   customized,
+			OnlyIn:       def.OnlyIn,
+			UpdatedBy:    flag.UpdatedByName,
+		}
+		for _, e := range flag.Environments {
+			vm.Environments[e] = true
+		}
+		var setting *models.FeatureFlag
+		if customized {
+			setting = &flag
+			vm.UpdatedAt = flag.UpdatedAt.Format("Jan 2, 2006 3:04 PM")
+		}
+		vm.On = featureflags.Evaluate(def, setting, env, subject)
+		vms = append(vms, vm)
+	}
+
+	data := ListVM{
+		BaseVM:       viewdata.NewBaseVM(r, h.DB, "Feature Flags", "/dashboard"),
+		Env:          env,
+		Environments: featureflags.Environments,
+		Flags:        vms,
+		Success:      success,
+		Error:        formErr,
+	}
+	templates.Render(w, r, "featureflags/list", data)
+}
+
+// summary describes where and for whom a flag setting is on.
+func summary(flag models.FeatureFlag) string {
+	s := featureflags.RolloutLabel(flag)
+	if flag.Enabled && len(flag.Environments) > 0 {
+		s += " in " + strings.Join(flag.Environments, ", ")
+	}
+	return s
+}
+
+// HandleUpdate handles POST /feature-flags/{key} - save a flag's setting.
+func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	def, ok := featureflags.Lookup(chi.URLParam(r, "key"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	flag, errMsg := parseFlag(r.Form.Get("enabled") == "on", r.Form["env"], r.Form.Get("rollout"))
+	if errMsg != "" {
+		h.renderList(w, r, "", def.Name+": "+errMsg)
+		return
+	}
+	flag.Key = def.Key
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	flag.UpdatedByID, flag.UpdatedByName = &actorID, actor.Name
+	if err := h.Store.Set(ctx, flag); err != nil {
+		h.ErrLog.Log(r, "failed to save feature flag", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.Flags.Invalidate()
+
+	h.AuditLogger.LogAdminEvent(r, &actorID, nil, "feature_flag_updated", map[string]string{
+		"flag":         def.Key,
+		"enabled":      strconv.FormatBool(flag.Enabled),
+		"environments": strings.Join(flag.Environments, ","),
+		"rollout":      strconv.Itoa(flag.Rollout),
+	})
+
+	http.Redirect(w, r, "/feature-flags?saved=1", http.StatusSeeOther)
+}
+
+// HandleReset handles POST /feature-flags/{key}/reset - go back to the
+// flag's default setting.
+func (h *Handler) HandleReset(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	def, ok := featureflags.Lookup(chi.URLParam(r, "key"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if err := h.Store.Delete(ctx, def.Key); err != nil {
+		h.ErrLog.Log(r, "failed to reset feature flag", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.Flags.Invalidate()
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.AuditLogger.LogAdminEvent(r, &actorID, nil, "feature_flag_reset", map[string]string{"flag": def.Key})
+
+	http.Redirect(w, r, "/feature-flags?saved=reset", http.StatusSeeOther)
+}
+
+// parseFlag validates a flag setting from the form. It returns a message
+// for the admin if the setting is invalid.
+func parseFlag(enabled bool, envs []string, rollout string) (models.FeatureFlag, string) {
+	flag := models.FeatureFlag{Enabled: enabled}
+
+	pct, err := strconv.Atoi(strings.TrimSpace(rollout))
+	if err != nil || pct < 0 || pct > 100 {
+		return flag, "Rollout must be a whole number from 0 to 100."
+	}
+	flag.Rollout = pct
+
+	// Keep the environments in their usual order, ignoring unknown ones;
+	// all of them is the same as none
+	for _, e := range featureflags.Environments {
+		for _, v := range envs {
+			if v == e {
+				flag.Environments = append(flag.Environments, e)
+				break
+			}
+		}
+	}
+	if len(flag.Environments) == len(featureflags.Environments) {
+		flag.Environments = nil
+	}
+	return flag, ""
+}
@@ -0,0 +1,40 @@
+package featureflagsfeature
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		envs    []string
+		rollout string
+		wantEnv []string
+		wantPct int
+		wantErr bool
+	}{
+		{"everyone", true, nil, "100", nil, 100, false},
+		{"prod only", true, []string{"prod"}, "25", []string{"prod"}, 25, false},
+		{"all environments", true, []string{"prod", "dev"}, "100", nil, 100, false},
+		{"unknown environment", true, []string{"staging", "dev"}, "50", []string{"dev"}, 50, false},
+		{"off", false, nil, "0", nil, 0, false},
+		{"rollout too high", true, nil, "101", nil, 0, true},
+		{"rollout not a number", true, nil, "half", nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag, errMsg := parseFlag(tt.enabled, tt.envs, tt.rollout)
+			if (errMsg != "") != tt.wantErr {
+				t.Fatalf("parseFlag() error = %q, wantErr %v", errMsg, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if flag.Enabled != tt.enabled || flag.Rollout != tt.wantPct || !reflect.DeepEqual(flag.Environments, tt.wantEnv) {
+				t.Errorf("parseFlag() = %+v, want enabled %v, environments %v, rollout %d", flag, tt.enabled, tt.wantEnv, tt.wantPct)
+			}
+		})
+	}
+}
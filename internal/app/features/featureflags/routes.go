@@ -0,0 +1,20 @@
+// internal/app/features/featureflags/routes.go
+package featureflagsfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the feature flags admin page.
+// Access is restricted to the admin role.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin"))
+
+	r.Get("/", h.ServeList)
+	r.Post("/{key}", h.HandleUpdate)
+	r.Post("/{key}/reset", h.HandleReset)
+
+	return r
+}
@@ -0,0 +1,19 @@
+// internal/app/features/featureflags/templates.go
+package featureflagsfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "featureflags",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
@@ -0,0 +1,30 @@
+// internal/app/features/featureflags/types.go
+package featureflagsfeature
+
+import "github.com/dalemusser/stratasave/internal/app/system/viewdata"
+
+// FlagVM is the view model for one flag.
+type FlagVM struct {
+	Key          string
+	Name         string
+	Description  string
+	Enabled      bool
+	Environments map[string]bool // environment -> flag limited to it
+	Rollout      int
+	Summary      string // e.g. "25% of users in prod"
+	Customized   bool   // an admin has changed the default
+	On           bool   // on for the admin viewing the page
+	OnlyIn       []string
+	UpdatedAt    string
+	UpdatedBy    string
+}
+
+// ListVM is the view model for the feature flags page.
+type ListVM struct {
+	viewdata.BaseVM
+	Env          string
+	Environments []string
+	Flags        []FlagVM
+	Success      string
+	Error        string
+}
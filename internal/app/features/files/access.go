@@ -0,0 +1,157 @@
+package files
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/fileaccess"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// maxAccessReportRows is the most access records in one file's report.
+const maxAccessReportRows = 10000
+
+// countsAsAccess reports whether a request for a file's content is a new
+// view or download. Requests for a later part of the content, such as a
+// video being seeked or a download resuming, continue an earlier one.
+func countsAsAccess(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	rng := r.Header.Get("Range")
+	return rng == "" || strings.HasPrefix(rng, "bytes=0-")
+}
+
+// recordAccess records a view or download of f and adds it to the file's
+// counts. Failures are logged; they don't stop the file being served.
+func (h *Handler) recordAccess(r *http.Request, f *models.File, kind string, shareID *primitive.ObjectID) {
+	if !countsAsAccess(r) {
+		return
+	}
+	ctx := r.Context()
+	now := time.Now().UTC()
+
+	a := fileaccess.Access{
+		FileID:    f.ID,
+		Kind:      kind,
+		ShareID:   shareID,
+		IP:        network.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+		CreatedAt: now,
+	}
+	if user, ok := auth.CurrentUser(r); ok && shareID == nil {
+		userID := user.UserID()
+		a.UserID = &userID
+	}
+	if err := h.access.Record(ctx, a); err != nil {
+		h.errLog.Log(r, "failed to record file access", err)
+	}
+	if err := h.fileStore.CountAccess(ctx, f.ID, kind != fileaccess.KindView, now); err != nil {
+		h.errLog.Log(r, "failed to count file access", err)
+	}
+}
+
+// accessReport downloads a CSV of a file's views and downloads, newest
+// first.
+func (h *Handler) accessReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.fileStore.GetByID(ctx, objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	records, err := h.access.ListByFile(ctx, objID, maxAccessReportRows)
+	if err != nil {
+		h.errLog.Log(r, "failed to list file access", err)
+		http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		return
+	}
+
+	// Names and login IDs of the users in the report
+	seen := make(map[primitive.ObjectID]bool)
+	var userIDs []primitive.ObjectID
+	for _, a := range records {
+		if a.UserID != nil && !seen[*a.UserID] {
+			seen[*a.UserID] = true
+			userIDs = append(userIDs, *a.UserID)
+		}
+	}
+	users, err := h.users.GetByIDs(ctx, userIDs)
+	if err != nil {
+		h.errLog.Log(r, "failed to load users for access report", err)
+		http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		return
+	}
+	byID := make(map[primitive.ObjectID]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	filename := fmt.Sprintf("%s_access.csv", strings.TrimSuffix(f.Name, filepath.Ext(f.Name)))
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename)
+
+	// UTF-8 BOM for Excel
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		h.logger.Error("CSV write failed (BOM)", zap.Error(err))
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	defer cw.Flush()
+
+	if err := cw.Write([]string{"accessed_at", "kind", "user_id", "user_name", "login_id", "share_id", "ip", "user_agent"}); err != nil {
+		h.logger.Error("CSV write failed (header)", zap.Error(err))
+		return
+	}
+	for _, a := range records {
+		if err := cw.Write(accessRow(a, byID)); err != nil {
+			h.logger.Error("CSV write failed (row)", zap.Error(err))
+			return
+		}
+	}
+}
+
+// accessRow formats an access record as a report row.
+func accessRow(a fileaccess.Access, users map[primitive.ObjectID]models.User) []string {
+	var userID, name, loginID, shareID string
+	if a.UserID != nil {
+		userID = a.UserID.Hex()
+		if u, ok := users[*a.UserID]; ok {
+			name = u.FullName
+			if u.LoginID != nil {
+				loginID = *u.LoginID
+			}
+		}
+	}
+	if a.ShareID != nil {
+		shareID = a.ShareID.Hex()
+	}
+	return []string{
+		a.CreatedAt.UTC().Format(time.RFC3339),
+		a.Kind,
+		userID,
+		csvutil.SafeField(name),
+		csvutil.SafeField(loginID),
+		shareID,
+		a.IP,
+		csvutil.SafeField(a.UserAgent),
+	}
+}
@@ -0,0 +1,63 @@
+package files
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/fileaccess"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCountsAsAccess(t *testing.T) {
+	tests := []struct {
+		method   string
+		rangeHdr string
+		want     bool
+	}{
+		{http.MethodGet, "", true},
+		{http.MethodGet, "bytes=0-", true},
+		{http.MethodGet, "bytes=0-1023", true},
+		{http.MethodGet, "bytes=1024-", false},
+		{http.MethodGet, "bytes=-500", false},
+		{http.MethodHead, "", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/library/file/x/view", nil)
+		if tt.rangeHdr != "" {
+			req.Header.Set("Range", tt.rangeHdr)
+		}
+		if got := countsAsAccess(req); got != tt.want {
+			t.Errorf("countsAsAccess(%s %q) = %v, want %v", tt.method, tt.rangeHdr, got, tt.want)
+		}
+	}
+}
+
+func TestAccessRow(t *testing.T) {
+	userID := primitive.NewObjectID()
+	shareID := primitive.NewObjectID()
+	loginID := "ada"
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	users := map[primitive.ObjectID]models.User{
+		userID: {ID: userID, FullName: "=Ada", LoginID: &loginID},
+	}
+
+	got := accessRow(fileaccess.Access{
+		Kind: fileaccess.KindDownload, UserID: &userID, IP: "203.0.113.7", UserAgent: "curl/8.0", CreatedAt: at,
+	}, users)
+	want := []string{"2026-10-16T09:30:00Z", "download", userID.Hex(), "'=Ada", "ada", "", "203.0.113.7", "curl/8.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("accessRow(user) = %q, want %q", got, want)
+	}
+
+	got = accessRow(fileaccess.Access{
+		Kind: fileaccess.KindShareDownload, ShareID: &shareID, IP: "198.51.100.2", CreatedAt: at,
+	}, users)
+	want = []string{"2026-10-16T09:30:00Z", "share_download", "", "", "", shareID.Hex(), "198.51.100.2", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("accessRow(share) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// errCorrupt means stored content no longer matches the hash taken when
+// it was uploaded.
+var errCorrupt = errors.New("stored content does not match its SHA-256")
+
+// hashContent returns the hex SHA-256 of r's content.
+func hashContent(r io.Reader) (string, error) {
+	sum := sha256.New()
+	if _, err := io.Copy(sum, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// hashStored returns the hex SHA-256 of an object already in storage.
+func (h *Handler) hashStored(r *http.Request, path string) (string, error) {
+	rc, err := h.fileStorage.Get(r.Context(), path)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return hashContent(rc)
+}
+
+// storeContent stores an upload's content under a new path with put,
+// unless identical content is already stored, in which case the upload
+// shares it. It returns where the content is.
+func (h *Handler) storeContent(r *http.Request, sum, name string, size int64, put func(path string) error) (string, error) {
+	b, err := h.blobs.Acquire(r.Context(), sum)
+	if err == nil {
+		return b.StoragePath, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", err
+	}
+
+	path := newStoragePath(name)
+	if err := put(path); err != nil {
+		return "", err
+	}
+	h.recordContent(r, sum, path, size)
+	return path, nil
+}
+
+// recordContent records newly stored content so later uploads of the
+// same content can share it. If it can't be recorded, for instance
+// because identical content was stored at the same moment, the content
+// just isn't shared.
+func (h *Handler) recordContent(r *http.Request, sum, path string, size int64) {
+	if err := h.blobs.Create(r.Context(), sum, path, size); err != nil && !mongo.IsDuplicateKeyError(err) {
+		h.errLog.Log(r, "failed to record file content", err)
+	}
+}
+
+// dropContent gives up content stored or shared for a file whose record
+// couldn't be created, deleting it unless other files share it.
+func (h *Handler) dropContent(ctx context.Context, path string) {
+	remaining, err := h.blobs.Release(ctx, path)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		h.logger.Warn("failed to release file content",
+			zap.String("path", path),
+			zap.Error(err))
+		return
+	}
+	if remaining == 0 {
+		if err := h.fileStorage.Delete(ctx, path); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			h.logger.Warn("failed to delete file content",
+				zap.String("path", path),
+				zap.Error(err))
+		}
+	}
+}
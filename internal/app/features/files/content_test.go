@@ -0,0 +1,17 @@
+package files
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashContent(t *testing.T) {
+	got, err := hashContent(strings.NewReader("test"))
+	if err != nil {
+		t.Fatalf("hashContent() error = %v", err)
+	}
+	const want = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	if got != want {
+		t.Errorf("hashContent() = %s, want %s", got, want)
+	}
+}
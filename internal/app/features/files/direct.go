@@ -0,0 +1,278 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/upload"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/jsonutil"
+	"github.com/dalemusser/stratasave/internal/app/system/resumable"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Direct uploads send file data from the browser straight to the storage
+// bucket, so the app server never handles it:
+//
+//	POST /library/file/direct                presigned URL for a new upload
+//	PUT  <presigned URL>                     browser sends the file to the bucket
+//	POST /library/file/direct/{id}/complete  check the object and record the file
+//
+// Uploads that are never completed expire and their objects are removed by
+// the upload cleanup job.
+
+// directUploadRequest is the body of POST /library/file/direct.
+type directUploadRequest struct {
+	Filename    string `json:"filename"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	FolderID    string `json:"folder_id"`
+	Description string `json:"description"`
+}
+
+// createDirectUpload issues a presigned URL for uploading one file.
+func (h *Handler) createDirectUpload(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	var req directUploadRequest
+	if err := jsonutil.Decode(r, &req); err != nil {
+		jsonutil.BadRequest(w, "invalid request body")
+		return
+	}
+	name := strings.TrimSpace(req.Filename)
+	if name == "" {
+		jsonutil.BadRequest(w, "filename is required")
+		return
+	}
+	if req.Size <= 0 {
+		jsonutil.BadRequest(w, "size must be a positive number of bytes")
+		return
+	}
+	// The content is checked once it reaches storage
+	contentType, err := h.policy.CheckType(req.ContentType, nil)
+	if err != nil {
+		jsonutil.Error(w, http.StatusUnsupportedMediaType, "This file type is not allowed")
+		return
+	}
+
+	var folderID *primitive.ObjectID
+	if id, err := primitive.ObjectIDFromHex(req.FolderID); err == nil {
+		folderID = &id
+	}
+	if err := h.checkQuota(r.Context(), folderID, req.Size); err != nil {
+		var qe *quotaError
+		if errors.As(err, &qe) {
+			jsonutil.Error(w, http.StatusInsufficientStorage, qe.Error())
+			return
+		}
+		h.errLog.Log(r, "failed to check storage quota", err)
+		jsonutil.InternalError(w, "Failed to start upload")
+		return
+	}
+
+	u, presigned, err := h.uploads.CreateDirect(r.Context(), upload.CreateInput{
+		UserID:      actor.UserID(),
+		FolderID:    folderID,
+		Name:        name,
+		ContentType: contentType,
+		Description: strings.TrimSpace(req.Description),
+		Size:        req.Size,
+		StoragePath: newStoragePath(name),
+	})
+	if errors.Is(err, resumable.ErrTooLarge) {
+		jsonutil.Error(w, http.StatusRequestEntityTooLarge, "File too large (max "+FormatFileSize(h.uploads.MaxSize())+")")
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to start direct upload", err)
+		jsonutil.InternalError(w, "Failed to start upload")
+		return
+	}
+
+	method := presigned.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	jsonutil.Created(w, map[string]any{
+		"id":      u.ID.Hex(),
+		"url":     presigned.URL,
+		"method":  method,
+		"headers": browserHeaders(presigned.Headers, contentType),
+	})
+}
+
+// completeDirectUpload checks an object the browser has sent to storage
+// and records it as a library file.
+func (h *Handler) completeDirectUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	if !u.Direct() {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := h.fileStorage.Head(ctx, u.StoragePath)
+	if errors.Is(err, storage.ErrNotFound) {
+		jsonutil.Error(w, http.StatusConflict, "The file has not finished uploading")
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to check direct upload", err)
+		jsonutil.InternalError(w, "Failed to check upload")
+		return
+	}
+	if info.Size != u.Size {
+		h.rejectDirectUpload(w, r, u, http.StatusBadRequest, "The uploaded file does not match the size declared")
+		return
+	}
+
+	// Check the start of the content, not just the declared type
+	head, err := h.readHead(r, u.StoragePath)
+	if err != nil {
+		h.errLog.Log(r, "failed to read direct upload", err)
+		jsonutil.InternalError(w, "Failed to check upload")
+		return
+	}
+	if _, err := h.policy.CheckType(u.ContentType, head); err != nil {
+		h.rejectDirectUpload(w, r, u, http.StatusUnsupportedMediaType, "This file type is not allowed")
+		return
+	}
+
+	scanStatus, err := h.scanStored(r, u.Name, u.StoragePath)
+	if err != nil {
+		status, msg := scanFailure(err)
+		if status == http.StatusUnprocessableEntity {
+			h.rejectDirectUpload(w, r, u, status, msg)
+			return
+		}
+		// Keep the object so completing can be retried
+		jsonutil.Error(w, status, msg)
+		return
+	}
+
+	sum, err := h.hashStored(r, u.StoragePath)
+	if err != nil {
+		h.errLog.Log(r, "failed to hash direct upload", err)
+		jsonutil.InternalError(w, "Failed to check upload")
+		return
+	}
+
+	// Share the same content if it is already stored
+	storagePath := u.StoragePath
+	shared, err := h.blobs.Acquire(ctx, sum)
+	switch {
+	case err == nil:
+		storagePath = shared.StoragePath
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		h.errLog.Log(r, "failed to look up file content", err)
+		jsonutil.InternalError(w, "Failed to save file")
+		return
+	}
+
+	createdFile, err := h.fileStore.Create(ctx, file.CreateInput{
+		FolderID:    u.FolderID,
+		Name:        u.Name,
+		StoragePath: storagePath,
+		Size:        info.Size,
+		ContentType: u.ContentType,
+		Description: u.Description,
+		SHA256:      sum,
+		ScanStatus:  scanStatus,
+		CreatedByID: actor.UserID(),
+	})
+	if err != nil {
+		// Keep the object; the client can retry, or the cleanup job removes it
+		if shared != nil {
+			h.dropContent(ctx, storagePath)
+		}
+		h.errLog.Log(r, "failed to create file record", err)
+		jsonutil.InternalError(w, "Failed to save file record")
+		return
+	}
+
+	if shared != nil {
+		// The file uses the copy already stored, so the uploaded object goes
+		if err := h.uploads.Remove(ctx, u); err != nil {
+			// The cleanup job removes it once the upload expires
+			h.logger.Warn("failed to remove duplicate direct upload",
+				zap.String("upload_id", u.ID.Hex()),
+				zap.Error(err))
+		}
+	} else {
+		h.recordContent(r, sum, storagePath, info.Size)
+		if err := h.uploads.Release(ctx, u); err != nil {
+			// The object now belongs to the file, so the record must not be left
+			// for the cleanup job to find
+			h.logger.Error("failed to release direct upload",
+				zap.String("upload_id", u.ID.Hex()),
+				zap.String("file_id", createdFile.ID.Hex()),
+				zap.Error(err))
+		}
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &createdFile.ID, "file_uploaded", nil)
+
+	redirectURL := "/library?success=uploaded"
+	if u.FolderID != nil {
+		redirectURL = "/library/folder/" + u.FolderID.Hex() + "?success=uploaded"
+	}
+	jsonutil.OK(w, map[string]any{
+		"id":       createdFile.ID.Hex(),
+		"redirect": redirectURL,
+	})
+}
+
+// rejectDirectUpload removes an upload whose object failed a check and
+// writes the error.
+func (h *Handler) rejectDirectUpload(w http.ResponseWriter, r *http.Request, u *upload.Upload, status int, msg string) {
+	if err := h.uploads.Remove(r.Context(), u); err != nil {
+		h.errLog.Log(r, "failed to remove rejected upload", err)
+	}
+	jsonutil.Error(w, status, msg)
+}
+
+// readHead returns up to the first 512 bytes of a stored object.
+func (h *Handler) readHead(r *http.Request, path string) ([]byte, error) {
+	rc, err := h.fileStorage.Get(r.Context(), path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(rc, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// browserHeaders returns the headers the browser must send with a
+// presigned upload. Headers the browser sets itself, and may not set from
+// script, are dropped.
+func browserHeaders(signed map[string]string, contentType string) map[string]string {
+	headers := make(map[string]string, len(signed)+1)
+	for k, v := range signed {
+		switch http.CanonicalHeaderKey(k) {
+		case "Host", "Content-Length":
+			continue
+		}
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	if _, ok := headers["Content-Type"]; !ok && contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	return headers
+}
@@ -0,0 +1,31 @@
+package files
+
+import "testing"
+
+func TestBrowserHeaders(t *testing.T) {
+	got := browserHeaders(map[string]string{
+		"host":           "bucket.s3.amazonaws.com",
+		"Content-Length": "42",
+		"x-amz-acl":      "private",
+	}, "video/mp4")
+
+	want := map[string]string{
+		"X-Amz-Acl":    "private",
+		"Content-Type": "video/mp4",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("browserHeaders() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("browserHeaders()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestBrowserHeaders_KeepsSignedContentType(t *testing.T) {
+	got := browserHeaders(map[string]string{"content-type": "application/pdf"}, "video/mp4")
+	if got["Content-Type"] != "application/pdf" {
+		t.Errorf("Content-Type = %q, want the signed value", got["Content-Type"])
+	}
+}
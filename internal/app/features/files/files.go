@@ -2,20 +2,30 @@
 package files
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/blob"
 	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/fileaccess"
 	"github.com/dalemusser/stratasave/internal/app/store/folder"
+	"github.com/dalemusser/stratasave/internal/app/store/share"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/librarytrash"
+	"github.com/dalemusser/stratasave/internal/app/system/resumable"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/virusscan"
 	"github.com/dalemusser/waffle/pantry/storage"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/go-chi/chi/v5"
@@ -26,16 +36,36 @@ import (
 	"go.uber.org/zap"
 )
 
-const maxUploadSize = 32 << 20 // 32MB
+const (
+	multipartMemory   = 32 << 20 // Form data held in memory; the rest goes to temp files
+	multipartOverhead = 1 << 20  // Allowance for form fields besides the file
+	maxBatchFiles     = 20       // Most files accepted in one upload request
+	maxQuotaMB        = 10 << 20 // Largest folder quota, 10 TB
+)
 
 // Handler provides file management handlers.
 type Handler struct {
 	folderStore *folder.Store
 	fileStore   *file.Store
+	shareStore  *share.Store
+	blobs       *blob.Store
+	access      *fileaccess.Store
+	users       *userstore.Store
 	fileStorage storage.Store
 	errLog      *errorsfeature.ErrorLogger
 	auditLogger *auditlog.Logger
 	logger      *zap.Logger
+	uploads     *resumable.Manager  // nil if resumable uploads are off
+	direct      bool                // Browsers upload straight to storage
+	trash       *librarytrash.Trash // nil if trashed items can't be deleted by hand
+	policy      UploadPolicy
+	baseURL     string             // Public site URL for share links
+	scanner     *virusscan.Scanner // nil if uploads aren't scanned
+	quota       int64              // Most bytes the library may store (0 = no limit)
+	signedTTL   time.Duration      // Views and downloads go to signed storage URLs valid this long (0 = streamed)
+
+	storageOpts   StorageOptions // Storage settings for uploaded and copied files
+	storageChoice bool           // Uploaders can choose storage settings per upload
 }
 
 // NewHandler creates a new files Handler.
@@ -49,13 +79,46 @@ func NewHandler(
 	return &Handler{
 		folderStore: folder.New(db),
 		fileStore:   file.New(db),
+		shareStore:  share.New(db),
+		blobs:       blob.New(db),
+		access:      fileaccess.New(db),
+		users:       userstore.New(db),
 		fileStorage: fileStorage,
 		errLog:      errLog,
 		auditLogger: auditLogger,
 		logger:      logger,
+		policy:      UploadPolicy{MaxSize: defaultMaxUploadSize},
 	}
 }
 
+// SetUploadPolicy sets the size limit and content types accepted for
+// uploads.
+func (h *Handler) SetUploadPolicy(p UploadPolicy) {
+	h.policy = p
+}
+
+// SetResumableUploads enables chunked, resumable uploads, which lift the
+// single-request size limit.
+func (h *Handler) SetResumableUploads(m *resumable.Manager) {
+	h.uploads = m
+}
+
+// SetDirectUploads makes browsers upload files straight to storage with
+// presigned URLs, keeping file data off the app server. It needs
+// resumable uploads to be enabled and a storage backend that can presign
+// (S3).
+func (h *Handler) SetDirectUploads(enabled bool) {
+	h.direct = enabled
+}
+
+// SetSignedDownloads sends library views and downloads to signed storage
+// URLs (CloudFront, or S3 presigned GETs) valid for ttl, so file data is
+// served by the CDN or bucket rather than through the app server. A ttl
+// of 0 streams files through the app.
+func (h *Handler) SetSignedDownloads(ttl time.Duration) {
+	h.signedTTL = ttl
+}
+
 // Routes returns a chi.Router with file routes mounted.
 func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r := chi.NewRouter()
@@ -64,6 +127,7 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	// Browse routes (all authenticated users)
 	r.Get("/", h.browse)
 	r.Get("/folder/{id}", h.browse)
+	r.Get("/search", h.search)
 	r.Get("/folder/{id}/info_modal", h.folderInfoModal)
 	r.Get("/file/{id}/info_modal", h.fileInfoModal)
 	r.Get("/file/{id}/view", h.view)
@@ -79,15 +143,47 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 		r.Get("/folder/{id}/edit", h.showEditFolder)
 		r.Post("/folder/{id}", h.updateFolder)
 		r.Get("/folder/{id}/manage_modal", h.folderManageModal)
+		r.Get("/folder/{id}/move", h.showMoveFolder)
+		r.Post("/folder/{id}/move", h.moveFolder)
 		r.Post("/folder/{id}/delete", h.deleteFolder)
 
 		// File management
 		r.Get("/file/upload", h.showUpload)
 		r.Post("/file/upload", h.upload)
+		if h.uploads != nil {
+			r.Post("/file/uploads", h.createUpload)
+			r.Head("/file/uploads/{id}", h.uploadOffset)
+			r.Patch("/file/uploads/{id}", h.uploadChunk)
+			r.Delete("/file/uploads/{id}", h.cancelUpload)
+			if h.direct {
+				r.Post("/file/direct", h.createDirectUpload)
+				r.Post("/file/direct/{id}/complete", h.completeDirectUpload)
+			}
+		}
 		r.Get("/file/{id}/edit", h.showEditFile)
 		r.Post("/file/{id}", h.updateFile)
 		r.Get("/file/{id}/manage_modal", h.fileManageModal)
+		r.Get("/file/{id}/move", h.showMoveFile)
+		r.Post("/file/{id}/move", h.moveFile)
+		r.Get("/file/{id}/copy", h.showCopyFile)
+		r.Post("/file/{id}/copy", h.copyFile)
+		r.Get("/file/{id}/shares", h.showShares)
+		r.Post("/file/{id}/shares", h.createShare)
+		r.Post("/file/{id}/shares/{shareID}/revoke", h.revokeShare)
+		r.Get("/file/{id}/access", h.accessReport)
 		r.Post("/file/{id}/delete", h.deleteFile)
+
+		// Storage usage
+		r.Get("/storage", h.showStorage)
+
+		// Trash
+		r.Get("/trash", h.showTrash)
+		r.Post("/trash/file/{id}/restore", h.restoreFile)
+		r.Post("/trash/folder/{id}/restore", h.restoreFolder)
+		if h.trash != nil {
+			r.Post("/trash/file/{id}/delete", h.purgeFile)
+			r.Post("/trash/folder/{id}/delete", h.purgeFolder)
+		}
 	})
 
 	return r
@@ -120,6 +216,7 @@ type FileRow struct {
 	ContentType string
 	TypeIcon    string
 	IsViewable  bool
+	Tags        []string
 	CreatedAt   string
 	UpdatedAt   string
 }
@@ -137,6 +234,8 @@ type BrowseVM struct {
 	SortBy          string
 	SortOrder       string
 	TypeFilter      string
+	TagFilter       string
+	AllTags         []string // Tags in use, for the tag filter
 	SearchQuery     string
 	TotalFolders    int
 	TotalFiles      int
@@ -175,7 +274,7 @@ func (h *Handler) browse(w http.ResponseWriter, r *http.Request) {
 			ID:          f.ID.Hex(),
 			Name:        f.Name,
 			Description: f.Description,
-			UpdatedAt:   f.UpdatedAt.Format("Jan 2, 2006"),
+			UpdatedAt:   viewdata.FormatTime(r, f.UpdatedAt, "Jan 2, 2006"),
 		}
 	}
 
@@ -232,18 +331,20 @@ func (h *Handler) browse(w http.ResponseWriter, r *http.Request) {
 			Name:        f.Name,
 			Description: f.Description,
 			ItemCount:   itemCount,
-			UpdatedAt:   f.UpdatedAt.Format("Jan 2, 2006"),
+			UpdatedAt:   viewdata.FormatTime(r, f.UpdatedAt, "Jan 2, 2006"),
 		})
 	}
 
 	// Get files
 	typeFilter := r.URL.Query().Get("type")
+	tagFilter := r.URL.Query().Get("tag")
 	searchQuery := r.URL.Query().Get("q")
 	fileOpts := file.ListOptions{
 		SortBy:      sortBy,
 		SortOrder:   sortOrder,
 		ContentType: typeFilter,
 		Search:      searchQuery,
+		Tag:         tagFilter,
 	}
 	files, err := h.fileStore.ListByFolder(ctx, folderID, fileOpts)
 	if err != nil {
@@ -264,10 +365,17 @@ func (h *Handler) browse(w http.ResponseWriter, r *http.Request) {
 			ContentType: f.ContentType,
 			TypeIcon:    FileTypeIcon(f.ContentType),
 			IsViewable:  IsViewable(f.ContentType),
-			UpdatedAt:   f.UpdatedAt.Format("Jan 2, 2006"),
+			Tags:        f.Tags,
+			UpdatedAt:   viewdata.FormatTime(r, f.UpdatedAt, "Jan 2, 2006"),
 		})
 	}
 
+	// The filter works without the list; it just can't offer choices
+	allTags, err := h.fileStore.ListTags(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list tags", err)
+	}
+
 	// Determine sort order string for UI
 	sortOrderStr := "asc"
 	if sortOrder == -1 {
@@ -286,6 +394,8 @@ func (h *Handler) browse(w http.ResponseWriter, r *http.Request) {
 		SortBy:          sortBy,
 		SortOrder:       sortOrderStr,
 		TypeFilter:      typeFilter,
+		TagFilter:       tagFilter,
+		AllTags:         allTags,
 		SearchQuery:     searchQuery,
 		TotalFolders:    len(folderRows),
 		TotalFiles:      len(fileRows),
@@ -300,18 +410,28 @@ func (h *Handler) browse(w http.ResponseWriter, r *http.Request) {
 	case "folder_updated":
 		vm.Success = "Folder updated successfully"
 	case "folder_deleted":
-		vm.Success = "Folder deleted successfully"
+		vm.Success = "Folder moved to the trash"
+	case "folder_moved":
+		vm.Success = "Folder moved successfully"
 	case "uploaded":
 		vm.Success = "File uploaded successfully"
 	case "file_updated":
 		vm.Success = "File updated successfully"
 	case "file_deleted":
-		vm.Success = "File deleted successfully"
+		vm.Success = "File moved to the trash"
+	case "file_moved":
+		vm.Success = "File moved successfully"
+	case "file_copied":
+		vm.Success = "File copied successfully"
+	case "share_revoked":
+		vm.Success = "Share link revoked"
 	}
 
 	switch r.URL.Query().Get("error") {
 	case "delete_failed":
 		vm.Error = "Failed to delete item"
+	case "revoke_failed":
+		vm.Error = "Failed to revoke share link"
 	}
 
 	templates.Render(w, r, "files/browse", vm)
@@ -325,6 +445,7 @@ type FolderFormVM struct {
 	Description string
 	ParentID    string
 	ParentName  string
+	QuotaMB     string // Edit only; empty for no quota
 	Error       string
 }
 
@@ -470,6 +591,9 @@ func (h *Handler) showEditFolder(w http.ResponseWriter, r *http.Request) {
 		Name:        f.Name,
 		Description: f.Description,
 	}
+	if f.QuotaBytes > 0 {
+		vm.QuotaMB = strconv.FormatInt(f.QuotaBytes>>20, 10)
+	}
 	vm.Title = "Edit Folder"
 	vm.BackURL = backURL
 
@@ -502,6 +626,7 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	description := strings.TrimSpace(r.FormValue("description"))
+	quotaInput := strings.TrimSpace(r.FormValue("quota_mb"))
 
 	// Validate name
 	if name == "" {
@@ -510,6 +635,7 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 			ID:          id,
 			Name:        name,
 			Description: description,
+			QuotaMB:     quotaInput,
 			Error:       "Folder name is required",
 		}
 		vm.Title = "Edit Folder"
@@ -531,6 +657,7 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 			ID:          id,
 			Name:        name,
 			Description: description,
+			QuotaMB:     quotaInput,
 			Error:       "A folder with this name already exists",
 		}
 		vm.Title = "Edit Folder"
@@ -539,10 +666,29 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate quota (empty or 0 = no quota)
+	quotaMB, err := formInt(quotaInput)
+	if err != nil || quotaMB < 0 || quotaMB > maxQuotaMB {
+		vm := FolderFormVM{
+			BaseVM:      viewdata.New(r),
+			ID:          id,
+			Name:        name,
+			Description: description,
+			QuotaMB:     quotaInput,
+			Error:       fmt.Sprintf("Quota must be a whole number of MB from 0 to %d", maxQuotaMB),
+		}
+		vm.Title = "Edit Folder"
+		vm.BackURL = "/library"
+		templates.Render(w, r, "files/folder_edit", vm)
+		return
+	}
+	quotaBytes := int64(quotaMB) << 20
+
 	// Update folder
 	input := folder.UpdateInput{
 		Name:        &name,
 		Description: &description,
+		QuotaBytes:  &quotaBytes,
 	}
 	if err := h.folderStore.Update(ctx, objID, input); err != nil {
 		h.errLog.Log(r, "failed to update folder", err)
@@ -552,7 +698,11 @@ func (h *Handler) updateFolder(w http.ResponseWriter, r *http.Request) {
 
 	// Audit log
 	actorID := actor.UserID()
-	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_updated", nil)
+	changes := audit.Diff(
+		folderAuditFields(f.Name, f.Description, f.QuotaBytes),
+		folderAuditFields(name, description, quotaBytes),
+	)
+	h.auditLogger.LogAdminChange(r, &actorID, &objID, "folder_updated", changes)
 
 	// Redirect to parent folder
 	redirectURL := "/library?success=folder_updated"
@@ -648,51 +798,14 @@ func (h *Handler) folderInfoModal(w http.ResponseWriter, r *http.Request) {
 		Name:        f.Name,
 		Description: f.Description,
 		ItemCount:   itemCount,
-		CreatedAt:   f.CreatedAt.Format("Jan 2, 2006 3:04 PM"),
-		UpdatedAt:   f.UpdatedAt.Format("Jan 2, 2006 3:04 PM"),
+		CreatedAt:   viewdata.FormatTime(r, f.CreatedAt, "Jan 2, 2006 3:04 PM"),
+		UpdatedAt:   viewdata.FormatTime(r, f.UpdatedAt, "Jan 2, 2006 3:04 PM"),
 	}
 
 	templates.RenderSnippet(w, "files/folder_info_modal", vm)
 }
 
-// deleteFolderContents recursively deletes all files and subfolders within a folder.
-func (h *Handler) deleteFolderContents(ctx context.Context, folderID primitive.ObjectID) error {
-	// Get and delete all files in this folder
-	files, err := h.fileStore.ListByFolder(ctx, &folderID, file.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("listing files: %w", err)
-	}
-	for _, f := range files {
-		// Delete from storage
-		if err := h.fileStorage.Delete(ctx, f.StoragePath); err != nil {
-			h.logger.Warn("failed to delete file from storage",
-				zap.String("path", f.StoragePath),
-				zap.Error(err))
-		}
-		// Delete from database
-		if err := h.fileStore.Delete(ctx, f.ID); err != nil {
-			return fmt.Errorf("deleting file %s: %w", f.ID.Hex(), err)
-		}
-	}
-
-	// Get and recursively delete all subfolders
-	subfolders, err := h.folderStore.ListByParent(ctx, &folderID, folder.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("listing subfolders: %w", err)
-	}
-	for _, sf := range subfolders {
-		if err := h.deleteFolderContents(ctx, sf.ID); err != nil {
-			return err
-		}
-		if err := h.folderStore.Delete(ctx, sf.ID); err != nil {
-			return fmt.Errorf("deleting subfolder %s: %w", sf.ID.Hex(), err)
-		}
-	}
-
-	return nil
-}
-
-// deleteFolder deletes a folder.
+// deleteFolder moves a folder, with everything in it, to the trash.
 func (h *Handler) deleteFolder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	actor, _ := auth.CurrentUser(r)
@@ -710,31 +823,34 @@ func (h *Handler) deleteFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete all contents recursively (files and subfolders)
-	if err := h.deleteFolderContents(ctx, objID); err != nil {
-		h.errLog.Log(r, "failed to delete folder contents", err)
-		redirectURL := "/library?error=delete_failed"
-		if f.ParentID != nil {
-			redirectURL = "/library/folder/" + f.ParentID.Hex() + "?error=delete_failed"
-		}
-		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
-		return
+	failURL := "/library?error=delete_failed"
+	if f.ParentID != nil {
+		failURL = "/library/folder/" + f.ParentID.Hex() + "?error=delete_failed"
 	}
 
-	// Delete the folder itself
-	if err := h.folderStore.Delete(ctx, objID); err != nil {
-		h.errLog.Log(r, "failed to delete folder", err)
-		redirectURL := "/library?error=delete_failed"
-		if f.ParentID != nil {
-			redirectURL = "/library/folder/" + f.ParentID.Hex() + "?error=delete_failed"
-		}
-		http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+	// Everything inside is trashed along with the folder, so restoring the
+	// folder brings it all back
+	descendants, err := h.folderStore.DescendantIDs(ctx, objID)
+	if err != nil {
+		h.errLog.Log(r, "failed to list subfolders", err)
+		http.Redirect(w, r, failURL, http.StatusSeeOther)
+		return
+	}
+	now := time.Now()
+	if err := h.fileStore.TrashInFolders(ctx, append(descendants, objID), objID, now); err != nil {
+		h.errLog.Log(r, "failed to trash folder contents", err)
+		http.Redirect(w, r, failURL, http.StatusSeeOther)
+		return
+	}
+	if err := h.folderStore.Trash(ctx, objID, descendants, now); err != nil {
+		h.errLog.Log(r, "failed to trash folder", err)
+		http.Redirect(w, r, failURL, http.StatusSeeOther)
 		return
 	}
 
 	// Audit log
 	actorID := actor.UserID()
-	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_deleted", nil)
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_trashed", nil)
 
 	// Redirect to parent folder
 	redirectURL := "/library?success=folder_deleted"
@@ -751,6 +867,15 @@ type FileUploadVM struct {
 	FolderName string
 	Error      string
 	MaxSize    string
+	ChunkSize  int64 // Bytes per chunk for resumable uploads (0 = single request)
+	Direct     bool  // Upload straight to storage with a presigned URL
+	Results    []UploadResult
+
+	// Per-upload storage settings, when they can be chosen
+	ChooseStorage  bool
+	Storage        StorageOptions // The defaults
+	Encryptions    []string
+	StorageClasses []string
 }
 
 // showUpload displays the file upload form.
@@ -774,32 +899,44 @@ func (h *Handler) showUpload(w http.ResponseWriter, r *http.Request) {
 		BaseVM:     viewdata.New(r),
 		FolderID:   folderID,
 		FolderName: folderName,
-		MaxSize:    "32 MB",
+		MaxSize:    FormatFileSize(h.policy.MaxSize),
+	}
+	if h.uploads != nil {
+		vm.ChunkSize = resumable.ChunkSize
+		vm.Direct = h.direct
 	}
-	vm.Title = "Upload File"
+	h.setStorageChoices(&vm)
+	vm.Title = "Upload Files"
 	vm.BackURL = backURL
 
 	templates.Render(w, r, "files/file_upload", vm)
 }
 
-// upload handles file upload.
-func (h *Handler) upload(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	actor, _ := auth.CurrentUser(r)
+// renderUploadError redisplays the upload form with an error.
+func (h *Handler) renderUploadError(w http.ResponseWriter, r *http.Request, folderID, msg string) {
+	h.renderUploadResults(w, r, folderID, msg, nil)
+}
+
+// UploadResult reports how one file of a multi-file upload went.
+type UploadResult struct {
+	Name  string
+	Error string // Empty if the file was uploaded
+}
 
-	// Parse multipart form
-	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
+// upload handles file upload. Several files can be sent in one request;
+// each is checked and stored on its own, so one bad file doesn't stop the
+// rest.
+func (h *Handler) upload(w http.ResponseWriter, r *http.Request) {
+	// Parse multipart form, allowing for the form's other fields. Files sent
+	// together share the size limit; the upload page sends larger batches
+	// one file at a time.
+	r.Body = http.MaxBytesReader(w, r.Body, h.policy.MaxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
 		h.errLog.Log(r, "failed to parse multipart form", err)
-		vm := FileUploadVM{
-			BaseVM:  viewdata.New(r),
-			Error:   "File too large (max 32MB)",
-			MaxSize: "32 MB",
-		}
-		vm.Title = "Upload File"
-		vm.BackURL = "/library"
-		templates.Render(w, r, "files/file_upload", vm)
+		h.renderUploadError(w, r, "", "File too large (max "+FormatFileSize(h.policy.MaxSize)+")")
 		return
 	}
+	defer r.MultipartForm.RemoveAll()
 
 	// Get folder ID
 	folderIDStr := r.FormValue("folder_id")
@@ -811,52 +948,115 @@ func (h *Handler) upload(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get uploaded file
-	uploadedFile, header, err := r.FormFile("file")
-	if err != nil {
-		vm := FileUploadVM{
-			BaseVM:     viewdata.New(r),
-			FolderID:   folderIDStr,
-			Error:      "Please select a file to upload",
-			MaxSize:    "32 MB",
-		}
-		vm.Title = "Upload File"
-		vm.BackURL = "/library"
-		templates.Render(w, r, "files/file_upload", vm)
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		h.renderUploadError(w, r, folderIDStr, "Please select a file to upload")
+		return
+	}
+	if len(headers) > maxBatchFiles {
+		h.renderUploadError(w, r, folderIDStr, fmt.Sprintf("Too many files (max %d at a time)", maxBatchFiles))
 		return
 	}
-	defer uploadedFile.Close()
 
 	description := strings.TrimSpace(r.FormValue("description"))
+	opts, ok := h.uploadStorageOptions(r.FormValue("encryption"), r.FormValue("storage_class"), r.FormValue("cache_control"))
+	if !ok {
+		h.renderUploadError(w, r, folderIDStr, "Invalid storage settings")
+		return
+	}
 
-	// Generate storage path: files/YYYY/MM/uuid-filename
-	now := time.Now().UTC()
-	ext := filepath.Ext(header.Filename)
-	uniqueName := fmt.Sprintf("%s%s", uuid.New().String()[:8], ext)
-	storagePath := fmt.Sprintf("files/%04d/%02d/%s", now.Year(), int(now.Month()), uniqueName)
+	results := make([]UploadResult, 0, len(headers))
+	failed := 0
+	for _, header := range headers {
+		msg := h.storeUpload(r, header, folderID, description, opts)
+		if msg != "" {
+			failed++
+		}
+		results = append(results, UploadResult{Name: header.Filename, Error: msg})
+	}
+
+	if failed > 0 {
+		if len(results) == 1 {
+			h.renderUploadError(w, r, folderIDStr, results[0].Error)
+			return
+		}
+		msg := fmt.Sprintf("%d of %d files could not be uploaded", failed, len(results))
+		h.renderUploadResults(w, r, folderIDStr, msg, results)
+		return
+	}
 
-	// Get content type
-	contentType := header.Header.Get("Content-Type")
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	// Redirect back to folder
+	redirectURL := "/library?success=uploaded"
+	if folderID != nil {
+		redirectURL = "/library/folder/" + folderID.Hex() + "?success=uploaded"
 	}
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+}
 
-	// Upload to storage
-	opts := &storage.PutOptions{
-		ContentType: contentType,
+// storeUpload stores one uploaded file and records it in the library. It
+// returns a message for the user if the file was not uploaded.
+func (h *Handler) storeUpload(r *http.Request, header *multipart.FileHeader, folderID *primitive.ObjectID, description string, opts StorageOptions) string {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	if header.Size > h.policy.MaxSize {
+		return "File too large (max " + FormatFileSize(h.policy.MaxSize) + ")"
 	}
-	if err := h.fileStorage.Put(ctx, storagePath, uploadedFile, opts); err != nil {
-		h.errLog.Log(r, "failed to upload file", err)
-		vm := FileUploadVM{
-			BaseVM:     viewdata.New(r),
-			FolderID:   folderIDStr,
-			Error:      "Failed to upload file",
-			MaxSize:    "32 MB",
+	if err := h.checkQuota(ctx, folderID, header.Size); err != nil {
+		var qe *quotaError
+		if errors.As(err, &qe) {
+			return qe.Error()
 		}
-		vm.Title = "Upload File"
-		vm.BackURL = "/library"
-		templates.Render(w, r, "files/file_upload", vm)
-		return
+		h.errLog.Log(r, "failed to check storage quota", err)
+		return "Failed to upload file"
+	}
+
+	uploadedFile, err := header.Open()
+	if err != nil {
+		h.errLog.Log(r, "failed to open uploaded file", err)
+		return "Failed to upload file"
+	}
+	defer uploadedFile.Close()
+
+	// Check the content type, sniffing the content rather than trusting
+	// the browser's header alone
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(uploadedFile, head)
+	if _, err := uploadedFile.Seek(0, io.SeekStart); err != nil {
+		h.errLog.Log(r, "failed to rewind uploaded file", err)
+		return "Failed to upload file"
+	}
+	contentType, err := h.policy.CheckType(header.Header.Get("Content-Type"), head[:n])
+	if err != nil {
+		return "This file type is not allowed"
+	}
+
+	// Scan before anything is stored, then rewind for the upload
+	scanStatus, err := h.scanUpload(r, header.Filename, uploadedFile)
+	if err != nil {
+		_, msg := scanFailure(err)
+		return msg
+	}
+	if _, err := uploadedFile.Seek(0, io.SeekStart); err != nil {
+		h.errLog.Log(r, "failed to rewind uploaded file", err)
+		return "Failed to upload file"
+	}
+	sum, err := hashContent(uploadedFile)
+	if err == nil {
+		_, err = uploadedFile.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to hash uploaded file", err)
+		return "Failed to upload file"
+	}
+
+	// Upload to storage, unless the same content is already stored
+	storagePath, err := h.storeContent(r, sum, header.Filename, header.Size, func(path string) error {
+		return h.fileStorage.Put(ctx, path, uploadedFile, opts.putOptions(contentType))
+	})
+	if err != nil {
+		h.errLog.Log(r, "failed to upload file", err)
+		return "Failed to upload file"
 	}
 
 	// Create database record
@@ -867,36 +1067,46 @@ func (h *Handler) upload(w http.ResponseWriter, r *http.Request) {
 		Size:        header.Size,
 		ContentType: contentType,
 		Description: description,
+		SHA256:      sum,
+		ScanStatus:  scanStatus,
 		CreatedByID: actor.UserID(),
 	}
 
 	createdFile, err := h.fileStore.Create(ctx, input)
 	if err != nil {
 		// Clean up uploaded file on DB error
-		_ = h.fileStorage.Delete(ctx, storagePath)
-		h.errLog.Log(r, "failed to create file record", err)
-		vm := FileUploadVM{
-			BaseVM:     viewdata.New(r),
-			FolderID:   folderIDStr,
-			Error:      "Failed to save file record",
-			MaxSize:    "32 MB",
+		h.dropContent(ctx, storagePath)
+		if mongo.IsDuplicateKeyError(err) {
+			return "A file with this name already exists in this folder"
 		}
-		vm.Title = "Upload File"
-		vm.BackURL = "/library"
-		templates.Render(w, r, "files/file_upload", vm)
-		return
+		h.errLog.Log(r, "failed to create file record", err)
+		return "Failed to save file record"
 	}
 
 	// Audit log
 	actorID := actor.UserID()
 	h.auditLogger.LogAdminEvent(r, &actorID, &createdFile.ID, "file_uploaded", nil)
+	return ""
+}
 
-	// Redirect back to folder
-	redirectURL := "/library?success=uploaded"
-	if folderID != nil {
-		redirectURL = "/library/folder/" + folderID.Hex() + "?success=uploaded"
-	}
-	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+// renderUploadResults redisplays the upload form with an error and, for
+// a multi-file upload, how each file went.
+func (h *Handler) renderUploadResults(w http.ResponseWriter, r *http.Request, folderID, msg string, results []UploadResult) {
+	vm := FileUploadVM{
+		BaseVM:   viewdata.New(r),
+		FolderID: folderID,
+		Error:    msg,
+		Results:  results,
+		MaxSize:  FormatFileSize(h.policy.MaxSize),
+	}
+	if h.uploads != nil {
+		vm.ChunkSize = resumable.ChunkSize
+		vm.Direct = h.direct
+	}
+	h.setStorageChoices(&vm)
+	vm.Title = "Upload Files"
+	vm.BackURL = "/library"
+	templates.Render(w, r, "files/file_upload", vm)
 }
 
 // FileFormVM is the view model for file edit form.
@@ -905,6 +1115,7 @@ type FileFormVM struct {
 	ID          string
 	Name        string
 	Description string
+	Tags        string // Comma-separated
 	Size        string
 	ContentType string
 	Error       string
@@ -935,6 +1146,7 @@ func (h *Handler) showEditFile(w http.ResponseWriter, r *http.Request) {
 		ID:          id,
 		Name:        f.Name,
 		Description: f.Description,
+		Tags:        strings.Join(f.Tags, ", "),
 		Size:        FormatFileSize(f.Size),
 		ContentType: f.ContentType,
 	}
@@ -970,6 +1182,8 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 
 	name := strings.TrimSpace(r.FormValue("name"))
 	description := strings.TrimSpace(r.FormValue("description"))
+	tagsInput := r.FormValue("tags")
+	tags, tagsErr := ParseTags(tagsInput)
 
 	// Validate name
 	if name == "" {
@@ -978,6 +1192,7 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 			ID:          id,
 			Name:        name,
 			Description: description,
+			Tags:        tagsInput,
 			Size:        FormatFileSize(f.Size),
 			ContentType: f.ContentType,
 			Error:       "File name is required",
@@ -988,6 +1203,24 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Validate tags
+	if tagsErr != nil {
+		vm := FileFormVM{
+			BaseVM:      viewdata.New(r),
+			ID:          id,
+			Name:        name,
+			Description: description,
+			Tags:        tagsInput,
+			Size:        FormatFileSize(f.Size),
+			ContentType: f.ContentType,
+			Error:       "Invalid tags: " + tagsErr.Error(),
+		}
+		vm.Title = "Edit File"
+		vm.BackURL = "/library"
+		templates.Render(w, r, "files/file_edit", vm)
+		return
+	}
+
 	// Check for duplicate name (excluding self)
 	exists, err := h.fileStore.NameExistsInFolder(ctx, name, f.FolderID, &objID)
 	if err != nil {
@@ -1001,6 +1234,7 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 			ID:          id,
 			Name:        name,
 			Description: description,
+			Tags:        tagsInput,
 			Size:        FormatFileSize(f.Size),
 			ContentType: f.ContentType,
 			Error:       "A file with this name already exists",
@@ -1015,6 +1249,7 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 	input := file.UpdateInput{
 		Name:        &name,
 		Description: &description,
+		Tags:        &tags,
 	}
 	if err := h.fileStore.Update(ctx, objID, input); err != nil {
 		h.errLog.Log(r, "failed to update file", err)
@@ -1024,7 +1259,11 @@ func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
 
 	// Audit log
 	actorID := actor.UserID()
-	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "file_updated", nil)
+	changes := audit.Diff(
+		fileAuditFields(f.Name, f.Description, f.Tags),
+		fileAuditFields(name, description, tags),
+	)
+	h.auditLogger.LogAdminChange(r, &actorID, &objID, "file_updated", changes)
 
 	// Redirect to folder
 	redirectURL := "/library?success=file_updated"
@@ -1043,6 +1282,7 @@ type FileManageModalVM struct {
 	ContentType string
 	TypeIcon    string
 	IsViewable  bool
+	Shares      []ShareLink // Links not yet revoked
 	BackURL     string
 	CSRFToken   string
 }
@@ -1079,6 +1319,11 @@ func (h *Handler) fileManageModal(w http.ResponseWriter, r *http.Request) {
 		CSRFToken:   csrf.Token(r),
 	}
 
+	// The modal is still useful without the links
+	if vm.Shares, err = h.shareLinks(r, objID); err != nil {
+		h.errLog.Log(r, "failed to list share links", err)
+	}
+
 	templates.RenderSnippet(w, "files/file_manage_modal", vm)
 }
 
@@ -1091,12 +1336,20 @@ type FileInfoModalVM struct {
 	ContentType string
 	TypeIcon    string
 	IsViewable  bool
+	SHA256      string // Empty for files uploaded before hashes were kept
+	ScanStatus  string
+	Views       int64
+	Downloads   int64
+	LastAccess  string // Empty if never viewed or downloaded
+	IsAdmin     bool
 	CreatedAt   string
 	UpdatedAt   string
 }
 
 // fileInfoModal displays the info modal for a file.
 func (h *Handler) fileInfoModal(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
 	id := chi.URLParam(r, "id")
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -1118,14 +1371,22 @@ func (h *Handler) fileInfoModal(w http.ResponseWriter, r *http.Request) {
 		ContentType: f.ContentType,
 		TypeIcon:    FileTypeIcon(f.ContentType),
 		IsViewable:  IsViewable(f.ContentType),
-		CreatedAt:   f.CreatedAt.Format("Jan 2, 2006 3:04 PM"),
-		UpdatedAt:   f.UpdatedAt.Format("Jan 2, 2006 3:04 PM"),
+		SHA256:      f.SHA256,
+		ScanStatus:  scanStatusLabel(f.ScanStatus),
+		Views:       f.ViewCount,
+		Downloads:   f.DownloadCount,
+		IsAdmin:     actor.Role == "admin",
+		CreatedAt:   viewdata.FormatTime(r, f.CreatedAt, "Jan 2, 2006 3:04 PM"),
+		UpdatedAt:   viewdata.FormatTime(r, f.UpdatedAt, "Jan 2, 2006 3:04 PM"),
+	}
+	if f.LastAccessedAt != nil {
+		vm.LastAccess = viewdata.FormatTime(r, *f.LastAccessedAt, "Jan 2, 2006 3:04 PM")
 	}
 
 	templates.RenderSnippet(w, "files/file_info_modal", vm)
 }
 
-// deleteFile deletes a file.
+// deleteFile moves a file to the trash.
 func (h *Handler) deleteFile(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	actor, _ := auth.CurrentUser(r)
@@ -1143,17 +1404,8 @@ func (h *Handler) deleteFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete from storage
-	if err := h.fileStorage.Delete(ctx, f.StoragePath); err != nil {
-		h.logger.Warn("failed to delete file from storage",
-			zap.String("path", f.StoragePath),
-			zap.Error(err))
-		// Continue with DB deletion anyway
-	}
-
-	// Delete from database
-	if err := h.fileStore.Delete(ctx, objID); err != nil {
-		h.errLog.Log(r, "failed to delete file", err)
+	if err := h.fileStore.Trash(ctx, objID, time.Now()); err != nil {
+		h.errLog.Log(r, "failed to trash file", err)
 		redirectURL := "/library?error=delete_failed"
 		if f.FolderID != nil {
 			redirectURL = "/library/folder/" + f.FolderID.Hex() + "?error=delete_failed"
@@ -1164,7 +1416,7 @@ func (h *Handler) deleteFile(w http.ResponseWriter, r *http.Request) {
 
 	// Audit log
 	actorID := actor.UserID()
-	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "file_deleted", nil)
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "file_trashed", nil)
 
 	// Redirect to folder
 	redirectURL := "/library?success=file_deleted"
@@ -1191,6 +1443,12 @@ func (h *Handler) view(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Send the browser to the CDN or bucket when signed downloads are on
+	if h.redirectToSigned(w, r, f, "inline") {
+		h.recordAccess(r, f, fileaccess.KindView, nil)
+		return
+	}
+
 	// Try to get the file content and serve it
 	reader, err := h.fileStorage.Get(ctx, f.StoragePath)
 	if err != nil {
@@ -1204,12 +1462,10 @@ func (h *Handler) view(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", f.ContentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", f.Name))
 
-	// Stream the file
-	if _, err := io.Copy(w, reader); err != nil {
-		h.logger.Warn("failed to stream file",
-			zap.String("path", f.StoragePath),
-			zap.Error(err))
-	}
+	h.recordAccess(r, f, fileaccess.KindView, nil)
+
+	// Stream the file, or the range asked for
+	h.serveContent(w, r, f, reader)
 }
 
 // download handles file download.
@@ -1229,6 +1485,12 @@ func (h *Handler) download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Send the browser to the CDN or bucket when signed downloads are on
+	if h.redirectToSigned(w, r, f, "attachment") {
+		h.recordAccess(r, f, fileaccess.KindDownload, nil)
+		return
+	}
+
 	// Try to get the file content and serve it
 	reader, err := h.fileStorage.Get(ctx, f.StoragePath)
 	if err != nil {
@@ -1242,10 +1504,16 @@ func (h *Handler) download(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", f.ContentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", f.Name))
 
-	// Stream the file
-	if _, err := io.Copy(w, reader); err != nil {
-		h.logger.Warn("failed to stream file",
-			zap.String("path", f.StoragePath),
-			zap.Error(err))
-	}
+	h.recordAccess(r, f, fileaccess.KindDownload, nil)
+
+	// Stream the file, or the range asked for
+	h.serveContent(w, r, f, reader)
+}
+
+// newStoragePath generates a storage path for an uploaded file:
+// files/YYYY/MM/uuid-prefix.ext
+func newStoragePath(filename string) string {
+	now := time.Now().UTC()
+	uniqueName := fmt.Sprintf("%s%s", uuid.New().String()[:8], filepath.Ext(filename))
+	return fmt.Sprintf("files/%04d/%02d/%s", now.Year(), int(now.Month()), uniqueName)
 }
@@ -1,6 +1,8 @@
 package files
 
 import (
+	"bytes"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -504,10 +506,10 @@ func TestRoutes(t *testing.T) {
 	}
 }
 
-func TestMaxUploadSize(t *testing.T) {
-	// Verify the max upload size constant
-	if maxUploadSize != 32<<20 {
-		t.Errorf("maxUploadSize = %d, want %d (32MB)", maxUploadSize, 32<<20)
+func TestDefaultMaxUploadSize(t *testing.T) {
+	// Verify the limit used until SetUploadPolicy is called
+	if defaultMaxUploadSize != 32<<20 {
+		t.Errorf("defaultMaxUploadSize = %d, want %d (32MB)", defaultMaxUploadSize, 32<<20)
 	}
 }
 
@@ -597,3 +599,36 @@ func TestFormParsing(t *testing.T) {
 		t.Errorf("parent_id = %q, want %q", got, "abc123")
 	}
 }
+
+func TestMultiFileFormParsing(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatalf("CreateFormFile() error = %v", err)
+		}
+		fw.Write([]byte("content of " + name))
+	}
+	mw.WriteField("description", "batch")
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/file/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	if err := req.ParseMultipartForm(multipartMemory); err != nil {
+		t.Fatalf("ParseMultipartForm() error = %v", err)
+	}
+	defer req.MultipartForm.RemoveAll()
+
+	headers := req.MultipartForm.File["file"]
+	if len(headers) != 3 {
+		t.Fatalf("got %d files, want 3", len(headers))
+	}
+	if headers[1].Filename != "b.txt" {
+		t.Errorf("second file = %q, want %q", headers[1].Filename, "b.txt")
+	}
+	if len(headers) > maxBatchFiles {
+		t.Errorf("3 files exceed maxBatchFiles (%d)", maxBatchFiles)
+	}
+}
@@ -0,0 +1,401 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/folder"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxCopyNames is how many "(copy N)" names are tried before giving up.
+const maxCopyNames = 100
+
+// MoveTarget is a folder that can be chosen as a destination.
+type MoveTarget struct {
+	ID       string
+	Path     string // e.g. "Videos / 2024"
+	Disabled bool   // The folder being moved, or inside it
+}
+
+// MoveVM is the view model for the move and copy forms.
+type MoveVM struct {
+	viewdata.BaseVM
+	Kind     string // "file" or "folder"
+	Action   string // "move" or "copy"
+	ID       string
+	Name     string
+	Selected string // Current folder ID ("" = root)
+	Targets  []MoveTarget
+	Error    string
+}
+
+// showMoveFile displays the form for moving a file to another folder.
+func (h *Handler) showMoveFile(w http.ResponseWriter, r *http.Request) {
+	h.showFileTarget(w, r, "move")
+}
+
+// showCopyFile displays the form for copying a file.
+func (h *Handler) showCopyFile(w http.ResponseWriter, r *http.Request) {
+	h.showFileTarget(w, r, "copy")
+}
+
+func (h *Handler) showFileTarget(w http.ResponseWriter, r *http.Request, action string) {
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+	h.renderMove(w, r, "file", action, f.ID, f.Name, f.FolderID, nil, "")
+}
+
+// moveFile moves a file to another folder.
+func (h *Handler) moveFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+	target, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if !sameFolder(f.FolderID, target) {
+		exists, err := h.fileStore.NameExistsInFolder(ctx, f.Name, target, &f.ID)
+		if err != nil {
+			h.errLog.Log(r, "failed to check file name", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			h.renderMove(w, r, "file", "move", f.ID, f.Name, target, nil, "A file with this name already exists in that folder")
+			return
+		}
+
+		if err := h.fileStore.Move(ctx, f.ID, target); err != nil {
+			h.errLog.Log(r, "failed to move file", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		actorID := actor.UserID()
+		h.auditLogger.LogAdminEvent(r, &actorID, &f.ID, "file_moved", map[string]string{
+			"from": folderHex(f.FolderID),
+			"to":   folderHex(target),
+		})
+	}
+
+	http.Redirect(w, r, folderURL(target)+"?success=file_moved", http.StatusSeeOther)
+}
+
+// copyFile duplicates a file into a folder. The copy shares the stored
+// content, which is only duplicated for files stored before content was
+// shared. If the name is taken there, the copy is named "name (copy).ext".
+func (h *Handler) copyFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+	target, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	name, err := h.freeFileName(ctx, f.Name, target)
+	if err != nil {
+		h.errLog.Log(r, "failed to check file name", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if name == "" {
+		h.renderMove(w, r, "file", "copy", f.ID, f.Name, target, nil, "Too many copies of this file already exist in that folder")
+		return
+	}
+
+	storagePath := f.StoragePath
+	if _, err := h.blobs.AcquirePath(ctx, f.StoragePath); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			h.errLog.Log(r, "failed to look up file content", err)
+			h.renderMove(w, r, "file", "copy", f.ID, f.Name, target, nil, "Failed to copy file")
+			return
+		}
+		storagePath = newStoragePath(f.Name)
+		if err := h.copyObject(ctx, f.StoragePath, storagePath, f.ContentType); err != nil {
+			h.errLog.Log(r, "failed to copy file content", err)
+			h.renderMove(w, r, "file", "copy", f.ID, f.Name, target, nil, "Failed to copy file")
+			return
+		}
+	}
+
+	copied, err := h.fileStore.Create(ctx, file.CreateInput{
+		FolderID:    target,
+		Name:        name,
+		StoragePath: storagePath,
+		Size:        f.Size,
+		ContentType: f.ContentType,
+		Description: f.Description,
+		Tags:        f.Tags,
+		SHA256:      f.SHA256,
+		ScanStatus:  f.ScanStatus,
+		CreatedByID: actor.UserID(),
+	})
+	if err != nil {
+		// Clean up copied content on DB error
+		h.dropContent(ctx, storagePath)
+		h.errLog.Log(r, "failed to create file record", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &copied.ID, "file_copied", map[string]string{
+		"source_id": f.ID.Hex(),
+		"to":        folderHex(target),
+	})
+
+	http.Redirect(w, r, folderURL(target)+"?success=file_copied", http.StatusSeeOther)
+}
+
+// showMoveFolder displays the form for moving a folder under another
+// parent.
+func (h *Handler) showMoveFolder(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.loadFolder(w, r)
+	if !ok {
+		return
+	}
+	h.renderMove(w, r, "folder", "move", f.ID, f.Name, f.ParentID, &f.ID, "")
+}
+
+// moveFolder moves a folder, with everything in it, under another parent.
+func (h *Handler) moveFolder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	f, ok := h.loadFolder(w, r)
+	if !ok {
+		return
+	}
+	target, ok := h.parseTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if !sameFolder(f.ParentID, target) {
+		exists, err := h.folderStore.NameExistsInParent(ctx, f.Name, target, &f.ID)
+		if err != nil {
+			h.errLog.Log(r, "failed to check folder name", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			h.renderMove(w, r, "folder", "move", f.ID, f.Name, target, &f.ID, "A folder with this name already exists there")
+			return
+		}
+
+		err = h.folderStore.Move(ctx, f.ID, target)
+		if errors.Is(err, folder.ErrCycle) {
+			h.renderMove(w, r, "folder", "move", f.ID, f.Name, f.ParentID, &f.ID, "A folder cannot be moved into itself or one of its subfolders")
+			return
+		}
+		if err != nil {
+			h.errLog.Log(r, "failed to move folder", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		actorID := actor.UserID()
+		h.auditLogger.LogAdminEvent(r, &actorID, &f.ID, "folder_moved", map[string]string{
+			"from": folderHex(f.ParentID),
+			"to":   folderHex(target),
+		})
+	}
+
+	http.Redirect(w, r, folderURL(target)+"?success=folder_moved", http.StatusSeeOther)
+}
+
+// renderMove renders the move/copy form. exclude is a folder that can't be
+// chosen, along with its subfolders (the folder being moved).
+func (h *Handler) renderMove(w http.ResponseWriter, r *http.Request, kind, action string, id primitive.ObjectID, name string, selected, exclude *primitive.ObjectID, errMsg string) {
+	folders, err := h.folderStore.ListAll(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to list folders", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vm := MoveVM{
+		BaseVM:   viewdata.New(r),
+		Kind:     kind,
+		Action:   action,
+		ID:       id.Hex(),
+		Name:     name,
+		Selected: folderHex(selected),
+		Targets:  moveTargets(folders, exclude),
+		Error:    errMsg,
+	}
+	switch {
+	case kind == "folder":
+		vm.Title = "Move Folder"
+	case action == "copy":
+		vm.Title = "Copy File"
+	default:
+		vm.Title = "Move File"
+	}
+	vm.BackURL = folderURL(selected)
+
+	templates.Render(w, r, "files/move", vm)
+}
+
+// loadFile returns the file named in the URL, or writes a 404.
+func (h *Handler) loadFile(w http.ResponseWriter, r *http.Request) (*models.File, bool) {
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	f, err := h.fileStore.GetByID(r.Context(), objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return f, true
+}
+
+// loadFolder returns the folder named in the URL, or writes a 404.
+func (h *Handler) loadFolder(w http.ResponseWriter, r *http.Request) (*models.Folder, bool) {
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	f, err := h.folderStore.GetByID(r.Context(), objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return f, true
+}
+
+// parseTarget returns the destination folder from the form (nil for the
+// root). It writes an error and returns false if the folder doesn't exist.
+func (h *Handler) parseTarget(w http.ResponseWriter, r *http.Request) (*primitive.ObjectID, bool) {
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, false
+	}
+	idStr := r.FormValue("folder_id")
+	if idStr == "" {
+		return nil, true
+	}
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return nil, false
+	}
+	if _, err := h.folderStore.GetByID(r.Context(), id); err != nil {
+		http.Error(w, "Destination folder not found", http.StatusBadRequest)
+		return nil, false
+	}
+	return &id, true
+}
+
+// freeFileName returns name, or the first "(copy N)" variant of it not
+// already used in folderID. It returns "" if none is free.
+func (h *Handler) freeFileName(ctx context.Context, name string, folderID *primitive.ObjectID) (string, error) {
+	for n := 0; n <= maxCopyNames; n++ {
+		candidate := CopyName(name, n)
+		exists, err := h.fileStore.NameExistsInFolder(ctx, candidate, folderID, nil)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", nil
+}
+
+// copyObject copies stored content from src to dst with the default
+// storage settings.
+func (h *Handler) copyObject(ctx context.Context, src, dst, contentType string) error {
+	rc, err := h.fileStorage.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return h.fileStorage.Put(ctx, dst, rc, h.storageOpts.putOptions(contentType))
+}
+
+// moveTargets lists folders as destinations, sorted by path, with exclude
+// and its subfolders disabled.
+func moveTargets(folders []models.Folder, exclude *primitive.ObjectID) []MoveTarget {
+	byID := make(map[primitive.ObjectID]models.Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+
+	targets := make([]MoveTarget, 0, len(folders))
+	for _, f := range folders {
+		names := []string{f.Name}
+		disabled := exclude != nil && f.ID == *exclude
+		// The depth limit guards against a corrupt parent chain
+		for p, depth := f.ParentID, 0; p != nil && depth < len(folders); depth++ {
+			parent, ok := byID[*p]
+			if !ok {
+				break
+			}
+			if exclude != nil && parent.ID == *exclude {
+				disabled = true
+			}
+			names = append([]string{parent.Name}, names...)
+			p = parent.ParentID
+		}
+		targets = append(targets, MoveTarget{
+			ID:       f.ID.Hex(),
+			Path:     strings.Join(names, " / "),
+			Disabled: disabled,
+		})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return strings.ToLower(targets[i].Path) < strings.ToLower(targets[j].Path)
+	})
+	return targets
+}
+
+func sameFolder(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func folderHex(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
+
+func folderURL(id *primitive.ObjectID) string {
+	if id == nil {
+		return "/library"
+	}
+	return "/library/folder/" + id.Hex()
+}
@@ -0,0 +1,42 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMoveTargets(t *testing.T) {
+	videos := models.Folder{ID: primitive.NewObjectID(), Name: "Videos"}
+	y2024 := models.Folder{ID: primitive.NewObjectID(), Name: "2024", ParentID: &videos.ID}
+	raw := models.Folder{ID: primitive.NewObjectID(), Name: "raw", ParentID: &y2024.ID}
+	docs := models.Folder{ID: primitive.NewObjectID(), Name: "docs"}
+	folders := []models.Folder{raw, videos, docs, y2024}
+
+	got := moveTargets(folders, &y2024.ID)
+
+	want := []struct {
+		path     string
+		disabled bool
+	}{
+		{"docs", false},
+		{"Videos", false},
+		{"Videos / 2024", true},
+		{"Videos / 2024 / raw", true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("moveTargets() returned %d targets, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Path != w.path || got[i].Disabled != w.disabled {
+			t.Errorf("target %d = {%q, %v}, want {%q, %v}", i, got[i].Path, got[i].Disabled, w.path, w.disabled)
+		}
+	}
+
+	for _, target := range moveTargets(folders, nil) {
+		if target.Disabled {
+			t.Errorf("%q is disabled with nothing excluded", target.Path)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package files
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxUploadSize applies until SetUploadPolicy is called.
+const defaultMaxUploadSize = 32 << 20 // 32MB
+
+// ErrTypeNotAllowed is returned for a file whose content type the upload
+// policy rejects.
+var ErrTypeNotAllowed = errors.New("this file type is not allowed")
+
+// UploadPolicy limits what can be uploaded to the library.
+//
+// Type patterns are full content types ("application/pdf") or a top-level
+// type with a wildcard ("video/*"). A file is rejected if its declared or
+// sniffed type matches Denied. If Allowed is set, the declared type must
+// match it, and the sniffed type must either match it too or be consistent
+// with the declared type (same top-level type, or too generic to tell).
+type UploadPolicy struct {
+	MaxSize int64    // Largest file accepted, in bytes
+	Allowed []string // Empty = any type not denied
+	Denied  []string
+}
+
+// ParseTypeList splits a comma-separated list of content type patterns.
+func ParseTypeList(s string) []string {
+	var types []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// CheckType decides whether a file can be uploaded. declared is the
+// client-provided Content-Type; head is the start of the file's content
+// (up to 512 bytes), or nil when it isn't available yet. It returns the
+// content type to store.
+func (p UploadPolicy) CheckType(declared string, head []byte) (string, error) {
+	declared = baseType(declared)
+	sniffed := ""
+	if head != nil {
+		sniffed = baseType(http.DetectContentType(head))
+	}
+
+	contentType := declared
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = sniffed
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if matchesAny(p.Denied, contentType) || (sniffed != "" && matchesAny(p.Denied, sniffed)) {
+		return "", ErrTypeNotAllowed
+	}
+	if len(p.Allowed) > 0 {
+		if !matchesAny(p.Allowed, contentType) {
+			return "", ErrTypeNotAllowed
+		}
+		if sniffed != "" && !isGeneric(sniffed) && !matchesAny(p.Allowed, sniffed) &&
+			topLevel(sniffed) != topLevel(contentType) {
+			return "", ErrTypeNotAllowed
+		}
+	}
+	return contentType, nil
+}
+
+// baseType returns a content type without parameters, lowercased.
+func baseType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return t
+}
+
+// isGeneric reports whether a sniffed type says nothing specific about
+// the file.
+func isGeneric(contentType string) bool {
+	return contentType == "application/octet-stream" || contentType == "text/plain"
+}
+
+func topLevel(contentType string) string {
+	top, _, _ := strings.Cut(contentType, "/")
+	return top
+}
+
+func matchesAny(patterns []string, contentType string) bool {
+	for _, p := range patterns {
+		if p == contentType || p == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "/*"); ok && topLevel(contentType) == prefix {
+			return true
+		}
+	}
+	return false
+}
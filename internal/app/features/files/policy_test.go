@@ -0,0 +1,59 @@
+package files
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTypeList(t *testing.T) {
+	got := ParseTypeList(" Video/* , application/pdf,,")
+	if len(got) != 2 || got[0] != "video/*" || got[1] != "application/pdf" {
+		t.Errorf("ParseTypeList() = %v, want [video/* application/pdf]", got)
+	}
+	if got := ParseTypeList(""); got != nil {
+		t.Errorf("ParseTypeList(\"\") = %v, want nil", got)
+	}
+}
+
+func TestUploadPolicy_CheckType(t *testing.T) {
+	html := []byte("<!DOCTYPE html><html><script>alert(1)</script></html>")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x00\x00")
+	docx := "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	tests := []struct {
+		name     string
+		policy   UploadPolicy
+		declared string
+		head     []byte
+		want     string
+		wantErr  bool
+	}{
+		{"no policy keeps declared type", UploadPolicy{}, "image/png", png, "image/png", false},
+		{"parameters are dropped", UploadPolicy{}, "text/csv; charset=utf-8", []byte("a,b\n"), "text/csv", false},
+		{"missing type is sniffed", UploadPolicy{}, "", png, "image/png", false},
+		{"octet-stream is sniffed", UploadPolicy{}, "application/octet-stream", png, "image/png", false},
+		{"denied declared type", UploadPolicy{Denied: []string{"text/html"}}, "text/html", html, "", true},
+		{"denied type disguised", UploadPolicy{Denied: []string{"text/html"}}, "image/png", html, "", true},
+		{"denied wildcard", UploadPolicy{Denied: []string{"video/*"}}, "video/mp4", nil, "", true},
+		{"allowed wildcard", UploadPolicy{Allowed: []string{"image/*"}}, "image/png", png, "image/png", false},
+		{"not on allowlist", UploadPolicy{Allowed: []string{"image/*"}}, "application/pdf", nil, "", true},
+		{"allowlist sees through disguise", UploadPolicy{Allowed: []string{"image/*"}}, "image/png", html, "", true},
+		{"container format agrees", UploadPolicy{Allowed: []string{docx}}, docx, zip, docx, false},
+		{"generic sniff is fine", UploadPolicy{Allowed: []string{"text/csv"}}, "text/csv", []byte("a,b\n"), "text/csv", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.CheckType(tt.declared, tt.head)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTypeNotAllowed) {
+					t.Errorf("CheckType() error = %v, want ErrTypeNotAllowed", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CheckType() = %q, %v; want %q, nil", got, err, tt.want)
+			}
+		})
+	}
+}
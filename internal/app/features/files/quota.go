@@ -0,0 +1,72 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetStorageQuota limits how many bytes the library may take up in
+// storage, trash included. Zero means no limit.
+func (h *Handler) SetStorageQuota(bytes int64) {
+	h.quota = bytes
+}
+
+// quotaError means an upload would go over the library's quota or a
+// folder's.
+type quotaError struct {
+	scope string // "the library" or `folder "Name"`
+	quota int64
+	used  int64
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("This upload would go over the %s quota for %s (%s used)",
+		FormatFileSize(e.quota), e.scope, FormatFileSize(e.used))
+}
+
+// checkQuota returns a *quotaError if adding size bytes to a folder would
+// go over the library's quota, or the quota of the folder or any folder
+// above it.
+func (h *Handler) checkQuota(ctx context.Context, folderID *primitive.ObjectID, size int64) error {
+	if h.quota > 0 {
+		used, err := h.fileStore.StoredBytes(ctx)
+		if err != nil {
+			return err
+		}
+		if used+size > h.quota {
+			return &quotaError{scope: "the library", quota: h.quota, used: used}
+		}
+	}
+
+	if folderID == nil {
+		return nil
+	}
+	path, err := h.folderStore.GetPath(ctx, *folderID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range path {
+		if f.QuotaBytes <= 0 {
+			continue
+		}
+		ids, err := h.folderStore.DescendantIDs(ctx, f.ID)
+		if err != nil {
+			return err
+		}
+		used, err := h.fileStore.SumSize(ctx, append(ids, f.ID))
+		if err != nil {
+			return err
+		}
+		if used+size > f.QuotaBytes {
+			return &quotaError{scope: fmt.Sprintf("folder %q", f.Name), quota: f.QuotaBytes, used: used}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package files
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/upload"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/resumable"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Resumable uploads follow the core of the tus protocol (tus.io):
+//
+//	POST   /library/file/uploads       start an upload (Upload-Length, Upload-Metadata)
+//	HEAD   /library/file/uploads/{id}  how much has arrived (Upload-Offset)
+//	PATCH  /library/file/uploads/{id}  send the next chunk at Upload-Offset
+//	DELETE /library/file/uploads/{id}  cancel the upload
+//
+// The file record is created when the last chunk arrives.
+
+const tusVersion = "1.0.0"
+
+// createUpload starts a resumable upload.
+func (h *Handler) createUpload(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size <= 0 {
+		http.Error(w, "Upload-Length must be a positive number of bytes", http.StatusBadRequest)
+		return
+	}
+
+	meta := ParseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	name := strings.TrimSpace(meta["filename"])
+	if name == "" {
+		http.Error(w, "Upload-Metadata must include a filename", http.StatusBadRequest)
+		return
+	}
+	// The content is sniffed when the first chunk arrives
+	contentType, err := h.policy.CheckType(meta["filetype"], nil)
+	if err != nil {
+		http.Error(w, "This file type is not allowed", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	opts, ok := h.uploadStorageOptions(meta["encryption"], meta["storage_class"], meta["cache_control"])
+	if !ok {
+		http.Error(w, "Invalid storage settings", http.StatusBadRequest)
+		return
+	}
+
+	var folderID *primitive.ObjectID
+	if id, err := primitive.ObjectIDFromHex(meta["folder_id"]); err == nil {
+		folderID = &id
+	}
+	if err := h.checkQuota(r.Context(), folderID, size); err != nil {
+		var qe *quotaError
+		if errors.As(err, &qe) {
+			http.Error(w, qe.Error(), http.StatusInsufficientStorage)
+			return
+		}
+		h.errLog.Log(r, "failed to check storage quota", err)
+		http.Error(w, "Failed to start upload", http.StatusInternalServerError)
+		return
+	}
+
+	u, err := h.uploads.Create(r.Context(), upload.CreateInput{
+		UserID:      actor.UserID(),
+		FolderID:    folderID,
+		Name:        name,
+		ContentType: contentType,
+		Description: strings.TrimSpace(meta["description"]),
+		Size:        size,
+		Storage: upload.Storage{
+			Encryption:   opts.Encryption,
+			StorageClass: opts.StorageClass,
+			CacheControl: opts.CacheControl,
+		},
+	})
+	if errors.Is(err, resumable.ErrTooLarge) {
+		http.Error(w, "File too large (max "+FormatFileSize(h.uploads.MaxSize())+")", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to start upload", err)
+		http.Error(w, "Failed to start upload", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/library/file/uploads/"+u.ID.Hex())
+	w.Header().Set("Upload-Expires", u.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// uploadOffset reports how many bytes of an upload have arrived, so the
+// client knows where to resume.
+func (h *Handler) uploadOffset(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(u.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// uploadChunk stores the next chunk of an upload. When the last chunk
+// arrives, the chunks are joined into the library file.
+func (h *Handler) uploadChunk(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, "Upload-Offset must be a number of bytes", http.StatusBadRequest)
+		return
+	}
+
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+
+	body := bufio.NewReaderSize(r.Body, 512)
+	if offset == 0 && u.Offset == 0 {
+		// Check the start of the content, not just the declared type
+		head, _ := body.Peek(512)
+		if _, err := h.policy.CheckType(u.ContentType, head); err != nil {
+			if err := h.uploads.Remove(r.Context(), u); err != nil {
+				h.errLog.Log(r, "failed to remove rejected upload", err)
+			}
+			http.Error(w, "This file type is not allowed", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	newOffset, err := h.uploads.WriteChunk(r.Context(), u, offset, body)
+	w.Header().Set("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	switch {
+	case errors.Is(err, resumable.ErrOffsetMismatch):
+		http.Error(w, "Upload-Offset does not match the upload", http.StatusConflict)
+		return
+	case errors.Is(err, resumable.ErrChunkTooLarge), errors.Is(err, resumable.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		h.errLog.Log(r, "failed to store upload chunk", err)
+		http.Error(w, "Failed to store chunk", http.StatusInternalServerError)
+		return
+	}
+
+	if u.Complete() {
+		if !h.finishUpload(w, r, u) {
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cancelUpload abandons an upload and deletes what has arrived.
+func (h *Handler) cancelUpload(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+
+	u, ok := h.loadUpload(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uploads.Remove(r.Context(), u); err != nil {
+		h.errLog.Log(r, "failed to cancel upload", err)
+		http.Error(w, "Failed to cancel upload", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadUpload returns the upload named in the URL, if it belongs to the
+// current user. Otherwise it writes an error and returns false.
+func (h *Handler) loadUpload(w http.ResponseWriter, r *http.Request) (*upload.Upload, bool) {
+	actor, _ := auth.CurrentUser(r)
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	u, err := h.uploads.Get(r.Context(), id, actor.UserID())
+	if errors.Is(err, resumable.ErrNotFound) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to get upload", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return u, true
+}
+
+// finishUpload joins a complete upload into a library file and records it.
+// On failure it writes an error and returns false; the chunks are kept so
+// the client can retry by sending an empty chunk at the final offset.
+func (h *Handler) finishUpload(w http.ResponseWriter, r *http.Request, u *upload.Upload) bool {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	// Check the content before it is assembled
+	chunks := h.uploads.Open(ctx, u)
+	scanStatus, err := h.scanUpload(r, u.Name, chunks)
+	chunks.Close()
+	if err != nil {
+		status, msg := scanFailure(err)
+		if status == http.StatusUnprocessableEntity {
+			if err := h.uploads.Remove(ctx, u); err != nil {
+				h.errLog.Log(r, "failed to remove rejected upload", err)
+			}
+		}
+		// Otherwise keep the chunks so the upload can be completed later
+		http.Error(w, msg, status)
+		return false
+	}
+
+	chunks = h.uploads.Open(ctx, u)
+	sum, err := hashContent(chunks)
+	chunks.Close()
+	if err != nil {
+		h.errLog.Log(r, "failed to hash upload", err)
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		return false
+	}
+
+	// Assemble the chunks, unless the same content is already stored
+	storagePath, err := h.storeContent(r, sum, u.Name, u.Size, func(path string) error {
+		return h.uploads.Assemble(ctx, u, path)
+	})
+	if err != nil {
+		h.errLog.Log(r, "failed to assemble upload", err)
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		return false
+	}
+
+	createdFile, err := h.fileStore.Create(ctx, file.CreateInput{
+		FolderID:    u.FolderID,
+		Name:        u.Name,
+		StoragePath: storagePath,
+		Size:        u.Size,
+		ContentType: u.ContentType,
+		Description: u.Description,
+		SHA256:      sum,
+		ScanStatus:  scanStatus,
+		CreatedByID: actor.UserID(),
+	})
+	if err != nil {
+		// Clean up assembled file on DB error
+		h.dropContent(ctx, storagePath)
+		h.errLog.Log(r, "failed to create file record", err)
+		http.Error(w, "Failed to save file record", http.StatusInternalServerError)
+		return false
+	}
+
+	if err := h.uploads.Remove(ctx, u); err != nil {
+		// The cleanup job removes the chunks once the upload expires
+		h.logger.Warn("failed to remove finished upload",
+			zap.String("upload_id", u.ID.Hex()),
+			zap.Error(err))
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &createdFile.ID, "file_uploaded", nil)
+	return true
+}
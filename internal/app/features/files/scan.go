@@ -0,0 +1,107 @@
+package files
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/virusscan"
+	"go.uber.org/zap"
+)
+
+// errScanUnavailable means an upload couldn't be scanned and the scanner
+// doesn't fail open, so the upload is refused for now.
+var errScanUnavailable = errors.New("virus scanner unavailable")
+
+// infectedError means the scanner found malware in an upload.
+type infectedError struct {
+	signature string
+}
+
+func (e *infectedError) Error() string {
+	if e.signature == "" {
+		return "This file was rejected because it contains malware"
+	}
+	return "This file was rejected because it contains malware (" + e.signature + ")"
+}
+
+// SetVirusScanner scans uploads before a file record is created. Without
+// it, uploads are not scanned.
+func (h *Handler) SetVirusScanner(s *virusscan.Scanner) {
+	h.scanner = s
+}
+
+// scanUpload scans an upload's content and returns the scan status to
+// record on the file. An infected upload is logged and audited and comes
+// back as an *infectedError; the caller deletes what it stored.
+func (h *Handler) scanUpload(r *http.Request, name string, content io.Reader) (string, error) {
+	if h.scanner == nil {
+		return "", nil
+	}
+
+	res, err := h.scanner.Scan(r.Context(), content)
+	if err != nil {
+		h.logger.Error("virus scan failed",
+			zap.String("name", name),
+			zap.Error(err))
+		if h.scanner.FailOpen() {
+			return virusscan.StatusUnscanned, nil
+		}
+		return "", errScanUnavailable
+	}
+	if !res.Infected {
+		return virusscan.StatusClean, nil
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.logger.Warn("rejected infected upload",
+		zap.String("name", name),
+		zap.String("signature", res.Signature),
+		zap.String("user_id", actorID.Hex()))
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "file_rejected_infected", map[string]string{
+		"name":      name,
+		"signature": res.Signature,
+	})
+	return "", &infectedError{signature: res.Signature}
+}
+
+// scanFailure returns the HTTP status and user message for a scanUpload
+// error.
+func scanFailure(err error) (int, string) {
+	var infected *infectedError
+	if errors.As(err, &infected) {
+		return http.StatusUnprocessableEntity, infected.Error()
+	}
+	return http.StatusServiceUnavailable, "Virus scanning is unavailable; please try again later"
+}
+
+// scanStatusLabel describes a file's scan status for display.
+func scanStatusLabel(status string) string {
+	switch status {
+	case virusscan.StatusClean:
+		return "Clean"
+	case virusscan.StatusUnscanned:
+		return "Not scanned (scanner unavailable)"
+	default:
+		return "Not scanned"
+	}
+}
+
+// scanStored scans an object already in storage, for uploads sent
+// straight to the bucket.
+func (h *Handler) scanStored(r *http.Request, name, storagePath string) (string, error) {
+	if h.scanner == nil {
+		return "", nil
+	}
+	rc, err := h.fileStorage.Get(r.Context(), storagePath)
+	if err != nil {
+		h.logger.Error("failed to open upload for scanning",
+			zap.String("path", storagePath),
+			zap.Error(err))
+		return "", errScanUnavailable
+	}
+	defer rc.Close()
+	return h.scanUpload(r, name, rc)
+}
@@ -0,0 +1,59 @@
+package files
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/virusscan"
+	"go.uber.org/zap"
+)
+
+func TestScanUpload_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+	req := httptest.NewRequest(http.MethodPost, "/library/upload", nil)
+
+	h := &Handler{logger: zap.NewNop()}
+	if status, err := h.scanUpload(req, "a.txt", strings.NewReader("x")); status != "" || err != nil {
+		t.Errorf("without scanner = %q, %v; want \"\", nil", status, err)
+	}
+
+	h.scanner, _ = virusscan.New(virusscan.HTTP, srv.URL, "", false)
+	if _, err := h.scanUpload(req, "a.txt", strings.NewReader("x")); !errors.Is(err, errScanUnavailable) {
+		t.Errorf("fail closed err = %v, want errScanUnavailable", err)
+	}
+
+	h.scanner, _ = virusscan.New(virusscan.HTTP, srv.URL, "", true)
+	status, err := h.scanUpload(req, "a.txt", strings.NewReader("x"))
+	if err != nil || status != virusscan.StatusUnscanned {
+		t.Errorf("fail open = %q, %v; want %q, nil", status, err, virusscan.StatusUnscanned)
+	}
+}
+
+func TestScanFailure(t *testing.T) {
+	status, msg := scanFailure(&infectedError{signature: "Eicar-Test-Signature"})
+	if status != http.StatusUnprocessableEntity || !strings.Contains(msg, "Eicar-Test-Signature") {
+		t.Errorf("infected = %d, %q", status, msg)
+	}
+	if status, _ := scanFailure(errScanUnavailable); status != http.StatusServiceUnavailable {
+		t.Errorf("unavailable status = %d, want 503", status)
+	}
+}
+
+func TestScanStatusLabel(t *testing.T) {
+	tests := map[string]string{
+		virusscan.StatusClean:     "Clean",
+		virusscan.StatusUnscanned: "Not scanned (scanner unavailable)",
+		"":                        "Not scanned",
+	}
+	for in, want := range tests {
+		if got := scanStatusLabel(in); got != want {
+			t.Errorf("scanStatusLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package files
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	searchLimit    = 50  // Most files, and most folders, listed per search
+	maxSearchQuery = 200 // Longer queries are cut to this many characters
+)
+
+// SearchResult is a file or folder found by a library search.
+type SearchResult struct {
+	ID          string
+	Name        string
+	Description string
+	TypeIcon    string
+	Size        string // Files only
+	IsViewable  bool
+	Tags        []string         // Files only
+	URL         string           // The folder itself, or the folder holding the file
+	Path        []BreadcrumbItem // Where the item is, from the library root
+}
+
+// SearchVM is the view model for library search results.
+type SearchVM struct {
+	viewdata.BaseVM
+	Query     string
+	Tag       string // Set when listing files by tag instead of searching
+	Folders   []SearchResult
+	Files     []SearchResult
+	Truncated bool // More matches exist than are shown
+}
+
+// search finds files and folders anywhere in the library by name or
+// description, or lists the files with a tag (?tag=).
+func (h *Handler) search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if runes := []rune(query); len(runes) > maxSearchQuery {
+		query = string(runes[:maxSearchQuery])
+	}
+	tag := strings.TrimSpace(r.URL.Query().Get("tag"))
+
+	vm := SearchVM{
+		BaseVM: viewdata.New(r),
+		Query:  query,
+		Tag:    tag,
+	}
+	vm.Title = "Search Library"
+	vm.BackURL = "/library"
+
+	var folders []models.Folder
+	var files []models.File
+	var err error
+	switch {
+	case tag != "":
+		vm.Query = ""
+		vm.Title = "Tagged " + tag
+		files, err = h.fileStore.ListByTag(ctx, tag, searchLimit)
+		if err != nil {
+			h.errLog.Log(r, "failed to list files by tag", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	case query != "":
+		folders, err = h.folderStore.Search(ctx, query, searchLimit)
+		if err != nil {
+			h.errLog.Log(r, "failed to search folders", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		files, err = h.fileStore.Search(ctx, query, searchLimit)
+		if err != nil {
+			h.errLog.Log(r, "failed to search files", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		templates.Render(w, r, "files/search", vm)
+		return
+	}
+
+	// One read of the folder tree gives every result its path
+	all, err := h.folderStore.ListAll(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list folders", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	pathTo := folderPaths(all)
+
+	vm.Folders = make([]SearchResult, 0, len(folders))
+	for _, f := range folders {
+		vm.Folders = append(vm.Folders, SearchResult{
+			ID:          f.ID.Hex(),
+			Name:        f.Name,
+			Description: f.Description,
+			TypeIcon:    "folder",
+			URL:         folderURL(&f.ID),
+			Path:        pathTo(f.ParentID),
+		})
+	}
+	vm.Files = make([]SearchResult, 0, len(files))
+	for _, f := range files {
+		vm.Files = append(vm.Files, SearchResult{
+			ID:          f.ID.Hex(),
+			Name:        f.Name,
+			Description: f.Description,
+			TypeIcon:    FileTypeIcon(f.ContentType),
+			Size:        FormatFileSize(f.Size),
+			IsViewable:  IsViewable(f.ContentType),
+			Tags:        f.Tags,
+			URL:         folderURL(f.FolderID),
+			Path:        pathTo(f.FolderID),
+		})
+	}
+	vm.Truncated = len(folders) == searchLimit || len(files) == searchLimit
+
+	templates.Render(w, r, "files/search", vm)
+}
+
+// folderPaths returns a function giving the breadcrumb path, from the
+// library root, to a folder in folders (nil = the root itself).
+func folderPaths(folders []models.Folder) func(id *primitive.ObjectID) []BreadcrumbItem {
+	byID := make(map[primitive.ObjectID]*models.Folder, len(folders))
+	for i := range folders {
+		byID[folders[i].ID] = &folders[i]
+	}
+
+	return func(id *primitive.ObjectID) []BreadcrumbItem {
+		var chain []*models.Folder
+		// Stop at a missing parent, and after len(folders) steps in case
+		// of a cycle
+		for id != nil && len(chain) <= len(folders) {
+			f, ok := byID[*id]
+			if !ok {
+				break
+			}
+			chain = append(chain, f)
+			id = f.ParentID
+		}
+
+		path := make([]BreadcrumbItem, 0, len(chain)+1)
+		path = append(path, BreadcrumbItem{Name: "Library", URL: "/library"})
+		for i := len(chain) - 1; i >= 0; i-- {
+			path = append(path, BreadcrumbItem{
+				ID:   chain[i].ID.Hex(),
+				Name: chain[i].Name,
+				URL:  folderURL(&chain[i].ID),
+			})
+		}
+		return path
+	}
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFolderPaths(t *testing.T) {
+	docs := primitive.NewObjectID()
+	reports := primitive.NewObjectID()
+	orphan := primitive.NewObjectID()
+	gone := primitive.NewObjectID()
+
+	pathTo := folderPaths([]models.Folder{
+		{ID: docs, Name: "Docs"},
+		{ID: reports, Name: "Reports", ParentID: &docs},
+		{ID: orphan, Name: "Orphan", ParentID: &gone},
+	})
+
+	names := func(id *primitive.ObjectID) []string {
+		var out []string
+		for _, c := range pathTo(id) {
+			out = append(out, c.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		id   *primitive.ObjectID
+		want []string
+	}{
+		{"root", nil, []string{"Library"}},
+		{"top level", &docs, []string{"Library", "Docs"}},
+		{"nested", &reports, []string{"Library", "Docs", "Reports"}},
+		{"missing parent", &orphan, []string{"Library", "Orphan"}},
+		{"unknown folder", &gone, []string{"Library"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(tt.id)
+			if len(got) != len(tt.want) {
+				t.Fatalf("path = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("path = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if got := pathTo(&reports); got[2].URL != "/library/folder/"+reports.Hex() {
+		t.Errorf("URL = %q, want the folder's URL", got[2].URL)
+	}
+}
+
+func TestFolderPaths_Cycle(t *testing.T) {
+	a := primitive.NewObjectID()
+	b := primitive.NewObjectID()
+	pathTo := folderPaths([]models.Folder{
+		{ID: a, Name: "A", ParentID: &b},
+		{ID: b, Name: "B", ParentID: &a},
+	})
+	// Must return rather than loop forever
+	if got := pathTo(&a); len(got) == 0 {
+		t.Error("path should at least contain the library root")
+	}
+}
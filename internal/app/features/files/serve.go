@@ -0,0 +1,192 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.uber.org/zap"
+)
+
+// errSeekBackward means a forwardSeeker was asked to read something it
+// has already passed.
+var errSeekBackward = errors.New("cannot seek backward in a stored object stream")
+
+// serveContent sends a file's content with its Content-Length, answering
+// Range requests so video can be seeked and interrupted downloads resumed.
+// The caller sets Content-Type and Content-Disposition.
+//
+// When the whole content is sent and the file's hash is known, it is
+// checked against the hash and a mismatch is logged as storage corruption
+// and recorded on the file, which keeps it out of signed downloads until it
+// passes again. The response is already sent by then, so the check detects
+// corruption rather than preventing it.
+func (h *Handler) serveContent(w http.ResponseWriter, r *http.Request, f *models.File, content io.Reader) {
+	// ServeContent would otherwise sniff the type, reading ahead and
+	// seeking back to the start
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+
+	rs, ok := content.(io.ReadSeeker)
+	if !ok {
+		rs = &forwardSeeker{r: content, size: f.Size}
+		// Several ranges may need seeking backward, so send the whole file
+		if strings.Contains(r.Header.Get("Range"), ",") {
+			r.Header.Del("Range")
+		}
+	}
+
+	var verify *verifyingSeeker
+	if f.SHA256 != "" {
+		// Lets a resumed download check it is continuing the same content
+		w.Header().Set("ETag", `"`+f.SHA256+`"`)
+		verify = &verifyingSeeker{rs: rs, sum: sha256.New()}
+		rs = verify
+	}
+
+	// Stored content never changes, so its creation time is its modtime
+	http.ServeContent(w, r, "", f.CreatedAt, rs)
+
+	if verify != nil && verify.whole && verify.read == f.Size {
+		if got := hex.EncodeToString(verify.sum.Sum(nil)); got != f.SHA256 {
+			h.errLog.LogWithFields(r, "stored file failed integrity check", errCorrupt,
+				zap.String("file_id", f.ID.Hex()),
+				zap.String("path", f.StoragePath),
+				zap.String("sha256", got),
+				zap.String("want_sha256", f.SHA256))
+			now := time.Now()
+			h.setIntegrityFailed(r, f, &now)
+		} else if f.IntegrityFailedAt != nil {
+			h.setIntegrityFailed(r, f, nil)
+		}
+	}
+}
+
+// setIntegrityFailed records the result of a failed integrity check, or
+// clears it once the content checks out again.
+func (h *Handler) setIntegrityFailed(r *http.Request, f *models.File, at *time.Time) {
+	if h.fileStore == nil {
+		return
+	}
+	if err := h.fileStore.SetIntegrityFailed(r.Context(), f.StoragePath, at); err != nil {
+		h.logger.Warn("failed to record file integrity check",
+			zap.String("file_id", f.ID.Hex()), zap.Error(err))
+	}
+}
+
+// redirectToSigned sends the browser to a signed storage URL for f, with
+// the given Content-Disposition type ("inline" or "attachment"), instead
+// of streaming it through the app. It reports whether it did; when signed
+// downloads are off or the URL can't be signed, the caller streams the
+// file as usual.
+//
+// The storage service sends the content itself, so it isn't checked
+// against the file's hash. A file whose content last failed that check is
+// streamed instead, so each read is checked until it passes.
+//
+// S3 presigned URLs carry the file's name and type. CloudFront URLs are
+// signed for the stored object as is, so the browser gets the type and
+// name the object was stored with.
+func (h *Handler) redirectToSigned(w http.ResponseWriter, r *http.Request, f *models.File, disposition string) bool {
+	if h.signedTTL <= 0 || f.IntegrityFailedAt != nil {
+		return false
+	}
+	url, err := h.fileStorage.PresignedURL(r.Context(), f.StoragePath, &storage.PresignOptions{
+		Expires:            h.signedTTL,
+		ContentType:        f.ContentType,
+		ContentDisposition: fmt.Sprintf("%s; filename=%q", disposition, f.Name),
+	})
+	if err != nil {
+		h.logger.Warn("failed to sign storage URL, streaming file instead",
+			zap.String("file_id", f.ID.Hex()), zap.Error(err))
+		return false
+	}
+
+	// The signed URL expires, so the redirect must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, url, http.StatusFound)
+	return true
+}
+
+// forwardSeeker gives http.ServeContent the io.ReadSeeker it needs over a
+// stream that can only be read forward, such as an S3 object body. Seeking
+// is recorded and the bytes in between are discarded on the next read, so
+// a range far into a large object takes a while to reach. Reading behind
+// what has already been read fails.
+type forwardSeeker struct {
+	r    io.Reader
+	size int64
+	pos  int64 // Where the next read starts
+	read int64 // Bytes taken from r
+}
+
+func (s *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.pos + offset
+	case io.SeekEnd:
+		abs = s.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.pos = abs
+	return abs, nil
+}
+
+func (s *forwardSeeker) Read(p []byte) (int, error) {
+	if s.pos < s.read {
+		return 0, errSeekBackward
+	}
+	if s.pos > s.read {
+		n, err := io.CopyN(io.Discard, s.r, s.pos-s.read)
+		s.read += n
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.r.Read(p)
+	s.read += int64(n)
+	s.pos += int64(n)
+	return n, err
+}
+
+// verifyingSeeker hashes what is read after a seek to the start, so that
+// reading the whole content can be checked against the file's hash.
+type verifyingSeeker struct {
+	rs    io.ReadSeeker
+	sum   hash.Hash
+	whole bool  // Reading from the start, with no seek since
+	read  int64 // Bytes hashed
+}
+
+func (v *verifyingSeeker) Seek(offset int64, whence int) (int64, error) {
+	n, err := v.rs.Seek(offset, whence)
+	v.sum.Reset()
+	v.read = 0
+	v.whole = err == nil && n == 0
+	return n, err
+}
+
+func (v *verifyingSeeker) Read(p []byte) (int, error) {
+	n, err := v.rs.Read(p)
+	if v.whole {
+		v.sum.Write(p[:n])
+		v.read += int64(n)
+	}
+	return n, err
+}
@@ -0,0 +1,203 @@
+package files
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// streamOnly hides any Seek method, like an S3 object body.
+type streamOnly struct{ io.Reader }
+
+func TestServeContent_Ranges(t *testing.T) {
+	const content = "0123456789"
+
+	tests := []struct {
+		name       string
+		rangeHdr   string
+		seekable   bool
+		wantStatus int
+		wantBody   string
+		wantRange  string
+	}{
+		{"whole", "", false, http.StatusOK, content, ""},
+		{"range of stream", "bytes=4-6", false, http.StatusPartialContent, "456", "bytes 4-6/10"},
+		{"resume stream", "bytes=7-", false, http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"suffix of stream", "bytes=-2", false, http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"several ranges of stream", "bytes=6-7,0-1", false, http.StatusOK, content, ""},
+		{"range of seekable", "bytes=2-3", true, http.StatusPartialContent, "23", "bytes 2-3/10"},
+		{"unsatisfiable", "bytes=20-", false, http.StatusRequestedRangeNotSatisfiable, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{logger: zap.NewNop(), errLog: errorsfeature.NewErrorLogger(zap.NewNop())}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/library/file/x/view", nil)
+			if tt.rangeHdr != "" {
+				req.Header.Set("Range", tt.rangeHdr)
+			}
+			var r io.Reader = strings.NewReader(content)
+			if !tt.seekable {
+				r = streamOnly{r}
+			}
+
+			h.serveContent(rec, req, &models.File{Size: int64(len(content))}, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusRequestedRangeNotSatisfiable {
+				return
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want bytes", got)
+			}
+		})
+	}
+}
+
+func TestServeContent_Integrity(t *testing.T) {
+	const sum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" // "test"
+
+	tests := []struct {
+		name     string
+		sha256   string
+		content  string
+		rangeHdr string
+		logged   bool
+	}{
+		{"matches", sum, "test", "", false},
+		{"corrupt", sum, "tesT", "", true},
+		{"corrupt but partial", sum, "tesT", "bytes=0-1", false},
+		{"no hash recorded", "", "anything", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.ErrorLevel)
+			h := &Handler{logger: zap.NewNop(), errLog: errorsfeature.NewErrorLogger(zap.New(core))}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/library/file/x/download", nil)
+			if tt.rangeHdr != "" {
+				req.Header.Set("Range", tt.rangeHdr)
+			}
+
+			f := &models.File{SHA256: tt.sha256, Size: int64(len(tt.content))}
+			h.serveContent(rec, req, f, streamOnly{strings.NewReader(tt.content)})
+
+			if tt.rangeHdr == "" && rec.Body.String() != tt.content {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.content)
+			}
+			if got := logs.FilterMessage("stored file failed integrity check").Len() > 0; got != tt.logged {
+				t.Errorf("integrity failure logged = %v, want %v", got, tt.logged)
+			}
+		})
+	}
+}
+
+func TestServeContent_ResumeChecksETag(t *testing.T) {
+	const sum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	h := &Handler{logger: zap.NewNop(), errLog: errorsfeature.NewErrorLogger(zap.NewNop())}
+	f := &models.File{SHA256: sum, Size: 4}
+
+	for _, tt := range []struct {
+		ifRange    string
+		wantStatus int
+	}{
+		{`"` + sum + `"`, http.StatusPartialContent},
+		{`"stale"`, http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/library/file/x/download", nil)
+		req.Header.Set("Range", "bytes=2-")
+		req.Header.Set("If-Range", tt.ifRange)
+
+		h.serveContent(rec, req, f, streamOnly{strings.NewReader("test")})
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("If-Range %s: status = %d, want %d", tt.ifRange, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("ETag"); got != `"`+sum+`"` {
+			t.Errorf("ETag = %q", got)
+		}
+	}
+}
+
+// presigningStore signs URLs the way S3 would, recording the options.
+type presigningStore struct {
+	storage.Store
+	opts *storage.PresignOptions
+}
+
+func (s *presigningStore) PresignedURL(_ context.Context, path string, opts *storage.PresignOptions) (string, error) {
+	s.opts = opts
+	return "https://bucket.example.com/" + path + "?sig=abc", nil
+}
+
+func TestRedirectToSigned(t *testing.T) {
+	f := &models.File{StoragePath: "files/2026/10/a1b2c3d4.mp4", Name: "Intro.mp4", ContentType: "video/mp4"}
+	signer := &presigningStore{Store: storage.NewMemory(storage.MemoryConfig{})}
+
+	t.Run("off", func(t *testing.T) {
+		h := &Handler{logger: zap.NewNop(), fileStorage: signer}
+		rec := httptest.NewRecorder()
+		if h.redirectToSigned(rec, httptest.NewRequest(http.MethodGet, "/library/file/x/download", nil), f, "attachment") {
+			t.Error("redirected with signed downloads off")
+		}
+	})
+
+	t.Run("on", func(t *testing.T) {
+		h := &Handler{logger: zap.NewNop(), fileStorage: signer, signedTTL: time.Hour}
+		rec := httptest.NewRecorder()
+		if !h.redirectToSigned(rec, httptest.NewRequest(http.MethodGet, "/library/file/x/download", nil), f, "attachment") {
+			t.Fatal("did not redirect")
+		}
+		if rec.Code != http.StatusFound {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusFound)
+		}
+		if got := rec.Header().Get("Location"); got != "https://bucket.example.com/files/2026/10/a1b2c3d4.mp4?sig=abc" {
+			t.Errorf("Location = %q", got)
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" {
+			t.Error("redirect to an expiring URL should not be cached")
+		}
+		if signer.opts.Expires != time.Hour || signer.opts.ContentType != "video/mp4" ||
+			signer.opts.ContentDisposition != `attachment; filename="Intro.mp4"` {
+			t.Errorf("presign options = %+v", *signer.opts)
+		}
+	})
+
+	t.Run("failed integrity check", func(t *testing.T) {
+		h := &Handler{logger: zap.NewNop(), fileStorage: signer, signedTTL: time.Hour}
+		failed := time.Now()
+		corrupt := *f
+		corrupt.IntegrityFailedAt = &failed
+		rec := httptest.NewRecorder()
+		if h.redirectToSigned(rec, httptest.NewRequest(http.MethodGet, "/library/file/x/view", nil), &corrupt, "inline") {
+			t.Error("redirected a file that failed its integrity check; it should be streamed so it is checked")
+		}
+	})
+
+	t.Run("cannot sign", func(t *testing.T) {
+		h := &Handler{logger: zap.NewNop(), fileStorage: storage.NewMemory(storage.MemoryConfig{}), signedTTL: time.Hour}
+		rec := httptest.NewRecorder()
+		if h.redirectToSigned(rec, httptest.NewRequest(http.MethodGet, "/library/file/x/view", nil), f, "inline") {
+			t.Error("redirected although the store can't sign URLs")
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package files
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/fileaccess"
+	"github.com/dalemusser/stratasave/internal/app/store/share"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Limits on what a share link can be created with.
+const (
+	maxShareDays      = 365
+	maxShareDownloads = 100000
+)
+
+// SetBaseURL sets the site's public URL, used to build share links. Without
+// it, links are shown relative to the site.
+func (h *Handler) SetBaseURL(baseURL string) {
+	h.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// ShareRoutes returns a chi.Router with the public share link download.
+// It needs no session: the token is the credential.
+func ShareRoutes(h *Handler) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/{token}", h.sharedDownload)
+
+	return r
+}
+
+// ShareLink is a share link as shown to admins.
+type ShareLink struct {
+	ID        string
+	URL       string
+	CreatedAt string
+	ExpiresAt string // Empty if the link never expires
+	Downloads string // e.g. "2 of 5", or "2" with no limit
+	Usable    bool
+}
+
+// ShareVM is the view model for a file's share links page.
+type ShareVM struct {
+	viewdata.BaseVM
+	FileID   string
+	FileName string
+	Links    []ShareLink
+	Success  string
+	Error    string
+}
+
+// showShares lists a file's share links, with a form to create another.
+func (h *Handler) showShares(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.shareLinks(r, f.ID)
+	if err != nil {
+		h.errLog.Log(r, "failed to list share links", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vm := ShareVM{
+		BaseVM:   viewdata.New(r),
+		FileID:   f.ID.Hex(),
+		FileName: f.Name,
+		Links:    links,
+	}
+	vm.Title = "Share File"
+	vm.BackURL = folderURL(f.FolderID)
+
+	switch r.URL.Query().Get("success") {
+	case "created":
+		vm.Success = "Share link created. Copy it below."
+	case "share_revoked":
+		vm.Success = "Share link revoked"
+	}
+	switch r.URL.Query().Get("error") {
+	case "invalid":
+		vm.Error = fmt.Sprintf("Expiry must be 0 to %d days and the download limit 0 to %d", maxShareDays, maxShareDownloads)
+	case "failed":
+		vm.Error = "Failed to create share link"
+	case "revoke_failed":
+		vm.Error = "Failed to revoke share link"
+	}
+
+	templates.Render(w, r, "files/share", vm)
+}
+
+// createShare creates a share link for a file.
+func (h *Handler) createShare(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+	sharesURL := "/library/file/" + f.ID.Hex() + "/shares"
+
+	days, err1 := formInt(r.FormValue("expires_days"))
+	maxDownloads, err2 := formInt(r.FormValue("max_downloads"))
+	if err1 != nil || err2 != nil || days < 0 || days > maxShareDays || maxDownloads < 0 || maxDownloads > maxShareDownloads {
+		http.Redirect(w, r, sharesURL+"?error=invalid", http.StatusSeeOther)
+		return
+	}
+
+	input := share.CreateInput{
+		FileID:       f.ID,
+		CreatedByID:  actor.UserID(),
+		MaxDownloads: maxDownloads,
+	}
+	if days > 0 {
+		expires := time.Now().UTC().Add(time.Duration(days) * 24 * time.Hour)
+		input.ExpiresAt = &expires
+	}
+
+	sh, err := h.shareStore.Create(r.Context(), input)
+	if err != nil {
+		h.errLog.Log(r, "failed to create share link", err)
+		http.Redirect(w, r, sharesURL+"?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &f.ID, "file_share_created", map[string]string{
+		"share_id":      sh.ID.Hex(),
+		"expires_days":  strconv.Itoa(days),
+		"max_downloads": strconv.Itoa(maxDownloads),
+	})
+
+	http.Redirect(w, r, sharesURL+"?success=created", http.StatusSeeOther)
+}
+
+// revokeShare revokes one of a file's share links.
+func (h *Handler) revokeShare(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	f, ok := h.loadFile(w, r)
+	if !ok {
+		return
+	}
+	shareID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "shareID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Links are revoked from the manage modal or the share page
+	returnURL := r.FormValue("return")
+	if !strings.HasPrefix(returnURL, "/library") {
+		returnURL = "/library/file/" + f.ID.Hex() + "/shares"
+	}
+	sep := "?"
+	if strings.Contains(returnURL, "?") {
+		sep = "&"
+	}
+
+	if err := h.shareStore.Revoke(r.Context(), shareID, f.ID); err != nil {
+		h.errLog.Log(r, "failed to revoke share link", err)
+		http.Redirect(w, r, returnURL+sep+"error=revoke_failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &f.ID, "file_share_revoked", map[string]string{
+		"share_id": shareID.Hex(),
+	})
+
+	http.Redirect(w, r, returnURL+sep+"success=share_revoked", http.StatusSeeOther)
+}
+
+// sharedDownload serves a file to anyone holding a usable share link.
+func (h *Handler) sharedDownload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sh, err := h.shareStore.GetByToken(ctx, chi.URLParam(r, "token"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// Only a new download counts against the link; a later range continues
+	// one already counted, so it is served even once the limit is reached
+	counts := countsAsAccess(r)
+	now := time.Now()
+	if !sh.Usable(now) && (counts || !sh.Resumable(now)) {
+		http.Error(w, "This link has expired or been revoked", http.StatusGone)
+		return
+	}
+
+	// Files in the trash can't be downloaded
+	f, err := h.fileStore.GetByID(ctx, sh.FileID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	reader, err := h.fileStorage.Get(ctx, f.StoragePath)
+	if err != nil {
+		h.errLog.Log(r, "failed to get file from storage", err)
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer reader.Close()
+
+	// Count the download only once the file can be served; this also stops
+	// concurrent downloads from going past the limit
+	if counts {
+		ok, err := h.shareStore.RecordDownload(ctx, sh.ID)
+		if err != nil {
+			h.errLog.Log(r, "failed to record share download", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "This link has expired or been revoked", http.StatusGone)
+			return
+		}
+
+		h.auditLogger.LogAdminEvent(r, nil, &f.ID, "file_share_downloaded", map[string]string{
+			"share_id": sh.ID.Hex(),
+		})
+	}
+	h.recordAccess(r, f, fileaccess.KindShareDownload, &sh.ID)
+
+	w.Header().Set("Content-Type", f.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", f.Name))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	h.serveContent(w, r, f, reader)
+}
+
+// shareLinks returns a file's share links that haven't been revoked.
+func (h *Handler) shareLinks(r *http.Request, fileID primitive.ObjectID) ([]ShareLink, error) {
+	shares, err := h.shareStore.ListActiveByFile(r.Context(), fileID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(viewdata.Location(r))
+	links := make([]ShareLink, 0, len(shares))
+	for i := range shares {
+		links = append(links, h.shareLink(&shares[i], now))
+	}
+	return links, nil
+}
+
+// shareLink formats a share link for display, with times in now's
+// location.
+func (h *Handler) shareLink(sh *share.Share, now time.Time) ShareLink {
+	link := ShareLink{
+		ID:        sh.ID.Hex(),
+		URL:       h.baseURL + "/share/" + sh.Token,
+		CreatedAt: sh.CreatedAt.In(now.Location()).Format("Jan 2, 2006"),
+		Downloads: strconv.Itoa(sh.Downloads),
+		Usable:    sh.Usable(now),
+	}
+	if sh.ExpiresAt != nil {
+		link.ExpiresAt = sh.ExpiresAt.In(now.Location()).Format("Jan 2, 2006 3:04 PM")
+	}
+	if sh.MaxDownloads > 0 {
+		link.Downloads = fmt.Sprintf("%d of %d", sh.Downloads, sh.MaxDownloads)
+	}
+	return link
+}
+
+// formInt parses an optional whole-number form field; empty means 0.
+func formInt(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,133 @@
+package files
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/share"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func TestShareLink(t *testing.T) {
+	h := &Handler{}
+	h.SetBaseURL("https://example.com/")
+
+	now := time.Now()
+	expires := now.Add(48 * time.Hour)
+	sh := &share.Share{
+		ID:           primitive.NewObjectID(),
+		Token:        "abc123",
+		ExpiresAt:    &expires,
+		MaxDownloads: 5,
+		Downloads:    2,
+		CreatedAt:    now,
+	}
+
+	link := h.shareLink(sh, now)
+	if link.URL != "https://example.com/share/abc123" {
+		t.Errorf("URL = %q, want %q", link.URL, "https://example.com/share/abc123")
+	}
+	if link.Downloads != "2 of 5" {
+		t.Errorf("Downloads = %q, want %q", link.Downloads, "2 of 5")
+	}
+	if link.ExpiresAt == "" || !link.Usable {
+		t.Errorf("ExpiresAt = %q, Usable = %v; want a date and true", link.ExpiresAt, link.Usable)
+	}
+
+	sh.ExpiresAt = nil
+	sh.MaxDownloads = 0
+	link = h.shareLink(sh, now)
+	if link.ExpiresAt != "" || link.Downloads != "2" {
+		t.Errorf("unlimited link = %+v, want no expiry and a plain count", link)
+	}
+}
+
+func TestFormInt(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{" 7 ", 7, false},
+		{"0", 0, false},
+		{"-3", -3, false},
+		{"ten", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := formInt(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("formInt(%q) = %d, %v; want %d, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSharedDownload_RangeContinuesCountedDownload(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	content := strings.Repeat("0123456789", 30)
+	store := storage.NewMemory(storage.MemoryConfig{})
+	if err := store.Put(ctx, "files/shared.txt", strings.NewReader(content), nil); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	h := NewHandler(db, store, errorsfeature.NewErrorLogger(zap.NewNop()), nil, zap.NewNop())
+	f, err := h.fileStore.Create(ctx, file.CreateInput{
+		Name:        "shared.txt",
+		StoragePath: "files/shared.txt",
+		Size:        int64(len(content)),
+		ContentType: "text/plain",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	sh, err := h.shareStore.Create(ctx, share.CreateInput{FileID: f.ID, MaxDownloads: 1})
+	if err != nil {
+		t.Fatalf("Create share error = %v", err)
+	}
+	routes := ShareRoutes(h)
+
+	get := func(rng string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+sh.Token, nil)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get(""); rec.Code != http.StatusOK {
+		t.Fatalf("first download: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// A resumed download or seek continues the counted download
+	rec := get("bytes=100-")
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("range after limit: status = %d, want %d", rec.Code, http.StatusPartialContent)
+	}
+	if rec.Body.String() != content[100:] {
+		t.Errorf("range body = %q, want the content from byte 100", rec.Body.String())
+	}
+	got, err := h.shareStore.GetByToken(ctx, sh.Token)
+	if err != nil {
+		t.Fatalf("GetByToken() error = %v", err)
+	}
+	if got.Downloads != 1 {
+		t.Errorf("Downloads = %d, want 1; a range continuation must not count", got.Downloads)
+	}
+
+	// A new download is over the limit
+	if rec := get(""); rec.Code != http.StatusGone {
+		t.Errorf("second download: status = %d, want %d", rec.Code, http.StatusGone)
+	}
+}
@@ -0,0 +1,121 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dalemusser/waffle/pantry/storage"
+)
+
+// maxCacheControl is the longest Cache-Control value accepted.
+const maxCacheControl = 256
+
+// Encryptions are the S3 server-side encryption settings that can be
+// chosen. With "aws:kms", S3 uses the bucket's default KMS key, or the AWS
+// managed key if the bucket has none.
+var Encryptions = []string{"AES256", "aws:kms"}
+
+// StorageClasses are the S3 storage classes that can be chosen for
+// uploads. Classes that need a restore before they can be read
+// (GLACIER, DEEP_ARCHIVE) are left out, as library files must be
+// readable at once.
+var StorageClasses = []string{
+	"STANDARD",
+	"STANDARD_IA",
+	"ONEZONE_IA",
+	"INTELLIGENT_TIERING",
+	"GLACIER_IR",
+}
+
+// StorageOptions are the storage settings given to uploaded files. Empty
+// fields leave the choice to the storage backend or bucket.
+type StorageOptions struct {
+	Encryption   string // Server-side encryption, one of Encryptions
+	StorageClass string // One of StorageClasses
+	CacheControl string // Cache-Control header stored with the file
+}
+
+// Validate checks that the options are ones that can be used.
+func (o StorageOptions) Validate() error {
+	if o.Encryption != "" && !slices.Contains(Encryptions, o.Encryption) {
+		return fmt.Errorf("encryption must be one of %s", strings.Join(Encryptions, ", "))
+	}
+	if o.StorageClass != "" && !slices.Contains(StorageClasses, o.StorageClass) {
+		return fmt.Errorf("storage class must be one of %s", strings.Join(StorageClasses, ", "))
+	}
+	if len(o.CacheControl) > maxCacheControl {
+		return fmt.Errorf("cache-control must be at most %d characters", maxCacheControl)
+	}
+	for _, c := range o.CacheControl {
+		if c < ' ' || c > '~' {
+			return errors.New("cache-control must be printable ASCII")
+		}
+	}
+	return nil
+}
+
+// Override returns o with each non-empty choice in place of its setting,
+// or an error if a choice can't be used.
+func (o StorageOptions) Override(encryption, storageClass, cacheControl string) (StorageOptions, error) {
+	if encryption = strings.TrimSpace(encryption); encryption != "" {
+		o.Encryption = encryption
+	}
+	if storageClass = strings.TrimSpace(storageClass); storageClass != "" {
+		o.StorageClass = storageClass
+	}
+	if cacheControl = strings.TrimSpace(cacheControl); cacheControl != "" {
+		o.CacheControl = cacheControl
+	}
+	if err := o.Validate(); err != nil {
+		return StorageOptions{}, err
+	}
+	return o, nil
+}
+
+// putOptions returns the options for storing a file with these settings.
+func (o StorageOptions) putOptions(contentType string) *storage.PutOptions {
+	return &storage.PutOptions{
+		ContentType:          contentType,
+		ServerSideEncryption: o.Encryption,
+		StorageClass:         o.StorageClass,
+		CacheControl:         o.CacheControl,
+	}
+}
+
+// SetStorageOptions sets the storage settings for uploaded and copied
+// files. If perUpload is set, uploaders can choose other settings for
+// each upload. Direct uploads get the bucket's own settings instead, as
+// the browser sends them straight to the bucket.
+func (h *Handler) SetStorageOptions(defaults StorageOptions, perUpload bool) {
+	h.storageOpts = defaults
+	h.storageChoice = perUpload
+}
+
+// chooseStorage reports whether the upload form offers storage settings.
+func (h *Handler) chooseStorage() bool {
+	return h.storageChoice && !(h.direct && h.uploads != nil)
+}
+
+// setStorageChoices fills in the upload form's storage settings.
+func (h *Handler) setStorageChoices(vm *FileUploadVM) {
+	if !h.chooseStorage() {
+		return
+	}
+	vm.ChooseStorage = true
+	vm.Storage = h.storageOpts
+	vm.Encryptions = Encryptions
+	vm.StorageClasses = StorageClasses
+}
+
+// uploadStorageOptions returns the storage settings for an upload: the
+// defaults, with the uploader's choices in their place when choices are
+// offered. It returns false if a choice can't be used.
+func (h *Handler) uploadStorageOptions(encryption, storageClass, cacheControl string) (StorageOptions, bool) {
+	if !h.chooseStorage() {
+		return h.storageOpts, true
+	}
+	opts, err := h.storageOpts.Override(encryption, storageClass, cacheControl)
+	return opts, err == nil
+}
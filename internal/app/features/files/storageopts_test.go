@@ -0,0 +1,57 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/resumable"
+)
+
+func TestStorageOptionsOverride(t *testing.T) {
+	defaults := StorageOptions{Encryption: "AES256", CacheControl: "private, max-age=3600"}
+
+	got, err := defaults.Override("aws:kms", " STANDARD_IA ", "")
+	if err != nil {
+		t.Fatalf("Override: %v", err)
+	}
+	want := StorageOptions{Encryption: "aws:kms", StorageClass: "STANDARD_IA", CacheControl: "private, max-age=3600"}
+	if got != want {
+		t.Errorf("Override = %+v, want %+v", got, want)
+	}
+
+	for _, tt := range []struct{ encryption, class, cacheControl string }{
+		{"aws:kms:dsse", "", ""},
+		{"", "DEEP_ARCHIVE", ""},
+		{"", "", "no-cache\r\nX-Injected: 1"},
+	} {
+		if _, err := defaults.Override(tt.encryption, tt.class, tt.cacheControl); err == nil {
+			t.Errorf("Override(%q, %q, %q): expected error", tt.encryption, tt.class, tt.cacheControl)
+		}
+	}
+}
+
+func TestUploadStorageOptions(t *testing.T) {
+	h := &Handler{}
+	h.SetStorageOptions(StorageOptions{StorageClass: "STANDARD"}, false)
+
+	// Choices are ignored unless they are offered
+	opts, ok := h.uploadStorageOptions("", "GLACIER_IR", "")
+	if !ok || opts.StorageClass != "STANDARD" {
+		t.Errorf("without choices = %+v, %v", opts, ok)
+	}
+
+	h.SetStorageOptions(StorageOptions{StorageClass: "STANDARD"}, true)
+	opts, ok = h.uploadStorageOptions("", "GLACIER_IR", "")
+	if !ok || opts.StorageClass != "GLACIER_IR" {
+		t.Errorf("with choices = %+v, %v", opts, ok)
+	}
+	if _, ok := h.uploadStorageOptions("", "NOPE", ""); ok {
+		t.Error("unknown storage class accepted")
+	}
+
+	// Direct uploads go straight to the bucket, so there is no choice
+	h.SetDirectUploads(true)
+	h.uploads = &resumable.Manager{}
+	if h.chooseStorage() {
+		t.Error("chooseStorage with direct uploads")
+	}
+}
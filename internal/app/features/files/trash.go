@@ -0,0 +1,344 @@
+package files
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/librarytrash"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inlinePurgeMax is the most files a trashed folder can hold to be
+// deleted within the request; larger folders are deleted by a background
+// job.
+const inlinePurgeMax = 100
+
+// SetTrash sets the trash used to permanently delete library items.
+// Without it, trashed items can be restored but not deleted by hand.
+func (h *Handler) SetTrash(t *librarytrash.Trash) {
+	h.trash = t
+}
+
+// TrashItem is a file or folder in the trash.
+type TrashItem struct {
+	ID          string
+	Kind        string // "file" or "folder"
+	Name        string
+	TypeIcon    string
+	Size        string // Files only
+	DeletedAt   string
+	PurgeAt     string // Empty if items are kept until deleted by hand
+	ContentType string
+	JobID       string // Background job deleting the folder, if one is
+}
+
+// TrashVM is the view model for the trash page.
+type TrashVM struct {
+	viewdata.BaseVM
+	Items     []TrashItem
+	CanDelete bool
+	Retention int // Days items are kept (0 = until deleted by hand)
+	Success   string
+	Error     string
+}
+
+// showTrash lists trashed files and folders.
+func (h *Handler) showTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	folders, err := h.folderStore.ListTrash(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list trashed folders", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	files, err := h.fileStore.ListTrash(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list trashed files", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var retention time.Duration
+	if h.trash != nil {
+		retention = h.trash.Retention()
+	}
+	purgeAt := func(deleted time.Time) string {
+		if retention == 0 {
+			return ""
+		}
+		return viewdata.FormatTime(r, deleted.Add(retention), "Jan 2, 2006")
+	}
+
+	items := make([]TrashItem, 0, len(folders)+len(files))
+	for i, f := range folders {
+		item := TrashItem{
+			ID:        f.ID.Hex(),
+			Kind:      "folder",
+			Name:      f.Name,
+			TypeIcon:  "folder",
+			DeletedAt: viewdata.FormatTime(r, *f.DeletedAt, "Jan 2, 2006 3:04 PM"),
+			PurgeAt:   purgeAt(*f.DeletedAt),
+		}
+		if h.trash != nil {
+			deleting, err := h.trash.Deleting(ctx, &folders[i])
+			if err != nil {
+				h.errLog.Log(r, "failed to check folder purge job", err)
+			} else if deleting {
+				item.JobID = f.PurgeJobID.Hex()
+			}
+		}
+		items = append(items, item)
+	}
+	for _, f := range files {
+		items = append(items, TrashItem{
+			ID:          f.ID.Hex(),
+			Kind:        "file",
+			Name:        f.Name,
+			TypeIcon:    FileTypeIcon(f.ContentType),
+			Size:        FormatFileSize(f.Size),
+			DeletedAt:   viewdata.FormatTime(r, *f.DeletedAt, "Jan 2, 2006 3:04 PM"),
+			PurgeAt:     purgeAt(*f.DeletedAt),
+			ContentType: f.ContentType,
+		})
+	}
+
+	vm := TrashVM{
+		BaseVM:    viewdata.New(r),
+		Items:     items,
+		CanDelete: h.trash != nil,
+		Retention: int(retention / (24 * time.Hour)),
+	}
+	vm.Title = "Trash"
+	vm.BackURL = "/library"
+
+	switch r.URL.Query().Get("success") {
+	case "restored":
+		vm.Success = "Restored successfully"
+	case "deleted":
+		vm.Success = "Deleted permanently"
+	case "deleting":
+		vm.Success = "The folder is being deleted in the background. Its progress is shown on the Jobs page."
+	}
+	switch r.URL.Query().Get("error") {
+	case "name_taken":
+		vm.Error = "An item with the same name now exists where it would be restored. Rename or move that item, then try again."
+	case "deleting":
+		vm.Error = "That folder is already being deleted"
+	case "failed":
+		vm.Error = "The operation failed"
+	}
+
+	templates.Render(w, r, "files/trash", vm)
+}
+
+// restoreFile takes a file out of the trash, back into its folder, or into
+// the root if that folder is gone.
+func (h *Handler) restoreFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.fileStore.GetTrashedByID(ctx, objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	dest := f.FolderID
+	if dest != nil {
+		if _, err := h.folderStore.GetByID(ctx, *dest); err != nil {
+			dest = nil
+		}
+	}
+
+	exists, err := h.fileStore.NameExistsInFolder(ctx, f.Name, dest, nil)
+	if err != nil {
+		h.errLog.Log(r, "failed to check file name", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+	if exists {
+		http.Redirect(w, r, "/library/trash?error=name_taken", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.fileStore.Restore(ctx, objID, dest); err != nil {
+		h.errLog.Log(r, "failed to restore file", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "file_restored", nil)
+
+	http.Redirect(w, r, "/library/trash?success=restored", http.StatusSeeOther)
+}
+
+// restoreFolder takes a folder, with everything trashed along with it, out
+// of the trash. It goes back under its parent, or into the root if the
+// parent is gone.
+func (h *Handler) restoreFolder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.folderStore.GetTrashedByID(ctx, objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if h.purging(w, r, f) {
+		return
+	}
+
+	dest := f.ParentID
+	if dest != nil {
+		if _, err := h.folderStore.GetByID(ctx, *dest); err != nil {
+			dest = nil
+		}
+	}
+
+	exists, err := h.folderStore.NameExistsInParent(ctx, f.Name, dest, nil)
+	if err != nil {
+		h.errLog.Log(r, "failed to check folder name", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+	if exists {
+		http.Redirect(w, r, "/library/trash?error=name_taken", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.fileStore.RestoreTrashedWith(ctx, objID); err != nil {
+		h.errLog.Log(r, "failed to restore folder contents", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+	if err := h.folderStore.Restore(ctx, objID, dest); err != nil {
+		h.errLog.Log(r, "failed to restore folder", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_restored", nil)
+
+	http.Redirect(w, r, "/library/trash?success=restored", http.StatusSeeOther)
+}
+
+// purgeFile permanently deletes a trashed file.
+func (h *Handler) purgeFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.fileStore.GetTrashedByID(ctx, objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.trash.DeleteFile(ctx, f); err != nil {
+		h.errLog.Log(r, "failed to delete file", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "file_deleted", nil)
+
+	http.Redirect(w, r, "/library/trash?success=deleted", http.StatusSeeOther)
+}
+
+// purgeFolder permanently deletes a trashed folder and everything trashed
+// along with it.
+func (h *Handler) purgeFolder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.folderStore.GetTrashedByID(ctx, objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if h.purging(w, r, f) {
+		return
+	}
+
+	files, err := h.fileStore.CountTrashedWith(ctx, objID)
+	if err != nil {
+		h.errLog.Log(r, "failed to count folder files", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+	actorID := actor.UserID()
+
+	// Large folders would outlast the request timeout
+	if files > inlinePurgeMax {
+		jobID, err := h.trash.QueueDeleteFolder(ctx, objID)
+		if err != nil {
+			h.errLog.Log(r, "failed to queue folder deletion", err)
+			http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+			return
+		}
+		h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_delete_queued", map[string]string{
+			"job_id": jobID.Hex(),
+			"files":  strconv.FormatInt(files, 10),
+		})
+		http.Redirect(w, r, "/library/trash?success=deleting", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.trash.DeleteFolder(ctx, objID); err != nil {
+		h.errLog.Log(r, "failed to delete folder", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "folder_deleted", nil)
+
+	http.Redirect(w, r, "/library/trash?success=deleted", http.StatusSeeOther)
+}
+
+// purging redirects back to the trash, and reports true, if a background
+// job is already deleting f.
+func (h *Handler) purging(w http.ResponseWriter, r *http.Request, f *models.Folder) bool {
+	if h.trash == nil {
+		return false
+	}
+	deleting, err := h.trash.Deleting(r.Context(), f)
+	if err != nil {
+		h.errLog.Log(r, "failed to check folder purge job", err)
+		http.Redirect(w, r, "/library/trash?error=failed", http.StatusSeeOther)
+		return true
+	}
+	if deleting {
+		http.Redirect(w, r, "/library/trash?error=deleting", http.StatusSeeOther)
+		return true
+	}
+	return false
+}
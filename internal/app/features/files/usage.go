@@ -0,0 +1,183 @@
+package files
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UsageRow is one line of the storage dashboard: a folder or a content
+// type.
+type UsageRow struct {
+	Name         string
+	TypeIcon     string           // Content types only
+	Path         []BreadcrumbItem // Folders only
+	URL          string
+	Files        int64
+	Size         string
+	Percent      int    // Share of the library's bytes
+	Quota        string // Folders with a quota only
+	QuotaPercent int
+}
+
+// StorageVM is the view model for the storage dashboard.
+type StorageVM struct {
+	viewdata.BaseVM
+	Files        int64
+	Size         string
+	TrashFiles   int64
+	TrashSize    string
+	Stored       string
+	Quota        string // Empty if the library has no quota
+	QuotaPercent int
+	Folders      []UsageRow // Largest first, including subfolders
+	Types        []UsageRow // Largest first
+}
+
+// folderUsage is how much a folder holds, including its subfolders.
+type folderUsage struct {
+	Files int64
+	Bytes int64
+}
+
+// rollUpUsage adds the usage directly in each folder to the folder and
+// every folder above it. Usage of unknown folders is left out.
+func rollUpUsage(folders []models.Folder, usage []file.FolderUsage) map[primitive.ObjectID]folderUsage {
+	byID := make(map[primitive.ObjectID]*models.Folder, len(folders))
+	for i := range folders {
+		byID[folders[i].ID] = &folders[i]
+	}
+
+	totals := make(map[primitive.ObjectID]folderUsage, len(folders))
+	for _, u := range usage {
+		id := u.FolderID
+		// Stop after len(folders) steps in case of a cycle
+		for steps := 0; id != nil && steps <= len(folders); steps++ {
+			f, ok := byID[*id]
+			if !ok {
+				break
+			}
+			t := totals[f.ID]
+			t.Files += u.Files
+			t.Bytes += u.Bytes
+			totals[f.ID] = t
+			id = f.ParentID
+		}
+	}
+	return totals
+}
+
+// percent returns part as a whole-number percentage of whole, capped at 100.
+func percent(part, whole int64) int {
+	if whole <= 0 {
+		return 0
+	}
+	p := int(part * 100 / whole)
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+// showStorage displays how much storage the library uses, by folder and
+// by content type.
+func (h *Handler) showStorage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	totals, err := h.fileStore.Usage(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to sum library usage", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	byFolder, err := h.fileStore.UsageByFolder(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to sum usage by folder", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	byType, err := h.fileStore.UsageByType(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to sum usage by type", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	folders, err := h.folderStore.ListAll(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list folders", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vm := StorageVM{
+		BaseVM:     viewdata.New(r),
+		Files:      totals.Files,
+		Size:       FormatFileSize(totals.Bytes),
+		TrashFiles: totals.TrashFiles,
+		TrashSize:  FormatFileSize(totals.TrashBytes),
+		Stored:     FormatFileSize(totals.StoredBytes),
+	}
+	if h.quota > 0 {
+		vm.Quota = FormatFileSize(h.quota)
+		vm.QuotaPercent = percent(totals.StoredBytes, h.quota)
+	}
+
+	// Folders, with their subfolders counted in, largest first
+	rolled := rollUpUsage(folders, byFolder)
+	pathOf := folderPaths(folders)
+	listed := make([]*models.Folder, 0, len(folders))
+	for i := range folders {
+		if rolled[folders[i].ID].Bytes > 0 || folders[i].QuotaBytes > 0 {
+			listed = append(listed, &folders[i])
+		}
+	}
+	sort.SliceStable(listed, func(i, j int) bool {
+		return rolled[listed[i].ID].Bytes > rolled[listed[j].ID].Bytes
+	})
+	for _, f := range listed {
+		t := rolled[f.ID]
+		row := UsageRow{
+			Name:    f.Name,
+			Path:    pathOf(f.ParentID),
+			URL:     folderURL(&f.ID),
+			Files:   t.Files,
+			Size:    FormatFileSize(t.Bytes),
+			Percent: percent(t.Bytes, totals.Bytes),
+		}
+		if f.QuotaBytes > 0 {
+			row.Quota = FormatFileSize(f.QuotaBytes)
+			row.QuotaPercent = percent(t.Bytes, f.QuotaBytes)
+		}
+		vm.Folders = append(vm.Folders, row)
+	}
+	for _, u := range byFolder {
+		if u.FolderID == nil {
+			vm.Folders = append(vm.Folders, UsageRow{
+				Name:    "Files at the library root",
+				URL:     "/library",
+				Files:   u.Files,
+				Size:    FormatFileSize(u.Bytes),
+				Percent: percent(u.Bytes, totals.Bytes),
+			})
+		}
+	}
+
+	for _, u := range byType {
+		vm.Types = append(vm.Types, UsageRow{
+			Name:     u.ContentType,
+			TypeIcon: FileTypeIcon(u.ContentType),
+			Files:    u.Files,
+			Size:     FormatFileSize(u.Bytes),
+			Percent:  percent(u.Bytes, totals.Bytes),
+		})
+	}
+
+	vm.Title = "Storage"
+	vm.BackURL = "/library"
+	templates.Render(w, r, "files/storage", vm)
+}
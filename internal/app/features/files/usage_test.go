@@ -0,0 +1,77 @@
+package files
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRollUpUsage(t *testing.T) {
+	top := models.Folder{ID: primitive.NewObjectID(), Name: "Media"}
+	mid := models.Folder{ID: primitive.NewObjectID(), Name: "Video", ParentID: &top.ID}
+	leaf := models.Folder{ID: primitive.NewObjectID(), Name: "2026", ParentID: &mid.ID}
+	other := models.Folder{ID: primitive.NewObjectID(), Name: "Docs"}
+	unknown := primitive.NewObjectID()
+
+	totals := rollUpUsage([]models.Folder{top, mid, leaf, other}, []file.FolderUsage{
+		{FolderID: nil, Files: 9, Bytes: 900}, // Library root
+		{FolderID: &top.ID, Files: 1, Bytes: 10},
+		{FolderID: &leaf.ID, Files: 2, Bytes: 200},
+		{FolderID: &other.ID, Files: 3, Bytes: 30},
+		{FolderID: &unknown, Files: 4, Bytes: 40},
+	})
+
+	want := map[primitive.ObjectID]folderUsage{
+		top.ID:   {Files: 3, Bytes: 210},
+		mid.ID:   {Files: 2, Bytes: 200},
+		leaf.ID:  {Files: 2, Bytes: 200},
+		other.ID: {Files: 3, Bytes: 30},
+	}
+	if len(totals) != len(want) {
+		t.Errorf("rollUpUsage() has %d folders, want %d", len(totals), len(want))
+	}
+	for id, w := range want {
+		if got := totals[id]; got != w {
+			t.Errorf("rollUpUsage()[%s] = %+v, want %+v", id.Hex(), got, w)
+		}
+	}
+}
+
+func TestRollUpUsage_Cycle(t *testing.T) {
+	a := models.Folder{ID: primitive.NewObjectID()}
+	b := models.Folder{ID: primitive.NewObjectID(), ParentID: &a.ID}
+	a.ParentID = &b.ID
+
+	// Must return rather than loop forever
+	rollUpUsage([]models.Folder{a, b}, []file.FolderUsage{{FolderID: &a.ID, Files: 1, Bytes: 1}})
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		part, whole int64
+		want        int
+	}{
+		{0, 0, 0},
+		{50, 200, 25},
+		{1, 3, 33},
+		{300, 200, 100},
+	}
+	for _, tt := range tests {
+		if got := percent(tt.part, tt.whole); got != tt.want {
+			t.Errorf("percent(%d, %d) = %d, want %d", tt.part, tt.whole, got, tt.want)
+		}
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	err := &quotaError{scope: `folder "Video"`, quota: 10 << 20, used: 9 << 20}
+	msg := err.Error()
+	for _, want := range []string{"10.0 MB", `folder "Video"`, "9.0 MB used"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("quotaError.Error() = %q, missing %q", msg, want)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package files
 
 import (
+	"encoding/base64"
 	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -66,3 +68,93 @@ func IsViewable(contentType string) bool {
 		return false
 	}
 }
+
+// ParseUploadMetadata parses a tus Upload-Metadata header: comma-separated
+// pairs of a key and a base64-encoded value ("filename ZmlsZS50eHQ=").
+// A key may appear without a value. Pairs that don't decode are skipped.
+func ParseUploadMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 1:
+			meta[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				continue
+			}
+			meta[fields[0]] = string(value)
+		}
+	}
+	return meta
+}
+
+// CopyName returns the name for the nth copy of a file: the name itself
+// for n = 0, then "report (copy).pdf", "report (copy 2).pdf", and so on.
+func CopyName(name string, n int) string {
+	if n == 0 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = "" // A dotfile such as ".env" has no extension
+	}
+	base := strings.TrimSuffix(name, ext)
+	if n == 1 {
+		return base + " (copy)" + ext
+	}
+	return fmt.Sprintf("%s (copy %d)%s", base, n, ext)
+}
+
+// Limits on file tags.
+const (
+	MaxTags      = 20
+	MaxTagLength = 40
+)
+
+// ParseTags parses a comma-separated list of tags. Tags are lowercased,
+// runs of spaces are collapsed, and duplicates and empty entries dropped.
+func ParseTags(s string) ([]string, error) {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		tag := strings.ToLower(strings.Join(strings.Fields(part), " "))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len([]rune(tag)) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > MaxTags {
+		return nil, fmt.Errorf("a file can have at most %d tags", MaxTags)
+	}
+	return tags, nil
+}
+
+// fileAuditFields returns a file's editable metadata, as shown in the
+// audit log.
+func fileAuditFields(name, description string, tags []string) map[string]string {
+	return map[string]string{
+		"name":        name,
+		"description": description,
+		"tags":        strings.Join(tags, ", "),
+	}
+}
+
+// folderAuditFields returns a folder's editable metadata, as shown in the
+// audit log. A folder with no quota has an empty quota.
+func folderAuditFields(name, description string, quotaBytes int64) map[string]string {
+	quota := ""
+	if quotaBytes > 0 {
+		quota = FormatFileSize(quotaBytes)
+	}
+	return map[string]string{
+		"name":        name,
+		"description": description,
+		"quota":       quota,
+	}
+}
@@ -1,6 +1,10 @@
 package files
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestFormatFileSize(t *testing.T) {
 	tests := []struct {
@@ -159,3 +163,72 @@ func TestIsViewable(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUploadMetadata(t *testing.T) {
+	meta := ParseUploadMetadata("filename ZGVtbyB2aWRlby5tcDQ=, filetype dmlkZW8vbXA0,is_private, bad !!!")
+
+	if got := meta["filename"]; got != "demo video.mp4" {
+		t.Errorf("filename = %q, want %q", got, "demo video.mp4")
+	}
+	if got := meta["filetype"]; got != "video/mp4" {
+		t.Errorf("filetype = %q, want %q", got, "video/mp4")
+	}
+	if v, ok := meta["is_private"]; !ok || v != "" {
+		t.Errorf("is_private = %q, %v; want empty and present", v, ok)
+	}
+	if _, ok := meta["bad"]; ok {
+		t.Error("a value that isn't base64 should be skipped")
+	}
+	if len(ParseUploadMetadata("")) != 0 {
+		t.Error("an empty header should give no metadata")
+	}
+}
+
+func TestCopyName(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"report.pdf", 0, "report.pdf"},
+		{"report.pdf", 1, "report (copy).pdf"},
+		{"report.pdf", 2, "report (copy 2).pdf"},
+		{"archive.tar.gz", 1, "archive.tar (copy).gz"},
+		{"README", 1, "README (copy)"},
+		{".env", 3, ".env (copy 3)"},
+	}
+	for _, tt := range tests {
+		if got := CopyName(tt.name, tt.n); got != tt.want {
+			t.Errorf("CopyName(%q, %d) = %q, want %q", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	var tooMany []string
+	for i := 0; i <= MaxTags; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("tag%d", i))
+	}
+
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"onboarding", []string{"onboarding"}, false},
+		{" Onboarding ,  2025   Curriculum,onboarding,, ", []string{"onboarding", "2025 curriculum"}, false},
+		{strings.Repeat("x", MaxTagLength+1), nil, true},
+		{strings.Join(tooMany, ","), nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTags(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTags(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("ParseTags(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
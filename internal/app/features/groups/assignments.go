@@ -0,0 +1,359 @@
+// internal/app/features/groups/assignments.go
+package groups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/assignment"
+	"github.com/dalemusser/stratasave/internal/app/store/group"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxInstructionsLen is the longest assignment instructions accepted.
+const maxInstructionsLen = 2000
+
+// groupOption is a group that a resource can be assigned to.
+type groupOption struct {
+	ID   string
+	Name string
+}
+
+// AssignVM is the view model for the assign-to-group form.
+type AssignVM struct {
+	viewdata.BaseVM
+	ResourceType string
+	ResourceID   string
+	ResourceName string
+	Groups       []groupOption
+	GroupID      string
+	Instructions string
+	VisibleFrom  string
+	VisibleUntil string
+	Notify       bool
+	Error        string
+}
+
+// resource returns the name of a library file or folder and the path
+// members use to open it. It returns mongo.ErrNoDocuments if the resource
+// has been deleted or is in the trash.
+func (h *Handler) resource(r *http.Request, resourceType string, id primitive.ObjectID) (name, path string, err error) {
+	switch resourceType {
+	case assignment.TypeFile:
+		f, err := h.files.GetByID(r.Context(), id)
+		if err != nil {
+			return "", "", err
+		}
+		return f.Name, "/library/file/" + f.ID.Hex() + "/view", nil
+	case assignment.TypeFolder:
+		f, err := h.folders.GetByID(r.Context(), id)
+		if err != nil {
+			return "", "", err
+		}
+		return f.Name, "/library/folder/" + f.ID.Hex(), nil
+	}
+	return "", "", mongo.ErrNoDocuments
+}
+
+// newAssignVM returns the assign form for a resource, with the groups to
+// choose from.
+func (h *Handler) newAssignVM(r *http.Request, resourceType, resourceID, resourceName string) (AssignVM, error) {
+	groups, err := h.groups.List(r.Context())
+	if err != nil {
+		return AssignVM{}, err
+	}
+	options := make([]groupOption, 0, len(groups))
+	for _, g := range groups {
+		options = append(options, groupOption{ID: g.ID.Hex(), Name: g.Name})
+	}
+
+	vm := AssignVM{
+		BaseVM:       viewdata.New(r),
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		ResourceName: resourceName,
+		Groups:       options,
+	}
+	vm.Title = "Assign to Group"
+	vm.BackURL = r.FormValue("return")
+	if vm.BackURL == "" {
+		vm.BackURL = "/library/"
+	}
+	return vm, nil
+}
+
+// loadResource loads the resource named by the type and id form values,
+// sending a 404 if there is none.
+func (h *Handler) loadResource(w http.ResponseWriter, r *http.Request) (resourceType string, id primitive.ObjectID, name string, ok bool) {
+	resourceType = r.FormValue("type")
+	id, err := primitive.ObjectIDFromHex(r.FormValue("id"))
+	if err != nil || !assignment.IsValidType(resourceType) {
+		http.NotFound(w, r)
+		return "", id, "", false
+	}
+	name, _, err = h.resource(r, resourceType, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.NotFound(w, r)
+		return "", id, "", false
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to load assigned resource", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return "", id, "", false
+	}
+	return resourceType, id, name, true
+}
+
+// showAssign displays the form for assigning a library file or folder to a
+// group.
+func (h *Handler) showAssign(w http.ResponseWriter, r *http.Request) {
+	resourceType, id, name, ok := h.loadResource(w, r)
+	if !ok {
+		return
+	}
+
+	vm, err := h.newAssignVM(r, resourceType, id.Hex(), name)
+	if err != nil {
+		h.errLog.Log(r, "failed to list groups", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	vm.GroupID = r.URL.Query().Get("group")
+	vm.Notify = true
+	templates.Render(w, r, "groups/assign", vm)
+}
+
+// assign assigns a library file or folder to a group and, if asked,
+// emails the group's members about it.
+func (h *Handler) assign(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	resourceType, resourceID, name, ok := h.loadResource(w, r)
+	if !ok {
+		return
+	}
+
+	vm, err := h.newAssignVM(r, resourceType, resourceID.Hex(), name)
+	if err != nil {
+		h.errLog.Log(r, "failed to list groups", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	vm.GroupID = r.FormValue("group_id")
+	vm.Instructions = strings.TrimSpace(r.FormValue("instructions"))
+	vm.VisibleFrom = r.FormValue("visible_from")
+	vm.VisibleUntil = r.FormValue("visible_until")
+	vm.Notify = r.FormValue("notify") == "on"
+	renderErr := func(msg string) {
+		vm.Error = msg
+		templates.Render(w, r, "groups/assign", vm)
+	}
+
+	var g *group.Group
+	if groupID, err := primitive.ObjectIDFromHex(vm.GroupID); err == nil {
+		g, err = h.groups.GetByID(r.Context(), groupID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			h.errLog.Log(r, "failed to load group", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if g == nil {
+		renderErr("Choose a group")
+		return
+	}
+	if len([]rune(vm.Instructions)) > maxInstructionsLen {
+		renderErr(fmt.Sprintf("Instructions must be %d characters or fewer", maxInstructionsLen))
+		return
+	}
+	from, until, msg := parseWindow(vm.VisibleFrom, vm.VisibleUntil)
+	if msg != "" {
+		renderErr(msg)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	a, err := h.assignments.Create(r.Context(), assignment.CreateInput{
+		GroupID:      g.ID,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Instructions: vm.Instructions,
+		VisibleFrom:  from,
+		VisibleUntil: until,
+		AssignedByID: actorID,
+	})
+	if err != nil {
+		h.errLog.Log(r, "failed to create assignment", err)
+		renderErr("Failed to assign resource")
+		return
+	}
+
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "resource_assigned", map[string]string{
+		"group_id":      g.ID.Hex(),
+		"group":         g.Name,
+		"assignment_id": a.ID.Hex(),
+		"resource_type": resourceType,
+		"resource_id":   resourceID.Hex(),
+		"resource":      name,
+	})
+
+	if vm.Notify && h.mailer != nil {
+		if err := h.notifyAssigned(r, *g, *a, name); err != nil {
+			h.errLog.Log(r, "failed to queue assignment emails", err)
+		}
+	}
+
+	http.Redirect(w, r, "/groups/"+g.ID.Hex()+"?success=assigned", http.StatusSeeOther)
+}
+
+// notifyAssigned emails each of a group's members that has an email
+// address about a new assignment. The emails are sent in the background.
+func (h *Handler) notifyAssigned(r *http.Request, g group.Group, a assignment.Assignment, resourceName string) error {
+	members, err := h.members.ListByGroup(r.Context(), g.ID)
+	if err != nil {
+		return err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+	}
+	users, err := h.users.GetByIDs(r.Context(), userIDs)
+	if err != nil {
+		return err
+	}
+
+	_, path, err := h.resource(r, a.ResourceType, a.ResourceID)
+	if err != nil {
+		return err
+	}
+	brand := h.mailer.Brand(r.Context())
+	siteName := viewdata.New(r).SiteName
+
+	emails := make([]mailer.Email, 0, len(users))
+	for _, u := range users {
+		if u.Email == nil || *u.Email == "" || u.Status != "active" {
+			continue
+		}
+		text, html := mailer.ResourceAssignedEmail(mailer.ResourceAssignedEmailData{
+			Locale:       u.Locale,
+			Brand:        brand,
+			AppName:      siteName,
+			UserName:     u.FullName,
+			ResourceName: resourceName,
+			ResourceType: a.ResourceType,
+			GroupName:    g.Name,
+			Instructions: a.Instructions,
+			LaunchURL:    h.baseURL + path,
+			VisibleFrom:  formatTime(a.VisibleFrom),
+			VisibleUntil: formatTime(a.VisibleUntil),
+		})
+		emails = append(emails, mailer.Email{
+			To:       *u.Email,
+			Subject:  mailer.T(u.Locale, "resource_assigned.title"),
+			Template: "resource_assigned",
+			UserID:   u.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		})
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	go func() {
+		p, err := h.mailer.SendBulk(context.Background(), emails, nil)
+		if err != nil || p.Failed > 0 {
+			h.logger.Warn("some assignment emails were not sent",
+				zap.String("assignment_id", a.ID.Hex()),
+				zap.Int("failed", p.Failed),
+				zap.Int("total", p.Total),
+				zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// unassign removes a resource from a group.
+func (h *Handler) unassign(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "assignmentID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	a, err := h.assignments.GetByID(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && a.GroupID != g.ID) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to load assignment", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.assignments.Delete(r.Context(), a.ID); err != nil {
+		h.errLog.Log(r, "failed to delete assignment", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "resource_unassigned", map[string]string{
+		"group_id":      g.ID.Hex(),
+		"group":         g.Name,
+		"assignment_id": a.ID.Hex(),
+		"resource_type": a.ResourceType,
+		"resource_id":   a.ResourceID.Hex(),
+	})
+
+	http.Redirect(w, r, "/groups/"+g.ID.Hex()+"?success=unassigned", http.StatusSeeOther)
+}
+
+// parseWindow parses the optional datetime-local inputs bounding when
+// members see an assignment. It returns a message for the user if they
+// are not valid.
+func parseWindow(fromValue, untilValue string) (from, until *time.Time, msg string) {
+	parse := func(v string) (*time.Time, bool) {
+		if v == "" {
+			return nil, true
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04", v, time.Local)
+		if err != nil {
+			return nil, false
+		}
+		return &t, true
+	}
+
+	from, ok := parse(fromValue)
+	if !ok {
+		return nil, nil, "Visible from is not a valid date and time"
+	}
+	until, ok = parse(untilValue)
+	if !ok {
+		return nil, nil, "Visible until is not a valid date and time"
+	}
+	if from != nil && until != nil && !until.After(*from) {
+		return nil, nil, "Visible until must be after visible from"
+	}
+	return from, until, ""
+}
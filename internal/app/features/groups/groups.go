@@ -0,0 +1,448 @@
+// internal/app/features/groups/groups.go
+package groups
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/assignment"
+	filestore "github.com/dalemusser/stratasave/internal/app/store/file"
+	folderstore "github.com/dalemusser/stratasave/internal/app/store/folder"
+	"github.com/dalemusser/stratasave/internal/app/store/group"
+	"github.com/dalemusser/stratasave/internal/app/store/groupmember"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxNameLen is the longest group name accepted.
+const maxNameLen = 100
+
+// Handler provides group handlers.
+type Handler struct {
+	groups      *group.Store
+	members     *groupmember.Store
+	assignments *assignment.Store
+	users       *userstore.Store
+	files       *filestore.Store
+	folders     *folderstore.Store
+	mailer      *mailer.Mailer
+	errLog      *errorsfeature.ErrorLogger
+	auditLogger *auditlog.Logger
+	logger      *zap.Logger
+	baseURL     string
+}
+
+// NewHandler creates a new groups Handler.
+func NewHandler(
+	db *mongo.Database,
+	m *mailer.Mailer,
+	errLog *errorsfeature.ErrorLogger,
+	auditLogger *auditlog.Logger,
+	logger *zap.Logger,
+) *Handler {
+	return &Handler{
+		groups:      group.New(db),
+		members:     groupmember.New(db),
+		assignments: assignment.New(db),
+		users:       userstore.New(db),
+		files:       filestore.New(db),
+		folders:     folderstore.New(db),
+		mailer:      m,
+		errLog:      errLog,
+		auditLogger: auditLogger,
+		logger:      logger,
+	}
+}
+
+// SetBaseURL sets the site's public URL, used for the links in
+// notification emails.
+func (h *Handler) SetBaseURL(baseURL string) {
+	h.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// Routes returns a chi.Router with group routes mounted. Admins manage all
+// groups; other users see the groups they belong to, and leaders can add
+// and remove their group's members.
+func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
+	r := chi.NewRouter()
+	r.Use(sessionMgr.RequireAuth)
+
+	r.Get("/", h.list)
+
+	// Admin-only routes
+	r.Group(func(r chi.Router) {
+		r.Use(sessionMgr.RequireRole("admin"))
+
+		r.Get("/new", h.showNew)
+		r.Post("/new", h.create)
+		r.Get("/assign", h.showAssign)
+		r.Post("/assign", h.assign)
+		r.Get("/{id}/edit", h.showEdit)
+		r.Post("/{id}", h.update)
+		r.Post("/{id}/delete", h.delete)
+		r.Post("/{id}/members/{userID}/role", h.setMemberRole)
+		r.Post("/{id}/assignments/{assignmentID}/remove", h.unassign)
+	})
+
+	// Admins and the group's members; each handler checks what the user
+	// may do in the group
+	r.Get("/{id}", h.show)
+	r.Post("/{id}/members", h.addMember)
+	r.Post("/{id}/members/{userID}/remove", h.removeMember)
+
+	return r
+}
+
+// groupRow represents a group in the list.
+type groupRow struct {
+	ID          string
+	Name        string
+	Description string
+	Members     int
+	Role        string // The current user's role in the group; empty for admins who aren't in it
+}
+
+// ListVM is the view model for the groups list.
+type ListVM struct {
+	viewdata.BaseVM
+	Groups  []groupRow
+	IsAdmin bool
+	Success string
+}
+
+// list displays all groups to admins, and their own groups to other users.
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.CurrentUser(r)
+	ctx := r.Context()
+	isAdmin := user.Role == "admin"
+
+	memberships, err := h.members.ListByUser(ctx, user.UserID())
+	if err != nil {
+		h.errLog.Log(r, "failed to list group memberships", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	roles := make(map[primitive.ObjectID]string, len(memberships))
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, m := range memberships {
+		roles[m.GroupID] = m.Role
+		ids = append(ids, m.GroupID)
+	}
+
+	var groups []group.Group
+	if isAdmin {
+		groups, err = h.groups.List(ctx)
+	} else {
+		groups, err = h.groups.GetByIDs(ctx, ids)
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to list groups", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	groupIDs := make([]primitive.ObjectID, 0, len(groups))
+	for _, g := range groups {
+		groupIDs = append(groupIDs, g.ID)
+	}
+	counts, err := h.members.CountByGroup(ctx, groupIDs)
+	if err != nil {
+		h.errLog.Log(r, "failed to count group members", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]groupRow, 0, len(groups))
+	for _, g := range groups {
+		rows = append(rows, groupRow{
+			ID:          g.ID.Hex(),
+			Name:        g.Name,
+			Description: g.Description,
+			Members:     counts[g.ID],
+			Role:        roles[g.ID],
+		})
+	}
+
+	vm := ListVM{
+		BaseVM:  viewdata.New(r),
+		Groups:  rows,
+		IsAdmin: isAdmin,
+	}
+	vm.Title = "Groups"
+
+	switch r.URL.Query().Get("success") {
+	case "deleted":
+		vm.Success = "Group deleted"
+	}
+
+	templates.Render(w, r, "groups/list", vm)
+}
+
+// FormVM is the view model for the new and edit group forms.
+type FormVM struct {
+	viewdata.BaseVM
+	ID          string
+	Name        string
+	Description string
+	Error       string
+}
+
+// validateGroup trims and checks a group's name and description. It
+// returns a message for the user if they are not valid.
+func validateGroup(name, description string) (string, string, string) {
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	switch {
+	case name == "":
+		return name, description, "Name is required"
+	case len([]rune(name)) > maxNameLen:
+		return name, description, "Name is too long"
+	}
+	return name, description, ""
+}
+
+// showNew displays the new group form.
+func (h *Handler) showNew(w http.ResponseWriter, r *http.Request) {
+	vm := FormVM{BaseVM: viewdata.New(r)}
+	vm.Title = "New Group"
+	vm.BackURL = "/groups"
+	templates.Render(w, r, "groups/new", vm)
+}
+
+// create creates a new group and opens it so members can be added.
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	name, description, msg := validateGroup(r.FormValue("name"), r.FormValue("description"))
+	renderErr := func(msg string) {
+		vm := FormVM{
+			BaseVM:      viewdata.New(r),
+			Name:        name,
+			Description: description,
+			Error:       msg,
+		}
+		vm.Title = "New Group"
+		vm.BackURL = "/groups"
+		templates.Render(w, r, "groups/new", vm)
+	}
+	if msg != "" {
+		renderErr(msg)
+		return
+	}
+
+	g, err := h.groups.Create(r.Context(), group.CreateInput{
+		Name:        name,
+		Description: description,
+		CreatedByID: actor.UserID(),
+	})
+	if errors.Is(err, group.ErrDuplicateName) {
+		renderErr("A group with this name already exists")
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to create group", err)
+		renderErr("Failed to create group")
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "group_created", map[string]string{
+		"group_id": g.ID.Hex(),
+		"name":     g.Name,
+	})
+
+	http.Redirect(w, r, "/groups/"+g.ID.Hex()+"?success=created", http.StatusSeeOther)
+}
+
+// showEdit displays the edit group form.
+func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+
+	vm := FormVM{
+		BaseVM:      viewdata.New(r),
+		ID:          g.ID.Hex(),
+		Name:        g.Name,
+		Description: g.Description,
+	}
+	vm.Title = "Edit Group"
+	vm.BackURL = "/groups/" + g.ID.Hex()
+	templates.Render(w, r, "groups/edit", vm)
+}
+
+// update saves a group's name and description.
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+	g, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	name, description, msg := validateGroup(r.FormValue("name"), r.FormValue("description"))
+	renderErr := func(msg string) {
+		vm := FormVM{
+			BaseVM:      viewdata.New(r),
+			ID:          g.ID.Hex(),
+			Name:        name,
+			Description: description,
+			Error:       msg,
+		}
+		vm.Title = "Edit Group"
+		vm.BackURL = "/groups/" + g.ID.Hex()
+		templates.Render(w, r, "groups/edit", vm)
+	}
+	if msg != "" {
+		renderErr(msg)
+		return
+	}
+
+	err := h.groups.Update(r.Context(), g.ID, name, description)
+	if errors.Is(err, group.ErrDuplicateName) {
+		renderErr("A group with this name already exists")
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to update group", err)
+		renderErr("Failed to update group")
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "group_updated", map[string]string{
+		"group_id": g.ID.Hex(),
+		"name":     name,
+	})
+
+	http.Redirect(w, r, "/groups/"+g.ID.Hex()+"?success=updated", http.StatusSeeOther)
+}
+
+// delete deletes a group with its members and assignments.
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+	g, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	// Members and assignments go first so a failure leaves the group in
+	// place to delete again
+	if err := h.members.DeleteByGroup(ctx, g.ID); err != nil {
+		h.errLog.Log(r, "failed to delete group members", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.assignments.DeleteByGroup(ctx, g.ID); err != nil {
+		h.errLog.Log(r, "failed to delete group assignments", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.groups.Delete(ctx, g.ID); err != nil {
+		h.errLog.Log(r, "failed to delete group", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "group_deleted", map[string]string{
+		"group_id": g.ID.Hex(),
+		"name":     g.Name,
+	})
+
+	http.Redirect(w, r, "/groups?success=deleted", http.StatusSeeOther)
+}
+
+// loadGroup loads the group named by the id URL parameter, sending a 404
+// if there is none.
+func (h *Handler) loadGroup(w http.ResponseWriter, r *http.Request) (*group.Group, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	g, err := h.groups.GetByID(r.Context(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to load group", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return g, true
+}
+
+// access loads the group named by the id URL parameter along with the
+// current user's place in it: whether they are an admin, and their role
+// if they are a member. Users who are neither get a 404, so the group's
+// existence isn't revealed.
+func (h *Handler) access(w http.ResponseWriter, r *http.Request) (g *group.Group, isAdmin bool, role string, ok bool) {
+	g, ok = h.loadGroup(w, r)
+	if !ok {
+		return nil, false, "", false
+	}
+
+	user, _ := auth.CurrentUser(r)
+	isAdmin = user.Role == "admin"
+	m, err := h.members.Get(r.Context(), g.ID, user.UserID())
+	switch {
+	case err == nil:
+		role = m.Role
+	case !errors.Is(err, mongo.ErrNoDocuments):
+		h.errLog.Log(r, "failed to load group membership", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false, "", false
+	case !isAdmin:
+		http.NotFound(w, r)
+		return nil, false, "", false
+	}
+	return g, isAdmin, role, true
+}
+
+// canManageMembers reports whether a user can add and remove members.
+func canManageMembers(isAdmin bool, role string) bool {
+	return isAdmin || role == groupmember.RoleLeader
+}
+
+// canAddAs reports whether a user can add someone to the group with
+// newRole. Leaders can add plain members; only admins add leaders.
+func canAddAs(isAdmin bool, role, newRole string) bool {
+	if isAdmin {
+		return true
+	}
+	return role == groupmember.RoleLeader && newRole == groupmember.RoleMember
+}
+
+// canRemove reports whether a user can remove a member with targetRole.
+// Leaders can remove plain members; only admins remove leaders.
+func canRemove(isAdmin bool, role, targetRole string) bool {
+	if isAdmin {
+		return true
+	}
+	return role == groupmember.RoleLeader && targetRole == groupmember.RoleMember
+}
@@ -0,0 +1,294 @@
+package groups
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/assignment"
+	"github.com/dalemusser/stratasave/internal/app/store/group"
+	"github.com/dalemusser/stratasave/internal/app/store/groupmember"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	return NewHandler(db, nil, nil, nil, zap.NewNop())
+}
+
+// groupRequest returns a request by user with the given chi URL params.
+func groupRequest(method, target string, form url.Values, user *auth.SessionUser, params map[string]string) *http.Request {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	return auth.WithTestUser(req, user)
+}
+
+func sessionUser(id primitive.ObjectID, role string) *auth.SessionUser {
+	return &auth.SessionUser{ID: id.Hex(), Name: "Test User", LoginID: id.Hex(), Role: role}
+}
+
+func createUser(t *testing.T, h *Handler, name string) primitive.ObjectID {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	u, err := h.users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName:   name,
+		LoginID:    strings.ToLower(strings.ReplaceAll(name, " ", ".")),
+		AuthMethod: "trust",
+		Role:       "developer",
+	})
+	if err != nil {
+		t.Fatalf("CreateFromInput(%q) error = %v", name, err)
+	}
+	return u.ID
+}
+
+func createGroup(t *testing.T, h *Handler, name string) *group.Group {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	g, err := h.groups.Create(ctx, group.CreateInput{Name: name})
+	if err != nil {
+		t.Fatalf("Create(%q) error = %v", name, err)
+	}
+	return g
+}
+
+func TestRoutes(t *testing.T) {
+	h := newTestHandler(t)
+	sessionMgr, err := auth.NewSessionManager(
+		"test-session-key-for-testing-1234567890",
+		"test-session",
+		"",
+		24*time.Hour,
+		false,
+		zap.NewNop(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create session manager: %v", err)
+	}
+	if Routes(h, sessionMgr) == nil {
+		t.Fatal("Routes() returned nil")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	h := newTestHandler(t)
+	admin := sessionUser(primitive.NewObjectID(), "admin")
+
+	form := url.Values{"name": {"  Teachers  "}, "description": {"Staff"}}
+	rec := httptest.NewRecorder()
+	h.create(rec, groupRequest(http.MethodPost, "/groups/new", form, admin, nil))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	groups, err := h.groups.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(groups) != 1 || groups[0].Name != "Teachers" {
+		t.Fatalf("groups = %+v, want one named Teachers", groups)
+	}
+	if want := "/groups/" + groups[0].ID.Hex() + "?success=created"; rec.Header().Get("Location") != want {
+		t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+}
+
+func TestAddMember_LeaderCanOnlyAddMembers(t *testing.T) {
+	h := newTestHandler(t)
+	g := createGroup(t, h, "Class A")
+	leaderID := createUser(t, h, "Lee Leader")
+	userID := createUser(t, h, "Max Member")
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	if _, err := h.members.Add(ctx, groupmember.AddInput{GroupID: g.ID, UserID: leaderID, Role: groupmember.RoleLeader}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	leader := sessionUser(leaderID, "developer")
+	params := map[string]string{"id": g.ID.Hex()}
+
+	// Adding a leader is refused
+	form := url.Values{"user_id": {userID.Hex()}, "role": {groupmember.RoleLeader}}
+	rec := httptest.NewRecorder()
+	h.addMember(rec, groupRequest(http.MethodPost, "/groups/"+g.ID.Hex()+"/members", form, leader, params))
+	if loc := rec.Header().Get("Location"); !strings.HasSuffix(loc, "?error=not_allowed") {
+		t.Fatalf("Location = %q, want not_allowed", loc)
+	}
+
+	// Adding a member works
+	form.Set("role", groupmember.RoleMember)
+	rec = httptest.NewRecorder()
+	h.addMember(rec, groupRequest(http.MethodPost, "/groups/"+g.ID.Hex()+"/members", form, leader, params))
+	if loc := rec.Header().Get("Location"); !strings.HasSuffix(loc, "?success=member_added") {
+		t.Fatalf("Location = %q, want member_added", loc)
+	}
+	m, err := h.members.Get(ctx, g.ID, userID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if m.Role != groupmember.RoleMember || m.AddedByID != leaderID {
+		t.Errorf("member = %+v, want a member added by the leader", m)
+	}
+}
+
+func TestAddMember_PlainMemberForbidden(t *testing.T) {
+	h := newTestHandler(t)
+	g := createGroup(t, h, "Class B")
+	memberID := createUser(t, h, "Pat Plain")
+	otherID := createUser(t, h, "Oda Other")
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	if _, err := h.members.Add(ctx, groupmember.AddInput{GroupID: g.ID, UserID: memberID, Role: groupmember.RoleMember}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	form := url.Values{"user_id": {otherID.Hex()}}
+	rec := httptest.NewRecorder()
+	h.addMember(rec, groupRequest(http.MethodPost, "/groups/"+g.ID.Hex()+"/members", form,
+		sessionUser(memberID, "developer"), map[string]string{"id": g.ID.Hex()}))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestShow_NonMemberNotFound(t *testing.T) {
+	h := newTestHandler(t)
+	g := createGroup(t, h, "Private")
+
+	rec := httptest.NewRecorder()
+	h.show(rec, groupRequest(http.MethodGet, "/groups/"+g.ID.Hex(), nil,
+		sessionUser(primitive.NewObjectID(), "developer"), map[string]string{"id": g.ID.Hex()}))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestDelete_RemovesMembersAndAssignments(t *testing.T) {
+	h := newTestHandler(t)
+	g := createGroup(t, h, "Gone")
+	userID := createUser(t, h, "Una User")
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	if _, err := h.members.Add(ctx, groupmember.AddInput{GroupID: g.ID, UserID: userID, Role: groupmember.RoleMember}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := h.assignments.Create(ctx, assignment.CreateInput{GroupID: g.ID, ResourceType: assignment.TypeFile, ResourceID: primitive.NewObjectID()}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.delete(rec, groupRequest(http.MethodPost, "/groups/"+g.ID.Hex()+"/delete", url.Values{},
+		sessionUser(primitive.NewObjectID(), "admin"), map[string]string{"id": g.ID.Hex()}))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+
+	if members, _ := h.members.ListByGroup(ctx, g.ID); len(members) != 0 {
+		t.Errorf("got %d members, want none", len(members))
+	}
+	if list, _ := h.assignments.ListByGroup(ctx, g.ID); len(list) != 0 {
+		t.Errorf("got %d assignments, want none", len(list))
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	leader, member := groupmember.RoleLeader, groupmember.RoleMember
+
+	if !canAddAs(true, "", leader) || !canRemove(true, "", leader) {
+		t.Error("admins should manage leaders")
+	}
+	if !canAddAs(false, leader, member) || !canRemove(false, leader, member) {
+		t.Error("leaders should manage members")
+	}
+	if canAddAs(false, leader, leader) || canRemove(false, leader, leader) {
+		t.Error("leaders should not manage leaders")
+	}
+	if canManageMembers(false, member) || canAddAs(false, member, member) || canRemove(false, member, member) {
+		t.Error("members should not manage members")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	from, until, msg := parseWindow("", "")
+	if from != nil || until != nil || msg != "" {
+		t.Errorf("empty window = %v, %v, %q; want no bounds", from, until, msg)
+	}
+
+	from, until, msg = parseWindow("2025-03-01T09:00", "2025-03-08T17:30")
+	if msg != "" {
+		t.Fatalf("msg = %q, want none", msg)
+	}
+	if want := time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local); !from.Equal(want) {
+		t.Errorf("from = %v, want %v", from, want)
+	}
+	if want := time.Date(2025, 3, 8, 17, 30, 0, 0, time.Local); !until.Equal(want) {
+		t.Errorf("until = %v, want %v", until, want)
+	}
+
+	if _, _, msg := parseWindow("soon", ""); msg == "" {
+		t.Error("invalid from should be rejected")
+	}
+	if _, _, msg := parseWindow("2025-03-08T09:00", "2025-03-01T09:00"); msg == "" {
+		t.Error("until before from should be rejected")
+	}
+}
+
+func TestAssignmentState(t *testing.T) {
+	now := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		from, until *time.Time
+		want        string
+	}{
+		{"open", nil, nil, ""},
+		{"within window", &before, &after, ""},
+		{"not started", &after, nil, "scheduled"},
+		{"ended", nil, &before, "ended"},
+	}
+	for _, tt := range tests {
+		a := assignment.Assignment{VisibleFrom: tt.from, VisibleUntil: tt.until}
+		if got := assignmentState(&a, now); got != tt.want {
+			t.Errorf("%s: assignmentState() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateGroup(t *testing.T) {
+	if name, desc, msg := validateGroup("  Team  ", " About "); name != "Team" || desc != "About" || msg != "" {
+		t.Errorf("validateGroup() = %q, %q, %q", name, desc, msg)
+	}
+	if _, _, msg := validateGroup("   ", ""); msg == "" {
+		t.Error("blank name should be rejected")
+	}
+	if _, _, msg := validateGroup(strings.Repeat("x", maxNameLen+1), ""); msg == "" {
+		t.Error("long name should be rejected")
+	}
+}
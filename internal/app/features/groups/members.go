@@ -0,0 +1,225 @@
+// internal/app/features/groups/members.go
+package groups
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dalemusser/stratasave/internal/app/store/group"
+	"github.com/dalemusser/stratasave/internal/app/store/groupmember"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// addMember adds a user to a group, optionally emailing them about it.
+// Admins can add leaders and members; leaders can add members.
+func (h *Handler) addMember(w http.ResponseWriter, r *http.Request) {
+	g, isAdmin, role, ok := h.access(w, r)
+	if !ok {
+		return
+	}
+	if !canManageMembers(isAdmin, role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	groupURL := "/groups/" + g.ID.Hex()
+	newRole := r.FormValue("role")
+	if newRole == "" {
+		newRole = groupmember.RoleMember
+	}
+	if !groupmember.IsValidRole(newRole) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if !canAddAs(isAdmin, role, newRole) {
+		http.Redirect(w, r, groupURL+"?error=not_allowed", http.StatusSeeOther)
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(r.FormValue("user_id"))
+	if err != nil {
+		http.Redirect(w, r, groupURL+"?error=user_not_found", http.StatusSeeOther)
+		return
+	}
+	user, err := h.users.GetByID(r.Context(), userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.Redirect(w, r, groupURL+"?error=user_not_found", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to load user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	_, err = h.members.Add(r.Context(), groupmember.AddInput{
+		GroupID:   g.ID,
+		UserID:    user.ID,
+		Role:      newRole,
+		AddedByID: actorID,
+	})
+	if errors.Is(err, groupmember.ErrAlreadyMember) {
+		http.Redirect(w, r, groupURL+"?error=already_member", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to add group member", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.auditLogger.LogAdminEvent(r, &actorID, &user.ID, "group_member_added", map[string]string{
+		"group_id": g.ID.Hex(),
+		"group":    g.Name,
+		"role":     newRole,
+	})
+
+	if r.FormValue("notify") == "on" && h.mailer != nil && user.Email != nil && *user.Email != "" {
+		brand := h.mailer.Brand(r.Context())
+		go h.notifyMember(brand, viewdata.New(r).SiteName, *g, *user, newRole)
+	}
+
+	http.Redirect(w, r, groupURL+"?success=member_added", http.StatusSeeOther)
+}
+
+// notifyMember emails a user that they were added to a group. It is run in
+// the background once the member is added.
+func (h *Handler) notifyMember(brand mailer.Brand, siteName string, g group.Group, user models.User, role string) {
+	text, html := mailer.GroupMembershipEmail(mailer.GroupMembershipEmailData{
+		Locale:    user.Locale,
+		Brand:     brand,
+		AppName:   siteName,
+		UserName:  user.FullName,
+		GroupName: g.Name,
+		Role:      role,
+		GroupURL:  h.baseURL + "/groups/" + g.ID.Hex(),
+	})
+	err := h.mailer.Send(mailer.Email{
+		To:       *user.Email,
+		Subject:  mailer.T(user.Locale, "group_membership.title"),
+		Template: "group_membership",
+		UserID:   user.ID.Hex(),
+		TextBody: text,
+		HTMLBody: html,
+	})
+	if err != nil {
+		h.logger.Warn("failed to send group membership email",
+			zap.String("group_id", g.ID.Hex()),
+			zap.String("user_id", user.ID.Hex()),
+			zap.Error(err))
+	}
+}
+
+// removeMember removes a user from a group. Admins can remove anyone;
+// leaders can remove members.
+func (h *Handler) removeMember(w http.ResponseWriter, r *http.Request) {
+	g, isAdmin, role, ok := h.access(w, r)
+	if !ok {
+		return
+	}
+	if !canManageMembers(isAdmin, role) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	groupURL := "/groups/" + g.ID.Hex()
+	target, ok := h.loadMember(w, r, g.ID)
+	if !ok {
+		return
+	}
+	if !canRemove(isAdmin, role, target.Role) {
+		http.Redirect(w, r, groupURL+"?error=not_allowed", http.StatusSeeOther)
+		return
+	}
+
+	if _, err := h.members.Remove(r.Context(), g.ID, target.UserID); err != nil {
+		h.errLog.Log(r, "failed to remove group member", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &target.UserID, "group_member_removed", map[string]string{
+		"group_id": g.ID.Hex(),
+		"group":    g.Name,
+		"role":     target.Role,
+	})
+
+	http.Redirect(w, r, groupURL+"?success=member_removed", http.StatusSeeOther)
+}
+
+// setMemberRole makes a member a leader or a plain member.
+func (h *Handler) setMemberRole(w http.ResponseWriter, r *http.Request) {
+	g, ok := h.loadGroup(w, r)
+	if !ok {
+		return
+	}
+	target, ok := h.loadMember(w, r, g.ID)
+	if !ok {
+		return
+	}
+
+	newRole := r.FormValue("role")
+	if !groupmember.IsValidRole(newRole) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	groupURL := "/groups/" + g.ID.Hex()
+	if newRole == target.Role {
+		http.Redirect(w, r, groupURL, http.StatusSeeOther)
+		return
+	}
+	if err := h.members.SetRole(r.Context(), g.ID, target.UserID, newRole); err != nil {
+		h.errLog.Log(r, "failed to change group member role", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &target.UserID, "group_member_role_changed", map[string]string{
+		"group_id": g.ID.Hex(),
+		"group":    g.Name,
+		"from":     target.Role,
+		"to":       newRole,
+	})
+
+	http.Redirect(w, r, groupURL+"?success=role_changed", http.StatusSeeOther)
+}
+
+// loadMember loads the membership of the user named by the userID URL
+// parameter, sending a 404 if they aren't in the group.
+func (h *Handler) loadMember(w http.ResponseWriter, r *http.Request, groupID primitive.ObjectID) (*groupmember.Member, bool) {
+	userID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "userID"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	m, err := h.members.Get(r.Context(), groupID, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to load group membership", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return m, true
+}
@@ -0,0 +1,238 @@
+// internal/app/features/groups/show.go
+package groups
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/assignment"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// memberRow represents a member on the group page.
+type memberRow struct {
+	UserID    string
+	Name      string
+	LoginID   string
+	Role      string
+	AddedAt   string
+	CanRemove bool
+}
+
+// userOption is a user that can be added to the group.
+type userOption struct {
+	ID    string
+	Label string
+}
+
+// assignmentRow represents an assigned resource on the group page.
+type assignmentRow struct {
+	ID           string
+	Type         string
+	Name         string
+	URL          string // Empty if the resource is no longer in the library
+	Instructions string
+	VisibleFrom  string
+	VisibleUntil string
+	State        string // "", "scheduled", "ended", or "missing"
+}
+
+// ShowVM is the view model for the group page.
+type ShowVM struct {
+	viewdata.BaseVM
+	ID          string
+	Name        string
+	Description string
+	IsAdmin     bool
+	CanManage   bool
+	Members     []memberRow
+	Candidates  []userOption
+	Assignments []assignmentRow
+	Success     string
+	Error       string
+}
+
+// show displays a group's members and assigned resources. Members see the
+// resources that are currently visible; admins and leaders also see the
+// ones that are scheduled, ended, or gone from the library.
+func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
+	g, isAdmin, role, ok := h.access(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	canManage := canManageMembers(isAdmin, role)
+
+	members, err := h.members.ListByGroup(ctx, g.ID)
+	if err != nil {
+		h.errLog.Log(r, "failed to list group members", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(members))
+	inGroup := make(map[primitive.ObjectID]bool, len(members))
+	for _, m := range members {
+		userIDs = append(userIDs, m.UserID)
+		inGroup[m.UserID] = true
+	}
+	users, err := h.users.GetByIDs(ctx, userIDs)
+	if err != nil {
+		h.errLog.Log(r, "failed to load group members", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	names := make(map[primitive.ObjectID][2]string, len(users))
+	for _, u := range users {
+		if u.Status == status.Deleted {
+			continue // shown as a deleted user until restored
+		}
+		loginID := ""
+		if u.LoginID != nil {
+			loginID = *u.LoginID
+		}
+		names[u.ID] = [2]string{u.FullName, loginID}
+	}
+
+	memberRows := make([]memberRow, 0, len(members))
+	for _, m := range members {
+		n, found := names[m.UserID]
+		if !found {
+			n[0] = "(deleted user)"
+		}
+		memberRows = append(memberRows, memberRow{
+			UserID:    m.UserID.Hex(),
+			Name:      n[0],
+			LoginID:   n[1],
+			Role:      m.Role,
+			AddedAt:   m.CreatedAt.Format("Jan 2, 2006"),
+			CanRemove: canRemove(isAdmin, role, m.Role),
+		})
+	}
+
+	var candidates []userOption
+	if canManage {
+		active, err := h.users.Find(ctx, bson.M{"status": "active"},
+			options.Find().SetSort(bson.D{{Key: "full_name_ci", Value: 1}}))
+		if err != nil {
+			h.errLog.Log(r, "failed to list users", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		for _, u := range active {
+			if inGroup[u.ID] {
+				continue
+			}
+			label := u.FullName
+			if u.LoginID != nil && *u.LoginID != "" {
+				label += " (" + *u.LoginID + ")"
+			}
+			candidates = append(candidates, userOption{ID: u.ID.Hex(), Label: label})
+		}
+	}
+
+	assignments, err := h.assignments.ListByGroup(ctx, g.ID)
+	if err != nil {
+		h.errLog.Log(r, "failed to list group assignments", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	assignmentRows := make([]assignmentRow, 0, len(assignments))
+	for _, a := range assignments {
+		row := h.assignmentRow(r, a, now)
+		if row.State != "" && !canManage {
+			continue
+		}
+		assignmentRows = append(assignmentRows, row)
+	}
+
+	vm := ShowVM{
+		BaseVM:      viewdata.New(r),
+		ID:          g.ID.Hex(),
+		Name:        g.Name,
+		Description: g.Description,
+		IsAdmin:     isAdmin,
+		CanManage:   canManage,
+		Members:     memberRows,
+		Candidates:  candidates,
+		Assignments: assignmentRows,
+	}
+	vm.Title = g.Name
+	vm.BackURL = "/groups"
+
+	switch r.URL.Query().Get("success") {
+	case "created":
+		vm.Success = "Group created. Add members below."
+	case "updated":
+		vm.Success = "Group updated"
+	case "member_added":
+		vm.Success = "Member added"
+	case "member_removed":
+		vm.Success = "Member removed"
+	case "role_changed":
+		vm.Success = "Member role changed"
+	case "assigned":
+		vm.Success = "Resource assigned"
+	case "unassigned":
+		vm.Success = "Assignment removed"
+	}
+	switch r.URL.Query().Get("error") {
+	case "already_member":
+		vm.Error = "That user is already in this group"
+	case "user_not_found":
+		vm.Error = "That user was not found"
+	case "not_allowed":
+		vm.Error = "Only admins can add or remove group leaders"
+	}
+
+	templates.Render(w, r, "groups/show", vm)
+}
+
+// assignmentRow returns the row for an assignment, looking up the
+// resource it points to.
+func (h *Handler) assignmentRow(r *http.Request, a assignment.Assignment, now time.Time) assignmentRow {
+	row := assignmentRow{
+		ID:           a.ID.Hex(),
+		Type:         a.ResourceType,
+		Instructions: a.Instructions,
+		VisibleFrom:  formatTime(a.VisibleFrom),
+		VisibleUntil: formatTime(a.VisibleUntil),
+		State:        assignmentState(&a, now),
+	}
+
+	name, path, err := h.resource(r, a.ResourceType, a.ResourceID)
+	if err != nil {
+		row.Name = "(no longer in the library)"
+		row.State = "missing"
+		return row
+	}
+	row.Name = name
+	row.URL = path
+	return row
+}
+
+// assignmentState describes when members can see an assignment: "" while
+// it is visible, "scheduled" before it starts, and "ended" after.
+func assignmentState(a *assignment.Assignment, now time.Time) string {
+	switch {
+	case a.Visible(now):
+		return ""
+	case a.VisibleFrom != nil && now.Before(*a.VisibleFrom):
+		return "scheduled"
+	default:
+		return "ended"
+	}
+}
+
+// formatTime formats an optional time for display.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format("Jan 2, 2006 3:04 PM")
+}
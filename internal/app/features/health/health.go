@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/healthcheck"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -16,6 +17,7 @@ import (
 // Handler provides health check endpoints.
 type Handler struct {
 	mongoClient *mongo.Client
+	checker     *healthcheck.Checker // nil if only MongoDB is checked
 	logger      *zap.Logger
 }
 
@@ -27,10 +29,71 @@ func NewHandler(mongoClient *mongo.Client, logger *zap.Logger) *Handler {
 	}
 }
 
+// SetChecker adds the components c probes, such as file storage and
+// SMTP, to the health and readiness checks. A failing critical component
+// makes the service not ready; any other failing component only marks it
+// degraded.
+func (h *Handler) SetChecker(c *healthcheck.Checker) {
+	h.checker = c
+}
+
 // Response represents the health check response.
 type Response struct {
-	Status   string            `json:"status"`
-	Services map[string]string `json:"services,omitempty"`
+	Status     string            `json:"status"`
+	Services   map[string]string `json:"services,omitempty"`
+	Components []Component       `json:"components,omitempty"`
+}
+
+// Component is the last probe result of a component, as reported by the
+// health and readiness checks.
+type Component struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status"` // "ok" or "failing"
+	Critical  bool       `json:"critical"`
+	Error     string     `json:"error,omitempty"`
+	LatencyMS int64      `json:"latency_ms"`
+	CheckedAt time.Time  `json:"checked_at"`
+	LastOK    *time.Time `json:"last_ok,omitempty"`
+	Failures  int        `json:"failures,omitempty"` // Probes failed in a row
+}
+
+// components converts probe results for a response.
+func components(statuses []healthcheck.Status) []Component {
+	out := make([]Component, 0, len(statuses))
+	for _, s := range statuses {
+		c := Component{
+			Name:      s.Name,
+			Status:    "ok",
+			Critical:  s.Critical,
+			Error:     s.Error,
+			LatencyMS: s.Latency.Milliseconds(),
+			CheckedAt: s.CheckedAt,
+			Failures:  s.Failures,
+		}
+		if !s.OK {
+			c.Status = "failing"
+		}
+		if !s.LastOK.IsZero() {
+			lastOK := s.LastOK
+			c.LastOK = &lastOK
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// readiness decides the readiness status from the MongoDB ping and the
+// component probes.
+func readiness(mongoErr error, statuses []healthcheck.Status) (string, int) {
+	if mongoErr != nil || !healthcheck.Ready(statuses) {
+		return "not ready", http.StatusServiceUnavailable
+	}
+	for _, s := range statuses {
+		if !s.OK {
+			return "degraded", http.StatusOK
+		}
+	}
+	return "ready", http.StatusOK
 }
 
 // Routes returns a chi.Router with health check routes mounted.
@@ -72,6 +135,21 @@ func (h *Handler) Check(w http.ResponseWriter, r *http.Request) {
 		resp.Services["mongodb"] = "ok"
 	}
 
+	if h.checker != nil {
+		statuses := h.checker.Check(r.Context())
+		for _, s := range statuses {
+			if s.OK {
+				resp.Services[s.Name] = "ok"
+				continue
+			}
+			resp.Services[s.Name] = "unavailable"
+			if s.Critical {
+				resp.Status = "degraded"
+			}
+		}
+		resp.Components = components(statuses)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if resp.Status != "ok" {
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -82,6 +160,11 @@ func (h *Handler) Check(w http.ResponseWriter, r *http.Request) {
 // Ready checks if the service is ready to accept requests.
 // Used by Kubernetes readiness probes.
 func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.checker != nil {
+		h.readyWithComponents(w, r)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
@@ -96,6 +179,35 @@ func (h *Handler) Ready(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"ready"}`))
 }
 
+// readyWithComponents is Ready when components are probed too: it
+// reports each component so a failing node can be diagnosed from the
+// probe's response.
+func (h *Handler) readyWithComponents(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	mongoErr := h.mongoClient.Ping(ctx, readpref.Primary())
+	if mongoErr != nil {
+		h.logger.Warn("readiness check failed", zap.Error(mongoErr))
+	}
+	statuses := h.checker.Check(r.Context())
+
+	mongoStatus := "ok"
+	if mongoErr != nil {
+		mongoStatus = "unavailable"
+	}
+	status, code := readiness(mongoErr, statuses)
+	resp := Response{
+		Status:     status,
+		Services:   map[string]string{"mongodb": mongoStatus},
+		Components: components(statuses),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // Live checks if the service is alive.
 // Used by Kubernetes liveness probes.
 func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
@@ -2,10 +2,13 @@ package health
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/healthcheck"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
@@ -164,3 +167,39 @@ func TestResponse_JSON(t *testing.T) {
 		t.Errorf("cache status = %q, want %q", decoded.Services["cache"], "degraded")
 	}
 }
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		mongoErr   error
+		statuses   []healthcheck.Status
+		wantStatus string
+		wantCode   int
+	}{
+		{"all ok", nil, []healthcheck.Status{{Name: "storage", OK: true}}, "ready", http.StatusOK},
+		{"mongodb down", errors.New("down"), nil, "not ready", http.StatusServiceUnavailable},
+		{"required component failing", nil, []healthcheck.Status{{Name: "storage", Critical: true}}, "not ready", http.StatusServiceUnavailable},
+		{"optional component failing", nil, []healthcheck.Status{{Name: "smtp"}}, "degraded", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := readiness(tt.mongoErr, tt.statuses)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("readiness = %q, %d; want %q, %d", status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestComponents(t *testing.T) {
+	got := components([]healthcheck.Status{
+		{Name: "storage", OK: true, LastOK: time.Now()},
+		{Name: "smtp", Error: "connection refused", Failures: 3},
+	})
+	if got[0].Status != "ok" || got[0].LastOK == nil {
+		t.Errorf("storage = %+v", got[0])
+	}
+	if got[1].Status != "failing" || got[1].LastOK != nil || got[1].Failures != 3 {
+		t.Errorf("smtp = %+v", got[1])
+	}
+}
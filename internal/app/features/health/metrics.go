@@ -0,0 +1,34 @@
+// internal/app/features/health/metrics.go
+package health
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/dalemusser/waffle/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+// MountMetrics adds the Prometheus /metrics endpoint to the root router,
+// protected by a bearer token. An empty token leaves it unmounted.
+func MountMetrics(r chi.Router, token string) {
+	if token == "" {
+		return
+	}
+	r.Handle("/metrics", RequireToken(token, metrics.Handler()))
+}
+
+// RequireToken only passes requests carrying "Authorization: Bearer
+// <token>" on to next.
+func RequireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
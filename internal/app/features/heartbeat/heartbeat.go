@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/store/activity"
@@ -22,7 +23,9 @@ type Handler struct {
 	SessionMgr *auth.SessionManager
 	Log        *zap.Logger
 
-	// Idle logout configuration
+	// Idle logout configuration. Use SetIdleLogoutConfig to change it once
+	// the handler is serving requests.
+	mu                sync.RWMutex
 	IdleLogoutEnabled bool
 	IdleLogoutTimeout time.Duration
 	IdleLogoutWarning time.Duration
@@ -38,8 +41,11 @@ func NewHandler(sessStore *sessions.Store, activityStore *activity.Store, sessio
 	}
 }
 
-// SetIdleLogoutConfig configures idle logout settings.
+// SetIdleLogoutConfig configures idle logout settings. It is safe to call
+// while requests are being served, as on config reload.
 func (h *Handler) SetIdleLogoutConfig(enabled bool, timeout, warning time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	h.IdleLogoutEnabled = enabled
 	h.IdleLogoutTimeout = timeout
 	h.IdleLogoutWarning = warning
@@ -50,6 +56,8 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r := chi.NewRouter()
 	r.Use(sessionMgr.RequireAuth)
 	r.Post("/", h.ServeHeartbeat)
+	r.Get("/idle", h.ServeIdleStatus)
+	r.Post("/extend", h.ServeExtend)
 	return r
 }
 
@@ -59,10 +67,59 @@ type heartbeatRequest struct {
 	HadUserActivity bool   `json:"had_user_activity"` // True if user interacted since last heartbeat
 }
 
-// heartbeatResponse is returned when idle warning or logout is needed.
+// heartbeatResponse reports the idle logout state of the session. It is
+// empty when idle logout is disabled.
 type heartbeatResponse struct {
-	IdleWarning      bool `json:"idle_warning,omitempty"`
-	SecondsRemaining int  `json:"seconds_remaining,omitempty"`
+	IdleLogout       bool `json:"idle_logout,omitempty"`       // Idle logout is enabled
+	IdleWarning      bool `json:"idle_warning,omitempty"`      // Within the warning window
+	SecondsRemaining int  `json:"seconds_remaining,omitempty"` // Until idle logout
+	TimeoutSeconds   int  `json:"timeout_seconds,omitempty"`   // Configured idle timeout
+	WarningSeconds   int  `json:"warning_seconds,omitempty"`   // Configured warning window
+}
+
+// idleState computes the idle logout state for a session whose user last
+// interacted at lastUserActivity. expired is true once the timeout has passed.
+func (h *Handler) idleState(lastUserActivity, now time.Time) (resp heartbeatResponse, expired bool) {
+	enabled, timeout, warning := h.idleLogoutConfig()
+	if !enabled {
+		return heartbeatResponse{}, false
+	}
+
+	idleTime := now.Sub(lastUserActivity)
+	if idleTime > timeout {
+		return heartbeatResponse{}, true
+	}
+
+	remaining := timeout - idleTime
+	return heartbeatResponse{
+		IdleLogout:       true,
+		IdleWarning:      remaining < warning,
+		SecondsRemaining: int(remaining.Seconds()),
+		TimeoutSeconds:   int(timeout.Seconds()),
+		WarningSeconds:   int(warning.Seconds()),
+	}, false
+}
+
+// idleLogoutConfig returns the current idle logout settings.
+func (h *Handler) idleLogoutConfig() (enabled bool, timeout, warning time.Duration) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.IdleLogoutEnabled, h.IdleLogoutTimeout, h.IdleLogoutWarning
+}
+
+// lastUserActivity returns when the session's user last interacted, falling
+// back to last_activity for legacy sessions.
+func lastUserActivity(sess *sessions.Session) time.Time {
+	if sess.LastUserActivity.IsZero() {
+		return sess.LastActivity
+	}
+	return sess.LastUserActivity
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
 }
 
 // ServeHeartbeat handles POST /api/heartbeat.
@@ -133,40 +190,33 @@ func (h *Handler) ServeHeartbeat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check idle timeout if enabled
-	if h.IdleLogoutEnabled && result.Updated {
+	if enabled, _, _ := h.idleLogoutConfig(); enabled && result.Updated {
 		// Use the session we already have or refresh it
 		sess := dbSession
 		if sess == nil {
 			sess, _ = h.Sessions.GetByToken(ctx, sessionToken)
 		}
-		if sess != nil {
-			// Use last_user_activity for idle check, fall back to last_activity for legacy sessions
-			lastUserActivity := sess.LastUserActivity
-			if lastUserActivity.IsZero() {
-				lastUserActivity = sess.LastActivity
-			}
 
-			idleTime := time.Since(lastUserActivity)
+		if sess != nil {
+			resp, expired := h.idleState(lastUserActivity(sess), time.Now())
 
 			// If past timeout, force logout
-			if idleTime > h.IdleLogoutTimeout {
+			if expired {
 				h.Log.Info("idle timeout exceeded, forcing logout",
 					zap.String("user_id", user.ID),
-					zap.Duration("idle_time", idleTime))
+					zap.Duration("idle_time", time.Since(lastUserActivity(sess))))
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
 
-			// If within warning window, send warning
-			if idleTime > h.IdleLogoutTimeout-h.IdleLogoutWarning {
-				remaining := h.IdleLogoutTimeout - idleTime
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(heartbeatResponse{
-					IdleWarning:      true,
-					SecondsRemaining: int(remaining.Seconds()),
-				})
-				return
+			// Interaction reported with this heartbeat restarts the idle clock
+			if req.HadUserActivity {
+				resp, _ = h.idleState(time.Now(), time.Now())
 			}
+
+			// Report time remaining so the client can warn before logout
+			writeJSON(w, resp)
+			return
 		}
 	}
 
@@ -200,7 +250,7 @@ func (h *Handler) ServeHeartbeat(w http.ResponseWriter, r *http.Request) {
 			LoginAt:      now,
 			LastActivity: now,
 			CurrentPage:  req.Page,
-			ExpiresAt:    now.Add(24 * 30 * time.Hour), // 30 days
+			ExpiresAt:    now.Add(h.SessionMgr.Lifetime(user.Role).Absolute),
 		}
 		if err := h.Sessions.Create(ctx, newSess); err != nil {
 			h.Log.Warn("failed to create new activity session after timeout",
@@ -236,6 +286,72 @@ func (h *Handler) ServeHeartbeat(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// ServeIdleStatus handles GET /api/heartbeat/idle.
+// Reports the time remaining before idle logout without counting as
+// activity, so a client countdown can resync with the server.
+// Returns 401 if the session has ended or the idle timeout has passed.
+func (h *Handler) ServeIdleStatus(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.currentSession(w, r)
+	if !ok {
+		return
+	}
+
+	resp, expired := h.idleState(lastUserActivity(sess), time.Now())
+	if expired {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// ServeExtend handles POST /api/heartbeat/extend.
+// Records user activity on the current session, restarting the idle clock,
+// and returns the new idle logout state. A session already past the idle
+// timeout can't be extended and gets 401.
+func (h *Handler) ServeExtend(w http.ResponseWriter, r *http.Request) {
+	sess, ok := h.currentSession(w, r)
+	if !ok {
+		return
+	}
+
+	if _, expired := h.idleState(lastUserActivity(sess), time.Now()); expired {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Sessions.UpdateUserActivity(ctx, sess.Token); err != nil {
+		h.Log.Warn("failed to extend session", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	resp, _ := h.idleState(time.Now(), time.Now())
+	writeJSON(w, resp)
+}
+
+// currentSession loads the tracked session for the request. It writes 401
+// and returns false if there is no user or the session has ended.
+func (h *Handler) currentSession(w http.ResponseWriter, r *http.Request) (*sessions.Session, bool) {
+	user, ok := auth.CurrentUser(r)
+	if !ok || user.SessionToken() == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sess, err := h.Sessions.GetByToken(ctx, user.SessionToken())
+	if err != nil || sess == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return nil, false
+	}
+	return sess, true
+}
+
 // clientIP extracts the client IP from the request.
 func clientIP(r *http.Request) string {
 	// Check X-Forwarded-For header first (for reverse proxies)
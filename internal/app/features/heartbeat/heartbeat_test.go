@@ -239,3 +239,73 @@ func TestClientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestIdleState(t *testing.T) {
+	now := time.Now()
+	h := &Handler{}
+	h.SetIdleLogoutConfig(true, 30*time.Minute, 5*time.Minute)
+
+	tests := []struct {
+		name        string
+		idle        time.Duration
+		wantWarning bool
+		wantSeconds int
+		wantExpired bool
+	}{
+		{"just active", 0, false, 1800, false},
+		{"before warning", 20 * time.Minute, false, 600, false},
+		{"in warning", 27 * time.Minute, true, 180, false},
+		{"expired", 31 * time.Minute, false, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, expired := h.idleState(now.Add(-tt.idle), now)
+			if expired != tt.wantExpired {
+				t.Fatalf("expired = %v, want %v", expired, tt.wantExpired)
+			}
+			if expired {
+				return
+			}
+			if !resp.IdleLogout {
+				t.Error("IdleLogout should be true")
+			}
+			if resp.IdleWarning != tt.wantWarning {
+				t.Errorf("IdleWarning = %v, want %v", resp.IdleWarning, tt.wantWarning)
+			}
+			if resp.SecondsRemaining != tt.wantSeconds {
+				t.Errorf("SecondsRemaining = %d, want %d", resp.SecondsRemaining, tt.wantSeconds)
+			}
+			if resp.TimeoutSeconds != 1800 || resp.WarningSeconds != 300 {
+				t.Errorf("TimeoutSeconds, WarningSeconds = %d, %d, want 1800, 300", resp.TimeoutSeconds, resp.WarningSeconds)
+			}
+		})
+	}
+}
+
+func TestIdleState_Disabled(t *testing.T) {
+	h := &Handler{}
+
+	resp, expired := h.idleState(time.Now().Add(-24*time.Hour), time.Now())
+	if expired {
+		t.Error("expired should be false when idle logout is disabled")
+	}
+	if resp != (heartbeatResponse{}) {
+		t.Errorf("resp = %+v, want empty", resp)
+	}
+}
+
+func TestIdleStatus_Unauthenticated(t *testing.T) {
+	h := &Handler{}
+
+	for _, serve := range []http.HandlerFunc{h.ServeIdleStatus, h.ServeExtend} {
+		req := httptest.NewRequest(http.MethodPost, "/heartbeat/extend", nil)
+		rec := httptest.NewRecorder()
+
+		serve(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// internal/app/features/impersonation/handler.go
+package impersonationfeature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Handler starts and stops admin impersonation of other users.
+type Handler struct {
+	Users       *userstore.Store
+	SessionMgr  *auth.SessionManager
+	Timeout     time.Duration
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+}
+
+// NewHandler creates a new impersonation handler. Impersonations end on their
+// own after timeout.
+func NewHandler(db *mongo.Database, sessionMgr *auth.SessionManager, timeout time.Duration, errLog *errorsfeature.ErrorLogger, auditLogger *auditlog.Logger, logger *zap.Logger) *Handler {
+	return &Handler{
+		Users:       userstore.New(db),
+		SessionMgr:  sessionMgr,
+		Timeout:     timeout,
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// CanImpersonate reports whether an admin may act as the given user. Other
+// admins and disabled accounts can't be impersonated.
+func CanImpersonate(admin *auth.SessionUser, user *models.User) bool {
+	return user.ID != admin.UserID() &&
+		normalize.Role(user.Role) != models.RoleAdmin &&
+		normalize.Status(user.Status) != "disabled"
+}
+
+// HandleStart handles POST /impersonate/{id} - switch the admin's session to
+// act as the user.
+func (h *Handler) HandleStart(w http.ResponseWriter, r *http.Request) {
+	admin, _ := auth.CurrentUser(r)
+
+	targetID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	user, err := h.Users.GetByID(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.NotFound(w, r)
+			return
+		}
+		h.ErrLog.Log(r, "failed to load user to impersonate", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !CanImpersonate(admin, user) {
+		http.Error(w, "This user can't be impersonated.", http.StatusForbidden)
+		return
+	}
+
+	if err := h.SessionMgr.StartImpersonation(w, r, admin, user.ID, normalize.Role(user.Role), h.Timeout); err != nil {
+		h.ErrLog.Log(r, "failed to start impersonation", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.AuditLogger.ImpersonationStarted(r.Context(), r, admin.UserID(), user.ID, admin.Role, h.Timeout.String())
+	h.Log.Info("impersonation started",
+		zap.String("admin_id", admin.ID),
+		zap.String("user_id", user.ID.Hex()))
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// HandleStop handles POST /impersonate/stop - return to the admin's own
+// account.
+func (h *Handler) HandleStop(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.CurrentUser(r)
+	if !user.IsImpersonated() {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	adminID, err := h.SessionMgr.StopImpersonation(w, r)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to stop impersonation", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if adminOID, err := primitive.ObjectIDFromHex(adminID); err == nil {
+		h.AuditLogger.ImpersonationEnded(r.Context(), r, adminOID, user.UserID(), "stopped")
+	}
+
+	http.Redirect(w, r, "/system-users/"+user.ID, http.StatusSeeOther)
+}
+
+// Expired records an impersonation that timed out. Set it as the session
+// manager's impersonation-expired hook.
+func (h *Handler) Expired(r *http.Request, adminID, userID string) {
+	adminOID, err := primitive.ObjectIDFromHex(adminID)
+	if err != nil {
+		return
+	}
+	userOID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+	h.AuditLogger.ImpersonationEnded(r.Context(), r, adminOID, userOID, "expired")
+}
@@ -0,0 +1,20 @@
+// internal/app/features/impersonation/routes.go
+package impersonationfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for starting and stopping impersonation.
+// Only admins can start one; stopping is open to any signed-in session,
+// since while impersonating the session has the impersonated user's role.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireSignedIn)
+
+	r.Post("/stop", h.HandleStop)
+	r.With(sm.RequireRole("admin"), sm.RequireRecentAuth).Post("/{id}", h.HandleStart)
+
+	return r
+}
@@ -0,0 +1,316 @@
+package invitations
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxBulkInvitations is the most addresses one bulk invitation accepts.
+	maxBulkInvitations = 500
+
+	// maxBulkCSVSize is the largest CSV file a bulk invitation accepts.
+	maxBulkCSVSize = 1 << 20
+)
+
+// Outcomes of inviting an address in bulk.
+const (
+	bulkInvited   = "invited"
+	bulkInvalid   = "invalid"
+	bulkDomain    = "domain"
+	bulkDuplicate = "duplicate"
+	bulkExists    = "exists"
+	bulkPending   = "pending"
+	bulkFailed    = "failed"
+)
+
+// bulkResult is the outcome for one address in a bulk invitation.
+type bulkResult struct {
+	Email   string
+	Status  string
+	Message string
+}
+
+// BulkVM is the view model for the bulk invitation form and its results.
+type BulkVM struct {
+	viewdata.BaseVM
+	Emails         string
+	Role           string
+	AvailableRoles []string
+	ExpiryDays     int
+	ExpiryChoices  []int
+	Message        string
+	MaxMessage     int
+	Error          string
+	Results        []bulkResult
+	Invited        int
+	Skipped        int
+	Emailing       bool   // invitation emails are being sent
+	EmailJobID     string // job sending them, for following its progress
+	MaxAddresses   int
+}
+
+// showBulk displays the bulk invitation form.
+func (h *Handler) showBulk(w http.ResponseWriter, r *http.Request) {
+	h.renderBulk(w, r, BulkVM{Role: "admin", ExpiryDays: h.expiryDays})
+}
+
+// renderBulk renders the bulk invitation page.
+func (h *Handler) renderBulk(w http.ResponseWriter, r *http.Request, vm BulkVM) {
+	vm.BaseVM = viewdata.New(r)
+	vm.AvailableRoles = models.AllRoles()
+	vm.ExpiryChoices = h.expiryChoices()
+	vm.MaxMessage = maxMessageLength
+	vm.MaxAddresses = maxBulkInvitations
+	vm.Title = "Bulk Invitations"
+	vm.BackURL = "/invitations"
+	templates.Render(w, r, "invitations/bulk", vm)
+}
+
+// createBulk invites every address pasted into the form or listed in the
+// uploaded CSV with the same role. Each address is checked on its own, and
+// the page lists what happened to each one. The emails are sent by a job on
+// the bulk email queue, at the mailer's rate.
+func (h *Handler) createBulk(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkCSVSize+64<<10)
+	if err := r.ParseMultipartForm(maxBulkCSVSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		h.renderBulk(w, r, BulkVM{Role: "admin", ExpiryDays: h.expiryDays, Error: "The upload is too large. CSV files can be up to 1 MB."})
+		return
+	}
+
+	vm := BulkVM{
+		Emails: r.FormValue("emails"),
+		Role:   r.FormValue("role"),
+	}
+	if !models.IsValidRole(vm.Role) {
+		vm.Role = "admin"
+	}
+	var errMsg string
+	vm.ExpiryDays, vm.Message, errMsg = h.parseInviteOptions(r)
+	if errMsg != "" {
+		vm.Error = errMsg
+		h.renderBulk(w, r, vm)
+		return
+	}
+
+	addresses := splitAddresses(vm.Emails)
+	if file, _, err := r.FormFile("csv"); err == nil {
+		fromCSV, err := readCSVAddresses(file)
+		file.Close()
+		if err != nil {
+			vm.Error = "The CSV file could not be read: " + err.Error()
+			h.renderBulk(w, r, vm)
+			return
+		}
+		addresses = append(addresses, fromCSV...)
+	}
+
+	switch {
+	case len(addresses) == 0:
+		vm.Error = "Enter at least one email address or choose a CSV file"
+		h.renderBulk(w, r, vm)
+		return
+	case len(addresses) > maxBulkInvitations:
+		vm.Error = fmt.Sprintf("Invite up to %d addresses at a time; this list has %d", maxBulkInvitations, len(addresses))
+		h.renderBulk(w, r, vm)
+		return
+	}
+
+	pending, err := h.invitationStore.ListPending(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to list pending invitations", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	invited := make(map[string]bool, len(pending))
+	for _, inv := range pending {
+		invited[inv.Email] = true
+	}
+
+	settings, err := h.settingsStore.Get(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to load settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actorID := actor.UserID()
+	input := invitation.CreateInput{
+		Role:       vm.Role,
+		InvitedBy:  actorID,
+		ExpiryDays: vm.ExpiryDays,
+		Message:    vm.Message,
+	}
+	seen := make(map[string]bool, len(addresses))
+	var toEmail []primitive.ObjectID
+	for _, addr := range addresses {
+		res := h.inviteOne(r.Context(), addr, input, settings, seen, invited)
+		if res.Status == bulkInvited {
+			vm.Invited++
+		} else {
+			vm.Skipped++
+		}
+		vm.Results = append(vm.Results, res.bulkResult)
+		if res.inv == nil {
+			continue
+		}
+		toEmail = append(toEmail, res.inv.ID)
+		h.auditLogger.LogAdminEvent(r, &actorID, nil, "invitation_sent", map[string]string{
+			"email": res.inv.Email,
+			"role":  res.inv.Role,
+			"bulk":  "true",
+		})
+	}
+
+	if h.mailer != nil && h.bulkMail != nil && len(toEmail) > 0 {
+		jobID, err := h.bulkMail.Queue(r.Context(), toEmail, actor.Name)
+		if err != nil {
+			h.errLog.Log(r, "failed to queue invitation emails", err)
+			vm.Error = "The invitations were created, but their emails could not be queued. Resend them from the invitations list."
+		} else {
+			vm.Emailing = true
+			vm.EmailJobID = jobID.Hex()
+		}
+	}
+
+	vm.Emails = ""
+	h.renderBulk(w, r, vm)
+}
+
+// bulkOutcome is a bulkResult and, if one was created, the invitation.
+type bulkOutcome struct {
+	bulkResult
+	inv *invitation.Invitation
+}
+
+// inviteOne validates one address of a bulk invitation and invites it with
+// input's role, expiry, and message. Addresses outside the settings'
+// allowed email domains are skipped. seen holds the addresses already
+// handled in this list, and invited those with a pending invitation.
+func (h *Handler) inviteOne(ctx context.Context, addr string, input invitation.CreateInput, settings *models.SiteSettings, seen, invited map[string]bool) bulkOutcome {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		return bulkOutcome{bulkResult: bulkResult{Email: addr, Status: bulkInvalid, Message: "Not a valid email address"}}
+	}
+	email := strings.ToLower(parsed.Address)
+	out := bulkOutcome{bulkResult: bulkResult{Email: email}}
+
+	switch {
+	case !settings.EmailDomainAllowed(email):
+		out.Status, out.Message = bulkDomain, "Email domain is not allowed"
+		return out
+	case seen[email]:
+		out.Status, out.Message = bulkDuplicate, "Listed more than once"
+		return out
+	case invited[email]:
+		out.Status, out.Message = bulkPending, "Already has a pending invitation"
+		return out
+	}
+	seen[email] = true
+
+	exists, err := h.userExists(ctx, email)
+	if err != nil {
+		h.logger.Warn("failed to check existing user for bulk invitation", zap.String("email", email), zap.Error(err))
+		out.Status, out.Message = bulkFailed, "Could not be checked"
+		return out
+	}
+	if exists {
+		out.Status, out.Message = bulkExists, "A user with this email already exists"
+		return out
+	}
+
+	input.Email = email
+	inv, err := h.invitationStore.Create(ctx, input)
+	if err != nil {
+		h.logger.Warn("failed to create bulk invitation", zap.String("email", email), zap.Error(err))
+		out.Status, out.Message = bulkFailed, "Failed to create invitation"
+		return out
+	}
+	out.Status, out.Message, out.inv = bulkInvited, "Invited", inv
+	return out
+}
+
+// userExists reports whether a user has the email as their email or
+// login ID.
+func (h *Handler) userExists(ctx context.Context, email string) (bool, error) {
+	u, err := h.userStore.GetByEmail(ctx, email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	if u != nil {
+		return true, nil
+	}
+	u, err = h.userStore.GetByLoginID(ctx, email)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	return u != nil, nil
+}
+
+// splitAddresses splits a pasted list of addresses on newlines, commas,
+// and semicolons, dropping blanks.
+func splitAddresses(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ';'
+	})
+	var out []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// readCSVAddresses reads addresses from a CSV file. If the first row has a
+// column headed "email", addresses are read from that column; otherwise
+// they are read from the first column of every row.
+func readCSVAddresses(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	col := 0
+	for i, name := range rows[0] {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), "email") {
+			col = i
+			rows = rows[1:]
+			break
+		}
+	}
+
+	var out []string
+	for _, row := range rows {
+		if col >= len(row) {
+			continue
+		}
+		if v := strings.TrimSpace(strings.TrimPrefix(row[col], "\ufeff")); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
@@ -6,10 +6,16 @@ package invitations
 //   - LoginID / loginID / login_id: The human-readable string users type to log in
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	"github.com/dalemusser/stratasave/internal/app/store/invitation"
@@ -18,11 +24,16 @@ import (
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/invitationmail"
 	"github.com/dalemusser/stratasave/internal/app/system/mailer"
 	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/app/system/newdevice"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/dalemusser/waffle/pantry/urlutil"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/csrf"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -30,6 +41,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxMessageLength is the longest personal message an invitation can
+// include, in characters.
+const maxMessageLength = 1000
+
 // Handler provides invitation handlers.
 type Handler struct {
 	invitationStore *invitation.Store
@@ -41,7 +56,12 @@ type Handler struct {
 	mailer          *mailer.Mailer
 	auditLogger     *auditlog.Logger
 	baseURL         string
+	expiryDays      int // default invitation lifetime
 	logger          *zap.Logger
+	captcha         *captcha.Verifier   // nil if CAPTCHA is disabled
+	newDevices      *newdevice.Notifier // nil if login devices aren't tracked
+	webhooks        *webhooks.Dispatcher
+	bulkMail        *invitationmail.Sender // nil if bulk invitations aren't emailed
 }
 
 // NewHandler creates a new invitations Handler.
@@ -70,10 +90,33 @@ func NewHandler(
 		mailer:          m,
 		auditLogger:     auditLogger,
 		baseURL:         baseURL,
+		expiryDays:      max(int(inviteExpiry/(24*time.Hour)), 1),
 		logger:          logger,
 	}
 }
 
+// SetCaptcha requires a CAPTCHA on the accept invitation form.
+func (h *Handler) SetCaptcha(v *captcha.Verifier) {
+	h.captcha = v
+}
+
+// SetNewDeviceNotifier records the device an invitation is accepted from as
+// the new user's first known device.
+func (h *Handler) SetNewDeviceNotifier(n *newdevice.Notifier) {
+	h.newDevices = n
+}
+
+// SetWebhooks enables user.created webhook events for accepted invitations.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
+}
+
+// SetBulkSender emails bulk invitations from the job queue, where their
+// progress can be followed in the jobs console.
+func (h *Handler) SetBulkSender(s *invitationmail.Sender) {
+	h.bulkMail = s
+}
+
 // invitationRow represents an invitation in the list.
 type invitationRow struct {
 	ID        string
@@ -81,6 +124,7 @@ type invitationRow struct {
 	Role      string
 	ExpiresAt time.Time
 	Expired   bool
+	Reminded  bool // the invitee was emailed a reminder
 }
 
 // ListVM is the view model for the invitations list.
@@ -99,9 +143,12 @@ func AdminRoutes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Get("/", h.list)
 	r.Get("/new", h.showNew)
 	r.Post("/new", h.create)
+	r.Get("/bulk", h.showBulk)
+	r.Post("/bulk", h.createBulk)
 	r.Get("/{id}/manage_modal", h.manageModal)
 	r.Post("/{id}/revoke", h.revoke)
 	r.Post("/{id}/resend", h.resend)
+	r.Post("/{id}/extend", h.extend)
 
 	return r
 }
@@ -116,9 +163,10 @@ func AcceptRoutes(h *Handler) http.Handler {
 	return r
 }
 
-// list displays pending invitations.
+// list displays pending invitations, and expired ones that can still be
+// resent or extended.
 func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
-	invitations, err := h.invitationStore.ListPending(r.Context())
+	invitations, err := h.invitationStore.ListOpen(r.Context())
 	if err != nil {
 		h.errLog.Log(r, "failed to list invitations", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -134,6 +182,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 			Role:      inv.Role,
 			ExpiresAt: inv.ExpiresAt,
 			Expired:   inv.ExpiresAt.Before(now),
+			Reminded:  inv.ReminderSentAt != nil,
 		})
 	}
 
@@ -152,6 +201,9 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Query().Get("resent") == "1" {
 		vm.Success = "Invitation resent"
 	}
+	if r.URL.Query().Get("extended") == "1" {
+		vm.Success = "Invitation extended"
+	}
 	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
 		vm.Error = errMsg
 	}
@@ -165,6 +217,10 @@ type NewVM struct {
 	Email          string
 	Role           string
 	AvailableRoles []string
+	ExpiryDays     int
+	ExpiryChoices  []int
+	Message        string
+	MaxMessage     int
 	Error          string
 }
 
@@ -212,14 +268,20 @@ func (h *Handler) manageModal(w http.ResponseWriter, r *http.Request) {
 
 // showNew displays the new invitation form.
 func (h *Handler) showNew(w http.ResponseWriter, r *http.Request) {
-	vm := NewVM{
-		BaseVM:         viewdata.New(r),
-		Role:           "admin", // Default role
-		AvailableRoles: models.AllRoles(),
-	}
+	h.renderNew(w, r, NewVM{
+		Role:       "admin", // Default role
+		ExpiryDays: h.expiryDays,
+	})
+}
+
+// renderNew renders the new invitation form.
+func (h *Handler) renderNew(w http.ResponseWriter, r *http.Request, vm NewVM) {
+	vm.BaseVM = viewdata.New(r)
+	vm.AvailableRoles = models.AllRoles()
+	vm.ExpiryChoices = h.expiryChoices()
+	vm.MaxMessage = maxMessageLength
 	vm.Title = "Send Invitation"
 	vm.BackURL = "/invitations"
-
 	templates.Render(w, r, "invitations/new", vm)
 }
 
@@ -238,18 +300,36 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 	if role == "" || !models.IsValidRole(role) {
 		role = "admin"
 	}
+	days, message, errMsg := h.parseInviteOptions(r)
+	vm := NewVM{
+		Email:      email,
+		Role:       role,
+		ExpiryDays: days,
+		Message:    message,
+	}
+	if errMsg != "" {
+		vm.Error = errMsg
+		h.renderNew(w, r, vm)
+		return
+	}
 
 	// Validate email
 	if _, err := mail.ParseAddress(email); err != nil {
-		vm := NewVM{
-			BaseVM:         viewdata.New(r),
-			Email:          email,
-			Role:           role,
-			AvailableRoles: models.AllRoles(),
-			Error:          "Please enter a valid email address",
-		}
-		vm.BackURL = "/invitations"
-		templates.Render(w, r, "invitations/new", vm)
+		vm.Error = "Please enter a valid email address"
+		h.renderNew(w, r, vm)
+		return
+	}
+
+	settings, err := h.settingsStore.Get(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to load settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !settings.EmailDomainAllowed(email) {
+		vm.Error = "Only addresses at " + strings.Join(settings.AllowedEmailDomains, ", ") +
+			" can be invited. Allowed email domains are set in Site Settings."
+		h.renderNew(w, r, vm)
 		return
 	}
 
@@ -269,51 +349,29 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if existingUser != nil {
-		vm := NewVM{
-			BaseVM:         viewdata.New(r),
-			Email:          email,
-			Role:           role,
-			AvailableRoles: models.AllRoles(),
-			Error:          "A user with this email already exists",
-		}
-		vm.BackURL = "/invitations"
-		templates.Render(w, r, "invitations/new", vm)
+		vm.Error = "A user with this email already exists"
+		h.renderNew(w, r, vm)
 		return
 	}
 
 	// Create invitation
 	inv, err := h.invitationStore.Create(r.Context(), invitation.CreateInput{
-		Email:     email,
-		Role:      role,
-		InvitedBy: actor.UserID(),
+		Email:      email,
+		Role:       role,
+		InvitedBy:  actor.UserID(),
+		ExpiryDays: days,
+		Message:    message,
 	})
 	if err != nil {
 		h.errLog.Log(r, "failed to create invitation", err)
-		vm := NewVM{
-			BaseVM:         viewdata.New(r),
-			Email:          email,
-			Role:           role,
-			AvailableRoles: models.AllRoles(),
-			Error:          "Failed to create invitation",
-		}
-		vm.BackURL = "/invitations"
-		templates.Render(w, r, "invitations/new", vm)
+		vm.Error = "Failed to create invitation"
+		h.renderNew(w, r, vm)
 		return
 	}
 
 	// Send invitation email
 	if h.mailer != nil {
-		inviteURL := h.baseURL + "/invite?token=" + inv.Token
-		err = h.mailer.Send(mailer.Email{
-			To:      email,
-			Subject: "You're Invited!",
-			TextBody: "You've been invited to join our platform.\n\n" +
-				"Click the link below to set up your account:\n\n" +
-				inviteURL + "\n\n" +
-				"This invitation expires in 7 days.\n\n" +
-				"If you did not expect this invitation, you can safely ignore this email.",
-		})
-		if err != nil {
+		if err := h.mailer.Send(h.invitationEmail(inv, actor.Name, h.siteName(r.Context()), h.mailer.Brand(r.Context()))); err != nil {
 			h.errLog.Log(r, "failed to send invitation email", err)
 		}
 	}
@@ -346,7 +404,7 @@ func (h *Handler) revoke(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.invitationStore.Revoke(r.Context(), objID); err != nil {
 		h.errLog.Log(r, "failed to revoke invitation", err)
-		http.Redirect(w, r, "/invitations?error=Failed+to+revoke+invitation", http.StatusSeeOther)
+		h.redirectBack(w, r, "error", "Failed to revoke invitation")
 		return
 	}
 
@@ -355,7 +413,7 @@ func (h *Handler) revoke(w http.ResponseWriter, r *http.Request) {
 		"email": inv.Email,
 	})
 
-	http.Redirect(w, r, "/invitations?revoked=1", http.StatusSeeOther)
+	h.redirectBack(w, r, "revoked", "1")
 }
 
 // resend resends an invitation.
@@ -377,7 +435,7 @@ func (h *Handler) resend(w http.ResponseWriter, r *http.Request) {
 
 	// Check if already used or revoked
 	if inv.UsedAt != nil || inv.Revoked {
-		http.Redirect(w, r, "/invitations?error=Invitation+is+no+longer+valid", http.StatusSeeOther)
+		h.redirectBack(w, r, "error", "Invitation is no longer valid")
 		return
 	}
 
@@ -385,29 +443,21 @@ func (h *Handler) resend(w http.ResponseWriter, r *http.Request) {
 	h.invitationStore.Revoke(r.Context(), objID)
 
 	newInv, err := h.invitationStore.Create(r.Context(), invitation.CreateInput{
-		Email:     inv.Email,
-		Role:      inv.Role,
-		InvitedBy: actor.UserID(),
+		Email:      inv.Email,
+		Role:       inv.Role,
+		InvitedBy:  actor.UserID(),
+		ExpiryDays: inv.ExpiryDays,
+		Message:    inv.Message,
 	})
 	if err != nil {
 		h.errLog.Log(r, "failed to resend invitation", err)
-		http.Redirect(w, r, "/invitations?error=Failed+to+resend+invitation", http.StatusSeeOther)
+		h.redirectBack(w, r, "error", "Failed to resend invitation")
 		return
 	}
 
 	// Send invitation email
 	if h.mailer != nil {
-		inviteURL := h.baseURL + "/invite?token=" + newInv.Token
-		err = h.mailer.Send(mailer.Email{
-			To:      inv.Email,
-			Subject: "You're Invited!",
-			TextBody: "You've been invited to join our platform.\n\n" +
-				"Click the link below to set up your account:\n\n" +
-				inviteURL + "\n\n" +
-				"This invitation expires in 7 days.\n\n" +
-				"If you did not expect this invitation, you can safely ignore this email.",
-		})
-		if err != nil {
+		if err := h.mailer.Send(h.invitationEmail(newInv, actor.Name, h.siteName(r.Context()), h.mailer.Brand(r.Context()))); err != nil {
 			h.errLog.Log(r, "failed to send invitation email", err)
 		}
 	}
@@ -417,7 +467,91 @@ func (h *Handler) resend(w http.ResponseWriter, r *http.Request) {
 		"email": inv.Email,
 	})
 
-	http.Redirect(w, r, "/invitations?resent=1", http.StatusSeeOther)
+	h.redirectBack(w, r, "resent", "1")
+}
+
+// extend gives an invitation a full expiry period from now, so its
+// original link works again without sending a new one.
+func (h *Handler) extend(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	inv, err := h.invitationStore.Extend(r.Context(), objID)
+	if err == mongo.ErrNoDocuments {
+		h.redirectBack(w, r, "error", "Invitation is no longer valid")
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to extend invitation", err)
+		h.redirectBack(w, r, "error", "Failed to extend invitation")
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "invitation_extended", map[string]string{
+		"email":      inv.Email,
+		"expires_at": inv.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+
+	h.redirectBack(w, r, "extended", "1")
+}
+
+// invitationEmail builds the email that sends an invitation's link, from
+// the admin named inviter.
+func (h *Handler) invitationEmail(inv *invitation.Invitation, inviter, appName string, brand mailer.Brand) mailer.Email {
+	return invitationmail.Email(inv, inviter, appName, brand, h.baseURL, h.expiryDays)
+}
+
+// siteName returns the site's name for emails.
+func (h *Handler) siteName(ctx context.Context) string {
+	if st, err := h.settingsStore.Get(ctx); err == nil && st.SiteName != "" {
+		return st.SiteName
+	}
+	return models.DefaultSiteName
+}
+
+// parseInviteOptions reads the expiry and personal message chosen on an
+// invitation form. An expiry that isn't offered falls back to the default.
+func (h *Handler) parseInviteOptions(r *http.Request) (days int, message, errMsg string) {
+	days = h.expiryDays
+	if n, err := strconv.Atoi(r.FormValue("expiry_days")); err == nil && slices.Contains(h.expiryChoices(), n) {
+		days = n
+	}
+	message = strings.TrimSpace(r.FormValue("message"))
+	if utf8.RuneCountInString(message) > maxMessageLength {
+		errMsg = fmt.Sprintf("The personal message can be at most %d characters", maxMessageLength)
+	}
+	return days, message, errMsg
+}
+
+// expiryChoices returns the expiry periods offered on invitation forms, in
+// days, including the configured default.
+func (h *Handler) expiryChoices() []int {
+	choices := []int{1, 3, 7, 14, 30}
+	if !slices.Contains(choices, h.expiryDays) {
+		choices = append(choices, h.expiryDays)
+		slices.Sort(choices)
+	}
+	return choices
+}
+
+// redirectBack redirects to the page the action was taken from (the form's
+// return value, such as the system users list) or the invitations list,
+// with key=value added to its query to report the result.
+func (h *Handler) redirectBack(w http.ResponseWriter, r *http.Request, key, value string) {
+	dest, err := url.Parse(urlutil.SafeReturn(r.FormValue("return"), "", "/invitations"))
+	if err != nil {
+		dest = &url.URL{Path: "/invitations"}
+	}
+	q := dest.Query()
+	q.Set(key, value)
+	dest.RawQuery = q.Encode()
+	http.Redirect(w, r, dest.String(), http.StatusSeeOther)
 }
 
 // AcceptVM is the view model for accepting an invitation.
@@ -427,6 +561,41 @@ type AcceptVM struct {
 	Email    string
 	FullName string
 	Error    string
+	Captcha  *captcha.Widget
+}
+
+// renderAccept renders the accept form with the CAPTCHA widget, if enabled.
+func (h *Handler) renderAccept(w http.ResponseWriter, r *http.Request, vm AcceptVM) {
+	vm.Captcha = h.captcha.Widget()
+	templates.Render(w, r, "invitations/accept", vm)
+}
+
+// checkAcceptDomain renders an error on the accept page and returns false
+// if the invitation's address is outside the site's allowed email domains,
+// which may have been set after the invitation was sent. The invitation is
+// left open, so it works again if the domain is allowed later.
+func (h *Handler) checkAcceptDomain(w http.ResponseWriter, r *http.Request, inv *invitation.Invitation) bool {
+	settings, err := h.settingsStore.Get(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to load settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return false
+	}
+	if settings.EmailDomainAllowed(inv.Email) {
+		return true
+	}
+
+	h.auditLogger.LogAuthEvent(r, nil, "invitation_blocked_domain", false, inv.Email)
+
+	vm := AcceptVM{
+		BaseVM: viewdata.New(r),
+		Error: "This invitation is for " + inv.Email + ", but only addresses at " +
+			strings.Join(settings.AllowedEmailDomains, ", ") +
+			" can join this site. Please ask an administrator to invite your work email address.",
+	}
+	vm.Title = "Email Domain Not Allowed"
+	h.renderAccept(w, r, vm)
+	return false
 }
 
 // showAccept displays the accept invitation form.
@@ -447,7 +616,7 @@ func (h *Handler) showAccept(w http.ResponseWriter, r *http.Request) {
 			Error:  "This invitation link is invalid or has expired. Please contact an administrator for a new invitation.",
 		}
 		vm.Title = "Invalid Invitation"
-		templates.Render(w, r, "invitations/accept", vm)
+		h.renderAccept(w, r, vm)
 		return
 	}
 
@@ -495,7 +664,11 @@ func (h *Handler) showAccept(w http.ResponseWriter, r *http.Request) {
 			Error:  "An account with this email already exists. Please log in instead.",
 		}
 		vm.Title = "Account Already Exists"
-		templates.Render(w, r, "invitations/accept", vm)
+		h.renderAccept(w, r, vm)
+		return
+	}
+
+	if !h.checkAcceptDomain(w, r, inv) {
 		return
 	}
 
@@ -506,7 +679,7 @@ func (h *Handler) showAccept(w http.ResponseWriter, r *http.Request) {
 	}
 	vm.Title = "Complete Your Registration"
 
-	templates.Render(w, r, "invitations/accept", vm)
+	h.renderAccept(w, r, vm)
 }
 
 // handleAccept processes the invitation acceptance.
@@ -531,7 +704,11 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 			Error:  "This invitation link is invalid or has expired. Please contact an administrator for a new invitation.",
 		}
 		vm.Title = "Invalid Invitation"
-		templates.Render(w, r, "invitations/accept", vm)
+		h.renderAccept(w, r, vm)
+		return
+	}
+
+	if !h.checkAcceptDomain(w, r, inv) {
 		return
 	}
 
@@ -545,7 +722,21 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 			Error:    "Full name is required",
 		}
 		vm.Title = "Complete Your Registration"
-		templates.Render(w, r, "invitations/accept", vm)
+		h.renderAccept(w, r, vm)
+		return
+	}
+
+	if !h.captcha.Verify(r) {
+		h.auditLogger.LogAuthEvent(r, nil, "captcha_failed", false, "invitation for "+inv.Email)
+		vm := AcceptVM{
+			BaseVM:   viewdata.New(r),
+			Token:    token,
+			Email:    inv.Email,
+			FullName: fullName,
+			Error:    "Please complete the CAPTCHA challenge.",
+		}
+		vm.Title = "Complete Your Registration"
+		h.renderAccept(w, r, vm)
 		return
 	}
 
@@ -573,7 +764,7 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 				Error:  "An account with this email already exists. Please log in instead.",
 			}
 			vm.Title = "Account Already Exists"
-			templates.Render(w, r, "invitations/accept", vm)
+			h.renderAccept(w, r, vm)
 			return
 		}
 
@@ -586,7 +777,7 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 			Error:    "Failed to create account. Please try again.",
 		}
 		vm.Title = "Complete Your Registration"
-		templates.Render(w, r, "invitations/accept", vm)
+		h.renderAccept(w, r, vm)
 		return
 	}
 
@@ -594,6 +785,7 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 	h.invitationStore.MarkUsed(r.Context(), inv.ID)
 
 	h.auditLogger.LogAuthEvent(r, &user.ID, "user_registered_via_invitation", true, inv.Email)
+	h.webhooks.Publish(webhooks.EventUserCreated, webhooks.UserData(&user, "invitation"))
 
 	// Send welcome email if enabled
 	if h.mailer != nil {
@@ -602,12 +794,17 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 			userEmail := inv.Email
 			userName := fullName
 			userRole := inv.Role
+			userLocale := user.Locale
+			userID := user.ID.Hex()
+			brand := h.mailer.Brand(r.Context())
 			siteName := settings.SiteName
 			if siteName == "" {
 				siteName = "Strata"
 			}
 			go func() {
 				text, html := mailer.WelcomeEmail(mailer.WelcomeEmailData{
+					Locale:   userLocale,
+					Brand:    brand,
 					AppName:  siteName,
 					UserName: userName,
 					LoginURL: h.baseURL + "/login",
@@ -615,7 +812,9 @@ func (h *Handler) handleAccept(w http.ResponseWriter, r *http.Request) {
 				})
 				_ = h.mailer.Send(mailer.Email{
 					To:       userEmail,
-					Subject:  "Welcome to " + siteName + "!",
+					Subject:  mailer.T(userLocale, "welcome.subject", siteName),
+					Template: "welcome",
+					UserID:   userID,
 					TextBody: text,
 					HTMLBody: html,
 				})
@@ -659,7 +858,7 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		UserAgent:    r.UserAgent(),
 		LoginAt:      now,
 		LastActivity: now,
-		ExpiresAt:    now.Add(24 * 30 * time.Hour), // 30 days
+		ExpiresAt:    now.Add(h.sessionMgr.Lifetime(role).Absolute),
 	}
 
 	// Best effort - don't fail login if tracking fails
@@ -667,5 +866,11 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		h.logger.Warn("failed to track session in MongoDB", zap.Error(err))
 	}
 
+	if err := h.userStore.RecordLogin(r.Context(), userID, now); err != nil {
+		h.logger.Warn("failed to record last login", zap.Error(err))
+	}
+
+	h.newDevices.Check(r, userID)
+
 	return nil
 }
@@ -2,6 +2,7 @@ package invitations
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/dalemusser/stratasave/internal/app/store/invitation"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/testutil"
@@ -251,6 +253,133 @@ func TestCreate_Success(t *testing.T) {
 	}
 }
 
+func TestCreateBulk(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _, invStore, userStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if _, err := userStore.CreateFromInput(ctx, userstore.CreateInput{
+		FullName:   "Existing User",
+		Email:      "existing@example.com",
+		AuthMethod: "password",
+		Role:       "admin",
+	}); err != nil {
+		t.Fatalf("failed to create existing user: %v", err)
+	}
+
+	sessionUser := &auth.SessionUser{
+		ID:      primitive.NewObjectID().Hex(),
+		Name:    "Admin User",
+		LoginID: "admin@example.com",
+		Role:    "admin",
+	}
+
+	form := url.Values{}
+	form.Set("emails", "one@example.com\nTwo@Example.com, not-an-email\none@example.com; existing@example.com")
+	form.Set("role", "developer")
+
+	req := httptest.NewRequest(http.MethodPost, "/invitations/bulk", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = auth.WithTestUser(req, sessionUser)
+	req = testutil.WithCSRFToken(req)
+	rec := httptest.NewRecorder()
+
+	h.createBulk(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	pending, err := invStore.ListPending(ctx)
+	if err != nil {
+		t.Fatalf("ListPending() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, inv := range pending {
+		got[inv.Email] = inv.Role
+	}
+	want := map[string]string{"one@example.com": "developer", "two@example.com": "developer"}
+	if len(got) != len(want) || got["one@example.com"] != "developer" || got["two@example.com"] != "developer" {
+		t.Errorf("pending invitations = %v, want %v", got, want)
+	}
+}
+
+func TestSplitAddresses(t *testing.T) {
+	got := splitAddresses(" a@example.com\r\nb@example.com,c@example.com ;\n\n, d@example.com ")
+	want := []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("splitAddresses() = %v, want %v", got, want)
+	}
+}
+
+func TestReadCSVAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []string
+	}{
+		{"email column", "Name,Email\nAda,ada@example.com\nBob,\nCy,cy@example.com\n", []string{"ada@example.com", "cy@example.com"}},
+		{"byte order mark", "\ufeffemail\nada@example.com\n", []string{"ada@example.com"}},
+		{"no header", "ada@example.com,Ada\ncy@example.com\n", []string{"ada@example.com", "cy@example.com"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSVAddresses(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatalf("readCSVAddresses() error = %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("readCSVAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInviteOptions(t *testing.T) {
+	h := &Handler{expiryDays: 7}
+	tests := []struct {
+		name     string
+		days     string
+		message  string
+		wantDays int
+		wantErr  bool
+	}{
+		{"offered expiry", "14", " See you there! ", 14, false},
+		{"unoffered expiry", "5", "", 7, false},
+		{"missing expiry", "", "", 7, false},
+		{"message too long", "3", strings.Repeat("x", maxMessageLength+1), 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"expiry_days": {tt.days}, "message": {tt.message}}
+			req := httptest.NewRequest(http.MethodPost, "/invitations/new", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			days, message, errMsg := h.parseInviteOptions(req)
+			if days != tt.wantDays {
+				t.Errorf("days = %d, want %d", days, tt.wantDays)
+			}
+			if (errMsg != "") != tt.wantErr {
+				t.Errorf("errMsg = %q, want error %v", errMsg, tt.wantErr)
+			}
+			if !tt.wantErr && message != strings.TrimSpace(tt.message) {
+				t.Errorf("message = %q, want trimmed %q", message, tt.message)
+			}
+		})
+	}
+}
+
+func TestExpiryChoices_IncludesDefault(t *testing.T) {
+	h := &Handler{expiryDays: 10}
+	got := h.expiryChoices()
+	want := []int{1, 3, 7, 10, 14, 30}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expiryChoices() = %v, want %v", got, want)
+	}
+}
+
 func TestRevoke_Success(t *testing.T) {
 	h, _, invStore, _ := newTestHandler(t)
 	ctx, cancel := testutil.TestContext()
@@ -485,6 +614,47 @@ func TestHandleAccept_InvalidToken(t *testing.T) {
 	}
 }
 
+func TestHandleAccept_DomainNotAllowed(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, db, invStore, userStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	inv, err := invStore.Create(ctx, invitation.CreateInput{
+		Email:     "someone@gmail.com",
+		Role:      "developer",
+		InvitedBy: primitive.NewObjectID(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create test invitation: %v", err)
+	}
+	// Restrict domains after the invitation was sent
+	if err := settingsstore.New(db).Upsert(ctx, settingsstore.UpdateInput{
+		SiteName:            "Test",
+		AllowedEmailDomains: []string{"example.com"},
+	}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+
+	form := url.Values{}
+	form.Set("token", inv.Token)
+	form.Set("full_name", "Some One")
+
+	req := httptest.NewRequest(http.MethodPost, "/invite", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = testutil.WithCSRFToken(req)
+	rec := httptest.NewRecorder()
+
+	h.handleAccept(rec, req)
+
+	if !strings.Contains(rec.Body.String(), "only addresses at example.com") {
+		t.Error("expected the allowed domains in the error message")
+	}
+	if u, _ := userStore.GetByEmail(ctx, "someone@gmail.com"); u != nil {
+		t.Error("user should not be created for a disallowed domain")
+	}
+}
+
 func TestHandleAccept_CreatesUser(t *testing.T) {
 	h, _, invStore, userStore := newTestHandler(t)
 	ctx, cancel := testutil.TestContext()
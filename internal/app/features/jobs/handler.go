@@ -8,6 +8,7 @@ import (
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/system/scheduler"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
@@ -17,11 +18,13 @@ import (
 	"go.uber.org/zap"
 )
 
-// Handler handles job monitoring HTTP requests.
+// Handler handles job monitoring and scheduled job HTTP requests.
 type Handler struct {
 	DB     *mongo.Database
 	ErrLog *errorsfeature.ErrorLogger
 	Log    *zap.Logger
+
+	scheduler *scheduler.Scheduler
 }
 
 // NewHandler creates a new jobs handler.
@@ -48,7 +51,13 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get recent failed jobs
+	// Get jobs waiting to be retried and recent dead-lettered jobs
+	retrying, err := store.Retrying(ctx, 10)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load retrying jobs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
 	recentFailed, err := store.RecentFailed(ctx, 10)
 	if err != nil {
 		h.ErrLog.Log(r, "failed to load recent failures", err)
@@ -62,6 +71,11 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 		statsVMs[i] = toQueueStatsVM(s)
 	}
 
+	retryingVMs := make([]JobVM, len(retrying))
+	for i, j := range retrying {
+		retryingVMs[i] = toJobVM(j)
+	}
+
 	failedVMs := make([]JobVM, len(recentFailed))
 	for i, j := range recentFailed {
 		failedVMs[i] = toJobVM(j)
@@ -71,6 +85,7 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 	data := JobDashboardVM{
 		BaseVM:       base,
 		QueueStats:   statsVMs,
+		Retrying:     retryingVMs,
 		RecentFailed: failedVMs,
 	}
 
@@ -169,7 +184,41 @@ func (h *Handler) ServeDetail(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "jobs/detail", data)
 }
 
-// HandleRetry handles POST /jobs/{id}/retry - retry a failed job.
+// ServeProgress handles GET /jobs/{id}/progress - the progress section of
+// the detail page, polled while the job is pending or running. Once the
+// job finishes the whole page is refreshed to show how it ended.
+func (h *Handler) ServeProgress(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	job, err := jobstore.New(h.DB).GetByID(ctx, id)
+	if err != nil {
+		if err == jobstore.ErrNotFound {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to load job progress", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if job.Status != jobstore.StatusPending && job.Status != jobstore.StatusRunning {
+		w.Header().Set("HX-Refresh", "true")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	templates.RenderSnippet(w, "jobs_progress", toJobVM(*job))
+}
+
+// HandleRetry handles POST /jobs/{id}/retry - retry a failed or cancelled
+// job, or run a job waiting for a retry now.
 func (h *Handler) HandleRetry(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
 	defer cancel()
@@ -199,6 +248,31 @@ func (h *Handler) HandleRetry(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// HandleRetryFailed handles POST /jobs/dead-letter/retry - retry every
+// dead-lettered job, or those in the queue and of the type posted.
+func (h *Handler) HandleRetryFailed(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	queueName := r.FormValue("queue")
+	jobType := r.FormValue("type")
+
+	count, err := jobstore.New(h.DB).RetryFailed(ctx, queueName, jobType)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to retry dead-lettered jobs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Log.Info("dead-lettered jobs retried",
+		zap.String("queue", queueName),
+		zap.String("job_type", jobType),
+		zap.Int64("count", count))
+
+	w.Header().Set("HX-Redirect", "/jobs")
+	w.WriteHeader(http.StatusOK)
+}
+
 // HandleCancel handles POST /jobs/{id}/cancel - cancel a pending/running job.
 func (h *Handler) HandleCancel(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
@@ -262,6 +336,7 @@ func toJobVM(j jobstore.Job) JobVM {
 		ScheduledAt: j.ScheduledAt.Format("2006-01-02 15:04:05"),
 		CreatedAt:   j.CreatedAt.Format("2006-01-02 15:04:05"),
 		StatusClass: getStatusClass(j.Status),
+		Retrying:    j.Retrying(),
 	}
 
 	if j.StartedAt != nil {
@@ -270,6 +345,22 @@ func toJobVM(j jobstore.Job) JobVM {
 	if j.CompletedAt != nil {
 		vm.CompletedAt = j.CompletedAt.Format("2006-01-02 15:04:05")
 	}
+	for _, f := range j.Failures {
+		vm.Failures = append(vm.Failures, FailureVM{
+			Attempt:    f.Attempt,
+			Error:      f.Error,
+			WorkerID:   f.WorkerID,
+			DurationMs: f.DurationMs,
+			FailedAt:   f.FailedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	if j.Progress != nil {
+		vm.HasProgress = j.Progress.Total > 0
+		vm.ProgressDone = j.Progress.Done
+		vm.ProgressTotal = j.Progress.Total
+		vm.ProgressPercent = j.Progress.Percent()
+		vm.ProgressStep = j.Progress.Step
+	}
 
 	return vm
 }
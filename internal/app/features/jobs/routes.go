@@ -14,7 +14,14 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 
 	r.Get("/", h.ServeDashboard)
 	r.Get("/list", h.ServeList)
+	r.Get("/schedules", h.ServeSchedules)
+	r.Get("/schedules/{name}", h.ServeScheduleDetail)
+	r.Post("/schedules/{name}/pause", h.HandlePause)
+	r.Post("/schedules/{name}/resume", h.HandleResume)
+	r.Post("/schedules/{name}/run", h.HandleRunNow)
+	r.Post("/dead-letter/retry", h.HandleRetryFailed)
 	r.Get("/{id}", h.ServeDetail)
+	r.Get("/{id}/progress", h.ServeProgress)
 	r.Post("/{id}/retry", h.HandleRetry)
 	r.Post("/{id}/cancel", h.HandleCancel)
 
@@ -0,0 +1,252 @@
+// internal/app/features/jobs/schedules.go
+package jobsfeature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	schedulestore "github.com/dalemusser/stratasave/internal/app/store/schedules"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/scheduler"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+)
+
+// runsPerPage is how many runs the run history shows per page.
+const runsPerPage = 50
+
+// SetScheduler enables the scheduled jobs pages. Without it they show
+// nothing is scheduled.
+func (h *Handler) SetScheduler(s *scheduler.Scheduler) {
+	h.scheduler = s
+}
+
+// ServeSchedules handles GET /jobs/schedules - list scheduled jobs.
+func (h *Handler) ServeSchedules(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	var vms []ScheduleVM
+	if h.scheduler != nil {
+		states, err := schedulestore.New(h.DB).All(ctx)
+		if err != nil {
+			h.ErrLog.Log(r, "failed to load schedules", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		running, err := h.scheduler.Running(ctx)
+		if err != nil {
+			h.ErrLog.Log(r, "failed to load running jobs", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		for _, info := range h.scheduler.Jobs() {
+			vms = append(vms, toScheduleVM(info, states[info.Name], running[info.Name]))
+		}
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Scheduled Jobs", "/jobs")
+	templates.Render(w, r, "jobs/schedules", ScheduleListVM{
+		BaseVM:    base,
+		Schedules: vms,
+	})
+}
+
+// ServeScheduleDetail handles GET /jobs/schedules/{name} - a scheduled
+// job and its run history.
+func (h *Handler) ServeScheduleDetail(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	info, ok := h.lookupSchedule(r)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	store := schedulestore.New(h.DB)
+	state, err := store.Get(ctx, info.Name)
+	if err != nil && !errors.Is(err, schedulestore.ErrNotFound) {
+		h.ErrLog.Log(r, "failed to load schedule", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	running, err := h.scheduler.Running(ctx)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load running jobs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	runs, total, err := store.Runs(ctx, info.Name, page, runsPerPage)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load schedule runs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	runVMs := make([]ScheduleRunVM, len(runs))
+	for i, run := range runs {
+		runVMs[i] = ScheduleRunVM{
+			StartedAt:   run.StartedAt.Format("2006-01-02 15:04:05"),
+			DurationMs:  run.DurationMs,
+			Trigger:     run.Trigger,
+			TriggeredBy: run.TriggeredBy,
+			Instance:    run.Instance,
+			Status:      run.Status,
+			Error:       run.Error,
+			StatusClass: runStatusClass(run.Status),
+		}
+	}
+
+	totalPages := int((total + runsPerPage - 1) / runsPerPage)
+	if totalPages < 1 {
+		totalPages = 1
+	}
+	prevPage := page - 1
+	if prevPage < 1 {
+		prevPage = 1
+	}
+	nextPage := page + 1
+	if nextPage > totalPages {
+		nextPage = totalPages
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Scheduled Job", "/jobs/schedules")
+	templates.Render(w, r, "jobs/schedule_detail", ScheduleDetailVM{
+		BaseVM:     base,
+		Schedule:   toScheduleVM(info, state, running[info.Name]),
+		Runs:       runVMs,
+		Page:       page,
+		TotalPages: totalPages,
+		TotalCount: total,
+		PrevPage:   prevPage,
+		NextPage:   nextPage,
+	})
+}
+
+// HandlePause handles POST /jobs/schedules/{name}/pause.
+func (h *Handler) HandlePause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+// HandleResume handles POST /jobs/schedules/{name}/resume.
+func (h *Handler) HandleResume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+// setPaused pauses or resumes the job named in the URL.
+func (h *Handler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	info, ok := h.lookupSchedule(r)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err := h.scheduler.SetPaused(ctx, info.Name, paused, actorName(r)); err != nil {
+		if errors.Is(err, schedulestore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to change schedule", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", scheduleURL(info.Name))
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleRunNow handles POST /jobs/schedules/{name}/run - run a scheduled
+// job now.
+func (h *Handler) HandleRunNow(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	info, ok := h.lookupSchedule(r)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	// A job that is already running is left to finish; its page shows it running
+	err := h.scheduler.Trigger(ctx, info.Name, actorName(r))
+	if err != nil && !errors.Is(err, scheduler.ErrRunning) {
+		h.ErrLog.Log(r, "failed to run scheduled job", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", scheduleURL(info.Name))
+	w.WriteHeader(http.StatusOK)
+}
+
+// lookupSchedule returns the registered job named in the URL.
+func (h *Handler) lookupSchedule(r *http.Request) (scheduler.Info, bool) {
+	if h.scheduler == nil {
+		return scheduler.Info{}, false
+	}
+	return h.scheduler.Lookup(chi.URLParam(r, "name"))
+}
+
+// actorName returns the name of the signed-in user, recorded against
+// pauses and manual runs.
+func actorName(r *http.Request) string {
+	if u, ok := auth.CurrentUser(r); ok {
+		return u.Name
+	}
+	return ""
+}
+
+// scheduleURL returns the page of a scheduled job.
+func scheduleURL(name string) string {
+	return "/jobs/schedules/" + url.PathEscape(name)
+}
+
+// toScheduleVM converts a registered job, its stored state, and the
+// instance running it, if any, to a view model. A job not yet recorded has
+// a zero state.
+func toScheduleVM(info scheduler.Info, state schedulestore.Schedule, runningOn string) ScheduleVM {
+	vm := ScheduleVM{
+		Name:           info.Name,
+		Spec:           info.Spec.String(),
+		Paused:         state.Paused,
+		PausedBy:       state.PausedBy,
+		Running:        runningOn != "",
+		RunningOn:      runningOn,
+		LastStatus:     state.LastStatus,
+		LastError:      state.LastError,
+		LastDurationMs: state.LastDurationMs,
+		StatusClass:    runStatusClass(state.LastStatus),
+	}
+	if !state.NextRunAt.IsZero() && !state.Paused {
+		vm.NextRunAt = state.NextRunAt.Format("2006-01-02 15:04:05")
+	}
+	if state.LastRunAt != nil {
+		vm.LastRunAt = state.LastRunAt.Format("2006-01-02 15:04:05")
+	}
+	return vm
+}
+
+// runStatusClass returns a CSS class based on a run's status.
+func runStatusClass(status string) string {
+	switch status {
+	case schedulestore.StatusSucceeded:
+		return getStatusClass(jobstore.StatusCompleted)
+	case schedulestore.StatusFailed:
+		return getStatusClass(jobstore.StatusFailed)
+	default:
+		return getStatusClass(jobstore.StatusCancelled)
+	}
+}
@@ -35,13 +35,32 @@ type JobVM struct {
 	CompletedAt string
 	CreatedAt   string
 	StatusClass string // CSS class for status badge
+	Retrying    bool   // Failed and waiting for its next attempt, at ScheduledAt
+	Failures    []FailureVM
+
+	// Progress, for jobs that report it
+	HasProgress     bool // A count of work done out of a total
+	ProgressDone    int64
+	ProgressTotal   int64
+	ProgressPercent int
+	ProgressStep    string
+}
+
+// FailureVM is the view model for one failed attempt at a job.
+type FailureVM struct {
+	Attempt    int
+	Error      string
+	WorkerID   string
+	DurationMs int64
+	FailedAt   string
 }
 
 // JobDashboardVM is the view model for the jobs dashboard page.
 type JobDashboardVM struct {
 	viewdata.BaseVM
 	QueueStats   []QueueStatsVM
-	RecentFailed []JobVM
+	Retrying     []JobVM
+	RecentFailed []JobVM // Most recent jobs in the dead-letter queue
 }
 
 // JobListVM is the view model for the jobs list page.
@@ -61,3 +80,49 @@ type JobDetailVM struct {
 	viewdata.BaseVM
 	Job JobVM
 }
+
+// ScheduleVM is the view model for a scheduled job.
+type ScheduleVM struct {
+	Name           string
+	Spec           string
+	Paused         bool
+	PausedBy       string
+	Running        bool
+	RunningOn      string // Instance the job is running on
+	NextRunAt      string
+	LastRunAt      string
+	LastStatus     string
+	LastError      string
+	LastDurationMs int64
+	StatusClass    string // CSS class for the last run's status badge
+}
+
+// ScheduleRunVM is the view model for one run of a scheduled job.
+type ScheduleRunVM struct {
+	StartedAt   string
+	DurationMs  int64
+	Trigger     string
+	TriggeredBy string
+	Instance    string
+	Status      string
+	Error       string
+	StatusClass string
+}
+
+// ScheduleListVM is the view model for the scheduled jobs page.
+type ScheduleListVM struct {
+	viewdata.BaseVM
+	Schedules []ScheduleVM
+}
+
+// ScheduleDetailVM is the view model for a scheduled job and its run history.
+type ScheduleDetailVM struct {
+	viewdata.BaseVM
+	Schedule   ScheduleVM
+	Runs       []ScheduleRunVM
+	Page       int
+	TotalPages int
+	TotalCount int64
+	PrevPage   int
+	NextPage   int
+}
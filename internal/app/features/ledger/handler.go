@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
 	ledgerstore "github.com/dalemusser/stratasave/internal/app/store/ledger"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/timezones"
@@ -50,10 +52,19 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	filter := ledgerstore.ListFilter{
 		ActorType: r.URL.Query().Get("actor_type"),
 		ActorID:   r.URL.Query().Get("actor_id"),
+		UserID:    strings.TrimSpace(r.URL.Query().Get("user_id")),
+		Game:      strings.TrimSpace(r.URL.Query().Get("game")),
 		Method:    r.URL.Query().Get("method"),
 		Path:      r.URL.Query().Get("path"),
 	}
 
+	// Requests made with one API key
+	apiKey := r.URL.Query().Get("api_key")
+	if apiKey != "" {
+		filter.ActorType = "api_key"
+		filter.ActorID = apiKey
+	}
+
 	// Parse time range
 	if start := r.URL.Query().Get("start_time"); start != "" {
 		if t, err := time.Parse("2006-01-02", start); err == nil {
@@ -80,6 +91,8 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filter.ErrorClass = r.URL.Query().Get("error_class")
+	filter.Captured = r.URL.Query().Get("capture") == "full"
+	filter.Sampled = r.URL.Query().Get("capture") == "sampled"
 	filter.Search = r.URL.Query().Get("search")
 
 	store := ledgerstore.New(h.DB)
@@ -109,12 +122,24 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	// Load timezone groups
 	tzGroups, _ := timezones.Groups()
 
+	// API keys for the key filter, revoked ones included for old entries
+	var apiKeys []APIKeyOptionVM
+	if keys, err := apikeystore.New(h.DB).List(ctx); err != nil {
+		h.Log.Warn("failed to load API keys for ledger filter", zap.Error(err))
+	} else {
+		for _, k := range keys {
+			apiKeys = append(apiKeys, APIKeyOptionVM{ID: k.ID.Hex(), Name: k.Name, Revoked: k.Status == apikeystore.StatusRevoked})
+		}
+	}
+
 	base := viewdata.NewBaseVM(r, h.DB, "Request Error Ledger", "/dashboard")
 	data := LedgerListVM{
 		BaseVM:         base,
 		TimezoneGroups: tzGroups,
 		Entries:        entries,
 		Filter:         filter,
+		APIKeys:        apiKeys,
+		APIKey:         apiKey,
 		Page:           result.Page,
 		TotalPages:     result.TotalPages,
 		TotalCount:     result.TotalCount,
@@ -206,6 +231,14 @@ func (h *Handler) ServeStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Get the baseline from sampled successful requests
+	sampleStats, err := store.SampledSuccessStats(ctx, start, end)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load sampled request stats", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
 	// Get recent errors
 	recentErrors, err := store.RecentErrors(ctx, 10)
 	if err != nil {
@@ -254,6 +287,7 @@ func (h *Handler) ServeStats(w http.ResponseWriter, r *http.Request) {
 		StatusBreakdown: statusBreakdown,
 		TotalErrors:     totalErrors,
 		AvgResponseTime: avgResponseTime,
+		Sampled:         sampleStats,
 		RecentErrors:    errorVMs,
 	}
 
@@ -344,7 +378,7 @@ func (h *Handler) HandleDeleteRange(w http.ResponseWriter, r *http.Request) {
 
 // toLedgerEntryVM converts a store Entry to a view model.
 func toLedgerEntryVM(e ledgerstore.Entry) LedgerEntryVM {
-	return LedgerEntryVM{
+	vm := LedgerEntryVM{
 		ID:                 e.ID.Hex(),
 		RequestID:          e.RequestID,
 		TraceID:            e.TraceID,
@@ -357,11 +391,16 @@ func toLedgerEntryVM(e ledgerstore.Entry) LedgerEntryVM {
 		ActorType:          e.ActorType,
 		ActorID:            e.ActorID,
 		ActorName:          e.ActorName,
+		UserID:             e.UserID,
+		Game:               e.Game,
+		SampleRate:         e.SampleRate,
 		RequestBodySize:    e.RequestBodySize,
 		RequestBodyHash:    e.RequestBodyHash,
 		RequestBodyPreview: e.RequestBodyPreview,
 		RequestBody:        e.RequestBody,
 		RequestContentType: e.RequestContentType,
+		Captured:           e.Captured,
+		ResponseBody:       e.ResponseBody,
 		StatusCode:         e.StatusCode,
 		ResponseSize:       e.ResponseSize,
 		ErrorClass:         e.ErrorClass,
@@ -379,6 +418,11 @@ func toLedgerEntryVM(e ledgerstore.Entry) LedgerEntryVM {
 		Metadata:           e.Metadata,
 		StatusClass:        getStatusClass(e.StatusCode),
 	}
+	if e.CaptureExpiresAt != nil {
+		vm.CaptureExpiresAt = e.CaptureExpiresAt.Format("2006-01-02 15:04:05")
+		vm.CaptureExpiresISO = e.CaptureExpiresAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return vm
 }
 
 // getStatusClass returns a CSS class based on status code.
@@ -21,11 +21,18 @@ type LedgerEntryVM struct {
 	ActorType          string
 	ActorID            string
 	ActorName          string
+	UserID             string
+	Game               string
 	RequestBodySize    int64
 	RequestBodyHash    string
 	RequestBodyPreview string
-	RequestBody        string // Full body (only available on errors)
+	RequestBody        string // Full body (available on errors and in full capture)
 	RequestContentType string
+	Captured           bool   // Logged in full capture mode
+	SampleRate         int    // 1-in-N rate when a successful request was sampled
+	ResponseBody       string // Response body (full capture only, until it expires)
+	CaptureExpiresAt   string
+	CaptureExpiresISO  string // ISO 8601 format for JavaScript timezone conversion
 	StatusCode         int
 	ResponseSize       int64
 	ErrorClass         string
@@ -44,12 +51,21 @@ type LedgerEntryVM struct {
 	StatusClass        string // CSS class for status code
 }
 
+// APIKeyOptionVM is an API key in the ledger's key filter.
+type APIKeyOptionVM struct {
+	ID      string
+	Name    string
+	Revoked bool
+}
+
 // LedgerListVM is the view model for the ledger list page.
 type LedgerListVM struct {
 	viewdata.BaseVM
 	TimezoneGroups []timezones.ZoneGroup
 	Entries        []LedgerEntryVM
 	Filter         ledgerstore.ListFilter
+	APIKeys        []APIKeyOptionVM
+	APIKey         string // Selected API key ID
 	Page           int
 	TotalPages     int
 	TotalCount     int64
@@ -82,5 +98,6 @@ type LedgerStatsVM struct {
 	StatusBreakdown  []StatusBreakdownVM
 	TotalErrors      int64
 	AvgResponseTime  float64
+	Sampled          ledgerstore.SampleStats // Baseline from sampled successful requests
 	RecentErrors     []LedgerEntryVM
 }
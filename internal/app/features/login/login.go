@@ -6,6 +6,7 @@ package login
 //   - LoginID / loginID / login_id: The human-readable string users type to log in
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -13,17 +14,28 @@ import (
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
 	"github.com/dalemusser/stratasave/internal/app/store/activity"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/backupcodes"
 	"github.com/dalemusser/stratasave/internal/app/store/emailverify"
 	"github.com/dalemusser/stratasave/internal/app/store/passwordreset"
 	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
 	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/pwned"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
 	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/app/system/newdevice"
+	"github.com/dalemusser/stratasave/internal/app/system/suspicious"
+	"github.com/dalemusser/stratasave/internal/app/system/passwordexpiry"
+	"github.com/dalemusser/stratasave/internal/app/system/sessionrotate"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/query"
 	"github.com/dalemusser/waffle/pantry/templates"
@@ -40,7 +52,9 @@ type Handler struct {
 	emailVerifyStore   *emailverify.Store
 	passwordResetStore *passwordreset.Store
 	sessionsStore      *sessions.Store
+	settingsStore      *settingsstore.Store
 	activityStore      *activity.Store
+	backupCodes        *backupcodes.Store
 	rateLimitStore     *ratelimit.Store // nil if rate limiting disabled
 	sessionMgr         *auth.SessionManager
 	errLog             *errorsfeature.ErrorLogger
@@ -48,12 +62,18 @@ type Handler struct {
 	auditLogger        *auditlog.Logger
 	baseURL            string
 	emailVerifyExpiry  time.Duration
-	trustLoginEnabled  bool // Only enable in dev mode for security
+	resendInterval     time.Duration // Minimum time between login codes for an address
 	logger             *zap.Logger
+	breaches           *pwned.Checker       // nil if breached passwords are allowed
+	captcha            *captcha.Verifier    // nil if CAPTCHA is disabled
+	newDevices         *newdevice.Notifier  // nil if login devices aren't tracked
+	suspicious         *suspicious.Detector // nil if detection is off
+	passwordExpiry     passwordexpiry.Policy
+	rotator            *sessionrotate.Rotator
+	flags              *featureflags.Flags // trust login and self-registration
 }
 
 // NewHandler creates a new login Handler.
-// Set trustLoginEnabled to true only in development mode.
 // rateLimitStore can be nil to disable rate limiting.
 func NewHandler(
 	db *mongo.Database,
@@ -66,7 +86,6 @@ func NewHandler(
 	rateLimitStore *ratelimit.Store,
 	baseURL string,
 	emailVerifyExpiry time.Duration,
-	trustLoginEnabled bool,
 	logger *zap.Logger,
 ) *Handler {
 	// Use same expiry for password reset as email verification (default 10 minutes)
@@ -80,7 +99,9 @@ func NewHandler(
 		emailVerifyStore:   emailverify.New(db, emailVerifyExpiry),
 		passwordResetStore: passwordreset.New(db, passwordResetExpiry),
 		sessionsStore:      sessionsStore,
+		settingsStore:      settingsstore.New(db),
 		activityStore:      activityStore,
+		backupCodes:        backupcodes.New(db),
 		rateLimitStore:     rateLimitStore,
 		sessionMgr:         sessionMgr,
 		errLog:             errLog,
@@ -88,32 +109,89 @@ func NewHandler(
 		auditLogger:        auditLogger,
 		baseURL:            baseURL,
 		emailVerifyExpiry:  emailVerifyExpiry,
-		trustLoginEnabled:  trustLoginEnabled,
 		logger:             logger,
 	}
 }
 
+// SetBreachChecker enables rejecting reset passwords that appear in known
+// data breaches.
+func (h *Handler) SetBreachChecker(c *pwned.Checker) {
+	h.breaches = c
+}
+
+// SetCaptcha requires a CAPTCHA on the password and forgot-password forms.
+func (h *Handler) SetCaptcha(v *captcha.Verifier) {
+	h.captcha = v
+}
+
+// SetNewDeviceNotifier records the device behind each login and alerts the
+// user to logins from devices they haven't used before.
+func (h *Handler) SetNewDeviceNotifier(n *newdevice.Notifier) {
+	h.newDevices = n
+}
+
+// SetSuspiciousDetector reports failed and successful logins for
+// suspicious activity detection, and refuses logins from blocked IPs.
+func (h *Handler) SetSuspiciousDetector(d *suspicious.Detector) {
+	h.suspicious = d
+}
+
+// SetPasswordExpiry sends users whose password is older than the policy's
+// maximum age to change it right after logging in.
+func (h *Handler) SetPasswordExpiry(p passwordexpiry.Policy) {
+	h.passwordExpiry = p
+}
+
+// SetSessionRotator sets the rotator used to sign the user out everywhere
+// after they reset their password.
+func (h *Handler) SetSessionRotator(rt *sessionrotate.Rotator) {
+	h.rotator = rt
+}
+
+// SetFlags sets the feature flags that turn trust login and the signup
+// link on and off. Without them, trust login is off.
+func (h *Handler) SetFlags(f *featureflags.Flags) {
+	h.flags = f
+}
+
+// SetEmailCodePolicy limits how email login codes are issued and checked:
+// whether a new code replaces earlier ones, how often codes can be resent,
+// and how many wrong codes may be entered.
+func (h *Handler) SetEmailCodePolicy(p emailverify.Policy) {
+	h.emailVerifyStore.SetPolicy(p)
+	h.resendInterval = p.ResendInterval
+}
+
+// resendWaitMessage explains why another code wasn't sent.
+func (h *Handler) resendWaitMessage() string {
+	if h.resendInterval > time.Minute {
+		minutes := int((h.resendInterval + time.Minute - 1) / time.Minute)
+		return fmt.Sprintf("A code was sent less than %d minute(s) ago. Please check your email or wait before requesting another.", minutes)
+	}
+	return fmt.Sprintf("A code was sent less than %d second(s) ago. Please check your email or wait before requesting another.", int(h.resendInterval.Seconds()))
+}
+
 // LoginVM is the view model for the login page.
 type LoginVM struct {
 	viewdata.BaseVM
-	Error     string
-	LoginID   string
-	ReturnURL string
+	Error       string
+	LoginID     string
+	ReturnURL   string
+	CanRegister bool // Public signup is enabled
 }
 
 // Routes returns a chi.Router with login routes mounted.
 func Routes(h *Handler) http.Handler {
 	r := chi.NewRouter()
+	r.Use(h.refuseBlockedIPs)
 
 	r.Get("/", h.showLogin)
 	r.Post("/", h.handleLogin)
 
-	// Trust auth - only enable in development mode for security
-	// In production, these routes should not be accessible
-	if h.trustLoginEnabled {
-		r.Get("/trust", h.showTrustLogin)
-		r.Post("/trust", h.handleTrustLogin)
-	}
+	// Trust auth - behind the trust_login flag, which can't be turned on
+	// in production
+	r.With(h.flags.Require(featureflags.TrustLogin)).Get("/trust", h.showTrustLogin)
+	r.With(h.flags.Require(featureflags.TrustLogin)).Post("/trust", h.handleTrustLogin)
 
 	// Password auth
 	r.Get("/password", h.showPasswordLogin)
@@ -130,9 +208,38 @@ func Routes(h *Handler) http.Handler {
 	r.Post("/verify-email", h.handleVerifyEmailSubmit)
 	r.Post("/resend-code", h.handleResendCode)
 
+	// Backup codes, for email auth users who can't reach their inbox
+	r.Get("/backup-code", h.showBackupCode)
+	r.Post("/backup-code", h.handleBackupCode)
+
 	return r
 }
 
+// refuseBlockedIPs turns away form submissions from IPs that suspicious
+// activity detection has blocked, showing the login page with an error.
+func (h *Handler) refuseBlockedIPs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		blocked, until := h.suspicious.Blocked(r)
+		if !blocked {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.auditLogger.LogSecurityEvent(r, nil, audit.EventLoginBlockedIP, map[string]string{
+			"blocked_until": until.UTC().Format(time.RFC3339),
+		})
+		vm := LoginVM{
+			BaseVM: viewdata.New(r),
+			Error:  "Too many failed logins have come from your network. Please try again later.",
+		}
+		vm.Title = "Login"
+		templates.Render(w, r, "login/index", vm)
+	})
+}
+
 // showLogin displays the login page with login_id field.
 func (h *Handler) showLogin(w http.ResponseWriter, r *http.Request) {
 	// Map error codes to user-friendly messages
@@ -143,8 +250,12 @@ func (h *Handler) showLogin(w http.ResponseWriter, r *http.Request) {
 		errorMsg = "Invalid or expired link. Please try again."
 	case "account_disabled":
 		errorMsg = "Account is disabled."
+	case "account_pending":
+		errorMsg = "Your account is awaiting administrator approval."
 	case "service_unavailable":
 		errorMsg = "Service temporarily unavailable. Please try again."
+	case "google_not_linked":
+		errorMsg = "Google sign-in isn't connected to this account. Log in another way, then connect Google from your profile."
 	case "":
 		// No error
 	default:
@@ -158,6 +269,11 @@ func (h *Handler) showLogin(w http.ResponseWriter, r *http.Request) {
 		Error:         errorMsg,
 	}
 	vm.Title = "Login"
+	if h.mailer != nil {
+		if settings, err := h.settingsStore.Get(r.Context()); err == nil {
+			vm.CanRegister = settings.RegistrationEnabled && h.flags.EnabledFor(r, featureflags.SelfRegistration)
+		}
+	}
 
 	templates.Render(w, r, "login/index", vm)
 }
@@ -185,12 +301,13 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Look up user by login_id
-	user, err := h.userStore.GetByLoginID(r.Context(), loginID)
+	user, err := h.findLoginUser(r.Context(), loginID)
 	if err != nil {
 		// Distinguish between "user not found" and database errors
 		if err == mongo.ErrNoDocuments {
 			// User not found - show error
 			h.auditLogger.LoginFailedUserNotFound(r.Context(), r, loginID)
+			h.suspicious.LoginFailed(r, loginID)
 			vm := LoginVM{
 				BaseVM:        viewdata.New(r),
 						Error:         "User not found",
@@ -214,6 +331,19 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Status == status.Pending {
+		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_user_disabled", false, "awaiting approval")
+		vm := LoginVM{
+			BaseVM:    viewdata.New(r),
+			Error:     "Your account is awaiting administrator approval.",
+			LoginID:   loginID,
+			ReturnURL: returnURL,
+		}
+		vm.Title = "Login"
+		templates.Render(w, r, "login/index", vm)
+		return
+	}
+
 	if user.Status != "active" {
 		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_user_disabled", false, "user disabled")
 		vm := LoginVM{
@@ -233,7 +363,26 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		returnParam = "?return=" + returnURL
 	}
 
-	switch user.AuthMethod {
+	// Users with several sign-in methods choose one
+	method := r.FormValue("method")
+	if !user.HasMethod(method) {
+		if methods := user.Methods(); len(methods) > 1 {
+			vm := ChooseMethodVM{
+				BaseVM:    viewdata.New(r),
+				LoginID:   loginID,
+				ReturnURL: returnURL,
+			}
+			for _, m := range methods {
+				vm.Methods = append(vm.Methods, models.AuthMethod{Value: m, Label: models.AuthMethodLabel(m)})
+			}
+			vm.Title = "Login"
+			templates.Render(w, r, "login/choose", vm)
+			return
+		}
+		method = user.AuthMethod
+	}
+
+	switch method {
 	case "trust":
 		// Trust auth - log in immediately
 		if err := h.createTrackedSession(w, r, user.ID, user.Role); err != nil {
@@ -256,6 +405,15 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ChooseMethodVM is the view model for choosing how to log in, for users
+// with more than one sign-in method.
+type ChooseMethodVM struct {
+	viewdata.BaseVM
+	LoginID   string
+	ReturnURL string
+	Methods   []models.AuthMethod
+}
+
 // TrustLoginVM is the view model for trust login.
 type TrustLoginVM struct {
 	viewdata.BaseVM
@@ -283,7 +441,7 @@ func (h *Handler) handleTrustLogin(w http.ResponseWriter, r *http.Request) {
 
 	loginID := r.FormValue("login_id")
 
-	user, err := h.userStore.GetByLoginID(r.Context(), loginID)
+	user, err := h.findLoginUser(r.Context(), loginID)
 	if err != nil {
 		// Distinguish between "user not found" and database errors
 		if err == mongo.ErrNoDocuments {
@@ -337,6 +495,13 @@ type PasswordLoginVM struct {
 	Error     string
 	LoginID   string
 	ReturnURL string
+	Captcha   *captcha.Widget
+}
+
+// renderPasswordLogin renders the password form with the CAPTCHA widget, if enabled.
+func (h *Handler) renderPasswordLogin(w http.ResponseWriter, r *http.Request, vm PasswordLoginVM) {
+	vm.Captcha = h.captcha.Widget()
+	templates.Render(w, r, "login/password", vm)
 }
 
 // showPasswordLogin displays the password login form.
@@ -348,7 +513,7 @@ func (h *Handler) showPasswordLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	vm.Title = "Enter Password"
 
-	templates.Render(w, r, "login/password", vm)
+	h.renderPasswordLogin(w, r, vm)
 }
 
 // handlePasswordLogin processes password login.
@@ -385,12 +550,24 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 				LoginID:   loginID,
 				ReturnURL: returnURL,
 			}
-			templates.Render(w, r, "login/password", vm)
+			h.renderPasswordLogin(w, r, vm)
 			return
 		}
 	}
 
-	user, err := h.userStore.GetByLoginID(r.Context(), loginID)
+	if !h.captcha.Verify(r) {
+		h.auditLogger.LogAuthEvent(r, nil, "captcha_failed", false, "password login for "+loginID)
+		vm := PasswordLoginVM{
+			BaseVM:    viewdata.New(r),
+			Error:     "Please complete the CAPTCHA challenge.",
+			LoginID:   loginID,
+			ReturnURL: returnURL,
+		}
+		h.renderPasswordLogin(w, r, vm)
+		return
+	}
+
+	user, err := h.findLoginUser(r.Context(), loginID)
 	if err != nil {
 		// Distinguish between "user not found" and database errors
 		if err == mongo.ErrNoDocuments {
@@ -399,6 +576,7 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 				h.rateLimitStore.RecordFailure(r.Context(), loginID)
 			}
 			h.auditLogger.LoginFailedUserNotFound(r.Context(), r, loginID)
+			h.suspicious.LoginFailed(r, loginID)
 
 			vm := PasswordLoginVM{
 				BaseVM:    viewdata.New(r),
@@ -406,7 +584,7 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 				LoginID:   loginID,
 				ReturnURL: returnURL,
 			}
-			templates.Render(w, r, "login/password", vm)
+			h.renderPasswordLogin(w, r, vm)
 			return
 		}
 		// Database error
@@ -417,7 +595,7 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 			LoginID:   loginID,
 			ReturnURL: returnURL,
 		}
-		templates.Render(w, r, "login/password", vm)
+		h.renderPasswordLogin(w, r, vm)
 		return
 	}
 
@@ -433,11 +611,13 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 			Error:   "Account is disabled",
 			LoginID: loginID,
 		}
-		templates.Render(w, r, "login/password", vm)
+		h.renderPasswordLogin(w, r, vm)
 		return
 	}
 
 	if user.PasswordHash == nil || !authutil.CheckPassword(password, *user.PasswordHash) {
+		h.suspicious.LoginFailed(r, loginID)
+
 		// Record failure for rate limiting
 		if h.rateLimitStore != nil {
 			lockedOut, lockedUntil := h.rateLimitStore.RecordFailure(r.Context(), loginID)
@@ -458,7 +638,7 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 					LoginID:   loginID,
 					ReturnURL: returnURL,
 				}
-				templates.Render(w, r, "login/password", vm)
+				h.renderPasswordLogin(w, r, vm)
 				return
 			}
 		}
@@ -469,7 +649,7 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 			Error:   "Invalid credentials",
 			LoginID: loginID,
 		}
-		templates.Render(w, r, "login/password", vm)
+		h.renderPasswordLogin(w, r, vm)
 		return
 	}
 
@@ -492,6 +672,11 @@ func (h *Handler) handlePasswordLogin(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/profile/change-password?required=1", http.StatusSeeOther)
 		return
 	}
+	if h.passwordExpiry.Expired(user, time.Now()) {
+		h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventPasswordExpired, true, "")
+		http.Redirect(w, r, "/profile/change-password?required=expired", http.StatusSeeOther)
+		return
+	}
 
 	http.Redirect(w, r, urlutil.SafeReturn(returnURL, "", "/dashboard"), http.StatusSeeOther)
 }
@@ -502,6 +687,13 @@ type ForgotPasswordVM struct {
 	Error   string
 	Success string
 	LoginID string
+	Captcha *captcha.Widget
+}
+
+// renderForgotPassword renders the forgot password form with the CAPTCHA widget, if enabled.
+func (h *Handler) renderForgotPassword(w http.ResponseWriter, r *http.Request, vm ForgotPasswordVM) {
+	vm.Captcha = h.captcha.Widget()
+	templates.Render(w, r, "login/forgot_password", vm)
 }
 
 // showForgotPassword displays the forgot password form.
@@ -511,7 +703,7 @@ func (h *Handler) showForgotPassword(w http.ResponseWriter, r *http.Request) {
 	}
 	vm.Title = "Forgot Password"
 
-	templates.Render(w, r, "login/forgot_password", vm)
+	h.renderForgotPassword(w, r, vm)
 }
 
 // handleForgotPassword sends a password reset email.
@@ -537,30 +729,42 @@ func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 			Error:  "Please enter your Login ID",
 		}
 		vm.Title = "Forgot Password"
-		templates.Render(w, r, "login/forgot_password", vm)
+		h.renderForgotPassword(w, r, vm)
+		return
+	}
+
+	if !h.captcha.Verify(r) {
+		h.auditLogger.LogAuthEvent(r, nil, "captcha_failed", false, "password reset for "+loginID)
+		vm := ForgotPasswordVM{
+			BaseVM:  viewdata.New(r),
+			LoginID: loginID,
+			Error:   "Please complete the CAPTCHA challenge.",
+		}
+		vm.Title = "Forgot Password"
+		h.renderForgotPassword(w, r, vm)
 		return
 	}
 
 	// Look up user by login_id
-	user, err := h.userStore.GetByLoginID(r.Context(), loginID)
+	user, err := h.findLoginUser(r.Context(), loginID)
 	if err != nil {
 		// User not found - still show success to avoid enumeration
 		h.auditLogger.LogAuthEvent(r, nil, "password_reset_requested", true, "user not found")
-		templates.Render(w, r, "login/forgot_password", successVM)
+		h.renderForgotPassword(w, r, successVM)
 		return
 	}
 
 	if user.Status != "active" {
 		// Disabled user - still show success
 		h.auditLogger.LogAuthEvent(r, &user.ID, "password_reset_requested", false, "user disabled")
-		templates.Render(w, r, "login/forgot_password", successVM)
+		h.renderForgotPassword(w, r, successVM)
 		return
 	}
 
-	// Only allow password reset for password auth users
-	if user.AuthMethod != "password" && user.AuthMethod != "" {
+	// Only allow password reset for users who log in with a password
+	if !user.HasMethod("password") && user.AuthMethod != "" {
 		h.auditLogger.LogAuthEvent(r, &user.ID, "password_reset_requested", false, "not password auth")
-		templates.Render(w, r, "login/forgot_password", successVM)
+		h.renderForgotPassword(w, r, successVM)
 		return
 	}
 
@@ -573,7 +777,7 @@ func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 			Error:   "Your account does not have an email address on file. Please contact an administrator to reset your password.",
 		}
 		vm.Title = "Forgot Password"
-		templates.Render(w, r, "login/forgot_password", vm)
+		h.renderForgotPassword(w, r, vm)
 		return
 	}
 
@@ -581,7 +785,7 @@ func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 	reset, err := h.passwordResetStore.Create(r.Context(), user.ID, *user.Email)
 	if err != nil {
 		h.errLog.Log(r, "failed to create password reset", err)
-		templates.Render(w, r, "login/forgot_password", successVM)
+		h.renderForgotPassword(w, r, successVM)
 		return
 	}
 
@@ -593,13 +797,17 @@ func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 			expiryMin = 10 // default
 		}
 		textBody, htmlBody := mailer.PasswordResetEmail(mailer.PasswordResetEmailData{
+			Locale:    user.Locale,
+			Brand:     h.mailer.Brand(r.Context()),
 			AppName:   h.mailer.FromName(),
 			ResetURL:  resetURL,
 			ExpiryMin: expiryMin,
 		})
 		err = h.mailer.Send(mailer.Email{
 			To:       *user.Email,
-			Subject:  "Password Reset Request",
+			Subject:  mailer.T(user.Locale, "password_reset.subject"),
+			Template: "password_reset",
+			UserID:   user.ID.Hex(),
 			TextBody: textBody,
 			HTMLBody: htmlBody,
 		})
@@ -610,7 +818,7 @@ func (h *Handler) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
 
 	h.auditLogger.LogAuthEvent(r, &user.ID, "password_reset_requested", true, "")
 
-	templates.Render(w, r, "login/forgot_password", successVM)
+	h.renderForgotPassword(w, r, successVM)
 }
 
 // ResetPasswordVM is the view model for reset password.
@@ -705,6 +913,17 @@ func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.breaches.IsBreached(r.Context(), password) {
+		vm := ResetPasswordVM{
+			BaseVM: viewdata.New(r),
+			Token:  token,
+			Error:  authutil.ErrPasswordBreached.Error(),
+		}
+		vm.Title = "Reset Password"
+		templates.Render(w, r, "login/reset_password", vm)
+		return
+	}
+
 	// Hash new password
 	hash, err := authutil.HashPassword(password)
 	if err != nil {
@@ -735,18 +954,27 @@ func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
 	// Mark reset token as used
 	h.passwordResetStore.MarkUsed(r.Context(), reset.ID)
 
+	if err := h.rotator.Rotate(w, r, reset.UserID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
 	h.auditLogger.LogAuthEvent(r, &reset.UserID, "password_reset_completed", true, "")
 
 	// Send password changed confirmation email
 	if h.mailer != nil {
 		loginURL := h.baseURL + "/login"
+		locale := h.userLocale(r.Context(), reset.UserID)
 		textBody, htmlBody := mailer.PasswordChangedEmail(mailer.PasswordChangedEmailData{
+			Locale:   locale,
+			Brand:    h.mailer.Brand(r.Context()),
 			AppName:  h.mailer.FromName(),
 			LoginURL: loginURL,
 		})
 		err = h.mailer.Send(mailer.Email{
 			To:       reset.Email,
-			Subject:  "Your Password Has Been Changed",
+			Subject:  mailer.T(locale, "password_changed.subject"),
+			Template: "password_changed",
+			UserID:   reset.UserID.Hex(),
 			TextBody: textBody,
 			HTMLBody: htmlBody,
 		})
@@ -764,6 +992,16 @@ func (h *Handler) handleResetPassword(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "login/reset_password", vm)
 }
 
+// findLoginUser looks up the user signing in by login ID. Deleted accounts
+// are reported as not found, so they can't sign in or reset their password.
+func (h *Handler) findLoginUser(ctx context.Context, loginID string) (*models.User, error) {
+	user, err := h.userStore.GetByLoginID(ctx, loginID)
+	if err == nil && user.Status == status.Deleted {
+		return nil, mongo.ErrNoDocuments
+	}
+	return user, err
+}
+
 // createTrackedSession creates a session in both the cookie and MongoDB for tracking.
 func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID, role string) error {
 	// Generate token first so we can use it for both cookie and MongoDB tracking
@@ -786,7 +1024,7 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		UserAgent:    r.UserAgent(),
 		LoginAt:      now,
 		LastActivity: now,
-		ExpiresAt:    now.Add(24 * 30 * time.Hour), // 30 days
+		ExpiresAt:    now.Add(h.sessionMgr.Lifetime(role).Absolute),
 	}
 
 	// Best effort - don't fail login if tracking fails
@@ -796,6 +1034,13 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 		h.logger.Warn("failed to track session", zap.Error(err))
 	}
 
+	if err := h.userStore.RecordLogin(r.Context(), userID, now); err != nil {
+		h.logger.Warn("failed to record last login", zap.Error(err))
+	}
+
+	h.newDevices.Check(r, userID)
+	h.suspicious.LoginSucceeded(r, userID)
+
 	return nil
 }
 
@@ -803,6 +1048,16 @@ func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, u
 | Email verification flow (StrataHub-style)                                    |
 *─────────────────────────────────────────────────────────────────────────────*/
 
+// userLocale returns the email language for a user, or "" (the default
+// language) if the user can't be loaded.
+func (h *Handler) userLocale(ctx context.Context, userID primitive.ObjectID) string {
+	user, err := h.userStore.GetByID(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	return user.Locale
+}
+
 // startEmailFlow creates a verification code/token and sends the email.
 // This is called from handleLogin when user's auth_method is "email".
 func (h *Handler) startEmailFlow(w http.ResponseWriter, r *http.Request, user *models.User, returnURL string) {
@@ -826,8 +1081,10 @@ func (h *Handler) startEmailFlow(w http.ResponseWriter, r *http.Request, user *m
 	}
 
 	// Create verification record
+	// If a code was sent moments ago (the resend interval hasn't passed),
+	// don't send another; the user goes on to enter the one they have.
 	verification, err := h.emailVerifyStore.Create(r.Context(), email, user.ID)
-	if err != nil {
+	if err != nil && err != emailverify.ErrTooSoon {
 		h.errLog.Log(r, "failed to create email verification", err)
 		vm := LoginVM{
 			BaseVM:        viewdata.New(r),
@@ -841,27 +1098,33 @@ func (h *Handler) startEmailFlow(w http.ResponseWriter, r *http.Request, user *m
 	}
 
 	// Send email with code and magic link
-	if h.mailer != nil {
-		magicURL := h.baseURL + "/login/verify-email?token=" + verification.Token
-		textBody, htmlBody := mailer.LoginCodeEmail(mailer.LoginCodeEmailData{
-			AppName:  h.mailer.FromName(),
-			Code:     verification.Code,
-			MagicURL: magicURL,
-		})
-		err = h.mailer.Send(mailer.Email{
-			To:       email,
-			Subject:  "Your Login Code",
-			TextBody: textBody,
-			HTMLBody: htmlBody,
-		})
-		if err != nil {
-			h.errLog.Log(r, "failed to send verification email", err)
-			// Continue anyway - user can request resend
+	if verification != nil {
+		if h.mailer != nil {
+			magicURL := h.baseURL + "/login/verify-email?token=" + verification.Token
+			textBody, htmlBody := mailer.LoginCodeEmail(mailer.LoginCodeEmailData{
+				Locale:   user.Locale,
+				Brand:    h.mailer.Brand(r.Context()),
+				AppName:  h.mailer.FromName(),
+				Code:     verification.Code,
+				MagicURL: magicURL,
+			})
+			err = h.mailer.Send(mailer.Email{
+				To:       email,
+				Subject:  mailer.T(user.Locale, "login_code.subject"),
+				Template: "login_code",
+				UserID:   user.ID.Hex(),
+				TextBody: textBody,
+				HTMLBody: htmlBody,
+			})
+			if err != nil {
+				h.errLog.Log(r, "failed to send verification email", err)
+				// Continue anyway - user can request resend
+			}
 		}
-	}
 
-	h.logger.Info("verification email sent", zap.String("email", email), zap.String("user_id", user.ID.Hex()))
-	h.auditLogger.LogAuthEvent(r, &user.ID, "verification_code_sent", true, "")
+		h.logger.Info("verification email sent", zap.String("email", email), zap.String("user_id", user.ID.Hex()))
+		h.auditLogger.LogAuthEvent(r, &user.ID, "verification_code_sent", true, "")
+	}
 
 	// Store pending email login in session
 	sess, err := h.sessionMgr.GetSession(r)
@@ -1068,7 +1331,7 @@ func (h *Handler) handleVerifyEmailSubmit(w http.ResponseWriter, r *http.Request
 		h.auditLogger.LogAuthEvent(r, nil, "verification_code_failed", false, "invalid code")
 		vm := VerifyEmailVM{
 			BaseVM:    viewdata.New(r),
-			Error:     "Invalid or expired verification code. Please try again.",
+			Error:     "Invalid or expired verification code. Please try again, or request a new code.",
 			LoginID:   pendingLoginID,
 			Email:     pendingEmail,
 			ReturnURL: returnURL,
@@ -1170,6 +1433,18 @@ func (h *Handler) handleResendCode(w http.ResponseWriter, r *http.Request) {
 
 	// Create new verification record
 	verification, err := h.emailVerifyStore.Create(r.Context(), pendingEmail, userID)
+	if err == emailverify.ErrTooSoon {
+		vm := VerifyEmailVM{
+			BaseVM:    viewdata.New(r),
+			Error:     h.resendWaitMessage(),
+			LoginID:   pendingLoginID,
+			Email:     pendingEmail,
+			ReturnURL: returnURL,
+		}
+		vm.Title = "Check Your Email"
+		templates.Render(w, r, "login/verify_email", vm)
+		return
+	}
 	if err != nil {
 		h.errLog.Log(r, "failed to create email verification for resend", err)
 		vm := VerifyEmailVM{
@@ -1187,14 +1462,19 @@ func (h *Handler) handleResendCode(w http.ResponseWriter, r *http.Request) {
 	// Send email with code and magic link
 	if h.mailer != nil {
 		magicURL := h.baseURL + "/login/verify-email?token=" + verification.Token
+		locale := h.userLocale(r.Context(), userID)
 		textBody, htmlBody := mailer.LoginCodeEmail(mailer.LoginCodeEmailData{
+			Locale:   locale,
+			Brand:    h.mailer.Brand(r.Context()),
 			AppName:  h.mailer.FromName(),
 			Code:     verification.Code,
 			MagicURL: magicURL,
 		})
 		err = h.mailer.Send(mailer.Email{
 			To:       pendingEmail,
-			Subject:  "Your Login Code",
+			Subject:  mailer.T(locale, "login_code.subject"),
+			Template: "login_code",
+			UserID:   userID.Hex(),
 			TextBody: textBody,
 			HTMLBody: htmlBody,
 		})
@@ -1219,3 +1499,142 @@ func (h *Handler) handleResendCode(w http.ResponseWriter, r *http.Request) {
 	// Redirect back to verify page with success indicator
 	http.Redirect(w, r, "/login/verify-email?resent=1", http.StatusSeeOther)
 }
+
+/*─────────────────────────────────────────────────────────────────────────────*
+| Backup code recovery                                                         |
+*─────────────────────────────────────────────────────────────────────────────*/
+
+// BackupCodeVM is the view model for the backup code page.
+type BackupCodeVM struct {
+	viewdata.BaseVM
+	Error   string
+	LoginID string
+}
+
+// renderBackupCode renders the backup code form.
+func renderBackupCode(w http.ResponseWriter, r *http.Request, loginID, errMsg string) {
+	vm := BackupCodeVM{
+		BaseVM:  viewdata.New(r),
+		Error:   errMsg,
+		LoginID: loginID,
+	}
+	vm.Title = "Use a Backup Code"
+	templates.Render(w, r, "login/backup_code", vm)
+}
+
+// showBackupCode displays the backup code form. It follows on from the email
+// verification page, so it needs the pending login that page started.
+// GET /login/backup-code
+func (h *Handler) showBackupCode(w http.ResponseWriter, r *http.Request) {
+	sess, err := h.sessionMgr.GetSession(r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	pendingUserID, _ := sess.Values["pending_user_id"].(string)
+	pendingLoginID, _ := sess.Values["pending_login_id"].(string)
+	if pendingUserID == "" {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	renderBackupCode(w, r, pendingLoginID, "")
+}
+
+// handleBackupCode redeems a backup code and completes the pending login.
+// Failed codes count toward the login ID's rate limit, the same as wrong
+// passwords.
+// POST /login/backup-code
+func (h *Handler) handleBackupCode(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	sess, err := h.sessionMgr.GetSession(r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	pendingUserID, _ := sess.Values["pending_user_id"].(string)
+	pendingLoginID, _ := sess.Values["pending_login_id"].(string)
+	returnURL, _ := sess.Values["pending_return_url"].(string)
+	userID, err := primitive.ObjectIDFromHex(pendingUserID)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if h.rateLimitStore != nil {
+		if allowed, _, _ := h.rateLimitStore.CheckAllowed(r.Context(), pendingLoginID); !allowed {
+			h.auditLogger.LogAuthEvent(r, &userID, audit.EventLoginRateLimited, false, "rate limit exceeded for "+pendingLoginID)
+			renderBackupCode(w, r, pendingLoginID, "Too many failed login attempts. Please try again later.")
+			return
+		}
+	}
+
+	code := strings.TrimSpace(r.FormValue("code"))
+	if code == "" {
+		renderBackupCode(w, r, pendingLoginID, "Please enter a backup code.")
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), userID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		h.errLog.Log(r, "database error during backup code user lookup", err)
+		renderBackupCode(w, r, pendingLoginID, "Service temporarily unavailable. Please try again.")
+		return
+	}
+
+	if user.Status != "active" {
+		h.auditLogger.LogAuthEvent(r, &user.ID, "login_failed_user_disabled", false, "user disabled")
+		renderBackupCode(w, r, pendingLoginID, "Account is disabled.")
+		return
+	}
+
+	ok, err := h.backupCodes.Redeem(r.Context(), user.ID, code)
+	if err != nil {
+		h.errLog.Log(r, "failed to redeem backup code", err)
+		renderBackupCode(w, r, pendingLoginID, "Service temporarily unavailable. Please try again.")
+		return
+	}
+	if !ok {
+		if h.rateLimitStore != nil {
+			if lockedOut, _ := h.rateLimitStore.RecordFailure(r.Context(), pendingLoginID); lockedOut {
+				h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventLoginLockedOut, false, "too many failed attempts")
+			}
+		}
+		h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventBackupCodeFailed, false, "invalid code")
+		renderBackupCode(w, r, pendingLoginID, "Invalid or already used backup code.")
+		return
+	}
+
+	if h.rateLimitStore != nil {
+		h.rateLimitStore.ClearOnSuccess(r.Context(), pendingLoginID)
+	}
+
+	// Clear pending state from session
+	delete(sess.Values, "pending_user_id")
+	delete(sess.Values, "pending_login_id")
+	delete(sess.Values, "pending_email")
+	delete(sess.Values, "pending_return_url")
+	sess.Save(r, w)
+
+	h.logger.Info("user logged in via backup code", zap.String("user_id", user.ID.Hex()))
+	h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventBackupCodeUsed, true, "")
+
+	if err := h.createTrackedSession(w, r, user.ID, user.Role); err != nil {
+		h.errLog.Log(r, "failed to create session", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, urlutil.SafeReturn(returnURL, "", "/dashboard"), http.StatusSeeOther)
+}
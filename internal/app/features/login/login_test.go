@@ -11,6 +11,7 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.uber.org/zap"
 )
@@ -404,7 +405,6 @@ func TestNewHandler(t *testing.T) {
 		nil, // rateLimitStore (nil = disabled)
 		"http://localhost:8080",
 		10*time.Minute,
-		false, // trustLoginEnabled
 		logger,
 	)
 
@@ -414,33 +414,31 @@ func TestNewHandler(t *testing.T) {
 	if h.baseURL != "http://localhost:8080" {
 		t.Errorf("baseURL = %q, want %q", h.baseURL, "http://localhost:8080")
 	}
-	if h.trustLoginEnabled {
-		t.Error("trustLoginEnabled should be false")
+	if h.flags != nil {
+		t.Error("flags should be unset")
 	}
 }
 
-func TestRoutes_TrustLoginEnabled(t *testing.T) {
+func TestRoutes_TrustLoginFlag(t *testing.T) {
 	db := testutil.SetupTestDB(t)
+	testutil.MustBootTemplates(t)
 	logger := zap.NewNop()
 
-	// Test with trust login enabled
-	h := NewHandler(db, nil, nil, nil, nil, nil, nil, nil, "", 0, true, logger)
-	routes := Routes(h)
-
-	if routes == nil {
-		t.Error("Routes() returned nil")
+	tests := []struct {
+		env        string
+		wantStatus int
+	}{
+		{"dev", http.StatusOK},
+		{"prod", http.StatusNotFound},
 	}
-}
-
-func TestRoutes_TrustLoginDisabled(t *testing.T) {
-	db := testutil.SetupTestDB(t)
-	logger := zap.NewNop()
-
-	// Test with trust login disabled
-	h := NewHandler(db, nil, nil, nil, nil, nil, nil, nil, "", 0, false, logger)
-	routes := Routes(h)
+	for _, tt := range tests {
+		h := NewHandler(db, nil, nil, nil, nil, nil, nil, nil, "", 0, logger)
+		h.SetFlags(featureflags.New(db, tt.env, logger))
 
-	if routes == nil {
-		t.Error("Routes() returned nil")
+		rec := httptest.NewRecorder()
+		Routes(h).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trust", nil))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("GET /trust in %s: status = %d, want %d", tt.env, rec.Code, tt.wantStatus)
+		}
 	}
 }
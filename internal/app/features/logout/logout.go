@@ -8,6 +8,7 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -46,7 +47,15 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 // handleLogout terminates the session.
 func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	if user, ok := auth.CurrentUser(r); ok {
-		h.auditLogger.Logout(r.Context(), r, user.ID)
+		if user.IsImpersonated() {
+			// The admin is the one signing out; the impersonation ends with them
+			if adminID, err := primitive.ObjectIDFromHex(user.ImpersonatorID); err == nil {
+				h.auditLogger.ImpersonationEnded(r.Context(), r, adminID, user.UserID(), "logout")
+			}
+			h.auditLogger.Logout(r.Context(), r, user.ImpersonatorID)
+		} else {
+			h.auditLogger.Logout(r.Context(), r, user.ID)
+		}
 
 		// Close session in MongoDB tracking (preserves for audit, records duration)
 		// Note: Logout time is captured in the session record (logout_at), so we don't need
@@ -0,0 +1,241 @@
+// internal/app/features/outbox/handler.go
+package outboxfeature
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	filesfeature "github.com/dalemusser/stratasave/internal/app/features/files"
+	outboxstore "github.com/dalemusser/stratasave/internal/app/store/outbox"
+	"github.com/dalemusser/stratasave/internal/app/system/emailoutbox"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// statuses lists message statuses in the order they are shown.
+var statuses = []string{
+	outboxstore.StatusQueued,
+	outboxstore.StatusRetrying,
+	outboxstore.StatusSent,
+	outboxstore.StatusFailed,
+}
+
+// Handler handles email outbox HTTP requests.
+type Handler struct {
+	DB     *mongo.Database
+	Outbox *emailoutbox.Outbox // nil when the email queue is disabled
+	ErrLog *errorsfeature.ErrorLogger
+	Log    *zap.Logger
+}
+
+// NewHandler creates a new outbox handler. outbox may be nil, in which case
+// the pages still show past messages but failed ones cannot be retried.
+func NewHandler(db *mongo.Database, outbox *emailoutbox.Outbox, errLog *errorsfeature.ErrorLogger, logger *zap.Logger) *Handler {
+	return &Handler{
+		DB:     db,
+		Outbox: outbox,
+		ErrLog: errLog,
+		Log:    logger,
+	}
+}
+
+// ServeList handles GET /email-outbox - list queued and sent emails.
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter := outboxstore.ListFilter{
+		Status: r.URL.Query().Get("status"),
+		To:     r.URL.Query().Get("to"),
+	}
+
+	store := outboxstore.New(h.DB)
+	result, err := store.List(ctx, filter, page, 50)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load outbox", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	counts, err := store.CountByStatus(ctx)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to count outbox messages", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	countVMs := make([]StatusCountVM, len(statuses))
+	for i, s := range statuses {
+		countVMs[i] = StatusCountVM{Status: s, Count: counts[s], StatusClass: getStatusClass(s)}
+	}
+
+	msgVMs := make([]MessageVM, len(result.Messages))
+	for i, m := range result.Messages {
+		msgVMs[i] = toMessageVM(m)
+	}
+
+	prevPage := result.Page - 1
+	if prevPage < 1 {
+		prevPage = 1
+	}
+	nextPage := result.Page + 1
+	if nextPage > result.TotalPages {
+		nextPage = result.TotalPages
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Email Outbox", "/dashboard")
+	data := ListVM{
+		BaseVM:       base,
+		QueueEnabled: h.Outbox != nil,
+		Counts:       countVMs,
+		Messages:     msgVMs,
+		Filter:       filter,
+		Page:         result.Page,
+		TotalPages:   result.TotalPages,
+		TotalCount:   result.TotalCount,
+		PrevPage:     prevPage,
+		NextPage:     nextPage,
+	}
+
+	if r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Target") == "outbox-table" {
+		templates.RenderSnippet(w, "outbox_table", data)
+		return
+	}
+
+	templates.Render(w, r, "outbox/list", data)
+}
+
+// ServeDetail handles GET /email-outbox/{id} - view one email and its delivery status.
+func (h *Handler) ServeDetail(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	msg, err := outboxstore.New(h.DB).GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, outboxstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to load outbox message", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Email Details", "/email-outbox")
+	data := DetailVM{
+		BaseVM:       base,
+		QueueEnabled: h.Outbox != nil,
+		Message:      toMessageVM(*msg),
+	}
+
+	templates.Render(w, r, "outbox/detail", data)
+}
+
+// HandleRetry handles POST /email-outbox/{id}/retry - requeue a failed email.
+func (h *Handler) HandleRetry(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	idStr := chi.URLParam(r, "id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	if h.Outbox == nil {
+		http.Error(w, "Email queue is disabled", http.StatusConflict)
+		return
+	}
+
+	if err := h.Outbox.Retry(ctx, id); err != nil {
+		if errors.Is(err, outboxstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to retry email", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Log.Info("email requeued", zap.String("message_id", idStr))
+
+	w.Header().Set("HX-Redirect", "/email-outbox/"+idStr)
+	w.WriteHeader(http.StatusOK)
+}
+
+// toMessageVM converts a store Message to a view model.
+func toMessageVM(m outboxstore.Message) MessageVM {
+	vm := MessageVM{
+		ID:          m.ID.Hex(),
+		To:          m.To,
+		Subject:     m.Subject,
+		TextBody:    m.TextBody,
+		HTMLBody:    m.HTMLBody,
+		Status:      m.Status,
+		Attempts:    m.Attempts,
+		MaxAttempts: m.MaxAttempts,
+		LastError:   m.LastError,
+		CreatedAt:   m.CreatedAt.Format("2006-01-02 15:04:05"),
+		StatusClass: getStatusClass(m.Status),
+	}
+	for _, a := range m.Attachments {
+		vm.Attachments = append(vm.Attachments, AttachmentVM{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Size:        filesfeature.FormatFileSize(int64(len(a.Data))),
+			Inline:      a.ContentID != "",
+		})
+	}
+	if m.Status == outboxstore.StatusFailed && (m.TextBody != "" || m.HTMLBody != "") {
+		// Kept so the message can be retried, but not shown: a failed
+		// message may sit in the outbox indefinitely.
+		vm.TextBody, vm.HTMLBody = "", ""
+		vm.BodyWithheld = true
+	}
+	if !m.JobID.IsZero() {
+		vm.JobID = m.JobID.Hex()
+	}
+	if m.NextAttemptAt != nil {
+		vm.NextAttemptAt = m.NextAttemptAt.Format("2006-01-02 15:04:05")
+	}
+	if m.SentAt != nil {
+		vm.SentAt = m.SentAt.Format("2006-01-02 15:04:05")
+	}
+	return vm
+}
+
+// getStatusClass returns a CSS class based on message status.
+func getStatusClass(status string) string {
+	switch status {
+	case outboxstore.StatusQueued:
+		return "bg-yellow-100 text-yellow-800 dark:bg-yellow-900/40 dark:text-yellow-400"
+	case outboxstore.StatusRetrying:
+		return "bg-orange-100 text-orange-800 dark:bg-orange-900/40 dark:text-orange-400"
+	case outboxstore.StatusSent:
+		return "bg-green-100 text-green-800 dark:bg-green-900/40 dark:text-green-400"
+	case outboxstore.StatusFailed:
+		return "bg-red-100 text-red-800 dark:bg-red-900/40 dark:text-red-400"
+	default:
+		return "bg-gray-100 text-gray-700 dark:bg-gray-600 dark:text-gray-300"
+	}
+}
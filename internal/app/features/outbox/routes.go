@@ -0,0 +1,26 @@
+// internal/app/features/outbox/routes.go
+package outboxfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the email outbox feature.
+// The list is open to admin and developer roles. Message details, which
+// include bodies that may carry sign-in and reset links, and retrying are
+// restricted to admins.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin", "developer"))
+
+	r.Get("/", h.ServeList)
+
+	r.Group(func(r chi.Router) {
+		r.Use(sm.RequireRole("admin"))
+		r.Get("/{id}", h.ServeDetail)
+		r.Post("/{id}/retry", h.HandleRetry)
+	})
+
+	return r
+}
@@ -0,0 +1,19 @@
+// internal/app/features/outbox/templates.go
+package outboxfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "outbox",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
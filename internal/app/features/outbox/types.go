@@ -0,0 +1,63 @@
+// internal/app/features/outbox/types.go
+package outboxfeature
+
+import (
+	outboxstore "github.com/dalemusser/stratasave/internal/app/store/outbox"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// StatusCountVM is the number of messages in one status.
+type StatusCountVM struct {
+	Status      string
+	Count       int64
+	StatusClass string
+}
+
+// MessageVM is the view model for a single outbox message.
+type MessageVM struct {
+	ID            string
+	To            string
+	Subject       string
+	TextBody      string
+	HTMLBody      string
+	BodyWithheld  bool // Failed message; the body is kept only for retrying
+	Attachments   []AttachmentVM
+	Status        string
+	Attempts      int
+	MaxAttempts   int
+	LastError     string
+	JobID         string
+	NextAttemptAt string
+	SentAt        string
+	CreatedAt     string
+	StatusClass   string // CSS class for status badge
+}
+
+// AttachmentVM describes a file attached to a message.
+type AttachmentVM struct {
+	Filename    string
+	ContentType string
+	Size        string
+	Inline      bool
+}
+
+// ListVM is the view model for the outbox list page.
+type ListVM struct {
+	viewdata.BaseVM
+	QueueEnabled bool
+	Counts       []StatusCountVM
+	Messages     []MessageVM
+	Filter       outboxstore.ListFilter
+	Page         int
+	TotalPages   int
+	TotalCount   int64
+	PrevPage     int
+	NextPage     int
+}
+
+// DetailVM is the view model for the message detail page.
+type DetailVM struct {
+	viewdata.BaseVM
+	QueueEnabled bool
+	Message      MessageVM
+}
@@ -0,0 +1,340 @@
+// internal/app/features/pages/custom.go
+package pages
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pagestore "github.com/dalemusser/stratasave/internal/app/store/pages"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/htmlsanitize"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxNavOrder bounds a custom page's menu position either way.
+const maxNavOrder = 10000
+
+// CustomPageVM is the view model for creating a custom page or changing
+// its slug and menu settings.
+type CustomPageVM struct {
+	viewdata.BaseVM
+	IsNew     bool
+	OldSlug   string // slug before the change, when editing settings
+	Slug      string
+	PageTitle string // new pages only; afterwards it is edited with the content
+	ShowInNav bool
+	NavLabel  string
+	NavOrder  int
+	Error     string
+}
+
+// Key returns the page's current slug for use in a URL path segment.
+func (vm CustomPageVM) Key() string { return pageKey(vm.OldSlug) }
+
+// customPageRow is a custom page in the pages list.
+type customPageRow struct {
+	Slug      string
+	Key       string
+	Title     string
+	Published bool
+	HasDraft  bool
+	ShowInNav bool
+	NavLabel  string
+	NavOrder  int
+}
+
+// SetReservedRoutes records the first path segment of every route the app
+// serves, such as "login" or "console", so custom pages can't be given
+// slugs under them. Call it once the router is built.
+func (h *Handler) SetReservedRoutes(routes chi.Routes) {
+	reserved := make(map[string]bool)
+	_ = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		first, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if first != "" && !strings.ContainsAny(first, "{*") {
+			reserved[first] = true
+		}
+		return nil
+	})
+	h.reserved = reserved
+}
+
+// NavLinks returns the published custom pages linked in the site menu.
+func (h *Handler) NavLinks(ctx context.Context) []viewdata.NavLinkVM {
+	pages, err := h.pageStore.ListNav(ctx)
+	if err != nil {
+		h.logger.Warn("failed to load menu pages", zap.Error(err))
+		return nil
+	}
+	links := make([]viewdata.NavLinkVM, 0, len(pages))
+	for _, p := range pages {
+		links = append(links, viewdata.NavLinkVM{Label: p.Label(), URL: "/" + p.Slug})
+	}
+	return links
+}
+
+// CustomPages returns a handler that serves published custom pages at
+// their slugs and passes every other request to notFound. Mounted as the
+// router's not-found handler, custom pages can never hide a route.
+func (h *Handler) CustomPages(notFound http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := strings.TrimPrefix(r.URL.Path, "/")
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || !models.IsValidCustomPageSlug(slug) {
+			notFound(w, r)
+			return
+		}
+
+		page, err := h.pageStore.GetBySlug(r.Context(), slug)
+		if err != nil && err != mongo.ErrNoDocuments {
+			h.errLog.Log(r, "failed to get custom page", err)
+		}
+		if err != nil || !page.Custom || !page.Published() {
+			notFound(w, r)
+			return
+		}
+
+		vm := PageVM{
+			BaseVM:  viewdata.New(r),
+			Slug:    slug,
+			Content: htmlsanitize.PrepareForDisplay(page.Content),
+		}
+		if user, ok := auth.CurrentUser(r); ok && user.Role == "admin" {
+			vm.CanEdit = true
+		}
+		vm.Title = page.Title
+
+		templates.Render(w, r, "pages/show", vm)
+	}
+}
+
+// customSlugError returns why slug can't be used for a custom page, or ""
+// if it can.
+func (h *Handler) customSlugError(slug string) string {
+	first, _, _ := strings.Cut(slug, "/")
+	switch {
+	case slug == "":
+		return "Slug is required."
+	case !models.IsValidCustomPageSlug(slug):
+		return "Slugs can use lowercase letters, numbers and hyphens, with slashes between parts, like docs/getting-started."
+	case models.IsValidPageSlug(slug):
+		return "/" + slug + " is a built-in page."
+	case h.reserved[first]:
+		return "Pages can't be added under /" + first + ", which the site already uses."
+	}
+	return ""
+}
+
+// parseCustomPageForm reads the custom page form into vm and returns a
+// validation error, if any.
+func (h *Handler) parseCustomPageForm(r *http.Request, vm *CustomPageVM) string {
+	vm.Slug = strings.Trim(strings.ToLower(strings.TrimSpace(r.FormValue("slug"))), "/")
+	vm.ShowInNav = r.FormValue("show_in_nav") == "on"
+	vm.NavLabel = strings.TrimSpace(r.FormValue("nav_label"))
+
+	// A custom page keeps its slug even if it is now reserved
+	if msg := h.customSlugError(vm.Slug); msg != "" && (vm.IsNew || vm.Slug != vm.OldSlug) {
+		return msg
+	}
+	if raw := strings.TrimSpace(r.FormValue("nav_order")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < -maxNavOrder || n > maxNavOrder {
+			return "Menu order must be a whole number between -10000 and 10000."
+		}
+		vm.NavOrder = n
+	}
+	if len(vm.NavLabel) > 50 {
+		return "Menu label can be at most 50 characters."
+	}
+	return ""
+}
+
+// newCustomPage shows the form for adding a custom page.
+func (h *Handler) newCustomPage(w http.ResponseWriter, r *http.Request) {
+	vm := CustomPageVM{BaseVM: viewdata.New(r), IsNew: true}
+	vm.Title = "New Page"
+	vm.BackURL = "/pages"
+	templates.Render(w, r, "pages/custom", vm)
+}
+
+// createCustomPage adds a custom page, unpublished, and opens it in the
+// editor.
+func (h *Handler) createCustomPage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	vm := CustomPageVM{BaseVM: viewdata.New(r), IsNew: true}
+	vm.Title = "New Page"
+	vm.BackURL = "/pages"
+	vm.PageTitle = strings.TrimSpace(r.FormValue("title"))
+	vm.Error = h.parseCustomPageForm(r, &vm)
+	if vm.Error == "" && vm.PageTitle == "" {
+		vm.Error = "Title is required."
+	}
+	if vm.Error != "" {
+		templates.Render(w, r, "pages/custom", vm)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	draft := &models.PageDraft{Title: vm.PageTitle}
+	actorID := actor.UserID()
+	draft.UpdatedByID, draft.UpdatedByName = &actorID, actor.Name
+
+	_, err := h.pageStore.CreateCustom(r.Context(), models.Page{
+		Slug:      vm.Slug,
+		Draft:     draft,
+		ShowInNav: vm.ShowInNav,
+		NavLabel:  vm.NavLabel,
+		NavOrder:  vm.NavOrder,
+	})
+	if err == pagestore.ErrDuplicateSlug {
+		vm.Error = "A page already uses /" + vm.Slug + "."
+		templates.Render(w, r, "pages/custom", vm)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to create custom page", err)
+		vm.Error = "Failed to create page. Please try again."
+		templates.Render(w, r, "pages/custom", vm)
+		return
+	}
+
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "page_created", map[string]string{
+		"page_slug": vm.Slug,
+	})
+	http.Redirect(w, r, "/pages/"+pageKey(vm.Slug)+"/edit", http.StatusSeeOther)
+}
+
+// loadCustomPage returns the custom page named in an admin URL, or writes
+// a 404; built-in pages have no slug or menu settings.
+func (h *Handler) loadCustomPage(w http.ResponseWriter, r *http.Request) (models.Page, bool) {
+	page, ok := h.loadPage(w, r)
+	if ok && !page.Custom {
+		http.NotFound(w, r)
+		return models.Page{}, false
+	}
+	return page, ok
+}
+
+// customPageSettings shows a custom page's slug and menu settings.
+func (h *Handler) customPageSettings(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadCustomPage(w, r)
+	if !ok {
+		return
+	}
+
+	vm := CustomPageVM{
+		BaseVM:    viewdata.New(r),
+		OldSlug:   page.Slug,
+		Slug:      page.Slug,
+		ShowInNav: page.ShowInNav,
+		NavLabel:  page.NavLabel,
+		NavOrder:  page.NavOrder,
+	}
+	vm.Title = pageName(page) + " Settings"
+	vm.BackURL = "/pages"
+	templates.Render(w, r, "pages/custom", vm)
+}
+
+// updateCustomPageSettings changes a custom page's slug and menu settings.
+// Its revisions move with it to a new slug.
+func (h *Handler) updateCustomPageSettings(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadCustomPage(w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	vm := CustomPageVM{BaseVM: viewdata.New(r), OldSlug: page.Slug}
+	vm.Title = pageName(page) + " Settings"
+	vm.BackURL = "/pages"
+	if vm.Error = h.parseCustomPageForm(r, &vm); vm.Error != "" {
+		templates.Render(w, r, "pages/custom", vm)
+		return
+	}
+
+	err := h.pageStore.UpdateCustomSettings(r.Context(), page.Slug, pagestore.CustomSettings{
+		Slug:      vm.Slug,
+		ShowInNav: vm.ShowInNav,
+		NavLabel:  vm.NavLabel,
+		NavOrder:  vm.NavOrder,
+	})
+	if err == pagestore.ErrDuplicateSlug {
+		vm.Error = "A page already uses /" + vm.Slug + "."
+		templates.Render(w, r, "pages/custom", vm)
+		return
+	}
+	if err == nil && vm.Slug != page.Slug {
+		err = h.revisionStore.RenameSlug(r.Context(), page.Slug, vm.Slug)
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to update custom page settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "page_settings_updated", map[string]string{
+		"page_slug": vm.Slug,
+		"old_slug":  page.Slug,
+	})
+	http.Redirect(w, r, "/pages?saved=1", http.StatusSeeOther)
+}
+
+// deleteCustomPage removes a custom page and its revisions.
+func (h *Handler) deleteCustomPage(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadCustomPage(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.pageStore.DeleteCustom(r.Context(), page.Slug); err != nil {
+		h.errLog.Log(r, "failed to delete custom page", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.revisionStore.DeleteBySlug(r.Context(), page.Slug); err != nil {
+		h.logger.Warn("failed to delete page revisions", zap.String("slug", page.Slug), zap.Error(err))
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "page_deleted", map[string]string{
+		"page_slug": page.Slug,
+	})
+	http.Redirect(w, r, "/pages?deleted=1", http.StatusSeeOther)
+}
+
+// customPageRows returns the custom pages for the pages list.
+func (h *Handler) customPageRows(ctx context.Context) ([]customPageRow, error) {
+	pages, err := h.pageStore.ListCustom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]customPageRow, 0, len(pages))
+	for _, p := range pages {
+		rows = append(rows, customPageRow{
+			Slug:      p.Slug,
+			Key:       pageKey(p.Slug),
+			Title:     pageName(p),
+			Published: p.Published(),
+			HasDraft:  p.Draft != nil,
+			ShowInNav: p.ShowInNav,
+			NavLabel:  p.Label(),
+			NavOrder:  p.NavOrder,
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,96 @@
+package pages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSetReservedRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	r.Get("/login", func(http.ResponseWriter, *http.Request) {})
+	r.Route("/console", func(r chi.Router) {
+		r.Get("/users/{id}", func(http.ResponseWriter, *http.Request) {})
+	})
+	r.Get("/{slug}", func(http.ResponseWriter, *http.Request) {})
+
+	h := &Handler{}
+	h.SetReservedRoutes(r)
+
+	for _, seg := range []string{"login", "console"} {
+		if !h.reserved[seg] {
+			t.Errorf("reserved[%q] = false, want true", seg)
+		}
+	}
+	if len(h.reserved) != 2 {
+		t.Errorf("reserved = %v, want only login and console", h.reserved)
+	}
+}
+
+func TestCustomSlugError(t *testing.T) {
+	h := &Handler{reserved: map[string]bool{"console": true}}
+
+	tests := []struct {
+		slug string
+		ok   bool
+	}{
+		{"faq", true},
+		{"docs/getting-started", true},
+		{"", false},
+		{"Docs", false},
+		{"docs//intro", false},
+		{"-faq", false},
+		{"about", false},
+		{"console", false},
+		{"console/help", false},
+		{"about/team", true},
+	}
+	for _, tt := range tests {
+		if got := h.customSlugError(tt.slug) == ""; got != tt.ok {
+			t.Errorf("customSlugError(%q) allowed = %v, want %v", tt.slug, got, tt.ok)
+		}
+	}
+}
+
+func TestCustomPages(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _, pageStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if _, err := pageStore.CreateCustom(ctx, models.Page{Slug: "docs/faq", Title: "FAQ", Content: "<p>Answers</p>"}); err != nil {
+		t.Fatalf("CreateCustom() error = %v", err)
+	}
+	if _, err := pageStore.CreateCustom(ctx, models.Page{Slug: "unpublished", Draft: &models.PageDraft{Title: "Soon"}}); err != nil {
+		t.Fatalf("CreateCustom() error = %v", err)
+	}
+
+	notFound := func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) }
+	serve := h.CustomPages(notFound)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/docs/faq", http.StatusOK},
+		{"/unpublished", http.StatusNotFound},
+		{"/missing", http.StatusNotFound},
+		{"/terms", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := testutil.WithCSRFToken(httptest.NewRequest(http.MethodGet, tt.path, nil))
+		rec := httptest.NewRecorder()
+		serve(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && !strings.Contains(rec.Body.String(), "Answers") {
+			t.Errorf("GET %s body missing page content", tt.path)
+		}
+	}
+}
@@ -4,9 +4,12 @@ package pages
 import (
 	"html/template"
 	"net/http"
+	"net/url"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/pagerevision"
 	pagestore "github.com/dalemusser/stratasave/internal/app/store/pages"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/htmlsanitize"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
@@ -19,28 +22,41 @@ import (
 
 // Handler provides page content handlers.
 type Handler struct {
-	pageStore *pagestore.Store
-	errLog    *errorsfeature.ErrorLogger
-	logger    *zap.Logger
+	pageStore     *pagestore.Store
+	revisionStore *pagerevision.Store
+	auditLogger   *auditlog.Logger // nil unless set with SetAuditLogger
+	reserved      map[string]bool  // first path segments custom pages can't use; see SetReservedRoutes
+	errLog        *errorsfeature.ErrorLogger
+	logger        *zap.Logger
 }
 
 // NewHandler creates a new pages Handler.
 func NewHandler(db *mongo.Database, errLog *errorsfeature.ErrorLogger, logger *zap.Logger) *Handler {
 	return &Handler{
-		pageStore: pagestore.New(db),
-		errLog:    errLog,
-		logger:    logger,
+		pageStore:     pagestore.New(db),
+		revisionStore: pagerevision.New(db),
+		errLog:        errLog,
+		logger:        logger,
 	}
 }
 
+// SetAuditLogger records publishing and rolling back pages in the audit log.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
 // PageVM is the view model for page content.
 type PageVM struct {
 	viewdata.BaseVM
 	Slug    string
 	Content template.HTML
 	CanEdit bool
+	Preview string // set when showing a draft or old revision to an admin
 }
 
+// Key returns the page's slug for use in a URL path segment.
+func (vm PageVM) Key() string { return pageKey(vm.Slug) }
+
 // AboutRouter returns a router for the about page.
 func (h *Handler) AboutRouter() http.Handler {
 	r := chi.NewRouter()
@@ -92,8 +108,11 @@ func (h *Handler) showPage(slug, defaultTitle string) http.HandlerFunc {
 		}
 		vm.Title = defaultTitle
 
+		// A page with only a draft has no published title yet
 		if err == nil {
-			vm.Title = page.Title
+			if page.Title != "" {
+				vm.Title = page.Title
+			}
 			vm.Content = htmlsanitize.PrepareForDisplay(page.Content)
 		}
 
@@ -107,8 +126,18 @@ func EditRoutes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Use(sessionMgr.RequireRole("admin"))
 
 	r.Get("/", h.listPages)
+	r.Get("/new", h.newCustomPage)
+	r.Post("/new", h.createCustomPage)
+	r.Get("/{slug}/settings", h.customPageSettings)
+	r.Post("/{slug}/settings", h.updateCustomPageSettings)
+	r.Post("/{slug}/delete", h.deleteCustomPage)
 	r.Get("/{slug}/edit", h.editPage)
 	r.Post("/{slug}", h.updatePage)
+	r.Get("/{slug}/preview", h.previewDraft)
+	r.Post("/{slug}/draft/discard", h.discardDraft)
+	r.Get("/{slug}/history", h.history)
+	r.Get("/{slug}/revisions/{id}", h.showRevision)
+	r.Post("/{slug}/revisions/{id}/restore", h.restoreRevision)
 
 	return r
 }
@@ -119,10 +148,71 @@ type EditPageVM struct {
 	Slug      string
 	PageTitle string
 	Content   string
+	Draft     *models.PageDraft // the draft being edited, if any
 	Success   bool
+	Notice    string
 	Error     string
 }
 
+// Key returns the page's slug for use in a URL path segment.
+func (vm EditPageVM) Key() string { return pageKey(vm.Slug) }
+
+// pageKey escapes a slug for use as one segment of an admin URL, since
+// custom page slugs can contain slashes.
+func pageKey(slug string) string {
+	return url.PathEscape(slug)
+}
+
+// pageSlug returns the page slug from an admin URL.
+func pageSlug(r *http.Request) string {
+	slug, err := url.PathUnescape(chi.URLParam(r, "slug"))
+	if err != nil {
+		return ""
+	}
+	return slug
+}
+
+// loadPage returns the built-in or custom page named in an admin URL, or
+// writes a 404. A built-in page that hasn't been saved yet is returned
+// empty.
+func (h *Handler) loadPage(w http.ResponseWriter, r *http.Request) (models.Page, bool) {
+	slug := pageSlug(r)
+	builtIn := models.IsValidPageSlug(slug)
+	if !builtIn && !models.IsValidCustomPageSlug(slug) {
+		http.NotFound(w, r)
+		return models.Page{}, false
+	}
+
+	page, err := h.pageStore.GetBySlug(r.Context(), slug)
+	switch {
+	case err == mongo.ErrNoDocuments && builtIn:
+		return models.Page{Slug: slug}, true
+	case err == mongo.ErrNoDocuments || (err == nil && !builtIn && !page.Custom):
+		http.NotFound(w, r)
+		return models.Page{}, false
+	case err != nil:
+		h.errLog.Log(r, "failed to get page", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return models.Page{}, false
+	}
+	return page, true
+}
+
+// pageName returns a human-friendly name for a page: the built-in page's
+// name, or a custom page's title.
+func pageName(page models.Page) string {
+	switch {
+	case !page.Custom:
+		return pageDisplayName(page.Slug)
+	case page.Draft != nil && page.Draft.Title != "":
+		return page.Draft.Title
+	case page.Title != "":
+		return page.Title
+	default:
+		return page.Slug
+	}
+}
+
 // pageDisplayName returns a human-friendly name for a page slug.
 func pageDisplayName(slug string) string {
 	switch slug {
@@ -139,47 +229,70 @@ func pageDisplayName(slug string) string {
 	}
 }
 
+// ListVM is the view model for the list of editable pages.
+type ListVM struct {
+	viewdata.BaseVM
+	Pages   []string // built-in page slugs
+	Custom  []customPageRow
+	Success string
+}
+
 // listPages shows all editable pages.
 func (h *Handler) listPages(w http.ResponseWriter, r *http.Request) {
-	pageSlugs := []string{"about", "contact", "terms", "privacy"}
+	custom, err := h.customPageRows(r.Context())
+	if err != nil {
+		h.errLog.Log(r, "failed to list custom pages", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
 
-	vm := struct {
-		viewdata.BaseVM
-		Pages []string
-	}{
+	vm := ListVM{
 		BaseVM: viewdata.New(r),
-		Pages:  pageSlugs,
+		Pages:  models.AllPageSlugs(),
+		Custom: custom,
 	}
 	vm.Title = "Manage Pages"
+	switch {
+	case r.URL.Query().Get("saved") == "1":
+		vm.Success = "Page settings saved."
+	case r.URL.Query().Get("deleted") == "1":
+		vm.Success = "Page deleted."
+	}
 
 	templates.Render(w, r, "pages/list", vm)
 }
 
 // editPage shows the edit form for a page.
 func (h *Handler) editPage(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
-
-	page, err := h.pageStore.GetBySlug(r.Context(), slug)
-	if err != nil && err != mongo.ErrNoDocuments {
-		h.errLog.Log(r, "failed to get page for edit", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	page, ok := h.loadPage(w, r)
+	if !ok {
 		return
 	}
 
 	vm := EditPageVM{
 		BaseVM: viewdata.New(r),
-		Slug:   slug,
+		Slug:   page.Slug,
 	}
-	vm.Title = "Edit " + pageDisplayName(slug)
+	vm.Title = "Edit " + pageName(page)
 
 	// Check for success query parameter
 	if r.URL.Query().Get("success") == "1" {
 		vm.Success = true
 	}
+	switch {
+	case r.URL.Query().Get("draft") == "1":
+		vm.Notice = "Draft saved. Visitors still see the published page."
+	case r.URL.Query().Get("discarded") == "1":
+		vm.Notice = "Draft discarded."
+	}
 
-	if err == nil {
-		vm.PageTitle = page.Title
-		vm.Content = page.Content
+	// Pick up where the draft left off, if there is one
+	vm.PageTitle = page.Title
+	vm.Content = page.Content
+	if page.Draft != nil {
+		vm.Draft = page.Draft
+		vm.PageTitle = page.Draft.Title
+		vm.Content = page.Draft.Content
 	}
 
 	templates.Render(w, r, "pages/edit", vm)
@@ -190,7 +303,11 @@ const MaxContentLength = 100000
 
 // updatePage saves changes to a page.
 func (h *Handler) updatePage(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
+	page, ok := h.loadPage(w, r)
+	if !ok {
+		return
+	}
+	slug := page.Slug
 
 	if err := r.ParseForm(); err != nil {
 		h.errLog.Log(r, "failed to parse form", err)
@@ -210,20 +327,37 @@ func (h *Handler) updatePage(w http.ResponseWriter, r *http.Request) {
 			Content:   rawContent,
 			Error:     "Content is too long. Maximum length is 100,000 characters.",
 		}
-		vm.Title = "Edit " + pageDisplayName(slug)
+		vm.Title = "Edit " + pageName(page)
 		templates.Render(w, r, "pages/edit", vm)
 		return
 	}
 
 	content := htmlsanitize.Sanitize(rawContent)
 
-	page := models.Page{
-		Slug:    slug,
-		Title:   title,
-		Content: content,
+	if r.FormValue("action") == "draft" {
+		draft := models.PageDraft{Title: title, Content: content}
+		if actor, ok := auth.CurrentUser(r); ok {
+			id := actor.UserID()
+			draft.UpdatedByID, draft.UpdatedByName = &id, actor.Name
+		}
+		if err := h.pageStore.SaveDraft(r.Context(), slug, draft); err != nil {
+			h.errLog.Log(r, "failed to save page draft", err)
+			vm := EditPageVM{
+				BaseVM:    viewdata.New(r),
+				Slug:      slug,
+				PageTitle: title,
+				Content:   content,
+				Error:     "Failed to save draft. Please try again.",
+			}
+			vm.Title = "Edit " + pageName(page)
+			templates.Render(w, r, "pages/edit", vm)
+			return
+		}
+		http.Redirect(w, r, "/pages/"+pageKey(slug)+"/edit?draft=1", http.StatusSeeOther)
+		return
 	}
 
-	if err := h.pageStore.Upsert(r.Context(), page); err != nil {
+	if _, err := h.publish(r, slug, title, content, 0); err != nil {
 		h.errLog.Log(r, "failed to update page", err)
 
 		vm := EditPageVM{
@@ -233,11 +367,54 @@ func (h *Handler) updatePage(w http.ResponseWriter, r *http.Request) {
 			Content:   content,
 			Error:     "Failed to save page. Please try again.",
 		}
-		vm.Title = "Edit " + pageDisplayName(slug)
+		vm.Title = "Edit " + pageName(page)
 		templates.Render(w, r, "pages/edit", vm)
 		return
 	}
 
 	// Redirect back to edit page with success message
-	http.Redirect(w, r, "/pages/"+slug+"/edit?success=1", http.StatusSeeOther)
+	http.Redirect(w, r, "/pages/"+pageKey(slug)+"/edit?success=1", http.StatusSeeOther)
+}
+
+// previewDraft shows the page as it will look once the draft is
+// published, or the published page if there is no draft.
+func (h *Handler) previewDraft(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadPage(w, r)
+	if !ok {
+		return
+	}
+
+	vm := PageVM{
+		BaseVM:  viewdata.New(r),
+		Slug:    page.Slug,
+		Preview: "Preview of the published page. There is no draft.",
+	}
+	vm.BackURL = "/pages/" + pageKey(page.Slug) + "/edit"
+	title, content := page.Title, page.Content
+	if page.Draft != nil {
+		title, content = page.Draft.Title, page.Draft.Content
+		vm.Preview = "Preview of the unpublished draft. Visitors still see the published page."
+	}
+	vm.Title = pageName(page)
+	if title != "" {
+		vm.Title = title
+	}
+	vm.Content = htmlsanitize.PrepareForDisplay(content)
+
+	templates.Render(w, r, "pages/show", vm)
+}
+
+// discardDraft throws away a page's draft, leaving the published page.
+func (h *Handler) discardDraft(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadPage(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.pageStore.DiscardDraft(r.Context(), page.Slug); err != nil {
+		h.errLog.Log(r, "failed to discard page draft", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/pages/"+pageKey(page.Slug)+"/edit?discarded=1", http.StatusSeeOther)
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/store/pagerevision"
 	pagestore "github.com/dalemusser/stratasave/internal/app/store/pages"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/domain/models"
@@ -406,3 +407,86 @@ func TestShowPage_WithExistingContent(t *testing.T) {
 		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
 	}
 }
+
+// postPage submits the edit form for slug as an admin.
+func postPage(h *Handler, slug string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pages/"+slug, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: primitive.NewObjectID().Hex(), Name: "Admin User", Role: "admin"})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("slug", slug)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	h.updatePage(rec, req)
+	return rec
+}
+
+func TestUpdatePage_SaveDraft(t *testing.T) {
+	h, _, pageStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if err := pageStore.Upsert(ctx, models.Page{Slug: "terms", Title: "Terms", Content: "<p>Live</p>"}); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	rec := postPage(h, "terms", url.Values{"title": {"Terms"}, "content": {"<p>Draft</p>"}, "action": {"draft"}})
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "draft=1") {
+		t.Errorf("Location = %q, want to contain 'draft=1'", loc)
+	}
+
+	page, err := pageStore.GetBySlug(ctx, "terms")
+	if err != nil {
+		t.Fatalf("failed to get page: %v", err)
+	}
+	if page.Content != "<p>Live</p>" {
+		t.Errorf("page.Content = %q, saving a draft changed the published page", page.Content)
+	}
+	if page.Draft == nil || page.Draft.Content != "<p>Draft</p>" || page.Draft.UpdatedByName != "Admin User" {
+		t.Errorf("page.Draft = %+v, want the draft by Admin User", page.Draft)
+	}
+}
+
+func TestPublishAndRestore(t *testing.T) {
+	h, db, pageStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	revStore := pagerevision.New(db)
+
+	// A page published before revisions were kept becomes version 1
+	if err := pageStore.Upsert(ctx, models.Page{Slug: "terms", Title: "Terms", Content: "<p>Original</p>"}); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	postPage(h, "terms", url.Values{"title": {"Terms"}, "content": {"<p>Changed</p>"}})
+
+	revs, err := revStore.List(ctx, "terms")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(revs) != 2 || revs[1].Version != 1 || revs[0].PublishedByName != "Admin User" {
+		t.Fatalf("revisions = %+v, want the original as version 1 and the change as version 2", revs)
+	}
+
+	// Roll back to version 1
+	req := httptest.NewRequest(http.MethodPost, "/pages/terms/revisions/"+revs[1].ID.Hex()+"/restore", nil)
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: primitive.NewObjectID().Hex(), Name: "Admin User", Role: "admin"})
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("slug", "terms")
+	rctx.URLParams.Add("id", revs[1].ID.Hex())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.restoreRevision(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	page, _ := pageStore.GetBySlug(ctx, "terms")
+	if page.Content != "<p>Original</p>" {
+		t.Errorf("page.Content = %q, want the restored version", page.Content)
+	}
+	latest, _ := revStore.Latest(ctx, "terms")
+	if latest.Version != 3 || latest.RestoredFrom != 1 {
+		t.Errorf("latest revision = %+v, want version 3 restored from 1", latest)
+	}
+}
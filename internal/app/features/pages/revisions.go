@@ -0,0 +1,221 @@
+// internal/app/features/pages/revisions.go
+package pages
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/pagerevision"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/htmlsanitize"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HistoryVM is the view model for a page's revision history.
+type HistoryVM struct {
+	viewdata.BaseVM
+	Slug      string
+	HasDraft  bool
+	Revisions []revisionVM
+	Success   string
+}
+
+// Key returns the page's slug for use in a URL path segment.
+func (vm HistoryVM) Key() string { return pageKey(vm.Slug) }
+
+// revisionVM is one row of the revision history.
+type revisionVM struct {
+	ID           string
+	Version      int
+	Title        string
+	PublishedAt  time.Time
+	PublishedBy  string
+	RestoredFrom int
+	Current      bool // the version visitors see
+}
+
+// publish makes title and content the live page and records them as the
+// page's next revision. restoredFrom is the version a rollback republishes,
+// or 0. Publishing from the editor discards the draft, since that is what
+// was published; a rollback leaves any draft alone.
+func (h *Handler) publish(r *http.Request, slug, title, content string, restoredFrom int) (pagerevision.Revision, error) {
+	ctx := r.Context()
+
+	page := models.Page{Slug: slug, Title: title, Content: content}
+	rev := pagerevision.Revision{Slug: slug, Title: title, Content: content, RestoredFrom: restoredFrom}
+	actor, ok := auth.CurrentUser(r)
+	if ok {
+		id := actor.UserID()
+		page.UpdatedByID, page.UpdatedByName = &id, actor.Name
+		rev.PublishedByID, rev.PublishedByName = &id, actor.Name
+	}
+
+	if err := h.recordBaseline(ctx, slug); err != nil {
+		return pagerevision.Revision{}, err
+	}
+
+	save := h.pageStore.Publish
+	if restoredFrom > 0 {
+		save = h.pageStore.Upsert
+	}
+	if err := save(ctx, page); err != nil {
+		return pagerevision.Revision{}, err
+	}
+	rev, err := h.revisionStore.Create(ctx, rev)
+	if err != nil {
+		return pagerevision.Revision{}, err
+	}
+
+	if ok {
+		if restoredFrom > 0 {
+			actorID := actor.UserID()
+			h.auditLogger.LogAdminEvent(r, &actorID, nil, "page_restored", map[string]string{
+				"page_slug":     slug,
+				"version":       strconv.Itoa(rev.Version),
+				"restored_from": strconv.Itoa(restoredFrom),
+			})
+		} else {
+			h.auditLogger.PageUpdated(ctx, r, actor.UserID(), actor.Role, slug)
+		}
+	}
+	return rev, nil
+}
+
+// recordBaseline saves the live page as its first revision if it has
+// none, as for pages last published before revisions were kept, so the
+// first change to it can still be rolled back.
+func (h *Handler) recordBaseline(ctx context.Context, slug string) error {
+	_, err := h.revisionStore.Latest(ctx, slug)
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	page, err := h.pageStore.GetBySlug(ctx, slug)
+	if err == mongo.ErrNoDocuments || (err == nil && page.Title == "" && page.Content == "") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rev := pagerevision.Revision{
+		Slug:            slug,
+		Title:           page.Title,
+		Content:         page.Content,
+		PublishedByID:   page.UpdatedByID,
+		PublishedByName: page.UpdatedByName,
+	}
+	if page.UpdatedAt != nil {
+		rev.PublishedAt = *page.UpdatedAt
+	}
+	_, err = h.revisionStore.Create(ctx, rev)
+	return err
+}
+
+// history lists a page's published versions, newest first.
+func (h *Handler) history(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.loadPage(w, r)
+	if !ok {
+		return
+	}
+
+	revs, err := h.revisionStore.List(r.Context(), page.Slug)
+	if err != nil {
+		h.errLog.Log(r, "failed to list page revisions", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vm := HistoryVM{
+		BaseVM:   viewdata.New(r),
+		Slug:     page.Slug,
+		HasDraft: page.Draft != nil,
+	}
+	vm.Title = pageName(page) + " History"
+	vm.BackURL = "/pages/" + pageKey(page.Slug) + "/edit"
+	for i, rev := range revs {
+		vm.Revisions = append(vm.Revisions, revisionVM{
+			ID:           rev.ID.Hex(),
+			Version:      rev.Version,
+			Title:        rev.Title,
+			PublishedAt:  rev.PublishedAt,
+			PublishedBy:  rev.PublishedByName,
+			RestoredFrom: rev.RestoredFrom,
+			Current:      i == 0,
+		})
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("restored")); err == nil {
+		vm.Success = "Version " + strconv.Itoa(v) + " is now the published page."
+	}
+
+	templates.Render(w, r, "pages/history", vm)
+}
+
+// loadRevision returns the revision named in the URL, or writes a 404.
+func (h *Handler) loadRevision(w http.ResponseWriter, r *http.Request) (pagerevision.Revision, bool) {
+	page, ok := h.loadPage(w, r)
+	if !ok {
+		return pagerevision.Revision{}, false
+	}
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return pagerevision.Revision{}, false
+	}
+	rev, err := h.revisionStore.GetByID(r.Context(), page.Slug, id)
+	if err == mongo.ErrNoDocuments {
+		http.NotFound(w, r)
+		return pagerevision.Revision{}, false
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to get page revision", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return pagerevision.Revision{}, false
+	}
+	return rev, true
+}
+
+// showRevision shows a page as it was published in one revision.
+func (h *Handler) showRevision(w http.ResponseWriter, r *http.Request) {
+	rev, ok := h.loadRevision(w, r)
+	if !ok {
+		return
+	}
+
+	vm := PageVM{
+		BaseVM:  viewdata.New(r),
+		Slug:    rev.Slug,
+		Content: htmlsanitize.PrepareForDisplay(rev.Content),
+		Preview: "Version " + strconv.Itoa(rev.Version) + ", published " + rev.PublishedAt.Format("Jan 2, 2006 3:04 PM"),
+	}
+	vm.BackURL = "/pages/" + pageKey(rev.Slug) + "/history"
+	if rev.PublishedByName != "" {
+		vm.Preview += " by " + rev.PublishedByName
+	}
+	vm.Title = rev.Title
+
+	templates.Render(w, r, "pages/show", vm)
+}
+
+// restoreRevision rolls a page back by publishing an earlier revision's
+// title and content again, as a new revision.
+func (h *Handler) restoreRevision(w http.ResponseWriter, r *http.Request) {
+	rev, ok := h.loadRevision(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.publish(r, rev.Slug, rev.Title, rev.Content, rev.Version); err != nil {
+		h.errLog.Log(r, "failed to restore page revision", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/pages/"+pageKey(rev.Slug)+"/history?restored="+strconv.Itoa(rev.Version), http.StatusSeeOther)
+}
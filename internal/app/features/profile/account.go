@@ -0,0 +1,289 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportSessionLimit and exportActivityLimit bound how many of the
+	// most recent sessions and activity events a data download includes.
+	exportSessionLimit  = 1000
+	exportActivityLimit = 10000
+
+	// deleteConfirmation is what users type to confirm deleting their
+	// account.
+	deleteConfirmation = "DELETE"
+)
+
+// SetAccountDeletion lets users delete their own account from the profile
+// page. The account is deleted after grace, during which the user can
+// cancel; with a grace of zero or less it is deleted at once.
+func (h *Handler) SetAccountDeletion(enabled bool, grace time.Duration) {
+	h.selfDelete = enabled
+	h.deleteGrace = max(grace, 0)
+}
+
+// dataExport is the "download my data" file.
+type dataExport struct {
+	ExportedAt time.Time        `json:"exported_at"`
+	Profile    exportProfile    `json:"profile"`
+	Sessions   []exportSession  `json:"sessions"`
+	Activity   []exportActivity `json:"activity"`
+}
+
+// exportProfile is the account in a data download. Credentials and
+// internal bookkeeping are left out.
+type exportProfile struct {
+	ID              string     `json:"id"`
+	FullName        string     `json:"full_name"`
+	LoginID         string     `json:"login_id,omitempty"`
+	Email           string     `json:"email,omitempty"`
+	AuthMethod      string     `json:"auth_method"`
+	SignInMethods   []string   `json:"sign_in_methods"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	ThemePreference string     `json:"theme_preference,omitempty"`
+	Locale          string     `json:"locale,omitempty"`
+	Timezone        string     `json:"timezone,omitempty"`
+	HasAvatar       bool       `json:"has_avatar"`
+	LastLoginAt     *time.Time `json:"last_login_at,omitempty"`
+	DisableAt       *time.Time `json:"disable_at,omitempty"`
+	DeleteAt        *time.Time `json:"delete_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// exportSession is a session in a data download.
+type exportSession struct {
+	LoginAt      time.Time  `json:"login_at"`
+	LogoutAt     *time.Time `json:"logout_at,omitempty"`
+	LastActivity time.Time  `json:"last_activity"`
+	EndReason    string     `json:"end_reason,omitempty"`
+	IPAddress    string     `json:"ip_address,omitempty"`
+	UserAgent    string     `json:"user_agent,omitempty"`
+	Device       string     `json:"device,omitempty"`
+	DeviceName   string     `json:"device_name,omitempty"`
+}
+
+// exportActivity is an activity event in a data download.
+type exportActivity struct {
+	Timestamp time.Time `json:"timestamp"`
+	EventType string    `json:"event_type"`
+	PagePath  string    `json:"page_path,omitempty"`
+}
+
+// exportData sends the user a JSON file of their profile, their sessions,
+// and their activity.
+func (h *Handler) exportData(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	export, err := h.buildDataExport(ctx, sessionUser.UserID())
+	if err != nil {
+		h.errLog.Log(r, "failed to build data export", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	userID := sessionUser.UserID()
+	h.auditLogger.LogAuthEvent(r, &userID, audit.EventDataExported, true, "")
+
+	filename := fmt.Sprintf("my_data_%s.json", export.ExportedAt.Format("20060102"))
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename)
+	w.Header().Set("Cache-Control", "no-store")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		h.logger.Error("JSON encode failed", zap.Error(err))
+	}
+}
+
+// buildDataExport gathers a user's data for download.
+func (h *Handler) buildDataExport(ctx context.Context, userID primitive.ObjectID) (dataExport, error) {
+	user, err := h.userStore.GetByID(ctx, userID)
+	if err != nil {
+		return dataExport{}, fmt.Errorf("loading user: %w", err)
+	}
+	sessionList, err := h.sessionsStore.ListRecentByUser(ctx, userID, exportSessionLimit)
+	if err != nil {
+		return dataExport{}, fmt.Errorf("loading sessions: %w", err)
+	}
+	events, err := h.activity.GetByUser(ctx, userID, exportActivityLimit)
+	if err != nil {
+		return dataExport{}, fmt.Errorf("loading activity: %w", err)
+	}
+	names, err := h.devices.Names(ctx, []primitive.ObjectID{userID})
+	if err != nil {
+		h.logger.Warn("failed to load device names", zap.Error(err))
+	}
+
+	export := dataExport{
+		ExportedAt: time.Now().UTC(),
+		Profile:    newExportProfile(user),
+		Sessions:   make([]exportSession, 0, len(sessionList)),
+		Activity:   make([]exportActivity, 0, len(events)),
+	}
+	for _, s := range sessionList {
+		export.Sessions = append(export.Sessions, newExportSession(s, names[userID][s.Fingerprint()]))
+	}
+	for _, e := range events {
+		export.Activity = append(export.Activity, exportActivity{
+			Timestamp: e.Timestamp,
+			EventType: e.EventType,
+			PagePath:  e.PagePath,
+		})
+	}
+	return export, nil
+}
+
+// newExportProfile returns the downloadable part of a user's account.
+func newExportProfile(u *models.User) exportProfile {
+	p := exportProfile{
+		ID:              u.ID.Hex(),
+		FullName:        u.FullName,
+		AuthMethod:      u.AuthMethod,
+		SignInMethods:   u.Methods(),
+		Role:            u.Role,
+		Status:          u.Status,
+		ThemePreference: u.ThemePreference,
+		Locale:          u.Locale,
+		Timezone:        u.Timezone,
+		HasAvatar:       u.AvatarPath != "",
+		LastLoginAt:     u.LastLoginAt,
+		DisableAt:       u.DisableAt,
+		DeleteAt:        u.DeleteAt,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+	if u.LoginID != nil {
+		p.LoginID = *u.LoginID
+	}
+	if u.Email != nil {
+		p.Email = *u.Email
+	}
+	return p
+}
+
+// newExportSession returns a session as it appears in a data download.
+func newExportSession(s sessions.Session, deviceName string) exportSession {
+	return exportSession{
+		LoginAt:      s.LoginAt,
+		LogoutAt:     s.LogoutAt,
+		LastActivity: s.LastActivity,
+		EndReason:    s.EndReason,
+		IPAddress:    s.IPAddress,
+		UserAgent:    s.UserAgent,
+		Device:       s.Device().String(),
+		DeviceName:   deviceName,
+	}
+}
+
+// handleDeleteAccount schedules the user's account for deletion after the
+// grace period, or deletes it at once and signs the user out when there is
+// none. The last active admin can't delete their account.
+func (h *Handler) handleDeleteAccount(sessionMgr *auth.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionUser, ok := auth.CurrentUser(r)
+		if !ok {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		if !h.selfDelete {
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			h.errLog.Log(r, "failed to parse form", err)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("confirm") != deleteConfirmation {
+			http.Redirect(w, r, "/profile?error=delete_confirm", http.StatusSeeOther)
+			return
+		}
+
+		ctx := r.Context()
+		user, err := h.userStore.GetByID(ctx, sessionUser.UserID())
+		if err != nil {
+			h.errLog.Log(r, "failed to get user", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		last, err := h.userStore.IsLastActiveAdmin(ctx, user)
+		if err != nil {
+			h.errLog.Log(r, "failed to count admins", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if last {
+			http.Redirect(w, r, "/profile?error=delete_last_admin", http.StatusSeeOther)
+			return
+		}
+
+		now := time.Now()
+		if h.deleteGrace > 0 {
+			if err := h.userStore.ScheduleDeletion(ctx, user.ID, now.Add(h.deleteGrace)); err != nil {
+				h.errLog.Log(r, "failed to schedule account deletion", err)
+				http.Redirect(w, r, "/profile?error=delete_failed", http.StatusSeeOther)
+				return
+			}
+			h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventAccountDeletionRequested, true, "")
+			http.Redirect(w, r, "/profile?success=delete_scheduled", http.StatusSeeOther)
+			return
+		}
+
+		if err := h.userStore.SoftDelete(ctx, user.ID, user.ID, now); err != nil {
+			h.errLog.Log(r, "failed to delete account", err)
+			http.Redirect(w, r, "/profile?error=delete_failed", http.StatusSeeOther)
+			return
+		}
+		h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventAccountDeletionRequested, true, "")
+		h.auditLogger.LogAdminEvent(r, &user.ID, &user.ID, audit.EventUserDeleted, map[string]string{"reason": "self_service"})
+
+		if err := h.sessionsStore.CloseByUser(ctx, user.ID, sessions.EndReasonDeleted); err != nil {
+			h.logger.Warn("failed to close sessions of deleted user", zap.Error(err))
+		}
+		sessionMgr.DestroySession(w, r)
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	}
+}
+
+// handleCancelDeletion cancels the user's scheduled account deletion.
+func (h *Handler) handleCancelDeletion(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	userID := sessionUser.UserID()
+	if err := h.userStore.CancelDeletion(r.Context(), userID); err != nil {
+		h.errLog.Log(r, "failed to cancel account deletion", err)
+		http.Redirect(w, r, "/profile?error=delete_failed", http.StatusSeeOther)
+		return
+	}
+	h.auditLogger.LogAuthEvent(r, &userID, audit.EventAccountDeletionCanceled, true, "")
+
+	http.Redirect(w, r, "/profile?success=delete_canceled", http.StatusSeeOther)
+}
@@ -0,0 +1,199 @@
+package profile
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func TestNewExportProfile(t *testing.T) {
+	loginID := "jane@example.com"
+	hash := "$2a$10$secret"
+	u := &models.User{
+		ID:           primitive.NewObjectID(),
+		FullName:     "Jane Doe",
+		LoginID:      &loginID,
+		AuthMethod:   "password",
+		PasswordHash: &hash,
+		Role:         "developer",
+		Status:       "active",
+		AvatarPath:   "avatars/x/y.jpg",
+	}
+
+	p := newExportProfile(u)
+	if p.LoginID != loginID || p.Email != "" || !p.HasAvatar {
+		t.Errorf("newExportProfile() = %+v", p)
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), hash) || strings.Contains(string(b), u.AvatarPath) {
+		t.Errorf("export includes internal fields: %s", b)
+	}
+}
+
+// deleteRequest returns a POST to /profile/delete from the given user.
+func deleteRequest(userID primitive.ObjectID, role, confirm string) *http.Request {
+	form := url.Values{"confirm": {confirm}}
+	req := httptest.NewRequest(http.MethodPost, "/profile/delete", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), Name: "Test User", Role: role})
+	return testutil.WithCSRFToken(req)
+}
+
+func newTestSessionManager(t *testing.T) *auth.SessionManager {
+	t.Helper()
+	sessionMgr, err := auth.NewSessionManager("test-session-key-for-testing-1234567890", "test-session", "", 24*time.Hour, false, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create session manager: %v", err)
+	}
+	return sessionMgr
+}
+
+func TestDeleteAccount_Scheduled(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	h.SetAccountDeletion(true, 14*24*time.Hour)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, _ := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "password")
+
+	rec := httptest.NewRecorder()
+	h.handleDeleteAccount(newTestSessionManager(t))(rec, deleteRequest(userID, "developer", deleteConfirmation))
+
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "success=delete_scheduled") {
+		t.Fatalf("Location = %q, want delete_scheduled", loc)
+	}
+	u, err := users.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if u.DeleteAt == nil || u.DeleteAt.Before(time.Now().Add(13*24*time.Hour)) {
+		t.Errorf("DeleteAt = %v, want about 14 days from now", u.DeleteAt)
+	}
+	if u.Status == "deleted" {
+		t.Error("account was deleted before the grace period ended")
+	}
+
+	// Canceling clears the schedule
+	req := httptest.NewRequest(http.MethodPost, "/profile/delete/cancel", nil)
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), Role: "developer"})
+	rec = httptest.NewRecorder()
+	h.handleCancelDeletion(rec, req)
+
+	u, _ = users.GetByID(ctx, userID)
+	if u.DeleteAt != nil {
+		t.Errorf("DeleteAt = %v after cancel, want nil", u.DeleteAt)
+	}
+}
+
+func TestDeleteAccount_Immediate(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	h.SetAccountDeletion(true, 0)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, _ := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "password")
+
+	rec := httptest.NewRecorder()
+	h.handleDeleteAccount(newTestSessionManager(t))(rec, deleteRequest(userID, "developer", deleteConfirmation))
+
+	u, err := users.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if u.Status != "deleted" || u.DeletedByID == nil || *u.DeletedByID != userID {
+		t.Errorf("status = %q, deleted by %v; want deleted by the user", u.Status, u.DeletedByID)
+	}
+}
+
+func TestDeleteAccount_NotConfirmed(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	h.SetAccountDeletion(true, 0)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, _ := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "password")
+
+	rec := httptest.NewRecorder()
+	h.handleDeleteAccount(newTestSessionManager(t))(rec, deleteRequest(userID, "developer", "delete"))
+
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=delete_confirm") {
+		t.Errorf("Location = %q, want delete_confirm", loc)
+	}
+	if u, _ := users.GetByID(ctx, userID); u.Status == "deleted" {
+		t.Error("account deleted without confirmation")
+	}
+}
+
+func TestDeleteAccount_LastAdmin(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	h.SetAccountDeletion(true, 0)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, _ := createTestUser(t, users, "Only Admin", "admin@example.com", "admin", "password")
+
+	rec := httptest.NewRecorder()
+	h.handleDeleteAccount(newTestSessionManager(t))(rec, deleteRequest(userID, "admin", deleteConfirmation))
+
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=delete_last_admin") {
+		t.Errorf("Location = %q, want delete_last_admin", loc)
+	}
+	if u, _ := users.GetByID(ctx, userID); u.Status == "deleted" {
+		t.Error("the last active admin was deleted")
+	}
+}
+
+func TestDeleteAccount_Disabled(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	userID, _ := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "password")
+
+	rec := httptest.NewRecorder()
+	h.handleDeleteAccount(newTestSessionManager(t))(rec, deleteRequest(userID, "developer", deleteConfirmation))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestExportData(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	userID, email := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "password")
+
+	req := httptest.NewRequest(http.MethodGet, "/profile/export", nil)
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), Role: "developer"})
+	rec := httptest.NewRecorder()
+	h.exportData(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want an attachment", cd)
+	}
+	var export dataExport
+	if err := json.NewDecoder(rec.Body).Decode(&export); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if export.Profile.ID != userID.Hex() || export.Profile.LoginID != email {
+		t.Errorf("profile = %+v", export.Profile)
+	}
+	if export.Sessions == nil || export.Activity == nil {
+		t.Error("sessions and activity should be empty lists, not null")
+	}
+	if strings.Contains(rec.Body.String(), "password_hash") {
+		t.Error("export includes the password hash")
+	}
+}
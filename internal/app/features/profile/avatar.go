@@ -0,0 +1,217 @@
+package profile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"net/http"
+
+	// Register the decoders for the photo formats accepted
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxAvatarUpload is the largest profile photo upload accepted.
+	maxAvatarUpload = 5 << 20
+
+	// maxAvatarPixels bounds the decoded size of an uploaded photo, so a
+	// small file can't expand into a huge image in memory.
+	maxAvatarPixels = 40_000_000
+
+	// avatarSize is the width and height of stored profile photos.
+	avatarSize = 256
+
+	// avatarQuality is the JPEG quality of stored profile photos.
+	avatarQuality = 85
+)
+
+// errNotImage is returned for uploads that aren't a JPEG, PNG, or GIF.
+var errNotImage = errors.New("not a JPEG, PNG, or GIF image")
+
+// errImageTooLarge is returned for images with too many pixels.
+var errImageTooLarge = errors.New("image dimensions are too large")
+
+// resizeAvatar decodes a JPEG, PNG, or GIF photo, crops it to a centered
+// square, scales it to avatarSize, and returns it as a JPEG. Transparent
+// areas become white.
+func resizeAvatar(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errNotImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxAvatarPixels {
+		return nil, errImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errNotImage
+	}
+
+	// Crop to the largest centered square
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		b.Min.X+(b.Dx()-side)/2,
+		b.Min.Y+(b.Dy()-side)/2,
+	))
+
+	// Flatten onto white so transparency doesn't turn black in the JPEG
+	flat := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, crop.Min, draw.Over)
+
+	out := scaleSquare(flat, min(side, avatarSize))
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, out, &jpeg.Options{Quality: avatarQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleSquare scales a square image down to size×size by averaging the
+// source pixels that fall in each destination pixel.
+func scaleSquare(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	if size == side {
+		return src
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := y*side/size, (y+1)*side/size
+		for x := 0; x < size; x++ {
+			x0, x1 := x*side/size, (x+1)*side/size
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4:]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					b += uint64(p[2])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// SetFileStorage enables profile photos, stored in store. With no storage
+// set, the profile page doesn't offer a photo upload.
+func (h *Handler) SetFileStorage(store storage.Store) {
+	h.fileStorage = store
+}
+
+// handleUploadAvatar replaces the user's profile photo with an uploaded one.
+func (h *Handler) handleUploadAvatar(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if h.fileStorage == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Allow room for the form's other fields on top of the photo
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarUpload+1<<20)
+	if err := r.ParseMultipartForm(maxAvatarUpload); err != nil {
+		http.Redirect(w, r, "/profile?error=avatar_too_large", http.StatusSeeOther)
+		return
+	}
+	file, header, err := r.FormFile("avatar")
+	if err != nil || header.Size == 0 {
+		http.Redirect(w, r, "/profile?error=avatar_missing", http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxAvatarUpload {
+		http.Redirect(w, r, "/profile?error=avatar_too_large", http.StatusSeeOther)
+		return
+	}
+
+	photo, err := resizeAvatar(file)
+	if err != nil {
+		if errors.Is(err, errImageTooLarge) {
+			http.Redirect(w, r, "/profile?error=avatar_too_large", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, "/profile?error=avatar_invalid", http.StatusSeeOther)
+		return
+	}
+
+	ctx := r.Context()
+	userID := sessionUser.UserID()
+	path := fmt.Sprintf("avatars/%s/%s.jpg", userID.Hex(), uuid.New().String()[:8])
+	if err := h.fileStorage.Put(ctx, path, bytes.NewReader(photo), &storage.PutOptions{ContentType: "image/jpeg"}); err != nil {
+		h.errLog.Log(r, "failed to store profile photo", err)
+		http.Redirect(w, r, "/profile?error=avatar_failed", http.StatusSeeOther)
+		return
+	}
+	if err := h.userStore.UpdateAvatar(ctx, userID, path); err != nil {
+		h.errLog.Log(r, "failed to save profile photo", err)
+		if err := h.fileStorage.Delete(ctx, path); err != nil {
+			h.logger.Warn("failed to delete unused profile photo", zap.String("path", path), zap.Error(err))
+		}
+		http.Redirect(w, r, "/profile?error=avatar_failed", http.StatusSeeOther)
+		return
+	}
+	h.deleteAvatar(r, sessionUser.AvatarPath)
+
+	http.Redirect(w, r, "/profile?success=avatar", http.StatusSeeOther)
+}
+
+// handleRemoveAvatar removes the user's profile photo.
+func (h *Handler) handleRemoveAvatar(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	if h.fileStorage == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.userStore.UpdateAvatar(r.Context(), sessionUser.UserID(), ""); err != nil {
+		h.errLog.Log(r, "failed to remove profile photo", err)
+		http.Redirect(w, r, "/profile?error=avatar_failed", http.StatusSeeOther)
+		return
+	}
+	h.deleteAvatar(r, sessionUser.AvatarPath)
+
+	http.Redirect(w, r, "/profile?success=avatar_removed", http.StatusSeeOther)
+}
+
+// deleteAvatar deletes a replaced profile photo from file storage. A
+// failure leaves an unused file behind, so it's only logged.
+func (h *Handler) deleteAvatar(r *http.Request, path string) {
+	if path == "" {
+		return
+	}
+	if err := h.fileStorage.Delete(r.Context(), path); err != nil {
+		h.logger.Warn("failed to delete old profile photo", zap.String("path", path), zap.Error(err))
+	}
+}
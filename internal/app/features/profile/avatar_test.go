@@ -0,0 +1,99 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestResizeAvatar(t *testing.T) {
+	// A wide image: blue at the edges, red in the centered square, and a
+	// transparent strip through the middle of the square
+	src := image.NewNRGBA(image.Rect(0, 0, 600, 300))
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 600; x++ {
+			c := color.NRGBA{B: 255, A: 255}
+			if x >= 150 && x < 450 {
+				c = color.NRGBA{R: 255, A: 255}
+				if y >= 140 && y < 160 {
+					c = color.NRGBA{}
+				}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	out, err := resizeAvatar(bytes.NewReader(encodePNG(t, src)))
+	if err != nil {
+		t.Fatalf("resizeAvatar() error = %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("result is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != avatarSize || b.Dy() != avatarSize {
+		t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), avatarSize, avatarSize)
+	}
+
+	near := func(got uint32, want int) bool { d := int(got>>8) - want; return d > -40 && d < 40 }
+	// The edges were cropped away, leaving red
+	if r, g, b, _ := img.At(5, 5).RGBA(); !near(r, 255) || !near(g, 0) || !near(b, 0) {
+		t.Errorf("corner = (%d, %d, %d), want red", r>>8, g>>8, b>>8)
+	}
+	// Transparency became white
+	if r, g, b, _ := img.At(avatarSize/2, avatarSize/2).RGBA(); !near(r, 255) || !near(g, 255) || !near(b, 255) {
+		t.Errorf("center = (%d, %d, %d), want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestResizeAvatar_SmallImageNotEnlarged(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 64))
+	out, err := resizeAvatar(bytes.NewReader(encodePNG(t, src)))
+	if err != nil {
+		t.Fatalf("resizeAvatar() error = %v", err)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("result is not a JPEG: %v", err)
+	}
+	if cfg.Width != 40 || cfg.Height != 40 {
+		t.Errorf("size = %dx%d, want 40x40", cfg.Width, cfg.Height)
+	}
+}
+
+func TestResizeAvatar_NotImage(t *testing.T) {
+	_, err := resizeAvatar(strings.NewReader("%PDF-1.7 not a photo"))
+	if !errors.Is(err, errNotImage) {
+		t.Errorf("error = %v, want errNotImage", err)
+	}
+}
+
+func TestResizeAvatar_TooManyPixels(t *testing.T) {
+	// Claim huge dimensions in the header of a tiny PNG
+	data := encodePNG(t, image.NewGray(image.Rect(0, 0, 1, 1)))
+	ihdr := data[8+8 : 8+8+13] // After the signature and the chunk's length and type
+	binary.BigEndian.PutUint32(ihdr[0:4], 20000)
+	binary.BigEndian.PutUint32(ihdr[4:8], 20000)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+
+	_, err := resizeAvatar(bytes.NewReader(data))
+	if !errors.Is(err, errImageTooLarge) {
+		t.Errorf("error = %v, want errImageTooLarge", err)
+	}
+}
@@ -0,0 +1,164 @@
+package profile
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetGoogleLinking lets users connect a Google account from the profile
+// page. Enable it when Google sign-in is configured.
+func (h *Handler) SetGoogleLinking(enabled bool) {
+	h.googleLinking = enabled
+}
+
+// connectedAccount is a sign-in method in the Connected Accounts section.
+type connectedAccount struct {
+	Method    string
+	Label     string
+	Linked    bool
+	CanUnlink bool   // False for the user's only method
+	LinkURL   string // Where to connect it ("" = can't be connected from here)
+}
+
+// connectedAccounts lists the user's sign-in methods, followed by the ones
+// they can connect.
+func (h *Handler) connectedAccounts(u *models.User) []connectedAccount {
+	methods := u.Methods()
+	var accounts []connectedAccount
+	for _, m := range methods {
+		accounts = append(accounts, connectedAccount{
+			Method:    m,
+			Label:     models.AuthMethodLabel(m),
+			Linked:    true,
+			CanUnlink: len(methods) > 1,
+		})
+	}
+	if !u.HasMethod("password") {
+		// Connected with the form on the page
+		accounts = append(accounts, connectedAccount{Method: "password", Label: models.AuthMethodLabel("password")})
+	}
+	if h.googleLinking && !u.HasMethod("google") {
+		accounts = append(accounts, connectedAccount{Method: "google", Label: models.AuthMethodLabel("google"), LinkURL: "/auth/google/link"})
+	}
+	return accounts
+}
+
+// handleLinkPassword sets a password for a user who doesn't log in with
+// one, so they can.
+func (h *Handler) handleLinkPassword(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		h.errLog.Log(r, "failed to parse form", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), sessionUser.UserID())
+	if err != nil {
+		h.errLog.Log(r, "failed to get user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// Users who have a password change it in the password section
+	if user.HasMethod("password") && user.PasswordHash != nil {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	newPassword := r.FormValue("new_password")
+	if err := authutil.ValidatePassword(newPassword); err != nil {
+		h.renderConnectedError(w, r, user, err.Error())
+		return
+	}
+	if newPassword != r.FormValue("confirm_password") {
+		h.renderConnectedError(w, r, user, "Passwords do not match.")
+		return
+	}
+	if h.breaches.IsBreached(r.Context(), newPassword) {
+		h.renderConnectedError(w, r, user, authutil.ErrPasswordBreached.Error())
+		return
+	}
+
+	hash, err := authutil.HashPassword(newPassword)
+	if err != nil {
+		h.errLog.Log(r, "failed to hash password", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if err := h.userStore.LinkPassword(r.Context(), user.ID, hash); err != nil {
+		h.errLog.Log(r, "failed to link password", err)
+		h.renderConnectedError(w, r, user, "Failed to add the password. Please try again.")
+		return
+	}
+	h.auditLogger.AuthMethodLinked(r.Context(), r, user.ID, "password")
+
+	http.Redirect(w, r, "/profile?success=password_linked", http.StatusSeeOther)
+}
+
+// handleUnlinkMethod disconnects a sign-in method from the user's account.
+// The user's last method can't be disconnected.
+func (h *Handler) handleUnlinkMethod(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), sessionUser.UserID())
+	if err != nil {
+		h.errLog.Log(r, "failed to get user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	method := chi.URLParam(r, "method")
+	if !user.HasMethod(method) {
+		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.userStore.UnlinkMethod(r.Context(), user, method); err != nil {
+		switch {
+		case errors.Is(err, userstore.ErrLastMethod):
+			http.Redirect(w, r, "/profile?error=last_method", http.StatusSeeOther)
+		case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, userstore.ErrDuplicateLoginID):
+			http.Redirect(w, r, "/profile?error=unlink_failed", http.StatusSeeOther)
+		default:
+			h.errLog.Log(r, "failed to unlink sign-in method", err)
+			http.Redirect(w, r, "/profile?error=unlink_failed", http.StatusSeeOther)
+		}
+		return
+	}
+	h.auditLogger.AuthMethodUnlinked(r.Context(), r, user.ID, method)
+
+	// Sessions on other devices may have signed in with the removed method
+	if err := h.rotator.Rotate(w, r, user.ID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
+	http.Redirect(w, r, "/profile?success=unlinked", http.StatusSeeOther)
+}
+
+// renderConnectedError re-renders the profile page with an error from the
+// Connected Accounts section.
+func (h *Handler) renderConnectedError(w http.ResponseWriter, r *http.Request, user *models.User, errMsg string) {
+	vm := buildProfileVM(r, user)
+	vm.ConnectedAccounts = h.connectedAccounts(user)
+	vm.Error = template.HTML(errMsg)
+	templates.Render(w, r, "profile/show", vm)
+}
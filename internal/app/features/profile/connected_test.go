@@ -0,0 +1,106 @@
+package profile
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestConnectedAccounts(t *testing.T) {
+	h := &Handler{}
+	h.SetGoogleLinking(true)
+
+	got := h.connectedAccounts(&models.User{AuthMethod: "email"})
+	if len(got) != 3 {
+		t.Fatalf("connectedAccounts() = %+v, want email, password, and google", got)
+	}
+	if !got[0].Linked || got[0].Method != "email" || got[0].CanUnlink {
+		t.Errorf("only method = %+v, want linked and not removable", got[0])
+	}
+	if got[1].Method != "password" || got[1].Linked || got[1].LinkURL != "" {
+		t.Errorf("password = %+v, want unlinked with no link URL", got[1])
+	}
+	if got[2].Method != "google" || got[2].Linked || got[2].LinkURL == "" {
+		t.Errorf("google = %+v, want unlinked with a link URL", got[2])
+	}
+
+	got = h.connectedAccounts(&models.User{AuthMethod: "google", LinkedMethods: []string{"password"}})
+	if len(got) != 2 || !got[0].CanUnlink || !got[1].CanUnlink {
+		t.Errorf("connectedAccounts() = %+v, want two removable methods", got)
+	}
+}
+
+func TestConnectedAccounts_GoogleNotConfigured(t *testing.T) {
+	h := &Handler{}
+	for _, a := range h.connectedAccounts(&models.User{AuthMethod: "password"}) {
+		if a.Method == "google" {
+			t.Errorf("google offered without Google sign-in configured: %+v", a)
+		}
+	}
+}
+
+// unlinkRequest returns a POST that disconnects method from the user's account.
+func unlinkRequest(userID primitive.ObjectID, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/profile/connected/"+method+"/unlink", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("method", method)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), Role: "developer"})
+	return testutil.WithCSRFToken(req)
+}
+
+func TestLinkAndUnlinkPassword(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, _ := createTestUser(t, users, "Dev User", "dev@example.com", "developer", "email")
+
+	form := url.Values{"new_password": {"Another-Passw0rd!"}, "confirm_password": {"Another-Passw0rd!"}}
+	req := httptest.NewRequest(http.MethodPost, "/profile/connected/password", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), Role: "developer"})
+	rec := httptest.NewRecorder()
+	h.handleLinkPassword(rec, testutil.WithCSRFToken(req))
+
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "success=password_linked") {
+		t.Fatalf("Location = %q, want password_linked", loc)
+	}
+	u, err := users.GetByID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !u.HasMethod("password") || u.AuthMethod != "email" {
+		t.Fatalf("methods = %v, want email then password", u.Methods())
+	}
+
+	// Removing the primary method makes the password primary
+	rec = httptest.NewRecorder()
+	h.handleUnlinkMethod(rec, unlinkRequest(userID, "email"))
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "success=unlinked") {
+		t.Fatalf("Location = %q, want unlinked", loc)
+	}
+	u, _ = users.GetByID(ctx, userID)
+	if u.AuthMethod != "password" || len(u.Methods()) != 1 {
+		t.Fatalf("methods = %v, want just password", u.Methods())
+	}
+
+	// The last method can't be removed
+	rec = httptest.NewRecorder()
+	h.handleUnlinkMethod(rec, unlinkRequest(userID, "password"))
+	if loc := rec.Header().Get("Location"); !strings.Contains(loc, "error=last_method") {
+		t.Errorf("Location = %q, want last_method", loc)
+	}
+	if u, _ = users.GetByID(ctx, userID); u.PasswordHash == nil {
+		t.Error("the only sign-in method was removed")
+	}
+}
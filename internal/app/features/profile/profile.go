@@ -6,18 +6,31 @@ package profile
 //   - LoginID / loginID / login_id: The human-readable string users type to log in
 
 import (
+	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/activity"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/backupcodes"
+	devicestore "github.com/dalemusser/stratasave/internal/app/store/devices"
 	"github.com/dalemusser/stratasave/internal/app/store/sessions"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/pwned"
+	"github.com/dalemusser/stratasave/internal/app/system/sessionrotate"
+	"github.com/dalemusser/stratasave/internal/app/system/timezones"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/storage"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/go-chi/chi/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -29,8 +42,18 @@ import (
 type Handler struct {
 	userStore     *userstore.Store
 	sessionsStore *sessions.Store
+	backupCodes   *backupcodes.Store
+	devices       *devicestore.Store
 	errLog        *errorsfeature.ErrorLogger
+	auditLogger   *auditlog.Logger
 	logger        *zap.Logger
+	breaches      *pwned.Checker
+	rotator       *sessionrotate.Rotator
+	fileStorage   storage.Store
+	activity      *activity.Store
+	selfDelete    bool
+	deleteGrace   time.Duration
+	googleLinking bool
 }
 
 // NewHandler creates a new profile Handler.
@@ -38,11 +61,32 @@ func NewHandler(db *mongo.Database, sessionsStore *sessions.Store, errLog *error
 	return &Handler{
 		userStore:     userstore.New(db),
 		sessionsStore: sessionsStore,
+		backupCodes:   backupcodes.New(db),
+		devices:       devicestore.New(db),
+		activity:      activity.New(db),
 		errLog:        errLog,
 		logger:        logger,
 	}
 }
 
+// SetAuditLogger records profile security changes, such as generating
+// backup codes, in the audit log.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
+// SetBreachChecker enables rejecting new passwords that appear in known
+// data breaches. With no checker set, passwords aren't checked.
+func (h *Handler) SetBreachChecker(c *pwned.Checker) {
+	h.breaches = c
+}
+
+// SetSessionRotator sets the rotator used to invalidate the user's other
+// sessions after a password change or new backup codes.
+func (h *Handler) SetSessionRotator(rt *sessionrotate.Rotator) {
+	h.rotator = rt
+}
+
 // ProfileVM is the view model for the profile page.
 type ProfileVM struct {
 	viewdata.BaseVM
@@ -51,16 +95,39 @@ type ProfileVM struct {
 	FullName   string
 	AuthMethod string
 
+	// Profile photo section (only shown when file storage is available)
+	ShowAvatar  bool
+	Avatar      viewdata.Avatar
+	AvatarMaxMB int
+
+	// Connected accounts: the user's sign-in methods and those they can add
+	ConnectedAccounts []connectedAccount
+
 	// Password section (only shown for password auth)
 	ShowPasswordSection bool
 	PasswordRules       string
 
+	// Backup codes section (only shown for email auth)
+	ShowBackupCodes      bool
+	BackupCodesRemaining int64
+
 	// Preferences
 	ThemePreference string // "light", "dark", "system"
+	Locale          string // Email language ("" = default)
+	Locales         []mailer.Locale
+	Timezone        string // Timezone times are shown in
+	TimezoneGroups  []timezones.ZoneGroup
 
 	// Active sessions
 	Sessions []sessionRow
 
+	// Your data section: download and account deletion
+	ShowDeleteAccount  bool
+	DeleteGraceDays    int    // 0 = deleted at once
+	DeleteOn           string // Date a scheduled deletion happens ("" = none scheduled)
+	IsLastAdmin        bool   // The account can't be deleted
+	DeleteConfirmation string // What to type to confirm
+
 	// Form state
 	Success template.HTML
 	Error   template.HTML
@@ -71,19 +138,33 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r := chi.NewRouter()
 
 	r.Get("/", h.showProfile)
-	r.Post("/password", h.handleChangePassword)
+	r.With(sessionMgr.RequireNotImpersonating).Post("/password", h.handleChangePassword)
 	r.Post("/preferences", h.handleUpdatePreferences)
+	r.With(sessionMgr.RequireNotImpersonating).Post("/avatar", h.handleUploadAvatar)
+	r.With(sessionMgr.RequireNotImpersonating).Post("/avatar/remove", h.handleRemoveAvatar)
+	r.With(sessionMgr.RequireNotImpersonating).Get("/export", h.exportData)
+	r.With(sessionMgr.RequireNotImpersonating, sessionMgr.RequireRecentAuth).Post("/delete", h.handleDeleteAccount(sessionMgr))
+	r.With(sessionMgr.RequireNotImpersonating).Post("/delete/cancel", h.handleCancelDeletion)
+	r.With(sessionMgr.RequireNotImpersonating, sessionMgr.RequireRecentAuth).Post("/backup-codes", h.handleGenerateBackupCodes)
+	r.With(sessionMgr.RequireNotImpersonating, sessionMgr.RequireRecentAuth).Post("/connected/password", h.handleLinkPassword)
+	r.With(sessionMgr.RequireNotImpersonating, sessionMgr.RequireRecentAuth).Post("/connected/{method}/unlink", h.handleUnlinkMethod)
 
 	// Session management (sessions are now embedded in profile page)
 	r.Get("/sessions", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/profile", http.StatusSeeOther)
 	})
-	r.Post("/sessions/{id}/revoke", h.revokeSession)
-	r.Post("/sessions/revoke-all", h.revokeAllSessions(sessionMgr))
+	r.With(sessionMgr.RequireNotImpersonating).Post("/sessions/{id}/revoke", h.revokeSession)
+	r.With(sessionMgr.RequireNotImpersonating).Post("/sessions/{id}/name", h.nameDevice)
+	r.With(sessionMgr.RequireNotImpersonating).Post("/sessions/revoke-all", h.revokeAllSessions(sessionMgr))
 
-	// Legacy change password page (redirect to profile)
+	// Legacy change password page (redirect to profile). Login sends users
+	// here with ?required= when their password must be changed.
 	r.Get("/change-password", func(w http.ResponseWriter, r *http.Request) {
-		http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		target := "/profile"
+		if required := r.URL.Query().Get("required"); required != "" {
+			target += "?required=" + url.QueryEscape(required)
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
 	})
 
 	return r
@@ -112,6 +193,13 @@ func (h *Handler) showProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Names the user has given their devices
+	names, err := h.devices.Names(r.Context(), []primitive.ObjectID{sessionUser.UserID()})
+	if err != nil {
+		h.logger.Warn("failed to load device names", zap.Error(err))
+	}
+	deviceNames := names[sessionUser.UserID()]
+
 	currentToken := sessionUser.SessionToken()
 	sessionRows := make([]sessionRow, 0, len(sessionsList))
 	for _, s := range sessionsList {
@@ -119,7 +207,8 @@ func (h *Handler) showProfile(w http.ResponseWriter, r *http.Request) {
 			ID:           s.ID.Hex(),
 			IPAddress:    s.IPAddress,
 			UserAgent:    s.UserAgent,
-			Device:       parseDevice(s.UserAgent),
+			Device:       s.Device().String(),
+			DeviceName:   deviceNames[s.Fingerprint()],
 			LastActivity: s.LastActivity,
 			IsCurrent:    s.Token == currentToken,
 		})
@@ -127,6 +216,31 @@ func (h *Handler) showProfile(w http.ResponseWriter, r *http.Request) {
 
 	vm := buildProfileVM(r, user)
 	vm.Sessions = sessionRows
+	vm.ShowAvatar = h.fileStorage != nil && !vm.Impersonating
+	vm.AvatarMaxMB = maxAvatarUpload >> 20
+	if !vm.Impersonating {
+		vm.ConnectedAccounts = h.connectedAccounts(user)
+	}
+	if h.selfDelete && !vm.Impersonating {
+		vm.ShowDeleteAccount = true
+		vm.DeleteGraceDays = int(h.deleteGrace.Hours() / 24)
+		vm.DeleteConfirmation = deleteConfirmation
+		if user.DeleteAt != nil {
+			vm.DeleteOn = viewdata.FormatTime(r, *user.DeleteAt, "January 2, 2006")
+		}
+		last, err := h.userStore.IsLastActiveAdmin(r.Context(), user)
+		if err != nil {
+			h.logger.Warn("failed to count admins", zap.Error(err))
+		}
+		vm.IsLastAdmin = last
+	}
+	if vm.ShowBackupCodes {
+		remaining, err := h.backupCodes.Remaining(r.Context(), user.ID)
+		if err != nil {
+			h.logger.Warn("failed to count backup codes", zap.Error(err))
+		}
+		vm.BackupCodesRemaining = remaining
+	}
 
 	// Check for success message in query params
 	switch r.URL.Query().Get("success") {
@@ -138,6 +252,22 @@ func (h *Handler) showProfile(w http.ResponseWriter, r *http.Request) {
 		vm.Success = "Session revoked successfully."
 	case "revoked_all":
 		vm.Success = "All other sessions have been logged out."
+	case "device_named":
+		vm.Success = "Device name saved."
+	case "avatar":
+		vm.Success = "Profile photo updated."
+	case "avatar_removed":
+		vm.Success = "Profile photo removed."
+	case "delete_scheduled":
+		vm.Success = template.HTML(fmt.Sprintf("Your account will be deleted on %s. You can cancel until then.", vm.DeleteOn))
+	case "delete_canceled":
+		vm.Success = "Your account will not be deleted."
+	case "password_linked":
+		vm.Success = "Password added. You can now log in with it."
+	case "google_linked":
+		vm.Success = "Google account connected. You can now log in with Google."
+	case "unlinked":
+		vm.Success = "Sign-in method disconnected."
 	}
 
 	// Check for error message in query params
@@ -146,6 +276,42 @@ func (h *Handler) showProfile(w http.ResponseWriter, r *http.Request) {
 		vm.Error = "Use the logout option to end your current session."
 	case "failed":
 		vm.Error = "Failed to revoke session. Please try again."
+	case "name_too_long":
+		vm.Error = template.HTML(fmt.Sprintf("Device names can be at most %d characters.", devicestore.MaxNameLength))
+	case "name_failed":
+		vm.Error = "Failed to save the device name. Please try again."
+	case "avatar_missing":
+		vm.Error = "Choose a photo to upload."
+	case "avatar_too_large":
+		vm.Error = template.HTML(fmt.Sprintf("Profile photos must be at most %d MB and %d megapixels.", maxAvatarUpload>>20, maxAvatarPixels/1_000_000))
+	case "avatar_invalid":
+		vm.Error = "Profile photos must be JPEG, PNG, or GIF images."
+	case "avatar_failed":
+		vm.Error = "Failed to save the profile photo. Please try again."
+	case "delete_confirm":
+		vm.Error = template.HTML(fmt.Sprintf("Type %s to confirm deleting your account.", deleteConfirmation))
+	case "delete_last_admin":
+		vm.Error = "You are the only active admin. Make another user an admin before deleting your account."
+	case "delete_failed":
+		vm.Error = "Failed to update your account. Please try again."
+	case "google_failed":
+		vm.Error = "Failed to connect your Google account. Please try again."
+	case "google_in_use":
+		vm.Error = "That Google account is connected to another user."
+	case "last_method":
+		vm.Error = "You can't disconnect your only way to log in. Connect another method first."
+	case "unlink_failed":
+		vm.Error = "Failed to disconnect the sign-in method. Please try again."
+	}
+
+	// Explain why login sent the user here
+	if vm.ShowPasswordSection {
+		switch r.URL.Query().Get("required") {
+		case "expired":
+			vm.Error = "Your password has expired. Please choose a new one."
+		case "1":
+			vm.Error = "You must change your temporary password before continuing."
+		}
 	}
 
 	templates.Render(w, r, "profile/show", vm)
@@ -172,8 +338,8 @@ func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Only allow password change for password auth users
-	if user.AuthMethod != "password" {
+	// Only allow password change for users who log in with a password
+	if !user.HasMethod("password") {
 		renderProfileWithError(w, r, user, "Password change is only available for password authentication.")
 		return
 	}
@@ -208,6 +374,11 @@ func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.breaches.IsBreached(r.Context(), newPassword) {
+		renderProfileWithError(w, r, user, authutil.ErrPasswordBreached.Error())
+		return
+	}
+
 	// Hash and save the new password
 	hash, err := authutil.HashPassword(newPassword)
 	if err != nil {
@@ -226,6 +397,10 @@ func (h *Handler) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.rotator.Rotate(w, r, user.ID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
 	http.Redirect(w, r, "/profile?success=password", http.StatusSeeOther)
 }
 
@@ -261,6 +436,34 @@ func (h *Handler) handleUpdatePreferences(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Unsupported values fall back to the default language
+	locale := strings.TrimSpace(r.FormValue("locale"))
+	if !mailer.IsSupportedLocale(locale) {
+		locale = ""
+	}
+
+	if err := h.userStore.UpdateLocale(r.Context(), sessionUser.UserID(), locale); err != nil {
+		h.errLog.Log(r, "failed to update locale", err)
+
+		user, _ := h.userStore.GetByID(r.Context(), sessionUser.UserID())
+		renderProfileWithError(w, r, user, "Failed to save preferences.")
+		return
+	}
+
+	// Unknown timezones fall back to UTC
+	tz := strings.TrimSpace(r.FormValue("timezone"))
+	if !timezones.Valid(tz) {
+		tz = ""
+	}
+
+	if err := h.userStore.UpdateTimezone(r.Context(), sessionUser.UserID(), tz); err != nil {
+		h.errLog.Log(r, "failed to update timezone", err)
+
+		user, _ := h.userStore.GetByID(r.Context(), sessionUser.UserID())
+		renderProfileWithError(w, r, user, "Failed to save preferences.")
+		return
+	}
+
 	// Set theme preference cookie so the new theme applies immediately on redirect
 	// HttpOnly is false to allow client-side JavaScript to read it for immediate theme application
 	// MaxAge is 1 year (the database is the source of truth, this is just for client-side convenience)
@@ -276,6 +479,60 @@ func (h *Handler) handleUpdatePreferences(w http.ResponseWriter, r *http.Request
 	http.Redirect(w, r, "/profile?success=preferences", http.StatusSeeOther)
 }
 
+// BackupCodesVM is the view model for the page that shows a new set of
+// backup codes.
+type BackupCodesVM struct {
+	viewdata.BaseVM
+	Codes []string
+}
+
+// handleGenerateBackupCodes replaces the user's backup codes with a new set
+// and shows them once. Backup codes let email auth users log in when they
+// can't reach their inbox.
+func (h *Handler) handleGenerateBackupCodes(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), sessionUser.UserID())
+	if err != nil {
+		h.errLog.Log(r, "failed to get user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if !user.HasMethod("email") {
+		renderProfileWithError(w, r, user, "Backup codes are only available for email authentication.")
+		return
+	}
+
+	codes, err := h.backupCodes.Generate(r.Context(), user.ID)
+	if err != nil {
+		h.errLog.Log(r, "failed to generate backup codes", err)
+		renderProfileWithError(w, r, user, "Failed to generate backup codes. Please try again.")
+		return
+	}
+
+	h.auditLogger.LogAuthEvent(r, &user.ID, audit.EventBackupCodesGenerated, true, "")
+
+	if err := h.rotator.Rotate(w, r, user.ID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
+	vm := BackupCodesVM{
+		BaseVM: viewdata.New(r),
+		Codes:  codes,
+	}
+	vm.Title = "Backup Codes"
+	vm.BackURL = "/profile"
+
+	// The codes are shown only once; keep them out of caches and history
+	w.Header().Set("Cache-Control", "no-store")
+	templates.Render(w, r, "profile/backup_codes", vm)
+}
+
 // buildProfileVM creates the profile view model from a user.
 func buildProfileVM(r *http.Request, user *models.User) ProfileVM {
 	themePreference := user.ThemePreference
@@ -283,13 +540,31 @@ func buildProfileVM(r *http.Request, user *models.User) ProfileVM {
 		themePreference = "system"
 	}
 
+	tz := user.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	tzGroups, _ := timezones.Groups()
+
+	labels := make([]string, 0, len(user.Methods()))
+	for _, m := range user.Methods() {
+		labels = append(labels, formatAuthMethod(m))
+	}
+
+	base := viewdata.New(r)
 	return ProfileVM{
-		BaseVM:              viewdata.New(r),
+		BaseVM:              base,
 		FullName:            user.FullName,
-		AuthMethod:          formatAuthMethod(user.AuthMethod),
-		ShowPasswordSection: user.AuthMethod == "password",
+		Avatar:              viewdata.NewAvatar(user.FullName, user.AvatarPath),
+		AuthMethod:          strings.Join(labels, ", "),
+		ShowPasswordSection: user.HasMethod("password") && !base.Impersonating,
+		ShowBackupCodes:     user.HasMethod("email") && !base.Impersonating,
 		PasswordRules:       authutil.PasswordRules(),
 		ThemePreference:     themePreference,
+		Locale:              user.Locale,
+		Locales:             mailer.Locales(),
+		Timezone:            tz,
+		TimezoneGroups:      tzGroups,
 	}
 }
 
@@ -321,7 +596,8 @@ type sessionRow struct {
 	ID           string
 	IPAddress    string
 	UserAgent    string
-	Device       string
+	Device       string // Browser and OS, e.g. "Chrome on Windows"
+	DeviceName   string // Name the user gave the device, if any
 	LastActivity time.Time
 	IsCurrent    bool
 }
@@ -369,6 +645,46 @@ func (h *Handler) revokeSession(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/profile?success=revoked", http.StatusSeeOther)
 }
 
+// nameDevice names the device one of the user's sessions was created from.
+// The name applies to every session from that device, now and later.
+func (h *Handler) nameDevice(w http.ResponseWriter, r *http.Request) {
+	sessionUser, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	session, err := h.sessionsStore.GetByID(r.Context(), objID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if session.UserID != sessionUser.UserID() {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if utf8.RuneCountInString(name) > devicestore.MaxNameLength {
+		http.Redirect(w, r, "/profile?error=name_too_long", http.StatusSeeOther)
+		return
+	}
+
+	if err := h.devices.SetName(r.Context(), sessionUser.UserID(), session.UserAgent, session.IPAddress, name); err != nil {
+		h.errLog.Log(r, "failed to name device", err)
+		http.Redirect(w, r, "/profile?error=name_failed", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/profile?success=device_named", http.StatusSeeOther)
+}
+
 // revokeAllSessions returns a handler that revokes all sessions except the current one.
 func (h *Handler) revokeAllSessions(sessionMgr *auth.SessionManager) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -388,65 +704,3 @@ func (h *Handler) revokeAllSessions(sessionMgr *auth.SessionManager) http.Handle
 		http.Redirect(w, r, "/profile?success=revoked_all", http.StatusSeeOther)
 	}
 }
-
-// parseDevice extracts a simple device description from the user agent string.
-func parseDevice(userAgent string) string {
-	if userAgent == "" {
-		return "Unknown Device"
-	}
-
-	ua := strings.ToLower(userAgent)
-
-	// Detect mobile devices
-	if strings.Contains(ua, "iphone") {
-		return "iPhone"
-	}
-	if strings.Contains(ua, "ipad") {
-		return "iPad"
-	}
-	if strings.Contains(ua, "android") {
-		if strings.Contains(ua, "mobile") {
-			return "Android Phone"
-		}
-		return "Android Tablet"
-	}
-
-	// Detect browsers on desktop
-	if strings.Contains(ua, "windows") {
-		if strings.Contains(ua, "edge") {
-			return "Windows (Edge)"
-		}
-		if strings.Contains(ua, "chrome") {
-			return "Windows (Chrome)"
-		}
-		if strings.Contains(ua, "firefox") {
-			return "Windows (Firefox)"
-		}
-		return "Windows"
-	}
-
-	if strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os") {
-		if strings.Contains(ua, "safari") && !strings.Contains(ua, "chrome") {
-			return "Mac (Safari)"
-		}
-		if strings.Contains(ua, "chrome") {
-			return "Mac (Chrome)"
-		}
-		if strings.Contains(ua, "firefox") {
-			return "Mac (Firefox)"
-		}
-		return "Mac"
-	}
-
-	if strings.Contains(ua, "linux") {
-		if strings.Contains(ua, "chrome") {
-			return "Linux (Chrome)"
-		}
-		if strings.Contains(ua, "firefox") {
-			return "Linux (Firefox)"
-		}
-		return "Linux"
-	}
-
-	return "Unknown Device"
-}
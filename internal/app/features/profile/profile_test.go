@@ -62,37 +62,6 @@ func createTestUser(t *testing.T, users *userstore.Store, name, email, role, aut
 	return user.ID, email
 }
 
-func TestParseDevice(t *testing.T) {
-	tests := []struct {
-		name      string
-		userAgent string
-		want      string
-	}{
-		{"empty", "", "Unknown Device"},
-		{"iphone", "Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X)", "iPhone"},
-		{"ipad", "Mozilla/5.0 (iPad; CPU OS 14_0 like Mac OS X)", "iPad"},
-		{"android_phone", "Mozilla/5.0 (Linux; Android 10; Mobile)", "Android Phone"},
-		{"android_tablet", "Mozilla/5.0 (Linux; Android 10; Tablet)", "Android Tablet"},
-		{"windows_chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/90", "Windows (Chrome)"},
-		{"windows_firefox", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:88.0) Firefox/88", "Windows (Firefox)"},
-		{"windows_edge", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Edge/90", "Windows (Edge)"},
-		{"mac_safari", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) Safari/605.1.15", "Mac (Safari)"},
-		{"mac_chrome", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) Chrome/90", "Mac (Chrome)"},
-		{"mac_firefox", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:88.0) Firefox/88", "Mac (Firefox)"},
-		{"linux_chrome", "Mozilla/5.0 (X11; Linux x86_64) Chrome/90", "Linux (Chrome)"},
-		{"linux_firefox", "Mozilla/5.0 (X11; Linux x86_64; rv:88.0) Firefox/88", "Linux (Firefox)"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parseDevice(tt.userAgent)
-			if got != tt.want {
-				t.Errorf("parseDevice(%q) = %q, want %q", tt.userAgent, got, tt.want)
-			}
-		})
-	}
-}
-
 func TestFormatAuthMethod(t *testing.T) {
 	tests := []struct {
 		method string
@@ -462,6 +431,39 @@ func TestUpdatePreferences_InvalidTheme(t *testing.T) {
 	}
 }
 
+func TestUpdatePreferences_Timezone(t *testing.T) {
+	h, _, users, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, email := createTestUser(t, users, "Test User", "tz@example.com", "admin", "password")
+
+	tests := []struct {
+		tz   string
+		want string
+	}{
+		{"America/Chicago", "America/Chicago"},
+		{"Mars/Olympus_Mons", ""}, // unknown zones fall back to UTC
+	}
+	for _, tt := range tests {
+		form := url.Values{"theme_preference": {"system"}, "timezone": {tt.tz}}
+		req := httptest.NewRequest(http.MethodPost, "/profile/preferences", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req = auth.WithTestUser(req, &auth.SessionUser{ID: userID.Hex(), LoginID: email, Role: "admin"})
+		rec := httptest.NewRecorder()
+
+		h.handleUpdatePreferences(rec, req)
+
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			t.Fatalf("failed to get user: %v", err)
+		}
+		if user.Timezone != tt.want {
+			t.Errorf("timezone %q: Timezone = %q, want %q", tt.tz, user.Timezone, tt.want)
+		}
+	}
+}
+
 func TestRevokeSession_Success(t *testing.T) {
 	h, _, users, sessionsStore := newTestHandler(t)
 	ctx, cancel := testutil.TestContext()
@@ -708,6 +710,62 @@ func TestRevokeAllSessions_Success(t *testing.T) {
 	}
 }
 
+func TestGenerateBackupCodes_Success(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _, users, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, email := createTestUser(t, users, "Email User", "email@example.com", "developer", "email")
+
+	req := httptest.NewRequest(http.MethodPost, "/profile/backup-codes", nil)
+	req = auth.WithTestUser(req, &auth.SessionUser{
+		ID:      userID.Hex(),
+		Name:    "Email User",
+		LoginID: email,
+		Role:    "developer",
+	})
+	req = testutil.WithCSRFToken(req)
+	rec := httptest.NewRecorder()
+
+	h.handleGenerateBackupCodes(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Error("backup codes page should not be cached")
+	}
+	if n, _ := h.backupCodes.Remaining(ctx, userID); n != 10 {
+		t.Errorf("Remaining() = %d, want 10", n)
+	}
+}
+
+func TestGenerateBackupCodes_PasswordUser(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _, users, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID, email := createTestUser(t, users, "Password User", "pw@example.com", "developer", "password")
+
+	req := httptest.NewRequest(http.MethodPost, "/profile/backup-codes", nil)
+	req = auth.WithTestUser(req, &auth.SessionUser{
+		ID:      userID.Hex(),
+		Name:    "Password User",
+		LoginID: email,
+		Role:    "developer",
+	})
+	req = testutil.WithCSRFToken(req)
+	rec := httptest.NewRecorder()
+
+	h.handleGenerateBackupCodes(rec, req)
+
+	if n, _ := h.backupCodes.Remaining(ctx, userID); n != 0 {
+		t.Errorf("password user got %d backup codes, want 0", n)
+	}
+}
+
 func TestRoutes(t *testing.T) {
 	h, _, _, _ := newTestHandler(t)
 	logger := zap.NewNop()
@@ -0,0 +1,277 @@
+// internal/app/features/ratelimits/handler.go
+package ratelimitsfeature
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	ipblockstore "github.com/dalemusser/stratasave/internal/app/store/ipblocks"
+	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/suspicious"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/dalemusser/waffle/pantry/text"
+	"github.com/dalemusser/waffle/pantry/urlutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// listLimit caps the rows shown. Records expire a day after the last
+// attempt, so the list only grows this long during an attack.
+const listLimit = 200
+
+// reauthPrefix marks the keys the reauth feature uses for failed identity
+// confirmations; the rest of the key is the user's ID.
+const reauthPrefix = "reauth:"
+
+// Handler serves the login lockout admin page.
+type Handler struct {
+	Store       *ratelimit.Store // nil when rate limiting is disabled
+	Blocks      *ipblockstore.Store
+	Users       *userstore.Store
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+}
+
+// NewHandler creates a new rate limit handler. store may be nil.
+func NewHandler(db *mongo.Database, store *ratelimit.Store, errLog *errorsfeature.ErrorLogger, auditLogger *auditlog.Logger, logger *zap.Logger) *Handler {
+	return &Handler{
+		Store:       store,
+		Blocks:      ipblockstore.New(db),
+		Users:       userstore.New(db),
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// ServeList handles GET /rate-limits - list login IDs that are locked out or
+// have recent failed attempts, and IPs blocked from logging in.
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	vm := ListVM{
+		BaseVM:    viewdata.New(r),
+		Enabled:   h.Store != nil,
+		Cleared:   r.URL.Query().Get("cleared"),
+		Unblocked: r.URL.Query().Get("unblocked"),
+	}
+	vm.Title = "Login Lockouts"
+	vm.BackURL = "/dashboard"
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	if h.Blocks != nil {
+		blocks, err := h.Blocks.ListActive(ctx, listLimit)
+		if err != nil {
+			h.ErrLog.Log(r, "failed to list ip blocks", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		vm.Blocks = make([]BlockVM, len(blocks))
+		for i, b := range blocks {
+			vm.Blocks[i] = BlockVM{
+				IP:           b.IP,
+				Reason:       blockReasonLabel(b.Reason),
+				BlockedAt:    b.CreatedAt.Format("2006-01-02 15:04:05"),
+				BlockedUntil: b.BlockedUntil.Format("2006-01-02 15:04:05"),
+			}
+		}
+	}
+
+	if h.Store == nil {
+		templates.Render(w, r, "ratelimits/list", vm)
+		return
+	}
+
+	attempts, err := h.Store.ListActive(ctx, listLimit)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to list rate limits", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	users, err := h.resolveUsers(ctx, attempts)
+	if err != nil {
+		// The list is still useful without names
+		h.Log.Warn("failed to resolve rate-limited users", zap.Error(err))
+	}
+
+	maxAttempts, window, lockout := h.Store.Limits()
+	vm.MaxAttempts = maxAttempts
+	vm.Window = shortDuration(window)
+	vm.Lockout = shortDuration(lockout)
+
+	now := time.Now()
+	vm.Attempts = make([]AttemptVM, len(attempts))
+	for i, a := range attempts {
+		row := AttemptVM{
+			Key:         a.LoginID,
+			LoginID:     a.LoginID,
+			Kind:        "Login",
+			Attempts:    a.AttemptCount,
+			Locked:      a.IsLocked(now),
+			LastAttempt: a.LastAttempt.Format("2006-01-02 15:04:05"),
+		}
+		if strings.HasPrefix(a.LoginID, reauthPrefix) {
+			row.LoginID = ""
+			row.Kind = "Identity confirmation"
+		}
+		if row.Locked {
+			row.LockedUntil = a.LockedUntil.Format("2006-01-02 15:04:05")
+		}
+		if u, ok := users[a.LoginID]; ok {
+			row.UserID = u.ID.Hex()
+			row.UserName = u.FullName
+		}
+		vm.Attempts[i] = row
+	}
+
+	templates.Render(w, r, "ratelimits/list", vm)
+}
+
+// HandleClear handles POST /rate-limits/clear - remove the record for a
+// login ID, lifting its lockout and resetting its failed attempts. The
+// form may carry a return path, as the user detail page does.
+func (h *Handler) HandleClear(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimSpace(r.FormValue("login_id"))
+	if h.Store == nil || key == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	attempt, err := h.Store.GetAttempt(ctx, key)
+	if err == nil && attempt != nil {
+		err = h.Store.Clear(ctx, key)
+	}
+	if err != nil {
+		h.ErrLog.Log(r, "failed to clear rate limit", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if attempt != nil {
+		var targetID *primitive.ObjectID
+		if users, _ := h.resolveUsers(ctx, []ratelimit.Attempt{*attempt}); users[attempt.LoginID] != nil {
+			targetID = &users[attempt.LoginID].ID
+		}
+		actor, _ := auth.CurrentUser(r)
+		actorID := actor.UserID()
+		h.AuditLogger.LogAdminEvent(r, &actorID, targetID, audit.EventRateLimitCleared, map[string]string{
+			"login_id": attempt.LoginID,
+			"locked":   strconv.FormatBool(attempt.IsLocked(time.Now())),
+		})
+	}
+
+	dest := "/rate-limits?cleared=" + url.QueryEscape(key)
+	http.Redirect(w, r, urlutil.SafeReturn(r.FormValue("return"), "", dest), http.StatusSeeOther)
+}
+
+// HandleUnblock handles POST /rate-limits/unblock - lift the block on an IP
+// raised by suspicious activity detection.
+func (h *Handler) HandleUnblock(w http.ResponseWriter, r *http.Request) {
+	ip := strings.TrimSpace(r.FormValue("ip"))
+	if h.Blocks == nil || ip == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	removed, err := h.Blocks.Unblock(ctx, ip)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to unblock ip", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if removed {
+		actor, _ := auth.CurrentUser(r)
+		actorID := actor.UserID()
+		h.AuditLogger.LogAdminEvent(r, &actorID, nil, audit.EventIPUnblocked, map[string]string{
+			"ip": ip,
+		})
+	}
+
+	http.Redirect(w, r, "/rate-limits?unblocked="+url.QueryEscape(ip), http.StatusSeeOther)
+}
+
+// blockReasonLabel describes the detection rule behind an IP block.
+func blockReasonLabel(reason string) string {
+	switch reason {
+	case suspicious.ReasonFailedLogins:
+		return "Failed logins across many accounts"
+	}
+	return reason
+}
+
+// resolveUsers maps rate limit keys to the users they belong to. Login keys
+// match on login ID; identity confirmation keys carry the user ID.
+func (h *Handler) resolveUsers(ctx context.Context, attempts []ratelimit.Attempt) (map[string]*models.User, error) {
+	byKey := make(map[string]*models.User, len(attempts))
+
+	var folded []string
+	var ids []primitive.ObjectID
+	for _, a := range attempts {
+		if hex, ok := strings.CutPrefix(a.LoginID, reauthPrefix); ok {
+			if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+				ids = append(ids, id)
+			}
+			continue
+		}
+		folded = append(folded, text.Fold(a.LoginID))
+	}
+
+	if len(folded) > 0 {
+		users, err := h.Users.Find(ctx, bson.M{"login_id_ci": bson.M{"$in": folded}})
+		if err != nil {
+			return byKey, err
+		}
+		for i := range users {
+			if users[i].LoginID != nil {
+				byKey[strings.ToLower(*users[i].LoginID)] = &users[i]
+			}
+		}
+	}
+	if len(ids) > 0 {
+		users, err := h.Users.GetByIDs(ctx, ids)
+		if err != nil {
+			return byKey, err
+		}
+		for i := range users {
+			byKey[reauthPrefix+users[i].ID.Hex()] = &users[i]
+		}
+	}
+	return byKey, nil
+}
+
+// shortDuration formats d as "15m" or "1h30m".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+package ratelimitsfeature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func clearRequest(form url.Values) *http.Request {
+	req := testutil.NewAuthenticatedRequestWithCSRF(http.MethodPost, "/rate-limits/clear", testutil.AdminUser())
+	req.Body = httpBody(form)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func httpBody(form url.Values) *readCloser {
+	return &readCloser{strings.NewReader(form.Encode())}
+}
+
+type readCloser struct{ *strings.Reader }
+
+func (readCloser) Close() error { return nil }
+
+func TestServeList_Disabled(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h := &Handler{Log: zap.NewNop()}
+
+	rec := httptest.NewRecorder()
+	h.ServeList(rec, testutil.NewAuthenticatedRequest(http.MethodGet, "/rate-limits", testutil.AdminUser()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "rate_limit_enabled") {
+		t.Error("page should say rate limiting is disabled")
+	}
+}
+
+func TestHandleClear_Disabled(t *testing.T) {
+	h := &Handler{Log: zap.NewNop()}
+
+	rec := httptest.NewRecorder()
+	h.HandleClear(rec, clearRequest(url.Values{"login_id": {"someone@example.com"}}))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleClear_Unlocks(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := ratelimit.New(db, 2, 15*time.Minute, 30*time.Minute)
+	h := NewHandler(db, store, nil, nil, zap.NewNop())
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	user, err := h.Users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName: "Locked", LoginID: "locked@example.com", Email: "locked@example.com", AuthMethod: "password", Role: "developer",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	store.RecordFailure(ctx, "locked@example.com")
+	store.RecordFailure(ctx, "locked@example.com")
+	store.RecordFailure(ctx, "reauth:"+user.ID.Hex())
+
+	users, err := h.resolveUsers(ctx, []ratelimit.Attempt{{LoginID: "locked@example.com"}, {LoginID: "reauth:" + user.ID.Hex()}})
+	if err != nil {
+		t.Fatalf("resolveUsers() error = %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("resolveUsers() matched %d keys, want 2", len(users))
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleClear(rec, clearRequest(url.Values{
+		"login_id": {"locked@example.com"},
+		"return":   {"/system-users/" + user.ID.Hex()},
+	}))
+
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/system-users/"+user.ID.Hex() {
+		t.Errorf("got %d %q, want redirect to the user page", rec.Code, rec.Header().Get("Location"))
+	}
+	if allowed, _, _ := store.CheckAllowed(ctx, "locked@example.com"); !allowed {
+		t.Error("login ID still locked after clear")
+	}
+}
+
+func TestShortDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		15 * time.Minute: "15m",
+		90 * time.Minute: "1h30m",
+		2 * time.Hour:    "2h",
+		45 * time.Second: "45s",
+	}
+	for d, want := range tests {
+		if got := shortDuration(d); got != want {
+			t.Errorf("shortDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// internal/app/features/ratelimits/routes.go
+package ratelimitsfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the login lockout admin page.
+// Access is restricted to admins.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin"))
+
+	r.Get("/", h.ServeList)
+	r.Post("/clear", h.HandleClear)
+	r.Post("/unblock", h.HandleUnblock)
+
+	return r
+}
@@ -0,0 +1,19 @@
+// internal/app/features/ratelimits/templates.go
+package ratelimitsfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "ratelimits",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
@@ -0,0 +1,41 @@
+// internal/app/features/ratelimits/types.go
+package ratelimitsfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// AttemptVM is the view model for one rate-limited login ID.
+type AttemptVM struct {
+	Key         string // Rate limit key, posted back to clear it
+	LoginID     string // What was typed at login (empty for confirmation keys)
+	Kind        string // "Login" or "Identity confirmation"
+	UserID      string // Matching user, if any
+	UserName    string
+	Attempts    int
+	Locked      bool
+	LockedUntil string
+	LastAttempt string
+}
+
+// BlockVM is the view model for one IP blocked by suspicious activity
+// detection.
+type BlockVM struct {
+	IP           string
+	Reason       string
+	BlockedAt    string
+	BlockedUntil string
+}
+
+// ListVM is the view model for the lockout list page.
+type ListVM struct {
+	viewdata.BaseVM
+	Enabled     bool // false when rate_limit_enabled is off
+	Attempts    []AttemptVM
+	MaxAttempts int
+	Window      string
+	Lockout     string
+	Cleared     string // Login ID just cleared, for the notice
+	Blocks      []BlockVM
+	Unblocked   string // IP just unblocked, for the notice
+}
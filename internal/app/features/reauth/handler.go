@@ -0,0 +1,262 @@
+// internal/app/features/reauth/handler.go
+package reauthfeature
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/emailverify"
+	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/query"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/dalemusser/waffle/pantry/urlutil"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Handler lets a signed-in user confirm their identity before a sensitive
+// action guarded by auth.RequireRecentAuth.
+type Handler struct {
+	Users       *userstore.Store
+	Verify      *emailverify.Store
+	SessionMgr  *auth.SessionManager
+	RateLimit   *ratelimit.Store // nil if rate limiting is disabled
+	Mailer      *mailer.Mailer   // nil if email is not configured; code confirmation is then unavailable
+	BaseURL     string
+	Flags       *featureflags.Flags // trust_login lets trust users confirm without credentials
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+}
+
+// NewHandler creates a new reauth handler. Emailed codes expire after
+// codeExpiry.
+func NewHandler(
+	db *mongo.Database,
+	sessionMgr *auth.SessionManager,
+	rateLimit *ratelimit.Store,
+	m *mailer.Mailer,
+	baseURL string,
+	codeExpiry time.Duration,
+	flags *featureflags.Flags,
+	errLog *errorsfeature.ErrorLogger,
+	auditLogger *auditlog.Logger,
+	logger *zap.Logger,
+) *Handler {
+	if codeExpiry == 0 {
+		codeExpiry = 10 * time.Minute
+	}
+	return &Handler{
+		Users:       userstore.New(db),
+		Verify:      emailverify.New(db, codeExpiry),
+		SessionMgr:  sessionMgr,
+		RateLimit:   rateLimit,
+		Mailer:      m,
+		BaseURL:     baseURL,
+		Flags:       flags,
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// SetEmailCodePolicy applies the email code limits used at login to the
+// confirmation codes sent here: whether a new code replaces earlier ones,
+// how often codes can be resent, and how many wrong codes may be entered.
+func (h *Handler) SetEmailCodePolicy(p emailverify.Policy) {
+	h.Verify.SetPolicy(p)
+}
+
+// method returns how the user confirms their identity and, for emailed
+// codes, the address the code goes to.
+func (h *Handler) method(ctx context.Context, user *models.User) (method, email string) {
+	// A linked password is the quickest way to confirm, whatever the
+	// primary method
+	if user.HasMethod("password") && user.PasswordHash != nil {
+		return methodPassword, ""
+	}
+	switch user.AuthMethod {
+	case "trust":
+		if h.Flags.Enabled(ctx, featureflags.TrustLogin, user.ID.Hex()) {
+			return methodTrust, ""
+		}
+		return "", ""
+	}
+
+	// Email and Google users (and password users who haven't set one yet)
+	// get a code at their email address
+	if h.Mailer == nil {
+		return "", ""
+	}
+	if user.Email != nil && *user.Email != "" {
+		return methodCode, *user.Email
+	}
+	if user.AuthMethod == "email" && user.LoginID != nil {
+		return methodCode, *user.LoginID
+	}
+	return "", ""
+}
+
+// rateLimitKey keeps failed confirmations apart from failed logins, so one
+// can't lock out the other.
+func rateLimitKey(user *models.User) string {
+	return "reauth:" + user.ID.Hex()
+}
+
+// render shows the confirmation page for the user.
+func (h *Handler) render(w http.ResponseWriter, r *http.Request, user *models.User, returnURL string, codeSent bool, errMsg string) {
+	vm := ConfirmVM{
+		BaseVM:    viewdata.New(r),
+		CodeSent:  codeSent,
+		ReturnURL: urlutil.SafeReturn(returnURL, "", "/dashboard"),
+		Error:     errMsg,
+	}
+	vm.Method, vm.Email = h.method(r.Context(), user)
+	vm.Title = "Confirm It's You"
+	templates.Render(w, r, "reauth/confirm", vm)
+}
+
+// currentUser loads the signed-in user's account.
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) *models.User {
+	su, _ := auth.CurrentUser(r)
+	user, err := h.Users.GetByID(r.Context(), su.UserID())
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load user for reauth", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil
+	}
+	return user
+}
+
+// ServeConfirm handles GET /reauth - show the confirmation form.
+func (h *Handler) ServeConfirm(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	h.render(w, r, user, query.Get(r, "return"), false, "")
+}
+
+// HandleSendCode handles POST /reauth/send-code - email a confirmation code.
+func (h *Handler) HandleSendCode(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	returnURL := r.FormValue("return")
+
+	method, email := h.method(r.Context(), user)
+	if method != methodCode {
+		h.render(w, r, user, returnURL, false, "")
+		return
+	}
+
+	v, err := h.Verify.Create(r.Context(), email, user.ID)
+	if err == emailverify.ErrTooSoon {
+		h.render(w, r, user, returnURL, true, "A code was sent moments ago. Please check your email or wait before requesting another.")
+		return
+	}
+	if err != nil {
+		h.ErrLog.Log(r, "failed to create reauth code", err)
+		h.render(w, r, user, returnURL, false, "Failed to send the code. Please try again.")
+		return
+	}
+
+	// No magic link: it would log in, not confirm, and the code must be
+	// entered on the reauth page
+	text, html := mailer.LoginCodeEmail(mailer.LoginCodeEmailData{
+		Locale:  user.Locale,
+		Brand:   h.Mailer.Brand(r.Context()),
+		AppName: h.Mailer.FromName(),
+		Code:    v.Code,
+	})
+	if err := h.Mailer.Send(mailer.Email{
+		To:       email,
+		Subject:  mailer.T(user.Locale, "login_code.subject"),
+		Template: "login_code",
+		UserID:   user.ID.Hex(),
+		TextBody: text,
+		HTMLBody: html,
+	}); err != nil {
+		h.ErrLog.Log(r, "failed to send reauth code", err)
+		h.render(w, r, user, returnURL, false, "Failed to send the code. Please try again.")
+		return
+	}
+
+	h.AuditLogger.LogAuthEvent(r, &user.ID, "verification_code_sent", true, "")
+	h.render(w, r, user, returnURL, true, "")
+}
+
+// HandleConfirm handles POST /reauth - check the password or code and send
+// the user back to what they were doing.
+func (h *Handler) HandleConfirm(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	returnURL := r.FormValue("return")
+	key := rateLimitKey(user)
+
+	if h.RateLimit != nil {
+		if allowed, _, _ := h.RateLimit.CheckAllowed(r.Context(), key); !allowed {
+			h.AuditLogger.LogAuthEvent(r, &user.ID, "reauth_failed", false, "rate limited")
+			h.render(w, r, user, returnURL, false, "Too many failed attempts. Please try again later.")
+			return
+		}
+	}
+
+	method, email := h.method(r.Context(), user)
+	if method == "" {
+		h.render(w, r, user, returnURL, false, "")
+		return
+	}
+
+	ok := false
+	switch method {
+	case methodPassword:
+		ok = authutil.CheckPassword(r.FormValue("password"), *user.PasswordHash)
+	case methodCode:
+		code := strings.TrimSpace(r.FormValue("code"))
+		if v, err := h.Verify.VerifyCode(r.Context(), email, code); err == nil && v.UserID == user.ID {
+			_ = h.Verify.MarkUsed(r.Context(), v.ID)
+			ok = true
+		}
+	case methodTrust:
+		ok = true
+	}
+
+	if !ok {
+		if h.RateLimit != nil {
+			h.RateLimit.RecordFailure(r.Context(), key)
+		}
+		h.AuditLogger.LogAuthEvent(r, &user.ID, "reauth_failed", false, "invalid "+method)
+		msg := "Incorrect password."
+		if method == methodCode {
+			msg = "Invalid or expired code."
+		}
+		h.render(w, r, user, returnURL, method == methodCode, msg)
+		return
+	}
+
+	if h.RateLimit != nil {
+		h.RateLimit.ClearOnSuccess(r.Context(), key)
+	}
+	if err := h.SessionMgr.MarkReauthenticated(w, r); err != nil {
+		h.ErrLog.Log(r, "failed to record reauthentication", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.AuditLogger.LogAuthEvent(r, &user.ID, "reauth_success", true, "")
+	http.Redirect(w, r, urlutil.SafeReturn(returnURL, "", "/dashboard"), http.StatusSeeOther)
+}
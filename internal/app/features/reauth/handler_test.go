@@ -0,0 +1,107 @@
+package reauthfeature
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	logger := zap.NewNop()
+
+	sessionMgr, err := auth.NewSessionManager(
+		"test-session-key-for-testing-1234567890",
+		"test-session",
+		"",
+		24*time.Hour,
+		false,
+		logger,
+	)
+	if err != nil {
+		t.Fatalf("failed to create session manager: %v", err)
+	}
+	sessionMgr.SetReauthWindow(10 * time.Minute)
+
+	return NewHandler(db, sessionMgr, nil, nil, "http://localhost:8080", 10*time.Minute, nil, nil, nil, logger)
+}
+
+func passwordUser(t *testing.T, h *Handler) testutil.TestUser {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	hash, err := authutil.HashPassword("Correct-Horse-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := h.Users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName: "Ada Admin", LoginID: "ada@example.com", AuthMethod: "password", Role: "admin", PasswordHash: &hash,
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return testutil.TestUser{ID: user.ID.Hex(), Name: user.FullName, Role: user.Role}
+}
+
+func confirmRequest(user testutil.TestUser, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/reauth", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return testutil.WithUser(testutil.WithCSRFToken(req), user)
+}
+
+func TestHandleConfirm_Password(t *testing.T) {
+	h := newTestHandler(t)
+	user := passwordUser(t, h)
+
+	rec := httptest.NewRecorder()
+	h.HandleConfirm(rec, confirmRequest(user, url.Values{"password": {"Correct-Horse-9"}, "return": {"/settings"}}))
+
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/settings" {
+		t.Fatalf("got %d %q, want redirect to /settings", rec.Code, rec.Header().Get("Location"))
+	}
+	if len(rec.Result().Cookies()) == 0 {
+		t.Error("confirmation was not saved to the session")
+	}
+}
+
+func TestHandleConfirm_WrongPassword(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h := newTestHandler(t)
+	user := passwordUser(t, h)
+
+	rec := httptest.NewRecorder()
+	h.HandleConfirm(rec, confirmRequest(user, url.Values{"password": {"wrong"}, "return": {"/settings"}}))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if !strings.Contains(rec.Body.String(), "Incorrect password") {
+		t.Error("expected an incorrect password message")
+	}
+	if len(rec.Result().Cookies()) > 0 {
+		t.Error("a failed confirmation should not touch the session")
+	}
+}
+
+func TestHandleConfirm_UnsafeReturn(t *testing.T) {
+	h := newTestHandler(t)
+	user := passwordUser(t, h)
+
+	rec := httptest.NewRecorder()
+	h.HandleConfirm(rec, confirmRequest(user, url.Values{"password": {"Correct-Horse-9"}, "return": {"https://evil.example/"}}))
+
+	if got := rec.Header().Get("Location"); got != "/dashboard" {
+		t.Errorf("Location = %q, want /dashboard", got)
+	}
+}
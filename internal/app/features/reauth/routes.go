@@ -0,0 +1,21 @@
+// internal/app/features/reauth/routes.go
+package reauthfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the identity confirmation routes for signed-in users.
+// Confirming while impersonating isn't allowed: the impersonated user's
+// credentials don't belong to the admin.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireSignedIn, sm.RequireNotImpersonating)
+
+	r.Get("/", h.ServeConfirm)
+	r.Post("/", h.HandleConfirm)
+	r.Post("/send-code", h.HandleSendCode)
+
+	return r
+}
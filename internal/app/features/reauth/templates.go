@@ -0,0 +1,19 @@
+// internal/app/features/reauth/templates.go
+package reauthfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "reauth",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
@@ -0,0 +1,21 @@
+// internal/app/features/reauth/types.go
+package reauthfeature
+
+import "github.com/dalemusser/stratasave/internal/app/system/viewdata"
+
+// How a user confirms their identity, by auth method.
+const (
+	methodPassword = "password" // Enter their password
+	methodCode     = "code"     // Enter a code emailed to them
+	methodTrust    = "trust"    // Dev-mode trust login: just confirm
+)
+
+// ConfirmVM is the view model for the identity confirmation page.
+type ConfirmVM struct {
+	viewdata.BaseVM
+	Method    string // methodPassword, methodCode, or methodTrust; empty if the user can't confirm
+	Email     string // Where the code is sent (methodCode)
+	CodeSent  bool
+	ReturnURL string
+	Error     string
+}
@@ -0,0 +1,591 @@
+// internal/app/features/registration/handler.go
+package registrationfeature
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/emailverify"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/featureflags"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/app/system/newdevice"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// Handler serves public self-service registration and the admin queue for
+// approving the accounts it creates.
+type Handler struct {
+	Users       *userstore.Store
+	Settings    *settingsstore.Store
+	Verify      *emailverify.Store
+	SessionMgr  *auth.SessionManager
+	Sessions    *sessions.Store
+	Mailer      *mailer.Mailer // nil if email is not configured; registration is then unavailable
+	BaseURL     string
+	LinkExpiry  time.Duration
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+	Captcha     *captcha.Verifier    // nil if CAPTCHA is disabled
+	NewDevices  *newdevice.Notifier  // nil if login devices aren't tracked
+	Webhooks    *webhooks.Dispatcher // nil if user.created events aren't sent
+	Flags       *featureflags.Flags  // self_registration turns the signup form on and off
+}
+
+// NewHandler creates a new registration handler. Verification links expire
+// after linkExpiry.
+func NewHandler(
+	db *mongo.Database,
+	sessionMgr *auth.SessionManager,
+	sessionsStore *sessions.Store,
+	m *mailer.Mailer,
+	baseURL string,
+	linkExpiry time.Duration,
+	errLog *errorsfeature.ErrorLogger,
+	auditLogger *auditlog.Logger,
+	logger *zap.Logger,
+) *Handler {
+	if linkExpiry == 0 {
+		linkExpiry = 10 * time.Minute
+	}
+	return &Handler{
+		Users:       userstore.New(db),
+		Settings:    settingsstore.New(db),
+		Verify:      emailverify.New(db, linkExpiry),
+		SessionMgr:  sessionMgr,
+		Sessions:    sessionsStore,
+		Mailer:      m,
+		BaseURL:     baseURL,
+		LinkExpiry:  linkExpiry,
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// openSettings returns the site settings if registration is open, or nil if
+// it is turned off, its feature flag is off for the request, or email isn't
+// configured to verify new addresses.
+func (h *Handler) openSettings(ctx context.Context, r *http.Request) *models.SiteSettings {
+	if h.Mailer == nil || !h.Flags.Enabled(ctx, featureflags.SelfRegistration, featureflags.Subject(r)) {
+		return nil
+	}
+	settings, err := h.Settings.Get(ctx)
+	if err != nil || !settings.RegistrationEnabled {
+		return nil
+	}
+	return settings
+}
+
+// ServeRegister handles GET /register - the signup form.
+func (h *Handler) ServeRegister(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	if h.openSettings(ctx, r) == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if _, ok := auth.CurrentUser(r); ok {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	vm := RegisterVM{BaseVM: viewdata.New(r), Captcha: h.Captcha.Widget()}
+	vm.Title = "Create an Account"
+	templates.Render(w, r, "registration/register", vm)
+}
+
+// HandleRegister handles POST /register - email a verification link to the
+// address. The response is the same whether or not an account already uses
+// the address, so the form can't be used to discover who has an account.
+func (h *Handler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	settings := h.openSettings(ctx, r)
+	if settings == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	email := strings.TrimSpace(strings.ToLower(r.FormValue("email")))
+
+	vm := RegisterVM{BaseVM: viewdata.New(r), Email: email, Captcha: h.Captcha.Widget()}
+	vm.Title = "Create an Account"
+
+	if _, err := mail.ParseAddress(email); err != nil {
+		vm.Error = "Please enter a valid email address"
+		templates.Render(w, r, "registration/register", vm)
+		return
+	}
+
+	if !settings.EmailDomainAllowed(email) {
+		vm.Error = domainNotAllowed(settings)
+		templates.Render(w, r, "registration/register", vm)
+		return
+	}
+
+	if !h.Captcha.Verify(r) {
+		h.AuditLogger.LogAuthEvent(r, nil, "captcha_failed", false, "registration for "+email)
+		vm.Error = "Please complete the CAPTCHA challenge."
+		templates.Render(w, r, "registration/register", vm)
+		return
+	}
+
+	exists, err := h.accountExists(ctx, email)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to check existing account", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if exists {
+		h.Log.Info("registration requested for existing account", zap.String("email", email))
+	} else {
+		// Registration links have no user yet, so UserID is left zero
+		v, err := h.Verify.Create(ctx, email, primitive.NilObjectID)
+		if err != nil {
+			h.ErrLog.Log(r, "failed to create registration verification", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		h.sendVerification(email, settings.SiteName, v.Token)
+	}
+
+	vm.Sent = true
+	templates.Render(w, r, "registration/register", vm)
+}
+
+// ServeComplete handles GET /register/complete?token=... - the link from the
+// verification email. Asks for the rest of the account details.
+func (h *Handler) ServeComplete(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	if h.openSettings(ctx, r) == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	v, ok := h.verifyToken(ctx, token)
+	if !ok {
+		h.renderInvalidLink(w, r)
+		return
+	}
+
+	vm := CompleteVM{BaseVM: viewdata.New(r), Token: token, Email: v.Email}
+	vm.Title = "Complete Your Registration"
+	templates.Render(w, r, "registration/complete", vm)
+}
+
+// HandleComplete handles POST /register/complete - create the account. With
+// approval required the account is created pending and can't log in yet;
+// otherwise the user is logged in, having proved they own the address.
+func (h *Handler) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	settings := h.openSettings(ctx, r)
+	if settings == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	token := r.FormValue("token")
+	fullName := strings.TrimSpace(r.FormValue("full_name"))
+
+	v, ok := h.verifyToken(ctx, token)
+	if !ok {
+		h.renderInvalidLink(w, r)
+		return
+	}
+
+	vm := CompleteVM{BaseVM: viewdata.New(r), Token: token, Email: v.Email, FullName: fullName}
+	vm.Title = "Complete Your Registration"
+
+	if fullName == "" {
+		vm.Error = "Full name is required"
+		templates.Render(w, r, "registration/complete", vm)
+		return
+	}
+
+	// The allowed domains may have changed since the link was sent
+	if !settings.EmailDomainAllowed(v.Email) {
+		vm.Error = domainNotAllowed(settings)
+		templates.Render(w, r, "registration/complete", vm)
+		return
+	}
+
+	role := settings.DefaultRegistrationRole()
+	userStatus := status.Active
+	if settings.RegistrationRequiresApproval {
+		userStatus = status.Pending
+	}
+
+	// For email auth, login_id IS the email address. The unique index on
+	// login_id catches an account created since the link was sent.
+	user, err := h.Users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName:   fullName,
+		LoginID:    v.Email,
+		Email:      v.Email,
+		AuthMethod: "email",
+		Role:       role,
+		Status:     userStatus,
+	})
+	if err != nil {
+		if err == userstore.ErrDuplicateLoginID {
+			_ = h.Verify.MarkUsed(ctx, v.ID)
+			vm = CompleteVM{BaseVM: viewdata.New(r), Error: "An account with this email already exists. Please log in instead."}
+			vm.Title = "Account Already Exists"
+			templates.Render(w, r, "registration/complete", vm)
+			return
+		}
+		h.ErrLog.Log(r, "failed to create registered user", err)
+		vm.Error = "Failed to create account. Please try again."
+		templates.Render(w, r, "registration/complete", vm)
+		return
+	}
+
+	if err := h.Verify.MarkUsed(ctx, v.ID); err != nil {
+		h.Log.Warn("failed to mark registration link used", zap.Error(err))
+	}
+	h.AuditLogger.UserRegistered(ctx, r, user.ID, v.Email, role, userStatus)
+	h.Webhooks.Publish(webhooks.EventUserCreated, webhooks.UserData(&user, "registration"))
+
+	if userStatus == status.Pending {
+		vm = CompleteVM{BaseVM: viewdata.New(r), Email: v.Email, Pending: true}
+		vm.Title = "Awaiting Approval"
+		templates.Render(w, r, "registration/complete", vm)
+		return
+	}
+
+	if settings.NotifyUserOnWelcome {
+		h.sendWelcome(ctx, &user, settings.SiteName)
+	}
+
+	if err := h.createTrackedSession(w, r, user.ID, user.Role); err != nil {
+		h.ErrLog.Log(r, "failed to create session after registration", err)
+		http.Redirect(w, r, "/login?registered=1", http.StatusSeeOther)
+		return
+	}
+	h.AuditLogger.LogAuthEvent(r, &user.ID, "login_success", true, "")
+
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// ServeApprovals handles GET /registrations - accounts awaiting approval,
+// oldest first.
+func (h *Handler) ServeApprovals(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	users, err := h.Users.Find(ctx, bson.M{"status": status.Pending},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		h.ErrLog.Log(r, "failed to list pending registrations", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]PendingRow, 0, len(users))
+	for _, u := range users {
+		row := PendingRow{
+			ID:        u.ID.Hex(),
+			FullName:  u.FullName,
+			Role:      u.Role,
+			CreatedAt: u.CreatedAt,
+		}
+		if u.Email != nil {
+			row.Email = *u.Email
+		}
+		rows = append(rows, row)
+	}
+
+	vm := ApprovalsVM{BaseVM: viewdata.New(r), Pending: rows}
+	vm.Title = "Pending Registrations"
+	switch {
+	case r.URL.Query().Get("approved") == "1":
+		vm.Success = "Account approved"
+	case r.URL.Query().Get("rejected") == "1":
+		vm.Success = "Registration rejected"
+	}
+
+	templates.Render(w, r, "registration/approvals", vm)
+}
+
+// HandleApprove handles POST /registrations/{id}/approve - activate a pending
+// account and let the user know they can log in.
+func (h *Handler) HandleApprove(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	user, ok := h.loadPending(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	active := status.Active
+	if err := h.Users.UpdateFromInput(ctx, user.ID, userstore.UpdateInput{Status: &active}); err != nil {
+		h.ErrLog.Log(r, "failed to approve registration", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.AuditLogger.RegistrationApproved(ctx, r, actor.UserID(), user.ID, actor.Role)
+
+	if h.Mailer != nil && user.Email != nil && *user.Email != "" {
+		settings, _ := h.Settings.Get(ctx)
+		siteName := models.DefaultSiteName
+		if settings != nil && settings.SiteName != "" {
+			siteName = settings.SiteName
+		}
+		h.sendApproved(ctx, user, siteName)
+	}
+
+	http.Redirect(w, r, "/registrations?approved=1", http.StatusSeeOther)
+}
+
+// HandleReject handles POST /registrations/{id}/reject - delete a pending
+// account. The address is free to register again.
+func (h *Handler) HandleReject(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	user, ok := h.loadPending(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Users.Delete(ctx, user.ID); err != nil {
+		h.ErrLog.Log(r, "failed to reject registration", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+	h.AuditLogger.RegistrationRejected(ctx, r, actor.UserID(), user.ID, actor.Role, email)
+
+	http.Redirect(w, r, "/registrations?rejected=1", http.StatusSeeOther)
+}
+
+// loadPending loads the pending account named in the URL. It writes an error
+// response and returns false if there isn't one.
+func (h *Handler) loadPending(ctx context.Context, w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	user, err := h.Users.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.NotFound(w, r)
+			return nil, false
+		}
+		h.ErrLog.Log(r, "failed to load pending registration", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if user.Status != status.Pending {
+		http.Error(w, "This account is not awaiting approval.", http.StatusConflict)
+		return nil, false
+	}
+	return user, true
+}
+
+// accountExists reports whether a user already has the email as their email
+// or login ID.
+func (h *Handler) accountExists(ctx context.Context, email string) (bool, error) {
+	if _, err := h.Users.GetByEmail(ctx, email); err == nil {
+		return true, nil
+	} else if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	if _, err := h.Users.GetByLoginID(ctx, email); err == nil {
+		return true, nil
+	} else if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	return false, nil
+}
+
+// verifyToken checks a registration link token. Login codes share the
+// verification store but always belong to a user, so they are refused here.
+func (h *Handler) verifyToken(ctx context.Context, token string) (*emailverify.Verification, bool) {
+	if token == "" {
+		return nil, false
+	}
+	v, err := h.Verify.VerifyToken(ctx, token)
+	if err != nil || !v.UserID.IsZero() {
+		return nil, false
+	}
+	return v, true
+}
+
+// renderInvalidLink shows the error page for a bad or expired link.
+func (h *Handler) renderInvalidLink(w http.ResponseWriter, r *http.Request) {
+	h.AuditLogger.LogAuthEvent(r, nil, "registration_invalid_token", false, "")
+	vm := CompleteVM{
+		BaseVM: viewdata.New(r),
+		Error:  "This link is invalid or has expired. Please sign up again to get a new one.",
+	}
+	vm.Title = "Invalid Link"
+	templates.Render(w, r, "registration/complete", vm)
+}
+
+// domainNotAllowed is the error shown when an address is outside the
+// site's allowed email domains.
+func domainNotAllowed(settings *models.SiteSettings) string {
+	return "Sign up with your work email address. Only addresses at " +
+		strings.Join(settings.AllowedEmailDomains, ", ") + " can create an account."
+}
+
+// sendVerification emails the link that continues registration.
+func (h *Handler) sendVerification(email, siteName, token string) {
+	link := h.BaseURL + "/register/complete?token=" + token
+	expiryMin := int(h.LinkExpiry.Minutes())
+	go func() {
+		err := h.Mailer.Send(mailer.Email{
+			To:       email,
+			Subject:  "Confirm your email address",
+			Template: "registration",
+			TextBody: "Thanks for signing up for " + siteName + ".\n\n" +
+				"Click the link below to confirm your email address and finish creating your account:\n\n" +
+				link + "\n\n" +
+				fmt.Sprintf("This link expires in %d minutes.\n\n", expiryMin) +
+				"If you did not sign up, you can safely ignore this email.",
+		})
+		if err != nil {
+			h.Log.Warn("failed to send registration email", zap.Error(err))
+		}
+	}()
+}
+
+// sendWelcome sends the welcome email to a newly registered user.
+func (h *Handler) sendWelcome(ctx context.Context, user *models.User, siteName string) {
+	brand := h.Mailer.Brand(ctx)
+	email := *user.Email
+	go func() {
+		text, html := mailer.WelcomeEmail(mailer.WelcomeEmailData{
+			Locale:   user.Locale,
+			Brand:    brand,
+			AppName:  siteName,
+			UserName: user.FullName,
+			LoginURL: h.BaseURL + "/login",
+			Role:     user.Role,
+		})
+		_ = h.Mailer.Send(mailer.Email{
+			To:       email,
+			Subject:  mailer.T(user.Locale, "welcome.subject", siteName),
+			Template: "welcome",
+			UserID:   user.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		})
+	}()
+}
+
+// sendApproved tells a user their account was approved. The account enabled
+// email fits: the account couldn't be used and now it can.
+func (h *Handler) sendApproved(ctx context.Context, user *models.User, siteName string) {
+	brand := h.Mailer.Brand(ctx)
+	email := *user.Email
+	go func() {
+		text, html := mailer.AccountEnabledEmail(mailer.AccountEnabledEmailData{
+			Locale:   user.Locale,
+			Brand:    brand,
+			AppName:  siteName,
+			UserName: user.FullName,
+			LoginURL: h.BaseURL + "/login",
+		})
+		_ = h.Mailer.Send(mailer.Email{
+			To:       email,
+			Subject:  mailer.T(user.Locale, "account_enabled.subject", siteName),
+			Template: "account_enabled",
+			UserID:   user.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		})
+	}()
+}
+
+// createTrackedSession creates a session in both the cookie and MongoDB for tracking.
+func (h *Handler) createTrackedSession(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID, role string) error {
+	token, err := auth.GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+
+	if err := h.SessionMgr.CreateSession(w, r, userID, role, token); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	session := sessions.Session{
+		Token:        token,
+		UserID:       userID,
+		IPAddress:    network.GetClientIP(r),
+		UserAgent:    r.UserAgent(),
+		LoginAt:      now,
+		LastActivity: now,
+		ExpiresAt:    now.Add(h.SessionMgr.Lifetime(role).Absolute),
+	}
+
+	// Best effort - don't fail login if tracking fails
+	if err := h.Sessions.Create(r.Context(), session); err != nil {
+		h.Log.Warn("failed to track session in MongoDB", zap.Error(err))
+	}
+
+	if err := h.Users.RecordLogin(r.Context(), userID, now); err != nil {
+		h.Log.Warn("failed to record last login", zap.Error(err))
+	}
+
+	h.NewDevices.Check(r, userID)
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package registrationfeature
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+func newTestHandler(t *testing.T) (*Handler, *mongo.Database) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	logger := zap.NewNop()
+
+	sessionMgr, err := auth.NewSessionManager(
+		"test-session-key-for-testing-1234567890",
+		"test-session",
+		"",
+		24*time.Hour,
+		false,
+		logger,
+	)
+	if err != nil {
+		t.Fatalf("failed to create session manager: %v", err)
+	}
+
+	// Nothing listens on the mail port; sends fail quietly in the background
+	m := mailer.New(mailer.Config{Host: "127.0.0.1", Port: 1, From: "noreply@example.com"}, logger)
+
+	h := NewHandler(db, sessionMgr, sessions.New(db), m, "http://localhost:8080", 10*time.Minute, nil, nil, logger)
+	return h, db
+}
+
+func openRegistration(t *testing.T, h *Handler, requireApproval bool) {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	err := h.Settings.Upsert(ctx, settingsstore.UpdateInput{
+		SiteName:                     "Test Site",
+		RegistrationEnabled:          true,
+		RegistrationRole:             "developer",
+		RegistrationRequiresApproval: requireApproval,
+	})
+	if err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+}
+
+func postForm(target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return testutil.WithCSRFToken(req)
+}
+
+func TestServeRegister_NotFoundWhenDisabled(t *testing.T) {
+	h, _ := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeRegister(rec, httptest.NewRequest(http.MethodGet, "/register", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleRegister_ExistingAccountGetsSameResponse(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, db := newTestHandler(t)
+	openRegistration(t, h, false)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if _, err := h.Users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName: "Existing", LoginID: "taken@example.com", Email: "taken@example.com", AuthMethod: "email", Role: "developer",
+	}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	bodies := map[string]string{}
+	for _, email := range []string{"taken@example.com", "new@example.com"} {
+		rec := httptest.NewRecorder()
+		h.HandleRegister(rec, postForm("/register", url.Values{"email": {email}}))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want %d", email, rec.Code, http.StatusOK)
+		}
+		bodies[email] = strings.ReplaceAll(rec.Body.String(), email, "")
+	}
+	if bodies["taken@example.com"] != bodies["new@example.com"] {
+		t.Error("response differs for an existing account")
+	}
+
+	verifications := db.Collection("email_verifications")
+	if n, _ := verifications.CountDocuments(ctx, bson.M{"email": "taken@example.com"}); n != 0 {
+		t.Error("verification link created for an existing account")
+	}
+	if n, _ := verifications.CountDocuments(ctx, bson.M{"email": "new@example.com"}); n != 1 {
+		t.Errorf("new address got %d verification links, want 1", n)
+	}
+}
+
+func TestHandleComplete_CreatesActiveUser(t *testing.T) {
+	h, _ := newTestHandler(t)
+	openRegistration(t, h, false)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	v, err := h.Verify.Create(ctx, "newuser@example.com", primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to create verification: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleComplete(rec, postForm("/register/complete", url.Values{"token": {v.Token}, "full_name": {"New User"}}))
+
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/dashboard" {
+		t.Errorf("got %d %q, want redirect to /dashboard", rec.Code, rec.Header().Get("Location"))
+	}
+
+	user, err := h.Users.GetByEmail(ctx, "newuser@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if user.Status != status.Active || user.Role != "developer" || user.AuthMethod != "email" {
+		t.Errorf("user = status %q role %q auth %q, want active developer with email auth", user.Status, user.Role, user.AuthMethod)
+	}
+	if _, err := h.Verify.VerifyToken(ctx, v.Token); err == nil {
+		t.Error("registration link should be single-use")
+	}
+}
+
+func TestHandleComplete_PendingWhenApprovalRequired(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _ := newTestHandler(t)
+	openRegistration(t, h, true)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	v, err := h.Verify.Create(ctx, "pending@example.com", primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("failed to create verification: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleComplete(rec, postForm("/register/complete", url.Values{"token": {v.Token}, "full_name": {"Pending User"}}))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d (awaiting approval page)", rec.Code, http.StatusOK)
+	}
+	if len(rec.Result().Cookies()) > 0 {
+		t.Error("pending user should not be logged in")
+	}
+
+	user, err := h.Users.GetByEmail(ctx, "pending@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if user.Status != status.Pending {
+		t.Errorf("status = %q, want %q", user.Status, status.Pending)
+	}
+}
+
+func TestHandleComplete_RefusesLoginCodeToken(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	h, _ := newTestHandler(t)
+	openRegistration(t, h, false)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	// Login links belong to an existing user
+	v, err := h.Verify.Create(ctx, "someone@example.com", primitive.NewObjectID())
+	if err != nil {
+		t.Fatalf("failed to create verification: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HandleComplete(rec, postForm("/register/complete", url.Values{"token": {v.Token}, "full_name": {"Someone"}}))
+
+	if _, err := h.Users.GetByEmail(ctx, "someone@example.com"); err == nil {
+		t.Error("account created from a login token")
+	}
+}
+
+func pendingUser(t *testing.T, h *Handler, ctx context.Context) primitive.ObjectID {
+	t.Helper()
+	user, err := h.Users.CreateFromInput(ctx, userstore.CreateInput{
+		FullName: "Pending", LoginID: "queue@example.com", Email: "queue@example.com", AuthMethod: "email", Role: "developer", Status: status.Pending,
+	})
+	if err != nil {
+		t.Fatalf("failed to create pending user: %v", err)
+	}
+	return user.ID
+}
+
+func adminRequest(target, id string) *http.Request {
+	req := testutil.NewAuthenticatedRequestWithCSRF(http.MethodPost, target, testutil.AdminUser())
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleApprove(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	id := pendingUser(t, h, ctx)
+
+	rec := httptest.NewRecorder()
+	h.HandleApprove(rec, adminRequest("/registrations/"+id.Hex()+"/approve", id.Hex()))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	user, err := h.Users.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("failed to reload user: %v", err)
+	}
+	if user.Status != status.Active {
+		t.Errorf("status = %q, want %q", user.Status, status.Active)
+	}
+
+	// A second approval finds nothing pending
+	rec = httptest.NewRecorder()
+	h.HandleApprove(rec, adminRequest("/registrations/"+id.Hex()+"/approve", id.Hex()))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("second approve status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestHandleReject(t *testing.T) {
+	h, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+	id := pendingUser(t, h, ctx)
+
+	rec := httptest.NewRecorder()
+	h.HandleReject(rec, adminRequest("/registrations/"+id.Hex()+"/reject", id.Hex()))
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	if _, err := h.Users.GetByID(ctx, id); err == nil {
+		t.Error("rejected account should be deleted")
+	}
+}
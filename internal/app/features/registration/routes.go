@@ -0,0 +1,33 @@
+// internal/app/features/registration/routes.go
+package registrationfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the public signup routes. They respond 404 unless
+// registration is enabled in site settings.
+func Routes(h *Handler) chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", h.ServeRegister)
+	r.Post("/", h.HandleRegister)
+	r.Get("/complete", h.ServeComplete)
+	r.Post("/complete", h.HandleComplete)
+
+	return r
+}
+
+// ApprovalRoutes returns the admin queue for approving self-registered
+// accounts.
+func ApprovalRoutes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin"))
+
+	r.Get("/", h.ServeApprovals)
+	r.Post("/{id}/approve", h.HandleApprove)
+	r.Post("/{id}/reject", h.HandleReject)
+
+	return r
+}
@@ -0,0 +1,46 @@
+// internal/app/features/registration/types.go
+package registrationfeature
+
+import (
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/captcha"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// RegisterVM is the view model for the signup form.
+type RegisterVM struct {
+	viewdata.BaseVM
+	Email   string
+	Sent    bool // Verification link sent; show "check your email"
+	Error   string
+	Captcha *captcha.Widget
+}
+
+// CompleteVM is the view model for finishing signup after the email link is
+// followed.
+type CompleteVM struct {
+	viewdata.BaseVM
+	Token    string
+	Email    string
+	FullName string
+	Pending  bool // Account created and awaiting admin approval
+	Error    string
+}
+
+// PendingRow is a self-registered account awaiting approval.
+type PendingRow struct {
+	ID        string
+	FullName  string
+	Email     string
+	Role      string
+	CreatedAt time.Time
+}
+
+// ApprovalsVM is the view model for the admin approval queue.
+type ApprovalsVM struct {
+	viewdata.BaseVM
+	Pending []PendingRow
+	Success string
+	Error   string
+}
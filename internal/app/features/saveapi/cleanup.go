@@ -11,6 +11,16 @@ import (
 	"go.uber.org/zap"
 )
 
+// TrimSaves removes a user's saves for game beyond the max_saves_per_user
+// limit, in the background. It does nothing when every save is kept or h
+// is nil, so callers that add saves some other way can call it as is.
+func (h *Handler) TrimSaves(userID, game string) {
+	if h == nil || h.maxSavesPerUser.Load() <= 0 {
+		return
+	}
+	go h.cleanupOldStates(userID, game)
+}
+
 // cleanupOldStates removes states exceeding the retention limit for a user/game.
 // Runs asynchronously after each save.
 func (h *Handler) cleanupOldStates(userID, game string) {
@@ -23,7 +33,7 @@ func (h *Handler) cleanupOldStates(userID, game string) {
 	filter := bson.M{"user_id": userID, "game": game}
 	opts := options.Find().
 		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
-		SetSkip(int64(h.maxSavesPerUser)).
+		SetSkip(h.maxSavesPerUser.Load()).
 		SetLimit(1).
 		SetProjection(bson.M{"_id": 1})
 
@@ -72,6 +82,8 @@ func (h *Handler) cleanupOldStates(userID, game string) {
 	}
 
 	if result.DeletedCount > 0 {
+		// Cached loads with a limit above the retention count may include them
+		h.loadCache.Invalidate(userID, game)
 		h.logger.Info("cleanup: removed old states",
 			zap.String("user_id", userID),
 			zap.String("game", game),
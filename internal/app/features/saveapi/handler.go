@@ -8,14 +8,20 @@
 package saveapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
+	"github.com/dalemusser/stratasave/internal/app/system/statecache"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,21 +41,87 @@ type PlayerState struct {
 	SaveData  bson.M             `bson:"save_data"     json:"save_data"`
 }
 
+// saveRequest is the request body for SaveHandler.
+type saveRequest struct {
+	UserID   string `json:"user_id"   openapi:"required,desc=Player identifier,example=player123"`
+	Game     string `json:"game"      openapi:"required,desc=Game identifier,example=mygame"`
+	SaveData bson.M `json:"save_data" openapi:"required,desc=Arbitrary JSON object to store"`
+}
+
+// loadRequest is the request body for LoadHandler.
+type loadRequest struct {
+	UserID string `json:"user_id" openapi:"required,desc=Player identifier,example=player123"`
+	Game   string `json:"game"    openapi:"required,desc=Game identifier,example=mygame"`
+	Limit  int64  `json:"limit"   openapi:"desc=Number of saves to return (newest first; default 1),example=1"`
+}
+
 // Handler handles save/load API requests.
 type Handler struct {
 	db              *mongo.Database
 	logger          *zap.Logger
-	maxSavesPerUser int       // -1 means "all" (no limit)
-	indexEnsured    sync.Once // Ensure index is created once
+	maxSavesPerUser atomic.Int64 // -1 means "all" (no limit); changes on config reload
+	indexEnsured    sync.Once    // Ensure index is created once
+	keyValidator    auth.APIKeyValidator
+	sigVerifier     *auth.SignatureVerifier
+	tokens          *auth.TokenIssuer
+	trustedProxies  []string
+	loadCache       *statecache.Cache // nil disables caching
+	webhooks        *webhooks.Dispatcher
 }
 
 // NewHandler creates a new saveapi handler.
 func NewHandler(db *mongo.Database, logger *zap.Logger, maxSavesConfig string) *Handler {
-	return &Handler{
-		db:              db,
-		logger:          logger,
-		maxSavesPerUser: parseMaxSaves(maxSavesConfig),
+	h := &Handler{
+		db:     db,
+		logger: logger,
 	}
+	h.SetMaxSaves(maxSavesConfig)
+	return h
+}
+
+// SetMaxSaves changes how many saves are kept per user and game, from a
+// max_saves_per_user value. Older saves beyond the limit are removed after
+// each player's next save. Safe to call while requests are being served.
+func (h *Handler) SetMaxSaves(maxSavesConfig string) {
+	h.maxSavesPerUser.Store(int64(parseMaxSaves(maxSavesConfig)))
+}
+
+// SetAPIKeyValidator enables authentication with database-managed API keys
+// in addition to the static configured key. Call before building routes.
+func (h *Handler) SetAPIKeyValidator(v auth.APIKeyValidator) {
+	h.keyValidator = v
+}
+
+// SetSignatureVerifier enables HMAC request signing as an alternative to
+// bearer API keys. Call before building routes.
+func (h *Handler) SetSignatureVerifier(v *auth.SignatureVerifier) {
+	h.sigVerifier = v
+}
+
+// SetTokenIssuer enables authentication with short-lived API tokens. A token
+// only grants access to the user and game it was issued for.
+// Call before building routes.
+func (h *Handler) SetTokenIssuer(ti *auth.TokenIssuer) {
+	h.tokens = ti
+}
+
+// SetTrustedProxies sets the reverse proxies (CIDR ranges) whose forwarding
+// headers are believed when checking an API key's IP allowlist.
+// Call before building routes.
+func (h *Handler) SetTrustedProxies(cidrs []string) {
+	h.trustedProxies = cidrs
+}
+
+// SetLoadCache enables caching of load responses. Saves through this
+// handler invalidate the cache; other writers to player_states must call
+// Invalidate themselves.
+func (h *Handler) SetLoadCache(c *statecache.Cache) {
+	h.loadCache = c
+}
+
+// SetWebhooks enables save.created webhook events.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
 }
 
 // parseMaxSaves parses the max_saves_per_user config value.
@@ -87,11 +159,7 @@ func parseMaxSaves(config string) int {
 //	    "save_data": { ... }
 //	}
 func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
-	var in struct {
-		UserID   string `json:"user_id"`
-		Game     string `json:"game"`
-		SaveData bson.M `json:"save_data"`
-	}
+	var in saveRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeJSONError(w, r, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -100,6 +168,10 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, r, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	if !auth.APITokenAllows(r.Context(), in.UserID, in.Game) {
+		writeJSONError(w, r, "Token is not valid for this user and game", http.StatusForbidden)
+		return
+	}
 
 	state := PlayerState{
 		UserID:    in.UserID,
@@ -122,6 +194,8 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
 		state.ID = oid
 	}
+	h.loadCache.Invalidate(in.UserID, in.Game)
+	h.webhooks.Publish(webhooks.EventSaveCreated, webhooks.SaveData(state.ID, state.UserID, state.Game, state.Timestamp))
 
 	h.logger.Debug("game state saved",
 		zap.String("game", in.Game),
@@ -137,9 +211,7 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Trigger async cleanup if retention limit is configured
-	if h.maxSavesPerUser > 0 {
-		go h.cleanupOldStates(in.UserID, in.Game)
-	}
+	h.TrimSaves(in.UserID, in.Game)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -171,11 +243,7 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 //	    }
 //	]
 func (h *Handler) LoadHandler(w http.ResponseWriter, r *http.Request) {
-	var in struct {
-		UserID string `json:"user_id"`
-		Game   string `json:"game"`
-		Limit  int64  `json:"limit"`
-	}
+	var in loadRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeJSONError(w, r, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -184,10 +252,22 @@ func (h *Handler) LoadHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, r, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	if !auth.APITokenAllows(r.Context(), in.UserID, in.Game) {
+		writeJSONError(w, r, "Token is not valid for this user and game", http.StatusForbidden)
+		return
+	}
 	if in.Limit <= 0 {
 		in.Limit = 1
 	}
 
+	cached, cacheSeq, hit := h.loadCache.Get(in.UserID, in.Game, in.Limit)
+	if hit {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Cache", "HIT")
+		_, _ = w.Write(cached)
+		return
+	}
+
 	coll := h.db.Collection(CollectionName)
 	filter := bson.M{"user_id": in.UserID, "game": in.Game}
 	opts := options.Find().
@@ -228,18 +308,33 @@ func (h *Handler) LoadHandler(w http.ResponseWriter, r *http.Request) {
 		zap.Int("count", len(out)),
 	)
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(out); err != nil {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(out); err != nil {
 		h.logger.Error("failed to encode load response", zap.Error(err))
+		writeJSONError(w, r, "Failed to encode saves", http.StatusInternalServerError)
+		return
 	}
+	h.loadCache.Set(in.UserID, in.Game, in.Limit, cacheSeq, body.Bytes())
+
+	w.Header().Set("Content-Type", "application/json")
+	if h.loadCache != nil {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	_, _ = w.Write(body.Bytes())
 }
 
 // writeJSONError writes a JSON error response and logs the error to the ledger.
+// The response carries the request ID, so a client can quote it when
+// reporting the error.
 func writeJSONError(w http.ResponseWriter, r *http.Request, msg string, code int) {
 	// Set error message in ledger context for debugging
 	ledger.SetErrorMessage(r.Context(), msg)
 
+	body := map[string]string{"error": msg}
+	if id := requestid.FromContext(r.Context()); id != "" {
+		body["request_id"] = id
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	_ = json.NewEncoder(w).Encode(body)
 }
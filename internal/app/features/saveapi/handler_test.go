@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.uber.org/zap"
@@ -526,6 +527,20 @@ func TestHandler_WriteJSONError(t *testing.T) {
 	if resp["error"] != "test error message" {
 		t.Errorf("error message = %q, want %q", resp["error"], "test error message")
 	}
+	if _, ok := resp["request_id"]; ok {
+		t.Errorf("request_id = %q outside the requestid middleware, want none", resp["request_id"])
+	}
+
+	rec = httptest.NewRecorder()
+	req = req.WithContext(requestid.NewContext(req.Context(), "req-123"))
+	writeJSONError(rec, req, "test error message", http.StatusBadRequest)
+	resp = nil
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["request_id"] != "req-123" {
+		t.Errorf("request_id = %q, want %q", resp["request_id"], "req-123")
+	}
 }
 
 func TestParseMaxSaves(t *testing.T) {
@@ -641,8 +656,8 @@ func TestHandler_NoCleanupWhenAllConfigured(t *testing.T) {
 	}
 
 	// Verify maxSavesPerUser is -1 (no limit)
-	if h.maxSavesPerUser != -1 {
-		t.Errorf("maxSavesPerUser = %d, want -1", h.maxSavesPerUser)
+	if got := h.maxSavesPerUser.Load(); got != -1 {
+		t.Errorf("maxSavesPerUser = %d, want -1", got)
 	}
 
 	// Cleanup should be a no-op (never called since limit is -1)
@@ -707,6 +722,47 @@ func TestHandler_CleanupIsolatesUsers(t *testing.T) {
 	}
 }
 
+func TestHandler_TrimSaves(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	h := NewHandler(db, zap.NewNop(), "2")
+
+	game := "trim_test_game"
+	userID := "trim_user"
+	coll := db.Collection(CollectionName)
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	baseTime := time.Now().UTC()
+	for i := 0; i < 4; i++ {
+		coll.InsertOne(ctx, bson.M{
+			"user_id":   userID,
+			"game":      game,
+			"timestamp": baseTime.Add(time.Duration(i) * time.Second),
+			"save_data": bson.M{"index": i},
+		})
+	}
+
+	// Saves added outside the API, such as restores, are trimmed in the
+	// background
+	h.TrimSaves(userID, game)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		count, _ := coll.CountDocuments(ctx, bson.M{"user_id": userID, "game": game})
+		if count == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 saves after TrimSaves, got %d", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A nil handler keeps every save
+	var none *Handler
+	none.TrimSaves(userID, game)
+}
+
 func TestHandler_CleanupIsolatesGames(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	logger := zap.NewNop()
@@ -0,0 +1,47 @@
+package saveapi
+
+import "github.com/dalemusser/stratasave/internal/app/system/openapi"
+
+// Tag is the OpenAPI tag for the state endpoints.
+const Tag = "State"
+
+// DescribeAPI adds the state endpoints to an OpenAPI document whose server
+// URL is a versioned API prefix such as /api/v1. The schemas are built from
+// the same request and response types the handlers decode and encode, so
+// the document stays in step with the code.
+func DescribeAPI(doc *openapi.Document) {
+	doc.AddTag(Tag, "Save and load game state. Each save creates a new entry, keeping a history per player and game.")
+
+	saveReq := doc.Ref("SaveStateRequest", saveRequest{})
+	loadReq := doc.Ref("LoadStateRequest", loadRequest{})
+	state := doc.Ref("PlayerState", PlayerState{})
+
+	doc.Post("/state/save", &openapi.Operation{
+		Tags:        []string{Tag},
+		Summary:     "Save game state",
+		Description: "Stores a new save for the player and game. Older saves beyond the configured retention limit are removed in the background.",
+		OperationID: "saveState",
+		RequestBody: openapi.JSONBody(saveReq),
+		Responses:   stateResponses(doc, "201", "Saved state", state),
+	})
+	doc.Post("/state/load", &openapi.Operation{
+		Tags:        []string{Tag},
+		Summary:     "Load game state",
+		Description: "Returns the most recent saves for the player and game, newest first. Returns an empty array when there are none.",
+		OperationID: "loadState",
+		RequestBody: openapi.JSONBody(loadReq),
+		Responses:   stateResponses(doc, "200", "Saved states, newest first", openapi.ArrayOf(state)),
+	})
+}
+
+// stateResponses returns the success response plus the error responses
+// common to all state endpoints.
+func stateResponses(doc *openapi.Document, code, description string, body *openapi.Schema) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		code:  openapi.JSONResponse(description, body),
+		"400": doc.ErrorResponse("Invalid JSON or missing required fields"),
+		"401": doc.ErrorResponse("Missing or invalid credentials"),
+		"403": doc.ErrorResponse("Token not valid for this player and game, or client IP not allowed"),
+		"500": doc.ErrorResponse("Database error"),
+	}
+}
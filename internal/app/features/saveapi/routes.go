@@ -7,17 +7,21 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/system/apicors"
 	"github.com/dalemusser/stratasave/internal/app/system/apistats"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
 // Routes returns a router with the state save/load API endpoints.
 //
-// When mounted at /api/state:
-//   - POST /api/state/save - Save game state
-//   - POST /api/state/load - Load game state
+// When mounted at /api/v1/state (and the unversioned /api/state alias):
+//   - POST /api/v1/state/save - Save game state
+//   - POST /api/v1/state/load - Load game state
 //
-// Authentication is via API key (Bearer token in Authorization header).
+// Authentication is via API key (Bearer token in Authorization header), either
+// the static configured key or a database-managed key (see SetAPIKeyValidator),
+// via an HMAC-signed request (see SetSignatureVerifier), or via a short-lived
+// API token scoped to one user and game (see SetTokenIssuer).
 // CORS is permissive (allows any origin) since API key auth is used.
 func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
@@ -26,7 +30,7 @@ func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.
 	r.Use(apicors.Middleware())
 
 	// API key authentication
-	r.Use(auth.APIKeyAuth(apiKey, logger))
+	r.Use(h.apiKeyAuth(apiKey, logger))
 
 	// Save endpoint with stats tracking
 	r.Route("/save", func(sr chi.Router) {
@@ -49,7 +53,7 @@ func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.
 //   - POST /save - Save game state (legacy)
 //   - POST /load - Load game state (legacy)
 //
-// New integrations should use /api/state/save and /api/state/load instead.
+// New integrations should use /api/v1/state/save and /api/v1/state/load instead.
 func LegacyRoutes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
 
@@ -57,7 +61,7 @@ func LegacyRoutes(h *Handler, recorder *apistats.Recorder, apiKey string, logger
 	r.Use(apicors.Middleware())
 
 	// API key authentication
-	r.Use(auth.APIKeyAuth(apiKey, logger))
+	r.Use(h.apiKeyAuth(apiKey, logger))
 
 	// Legacy save endpoint
 	r.Group(func(sr chi.Router) {
@@ -76,7 +80,7 @@ func LegacyLoadRoutes(h *Handler, recorder *apistats.Recorder, apiKey string, lo
 	r.Use(apicors.Middleware())
 
 	// API key authentication
-	r.Use(auth.APIKeyAuth(apiKey, logger))
+	r.Use(h.apiKeyAuth(apiKey, logger))
 
 	// Legacy load endpoint
 	r.Group(func(sr chi.Router) {
@@ -86,3 +90,18 @@ func LegacyLoadRoutes(h *Handler, recorder *apistats.Recorder, apiKey string, lo
 
 	return r
 }
+
+// apiKeyAuth builds the API key middleware for these routes. Rejection
+// reasons are recorded on the request's ledger entry.
+func (h *Handler) apiKeyAuth(apiKey string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return auth.APIKeyAuthWithConfig(auth.APIKeyAuthConfig{
+		StaticKey:      apiKey,
+		Validator:      h.keyValidator,
+		Signature:      h.sigVerifier,
+		Tokens:         h.tokens,
+		TrustedProxies: h.trustedProxies,
+		OnReject: func(r *http.Request, reason string) {
+			ledger.SetErrorMessage(r.Context(), reason)
+		},
+	}, logger)
+}
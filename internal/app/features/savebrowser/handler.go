@@ -3,16 +3,24 @@ package savebrowser
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	saveapifeature "github.com/dalemusser/stratasave/internal/app/features/saveapi"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/statecache"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/timezones"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
@@ -30,6 +38,9 @@ type Handler struct {
 	logger       *zap.Logger
 	defaultLimit int
 	apiKey       string
+	loadCache    *statecache.Cache
+	auditLogger  *auditlog.Logger
+	retention    *saveapifeature.Handler
 }
 
 // NewHandler creates a new save browser handler.
@@ -47,6 +58,25 @@ func NewHandler(db *mongo.Database, errLog *errorsfeature.ErrorLogger, defaultLi
 	}
 }
 
+// SetLoadCache sets the state API's load cache so edits made here are
+// visible to the next load.
+func (h *Handler) SetLoadCache(c *statecache.Cache) {
+	h.loadCache = c
+}
+
+// SetRetention sets the state API handler whose max_saves_per_user limit
+// applies to saves restored here, so a restore trims older saves the way an
+// API save does.
+func (h *Handler) SetRetention(api *saveapifeature.Handler) {
+	h.retention = api
+}
+
+// SetAuditLogger records save edits, restores, and deletions in the audit
+// log.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
 // ServeList renders the main browser page with game header, players table, and saves.
 func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
@@ -64,6 +94,7 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	selectedGame := r.URL.Query().Get("game")
 	selectedUser := r.URL.Query().Get("user")
 	playerSearch := r.URL.Query().Get("search")
+	saveQuery := r.URL.Query().Get("q")
 	limitStr := r.URL.Query().Get("limit")
 	afterID := r.URL.Query().Get("after")
 	beforeID := r.URL.Query().Get("before")
@@ -98,6 +129,8 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 		SelectedGame:   selectedGame,
 		SelectedUser:   selectedUser,
 		PlayerSearch:   playerSearch,
+		SaveQuery:      saveQuery,
+		SaveQueryParam: url.QueryEscape(saveQuery),
 		PlayerPage:     page,
 		SaveLimit:      limit,
 		DefaultLimit:   h.defaultLimit,
@@ -105,10 +138,13 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 
 	// If game selected, load players with counts
 	if selectedGame != "" {
-		users, total, err := h.store.ListUsersWithCounts(ctx, selectedGame, playerSearch, page, defaultPlayerLimit)
+		conds, err := ParseQuery(saveQuery)
 		if err != nil {
+			data.QueryError = err.Error()
+		} else if users, total, err := h.store.ListUsersWithCounts(ctx, selectedGame, playerSearch, queryFilter(conds), page, defaultPlayerLimit); err != nil {
 			h.logger.Warn("failed to list users with counts", zap.Error(err))
 		} else {
+			data.UnindexedFields = h.unindexedFields(ctx, conds)
 			data.Players = make([]PlayerRowVM, len(users))
 			for i, u := range users {
 				data.Players[i] = PlayerRowVM{
@@ -150,6 +186,7 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 						Game:      s.Game,
 						Timestamp: s.Timestamp,
 						SaveData:  string(jsonBytes),
+						Latest:    !hasPrev && i == 0,
 					}
 				}
 				data.HasPrev = hasPrev
@@ -179,6 +216,10 @@ func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 				SelectedGame:     selectedGame,
 				SelectedUser:     selectedUser,
 				PlayerSearch:     playerSearch,
+				SaveQuery:        saveQuery,
+				SaveQueryParam:   data.SaveQueryParam,
+				QueryError:       data.QueryError,
+				UnindexedFields:  data.UnindexedFields,
 				Players:          data.Players,
 				PlayerTotal:      data.PlayerTotal,
 				PlayerPage:       page,
@@ -218,6 +259,7 @@ func (h *Handler) ServePlayers(w http.ResponseWriter, r *http.Request) {
 
 	game := r.URL.Query().Get("game")
 	search := r.URL.Query().Get("search")
+	saveQuery := r.URL.Query().Get("q")
 	selectedUser := r.URL.Query().Get("user")
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
@@ -237,11 +279,13 @@ func (h *Handler) ServePlayers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := PlayersPartialVM{
-		SelectedGame: game,
-		SelectedUser: selectedUser,
-		PlayerSearch: search,
-		PlayerPage:   page,
-		Limit:        limit,
+		SelectedGame:   game,
+		SelectedUser:   selectedUser,
+		PlayerSearch:   search,
+		SaveQuery:      saveQuery,
+		SaveQueryParam: url.QueryEscape(saveQuery),
+		PlayerPage:     page,
+		Limit:          limit,
 	}
 
 	if game == "" {
@@ -249,12 +293,20 @@ func (h *Handler) ServePlayers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users, total, err := h.store.ListUsersWithCounts(ctx, game, search, page, defaultPlayerLimit)
+	conds, err := ParseQuery(saveQuery)
+	if err != nil {
+		data.QueryError = err.Error()
+		templates.RenderSnippet(w, "savebrowser/players_partial", data)
+		return
+	}
+
+	users, total, err := h.store.ListUsersWithCounts(ctx, game, search, queryFilter(conds), page, defaultPlayerLimit)
 	if err != nil {
 		h.logger.Warn("failed to list users with counts", zap.Error(err))
 		templates.RenderSnippet(w, "savebrowser/players_partial", data)
 		return
 	}
+	data.UnindexedFields = h.unindexedFields(ctx, conds)
 
 	data.Players = make([]PlayerRowVM, len(users))
 	for i, u := range users {
@@ -284,6 +336,20 @@ func (h *Handler) ServePlayers(w http.ResponseWriter, r *http.Request) {
 	templates.RenderSnippet(w, "savebrowser/players_partial", data)
 }
 
+// unindexedFields returns the fields of a save data query that no index
+// serves, for the index advisor. A failure to read the indexes is logged and
+// gives no advice.
+func (h *Handler) unindexedFields(ctx context.Context, conds []Condition) []string {
+	if len(conds) == 0 {
+		return nil
+	}
+	fields, err := h.store.UnindexedFields(ctx, conds)
+	if err != nil {
+		h.logger.Warn("failed to list player_states indexes", zap.Error(err))
+	}
+	return fields
+}
+
 // ServeGamePicker handles GET /saves/game-picker - game selector modal.
 func (h *Handler) ServeGamePicker(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
@@ -372,6 +438,7 @@ func (h *Handler) ServeSaves(w http.ResponseWriter, r *http.Request) {
 			Game:      s.Game,
 			Timestamp: s.Timestamp,
 			SaveData:  string(jsonBytes),
+			Latest:    !hasPrev && i == 0,
 		}
 	}
 	data.HasPrev = hasPrev
@@ -410,6 +477,19 @@ func (h *Handler) HandleDeleteSave(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Look the save up first to know whose cached load to drop and which
+	// player the audit event is about
+	save, err := h.store.GetSave(ctx, game, id)
+	if err != nil {
+		h.errLog.Log(r, "failed to load save", err)
+		http.Error(w, "Failed to delete save", http.StatusInternalServerError)
+		return
+	}
+	if save == nil {
+		http.Error(w, "Save not found", http.StatusNotFound)
+		return
+	}
+
 	if err := h.store.DeleteSave(ctx, game, id); err != nil {
 		h.errLog.Log(r, "failed to delete save", err)
 		http.Error(w, "Failed to delete save", http.StatusInternalServerError)
@@ -420,12 +500,149 @@ func (h *Handler) HandleDeleteSave(w http.ResponseWriter, r *http.Request) {
 		zap.String("game", game),
 		zap.String("id", idStr),
 	)
+	h.loadCache.Invalidate(save.UserID, game)
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "save_deleted", map[string]string{
+		"game":      game,
+		"save_id":   idStr,
+		"player_id": save.UserID,
+	})
 
 	// Return success - the client will refresh the list
 	w.Header().Set("HX-Trigger", "save-deleted")
 	w.WriteHeader(http.StatusOK)
 }
 
+// errNotObject is returned by parseSaveData for JSON that isn't an object.
+var errNotObject = errors.New("must be a JSON object")
+
+// parseSaveData parses edited save data, which must be a JSON object like
+// the save_data the State API accepts.
+func parseSaveData(s string) (bson.M, error) {
+	var data bson.M
+	if err := json.Unmarshal([]byte(s), &data); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, errNotObject
+		}
+		return nil, fmt.Errorf("is not valid JSON: %w", err)
+	}
+	if data == nil {
+		return nil, errNotObject
+	}
+	return data, nil
+}
+
+// HandleEditSave handles POST /saves/{game}/{id}/edit - replace a save's data.
+func (h *Handler) HandleEditSave(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	game := chi.URLParam(r, "game")
+	idStr := chi.URLParam(r, "id")
+
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Invalid save ID", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+	data, err := parseSaveData(r.FormValue("data"))
+	if err != nil {
+		http.Error(w, "State data "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	save, err := h.store.GetSave(ctx, game, id)
+	if err != nil {
+		h.errLog.Log(r, "failed to load save", err)
+		http.Error(w, "Failed to save changes", http.StatusInternalServerError)
+		return
+	}
+	if save == nil {
+		http.Error(w, "Save not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.store.UpdateSaveData(ctx, game, id, data); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Save not found", http.StatusNotFound)
+			return
+		}
+		h.errLog.Log(r, "failed to update save", err)
+		http.Error(w, "Failed to save changes", http.StatusInternalServerError)
+		return
+	}
+	h.loadCache.Invalidate(save.UserID, game)
+
+	h.logger.Info("save edited",
+		zap.String("game", game),
+		zap.String("id", idStr),
+	)
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "save_edited", map[string]string{
+		"game":      game,
+		"save_id":   idStr,
+		"player_id": save.UserID,
+	})
+
+	// Return success - the client will refresh the list
+	w.Header().Set("HX-Trigger", "save-edited")
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleRestoreSave handles POST /saves/{game}/{id}/restore - make a copy of
+// a previous save the player's latest.
+func (h *Handler) HandleRestoreSave(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	game := chi.URLParam(r, "game")
+	idStr := chi.URLParam(r, "id")
+
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		http.Error(w, "Invalid save ID", http.StatusBadRequest)
+		return
+	}
+
+	restored, err := h.store.RestoreSave(ctx, game, id)
+	if err != nil {
+		h.errLog.Log(r, "failed to restore save", err)
+		http.Error(w, "Failed to restore save", http.StatusInternalServerError)
+		return
+	}
+	if restored == nil {
+		http.Error(w, "Save not found", http.StatusNotFound)
+		return
+	}
+	h.loadCache.Invalidate(restored.UserID, game)
+	h.retention.TrimSaves(restored.UserID, game)
+
+	h.logger.Info("save restored",
+		zap.String("game", game),
+		zap.String("from", idStr),
+		zap.String("id", restored.ID.Hex()),
+	)
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "save_restored", map[string]string{
+		"game":          game,
+		"save_id":       restored.ID.Hex(),
+		"restored_from": idStr,
+		"player_id":     restored.UserID,
+	})
+
+	// Return success - the client will refresh the list
+	w.Header().Set("HX-Trigger", "save-restored")
+	w.WriteHeader(http.StatusOK)
+}
+
 // HandleCreateState handles POST /console/api/state/create - create test state.
 func (h *Handler) HandleCreateState(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
@@ -461,6 +678,7 @@ func (h *Handler) HandleCreateState(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create state", http.StatusInternalServerError)
 		return
 	}
+	h.loadCache.Invalidate(userID, game)
 
 	h.logger.Info("state created",
 		zap.String("game", game),
@@ -486,12 +704,20 @@ func (h *Handler) HandleDeleteUserSaves(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Failed to delete saves", http.StatusInternalServerError)
 		return
 	}
+	h.loadCache.Invalidate(userID, game)
 
 	h.logger.Info("user saves deleted",
 		zap.String("game", game),
 		zap.String("user_id", userID),
 		zap.Int64("count", count),
 	)
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "player_saves_deleted", map[string]string{
+		"game":      game,
+		"player_id": userID,
+		"count":     strconv.FormatInt(count, 10),
+	})
 
 	// Return success - the client will refresh
 	w.Header().Set("HX-Trigger", "saves-deleted")
@@ -0,0 +1,59 @@
+package savebrowser
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+)
+
+func TestParseSaveData(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr bool
+	}{
+		{`{"level": 1, "items": ["sword"]}`, false},
+		{`{}`, false},
+		{`[1, 2]`, true},
+		{`"text"`, true},
+		{`null`, true},
+		{`{"level": }`, true},
+		{``, true},
+	}
+	for _, tt := range tests {
+		data, err := parseSaveData(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSaveData(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if err == nil && data == nil {
+			t.Errorf("parseSaveData(%q) = nil data", tt.in)
+		}
+	}
+}
+
+func TestPlaygroundBody(t *testing.T) {
+	form := url.Values{"operation": {"save"}, "user_id": {"u1"}, "game": {"quest"}, "save_data": {"{\n  \"level\": 2\n}"}}
+	op, body, err := playgroundBody(form)
+	if err != nil || op != "save" {
+		t.Fatalf("playgroundBody(save) = %q, %v", op, err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if got["user_id"] != "u1" || got["game"] != "quest" || got["save_data"].(map[string]any)["level"] != 2.0 || got["limit"] != nil {
+		t.Errorf("save body = %s", body)
+	}
+
+	form = url.Values{"operation": {"load"}, "user_id": {"u1"}, "game": {"quest"}, "limit": {"x"}}
+	if _, body, _ = playgroundBody(form); string(body) != `{"user_id":"u1","game":"quest","limit":1}` {
+		t.Errorf("load body = %s, want limit 1", body)
+	}
+
+	form = url.Values{"operation": {"save"}, "save_data": {"{level"}}
+	if _, _, err := playgroundBody(form); err == nil {
+		t.Error("playgroundBody(invalid save data) error = nil")
+	}
+	if _, _, err := playgroundBody(url.Values{}); err == nil {
+		t.Error("playgroundBody(no operation) error = nil")
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"github.com/dalemusser/stratasave/internal/app/system/apisnippets"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
+	"go.uber.org/zap"
 )
 
 // PlaygroundVM is the view model for the playground page.
@@ -17,6 +23,9 @@ type PlaygroundVM struct {
 	viewdata.BaseVM
 	APIEndpoint string
 	APIKey      string
+	Game        string // Prefilled from ?game=
+	UserID      string // Prefilled from ?user=
+	Builder     apisnippets.Builder
 }
 
 // DocsVM is the view model for the documentation page.
@@ -41,27 +50,101 @@ type PlaygroundResponse struct {
 	Error      string            `json:"error,omitempty"`
 }
 
-// ServePlayground renders the playground page.
+// ServePlayground renders the playground page. The game and user can be
+// prefilled with ?game= and ?user=.
 func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
+	keys, err := apisnippets.KeyOptions(r.Context(), h.apiKey, apikeystore.New(h.db))
+	if err != nil {
+		h.logger.Warn("failed to list API keys", zap.Error(err))
+	}
+
 	data := PlaygroundVM{
 		BaseVM:      viewdata.NewBaseVM(r, h.db, "State API Playground", "/console/api/state"),
-		APIEndpoint: "/api/state",
+		APIEndpoint: "/api/v1/state",
 		APIKey:      h.apiKey,
+		Game:        r.URL.Query().Get("game"),
+		UserID:      r.URL.Query().Get("user"),
+		Builder: apisnippets.Builder{
+			SnippetsURL: "/console/api/state/playground/snippets",
+			Keys:        keys,
+		},
 	}
 	templates.Render(w, r, "savebrowser/playground", data)
 }
 
-// ServeDocs renders the documentation page.
-func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
+// HandlePlaygroundSnippets renders the playground's request as cURL, Unity,
+// and Unreal code for the request builder.
+func (h *Handler) HandlePlaygroundSnippets(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	var view apisnippets.View
+	operation, body, err := playgroundBody(r.PostForm)
+	if err != nil {
+		view.Error = "Fix the request to see its code: " + err.Error()
+	} else {
+		key, complete := apisnippets.ResolveKey(r.Context(), r.PostFormValue("snippet_key"), h.apiKey, apikeystore.New(h.db))
+		view.Snippets = apisnippets.Generate(apisnippets.Request{
+			URL:    requestBaseURL(r) + "/api/v1/state/" + operation,
+			APIKey: key,
+			Body:   body,
+		})
+		view.PartialKey = !complete
+	}
+	templates.RenderSnippet(w, "savebrowser/playground_snippets", view)
+}
+
+// playgroundRequestBody is the body of a State API request.
+type playgroundRequestBody struct {
+	UserID   string          `json:"user_id"`
+	Game     string          `json:"game"`
+	SaveData json.RawMessage `json:"save_data,omitempty"`
+	Limit    int             `json:"limit,omitempty"`
+}
+
+// playgroundBody returns the operation and JSON body of the request in the
+// playground form, as the playground would send it.
+func playgroundBody(form url.Values) (operation string, body []byte, err error) {
+	req := playgroundRequestBody{
+		UserID: form.Get("user_id"),
+		Game:   form.Get("game"),
+	}
+	operation = form.Get("operation")
+	switch operation {
+	case "save":
+		data := []byte(form.Get("save_data"))
+		if !json.Valid(data) {
+			return "", nil, errors.New("save data isn't valid JSON")
+		}
+		req.SaveData = data
+	case "load":
+		req.Limit = 1
+		if n, err := strconv.Atoi(form.Get("limit")); err == nil && n > 0 {
+			req.Limit = n
+		}
+	default:
+		return "", nil, errors.New("no operation chosen")
+	}
+	body, err = json.Marshal(req)
+	return operation, body, err
+}
+
+// requestBaseURL returns the scheme and host the request was made to.
+func requestBaseURL(r *http.Request) string {
 	scheme := "http"
 	if r.TLS != nil {
 		scheme = "https"
 	}
-	baseURL := scheme + "://" + r.Host
+	return scheme + "://" + r.Host
+}
 
+// ServeDocs renders the documentation page.
+func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
 	data := DocsVM{
 		BaseVM:  viewdata.NewBaseVM(r, h.db, "States API Documentation", "/console/api/state"),
-		BaseURL: baseURL,
+		BaseURL: requestBaseURL(r),
 	}
 	templates.Render(w, r, "savebrowser/docs", data)
 }
@@ -78,9 +161,9 @@ func (h *Handler) HandlePlaygroundExecute(w http.ResponseWriter, r *http.Request
 	var targetPath string
 	switch req.Operation {
 	case "save":
-		targetPath = "/api/state/save"
+		targetPath = "/api/v1/state/save"
 	case "load":
-		targetPath = "/api/state/load"
+		targetPath = "/api/v1/state/load"
 	default:
 		writePlaygroundError(w, "Invalid operation: must be 'save' or 'load'", http.StatusBadRequest)
 		return
@@ -0,0 +1,177 @@
+package savebrowser
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Condition is one clause of a save data query, such as "level > 10".
+type Condition struct {
+	Field string // Document field, e.g. "save_data.level"
+	Op    string // MongoDB operator, e.g. "$gt"
+	Value any
+}
+
+var (
+	// clauseAnd separates the clauses of a query.
+	clauseAnd = regexp.MustCompile(`(?i)\s+and\s+`)
+
+	// comparison matches "path op value"; two-character operators are
+	// listed first so ">=" isn't read as ">".
+	comparison = regexp.MustCompile(`^([^\s=!<>]+)\s*(==|!=|>=|<=|=|>|<)\s*(.+)$`)
+
+	// existence matches "path exists" and "path missing".
+	existence = regexp.MustCompile(`(?i)^([^\s=!<>]+)\s+(exists|missing)$`)
+
+	// pathSegment is one dot-separated part of a save data path. Array
+	// positions ("items.0") are allowed; "$" never is, so a query can't
+	// smuggle in an operator.
+	pathSegment = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// operators maps query operators to MongoDB ones.
+var operators = map[string]string{
+	"=":  "$eq",
+	"==": "$eq",
+	"!=": "$ne",
+	">":  "$gt",
+	">=": "$gte",
+	"<":  "$lt",
+	"<=": "$lte",
+}
+
+// ParseQuery parses a save data query: clauses joined by "and", each
+// comparing a path in the save data with a value, or testing whether it is
+// there at all:
+//
+//	level > 10 and world = "forest"
+//	inventory.sword exists
+//
+// Paths may start with "save_data.". Values are JSON numbers, booleans,
+// null, or strings; a bare word is a string, and "and" inside a quoted
+// string is part of it. An empty query has no conditions.
+func ParseQuery(q string) ([]Condition, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, nil
+	}
+
+	var conds []Condition
+	for _, clause := range splitClauses(q) {
+		clause = strings.TrimSpace(clause)
+
+		if m := existence.FindStringSubmatch(clause); m != nil {
+			field, err := saveDataField(m[1])
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, Condition{Field: field, Op: "$exists", Value: strings.EqualFold(m[2], "exists")})
+			continue
+		}
+
+		m := comparison.FindStringSubmatch(clause)
+		if m == nil {
+			return nil, fmt.Errorf("can't read %q; expected something like level > 10", clause)
+		}
+		field, err := saveDataField(m[1])
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, Condition{Field: field, Op: operators[m[2]], Value: parseQueryValue(m[3])})
+	}
+	return conds, nil
+}
+
+// splitClauses splits a query into its clauses at each "and" outside a
+// quoted string, so a value like "rock and roll" stays whole.
+func splitClauses(q string) []string {
+	// Blank out quoted text so clauseAnd only sees what is outside quotes;
+	// the blanked copy has the same length, so its matches index q
+	masked := []byte(q)
+	inQuote, escaped := false, false
+	for i, c := range masked {
+		switch {
+		case escaped:
+			escaped = false
+			masked[i] = '_'
+		case inQuote && c == '\\':
+			escaped = true
+			masked[i] = '_'
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+			masked[i] = '_'
+		}
+	}
+
+	var clauses []string
+	start := 0
+	for _, m := range clauseAnd.FindAllIndex(masked, -1) {
+		clauses = append(clauses, q[start:m[0]])
+		start = m[1]
+	}
+	return append(clauses, q[start:])
+}
+
+// saveDataField returns the document field for a save data path.
+func saveDataField(path string) (string, error) {
+	path = strings.TrimPrefix(path, "save_data.")
+	for _, seg := range strings.Split(path, ".") {
+		if !pathSegment.MatchString(seg) {
+			return "", fmt.Errorf("%q isn't a save data path; use names like level or inventory.gold", path)
+		}
+	}
+	return "save_data." + path, nil
+}
+
+// parseQueryValue reads a value as a JSON scalar, falling back to the
+// text itself.
+func parseQueryValue(s string) any {
+	s = strings.TrimSpace(s)
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		switch v.(type) {
+		case float64, bool, string, nil:
+			return v
+		}
+	}
+	return s
+}
+
+// queryFilter returns the MongoDB filter for conds, or nil if there are
+// none.
+func queryFilter(conds []Condition) bson.M {
+	switch len(conds) {
+	case 0:
+		return nil
+	case 1:
+		c := conds[0]
+		return bson.M{c.Field: bson.M{c.Op: c.Value}}
+	}
+	and := make([]bson.M, len(conds))
+	for i, c := range conds {
+		and[i] = bson.M{c.Field: bson.M{c.Op: c.Value}}
+	}
+	return bson.M{"$and": and}
+}
+
+// indexServes reports whether an index with the given keys can serve a
+// per-game query on field: the field leads the index, alone or right after
+// game, or a wildcard index covers the save data.
+func indexServes(keys bson.D, field string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	first := keys[0].Key
+	if first == "$**" || first == "save_data.$**" {
+		return true
+	}
+	if first == field {
+		return true
+	}
+	return first == "game" && len(keys) > 1 && keys[1].Key == field
+}
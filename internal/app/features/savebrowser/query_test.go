@@ -0,0 +1,96 @@
+package savebrowser
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Condition
+	}{
+		{"", nil},
+		{"level > 10", []Condition{{Field: "save_data.level", Op: "$gt", Value: 10.0}}},
+		{"save_data.level>=10", []Condition{{Field: "save_data.level", Op: "$gte", Value: 10.0}}},
+		{`world = "forest"`, []Condition{{Field: "save_data.world", Op: "$eq", Value: "forest"}}},
+		{"world == forest", []Condition{{Field: "save_data.world", Op: "$eq", Value: "forest"}}},
+		{"tutorial_done != true", []Condition{{Field: "save_data.tutorial_done", Op: "$ne", Value: true}}},
+		{"boss = null", []Condition{{Field: "save_data.boss", Op: "$eq", Value: nil}}},
+		{"items.0 exists AND gold <= 0", []Condition{
+			{Field: "save_data.items.0", Op: "$exists", Value: true},
+			{Field: "save_data.gold", Op: "$lte", Value: 0.0},
+		}},
+		{"inventory.sword missing", []Condition{{Field: "save_data.inventory.sword", Op: "$exists", Value: false}}},
+		{`name = "rock and roll"`, []Condition{{Field: "save_data.name", Op: "$eq", Value: "rock and roll"}}},
+		{`name = "say \"and\" twice" and level < 3`, []Condition{
+			{Field: "save_data.name", Op: "$eq", Value: `say "and" twice`},
+			{Field: "save_data.level", Op: "$lt", Value: 3.0},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuery(tt.in)
+		if err != nil {
+			t.Errorf("ParseQuery(%q) error = %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseQuery_Invalid(t *testing.T) {
+	for _, in := range []string{
+		"level",
+		"level >",
+		"> 10",
+		"$where = 1",
+		"level.$gt = 1",
+		"a..b = 1",
+	} {
+		if _, err := ParseQuery(in); err == nil {
+			t.Errorf("ParseQuery(%q) error = nil, want an error", in)
+		}
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	if f := queryFilter(nil); f != nil {
+		t.Errorf("queryFilter(nil) = %v, want nil", f)
+	}
+
+	one := []Condition{{Field: "save_data.level", Op: "$gt", Value: 10.0}}
+	want := bson.M{"save_data.level": bson.M{"$gt": 10.0}}
+	if f := queryFilter(one); !reflect.DeepEqual(f, want) {
+		t.Errorf("queryFilter() = %v, want %v", f, want)
+	}
+
+	two := append(one, Condition{Field: "save_data.level", Op: "$lt", Value: 20.0})
+	and, ok := queryFilter(two)["$and"].([]bson.M)
+	if !ok || len(and) != 2 {
+		t.Errorf("queryFilter() = %v, want both conditions under $and", queryFilter(two))
+	}
+}
+
+func TestIndexServes(t *testing.T) {
+	const field = "save_data.level"
+	tests := []struct {
+		keys bson.D
+		want bool
+	}{
+		{bson.D{{Key: "_id", Value: 1}}, false},
+		{bson.D{{Key: "game", Value: 1}, {Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}, false},
+		{bson.D{{Key: "game", Value: 1}, {Key: field, Value: 1}}, true},
+		{bson.D{{Key: field, Value: -1}}, true},
+		{bson.D{{Key: "save_data.$**", Value: 1}}, true},
+		{bson.D{{Key: "user_id", Value: 1}, {Key: field, Value: 1}}, false},
+	}
+	for _, tt := range tests {
+		if got := indexServes(tt.keys, field); got != tt.want {
+			t.Errorf("indexServes(%v) = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+}
@@ -22,6 +22,7 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 	// Playground - interactive API testing
 	r.Get("/playground", h.ServePlayground)
 	r.Post("/playground/execute", h.HandlePlaygroundExecute)
+	r.Post("/playground/snippets", h.HandlePlaygroundSnippets)
 
 	// Documentation
 	r.Get("/docs", h.ServeDocs)
@@ -29,6 +30,10 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 	// Create (for dev tool)
 	r.Post("/create", h.HandleCreateState)
 
+	// Edit operations
+	r.Post("/{game}/{id}/edit", h.HandleEditSave)
+	r.Post("/{game}/{id}/restore", h.HandleRestoreSave)
+
 	// Delete operations
 	r.Post("/{game}/{id}/delete", h.HandleDeleteSave)
 	r.Post("/{game}/user/{userID}/delete", h.HandleDeleteUserSaves)
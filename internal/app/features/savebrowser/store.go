@@ -194,6 +194,45 @@ func (s *Store) DeleteSave(ctx context.Context, game string, id primitive.Object
 	return err
 }
 
+// UpdateSaveData replaces a save's data, keeping its timestamp.
+// Returns mongo.ErrNoDocuments if the save doesn't exist.
+func (s *Store) UpdateSaveData(ctx context.Context, game string, id primitive.ObjectID, data bson.M) error {
+	coll := s.db.Collection(CollectionName)
+	result, err := coll.UpdateOne(ctx, bson.M{"_id": id, "game": game}, bson.M{"$set": bson.M{"save_data": data}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RestoreSave copies a previous save to a new save stamped now, so it is
+// what the next load returns. The copied save is left as it was.
+// Returns the new save, or nil if the save doesn't exist.
+func (s *Store) RestoreSave(ctx context.Context, game string, id primitive.ObjectID) (*PlayerState, error) {
+	save, err := s.GetSave(ctx, game, id)
+	if err != nil || save == nil {
+		return nil, err
+	}
+
+	restored := PlayerState{
+		UserID:    save.UserID,
+		Game:      save.Game,
+		Timestamp: time.Now().UTC(),
+		SaveData:  save.SaveData,
+	}
+	result, err := s.db.Collection(CollectionName).InsertOne(ctx, restored)
+	if err != nil {
+		return nil, err
+	}
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		restored.ID = oid
+	}
+	return &restored, nil
+}
+
 // DeleteUserSaves deletes all saves for a user/game.
 // Returns the number of deleted documents.
 func (s *Store) DeleteUserSaves(ctx context.Context, game, userID string) (int64, error) {
@@ -242,8 +281,9 @@ type UserWithCount struct {
 }
 
 // ListUsersWithCounts returns distinct user_ids with their save counts for a game.
-// Supports pagination and optional search filter.
-func (s *Store) ListUsersWithCounts(ctx context.Context, game, search string, page, limit int) ([]UserWithCount, int64, error) {
+// Supports pagination, an optional search filter, and an optional save data
+// filter (see queryFilter); with one, only matching saves are counted.
+func (s *Store) ListUsersWithCounts(ctx context.Context, game, search string, where bson.M, page, limit int) ([]UserWithCount, int64, error) {
 	coll := s.db.Collection(CollectionName)
 
 	// Build match filter
@@ -251,6 +291,9 @@ func (s *Store) ListUsersWithCounts(ctx context.Context, game, search string, pa
 	if search != "" {
 		matchFilter["user_id"] = bson.M{"$regex": search, "$options": "i"}
 	}
+	for k, v := range where {
+		matchFilter[k] = v
+	}
 
 	// Count total distinct users first
 	countPipeline := mongo.Pipeline{
@@ -302,3 +345,40 @@ func (s *Store) ListUsersWithCounts(ctx context.Context, game, search string, pa
 
 	return results, total, nil
 }
+
+// UnindexedFields returns the fields in conds that no index on the
+// collection can serve, so querying them scans every save for the game.
+func (s *Store) UnindexedFields(ctx context.Context, conds []Condition) ([]string, error) {
+	cursor, err := s.db.Collection(CollectionName).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var indexes []struct {
+		Key bson.D `bson:"key"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, err
+	}
+
+	var unindexed []string
+	seen := make(map[string]bool)
+	for _, c := range conds {
+		if seen[c.Field] {
+			continue
+		}
+		seen[c.Field] = true
+		served := false
+		for _, idx := range indexes {
+			if indexServes(idx.Key, c.Field) {
+				served = true
+				break
+			}
+		}
+		if !served {
+			unindexed = append(unindexed, c.Field)
+		}
+	}
+	return unindexed, nil
+}
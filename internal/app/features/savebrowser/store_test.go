@@ -1,10 +1,12 @@
 package savebrowser
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
@@ -101,3 +103,88 @@ func TestStore_ListUsers(t *testing.T) {
 		}
 	})
 }
+
+func TestStore_EditAndRestoreSave(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := NewStore(db, zap.NewNop())
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if err := store.CreateState(ctx, "mygame", "alice", bson.M{"level": 1}); err != nil {
+		t.Fatalf("CreateState() error = %v", err)
+	}
+	if err := store.CreateState(ctx, "mygame", "alice", bson.M{"level": 2}); err != nil {
+		t.Fatalf("CreateState() error = %v", err)
+	}
+	saves, _, _, err := store.ListSaves(ctx, "mygame", "alice", 10, "", "")
+	if err != nil || len(saves) != 2 {
+		t.Fatalf("ListSaves() = %d saves, %v; want 2", len(saves), err)
+	}
+	newest, oldest := saves[0], saves[1]
+
+	if err := store.UpdateSaveData(ctx, "mygame", newest.ID, bson.M{"level": 3}); err != nil {
+		t.Fatalf("UpdateSaveData() error = %v", err)
+	}
+	got, _ := store.GetSave(ctx, "mygame", newest.ID)
+	if fmt.Sprint(got.SaveData["level"]) != "3" || !got.Timestamp.Equal(newest.Timestamp) {
+		t.Errorf("edited save = %+v, want level 3 and the same timestamp", got)
+	}
+	if err := store.UpdateSaveData(ctx, "othergame", newest.ID, bson.M{}); err != mongo.ErrNoDocuments {
+		t.Errorf("UpdateSaveData(wrong game) error = %v, want ErrNoDocuments", err)
+	}
+
+	restored, err := store.RestoreSave(ctx, "mygame", oldest.ID)
+	if err != nil {
+		t.Fatalf("RestoreSave() error = %v", err)
+	}
+	saves, _, _, _ = store.ListSaves(ctx, "mygame", "alice", 10, "", "")
+	if len(saves) != 3 || saves[0].ID != restored.ID || fmt.Sprint(saves[0].SaveData["level"]) != "1" {
+		t.Errorf("after restore, latest = %+v, want a copy of level 1", saves[0])
+	}
+}
+
+func TestStore_QuerySaveData(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := NewStore(db, zap.NewNop())
+
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	for _, s := range []struct {
+		user  string
+		level int
+	}{{"alice", 5}, {"alice", 12}, {"alice", 15}, {"bob", 3}, {"carol", 11}} {
+		if err := store.CreateState(ctx, "mygame", s.user, bson.M{"level": s.level}); err != nil {
+			t.Fatalf("CreateState() error = %v", err)
+		}
+	}
+
+	conds, err := ParseQuery("level > 10")
+	if err != nil {
+		t.Fatalf("ParseQuery() error = %v", err)
+	}
+	users, total, err := store.ListUsersWithCounts(ctx, "mygame", "", queryFilter(conds), 1, 20)
+	if err != nil {
+		t.Fatalf("ListUsersWithCounts() error = %v", err)
+	}
+	if total != 2 || len(users) != 2 || users[0].UserID != "alice" || users[0].SaveCount != 2 || users[1].UserID != "carol" {
+		t.Errorf("ListUsersWithCounts() = %+v (total %d), want alice with 2 and carol with 1", users, total)
+	}
+
+	unindexed, err := store.UnindexedFields(ctx, conds)
+	if err != nil {
+		t.Fatalf("UnindexedFields() error = %v", err)
+	}
+	if len(unindexed) != 1 || unindexed[0] != "save_data.level" {
+		t.Errorf("UnindexedFields() = %v, want save_data.level", unindexed)
+	}
+
+	idx := mongo.IndexModel{Keys: bson.D{{Key: "game", Value: 1}, {Key: "save_data.level", Value: 1}}}
+	if _, err := db.Collection(CollectionName).Indexes().CreateOne(ctx, idx); err != nil {
+		t.Fatalf("CreateOne() error = %v", err)
+	}
+	if unindexed, _ = store.UnindexedFields(ctx, conds); len(unindexed) != 0 {
+		t.Errorf("UnindexedFields() = %v after indexing, want none", unindexed)
+	}
+}
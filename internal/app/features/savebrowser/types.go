@@ -23,6 +23,12 @@ type ListVM struct {
 	PlayerSearch string
 	SelectedUser string
 
+	// Save data query (see ParseQuery)
+	SaveQuery       string
+	SaveQueryParam  string // SaveQuery escaped for pagination links
+	QueryError      string
+	UnindexedFields []string // Queried fields no index serves
+
 	// Pagination for players
 	PlayerTotal      int64
 	PlayerPage       int
@@ -59,6 +65,7 @@ type SaveRowVM struct {
 	Game      string
 	Timestamp time.Time
 	SaveData  string // JSON string for display
+	Latest    bool   // The player's newest save (nothing to restore over)
 }
 
 // SavesPartialVM is the view model for the saves HTMX partial.
@@ -81,6 +88,10 @@ type PlayersPartialVM struct {
 	SelectedGame     string
 	SelectedUser     string
 	PlayerSearch     string
+	SaveQuery        string
+	SaveQueryParam   string
+	QueryError       string
+	UnindexedFields  []string
 	Players          []PlayerRowVM
 	PlayerTotal      int64
 	PlayerPage       int
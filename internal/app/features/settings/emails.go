@@ -0,0 +1,147 @@
+// internal/app/features/settings/emails.go
+package settings
+
+import (
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.uber.org/zap"
+)
+
+// testSubjectPrefix marks test messages so recipients can tell them apart
+// from real notifications.
+const testSubjectPrefix = "[Test] "
+
+// EmailPreviewVM is the view model for the email preview page.
+type EmailPreviewVM struct {
+	viewdata.BaseVM
+	Samples       []mailer.Sample
+	Locales       []mailer.Locale
+	Template      string // Selected sample name
+	Locale        string // Selected locale code
+	View          string // "html" or "text"
+	Subject       string
+	HTMLBody      string
+	TextBody      string
+	To            string // Test recipient
+	MailerEnabled bool
+	Success       string
+	Error         string
+}
+
+// showEmails renders an email template with sample data using the current
+// branding.
+func (h *Handler) showEmails(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	vm := h.emailPreviewVM(r, q.Get("template"), q.Get("locale"), q.Get("view"))
+
+	if user, ok := auth.CurrentUser(r); ok && strings.Contains(user.LoginID, "@") {
+		vm.To = user.LoginID
+	}
+	if q.Get("sent") != "" {
+		vm.Success = "Test email sent to " + q.Get("sent") + "."
+	}
+
+	templates.Render(w, r, "settings/emails", vm)
+}
+
+// sendTestEmail sends the selected template to the given address. The email
+// is delivered immediately rather than queued so SMTP errors are shown to the
+// admin.
+func (h *Handler) sendTestEmail(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	name := r.FormValue("template")
+	locale := r.FormValue("locale")
+	to := strings.TrimSpace(r.FormValue("to"))
+
+	renderErr := func(msg string) {
+		vm := h.emailPreviewVM(r, name, locale, r.FormValue("view"))
+		vm.To = to
+		vm.Error = msg
+		templates.Render(w, r, "settings/emails", vm)
+	}
+
+	if h.mailer == nil {
+		renderErr("Email is not configured. Set the SMTP settings to send test messages.")
+		return
+	}
+	addr, err := mail.ParseAddress(to)
+	if err != nil || addr.Address != to {
+		renderErr("Enter a valid email address.")
+		return
+	}
+	if h.mailer.Suppressed(to) {
+		renderErr(to + " is on the email suppression list. Remove it from the list to send a test message.")
+		return
+	}
+
+	email, ok := mailer.RenderSample(name, locale, viewdata.New(r).SiteName, h.mailer.Brand(r.Context()))
+	if !ok {
+		renderErr("Unknown email template.")
+		return
+	}
+	email.To = to
+	email.Subject = testSubjectPrefix + email.Subject
+	email.Template = name
+
+	if err := h.mailer.Deliver(email); err != nil {
+		h.errLog.Log(r, "failed to send test email", err)
+		renderErr("Failed to send the test email: " + err.Error())
+		return
+	}
+
+	h.logger.Info("test email sent",
+		zap.String("template", name),
+		zap.String("to", to))
+
+	v := url.Values{"template": {name}, "locale": {locale}, "view": {r.FormValue("view")}, "sent": {to}}
+	http.Redirect(w, r, "/settings/emails?"+v.Encode(), http.StatusSeeOther)
+}
+
+// emailPreviewVM renders the named sample (the first one if name is
+// unknown) for the preview page.
+func (h *Handler) emailPreviewVM(r *http.Request, name, locale, view string) EmailPreviewVM {
+	samples := mailer.Samples()
+	found := false
+	for _, s := range samples {
+		found = found || s.Name == name
+	}
+	if !found {
+		name = samples[0].Name
+	}
+	locale = mailer.NormalizeLocale(locale)
+	if view != "text" {
+		view = "html"
+	}
+
+	vm := EmailPreviewVM{
+		BaseVM:        viewdata.New(r),
+		Samples:       samples,
+		Locales:       mailer.Locales(),
+		Template:      name,
+		Locale:        locale,
+		View:          view,
+		MailerEnabled: h.mailer != nil,
+	}
+	vm.Title = "Email Templates"
+
+	var brand mailer.Brand
+	if h.mailer != nil {
+		brand = h.mailer.Brand(r.Context())
+	}
+	email, _ := mailer.RenderSample(name, locale, vm.SiteName, brand)
+	vm.Subject = email.Subject
+	vm.HTMLBody = email.HTMLBody
+	vm.TextBody = email.TextBody
+	return vm
+}
@@ -7,11 +7,22 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
 	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/htmlsanitize"
+	"github.com/dalemusser/stratasave/internal/app/system/inputval"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/reports"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/waffle/pantry/storage"
@@ -26,6 +37,9 @@ import (
 type Handler struct {
 	settingsStore *settingsstore.Store
 	fileStorage   storage.Store
+	mailer        *mailer.Mailer   // nil if email is not configured
+	auditLogger   *auditlog.Logger // nil unless set with SetAuditLogger
+	secrets       *secretbox.Box   // nil unless set with SetSecretBox
 	errLog        *errorsfeature.ErrorLogger
 	logger        *zap.Logger
 }
@@ -34,34 +48,68 @@ type Handler struct {
 func NewHandler(
 	db *mongo.Database,
 	fileStorage storage.Store,
+	mail *mailer.Mailer,
 	errLog *errorsfeature.ErrorLogger,
 	logger *zap.Logger,
 ) *Handler {
 	return &Handler{
 		settingsStore: settingsstore.New(db),
 		fileStorage:   fileStorage,
+		mailer:        mail,
 		errLog:        errLog,
 		logger:        logger,
 	}
 }
 
+// SetAuditLogger records settings changes in the audit log.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
 // SettingsVM is the view model for the settings page.
 type SettingsVM struct {
 	viewdata.BaseVM
-	Settings       *models.SiteSettings
-	LandingTitle   string // Landing page title (with default if empty)
-	LandingContent string // Landing page content
-	HasLogo        bool   // Whether a logo is uploaded
-	LogoURL        string // Generated URL for the logo
-	LogoName       string // Original filename of the logo
-	Success        string
-	Error          string
+	Settings          *models.SiteSettings
+	LandingTitle      string            // Landing page title (with default if empty)
+	LandingContent    string            // Landing page content
+	HasLogo           bool              // Whether a logo is uploaded
+	LogoURL           string            // Generated URL for the logo
+	LogoName          string            // Original filename of the logo
+	RegistrationRoles []string          // Roles that may be given to self-registered users
+	ReportSections    []reportSectionVM // Sections scheduled reports can include
+	ReportRecipients  string            // Report recipients, one per line
+	AllowedDomains    string            // Allowed email domains, one per line
+	EmailEnabled      bool              // Whether email is configured, so reports can be sent
+	Success           string
+	Error             string
+}
+
+// reportSectionVM is a scheduled report section checkbox.
+type reportSectionVM struct {
+	Name     string
+	Label    string
+	Selected bool
+}
+
+// reportSectionVMs returns the report section checkboxes for the settings.
+func reportSectionVMs(s *models.SiteSettings) []reportSectionVM {
+	vms := make([]reportSectionVM, len(reports.Sections))
+	for i, sec := range reports.Sections {
+		vms[i] = reportSectionVM{Name: sec.Name, Label: sec.Label, Selected: reports.Includes(s.ReportSections, sec.Name)}
+	}
+	return vms
 }
 
 // MountRoutes mounts settings routes on the given router.
-func (h *Handler) MountRoutes(r chi.Router) {
-	r.Get("/", h.show)
-	r.Post("/", h.update)
+func (h *Handler) MountRoutes(r chi.Router, sm *auth.SessionManager) {
+	// The settings form covers registration and other auth settings, so
+	// both showing and saving it need a recent identity confirmation
+	r.With(sm.RequireRecentAuth).Get("/", h.show)
+	r.With(sm.RequireRecentAuth).Post("/", h.update)
+	r.Get("/emails", h.showEmails)
+	r.With(sm.RequireRecentAuth).Get("/smtp", h.showSMTP)
+	r.With(sm.RequireRecentAuth).Post("/smtp", h.updateSMTP)
+	r.Post("/emails/test", h.sendTestEmail)
 }
 
 // show displays the settings page.
@@ -92,13 +140,18 @@ func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 	}
 
 	vm := SettingsVM{
-		BaseVM:         viewdata.New(r),
-		Settings:       settings,
-		LandingTitle:   landingTitle,
-		LandingContent: settings.LandingContent,
-		HasLogo:        settings.HasLogo(),
-		LogoURL:        logoURL,
-		LogoName:       settings.LogoName,
+		BaseVM:            viewdata.New(r),
+		Settings:          settings,
+		LandingTitle:      landingTitle,
+		LandingContent:    settings.LandingContent,
+		HasLogo:           settings.HasLogo(),
+		LogoURL:           logoURL,
+		LogoName:          settings.LogoName,
+		RegistrationRoles: models.RegistrationRoles(),
+		ReportSections:    reportSectionVMs(settings),
+		ReportRecipients:  strings.Join(settings.ReportRecipients, "\n"),
+		AllowedDomains:    strings.Join(settings.AllowedEmailDomains, "\n"),
+		EmailEnabled:      h.mailer != nil,
 	}
 	vm.Title = "Site Settings"
 	vm.SiteName = settings.SiteName
@@ -117,6 +170,15 @@ const MaxContentLength = 100000
 // MaxFooterLength is the maximum allowed length for footer HTML (10KB).
 const MaxFooterLength = 10000
 
+// MaxEmailFooterLength is the maximum allowed length for the email footer text.
+const MaxEmailFooterLength = 500
+
+// MaxReportRecipients is the most addresses scheduled reports can be sent to.
+const MaxReportRecipients = 50
+
+// MaxAllowedDomains is the most email domains signups can be restricted to.
+const MaxAllowedDomains = 50
+
 // update saves the settings including logo handling.
 func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form for file uploads (10MB max)
@@ -132,6 +194,8 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	rawLandingContent := r.FormValue("landing_content")
 	rawFooterHTML := r.FormValue("footer_html")
 	removeLogo := r.FormValue("remove_logo") != ""
+	emailPrimaryColor := strings.TrimSpace(r.FormValue("email_primary_color"))
+	emailFooterText := strings.TrimSpace(r.FormValue("email_footer_text"))
 
 	// Validate content lengths
 	if len(rawLandingContent) > MaxContentLength {
@@ -142,6 +206,14 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		h.renderSettingsWithError(w, r, "Footer HTML is too long. Maximum length is 10,000 characters.")
 		return
 	}
+	if emailPrimaryColor != "" && !mailer.IsValidColor(emailPrimaryColor) {
+		h.renderSettingsWithError(w, r, "Email button color must be a hex color such as #4f46e5.")
+		return
+	}
+	if len(emailFooterText) > MaxEmailFooterLength {
+		h.renderSettingsWithError(w, r, "Email footer text is too long. Maximum length is 500 characters.")
+		return
+	}
 
 	landingContent := htmlsanitize.Sanitize(rawLandingContent)
 	footerHTML := htmlsanitize.Sanitize(rawFooterHTML)
@@ -196,18 +268,68 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	notifyUserOnDisable := r.FormValue("notify_user_on_disable") == "on"
 	notifyUserOnEnable := r.FormValue("notify_user_on_enable") == "on"
 	notifyUserOnWelcome := r.FormValue("notify_user_on_welcome") == "on"
+	notifyUserOnNewDevice := r.FormValue("notify_user_on_new_device") == "on"
+
+	// Parse scheduled report settings. Selecting every section is stored
+	// as none, so sections added later are included too.
+	reportWeekly := r.FormValue("report_weekly") == "on"
+	reportMonthly := r.FormValue("report_monthly") == "on"
+	var reportSections []string
+	for _, name := range r.Form["report_sections"] {
+		if reports.ValidSection(name) && !slices.Contains(reportSections, name) {
+			reportSections = append(reportSections, name)
+		}
+	}
+	if len(reportSections) == len(reports.Sections) {
+		reportSections = nil
+	} else if len(reportSections) == 0 && (reportWeekly || reportMonthly) {
+		h.renderSettingsWithError(w, r, "Choose at least one section for scheduled reports.")
+		return
+	}
+	reportRecipients, err := parseRecipients(r.FormValue("report_recipients"))
+	if err != nil {
+		h.renderSettingsWithError(w, r, err.Error())
+		return
+	}
+
+	// Parse self-service registration settings
+	registrationEnabled := r.FormValue("registration_enabled") == "on"
+	registrationRequiresApproval := r.FormValue("registration_requires_approval") == "on"
+	registrationRole := r.FormValue("registration_role")
+	if !slices.Contains(models.RegistrationRoles(), registrationRole) {
+		registrationRole = models.RoleDeveloper
+	}
+	allowedDomains, err := parseDomains(r.FormValue("allowed_email_domains"))
+	if err != nil {
+		h.renderSettingsWithError(w, r, err.Error())
+		return
+	}
 
 	input := settingsstore.UpdateInput{
-		SiteName:            siteName,
-		LandingTitle:        landingTitle,
-		LandingContent:      landingContent,
-		FooterHTML:          footerHTML,
-		LogoPath:            logoPath,
-		LogoName:            logoName,
-		NotifyUserOnCreate:  notifyUserOnCreate,
-		NotifyUserOnDisable: notifyUserOnDisable,
-		NotifyUserOnEnable:  notifyUserOnEnable,
-		NotifyUserOnWelcome: notifyUserOnWelcome,
+		SiteName:              siteName,
+		LandingTitle:          landingTitle,
+		LandingContent:        landingContent,
+		FooterHTML:            footerHTML,
+		LogoPath:              logoPath,
+		LogoName:              logoName,
+		EmailPrimaryColor:     emailPrimaryColor,
+		EmailFooterText:       emailFooterText,
+		NotifyUserOnCreate:    notifyUserOnCreate,
+		NotifyUserOnDisable:   notifyUserOnDisable,
+		NotifyUserOnEnable:    notifyUserOnEnable,
+		NotifyUserOnWelcome:   notifyUserOnWelcome,
+		NotifyUserOnNewDevice: notifyUserOnNewDevice,
+
+		ReportWeekly:     reportWeekly,
+		ReportMonthly:    reportMonthly,
+		ReportSections:   reportSections,
+		ReportRecipients: reportRecipients,
+
+		RegistrationEnabled:          registrationEnabled,
+		RegistrationRole:             registrationRole,
+		RegistrationRequiresApproval: registrationRequiresApproval,
+
+		AllowedEmailDomains: allowedDomains,
 	}
 
 	if err := h.settingsStore.Upsert(ctx, input); err != nil {
@@ -216,9 +338,108 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	updated := *current
+	updated.SiteName = input.SiteName
+	updated.LandingTitle = input.LandingTitle
+	updated.LandingContent = input.LandingContent
+	updated.FooterHTML = input.FooterHTML
+	updated.LogoPath = input.LogoPath
+	updated.LogoName = input.LogoName
+	updated.EmailPrimaryColor = input.EmailPrimaryColor
+	updated.EmailFooterText = input.EmailFooterText
+	updated.NotifyUserOnCreate = input.NotifyUserOnCreate
+	updated.NotifyUserOnDisable = input.NotifyUserOnDisable
+	updated.NotifyUserOnEnable = input.NotifyUserOnEnable
+	updated.NotifyUserOnWelcome = input.NotifyUserOnWelcome
+	updated.NotifyUserOnNewDevice = input.NotifyUserOnNewDevice
+	updated.ReportWeekly = input.ReportWeekly
+	updated.ReportMonthly = input.ReportMonthly
+	updated.ReportSections = input.ReportSections
+	updated.ReportRecipients = input.ReportRecipients
+	updated.RegistrationEnabled = input.RegistrationEnabled
+	updated.RegistrationRole = input.RegistrationRole
+	updated.RegistrationRequiresApproval = input.RegistrationRequiresApproval
+	updated.AllowedEmailDomains = input.AllowedEmailDomains
+
+	actor, _ := auth.CurrentUser(r)
+	changes := audit.Diff(settingsAuditFields(current), settingsAuditFields(&updated))
+	h.auditLogger.SettingsUpdated(ctx, r, actor.UserID(), actor.Role, changes)
+
 	http.Redirect(w, r, "/settings?success=1", http.StatusSeeOther)
 }
 
+// settingsAuditFields returns the settings an admin can edit, as shown in
+// the audit log. The logo includes its storage path, which is new for every
+// upload, so replacing it with a file of the same name still shows.
+func settingsAuditFields(s *models.SiteSettings) map[string]string {
+	logo := ""
+	if s.HasLogo() {
+		logo = s.LogoName + " (" + s.LogoPath + ")"
+	}
+	return map[string]string{
+		"site_name":                      s.SiteName,
+		"landing_title":                  s.LandingTitle,
+		"landing_content":                s.LandingContent,
+		"footer_html":                    s.FooterHTML,
+		"logo":                           logo,
+		"email_primary_color":            s.EmailPrimaryColor,
+		"email_footer_text":              s.EmailFooterText,
+		"notify_user_on_create":          strconv.FormatBool(s.NotifyUserOnCreate),
+		"notify_user_on_disable":         strconv.FormatBool(s.NotifyUserOnDisable),
+		"notify_user_on_enable":          strconv.FormatBool(s.NotifyUserOnEnable),
+		"notify_user_on_welcome":         strconv.FormatBool(s.NotifyUserOnWelcome),
+		"notify_user_on_new_device":      strconv.FormatBool(s.NotifyUserOnNewDevice),
+		"report_weekly":                  strconv.FormatBool(s.ReportWeekly),
+		"report_monthly":                 strconv.FormatBool(s.ReportMonthly),
+		"report_sections":                strings.Join(s.ReportSections, ", "),
+		"report_recipients":              strings.Join(s.ReportRecipients, ", "),
+		"registration_enabled":           strconv.FormatBool(s.RegistrationEnabled),
+		"registration_role":              s.RegistrationRole,
+		"registration_requires_approval": strconv.FormatBool(s.RegistrationRequiresApproval),
+		"allowed_email_domains":          strings.Join(s.AllowedEmailDomains, ", "),
+	}
+}
+
+// parseRecipients splits a list of email addresses on commas and line
+// breaks, dropping blanks and repeats.
+func parseRecipients(raw string) ([]string, error) {
+	var out []string
+	for _, addr := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		addr = strings.TrimSpace(addr)
+		if addr == "" || slices.Contains(out, addr) {
+			continue
+		}
+		if !inputval.IsValidEmail(addr) {
+			return nil, fmt.Errorf("%q is not a valid email address.", addr)
+		}
+		out = append(out, addr)
+	}
+	if len(out) > MaxReportRecipients {
+		return nil, fmt.Errorf("Scheduled reports can be sent to at most %d addresses.", MaxReportRecipients)
+	}
+	return out, nil
+}
+
+// parseDomains splits a list of email domains on commas, spaces and line
+// breaks, lowercasing them and dropping blanks, repeats and a leading "@".
+func parseDomains(raw string) ([]string, error) {
+	var out []string
+	for _, d := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		d = strings.ToLower(strings.TrimPrefix(d, "@"))
+		if d == "" || slices.Contains(out, d) {
+			continue
+		}
+		if !strings.Contains(d, ".") || !inputval.IsValidEmail("user@"+d) {
+			return nil, fmt.Errorf("%q is not a valid email domain.", d)
+		}
+		out = append(out, d)
+	}
+	if len(out) > MaxAllowedDomains {
+		return nil, fmt.Errorf("At most %d email domains can be allowed.", MaxAllowedDomains)
+	}
+	return out, nil
+}
+
 // renderSettingsWithError re-renders the settings page with an error message.
 func (h *Handler) renderSettingsWithError(w http.ResponseWriter, r *http.Request, errMsg string) {
 	settings, _ := h.settingsStore.Get(r.Context())
@@ -237,14 +458,19 @@ func (h *Handler) renderSettingsWithError(w http.ResponseWriter, r *http.Request
 	}
 
 	vm := SettingsVM{
-		BaseVM:         viewdata.New(r),
-		Settings:       settings,
-		LandingTitle:   landingTitle,
-		LandingContent: settings.LandingContent,
-		HasLogo:        settings.HasLogo(),
-		LogoURL:        logoURL,
-		LogoName:       settings.LogoName,
-		Error:          errMsg,
+		BaseVM:            viewdata.New(r),
+		Settings:          settings,
+		LandingTitle:      landingTitle,
+		LandingContent:    settings.LandingContent,
+		HasLogo:           settings.HasLogo(),
+		LogoURL:           logoURL,
+		LogoName:          settings.LogoName,
+		RegistrationRoles: models.RegistrationRoles(),
+		ReportSections:    reportSectionVMs(settings),
+		ReportRecipients:  strings.Join(settings.ReportRecipients, "\n"),
+		AllowedDomains:    strings.Join(settings.AllowedEmailDomains, "\n"),
+		EmailEnabled:      h.mailer != nil,
+		Error:             errMsg,
 	}
 	vm.Title = "Site Settings"
 	vm.SiteName = settings.SiteName
@@ -18,7 +18,7 @@ func TestNewHandler(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	logger := zap.NewNop()
 
-	h := NewHandler(db, nil, nil, logger)
+	h := NewHandler(db, nil, nil, nil, logger)
 
 	if h == nil {
 		t.Fatal("NewHandler() returned nil")
@@ -363,7 +363,7 @@ func TestMountRoutes(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	logger := zap.NewNop()
 
-	h := NewHandler(db, nil, nil, logger)
+	h := NewHandler(db, nil, nil, nil, logger)
 
 	// Verify MountRoutes doesn't panic
 	// We can't fully test without a chi.Router setup
@@ -492,3 +492,39 @@ func TestLogoPathGeneration(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRecipients(t *testing.T) {
+	got, err := parseRecipients("ops@example.com\r\n\r\nlead@example.com, ops@example.com")
+	if err != nil {
+		t.Fatalf("parseRecipients() error = %v", err)
+	}
+	if strings.Join(got, " ") != "ops@example.com lead@example.com" {
+		t.Errorf("parseRecipients() = %v", got)
+	}
+
+	if got, err := parseRecipients("  "); err != nil || got != nil {
+		t.Errorf("parseRecipients(blank) = %v, %v; want nil, nil", got, err)
+	}
+	if _, err := parseRecipients("ops@example.com\nnot-an-address"); err == nil {
+		t.Error("parseRecipients() accepted an invalid address")
+	}
+}
+
+func TestParseDomains(t *testing.T) {
+	got, err := parseDomains("Example.com\r\n@corp.example.org, example.com")
+	if err != nil {
+		t.Fatalf("parseDomains() error = %v", err)
+	}
+	if strings.Join(got, " ") != "example.com corp.example.org" {
+		t.Errorf("parseDomains() = %v", got)
+	}
+
+	if got, err := parseDomains("  "); err != nil || got != nil {
+		t.Errorf("parseDomains(blank) = %v, %v; want nil, nil", got, err)
+	}
+	for _, bad := range []string{"localhost", "user@example.com", "exa mple.com;x"} {
+		if _, err := parseDomains(bad); err == nil {
+			t.Errorf("parseDomains(%q) accepted an invalid domain", bad)
+		}
+	}
+}
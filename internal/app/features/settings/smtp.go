@@ -0,0 +1,263 @@
+// internal/app/features/settings/smtp.go
+package settings
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/emailsmtp"
+	"github.com/dalemusser/stratasave/internal/app/system/inputval"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"go.uber.org/zap"
+)
+
+// SMTPVM is the view model for the SMTP settings page.
+type SMTPVM struct {
+	viewdata.BaseVM
+	MailerEnabled bool        // Whether email is configured at all
+	Editable      bool        // Whether settings_encryption_key is set, so settings can be saved
+	Configured    mailer.SMTP // The mail_smtp_* and mail_from* config values
+	Saved         bool        // Whether settings saved here replace the configured ones
+	HasPassword   bool        // Whether a password is saved
+	Host          string
+	Port          string
+	User          string
+	From          string
+	FromName      string
+	TestTo        string
+	Success       string
+	Error         string
+}
+
+// SetSecretBox lets admins save SMTP settings, encrypting the password with
+// box. Without it the SMTP settings page is read-only.
+func (h *Handler) SetSecretBox(box *secretbox.Box) {
+	h.secrets = box
+}
+
+// showSMTP displays the SMTP settings form.
+func (h *Handler) showSMTP(w http.ResponseWriter, r *http.Request) {
+	vm, err := h.smtpVM(r)
+	if err != nil {
+		h.errLog.Log(r, "failed to get settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case r.URL.Query().Get("saved") == "1":
+		vm.Success = "SMTP settings saved. They are used for the next email sent."
+	case r.URL.Query().Get("reset") == "1":
+		vm.Success = "SMTP settings cleared. Email is sent with the server from the configuration."
+	}
+	templates.Render(w, r, "settings/smtp", vm)
+}
+
+// smtpVM returns the SMTP page showing the settings in effect: those saved
+// on this page, or else the configured ones.
+func (h *Handler) smtpVM(r *http.Request) (SMTPVM, error) {
+	vm := SMTPVM{
+		BaseVM:        viewdata.New(r),
+		MailerEnabled: h.mailer != nil,
+		Editable:      h.mailer != nil && h.secrets != nil,
+	}
+	vm.Title = "Email Server"
+	if user, ok := auth.CurrentUser(r); ok && strings.Contains(user.LoginID, "@") {
+		vm.TestTo = user.LoginID
+	}
+	if h.mailer == nil {
+		return vm, nil
+	}
+
+	vm.Configured = h.mailer.ConfiguredSMTP()
+	s := vm.Configured
+	settings, err := h.settingsStore.Get(r.Context())
+	if err != nil {
+		return vm, err
+	}
+	if settings.SMTP != nil {
+		vm.Saved = true
+		vm.HasPassword = settings.SMTP.PassEncrypted != ""
+		s = mailer.SMTP{
+			Host:     settings.SMTP.Host,
+			Port:     settings.SMTP.Port,
+			User:     settings.SMTP.User,
+			From:     settings.SMTP.From,
+			FromName: settings.SMTP.FromName,
+		}
+	}
+	vm.Host, vm.Port, vm.User = s.Host, strconv.Itoa(s.Port), s.User
+	vm.From, vm.FromName = s.From, s.FromName
+	return vm, nil
+}
+
+// updateSMTP saves the SMTP settings, sends a test email with them, or
+// clears them, depending on the button pressed.
+func (h *Handler) updateSMTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	vm, err := h.smtpVM(r)
+	if err != nil {
+		h.errLog.Log(r, "failed to get settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !vm.Editable {
+		vm.Error = "SMTP settings can't be changed here until settings_encryption_key is set."
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+
+	if r.FormValue("action") == "reset" {
+		if err := h.settingsStore.SetSMTP(ctx, nil); err != nil {
+			h.errLog.Log(r, "failed to clear SMTP settings", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		h.auditLogger.LogAdminEvent(r, &actorID, nil, "smtp_settings_cleared", nil)
+		http.Redirect(w, r, "/settings/smtp?reset=1", http.StatusSeeOther)
+		return
+	}
+
+	vm.Host = strings.TrimSpace(r.FormValue("host"))
+	vm.Port = strings.TrimSpace(r.FormValue("port"))
+	vm.User = strings.TrimSpace(r.FormValue("user"))
+	vm.From = strings.TrimSpace(r.FormValue("from"))
+	vm.FromName = strings.TrimSpace(r.FormValue("from_name"))
+	vm.TestTo = strings.TrimSpace(r.FormValue("test_to"))
+	pass := r.FormValue("pass")
+
+	s, msg := parseSMTP(vm.Host, vm.Port, vm.User, vm.From, vm.FromName)
+	if msg != "" {
+		vm.Error = msg
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+
+	// A blank password keeps the saved one
+	sealed := ""
+	current, err := h.settingsStore.Get(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to get settings", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if pass != "" {
+		s.Pass = pass
+	} else if current.SMTP != nil && current.SMTP.PassEncrypted != "" {
+		saved, _, err := emailsmtp.Open(h.secrets, current.SMTP)
+		if err != nil {
+			vm.Error = "The saved password can't be decrypted, probably because settings_encryption_key changed. Enter the password again."
+			templates.Render(w, r, "settings/smtp", vm)
+			return
+		}
+		s.Pass = saved.Pass
+		sealed = current.SMTP.PassEncrypted
+	}
+
+	if r.FormValue("action") == "test" {
+		h.testSMTP(w, r, vm, s)
+		return
+	}
+
+	if pass != "" {
+		if sealed, err = h.secrets.Seal(pass); err != nil {
+			h.errLog.Log(r, "failed to encrypt SMTP password", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+	err = h.settingsStore.SetSMTP(ctx, &models.SMTPSettings{
+		Host:          s.Host,
+		Port:          s.Port,
+		User:          s.User,
+		PassEncrypted: sealed,
+		From:          s.From,
+		FromName:      s.FromName,
+	})
+	if err != nil {
+		h.errLog.Log(r, "failed to save SMTP settings", err)
+		vm.Error = "Failed to save SMTP settings"
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "smtp_settings_updated", map[string]string{
+		"host":             s.Host,
+		"port":             strconv.Itoa(s.Port),
+		"user":             s.User,
+		"from":             s.From,
+		"from_name":        s.FromName,
+		"password_changed": strconv.FormatBool(pass != ""),
+	})
+	http.Redirect(w, r, "/settings/smtp?saved=1", http.StatusSeeOther)
+}
+
+// testSMTP sends a test email through the settings in the form, which may
+// not be saved yet, and shows the result.
+func (h *Handler) testSMTP(w http.ResponseWriter, r *http.Request, vm SMTPVM, s mailer.SMTP) {
+	addr, err := mail.ParseAddress(vm.TestTo)
+	if err != nil || addr.Address != vm.TestTo {
+		vm.Error = "Enter a valid email address to send the test email to."
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+	if h.mailer.Suppressed(vm.TestTo) {
+		vm.Error = vm.TestTo + " is on the email suppression list. Remove it from the list to send a test message."
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+
+	email := mailer.Email{
+		To:       vm.TestTo,
+		Subject:  testSubjectPrefix + vm.SiteName + " email settings",
+		TextBody: fmt.Sprintf("This test email was sent through %s:%d from %s.\n\nIf you received it, %s can deliver email with these settings.\n", s.Host, s.Port, s.From, vm.SiteName),
+		Template: "smtp_test",
+	}
+	if err := h.mailer.DeliverVia(s, email); err != nil {
+		vm.Error = "The test email could not be sent: " + err.Error()
+		templates.Render(w, r, "settings/smtp", vm)
+		return
+	}
+
+	h.logger.Info("SMTP test email sent",
+		zap.String("host", s.Host),
+		zap.String("to", vm.TestTo))
+	vm.Success = "Test email sent to " + vm.TestTo + ". Changes to the settings take effect once saved."
+	templates.Render(w, r, "settings/smtp", vm)
+}
+
+// parseSMTP validates the SMTP form fields and returns them as settings,
+// or a message saying what is wrong.
+func parseSMTP(host, port, user, from, fromName string) (mailer.SMTP, string) {
+	s := mailer.SMTP{Host: host, User: user, From: from, FromName: fromName}
+	if host == "" || strings.ContainsAny(host, " /:") {
+		return s, "Enter the SMTP server's host name, such as smtp.example.com."
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return s, "Port must be a number from 1 to 65535."
+	}
+	s.Port = p
+	if !inputval.IsValidEmail(from) {
+		return s, "From address must be a valid email address."
+	}
+	if len(fromName) > 100 || strings.ContainsAny(fromName, "\r\n<>\"") {
+		return s, "From name can be at most 100 characters, without quotes or angle brackets."
+	}
+	return s, ""
+}
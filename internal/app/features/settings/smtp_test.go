@@ -0,0 +1,95 @@
+package settings
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/emailsmtp"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func TestParseSMTP(t *testing.T) {
+	s, msg := parseSMTP("smtp.example.com", "587", "mailer", "hello@example.com", "Example")
+	want := mailer.SMTP{Host: "smtp.example.com", Port: 587, User: "mailer", From: "hello@example.com", FromName: "Example"}
+	if msg != "" || s != want {
+		t.Errorf("parseSMTP() = %+v, %q, want %+v", s, msg, want)
+	}
+
+	bad := [][5]string{
+		{"", "587", "", "hello@example.com", ""},
+		{"smtp.example.com:587", "587", "", "hello@example.com", ""},
+		{"smtp.example.com", "0", "", "hello@example.com", ""},
+		{"smtp.example.com", "70000", "", "hello@example.com", ""},
+		{"smtp.example.com", "smtp", "", "hello@example.com", ""},
+		{"smtp.example.com", "587", "", "not-an-email", ""},
+		{"smtp.example.com", "587", "", "hello@example.com", "Evil <x@example.com>"},
+	}
+	for _, b := range bad {
+		if _, msg := parseSMTP(b[0], b[1], b[2], b[3], b[4]); msg == "" {
+			t.Errorf("parseSMTP(%q) accepted invalid settings", b)
+		}
+	}
+}
+
+func TestUpdateSMTP_SavesEncryptedPassword(t *testing.T) {
+	testutil.MustBootTemplates(t)
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	m := mailer.New(mailer.Config{Host: "localhost", Port: 1025, From: "noreply@example.com"}, zap.NewNop())
+	h := NewHandler(db, nil, m, nil, zap.NewNop())
+	box, _ := secretbox.New("test-key")
+	h.SetSecretBox(box)
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/settings/smtp", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req = auth.WithTestUser(req, &auth.SessionUser{ID: primitive.NewObjectID().Hex(), Name: "Admin", Role: "admin"})
+		req = testutil.WithCSRFToken(req)
+		rec := httptest.NewRecorder()
+		h.updateSMTP(rec, req)
+		return rec
+	}
+
+	form := url.Values{
+		"action": {"save"}, "host": {"smtp.example.com"}, "port": {"587"},
+		"user": {"mailer"}, "pass": {"hunter2"}, "from": {"hello@example.com"},
+	}
+	if rec := post(form); rec.Code != http.StatusSeeOther {
+		t.Fatalf("save status = %d, want %d: %s", rec.Code, http.StatusSeeOther, rec.Body.String())
+	}
+
+	store := settingsstore.New(db)
+	settings, err := store.Get(ctx)
+	if err != nil || settings.SMTP == nil {
+		t.Fatalf("Get() = %+v, %v, want saved SMTP settings", settings, err)
+	}
+	if settings.SMTP.PassEncrypted == "" || strings.Contains(settings.SMTP.PassEncrypted, "hunter2") {
+		t.Errorf("PassEncrypted = %q, want the password encrypted", settings.SMTP.PassEncrypted)
+	}
+
+	// Saving again with a blank password keeps it; the mailer uses the
+	// new settings at once
+	form.Set("pass", "")
+	form.Set("port", "2525")
+	post(form)
+	m.SetSMTPProvider(emailsmtp.New(db, box))
+	if got := m.SMTP(ctx); got.Port != 2525 || got.Pass != "hunter2" {
+		t.Errorf("mailer SMTP = %+v, want port 2525 and the saved password", got)
+	}
+
+	post(url.Values{"action": {"reset"}})
+	if got := m.SMTP(ctx); got != m.ConfiguredSMTP() {
+		t.Errorf("after reset mailer SMTP = %+v, want the configured settings", got)
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,11 +37,28 @@ type PlayerSettings struct {
 	SettingsData bson.M             `bson:"settings_data"   json:"settings_data"`
 }
 
+// saveRequest is the request body for SaveHandler.
+type saveRequest struct {
+	UserID       string `json:"user_id"       openapi:"required,desc=Player identifier,example=player123"`
+	Game         string `json:"game"          openapi:"required,desc=Game identifier,example=mygame"`
+	SettingsData bson.M `json:"settings_data" openapi:"required,desc=Arbitrary JSON object to store"`
+}
+
+// loadRequest is the request body for LoadHandler.
+type loadRequest struct {
+	UserID string `json:"user_id" openapi:"required,desc=Player identifier,example=player123"`
+	Game   string `json:"game"    openapi:"required,desc=Game identifier,example=mygame"`
+}
+
 // Handler handles settings save/load API requests.
 type Handler struct {
-	db           *mongo.Database
-	logger       *zap.Logger
-	indexEnsured sync.Once // Ensure index is created once
+	db             *mongo.Database
+	logger         *zap.Logger
+	indexEnsured   sync.Once // Ensure index is created once
+	keyValidator   auth.APIKeyValidator
+	sigVerifier    *auth.SignatureVerifier
+	tokens         *auth.TokenIssuer
+	trustedProxies []string
 }
 
 // NewHandler creates a new settingsapi handler.
@@ -50,6 +69,32 @@ func NewHandler(db *mongo.Database, logger *zap.Logger) *Handler {
 	}
 }
 
+// SetAPIKeyValidator enables authentication with database-managed API keys
+// in addition to the static configured key. Call before building routes.
+func (h *Handler) SetAPIKeyValidator(v auth.APIKeyValidator) {
+	h.keyValidator = v
+}
+
+// SetSignatureVerifier enables HMAC request signing as an alternative to
+// bearer API keys. Call before building routes.
+func (h *Handler) SetSignatureVerifier(v *auth.SignatureVerifier) {
+	h.sigVerifier = v
+}
+
+// SetTokenIssuer enables authentication with short-lived API tokens. A token
+// only grants access to the user and game it was issued for.
+// Call before building routes.
+func (h *Handler) SetTokenIssuer(ti *auth.TokenIssuer) {
+	h.tokens = ti
+}
+
+// SetTrustedProxies sets the reverse proxies (CIDR ranges) whose forwarding
+// headers are believed when checking an API key's IP allowlist.
+// Call before building routes.
+func (h *Handler) SetTrustedProxies(cidrs []string) {
+	h.trustedProxies = cidrs
+}
+
 // SaveHandler handles POST /settings/save requests.
 // It saves player settings to the player_settings collection.
 // Uses upsert - one settings document per user per game.
@@ -72,11 +117,7 @@ func NewHandler(db *mongo.Database, logger *zap.Logger) *Handler {
 //	    "settings_data": { ... }
 //	}
 func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
-	var in struct {
-		UserID       string `json:"user_id"`
-		Game         string `json:"game"`
-		SettingsData bson.M `json:"settings_data"`
-	}
+	var in saveRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeJSONError(w, r, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -85,6 +126,10 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, r, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	if !auth.APITokenAllows(r.Context(), in.UserID, in.Game) {
+		writeJSONError(w, r, "Token is not valid for this user and game", http.StatusForbidden)
+		return
+	}
 
 	now := time.Now().UTC()
 	coll := h.db.Collection(CollectionName)
@@ -156,10 +201,7 @@ func (h *Handler) SaveHandler(w http.ResponseWriter, r *http.Request) {
 //	    "settings_data": { ... }
 //	}
 func (h *Handler) LoadHandler(w http.ResponseWriter, r *http.Request) {
-	var in struct {
-		UserID string `json:"user_id"`
-		Game   string `json:"game"`
-	}
+	var in loadRequest
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		writeJSONError(w, r, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -168,6 +210,10 @@ func (h *Handler) LoadHandler(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, r, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	if !auth.APITokenAllows(r.Context(), in.UserID, in.Game) {
+		writeJSONError(w, r, "Token is not valid for this user and game", http.StatusForbidden)
+		return
+	}
 
 	coll := h.db.Collection(CollectionName)
 	filter := bson.M{"user_id": in.UserID, "game": in.Game}
@@ -230,11 +276,17 @@ func (h *Handler) ensureIndex(ctx context.Context) error {
 }
 
 // writeJSONError writes a JSON error response and logs the error to the ledger.
+// The response carries the request ID, so a client can quote it when
+// reporting the error.
 func writeJSONError(w http.ResponseWriter, r *http.Request, msg string, code int) {
 	// Set error message in ledger context for debugging
 	ledger.SetErrorMessage(r.Context(), msg)
 
+	body := map[string]string{"error": msg}
+	if id := requestid.FromContext(r.Context()); id != "" {
+		body["request_id"] = id
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	_ = json.NewEncoder(w).Encode(body)
 }
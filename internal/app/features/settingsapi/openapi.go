@@ -0,0 +1,43 @@
+package settingsapi
+
+import "github.com/dalemusser/stratasave/internal/app/system/openapi"
+
+// Tag is the OpenAPI tag for the settings endpoints.
+const Tag = "Settings"
+
+// DescribeAPI adds the settings endpoints to an OpenAPI document whose
+// server URL is a versioned API prefix such as /api/v1.
+func DescribeAPI(doc *openapi.Document) {
+	doc.AddTag(Tag, "Save and load player settings. Each player has one settings document per game.")
+
+	settings := doc.Ref("PlayerSettings", PlayerSettings{})
+
+	doc.Post("/settings/save", &openapi.Operation{
+		Tags:        []string{Tag},
+		Summary:     "Save player settings",
+		Description: "Creates or replaces the settings for the player and game.",
+		OperationID: "saveSettings",
+		RequestBody: openapi.JSONBody(doc.Ref("SaveSettingsRequest", saveRequest{})),
+		Responses:   settingsResponses(doc, "Saved settings", settings),
+	})
+	doc.Post("/settings/load", &openapi.Operation{
+		Tags:        []string{Tag},
+		Summary:     "Load player settings",
+		Description: "Returns the settings for the player and game, or null when none have been saved.",
+		OperationID: "loadSettings",
+		RequestBody: openapi.JSONBody(doc.Ref("LoadSettingsRequest", loadRequest{})),
+		Responses:   settingsResponses(doc, "Settings, or null if not found", settings),
+	})
+}
+
+// settingsResponses returns the success response plus the error responses
+// common to both settings endpoints.
+func settingsResponses(doc *openapi.Document, description string, body *openapi.Schema) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		"200": openapi.JSONResponse(description, body),
+		"400": doc.ErrorResponse("Invalid JSON or missing required fields"),
+		"401": doc.ErrorResponse("Missing or invalid credentials"),
+		"403": doc.ErrorResponse("Token not valid for this player and game, or client IP not allowed"),
+		"500": doc.ErrorResponse("Database error"),
+	}
+}
@@ -7,17 +7,21 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/system/apicors"
 	"github.com/dalemusser/stratasave/internal/app/system/apistats"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
 // Routes returns a router with the settings API endpoints.
 //
-// When mounted at /api/settings:
-//   - POST /api/settings/save - Save player settings
-//   - POST /api/settings/load - Load player settings
+// When mounted at /api/v1/settings (and the unversioned /api/settings alias):
+//   - POST /api/v1/settings/save - Save player settings
+//   - POST /api/v1/settings/load - Load player settings
 //
-// Authentication is via API key (Bearer token in Authorization header).
+// Authentication is via API key (Bearer token in Authorization header), either
+// the static configured key or a database-managed key (see SetAPIKeyValidator),
+// via an HMAC-signed request (see SetSignatureVerifier), or via a short-lived
+// API token scoped to one user and game (see SetTokenIssuer).
 // CORS is permissive (allows any origin) since API key auth is used.
 func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.Logger) http.Handler {
 	r := chi.NewRouter()
@@ -26,7 +30,7 @@ func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.
 	r.Use(apicors.Middleware())
 
 	// API key authentication
-	r.Use(auth.APIKeyAuth(apiKey, logger))
+	r.Use(h.apiKeyAuth(apiKey, logger))
 
 	// Save endpoint with stats tracking
 	r.Route("/save", func(sr chi.Router) {
@@ -42,3 +46,18 @@ func Routes(h *Handler, recorder *apistats.Recorder, apiKey string, logger *zap.
 
 	return r
 }
+
+// apiKeyAuth builds the API key middleware for these routes. Rejection
+// reasons are recorded on the request's ledger entry.
+func (h *Handler) apiKeyAuth(apiKey string, logger *zap.Logger) func(http.Handler) http.Handler {
+	return auth.APIKeyAuthWithConfig(auth.APIKeyAuthConfig{
+		StaticKey:      apiKey,
+		Validator:      h.keyValidator,
+		Signature:      h.sigVerifier,
+		Tokens:         h.tokens,
+		TrustedProxies: h.trustedProxies,
+		OnReject: func(r *http.Request, reason string) {
+			ledger.SetErrorMessage(r.Context(), reason)
+		},
+	}, logger)
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/timezones"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
@@ -29,6 +31,8 @@ type Handler struct {
 	errLog *errorsfeature.ErrorLogger
 	logger *zap.Logger
 	apiKey string
+
+	auditLogger *auditlog.Logger
 }
 
 // NewHandler creates a new settings browser handler.
@@ -42,6 +46,11 @@ func NewHandler(db *mongo.Database, errLog *errorsfeature.ErrorLogger, apiKey st
 	}
 }
 
+// SetAuditLogger records settings deletions in the audit log.
+func (h *Handler) SetAuditLogger(l *auditlog.Logger) {
+	h.auditLogger = l
+}
+
 // ServeList renders the main browser page.
 func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
@@ -322,6 +331,12 @@ func (h *Handler) HandleDeleteSetting(w http.ResponseWriter, r *http.Request) {
 		zap.String("game", game),
 		zap.String("user_id", userID),
 	)
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "player_settings_deleted", map[string]string{
+		"game":      game,
+		"player_id": userID,
+	})
 
 	// Return success - the client will refresh the list
 	w.Header().Set("HX-Trigger", "setting-deleted")
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"github.com/dalemusser/stratasave/internal/app/system/apisnippets"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/pantry/templates"
+	"go.uber.org/zap"
 )
 
 // PlaygroundVM is the view model for the playground page.
@@ -17,6 +22,9 @@ type PlaygroundVM struct {
 	viewdata.BaseVM
 	APIEndpoint string
 	APIKey      string
+	Game        string // Prefilled from ?game=
+	UserID      string // Prefilled from ?user=
+	Builder     apisnippets.Builder
 }
 
 // DocsVM is the view model for the documentation page.
@@ -41,27 +49,96 @@ type PlaygroundResponse struct {
 	Error      string            `json:"error,omitempty"`
 }
 
-// ServePlayground renders the playground page.
+// ServePlayground renders the playground page. The game and user can be
+// prefilled with ?game= and ?user=.
 func (h *Handler) ServePlayground(w http.ResponseWriter, r *http.Request) {
+	keys, err := apisnippets.KeyOptions(r.Context(), h.apiKey, apikeystore.New(h.db))
+	if err != nil {
+		h.logger.Warn("failed to list API keys", zap.Error(err))
+	}
+
 	data := PlaygroundVM{
 		BaseVM:      viewdata.NewBaseVM(r, h.db, "Settings API Playground", "/console/api/settings"),
-		APIEndpoint: "/api/settings",
+		APIEndpoint: "/api/v1/settings",
 		APIKey:      h.apiKey,
+		Game:        r.URL.Query().Get("game"),
+		UserID:      r.URL.Query().Get("user"),
+		Builder: apisnippets.Builder{
+			SnippetsURL: "/console/api/settings/playground/snippets",
+			Keys:        keys,
+		},
 	}
 	templates.Render(w, r, "settingsbrowser/playground", data)
 }
 
-// ServeDocs renders the documentation page.
-func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
+// HandlePlaygroundSnippets renders the playground's request as cURL, Unity,
+// and Unreal code for the request builder.
+func (h *Handler) HandlePlaygroundSnippets(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	var view apisnippets.View
+	operation, body, err := playgroundBody(r.PostForm)
+	if err != nil {
+		view.Error = "Fix the request to see its code: " + err.Error()
+	} else {
+		key, complete := apisnippets.ResolveKey(r.Context(), r.PostFormValue("snippet_key"), h.apiKey, apikeystore.New(h.db))
+		view.Snippets = apisnippets.Generate(apisnippets.Request{
+			URL:    requestBaseURL(r) + "/api/v1/settings/" + operation,
+			APIKey: key,
+			Body:   body,
+		})
+		view.PartialKey = !complete
+	}
+	templates.RenderSnippet(w, "settingsbrowser/playground_snippets", view)
+}
+
+// playgroundRequestBody is the body of a Settings API request.
+type playgroundRequestBody struct {
+	UserID       string          `json:"user_id"`
+	Game         string          `json:"game"`
+	SettingsData json.RawMessage `json:"settings_data,omitempty"`
+}
+
+// playgroundBody returns the operation and JSON body of the request in the
+// playground form, as the playground would send it.
+func playgroundBody(form url.Values) (operation string, body []byte, err error) {
+	req := playgroundRequestBody{
+		UserID: form.Get("user_id"),
+		Game:   form.Get("game"),
+	}
+	operation = form.Get("operation")
+	switch operation {
+	case "save":
+		data := []byte(form.Get("settings_data"))
+		if !json.Valid(data) {
+			return "", nil, errors.New("settings data isn't valid JSON")
+		}
+		req.SettingsData = data
+	case "load":
+	default:
+		return "", nil, errors.New("no operation chosen")
+	}
+	body, err = json.Marshal(req)
+	return operation, body, err
+}
+
+// requestBaseURL returns the scheme and host the request was made to.
+func requestBaseURL(r *http.Request) string {
 	scheme := "http"
 	if r.TLS != nil {
 		scheme = "https"
 	}
-	baseURL := scheme + "://" + r.Host
+	return scheme + "://" + r.Host
+}
 
+// ServeDocs renders the documentation page.
+func (h *Handler) ServeDocs(w http.ResponseWriter, r *http.Request) {
 	data := DocsVM{
 		BaseVM:  viewdata.NewBaseVM(r, h.db, "Settings API Documentation", "/console/api/settings"),
-		BaseURL: baseURL,
+		BaseURL: requestBaseURL(r),
 	}
 	templates.Render(w, r, "settingsbrowser/docs", data)
 }
@@ -78,9 +155,9 @@ func (h *Handler) HandlePlaygroundExecute(w http.ResponseWriter, r *http.Request
 	var targetPath string
 	switch req.Operation {
 	case "save":
-		targetPath = "/api/settings/save"
+		targetPath = "/api/v1/settings/save"
 	case "load":
-		targetPath = "/api/settings/load"
+		targetPath = "/api/v1/settings/load"
 	default:
 		writePlaygroundError(w, "Invalid operation: must be 'save' or 'load'", http.StatusBadRequest)
 		return
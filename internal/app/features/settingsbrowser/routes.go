@@ -22,6 +22,7 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 	// Playground - interactive API testing
 	r.Get("/playground", h.ServePlayground)
 	r.Post("/playground/execute", h.HandlePlaygroundExecute)
+	r.Post("/playground/snippets", h.HandlePlaygroundSnippets)
 
 	// Documentation
 	r.Get("/docs", h.ServeDocs)
@@ -0,0 +1,143 @@
+// internal/app/features/stats/export.go
+package statsfeature
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	statsstore "github.com/dalemusser/stratasave/internal/app/store/stats"
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"go.uber.org/zap"
+)
+
+// exportRow is one counter or gauge value on one day, for CSV/JSON export.
+type exportRow struct {
+	Date     string  `json:"date"`
+	StatType string  `json:"stat_type"`
+	Kind     string  `json:"kind"` // "counter" or "gauge"
+	Name     string  `json:"name"`
+	Value    float64 `json:"value"`
+}
+
+// ServeExportCSV handles GET /stats/export.csv - the daily stats for the
+// dashboard's period and type, one value per row.
+func (h *Handler) ServeExportCSV(w http.ResponseWriter, r *http.Request) {
+	rows, filename, ok := h.exportRows(w, r)
+	if !ok {
+		return
+	}
+
+	csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", filename+".csv")
+
+	// UTF-8 BOM for Excel
+	if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+		h.Log.Error("CSV write failed (BOM)", zap.Error(err))
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	defer cw.Flush()
+
+	if err := cw.Write([]string{"date", "stat_type", "kind", "name", "value"}); err != nil {
+		h.Log.Error("CSV write failed (header)", zap.Error(err))
+		return
+	}
+	for _, row := range rows {
+		if err := cw.Write([]string{
+			row.Date,
+			csvutil.SafeField(row.StatType),
+			row.Kind,
+			csvutil.SafeField(row.Name),
+			strconv.FormatFloat(row.Value, 'f', -1, 64),
+		}); err != nil {
+			h.Log.Error("CSV write failed (row)", zap.Error(err))
+			return
+		}
+	}
+}
+
+// ServeExportJSON handles GET /stats/export.json - the daily stats for the
+// dashboard's period and type as a JSON array.
+func (h *Handler) ServeExportJSON(w http.ResponseWriter, r *http.Request) {
+	rows, filename, ok := h.exportRows(w, r)
+	if !ok {
+		return
+	}
+
+	csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", filename+".json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rows); err != nil {
+		h.Log.Error("JSON encode failed", zap.Error(err))
+	}
+}
+
+// exportRows loads the rows to export for the request's period, dates, and
+// type, or every type if none is given. It reports an error to the client
+// and returns false if they can't be loaded.
+func (h *Handler) exportRows(w http.ResponseWriter, r *http.Request) ([]exportRow, string, bool) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Long())
+	defer cancel()
+
+	_, startDate, endDate := parsePeriod(r)
+	statType := r.URL.Query().Get("type")
+
+	stats, err := loadRange(ctx, statsstore.New(h.DB), startDate, endDate, statType)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load stats for export", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, "", false
+	}
+
+	filename := "stats"
+	if statType != "" {
+		filename += "_" + statType
+	}
+	filename += fmt.Sprintf("_%s_%s", startDate.Format("20060102"), endDate.Format("20060102"))
+
+	return toExportRows(stats), filename, true
+}
+
+// loadRange returns the daily stats of one type, or of all types if
+// statType is empty.
+func loadRange(ctx context.Context, store *statsstore.Store, startDate, endDate time.Time, statType string) ([]statsstore.DailyStats, error) {
+	if statType == "" {
+		return store.GetRangeAllTypes(ctx, startDate, endDate)
+	}
+	return store.GetRange(ctx, startDate, endDate, statType)
+}
+
+// toExportRows flattens daily stats into one row per value: counters, then
+// gauges, each in name order.
+func toExportRows(stats []statsstore.DailyStats) []exportRow {
+	rows := []exportRow{}
+	for _, ds := range stats {
+		date := ds.Date.Format("2006-01-02")
+		for _, name := range sortedKeys(ds.Counters) {
+			rows = append(rows, exportRow{Date: date, StatType: ds.StatType, Kind: "counter", Name: name, Value: float64(ds.Counters[name])})
+		}
+		for _, name := range sortedKeys(ds.Gauges) {
+			rows = append(rows, exportRow{Date: date, StatType: ds.StatType, Kind: "gauge", Name: name, Value: ds.Gauges[name]})
+		}
+	}
+	return rows
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
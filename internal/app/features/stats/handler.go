@@ -37,42 +37,7 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
 	defer cancel()
 
-	// Parse time period
-	period := r.URL.Query().Get("period")
-	if period == "" {
-		period = "week"
-	}
-
-	// Calculate date range based on period
-	now := time.Now()
-	var startDate, endDate time.Time
-	switch period {
-	case "day":
-		startDate = now.AddDate(0, 0, -1)
-		endDate = now
-	case "week":
-		startDate = now.AddDate(0, 0, -7)
-		endDate = now
-	case "month":
-		startDate = now.AddDate(0, -1, 0)
-		endDate = now
-	default:
-		startDate = now.AddDate(0, 0, -7)
-		endDate = now
-		period = "week"
-	}
-
-	// Allow custom date range
-	if start := r.URL.Query().Get("start"); start != "" {
-		if t, err := time.Parse("2006-01-02", start); err == nil {
-			startDate = t
-		}
-	}
-	if end := r.URL.Query().Get("end"); end != "" {
-		if t, err := time.Parse("2006-01-02", end); err == nil {
-			endDate = t
-		}
-	}
+	period, startDate, endDate := parsePeriod(r)
 
 	selectedType := r.URL.Query().Get("type")
 
@@ -187,6 +152,50 @@ func (h *Handler) ServeDashboard(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "stats/dashboard", data)
 }
 
+// parsePeriod returns the dashboard's period ("day", "week", or "month";
+// default "week") and its date range, which explicit start and end dates
+// override.
+func parsePeriod(r *http.Request) (string, time.Time, time.Time) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "week"
+	}
+
+	// Calculate date range based on period, ending on today's date in the
+	// user's timezone
+	now := time.Now().In(viewdata.Location(r))
+	var startDate, endDate time.Time
+	switch period {
+	case "day":
+		startDate = now.AddDate(0, 0, -1)
+		endDate = now
+	case "week":
+		startDate = now.AddDate(0, 0, -7)
+		endDate = now
+	case "month":
+		startDate = now.AddDate(0, -1, 0)
+		endDate = now
+	default:
+		startDate = now.AddDate(0, 0, -7)
+		endDate = now
+		period = "week"
+	}
+
+	// Allow custom date range
+	if start := r.URL.Query().Get("start"); start != "" {
+		if t, err := time.Parse("2006-01-02", start); err == nil {
+			startDate = t
+		}
+	}
+	if end := r.URL.Query().Get("end"); end != "" {
+		if t, err := time.Parse("2006-01-02", end); err == nil {
+			endDate = t
+		}
+	}
+
+	return period, startDate, endDate
+}
+
 // ServeDetail handles GET /stats/{type} - detailed view for a stat type.
 func (h *Handler) ServeDetail(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
@@ -14,6 +14,8 @@ func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
 
 	r.Get("/", h.ServeDashboard)
 	r.Get("/detail", h.ServeDetail)
+	r.Get("/export.csv", h.ServeExportCSV)
+	r.Get("/export.json", h.ServeExportJSON)
 
 	return r
 }
@@ -0,0 +1,255 @@
+// internal/app/features/status/checks.go
+package status
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/waffle/pantry/storage"
+)
+
+// checkTimeout bounds how long the configuration checks can hold up the
+// status page.
+const checkTimeout = 5 * time.Second
+
+// Check severities.
+const (
+	SeverityOK      = "ok"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Check is the result of validating one part of the configuration.
+type Check struct {
+	Area     string
+	Severity string // SeverityOK, SeverityWarning, or SeverityError
+	Message  string
+	Fix      string // what to change, when Severity is not SeverityOK
+}
+
+// runChecks validates the configuration against the services it points
+// at. The checks run at once, so the slowest decides how long it takes.
+func (h *Handler) runChecks(ctx context.Context) []Check {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	cfg := h.appConfig()
+	env := ""
+	if h.CoreCfg != nil {
+		env = h.CoreCfg.Env
+	}
+
+	checks := []func() Check{
+		func() Check { return checkBaseURL(env, cfg.BaseURL) },
+		func() Check { return checkGoogleOAuth(cfg.GoogleClientID, cfg.GoogleClientSecret) },
+		func() Check { return checkSMTP(ctx, h.Mailer) },
+		func() Check { return checkStorage(ctx, h.Storage, cfg) },
+	}
+	if cfg.StorageType == "s3" && cfg.StorageCFURL != "" {
+		checks = append(checks, func() Check { return checkCloudFrontKey(cfg.StorageCFKeyPairID, cfg.StorageCFKeyPath) })
+	}
+	if cfg.StorageType == "s3" && (cfg.StorageCFURL != "" || cfg.StorageS3PresignedDownloads) {
+		checks = append(checks, func() Check { return checkSignedDownloads(cfg) })
+	}
+
+	results := make([]Check, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = check()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// checkBaseURL checks that base_url, used in links in emails, is set and
+// served over HTTPS in production.
+func checkBaseURL(env, baseURL string) Check {
+	c := Check{Area: "Base URL"}
+	if baseURL == "" {
+		c.Severity = SeverityError
+		c.Message = "base_url is not set, so links in emails will not work."
+		c.Fix = "Set base_url to the address users reach the site at, such as https://save.example.com."
+		return c
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		c.Severity = SeverityError
+		c.Message = fmt.Sprintf("base_url %q is not a valid http or https URL.", baseURL)
+		c.Fix = "Set base_url to a full URL including the scheme, such as https://save.example.com."
+		return c
+	}
+	if env == "prod" && u.Scheme != "https" {
+		c.Severity = SeverityWarning
+		c.Message = fmt.Sprintf("base_url %q uses http in production.", baseURL)
+		c.Fix = "Use an https base_url so links in emails don't send users over an unencrypted connection."
+		return c
+	}
+	c.Severity = SeverityOK
+	c.Message = baseURL
+	return c
+}
+
+// checkGoogleOAuth checks that Google sign-in is either fully configured
+// or not configured at all.
+func checkGoogleOAuth(clientID, clientSecret string) Check {
+	c := Check{Area: "Google sign-in", Severity: SeverityOK}
+	switch {
+	case clientID == "" && clientSecret == "":
+		c.Message = "Not configured."
+	case clientID == "":
+		c.Severity = SeverityWarning
+		c.Message = "google_client_secret is set without google_client_id, so Google sign-in is off."
+		c.Fix = "Set google_client_id, or remove google_client_secret."
+	case clientSecret == "":
+		c.Severity = SeverityWarning
+		c.Message = "google_client_id is set without google_client_secret, so Google sign-in is off."
+		c.Fix = "Set google_client_secret, or remove google_client_id."
+	default:
+		c.Message = "Configured."
+	}
+	return c
+}
+
+// checkSMTP checks that email has a sender and that the SMTP server
+// answers. It connects and reads the greeting but does not log in.
+func checkSMTP(ctx context.Context, m *mailer.Mailer) Check {
+	c := Check{Area: "Email (SMTP)"}
+	if m == nil {
+		c.Severity = SeverityWarning
+		c.Message = "Email is not configured, so verification codes, invitations, and password resets can't be sent."
+		c.Fix = "Set mail_smtp_host, mail_smtp_port, and mail_from, or enter them under Settings."
+		return c
+	}
+	s := m.SMTP(ctx)
+	if s.Host == "" {
+		c.Severity = SeverityWarning
+		c.Message = "No SMTP host is set, so no email can be delivered."
+		c.Fix = "Set mail_smtp_host, or enter the SMTP server under Settings."
+		return c
+	}
+	if s.From == "" {
+		c.Severity = SeverityError
+		c.Message = "No sender address is set."
+		c.Fix = "Set mail_from, or enter the From address under Settings."
+		return c
+	}
+
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if err := mailer.PingSMTP(ctx, s); err != nil {
+		c.Severity = SeverityError
+		c.Message = fmt.Sprintf("Cannot reach the SMTP server at %s: %v", addr, err)
+		c.Fix = "Check the SMTP host and port, and that a firewall or security group allows outbound connections to it."
+		return c
+	}
+	c.Severity = SeverityOK
+	c.Message = "Reachable at " + addr + "."
+	return c
+}
+
+// checkStorage checks that file storage can be reached by listing it:
+// for S3, GCS, and Azure that the bucket or container exists and can be
+// read, and for local storage that the directory can be written.
+func checkStorage(ctx context.Context, store storage.Store, cfg AppConfig) Check {
+	c := Check{Area: "File storage"}
+	if store == nil {
+		c.Severity = SeverityError
+		c.Message = "File storage is not available."
+		c.Fix = "Check storage_type and the storage settings, then restart."
+		return c
+	}
+
+	switch cfg.StorageType {
+	case "s3":
+		if _, err := store.List(ctx, "", &storage.ListOptions{MaxKeys: 1}); err != nil {
+			c.Severity = SeverityError
+			c.Message = fmt.Sprintf("Cannot list S3 bucket %q in %s: %v", cfg.StorageS3Bucket, cfg.StorageS3Region, err)
+			c.Fix = "Check that the bucket exists, that storage_s3_region is its region, and that the server's AWS credentials allow s3:ListBucket on it."
+			return c
+		}
+		c.Severity = SeverityOK
+		c.Message = fmt.Sprintf("S3 bucket %q is reachable.", cfg.StorageS3Bucket)
+		return c
+	case "gcs":
+		if _, err := store.List(ctx, "", &storage.ListOptions{MaxKeys: 1}); err != nil {
+			c.Severity = SeverityError
+			c.Message = fmt.Sprintf("Cannot list GCS bucket %q: %v", cfg.StorageGCSBucket, err)
+			c.Fix = "Check that the bucket exists and that the service account (storage_gcs_credentials_file, or the default credentials) has storage.objects.list on it."
+			return c
+		}
+		c.Severity = SeverityOK
+		c.Message = fmt.Sprintf("GCS bucket %q is reachable.", cfg.StorageGCSBucket)
+		return c
+	case "azure":
+		if _, err := store.List(ctx, "", &storage.ListOptions{MaxKeys: 1}); err != nil {
+			c.Severity = SeverityError
+			c.Message = fmt.Sprintf("Cannot list Azure container %q: %v", cfg.StorageAzureContainer, err)
+			c.Fix = "Check that the container exists in the storage account and that the account key, connection string, or managed identity can list blobs in it."
+			return c
+		}
+		c.Severity = SeverityOK
+		c.Message = fmt.Sprintf("Azure container %q is reachable.", cfg.StorageAzureContainer)
+		return c
+	}
+
+	f, err := os.CreateTemp(cfg.StorageLocalPath, ".status-check-*")
+	if err != nil {
+		c.Severity = SeverityError
+		c.Message = fmt.Sprintf("Cannot write to %s: %v", cfg.StorageLocalPath, err)
+		c.Fix = "Create the storage_local_path directory and make it writable by the server's user."
+		return c
+	}
+	f.Close()
+	os.Remove(f.Name())
+	c.Severity = SeverityOK
+	c.Message = fmt.Sprintf("%s is writable.", cfg.StorageLocalPath)
+	return c
+}
+
+// checkCloudFrontKey checks that signed CloudFront URLs have a key pair
+// ID and a readable private key.
+func checkCloudFrontKey(keyPairID, keyPath string) Check {
+	c := Check{Area: "CloudFront"}
+	if keyPairID == "" || keyPath == "" {
+		c.Severity = SeverityError
+		c.Message = "storage_cf_url is set without both storage_cf_keypair_id and storage_cf_key_path."
+		c.Fix = "Set the CloudFront key pair ID and the path to its private key, or remove storage_cf_url."
+		return c
+	}
+	if _, err := os.ReadFile(keyPath); err != nil {
+		c.Severity = SeverityError
+		c.Message = fmt.Sprintf("Cannot read the CloudFront private key: %v", err)
+		c.Fix = "Check that storage_cf_key_path points to the key file and that the server's user can read it."
+		return c
+	}
+	c.Severity = SeverityOK
+	c.Message = "Private key is readable."
+	return c
+}
+
+// checkSignedDownloads reports what signed downloads give up: the storage
+// service sends the content, so it isn't checked against the file's hash,
+// and a view or download is counted when the redirect is sent, whether or
+// not the browser follows it.
+func checkSignedDownloads(cfg AppConfig) Check {
+	via := "S3 presigned URLs"
+	if cfg.StorageCFURL != "" {
+		via = "CloudFront signed URLs"
+	}
+	return Check{
+		Area:     "Signed downloads",
+		Severity: SeverityOK,
+		Message: fmt.Sprintf("Library files are sent from %s, so their content isn't checked against its SHA-256 as it is served, "+
+			"and views and downloads are counted when the redirect is sent. Files whose content last failed the check are streamed through the app until it passes.", via),
+	}
+}
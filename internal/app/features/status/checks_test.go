@@ -0,0 +1,112 @@
+package status
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/waffle/pantry/storage"
+)
+
+func TestCheckBaseURL(t *testing.T) {
+	tests := []struct {
+		env, url string
+		want     string
+	}{
+		{"prod", "https://save.example.com", SeverityOK},
+		{"dev", "http://localhost:8080", SeverityOK},
+		{"prod", "http://save.example.com", SeverityWarning},
+		{"prod", "", SeverityError},
+		{"prod", "save.example.com", SeverityError},
+	}
+	for _, tt := range tests {
+		c := checkBaseURL(tt.env, tt.url)
+		if c.Severity != tt.want {
+			t.Errorf("checkBaseURL(%q, %q) = %s (%s), want %s", tt.env, tt.url, c.Severity, c.Message, tt.want)
+		}
+		if c.Severity != SeverityOK && c.Fix == "" {
+			t.Errorf("checkBaseURL(%q, %q) has no fix", tt.env, tt.url)
+		}
+	}
+}
+
+func TestCheckGoogleOAuth(t *testing.T) {
+	tests := []struct {
+		id, secret string
+		want       string
+	}{
+		{"", "", SeverityOK},
+		{"id", "secret", SeverityOK},
+		{"id", "", SeverityWarning},
+		{"", "secret", SeverityWarning},
+	}
+	for _, tt := range tests {
+		if c := checkGoogleOAuth(tt.id, tt.secret); c.Severity != tt.want {
+			t.Errorf("checkGoogleOAuth(%q, %q) = %s, want %s", tt.id, tt.secret, c.Severity, tt.want)
+		}
+	}
+}
+
+func TestCheckSMTP_NotConfigured(t *testing.T) {
+	if c := checkSMTP(context.Background(), nil); c.Severity != SeverityWarning {
+		t.Errorf("Severity = %s, want %s", c.Severity, SeverityWarning)
+	}
+}
+
+func TestCheckStorage_Local(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewMemory(storage.MemoryConfig{})
+
+	c := checkStorage(context.Background(), store, AppConfig{StorageType: "local", StorageLocalPath: dir})
+	if c.Severity != SeverityOK {
+		t.Errorf("writable dir: Severity = %s (%s)", c.Severity, c.Message)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("check left %d files behind", len(entries))
+	}
+
+	c = checkStorage(context.Background(), store, AppConfig{StorageType: "local", StorageLocalPath: filepath.Join(dir, "missing")})
+	if c.Severity != SeverityError || c.Fix == "" {
+		t.Errorf("missing dir: Severity = %s, Fix = %q", c.Severity, c.Fix)
+	}
+
+	if c := checkStorage(context.Background(), nil, AppConfig{}); c.Severity != SeverityError {
+		t.Errorf("nil store: Severity = %s", c.Severity)
+	}
+}
+
+func TestCheckCloudFrontKey(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "cf.pem")
+	if err := os.WriteFile(key, []byte("key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := checkCloudFrontKey("K123", key); c.Severity != SeverityOK {
+		t.Errorf("readable key: Severity = %s (%s)", c.Severity, c.Message)
+	}
+	if c := checkCloudFrontKey("K123", key+".missing"); c.Severity != SeverityError {
+		t.Errorf("missing key: Severity = %s", c.Severity)
+	}
+	if c := checkCloudFrontKey("", key); c.Severity != SeverityError {
+		t.Errorf("no key pair ID: Severity = %s", c.Severity)
+	}
+}
+
+func TestCheckSignedDownloads(t *testing.T) {
+	if c := checkSignedDownloads(AppConfig{StorageCFURL: "https://d1.cloudfront.net"}); !strings.Contains(c.Message, "CloudFront") {
+		t.Errorf("CloudFront: Message = %q", c.Message)
+	}
+	if c := checkSignedDownloads(AppConfig{StorageS3PresignedDownloads: true}); !strings.Contains(c.Message, "S3 presigned") {
+		t.Errorf("S3: Message = %q", c.Message)
+	}
+}
+
+func TestSetAppConfig(t *testing.T) {
+	h := &Handler{AppCfg: AppConfig{RateLimitLoginAttempts: 5}}
+	h.SetAppConfig(AppConfig{RateLimitLoginAttempts: 10})
+	if got := h.appConfig().RateLimitLoginAttempts; got != 10 {
+		t.Errorf("RateLimitLoginAttempts = %d, want 10", got)
+	}
+}
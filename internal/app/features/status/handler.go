@@ -7,12 +7,17 @@ import (
 	"net/http"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/system/certcheck"
+	"github.com/dalemusser/stratasave/internal/app/system/configreload"
+	"github.com/dalemusser/stratasave/internal/app/system/healthcheck"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
 	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
 	"github.com/dalemusser/waffle/config"
+	"github.com/dalemusser/waffle/pantry/storage"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/dalemusser/waffle/server"
 	"go.mongodb.org/mongo-driver/bson"
@@ -30,6 +35,17 @@ type Handler struct {
 	Log     *zap.Logger
 	CoreCfg *config.CoreConfig
 	AppCfg  AppConfig
+
+	// Optional: checked by the configuration report, and used to reload
+	// config from the page. Nil ones are reported as not configured.
+	Storage  storage.Store
+	Mailer   *mailer.Mailer
+	Reloader *configreload.Reloader
+
+	// Optional: the component probes also behind /health/ready
+	Health *healthcheck.Checker
+
+	mu sync.RWMutex // guards AppCfg once the server is running
 }
 
 // AppConfig mirrors bootstrap.AppConfig for status display.
@@ -50,25 +66,95 @@ type AppConfig struct {
 	IdleLogoutWarning time.Duration
 	CSRFKey           string
 
+	SettingsEncryptionKey string
+
+	ImpersonationTimeout time.Duration
+	ReauthWindow         time.Duration
+
+	// Session lifetimes
+	SessionIdleTimeout          time.Duration
+	SessionAdminMaxAge          time.Duration
+	SessionAdminIdleTimeout     time.Duration
+	SessionDeveloperMaxAge      time.Duration
+	SessionDeveloperIdleTimeout time.Duration
+
 	// Rate Limiting
 	RateLimitEnabled       bool
 	RateLimitLoginAttempts int
 	RateLimitLoginWindow   time.Duration
 	RateLimitLoginLockout  time.Duration
+	PasswordBreachCheck    bool
+	PasswordMaxAge         time.Duration
+	PasswordExpiryWarning  time.Duration
+	UserRestoreDays        int
+	AccountSelfDelete      bool
+	AccountDeleteGraceDays int
+	UserDisableWarning     time.Duration
+	InviteReminder         time.Duration
+	CaptchaProvider        string
+	CaptchaSiteKey         string
+	CaptchaSecretKey       string
+
+	// Suspicious activity detection
+	DetectFailedLoginAccounts int
+	DetectFailedLoginWindow   time.Duration
+	DetectBlockDuration       time.Duration
+	DetectTravelSpeedKmh      int
+	DetectLatitudeHeader      string
+	DetectLongitudeHeader     string
+	SecurityAlertEmails       string
 
 	// API
-	APIKey string
+	APIKey            string
+	APISigningSecret  string
+	APISigningMaxSkew time.Duration
+	APITokenSecret    string
+	APITokenTTL       time.Duration
+
+	// API key notifications
+	APIKeyAlertEmails    string
+	APIKeyExpiryWarning  time.Duration
+	APIKeyErrorThreshold int
+	APIKeyErrorWindow    time.Duration
 
 	// Storage
-	StorageType        string
-	StorageLocalPath   string
-	StorageLocalURL    string
-	StorageS3Region    string
-	StorageS3Bucket    string
-	StorageS3Prefix    string
-	StorageCFURL       string
-	StorageCFKeyPairID string
-	StorageCFKeyPath   string
+	StorageType                  string
+	StorageLocalPath             string
+	StorageLocalURL              string
+	StorageS3Region              string
+	StorageS3Bucket              string
+	StorageS3Prefix              string
+	StorageCFURL                 string
+	StorageCFKeyPairID           string
+	StorageCFKeyPath             string
+	StorageMaxUploadMB           int
+	StorageS3Direct              bool
+	StorageS3PresignedDownloads  bool
+	StorageDownloadURLTTL        time.Duration
+	StorageS3Encryption          string
+	StorageS3StorageClass        string
+	StorageS3UploadOptions       bool
+	StorageCacheControl          string
+	StorageGCSBucket             string
+	StorageGCSPrefix             string
+	StorageGCSCredentialsFile    string
+	StorageGCSProjectID          string
+	StorageAzureAccount          string
+	StorageAzureKey              string
+	StorageAzureConnectionString string
+	StorageAzureContainer        string
+	StorageAzurePrefix           string
+	StorageAzureEndpoint         string
+	StorageAllowedTypes          string
+	StorageDeniedTypes           string
+	StorageTrashDays             int
+	StorageQuotaMB               int
+
+	// Virus scanning
+	VirusScan         string
+	VirusScanAddress  string
+	VirusScanToken    string
+	VirusScanFailOpen bool
 
 	// Email/SMTP
 	MailSMTPHost      string
@@ -80,10 +166,46 @@ type AppConfig struct {
 	BaseURL           string
 	EmailVerifyExpiry time.Duration
 
+	// Email login codes
+	EmailCodeSingleActive   bool
+	EmailCodeResendInterval time.Duration
+	EmailCodeMaxAttempts    int
+
+	// Email queue
+	MailQueueEnabled    bool
+	MailMaxAttempts     int
+	MailOutboxRetention time.Duration
+	MailLogRetention    time.Duration
+	MailRateLimit       int
+	MailBatchSize       int
+	MailWebhookSecret   string
+	JobRetryDelay       time.Duration
+	JobMaxRetryDelay    time.Duration
+	MetricsToken        string
+
+	// Health checks
+	HealthProbeStorage  bool
+	HealthProbeSMTP     bool
+	HealthProbeInterval time.Duration
+	HealthReadyRequire  string
+
+	// Scheduled jobs
+	ScheduleRunRetention time.Duration
+
+	// Outgoing webhooks
+	WebhookMaxAttempts       int
+	WebhookTimeout           time.Duration
+	WebhookDeliveryRetention time.Duration
+
 	// Audit
 	AuditLogAuth  string
 	AuditLogAdmin string
 
+	AuditForward        string
+	AuditForwardAddress string
+	AuditForwardAuth    string
+	AuditRetentionDays  int
+
 	// Google OAuth
 	GoogleClientID     string
 	GoogleClientSecret string
@@ -91,6 +213,23 @@ type AppConfig struct {
 	// Admin seeding
 	SeedAdminEmail string
 	SeedAdminName  string
+
+	// Game state API
+	MaxSavesPerUser      string
+	StateCacheTTL        time.Duration
+	StateCacheMaxEntries int
+
+	// Request ledger
+	LedgerRetention    time.Duration
+	LedgerArchive      bool
+	LedgerSampleOneIn  int
+	LedgerCapturePaths string
+	LedgerCaptureTTL   time.Duration
+	LedgerMaskFields   string
+
+	// API stats
+	APIStatsBucket    time.Duration
+	APIStatsRetention time.Duration
 }
 
 // NewHandler creates a new status Handler.
@@ -104,6 +243,20 @@ func NewHandler(client *mongo.Client, baseURL string, coreCfg *config.CoreConfig
 	}
 }
 
+// SetAppConfig replaces the config shown, as after a config reload.
+func (h *Handler) SetAppConfig(cfg AppConfig) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.AppCfg = cfg
+}
+
+// appConfig returns the config shown.
+func (h *Handler) appConfig() AppConfig {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.AppCfg
+}
+
 // ConfigItem represents a single configuration variable for display.
 type ConfigItem struct {
 	Name  string
@@ -146,6 +299,19 @@ type statusVM struct {
 	NumGoroutine int
 	MemAlloc     string
 
+	// Component probes, as reported by /health/ready
+	Components []healthcheck.Status
+
+	// Configuration checks against the services it points at
+	Checks []Check
+
+	// Config reload
+	CanReload   bool
+	Reloadable  []string
+	LastReload  *configreload.Result
+	Reloaded    bool   // true if a reload was just requested
+	ReloadError string // why the reload just requested failed
+
 	// Configuration (organized by groups)
 	ConfigGroups []ConfigGroup
 }
@@ -155,7 +321,7 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
 	defer cancel()
 
-	db := h.Client.Database(h.AppCfg.MongoDatabase)
+	db := h.Client.Database(h.appConfig().MongoDatabase)
 	vm := statusVM{
 		BaseVM:       viewdata.NewBaseVM(r, db, "System Status", "/dashboard"),
 		GoVersion:    runtime.Version(),
@@ -213,6 +379,17 @@ func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
 		vm.CertChallengeType = renewer.ChallengeType()
 	}
 
+	// Probe components, validate configuration, and show the last reload
+	vm.Components = h.Health.Check(ctx)
+	vm.Checks = h.runChecks(ctx)
+	vm.CanReload = h.Reloader != nil
+	vm.Reloadable = h.Reloader.Reloadable()
+	vm.LastReload = h.Reloader.Last()
+	vm.Reloaded = r.URL.Query().Get("reloaded") == "1"
+	if vm.Reloaded && vm.LastReload != nil {
+		vm.ReloadError = vm.LastReload.Error
+	}
+
 	// Build configuration groups
 	vm.ConfigGroups = h.buildConfigGroups()
 
@@ -247,6 +424,17 @@ func (h *Handler) HandleRenew(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/admin/status?renewed=1", http.StatusSeeOther)
 }
 
+// HandleReload handles POST /admin/status/reload to re-read the
+// configuration and apply the values that can change without a restart.
+func (h *Handler) HandleReload(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.Reloader.Reload(configreload.TriggerAdmin); err == configreload.ErrUnavailable {
+		http.Error(w, "Configuration reload not available", http.StatusBadRequest)
+		return
+	}
+	// A failed reload is shown on the status page from the last result.
+	http.Redirect(w, r, "/admin/status?reloaded=1", http.StatusSeeOther)
+}
+
 // formatDuration formats a duration in a human-readable way.
 func formatDuration(d time.Duration) string {
 	days := int(d.Hours() / 24)
@@ -318,6 +506,7 @@ func formatFloat(f float64) string {
 // buildConfigGroups creates organized groups of config items for display.
 func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups := []ConfigGroup{}
+	cfg := h.appConfig()
 
 	// Helper to mask sensitive values
 	mask := func(s string) string {
@@ -414,10 +603,10 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 
 	// Database
 	dbItems := []ConfigItem{
-		{Name: "mongo_uri", Value: mask(h.AppCfg.MongoURI)},
-		{Name: "mongo_database", Value: h.AppCfg.MongoDatabase},
-		{Name: "mongo_max_pool_size", Value: fmt.Sprintf("%d", h.AppCfg.MongoMaxPoolSize)},
-		{Name: "mongo_min_pool_size", Value: fmt.Sprintf("%d", h.AppCfg.MongoMinPoolSize)},
+		{Name: "mongo_uri", Value: mask(cfg.MongoURI)},
+		{Name: "mongo_database", Value: cfg.MongoDatabase},
+		{Name: "mongo_max_pool_size", Value: fmt.Sprintf("%d", cfg.MongoMaxPoolSize)},
+		{Name: "mongo_min_pool_size", Value: fmt.Sprintf("%d", cfg.MongoMinPoolSize)},
 	}
 	if h.CoreCfg != nil {
 		dbItems = append(dbItems,
@@ -431,19 +620,53 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Session & Security",
 		Items: []ConfigItem{
-			{Name: "session_key", Value: mask(h.AppCfg.SessionKey)},
-			{Name: "session_name", Value: h.AppCfg.SessionName},
-			{Name: "session_domain", Value: h.AppCfg.SessionDomain},
-			{Name: "session_max_age", Value: h.AppCfg.SessionMaxAge.String()},
-			{Name: "idle_logout_enabled", Value: boolStr(h.AppCfg.IdleLogoutEnabled)},
-			{Name: "idle_logout_timeout", Value: h.AppCfg.IdleLogoutTimeout.String()},
-			{Name: "idle_logout_warning", Value: h.AppCfg.IdleLogoutWarning.String()},
-			{Name: "rate_limit_enabled", Value: boolStr(h.AppCfg.RateLimitEnabled)},
-			{Name: "rate_limit_login_attempts", Value: fmt.Sprintf("%d", h.AppCfg.RateLimitLoginAttempts)},
-			{Name: "rate_limit_login_window", Value: h.AppCfg.RateLimitLoginWindow.String()},
-			{Name: "rate_limit_login_lockout", Value: h.AppCfg.RateLimitLoginLockout.String()},
-			{Name: "csrf_key", Value: mask(h.AppCfg.CSRFKey)},
-			{Name: "api_key", Value: mask(h.AppCfg.APIKey)},
+			{Name: "session_key", Value: mask(cfg.SessionKey)},
+			{Name: "session_name", Value: cfg.SessionName},
+			{Name: "session_domain", Value: cfg.SessionDomain},
+			{Name: "session_max_age", Value: cfg.SessionMaxAge.String()},
+			{Name: "session_idle_timeout", Value: cfg.SessionIdleTimeout.String()},
+			{Name: "session_admin_max_age", Value: cfg.SessionAdminMaxAge.String()},
+			{Name: "session_admin_idle_timeout", Value: cfg.SessionAdminIdleTimeout.String()},
+			{Name: "session_developer_max_age", Value: cfg.SessionDeveloperMaxAge.String()},
+			{Name: "session_developer_idle_timeout", Value: cfg.SessionDeveloperIdleTimeout.String()},
+			{Name: "idle_logout_enabled", Value: boolStr(cfg.IdleLogoutEnabled)},
+			{Name: "idle_logout_timeout", Value: cfg.IdleLogoutTimeout.String()},
+			{Name: "idle_logout_warning", Value: cfg.IdleLogoutWarning.String()},
+			{Name: "impersonation_timeout", Value: cfg.ImpersonationTimeout.String()},
+			{Name: "reauth_window", Value: cfg.ReauthWindow.String()},
+			{Name: "rate_limit_enabled", Value: boolStr(cfg.RateLimitEnabled)},
+			{Name: "rate_limit_login_attempts", Value: fmt.Sprintf("%d", cfg.RateLimitLoginAttempts)},
+			{Name: "rate_limit_login_window", Value: cfg.RateLimitLoginWindow.String()},
+			{Name: "rate_limit_login_lockout", Value: cfg.RateLimitLoginLockout.String()},
+			{Name: "detect_failed_login_accounts", Value: fmt.Sprintf("%d", cfg.DetectFailedLoginAccounts)},
+			{Name: "detect_failed_login_window", Value: cfg.DetectFailedLoginWindow.String()},
+			{Name: "detect_block_duration", Value: cfg.DetectBlockDuration.String()},
+			{Name: "detect_travel_speed_kmh", Value: fmt.Sprintf("%d", cfg.DetectTravelSpeedKmh)},
+			{Name: "detect_latitude_header", Value: cfg.DetectLatitudeHeader},
+			{Name: "detect_longitude_header", Value: cfg.DetectLongitudeHeader},
+			{Name: "security_alert_emails", Value: cfg.SecurityAlertEmails},
+			{Name: "password_breach_check", Value: boolStr(cfg.PasswordBreachCheck)},
+			{Name: "password_max_age", Value: cfg.PasswordMaxAge.String()},
+			{Name: "password_expiry_warning", Value: cfg.PasswordExpiryWarning.String()},
+			{Name: "user_restore_days", Value: fmt.Sprintf("%d", cfg.UserRestoreDays)},
+			{Name: "account_self_delete", Value: boolStr(cfg.AccountSelfDelete)},
+			{Name: "account_delete_grace_days", Value: fmt.Sprintf("%d", cfg.AccountDeleteGraceDays)},
+			{Name: "user_disable_warning", Value: cfg.UserDisableWarning.String()},
+			{Name: "invite_reminder", Value: cfg.InviteReminder.String()},
+			{Name: "captcha_provider", Value: cfg.CaptchaProvider},
+			{Name: "captcha_site_key", Value: cfg.CaptchaSiteKey},
+			{Name: "captcha_secret_key", Value: mask(cfg.CaptchaSecretKey)},
+			{Name: "csrf_key", Value: mask(cfg.CSRFKey)},
+			{Name: "settings_encryption_key", Value: mask(cfg.SettingsEncryptionKey)},
+			{Name: "api_key", Value: mask(cfg.APIKey)},
+			{Name: "api_signing_secret", Value: mask(cfg.APISigningSecret)},
+			{Name: "api_signing_max_skew", Value: cfg.APISigningMaxSkew.String()},
+			{Name: "api_token_secret", Value: mask(cfg.APITokenSecret)},
+			{Name: "api_token_ttl", Value: cfg.APITokenTTL.String()},
+			{Name: "api_key_alert_emails", Value: cfg.APIKeyAlertEmails},
+			{Name: "api_key_expiry_warning", Value: cfg.APIKeyExpiryWarning.String()},
+			{Name: "api_key_error_threshold", Value: fmt.Sprintf("%d", cfg.APIKeyErrorThreshold)},
+			{Name: "api_key_error_window", Value: cfg.APIKeyErrorWindow.String()},
 		},
 	})
 
@@ -451,15 +674,48 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Storage",
 		Items: []ConfigItem{
-			{Name: "storage_type", Value: h.AppCfg.StorageType},
-			{Name: "storage_local_path", Value: h.AppCfg.StorageLocalPath},
-			{Name: "storage_local_url", Value: h.AppCfg.StorageLocalURL},
-			{Name: "storage_s3_region", Value: h.AppCfg.StorageS3Region},
-			{Name: "storage_s3_bucket", Value: h.AppCfg.StorageS3Bucket},
-			{Name: "storage_s3_prefix", Value: h.AppCfg.StorageS3Prefix},
-			{Name: "storage_cf_url", Value: h.AppCfg.StorageCFURL},
-			{Name: "storage_cf_keypair_id", Value: h.AppCfg.StorageCFKeyPairID},
-			{Name: "storage_cf_key_path", Value: h.AppCfg.StorageCFKeyPath},
+			{Name: "storage_type", Value: cfg.StorageType},
+			{Name: "storage_local_path", Value: cfg.StorageLocalPath},
+			{Name: "storage_local_url", Value: cfg.StorageLocalURL},
+			{Name: "storage_s3_region", Value: cfg.StorageS3Region},
+			{Name: "storage_s3_bucket", Value: cfg.StorageS3Bucket},
+			{Name: "storage_s3_prefix", Value: cfg.StorageS3Prefix},
+			{Name: "storage_cf_url", Value: cfg.StorageCFURL},
+			{Name: "storage_cf_keypair_id", Value: cfg.StorageCFKeyPairID},
+			{Name: "storage_cf_key_path", Value: cfg.StorageCFKeyPath},
+			{Name: "storage_s3_direct_uploads", Value: boolStr(cfg.StorageS3Direct)},
+			{Name: "storage_s3_presigned_downloads", Value: boolStr(cfg.StorageS3PresignedDownloads)},
+			{Name: "storage_download_url_ttl", Value: cfg.StorageDownloadURLTTL.String()},
+			{Name: "storage_s3_encryption", Value: cfg.StorageS3Encryption},
+			{Name: "storage_s3_storage_class", Value: cfg.StorageS3StorageClass},
+			{Name: "storage_s3_upload_options", Value: boolStr(cfg.StorageS3UploadOptions)},
+			{Name: "storage_cache_control", Value: cfg.StorageCacheControl},
+			{Name: "storage_gcs_bucket", Value: cfg.StorageGCSBucket},
+			{Name: "storage_gcs_prefix", Value: cfg.StorageGCSPrefix},
+			{Name: "storage_gcs_credentials_file", Value: cfg.StorageGCSCredentialsFile},
+			{Name: "storage_gcs_project_id", Value: cfg.StorageGCSProjectID},
+			{Name: "storage_azure_account", Value: cfg.StorageAzureAccount},
+			{Name: "storage_azure_key", Value: mask(cfg.StorageAzureKey)},
+			{Name: "storage_azure_connection_string", Value: mask(cfg.StorageAzureConnectionString)},
+			{Name: "storage_azure_container", Value: cfg.StorageAzureContainer},
+			{Name: "storage_azure_prefix", Value: cfg.StorageAzurePrefix},
+			{Name: "storage_azure_endpoint", Value: cfg.StorageAzureEndpoint},
+			{Name: "storage_max_upload_mb", Value: fmt.Sprintf("%d", cfg.StorageMaxUploadMB)},
+			{Name: "storage_allowed_types", Value: cfg.StorageAllowedTypes},
+			{Name: "storage_denied_types", Value: cfg.StorageDeniedTypes},
+			{Name: "storage_trash_days", Value: fmt.Sprintf("%d", cfg.StorageTrashDays)},
+			{Name: "storage_quota_mb", Value: fmt.Sprintf("%d", cfg.StorageQuotaMB)},
+		},
+	})
+
+	// Virus scanning
+	groups = append(groups, ConfigGroup{
+		Name: "Virus Scanning",
+		Items: []ConfigItem{
+			{Name: "virus_scan", Value: cfg.VirusScan},
+			{Name: "virus_scan_address", Value: cfg.VirusScanAddress},
+			{Name: "virus_scan_token", Value: mask(cfg.VirusScanToken)},
+			{Name: "virus_scan_fail_open", Value: boolStr(cfg.VirusScanFailOpen)},
 		},
 	})
 
@@ -467,14 +723,49 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Email/SMTP",
 		Items: []ConfigItem{
-			{Name: "mail_smtp_host", Value: h.AppCfg.MailSMTPHost},
-			{Name: "mail_smtp_port", Value: fmt.Sprintf("%d", h.AppCfg.MailSMTPPort)},
-			{Name: "mail_smtp_user", Value: h.AppCfg.MailSMTPUser},
-			{Name: "mail_smtp_pass", Value: mask(h.AppCfg.MailSMTPPass)},
-			{Name: "mail_from", Value: h.AppCfg.MailFrom},
-			{Name: "mail_from_name", Value: h.AppCfg.MailFromName},
-			{Name: "base_url", Value: h.AppCfg.BaseURL},
-			{Name: "email_verify_expiry", Value: h.AppCfg.EmailVerifyExpiry.String()},
+			{Name: "mail_smtp_host", Value: cfg.MailSMTPHost},
+			{Name: "mail_smtp_port", Value: fmt.Sprintf("%d", cfg.MailSMTPPort)},
+			{Name: "mail_smtp_user", Value: cfg.MailSMTPUser},
+			{Name: "mail_smtp_pass", Value: mask(cfg.MailSMTPPass)},
+			{Name: "mail_from", Value: cfg.MailFrom},
+			{Name: "mail_from_name", Value: cfg.MailFromName},
+			{Name: "base_url", Value: cfg.BaseURL},
+			{Name: "email_verify_expiry", Value: cfg.EmailVerifyExpiry.String()},
+			{Name: "email_code_single_active", Value: boolStr(cfg.EmailCodeSingleActive)},
+			{Name: "email_code_resend_interval", Value: cfg.EmailCodeResendInterval.String()},
+			{Name: "email_code_max_attempts", Value: fmt.Sprintf("%d", cfg.EmailCodeMaxAttempts)},
+		},
+	})
+
+	// Email queue
+	groups = append(groups, ConfigGroup{
+		Name: "Email Queue",
+		Items: []ConfigItem{
+			{Name: "mail_queue_enabled", Value: boolStr(cfg.MailQueueEnabled)},
+			{Name: "mail_max_attempts", Value: fmt.Sprintf("%d", cfg.MailMaxAttempts)},
+			{Name: "mail_outbox_retention", Value: cfg.MailOutboxRetention.String()},
+			{Name: "mail_log_retention", Value: cfg.MailLogRetention.String()},
+			{Name: "mail_rate_limit", Value: fmt.Sprintf("%d", cfg.MailRateLimit)},
+			{Name: "mail_batch_size", Value: fmt.Sprintf("%d", cfg.MailBatchSize)},
+			{Name: "mail_webhook_secret", Value: mask(cfg.MailWebhookSecret)},
+			{Name: "job_retry_delay", Value: cfg.JobRetryDelay.String()},
+			{Name: "job_max_retry_delay", Value: cfg.JobMaxRetryDelay.String()},
+			{Name: "schedule_run_retention", Value: cfg.ScheduleRunRetention.String()},
+			{Name: "metrics_token", Value: mask(cfg.MetricsToken)},
+			{Name: "health_probe_storage", Value: boolStr(cfg.HealthProbeStorage)},
+			{Name: "health_probe_smtp", Value: boolStr(cfg.HealthProbeSMTP)},
+			{Name: "health_probe_interval", Value: cfg.HealthProbeInterval.String()},
+			{Name: "health_ready_require", Value: cfg.HealthReadyRequire},
+		},
+	})
+
+	// Outgoing webhooks
+	groups = append(groups, ConfigGroup{
+		Name: "Webhooks",
+		Items: []ConfigItem{
+			{Name: "webhook_max_attempts", Value: fmt.Sprintf("%d", cfg.WebhookMaxAttempts)},
+			{Name: "webhook_timeout", Value: cfg.WebhookTimeout.String()},
+			{Name: "webhook_delivery_retention", Value: cfg.WebhookDeliveryRetention.String()},
 		},
 	})
 
@@ -482,8 +773,8 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Authentication",
 		Items: []ConfigItem{
-			{Name: "google_client_id", Value: mask(h.AppCfg.GoogleClientID)},
-			{Name: "google_client_secret", Value: mask(h.AppCfg.GoogleClientSecret)},
+			{Name: "google_client_id", Value: mask(cfg.GoogleClientID)},
+			{Name: "google_client_secret", Value: mask(cfg.GoogleClientSecret)},
 		},
 	})
 
@@ -491,8 +782,12 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Audit Logging",
 		Items: []ConfigItem{
-			{Name: "audit_log_auth", Value: h.AppCfg.AuditLogAuth},
-			{Name: "audit_log_admin", Value: h.AppCfg.AuditLogAdmin},
+			{Name: "audit_log_auth", Value: cfg.AuditLogAuth},
+			{Name: "audit_log_admin", Value: cfg.AuditLogAdmin},
+			{Name: "audit_forward", Value: cfg.AuditForward},
+			{Name: "audit_forward_address", Value: cfg.AuditForwardAddress},
+			{Name: "audit_forward_auth", Value: mask(cfg.AuditForwardAuth)},
+			{Name: "audit_retention_days", Value: fmt.Sprintf("%d", cfg.AuditRetentionDays)},
 		},
 	})
 
@@ -500,8 +795,40 @@ func (h *Handler) buildConfigGroups() []ConfigGroup {
 	groups = append(groups, ConfigGroup{
 		Name: "Admin Seeding",
 		Items: []ConfigItem{
-			{Name: "seed_admin_email", Value: h.AppCfg.SeedAdminEmail},
-			{Name: "seed_admin_name", Value: h.AppCfg.SeedAdminName},
+			{Name: "seed_admin_email", Value: cfg.SeedAdminEmail},
+			{Name: "seed_admin_name", Value: cfg.SeedAdminName},
+		},
+	})
+
+	// Game State API
+	groups = append(groups, ConfigGroup{
+		Name: "Game State API",
+		Items: []ConfigItem{
+			{Name: "max_saves_per_user", Value: cfg.MaxSavesPerUser},
+			{Name: "state_cache_ttl", Value: cfg.StateCacheTTL.String()},
+			{Name: "state_cache_max_entries", Value: fmt.Sprintf("%d", cfg.StateCacheMaxEntries)},
+		},
+	})
+
+	// Request Ledger
+	groups = append(groups, ConfigGroup{
+		Name: "Request Ledger",
+		Items: []ConfigItem{
+			{Name: "ledger_retention", Value: cfg.LedgerRetention.String()},
+			{Name: "ledger_archive", Value: fmt.Sprintf("%t", cfg.LedgerArchive)},
+			{Name: "ledger_success_sample_one_in", Value: fmt.Sprintf("%d", cfg.LedgerSampleOneIn)},
+			{Name: "ledger_capture_paths", Value: cfg.LedgerCapturePaths},
+			{Name: "ledger_capture_ttl", Value: cfg.LedgerCaptureTTL.String()},
+			{Name: "ledger_mask_fields", Value: cfg.LedgerMaskFields},
+		},
+	})
+
+	// API Stats
+	groups = append(groups, ConfigGroup{
+		Name: "API Stats",
+		Items: []ConfigItem{
+			{Name: "api_stats_bucket", Value: cfg.APIStatsBucket.String()},
+			{Name: "api_stats_retention", Value: cfg.APIStatsRetention.String()},
 		},
 	})
 
@@ -14,5 +14,6 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Use(sessionMgr.RequireRole("admin"))
 	r.Get("/", h.Serve)
 	r.Post("/renew", h.HandleRenew)
+	r.Post("/reload", h.HandleReload)
 	return r
 }
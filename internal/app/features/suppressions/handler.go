@@ -0,0 +1,309 @@
+// internal/app/features/suppressions/handler.go
+package suppressionsfeature
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	suppressionstore "github.com/dalemusser/stratasave/internal/app/store/suppression"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/emailbounce"
+	"github.com/dalemusser/stratasave/internal/app/system/jsonutil"
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxWebhookBody limits the size of provider notifications.
+const maxWebhookBody = 1 << 20
+
+// Handler serves the suppression list pages and the provider webhook.
+type Handler struct {
+	DB            *mongo.Database
+	Store         *suppressionstore.Store
+	WebhookSecret string
+	ErrLog        *errorsfeature.ErrorLogger
+	AuditLogger   *auditlog.Logger
+	Log           *zap.Logger
+
+	// client confirms SNS subscriptions
+	client *http.Client
+}
+
+// NewHandler creates a new suppressions handler. The webhook is disabled
+// when webhookSecret is empty.
+func NewHandler(db *mongo.Database, webhookSecret string, errLog *errorsfeature.ErrorLogger, auditLogger *auditlog.Logger, logger *zap.Logger) *Handler {
+	return &Handler{
+		DB:            db,
+		Store:         suppressionstore.New(db),
+		WebhookSecret: webhookSecret,
+		ErrLog:        errLog,
+		AuditLogger:   auditLogger,
+		Log:           logger,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ServeList handles GET /email-suppressions - list suppressed addresses.
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	h.renderList(w, r, "")
+}
+
+func (h *Handler) renderList(w http.ResponseWriter, r *http.Request, formErr string) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	filter := suppressionstore.ListFilter{
+		Reason: r.URL.Query().Get("reason"),
+		Email:  r.URL.Query().Get("email"),
+	}
+
+	result, err := h.Store.List(ctx, filter, page, 50)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load suppressions", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vms := make([]SuppressionVM, len(result.Suppressions))
+	for i, s := range result.Suppressions {
+		vms[i] = toSuppressionVM(s)
+	}
+
+	prevPage := result.Page - 1
+	if prevPage < 1 {
+		prevPage = 1
+	}
+	nextPage := result.Page + 1
+	if nextPage > result.TotalPages {
+		nextPage = result.TotalPages
+	}
+
+	base := viewdata.NewBaseVM(r, h.DB, "Email Suppressions", "/dashboard")
+	data := ListVM{
+		BaseVM:         base,
+		Suppressions:   vms,
+		Filter:         filter,
+		Page:           result.Page,
+		TotalPages:     result.TotalPages,
+		TotalCount:     result.TotalCount,
+		PrevPage:       prevPage,
+		NextPage:       nextPage,
+		WebhookEnabled: h.WebhookSecret != "",
+		Error:          formErr,
+	}
+
+	if r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Target") == "suppressions-table" {
+		templates.RenderSnippet(w, "suppressions_table", data)
+		return
+	}
+
+	templates.Render(w, r, "suppressions/list", data)
+}
+
+// HandleAdd handles POST /email-suppressions - suppress an address by hand.
+func (h *Handler) HandleAdd(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	email := normalize.Email(r.FormValue("email"))
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		h.renderList(w, r, "Enter a valid email address.")
+		return
+	}
+
+	err := h.Store.Add(ctx, suppressionstore.AddInput{
+		Email:  email,
+		Reason: suppressionstore.ReasonManual,
+		Source: "admin",
+		Detail: r.FormValue("detail"),
+	})
+	if err != nil {
+		h.ErrLog.Log(r, "failed to add suppression", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.AuditLogger.LogAdminEvent(r, &actorID, nil, "email_suppression_added", map[string]string{"email": email})
+
+	http.Redirect(w, r, "/email-suppressions", http.StatusSeeOther)
+}
+
+// HandleRemove handles POST /email-suppressions/{id}/delete - allow email to
+// an address again.
+func (h *Handler) HandleRemove(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	sup, err := h.Store.GetByID(ctx, id)
+	if err == nil {
+		err = h.Store.Remove(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, suppressionstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to remove suppression", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.AuditLogger.LogAdminEvent(r, &actorID, nil, "email_suppression_removed", map[string]string{
+		"email":  sup.Email,
+		"reason": sup.Reason,
+	})
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/email-suppressions")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, "/email-suppressions", http.StatusSeeOther)
+}
+
+// HandleWebhook handles POST /webhooks/email - bounce and complaint
+// notifications from the email provider. The shared secret is passed as
+// the token query parameter (SNS subscriptions can't set headers) or the
+// X-Webhook-Token header.
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.WebhookSecret == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.Header.Get("X-Webhook-Token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.WebhookSecret)) != 1 {
+		jsonutil.Unauthorized(w, "invalid webhook token")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		jsonutil.BadRequest(w, "request body too large")
+		return
+	}
+
+	n, err := emailbounce.Parse(body)
+	if err != nil {
+		jsonutil.BadRequest(w, err.Error())
+		return
+	}
+
+	if n.SubscribeURL != "" {
+		h.confirmSubscription(r.Context(), n.SubscribeURL)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	for _, ev := range n.Events {
+		err := h.Store.Add(ctx, suppressionstore.AddInput{
+			Email:  ev.Email,
+			Reason: ev.Reason,
+			Source: n.Source,
+			Detail: ev.Detail,
+		})
+		if err != nil {
+			// Fail the request so the provider redelivers the notification
+			h.Log.Error("failed to record email suppression",
+				zap.String("email", ev.Email),
+				zap.String("reason", ev.Reason),
+				zap.Error(err))
+			jsonutil.InternalError(w, "failed to record suppression")
+			return
+		}
+		h.Log.Info("email address suppressed",
+			zap.String("email", ev.Email),
+			zap.String("reason", ev.Reason),
+			zap.String("source", n.Source))
+	}
+
+	jsonutil.OK(w, map[string]any{"suppressed": len(n.Events)})
+}
+
+// confirmSubscription visits an SNS SubscribeURL to start receiving
+// notifications.
+func (h *Handler) confirmSubscription(ctx context.Context, subscribeURL string) {
+	if !emailbounce.ValidSubscribeURL(subscribeURL) {
+		h.Log.Warn("ignoring SNS subscription confirmation with unexpected URL",
+			zap.String("subscribe_url", subscribeURL))
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		h.Log.Warn("invalid SNS subscribe URL", zap.Error(err))
+		return
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		h.Log.Warn("failed to confirm SNS subscription", zap.Error(err))
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		h.Log.Warn("SNS subscription confirmation rejected", zap.Int("status", resp.StatusCode))
+		return
+	}
+	h.Log.Info("confirmed SNS subscription for email notifications")
+}
+
+// toSuppressionVM converts a store Suppression to a view model.
+func toSuppressionVM(s suppressionstore.Suppression) SuppressionVM {
+	return SuppressionVM{
+		ID:          s.ID.Hex(),
+		Email:       s.Email,
+		Reason:      s.Reason,
+		Source:      s.Source,
+		Detail:      s.Detail,
+		Events:      s.Events,
+		CreatedAt:   s.CreatedAt.Format("2006-01-02 15:04:05"),
+		LastEventAt: s.LastEventAt.Format("2006-01-02 15:04:05"),
+		ReasonClass: getReasonClass(s.Reason),
+	}
+}
+
+// getReasonClass returns a CSS class based on suppression reason.
+func getReasonClass(reason string) string {
+	switch reason {
+	case suppressionstore.ReasonBounce:
+		return "bg-red-100 text-red-800 dark:bg-red-900/40 dark:text-red-400"
+	case suppressionstore.ReasonComplaint:
+		return "bg-orange-100 text-orange-800 dark:bg-orange-900/40 dark:text-orange-400"
+	default:
+		return "bg-gray-100 text-gray-700 dark:bg-gray-600 dark:text-gray-300"
+	}
+}
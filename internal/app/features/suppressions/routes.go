@@ -0,0 +1,23 @@
+// internal/app/features/suppressions/routes.go
+package suppressionsfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the suppression list admin pages.
+// Access is restricted to admin and developer roles.
+//
+// The provider webhook is not part of this router because it is called
+// without a session; mount HandleWebhook separately.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin", "developer"))
+
+	r.Get("/", h.ServeList)
+	r.Post("/", h.HandleAdd)
+	r.Post("/{id}/delete", h.HandleRemove)
+
+	return r
+}
@@ -0,0 +1,19 @@
+// internal/app/features/suppressions/templates.go
+package suppressionsfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "suppressions",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
@@ -0,0 +1,34 @@
+// internal/app/features/suppressions/types.go
+package suppressionsfeature
+
+import (
+	suppressionstore "github.com/dalemusser/stratasave/internal/app/store/suppression"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// SuppressionVM is the view model for a single suppressed address.
+type SuppressionVM struct {
+	ID          string
+	Email       string
+	Reason      string
+	Source      string
+	Detail      string
+	Events      int
+	CreatedAt   string
+	LastEventAt string
+	ReasonClass string // CSS class for reason badge
+}
+
+// ListVM is the view model for the suppression list page.
+type ListVM struct {
+	viewdata.BaseVM
+	Suppressions   []SuppressionVM
+	Filter         suppressionstore.ListFilter
+	Page           int
+	TotalPages     int
+	TotalCount     int64
+	PrevPage       int
+	NextPage       int
+	WebhookEnabled bool
+	Error          string
+}
@@ -0,0 +1,28 @@
+// internal/app/features/systemusers/changes.go
+package systemusers
+
+import (
+	"github.com/dalemusser/stratasave/internal/domain/models"
+)
+
+// userAuditFields returns the fields of a user an admin can edit, as shown
+// in the audit log. Secrets such as the password hash are left out.
+func userAuditFields(u *models.User) map[string]string {
+	if u == nil {
+		return nil
+	}
+	fields := map[string]string{
+		"full_name":   u.FullName,
+		"auth_method": u.AuthMethod,
+		"role":        u.Role,
+		"status":      u.Status,
+		"disable_on":  formatDisableOn(u.DisableAt),
+	}
+	if u.LoginID != nil {
+		fields["login_id"] = *u.LoginID
+	}
+	if u.Email != nil {
+		fields["email"] = *u.Email
+	}
+	return fields
+}
@@ -0,0 +1,187 @@
+// internal/app/features/systemusers/deleted.go
+package systemusers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/dalemusser/stratasave/internal/app/system/userpurge"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetPurger sets the purger used to permanently delete deleted users.
+// Without it, deleted users can be restored but not purged by hand.
+func (h *Handler) SetPurger(p *userpurge.Purger) {
+	h.purger = p
+}
+
+// deletedRow represents a deleted user on the deleted users page.
+type deletedRow struct {
+	ID        string
+	FullName  string
+	LoginID   string
+	Role      string
+	DeletedAt string
+	DeletedBy string
+	PurgeAt   string // Empty if users are kept until purged by hand
+}
+
+// DeletedVM is the view model for the deleted users page.
+type DeletedVM struct {
+	viewdata.BaseVM
+	Rows      []deletedRow
+	CanPurge  bool
+	Retention int // Days deleted users can be restored (0 = until purged by hand)
+	Success   string
+	Error     string
+}
+
+// showDeleted lists deleted users, who can be restored until they are
+// purged.
+func (h *Handler) showDeleted(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	users, err := h.userStore.ListDeleted(ctx)
+	if err != nil {
+		h.errLog.Log(r, "failed to list deleted users", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// Look up who deleted them
+	var adminIDs []primitive.ObjectID
+	for _, u := range users {
+		if u.DeletedByID != nil {
+			adminIDs = append(adminIDs, *u.DeletedByID)
+		}
+	}
+	admins, err := h.userStore.GetByIDs(ctx, adminIDs)
+	if err != nil {
+		h.errLog.Log(r, "failed to load deleting admins", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	adminNames := make(map[primitive.ObjectID]string, len(admins))
+	for _, a := range admins {
+		adminNames[a.ID] = a.FullName
+	}
+
+	var retention time.Duration
+	if h.purger != nil {
+		retention = h.purger.Retention()
+	}
+
+	rows := make([]deletedRow, 0, len(users))
+	for _, u := range users {
+		row := deletedRow{
+			ID:       u.ID.Hex(),
+			FullName: u.FullName,
+			Role:     normalize.Role(u.Role),
+		}
+		if u.LoginID != nil {
+			row.LoginID = *u.LoginID
+		}
+		if u.DeletedAt != nil {
+			row.DeletedAt = viewdata.FormatTime(r, *u.DeletedAt, "Jan 2, 2006 3:04 PM")
+			if retention > 0 {
+				row.PurgeAt = viewdata.FormatTime(r, u.DeletedAt.Add(retention), "Jan 2, 2006")
+			}
+		}
+		if u.DeletedByID != nil {
+			row.DeletedBy = adminNames[*u.DeletedByID]
+		}
+		rows = append(rows, row)
+	}
+
+	vm := DeletedVM{
+		BaseVM:    viewdata.New(r),
+		Rows:      rows,
+		CanPurge:  h.purger != nil,
+		Retention: int(retention / (24 * time.Hour)),
+	}
+	vm.Title = "Deleted Users"
+	vm.BackURL = "/system-users"
+
+	switch r.URL.Query().Get("success") {
+	case "restored":
+		vm.Success = "User restored"
+	case "purged":
+		vm.Success = "User deleted permanently"
+	}
+	if r.URL.Query().Get("error") == "failed" {
+		vm.Error = "The operation failed"
+	}
+
+	templates.Render(w, r, "systemusers/deleted", vm)
+}
+
+// restore returns a deleted user to the status they had before.
+func (h *Handler) restore(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.userStore.Restore(r.Context(), objID); err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.NotFound(w, r)
+			return
+		}
+		h.errLog.Log(r, "failed to restore user", err)
+		http.Redirect(w, r, "/system-users/deleted?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "user_restored", nil)
+
+	http.Redirect(w, r, "/system-users/deleted?success=restored", http.StatusSeeOther)
+}
+
+// purge permanently deletes a deleted user.
+func (h *Handler) purge(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), objID)
+	if err == mongo.ErrNoDocuments || (err == nil && user.Status != "deleted") {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		h.errLog.Log(r, "failed to get user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.purger.Purge(r.Context(), objID); err != nil {
+		h.errLog.Log(r, "failed to purge user", err)
+		http.Redirect(w, r, "/system-users/deleted?error=failed", http.StatusSeeOther)
+		return
+	}
+
+	// The account is gone, so record who it was
+	loginID := ""
+	if user.LoginID != nil {
+		loginID = *user.LoginID
+	}
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "user_purged", map[string]string{
+		"full_name": user.FullName,
+		"login_id":  loginID,
+	})
+
+	http.Redirect(w, r, "/system-users/deleted?success=purged", http.StatusSeeOther)
+}
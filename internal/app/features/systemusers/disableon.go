@@ -0,0 +1,46 @@
+// internal/app/features/systemusers/disableon.go
+package systemusers
+
+import (
+	"errors"
+	"time"
+)
+
+// disableOnLayout is the format of the "Disable on" date field.
+const disableOnLayout = "2006-01-02"
+
+var errDisableOnPast = errors.New("disable date is in the past")
+
+// parseDisableOn parses the "Disable on" field. The account is disabled at
+// the start of that day, UTC. An empty value returns the zero time, meaning
+// the account isn't scheduled to be disabled.
+func parseDisableOn(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(disableOnLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.Before(now.UTC().Truncate(24 * time.Hour)) {
+		return time.Time{}, errDisableOnPast
+	}
+	return t, nil
+}
+
+// formatDisableOn formats a scheduled disable date for the date field, or
+// returns "" if there isn't one.
+func formatDisableOn(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(disableOnLayout)
+}
+
+// disableOnError returns the message shown for an invalid "Disable on" date.
+func disableOnError(err error) string {
+	if errors.Is(err, errDisableOnPast) {
+		return "The disable date can't be in the past"
+	}
+	return "The disable date must be a date like 2026-06-30"
+}
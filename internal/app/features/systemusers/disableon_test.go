@@ -0,0 +1,42 @@
+package systemusers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDisableOn(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2026-03-10", time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), false},
+		{"2026-06-30", time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC), false},
+		{"2026-03-09", time.Time{}, true},
+		{"06/30/2026", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDisableOn(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDisableOn(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDisableOn(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDisableOn(t *testing.T) {
+	if got := formatDisableOn(nil); got != "" {
+		t.Errorf("formatDisableOn(nil) = %q, want empty", got)
+	}
+	at := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	if got := formatDisableOn(&at); got != "2026-06-30" {
+		t.Errorf("formatDisableOn() = %q, want %q", got, "2026-06-30")
+	}
+}
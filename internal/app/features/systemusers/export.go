@@ -0,0 +1,202 @@
+// internal/app/features/systemusers/export.go
+package systemusers
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/csvutil"
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// exportRow is one user in an export.
+type exportRow struct {
+	ID          string     `json:"id"`
+	FullName    string     `json:"full_name"`
+	LoginID     string     `json:"login_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	AuthMethod  string     `json:"auth_method"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	LastLoginAt *time.Time `json:"last_login_at"` // nil if the user has never logged in
+}
+
+// exportColumns is the CSV header, in exportRow's field order.
+var exportColumns = []string{"id", "full_name", "login_id", "email", "role", "auth_method", "status", "created_at", "updated_at", "last_login_at"}
+
+// newExportRow returns the export row for a user.
+func newExportRow(u models.User) exportRow {
+	row := exportRow{
+		ID:          u.ID.Hex(),
+		FullName:    u.FullName,
+		Role:        normalize.Role(u.Role),
+		AuthMethod:  u.AuthMethod,
+		Status:      normalize.Status(u.Status),
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+		LastLoginAt: u.LastLoginAt,
+	}
+	if u.LoginID != nil {
+		row.LoginID = *u.LoginID
+	}
+	if u.Email != nil {
+		row.Email = *u.Email
+	}
+	return row
+}
+
+// record returns the row as CSV fields. Free-text fields are guarded
+// against formula injection when the file is opened in a spreadsheet.
+func (row exportRow) record() []string {
+	lastLogin := ""
+	if row.LastLoginAt != nil {
+		lastLogin = row.LastLoginAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		row.ID,
+		csvutil.SafeField(row.FullName),
+		csvutil.SafeField(row.LoginID),
+		csvutil.SafeField(row.Email),
+		row.Role,
+		row.AuthMethod,
+		row.Status,
+		row.CreatedAt.UTC().Format(time.RFC3339),
+		row.UpdatedAt.UTC().Format(time.RFC3339),
+		lastLogin,
+	}
+}
+
+// exportCSV streams the users matching the list's current filters as CSV.
+func (h *Handler) exportCSV(w http.ResponseWriter, r *http.Request) {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+
+	// Nothing is written until the first user is read, so a failed query
+	// can still be reported as an error
+	started := false
+	start := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		csvutil.SetDownloadHeaders(w, "text/csv; charset=utf-8", exportFilename("csv"))
+
+		// UTF-8 BOM for Excel
+		if _, err := w.Write([]byte{0xEF, 0xBB, 0xBF}); err != nil {
+			return err
+		}
+		return cw.Write(exportColumns)
+	}
+
+	n, err := h.eachExportRow(r, func(row exportRow) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return cw.Write(row.record())
+	})
+	if err == nil {
+		err = start()
+	}
+	if err == nil {
+		cw.Flush()
+		err = cw.Error()
+	}
+	h.finishExport(w, r, "csv", n, started, err)
+}
+
+// exportJSON streams the users matching the list's current filters as a
+// JSON array.
+func (h *Handler) exportJSON(w http.ResponseWriter, r *http.Request) {
+	started := false
+	sep := "[\n"
+	n, err := h.eachExportRow(r, func(row exportRow) error {
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", exportFilename("json"))
+		}
+		if _, err := w.Write([]byte(sep)); err != nil {
+			return err
+		}
+		sep = ",\n"
+		_, err = w.Write(b)
+		return err
+	})
+	if err == nil {
+		if !started {
+			started = true
+			csvutil.SetDownloadHeaders(w, "application/json; charset=utf-8", exportFilename("json"))
+			_, err = w.Write([]byte("[]\n"))
+		} else {
+			_, err = w.Write([]byte("\n]\n"))
+		}
+	}
+	h.finishExport(w, r, "json", n, started, err)
+}
+
+// eachExportRow calls fn for each user matching the request's search,
+// status, role, and login filters, in the list's order, and returns how
+// many it visited.
+func (h *Handler) eachExportRow(r *http.Request, fn func(exportRow) error) (int, error) {
+	q := r.URL.Query()
+	filter := listFilter(strings.TrimSpace(q.Get("search")), normalize.Status(q.Get("status")), normalize.Role(q.Get("role")),
+		normalize.QueryParam(q.Get("login")))
+	opts := options.Find().SetSort(listSort(normalize.QueryParam(q.Get("sort"))))
+
+	n := 0
+	err := h.userStore.Each(r.Context(), filter, func(u models.User) error {
+		n++
+		return fn(newExportRow(u))
+	}, opts)
+	return n, err
+}
+
+// finishExport logs and audits an export. If the export failed before
+// anything was written an error page is sent; after that the response is
+// already under way, so the failure is only logged and the download ends
+// short.
+func (h *Handler) finishExport(w http.ResponseWriter, r *http.Request, format string, rows int, started bool, err error) {
+	if err != nil {
+		h.errLog.Log(r, "user export failed", err)
+		if !started {
+			http.Error(w, "A database error occurred", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	q := r.URL.Query()
+	h.auditLogger.LogAdminEvent(r, &actorID, nil, "users_exported", map[string]string{
+		"format": format,
+		"rows":   strconv.Itoa(rows),
+		"search": strings.TrimSpace(q.Get("search")),
+		"status": normalize.Status(q.Get("status")),
+		"role":   normalize.Role(q.Get("role")),
+		"login":  normalize.QueryParam(q.Get("login")),
+	})
+	h.logger.Info("system users exported", zap.String("format", format), zap.Int("rows", rows))
+}
+
+// exportFilename names an export file after the current date.
+func exportFilename(ext string) string {
+	return fmt.Sprintf("system_users_%s.%s", time.Now().UTC().Format("20060102"), ext)
+}
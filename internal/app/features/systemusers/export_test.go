@@ -0,0 +1,141 @@
+package systemusers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func exportRequest(path string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	return auth.WithTestUser(req, &auth.SessionUser{
+		ID:      primitive.NewObjectID().Hex(),
+		Name:    "Admin User",
+		LoginID: "admin@example.com",
+		Role:    "admin",
+	})
+}
+
+func createExportUsers(t *testing.T, store *userstore.Store) {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	users := []userstore.CreateInput{
+		{FullName: "Ada Admin", LoginID: "ada", AuthMethod: "trust", Role: "admin"},
+		{FullName: "Dev Developer", Email: "dev@example.com", LoginID: "dev@example.com", AuthMethod: "email", Role: "developer"},
+		{FullName: "=Formula", LoginID: "formula", AuthMethod: "trust", Role: "developer", Status: "disabled"},
+	}
+	for _, in := range users {
+		if _, err := store.CreateFromInput(ctx, in); err != nil {
+			t.Fatalf("CreateFromInput(%q) error = %v", in.FullName, err)
+		}
+	}
+}
+
+func TestExportCSV_Filters(t *testing.T) {
+	h, _, store := newTestHandler(t)
+	createExportUsers(t, store)
+
+	rec := httptest.NewRecorder()
+	h.exportCSV(rec, exportRequest("/system-users/export.csv?role=developer"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") {
+		t.Errorf("Content-Disposition = %q, want an attachment", cd)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(rec.Body.String(), "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want a header and 2 developers", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		t.Errorf("header = %v, want %v", records[0], exportColumns)
+	}
+	// Sorted by name: "=Formula" sorts before "Dev Developer"
+	if records[1][1] != "'=Formula" {
+		t.Errorf("full_name = %q, want the formula guarded", records[1][1])
+	}
+	if records[1][6] != "disabled" {
+		t.Errorf("status = %q, want disabled", records[1][6])
+	}
+	if records[2][3] != "dev@example.com" {
+		t.Errorf("email = %q, want dev@example.com", records[2][3])
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	h, _, store := newTestHandler(t)
+	createExportUsers(t, store)
+
+	rec := httptest.NewRecorder()
+	h.exportJSON(rec, exportRequest("/system-users/export.json?status=active"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var rows []exportRow
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("decoding JSON: %v\n%s", err, rec.Body.String())
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d users, want the 2 active ones", len(rows))
+	}
+	if rows[0].FullName != "Ada Admin" || rows[0].LoginID != "ada" {
+		t.Errorf("rows[0] = %+v, want Ada Admin", rows[0])
+	}
+}
+
+func TestExportJSON_Empty(t *testing.T) {
+	h, _, _ := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.exportJSON(rec, exportRequest("/system-users/export.json?search=nobody"))
+
+	if got := strings.TrimSpace(rec.Body.String()); got != "[]" {
+		t.Errorf("body = %q, want an empty array", got)
+	}
+}
+
+func TestExportRowRecord(t *testing.T) {
+	loginID := "+1555"
+	email := "a@example.com"
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	row := newExportRow(models.User{
+		ID:         primitive.NewObjectID(),
+		FullName:   "@home",
+		LoginID:    &loginID,
+		Email:      &email,
+		Role:       "Admin",
+		AuthMethod: "trust",
+		Status:     "active",
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	})
+
+	rec := row.record()
+	if len(rec) != len(exportColumns) {
+		t.Fatalf("record has %d fields, want %d", len(rec), len(exportColumns))
+	}
+	want := []string{row.ID, "'@home", "'+1555", "a@example.com", "admin", "trust", "active", "2024-03-01T12:00:00Z", "2024-03-01T12:00:00Z", ""}
+	for i := range want {
+		if rec[i] != want[i] {
+			t.Errorf("%s = %q, want %q", exportColumns[i], rec[i], want[i])
+		}
+	}
+}
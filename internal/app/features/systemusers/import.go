@@ -0,0 +1,377 @@
+// internal/app/features/systemusers/import.go
+package systemusers
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+	"github.com/dalemusser/stratasave/internal/app/system/inputval"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/dalemusser/waffle/pantry/text"
+	"go.uber.org/zap"
+)
+
+const (
+	maxImportSize = 1 << 20 // Largest CSV accepted
+	maxImportRows = 500
+
+	// Hashing a password takes a noticeable moment, so this keeps an
+	// import within the request timeout
+	maxImportPasswords = 50
+)
+
+// importColumns are the CSV columns an import reads, in the order the
+// import page lists them. temp_password is only needed for password auth.
+var importColumns = []string{"full_name", "login_id", "email", "role", "auth_method", "temp_password"}
+
+// Import row outcomes.
+const (
+	importCreated = "created"
+	importValid   = "valid" // Check only: the row would be created
+	importFailed  = "error"
+)
+
+// importRow is one user read from an import CSV.
+type importRow struct {
+	Line         int // Line in the file, for reporting
+	FullName     string
+	LoginID      string
+	Email        string
+	Role         string
+	AuthMethod   string
+	TempPassword string
+}
+
+// ImportResult is what happened to one row of an import.
+type ImportResult struct {
+	Line    int
+	Name    string
+	LoginID string
+	Status  string // importCreated, importValid, or importFailed
+	Message string
+}
+
+// ImportVM is the view model for the import page.
+type ImportVM struct {
+	viewdata.BaseVM
+	Columns     []string
+	Roles       []string
+	AuthMethods []string
+	MaxRows     int
+	CheckOnly   bool
+	SendWelcome bool
+	CanEmail    bool // A mailer is configured
+	Results     []ImportResult
+	Created     int
+	Valid       int
+	Failed      int
+	Error       string
+}
+
+// newImportVM returns the import page's view model with its options set
+// from the request and site settings.
+func (h *Handler) newImportVM(r *http.Request) ImportVM {
+	vm := ImportVM{
+		BaseVM:      viewdata.New(r),
+		Columns:     importColumns,
+		Roles:       models.AllRoles(),
+		AuthMethods: models.AllAuthMethodValues(),
+		MaxRows:     maxImportRows,
+		CanEmail:    h.mailer != nil,
+	}
+	vm.Title = "Import Users"
+	vm.BackURL = "/system-users"
+	return vm
+}
+
+// showImport displays the CSV import form.
+func (h *Handler) showImport(w http.ResponseWriter, r *http.Request) {
+	vm := h.newImportVM(r)
+	vm.CheckOnly = true
+	if settings, _ := h.settingsStore.Get(r.Context()); settings != nil {
+		vm.SendWelcome = settings.NotifyUserOnCreate
+	}
+	templates.Render(w, r, "systemusers/import", vm)
+}
+
+// runImport checks the rows of an uploaded CSV and, unless only checking,
+// creates a user for each valid row. Each row is reported separately; a
+// bad row doesn't stop the others.
+func (h *Handler) runImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actor, _ := auth.CurrentUser(r)
+
+	vm := h.newImportVM(r)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+(64<<10))
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		vm.Error = "The file is too large. Imports are limited to 1 MB."
+		templates.Render(w, r, "systemusers/import", vm)
+		return
+	}
+	vm.CheckOnly = r.FormValue("check_only") == "on"
+	vm.SendWelcome = r.FormValue("send_welcome") == "on" && vm.CanEmail
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		vm.Error = "Choose a CSV file to import."
+		templates.Render(w, r, "systemusers/import", vm)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxImportSize {
+		vm.Error = "The file is too large. Imports are limited to 1 MB."
+		templates.Render(w, r, "systemusers/import", vm)
+		return
+	}
+
+	rows, err := parseImport(file)
+	if err == nil {
+		err = checkImportLimits(rows)
+	}
+	if err != nil {
+		vm.Error = err.Error()
+		templates.Render(w, r, "systemusers/import", vm)
+		return
+	}
+
+	actorID := actor.UserID()
+	seen := make(map[string]int)
+	var created []models.User
+	for _, row := range rows {
+		res := ImportResult{Line: row.Line, Name: row.FullName, LoginID: row.LoginID}
+		input, err := resolveImportRow(row, seen)
+		if err != nil {
+			res.Status, res.Message = importFailed, err.Error()
+			vm.Results = append(vm.Results, res)
+			continue
+		}
+		res.LoginID = input.LoginID
+
+		if vm.CheckOnly {
+			exists, err := h.userStore.ExistsByLoginID(ctx, input.LoginID)
+			switch {
+			case err != nil:
+				h.errLog.Log(r, "failed to check login id", err)
+				res.Status, res.Message = importFailed, "Could not be checked."
+			case exists:
+				res.Status, res.Message = importFailed, "Login ID is already in use."
+			default:
+				res.Status = importValid
+			}
+			vm.Results = append(vm.Results, res)
+			continue
+		}
+
+		if row.AuthMethod == "password" {
+			hash, err := authutil.HashPassword(row.TempPassword)
+			if err != nil {
+				h.errLog.Log(r, "failed to hash password", err)
+				res.Status, res.Message = importFailed, "Could not be created."
+				vm.Results = append(vm.Results, res)
+				continue
+			}
+			temp := true
+			input.PasswordHash = &hash
+			input.PasswordTemp = &temp
+		}
+
+		user, err := h.userStore.CreateFromInput(ctx, input)
+		switch {
+		case errors.Is(err, userstore.ErrDuplicateLoginID):
+			res.Status, res.Message = importFailed, "Login ID is already in use."
+		case err != nil:
+			h.errLog.Log(r, "failed to create imported user", err)
+			res.Status, res.Message = importFailed, "Could not be created."
+		default:
+			res.Status = importCreated
+			created = append(created, user)
+			h.auditLogger.LogAdminEvent(r, &actorID, &user.ID, "user_created", map[string]string{
+				"source": "import",
+			})
+			h.webhooks.Publish(webhooks.EventUserCreated, webhooks.UserData(&user, "import"))
+		}
+		vm.Results = append(vm.Results, res)
+	}
+
+	for _, res := range vm.Results {
+		switch res.Status {
+		case importCreated:
+			vm.Created++
+		case importValid:
+			vm.Valid++
+		default:
+			vm.Failed++
+		}
+	}
+
+	if !vm.CheckOnly {
+		h.logger.Info("system users imported",
+			zap.String("actor_id", actorID.Hex()),
+			zap.Int("created", vm.Created),
+			zap.Int("failed", vm.Failed))
+	}
+	if vm.SendWelcome && len(created) > 0 {
+		brand := h.mailer.Brand(ctx)
+		settings, _ := h.settingsStore.Get(ctx)
+		go h.sendWelcome(brand, siteName(settings), created)
+	}
+
+	templates.Render(w, r, "systemusers/import", vm)
+}
+
+// parseImport reads the users in an import CSV. The first row names the
+// columns, in any order; columns the import doesn't use are ignored, and
+// "name" is accepted for full_name.
+func parseImport(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("The file is empty.")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("The file is not a valid CSV: %v", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		col[name] = i
+	}
+	if _, ok := col["full_name"]; !ok {
+		if i, ok := col["name"]; ok {
+			col["full_name"] = i
+		}
+	}
+	for _, name := range []string{"full_name", "role", "auth_method"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("The file has no %s column.", name)
+		}
+	}
+	_, hasLogin := col["login_id"]
+	_, hasEmail := col["email"]
+	if !hasLogin && !hasEmail {
+		return nil, errors.New("The file needs a login_id or email column.")
+	}
+
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var rows []importRow
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("The file is not a valid CSV: %v", err)
+		}
+		line, _ := cr.FieldPos(0)
+		row := importRow{
+			Line:         line,
+			FullName:     field(rec, "full_name"),
+			LoginID:      field(rec, "login_id"),
+			Email:        field(rec, "email"),
+			Role:         strings.ToLower(field(rec, "role")),
+			AuthMethod:   strings.ToLower(field(rec, "auth_method")),
+			TempPassword: field(rec, "temp_password"),
+		}
+		if row == (importRow{Line: line}) {
+			continue // A row of empty fields
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("The file has no users in it.")
+	}
+	return rows, nil
+}
+
+// checkImportLimits rejects imports too large to finish within a request.
+func checkImportLimits(rows []importRow) error {
+	if len(rows) > maxImportRows {
+		return fmt.Errorf("The file has %d users. Imports are limited to %d; split the file and import each part.", len(rows), maxImportRows)
+	}
+	passwords := 0
+	for _, row := range rows {
+		if row.AuthMethod == "password" {
+			passwords++
+		}
+	}
+	if passwords > maxImportPasswords {
+		return fmt.Errorf("The file has %d users with password auth. Imports are limited to %d of them; split the file and import each part.", passwords, maxImportPasswords)
+	}
+	return nil
+}
+
+// resolveImportRow validates a row the way the Add User form does and
+// returns the user to create. seen maps the login IDs of earlier rows to
+// their lines, so a login ID can't be used twice in one file.
+func resolveImportRow(row importRow, seen map[string]int) (userstore.CreateInput, error) {
+	if row.FullName == "" {
+		return userstore.CreateInput{}, errors.New("Full name is required.")
+	}
+	if !models.IsValidRole(row.Role) {
+		return userstore.CreateInput{}, fmt.Errorf("Role must be one of: %s.", strings.Join(models.AllRoles(), ", "))
+	}
+	if !inputval.IsValidAuthMethod(row.AuthMethod) {
+		return userstore.CreateInput{}, fmt.Errorf("Auth method must be one of: %s.", strings.Join(inputval.AllowedAuthMethodsList(), ", "))
+	}
+
+	// The password is checked here and hashed only when the user is created
+	resolved, err := authutil.ValidateAndResolve(authutil.AuthInput{
+		Method:  row.AuthMethod,
+		LoginID: row.LoginID,
+		Email:   row.Email,
+		IsEdit:  true,
+	})
+	if err != nil {
+		return userstore.CreateInput{}, err
+	}
+	if row.Email != "" && !inputval.IsValidEmail(row.Email) {
+		return userstore.CreateInput{}, authutil.ErrInvalidEmail
+	}
+	if row.AuthMethod == "password" {
+		if row.TempPassword == "" {
+			return userstore.CreateInput{}, authutil.ErrPasswordRequired
+		}
+		if err := authutil.ValidatePassword(row.TempPassword); err != nil {
+			return userstore.CreateInput{}, err
+		}
+	}
+
+	key := text.Fold(strings.ToLower(resolved.EffectiveLoginID))
+	if line, ok := seen[key]; ok {
+		return userstore.CreateInput{}, errors.New("Login ID is also used on line " + strconv.Itoa(line) + ".")
+	}
+	seen[key] = row.Line
+
+	return userstore.CreateInput{
+		FullName:   row.FullName,
+		LoginID:    resolved.EffectiveLoginID,
+		Email:      row.Email,
+		AuthMethod: row.AuthMethod,
+		Role:       row.Role,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package systemusers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/authutil"
+)
+
+func TestParseImport(t *testing.T) {
+	csv := "\ufeffName,Login_ID,Email,Role,Auth_Method,Notes\n" +
+		"Ada Lovelace,ada,,admin,trust,first\n" +
+		",,,,\n" +
+		"\"Hopper, Grace\",,grace@example.com,Developer,EMAIL\n"
+
+	rows, err := parseImport(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("parseImport() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("parseImport() returned %d rows, want 2", len(rows))
+	}
+	want := importRow{Line: 2, FullName: "Ada Lovelace", LoginID: "ada", Role: "admin", AuthMethod: "trust"}
+	if rows[0] != want {
+		t.Errorf("rows[0] = %+v, want %+v", rows[0], want)
+	}
+	want = importRow{Line: 4, FullName: "Hopper, Grace", Email: "grace@example.com", Role: "developer", AuthMethod: "email"}
+	if rows[1] != want {
+		t.Errorf("rows[1] = %+v, want %+v", rows[1], want)
+	}
+}
+
+func TestParseImport_BadFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want string
+	}{
+		{"empty", "", "empty"},
+		{"no role", "full_name,login_id,auth_method\n", "no role column"},
+		{"no login", "full_name,role,auth_method\n", "login_id or email"},
+		{"no users", "full_name,login_id,role,auth_method\n,,,\n", "no users"},
+		{"bad quote", "full_name,login_id,role,auth_method\n\"Ada,ada,admin,trust\n", "not a valid CSV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseImport(strings.NewReader(tt.csv))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseImport() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckImportLimits(t *testing.T) {
+	rows := make([]importRow, maxImportPasswords)
+	for i := range rows {
+		rows[i].AuthMethod = "password"
+	}
+	if err := checkImportLimits(rows); err != nil {
+		t.Errorf("checkImportLimits(%d password rows) error = %v", len(rows), err)
+	}
+	if err := checkImportLimits(append(rows, importRow{AuthMethod: "password"})); err == nil {
+		t.Error("checkImportLimits() should reject too many password rows")
+	}
+	if err := checkImportLimits(make([]importRow, maxImportRows+1)); err == nil {
+		t.Error("checkImportLimits() should reject too many rows")
+	}
+}
+
+func TestResolveImportRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     importRow
+		loginID string // Resolved login ID when valid
+		err     string // Part of the error when invalid
+	}{
+		{"trust", importRow{FullName: "Ada", LoginID: "ada", Role: "admin", AuthMethod: "trust"}, "ada", ""},
+		{"email is login", importRow{FullName: "Grace", LoginID: "ignored", Email: "grace@example.com", Role: "developer", AuthMethod: "google"}, "grace@example.com", ""},
+		{"password", importRow{FullName: "Alan", LoginID: "alan", Role: "admin", AuthMethod: "password", TempPassword: "enigma42"}, "alan", ""},
+		{"no name", importRow{LoginID: "x", Role: "admin", AuthMethod: "trust"}, "", "Full name"},
+		{"bad role", importRow{FullName: "X", LoginID: "x", Role: "owner", AuthMethod: "trust"}, "", "Role must be"},
+		{"no role", importRow{FullName: "X", LoginID: "x", AuthMethod: "trust"}, "", "Role must be"},
+		{"bad method", importRow{FullName: "X", LoginID: "x", Role: "admin", AuthMethod: "ldap"}, "", "Auth method must be"},
+		{"no login id", importRow{FullName: "X", Role: "admin", AuthMethod: "trust"}, "", authutil.ErrLoginIDRequired.Error()},
+		{"no email", importRow{FullName: "X", Role: "admin", AuthMethod: "email"}, "", authutil.ErrEmailRequired.Error()},
+		{"bad optional email", importRow{FullName: "X", LoginID: "x", Email: "nope", Role: "admin", AuthMethod: "trust"}, "", authutil.ErrInvalidEmail.Error()},
+		{"no password", importRow{FullName: "X", LoginID: "x", Role: "admin", AuthMethod: "password"}, "", authutil.ErrPasswordRequired.Error()},
+		{"weak password", importRow{FullName: "X", LoginID: "x", Role: "admin", AuthMethod: "password", TempPassword: "password"}, "", authutil.ErrPasswordCommon.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := resolveImportRow(tt.row, map[string]int{})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("resolveImportRow() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveImportRow() error = %v", err)
+			}
+			if input.LoginID != tt.loginID {
+				t.Errorf("LoginID = %q, want %q", input.LoginID, tt.loginID)
+			}
+			if input.PasswordHash != nil {
+				t.Error("resolveImportRow() should leave hashing to the import")
+			}
+		})
+	}
+}
+
+func TestResolveImportRow_DuplicateInFile(t *testing.T) {
+	seen := map[string]int{}
+	first := importRow{Line: 2, FullName: "Ada", LoginID: "Ada", Role: "admin", AuthMethod: "trust"}
+	if _, err := resolveImportRow(first, seen); err != nil {
+		t.Fatalf("resolveImportRow() error = %v", err)
+	}
+	second := importRow{Line: 5, FullName: "Ada Again", LoginID: "ada", Role: "admin", AuthMethod: "trust"}
+	_, err := resolveImportRow(second, seen)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("resolveImportRow() error = %v, want a duplicate of line 2", err)
+	}
+}
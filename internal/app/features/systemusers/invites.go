@@ -0,0 +1,62 @@
+// internal/app/features/systemusers/invites.go
+package systemusers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/text"
+)
+
+// SetInvitationStore sets the store used to list pending invitations with
+// the users, so invited people who haven't accepted yet aren't forgotten.
+// Without it, invitations are only shown on the invitations page.
+func (h *Handler) SetInvitationStore(store *invitation.Store) {
+	h.invitations = store
+}
+
+// inviteRow represents a pending invitation in the users list.
+type inviteRow struct {
+	ID        string
+	Email     string
+	Role      string
+	ExpiresAt string
+}
+
+// pendingInvites returns the pending invitations matching the list's
+// filters. Invitations have no name, so the search matches their email.
+// They are only listed when no status filter is set, or when filtering to
+// invited people.
+func (h *Handler) pendingInvites(ctx context.Context, searchQ, status, role string) ([]inviteRow, error) {
+	if h.invitations == nil || (status != "" && status != "invited") {
+		return nil, nil
+	}
+
+	invs, err := h.invitations.ListPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qFold := text.Fold(searchQ)
+	var rows []inviteRow
+	for _, inv := range invs {
+		if !models.IsValidRole(inv.Role) {
+			continue // not a system user role
+		}
+		if role != "" && inv.Role != role {
+			continue
+		}
+		if qFold != "" && !strings.Contains(text.Fold(inv.Email), qFold) {
+			continue
+		}
+		rows = append(rows, inviteRow{
+			ID:        inv.ID.Hex(),
+			Email:     inv.Email,
+			Role:      inv.Role,
+			ExpiresAt: inv.ExpiresAt.Format("Jan 2, 2006"),
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,62 @@
+package systemusers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestListFilter_InvitedMatchesNoUsers(t *testing.T) {
+	got := listFilter("", "invited", "", "")["_id"]
+	if !reflect.DeepEqual(got, bson.M{"$in": bson.A{}}) {
+		t.Errorf("invited _id filter = %v, want no users matched", got)
+	}
+}
+
+func TestPendingInvites(t *testing.T) {
+	h, db, _ := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	// Without a store, no invitations are listed
+	if rows, err := h.pendingInvites(ctx, "", "", ""); err != nil || rows != nil {
+		t.Fatalf("pendingInvites() without store = %v, %v; want nil, nil", rows, err)
+	}
+
+	store := invitation.New(db, 7*24*time.Hour)
+	h.SetInvitationStore(store)
+	for _, in := range []invitation.CreateInput{
+		{Email: "alex@example.com", Role: "admin", InvitedBy: primitive.NewObjectID()},
+		{Email: "sam@example.com", Role: "developer", InvitedBy: primitive.NewObjectID()},
+	} {
+		if _, err := store.Create(ctx, in); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name                  string
+		searchQ, status, role string
+		want                  int
+	}{
+		{"all", "", "", "", 2},
+		{"invited filter", "", "invited", "", 2},
+		{"active filter", "", "active", "", 0},
+		{"role", "", "", "admin", 1},
+		{"search email", "SAM@", "", "", 1},
+	}
+	for _, tt := range tests {
+		rows, err := h.pendingInvites(ctx, tt.searchQ, tt.status, tt.role)
+		if err != nil {
+			t.Fatalf("%s: pendingInvites() error = %v", tt.name, err)
+		}
+		if len(rows) != tt.want {
+			t.Errorf("%s: pendingInvites() = %d rows, want %d", tt.name, len(rows), tt.want)
+		}
+	}
+}
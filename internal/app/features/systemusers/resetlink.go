@@ -0,0 +1,100 @@
+// internal/app/features/systemusers/resetlink.go
+package systemusers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dalemusser/stratasave/internal/app/store/passwordreset"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SetPasswordResetStore sets the store used to email users a link to set a
+// new password. Without it, admins can only set a temporary password.
+func (h *Handler) SetPasswordResetStore(store *passwordreset.Store) {
+	h.passwordResets = store
+}
+
+// SetBaseURL sets the site's public URL, used to build the password reset
+// links emailed to users.
+func (h *Handler) SetBaseURL(baseURL string) {
+	h.baseURL = strings.TrimRight(baseURL, "/")
+}
+
+// canSendResetLink reports whether a reset link can be emailed to the user.
+func (h *Handler) canSendResetLink(authMethod string, email *string) bool {
+	return h.passwordResets != nil && h.mailer != nil &&
+		authMethod == "password" && email != nil && *email != ""
+}
+
+// sendResetLink emails the user a link to set a new password, so the admin
+// never chooses or passes on a password.
+func (h *Handler) sendResetLink(w http.ResponseWriter, r *http.Request) {
+	actor, _ := auth.CurrentUser(r)
+
+	id := chi.URLParam(r, "id")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	user, err := h.getUser(r.Context(), objID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.NotFound(w, r)
+			return
+		}
+		h.errLog.Log(r, "failed to get user", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if !h.canSendResetLink(user.AuthMethod, user.Email) {
+		http.Redirect(w, r, "/system-users/"+id+"/edit?error=reset_unavailable", http.StatusSeeOther)
+		return
+	}
+
+	reset, err := h.passwordResets.Create(r.Context(), user.ID, *user.Email)
+	if err != nil {
+		h.errLog.Log(r, "failed to create password reset", err)
+		http.Redirect(w, r, "/system-users/"+id+"/edit?error=reset_failed", http.StatusSeeOther)
+		return
+	}
+
+	expiryMin := int(h.passwordResets.Expiry().Minutes())
+	if expiryMin < 1 {
+		expiryMin = 10 // default
+	}
+	textBody, htmlBody := mailer.PasswordResetEmail(mailer.PasswordResetEmailData{
+		Locale:    user.Locale,
+		Brand:     h.mailer.Brand(r.Context()),
+		AppName:   h.mailer.FromName(),
+		ResetURL:  h.baseURL + "/login/reset-password?token=" + reset.Token,
+		ExpiryMin: expiryMin,
+		ByAdmin:   true,
+	})
+	if err := h.mailer.Send(mailer.Email{
+		To:       *user.Email,
+		Subject:  mailer.T(user.Locale, "password_reset.subject"),
+		Template: "password_reset",
+		UserID:   user.ID.Hex(),
+		TextBody: textBody,
+		HTMLBody: htmlBody,
+	}); err != nil {
+		h.errLog.Log(r, "failed to send password reset email", err)
+		http.Redirect(w, r, "/system-users/"+id+"/edit?error=reset_failed", http.StatusSeeOther)
+		return
+	}
+
+	actorID := actor.UserID()
+	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "password_reset_link_sent", map[string]string{
+		"email": *user.Email,
+	})
+
+	http.Redirect(w, r, "/system-users/"+id+"/edit?success=reset_sent", http.StatusSeeOther)
+}
@@ -6,12 +6,20 @@ package systemusers
 //   - LoginID / loginID / login_id: The human-readable string users type to log in
 
 import (
+	"context"
 	"html/template"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	impersonationfeature "github.com/dalemusser/stratasave/internal/app/features/impersonation"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	"github.com/dalemusser/stratasave/internal/app/store/passwordreset"
+	"github.com/dalemusser/stratasave/internal/app/store/ratelimit"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
 	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
 	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
@@ -19,7 +27,10 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/system/authutil"
 	"github.com/dalemusser/stratasave/internal/app/system/mailer"
 	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/dalemusser/stratasave/internal/app/system/sessionrotate"
+	"github.com/dalemusser/stratasave/internal/app/system/userpurge"
 	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/waffle/pantry/templates"
 	"github.com/dalemusser/waffle/pantry/text"
@@ -36,12 +47,21 @@ const pageSize = 20
 
 // Handler provides system users management handlers.
 type Handler struct {
-	userStore     *userstore.Store
-	settingsStore *settingsstore.Store
-	mailer        *mailer.Mailer
-	errLog        *errorsfeature.ErrorLogger
-	auditLogger   *auditlog.Logger
-	logger        *zap.Logger
+	userStore      *userstore.Store
+	settingsStore  *settingsstore.Store
+	auditStore     *audit.Store
+	sessionsStore  *sessions.Store
+	rateLimitStore *ratelimit.Store // nil when login rate limiting is disabled
+	rotator        *sessionrotate.Rotator
+	purger         *userpurge.Purger    // nil if deleted users can't be purged by hand
+	passwordResets *passwordreset.Store // nil if reset links can't be emailed
+	invitations    *invitation.Store    // nil if pending invitations aren't listed
+	baseURL        string
+	webhooks       *webhooks.Dispatcher
+	mailer         *mailer.Mailer
+	errLog         *errorsfeature.ErrorLogger
+	auditLogger    *auditlog.Logger
+	logger         *zap.Logger
 }
 
 // NewHandler creates a new system users Handler.
@@ -55,6 +75,8 @@ func NewHandler(
 	return &Handler{
 		userStore:     userstore.New(db),
 		settingsStore: settingsstore.New(db),
+		auditStore:    audit.New(db),
+		sessionsStore: sessions.New(db),
 		mailer:        m,
 		errLog:        errLog,
 		auditLogger:   auditLogger,
@@ -62,14 +84,34 @@ func NewHandler(
 	}
 }
 
+// SetRateLimitStore sets the login rate limit store, so the user page can
+// show and clear a lockout. Pass nil when rate limiting is disabled.
+func (h *Handler) SetRateLimitStore(store *ratelimit.Store) {
+	h.rateLimitStore = store
+}
+
+// SetSessionRotator sets the rotator used to invalidate a user's sessions
+// when an admin changes their role or password.
+func (h *Handler) SetSessionRotator(rt *sessionrotate.Rotator) {
+	h.rotator = rt
+}
+
+// SetWebhooks enables user.created webhook events for users created or
+// imported here.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.webhooks = d
+}
+
 // userRow represents a user in the list.
 type userRow struct {
-	ID       primitive.ObjectID
-	FullName string
-	LoginID  string
-	Role     string
-	Auth     string
-	Status   string
+	ID        primitive.ObjectID
+	FullName  string
+	LoginID   string
+	Role      string
+	Auth      string
+	Status    string
+	LastLogin string // Empty if the user has never logged in
+	Sessions  int    // Active sessions
 }
 
 // ListVM is the view model for the users list.
@@ -78,8 +120,10 @@ type ListVM struct {
 
 	// Filter state
 	SearchQuery    string
-	Status         string   // "", active, disabled
+	Status         string   // "", active, disabled, invited
 	RoleFilter     string   // "", admin, developer (renamed to avoid shadowing BaseVM.Role)
+	LoginFilter    string   // "", never
+	Sort           string   // "", recent_login, oldest_login
 	AvailableRoles []string // for dropdown
 
 	// Pagination
@@ -94,9 +138,11 @@ type ListVM struct {
 	HasNext    bool
 
 	// Data
-	Rows []userRow
+	Rows    []userRow
+	Invites []inviteRow // Pending invitations, shown on the first page
 
 	Flash template.HTML
+	Error string
 }
 
 // Routes returns a chi.Router with system users routes mounted.
@@ -107,13 +153,25 @@ func Routes(h *Handler, sessionMgr *auth.SessionManager) http.Handler {
 	r.Get("/", h.list)
 	r.Get("/new", h.showNew)
 	r.Post("/new", h.create)
+	r.Get("/import", h.showImport)
+	r.Post("/import", h.runImport)
+	r.Get("/export.csv", h.exportCSV)
+	r.Get("/export.json", h.exportJSON)
+	r.Get("/deleted", h.showDeleted)
 	r.Get("/{id}", h.show)
 	r.Get("/{id}/edit", h.showEdit)
 	r.Post("/{id}", h.update)
 	r.Post("/{id}/disable", h.disable)
 	r.Post("/{id}/enable", h.enable)
-	r.Post("/{id}/reset-password", h.resetPassword)
-	r.Post("/{id}/delete", h.delete)
+	r.With(sessionMgr.RequireRecentAuth).Post("/{id}/reset-password", h.resetPassword)
+	if h.passwordResets != nil {
+		r.With(sessionMgr.RequireRecentAuth).Post("/{id}/send-reset", h.sendResetLink)
+	}
+	r.With(sessionMgr.RequireRecentAuth).Post("/{id}/delete", h.delete)
+	r.Post("/{id}/restore", h.restore)
+	if h.purger != nil {
+		r.With(sessionMgr.RequireRecentAuth).Post("/{id}/purge", h.purge)
+	}
 
 	// Manage modal for HTMX
 	r.Get("/{id}/manage_modal", h.manageModal)
@@ -129,6 +187,8 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 	searchQ := strings.TrimSpace(q.Get("search"))
 	status := normalize.Status(q.Get("status"))
 	role := normalize.Role(q.Get("role"))
+	login := normalize.QueryParam(q.Get("login"))
+	sort := normalize.QueryParam(q.Get("sort"))
 
 	// Parse page number
 	page := 1
@@ -138,24 +198,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Build filter - show all system users (admin and developer roles)
-	filter := bson.M{"role": bson.M{"$in": models.AllRoles()}}
-
-	// Apply role filter if specified
-	if role != "" && models.IsValidRole(role) {
-		filter["role"] = role
-	}
-
-	if status == "active" || status == "disabled" {
-		filter["status"] = status
-	}
-
-	// Search by name
-	if searchQ != "" {
-		qFold := text.Fold(searchQ)
-		hiFold := qFold + "\uffff"
-		filter["full_name_ci"] = bson.M{"$gte": qFold, "$lt": hiFold}
-	}
+	filter := listFilter(searchQ, status, role, login)
 
 	// Count total
 	total, err := h.userStore.Count(r.Context(), filter)
@@ -178,7 +221,7 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch users
 	findOpts := options.Find().
-		SetSort(bson.D{{Key: "full_name_ci", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSort(listSort(sort)).
 		SetSkip(int64(offset)).
 		SetLimit(int64(pageSize))
 
@@ -189,6 +232,29 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Pending invitations are listed above the users on the first page
+	var invites []inviteRow
+	if page == 1 {
+		invites, err = h.pendingInvites(r.Context(), searchQ, status, role)
+		if err != nil {
+			h.errLog.Log(r, "failed to list invitations", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Count the page's active sessions
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+	sessionCounts, err := h.sessionsStore.CountActiveByUsers(r.Context(), userIDs)
+	if err != nil {
+		h.errLog.Log(r, "failed to count user sessions", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
 	// Build rows
 	rows := make([]userRow, 0, len(users))
 	for _, u := range users {
@@ -196,13 +262,19 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		if u.LoginID != nil {
 			loginID = *u.LoginID
 		}
+		lastLogin := ""
+		if u.LastLoginAt != nil {
+			lastLogin = viewdata.FormatTime(r, *u.LastLoginAt, "Jan 2, 2006 3:04 PM")
+		}
 		rows = append(rows, userRow{
-			ID:       u.ID,
-			FullName: u.FullName,
-			LoginID:  loginID,
-			Role:     normalize.Role(u.Role),
-			Auth:     formatAuthMethod(u.AuthMethod),
-			Status:   normalize.Status(u.Status),
+			ID:        u.ID,
+			FullName:  u.FullName,
+			LoginID:   loginID,
+			Role:      normalize.Role(u.Role),
+			Auth:      formatAuthMethod(u.AuthMethod),
+			Status:    normalize.Status(u.Status),
+			LastLogin: lastLogin,
+			Sessions:  sessionCounts[u.ID],
 		})
 	}
 
@@ -219,6 +291,8 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		SearchQuery:    searchQ,
 		Status:         status,
 		RoleFilter:     role,
+		LoginFilter:    login,
+		Sort:           sort,
 		AvailableRoles: models.AllRoles(),
 		Page:           page,
 		PrevPage:       page - 1,
@@ -230,12 +304,76 @@ func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
 		HasPrev:        page > 1,
 		HasNext:        page < totalPages,
 		Rows:           rows,
+		Invites:        invites,
 	}
 	vm.Title = "System Users"
 
+	// Results of resending or revoking an invitation from the list
+	switch {
+	case q.Get("resent") == "1":
+		vm.Flash = "Invitation resent"
+	case q.Get("revoked") == "1":
+		vm.Flash = "Invitation revoked"
+	case q.Get("error") != "":
+		vm.Error = q.Get("error")
+	}
+
 	templates.RenderAutoMap(w, r, "systemusers/list", nil, vm)
 }
 
+// listFilter builds the users filter for the list's search, status, role,
+// and login filters. The export uses it too, so it matches what the list
+// shows.
+func listFilter(searchQ, status, role, login string) bson.M {
+	// Show all system users (admin and developer roles)
+	filter := bson.M{"role": bson.M{"$in": models.AllRoles()}}
+
+	// Apply role filter if specified
+	if role != "" && models.IsValidRole(role) {
+		filter["role"] = role
+	}
+
+	switch status {
+	case "active", "disabled":
+		filter["status"] = status
+	case "invited":
+		// Invited people aren't users until they accept; the list shows
+		// their pending invitations instead
+		filter["_id"] = bson.M{"$in": bson.A{}}
+	default:
+		// Deleted users are listed on the deleted users page instead
+		filter["status"] = bson.M{"$ne": "deleted"}
+	}
+
+	// Find stale accounts
+	if login == "never" {
+		filter["last_login_at"] = nil
+	}
+
+	// Search by name
+	if searchQ != "" {
+		qFold := text.Fold(searchQ)
+		hiFold := qFold + "\uffff"
+		filter["full_name_ci"] = bson.M{"$gte": qFold, "$lt": hiFold}
+	}
+
+	return filter
+}
+
+// listSort returns the list's sort order: by name, or by last login with
+// users who have never logged in last ("recent_login") or first
+// ("oldest_login").
+func listSort(sort string) bson.D {
+	switch sort {
+	case "recent_login":
+		return bson.D{{Key: "last_login_at", Value: -1}, {Key: "full_name_ci", Value: 1}, {Key: "_id", Value: 1}}
+	case "oldest_login":
+		return bson.D{{Key: "last_login_at", Value: 1}, {Key: "full_name_ci", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		return bson.D{{Key: "full_name_ci", Value: 1}, {Key: "_id", Value: 1}}
+	}
+}
+
 // ManageModalVM is the view model for the manage modal.
 type ManageModalVM struct {
 	ID        string
@@ -260,7 +398,7 @@ func (h *Handler) manageModal(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userStore.GetByID(r.Context(), objID)
+	user, err := h.getUser(r.Context(), objID)
 	if err != nil {
 		http.NotFound(w, r)
 		return
@@ -295,6 +433,7 @@ type NewUserVM struct {
 	AuthMethod     string
 	SelectedRole   string
 	AvailableRoles []string
+	DisableOn      string // YYYY-MM-DD, empty if not scheduled
 	Error          string
 }
 
@@ -353,6 +492,28 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 		Role:       role,
 	}
 
+	disableOn := r.FormValue("disable_on")
+	disableAt, err := parseDisableOn(disableOn, time.Now())
+	if err != nil {
+		vm := NewUserVM{
+			BaseVM:         viewdata.New(r),
+			FullName:       input.FullName,
+			LoginID:        r.FormValue("login_id"),
+			Email:          email,
+			AuthMethod:     input.AuthMethod,
+			SelectedRole:   role,
+			AvailableRoles: models.AllRoles(),
+			DisableOn:      disableOn,
+			Error:          disableOnError(err),
+		}
+		vm.BackURL = returnURL
+		templates.Render(w, r, "systemusers/new", vm)
+		return
+	}
+	if !disableAt.IsZero() {
+		input.DisableAt = &disableAt
+	}
+
 	// Handle password for password auth
 	if input.AuthMethod == "password" {
 		password := r.FormValue("temp_password")
@@ -365,6 +526,7 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 				AuthMethod:     input.AuthMethod,
 				SelectedRole:   role,
 				AvailableRoles: models.AllRoles(),
+				DisableOn:      disableOn,
 				Error:          "Password is required for password authentication",
 			}
 			vm.BackURL = returnURL
@@ -397,6 +559,7 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 			AuthMethod:     input.AuthMethod,
 			SelectedRole:   role,
 			AvailableRoles: models.AllRoles(),
+			DisableOn:      disableOn,
 			Error:          "Failed to create user. Login ID is already in use.",
 		}
 		vm.BackURL = returnURL
@@ -406,49 +569,90 @@ func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
 
 	actorID := actor.UserID()
 	h.auditLogger.LogAdminEvent(r, &actorID, &user.ID, "user_created", nil)
+	h.webhooks.Publish(webhooks.EventUserCreated, webhooks.UserData(&user, "admin"))
 
 	// Send welcome email if enabled and user has email
 	if h.mailer != nil && user.Email != nil && *user.Email != "" {
 		settings, _ := h.settingsStore.Get(r.Context())
 		if settings != nil && settings.NotifyUserOnCreate {
-			userEmail := *user.Email
-			userName := user.FullName
-			siteName := settings.SiteName
-			if siteName == "" {
-				siteName = "Strata"
-			}
-			go func() {
-				text, html := mailer.WelcomeEmail(mailer.WelcomeEmailData{
-					AppName:  siteName,
-					UserName: userName,
-					LoginURL: "/login",
-					Role:     user.Role,
-				})
-				_ = h.mailer.Send(mailer.Email{
-					To:       userEmail,
-					Subject:  "Welcome to " + siteName,
-					TextBody: text,
-					HTMLBody: html,
-				})
-			}()
+			brand := h.mailer.Brand(r.Context())
+			go h.sendWelcome(brand, siteName(settings), []models.User{user})
 		}
 	}
 
 	http.Redirect(w, r, returnURL, http.StatusSeeOther)
 }
 
+// sendWelcome emails each new user that has an email address a welcome
+// message. It is run in the background once the users are created.
+func (h *Handler) sendWelcome(brand mailer.Brand, siteName string, users []models.User) {
+	for _, user := range users {
+		if user.Email == nil || *user.Email == "" {
+			continue
+		}
+		text, html := mailer.WelcomeEmail(mailer.WelcomeEmailData{
+			Locale:   user.Locale,
+			Brand:    brand,
+			AppName:  siteName,
+			UserName: user.FullName,
+			LoginURL: "/login",
+			Role:     user.Role,
+		})
+		err := h.mailer.Send(mailer.Email{
+			To:       *user.Email,
+			Subject:  mailer.T(user.Locale, "welcome.subject", siteName),
+			Template: "welcome",
+			UserID:   user.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		})
+		if err != nil {
+			h.logger.Warn("failed to send welcome email",
+				zap.String("user_id", user.ID.Hex()),
+				zap.Error(err))
+		}
+	}
+}
+
+// siteName returns the site name used in emails.
+func siteName(settings *models.SiteSettings) string {
+	if settings == nil || settings.SiteName == "" {
+		return "Strata"
+	}
+	return settings.SiteName
+}
+
 // ShowVM is the view model for viewing a user.
 type ShowVM struct {
 	viewdata.BaseVM
-	ID       string
-	FullName string
-	LoginID  string
-	Email    string
-	UserRole string // renamed to avoid shadowing BaseVM.Role
-	Auth     string
-	Status   string
+	ID             string
+	FullName       string
+	LoginID        string
+	Email          string
+	UserRole       string // renamed to avoid shadowing BaseVM.Role
+	Auth           string
+	LinkedAuth     string // Other sign-in methods the user connected, empty if none
+	Status         string
+	DisableOn      string // Formatted scheduled disable date, empty if none
+	DeleteOn       string // Date the user asked to have their account deleted, empty if none
+	CanImpersonate bool
+
+	// Login rate limiting (see /rate-limits)
+	RateLimited    bool   // Rate limiting is enabled
+	FailedAttempts int    // Failed attempts in the current window
+	LockedUntil    string // Set while the login ID is locked out
+	Lockouts       []LockoutRow
+}
+
+// LockoutRow is a recent lockout of the user's login ID.
+type LockoutRow struct {
+	When string
+	IP   string
 }
 
+// recentLockouts is how many lockout events the user page lists.
+const recentLockouts = 5
+
 // show displays a single user.
 func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -458,7 +662,7 @@ func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userStore.GetByID(r.Context(), objID)
+	user, err := h.getUser(r.Context(), objID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			http.NotFound(w, r)
@@ -488,6 +692,23 @@ func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 		Auth:     formatAuthMethod(user.AuthMethod),
 		Status:   normalize.Status(user.Status),
 	}
+	var linked []string
+	for _, m := range user.Methods() {
+		if m != user.AuthMethod {
+			linked = append(linked, formatAuthMethod(m))
+		}
+	}
+	vm.LinkedAuth = strings.Join(linked, ", ")
+	if admin, ok := auth.CurrentUser(r); ok {
+		vm.CanImpersonate = impersonationfeature.CanImpersonate(admin, user)
+	}
+	if user.DisableAt != nil {
+		vm.DisableOn = user.DisableAt.UTC().Format("January 2, 2006")
+	}
+	if user.DeleteAt != nil {
+		vm.DeleteOn = user.DeleteAt.UTC().Format("January 2, 2006")
+	}
+	h.loadLockouts(r, &vm, objID, loginID)
 	vm.Title = user.FullName
 	vm.BackURL = r.URL.Query().Get("return")
 	if vm.BackURL == "" {
@@ -497,6 +718,45 @@ func (h *Handler) show(w http.ResponseWriter, r *http.Request) {
 	templates.Render(w, r, "systemusers/show", vm)
 }
 
+// loadLockouts fills in the user's current rate limit state and recent
+// lockouts. Failures are logged and leave the section empty.
+func (h *Handler) loadLockouts(r *http.Request, vm *ShowVM, userID primitive.ObjectID, loginID string) {
+	ctx := r.Context()
+
+	if h.rateLimitStore != nil && loginID != "" {
+		vm.RateLimited = true
+		attempt, err := h.rateLimitStore.GetAttempt(ctx, loginID)
+		if err != nil {
+			h.logger.Warn("failed to load rate limit state", zap.Error(err))
+		} else if attempt != nil {
+			now := time.Now()
+			if attempt.IsLocked(now) {
+				vm.LockedUntil = attempt.LockedUntil.Format("2006-01-02 15:04:05")
+			}
+			_, window, _ := h.rateLimitStore.Limits()
+			if attempt.IsLocked(now) || now.Before(attempt.WindowStart.Add(window)) {
+				vm.FailedAttempts = attempt.AttemptCount
+			}
+		}
+	}
+
+	events, err := h.auditStore.Query(ctx, audit.QueryFilter{
+		UserID:    &userID,
+		EventType: audit.EventLoginLockedOut,
+		Limit:     recentLockouts,
+	})
+	if err != nil {
+		h.logger.Warn("failed to load lockout events", zap.Error(err))
+		return
+	}
+	for _, e := range events {
+		vm.Lockouts = append(vm.Lockouts, LockoutRow{
+			When: e.CreatedAt.Format("2006-01-02 15:04:05"),
+			IP:   e.IP,
+		})
+	}
+}
+
 // EditVM is the view model for editing a user.
 type EditVM struct {
 	viewdata.BaseVM
@@ -510,6 +770,8 @@ type EditVM struct {
 	Status         string
 	IsSelf         bool   // true if editing own account
 	IsEdit         bool   // always true for edit (for template auth field logic)
+	CanSendReset   bool   // true if a password reset link can be emailed
+	DisableOn      string // YYYY-MM-DD, empty if not scheduled
 	Success        string
 	Error          string
 }
@@ -525,7 +787,7 @@ func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userStore.GetByID(r.Context(), objID)
+	user, err := h.getUser(r.Context(), objID)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			http.NotFound(w, r)
@@ -557,6 +819,8 @@ func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
 		Status:         normalize.Status(user.Status),
 		IsSelf:         actor.UserID() == objID,
 		IsEdit:         true,
+		CanSendReset:   h.canSendResetLink(user.AuthMethod, user.Email),
+		DisableOn:      formatDisableOn(user.DisableAt),
 	}
 	vm.Title = "Edit " + user.FullName
 	vm.BackURL = r.URL.Query().Get("return")
@@ -564,8 +828,11 @@ func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
 		vm.BackURL = "/system-users"
 	}
 
-	if r.URL.Query().Get("success") == "1" {
+	switch r.URL.Query().Get("success") {
+	case "1":
 		vm.Success = "User updated successfully"
+	case "reset_sent":
+		vm.Success = "Password reset link sent to " + email
 	}
 	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
 		switch errMsg {
@@ -575,6 +842,10 @@ func (h *Handler) showEdit(w http.ResponseWriter, r *http.Request) {
 			vm.Error = "You cannot delete your own account"
 		case "password_required":
 			vm.Error = "Password is required"
+		case "reset_unavailable":
+			vm.Error = "A reset link can only be sent to password users with an email address"
+		case "reset_failed":
+			vm.Error = "The password reset link could not be sent"
 		default:
 			vm.Error = "An error occurred"
 		}
@@ -614,6 +885,7 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 	role := r.FormValue("role")
 	tempPassword := r.FormValue("temp_password")
 	status := r.FormValue("status")
+	disableOn := r.FormValue("disable_on")
 
 	// Validate role
 	if !models.IsValidRole(role) {
@@ -648,11 +920,51 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		update.PasswordTemp = &tempTrue
 	}
 
-	// Only update status if not editing self
-	if !isSelf && status != "" {
+	// Only update status if not editing self; users are deleted with delete
+	if !isSelf && status != "" && status != "deleted" {
 		update.Status = &status
 	}
 
+	// Get user before update to tell whether the role changes
+	prevRole := ""
+	prev, err := h.getUser(r.Context(), objID)
+	if err == mongo.ErrNoDocuments {
+		http.NotFound(w, r)
+		return
+	}
+	if err == nil {
+		prevRole = prev.Role
+	}
+
+	// Only schedule deactivation if not editing self, and only save the
+	// date when it changes so the user isn't warned about it twice
+	if !isSelf && prev != nil {
+		disableAt, err := parseDisableOn(disableOn, time.Now())
+		if err != nil {
+			vm := EditVM{
+				BaseVM:         viewdata.New(r),
+				ID:             id,
+				FullName:       fullName,
+				LoginID:        loginID,
+				Email:          email,
+				Auth:           authMethod,
+				SelectedRole:   role,
+				AvailableRoles: models.AllRoles(),
+				Status:         status,
+				IsSelf:         isSelf,
+				IsEdit:         true,
+				DisableOn:      disableOn,
+				Error:          disableOnError(err),
+			}
+			vm.BackURL = returnURL
+			templates.Render(w, r, "systemusers/edit", vm)
+			return
+		}
+		if disableOn != formatDisableOn(prev.DisableAt) {
+			update.DisableAt = &disableAt
+		}
+	}
+
 	if err := h.userStore.UpdateFromInput(r.Context(), objID, update); err != nil {
 		h.errLog.Log(r, "failed to update user", err)
 
@@ -668,6 +980,7 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 			Status:         status,
 			IsSelf:         isSelf,
 			IsEdit:         true,
+			DisableOn:      disableOn,
 			Error:          "Failed to update user. Login ID is already in use.",
 		}
 		vm.BackURL = returnURL
@@ -675,8 +988,28 @@ func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	actorID := actor.UserID()
-	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "user_updated", nil)
+	// Record what changed; the password itself is never logged
+	before := userAuditFields(prev)
+	var after map[string]string
+	if updated, err := h.getUser(r.Context(), objID); err != nil {
+		h.logger.Warn("failed to reload user for audit log", zap.Error(err))
+	} else {
+		after = userAuditFields(updated)
+	}
+	if after != nil && update.PasswordHash != nil {
+		after["password"] = "temporary password set"
+	}
+	var changes []audit.Change
+	if before != nil && after != nil {
+		changes = audit.Diff(before, after)
+	}
+	h.auditLogger.UserUpdated(r.Context(), r, actor.UserID(), objID, actor.Role, changes)
+
+	if role != prevRole || update.PasswordHash != nil {
+		if err := h.rotator.Rotate(w, r, objID); err != nil {
+			h.errLog.Log(r, "failed to rotate sessions", err)
+		}
+	}
 
 	http.Redirect(w, r, "/system-users/"+id+"/edit?success=1&return="+returnURL, http.StatusSeeOther)
 }
@@ -704,7 +1037,11 @@ func (h *Handler) disable(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user before update to get their email and name
-	user, err := h.userStore.GetByID(r.Context(), objID)
+	user, err := h.getUser(r.Context(), objID)
+	if err == mongo.ErrNoDocuments {
+		http.NotFound(w, r)
+		return
+	}
 	if err != nil {
 		h.errLog.Log(r, "failed to get user for disable", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -729,18 +1066,25 @@ func (h *Handler) disable(w http.ResponseWriter, r *http.Request) {
 		if settings != nil && settings.NotifyUserOnDisable {
 			userEmail := *user.Email
 			userName := user.FullName
+			userLocale := user.Locale
+			userID := user.ID.Hex()
+			brand := h.mailer.Brand(r.Context())
 			siteName := settings.SiteName
 			if siteName == "" {
 				siteName = "Strata"
 			}
 			go func() {
 				text, html := mailer.AccountDisabledEmail(mailer.AccountDisabledEmailData{
+					Locale:   userLocale,
+					Brand:    brand,
 					AppName:  siteName,
 					UserName: userName,
 				})
 				_ = h.mailer.Send(mailer.Email{
 					To:       userEmail,
-					Subject:  "Your " + siteName + " account has been disabled",
+					Subject:  mailer.T(userLocale, "account_disabled.subject", siteName),
+					Template: "account_disabled",
+					UserID:   userID,
 					TextBody: text,
 					HTMLBody: html,
 				})
@@ -768,7 +1112,11 @@ func (h *Handler) enable(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get user before update to get their email and name
-	user, err := h.userStore.GetByID(r.Context(), objID)
+	user, err := h.getUser(r.Context(), objID)
+	if err == mongo.ErrNoDocuments {
+		http.NotFound(w, r)
+		return
+	}
 	if err != nil {
 		h.errLog.Log(r, "failed to get user for enable", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -793,19 +1141,26 @@ func (h *Handler) enable(w http.ResponseWriter, r *http.Request) {
 		if settings != nil && settings.NotifyUserOnEnable {
 			userEmail := *user.Email
 			userName := user.FullName
+			userLocale := user.Locale
+			userID := user.ID.Hex()
+			brand := h.mailer.Brand(r.Context())
 			siteName := settings.SiteName
 			if siteName == "" {
 				siteName = "Strata"
 			}
 			go func() {
 				text, html := mailer.AccountEnabledEmail(mailer.AccountEnabledEmailData{
+					Locale:   userLocale,
+					Brand:    brand,
 					AppName:  siteName,
 					UserName: userName,
 					LoginURL: "/login",
 				})
 				_ = h.mailer.Send(mailer.Email{
 					To:       userEmail,
-					Subject:  "Your " + siteName + " account has been enabled",
+					Subject:  mailer.T(userLocale, "account_enabled.subject", siteName),
+					Template: "account_enabled",
+					UserID:   userID,
 					TextBody: text,
 					HTMLBody: html,
 				})
@@ -859,10 +1214,15 @@ func (h *Handler) resetPassword(w http.ResponseWriter, r *http.Request) {
 	actorID := actor.UserID()
 	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "password_reset", nil)
 
+	if err := h.rotator.Rotate(w, r, objID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
 	http.Redirect(w, r, "/system-users/"+id+"/edit?success=1", http.StatusSeeOther)
 }
 
-// delete deletes a user.
+// delete deletes a user. The account is kept, so it stays linked to its
+// audit history and can be restored, until it is purged.
 func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 	actor, _ := auth.CurrentUser(r)
 
@@ -884,18 +1244,37 @@ func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := h.userStore.Delete(r.Context(), objID); err != nil {
+	actorID := actor.UserID()
+	if err := h.userStore.SoftDelete(r.Context(), objID, actorID, time.Now()); err != nil {
+		if err == mongo.ErrNoDocuments {
+			http.NotFound(w, r)
+			return
+		}
 		h.errLog.Log(r, "failed to delete user", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	actorID := actor.UserID()
+	// Sign the user out everywhere
+	if err := h.rotator.Rotate(w, r, objID); err != nil {
+		h.errLog.Log(r, "failed to rotate sessions", err)
+	}
+
 	h.auditLogger.LogAdminEvent(r, &actorID, &objID, "user_deleted", nil)
 
 	http.Redirect(w, r, returnURL, http.StatusSeeOther)
 }
 
+// getUser loads a user for the management pages. Deleted users are
+// reported as not found; they are handled from the deleted users page.
+func (h *Handler) getUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	user, err := h.userStore.GetByID(ctx, id)
+	if err == nil && user.Status == "deleted" {
+		return nil, mongo.ErrNoDocuments
+	}
+	return user, err
+}
+
 // formatAuthMethod returns a display string for auth method.
 func formatAuthMethod(method string) string {
 	switch method {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
@@ -542,9 +544,136 @@ func TestDelete_Success(t *testing.T) {
 
 	// Verify user was deleted (soft delete)
 	deletedUser, err := userStore.GetByID(ctx, targetUser.ID)
-	if err == nil && deletedUser != nil {
-		// If user still exists, it should be marked as deleted
-		// The Delete method does soft delete by setting deleted_at
+	if err != nil {
+		t.Fatalf("GetByID() after delete error = %v", err)
+	}
+	if deletedUser.Status != "deleted" || deletedUser.DeletedAt == nil {
+		t.Errorf("after delete user = %+v, want soft deleted", deletedUser)
+	}
+	if deletedUser.DeletedByID == nil || *deletedUser.DeletedByID != adminID {
+		t.Errorf("DeletedByID = %v, want %s", deletedUser.DeletedByID, adminID.Hex())
+	}
+}
+
+func TestRestore_Success(t *testing.T) {
+	h, _, userStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	targetUser, err := userStore.CreateFromInput(ctx, userstore.CreateInput{
+		FullName:   "Restore Me",
+		Email:      "restoreme@example.com",
+		AuthMethod: "trust",
+		Role:       "developer",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := userStore.SoftDelete(ctx, targetUser.ID, primitive.NewObjectID(), time.Now()); err != nil {
+		t.Fatalf("SoftDelete() error = %v", err)
+	}
+
+	sessionUser := &auth.SessionUser{
+		ID:      primitive.NewObjectID().Hex(),
+		Name:    "Admin User",
+		LoginID: "admin@example.com",
+		Role:    "admin",
+	}
+	req := httptest.NewRequest(http.MethodPost, "/system-users/"+targetUser.ID.Hex()+"/restore", nil)
+	req = auth.WithTestUser(req, sessionUser)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", targetUser.ID.Hex())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	h.restore(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	restored, err := userStore.GetByID(ctx, targetUser.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if restored.Status != "active" || restored.DeletedAt != nil {
+		t.Errorf("after restore user = %+v, want active", restored)
+	}
+}
+
+func TestSendResetLink_NotPasswordUser(t *testing.T) {
+	h, _, userStore := newTestHandler(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	targetUser, err := userStore.CreateFromInput(ctx, userstore.CreateInput{
+		FullName:   "Trust User",
+		Email:      "trustuser@example.com",
+		AuthMethod: "trust",
+		Role:       "developer",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	sessionUser := &auth.SessionUser{
+		ID:      primitive.NewObjectID().Hex(),
+		Name:    "Admin User",
+		LoginID: "admin@example.com",
+		Role:    "admin",
+	}
+	req := httptest.NewRequest(http.MethodPost, "/system-users/"+targetUser.ID.Hex()+"/send-reset", nil)
+	req = auth.WithTestUser(req, sessionUser)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", targetUser.ID.Hex())
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	h.sendResetLink(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	want := "/system-users/" + targetUser.ID.Hex() + "/edit?error=reset_unavailable"
+	if got := rec.Header().Get("Location"); got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+}
+
+func TestListFilter_HidesDeleted(t *testing.T) {
+	if got := listFilter("", "", "", "")["status"]; !reflect.DeepEqual(got, bson.M{"$ne": "deleted"}) {
+		t.Errorf("unfiltered status = %v, want deleted users excluded", got)
+	}
+	if got := listFilter("", "disabled", "", "")["status"]; got != "disabled" {
+		t.Errorf("disabled status = %v, want %q", got, "disabled")
+	}
+}
+
+func TestListFilter_NeverLoggedIn(t *testing.T) {
+	filter := listFilter("", "", "", "never")
+	if got, ok := filter["last_login_at"]; !ok || got != nil {
+		t.Errorf("last_login_at = %v, want nil", got)
+	}
+	if _, ok := listFilter("", "", "", "")["last_login_at"]; ok {
+		t.Error("last_login_at should only be filtered for never")
+	}
+}
+
+func TestListSort(t *testing.T) {
+	tests := []struct {
+		sort  string
+		first string
+		order any
+	}{
+		{"", "full_name_ci", 1},
+		{"bogus", "full_name_ci", 1},
+		{"recent_login", "last_login_at", -1},
+		{"oldest_login", "last_login_at", 1},
+	}
+	for _, tt := range tests {
+		got := listSort(tt.sort)
+		if got[0].Key != tt.first || got[0].Value != tt.order {
+			t.Errorf("listSort(%q)[0] = %v, want %s %v", tt.sort, got[0], tt.first, tt.order)
+		}
 	}
 }
 
@@ -0,0 +1,156 @@
+// Package tokenapi provides the API token issuance endpoint.
+//
+// Endpoints:
+//   - POST /api/token - Exchange an API key for a short-lived token (protected with API key)
+//
+// Game servers call this endpoint with their API key and pass the returned
+// token to the game client. The client then calls the state and settings APIs
+// with the token, which only grants access to a single user_id and game.
+package tokenapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
+	"go.uber.org/zap"
+)
+
+// Handler handles API token issuance requests.
+type Handler struct {
+	tokens         *auth.TokenIssuer
+	logger         *zap.Logger
+	keyValidator   auth.APIKeyValidator
+	sigVerifier    *auth.SignatureVerifier
+	trustedProxies []string
+}
+
+// NewHandler creates a new tokenapi handler. If tokens is nil, token issuance
+// is disabled and the endpoint responds with 404 Not Found.
+func NewHandler(tokens *auth.TokenIssuer, logger *zap.Logger) *Handler {
+	return &Handler{
+		tokens: tokens,
+		logger: logger,
+	}
+}
+
+// SetAPIKeyValidator enables authentication with database-managed API keys
+// in addition to the static configured key. Call before building routes.
+func (h *Handler) SetAPIKeyValidator(v auth.APIKeyValidator) {
+	h.keyValidator = v
+}
+
+// SetSignatureVerifier enables HMAC request signing as an alternative to
+// bearer API keys. Call before building routes.
+func (h *Handler) SetSignatureVerifier(v *auth.SignatureVerifier) {
+	h.sigVerifier = v
+}
+
+// SetTrustedProxies sets the reverse proxies (CIDR ranges) whose forwarding
+// headers are believed when checking an API key's IP allowlist.
+// Call before building routes.
+func (h *Handler) SetTrustedProxies(cidrs []string) {
+	h.trustedProxies = cidrs
+}
+
+// tokenRequest is the request body for IssueHandler.
+type tokenRequest struct {
+	UserID string `json:"user_id" openapi:"required,desc=Player the token is scoped to,example=player123"`
+	Game   string `json:"game"    openapi:"required,desc=Game the token is scoped to,example=mygame"`
+}
+
+// tokenResponse is the response body for a successful token request.
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresIn int64     `json:"expires_in" openapi:"desc=Token lifetime in seconds"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    string    `json:"user_id"`
+	Game      string    `json:"game"`
+}
+
+// IssueHandler handles POST /api/token requests.
+//
+// Request body:
+//
+//	{
+//	    "user_id": "player123",
+//	    "game": "mygame"
+//	}
+//
+// Response (200 OK):
+//
+//	{
+//	    "token": "eyJhbGciOi...",
+//	    "token_type": "Bearer",
+//	    "expires_in": 900,
+//	    "expires_at": "2026-01-26T...",
+//	    "user_id": "player123",
+//	    "game": "mygame"
+//	}
+func (h *Handler) IssueHandler(w http.ResponseWriter, r *http.Request) {
+	if h.tokens == nil {
+		writeJSONError(w, r, "Token issuance is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var in tokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeJSONError(w, r, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if in.UserID == "" || in.Game == "" {
+		writeJSONError(w, r, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	// Set by the API key middleware when a database-managed key was used
+	keyID := r.Header.Get(auth.APIKeyIDHeader)
+
+	token, expiresAt, err := h.tokens.Issue(in.UserID, in.Game, keyID)
+	if err != nil {
+		h.logger.Error("failed to issue api token",
+			zap.String("game", in.Game),
+			zap.String("user_id", in.UserID),
+			zap.Error(err),
+		)
+		writeJSONError(w, r, "Failed to issue token", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Debug("api token issued",
+		zap.String("game", in.Game),
+		zap.String("user_id", in.UserID),
+		zap.String("key_id", keyID),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(tokenResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresIn: int64(h.tokens.TTL().Seconds()),
+		ExpiresAt: expiresAt.UTC(),
+		UserID:    in.UserID,
+		Game:      in.Game,
+	})
+}
+
+// writeJSONError writes a JSON error response and logs the error to the ledger.
+// The response carries the request ID, so a client can quote it when
+// reporting the error.
+func writeJSONError(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	// Set error message in ledger context for debugging
+	ledger.SetErrorMessage(r.Context(), msg)
+
+	body := map[string]string{"error": msg}
+	if id := requestid.FromContext(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,87 @@
+package tokenapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"go.uber.org/zap"
+)
+
+func TestHandler_IssueHandler(t *testing.T) {
+	logger := zap.NewNop()
+	tokens := auth.NewTokenIssuer("test-token-secret", 10*time.Minute)
+	h := NewHandler(tokens, logger)
+
+	t.Run("successful issue", func(t *testing.T) {
+		body := []byte(`{"user_id":"player123","game":"testgame"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/token", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.IssueHandler(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("IssueHandler() status = %d, want %d. Body: %s", rec.Code, http.StatusOK, rec.Body.String())
+		}
+
+		var resp tokenResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.ExpiresIn != 600 {
+			t.Errorf("expires_in = %d, want 600", resp.ExpiresIn)
+		}
+		claims, err := tokens.Parse(resp.Token)
+		if err != nil {
+			t.Fatalf("issued token does not parse: %v", err)
+		}
+		if !claims.Allows("player123", "testgame") {
+			t.Errorf("claims = %+v, want player123/testgame", claims)
+		}
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/token", bytes.NewReader([]byte(`{"user_id":"player123"}`)))
+		rec := httptest.NewRecorder()
+
+		h.IssueHandler(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("IssueHandler() status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := NewHandler(nil, logger)
+		req := httptest.NewRequest(http.MethodPost, "/api/token", bytes.NewReader([]byte(`{"user_id":"p","game":"g"}`)))
+		rec := httptest.NewRecorder()
+
+		disabled.IssueHandler(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("IssueHandler() status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestRoutes_RejectsToken(t *testing.T) {
+	logger := zap.NewNop()
+	tokens := auth.NewTokenIssuer("test-token-secret", time.Minute)
+	h := NewHandler(tokens, logger)
+	router := Routes(h, "test-api-key", logger)
+
+	token, _, _ := tokens.Issue("player123", "testgame", "")
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"user_id":"player123","game":"testgame"}`)))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d (tokens must not mint tokens)", rec.Code, http.StatusUnauthorized)
+	}
+}
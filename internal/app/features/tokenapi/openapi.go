@@ -0,0 +1,27 @@
+package tokenapi
+
+import "github.com/dalemusser/stratasave/internal/app/system/openapi"
+
+// Tag is the OpenAPI tag for the token endpoint.
+const Tag = "Tokens"
+
+// DescribeAPI adds the token endpoint to an OpenAPI document whose server
+// URL is a versioned API prefix such as /api/v1.
+func DescribeAPI(doc *openapi.Document) {
+	doc.AddTag(Tag, "Exchange an API key for a short-lived token scoped to one player and game.")
+
+	doc.Post("/token", &openapi.Operation{
+		Tags:        []string{Tag},
+		Summary:     "Issue an API token",
+		Description: "Requires an API key or signed request; tokens cannot be used to request other tokens. Returns 404 when token issuance is not enabled.",
+		OperationID: "issueToken",
+		RequestBody: openapi.JSONBody(doc.Ref("TokenRequest", tokenRequest{})),
+		Responses: map[string]*openapi.Response{
+			"200": openapi.JSONResponse("Issued token", doc.Ref("TokenResponse", tokenResponse{})),
+			"400": doc.ErrorResponse("Invalid JSON or missing required fields"),
+			"401": doc.ErrorResponse("Missing or invalid credentials"),
+			"404": doc.ErrorResponse("Token issuance is not enabled"),
+			"500": doc.ErrorResponse("Failed to issue token"),
+		},
+	})
+}
@@ -0,0 +1,41 @@
+package tokenapi
+
+import (
+	"net/http"
+
+	"github.com/dalemusser/stratasave/internal/app/system/apicors"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/ledger"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Routes returns a router with the token issuance endpoint.
+//
+// When mounted at /api/v1/token (and the unversioned /api/token alias):
+//   - POST /api/v1/token - Issue a short-lived API token
+//
+// Authentication is via API key (static or database-managed) or an HMAC-signed
+// request. API tokens themselves are not accepted here, so a token cannot be
+// used to mint further tokens.
+func Routes(h *Handler, apiKey string, logger *zap.Logger) http.Handler {
+	r := chi.NewRouter()
+
+	// API CORS - permissive for API key auth
+	r.Use(apicors.Middleware())
+
+	// API key authentication (tokens deliberately not accepted)
+	r.Use(auth.APIKeyAuthWithConfig(auth.APIKeyAuthConfig{
+		StaticKey:      apiKey,
+		Validator:      h.keyValidator,
+		Signature:      h.sigVerifier,
+		TrustedProxies: h.trustedProxies,
+		OnReject: func(r *http.Request, reason string) {
+			ledger.SetErrorMessage(r.Context(), reason)
+		},
+	}, logger))
+
+	r.Post("/", h.IssueHandler)
+
+	return r
+}
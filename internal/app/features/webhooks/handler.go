@@ -0,0 +1,626 @@
+// internal/app/features/webhooks/handler.go
+package webhooksfeature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	errorsfeature "github.com/dalemusser/stratasave/internal/app/features/errors"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	webhookstore "github.com/dalemusser/stratasave/internal/app/store/webhooks"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
+	"github.com/dalemusser/waffle/pantry/templates"
+	"github.com/go-chi/chi/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxNameLength is the longest an endpoint name may be.
+const maxNameLength = 100
+
+// maxURLLength is the longest an endpoint URL may be.
+const maxURLLength = 2048
+
+// statuses lists delivery statuses in the order they are shown.
+var statuses = []string{
+	webhookstore.StatusQueued,
+	webhookstore.StatusRetrying,
+	webhookstore.StatusDelivered,
+	webhookstore.StatusFailed,
+}
+
+// Handler handles webhook endpoint management HTTP requests.
+type Handler struct {
+	DB          *mongo.Database
+	Dispatcher  *webhooks.Dispatcher
+	ErrLog      *errorsfeature.ErrorLogger
+	AuditLogger *auditlog.Logger
+	Log         *zap.Logger
+}
+
+// NewHandler creates a new webhooks handler.
+func NewHandler(db *mongo.Database, dispatcher *webhooks.Dispatcher, errLog *errorsfeature.ErrorLogger, auditLogger *auditlog.Logger, logger *zap.Logger) *Handler {
+	return &Handler{
+		DB:          db,
+		Dispatcher:  dispatcher,
+		ErrLog:      errLog,
+		AuditLogger: auditLogger,
+		Log:         logger,
+	}
+}
+
+// ServeList handles GET /webhook-endpoints - list registered endpoints.
+func (h *Handler) ServeList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	endpoints, err := webhookstore.New(h.DB).List(ctx)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load webhooks", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	vms := make([]EndpointVM, len(endpoints))
+	for i, ep := range endpoints {
+		vms[i] = toEndpointVM(ep)
+	}
+
+	templates.Render(w, r, "webhooks/list", ListVM{
+		BaseVM:    viewdata.NewBaseVM(r, h.DB, "Webhooks", "/dashboard"),
+		Endpoints: vms,
+	})
+}
+
+// ServeNew handles GET /webhook-endpoints/new - show the registration form.
+func (h *Handler) ServeNew(w http.ResponseWriter, r *http.Request) {
+	templates.Render(w, r, "webhooks/form", FormVM{
+		BaseVM: viewdata.NewBaseVM(r, h.DB, "Add Webhook", "/webhook-endpoints"),
+		Events: eventOptions(nil),
+		Active: true,
+	})
+}
+
+// HandleCreate handles POST /webhook-endpoints - register an endpoint and
+// show its secret once.
+func (h *Handler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	form := formFromRequest(r)
+	if msg := validateForm(form.Name, form.URL, r.Form["events"]); msg != "" {
+		form.BaseVM = viewdata.NewBaseVM(r, h.DB, "Add Webhook", "/webhook-endpoints")
+		form.Error = msg
+		templates.Render(w, r, "webhooks/form", form)
+		return
+	}
+
+	actor, ok := auth.CurrentUser(r)
+	if !ok {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	actorID := actor.UserID()
+
+	ep, err := webhookstore.New(h.DB).Create(ctx, webhookstore.CreateInput{
+		Name:      form.Name,
+		URL:       form.URL,
+		Events:    r.Form["events"],
+		Active:    form.Active,
+		CreatedBy: actorID,
+	})
+	if errors.Is(err, webhookstore.ErrDuplicateName) {
+		form.BaseVM = viewdata.NewBaseVM(r, h.DB, "Add Webhook", "/webhook-endpoints")
+		form.Error = "A webhook with this name already exists."
+		templates.Render(w, r, "webhooks/form", form)
+		return
+	}
+	if err != nil {
+		h.ErrLog.Log(r, "failed to create webhook", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.Dispatcher.Invalidate()
+
+	h.AuditLogger.LogAdminEvent(r, &actorID, &ep.ID, "webhook_created", map[string]string{
+		"name":   ep.Name,
+		"url":    ep.URL,
+		"events": strings.Join(ep.Events, ", "),
+	})
+	h.Log.Info("webhook created", zap.String("webhook_id", ep.ID.Hex()), zap.String("url", ep.URL))
+
+	templates.Render(w, r, "webhooks/secret", SecretVM{
+		BaseVM:   viewdata.NewBaseVM(r, h.DB, "Webhook Added", "/webhook-endpoints"),
+		Endpoint: toEndpointVM(ep),
+		Secret:   ep.Secret,
+	})
+}
+
+// ServeDetail handles GET /webhook-endpoints/{id} - show an endpoint and
+// its delivery history.
+func (h *Handler) ServeDetail(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Medium())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	status := r.URL.Query().Get("status")
+
+	store := webhookstore.New(h.DB)
+	result, err := store.ListDeliveries(ctx, ep.ID, status, page, 25)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to load webhook deliveries", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	counts, err := store.CountDeliveriesByStatus(ctx, ep.ID)
+	if err != nil {
+		h.ErrLog.Log(r, "failed to count webhook deliveries", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	countVMs := make([]StatusCountVM, len(statuses))
+	for i, s := range statuses {
+		countVMs[i] = StatusCountVM{Status: s, Count: counts[s], StatusClass: getStatusClass(s)}
+	}
+	deliveries := make([]DeliveryVM, len(result.Deliveries))
+	for i, d := range result.Deliveries {
+		deliveries[i] = toDeliveryVM(d)
+	}
+
+	prevPage := result.Page - 1
+	if prevPage < 1 {
+		prevPage = 1
+	}
+	nextPage := result.Page + 1
+	if nextPage > result.TotalPages {
+		nextPage = result.TotalPages
+	}
+
+	var notice string
+	switch r.URL.Query().Get("notice") {
+	case "updated":
+		notice = "Webhook updated."
+	case "test":
+		notice = "Test event queued. It appears in the history below once sent."
+	}
+
+	data := DetailVM{
+		BaseVM:     viewdata.NewBaseVM(r, h.DB, ep.Name, "/webhook-endpoints"),
+		Endpoint:   toEndpointVM(*ep),
+		Notice:     notice,
+		Counts:     countVMs,
+		Deliveries: deliveries,
+		Status:     status,
+		Page:       result.Page,
+		TotalPages: result.TotalPages,
+		TotalCount: result.TotalCount,
+		PrevPage:   prevPage,
+		NextPage:   nextPage,
+	}
+
+	if r.Header.Get("HX-Request") == "true" && r.Header.Get("HX-Target") == "deliveries-table" {
+		templates.RenderSnippet(w, "webhook_deliveries_table", data)
+		return
+	}
+
+	templates.Render(w, r, "webhooks/detail", data)
+}
+
+// ServeEdit handles GET /webhook-endpoints/{id}/edit - show the edit form.
+func (h *Handler) ServeEdit(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	templates.Render(w, r, "webhooks/form", FormVM{
+		BaseVM: viewdata.NewBaseVM(r, h.DB, "Edit Webhook", "/webhook-endpoints/"+ep.ID.Hex()),
+		ID:     ep.ID.Hex(),
+		Name:   ep.Name,
+		URL:    ep.URL,
+		Events: eventOptions(ep.Events),
+		Active: ep.Active,
+	})
+}
+
+// HandleUpdate handles POST /webhook-endpoints/{id}/edit - save changes.
+func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	form := formFromRequest(r)
+	form.ID = ep.ID.Hex()
+	msg := validateForm(form.Name, form.URL, r.Form["events"])
+	if msg == "" {
+		err := webhookstore.New(h.DB).Update(ctx, ep.ID, webhookstore.UpdateInput{
+			Name:   form.Name,
+			URL:    form.URL,
+			Events: r.Form["events"],
+			Active: form.Active,
+		})
+		switch {
+		case errors.Is(err, webhookstore.ErrDuplicateName):
+			msg = "A webhook with this name already exists."
+		case errors.Is(err, webhookstore.ErrNotFound):
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		case err != nil:
+			h.ErrLog.Log(r, "failed to update webhook", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if msg != "" {
+		form.BaseVM = viewdata.NewBaseVM(r, h.DB, "Edit Webhook", "/webhook-endpoints/"+ep.ID.Hex())
+		form.Error = msg
+		templates.Render(w, r, "webhooks/form", form)
+		return
+	}
+	h.Dispatcher.Invalidate()
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	changes := audit.Diff(auditFields(ep.Name, ep.URL, ep.Events, ep.Active),
+		auditFields(form.Name, form.URL, r.Form["events"], form.Active))
+	if len(changes) > 0 {
+		h.AuditLogger.LogAdminChange(r, &actorID, &ep.ID, "webhook_updated", changes)
+	}
+
+	http.Redirect(w, r, "/webhook-endpoints/"+ep.ID.Hex()+"?notice=updated", http.StatusSeeOther)
+}
+
+// HandleRotateSecret handles POST /webhook-endpoints/{id}/rotate-secret -
+// replace the signing secret and show the new one once.
+func (h *Handler) HandleRotateSecret(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	secret, err := webhookstore.New(h.DB).RotateSecret(ctx, ep.ID)
+	if err != nil {
+		if errors.Is(err, webhookstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to rotate webhook secret", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.AuditLogger.LogAdminEvent(r, &actorID, &ep.ID, "webhook_secret_rotated", map[string]string{
+		"name": ep.Name,
+	})
+
+	templates.Render(w, r, "webhooks/secret", SecretVM{
+		BaseVM:   viewdata.NewBaseVM(r, h.DB, "Webhook Secret Rotated", "/webhook-endpoints/"+ep.ID.Hex()),
+		Endpoint: toEndpointVM(*ep),
+		Secret:   secret,
+		Rotated:  true,
+	})
+}
+
+// HandleTest handles POST /webhook-endpoints/{id}/test - queue a ping.
+func (h *Handler) HandleTest(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.Dispatcher.SendTest(ctx, ep.ID); err != nil {
+		h.ErrLog.Log(r, "failed to queue webhook test", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/webhook-endpoints/"+ep.ID.Hex()+"?notice=test")
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleDelete handles POST /webhook-endpoints/{id}/delete - remove an
+// endpoint and its delivery history.
+func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if err := webhookstore.New(h.DB).Delete(ctx, ep.ID); err != nil {
+		if errors.Is(err, webhookstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to delete webhook", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	h.Dispatcher.Invalidate()
+
+	actor, _ := auth.CurrentUser(r)
+	actorID := actor.UserID()
+	h.AuditLogger.LogAdminEvent(r, &actorID, &ep.ID, "webhook_deleted", map[string]string{
+		"name": ep.Name,
+		"url":  ep.URL,
+	})
+	h.Log.Info("webhook deleted", zap.String("webhook_id", ep.ID.Hex()))
+
+	w.Header().Set("HX-Redirect", "/webhook-endpoints")
+	w.WriteHeader(http.StatusOK)
+}
+
+// ServeDelivery handles GET /webhook-endpoints/{id}/deliveries/{deliveryID}
+// - show one delivery with its payload and last response.
+func (h *Handler) ServeDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, del, ok := h.loadDelivery(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	templates.Render(w, r, "webhooks/delivery", DeliveryDetailVM{
+		BaseVM:   viewdata.NewBaseVM(r, h.DB, "Webhook Delivery", "/webhook-endpoints/"+ep.ID.Hex()),
+		Endpoint: toEndpointVM(*ep),
+		Delivery: toDeliveryVM(*del),
+	})
+}
+
+// HandleRetry handles POST /webhook-endpoints/{id}/deliveries/{deliveryID}/retry
+// - requeue a failed delivery.
+func (h *Handler) HandleRetry(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeouts.Short())
+	defer cancel()
+
+	ep, del, ok := h.loadDelivery(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Dispatcher.Retry(ctx, del.ID); err != nil {
+		if errors.Is(err, webhookstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		h.ErrLog.Log(r, "failed to retry webhook delivery", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Log.Info("webhook delivery requeued", zap.String("delivery_id", del.ID.Hex()))
+
+	w.Header().Set("HX-Redirect", "/webhook-endpoints/"+ep.ID.Hex()+"/deliveries/"+del.ID.Hex())
+	w.WriteHeader(http.StatusOK)
+}
+
+// loadEndpoint loads the endpoint named in the URL, writing the error
+// response and returning false if it can't.
+func (h *Handler) loadEndpoint(ctx context.Context, w http.ResponseWriter, r *http.Request) (*webhookstore.Endpoint, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return nil, false
+	}
+	ep, err := webhookstore.New(h.DB).GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, webhookstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return nil, false
+		}
+		h.ErrLog.Log(r, "failed to load webhook", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return ep, true
+}
+
+// loadDelivery loads the endpoint and delivery named in the URL, writing
+// the error response and returning false if it can't.
+func (h *Handler) loadDelivery(ctx context.Context, w http.ResponseWriter, r *http.Request) (*webhookstore.Endpoint, *webhookstore.Delivery, bool) {
+	ep, ok := h.loadEndpoint(ctx, w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return nil, nil, false
+	}
+	del, err := webhookstore.New(h.DB).GetDelivery(ctx, id)
+	if err != nil || del.EndpointID != ep.ID {
+		if err == nil || errors.Is(err, webhookstore.ErrNotFound) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return nil, nil, false
+		}
+		h.ErrLog.Log(r, "failed to load webhook delivery", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return ep, del, true
+}
+
+// formFromRequest reads the endpoint form from a parsed request.
+func formFromRequest(r *http.Request) FormVM {
+	return FormVM{
+		Name:   strings.TrimSpace(r.FormValue("name")),
+		URL:    strings.TrimSpace(r.FormValue("url")),
+		Events: eventOptions(r.Form["events"]),
+		Active: r.FormValue("active") == "on",
+	}
+}
+
+// validateForm checks an endpoint's form values, returning a message
+// saying what is wrong or "" if they are valid.
+func validateForm(name, rawURL string, events []string) string {
+	if name == "" {
+		return "Name is required."
+	}
+	if len([]rune(name)) > maxNameLength {
+		return "The name can be at most 100 characters."
+	}
+	if msg := validateURL(rawURL); msg != "" {
+		return msg
+	}
+	if len(events) == 0 {
+		return "Choose at least one event."
+	}
+	for _, e := range events {
+		if !webhooks.ValidEvent(e) {
+			return "Choose events from the list."
+		}
+	}
+	return ""
+}
+
+// validateURL checks that rawURL is an absolute http or https URL.
+func validateURL(rawURL string) string {
+	if rawURL == "" {
+		return "URL is required."
+	}
+	if len(rawURL) > maxURLLength {
+		return "The URL is too long."
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Enter a full http:// or https:// URL."
+	}
+	if u.User != nil {
+		return "The URL can't contain a username or password."
+	}
+	return ""
+}
+
+// eventOptions returns the event checkboxes with the given events checked.
+func eventOptions(checked []string) []EventOptionVM {
+	opts := make([]EventOptionVM, len(webhooks.Events))
+	for i, e := range webhooks.Events {
+		opts[i] = EventOptionVM{Value: e}
+		for _, c := range checked {
+			if c == e {
+				opts[i].Checked = true
+			}
+		}
+	}
+	return opts
+}
+
+// auditFields returns an endpoint's editable fields for audit.Diff.
+func auditFields(name, rawURL string, events []string, active bool) map[string]string {
+	return map[string]string{
+		"name":   name,
+		"url":    rawURL,
+		"events": strings.Join(events, ", "),
+		"active": strconv.FormatBool(active),
+	}
+}
+
+// toEndpointVM converts a store Endpoint to a view model.
+func toEndpointVM(ep webhookstore.Endpoint) EndpointVM {
+	return EndpointVM{
+		ID:        ep.ID.Hex(),
+		Name:      ep.Name,
+		URL:       ep.URL,
+		Events:    ep.Events,
+		Active:    ep.Active,
+		CreatedAt: ep.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt: ep.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// toDeliveryVM converts a store Delivery to a view model.
+func toDeliveryVM(d webhookstore.Delivery) DeliveryVM {
+	vm := DeliveryVM{
+		ID:           d.ID.Hex(),
+		Event:        d.Event,
+		Status:       d.Status,
+		StatusClass:  getStatusClass(d.Status),
+		Attempts:     d.Attempts,
+		MaxAttempts:  d.MaxAttempts,
+		ResponseCode: d.ResponseCode,
+		ResponseBody: d.ResponseBody,
+		LastError:    d.LastError,
+		Payload:      prettyJSON(d.Payload),
+		CreatedAt:    d.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if !d.JobID.IsZero() {
+		vm.JobID = d.JobID.Hex()
+	}
+	if d.NextAttemptAt != nil {
+		vm.NextAttemptAt = d.NextAttemptAt.Format("2006-01-02 15:04:05")
+	}
+	if d.DeliveredAt != nil {
+		vm.DeliveredAt = d.DeliveredAt.Format("2006-01-02 15:04:05")
+	}
+	return vm
+}
+
+// prettyJSON indents a JSON payload for display, returning it unchanged if
+// it isn't valid JSON.
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+// getStatusClass returns a CSS class based on delivery status.
+func getStatusClass(status string) string {
+	switch status {
+	case webhookstore.StatusQueued:
+		return "bg-yellow-100 text-yellow-800 dark:bg-yellow-900/40 dark:text-yellow-400"
+	case webhookstore.StatusRetrying:
+		return "bg-orange-100 text-orange-800 dark:bg-orange-900/40 dark:text-orange-400"
+	case webhookstore.StatusDelivered:
+		return "bg-green-100 text-green-800 dark:bg-green-900/40 dark:text-green-400"
+	case webhookstore.StatusFailed:
+		return "bg-red-100 text-red-800 dark:bg-red-900/40 dark:text-red-400"
+	default:
+		return "bg-gray-100 text-gray-700 dark:bg-gray-600 dark:text-gray-300"
+	}
+}
@@ -0,0 +1,29 @@
+// internal/app/features/webhooks/routes.go
+package webhooksfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes returns the router for the webhooks feature.
+// Access is restricted to admin role only. Registering an endpoint,
+// rotating its secret, and deleting it need a recent identity confirmation.
+func Routes(h *Handler, sm *auth.SessionManager) chi.Router {
+	r := chi.NewRouter()
+	r.Use(sm.RequireRole("admin"))
+
+	r.Get("/", h.ServeList)
+	r.With(sm.RequireRecentAuth).Get("/new", h.ServeNew)
+	r.With(sm.RequireRecentAuth).Post("/", h.HandleCreate)
+	r.Get("/{id}", h.ServeDetail)
+	r.Get("/{id}/edit", h.ServeEdit)
+	r.Post("/{id}/edit", h.HandleUpdate)
+	r.With(sm.RequireRecentAuth).Post("/{id}/rotate-secret", h.HandleRotateSecret)
+	r.Post("/{id}/test", h.HandleTest)
+	r.With(sm.RequireRecentAuth).Post("/{id}/delete", h.HandleDelete)
+	r.Get("/{id}/deliveries/{deliveryID}", h.ServeDelivery)
+	r.Post("/{id}/deliveries/{deliveryID}/retry", h.HandleRetry)
+
+	return r
+}
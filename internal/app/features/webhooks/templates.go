@@ -0,0 +1,19 @@
+// internal/app/features/webhooks/templates.go
+package webhooksfeature
+
+import (
+	"embed"
+
+	"github.com/dalemusser/waffle/pantry/templates"
+)
+
+//go:embed templates/*.gohtml
+var FS embed.FS
+
+func init() {
+	templates.Register(templates.Set{
+		Name:     "webhooks",
+		FS:       FS,
+		Patterns: []string{"templates/*.gohtml"},
+	})
+}
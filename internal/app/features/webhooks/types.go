@@ -0,0 +1,97 @@
+// internal/app/features/webhooks/types.go
+package webhooksfeature
+
+import (
+	"github.com/dalemusser/stratasave/internal/app/system/viewdata"
+)
+
+// EndpointVM is the view model for a webhook endpoint.
+type EndpointVM struct {
+	ID        string
+	Name      string
+	URL       string
+	Events    []string
+	Active    bool
+	CreatedAt string
+	UpdatedAt string
+}
+
+// EventOptionVM is an event checkbox on the endpoint form.
+type EventOptionVM struct {
+	Value   string
+	Checked bool
+}
+
+// FormVM is the view model for the new and edit endpoint forms.
+type FormVM struct {
+	viewdata.BaseVM
+	ID     string // Empty when registering a new endpoint
+	Name   string
+	URL    string
+	Events []EventOptionVM
+	Active bool
+	Error  string
+}
+
+// SecretVM is the view model for the page that shows a signing secret
+// once, after an endpoint is created or its secret rotated.
+type SecretVM struct {
+	viewdata.BaseVM
+	Endpoint EndpointVM
+	Secret   string
+	Rotated  bool
+}
+
+// ListVM is the view model for the endpoint list page.
+type ListVM struct {
+	viewdata.BaseVM
+	Endpoints []EndpointVM
+}
+
+// StatusCountVM is the number of deliveries in one status.
+type StatusCountVM struct {
+	Status      string
+	Count       int64
+	StatusClass string
+}
+
+// DeliveryVM is the view model for a single delivery.
+type DeliveryVM struct {
+	ID            string
+	Event         string
+	Status        string
+	StatusClass   string
+	Attempts      int
+	MaxAttempts   int
+	ResponseCode  int
+	ResponseBody  string
+	LastError     string
+	Payload       string
+	JobID         string
+	NextAttemptAt string
+	DeliveredAt   string
+	CreatedAt     string
+}
+
+// DetailVM is the view model for the endpoint detail page with its
+// delivery history.
+type DetailVM struct {
+	viewdata.BaseVM
+	Endpoint   EndpointVM
+	Notice     string
+	Counts     []StatusCountVM
+	Deliveries []DeliveryVM
+	Status     string
+	Page       int
+	TotalPages int
+	TotalCount int64
+	PrevPage   int
+	NextPage   int
+}
+
+// DeliveryDetailVM is the view model for the delivery detail page.
+type DeliveryDetailVM struct {
+	viewdata.BaseVM
+	Endpoint EndpointVM
+	Delivery DeliveryVM
+}
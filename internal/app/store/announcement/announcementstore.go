@@ -22,16 +22,39 @@ const (
 
 // Announcement represents a system announcement.
 type Announcement struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	Title       string             `bson:"title"`
-	Content     string             `bson:"content"`
-	Type        Type               `bson:"type"`
-	Dismissible bool               `bson:"dismissible"`
-	Active      bool               `bson:"active"`
-	StartsAt    *time.Time         `bson:"starts_at,omitempty"`
-	EndsAt      *time.Time         `bson:"ends_at,omitempty"`
-	CreatedAt   time.Time          `bson:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at"`
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	Title          string             `bson:"title"`
+	Content        string             `bson:"content"`
+	Type           Type               `bson:"type"`
+	Dismissible    bool               `bson:"dismissible"`
+	RequireAck     bool               `bson:"require_ack,omitempty"` // users must acknowledge it; critical only
+	Active         bool               `bson:"active"`
+	StartsAt       *time.Time         `bson:"starts_at,omitempty"`       // publish at; nil shows it as soon as it's active
+	EndsAt         *time.Time         `bson:"ends_at,omitempty"`         // expire at; nil never expires
+	PublishPending bool               `bson:"publish_pending,omitempty"` // active, waiting for StartsAt to be published
+	EmailUsers     bool               `bson:"email_users,omitempty"`     // email every active user when it's published
+	EmailedAt      *time.Time         `bson:"emailed_at,omitempty"`      // when users were emailed; they are emailed once
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at"`
+}
+
+// Live reports whether the announcement is shown at now: active, started,
+// and not yet expired.
+func (a *Announcement) Live(now time.Time) bool {
+	return a.Active &&
+		(a.StartsAt == nil || !a.StartsAt.After(now)) &&
+		(a.EndsAt == nil || a.EndsAt.After(now))
+}
+
+// Expired reports whether the announcement's end time has passed at now.
+func (a *Announcement) Expired(now time.Time) bool {
+	return a.EndsAt != nil && !a.EndsAt.After(now)
+}
+
+// Pending reports whether an announcement that is active at now would
+// wait for its start time rather than show straight away.
+func Pending(startsAt *time.Time, now time.Time) bool {
+	return startsAt != nil && startsAt.After(now)
 }
 
 // Store provides access to the announcements collection.
@@ -61,6 +84,10 @@ func (s *Store) EnsureIndexes(ctx context.Context) error {
 			Keys:    bson.D{{Key: "ends_at", Value: 1}},
 			Options: options.Index(),
 		},
+		{
+			Keys:    bson.D{{Key: "publish_pending", Value: 1}, {Key: "starts_at", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"publish_pending": true}),
+		},
 	}
 
 	_, err := s.c.Indexes().CreateMany(ctx, indexes)
@@ -73,6 +100,8 @@ type CreateInput struct {
 	Content     string
 	Type        Type
 	Dismissible bool
+	RequireAck  bool
+	EmailUsers  bool
 	Active      bool
 	StartsAt    *time.Time
 	EndsAt      *time.Time
@@ -87,12 +116,15 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (*Announcement, e
 		Content:     input.Content,
 		Type:        input.Type,
 		Dismissible: input.Dismissible,
+		RequireAck:  input.RequireAck,
+		EmailUsers:  input.EmailUsers,
 		Active:      input.Active,
 		StartsAt:    input.StartsAt,
 		EndsAt:      input.EndsAt,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+	ann.PublishPending = ann.Active && Pending(ann.StartsAt, now)
 
 	if _, err := s.c.InsertOne(ctx, ann); err != nil {
 		return nil, err
@@ -116,14 +148,24 @@ type UpdateInput struct {
 	Content     *string
 	Type        *Type
 	Dismissible *bool
+	RequireAck  *bool
+	EmailUsers  *bool
 	Active      *bool
 	StartsAt    *time.Time
 	EndsAt      *time.Time
+	// ClearStartsAt and ClearEndsAt remove the start and end times; they
+	// take precedence over StartsAt and EndsAt.
+	ClearStartsAt bool
+	ClearEndsAt   bool
+	// PublishPending, if set, marks whether the announcement waits for
+	// its start time to be published.
+	PublishPending *bool
 }
 
 // Update updates an announcement.
 func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateInput) error {
 	set := bson.M{"updated_at": time.Now()}
+	unset := bson.M{}
 
 	if input.Title != nil {
 		set["title"] = *input.Title
@@ -137,17 +179,34 @@ func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateI
 	if input.Dismissible != nil {
 		set["dismissible"] = *input.Dismissible
 	}
+	if input.RequireAck != nil {
+		set["require_ack"] = *input.RequireAck
+	}
+	if input.EmailUsers != nil {
+		set["email_users"] = *input.EmailUsers
+	}
 	if input.Active != nil {
 		set["active"] = *input.Active
 	}
-	if input.StartsAt != nil {
+	if input.ClearStartsAt {
+		unset["starts_at"] = ""
+	} else if input.StartsAt != nil {
 		set["starts_at"] = *input.StartsAt
 	}
-	if input.EndsAt != nil {
+	if input.ClearEndsAt {
+		unset["ends_at"] = ""
+	} else if input.EndsAt != nil {
 		set["ends_at"] = *input.EndsAt
 	}
+	if input.PublishPending != nil {
+		set["publish_pending"] = *input.PublishPending
+	}
 
-	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
 	return err
 }
 
@@ -210,13 +269,120 @@ func (s *Store) GetActive(ctx context.Context) ([]Announcement, error) {
 	return announcements, nil
 }
 
-// SetActive sets the active status of an announcement.
+// SetActive sets the active status of an announcement. Activating an
+// announcement whose start time is still ahead leaves it pending publication.
 func (s *Store) SetActive(ctx context.Context, id primitive.ObjectID, active bool) error {
+	now := time.Now()
+	pending := false
+	if active {
+		ann, err := s.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		pending = Pending(ann.StartsAt, now)
+	}
 	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
 		"$set": bson.M{
-			"active":     active,
-			"updated_at": time.Now(),
+			"active":          active,
+			"publish_pending": pending,
+			"updated_at":      now,
 		},
 	})
 	return err
 }
+
+// ListPublishDue returns active announcements pending publication whose
+// start time has arrived by now and that haven't already expired.
+func (s *Store) ListPublishDue(ctx context.Context, now time.Time) ([]Announcement, error) {
+	cursor, err := s.c.Find(ctx, bson.M{
+		"active":          true,
+		"publish_pending": true,
+		"starts_at":       bson.M{"$lte": now},
+		"$or": []bson.M{
+			{"ends_at": nil},
+			{"ends_at": bson.M{"$gt": now}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "starts_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var announcements []Announcement
+	if err := cursor.All(ctx, &announcements); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+// MarkPublished clears the pending flag of an announcement. It reports
+// false if the announcement was no longer pending, so that only one caller
+// publishes it.
+func (s *Store) MarkPublished(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "publish_pending": true},
+		bson.M{"$set": bson.M{"publish_pending": false}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// DeactivateExpired deactivates active announcements whose end time has
+// passed by now, returning how many were deactivated.
+func (s *Store) DeactivateExpired(ctx context.Context, now time.Time) (int64, error) {
+	res, err := s.c.UpdateMany(ctx,
+		bson.M{"active": true, "ends_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{
+			"active":          false,
+			"publish_pending": false,
+			"updated_at":      now,
+		}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// ListEmailDue returns announcements showing at now that are to be emailed
+// to users and haven't been yet.
+func (s *Store) ListEmailDue(ctx context.Context, now time.Time) ([]Announcement, error) {
+	cursor, err := s.c.Find(ctx, bson.M{
+		"active":      true,
+		"email_users": true,
+		"emailed_at":  nil,
+		"$and": []bson.M{
+			{"$or": []bson.M{
+				{"starts_at": nil},
+				{"starts_at": bson.M{"$lte": now}},
+			}},
+			{"$or": []bson.M{
+				{"ends_at": nil},
+				{"ends_at": bson.M{"$gt": now}},
+			}},
+		},
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var announcements []Announcement
+	if err := cursor.All(ctx, &announcements); err != nil {
+		return nil, err
+	}
+	return announcements, nil
+}
+
+// MarkEmailed records that users were emailed an announcement at at. It
+// reports false if they already had been, so that only one caller emails
+// them.
+func (s *Store) MarkEmailed(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "emailed_at": nil},
+		bson.M{"$set": bson.M{"emailed_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
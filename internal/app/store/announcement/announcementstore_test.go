@@ -404,3 +404,162 @@ func TestTypeConstants(t *testing.T) {
 		t.Errorf("TypeCritical = %q, want 'critical'", TypeCritical)
 	}
 }
+
+func TestAnnouncement_Live(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		ann     Announcement
+		live    bool
+		expired bool
+	}{
+		{"active, no schedule", Announcement{Active: true}, true, false},
+		{"inactive", Announcement{Active: false}, false, false},
+		{"started, not ended", Announcement{Active: true, StartsAt: &past, EndsAt: &future}, true, false},
+		{"not started", Announcement{Active: true, StartsAt: &future}, false, false},
+		{"ended", Announcement{Active: true, EndsAt: &past}, false, true},
+		{"ended and inactive", Announcement{EndsAt: &past}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.ann.Live(now); got != tt.live {
+				t.Errorf("Live() = %v, want %v", got, tt.live)
+			}
+			if got := tt.ann.Expired(now); got != tt.expired {
+				t.Errorf("Expired() = %v, want %v", got, tt.expired)
+			}
+		})
+	}
+}
+
+func TestStore_Update_ClearSchedule(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	start := time.Now().Add(time.Hour)
+	end := time.Now().Add(2 * time.Hour)
+	ann, err := store.Create(ctx, CreateInput{
+		Title:    "Scheduled",
+		Type:     TypeInfo,
+		Active:   true,
+		StartsAt: &start,
+		EndsAt:   &end,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !ann.PublishPending {
+		t.Error("Create() with a future start should be pending publication")
+	}
+
+	if err := store.Update(ctx, ann.ID, UpdateInput{ClearStartsAt: true, ClearEndsAt: true}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := store.GetByID(ctx, ann.ID)
+	if got.StartsAt != nil || got.EndsAt != nil {
+		t.Errorf("Update() left schedule %v - %v, want cleared", got.StartsAt, got.EndsAt)
+	}
+}
+
+func TestStore_PublishDue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	start := time.Now().Add(time.Minute)
+	ann, err := store.Create(ctx, CreateInput{Title: "Soon", Type: TypeInfo, Active: true, StartsAt: &start})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, CreateInput{Title: "Now", Type: TypeInfo, Active: true}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	due, err := store.ListPublishDue(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("ListPublishDue() error = %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("ListPublishDue() before the start = %d, want 0", len(due))
+	}
+
+	due, err = store.ListPublishDue(ctx, start.Add(time.Second))
+	if err != nil {
+		t.Fatalf("ListPublishDue() error = %v", err)
+	}
+	if len(due) != 1 || due[0].ID != ann.ID {
+		t.Fatalf("ListPublishDue() after the start = %v, want only %q", due, ann.Title)
+	}
+
+	ok, err := store.MarkPublished(ctx, ann.ID)
+	if err != nil || !ok {
+		t.Fatalf("MarkPublished() = %v, %v, want true", ok, err)
+	}
+	if ok, _ := store.MarkPublished(ctx, ann.ID); ok {
+		t.Error("MarkPublished() twice = true, want false")
+	}
+}
+
+func TestStore_DeactivateExpired(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	expired, _ := store.Create(ctx, CreateInput{Title: "Expired", Type: TypeInfo, Active: true, EndsAt: &past})
+	running, _ := store.Create(ctx, CreateInput{Title: "Running", Type: TypeInfo, Active: true, EndsAt: &future})
+
+	n, err := store.DeactivateExpired(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("DeactivateExpired() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("DeactivateExpired() = %d, want 1", n)
+	}
+	if got, _ := store.GetByID(ctx, expired.ID); got.Active {
+		t.Error("expired announcement still active")
+	}
+	if got, _ := store.GetByID(ctx, running.ID); !got.Active {
+		t.Error("running announcement deactivated")
+	}
+}
+
+func TestStore_EmailDue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	now := time.Now()
+	later := now.Add(time.Hour)
+	live, _ := store.Create(ctx, CreateInput{Title: "Live", Type: TypeInfo, Active: true, EmailUsers: true})
+	store.Create(ctx, CreateInput{Title: "Scheduled", Type: TypeInfo, Active: true, EmailUsers: true, StartsAt: &later})
+	store.Create(ctx, CreateInput{Title: "Not emailed", Type: TypeInfo, Active: true})
+
+	due, err := store.ListEmailDue(ctx, now)
+	if err != nil {
+		t.Fatalf("ListEmailDue() error = %v", err)
+	}
+	if len(due) != 1 || due[0].ID != live.ID {
+		t.Fatalf("ListEmailDue() = %v, want only %q", due, live.Title)
+	}
+
+	ok, err := store.MarkEmailed(ctx, live.ID, now)
+	if err != nil || !ok {
+		t.Fatalf("MarkEmailed() = %v, %v, want true", ok, err)
+	}
+	if ok, _ := store.MarkEmailed(ctx, live.ID, now); ok {
+		t.Error("MarkEmailed() twice = true, want false")
+	}
+	if due, _ := store.ListEmailDue(ctx, now); len(due) != 0 {
+		t.Errorf("ListEmailDue() after MarkEmailed = %d, want 0", len(due))
+	}
+}
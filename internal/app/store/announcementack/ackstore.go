@@ -0,0 +1,104 @@
+// Package announcementack provides storage for users' acknowledgements of
+// announcements that require one.
+//
+// A user acknowledges an announcement at most once; the record keeps when
+// they did, for the announcement's completion report.
+package announcementack
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ack is a user's acknowledgement of an announcement.
+type Ack struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	AnnouncementID primitive.ObjectID `bson:"announcement_id"`
+	UserID         primitive.ObjectID `bson:"user_id"`
+	AcknowledgedAt time.Time          `bson:"acknowledged_at"`
+}
+
+// Store provides access to the announcement_acks collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new announcement acknowledgement store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("announcement_acks")}
+}
+
+// Acknowledge records that a user acknowledged an announcement at at.
+// Acknowledging again keeps the first time.
+func (s *Store) Acknowledge(ctx context.Context, announcementID, userID primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"announcement_id": announcementID, "user_id": userID},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":             primitive.NewObjectID(),
+			"acknowledged_at": at,
+		}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil // acknowledged concurrently
+	}
+	return err
+}
+
+// Acknowledged returns which of the given announcements the user has
+// acknowledged.
+func (s *Store) Acknowledged(ctx context.Context, userID primitive.ObjectID, announcementIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	acked := make(map[primitive.ObjectID]bool)
+	if len(announcementIDs) == 0 {
+		return acked, nil
+	}
+	cur, err := s.c.Find(ctx,
+		bson.M{"user_id": userID, "announcement_id": bson.M{"$in": announcementIDs}},
+		options.Find().SetProjection(bson.M{"announcement_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var a Ack
+		if err := cur.Decode(&a); err != nil {
+			return nil, err
+		}
+		acked[a.AnnouncementID] = true
+	}
+	return acked, cur.Err()
+}
+
+// ListByAnnouncement returns an announcement's acknowledgements, earliest
+// first.
+func (s *Store) ListByAnnouncement(ctx context.Context, announcementID primitive.ObjectID) ([]Ack, error) {
+	cur, err := s.c.Find(ctx, bson.M{"announcement_id": announcementID},
+		options.Find().SetSort(bson.D{{Key: "acknowledged_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var acks []Ack
+	if err := cur.All(ctx, &acks); err != nil {
+		return nil, err
+	}
+	return acks, nil
+}
+
+// DeleteByAnnouncement removes all of an announcement's acknowledgements.
+func (s *Store) DeleteByAnnouncement(ctx context.Context, announcementID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"announcement_id": announcementID})
+	return err
+}
+
+// DeleteByUser removes all of a user's acknowledgements.
+func (s *Store) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,51 @@
+package announcementack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_Acknowledge(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	annID := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	user := primitive.NewObjectID()
+	first := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+	if err := store.Acknowledge(ctx, annID, user, first); err != nil {
+		t.Fatalf("Acknowledge() error = %v", err)
+	}
+	if err := store.Acknowledge(ctx, annID, user, time.Now()); err != nil {
+		t.Fatalf("Acknowledge() again error = %v", err)
+	}
+
+	acks, err := store.ListByAnnouncement(ctx, annID)
+	if err != nil {
+		t.Fatalf("ListByAnnouncement() error = %v", err)
+	}
+	if len(acks) != 1 || !acks[0].AcknowledgedAt.Equal(first) {
+		t.Errorf("ListByAnnouncement() = %v, want one ack at %v", acks, first)
+	}
+
+	acked, err := store.Acknowledged(ctx, user, []primitive.ObjectID{annID, other})
+	if err != nil {
+		t.Fatalf("Acknowledged() error = %v", err)
+	}
+	if !acked[annID] || acked[other] {
+		t.Errorf("Acknowledged() = %v, want only %s", acked, annID.Hex())
+	}
+
+	if err := store.DeleteByUser(ctx, user); err != nil {
+		t.Fatalf("DeleteByUser() error = %v", err)
+	}
+	if acks, _ := store.ListByAnnouncement(ctx, annID); len(acks) != 0 {
+		t.Errorf("ListByAnnouncement() after DeleteByUser = %d, want 0", len(acks))
+	}
+}
@@ -0,0 +1,117 @@
+// Package announcementdismissal provides storage for users' dismissals of
+// announcement banners.
+//
+// Dismissals are kept per user rather than in the browser, so a banner
+// dismissed on one device stays hidden on the others, and admins can see
+// how many users have dismissed each announcement.
+package announcementdismissal
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Dismissal is a user's dismissal of an announcement.
+type Dismissal struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	AnnouncementID primitive.ObjectID `bson:"announcement_id"`
+	UserID         primitive.ObjectID `bson:"user_id"`
+	DismissedAt    time.Time          `bson:"dismissed_at"`
+}
+
+// Store provides access to the announcement_dismissals collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new announcement dismissal store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("announcement_dismissals")}
+}
+
+// Dismiss records that a user dismissed an announcement at at. Dismissing
+// again keeps the first time.
+func (s *Store) Dismiss(ctx context.Context, announcementID, userID primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"announcement_id": announcementID, "user_id": userID},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":          primitive.NewObjectID(),
+			"dismissed_at": at,
+		}},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil // dismissed concurrently
+	}
+	return err
+}
+
+// Dismissed returns which of the given announcements the user has
+// dismissed.
+func (s *Store) Dismissed(ctx context.Context, userID primitive.ObjectID, announcementIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	dismissed := make(map[primitive.ObjectID]bool)
+	if len(announcementIDs) == 0 {
+		return dismissed, nil
+	}
+	cur, err := s.c.Find(ctx,
+		bson.M{"user_id": userID, "announcement_id": bson.M{"$in": announcementIDs}},
+		options.Find().SetProjection(bson.M{"announcement_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var d Dismissal
+		if err := cur.Decode(&d); err != nil {
+			return nil, err
+		}
+		dismissed[d.AnnouncementID] = true
+	}
+	return dismissed, cur.Err()
+}
+
+// CountByAnnouncement returns how many users have dismissed each of the
+// given announcements. Announcements no one has dismissed are left out.
+func (s *Store) CountByAnnouncement(ctx context.Context, announcementIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	counts := make(map[primitive.ObjectID]int)
+	if len(announcementIDs) == 0 {
+		return counts, nil
+	}
+	cur, err := s.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"announcement_id": bson.M{"$in": announcementIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$announcement_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int                `bson:"count"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.ID] = row.Count
+	}
+	return counts, cur.Err()
+}
+
+// DeleteByAnnouncement removes all of an announcement's dismissals.
+func (s *Store) DeleteByAnnouncement(ctx context.Context, announcementID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"announcement_id": announcementID})
+	return err
+}
+
+// DeleteByUser removes all of a user's dismissals.
+func (s *Store) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
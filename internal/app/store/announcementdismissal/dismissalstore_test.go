@@ -0,0 +1,50 @@
+package announcementdismissal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_Dismiss(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	annID := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	alice := primitive.NewObjectID()
+	bob := primitive.NewObjectID()
+
+	for _, user := range []primitive.ObjectID{alice, alice, bob} {
+		if err := store.Dismiss(ctx, annID, user, time.Now()); err != nil {
+			t.Fatalf("Dismiss() error = %v", err)
+		}
+	}
+
+	dismissed, err := store.Dismissed(ctx, alice, []primitive.ObjectID{annID, other})
+	if err != nil {
+		t.Fatalf("Dismissed() error = %v", err)
+	}
+	if !dismissed[annID] || dismissed[other] {
+		t.Errorf("Dismissed() = %v, want only %s", dismissed, annID.Hex())
+	}
+
+	counts, err := store.CountByAnnouncement(ctx, []primitive.ObjectID{annID, other})
+	if err != nil {
+		t.Fatalf("CountByAnnouncement() error = %v", err)
+	}
+	if counts[annID] != 2 || counts[other] != 0 {
+		t.Errorf("CountByAnnouncement() = %v, want 2 for the dismissed announcement", counts)
+	}
+
+	if err := store.DeleteByUser(ctx, alice); err != nil {
+		t.Fatalf("DeleteByUser() error = %v", err)
+	}
+	if counts, _ := store.CountByAnnouncement(ctx, []primitive.ObjectID{annID}); counts[annID] != 1 {
+		t.Errorf("CountByAnnouncement() after DeleteByUser = %d, want 1", counts[annID])
+	}
+}
@@ -24,20 +24,31 @@ type Scope struct {
 
 // APIKey represents an API key record.
 type APIKey struct {
-	ID          primitive.ObjectID `bson:"_id"`
-	KeyHash     string             `bson:"key_hash"`               // bcrypt hash of the key
-	KeyPrefix   string             `bson:"key_prefix"`             // First 8 chars for display
-	Name        string             `bson:"name"`                   // "Production", "Staging"
-	Description string             `bson:"description,omitempty"`  // Optional description
-	CreatedBy   primitive.ObjectID `bson:"created_by"`             // User who created this key
-	Status      string             `bson:"status"`                 // "active", "revoked"
-	Scopes      []Scope            `bson:"scopes,omitempty"`       // Empty = full access
-	LastUsedAt  *time.Time         `bson:"last_used_at,omitempty"` // Last time key was used
-	UsageCount  int64              `bson:"usage_count"`            // Number of times used
-	CreatedAt   time.Time          `bson:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at"`
-	RevokedAt   *time.Time         `bson:"revoked_at,omitempty"` // When key was revoked
-	RevokedBy   primitive.ObjectID `bson:"revoked_by,omitempty"` // User who revoked this key
+	ID           primitive.ObjectID `bson:"_id"`
+	KeyHash      string             `bson:"key_hash"`                // bcrypt hash of the key
+	KeyPrefix    string             `bson:"key_prefix"`              // First 8 chars for display
+	Name         string             `bson:"name"`                    // "Production", "Staging"
+	Description  string             `bson:"description,omitempty"`   // Optional description
+	CreatedBy    primitive.ObjectID `bson:"created_by"`              // User who created this key
+	Status       string             `bson:"status"`                  // "active", "revoked"
+	Scopes       []Scope            `bson:"scopes,omitempty"`        // Empty = full access
+	AllowedCIDRs []string           `bson:"allowed_cidrs,omitempty"` // Empty = any IP
+	ExpiresAt    *time.Time         `bson:"expires_at,omitempty"`    // Nil = never expires
+	LastUsedAt   *time.Time         `bson:"last_used_at,omitempty"`  // Last time key was used
+	UsageCount   int64              `bson:"usage_count"`             // Number of times used
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+	RevokedAt    *time.Time         `bson:"revoked_at,omitempty"` // When key was revoked
+	RevokedBy    primitive.ObjectID `bson:"revoked_by,omitempty"` // User who revoked this key
+
+	// Notification bookkeeping (see apikeyalerts)
+	ExpiryNotifiedAt *time.Time `bson:"expiry_notified_at,omitempty"` // When the expiry warning was sent
+	ErrorAlertedAt   *time.Time `bson:"error_alerted_at,omitempty"`   // When the last error spike alert was sent
+}
+
+// IsExpired reports whether the key has passed its expiry time.
+func (key *APIKey) IsExpired() bool {
+	return key.ExpiresAt != nil && !time.Now().Before(*key.ExpiresAt)
 }
 
 // Status constants for API keys.
@@ -53,6 +64,8 @@ var (
 	ErrInvalidKey = errors.New("invalid api key")
 	// ErrKeyRevoked is returned when attempting to use a revoked key.
 	ErrKeyRevoked = errors.New("api key has been revoked")
+	// ErrKeyExpired is returned when attempting to use a key past its expiry time.
+	ErrKeyExpired = errors.New("api key has expired")
 	// ErrDuplicateName is returned when attempting to create a key with a name that already exists.
 	ErrDuplicateName = errors.New("an api key with this name already exists")
 )
@@ -94,10 +107,12 @@ func hashKey(key string) (string, error) {
 
 // CreateInput holds the fields for creating a new API key.
 type CreateInput struct {
-	Name        string
-	Description string
-	CreatedBy   primitive.ObjectID
-	Scopes      []Scope
+	Name         string
+	Description  string
+	CreatedBy    primitive.ObjectID
+	Scopes       []Scope
+	AllowedCIDRs []string   // Normalized CIDRs (see network.ParseCIDRList)
+	ExpiresAt    *time.Time // Nil = never expires
 }
 
 // CreateResult contains the created key and the full key value.
@@ -122,17 +137,19 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (CreateResult, er
 
 	now := time.Now()
 	key := APIKey{
-		ID:          primitive.NewObjectID(),
-		KeyHash:     keyHash,
-		KeyPrefix:   prefix,
-		Name:        input.Name,
-		Description: input.Description,
-		CreatedBy:   input.CreatedBy,
-		Status:      StatusActive,
-		Scopes:      input.Scopes,
-		UsageCount:  0,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:           primitive.NewObjectID(),
+		KeyHash:      keyHash,
+		KeyPrefix:    prefix,
+		Name:         input.Name,
+		Description:  input.Description,
+		CreatedBy:    input.CreatedBy,
+		Status:       StatusActive,
+		Scopes:       input.Scopes,
+		AllowedCIDRs: input.AllowedCIDRs,
+		ExpiresAt:    input.ExpiresAt,
+		UsageCount:   0,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 
 	if _, err := s.c.InsertOne(ctx, key); err != nil {
@@ -198,6 +215,9 @@ func (s *Store) Validate(ctx context.Context, providedKey string) (*APIKey, erro
 	if matchedKey == nil {
 		return nil, ErrInvalidKey
 	}
+	if matchedKey.IsExpired() {
+		return nil, ErrKeyExpired
+	}
 
 	// Update last_used_at and usage_count
 	now := time.Now()
@@ -318,9 +338,11 @@ func (s *Store) Revoke(ctx context.Context, id primitive.ObjectID, revokedBy pri
 
 // UpdateInput holds fields that can be updated for an API key.
 type UpdateInput struct {
-	Name        *string
-	Description *string
-	Scopes      *[]Scope
+	Name         *string
+	Description  *string
+	Scopes       *[]Scope
+	AllowedCIDRs *[]string
+	ExpiresAt    *time.Time // Zero time clears the expiry
 }
 
 // Update updates an API key's metadata (not the key itself).
@@ -338,8 +360,23 @@ func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateI
 	if input.Scopes != nil {
 		set["scopes"] = *input.Scopes
 	}
+	if input.AllowedCIDRs != nil {
+		set["allowed_cidrs"] = *input.AllowedCIDRs
+	}
 
-	result, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	update := bson.M{"$set": set}
+	if input.ExpiresAt != nil {
+		// A new expiry gets a fresh warning
+		unset := bson.M{"expiry_notified_at": ""}
+		if input.ExpiresAt.IsZero() {
+			unset["expires_at"] = ""
+		} else {
+			set["expires_at"] = *input.ExpiresAt
+		}
+		update["$unset"] = unset
+	}
+
+	result, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
 		if isDuplicateKeyError(err) {
 			return ErrDuplicateName
@@ -364,6 +401,54 @@ func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
 	return nil
 }
 
+// ListExpiringUnnotified returns active keys that expire before the given time
+// and have not yet had an expiry warning sent.
+func (s *Store) ListExpiringUnnotified(ctx context.Context, before time.Time) ([]APIKey, error) {
+	cur, err := s.c.Find(ctx, bson.M{
+		"status":             StatusActive,
+		"expires_at":         bson.M{"$lte": before},
+		"expiry_notified_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var keys []APIKey
+	if err := cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// MarkExpiryNotified records that the expiry warning for a key was sent.
+func (s *Store) MarkExpiryNotified(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"expiry_notified_at": time.Now()},
+	})
+	return err
+}
+
+// MarkErrorAlerted records that an error spike alert for a key was sent.
+// It only succeeds if no alert was sent since the given cutoff, and reports
+// whether this call claimed the alert. This keeps concurrent instances from
+// sending duplicate alerts.
+func (s *Store) MarkErrorAlerted(ctx context.Context, id primitive.ObjectID, cutoff time.Time) (bool, error) {
+	result, err := s.c.UpdateOne(ctx, bson.M{
+		"_id": id,
+		"$or": []bson.M{
+			{"error_alerted_at": bson.M{"$exists": false}},
+			{"error_alerted_at": bson.M{"$lt": cutoff}},
+		},
+	}, bson.M{
+		"$set": bson.M{"error_alerted_at": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
 // CountActive returns the number of active API keys.
 func (s *Store) CountActive(ctx context.Context) (int64, error) {
 	return s.c.CountDocuments(ctx, bson.M{"status": StatusActive})
@@ -0,0 +1,77 @@
+// internal/app/store/apikeys/validator.go
+package apikeystore
+
+import (
+	"context"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Validator implements auth.APIKeyValidator to authenticate API requests
+// against the database-managed keys in the api_keys collection.
+type Validator struct {
+	store  *Store
+	logger *zap.Logger
+}
+
+// NewValidator creates an APIKeyValidator that queries the given database.
+func NewValidator(db *mongo.Database, logger *zap.Logger) *Validator {
+	return &Validator{
+		store:  New(db),
+		logger: logger,
+	}
+}
+
+// ValidateAPIKey checks the provided key and returns nil if it is unknown,
+// revoked, expired, or if any error occurs. This implements auth.APIKeyValidator.
+func (v *Validator) ValidateAPIKey(ctx context.Context, key string) *auth.APIKeyPrincipal {
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Short())
+	defer cancel()
+
+	k, err := v.store.Validate(ctx, key)
+	if err != nil {
+		if err != ErrInvalidKey && err != ErrKeyExpired {
+			v.logger.Warn("api key validation failed", zap.Error(err))
+		}
+		return nil
+	}
+
+	return &auth.APIKeyPrincipal{
+		ID:           k.ID.Hex(),
+		Name:         k.Name,
+		AllowedCIDRs: k.AllowedCIDRs,
+	}
+}
+
+// LookupAPIKey returns the active, unexpired key with the given ID, or nil
+// if there is none or any error occurs. This implements auth.APIKeyValidator.
+func (v *Validator) LookupAPIKey(ctx context.Context, id string) *auth.APIKeyPrincipal {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Short())
+	defer cancel()
+
+	k, err := v.store.GetByID(ctx, oid)
+	if err != nil {
+		if err != ErrNotFound {
+			v.logger.Warn("api key lookup failed", zap.Error(err))
+		}
+		return nil
+	}
+	if k.Status != StatusActive || k.IsExpired() {
+		return nil
+	}
+
+	return &auth.APIKeyPrincipal{
+		ID:           k.ID.Hex(),
+		Name:         k.Name,
+		AllowedCIDRs: k.AllowedCIDRs,
+	}
+}
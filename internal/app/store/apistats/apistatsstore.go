@@ -4,6 +4,7 @@ package apistats
 import (
 	"context"
 	"errors"
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -36,9 +37,14 @@ type Bucket struct {
 	TotalMs        int64              `bson:"total_ms"`        // Sum of response times in ms
 	MinMs          int64              `bson:"min_ms"`          // Minimum response time
 	MaxMs          int64              `bson:"max_ms"`          // Maximum response time
+	Latency        Histogram          `bson:"latency"`         // Requests by response time bin
+	RolledUp       bool               `bson:"rolled_up"`       // Aggregated from finer buckets rather than recorded
 	UpdatedAt      time.Time          `bson:"updated_at"`      // Last update time
 }
 
+// DailyDuration is the bucket duration of daily summaries.
+var DailyDuration = (24 * time.Hour).String()
+
 // AvgMs returns the average response time in milliseconds.
 func (b *Bucket) AvgMs() float64 {
 	if b.Requests == 0 {
@@ -47,6 +53,25 @@ func (b *Bucket) AvgMs() float64 {
 	return float64(b.TotalMs) / float64(b.Requests)
 }
 
+// PercentileMs estimates the q (0-1) percentile response time from the
+// bucket's latency histogram, kept within its min and max. Buckets
+// recorded before histograms were kept return 0.
+func (b *Bucket) PercentileMs(q float64) float64 {
+	p := b.Latency.Percentile(q)
+	if p == 0 {
+		return 0
+	}
+	return math.Min(math.Max(p, float64(b.MinMs)), float64(b.MaxMs))
+}
+
+// ErrorRate returns the error rate as a percentage.
+func (b *Bucket) ErrorRate() float64 {
+	if b.Requests == 0 {
+		return 0
+	}
+	return float64(b.Errors) / float64(b.Requests) * 100
+}
+
 var (
 	// ErrNotFound is returned when no stats are found.
 	ErrNotFound = errors.New("stats not found")
@@ -107,8 +132,9 @@ func (s *Store) Record(ctx context.Context, statType StatType, bucketDuration ti
 	// so we don't include min_ms/max_ms in $setOnInsert (which would conflict).
 	update := bson.M{
 		"$inc": bson.M{
-			"requests": 1,
-			"total_ms": durationMs,
+			"requests":                          1,
+			"total_ms":                          durationMs,
+			"latency." + LatencyBin(durationMs): 1,
 		},
 		"$set": bson.M{
 			"updated_at": now,
@@ -312,43 +338,7 @@ func (s *Store) RollUp(ctx context.Context, statType StatType, startTime, endTim
 
 	// Create aggregated buckets
 	for targetBucket, sourceBuckets := range grouped {
-		var totalRequests, totalErrors, totalMs int64
-		minMs := int64(^uint64(0) >> 1) // Max int64
-		maxMs := int64(0)
-
-		for _, b := range sourceBuckets {
-			totalRequests += b.Requests
-			totalErrors += b.Errors
-			totalMs += b.TotalMs
-			if b.MinMs < minMs {
-				minMs = b.MinMs
-			}
-			if b.MaxMs > maxMs {
-				maxMs = b.MaxMs
-			}
-		}
-
-		// Upsert the aggregated bucket
-		now := time.Now().UTC()
-		opts := options.Update().SetUpsert(true)
-		_, err := s.c.UpdateOne(ctx, bson.M{
-			"bucket":          targetBucket,
-			"stat_type":       statType,
-			"bucket_duration": targetDurationStr,
-		}, bson.M{
-			"$set": bson.M{
-				"requests":   totalRequests,
-				"errors":     totalErrors,
-				"total_ms":   totalMs,
-				"min_ms":     minMs,
-				"max_ms":     maxMs,
-				"updated_at": now,
-			},
-			"$setOnInsert": bson.M{
-				"_id": primitive.NewObjectID(),
-			},
-		}, opts)
-		if err != nil {
+		if err := s.upsertRollUp(ctx, statType, targetBucket, targetDurationStr, sourceBuckets); err != nil {
 			return err
 		}
 	}
@@ -356,6 +346,160 @@ func (s *Store) RollUp(ctx context.Context, statType StatType, startTime, endTim
 	return nil
 }
 
+// upsertRollUp sets the bucket for statType at bucket and duration to the
+// combined totals of sources, marking it as rolled up.
+func (s *Store) upsertRollUp(ctx context.Context, statType StatType, bucket time.Time, duration string, sources []Bucket) error {
+	var totalRequests, totalErrors, totalMs int64
+	minMs := int64(^uint64(0) >> 1) // Max int64
+	maxMs := int64(0)
+	latency := Histogram{}
+
+	for _, b := range sources {
+		totalRequests += b.Requests
+		totalErrors += b.Errors
+		totalMs += b.TotalMs
+		latency.Add(b.Latency)
+		if b.MinMs < minMs {
+			minMs = b.MinMs
+		}
+		if b.MaxMs > maxMs {
+			maxMs = b.MaxMs
+		}
+	}
+
+	now := time.Now().UTC()
+	opts := options.Update().SetUpsert(true)
+	_, err := s.c.UpdateOne(ctx, bson.M{
+		"bucket":          bucket,
+		"stat_type":       statType,
+		"bucket_duration": duration,
+	}, bson.M{
+		"$set": bson.M{
+			"requests":   totalRequests,
+			"errors":     totalErrors,
+			"total_ms":   totalMs,
+			"min_ms":     minMs,
+			"max_ms":     maxMs,
+			"latency":    latency,
+			"rolled_up":  true,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"_id": primitive.NewObjectID(),
+		},
+	}, opts)
+	return err
+}
+
+// OldestIntraday returns the start of the oldest bucket finer than a day
+// that begins before the given time, or ErrNotFound if there is none.
+func (s *Store) OldestIntraday(ctx context.Context, before time.Time) (time.Time, error) {
+	filter := bson.M{
+		"bucket":          bson.M{"$lt": before.UTC()},
+		"bucket_duration": bson.M{"$ne": DailyDuration},
+	}
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "bucket", Value: 1}}).
+		SetProjection(bson.M{"bucket": 1})
+
+	var b Bucket
+	if err := s.c.FindOne(ctx, filter, opts).Decode(&b); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return time.Time{}, ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	return b.Bucket, nil
+}
+
+// CompactDay rolls the buckets finer than a day that start within the day
+// beginning at day into one daily bucket per stat type, then deletes them,
+// so each request in the day is counted once. It returns how many buckets
+// were deleted.
+//
+// Recorded buckets are combined whatever their duration, since the
+// recording resolution may have changed during the day. Buckets that were
+// themselves rolled up duplicate recorded ones and are used only when a
+// stat type has no recorded buckets left, taking the finest duration. A
+// recorded daily bucket is kept in the total.
+func (s *Store) CompactDay(ctx context.Context, day time.Time) (int64, error) {
+	day = TruncateToBucket(day, 24*time.Hour)
+	dayRange := bson.M{"$gte": day, "$lt": day.Add(24 * time.Hour)}
+
+	cur, err := s.c.Find(ctx, bson.M{"bucket": dayRange})
+	if err != nil {
+		return 0, err
+	}
+	var buckets []Bucket
+	if err := cur.All(ctx, &buckets); err != nil {
+		return 0, err
+	}
+
+	type dayBuckets struct {
+		recorded []Bucket
+		rolledUp map[string][]Bucket // By duration
+		daily    *Bucket
+	}
+	byType := make(map[StatType]*dayBuckets)
+	for i := range buckets {
+		b := &buckets[i]
+		d, ok := byType[b.StatType]
+		if !ok {
+			d = &dayBuckets{rolledUp: make(map[string][]Bucket)}
+			byType[b.StatType] = d
+		}
+		switch {
+		case b.BucketDuration == DailyDuration:
+			d.daily = b
+		case b.RolledUp:
+			d.rolledUp[b.BucketDuration] = append(d.rolledUp[b.BucketDuration], *b)
+		default:
+			d.recorded = append(d.recorded, *b)
+		}
+	}
+
+	for statType, d := range byType {
+		sources := d.recorded
+		if len(sources) == 0 {
+			sources = finestRollUp(d.rolledUp)
+		}
+		if len(sources) == 0 {
+			continue // Only a daily bucket
+		}
+		if d.daily != nil && !d.daily.RolledUp {
+			sources = append(sources, *d.daily)
+		}
+		if err := s.upsertRollUp(ctx, statType, day, DailyDuration, sources); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := s.c.DeleteMany(ctx, bson.M{
+		"bucket":          dayRange,
+		"bucket_duration": bson.M{"$ne": DailyDuration},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// finestRollUp returns the rolled-up buckets of the shortest duration.
+func finestRollUp(byDuration map[string][]Bucket) []Bucket {
+	var finest []Bucket
+	var finestDur time.Duration
+	for durStr, buckets := range byDuration {
+		dur, err := time.ParseDuration(durStr)
+		if err != nil {
+			continue
+		}
+		if finest == nil || dur < finestDur {
+			finest, finestDur = buckets, dur
+		}
+	}
+	return finest
+}
+
 // DeleteOlderThan deletes stats older than the cutoff time.
 // If bucketDuration is specified, only deletes that resolution.
 func (s *Store) DeleteOlderThan(ctx context.Context, cutoff time.Time, bucketDuration string) (int64, error) {
@@ -373,6 +517,39 @@ func (s *Store) DeleteOlderThan(ctx context.Context, cutoff time.Time, bucketDur
 	return result.DeletedCount, nil
 }
 
+// LatencyByType merges the latency histograms of all buckets in a time
+// range, for each stat type.
+func (s *Store) LatencyByType(ctx context.Context, startTime, endTime time.Time) (map[StatType]Histogram, error) {
+	filter := bson.M{
+		"bucket": bson.M{
+			"$gte": startTime.UTC(),
+			"$lte": endTime.UTC(),
+		},
+		"latency": bson.M{"$exists": true},
+	}
+	opts := options.Find().SetProjection(bson.M{"stat_type": 1, "latency": 1})
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	byType := make(map[StatType]Histogram)
+	for cur.Next(ctx) {
+		var b Bucket
+		if err := cur.Decode(&b); err != nil {
+			return nil, err
+		}
+		h, ok := byType[b.StatType]
+		if !ok {
+			h = Histogram{}
+			byType[b.StatType] = h
+		}
+		h.Add(b.Latency)
+	}
+	return byType, cur.Err()
+}
+
 // Summary represents a summary of stats for a stat type.
 type Summary struct {
 	StatType       StatType
@@ -0,0 +1,70 @@
+package apistats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+)
+
+func TestStore_CompactDay(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	day := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+	buckets := []Bucket{
+		// Recorded hourly, then at 15 minutes after a resolution change
+		{Bucket: day.Add(time.Hour), BucketDuration: "1h0m0s", StatType: StatTypeSaveState,
+			Requests: 10, Errors: 1, TotalMs: 100, MinMs: 5, MaxMs: 30, Latency: Histogram{"10": 8, "30": 2}},
+		{Bucket: day.Add(5 * time.Hour), BucketDuration: "15m0s", StatType: StatTypeSaveState,
+			Requests: 4, TotalMs: 80, MinMs: 2, MaxMs: 50, Latency: Histogram{"50": 4}},
+		// A manual roll-up of the hourly bucket, which must not be counted again
+		{Bucket: day, BucketDuration: "6h0m0s", StatType: StatTypeSaveState, RolledUp: true,
+			Requests: 10, Errors: 1, TotalMs: 100, MinMs: 5, MaxMs: 30},
+		// The next day is left alone
+		{Bucket: day.Add(25 * time.Hour), BucketDuration: "1h0m0s", StatType: StatTypeSaveState, Requests: 3},
+	}
+	for _, b := range buckets {
+		if _, err := store.c.InsertOne(ctx, b); err != nil {
+			t.Fatalf("insert bucket: %v", err)
+		}
+	}
+
+	deleted, err := store.CompactDay(ctx, day.Add(12*time.Hour))
+	if err != nil {
+		t.Fatalf("CompactDay() error = %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("CompactDay() deleted %d buckets, want 3", deleted)
+	}
+
+	got, err := store.GetRange(ctx, StatTypeSaveState, day, day.Add(48*time.Hour), "")
+	if err != nil {
+		t.Fatalf("GetRange() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetRange() = %d buckets, want the daily summary and the next day's bucket", len(got))
+	}
+	daily := got[0]
+	if daily.BucketDuration != DailyDuration || !daily.Bucket.Equal(day) || !daily.RolledUp {
+		t.Errorf("daily bucket = %+v", daily)
+	}
+	if daily.Requests != 14 || daily.Errors != 1 || daily.TotalMs != 180 || daily.MinMs != 2 || daily.MaxMs != 50 {
+		t.Errorf("daily totals = %d req, %d err, %d ms, %d-%d ms; want 14, 1, 180, 2-50",
+			daily.Requests, daily.Errors, daily.TotalMs, daily.MinMs, daily.MaxMs)
+	}
+	if daily.Latency.Count() != 14 {
+		t.Errorf("daily latency count = %d, want 14", daily.Latency.Count())
+	}
+
+	// Compacting again changes nothing
+	if deleted, err := store.CompactDay(ctx, day); err != nil || deleted != 0 {
+		t.Errorf("second CompactDay() = %d, %v; want 0, nil", deleted, err)
+	}
+	again, _ := store.GetRange(ctx, StatTypeSaveState, day, day, DailyDuration)
+	if len(again) != 1 || again[0].Requests != 14 {
+		t.Errorf("daily bucket after second compaction = %+v", again)
+	}
+}
@@ -0,0 +1,80 @@
+package apistats
+
+import (
+	"sort"
+	"strconv"
+)
+
+// latencyBounds are the upper bounds, in ms, of the latency histogram
+// bins. They grow roughly geometrically so percentiles stay within about
+// a third of the true value at any scale. Slower requests fall in a final
+// overflow bin.
+var latencyBounds = []int64{
+	1, 2, 3, 5, 7, 10, 15, 20, 30, 50, 75, 100, 150, 200, 300, 500,
+	750, 1000, 1500, 2000, 3000, 5000, 7500, 10000, 15000, 30000, 60000,
+}
+
+// overflowBin is the key of the bin for requests slower than the last bound.
+const overflowBin = "inf"
+
+// Histogram counts requests by response time. Keys are the upper bound of
+// each bin in ms, or "inf" for the overflow bin, so histograms from
+// different buckets can be added together and stored as a subdocument
+// that $inc can update.
+type Histogram map[string]int64
+
+// LatencyBin returns the histogram key for a response time in ms.
+func LatencyBin(ms int64) string {
+	i := sort.Search(len(latencyBounds), func(i int) bool { return latencyBounds[i] >= ms })
+	if i == len(latencyBounds) {
+		return overflowBin
+	}
+	return strconv.FormatInt(latencyBounds[i], 10)
+}
+
+// Add adds the counts in other to h.
+func (h Histogram) Add(other Histogram) {
+	for k, n := range other {
+		h[k] += n
+	}
+}
+
+// Count returns the number of requests in h.
+func (h Histogram) Count() int64 {
+	var n int64
+	for _, c := range h {
+		n += c
+	}
+	return n
+}
+
+// Percentile estimates the response time in ms below which q (0-1) of the
+// requests in h fall, interpolating within the bin it lands in. Requests
+// in the overflow bin count as the last bound. It returns 0 for an empty
+// histogram.
+func (h Histogram) Percentile(q float64) float64 {
+	total := h.Count()
+	if total == 0 {
+		return 0
+	}
+	if q < 0 {
+		q = 0
+	}
+	if q > 1 {
+		q = 1
+	}
+
+	rank := q * float64(total)
+	var seen int64
+	lower := int64(0)
+	for _, upper := range latencyBounds {
+		n := h[strconv.FormatInt(upper, 10)]
+		if n > 0 && float64(seen+n) >= rank {
+			frac := (rank - float64(seen)) / float64(n)
+			return float64(lower) + frac*float64(upper-lower)
+		}
+		seen += n
+		lower = upper
+	}
+	return float64(lower)
+}
@@ -0,0 +1,74 @@
+package apistats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLatencyBin(t *testing.T) {
+	tests := []struct {
+		ms   int64
+		want string
+	}{
+		{0, "1"},
+		{1, "1"},
+		{4, "5"},
+		{5, "5"},
+		{101, "150"},
+		{60000, "60000"},
+		{60001, "inf"},
+	}
+	for _, tt := range tests {
+		if got := LatencyBin(tt.ms); got != tt.want {
+			t.Errorf("LatencyBin(%d) = %q, want %q", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestHistogram_Percentile(t *testing.T) {
+	if got := (Histogram{}).Percentile(0.5); got != 0 {
+		t.Errorf("empty Percentile(0.5) = %v, want 0", got)
+	}
+
+	// 90 requests at 10ms or under (bin 7-10), 10 in the 200-300ms bin
+	h := Histogram{}
+	for i := 0; i < 90; i++ {
+		h[LatencyBin(9)]++
+	}
+	for i := 0; i < 10; i++ {
+		h[LatencyBin(250)]++
+	}
+
+	tests := []struct {
+		q        float64
+		min, max float64
+	}{
+		{0.5, 7, 10},
+		{0.9, 10, 10},
+		{0.95, 200, 300},
+		{0.99, 290, 300},
+		{1, 300, 300},
+	}
+	for _, tt := range tests {
+		got := h.Percentile(tt.q)
+		if got < tt.min || got > tt.max {
+			t.Errorf("Percentile(%v) = %v, want between %v and %v", tt.q, got, tt.min, tt.max)
+		}
+	}
+
+	overflow := Histogram{LatencyBin(90000): 3}
+	if got := overflow.Percentile(0.5); got != 60000 {
+		t.Errorf("overflow Percentile(0.5) = %v, want 60000", got)
+	}
+}
+
+func TestHistogram_Add(t *testing.T) {
+	h := Histogram{"5": 2}
+	h.Add(Histogram{"5": 1, "inf": 4})
+	if h["5"] != 3 || h["inf"] != 4 || h.Count() != 7 {
+		t.Errorf("Add() = %v, want 5:3 inf:4", h)
+	}
+	if math.IsNaN(h.Percentile(0.5)) {
+		t.Error("Percentile() is NaN")
+	}
+}
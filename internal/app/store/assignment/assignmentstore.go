@@ -0,0 +1,125 @@
+// Package assignment provides storage for library resources assigned to
+// user groups.
+//
+// An assignment points a group at a library file or folder, with optional
+// instructions and a window during which members see it.
+package assignment
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Resource types.
+const (
+	TypeFile   = "file"
+	TypeFolder = "folder"
+)
+
+// IsValidType reports whether t is a resource type that can be assigned.
+func IsValidType(t string) bool {
+	return t == TypeFile || t == TypeFolder
+}
+
+// Assignment is a library resource assigned to a group.
+type Assignment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	GroupID      primitive.ObjectID `bson:"group_id"`
+	ResourceType string             `bson:"resource_type"` // TypeFile or TypeFolder
+	ResourceID   primitive.ObjectID `bson:"resource_id"`
+	Instructions string             `bson:"instructions,omitempty"`
+	VisibleFrom  *time.Time         `bson:"visible_from,omitempty"`  // Nil: visible from the start
+	VisibleUntil *time.Time         `bson:"visible_until,omitempty"` // Nil: never hidden
+	AssignedByID primitive.ObjectID `bson:"assigned_by_id"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
+
+// Visible reports whether members can see the assignment at now.
+func (a *Assignment) Visible(now time.Time) bool {
+	if a.VisibleFrom != nil && now.Before(*a.VisibleFrom) {
+		return false
+	}
+	return a.VisibleUntil == nil || now.Before(*a.VisibleUntil)
+}
+
+// Store provides access to the group_resource_assignments collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new assignment store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("group_resource_assignments")}
+}
+
+// CreateInput contains the input for assigning a resource to a group.
+type CreateInput struct {
+	GroupID      primitive.ObjectID
+	ResourceType string
+	ResourceID   primitive.ObjectID
+	Instructions string
+	VisibleFrom  *time.Time
+	VisibleUntil *time.Time
+	AssignedByID primitive.ObjectID
+}
+
+// Create assigns a resource to a group.
+func (s *Store) Create(ctx context.Context, input CreateInput) (*Assignment, error) {
+	a := Assignment{
+		ID:           primitive.NewObjectID(),
+		GroupID:      input.GroupID,
+		ResourceType: input.ResourceType,
+		ResourceID:   input.ResourceID,
+		Instructions: input.Instructions,
+		VisibleFrom:  input.VisibleFrom,
+		VisibleUntil: input.VisibleUntil,
+		AssignedByID: input.AssignedByID,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := s.c.InsertOne(ctx, a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetByID retrieves an assignment by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Assignment, error) {
+	var a Assignment
+	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListByGroup returns a group's assignments, newest first.
+func (s *Store) ListByGroup(ctx context.Context, groupID primitive.ObjectID) ([]Assignment, error) {
+	cur, err := s.c.Find(ctx, bson.M{"group_id": groupID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var list []Assignment
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Delete deletes an assignment.
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeleteByGroup deletes all of a group's assignments.
+func (s *Store) DeleteByGroup(ctx context.Context, groupID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"group_id": groupID})
+	return err
+}
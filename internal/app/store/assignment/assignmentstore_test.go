@@ -0,0 +1,76 @@
+package assignment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAssignment_Visible(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	after := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		from, until *time.Time
+		want        bool
+	}{
+		{"always", nil, nil, true},
+		{"started", &before, nil, true},
+		{"not started", &after, nil, false},
+		{"ended", nil, &before, false},
+		{"in window", &before, &after, true},
+		{"ends now", nil, &now, false},
+	}
+	for _, tt := range tests {
+		a := Assignment{VisibleFrom: tt.from, VisibleUntil: tt.until}
+		if got := a.Visible(now); got != tt.want {
+			t.Errorf("%s: Visible() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStore_ListByGroup(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	groupID := primitive.NewObjectID()
+	first, err := store.Create(ctx, CreateInput{GroupID: groupID, ResourceType: TypeFile, ResourceID: primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	second, err := store.Create(ctx, CreateInput{GroupID: groupID, ResourceType: TypeFolder, ResourceID: primitive.NewObjectID(), Instructions: "Read first"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, CreateInput{GroupID: primitive.NewObjectID(), ResourceType: TypeFile, ResourceID: primitive.NewObjectID()}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	list, err := store.ListByGroup(ctx, groupID)
+	if err != nil {
+		t.Fatalf("ListByGroup() error = %v", err)
+	}
+	if len(list) != 2 || list[0].ID != second.ID || list[1].ID != first.ID {
+		t.Errorf("ListByGroup() = %v, want newest first", list)
+	}
+
+	if err := store.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.GetByID(ctx, first.ID); err == nil {
+		t.Error("GetByID() after Delete() should fail")
+	}
+	if err := store.DeleteByGroup(ctx, groupID); err != nil {
+		t.Fatalf("DeleteByGroup() error = %v", err)
+	}
+	if list, _ := store.ListByGroup(ctx, groupID); len(list) != 0 {
+		t.Errorf("ListByGroup() after DeleteByGroup() returned %d", len(list))
+	}
+}
@@ -0,0 +1,148 @@
+// internal/app/store/audit/archive.go
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Archive records a file of audit events moved out of the database under
+// the retention policy.
+type Archive struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Key       string             `bson:"key"` // Storage path of the gzip NDJSON file
+	Count     int                `bson:"count"`
+	From      time.Time          `bson:"from"` // Oldest event archived
+	To        time.Time          `bson:"to"`   // Newest event archived
+	FirstSeq  int64              `bson:"first_seq,omitempty"`
+	LastSeq   int64              `bson:"last_seq,omitempty"` // 0 if only unchained events were archived
+	LastHash  string             `bson:"last_hash,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// archives returns the collection that records archive files.
+func (s *Store) archives() *mongo.Collection {
+	return s.c.Database().Collection("audit_archives")
+}
+
+// ArchivableBatch returns up to limit of the oldest events logged before
+// cutoff. Events stored before the hash chain come first, by time. Chained
+// events follow in sequence order and stop at the first one logged at or
+// after cutoff, so only a prefix of the chain is ever archived and the
+// rest still links to the last archived event.
+func (s *Store) ArchivableBatch(ctx context.Context, cutoff time.Time, limit int64) ([]Event, error) {
+	unchained := bson.M{
+		"seq":        bson.M{"$exists": false},
+		"created_at": bson.M{"$lt": cutoff},
+	}
+	events, err := s.find(ctx, unchained, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(limit))
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+
+	chained, err := s.find(ctx, chainedFilter(), options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range chained {
+		if !e.CreatedAt.Before(cutoff) {
+			return chained[:i], nil
+		}
+	}
+	return chained, nil
+}
+
+// find returns the events matching query.
+func (s *Store) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]Event, error) {
+	cur, err := s.c.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var events []Event
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteByIDs removes the events with the given IDs, returning how many
+// were deleted.
+func (s *Store) DeleteByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res, err := s.c.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// RecordArchive saves a record of an archive file. It must be saved before
+// the archived events are deleted, since verification starts the chain
+// from the last archived event.
+func (s *Store) RecordArchive(ctx context.Context, a Archive) error {
+	if a.ID.IsZero() {
+		a.ID = primitive.NewObjectID()
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	_, err := s.archives().InsertOne(ctx, a)
+	return err
+}
+
+// LastChainArchive returns the archive holding the newest archived chained
+// event, or nil if no chained events have been archived.
+func (s *Store) LastChainArchive(ctx context.Context) (*Archive, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "last_seq", Value: -1}})
+	var a Archive
+	err := s.archives().FindOne(ctx, bson.M{"last_seq": bson.M{"$gt": 0}}, opts).Decode(&a)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ArchiveStats returns how many archive files there are and how many
+// events they hold.
+func (s *Store) ArchiveStats(ctx context.Context) (files int64, events int64, err error) {
+	cur, err := s.archives().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"files":  bson.M{"$sum": 1},
+			"events": bson.M{"$sum": "$count"},
+		}}},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var rows []struct {
+		Files  int64 `bson:"files"`
+		Events int64 `bson:"events"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return 0, 0, err
+	}
+	if len(rows) == 0 {
+		return 0, 0, nil
+	}
+	return rows[0].Files, rows[0].Events, nil
+}
@@ -0,0 +1,250 @@
+// internal/app/store/audit/chain.go
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Audit events form a hash chain: each stored event has a sequence number
+// one past the previous event's and a hash over its own fields and the
+// previous event's hash. Changing a stored event changes its hash, and
+// deleting one leaves a gap in the sequence, so either is found by
+// VerifyChain. Removing the newest events can't be seen from the records
+// alone; comparing the chain head with a copy kept elsewhere (such as the
+// hashes forwarded to a SIEM) catches that. When old events are archived,
+// the last archived event's hash anchors the start of what remains.
+
+// appendAttempts is how many times Append retries when another writer
+// takes the next sequence number first.
+const appendAttempts = 10
+
+// maxChainProblems is the most problems VerifyChain lists. Any more are
+// only counted.
+const maxChainProblems = 100
+
+// ErrChainContention is returned when an event can't be appended because
+// other writers kept taking the next sequence number.
+var ErrChainContention = errors.New("audit chain: too many concurrent writers")
+
+// Chain problem kinds, reported by VerifyChain.
+const (
+	ProblemMissing  = "missing"  // Sequence numbers with no event: events were deleted
+	ProblemModified = "modified" // The event's hash doesn't match its fields
+	ProblemBroken   = "broken"   // prev_hash doesn't match the previous event's hash
+)
+
+// ChainProblem is one inconsistency found in the chain.
+type ChainProblem struct {
+	Kind    string
+	Seq     int64  // The event's sequence number, or the first missing one
+	LastSeq int64  // For missing events, the last missing sequence number
+	EventID string // Empty for missing events
+}
+
+// ChainReport is the result of verifying the chain.
+type ChainReport struct {
+	Checked     int64  // Chained events examined
+	Unchained   int64  // Events stored before the chain was introduced
+	ArchivedSeq int64  // Last sequence number moved to an archive; checking starts after it
+	HeadSeq     int64  // Sequence number of the newest event
+	HeadHash    string // Hash of the newest event
+	Problems    []ChainProblem
+	Omitted     int // Problems found beyond maxChainProblems
+}
+
+// OK reports whether the chain verified with no problems.
+func (r ChainReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// hashInput is the part of an event covered by its hash, in a fixed field
+// order. Details are a map, which encoding/json writes in key order.
+type hashInput struct {
+	Seq           int64             `json:"seq"`
+	PrevHash      string            `json:"prev_hash"`
+	ID            string            `json:"id"`
+	CreatedAt     int64             `json:"created_at"` // Unix milliseconds, the precision MongoDB stores
+	Category      string            `json:"category"`
+	EventType     string            `json:"event_type"`
+	UserID        string            `json:"user_id"`
+	ActorID       string            `json:"actor_id"`
+	IP            string            `json:"ip"`
+	UserAgent     string            `json:"user_agent"`
+	Success       bool              `json:"success"`
+	FailureReason string            `json:"failure_reason"`
+	Details       map[string]string `json:"details"`
+	Changes       []Change          `json:"changes,omitempty"`    // Omitted when empty, so older hashes still match
+	RequestID     string            `json:"request_id,omitempty"` // Likewise
+}
+
+// ChainHash returns the hash of an event's fields, its sequence number,
+// and the previous event's hash, as a hex SHA-256 digest.
+func ChainHash(e Event) string {
+	in := hashInput{
+		Seq:           e.Seq,
+		PrevHash:      e.PrevHash,
+		ID:            e.ID.Hex(),
+		CreatedAt:     e.CreatedAt.UnixMilli(),
+		Category:      e.Category,
+		EventType:     e.EventType,
+		IP:            e.IP,
+		UserAgent:     e.UserAgent,
+		Success:       e.Success,
+		FailureReason: e.FailureReason,
+	}
+	if e.UserID != nil {
+		in.UserID = e.UserID.Hex()
+	}
+	if e.ActorID != nil {
+		in.ActorID = e.ActorID.Hex()
+	}
+	// Empty details aren't stored, so they read back as nil
+	if len(e.Details) > 0 {
+		in.Details = e.Details
+	}
+	in.Changes = e.Changes
+	in.RequestID = e.RequestID
+	b, _ := json.Marshal(in)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Append stores an event at the end of the chain and returns it as stored,
+// with its sequence number and hashes set.
+func (s *Store) Append(ctx context.Context, event Event) (Event, error) {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	// Hash what will be read back
+	event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Millisecond)
+
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		seq, hash, err := s.head(ctx)
+		if err != nil {
+			return event, err
+		}
+		event.Seq = seq + 1
+		event.PrevHash = hash
+		event.Hash = ChainHash(event)
+
+		_, err = s.c.InsertOne(ctx, event)
+		if err == nil {
+			return event, nil
+		}
+		// Another writer took this sequence number; chain onto theirs
+		if !mongo.IsDuplicateKeyError(err) {
+			return event, err
+		}
+	}
+	return event, ErrChainContention
+}
+
+// head returns the sequence number and hash of the newest chained event.
+// If every chained event has been archived it is the last archived one,
+// and zero and "" when there is none.
+func (s *Store) head(ctx context.Context) (int64, string, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "seq", Value: -1}}).
+		SetProjection(bson.M{"seq": 1, "hash": 1})
+	var e Event
+	err := s.c.FindOne(ctx, chainedFilter(), opts).Decode(&e)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		archive, err := s.LastChainArchive(ctx)
+		if err != nil || archive == nil {
+			return 0, "", err
+		}
+		return archive.LastSeq, archive.LastHash, nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("read audit chain head: %w", err)
+	}
+	return e.Seq, e.Hash, nil
+}
+
+// VerifyChain walks the chain in sequence order, recomputing each event's
+// hash and checking it links to the one before. Events moved out by
+// archiving are skipped: the chain is checked from the last archived event.
+func (s *Store) VerifyChain(ctx context.Context) (ChainReport, error) {
+	var report ChainReport
+
+	unchained, err := s.c.CountDocuments(ctx, bson.M{"seq": bson.M{"$exists": false}})
+	if err != nil {
+		return report, err
+	}
+	report.Unchained = unchained
+
+	var prevSeq int64
+	var prevHash string
+	archive, err := s.LastChainArchive(ctx)
+	if err != nil {
+		return report, err
+	}
+	if archive != nil {
+		prevSeq = archive.LastSeq
+		prevHash = archive.LastHash
+		report.ArchivedSeq = archive.LastSeq
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+	cur, err := s.c.Find(ctx, bson.M{"seq": bson.M{"$gt": prevSeq}}, opts)
+	if err != nil {
+		return report, err
+	}
+	defer cur.Close(ctx)
+
+	add := func(p ChainProblem) {
+		if len(report.Problems) < maxChainProblems {
+			report.Problems = append(report.Problems, p)
+		} else {
+			report.Omitted++
+		}
+	}
+
+	for cur.Next(ctx) {
+		var e Event
+		if err := cur.Decode(&e); err != nil {
+			return report, err
+		}
+		report.Checked++
+
+		if e.Seq > prevSeq+1 {
+			add(ChainProblem{Kind: ProblemMissing, Seq: prevSeq + 1, LastSeq: e.Seq - 1})
+		} else if e.PrevHash != prevHash {
+			// After a gap the previous hash is unknown, so only check
+			// links between consecutive events
+			add(ChainProblem{Kind: ProblemBroken, Seq: e.Seq, EventID: e.ID.Hex()})
+		}
+		if ChainHash(e) != e.Hash {
+			add(ChainProblem{Kind: ProblemModified, Seq: e.Seq, EventID: e.ID.Hex()})
+		}
+
+		prevSeq = e.Seq
+		prevHash = e.Hash
+	}
+	if err := cur.Err(); err != nil {
+		return report, err
+	}
+
+	report.HeadSeq = prevSeq
+	report.HeadHash = prevHash
+	return report, nil
+}
+
+// chainedFilter matches events that are part of the chain.
+func chainedFilter() bson.M {
+	return bson.M{"seq": bson.M{"$exists": true}}
+}
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func chainEvent() Event {
+	userID := primitive.NewObjectID()
+	return Event{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Category:  CategoryAuth,
+		EventType: EventLoginSuccess,
+		UserID:    &userID,
+		IP:        "192.168.1.1",
+		Success:   true,
+		Details:   map[string]string{"method": "password", "auth": "email"},
+		Seq:       7,
+		PrevHash:  "abc",
+	}
+}
+
+func TestChainHash_Deterministic(t *testing.T) {
+	e := chainEvent()
+	if ChainHash(e) != ChainHash(e) {
+		t.Fatal("ChainHash() differs for the same event")
+	}
+
+	// Details are hashed in key order, whatever order the map was built in
+	same := e
+	same.Details = map[string]string{"auth": "email", "method": "password"}
+	if ChainHash(same) != ChainHash(e) {
+		t.Error("ChainHash() depends on details insertion order")
+	}
+
+	// Empty and missing details read back the same way from MongoDB
+	a, b := e, e
+	a.Details = nil
+	b.Details = map[string]string{}
+	if ChainHash(a) != ChainHash(b) {
+		t.Error("ChainHash() differs for nil and empty details")
+	}
+
+	// Events without changes hash as they did before changes were recorded
+	d := e
+	d.Changes = []Change{}
+	if ChainHash(d) != ChainHash(e) {
+		t.Error("ChainHash() differs for nil and empty changes")
+	}
+
+	// Sub-millisecond precision isn't stored, so it isn't hashed
+	c := e
+	c.CreatedAt = e.CreatedAt.Add(300 * time.Microsecond)
+	if ChainHash(c) != ChainHash(e) {
+		t.Error("ChainHash() depends on sub-millisecond time")
+	}
+}
+
+func TestChainHash_CoversFields(t *testing.T) {
+	base := chainEvent()
+	want := ChainHash(base)
+
+	actor := primitive.NewObjectID()
+	changes := map[string]func(*Event){
+		"seq":            func(e *Event) { e.Seq++ },
+		"prev_hash":      func(e *Event) { e.PrevHash = "def" },
+		"id":             func(e *Event) { e.ID = primitive.NewObjectID() },
+		"created_at":     func(e *Event) { e.CreatedAt = e.CreatedAt.Add(time.Second) },
+		"category":       func(e *Event) { e.Category = CategoryAdmin },
+		"event_type":     func(e *Event) { e.EventType = EventLogout },
+		"user_id":        func(e *Event) { e.UserID = nil },
+		"actor_id":       func(e *Event) { e.ActorID = &actor },
+		"ip":             func(e *Event) { e.IP = "10.0.0.1" },
+		"user_agent":     func(e *Event) { e.UserAgent = "curl" },
+		"success":        func(e *Event) { e.Success = false },
+		"failure_reason": func(e *Event) { e.FailureReason = "wrong password" },
+		"details":        func(e *Event) { e.Details = map[string]string{"method": "google"} },
+		"changes":        func(e *Event) { e.Changes = []Change{{Field: "role", Before: "user", After: "admin"}} },
+		"request_id":     func(e *Event) { e.RequestID = "req-123" },
+	}
+	for field, change := range changes {
+		e := base
+		e.Details = map[string]string{"method": "password", "auth": "email"}
+		change(&e)
+		if ChainHash(e) == want {
+			t.Errorf("changing %s didn't change the hash", field)
+		}
+	}
+}
+
+func TestStore_Append_Chains(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	first, err := store.Append(ctx, Event{Category: CategoryAuth, EventType: EventLoginSuccess, Success: true})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	second, err := store.Append(ctx, Event{Category: CategoryAuth, EventType: EventLogout, Success: true})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	if first.Seq != 1 || first.PrevHash != "" {
+		t.Errorf("first event seq = %d, prev_hash = %q; want 1, empty", first.Seq, first.PrevHash)
+	}
+	if second.Seq != 2 || second.PrevHash != first.Hash {
+		t.Errorf("second event seq = %d, prev_hash = %q; want 2, %q", second.Seq, second.PrevHash, first.Hash)
+	}
+
+	report, err := store.VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if !report.OK() || report.Checked != 2 || report.HeadSeq != 2 || report.HeadHash != second.Hash {
+		t.Errorf("VerifyChain() = %+v, want 2 intact events ending at %q", report, second.Hash)
+	}
+}
+
+func TestStore_VerifyChain_DetectsTampering(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	var events []Event
+	for i := 0; i < 5; i++ {
+		e, err := store.Append(ctx, Event{Category: CategoryAdmin, EventType: EventUserUpdated, Success: true})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		events = append(events, e)
+	}
+
+	c := db.Collection("audit_logs")
+	if _, err := c.UpdateOne(ctx, bson.M{"_id": events[1].ID}, bson.M{"$set": bson.M{"ip": "10.9.9.9"}}); err != nil {
+		t.Fatalf("UpdateOne() error = %v", err)
+	}
+	if _, err := c.DeleteOne(ctx, bson.M{"_id": events[3].ID}); err != nil {
+		t.Fatalf("DeleteOne() error = %v", err)
+	}
+
+	report, err := store.VerifyChain(ctx)
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+
+	var modified, missing bool
+	for _, p := range report.Problems {
+		switch {
+		case p.Kind == ProblemModified && p.Seq == 2:
+			modified = true
+		case p.Kind == ProblemMissing && p.Seq == 4 && p.LastSeq == 4:
+			missing = true
+		}
+	}
+	if !modified || !missing {
+		t.Errorf("VerifyChain() problems = %+v, want seq 2 modified and seq 4 missing", report.Problems)
+	}
+}
@@ -0,0 +1,56 @@
+// internal/app/store/audit/changes.go
+package audit
+
+import (
+	"sort"
+)
+
+// maxChangeValue is the most characters of a before or after value kept in
+// a Change. Longer values, such as page HTML, are cut short.
+const maxChangeValue = 500
+
+// Change is one field changed by an admin action, with its value before
+// and after. Values are display strings; empty means unset.
+type Change struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before" json:"before"`
+	After  string `bson:"after" json:"after"`
+}
+
+// Diff returns the fields whose values differ between before and after,
+// sorted by field name. A field missing from one side is compared as
+// empty.
+func Diff(before, after map[string]string) []Change {
+	fields := make([]string, 0, len(after))
+	for f := range after {
+		fields = append(fields, f)
+	}
+	for f := range before {
+		if _, ok := after[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []Change
+	for _, f := range fields {
+		if before[f] == after[f] {
+			continue
+		}
+		changes = append(changes, Change{
+			Field:  f,
+			Before: clipChange(before[f]),
+			After:  clipChange(after[f]),
+		})
+	}
+	return changes
+}
+
+// clipChange cuts a value to maxChangeValue characters.
+func clipChange(s string) string {
+	r := []rune(s)
+	if len(r) <= maxChangeValue {
+		return s
+	}
+	return string(r[:maxChangeValue]) + "…"
+}
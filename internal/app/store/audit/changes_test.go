@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]string{"name": "a.pdf", "description": "old", "tags": "x", "quota": "1 MB"}
+	after := map[string]string{"name": "b.pdf", "description": "old", "tags": ""}
+
+	got := Diff(before, after)
+	want := []Change{
+		{Field: "name", Before: "a.pdf", After: "b.pdf"},
+		{Field: "quota", Before: "1 MB", After: ""},
+		{Field: "tags", Before: "x", After: ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Diff() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Diff()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := Diff(after, after); got != nil {
+		t.Errorf("Diff() of equal fields = %+v, want none", got)
+	}
+}
+
+func TestDiff_ClipsLongValues(t *testing.T) {
+	long := strings.Repeat("é", maxChangeValue+10)
+	got := Diff(map[string]string{"footer_html": ""}, map[string]string{"footer_html": long})
+	if len(got) != 1 {
+		t.Fatalf("Diff() = %+v, want one change", got)
+	}
+	if n := len([]rune(got[0].After)); n != maxChangeValue+1 || !strings.HasSuffix(got[0].After, "…") {
+		t.Errorf("After has %d characters, want %d ending in an ellipsis", n, maxChangeValue+1)
+	}
+}
@@ -7,6 +7,8 @@ package audit
 
 import (
 	"context"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,8 +19,9 @@ import (
 
 // Event categories
 const (
-	CategoryAuth  = "auth"
-	CategoryAdmin = "admin"
+	CategoryAuth     = "auth"
+	CategoryAdmin    = "admin"
+	CategorySecurity = "security"
 )
 
 // Auth event types
@@ -31,21 +34,51 @@ const (
 	EventLoginLockedOut           = "login_locked_out"
 	EventLogout                   = "logout"
 	EventPasswordChanged          = "password_changed"
+	EventPasswordExpired          = "password_expired"
 	EventVerificationCodeSent     = "verification_code_sent"
 	EventVerificationCodeResent   = "verification_code_resent"
 	EventVerificationCodeFailed   = "verification_code_failed"
 	EventMagicLinkUsed            = "magic_link_used"
+	EventUserRegistered           = "user_registered"
+	EventCaptchaFailed            = "captcha_failed"
+	EventNewDeviceLogin           = "new_device_login"
+	EventReauthSuccess            = "reauth_success"
+	EventReauthFailed             = "reauth_failed"
+	EventBackupCodesGenerated     = "backup_codes_generated"
+	EventBackupCodeUsed           = "backup_code_used"
+	EventBackupCodeFailed         = "backup_code_failed"
+	EventDataExported             = "data_exported"
+	EventAccountDeletionRequested = "account_deletion_requested"
+	EventAccountDeletionCanceled  = "account_deletion_canceled"
+	EventAuthMethodLinked         = "auth_method_linked"
+	EventAuthMethodUnlinked       = "auth_method_unlinked"
 )
 
 // Admin event types
 const (
-	EventUserCreated     = "user_created"
-	EventUserUpdated     = "user_updated"
-	EventUserDisabled    = "user_disabled"
-	EventUserEnabled     = "user_enabled"
-	EventUserDeleted     = "user_deleted"
-	EventSettingsUpdated = "settings_updated"
-	EventPageUpdated     = "page_updated"
+	EventUserCreated          = "user_created"
+	EventUserUpdated          = "user_updated"
+	EventUserDisabled         = "user_disabled"
+	EventUserEnabled          = "user_enabled"
+	EventUserDeleted          = "user_deleted"
+	EventSettingsUpdated      = "settings_updated"
+	EventPageUpdated          = "page_updated"
+	EventImpersonationStarted = "impersonation_started"
+	EventImpersonationEnded   = "impersonation_ended"
+	EventRegistrationApproved = "registration_approved"
+	EventRegistrationRejected = "registration_rejected"
+	EventRateLimitCleared     = "rate_limit_cleared"
+	EventIPUnblocked          = "ip_unblocked"
+	EventAuditLogExported     = "audit_log_exported"
+)
+
+// Security alert types, raised by suspicious activity detection
+const (
+	EventSuspiciousFailedLogins = "suspicious_failed_logins"
+	EventImpossibleTravel       = "impossible_travel"
+	EventIPBlocked              = "ip_blocked"
+	EventLoginBlockedIP         = "login_blocked_ip"
+	EventAPIKeyErrorSpike       = "api_key_error_spike"
 )
 
 // Event represents an audit event.
@@ -64,6 +97,7 @@ type Event struct {
 	// Context
 	IP        string `bson:"ip"`
 	UserAgent string `bson:"user_agent,omitempty"`
+	RequestID string `bson:"request_id,omitempty"` // Matches the request's ledger entry and log lines
 
 	// Outcome
 	Success       bool   `bson:"success"`
@@ -71,6 +105,14 @@ type Event struct {
 
 	// Additional details (varies by event type)
 	Details map[string]string `bson:"details,omitempty"`
+
+	// Fields an admin action changed (see changes.go)
+	Changes []Change `bson:"changes,omitempty"`
+
+	// Hash chain (see chain.go); absent on events stored before the chain
+	Seq      int64  `bson:"seq,omitempty"`
+	PrevHash string `bson:"prev_hash,omitempty"`
+	Hash     string `bson:"hash,omitempty"`
 }
 
 // QueryFilter defines filters for querying audit events.
@@ -78,7 +120,7 @@ type QueryFilter struct {
 	UserID    *primitive.ObjectID
 	ActorID   *primitive.ObjectID
 	Category  string
-	EventType string
+	EventType string // A trailing "*" matches any event type with that prefix, e.g. "login_failed_*"
 	StartTime *time.Time
 	EndTime   *time.Time
 	Limit     int64
@@ -123,51 +165,26 @@ func (s *Store) EnsureIndexes(ctx context.Context) error {
 			Keys:    bson.D{{Key: "created_at", Value: -1}},
 			Options: options.Index().SetName("idx_audit_created"),
 		},
+		// Hash chain order; unique so concurrent writers can't share a link
+		{
+			Keys: bson.D{{Key: "seq", Value: 1}},
+			Options: options.Index().SetName("uniq_audit_seq").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+		},
 	}
 	_, err := s.c.Indexes().CreateMany(ctx, indexes)
 	return err
 }
 
-// Log records an audit event.
+// Log records an audit event at the end of the hash chain.
 func (s *Store) Log(ctx context.Context, event Event) error {
-	if event.ID.IsZero() {
-		event.ID = primitive.NewObjectID()
-	}
-	if event.CreatedAt.IsZero() {
-		event.CreatedAt = time.Now()
-	}
-	_, err := s.c.InsertOne(ctx, event)
+	_, err := s.Append(ctx, event)
 	return err
 }
 
 // Query retrieves audit events matching the given filter.
 func (s *Store) Query(ctx context.Context, filter QueryFilter) ([]Event, error) {
-	query := bson.M{}
-
-	if filter.UserID != nil {
-		query["user_id"] = filter.UserID
-	}
-	if filter.ActorID != nil {
-		query["actor_id"] = filter.ActorID
-	}
-	if filter.Category != "" {
-		query["category"] = filter.Category
-	}
-	if filter.EventType != "" {
-		query["event_type"] = filter.EventType
-	}
-
-	// Time range
-	if filter.StartTime != nil || filter.EndTime != nil {
-		timeQuery := bson.M{}
-		if filter.StartTime != nil {
-			timeQuery["$gte"] = *filter.StartTime
-		}
-		if filter.EndTime != nil {
-			timeQuery["$lte"] = *filter.EndTime
-		}
-		query["created_at"] = timeQuery
-	}
+	query := filterQuery(filter)
 
 	// Set defaults
 	limit := filter.Limit
@@ -195,6 +212,34 @@ func (s *Store) Query(ctx context.Context, filter QueryFilter) ([]Event, error)
 
 // CountByFilter returns the count of events matching the filter.
 func (s *Store) CountByFilter(ctx context.Context, filter QueryFilter) (int64, error) {
+	return s.c.CountDocuments(ctx, filterQuery(filter))
+}
+
+// Each calls fn for every event matching the filter, newest first, reading
+// them from the database one at a time. Limit and Offset are ignored. It
+// stops at the first error fn returns.
+func (s *Store) Each(ctx context.Context, filter QueryFilter, fn func(Event) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := s.c.Find(ctx, filterQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var e Event
+		if err := cur.Decode(&e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+// filterQuery returns the MongoDB query for a filter's conditions.
+func filterQuery(filter QueryFilter) bson.M {
 	query := bson.M{}
 
 	if filter.UserID != nil {
@@ -206,10 +251,13 @@ func (s *Store) CountByFilter(ctx context.Context, filter QueryFilter) (int64, e
 	if filter.Category != "" {
 		query["category"] = filter.Category
 	}
-	if filter.EventType != "" {
+	if prefix, ok := strings.CutSuffix(filter.EventType, "*"); ok {
+		query["event_type"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	} else if filter.EventType != "" {
 		query["event_type"] = filter.EventType
 	}
 
+	// Time range
 	if filter.StartTime != nil || filter.EndTime != nil {
 		timeQuery := bson.M{}
 		if filter.StartTime != nil {
@@ -221,7 +269,7 @@ func (s *Store) CountByFilter(ctx context.Context, filter QueryFilter) (int64, e
 		query["created_at"] = timeQuery
 	}
 
-	return s.c.CountDocuments(ctx, query)
+	return query
 }
 
 // GetByUser retrieves recent audit events for a specific user.
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -438,3 +439,20 @@ func TestConstants(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterQuery_EventType(t *testing.T) {
+	if got := filterQuery(QueryFilter{EventType: EventLogout})["event_type"]; got != EventLogout {
+		t.Errorf("exact event type = %v, want %q", got, EventLogout)
+	}
+
+	got, ok := filterQuery(QueryFilter{EventType: "login_failed_*"})["event_type"].(bson.M)
+	if !ok || got["$regex"] != "^login_failed_" {
+		t.Errorf("wildcard event type = %v, want prefix regex ^login_failed_", got)
+	}
+
+	// Regex characters in the prefix match literally
+	got, _ = filterQuery(QueryFilter{EventType: "a.b*"})["event_type"].(bson.M)
+	if got["$regex"] != `^a\.b` {
+		t.Errorf("wildcard event type = %v, want escaped prefix", got)
+	}
+}
@@ -0,0 +1,135 @@
+// internal/app/store/backupcodes/backupcodestore.go
+package backupcodes
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Count is how many codes a user gets each time they generate a set.
+const Count = 10
+
+// alphabet leaves out characters that are easily confused (0/o, 1/l/i).
+const alphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// codeLength is the number of characters in a code, shown as two groups of
+// four ("abcd-efgh").
+const codeLength = 8
+
+// Code is a one-time backup code. Only a hash of the code is stored, so a
+// set can be shown to the user once and never again.
+type Code struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	CodeHash  string             `bson:"code_hash"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Store provides access to the backup_codes collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new backup code store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("backup_codes")}
+}
+
+// Generate replaces the user's backup codes with a fresh set and returns
+// the plaintext codes. Any codes from an earlier set stop working.
+func (s *Store) Generate(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	codes := make([]string, Count)
+	docs := make([]interface{}, Count)
+	now := time.Now().UTC()
+	for i := range codes {
+		code, err := generateCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		docs[i] = Code{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			CodeHash:  Hash(code),
+			CreatedAt: now,
+		}
+	}
+
+	if _, err := s.c.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		return nil, err
+	}
+	if _, err := s.c.InsertMany(ctx, docs); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Redeem marks an unused code as used and reports whether it matched.
+// Each code works once; concurrent attempts with the same code can't both
+// succeed.
+func (s *Store) Redeem(ctx context.Context, userID primitive.ObjectID, code string) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"user_id": userID, "code_hash": Hash(code), "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// Remaining returns how many unused codes the user has.
+func (s *Store) Remaining(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.c.CountDocuments(ctx, bson.M{"user_id": userID, "used_at": nil})
+}
+
+// DeleteForUser removes all of the user's codes.
+func (s *Store) DeleteForUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// Hash returns the stored form of a code. Codes are compared without case,
+// spaces, or the separating hyphen, so "ABCD EFGH" matches "abcd-efgh".
+func Hash(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateCode returns a random code formatted as "abcd-efgh".
+func generateCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(alphabet)))
+	for i := 0; i < codeLength; i++ {
+		if i == codeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(alphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
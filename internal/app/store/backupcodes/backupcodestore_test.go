@@ -0,0 +1,77 @@
+package backupcodes
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHash_Normalizes(t *testing.T) {
+	want := Hash("abcd-efgh")
+	for _, in := range []string{"ABCD-EFGH", "abcdefgh", " abcd efgh ", "AbCd-EfGh"} {
+		if got := Hash(in); got != want {
+			t.Errorf("Hash(%q) differs from Hash(%q)", in, "abcd-efgh")
+		}
+	}
+	if Hash("abcd-efgj") == want {
+		t.Error("different codes should not hash the same")
+	}
+}
+
+func TestGenerateCode_Format(t *testing.T) {
+	pattern := regexp.MustCompile(`^[` + alphabet + `]{4}-[` + alphabet + `]{4}$`)
+	for i := 0; i < 20; i++ {
+		code, err := generateCode()
+		if err != nil {
+			t.Fatalf("generateCode() error = %v", err)
+		}
+		if !pattern.MatchString(code) {
+			t.Errorf("generateCode() = %q, want xxxx-xxxx from the code alphabet", code)
+		}
+	}
+}
+
+func TestStore_GenerateAndRedeem(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID := primitive.NewObjectID()
+
+	codes, err := store.Generate(ctx, userID)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(codes) != Count {
+		t.Fatalf("Generate() returned %d codes, want %d", len(codes), Count)
+	}
+
+	ok, err := store.Redeem(ctx, userID, strings.ToUpper(codes[0]))
+	if err != nil || !ok {
+		t.Fatalf("Redeem() = %v, %v; want true, nil", ok, err)
+	}
+	if ok, _ := store.Redeem(ctx, userID, codes[0]); ok {
+		t.Error("a code should only be redeemable once")
+	}
+	if ok, _ := store.Redeem(ctx, primitive.NewObjectID(), codes[1]); ok {
+		t.Error("a code should not work for another user")
+	}
+	if n, _ := store.Remaining(ctx, userID); n != Count-1 {
+		t.Errorf("Remaining() = %d, want %d", n, Count-1)
+	}
+
+	// A new set replaces the old one
+	if _, err := store.Generate(ctx, userID); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if ok, _ := store.Redeem(ctx, userID, codes[1]); ok {
+		t.Error("codes from an earlier set should stop working")
+	}
+	if n, _ := store.Remaining(ctx, userID); n != Count {
+		t.Errorf("Remaining() after regenerate = %d, want %d", n, Count)
+	}
+}
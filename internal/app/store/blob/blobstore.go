@@ -0,0 +1,99 @@
+// Package blob provides storage for the reference counts of library file
+// content.
+//
+// Library files with identical content share one stored object. Each
+// object is recorded here under its SHA-256 with the number of files
+// using it; the object is deleted when the last of them is purged. Files
+// stored before deduplication, or whose content isn't recorded here, own
+// their object outright.
+package blob
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Blob is stored content shared by one or more library files.
+type Blob struct {
+	Hash        string    `bson:"_id"` // Hex SHA-256 of the content
+	StoragePath string    `bson:"storage_path"`
+	Size        int64     `bson:"size"`
+	Refs        int       `bson:"refs"` // Files using the content
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// Store provides access to the file_blobs collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new blob store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("file_blobs")}
+}
+
+// Acquire adds a reference to the stored content with the given hash. It
+// returns mongo.ErrNoDocuments if no such content is stored, or if its
+// last reference is being released.
+func (s *Store) Acquire(ctx context.Context, hash string) (*Blob, error) {
+	return s.addRef(ctx, bson.M{"_id": hash})
+}
+
+// AcquirePath adds a reference to the content stored at path, for a copy
+// of a file. It returns mongo.ErrNoDocuments if the content isn't shared.
+func (s *Store) AcquirePath(ctx context.Context, path string) (*Blob, error) {
+	return s.addRef(ctx, bson.M{"storage_path": path})
+}
+
+func (s *Store) addRef(ctx context.Context, filter bson.M) (*Blob, error) {
+	// Content whose count has reached zero is about to be deleted
+	filter["refs"] = bson.M{"$gt": 0}
+	var b Blob
+	err := s.c.FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"refs": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Create records newly stored content with one reference. It returns a
+// duplicate key error if content with the same hash was recorded first.
+func (s *Store) Create(ctx context.Context, hash, path string, size int64) error {
+	_, err := s.c.InsertOne(ctx, Blob{
+		Hash:        hash,
+		StoragePath: path,
+		Size:        size,
+		Refs:        1,
+		CreatedAt:   time.Now().UTC(),
+	})
+	return err
+}
+
+// Release removes a reference to the content stored at path and returns
+// how many remain. At zero the record is deleted and the caller deletes
+// the object. It returns mongo.ErrNoDocuments if the content isn't
+// shared, in which case the caller owns the object.
+func (s *Store) Release(ctx context.Context, path string) (int, error) {
+	var b Blob
+	err := s.c.FindOneAndUpdate(ctx,
+		bson.M{"storage_path": path, "refs": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"refs": -1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&b)
+	if err != nil {
+		return 0, err
+	}
+	if b.Refs <= 0 {
+		if _, err := s.c.DeleteOne(ctx, bson.M{"_id": b.Hash, "refs": bson.M{"$lte": 0}}); err != nil {
+			return 0, err
+		}
+	}
+	return b.Refs, nil
+}
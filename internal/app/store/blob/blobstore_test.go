@@ -0,0 +1,51 @@
+package blob
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_RefCounting(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	const hash = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	if _, err := store.Acquire(ctx, hash); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Fatalf("Acquire() before Create error = %v, want ErrNoDocuments", err)
+	}
+	if err := store.Create(ctx, hash, "files/2026/10/a.txt", 4); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := store.Create(ctx, hash, "files/2026/10/b.txt", 4); !mongo.IsDuplicateKeyError(err) {
+		t.Errorf("second Create() error = %v, want duplicate key", err)
+	}
+
+	b, err := store.Acquire(ctx, hash)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if b.StoragePath != "files/2026/10/a.txt" || b.Refs != 2 {
+		t.Errorf("Acquire() = %+v, want path a.txt with 2 refs", b)
+	}
+	if _, err := store.AcquirePath(ctx, "files/2026/10/a.txt"); err != nil {
+		t.Fatalf("AcquirePath() error = %v", err)
+	}
+
+	for want := 2; want >= 0; want-- {
+		n, err := store.Release(ctx, "files/2026/10/a.txt")
+		if err != nil || n != want {
+			t.Fatalf("Release() = %d, %v; want %d, nil", n, err, want)
+		}
+	}
+	if _, err := store.Release(ctx, "files/2026/10/a.txt"); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("Release() after last ref error = %v, want ErrNoDocuments", err)
+	}
+	if _, err := store.Acquire(ctx, hash); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("Acquire() after last ref error = %v, want ErrNoDocuments", err)
+	}
+}
@@ -0,0 +1,153 @@
+// internal/app/store/devices/devicestore.go
+package devicestore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Device is a browser a user has logged in from. A device is identified by
+// its user agent and the network it connects from, so a laptop moving
+// between home and office counts as two devices.
+type Device struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	Fingerprint string             `bson:"fingerprint"` // sha256 of user agent + IP range
+	UserAgent   string             `bson:"user_agent"`
+	IPRange     string             `bson:"ip_range"` // /24 for IPv4, /48 for IPv6
+	FirstSeen   time.Time          `bson:"first_seen"`
+	LastSeen    time.Time          `bson:"last_seen"`
+	Name        string             `bson:"name,omitempty"` // Set by the user, e.g. "Work laptop"
+}
+
+// MaxNameLength is the longest device name, in characters.
+const MaxNameLength = 50
+
+// Store tracks the devices each user has logged in from.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new known device store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("known_devices")}
+}
+
+// IPRange returns the network ip belongs to: the /24 for IPv4 and the /48
+// for IPv6. Addresses that don't parse are returned unchanged.
+func IPRange(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// Fingerprint identifies a device by its user agent and IP range.
+func Fingerprint(userAgent, ipRange string) string {
+	sum := sha256.Sum256([]byte(userAgent + "|" + ipRange))
+	return hex.EncodeToString(sum[:])
+}
+
+// Touch records a login by userID from the given user agent and IP. It
+// reports whether the device was new, and whether it is the first device
+// recorded for the user.
+func (s *Store) Touch(ctx context.Context, userID primitive.ObjectID, userAgent, ip string) (isNew, first bool, err error) {
+	now := time.Now().UTC()
+	ipRange := IPRange(ip)
+	fp := Fingerprint(userAgent, ipRange)
+
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"user_id": userID, "fingerprint": fp},
+		bson.M{
+			"$set": bson.M{"last_seen": now},
+			"$setOnInsert": bson.M{
+				"user_agent": userAgent,
+				"ip_range":   ipRange,
+				"first_seen": now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, false, err
+	}
+	if res.UpsertedCount == 0 {
+		return false, false, nil
+	}
+
+	n, err := s.c.CountDocuments(ctx, bson.M{"user_id": userID}, options.Count().SetLimit(2))
+	if err != nil {
+		return true, false, err
+	}
+	return true, n == 1, nil
+}
+
+// SetName names the device userID uses with the given user agent and IP.
+// The device is recorded if it isn't known yet. An empty name clears it.
+func (s *Store) SetName(ctx context.Context, userID primitive.ObjectID, userAgent, ip, name string) error {
+	now := time.Now().UTC()
+	ipRange := IPRange(ip)
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"user_agent": userAgent,
+			"ip_range":   ipRange,
+			"first_seen": now,
+			"last_seen":  now,
+		},
+	}
+	if name == "" {
+		update["$unset"] = bson.M{"name": ""}
+	} else {
+		update["$set"] = bson.M{"name": name}
+	}
+
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"user_id": userID, "fingerprint": Fingerprint(userAgent, ipRange)},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Names returns the names users have given their devices, by user ID and
+// then fingerprint. Unnamed devices are left out.
+func (s *Store) Names(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]map[string]string, error) {
+	names := make(map[primitive.ObjectID]map[string]string)
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	cur, err := s.c.Find(ctx,
+		bson.M{"user_id": bson.M{"$in": userIDs}, "name": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"user_id": 1, "fingerprint": 1, "name": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var d Device
+		if err := cur.Decode(&d); err != nil {
+			return nil, err
+		}
+		if names[d.UserID] == nil {
+			names[d.UserID] = make(map[string]string)
+		}
+		names[d.UserID][d.Fingerprint] = d.Name
+	}
+	return names, cur.Err()
+}
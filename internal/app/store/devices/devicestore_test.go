@@ -0,0 +1,110 @@
+package devicestore
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIPRange(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.42":        "203.0.113.0/24",
+		"::ffff:203.0.113.42": "203.0.113.0/24",
+		"2001:db8:abcd:12::1": "2001:db8:abcd::/48",
+		"not-an-ip":           "not-an-ip",
+	}
+	for in, want := range tests {
+		if got := IPRange(in); got != want {
+			t.Errorf("IPRange(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("Mozilla/5.0 Chrome", IPRange("203.0.113.42"))
+	if b := Fingerprint("Mozilla/5.0 Chrome", IPRange("203.0.113.99")); a != b {
+		t.Error("same browser on the same /24 should match")
+	}
+	if b := Fingerprint("Mozilla/5.0 Firefox", IPRange("203.0.113.42")); a == b {
+		t.Error("different user agents should not match")
+	}
+	if b := Fingerprint("Mozilla/5.0 Chrome", IPRange("198.51.100.1")); a == b {
+		t.Error("different networks should not match")
+	}
+}
+
+func TestStore_Touch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID := primitive.NewObjectID()
+
+	isNew, first, err := store.Touch(ctx, userID, "Chrome", "203.0.113.42")
+	if err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if !isNew || !first {
+		t.Errorf("first login: isNew=%v first=%v, want true, true", isNew, first)
+	}
+
+	isNew, _, err = store.Touch(ctx, userID, "Chrome", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if isNew {
+		t.Error("repeat login from the same network reported as new")
+	}
+
+	isNew, first, err = store.Touch(ctx, userID, "Firefox", "203.0.113.42")
+	if err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if !isNew || first {
+		t.Errorf("second device: isNew=%v first=%v, want true, false", isNew, first)
+	}
+}
+
+func TestStore_SetName(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID := primitive.NewObjectID()
+	fp := Fingerprint("Chrome", IPRange("203.0.113.42"))
+
+	// Naming an unrecorded device records it
+	if err := store.SetName(ctx, userID, "Chrome", "203.0.113.42", "Work laptop"); err != nil {
+		t.Fatalf("SetName() error = %v", err)
+	}
+	names, err := store.Names(ctx, []primitive.ObjectID{userID})
+	if err != nil {
+		t.Fatalf("Names() error = %v", err)
+	}
+	if got := names[userID][fp]; got != "Work laptop" {
+		t.Errorf("name = %q, want %q", got, "Work laptop")
+	}
+
+	isNew, _, err := store.Touch(ctx, userID, "Chrome", "203.0.113.42")
+	if err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	if isNew {
+		t.Error("named device reported as new on login")
+	}
+
+	// An empty name clears it
+	if err := store.SetName(ctx, userID, "Chrome", "203.0.113.42", ""); err != nil {
+		t.Fatalf("SetName() error = %v", err)
+	}
+	names, err = store.Names(ctx, []primitive.ObjectID{userID})
+	if err != nil {
+		t.Fatalf("Names() error = %v", err)
+	}
+	if _, ok := names[userID][fp]; ok {
+		t.Error("cleared name still returned")
+	}
+}
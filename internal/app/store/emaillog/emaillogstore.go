@@ -0,0 +1,168 @@
+// internal/app/store/emaillog/emaillogstore.go
+package emaillogstore
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Entry statuses.
+const (
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusSuppressed = "suppressed"
+)
+
+// Entry records one attempt to send an email.
+type Entry struct {
+	ID        primitive.ObjectID  `bson:"_id"`
+	To        string              `bson:"to"` // Normalized (lowercase, trimmed)
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty"`
+	Template  string              `bson:"template,omitempty"`
+	Subject   string              `bson:"subject"`
+	Status    string              `bson:"status"`
+	MessageID string              `bson:"message_id,omitempty"` // Message-ID header sent to the provider
+	Error     string              `bson:"error,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+// Store provides access to the email_log collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new email log store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("email_log")}
+}
+
+// CreateInput holds the fields for recording a delivery attempt.
+type CreateInput struct {
+	To        string
+	UserID    *primitive.ObjectID
+	Template  string
+	Subject   string
+	Status    string
+	MessageID string
+	Error     string
+}
+
+// Create records a delivery attempt.
+func (s *Store) Create(ctx context.Context, input CreateInput) error {
+	_, err := s.c.InsertOne(ctx, Entry{
+		ID:        primitive.NewObjectID(),
+		To:        normalize.Email(input.To),
+		UserID:    input.UserID,
+		Template:  input.Template,
+		Subject:   input.Subject,
+		Status:    input.Status,
+		MessageID: input.MessageID,
+		Error:     input.Error,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+// ListFilter specifies criteria for listing entries.
+type ListFilter struct {
+	To       string // Prefix match on the normalized address
+	Status   string
+	Template string
+
+	// UserID limits the list to one user. Entries sent to UserEmail are
+	// included too, so emails sent before the ID was recorded still show.
+	UserID    primitive.ObjectID
+	UserEmail string
+}
+
+// ListResult contains a page of entries with pagination info.
+type ListResult struct {
+	Entries    []Entry
+	TotalCount int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// List returns entries matching the filter, newest first.
+func (s *Store) List(ctx context.Context, filter ListFilter, page, pageSize int) (ListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 200 {
+		pageSize = 200
+	}
+
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Template != "" {
+		query["template"] = filter.Template
+	}
+	if e := normalize.Email(filter.To); e != "" {
+		query["to"] = bson.M{"$regex": "^" + regexp.QuoteMeta(e)}
+	}
+	if !filter.UserID.IsZero() {
+		or := bson.A{bson.M{"user_id": filter.UserID}}
+		if e := normalize.Email(filter.UserEmail); e != "" {
+			or = append(or, bson.M{"to": e})
+		}
+		query["$or"] = or
+	}
+
+	total, err := s.c.CountDocuments(ctx, query)
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	skip := (page - 1) * pageSize
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize))
+
+	cur, err := s.c.Find(ctx, query, opts)
+	if err != nil {
+		return ListResult{}, err
+	}
+	defer cur.Close(ctx)
+
+	var entries []Entry
+	if err := cur.All(ctx, &entries); err != nil {
+		return ListResult{}, err
+	}
+
+	return ListResult{
+		Entries:    entries,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// DeleteBefore removes entries created before cutoff and returns the number
+// removed.
+func (s *Store) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.c.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -26,14 +26,36 @@ type Verification struct {
 	Code      string             `bson:"code"`
 	Token     string             `bson:"token"`
 	Used      bool               `bson:"used"`
+	Attempts  int                `bson:"attempts"` // Wrong codes entered while this one was active
 	ExpiresAt time.Time          `bson:"expires_at"`
 	CreatedAt time.Time          `bson:"created_at"`
 }
 
+// ErrTooSoon is returned by Create when a code was sent to the address more
+// recently than the policy's resend interval allows.
+var ErrTooSoon = errors.New("a code was sent too recently")
+
+// Policy tightens how codes are issued and checked. The zero value applies
+// no limits.
+type Policy struct {
+	// SingleActive invalidates an address's earlier unused codes and links
+	// whenever a new one is issued.
+	SingleActive bool
+
+	// ResendInterval is the minimum time between codes for the same
+	// address (0 = no limit).
+	ResendInterval time.Duration
+
+	// MaxAttempts is how many wrong codes may be entered against a code
+	// before it stops working (0 = no limit). Magic links aren't affected.
+	MaxAttempts int
+}
+
 // Store provides access to the email_verifications collection.
 type Store struct {
 	c      *mongo.Collection
 	expiry time.Duration
+	policy Policy
 }
 
 // New creates a new email verification store.
@@ -65,8 +87,36 @@ func (s *Store) EnsureIndexes(ctx context.Context) error {
 	return err
 }
 
-// Create creates a new verification record and returns it.
+// SetPolicy sets the limits applied by Create and VerifyCode.
+func (s *Store) SetPolicy(p Policy) {
+	s.policy = p
+}
+
+// Create creates a new verification record and returns it. It returns
+// ErrTooSoon if the policy's resend interval hasn't passed for the address.
 func (s *Store) Create(ctx context.Context, email string, userID primitive.ObjectID) (*Verification, error) {
+	if s.policy.ResendInterval > 0 {
+		n, err := s.c.CountDocuments(ctx, bson.M{
+			"email":      email,
+			"created_at": bson.M{"$gt": time.Now().Add(-s.policy.ResendInterval)},
+		}, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return nil, ErrTooSoon
+		}
+	}
+
+	if s.policy.SingleActive {
+		if _, err := s.c.UpdateMany(ctx,
+			bson.M{"email": email, "used": false},
+			bson.M{"$set": bson.M{"used": true}},
+		); err != nil {
+			return nil, err
+		}
+	}
+
 	code, err := generateCode(6)
 	if err != nil {
 		return nil, err
@@ -97,17 +147,31 @@ func (s *Store) Create(ctx context.Context, email string, userID primitive.Objec
 }
 
 // VerifyCode verifies a code for an email and returns the verification if valid.
+// With a MaxAttempts policy, a wrong code counts against every active code
+// for the address, and a code stops matching once its attempts run out.
 func (s *Store) VerifyCode(ctx context.Context, email, code string) (*Verification, error) {
 	var v Verification
-	filter := bson.M{
+	active := bson.M{
 		"email":      email,
-		"code":       code,
 		"used":       false,
 		"expires_at": bson.M{"$gt": time.Now()},
 	}
+	if s.policy.MaxAttempts > 0 {
+		active["attempts"] = bson.M{"$lt": s.policy.MaxAttempts}
+	}
+
+	filter := bson.M{"code": code}
+	for k, val := range active {
+		filter[k] = val
+	}
 
 	if err := s.c.FindOne(ctx, filter).Decode(&v); err != nil {
 		if err == mongo.ErrNoDocuments {
+			if s.policy.MaxAttempts > 0 {
+				if _, err := s.c.UpdateMany(ctx, active, bson.M{"$inc": bson.M{"attempts": 1}}); err != nil {
+					return nil, err
+				}
+			}
 			return nil, errors.New("invalid or expired code")
 		}
 		return nil, err
@@ -437,3 +437,86 @@ func TestStore_EndToEnd_TokenFlow(t *testing.T) {
 		t.Error("Token should be invalid after use")
 	}
 }
+
+func TestStore_Policy_SingleActive(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db, testExpiry)
+	store.SetPolicy(Policy{SingleActive: true})
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	email := "single@example.com"
+	userID := primitive.NewObjectID()
+
+	first, err := store.Create(ctx, email, userID)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	second, err := store.Create(ctx, email, userID)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if first.Code != second.Code {
+		if _, err := store.VerifyCode(ctx, email, first.Code); err == nil {
+			t.Error("earlier code should stop working once a new one is issued")
+		}
+	}
+	if _, err := store.VerifyToken(ctx, first.Token); err == nil {
+		t.Error("earlier magic link should stop working once a new one is issued")
+	}
+	if _, err := store.VerifyCode(ctx, email, second.Code); err != nil {
+		t.Errorf("newest code should work: %v", err)
+	}
+}
+
+func TestStore_Policy_ResendInterval(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db, testExpiry)
+	store.SetPolicy(Policy{ResendInterval: time.Minute})
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	userID := primitive.NewObjectID()
+
+	if _, err := store.Create(ctx, "resend@example.com", userID); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, "resend@example.com", userID); err != ErrTooSoon {
+		t.Errorf("second Create() error = %v, want ErrTooSoon", err)
+	}
+	if _, err := store.Create(ctx, "other@example.com", userID); err != nil {
+		t.Errorf("interval should be per address: %v", err)
+	}
+}
+
+func TestStore_Policy_MaxAttempts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db, testExpiry)
+	store.SetPolicy(Policy{MaxAttempts: 2})
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	email := "attempts@example.com"
+	created, err := store.Create(ctx, email, primitive.NewObjectID())
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	wrong := "000000"
+	if created.Code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := store.VerifyCode(ctx, email, wrong); err == nil {
+			t.Fatal("wrong code should not verify")
+		}
+	}
+
+	if _, err := store.VerifyCode(ctx, email, created.Code); err == nil {
+		t.Error("code should stop working after MaxAttempts wrong entries")
+	}
+	if _, err := store.VerifyToken(ctx, created.Token); err != nil {
+		t.Errorf("magic link should not be affected by the attempt limit: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+// internal/app/store/featureflag/featureflagstore.go
+package featureflagstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store provides access to the feature_flags collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new feature flag store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("feature_flags")}
+}
+
+// List returns every stored flag setting, by key.
+func (s *Store) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	cur, err := s.c.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "key", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var flags []models.FeatureFlag
+	if err := cur.All(ctx, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Set saves the setting for flag.Key, replacing any earlier one.
+func (s *Store) Set(ctx context.Context, flag models.FeatureFlag) error {
+	flag.UpdatedAt = time.Now().UTC()
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"key": flag.Key},
+		bson.M{
+			"$set": bson.M{
+				"enabled":         flag.Enabled,
+				"environments":    flag.Environments,
+				"rollout":         flag.Rollout,
+				"updated_at":      flag.UpdatedAt,
+				"updated_by_id":   flag.UpdatedByID,
+				"updated_by_name": flag.UpdatedByName,
+			},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// Delete removes the setting for key, so the flag's default applies.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"key": key})
+	return err
+}
@@ -0,0 +1,42 @@
+package featureflagstore
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/stratasave/internal/testutil"
+)
+
+func TestStore_SetListDelete(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if err := store.Set(ctx, models.FeatureFlag{Key: "api_v2", Enabled: true, Rollout: 10}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// Setting it again replaces the earlier setting
+	if err := store.Set(ctx, models.FeatureFlag{Key: "api_v2", Enabled: true, Environments: []string{"prod"}, Rollout: 50}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	flags, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(flags) != 1 {
+		t.Fatalf("List() returned %d flags, want 1", len(flags))
+	}
+	if f := flags[0]; f.Rollout != 50 || len(f.Environments) != 1 || f.UpdatedAt.IsZero() {
+		t.Errorf("List() = %+v, want the second setting", f)
+	}
+
+	if err := store.Delete(ctx, "api_v2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	flags, _ = store.List(ctx)
+	if len(flags) != 0 {
+		t.Errorf("List() after Delete returned %d flags, want 0", len(flags))
+	}
+}
@@ -3,6 +3,7 @@ package file
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +15,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// notTrashed matches files that aren't in the trash.
+var notTrashed = bson.M{"$exists": false}
+
 // Store provides access to the files collection.
 type Store struct {
 	c *mongo.Collection
@@ -34,6 +38,9 @@ type CreateInput struct {
 	Size        int64
 	ContentType string
 	Description string
+	Tags        []string
+	SHA256      string
+	ScanStatus  string
 	CreatedByID primitive.ObjectID
 }
 
@@ -49,6 +56,9 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (*models.File, er
 		Size:        input.Size,
 		ContentType: input.ContentType,
 		Description: input.Description,
+		Tags:        input.Tags,
+		SHA256:      input.SHA256,
+		ScanStatus:  input.ScanStatus,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		CreatedByID: input.CreatedByID,
@@ -61,10 +71,10 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (*models.File, er
 	return &file, nil
 }
 
-// GetByID retrieves a file by ID.
+// GetByID retrieves a file by ID. Files in the trash are not found.
 func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*models.File, error) {
 	var file models.File
-	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&file); err != nil {
+	if err := s.c.FindOne(ctx, bson.M{"_id": id, "deleted_at": notTrashed}).Decode(&file); err != nil {
 		return nil, err
 	}
 	return &file, nil
@@ -74,6 +84,7 @@ func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Fil
 type UpdateInput struct {
 	Name        *string
 	Description *string
+	Tags        *[]string // An empty list removes all tags
 }
 
 // Update updates a file.
@@ -87,8 +98,30 @@ func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateI
 	if input.Description != nil {
 		set["description"] = *input.Description
 	}
+	update := bson.M{"$set": set}
+	if input.Tags != nil {
+		if len(*input.Tags) > 0 {
+			set["tags"] = *input.Tags
+		} else {
+			update["$unset"] = bson.M{"tags": ""}
+		}
+	}
+
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
 
-	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+// Move moves a file to another folder. Pass nil for folderID to move it to
+// the root.
+func (s *Store) Move(ctx context.Context, id primitive.ObjectID, folderID *primitive.ObjectID) error {
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if folderID != nil {
+		set["folder_id"] = *folderID
+	} else {
+		update["$unset"] = bson.M{"folder_id": ""}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
 	return err
 }
 
@@ -104,12 +137,13 @@ type ListOptions struct {
 	SortOrder   int    // 1 = asc, -1 = desc
 	ContentType string // Filter by MIME type: prefix match (e.g., "image/") or contains match with ~ prefix (e.g., "~word,document")
 	Search      string // Filter by filename
+	Tag         string // Filter by tag
 }
 
 // ListByFolder returns all files within a folder.
 // Pass nil for folderID to list root-level files.
 func (s *Store) ListByFolder(ctx context.Context, folderID *primitive.ObjectID, opts ListOptions) ([]models.File, error) {
-	filter := bson.M{"folder_id": folderID}
+	filter := bson.M{"folder_id": folderID, "deleted_at": notTrashed}
 
 	// Apply content type filter
 	if opts.ContentType != "" {
@@ -140,6 +174,11 @@ func (s *Store) ListByFolder(ctx context.Context, folderID *primitive.ObjectID,
 		filter["name_ci"] = bson.M{"$regex": searchFolded}
 	}
 
+	// Apply tag filter
+	if opts.Tag != "" {
+		filter["tags"] = opts.Tag
+	}
+
 	// Determine sort field
 	sortField := "name_ci"
 	switch opts.SortBy {
@@ -172,22 +211,241 @@ func (s *Store) ListByFolder(ctx context.Context, folderID *primitive.ObjectID,
 	return files, nil
 }
 
+// Search returns files whose name or description matches query, across
+// the whole library, best matches first. It uses the collection's text
+// index, so it matches whole words (and their stems), not substrings.
+func (s *Store) Search(ctx context.Context, query string, limit int64) ([]models.File, error) {
+	filter := bson.M{
+		"$text":      bson.M{"$search": query},
+		"deleted_at": notTrashed,
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "name_ci", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.File
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ListByTag returns files with a tag, across the whole library, sorted by
+// name.
+func (s *Store) ListByTag(ctx context.Context, tag string, limit int64) ([]models.File, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name_ci", Value: 1}}).SetLimit(limit)
+	cursor, err := s.c.Find(ctx, bson.M{"tags": tag, "deleted_at": notTrashed}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var files []models.File
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ListTags returns every tag in use on files outside the trash, sorted.
+func (s *Store) ListTags(ctx context.Context) ([]string, error) {
+	values, err := s.c.Distinct(ctx, "tags", bson.M{"deleted_at": notTrashed})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(values))
+	for _, v := range values {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// FolderUsage is how many files, and how many bytes, are directly in one
+// folder.
+type FolderUsage struct {
+	FolderID *primitive.ObjectID `bson:"_id"` // nil for the library root
+	Files    int64               `bson:"files"`
+	Bytes    int64               `bson:"bytes"`
+}
+
+// TypeUsage is how many files, and how many bytes, have one content type.
+type TypeUsage struct {
+	ContentType string `bson:"_id"`
+	Files       int64  `bson:"files"`
+	Bytes       int64  `bson:"bytes"`
+}
+
+// UsageTotals sums the whole library. Bytes count each file in full;
+// StoredBytes counts content shared by several files once, and includes
+// the trash, so it is what the library takes up in storage.
+type UsageTotals struct {
+	Files       int64
+	Bytes       int64
+	TrashFiles  int64
+	TrashBytes  int64
+	StoredBytes int64
+}
+
+// UsageByFolder sums the files outside the trash by the folder they are
+// directly in.
+func (s *Store) UsageByFolder(ctx context.Context) ([]FolderUsage, error) {
+	var usage []FolderUsage
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": notTrashed}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$folder_id",
+			"files": bson.M{"$sum": 1},
+			"bytes": bson.M{"$sum": "$size"},
+		}}},
+	}, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// UsageByType sums the files outside the trash by content type, largest
+// first.
+func (s *Store) UsageByType(ctx context.Context) ([]TypeUsage, error) {
+	var usage []TypeUsage
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted_at": notTrashed}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$content_type",
+			"files": bson.M{"$sum": 1},
+			"bytes": bson.M{"$sum": "$size"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "bytes", Value: -1}, {Key: "_id", Value: 1}}}},
+	}, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// Usage sums the whole library, in and out of the trash.
+func (s *Store) Usage(ctx context.Context) (UsageTotals, error) {
+	var groups []struct {
+		Trashed bool  `bson:"_id"`
+		Files   int64 `bson:"files"`
+		Bytes   int64 `bson:"bytes"`
+	}
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$cond": bson.A{bson.M{"$ifNull": bson.A{"$deleted_at", false}}, true, false}},
+			"files": bson.M{"$sum": 1},
+			"bytes": bson.M{"$sum": "$size"},
+		}}},
+	}, &groups); err != nil {
+		return UsageTotals{}, err
+	}
+
+	var totals UsageTotals
+	for _, g := range groups {
+		if g.Trashed {
+			totals.TrashFiles, totals.TrashBytes = g.Files, g.Bytes
+		} else {
+			totals.Files, totals.Bytes = g.Files, g.Bytes
+		}
+	}
+
+	stored, err := s.StoredBytes(ctx)
+	if err != nil {
+		return UsageTotals{}, err
+	}
+	totals.StoredBytes = stored
+	return totals, nil
+}
+
+// StoredBytes returns the bytes the library takes up in storage: every
+// stored object once, however many files share it, trash included.
+func (s *Store) StoredBytes(ctx context.Context) (int64, error) {
+	var sums []struct {
+		Bytes int64 `bson:"bytes"`
+	}
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$storage_path", "size": bson.M{"$first": "$size"}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "bytes": bson.M{"$sum": "$size"}}}},
+	}, &sums); err != nil {
+		return 0, err
+	}
+	if len(sums) == 0 {
+		return 0, nil
+	}
+	return sums[0].Bytes, nil
+}
+
+// AddedBetween returns how many files were uploaded in [start, end), and
+// their total bytes, whether or not they have since been trashed.
+func (s *Store) AddedBetween(ctx context.Context, start, end time.Time) (files, bytes int64, err error) {
+	var sums []struct {
+		Files int64 `bson:"files"`
+		Bytes int64 `bson:"bytes"`
+	}
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": start, "$lt": end}}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "files": bson.M{"$sum": 1}, "bytes": bson.M{"$sum": "$size"}}}},
+	}, &sums); err != nil {
+		return 0, 0, err
+	}
+	if len(sums) == 0 {
+		return 0, 0, nil
+	}
+	return sums[0].Files, sums[0].Bytes, nil
+}
+
+// SumSize returns the bytes held by the files outside the trash directly
+// in any of the given folders.
+func (s *Store) SumSize(ctx context.Context, folderIDs []primitive.ObjectID) (int64, error) {
+	var sums []struct {
+		Bytes int64 `bson:"bytes"`
+	}
+	if err := s.aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"folder_id": bson.M{"$in": folderIDs}, "deleted_at": notTrashed}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "bytes": bson.M{"$sum": "$size"}}}},
+	}, &sums); err != nil {
+		return 0, err
+	}
+	if len(sums) == 0 {
+		return 0, nil
+	}
+	return sums[0].Bytes, nil
+}
+
+func (s *Store) aggregate(ctx context.Context, pipeline mongo.Pipeline, results any) error {
+	cursor, err := s.c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, results)
+}
+
 // CountByFolder returns the number of files in a folder.
 func (s *Store) CountByFolder(ctx context.Context, folderID *primitive.ObjectID) (int64, error) {
-	return s.c.CountDocuments(ctx, bson.M{"folder_id": folderID})
+	return s.c.CountDocuments(ctx, bson.M{"folder_id": folderID, "deleted_at": notTrashed})
 }
 
 // CountByFolderID returns the number of files in a specific folder (by ID, not pointer).
 func (s *Store) CountByFolderID(ctx context.Context, folderID primitive.ObjectID) (int64, error) {
-	return s.c.CountDocuments(ctx, bson.M{"folder_id": folderID})
+	return s.c.CountDocuments(ctx, bson.M{"folder_id": folderID, "deleted_at": notTrashed})
 }
 
 // NameExistsInFolder checks if a file with the given name exists in the folder.
 // Pass excludeID to exclude a specific file (useful for updates).
 func (s *Store) NameExistsInFolder(ctx context.Context, name string, folderID *primitive.ObjectID, excludeID *primitive.ObjectID) (bool, error) {
 	filter := bson.M{
-		"folder_id": folderID,
-		"name_ci":   text.Fold(name),
+		"folder_id":  folderID,
+		"name_ci":    text.Fold(name),
+		"deleted_at": notTrashed,
 	}
 
 	if excludeID != nil {
@@ -213,7 +471,7 @@ func (s *Store) DeleteByFolderID(ctx context.Context, folderID primitive.ObjectI
 
 // GetByFolderID returns all files in a specific folder.
 func (s *Store) GetByFolderID(ctx context.Context, folderID primitive.ObjectID) ([]models.File, error) {
-	cursor, err := s.c.Find(ctx, bson.M{"folder_id": folderID})
+	cursor, err := s.c.Find(ctx, bson.M{"folder_id": folderID, "deleted_at": notTrashed})
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +485,141 @@ func (s *Store) GetByFolderID(ctx context.Context, folderID primitive.ObjectID)
 	return files, nil
 }
 
+// Trash moves a file to the trash.
+func (s *Store) Trash(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"deleted_at": at}})
+	return err
+}
+
+// CountAccess adds a view or a download to a file's access counts.
+func (s *Store) CountAccess(ctx context.Context, id primitive.ObjectID, download bool, at time.Time) error {
+	field := "view_count"
+	if download {
+		field = "download_count"
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{field: 1},
+		"$set": bson.M{"last_accessed_at": at},
+	})
+	return err
+}
+
+// SetIntegrityFailed records whether the content at storagePath failed its
+// SHA-256 check, on every file stored there. A nil at clears it.
+func (s *Store) SetIntegrityFailed(ctx context.Context, storagePath string, at *time.Time) error {
+	update := bson.M{"$unset": bson.M{"integrity_failed_at": ""}}
+	if at != nil {
+		update = bson.M{"$set": bson.M{"integrity_failed_at": *at}}
+	}
+	_, err := s.c.UpdateMany(ctx, bson.M{"storage_path": storagePath}, update)
+	return err
+}
+
+// TrashInFolders moves the files in folderIDs to the trash along with the
+// folder withID, so restoring that folder restores them.
+func (s *Store) TrashInFolders(ctx context.Context, folderIDs []primitive.ObjectID, withID primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateMany(ctx,
+		bson.M{"folder_id": bson.M{"$in": folderIDs}, "deleted_at": notTrashed},
+		bson.M{"$set": bson.M{"deleted_at": at, "trashed_with_id": withID}},
+	)
+	return err
+}
+
+// GetTrashedByID retrieves a file that was put in the trash itself (not
+// along with its folder).
+func (s *Store) GetTrashedByID(ctx context.Context, id primitive.ObjectID) (*models.File, error) {
+	var file models.File
+	filter := bson.M{
+		"_id":             id,
+		"deleted_at":      bson.M{"$exists": true},
+		"trashed_with_id": bson.M{"$exists": false},
+	}
+	if err := s.c.FindOne(ctx, filter).Decode(&file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// ListTrash returns files that were put in the trash themselves, most
+// recently deleted first.
+func (s *Store) ListTrash(ctx context.Context) ([]models.File, error) {
+	filter := bson.M{
+		"deleted_at":      bson.M{"$exists": true},
+		"trashed_with_id": bson.M{"$exists": false},
+	}
+	cursor, err := s.c.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var files []models.File
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ListTrashedWith returns the files trashed along with a folder.
+func (s *Store) ListTrashedWith(ctx context.Context, folderID primitive.ObjectID) ([]models.File, error) {
+	cursor, err := s.c.Find(ctx, bson.M{"trashed_with_id": folderID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var files []models.File
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// CountTrashedWith counts the files trashed along with a folder.
+func (s *Store) CountTrashedWith(ctx context.Context, folderID primitive.ObjectID) (int64, error) {
+	return s.c.CountDocuments(ctx, bson.M{"trashed_with_id": folderID})
+}
+
+// ListTrashedBefore returns up to limit files trashed before cutoff.
+func (s *Store) ListTrashedBefore(ctx context.Context, cutoff time.Time, limit int64) ([]models.File, error) {
+	cursor, err := s.c.Find(ctx,
+		bson.M{"deleted_at": bson.M{"$lt": cutoff}},
+		options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var files []models.File
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Restore takes a file out of the trash and places it in folderID (nil for
+// the root).
+func (s *Store) Restore(ctx context.Context, id primitive.ObjectID, folderID *primitive.ObjectID) error {
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}}
+	if folderID != nil {
+		update["$set"] = bson.M{"folder_id": *folderID}
+	} else {
+		update["$unset"] = bson.M{"deleted_at": "", "folder_id": ""}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// RestoreTrashedWith takes the files trashed along with a folder out of
+// the trash.
+func (s *Store) RestoreTrashedWith(ctx context.Context, folderID primitive.ObjectID) error {
+	_, err := s.c.UpdateMany(ctx,
+		bson.M{"trashed_with_id": folderID},
+		bson.M{"$unset": bson.M{"deleted_at": "", "trashed_with_id": ""}},
+	)
+	return err
+}
+
 // FileTypeCategory returns a category string for a content type.
 func FileTypeCategory(contentType string) string {
 	switch {
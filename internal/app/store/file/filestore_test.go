@@ -2,7 +2,9 @@ package file
 
 import (
 	"testing"
+	"time"
 
+	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -486,3 +488,259 @@ func TestFileTypeCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_Move(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	folderID := primitive.NewObjectID()
+	f, _ := store.Create(ctx, CreateInput{
+		Name:        "report.pdf",
+		StoragePath: "files/report.pdf",
+		Size:        100,
+		ContentType: "application/pdf",
+		CreatedByID: primitive.NewObjectID(),
+	})
+
+	if err := store.Move(ctx, f.ID, &folderID); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	moved, _ := store.GetByID(ctx, f.ID)
+	if moved.FolderID == nil || *moved.FolderID != folderID {
+		t.Errorf("FolderID = %v, want %v", moved.FolderID, folderID)
+	}
+
+	if err := store.Move(ctx, f.ID, nil); err != nil {
+		t.Fatalf("Move() to root error = %v", err)
+	}
+	moved, _ = store.GetByID(ctx, f.ID)
+	if !moved.IsInRoot() {
+		t.Errorf("FolderID = %v, want nil", moved.FolderID)
+	}
+}
+
+func TestStore_TrashAndRestore(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	folderID := primitive.NewObjectID()
+	creatorID := primitive.NewObjectID()
+	loose, _ := store.Create(ctx, CreateInput{Name: "loose.txt", StoragePath: "files/loose.txt", CreatedByID: creatorID})
+	inFolder, _ := store.Create(ctx, CreateInput{Name: "inside.txt", FolderID: &folderID, StoragePath: "files/inside.txt", CreatedByID: creatorID})
+
+	now := time.Now()
+	if err := store.Trash(ctx, loose.ID, now); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	if err := store.TrashInFolders(ctx, []primitive.ObjectID{folderID}, folderID, now); err != nil {
+		t.Fatalf("TrashInFolders() error = %v", err)
+	}
+
+	if _, err := store.GetByID(ctx, loose.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("GetByID() on trashed file error = %v, want ErrNoDocuments", err)
+	}
+	if exists, _ := store.NameExistsInFolder(ctx, "loose.txt", nil, nil); exists {
+		t.Error("a trashed file's name should be free to reuse")
+	}
+
+	// Files trashed with a folder aren't listed on their own
+	trash, _ := store.ListTrash(ctx)
+	if len(trash) != 1 || trash[0].ID != loose.ID {
+		t.Errorf("ListTrash() = %v, want only loose.txt", trash)
+	}
+	with, _ := store.ListTrashedWith(ctx, folderID)
+	if len(with) != 1 || with[0].ID != inFolder.ID {
+		t.Errorf("ListTrashedWith() = %v, want only inside.txt", with)
+	}
+
+	old, _ := store.ListTrashedBefore(ctx, now.Add(time.Minute), 10)
+	if len(old) != 2 {
+		t.Errorf("ListTrashedBefore() count = %d, want 2", len(old))
+	}
+
+	if err := store.Restore(ctx, loose.ID, nil); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if err := store.RestoreTrashedWith(ctx, folderID); err != nil {
+		t.Fatalf("RestoreTrashedWith() error = %v", err)
+	}
+	for _, id := range []primitive.ObjectID{loose.ID, inFolder.ID} {
+		f, err := store.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID() after restore error = %v", err)
+		}
+		if f.InTrash() || f.TrashedWithID != nil {
+			t.Errorf("file %s still marked as trashed", f.Name)
+		}
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	folderID := primitive.NewObjectID()
+	byName, _ := store.Create(ctx, CreateInput{
+		FolderID:    &folderID,
+		Name:        "quarterly budget.xlsx",
+		StoragePath: "files/a.xlsx",
+		CreatedByID: primitive.NewObjectID(),
+	})
+	byDescription, _ := store.Create(ctx, CreateInput{
+		Name:        "q3.pdf",
+		Description: "Budget review notes",
+		StoragePath: "files/b.pdf",
+		CreatedByID: primitive.NewObjectID(),
+	})
+	trashed, _ := store.Create(ctx, CreateInput{
+		Name:        "old budget.xlsx",
+		StoragePath: "files/c.xlsx",
+		CreatedByID: primitive.NewObjectID(),
+	})
+	store.Create(ctx, CreateInput{
+		Name:        "photo.jpg",
+		StoragePath: "files/d.jpg",
+		CreatedByID: primitive.NewObjectID(),
+	})
+	if err := store.Trash(ctx, trashed.ID, time.Now()); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+
+	results, err := store.Search(ctx, "budget", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Search() returned %d files, want 2", len(results))
+	}
+	// A match in the name ranks above one in the description
+	if results[0].ID != byName.ID || results[1].ID != byDescription.ID {
+		t.Errorf("results = %s, %s; want %s then %s",
+			results[0].Name, results[1].Name, byName.Name, byDescription.Name)
+	}
+}
+
+func TestStore_Tags(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	folderID := primitive.NewObjectID()
+	a, _ := store.Create(ctx, CreateInput{
+		Name:        "welcome.pdf",
+		StoragePath: "files/a.pdf",
+		Tags:        []string{"onboarding"},
+		CreatedByID: primitive.NewObjectID(),
+	})
+	b, _ := store.Create(ctx, CreateInput{
+		FolderID:    &folderID,
+		Name:        "syllabus.pdf",
+		StoragePath: "files/b.pdf",
+		CreatedByID: primitive.NewObjectID(),
+	})
+
+	tags := []string{"onboarding", "2025 curriculum"}
+	if err := store.Update(ctx, b.ID, UpdateInput{Tags: &tags}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	all, err := store.ListTags(ctx)
+	if err != nil {
+		t.Fatalf("ListTags() error = %v", err)
+	}
+	if len(all) != 2 || all[0] != "2025 curriculum" || all[1] != "onboarding" {
+		t.Errorf("ListTags() = %v, want [2025 curriculum onboarding]", all)
+	}
+
+	tagged, err := store.ListByTag(ctx, "onboarding", 10)
+	if err != nil {
+		t.Fatalf("ListByTag() error = %v", err)
+	}
+	if len(tagged) != 2 {
+		t.Errorf("ListByTag() returned %d files, want 2 from different folders", len(tagged))
+	}
+
+	inFolder, _ := store.ListByFolder(ctx, nil, ListOptions{Tag: "onboarding"})
+	if len(inFolder) != 1 || inFolder[0].ID != a.ID {
+		t.Errorf("ListByFolder(root, onboarding) = %d files, want only %s", len(inFolder), a.Name)
+	}
+
+	// An empty list removes the tags
+	none := []string{}
+	if err := store.Update(ctx, a.ID, UpdateInput{Tags: &none}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := store.GetByID(ctx, a.ID)
+	if len(got.Tags) != 0 {
+		t.Errorf("Tags = %v, want none", got.Tags)
+	}
+}
+
+func TestStore_Usage(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	folderID := primitive.NewObjectID()
+	create := func(folder *primitive.ObjectID, name, path, contentType string, size int64) *models.File {
+		f, err := store.Create(ctx, CreateInput{
+			FolderID:    folder,
+			Name:        name,
+			StoragePath: path,
+			Size:        size,
+			ContentType: contentType,
+			CreatedByID: primitive.NewObjectID(),
+		})
+		if err != nil {
+			t.Fatalf("Create(%s) error = %v", name, err)
+		}
+		return f
+	}
+	create(nil, "a.pdf", "files/a.pdf", "application/pdf", 100)
+	create(&folderID, "b.pdf", "files/a.pdf", "application/pdf", 100) // Shares a.pdf's content
+	create(&folderID, "c.mp4", "files/c.mp4", "video/mp4", 1000)
+	trashed := create(&folderID, "d.mp4", "files/d.mp4", "video/mp4", 50)
+	if err := store.Trash(ctx, trashed.ID, time.Now()); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+
+	totals, err := store.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage() error = %v", err)
+	}
+	want := UsageTotals{Files: 3, Bytes: 1200, TrashFiles: 1, TrashBytes: 50, StoredBytes: 1150}
+	if totals != want {
+		t.Errorf("Usage() = %+v, want %+v", totals, want)
+	}
+
+	byType, err := store.UsageByType(ctx)
+	if err != nil {
+		t.Fatalf("UsageByType() error = %v", err)
+	}
+	if len(byType) != 2 || byType[0].ContentType != "video/mp4" || byType[0].Bytes != 1000 || byType[1].Files != 2 {
+		t.Errorf("UsageByType() = %+v", byType)
+	}
+
+	byFolder, err := store.UsageByFolder(ctx)
+	if err != nil {
+		t.Fatalf("UsageByFolder() error = %v", err)
+	}
+	for _, u := range byFolder {
+		if u.FolderID != nil && u.Bytes != 1100 {
+			t.Errorf("folder usage = %d bytes, want 1100", u.Bytes)
+		}
+	}
+
+	sum, err := store.SumSize(ctx, []primitive.ObjectID{folderID})
+	if err != nil || sum != 1100 {
+		t.Errorf("SumSize() = %d, %v; want 1100", sum, err)
+	}
+}
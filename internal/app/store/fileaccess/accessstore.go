@@ -0,0 +1,81 @@
+// Package fileaccess records who viewed and downloaded library files.
+//
+// Each record is one view or download of one file, kept until the file is
+// deleted for good. The file record itself keeps running counts (see the
+// file store), so showing them doesn't need to count these records.
+package fileaccess
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Kinds of access.
+const (
+	KindView          = "view"           // Opened in the browser
+	KindDownload      = "download"       // Downloaded by a signed-in user
+	KindShareDownload = "share_download" // Downloaded through a share link
+)
+
+// Access is one view or download of a file.
+type Access struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	FileID    primitive.ObjectID  `bson:"file_id"`
+	Kind      string              `bson:"kind"`
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty"`  // nil for share-link downloads
+	ShareID   *primitive.ObjectID `bson:"share_id,omitempty"` // Set for share-link downloads
+	IP        string              `bson:"ip"`
+	UserAgent string              `bson:"user_agent,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+// Store provides access to the file_access collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new file access store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("file_access")}
+}
+
+// Record inserts an access record. If CreatedAt is zero, it's set to
+// time.Now().UTC().
+func (s *Store) Record(ctx context.Context, a Access) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	_, err := s.c.InsertOne(ctx, a)
+	return err
+}
+
+// ListByFile returns a file's most recent access records, newest first.
+func (s *Store) ListByFile(ctx context.Context, fileID primitive.ObjectID, limit int64) ([]Access, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+
+	cur, err := s.c.Find(ctx, bson.M{"file_id": fileID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []Access
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// DeleteByFile removes a file's access records, when the file is deleted
+// for good.
+func (s *Store) DeleteByFile(ctx context.Context, fileID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"file_id": fileID})
+	return err
+}
@@ -0,0 +1,51 @@
+package fileaccess
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_ListByFile(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	fileID := primitive.NewObjectID()
+	userID := primitive.NewObjectID()
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	kinds := []string{KindView, KindDownload, KindShareDownload}
+	for i, kind := range kinds {
+		a := Access{FileID: fileID, Kind: kind, IP: "203.0.113.7", CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+		if kind != KindShareDownload {
+			a.UserID = &userID
+		}
+		if err := store.Record(ctx, a); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := store.Record(ctx, Access{FileID: primitive.NewObjectID(), Kind: KindView}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	records, err := store.ListByFile(ctx, fileID, 2)
+	if err != nil {
+		t.Fatalf("ListByFile() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("ListByFile() returned %d records, want 2", len(records))
+	}
+	if records[0].Kind != KindShareDownload || records[1].Kind != KindDownload {
+		t.Errorf("ListByFile() kinds = %s, %s; want newest first", records[0].Kind, records[1].Kind)
+	}
+
+	if err := store.DeleteByFile(ctx, fileID); err != nil {
+		t.Fatalf("DeleteByFile() error = %v", err)
+	}
+	if records, _ := store.ListByFile(ctx, fileID, 10); len(records) != 0 {
+		t.Errorf("ListByFile() after DeleteByFile returned %d records", len(records))
+	}
+}
@@ -3,6 +3,7 @@ package folder
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/domain/models"
@@ -13,6 +14,13 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrCycle is returned when moving a folder into itself or one of its
+// subfolders.
+var ErrCycle = errors.New("a folder cannot be moved into itself or one of its subfolders")
+
+// notTrashed matches folders that aren't in the trash.
+var notTrashed = bson.M{"$exists": false}
+
 // Store provides access to the file_folders collection.
 type Store struct {
 	c *mongo.Collection
@@ -54,10 +62,10 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (*models.Folder,
 	return &folder, nil
 }
 
-// GetByID retrieves a folder by ID.
+// GetByID retrieves a folder by ID. Folders in the trash are not found.
 func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Folder, error) {
 	var folder models.Folder
-	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&folder); err != nil {
+	if err := s.c.FindOne(ctx, bson.M{"_id": id, "deleted_at": notTrashed}).Decode(&folder); err != nil {
 		return nil, err
 	}
 	return &folder, nil
@@ -67,6 +75,7 @@ func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Fol
 type UpdateInput struct {
 	Name        *string
 	Description *string
+	QuotaBytes  *int64 // Zero removes the quota
 }
 
 // Update updates a folder.
@@ -80,8 +89,46 @@ func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateI
 	if input.Description != nil {
 		set["description"] = *input.Description
 	}
+	update := bson.M{"$set": set}
+	if input.QuotaBytes != nil {
+		if *input.QuotaBytes > 0 {
+			set["quota_bytes"] = *input.QuotaBytes
+		} else {
+			update["$unset"] = bson.M{"quota_bytes": ""}
+		}
+	}
+
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Move moves a folder, with everything in it, under a new parent. Pass nil
+// for parentID to move it to the root. It returns ErrCycle if parentID is
+// the folder itself or one of its descendants.
+func (s *Store) Move(ctx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID) error {
+	if parentID != nil {
+		if *parentID == id {
+			return ErrCycle
+		}
+		ancestors, err := s.GetAncestors(ctx, *parentID)
+		if err != nil {
+			return err
+		}
+		for _, a := range ancestors {
+			if a.ID == id {
+				return ErrCycle
+			}
+		}
+	}
 
-	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	set := bson.M{"updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if parentID != nil {
+		set["parent_id"] = *parentID
+	} else {
+		update["$unset"] = bson.M{"parent_id": ""}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
 	return err
 }
 
@@ -100,7 +147,7 @@ type ListOptions struct {
 // ListByParent returns all folders within a parent folder.
 // Pass nil for parentID to list root folders.
 func (s *Store) ListByParent(ctx context.Context, parentID *primitive.ObjectID, opts ListOptions) ([]models.Folder, error) {
-	filter := bson.M{"parent_id": parentID}
+	filter := bson.M{"parent_id": parentID, "deleted_at": notTrashed}
 
 	// Determine sort field
 	sortField := "name_ci"
@@ -132,9 +179,51 @@ func (s *Store) ListByParent(ctx context.Context, parentID *primitive.ObjectID,
 	return folders, nil
 }
 
+// ListAll returns every folder, sorted by name.
+func (s *Store) ListAll(ctx context.Context) ([]models.Folder, error) {
+	cursor, err := s.c.Find(ctx, bson.M{"deleted_at": notTrashed}, options.Find().SetSort(bson.D{{Key: "name_ci", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var folders []models.Folder
+	if err := cursor.All(ctx, &folders); err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
+// Search returns folders whose name or description matches query, across
+// the whole library, best matches first. It uses the collection's text
+// index, so it matches whole words (and their stems), not substrings.
+func (s *Store) Search(ctx context.Context, query string, limit int64) ([]models.Folder, error) {
+	filter := bson.M{
+		"$text":      bson.M{"$search": query},
+		"deleted_at": notTrashed,
+	}
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}, {Key: "name_ci", Value: 1}}).
+		SetLimit(limit)
+
+	cursor, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []models.Folder
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 // CountByParent returns the number of folders within a parent folder.
 func (s *Store) CountByParent(ctx context.Context, parentID *primitive.ObjectID) (int64, error) {
-	return s.c.CountDocuments(ctx, bson.M{"parent_id": parentID})
+	return s.c.CountDocuments(ctx, bson.M{"parent_id": parentID, "deleted_at": notTrashed})
 }
 
 // GetAncestors returns all ancestors of a folder, ordered from root to immediate parent.
@@ -182,8 +271,9 @@ func (s *Store) GetPath(ctx context.Context, id primitive.ObjectID) ([]models.Fo
 // Pass excludeID to exclude a specific folder (useful for updates).
 func (s *Store) NameExistsInParent(ctx context.Context, name string, parentID *primitive.ObjectID, excludeID *primitive.ObjectID) (bool, error) {
 	filter := bson.M{
-		"parent_id": parentID,
-		"name_ci":   text.Fold(name),
+		"parent_id":  parentID,
+		"name_ci":    text.Fold(name),
+		"deleted_at": notTrashed,
 	}
 
 	if excludeID != nil {
@@ -200,9 +290,138 @@ func (s *Store) NameExistsInParent(ctx context.Context, name string, parentID *p
 
 // HasSubfolders checks if a folder has any subfolders.
 func (s *Store) HasSubfolders(ctx context.Context, id primitive.ObjectID) (bool, error) {
-	count, err := s.c.CountDocuments(ctx, bson.M{"parent_id": id})
+	count, err := s.c.CountDocuments(ctx, bson.M{"parent_id": id, "deleted_at": notTrashed})
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
+
+// DescendantIDs returns the IDs of every folder below a folder, excluding
+// folders already in the trash.
+func (s *Store) DescendantIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	level := []primitive.ObjectID{id}
+	for len(level) > 0 {
+		cursor, err := s.c.Find(ctx,
+			bson.M{"parent_id": bson.M{"$in": level}, "deleted_at": notTrashed},
+			options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return nil, err
+		}
+		var children []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		err = cursor.All(ctx, &children)
+		cursor.Close(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		level = nil
+		for _, c := range children {
+			ids = append(ids, c.ID)
+			level = append(level, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+// Trash moves a folder and its subfolders to the trash. The subfolders are
+// marked as trashed along with the folder, so restoring it restores them.
+func (s *Store) Trash(ctx context.Context, id primitive.ObjectID, descendants []primitive.ObjectID, at time.Time) error {
+	if len(descendants) > 0 {
+		if _, err := s.c.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": descendants}, "deleted_at": notTrashed},
+			bson.M{"$set": bson.M{"deleted_at": at, "trashed_with_id": id}},
+		); err != nil {
+			return err
+		}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"deleted_at": at}})
+	return err
+}
+
+// GetTrashedByID retrieves a folder that was put in the trash itself (not
+// along with a parent).
+func (s *Store) GetTrashedByID(ctx context.Context, id primitive.ObjectID) (*models.Folder, error) {
+	var folder models.Folder
+	filter := bson.M{
+		"_id":             id,
+		"deleted_at":      bson.M{"$exists": true},
+		"trashed_with_id": bson.M{"$exists": false},
+	}
+	if err := s.c.FindOne(ctx, filter).Decode(&folder); err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// ListTrash returns folders that were put in the trash themselves, most
+// recently deleted first.
+func (s *Store) ListTrash(ctx context.Context) ([]models.Folder, error) {
+	filter := bson.M{
+		"deleted_at":      bson.M{"$exists": true},
+		"trashed_with_id": bson.M{"$exists": false},
+	}
+	cursor, err := s.c.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var folders []models.Folder
+	if err := cursor.All(ctx, &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// Restore takes a folder, and the subfolders trashed with it, out of the
+// trash. The folder is placed under parentID (nil for the root).
+func (s *Store) Restore(ctx context.Context, id primitive.ObjectID, parentID *primitive.ObjectID) error {
+	if _, err := s.c.UpdateMany(ctx,
+		bson.M{"trashed_with_id": id},
+		bson.M{"$unset": bson.M{"deleted_at": "", "trashed_with_id": ""}},
+	); err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"deleted_at": "", "purge_job_id": ""}}
+	if parentID != nil {
+		update["$set"] = bson.M{"parent_id": *parentID}
+	} else {
+		update["$unset"] = bson.M{"deleted_at": "", "purge_job_id": "", "parent_id": ""}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// SetPurgeJob records the background job that is deleting a trashed
+// folder for good.
+func (s *Store) SetPurgeJob(ctx context.Context, id, jobID primitive.ObjectID) error {
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "deleted_at": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"purge_job_id": jobID}},
+	)
+	return err
+}
+
+// DeleteTrashed permanently deletes a trashed folder and the subfolders
+// trashed with it.
+func (s *Store) DeleteTrashed(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"$or": []bson.M{
+		{"_id": id, "deleted_at": bson.M{"$exists": true}},
+		{"trashed_with_id": id},
+	}})
+	return err
+}
+
+// PurgeTrashedBefore permanently deletes folders trashed before cutoff.
+func (s *Store) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.c.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -1,7 +1,9 @@
 package folder
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -397,3 +399,214 @@ func TestStore_HasSubfolders(t *testing.T) {
 		t.Error("HasSubfolders() should return false for empty folder")
 	}
 }
+
+func TestStore_Move(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	creatorID := primitive.NewObjectID()
+
+	// Create hierarchy: A > B > C, and D at the root
+	a, _ := store.Create(ctx, CreateInput{Name: "A", CreatedByID: creatorID})
+	b, _ := store.Create(ctx, CreateInput{Name: "B", ParentID: &a.ID, CreatedByID: creatorID})
+	c, _ := store.Create(ctx, CreateInput{Name: "C", ParentID: &b.ID, CreatedByID: creatorID})
+	d, _ := store.Create(ctx, CreateInput{Name: "D", CreatedByID: creatorID})
+
+	// Moving into itself or a descendant is a cycle
+	if err := store.Move(ctx, a.ID, &a.ID); !errors.Is(err, ErrCycle) {
+		t.Errorf("Move() into self error = %v, want ErrCycle", err)
+	}
+	if err := store.Move(ctx, a.ID, &c.ID); !errors.Is(err, ErrCycle) {
+		t.Errorf("Move() into descendant error = %v, want ErrCycle", err)
+	}
+
+	// Move B (with C) under D
+	if err := store.Move(ctx, b.ID, &d.ID); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	path, err := store.GetPath(ctx, c.ID)
+	if err != nil {
+		t.Fatalf("GetPath() error = %v", err)
+	}
+	if len(path) != 3 || path[0].ID != d.ID || path[1].ID != b.ID {
+		t.Errorf("GetPath() after move = %v, want D > B > C", path)
+	}
+
+	// Move B to the root
+	if err := store.Move(ctx, b.ID, nil); err != nil {
+		t.Fatalf("Move() to root error = %v", err)
+	}
+	roots, _ := store.ListByParent(ctx, nil, ListOptions{})
+	if len(roots) != 3 {
+		t.Errorf("ListByParent(nil) count = %d, want 3", len(roots))
+	}
+}
+
+func TestStore_ListAll(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	creatorID := primitive.NewObjectID()
+	parent, _ := store.Create(ctx, CreateInput{Name: "beta", CreatedByID: creatorID})
+	store.Create(ctx, CreateInput{Name: "Alpha", ParentID: &parent.ID, CreatedByID: creatorID})
+
+	folders, err := store.ListAll(ctx)
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if len(folders) != 2 || folders[0].Name != "Alpha" {
+		t.Errorf("ListAll() = %v, want Alpha then beta", folders)
+	}
+}
+
+func TestStore_TrashAndRestore(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	creatorID := primitive.NewObjectID()
+	parent, _ := store.Create(ctx, CreateInput{Name: "Parent", CreatedByID: creatorID})
+	child, _ := store.Create(ctx, CreateInput{Name: "Child", ParentID: &parent.ID, CreatedByID: creatorID})
+	grandchild, _ := store.Create(ctx, CreateInput{Name: "Grandchild", ParentID: &child.ID, CreatedByID: creatorID})
+
+	descendants, err := store.DescendantIDs(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("DescendantIDs() error = %v", err)
+	}
+	if len(descendants) != 2 {
+		t.Fatalf("DescendantIDs() count = %d, want 2", len(descendants))
+	}
+
+	if err := store.Trash(ctx, parent.ID, descendants, time.Now()); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+
+	// Trashed folders are hidden from normal lookups
+	if _, err := store.GetByID(ctx, grandchild.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("GetByID() on trashed folder error = %v, want ErrNoDocuments", err)
+	}
+	if exists, _ := store.NameExistsInParent(ctx, "Parent", nil, nil); exists {
+		t.Error("a trashed folder's name should be free to reuse")
+	}
+
+	// Only the folder that was deleted is listed in the trash
+	trash, err := store.ListTrash(ctx)
+	if err != nil {
+		t.Fatalf("ListTrash() error = %v", err)
+	}
+	if len(trash) != 1 || trash[0].ID != parent.ID {
+		t.Errorf("ListTrash() = %v, want only Parent", trash)
+	}
+	if _, err := store.GetTrashedByID(ctx, child.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("GetTrashedByID() on folder trashed with parent error = %v, want ErrNoDocuments", err)
+	}
+
+	if err := store.Restore(ctx, parent.ID, nil); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	path, err := store.GetPath(ctx, grandchild.ID)
+	if err != nil {
+		t.Fatalf("GetPath() after restore error = %v", err)
+	}
+	if len(path) != 3 {
+		t.Errorf("GetPath() after restore count = %d, want 3", len(path))
+	}
+}
+
+func TestStore_SetPurgeJob(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	f, _ := store.Create(ctx, CreateInput{Name: "Big", CreatedByID: primitive.NewObjectID()})
+	jobID := primitive.NewObjectID()
+
+	// Only trashed folders are purged
+	if err := store.SetPurgeJob(ctx, f.ID, jobID); err != nil {
+		t.Fatalf("SetPurgeJob() error = %v", err)
+	}
+	if got, _ := store.GetByID(ctx, f.ID); got.PurgeJobID != nil {
+		t.Error("SetPurgeJob() should not mark a folder outside the trash")
+	}
+
+	if err := store.Trash(ctx, f.ID, nil, time.Now()); err != nil {
+		t.Fatalf("Trash() error = %v", err)
+	}
+	if err := store.SetPurgeJob(ctx, f.ID, jobID); err != nil {
+		t.Fatalf("SetPurgeJob() error = %v", err)
+	}
+	trashed, err := store.GetTrashedByID(ctx, f.ID)
+	if err != nil {
+		t.Fatalf("GetTrashedByID() error = %v", err)
+	}
+	if trashed.PurgeJobID == nil || *trashed.PurgeJobID != jobID {
+		t.Errorf("PurgeJobID = %v, want %s", trashed.PurgeJobID, jobID.Hex())
+	}
+
+	// A restored folder no longer refers to the job
+	if err := store.Restore(ctx, f.ID, nil); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, _ := store.GetByID(ctx, f.ID); got == nil || got.PurgeJobID != nil {
+		t.Error("Restore() should clear PurgeJobID")
+	}
+}
+
+func TestStore_DeleteTrashed(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	creatorID := primitive.NewObjectID()
+	parent, _ := store.Create(ctx, CreateInput{Name: "Parent", CreatedByID: creatorID})
+	child, _ := store.Create(ctx, CreateInput{Name: "Child", ParentID: &parent.ID, CreatedByID: creatorID})
+	other, _ := store.Create(ctx, CreateInput{Name: "Other", CreatedByID: creatorID})
+
+	// A live folder isn't deleted
+	if err := store.DeleteTrashed(ctx, other.ID); err != nil {
+		t.Fatalf("DeleteTrashed() error = %v", err)
+	}
+	if _, err := store.GetByID(ctx, other.ID); err != nil {
+		t.Errorf("DeleteTrashed() removed a live folder: %v", err)
+	}
+
+	store.Trash(ctx, parent.ID, []primitive.ObjectID{child.ID}, time.Now())
+	if err := store.DeleteTrashed(ctx, parent.ID); err != nil {
+		t.Fatalf("DeleteTrashed() error = %v", err)
+	}
+	count, _ := db.Collection("file_folders").CountDocuments(ctx, map[string]any{})
+	if count != 1 {
+		t.Errorf("folders left = %d, want 1", count)
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	parent, _ := store.Create(ctx, CreateInput{Name: "Finance", CreatedByID: primitive.NewObjectID()})
+	match, _ := store.Create(ctx, CreateInput{
+		ParentID:    &parent.ID,
+		Name:        "Invoices",
+		Description: "Paid and unpaid invoices",
+		CreatedByID: primitive.NewObjectID(),
+	})
+	store.Create(ctx, CreateInput{Name: "Photos", CreatedByID: primitive.NewObjectID()})
+
+	results, err := store.Search(ctx, "invoices", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID != match.ID {
+		t.Errorf("Search() = %+v, want only %q", results, match.Name)
+	}
+}
@@ -0,0 +1,129 @@
+// Package group provides storage for user groups.
+//
+// A group gathers users (see the groupmember store) so library resources
+// can be assigned to all of them at once (see the assignment store).
+package group
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dalemusser/waffle/pantry/text"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateName is returned when another group already has the name.
+var ErrDuplicateName = errors.New("a group with this name already exists")
+
+// Group is a named set of users.
+type Group struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	NameCI      string             `bson:"name_ci"` // Folded, for sorting and unique names
+	Description string             `bson:"description,omitempty"`
+	CreatedByID primitive.ObjectID `bson:"created_by_id"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at"`
+}
+
+// Store provides access to the groups collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new group store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("groups")}
+}
+
+// CreateInput contains the input for creating a group.
+type CreateInput struct {
+	Name        string
+	Description string
+	CreatedByID primitive.ObjectID
+}
+
+// Create creates a new group. It returns ErrDuplicateName if another group
+// has the same name, ignoring case.
+func (s *Store) Create(ctx context.Context, input CreateInput) (*Group, error) {
+	now := time.Now()
+	g := Group{
+		ID:          primitive.NewObjectID(),
+		Name:        input.Name,
+		NameCI:      text.Fold(input.Name),
+		Description: input.Description,
+		CreatedByID: input.CreatedByID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := s.c.InsertOne(ctx, g); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicateName
+		}
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetByID retrieves a group by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Group, error) {
+	var g Group
+	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetByIDs retrieves the groups with the given IDs, sorted by name.
+func (s *Store) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]Group, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return s.find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+// List returns all groups, sorted by name.
+func (s *Store) List(ctx context.Context) ([]Group, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *Store) find(ctx context.Context, filter bson.M) ([]Group, error) {
+	cur, err := s.c.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name_ci", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var groups []Group
+	if err := cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// Update changes a group's name and description. It returns
+// ErrDuplicateName if another group has the new name.
+func (s *Store) Update(ctx context.Context, id primitive.ObjectID, name, description string) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":        name,
+		"name_ci":     text.Fold(name),
+		"description": description,
+		"updated_at":  time.Now(),
+	}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateName
+	}
+	return err
+}
+
+// Delete deletes a group. Its members and assignments are deleted
+// separately.
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,81 @@
+package group
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_CreateAndList(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	creator := primitive.NewObjectID()
+	b, err := store.Create(ctx, CreateInput{Name: "Period 3", CreatedByID: creator})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	a, err := store.Create(ctx, CreateInput{Name: "Algebra", Description: "Morning class", CreatedByID: creator})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, CreateInput{Name: "period 3", CreatedByID: creator}); err != ErrDuplicateName {
+		t.Errorf("Create() duplicate error = %v, want %v", err, ErrDuplicateName)
+	}
+
+	groups, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(groups) != 2 || groups[0].ID != a.ID || groups[1].ID != b.ID {
+		t.Errorf("List() = %v, want Algebra then Period 3", groups)
+	}
+
+	got, err := store.GetByIDs(ctx, []primitive.ObjectID{b.ID})
+	if err != nil {
+		t.Fatalf("GetByIDs() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "Period 3" {
+		t.Errorf("GetByIDs() = %v, want Period 3", got)
+	}
+}
+
+func TestStore_Update(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	g, err := store.Create(ctx, CreateInput{Name: "Algebra"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, CreateInput{Name: "Geometry"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := store.Update(ctx, g.ID, "Algebra II", "Second year"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := store.GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "Algebra II" || got.Description != "Second year" {
+		t.Errorf("after Update() = %q, %q", got.Name, got.Description)
+	}
+
+	if err := store.Update(ctx, g.ID, "GEOMETRY", ""); err != ErrDuplicateName {
+		t.Errorf("Update() to a taken name error = %v, want %v", err, ErrDuplicateName)
+	}
+
+	if err := store.Delete(ctx, g.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.GetByID(ctx, g.ID); err == nil {
+		t.Error("GetByID() after Delete() should fail")
+	}
+}
@@ -0,0 +1,177 @@
+// Package groupmember provides storage for the members of user groups.
+//
+// Each member is a leader or a plain member of one group. Leaders can add
+// and remove their group's members; only admins manage leaders.
+package groupmember
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Member roles.
+const (
+	RoleLeader = "leader"
+	RoleMember = "member"
+)
+
+// ErrAlreadyMember is returned when adding a user to a group they are
+// already in.
+var ErrAlreadyMember = errors.New("the user is already in this group")
+
+// IsValidRole reports whether role is a member role.
+func IsValidRole(role string) bool {
+	return role == RoleLeader || role == RoleMember
+}
+
+// Member is a user's membership of a group.
+type Member struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	GroupID   primitive.ObjectID `bson:"group_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Role      string             `bson:"role"` // RoleLeader or RoleMember
+	AddedByID primitive.ObjectID `bson:"added_by_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Store provides access to the group_memberships collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new group member store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("group_memberships")}
+}
+
+// AddInput contains the input for adding a user to a group.
+type AddInput struct {
+	GroupID   primitive.ObjectID
+	UserID    primitive.ObjectID
+	Role      string
+	AddedByID primitive.ObjectID
+}
+
+// Add adds a user to a group. It returns ErrAlreadyMember if they are
+// already in it.
+func (s *Store) Add(ctx context.Context, input AddInput) (*Member, error) {
+	m := Member{
+		ID:        primitive.NewObjectID(),
+		GroupID:   input.GroupID,
+		UserID:    input.UserID,
+		Role:      input.Role,
+		AddedByID: input.AddedByID,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.c.InsertOne(ctx, m); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyMember
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Get returns a user's membership of a group, or mongo.ErrNoDocuments if
+// they aren't in it.
+func (s *Store) Get(ctx context.Context, groupID, userID primitive.ObjectID) (*Member, error) {
+	var m Member
+	if err := s.c.FindOne(ctx, bson.M{"group_id": groupID, "user_id": userID}).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListByGroup returns a group's members, leaders first, in the order they
+// were added.
+func (s *Store) ListByGroup(ctx context.Context, groupID primitive.ObjectID) ([]Member, error) {
+	return s.find(ctx, bson.M{"group_id": groupID}, bson.D{{Key: "role", Value: 1}, {Key: "created_at", Value: 1}})
+}
+
+// ListByUser returns a user's memberships.
+func (s *Store) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Member, error) {
+	return s.find(ctx, bson.M{"user_id": userID}, bson.D{{Key: "created_at", Value: 1}})
+}
+
+func (s *Store) find(ctx context.Context, filter bson.M, sort bson.D) ([]Member, error) {
+	cur, err := s.c.Find(ctx, filter, options.Find().SetSort(sort))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var members []Member
+	if err := cur.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// CountByGroup returns the number of members in each of the given groups.
+// Groups with no members are left out.
+func (s *Store) CountByGroup(ctx context.Context, groupIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	counts := make(map[primitive.ObjectID]int)
+	if len(groupIDs) == 0 {
+		return counts, nil
+	}
+	cur, err := s.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"group_id": bson.M{"$in": groupIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$group_id", "n": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var row struct {
+			ID primitive.ObjectID `bson:"_id"`
+			N  int                `bson:"n"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.ID] = row.N
+	}
+	return counts, cur.Err()
+}
+
+// SetRole changes a member's role. It returns mongo.ErrNoDocuments if the
+// user isn't in the group.
+func (s *Store) SetRole(ctx context.Context, groupID, userID primitive.ObjectID, role string) error {
+	res, err := s.c.UpdateOne(ctx, bson.M{"group_id": groupID, "user_id": userID}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Remove removes a user from a group. It reports whether they were in it.
+func (s *Store) Remove(ctx context.Context, groupID, userID primitive.ObjectID) (bool, error) {
+	res, err := s.c.DeleteOne(ctx, bson.M{"group_id": groupID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
+
+// DeleteByGroup removes all of a group's members.
+func (s *Store) DeleteByGroup(ctx context.Context, groupID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"group_id": groupID})
+	return err
+}
+
+// DeleteByUser removes a user from all their groups.
+func (s *Store) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
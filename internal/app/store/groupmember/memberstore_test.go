@@ -0,0 +1,76 @@
+package groupmember
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_Members(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	groupID := primitive.NewObjectID()
+	member := primitive.NewObjectID()
+	leader := primitive.NewObjectID()
+	if _, err := store.Add(ctx, AddInput{GroupID: groupID, UserID: member, Role: RoleMember}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := store.Add(ctx, AddInput{GroupID: groupID, UserID: leader, Role: RoleLeader}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := store.Add(ctx, AddInput{GroupID: groupID, UserID: member, Role: RoleLeader}); err != ErrAlreadyMember {
+		t.Errorf("Add() again error = %v, want %v", err, ErrAlreadyMember)
+	}
+
+	members, err := store.ListByGroup(ctx, groupID)
+	if err != nil {
+		t.Fatalf("ListByGroup() error = %v", err)
+	}
+	if len(members) != 2 || members[0].UserID != leader {
+		t.Errorf("ListByGroup() = %v, want the leader first", members)
+	}
+
+	other := primitive.NewObjectID()
+	if _, err := store.Add(ctx, AddInput{GroupID: other, UserID: member, Role: RoleMember}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	counts, err := store.CountByGroup(ctx, []primitive.ObjectID{groupID, other, primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("CountByGroup() error = %v", err)
+	}
+	if counts[groupID] != 2 || counts[other] != 1 || len(counts) != 2 {
+		t.Errorf("CountByGroup() = %v", counts)
+	}
+	if mine, _ := store.ListByUser(ctx, member); len(mine) != 2 {
+		t.Errorf("ListByUser() returned %d memberships, want 2", len(mine))
+	}
+
+	if err := store.SetRole(ctx, groupID, member, RoleLeader); err != nil {
+		t.Fatalf("SetRole() error = %v", err)
+	}
+	if m, _ := store.Get(ctx, groupID, member); m == nil || m.Role != RoleLeader {
+		t.Errorf("Get() after SetRole() = %v, want a leader", m)
+	}
+	if err := store.SetRole(ctx, groupID, primitive.NewObjectID(), RoleLeader); err != mongo.ErrNoDocuments {
+		t.Errorf("SetRole() for a non-member error = %v, want %v", err, mongo.ErrNoDocuments)
+	}
+
+	if removed, err := store.Remove(ctx, groupID, leader); err != nil || !removed {
+		t.Errorf("Remove() = %v, %v; want true", removed, err)
+	}
+	if removed, _ := store.Remove(ctx, groupID, leader); removed {
+		t.Error("Remove() twice should report nothing removed")
+	}
+
+	if err := store.DeleteByUser(ctx, member); err != nil {
+		t.Fatalf("DeleteByUser() error = %v", err)
+	}
+	if mine, _ := store.ListByUser(ctx, member); len(mine) != 0 {
+		t.Errorf("ListByUser() after DeleteByUser() returned %d memberships", len(mine))
+	}
+}
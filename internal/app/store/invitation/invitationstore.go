@@ -25,6 +25,14 @@ type Invitation struct {
 	UsedAt    *time.Time          `bson:"used_at,omitempty"`
 	Revoked   bool                `bson:"revoked"`
 	CreatedAt time.Time           `bson:"created_at"`
+
+	// Chosen by the inviter
+	ExpiryDays int    `bson:"expiry_days,omitempty"` // how long it lasts (0 = the store's default)
+	Message    string `bson:"message,omitempty"`     // personal note included in the email
+
+	// Set by the invitation expiry job
+	ReminderSentAt *time.Time `bson:"reminder_sent_at,omitempty"` // when the invitee was reminded
+	ExpiredAt      *time.Time `bson:"expired_at,omitempty"`       // when it was marked expired
 }
 
 // Store provides access to the invitations collection.
@@ -64,9 +72,11 @@ func (s *Store) EnsureIndexes(ctx context.Context) error {
 
 // CreateInput contains the input for creating an invitation.
 type CreateInput struct {
-	Email     string
-	Role      string
-	InvitedBy primitive.ObjectID
+	Email      string
+	Role       string
+	InvitedBy  primitive.ObjectID
+	ExpiryDays int    // How long the invitation lasts (0 = the store's default expiry)
+	Message    string // Personal note from the inviter (optional)
 }
 
 // Create creates a new invitation and returns it.
@@ -78,14 +88,16 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (*Invitation, err
 
 	now := time.Now()
 	inv := Invitation{
-		ID:        primitive.NewObjectID(),
-		Email:     input.Email,
-		Token:     token,
-		Role:      input.Role,
-		InvitedBy: input.InvitedBy,
-		ExpiresAt: now.Add(s.expiry),
-		Revoked:   false,
-		CreatedAt: now,
+		ID:         primitive.NewObjectID(),
+		Email:      input.Email,
+		Token:      token,
+		Role:       input.Role,
+		InvitedBy:  input.InvitedBy,
+		ExpiresAt:  now.Add(s.lifetime(input.ExpiryDays)),
+		Revoked:    false,
+		CreatedAt:  now,
+		ExpiryDays: input.ExpiryDays,
+		Message:    input.Message,
 	}
 
 	if _, err := s.c.InsertOne(ctx, inv); err != nil {
@@ -158,6 +170,124 @@ func (s *Store) ListPending(ctx context.Context) ([]Invitation, error) {
 	return invitations, nil
 }
 
+// ListOpen returns invitations that haven't been used or revoked, including
+// expired ones, newest first. Expired invitations stay listed until the
+// cleanup job removes them, so they can be resent or extended.
+func (s *Store) ListOpen(ctx context.Context) ([]Invitation, error) {
+	cursor, err := s.c.Find(ctx,
+		bson.M{"used_at": nil, "revoked": false},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []Invitation
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// ListReminderDue returns pending invitations that expire after now but by
+// before, and whose invitee hasn't been reminded.
+func (s *Store) ListReminderDue(ctx context.Context, now, before time.Time) ([]Invitation, error) {
+	return s.find(ctx, bson.M{
+		"used_at":          nil,
+		"revoked":          false,
+		"reminder_sent_at": nil,
+		"expires_at":       bson.M{"$gt": now, "$lte": before},
+	})
+}
+
+// MarkReminded records that the invitee was reminded. It reports false if
+// they already had been, so only one caller sends the reminder.
+func (s *Store) MarkReminded(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "reminder_sent_at": nil},
+		bson.M{"$set": bson.M{"reminder_sent_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// ListExpireDue returns unused, unrevoked invitations that expired by now
+// and haven't been marked expired.
+func (s *Store) ListExpireDue(ctx context.Context, now time.Time) ([]Invitation, error) {
+	return s.find(ctx, bson.M{
+		"used_at":    nil,
+		"revoked":    false,
+		"expired_at": nil,
+		"expires_at": bson.M{"$lte": now},
+	})
+}
+
+// MarkExpired records that an invitation expired. It reports false if it
+// was already marked, or was used, revoked, or extended since it was listed.
+func (s *Store) MarkExpired(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{
+			"_id":        id,
+			"used_at":    nil,
+			"revoked":    false,
+			"expired_at": nil,
+			"expires_at": bson.M{"$lte": at},
+		},
+		bson.M{"$set": bson.M{"expired_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// Extend gives an unused, unrevoked invitation its full expiry period again
+// from now, keeping its link. Expired invitations can be extended; they are
+// reminded and marked expired again as if new.
+func (s *Store) Extend(ctx context.Context, id primitive.ObjectID) (*Invitation, error) {
+	var inv Invitation
+	filter := bson.M{"_id": id, "used_at": nil, "revoked": false}
+	if err := s.c.FindOne(ctx, filter).Decode(&inv); err != nil {
+		return nil, err
+	}
+	err := s.c.FindOneAndUpdate(ctx,
+		filter,
+		bson.M{
+			"$set":   bson.M{"expires_at": time.Now().Add(s.lifetime(inv.ExpiryDays))},
+			"$unset": bson.M{"reminder_sent_at": "", "expired_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&inv)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// lifetime returns how long an invitation lasts: days if set, otherwise
+// the store's default expiry.
+func (s *Store) lifetime(days int) time.Duration {
+	if days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return s.expiry
+}
+
+// find returns the invitations matching filter.
+func (s *Store) find(ctx context.Context, filter bson.M) ([]Invitation, error) {
+	cursor, err := s.c.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var invitations []Invitation
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
 // GetByID returns an invitation by ID.
 func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Invitation, error) {
 	var inv Invitation
@@ -322,6 +322,80 @@ func TestStore_ListPending(t *testing.T) {
 	}
 }
 
+func TestStore_ReminderAndExpiry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db, testExpiry)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	inv, err := store.Create(ctx, CreateInput{
+		Email:     "remind@example.com",
+		Role:      "user",
+		InvitedBy: primitive.NewObjectID(),
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	now := time.Now()
+
+	// Not due while the expiry is further off than the reminder period
+	if due, _ := store.ListReminderDue(ctx, now, now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("ListReminderDue() with a short period = %d, want 0", len(due))
+	}
+	due, err := store.ListReminderDue(ctx, now, now.Add(2*testExpiry))
+	if err != nil {
+		t.Fatalf("ListReminderDue() error = %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("ListReminderDue() = %d, want 1", len(due))
+	}
+	if ok, err := store.MarkReminded(ctx, inv.ID, now); err != nil || !ok {
+		t.Fatalf("MarkReminded() = %v, %v, want true", ok, err)
+	}
+	if ok, _ := store.MarkReminded(ctx, inv.ID, now); ok {
+		t.Error("MarkReminded() again = true, want false")
+	}
+
+	// Expired as of a day past its expiry, once only
+	later := now.Add(2 * testExpiry)
+	expired, err := store.ListExpireDue(ctx, later)
+	if err != nil {
+		t.Fatalf("ListExpireDue() error = %v", err)
+	}
+	if len(expired) != 1 {
+		t.Fatalf("ListExpireDue() = %d, want 1", len(expired))
+	}
+	if ok, err := store.MarkExpired(ctx, inv.ID, later); err != nil || !ok {
+		t.Fatalf("MarkExpired() = %v, %v, want true", ok, err)
+	}
+	if expired, _ := store.ListExpireDue(ctx, later); len(expired) != 0 {
+		t.Errorf("ListExpireDue() after MarkExpired = %d, want 0", len(expired))
+	}
+
+	// Extending clears both marks and keeps the link
+	extended, err := store.Extend(ctx, inv.ID)
+	if err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if extended.Token != inv.Token || extended.ReminderSentAt != nil || extended.ExpiredAt != nil {
+		t.Errorf("Extend() = %+v, want same token and no reminder or expired marks", extended)
+	}
+	open, err := store.ListOpen(ctx)
+	if err != nil {
+		t.Fatalf("ListOpen() error = %v", err)
+	}
+	if len(open) != 1 {
+		t.Errorf("ListOpen() = %d, want 1", len(open))
+	}
+
+	if err := store.Revoke(ctx, inv.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := store.Extend(ctx, inv.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Extend() revoked error = %v, want ErrNoDocuments", err)
+	}
+}
+
 func TestStore_GetByID(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db, testExpiry)
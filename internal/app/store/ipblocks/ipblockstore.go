@@ -0,0 +1,93 @@
+// internal/app/store/ipblocks/ipblockstore.go
+package ipblockstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Block stops an IP address from logging in until BlockedUntil. Expired
+// blocks are removed by a TTL index.
+type Block struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	IP           string             `bson:"ip"`
+	Reason       string             `bson:"reason"` // Detection rule that raised it, e.g. "failed_logins"
+	BlockedUntil time.Time          `bson:"blocked_until"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
+
+// Store provides access to the ip_blocks collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new IP block store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("ip_blocks")}
+}
+
+// Block blocks ip until the given time, replacing any block it already has.
+func (s *Store) Block(ctx context.Context, ip, reason string, until time.Time) error {
+	now := time.Now().UTC()
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"ip": ip},
+		bson.M{
+			"$set": bson.M{
+				"reason":        reason,
+				"blocked_until": until.UTC(),
+				"created_at":    now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Active returns the block in force for ip, or nil if there is none.
+func (s *Store) Active(ctx context.Context, ip string) (*Block, error) {
+	var b Block
+	err := s.c.FindOne(ctx, bson.M{
+		"ip":            ip,
+		"blocked_until": bson.M{"$gt": time.Now()},
+	}).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListActive returns the blocks in force, latest first.
+func (s *Store) ListActive(ctx context.Context, limit int64) ([]Block, error) {
+	cur, err := s.c.Find(ctx,
+		bson.M{"blocked_until": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []Block
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Unblock lifts the block on ip. It reports whether there was one.
+func (s *Store) Unblock(ctx context.Context, ip string) (bool, error) {
+	res, err := s.c.DeleteOne(ctx, bson.M{"ip": ip})
+	if err != nil {
+		return false, err
+	}
+	return res.DeletedCount > 0, nil
+}
@@ -12,7 +12,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Job status constants.
+// Job status constants. A failed job has used all its attempts, or hit an
+// error not worth retrying, and waits in the dead-letter queue until it is
+// retried by hand.
 const (
 	StatusPending   = "pending"
 	StatusRunning   = "running"
@@ -21,6 +23,9 @@ const (
 	StatusCancelled = "cancelled"
 )
 
+// maxFailures is how many failed attempts a job keeps in its history.
+const maxFailures = 20
+
 // Job represents a background job.
 type Job struct {
 	ID          primitive.ObjectID `bson:"_id"`
@@ -32,7 +37,9 @@ type Job struct {
 	Attempts    int                `bson:"attempts"`     // Current attempt count
 	MaxAttempts int                `bson:"max_attempts"` // Maximum retry attempts
 	Error       string             `bson:"error,omitempty"`
+	Failures    []Failure          `bson:"failures,omitempty"` // Failed attempts, oldest first
 	Result      map[string]any     `bson:"result,omitempty"`
+	Progress    *Progress          `bson:"progress,omitempty"` // Reported by long-running jobs
 	ScheduledAt time.Time          `bson:"scheduled_at"`          // When to run (for delayed jobs)
 	StartedAt   *time.Time         `bson:"started_at,omitempty"`  // When processing started
 	CompletedAt *time.Time         `bson:"completed_at,omitempty"`// When processing finished
@@ -41,6 +48,39 @@ type Job struct {
 	WorkerID    string             `bson:"worker_id,omitempty"` // ID of worker processing this job
 }
 
+// Failure is one failed attempt at a job.
+type Failure struct {
+	Attempt    int       `bson:"attempt"`
+	Error      string    `bson:"error"`
+	WorkerID   string    `bson:"worker_id,omitempty"`
+	DurationMs int64     `bson:"duration_ms"`
+	FailedAt   time.Time `bson:"failed_at"`
+}
+
+// Retrying reports whether the job failed and is waiting to be retried.
+func (j Job) Retrying() bool {
+	return j.Status == StatusPending && j.Attempts > 0
+}
+
+// Progress is how far a running job has got: a count of work done out of
+// a total, and the step it is on. Either may be unset.
+type Progress struct {
+	Done  int64  `bson:"done"`
+	Total int64  `bson:"total"`
+	Step  string `bson:"step,omitempty"` // What the job is doing, e.g. "Deleting files"
+}
+
+// Percent returns how much of the work is done, from 0 to 100.
+func (p Progress) Percent() int {
+	if p.Total <= 0 {
+		return 0
+	}
+	if p.Done >= p.Total {
+		return 100
+	}
+	return int(p.Done * 100 / p.Total)
+}
+
 var (
 	// ErrNotFound is returned when a job is not found.
 	ErrNotFound = errors.New("job not found")
@@ -189,44 +229,72 @@ func (s *Store) Complete(ctx context.Context, id primitive.ObjectID, result map[
 	return err
 }
 
-// Fail marks a job as failed with an error message.
-// If the job has remaining attempts, it will be rescheduled.
-func (s *Store) Fail(ctx context.Context, id primitive.ObjectID, errMsg string, retryDelay time.Duration) error {
+// SetProgress records how far a running job has got.
+func (s *Store) SetProgress(ctx context.Context, id primitive.ObjectID, p Progress) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"progress":   p,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
+// Fail records a failed attempt. If the job has attempts left it is
+// rescheduled after retryDelay; otherwise it moves to the dead-letter
+// queue. Reports whether the job was dead-lettered.
+func (s *Store) Fail(ctx context.Context, id primitive.ObjectID, failure Failure, retryDelay time.Duration) (bool, error) {
 	// First get the job to check attempts
 	job, err := s.GetByID(ctx, id)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	now := time.Now()
-
-	// If we have remaining attempts, reschedule
-	if job.Attempts < job.MaxAttempts {
-		_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
-			"$set": bson.M{
-				"status":       StatusPending,
-				"error":        errMsg,
-				"scheduled_at": now.Add(retryDelay),
-				"started_at":   nil,
-				"worker_id":    "",
-				"updated_at":   now,
-			},
-		})
-		return err
+	// No more attempts - dead-letter it
+	if job.Attempts >= job.MaxAttempts {
+		return true, s.DeadLetter(ctx, id, failure)
 	}
 
-	// No more attempts - mark as failed
+	now := time.Now()
 	_, err = s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"status":       StatusPending,
+			"error":        failure.Error,
+			"scheduled_at": now.Add(retryDelay),
+			"started_at":   nil,
+			"worker_id":    "",
+			"updated_at":   now,
+		},
+		"$push": pushFailure(failure),
+	})
+	return false, err
+}
+
+// DeadLetter records a failed attempt and moves the job to the dead-letter
+// queue without further retries.
+func (s *Store) DeadLetter(ctx context.Context, id primitive.ObjectID, failure Failure) error {
+	now := time.Now()
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
 		"$set": bson.M{
 			"status":       StatusFailed,
-			"error":        errMsg,
+			"error":        failure.Error,
 			"completed_at": now,
 			"updated_at":   now,
 		},
+		"$push": pushFailure(failure),
 	})
 	return err
 }
 
+// pushFailure appends a failure to a job's history, keeping the most
+// recent maxFailures.
+func pushFailure(failure Failure) bson.M {
+	return bson.M{"failures": bson.M{
+		"$each":  []Failure{failure},
+		"$slice": -maxFailures,
+	}}
+}
+
 // Cancel cancels a pending or running job.
 func (s *Store) Cancel(ctx context.Context, id primitive.ObjectID) error {
 	now := time.Now()
@@ -249,12 +317,17 @@ func (s *Store) Cancel(ctx context.Context, id primitive.ObjectID) error {
 	return nil
 }
 
-// Retry retries a failed or cancelled job.
+// Retry runs a failed or cancelled job again, or runs a job waiting for a
+// retry now instead of after its backoff. A dead-lettered job gets one
+// more attempt.
 func (s *Store) Retry(ctx context.Context, id primitive.ObjectID) error {
 	now := time.Now()
 	result, err := s.c.UpdateOne(ctx, bson.M{
-		"_id":    id,
-		"status": bson.M{"$in": []string{StatusFailed, StatusCancelled}},
+		"_id": id,
+		"$or": []bson.M{
+			{"status": bson.M{"$in": []string{StatusFailed, StatusCancelled}}},
+			{"status": StatusPending, "attempts": bson.M{"$gt": 0}},
+		},
 	}, bson.M{
 		"$set": bson.M{
 			"status":       StatusPending,
@@ -263,6 +336,7 @@ func (s *Store) Retry(ctx context.Context, id primitive.ObjectID) error {
 			"completed_at": nil,
 			"worker_id":    "",
 			"error":        "",
+			"progress":     nil,
 			"updated_at":   now,
 		},
 	})
@@ -275,6 +349,62 @@ func (s *Store) Retry(ctx context.Context, id primitive.ObjectID) error {
 	return nil
 }
 
+// RetryFailed runs every dead-lettered job again, optionally only those
+// in one queue or of one type, and returns how many were requeued.
+func (s *Store) RetryFailed(ctx context.Context, queueName, jobType string) (int64, error) {
+	filter := bson.M{"status": StatusFailed}
+	if queueName != "" {
+		filter["queue_name"] = queueName
+	}
+	if jobType != "" {
+		filter["job_type"] = jobType
+	}
+	now := time.Now()
+	result, err := s.c.UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{
+			"status":       StatusPending,
+			"scheduled_at": now,
+			"started_at":   nil,
+			"completed_at": nil,
+			"worker_id":    "",
+			"error":        "",
+			"progress":     nil,
+			"updated_at":   now,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// Retrying returns jobs waiting to be retried after a failed attempt,
+// soonest first.
+func (s *Store) Retrying(ctx context.Context, limit int) ([]Job, error) {
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "scheduled_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cur, err := s.c.Find(ctx, bson.M{"status": StatusPending, "attempts": bson.M{"$gt": 0}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var jobs []Job
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
 // GetByID retrieves a job by ID.
 func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Job, error) {
 	var job Job
@@ -32,13 +32,26 @@ type Entry struct {
 	ActorID   string `bson:"actor_id,omitempty"`   // API key ID or user ID
 	ActorName string `bson:"actor_name,omitempty"` // Display name
 
+	// Player and game the request was about, from the request body
+	UserID string `bson:"user_id,omitempty"`
+	Game   string `bson:"game,omitempty"`
+
 	// Request body handling
 	RequestBodySize    int64  `bson:"request_body_size"`
 	RequestBodyHash    string `bson:"request_body_hash,omitempty"`    // SHA256 first 8 chars
 	RequestBodyPreview string `bson:"request_body_preview,omitempty"` // First 500 chars
-	RequestBody        string `bson:"request_body,omitempty"`         // Full body (only saved on errors)
+	RequestBody        string `bson:"request_body,omitempty"`         // Full body (saved on errors and in full capture)
 	RequestContentType string `bson:"request_content_type,omitempty"`
 
+	// Set when a successful request was logged by sampling; the entry
+	// stands for this many requests
+	SampleRate int `bson:"sample_rate,omitempty"`
+
+	// Full capture (routes in debug mode)
+	Captured         bool       `bson:"captured,omitempty"`           // Request and response bodies were captured
+	ResponseBody     string     `bson:"response_body,omitempty"`      // Masked response body
+	CaptureExpiresAt *time.Time `bson:"capture_expires_at,omitempty"` // When the captured bodies are removed
+
 	// Response metadata
 	StatusCode   int    `bson:"status_code"`
 	ResponseSize int64  `bson:"response_size"`
@@ -112,6 +125,10 @@ type ListFilter struct {
 	ActorType string
 	ActorID   string
 
+	// Player filters
+	UserID string
+	Game   string
+
 	// Request filters
 	Method     string
 	PathPrefix string
@@ -122,8 +139,14 @@ type ListFilter struct {
 	StatusCodeMax *int
 	ErrorClass    string
 
+	// Captured limits results to entries from full capture
+	Captured bool
+
+	// Sampled limits results to sampled successful requests
+	Sampled bool
+
 	// Search
-	Search string // Searches request_id, path, actor_name
+	Search string // Searches request_id, client_request_id, path, actor_name
 }
 
 // ListResult contains a page of ledger entries with pagination info.
@@ -212,6 +235,14 @@ func (s *Store) buildQuery(filter ListFilter) bson.M {
 		query["actor_id"] = filter.ActorID
 	}
 
+	// Player filters
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Game != "" {
+		query["game"] = filter.Game
+	}
+
 	// Request filters
 	if filter.Method != "" {
 		query["method"] = filter.Method
@@ -236,11 +267,18 @@ func (s *Store) buildQuery(filter ListFilter) bson.M {
 	if filter.ErrorClass != "" {
 		query["error_class"] = filter.ErrorClass
 	}
+	if filter.Captured {
+		query["captured"] = true
+	}
+	if filter.Sampled {
+		query["sample_rate"] = bson.M{"$gt": 0}
+	}
 
 	// Search
 	if filter.Search != "" {
 		query["$or"] = []bson.M{
 			{"request_id": bson.M{"$regex": filter.Search, "$options": "i"}},
+			{"client_request_id": bson.M{"$regex": filter.Search, "$options": "i"}},
 			{"path": bson.M{"$regex": filter.Search, "$options": "i"}},
 			{"actor_name": bson.M{"$regex": filter.Search, "$options": "i"}},
 		}
@@ -288,6 +326,56 @@ func (s *Store) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, e
 	return result.DeletedCount, nil
 }
 
+// ArchivableBatch returns up to limit of the oldest entries that started
+// before cutoff, oldest first.
+func (s *Store) ArchivableBatch(ctx context.Context, cutoff time.Time, limit int) ([]Entry, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cur, err := s.c.Find(ctx, bson.M{"started_at": bson.M{"$lt": cutoff}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var entries []Entry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// DeleteByIDs deletes the entries with the given IDs.
+func (s *Store) DeleteByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := s.c.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// ExpireCapturedBodies removes the request and response bodies of captured
+// entries whose capture has expired. The entries themselves are kept.
+func (s *Store) ExpireCapturedBodies(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.c.UpdateMany(ctx, bson.M{
+		"capture_expires_at": bson.M{"$lte": now},
+	}, bson.M{
+		"$unset": bson.M{
+			"request_body":         "",
+			"request_body_preview": "",
+			"response_body":        "",
+			"capture_expires_at":   "",
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 // CountByStatus returns counts grouped by status code ranges.
 func (s *Store) CountByStatus(ctx context.Context, start, end time.Time) (map[string]int64, error) {
 	pipeline := []bson.M{
@@ -335,6 +423,104 @@ func (s *Store) CountByStatus(ctx context.Context, start, end time.Time) (map[st
 	return result, nil
 }
 
+// CountErrorsByActor returns the number of error responses (status >= 400)
+// per actor ID for the given actor type since the given time.
+func (s *Store) CountErrorsByActor(ctx context.Context, actorType string, since time.Time) (map[string]int64, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"started_at":  bson.M{"$gte": since},
+				"actor_type":  actorType,
+				"actor_id":    bson.M{"$exists": true, "$ne": ""},
+				"status_code": bson.M{"$gte": 400},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":   "$actor_id",
+				"count": bson.M{"$sum": 1},
+			},
+		},
+	}
+
+	cur, err := s.c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	result := make(map[string]int64)
+	for cur.Next(ctx) {
+		var doc struct {
+			ID    string `bson:"_id"`
+			Count int64  `bson:"count"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			continue
+		}
+		result[doc.ID] = doc.Count
+	}
+
+	return result, nil
+}
+
+// SampleStats summarizes the sampled successful requests in a time range.
+type SampleStats struct {
+	Entries         int64   // Sampled entries stored
+	Estimated       int64   // Successful requests they stand for
+	AvgMs           float64 // Average total time
+	MaxMs           float64 // Slowest sampled request
+	AvgRequestSize  float64 // Average request body size, in bytes
+	AvgResponseSize float64 // Average response size, in bytes
+}
+
+// SampledSuccessStats returns latency and size figures for the sampled
+// successful requests that started between start and end.
+func (s *Store) SampledSuccessStats(ctx context.Context, start, end time.Time) (SampleStats, error) {
+	pipeline := []bson.M{
+		{
+			"$match": bson.M{
+				"started_at":  bson.M{"$gte": start, "$lte": end},
+				"sample_rate": bson.M{"$gt": 0},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":               nil,
+				"entries":           bson.M{"$sum": 1},
+				"estimated":         bson.M{"$sum": "$sample_rate"},
+				"avg_ms":            bson.M{"$avg": "$timing.total_ms"},
+				"max_ms":            bson.M{"$max": "$timing.total_ms"},
+				"avg_request_size":  bson.M{"$avg": "$request_body_size"},
+				"avg_response_size": bson.M{"$avg": "$response_size"},
+			},
+		},
+	}
+
+	cur, err := s.c.Aggregate(ctx, pipeline)
+	if err != nil {
+		return SampleStats{}, err
+	}
+	defer cur.Close(ctx)
+
+	var stats SampleStats
+	if cur.Next(ctx) {
+		var doc struct {
+			Entries         int64   `bson:"entries"`
+			Estimated       int64   `bson:"estimated"`
+			AvgMs           float64 `bson:"avg_ms"`
+			MaxMs           float64 `bson:"max_ms"`
+			AvgRequestSize  float64 `bson:"avg_request_size"`
+			AvgResponseSize float64 `bson:"avg_response_size"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return SampleStats{}, err
+		}
+		stats = SampleStats(doc)
+	}
+	return stats, cur.Err()
+}
+
 // AverageResponseTime returns the average response time in milliseconds.
 func (s *Store) AverageResponseTime(ctx context.Context, start, end time.Time) (float64, error) {
 	pipeline := []bson.M{
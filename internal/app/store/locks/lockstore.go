@@ -0,0 +1,105 @@
+// internal/app/store/locks/lockstore.go
+package lockstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when no instance has held a lock.
+var ErrNotFound = errors.New("lock not found")
+
+// Lock is a lease on a named resource, held by one app instance until it
+// expires or is released. The holder extends the lease while it works, so
+// a lock whose holder stopped is free again once its lease runs out.
+type Lock struct {
+	Name       string    `bson:"_id"`
+	Owner      string    `bson:"owner"`       // Instance ID of the holder
+	AcquiredAt time.Time `bson:"acquired_at"` // When the holder took it
+	ExpiresAt  time.Time `bson:"expires_at"`
+}
+
+// Held reports whether the lock is held at now.
+func (l Lock) Held(now time.Time) bool {
+	return l.Owner != "" && l.ExpiresAt.After(now)
+}
+
+// Store manages distributed locks.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new lock store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("locks")}
+}
+
+// Acquire takes the named lock for owner until now+ttl, or extends it if
+// owner already holds it. It reports false if another owner holds it.
+func (s *Store) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	// A pipeline update keeps acquired_at when the holder extends its lease
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"owner":      owner,
+		"expires_at": now.Add(ttl),
+		"acquired_at": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{"$owner", owner}}, "$acquired_at", now,
+		}},
+	}}}}
+	_, err := s.c.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The lock exists and another owner holds it
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release gives up the named lock if owner holds it.
+func (s *Store) Release(ctx context.Context, name, owner string) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	return err
+}
+
+// Get returns the named lock, held or not.
+func (s *Store) Get(ctx context.Context, name string) (Lock, error) {
+	var l Lock
+	err := s.c.FindOne(ctx, bson.M{"_id": name}).Decode(&l)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Lock{}, ErrNotFound
+	}
+	return l, err
+}
+
+// Held returns the locks held at now, keyed by name.
+func (s *Store) Held(ctx context.Context, now time.Time) (map[string]Lock, error) {
+	cur, err := s.c.Find(ctx, bson.M{"expires_at": bson.M{"$gt": now}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var list []Lock
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Lock, len(list))
+	for _, l := range list {
+		byName[l.Name] = l
+	}
+	return byName, nil
+}
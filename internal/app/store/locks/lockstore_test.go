@@ -0,0 +1,100 @@
+package lockstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+)
+
+func TestStore_Acquire(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	ok, err := store.Acquire(ctx, "nightly", "host-a", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Acquire() by first owner = %v, %v; want true", ok, err)
+	}
+	first, err := store.Get(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	ok, err = store.Acquire(ctx, "nightly", "host-b", time.Minute)
+	if err != nil || ok {
+		t.Fatalf("Acquire() of a held lock = %v, %v; want false", ok, err)
+	}
+
+	// The holder extends its lease and keeps its acquired time
+	ok, err = store.Acquire(ctx, "nightly", "host-a", time.Hour)
+	if err != nil || !ok {
+		t.Fatalf("Acquire() by the holder = %v, %v; want true", ok, err)
+	}
+	renewed, _ := store.Get(ctx, "nightly")
+	if !renewed.ExpiresAt.After(first.ExpiresAt) {
+		t.Error("renewal did not extend the lease")
+	}
+	if !renewed.AcquiredAt.Equal(first.AcquiredAt) {
+		t.Errorf("AcquiredAt = %v after renewal, want %v", renewed.AcquiredAt, first.AcquiredAt)
+	}
+
+	held, err := store.Held(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Held() error = %v", err)
+	}
+	if held["nightly"].Owner != "host-a" {
+		t.Errorf("Held() = %+v, want nightly held by host-a", held)
+	}
+}
+
+func TestStore_AcquireExpired(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if _, err := store.Acquire(ctx, "nightly", "host-a", -time.Second); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	ok, err := store.Acquire(ctx, "nightly", "host-b", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("Acquire() of an expired lock = %v, %v; want true", ok, err)
+	}
+	l, _ := store.Get(ctx, "nightly")
+	if l.Owner != "host-b" {
+		t.Errorf("Owner = %q, want host-b", l.Owner)
+	}
+}
+
+func TestStore_Release(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if _, err := store.Acquire(ctx, "nightly", "host-a", time.Minute); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	// Only the holder can release
+	if err := store.Release(ctx, "nightly", "host-b"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "nightly"); err != nil {
+		t.Fatalf("lock released by a non-holder: %v", err)
+	}
+
+	if err := store.Release(ctx, "nightly", "host-a"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "nightly"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after release error = %v, want ErrNotFound", err)
+	}
+	ok, err := store.Acquire(ctx, "nightly", "host-b", time.Minute)
+	if err != nil || !ok {
+		t.Errorf("Acquire() after release = %v, %v; want true", ok, err)
+	}
+}
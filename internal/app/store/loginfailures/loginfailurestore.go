@@ -0,0 +1,74 @@
+// internal/app/store/loginfailures/loginfailurestore.go
+package loginfailurestore
+
+// Terminology: User Identifiers
+//   - UserID / userID / user_id: The MongoDB ObjectID (_id) that uniquely identifies a user record
+//   - LoginID / loginID / login_id: The human-readable string users type to log in
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Retention is how long failures are kept; a TTL index removes them after.
+const Retention = 24 * time.Hour
+
+// Failure is one failed login, recorded by IP so attempts against many
+// accounts from one address can be spotted.
+type Failure struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	IP        string             `bson:"ip"`
+	LoginID   string             `bson:"login_id"` // Normalized (lowercase)
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Store provides access to the login_failures collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new login failure store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("login_failures")}
+}
+
+// Record stores a failed login for loginID from ip.
+func (s *Store) Record(ctx context.Context, ip, loginID string) error {
+	_, err := s.c.InsertOne(ctx, Failure{
+		IP:        ip,
+		LoginID:   strings.ToLower(strings.TrimSpace(loginID)),
+		CreatedAt: time.Now().UTC(),
+	})
+	return err
+}
+
+// Accounts returns the distinct login IDs that failed from ip since the
+// given time.
+func (s *Store) Accounts(ctx context.Context, ip string, since time.Time) ([]string, error) {
+	vals, err := s.c.Distinct(ctx, "login_id", bson.M{
+		"ip":         ip,
+		"created_at": bson.M{"$gte": since},
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if id, ok := v.(string); ok {
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// ClearIP removes the failures recorded for ip, so the same attempts don't
+// raise a second alert.
+func (s *Store) ClearIP(ctx context.Context, ip string) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"ip": ip})
+	return err
+}
@@ -0,0 +1,56 @@
+// internal/app/store/loginlocations/loginlocationstore.go
+package loginlocationstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Location is where a user last logged in from, as reported by the
+// proxy's geolocation headers.
+type Location struct {
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Latitude  float64            `bson:"latitude"`
+	Longitude float64            `bson:"longitude"`
+	IP        string             `bson:"ip"`
+	At        time.Time          `bson:"at"`
+}
+
+// Store keeps the last known login location of each user.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new login location store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("login_locations")}
+}
+
+// Last returns the user's last login location, or nil if none is known.
+func (s *Store) Last(ctx context.Context, userID primitive.ObjectID) (*Location, error) {
+	var loc Location
+	err := s.c.FindOne(ctx, bson.M{"user_id": userID}).Decode(&loc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// Set replaces the user's last login location.
+func (s *Store) Set(ctx context.Context, loc Location) error {
+	_, err := s.c.ReplaceOne(ctx,
+		bson.M{"user_id": loc.UserID},
+		loc,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
@@ -13,10 +13,11 @@ import (
 
 // State represents an OAuth state token record.
 type State struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	State     string             `bson:"state"`
-	ExpiresAt time.Time          `bson:"expires_at"`
-	CreatedAt time.Time          `bson:"created_at"`
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	State     string              `bson:"state"`
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty"` // Set when a signed-in user is linking a Google account
+	ExpiresAt time.Time           `bson:"expires_at"`
+	CreatedAt time.Time           `bson:"created_at"`
 }
 
 // Store provides access to the oauth_states collection.
@@ -50,13 +51,21 @@ func (s *Store) EnsureIndexes(ctx context.Context) error {
 
 // Create stores a new OAuth state token (expires in 10 minutes).
 func (s *Store) Create(ctx context.Context, state string) error {
+	return s.insert(ctx, State{State: state})
+}
+
+// CreateForUser stores a new OAuth state token for a signed-in user linking
+// an account to theirs (expires in 10 minutes).
+func (s *Store) CreateForUser(ctx context.Context, state string, userID primitive.ObjectID) error {
+	return s.insert(ctx, State{State: state, UserID: &userID})
+}
+
+// insert stores doc with a new ID and a 10 minute expiry.
+func (s *Store) insert(ctx context.Context, doc State) error {
 	now := time.Now()
-	doc := State{
-		ID:        primitive.NewObjectID(),
-		State:     state,
-		ExpiresAt: now.Add(10 * time.Minute),
-		CreatedAt: now,
-	}
+	doc.ID = primitive.NewObjectID()
+	doc.ExpiresAt = now.Add(10 * time.Minute)
+	doc.CreatedAt = now
 
 	_, err := s.c.InsertOne(ctx, doc)
 	return err
@@ -65,11 +74,21 @@ func (s *Store) Create(ctx context.Context, state string) error {
 // Verify checks if a state token is valid and deletes it (single use).
 // Returns true if the state was valid, false otherwise.
 func (s *Store) Verify(ctx context.Context, state string) bool {
+	_, ok := s.Consume(ctx, state)
+	return ok
+}
+
+// Consume checks a state token and deletes it (single use), returning the
+// stored record. ok is false if the token is unknown or expired.
+func (s *Store) Consume(ctx context.Context, state string) (st *State, ok bool) {
 	filter := bson.M{
 		"state":      state,
 		"expires_at": bson.M{"$gt": time.Now()},
 	}
 
-	result := s.c.FindOneAndDelete(ctx, filter)
-	return result.Err() == nil
+	var doc State
+	if err := s.c.FindOneAndDelete(ctx, filter).Decode(&doc); err != nil {
+		return nil, false
+	}
+	return &doc, true
 }
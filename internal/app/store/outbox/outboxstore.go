@@ -0,0 +1,304 @@
+// internal/app/store/outbox/outboxstore.go
+package outboxstore
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Message status constants.
+const (
+	StatusQueued   = "queued"   // Waiting for its first delivery attempt
+	StatusRetrying = "retrying" // A delivery attempt failed; another is scheduled
+	StatusSent     = "sent"     // Accepted by the SMTP server
+	StatusFailed   = "failed"   // All delivery attempts failed
+)
+
+// Message is an outbound email waiting for, or finished with, delivery.
+type Message struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	To            string             `bson:"to"`
+	Subject       string             `bson:"subject"`
+	TextBody      string             `bson:"text_body,omitempty"`   // Removed once sent
+	HTMLBody      string             `bson:"html_body,omitempty"`   // Removed once sent
+	Attachments   []Attachment       `bson:"attachments,omitempty"` // Removed once sent
+	Template      string             `bson:"template,omitempty"`
+	UserID        string             `bson:"user_id,omitempty"` // Recipient's user ID (hex), if known
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts"`
+	LastError     string             `bson:"last_error,omitempty"`
+	JobID         primitive.ObjectID `bson:"job_id,omitempty"`          // Job delivering this message
+	NextAttemptAt *time.Time         `bson:"next_attempt_at,omitempty"` // Set while retrying
+	SentAt        *time.Time         `bson:"sent_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+}
+
+// Attachment is a file sent with a message. See mailer.Attachment.
+type Attachment struct {
+	Filename    string `bson:"filename"`
+	ContentType string `bson:"content_type,omitempty"`
+	Data        []byte `bson:"data"`
+	ContentID   string `bson:"content_id,omitempty"`
+}
+
+// ErrNotFound is returned when a message is not found.
+var ErrNotFound = errors.New("message not found")
+
+// Store provides access to the email_outbox collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new outbox store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("email_outbox")}
+}
+
+// CreateInput holds the fields for queuing a message.
+type CreateInput struct {
+	To          string
+	Subject     string
+	TextBody    string
+	HTMLBody    string
+	Attachments []Attachment
+	Template    string
+	UserID      string
+	MaxAttempts int
+}
+
+// Create stores a new queued message.
+func (s *Store) Create(ctx context.Context, input CreateInput) (Message, error) {
+	now := time.Now()
+
+	maxAttempts := input.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	msg := Message{
+		ID:          primitive.NewObjectID(),
+		To:          input.To,
+		Subject:     input.Subject,
+		TextBody:    input.TextBody,
+		HTMLBody:    input.HTMLBody,
+		Attachments: input.Attachments,
+		Template:    input.Template,
+		UserID:      input.UserID,
+		Status:      StatusQueued,
+		MaxAttempts: maxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := s.c.InsertOne(ctx, msg); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// SetJobID records the job that delivers a message.
+func (s *Store) SetJobID(ctx context.Context, id, jobID primitive.ObjectID) error {
+	_, err := s.c.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"job_id": jobID, "updated_at": time.Now()},
+	})
+	return err
+}
+
+// GetByID retrieves a message by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Message, error) {
+	var msg Message
+	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&msg); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// MarkSent records a successful delivery attempt. The bodies and attachments
+// are removed because emails often carry sign-in and reset links, or
+// exported data, that shouldn't stay readable in the database once
+// delivered.
+func (s *Store) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	_, err := s.c.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{"status": StatusSent, "sent_at": now, "updated_at": now},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"next_attempt_at": "", "text_body": "", "html_body": "", "attachments": ""},
+	})
+	return err
+}
+
+// MarkAttemptFailed records a failed delivery attempt. If nextAttempt is nil
+// the message has no attempts left and is marked failed; otherwise it is
+// marked retrying with the time of the next attempt.
+func (s *Store) MarkAttemptFailed(ctx context.Context, id primitive.ObjectID, errMsg string, nextAttempt *time.Time) error {
+	now := time.Now()
+	update := bson.M{
+		"$inc": bson.M{"attempts": 1},
+	}
+	if nextAttempt != nil {
+		update["$set"] = bson.M{
+			"status":          StatusRetrying,
+			"last_error":      errMsg,
+			"next_attempt_at": *nextAttempt,
+			"updated_at":      now,
+		}
+	} else {
+		update["$set"] = bson.M{
+			"status":     StatusFailed,
+			"last_error": errMsg,
+			"updated_at": now,
+		}
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+	_, err := s.c.UpdateByID(ctx, id, update)
+	return err
+}
+
+// Requeue resets a failed message so it can be delivered again.
+// Returns ErrNotFound if the message does not exist or has not failed.
+func (s *Store) Requeue(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.c.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": StatusFailed,
+	}, bson.M{
+		"$set": bson.M{
+			"status":     StatusQueued,
+			"attempts":   0,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListFilter specifies criteria for listing messages.
+type ListFilter struct {
+	Status string
+	To     string // Case-insensitive prefix match on the recipient
+}
+
+// ListResult contains a page of messages with pagination info.
+type ListResult struct {
+	Messages   []Message
+	TotalCount int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// List returns messages matching the filter, newest first. Attachment
+// data is left out; load a message with GetByID to get it.
+func (s *Store) List(ctx context.Context, filter ListFilter, page, pageSize int) (ListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 200 {
+		pageSize = 200
+	}
+
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.To != "" {
+		query["to"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.To), "$options": "i"}
+	}
+
+	total, err := s.c.CountDocuments(ctx, query)
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	skip := (page - 1) * pageSize
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize)).
+		SetProjection(bson.M{"attachments.data": 0})
+
+	cur, err := s.c.Find(ctx, query, opts)
+	if err != nil {
+		return ListResult{}, err
+	}
+	defer cur.Close(ctx)
+
+	var msgs []Message
+	if err := cur.All(ctx, &msgs); err != nil {
+		return ListResult{}, err
+	}
+
+	return ListResult{
+		Messages:   msgs,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// CountByStatus returns the number of messages in each status.
+func (s *Store) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	cur, err := s.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cur.Next(ctx) {
+		var row struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Status] = row.Count
+	}
+	return counts, cur.Err()
+}
+
+// Delete removes a message.
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeleteSentBefore removes sent messages older than cutoff.
+func (s *Store) DeleteSentBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.c.DeleteMany(ctx, bson.M{
+		"status":  StatusSent,
+		"sent_at": bson.M{"$lt": cutoff},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
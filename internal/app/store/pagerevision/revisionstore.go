@@ -0,0 +1,112 @@
+// Package pagerevision provides storage for the published versions of
+// content pages.
+//
+// Every time a page is published, its title and content are saved as a
+// new numbered revision, so changes to pages such as the terms of service
+// can be reviewed later and an earlier version can be published again.
+package pagerevision
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Revision is one published version of a page.
+type Revision struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty"`
+	Slug            string              `bson:"slug"`
+	Version         int                 `bson:"version"` // 1 for the first revision of the page
+	Title           string              `bson:"title"`
+	Content         string              `bson:"content"`
+	PublishedAt     time.Time           `bson:"published_at"`
+	PublishedByID   *primitive.ObjectID `bson:"published_by_id,omitempty"`
+	PublishedByName string              `bson:"published_by_name,omitempty"`
+	RestoredFrom    int                 `bson:"restored_from,omitempty"` // version this one republished, if a rollback
+}
+
+// Store provides access to the page_revisions collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new page revision store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("page_revisions")}
+}
+
+// Create saves rev as the page's next version and returns it with its ID
+// and Version set.
+func (s *Store) Create(ctx context.Context, rev Revision) (Revision, error) {
+	rev.PublishedAt = rev.PublishedAt.UTC()
+	if rev.PublishedAt.IsZero() {
+		rev.PublishedAt = time.Now().UTC()
+	}
+
+	// Two publishes at once can pick the same version; the unique index
+	// turns one away, and it tries again with the next number.
+	for attempt := 0; ; attempt++ {
+		latest, err := s.Latest(ctx, rev.Slug)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return Revision{}, err
+		}
+		rev.ID = primitive.NewObjectID()
+		rev.Version = latest.Version + 1
+		_, err = s.c.InsertOne(ctx, rev)
+		if err == nil {
+			return rev, nil
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == 2 {
+			return Revision{}, err
+		}
+	}
+}
+
+// Latest returns the page's most recent revision, or mongo.ErrNoDocuments
+// if it has none.
+func (s *Store) Latest(ctx context.Context, slug string) (Revision, error) {
+	var rev Revision
+	err := s.c.FindOne(ctx, bson.M{"slug": slug},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})).Decode(&rev)
+	return rev, err
+}
+
+// GetByID returns a revision of the page.
+func (s *Store) GetByID(ctx context.Context, slug string, id primitive.ObjectID) (Revision, error) {
+	var rev Revision
+	err := s.c.FindOne(ctx, bson.M{"_id": id, "slug": slug}).Decode(&rev)
+	return rev, err
+}
+
+// List returns the page's revisions, newest first, without their content.
+func (s *Store) List(ctx context.Context, slug string) ([]Revision, error) {
+	cur, err := s.c.Find(ctx, bson.M{"slug": slug}, options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"content": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var revs []Revision
+	if err := cur.All(ctx, &revs); err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// RenameSlug moves a page's revisions to its new slug.
+func (s *Store) RenameSlug(ctx context.Context, from, to string) error {
+	_, err := s.c.UpdateMany(ctx, bson.M{"slug": from}, bson.M{"$set": bson.M{"slug": to}})
+	return err
+}
+
+// DeleteBySlug removes all of a page's revisions.
+func (s *Store) DeleteBySlug(ctx context.Context, slug string) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"slug": slug})
+	return err
+}
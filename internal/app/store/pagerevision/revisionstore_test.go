@@ -0,0 +1,49 @@
+package pagerevision
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+)
+
+func TestStore_CreateAndList(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	first, err := store.Create(ctx, Revision{Slug: "terms", Title: "Terms", Content: "<p>v1</p>"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	second, err := store.Create(ctx, Revision{Slug: "terms", Title: "Terms", Content: "<p>v2</p>", RestoredFrom: 1})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	other, err := store.Create(ctx, Revision{Slug: "privacy", Title: "Privacy"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if first.Version != 1 || second.Version != 2 || other.Version != 1 {
+		t.Errorf("versions = %d, %d, %d; want 1, 2, 1", first.Version, second.Version, other.Version)
+	}
+
+	revs, err := store.List(ctx, "terms")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(revs) != 2 || revs[0].Version != 2 || revs[0].Content != "" {
+		t.Errorf("List() = %+v, want both versions newest first without content", revs)
+	}
+
+	got, err := store.GetByID(ctx, "terms", first.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Content != "<p>v1</p>" {
+		t.Errorf("GetByID().Content = %q, want %q", got.Content, "<p>v1</p>")
+	}
+	if _, err := store.GetByID(ctx, "privacy", first.ID); err == nil {
+		t.Error("GetByID() found a revision of another page")
+	}
+}
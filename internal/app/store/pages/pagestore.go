@@ -3,6 +3,7 @@ package pagestore
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/domain/models"
@@ -12,6 +13,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrDuplicateSlug is returned when a page already has the slug.
+var ErrDuplicateSlug = errors.New("a page with this slug already exists")
+
 // Store provides access to the pages collection.
 type Store struct {
 	c *mongo.Collection
@@ -34,7 +38,18 @@ func (s *Store) GetBySlug(ctx context.Context, slug string) (models.Page, error)
 
 // Upsert creates or updates a page by slug.
 // If a page with the given slug exists, it updates it; otherwise creates a new one.
+// Any draft is kept.
 func (s *Store) Upsert(ctx context.Context, page models.Page) error {
+	return s.upsert(ctx, page, false)
+}
+
+// Publish creates or updates a page by slug, like Upsert, and discards its
+// draft, which page is expected to have been made from.
+func (s *Store) Publish(ctx context.Context, page models.Page) error {
+	return s.upsert(ctx, page, true)
+}
+
+func (s *Store) upsert(ctx context.Context, page models.Page, clearDraft bool) error {
 	now := time.Now().UTC()
 	page.UpdatedAt = &now
 
@@ -52,12 +67,39 @@ func (s *Store) Upsert(ctx context.Context, page models.Page) error {
 			"slug": page.Slug,
 		},
 	}
+	if clearDraft {
+		update["$unset"] = bson.M{"draft": ""}
+	}
 
 	opts := options.Update().SetUpsert(true)
 	_, err := s.c.UpdateOne(ctx, filter, update, opts)
 	return err
 }
 
+// SaveDraft stores unpublished edits to a page, replacing any earlier
+// draft. The published page is unchanged; a page that doesn't exist yet
+// is created with no published content.
+func (s *Store) SaveDraft(ctx context.Context, slug string, draft models.PageDraft) error {
+	draft.UpdatedAt = time.Now().UTC()
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"slug": slug},
+		bson.M{
+			"$set": bson.M{"draft": draft},
+			"$setOnInsert": bson.M{
+				"_id":  primitive.NewObjectID(),
+				"slug": slug,
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// DiscardDraft removes a page's draft, if it has one.
+func (s *Store) DiscardDraft(ctx context.Context, slug string) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"slug": slug}, bson.M{"$unset": bson.M{"draft": ""}})
+	return err
+}
+
 // GetAll returns all pages.
 func (s *Store) GetAll(ctx context.Context) ([]models.Page, error) {
 	cur, err := s.c.Find(ctx, bson.M{})
@@ -81,3 +123,88 @@ func (s *Store) Exists(ctx context.Context, slug string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// CreateCustom adds a custom page. It starts unpublished, with its title
+// saved as a draft.
+func (s *Store) CreateCustom(ctx context.Context, page models.Page) (models.Page, error) {
+	page.ID = primitive.NewObjectID()
+	page.Custom = true
+	if page.Draft != nil {
+		page.Draft.UpdatedAt = time.Now().UTC()
+	}
+	if _, err := s.c.InsertOne(ctx, page); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return models.Page{}, ErrDuplicateSlug
+		}
+		return models.Page{}, err
+	}
+	return page, nil
+}
+
+// ListCustom returns the custom pages in menu order.
+func (s *Store) ListCustom(ctx context.Context) ([]models.Page, error) {
+	return s.findCustom(ctx, bson.M{"custom": true}, nil)
+}
+
+// ListNav returns the published custom pages shown in the site menu, in
+// menu order, without their content.
+func (s *Store) ListNav(ctx context.Context) ([]models.Page, error) {
+	return s.findCustom(ctx,
+		bson.M{"custom": true, "show_in_nav": true, "title": bson.M{"$gt": ""}},
+		bson.M{"slug": 1, "title": 1, "nav_label": 1})
+}
+
+func (s *Store) findCustom(ctx context.Context, filter, projection bson.M) ([]models.Page, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "nav_order", Value: 1}, {Key: "slug", Value: 1}})
+	if projection != nil {
+		opts.SetProjection(projection)
+	}
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var pages []models.Page
+	if err := cur.All(ctx, &pages); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// CustomSettings are the slug and menu settings of a custom page.
+type CustomSettings struct {
+	Slug      string
+	ShowInNav bool
+	NavLabel  string
+	NavOrder  int
+}
+
+// UpdateCustomSettings changes a custom page's slug and menu settings.
+// It returns mongo.ErrNoDocuments if there is no custom page at slug.
+func (s *Store) UpdateCustomSettings(ctx context.Context, slug string, cs CustomSettings) error {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"slug": slug, "custom": true},
+		bson.M{"$set": bson.M{
+			"slug":        cs.Slug,
+			"show_in_nav": cs.ShowInNav,
+			"nav_label":   cs.NavLabel,
+			"nav_order":   cs.NavOrder,
+		}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateSlug
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// DeleteCustom removes a custom page. Built-in pages can't be deleted.
+func (s *Store) DeleteCustom(ctx context.Context, slug string) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"slug": slug, "custom": true})
+	return err
+}
@@ -1,6 +1,7 @@
 package pagestore
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/dalemusser/stratasave/internal/domain/models"
@@ -122,6 +123,57 @@ func TestStore_Upsert_Update(t *testing.T) {
 	}
 }
 
+func TestStore_DraftAndPublish(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if err := store.Upsert(ctx, models.Page{Slug: "terms", Title: "Terms", Content: "<p>v1</p>"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := store.SaveDraft(ctx, "terms", models.PageDraft{Title: "Terms", Content: "<p>v2</p>", UpdatedByName: "Editor"}); err != nil {
+		t.Fatalf("SaveDraft() error = %v", err)
+	}
+
+	page, err := store.GetBySlug(ctx, "terms")
+	if err != nil {
+		t.Fatalf("GetBySlug() error = %v", err)
+	}
+	if page.Content != "<p>v1</p>" {
+		t.Errorf("Content = %q, want the published content unchanged", page.Content)
+	}
+	if page.Draft == nil || page.Draft.Content != "<p>v2</p>" || page.Draft.UpdatedAt.IsZero() {
+		t.Fatalf("Draft = %+v, want the saved draft", page.Draft)
+	}
+
+	// Upsert keeps the draft; Publish clears it
+	if err := store.Upsert(ctx, models.Page{Slug: "terms", Title: "Terms", Content: "<p>v1b</p>"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if page, _ := store.GetBySlug(ctx, "terms"); page.Draft == nil {
+		t.Error("Upsert() discarded the draft")
+	}
+	if err := store.Publish(ctx, models.Page{Slug: "terms", Title: "Terms", Content: "<p>v2</p>"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	page, _ = store.GetBySlug(ctx, "terms")
+	if page.Draft != nil || page.Content != "<p>v2</p>" {
+		t.Errorf("after Publish() page = %+v, want v2 and no draft", page)
+	}
+
+	// A draft of a page that was never published
+	if err := store.SaveDraft(ctx, "privacy", models.PageDraft{Title: "Privacy"}); err != nil {
+		t.Fatalf("SaveDraft() error = %v", err)
+	}
+	if err := store.DiscardDraft(ctx, "privacy"); err != nil {
+		t.Fatalf("DiscardDraft() error = %v", err)
+	}
+	if page, _ := store.GetBySlug(ctx, "privacy"); page.Draft != nil || page.Title != "" {
+		t.Errorf("after DiscardDraft() page = %+v, want no draft or title", page)
+	}
+}
+
 func TestStore_GetBySlug(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -303,3 +355,88 @@ func TestPageModel_IsValidPageSlug(t *testing.T) {
 		})
 	}
 }
+
+func TestPageModel_IsValidCustomPageSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		want bool
+	}{
+		{"faq", true},
+		{"docs/getting-started", true},
+		{"v2/release-notes", true},
+		{"", false},
+		{"FAQ", false},
+		{"/faq", false},
+		{"faq/", false},
+		{"docs//intro", false},
+		{"my--page", false},
+		{"my_page", false},
+		{strings.Repeat("a", models.MaxCustomPageSlugLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := models.IsValidCustomPageSlug(tt.slug); got != tt.want {
+			t.Errorf("IsValidCustomPageSlug(%q) = %v, want %v", tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestStore_CustomPages(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	if err := store.Upsert(ctx, models.Page{Slug: "about", Title: "About"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if _, err := store.CreateCustom(ctx, models.Page{Slug: "faq", Title: "FAQ", ShowInNav: true, NavOrder: 2}); err != nil {
+		t.Fatalf("CreateCustom() error = %v", err)
+	}
+	if _, err := store.CreateCustom(ctx, models.Page{Slug: "docs/intro", Title: "Intro", ShowInNav: true, NavLabel: "Docs", NavOrder: 1}); err != nil {
+		t.Fatalf("CreateCustom() error = %v", err)
+	}
+	if _, err := store.CreateCustom(ctx, models.Page{Slug: "draft", ShowInNav: true, Draft: &models.PageDraft{Title: "Draft"}}); err != nil {
+		t.Fatalf("CreateCustom() error = %v", err)
+	}
+
+	custom, err := store.ListCustom(ctx)
+	if err != nil {
+		t.Fatalf("ListCustom() error = %v", err)
+	}
+	if len(custom) != 3 {
+		t.Errorf("ListCustom() returned %d pages, want 3", len(custom))
+	}
+
+	// Unpublished pages stay out of the menu
+	nav, err := store.ListNav(ctx)
+	if err != nil {
+		t.Fatalf("ListNav() error = %v", err)
+	}
+	if len(nav) != 2 || nav[0].Label() != "Docs" || nav[1].Label() != "FAQ" {
+		t.Errorf("ListNav() = %+v, want Docs then FAQ", nav)
+	}
+
+	if err := store.UpdateCustomSettings(ctx, "faq", CustomSettings{Slug: "help/faq"}); err != nil {
+		t.Fatalf("UpdateCustomSettings() error = %v", err)
+	}
+	if _, err := store.GetBySlug(ctx, "help/faq"); err != nil {
+		t.Errorf("GetBySlug(help/faq) error = %v, want the renamed page", err)
+	}
+	if err := store.UpdateCustomSettings(ctx, "about", CustomSettings{Slug: "team"}); err != mongo.ErrNoDocuments {
+		t.Errorf("UpdateCustomSettings(about) error = %v, want ErrNoDocuments for a built-in page", err)
+	}
+
+	if err := store.DeleteCustom(ctx, "about"); err != nil {
+		t.Fatalf("DeleteCustom() error = %v", err)
+	}
+	if _, err := store.GetBySlug(ctx, "about"); err != nil {
+		t.Errorf("DeleteCustom() removed a built-in page: %v", err)
+	}
+	if err := store.DeleteCustom(ctx, "help/faq"); err != nil {
+		t.Fatalf("DeleteCustom() error = %v", err)
+	}
+	if _, err := store.GetBySlug(ctx, "help/faq"); err != mongo.ErrNoDocuments {
+		t.Errorf("GetBySlug() after DeleteCustom() error = %v, want ErrNoDocuments", err)
+	}
+}
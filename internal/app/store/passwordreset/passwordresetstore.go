@@ -43,6 +43,11 @@ func New(db *mongo.Database, expiry time.Duration) *Store {
 	}
 }
 
+// Expiry returns how long a reset token stays valid.
+func (s *Store) Expiry() time.Duration {
+	return s.expiry
+}
+
 // EnsureIndexes creates necessary indexes for the collection.
 func (s *Store) EnsureIndexes(ctx context.Context) error {
 	indexes := []mongo.IndexModel{
@@ -4,6 +4,7 @@ package ratelimit
 import (
 	"context"
 	"strings"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,9 +25,16 @@ type Attempt struct {
 	UpdatedAt    time.Time          `bson:"updated_at"`
 }
 
+// IsLocked reports whether the attempt record is locked out at now.
+func (a *Attempt) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
 // Store manages rate limit tracking for login attempts.
 type Store struct {
-	c               *mongo.Collection
+	c *mongo.Collection
+
+	mu              sync.RWMutex // guards the limits, which can change on config reload
 	maxAttempts     int
 	windowDuration  time.Duration
 	lockoutDuration time.Duration
@@ -73,16 +81,17 @@ func normalizeLoginID(loginID string) string {
 func (s *Store) CheckAllowed(ctx context.Context, loginID string) (allowed bool, remaining int, lockedUntil *time.Time) {
 	loginID = normalizeLoginID(loginID)
 	now := time.Now()
+	maxAttempts, window, _ := s.Limits()
 
 	var attempt Attempt
 	err := s.c.FindOne(ctx, bson.M{"login_id": loginID}).Decode(&attempt)
 	if err == mongo.ErrNoDocuments {
 		// No record exists - allowed with full attempts remaining
-		return true, s.maxAttempts, nil
+		return true, maxAttempts, nil
 	}
 	if err != nil {
 		// On error, allow the attempt (fail open for availability)
-		return true, s.maxAttempts, nil
+		return true, maxAttempts, nil
 	}
 
 	// Check if currently locked out
@@ -91,12 +100,12 @@ func (s *Store) CheckAllowed(ctx context.Context, loginID string) (allowed bool,
 	}
 
 	// Check if window has expired (reset counter)
-	if now.After(attempt.WindowStart.Add(s.windowDuration)) {
-		return true, s.maxAttempts, nil
+	if now.After(attempt.WindowStart.Add(window)) {
+		return true, maxAttempts, nil
 	}
 
 	// Within window - check remaining attempts
-	remaining = s.maxAttempts - attempt.AttemptCount
+	remaining = maxAttempts - attempt.AttemptCount
 	if remaining <= 0 {
 		// Should be locked but lockout wasn't set properly - treat as locked
 		return false, 0, nil
@@ -112,6 +121,7 @@ func (s *Store) CheckAllowed(ctx context.Context, loginID string) (allowed bool,
 func (s *Store) RecordFailure(ctx context.Context, loginID string) (lockedOut bool, lockedUntil *time.Time) {
 	loginID = normalizeLoginID(loginID)
 	now := time.Now()
+	maxAttempts, window, lockout := s.Limits()
 
 	// Try to find existing record
 	var attempt Attempt
@@ -130,8 +140,8 @@ func (s *Store) RecordFailure(ctx context.Context, loginID string) (lockedOut bo
 		}
 
 		// Check if this single attempt triggers lockout (shouldn't with default settings)
-		if attempt.AttemptCount >= s.maxAttempts {
-			lockoutTime := now.Add(s.lockoutDuration)
+		if attempt.AttemptCount >= maxAttempts {
+			lockoutTime := now.Add(lockout)
 			attempt.LockedUntil = &lockoutTime
 			lockedOut = true
 			lockedUntil = &lockoutTime
@@ -147,7 +157,7 @@ func (s *Store) RecordFailure(ctx context.Context, loginID string) (lockedOut bo
 	}
 
 	// Check if window has expired - reset counter
-	if now.After(attempt.WindowStart.Add(s.windowDuration)) {
+	if now.After(attempt.WindowStart.Add(window)) {
 		attempt.AttemptCount = 1
 		attempt.WindowStart = now
 		attempt.LockedUntil = nil
@@ -159,8 +169,8 @@ func (s *Store) RecordFailure(ctx context.Context, loginID string) (lockedOut bo
 	attempt.UpdatedAt = now
 
 	// Check if we've exceeded the limit
-	if attempt.AttemptCount >= s.maxAttempts {
-		lockoutTime := now.Add(s.lockoutDuration)
+	if attempt.AttemptCount >= maxAttempts {
+		lockoutTime := now.Add(lockout)
 		attempt.LockedUntil = &lockoutTime
 		lockedOut = true
 		lockedUntil = &lockoutTime
@@ -184,11 +194,57 @@ func (s *Store) RecordFailure(ctx context.Context, loginID string) (lockedOut bo
 // ClearOnSuccess removes the rate limit record for the given login_id.
 // Called after a successful login to reset the counter.
 func (s *Store) ClearOnSuccess(ctx context.Context, loginID string) error {
+	return s.Clear(ctx, loginID)
+}
+
+// Clear removes the rate limit record for the given login_id, lifting any
+// lockout.
+func (s *Store) Clear(ctx context.Context, loginID string) error {
 	loginID = normalizeLoginID(loginID)
 	_, err := s.c.DeleteOne(ctx, bson.M{"login_id": loginID})
 	return err
 }
 
+// Limits returns the configured attempts allowed per window, the window,
+// and the lockout duration.
+func (s *Store) Limits() (maxAttempts int, window, lockout time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxAttempts, s.windowDuration, s.lockoutDuration
+}
+
+// SetLimits changes the attempts allowed per window, the window, and the
+// lockout duration. Lockouts already in place keep their end time.
+func (s *Store) SetLimits(maxAttempts int, window, lockout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxAttempts, s.windowDuration, s.lockoutDuration = maxAttempts, window, lockout
+}
+
+// ListActive returns the records that still affect logins: those locked out
+// and those with failures inside the current window. Most recent first.
+func (s *Store) ListActive(ctx context.Context, limit int64) ([]Attempt, error) {
+	now := time.Now()
+	_, window, _ := s.Limits()
+	filter := bson.M{"$or": []bson.M{
+		{"locked_until": bson.M{"$gt": now}},
+		{"window_start": bson.M{"$gt": now.Add(-window)}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_attempt", Value: -1}}).SetLimit(limit)
+
+	cur, err := s.c.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var attempts []Attempt
+	if err := cur.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
 // GetAttempt returns the current attempt record for a login_id (for debugging/admin).
 func (s *Store) GetAttempt(ctx context.Context, loginID string) (*Attempt, error) {
 	loginID = normalizeLoginID(loginID)
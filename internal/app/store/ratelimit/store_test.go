@@ -264,3 +264,36 @@ func TestNormalizeLoginID(t *testing.T) {
 		})
 	}
 }
+
+func TestStore_ListActive(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db, 2, 15*time.Minute, 30*time.Minute)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	store.RecordFailure(ctx, "locked@example.com")
+	store.RecordFailure(ctx, "locked@example.com")
+	store.RecordFailure(ctx, "counting@example.com")
+
+	attempts, err := store.ListActive(ctx, 100)
+	if err != nil {
+		t.Fatalf("ListActive() error = %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("ListActive() returned %d records, want 2", len(attempts))
+	}
+	locked := map[string]bool{}
+	for _, a := range attempts {
+		locked[a.LoginID] = a.IsLocked(time.Now())
+	}
+	if !locked["locked@example.com"] || locked["counting@example.com"] {
+		t.Errorf("lock state = %v", locked)
+	}
+
+	if err := store.Clear(ctx, "Locked@Example.com"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if allowed, _, _ := store.CheckAllowed(ctx, "locked@example.com"); !allowed {
+		t.Error("CheckAllowed() should allow a login after Clear")
+	}
+}
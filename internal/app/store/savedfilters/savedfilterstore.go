@@ -16,14 +16,30 @@ import (
 type SavedFilter struct {
 	ID        primitive.ObjectID `bson:"_id"`
 	UserID    primitive.ObjectID `bson:"user_id"`
-	Feature   string             `bson:"feature"`    // "ledger", "jobs", app-specific
-	Name      string             `bson:"name"`       // "Last 24h errors"
-	Filters   map[string]string  `bson:"filters"`    // Query params
-	IsDefault bool               `bson:"is_default"` // Auto-apply on page load
+	Feature   string             `bson:"feature"`         // "ledger", "jobs", app-specific
+	Name      string             `bson:"name"`            // "Last 24h errors"
+	Filters   map[string]string  `bson:"filters"`         // Query params
+	IsDefault bool               `bson:"is_default"`      // Auto-apply on page load
+	Alert     *Alert             `bson:"alert,omitempty"` // nil unless the owner is alerted on matches
 	CreatedAt time.Time          `bson:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at"`
 }
 
+// Alert emails a filter's owner when the number of records matching the
+// filter within the last WindowMins minutes reaches Threshold.
+type Alert struct {
+	Threshold     int64      `bson:"threshold"`
+	WindowMins    int        `bson:"window_mins"`
+	LastCount     int64      `bson:"last_count"`                // Matches at the last check
+	LastCheckedAt *time.Time `bson:"last_checked_at,omitempty"` // nil until first checked
+	LastAlertAt   *time.Time `bson:"last_alert_at,omitempty"`   // nil until first alert
+}
+
+// Window returns the alert's window as a duration.
+func (a Alert) Window() time.Duration {
+	return time.Duration(a.WindowMins) * time.Minute
+}
+
 var (
 	// ErrNotFound is returned when a saved filter is not found.
 	ErrNotFound = errors.New("saved filter not found")
@@ -50,6 +66,7 @@ type CreateInput struct {
 	Name      string
 	Filters   map[string]string
 	IsDefault bool
+	Alert     *Alert
 }
 
 // Create creates a new saved filter.
@@ -79,6 +96,7 @@ func (s *Store) Create(ctx context.Context, input CreateInput) (SavedFilter, err
 		Name:      input.Name,
 		Filters:   input.Filters,
 		IsDefault: input.IsDefault,
+		Alert:     input.Alert,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -302,3 +320,55 @@ func (s *Store) DeleteAllForUser(ctx context.Context, userID primitive.ObjectID)
 func (s *Store) CountForUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
 	return s.c.CountDocuments(ctx, bson.M{"user_id": userID})
 }
+
+// ListWithAlerts returns every filter for feature that has an alert, for
+// all users.
+func (s *Store) ListWithAlerts(ctx context.Context, feature string) ([]SavedFilter, error) {
+	cur, err := s.c.Find(ctx, bson.M{
+		"feature": feature,
+		"alert":   bson.M{"$ne": nil},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var filters []SavedFilter
+	if err := cur.All(ctx, &filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// RecordAlertCheck saves the number of matches found when a filter's alert
+// was checked.
+func (s *Store) RecordAlertCheck(ctx context.Context, id primitive.ObjectID, count int64, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id, "alert": bson.M{"$ne": nil}}, bson.M{
+		"$set": bson.M{
+			"alert.last_count":      count,
+			"alert.last_checked_at": at,
+		},
+	})
+	return err
+}
+
+// ClaimAlert marks a filter's alert as sent at now, unless it was already
+// sent after since. It reports whether the caller should send the alert,
+// so an alert goes out at most once per period even with several
+// instances checking.
+func (s *Store) ClaimAlert(ctx context.Context, id primitive.ObjectID, since, now time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx, bson.M{
+		"_id":   id,
+		"alert": bson.M{"$ne": nil},
+		"$or": []bson.M{
+			{"alert.last_alert_at": nil},
+			{"alert.last_alert_at": bson.M{"$lt": since}},
+		},
+	}, bson.M{
+		"$set": bson.M{"alert.last_alert_at": now},
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,262 @@
+// internal/app/store/schedules/schedulestore.go
+package schedulestore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Run status constants.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Run trigger constants.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// ErrNotFound is returned when a scheduled job has no record.
+var ErrNotFound = errors.New("scheduled job not found")
+
+// Schedule is the shared state of one scheduled job: when it next runs,
+// whether it is paused, and how its last run went. Every instance of the
+// app reads it, so a job runs once per slot however many instances there
+// are.
+type Schedule struct {
+	Name      string    `bson:"_id"`
+	Spec      string    `bson:"schedule"` // Cron expression or "@every <duration>"
+	Paused    bool      `bson:"paused"`
+	NextRunAt time.Time `bson:"next_run_at"`
+
+	PausedBy string     `bson:"paused_by,omitempty"`
+	PausedAt *time.Time `bson:"paused_at,omitempty"`
+
+	LastRunAt      *time.Time `bson:"last_run_at,omitempty"`
+	LastStatus     string     `bson:"last_status,omitempty"`
+	LastError      string     `bson:"last_error,omitempty"`
+	LastDurationMs int64      `bson:"last_duration_ms,omitempty"`
+
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Run is one run of a scheduled job.
+type Run struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Job         string             `bson:"job"`
+	Trigger     string             `bson:"trigger"`                // schedule, manual
+	TriggeredBy string             `bson:"triggered_by,omitempty"` // Who ran it by hand
+	Instance    string             `bson:"instance,omitempty"`     // App instance it ran on
+	StartedAt   time.Time          `bson:"started_at"`
+	FinishedAt  time.Time          `bson:"finished_at"`
+	DurationMs  int64              `bson:"duration_ms"`
+	Status      string             `bson:"status"` // succeeded, failed, cancelled
+	Error       string             `bson:"error,omitempty"`
+}
+
+// Store manages scheduled job state and run history.
+type Store struct {
+	schedules *mongo.Collection
+	runs      *mongo.Collection
+}
+
+// New creates a new schedule store.
+func New(db *mongo.Database) *Store {
+	return &Store{
+		schedules: db.Collection("schedules"),
+		runs:      db.Collection("schedule_runs"),
+	}
+}
+
+// Sync records a registered job. A new job first runs at first; a job
+// whose schedule has changed next runs at next. A job already recorded
+// with the same schedule keeps its state.
+func (s *Store) Sync(ctx context.Context, name, spec string, first, next time.Time) error {
+	now := time.Now()
+	res, err := s.schedules.UpdateOne(ctx,
+		bson.M{"_id": name, "schedule": bson.M{"$ne": spec}},
+		bson.M{"$set": bson.M{"schedule": spec, "next_run_at": next, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+	_, err = s.schedules.InsertOne(ctx, Schedule{
+		Name:      name,
+		Spec:      spec,
+		NextRunAt: first,
+		UpdatedAt: now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		// Already recorded with this schedule, or by another instance
+		return nil
+	}
+	return err
+}
+
+// Due returns the names of unpaused jobs due to run at now.
+func (s *Store) Due(ctx context.Context, now time.Time) ([]string, error) {
+	cur, err := s.schedules.Find(ctx, bson.M{
+		"paused":      false,
+		"next_run_at": bson.M{"$lte": now},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var names []string
+	for cur.Next(ctx) {
+		var sch struct {
+			Name string `bson:"_id"`
+		}
+		if err := cur.Decode(&sch); err != nil {
+			return nil, err
+		}
+		names = append(names, sch.Name)
+	}
+	return names, cur.Err()
+}
+
+// Claim takes a due slot of a job, moving its next run to next. It reports
+// false if the job isn't due, is paused, or another instance claimed the
+// slot first.
+func (s *Store) Claim(ctx context.Context, name string, now, next time.Time) (bool, error) {
+	res, err := s.schedules.UpdateOne(ctx, bson.M{
+		"_id":         name,
+		"paused":      false,
+		"next_run_at": bson.M{"$lte": now},
+	}, bson.M{"$set": bson.M{
+		"next_run_at": next,
+		"updated_at":  now,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// Finish records a finished run in the history and as the job's last
+// run.
+func (s *Store) Finish(ctx context.Context, run Run) error {
+	if run.ID.IsZero() {
+		run.ID = primitive.NewObjectID()
+	}
+	if _, err := s.runs.InsertOne(ctx, run); err != nil {
+		return err
+	}
+	_, err := s.schedules.UpdateOne(ctx, bson.M{"_id": run.Job}, bson.M{
+		"$set": bson.M{
+			"last_run_at":      run.StartedAt,
+			"last_status":      run.Status,
+			"last_error":       run.Error,
+			"last_duration_ms": run.DurationMs,
+			"updated_at":       time.Now(),
+		},
+	})
+	return err
+}
+
+// SetPaused pauses or resumes a job. A resumed job next runs at next, so
+// runs missed while it was paused aren't made up.
+func (s *Store) SetPaused(ctx context.Context, name string, paused bool, by string, next time.Time) error {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"paused":     true,
+		"paused_by":  by,
+		"paused_at":  now,
+		"updated_at": now,
+	}}
+	if !paused {
+		update = bson.M{
+			"$set":   bson.M{"paused": false, "next_run_at": next, "updated_at": now},
+			"$unset": bson.M{"paused_by": "", "paused_at": ""},
+		}
+	}
+	res, err := s.schedules.UpdateOne(ctx, bson.M{"_id": name}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Get returns the state of one job.
+func (s *Store) Get(ctx context.Context, name string) (Schedule, error) {
+	var sch Schedule
+	err := s.schedules.FindOne(ctx, bson.M{"_id": name}).Decode(&sch)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Schedule{}, ErrNotFound
+	}
+	return sch, err
+}
+
+// All returns the state of every recorded job, keyed by name.
+func (s *Store) All(ctx context.Context) (map[string]Schedule, error) {
+	cur, err := s.schedules.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var list []Schedule
+	if err := cur.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]Schedule, len(list))
+	for _, sch := range list {
+		byName[sch.Name] = sch
+	}
+	return byName, nil
+}
+
+// Runs returns a page of a job's runs, newest first, and how many it has
+// in all.
+func (s *Store) Runs(ctx context.Context, name string, page, perPage int) ([]Run, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	filter := bson.M{"job": name}
+	total, err := s.runs.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((page - 1) * perPage)).
+		SetLimit(int64(perPage))
+	cur, err := s.runs.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	var runs []Run
+	if err := cur.All(ctx, &runs); err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+// DeleteRunsBefore deletes runs that started before cutoff.
+func (s *Store) DeleteRunsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.runs.DeleteMany(ctx, bson.M{"started_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
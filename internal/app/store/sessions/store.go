@@ -9,6 +9,8 @@ import (
 	"context"
 	"time"
 
+	devicestore "github.com/dalemusser/stratasave/internal/app/store/devices"
+	"github.com/dalemusser/stratasave/internal/app/system/useragent"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,8 +20,11 @@ import (
 // Session end reasons
 const (
 	EndReasonLogout   = "logout"   // User explicitly logged out
+	EndReasonRotated  = "rotated"  // Invalidated by a password, role, or backup code change
 	EndReasonExpired  = "expired"  // Session expired via TTL
 	EndReasonInactive = "inactive" // Closed due to inactivity
+	EndReasonDisabled = "disabled" // Account disabled on its scheduled date
+	EndReasonDeleted  = "deleted"  // Account deleted at the user's request
 )
 
 // Session represents a stored session in the database.
@@ -32,6 +37,11 @@ type Session struct {
 	UserAgent string             `bson:"user_agent,omitempty"`
 	Data      map[string]any     `bson:"data,omitempty"`
 
+	// Device, parsed from UserAgent when the session is created
+	OS                string `bson:"os,omitempty"`
+	Browser           string `bson:"browser,omitempty"`
+	DeviceFingerprint string `bson:"device_fingerprint,omitempty"` // Known device (see devicestore.Fingerprint)
+
 	// Activity tracking
 	CurrentPage      string     `bson:"current_page,omitempty"`       // Current page user is viewing
 	LoginAt          time.Time  `bson:"login_at"`                     // When session started
@@ -49,6 +59,23 @@ type Session struct {
 	UpdatedAt time.Time `bson:"updated_at"`
 }
 
+// Device returns the browser and operating system the session was created
+// from, parsing the user agent for sessions recorded before they were stored.
+func (s Session) Device() useragent.Info {
+	if s.OS == "" && s.Browser == "" {
+		return useragent.Parse(s.UserAgent)
+	}
+	return useragent.Info{Browser: s.Browser, OS: s.OS}
+}
+
+// Fingerprint returns the known device the session was created from.
+func (s Session) Fingerprint() string {
+	if s.DeviceFingerprint != "" {
+		return s.DeviceFingerprint
+	}
+	return devicestore.Fingerprint(s.UserAgent, devicestore.IPRange(s.IPAddress))
+}
+
 // Store manages session records in MongoDB.
 // Note: Strata primarily uses cookie-based sessions via gorilla/sessions.
 // This store is provided for scenarios requiring server-side session storage.
@@ -106,6 +133,13 @@ func (s *Store) Create(ctx context.Context, session Session) error {
 	if session.LastUserActivity.IsZero() {
 		session.LastUserActivity = now
 	}
+	if session.OS == "" && session.Browser == "" {
+		info := useragent.Parse(session.UserAgent)
+		session.OS, session.Browser = info.OS, info.Browser
+	}
+	if session.DeviceFingerprint == "" {
+		session.DeviceFingerprint = devicestore.Fingerprint(session.UserAgent, devicestore.IPRange(session.IPAddress))
+	}
 	_, err := s.c.InsertOne(ctx, session)
 	return err
 }
@@ -170,6 +204,23 @@ func (s *Store) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Se
 	return sessions, nil
 }
 
+// ListRecentByUser retrieves a user's sessions, ended ones included, most
+// recent first.
+func (s *Store) ListRecentByUser(ctx context.Context, userID primitive.ObjectID, limit int64) ([]Session, error) {
+	cursor, err := s.c.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "login_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 // UpdateActivity updates the last activity time and optionally the IP and user agent.
 func (s *Store) UpdateActivity(ctx context.Context, token string, ip string, userAgent string) error {
 	update := bson.M{
@@ -239,6 +290,17 @@ func (s *Store) Close(ctx context.Context, token string, reason string) error {
 	return err
 }
 
+// RotateToken replaces a session's token, keeping its activity history.
+func (s *Store) RotateToken(ctx context.Context, oldToken, newToken string) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"token": oldToken}, bson.M{
+		"$set": bson.M{
+			"token":      newToken,
+			"updated_at": time.Now(),
+		},
+	})
+	return err
+}
+
 // CloseByUser closes all sessions for a user with the given reason.
 func (s *Store) CloseByUser(ctx context.Context, userID primitive.ObjectID, reason string) error {
 	now := time.Now()
@@ -393,6 +455,40 @@ func (s *Store) GetActiveByUser(ctx context.Context, userID primitive.ObjectID)
 	return sessions, nil
 }
 
+// CountActiveByUsers counts the active sessions of each of the given users.
+// Users without an active session are left out of the map.
+func (s *Store) CountActiveByUsers(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	counts := make(map[primitive.ObjectID]int)
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	cursor, err := s.c.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    bson.M{"$in": userIDs},
+			"logout_at":  nil,
+			"expires_at": bson.M{"$gt": time.Now()},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			UserID primitive.ObjectID `bson:"_id"`
+			Count  int                `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.UserID] = row.Count
+	}
+	return counts, cursor.Err()
+}
+
 // CountActive counts currently active sessions (not logged out and not expired).
 func (s *Store) CountActive(ctx context.Context) (int64, error) {
 	return s.c.CountDocuments(ctx, bson.M{
@@ -507,3 +507,39 @@ func TestStore_Create_DuplicateToken(t *testing.T) {
 		t.Error("Second Create() with duplicate token should fail")
 	}
 }
+
+func TestStore_CountActiveByUsers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	busy, idle, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	loggedOut := time.Now()
+	sessions := []Session{
+		{Token: "busy-1", UserID: busy, ExpiresAt: time.Now().Add(time.Hour)},
+		{Token: "busy-2", UserID: busy, ExpiresAt: time.Now().Add(time.Hour)},
+		{Token: "busy-closed", UserID: busy, ExpiresAt: time.Now().Add(time.Hour), LogoutAt: &loggedOut},
+		{Token: "idle-expired", UserID: idle, ExpiresAt: time.Now().Add(-time.Hour)},
+		{Token: "other-1", UserID: other, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	for _, s := range sessions {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	counts, err := store.CountActiveByUsers(ctx, []primitive.ObjectID{busy, idle})
+	if err != nil {
+		t.Fatalf("CountActiveByUsers() error = %v", err)
+	}
+	if counts[busy] != 2 {
+		t.Errorf("busy count = %d, want 2", counts[busy])
+	}
+	if _, ok := counts[idle]; ok {
+		t.Errorf("idle user should have no count, got %d", counts[idle])
+	}
+	if _, ok := counts[other]; ok {
+		t.Error("users not asked for should be left out")
+	}
+}
@@ -62,6 +62,8 @@ func (s *Store) Save(ctx context.Context, settings models.SiteSettings) error {
 			"landing_title":        settings.LandingTitle,
 			"landing_content":      settings.LandingContent,
 			"footer_html":          settings.FooterHTML,
+			"email_primary_color":  settings.EmailPrimaryColor,
+			"email_footer_text":    settings.EmailFooterText,
 			"enabled_auth_methods": settings.EnabledAuthMethods,
 			"updated_at":           settings.UpdatedAt,
 			"updated_by_id":        settings.UpdatedByID,
@@ -77,6 +79,24 @@ func (s *Store) Save(ctx context.Context, settings models.SiteSettings) error {
 	return err
 }
 
+// SetSMTP saves the SMTP settings, or clears them if smtp is nil so the
+// configured server is used again.
+func (s *Store) SetSMTP(ctx context.Context, smtp *models.SMTPSettings) error {
+	filter := bson.M{"singleton": true}
+	update := bson.M{
+		"$set":         bson.M{"singleton": true, "smtp": smtp, "updated_at": time.Now().UTC()},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+	}
+	if smtp == nil {
+		update["$set"] = bson.M{"singleton": true, "updated_at": time.Now().UTC()}
+		update["$unset"] = bson.M{"smtp": ""}
+	}
+
+	opts := options.Update().SetUpsert(true)
+	_, err := s.c.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
 // Exists checks if settings have been saved.
 func (s *Store) Exists(ctx context.Context) (bool, error) {
 	filter := bson.M{"singleton": true}
@@ -95,11 +115,30 @@ type UpdateInput struct {
 	FooterHTML     string
 	LogoPath       string
 	LogoName       string
+
+	// Email branding
+	EmailPrimaryColor string
+	EmailFooterText   string
 	// Email notification settings
-	NotifyUserOnCreate  bool
-	NotifyUserOnDisable bool
-	NotifyUserOnEnable  bool
-	NotifyUserOnWelcome bool
+	NotifyUserOnCreate    bool
+	NotifyUserOnDisable   bool
+	NotifyUserOnEnable    bool
+	NotifyUserOnWelcome   bool
+	NotifyUserOnNewDevice bool
+
+	// Scheduled reports
+	ReportWeekly     bool
+	ReportMonthly    bool
+	ReportSections   []string
+	ReportRecipients []string
+
+	// Self-service registration
+	RegistrationEnabled          bool
+	RegistrationRole             string
+	RegistrationRequiresApproval bool
+
+	// Allowed email domains
+	AllowedEmailDomains []string
 }
 
 // Upsert updates or inserts site settings from UpdateInput.
@@ -109,18 +148,29 @@ func (s *Store) Upsert(ctx context.Context, input UpdateInput) error {
 	filter := bson.M{"singleton": true}
 	update := bson.M{
 		"$set": bson.M{
-			"singleton":              true,
-			"site_name":              input.SiteName,
-			"landing_title":          input.LandingTitle,
-			"landing_content":        input.LandingContent,
-			"footer_html":            input.FooterHTML,
-			"logo_path":              input.LogoPath,
-			"logo_name":              input.LogoName,
-			"notify_user_on_create":  input.NotifyUserOnCreate,
-			"notify_user_on_disable": input.NotifyUserOnDisable,
-			"notify_user_on_enable":  input.NotifyUserOnEnable,
-			"notify_user_on_welcome": input.NotifyUserOnWelcome,
-			"updated_at":             now,
+			"singleton":                      true,
+			"site_name":                      input.SiteName,
+			"landing_title":                  input.LandingTitle,
+			"landing_content":                input.LandingContent,
+			"footer_html":                    input.FooterHTML,
+			"logo_path":                      input.LogoPath,
+			"logo_name":                      input.LogoName,
+			"email_primary_color":            input.EmailPrimaryColor,
+			"email_footer_text":              input.EmailFooterText,
+			"notify_user_on_create":          input.NotifyUserOnCreate,
+			"notify_user_on_disable":         input.NotifyUserOnDisable,
+			"notify_user_on_enable":          input.NotifyUserOnEnable,
+			"notify_user_on_welcome":         input.NotifyUserOnWelcome,
+			"notify_user_on_new_device":      input.NotifyUserOnNewDevice,
+			"report_weekly":                  input.ReportWeekly,
+			"report_monthly":                 input.ReportMonthly,
+			"report_sections":                input.ReportSections,
+			"report_recipients":              input.ReportRecipients,
+			"registration_enabled":           input.RegistrationEnabled,
+			"registration_role":              input.RegistrationRole,
+			"registration_requires_approval": input.RegistrationRequiresApproval,
+			"allowed_email_domains":          input.AllowedEmailDomains,
+			"updated_at":                     now,
 		},
 		"$setOnInsert": bson.M{
 			"_id": primitive.NewObjectID(),
@@ -238,3 +238,35 @@ func TestStore_Singleton(t *testing.T) {
 		t.Error("Exists() should return true")
 	}
 }
+
+func TestStore_SetSMTP(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	smtp := &models.SMTPSettings{Host: "smtp.example.com", Port: 587, User: "mailer", PassEncrypted: "sealed", From: "hello@example.com"}
+	if err := store.SetSMTP(ctx, smtp); err != nil {
+		t.Fatalf("SetSMTP() error = %v", err)
+	}
+
+	// Saving the rest of the settings leaves SMTP alone
+	if err := store.Upsert(ctx, UpdateInput{SiteName: "Site"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	settings, err := store.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if settings.SMTP == nil || *settings.SMTP != *smtp {
+		t.Errorf("Get() SMTP = %+v, want %+v", settings.SMTP, smtp)
+	}
+
+	if err := store.SetSMTP(ctx, nil); err != nil {
+		t.Fatalf("SetSMTP(nil) error = %v", err)
+	}
+	settings, _ = store.Get(ctx)
+	if settings.SMTP != nil || settings.SiteName != "Site" {
+		t.Errorf("after SetSMTP(nil) settings = %+v, want no SMTP and the site name kept", settings)
+	}
+}
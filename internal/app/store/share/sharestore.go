@@ -0,0 +1,184 @@
+// Package share provides storage for public share links to library files.
+//
+// A share link lets anyone holding its token download one file without
+// signing in. A link can expire, can be limited to a number of downloads,
+// and can be revoked.
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Share is a public link to a library file.
+type Share struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	FileID       primitive.ObjectID `bson:"file_id"`
+	Token        string             `bson:"token"`
+	CreatedByID  primitive.ObjectID `bson:"created_by_id"`
+	ExpiresAt    *time.Time         `bson:"expires_at,omitempty"` // Nil if the link never expires
+	MaxDownloads int                `bson:"max_downloads"`        // 0 = unlimited
+	Downloads    int                `bson:"downloads"`
+	RevokedAt    *time.Time         `bson:"revoked_at,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+}
+
+// Usable reports whether the link can still be used to download its file.
+func (s *Share) Usable(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	if s.ExpiresAt != nil && !now.Before(*s.ExpiresAt) {
+		return false
+	}
+	return s.MaxDownloads == 0 || s.Downloads < s.MaxDownloads
+}
+
+// Resumable reports whether a download already counted against the link
+// can go on, such as a video being seeked or a download resuming. Unlike
+// Usable, a link whose downloads are used up still allows it.
+func (s *Share) Resumable(now time.Time) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+	if s.ExpiresAt != nil && !now.Before(*s.ExpiresAt) {
+		return false
+	}
+	return s.Downloads > 0
+}
+
+// Store provides access to the file_shares collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new share store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("file_shares")}
+}
+
+// CreateInput contains the input for creating a share link.
+type CreateInput struct {
+	FileID       primitive.ObjectID
+	CreatedByID  primitive.ObjectID
+	ExpiresAt    *time.Time
+	MaxDownloads int
+}
+
+// Create creates a share link with a new random token.
+func (s *Store) Create(ctx context.Context, input CreateInput) (*Share, error) {
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	sh := Share{
+		ID:           primitive.NewObjectID(),
+		FileID:       input.FileID,
+		Token:        token,
+		CreatedByID:  input.CreatedByID,
+		ExpiresAt:    input.ExpiresAt,
+		MaxDownloads: input.MaxDownloads,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if _, err := s.c.InsertOne(ctx, sh); err != nil {
+		return nil, err
+	}
+	return &sh, nil
+}
+
+// GetByToken retrieves a share link by its token, whether or not it can
+// still be used.
+func (s *Store) GetByToken(ctx context.Context, token string) (*Share, error) {
+	var sh Share
+	if err := s.c.FindOne(ctx, bson.M{"token": token}).Decode(&sh); err != nil {
+		return nil, err
+	}
+	return &sh, nil
+}
+
+// ListActiveByFile returns a file's links that haven't been revoked, newest
+// first. Expired and used-up links are included so their state can be shown.
+func (s *Store) ListActiveByFile(ctx context.Context, fileID primitive.ObjectID) ([]Share, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cur, err := s.c.Find(ctx, bson.M{
+		"file_id":    fileID,
+		"revoked_at": bson.M{"$exists": false},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var shares []Share
+	if err := cur.All(ctx, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Revoke stops a file's share link from being used.
+func (s *Store) Revoke(ctx context.Context, id, fileID primitive.ObjectID) error {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "file_id": fileID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RecordDownload counts a download through a link. It reports false,
+// without counting, if the link is revoked, expired or used up, so
+// concurrent downloads can't exceed the limit.
+func (s *Store) RecordDownload(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{
+			"_id":        id,
+			"revoked_at": bson.M{"$exists": false},
+			"$and": bson.A{
+				bson.M{"$or": bson.A{
+					bson.M{"expires_at": bson.M{"$exists": false}},
+					bson.M{"expires_at": bson.M{"$gt": now}},
+				}},
+				bson.M{"$or": bson.A{
+					bson.M{"max_downloads": 0},
+					bson.M{"$expr": bson.M{"$lt": bson.A{"$downloads", "$max_downloads"}}},
+				}},
+			},
+		},
+		bson.M{"$inc": bson.M{"downloads": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// DeleteByFile removes all of a file's share links, when the file is
+// deleted for good.
+func (s *Store) DeleteByFile(ctx context.Context, fileID primitive.ObjectID) error {
+	_, err := s.c.DeleteMany(ctx, bson.M{"file_id": fileID})
+	return err
+}
+
+// generateToken generates a random URL-safe token.
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,143 @@
+package share
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestShare_Usable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		share Share
+		want  bool
+	}{
+		{"unlimited", Share{}, true},
+		{"not yet expired", Share{ExpiresAt: &future}, true},
+		{"expired", Share{ExpiresAt: &past}, false},
+		{"downloads left", Share{MaxDownloads: 3, Downloads: 2}, true},
+		{"used up", Share{MaxDownloads: 3, Downloads: 3}, false},
+		{"revoked", Share{RevokedAt: &past}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.share.Usable(now); got != tt.want {
+				t.Errorf("Usable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShare_Resumable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		share Share
+		want  bool
+	}{
+		{"never downloaded", Share{MaxDownloads: 1}, false},
+		{"used up", Share{MaxDownloads: 1, Downloads: 1}, true},
+		{"expired", Share{MaxDownloads: 1, Downloads: 1, ExpiresAt: &past}, false},
+		{"revoked", Share{Downloads: 1, RevokedAt: &past}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.share.Resumable(now); got != tt.want {
+				t.Errorf("Resumable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_RecordDownload(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	sh, err := store.Create(ctx, CreateInput{
+		FileID:       primitive.NewObjectID(),
+		CreatedByID:  primitive.NewObjectID(),
+		MaxDownloads: 2,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if ok, err := store.RecordDownload(ctx, sh.ID); err != nil || !ok {
+			t.Fatalf("RecordDownload() #%d = %v, %v; want true, nil", i+1, ok, err)
+		}
+	}
+	if ok, _ := store.RecordDownload(ctx, sh.ID); ok {
+		t.Error("RecordDownload() past the limit should not count")
+	}
+
+	got, err := store.GetByToken(ctx, sh.Token)
+	if err != nil {
+		t.Fatalf("GetByToken() error = %v", err)
+	}
+	if got.Downloads != 2 || got.Usable(time.Now()) {
+		t.Errorf("downloads = %d, usable = %v; want 2, false", got.Downloads, got.Usable(time.Now()))
+	}
+}
+
+func TestStore_RecordDownload_Expired(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	past := time.Now().Add(-time.Minute)
+	sh, err := store.Create(ctx, CreateInput{FileID: primitive.NewObjectID(), ExpiresAt: &past})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if ok, _ := store.RecordDownload(ctx, sh.ID); ok {
+		t.Error("RecordDownload() on an expired link should not count")
+	}
+}
+
+func TestStore_Revoke(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	fileID := primitive.NewObjectID()
+	keep, err := store.Create(ctx, CreateInput{FileID: fileID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	revoke, err := store.Create(ctx, CreateInput{FileID: fileID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// The link must belong to the file named
+	if err := store.Revoke(ctx, revoke.ID, primitive.NewObjectID()); err == nil {
+		t.Error("Revoke() with another file's ID should fail")
+	}
+	if err := store.Revoke(ctx, revoke.ID, fileID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if ok, _ := store.RecordDownload(ctx, revoke.ID); ok {
+		t.Error("RecordDownload() on a revoked link should not count")
+	}
+
+	active, err := store.ListActiveByFile(ctx, fileID)
+	if err != nil {
+		t.Fatalf("ListActiveByFile() error = %v", err)
+	}
+	if len(active) != 1 || active[0].ID != keep.ID {
+		t.Errorf("active links = %+v, want only %s", active, keep.ID.Hex())
+	}
+}
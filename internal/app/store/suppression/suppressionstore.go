@@ -0,0 +1,193 @@
+// internal/app/store/suppression/suppressionstore.go
+package suppressionstore
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Suppression reasons.
+const (
+	ReasonBounce    = "bounce"    // Permanent (hard) bounce
+	ReasonComplaint = "complaint" // Recipient marked the email as spam
+	ReasonManual    = "manual"    // Added by an administrator
+)
+
+// Suppression is an address that email must not be sent to.
+type Suppression struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Email       string             `bson:"email"` // Normalized (lowercase, trimmed)
+	Reason      string             `bson:"reason"`
+	Source      string             `bson:"source"`           // e.g. "ses", "webhook", "admin"
+	Detail      string             `bson:"detail,omitempty"` // Provider diagnostic or admin note
+	Events      int                `bson:"events"`           // Number of bounce/complaint reports received
+	CreatedAt   time.Time          `bson:"created_at"`
+	LastEventAt time.Time          `bson:"last_event_at"`
+}
+
+// ErrNotFound is returned when a suppression is not found.
+var ErrNotFound = errors.New("suppression not found")
+
+// Store provides access to the email_suppressions collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new suppression store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("email_suppressions")}
+}
+
+// AddInput holds the fields for suppressing an address.
+type AddInput struct {
+	Email  string
+	Reason string
+	Source string
+	Detail string
+}
+
+// Add suppresses an address. If it is already suppressed, the reason,
+// source, and detail are replaced with the latest report and the event
+// count is incremented.
+func (s *Store) Add(ctx context.Context, input AddInput) error {
+	email := normalize.Email(input.Email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+	now := time.Now()
+	_, err := s.c.UpdateOne(ctx,
+		bson.M{"email": email},
+		bson.M{
+			"$set": bson.M{
+				"reason":        input.Reason,
+				"source":        input.Source,
+				"detail":        input.Detail,
+				"last_event_at": now,
+			},
+			"$inc":         bson.M{"events": 1},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// IsSuppressed reports whether email is on the suppression list.
+func (s *Store) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	email = normalize.Email(email)
+	if email == "" {
+		return false, nil
+	}
+	err := s.c.FindOne(ctx, bson.M{"email": email},
+		options.FindOne().SetProjection(bson.M{"_id": 1})).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetByID retrieves a suppression by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Suppression, error) {
+	var sup Suppression
+	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&sup); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &sup, nil
+}
+
+// Remove deletes a suppression so the address can receive email again.
+func (s *Store) Remove(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListFilter specifies criteria for listing suppressions.
+type ListFilter struct {
+	Reason string
+	Email  string // Prefix match on the normalized address
+}
+
+// ListResult contains a page of suppressions with pagination info.
+type ListResult struct {
+	Suppressions []Suppression
+	TotalCount   int64
+	Page         int
+	PageSize     int
+	TotalPages   int
+}
+
+// List returns suppressions matching the filter, most recent first.
+func (s *Store) List(ctx context.Context, filter ListFilter, page, pageSize int) (ListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 200 {
+		pageSize = 200
+	}
+
+	query := bson.M{}
+	if filter.Reason != "" {
+		query["reason"] = filter.Reason
+	}
+	if e := normalize.Email(filter.Email); e != "" {
+		query["email"] = bson.M{"$regex": "^" + regexp.QuoteMeta(e)}
+	}
+
+	total, err := s.c.CountDocuments(ctx, query)
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	skip := (page - 1) * pageSize
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_event_at", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(pageSize))
+
+	cur, err := s.c.Find(ctx, query, opts)
+	if err != nil {
+		return ListResult{}, err
+	}
+	defer cur.Close(ctx)
+
+	var sups []Suppression
+	if err := cur.All(ctx, &sups); err != nil {
+		return ListResult{}, err
+	}
+
+	return ListResult{
+		Suppressions: sups,
+		TotalCount:   total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages,
+	}, nil
+}
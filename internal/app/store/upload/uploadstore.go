@@ -0,0 +1,158 @@
+// Package upload provides storage for in-progress library uploads.
+//
+// A resumable upload is sent in chunks. Each chunk is written to file
+// storage as its own object and recorded here, so an interrupted upload can
+// continue from the last chunk received. Once every byte has arrived the
+// chunks are joined into the final file.
+//
+// A direct upload is sent by the browser straight to the storage bucket
+// with a presigned URL. It is recorded here until the browser reports that
+// it has finished, so abandoned objects can be cleaned up.
+package upload
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Chunk is one piece of an upload, stored as its own object.
+type Chunk struct {
+	Path string `bson:"path"`
+	Size int64  `bson:"size"`
+}
+
+// Upload is an upload that hasn't finished yet.
+type Upload struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID  `bson:"user_id"`
+	FolderID    *primitive.ObjectID `bson:"folder_id,omitempty"`
+	Name        string              `bson:"name"`
+	ContentType string              `bson:"content_type"`
+	Description string              `bson:"description,omitempty"`
+	Size        int64               `bson:"size"`   // Total bytes expected
+	Offset      int64               `bson:"offset"` // Bytes received so far
+	Chunks      []Chunk             `bson:"chunks"`
+	StoragePath string              `bson:"storage_path,omitempty"` // Set for direct uploads
+	Storage     Storage             `bson:"storage,omitempty"`      // How the assembled file is stored
+	CreatedAt   time.Time           `bson:"created_at"`
+	UpdatedAt   time.Time           `bson:"updated_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+// Storage holds the storage settings chosen for an upload's file. Empty
+// fields leave the choice to the storage backend.
+type Storage struct {
+	Encryption   string `bson:"encryption,omitempty"`
+	StorageClass string `bson:"storage_class,omitempty"`
+	CacheControl string `bson:"cache_control,omitempty"`
+}
+
+// Complete reports whether every byte of the upload has been received.
+func (u *Upload) Complete() bool {
+	return u.Offset >= u.Size
+}
+
+// Direct reports whether the upload goes straight to storage rather than
+// through the server in chunks.
+func (u *Upload) Direct() bool {
+	return u.StoragePath != ""
+}
+
+// Store provides access to the file_uploads collection.
+type Store struct {
+	c *mongo.Collection
+}
+
+// New creates a new upload store.
+func New(db *mongo.Database) *Store {
+	return &Store{c: db.Collection("file_uploads")}
+}
+
+// CreateInput contains the input for starting an upload.
+type CreateInput struct {
+	UserID      primitive.ObjectID
+	FolderID    *primitive.ObjectID
+	Name        string
+	ContentType string
+	Description string
+	Size        int64
+	StoragePath string // Where a direct upload is sent (empty for chunked uploads)
+	Storage     Storage
+}
+
+// Create records a new upload that expires after ttl without progress.
+func (s *Store) Create(ctx context.Context, input CreateInput, ttl time.Duration) (*Upload, error) {
+	now := time.Now().UTC()
+	u := Upload{
+		ID:          primitive.NewObjectID(),
+		UserID:      input.UserID,
+		FolderID:    input.FolderID,
+		Name:        input.Name,
+		ContentType: input.ContentType,
+		Description: input.Description,
+		Size:        input.Size,
+		Chunks:      []Chunk{},
+		StoragePath: input.StoragePath,
+		Storage:     input.Storage,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if _, err := s.c.InsertOne(ctx, u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// GetByID retrieves an upload by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Upload, error) {
+	var u Upload
+	if err := s.c.FindOne(ctx, bson.M{"_id": id}).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// AppendChunk records a chunk received at offset and extends the upload's
+// expiry by ttl. It reports false, without changing anything, if the
+// upload's offset is no longer offset (another request got there first).
+func (s *Store) AppendChunk(ctx context.Context, id primitive.ObjectID, offset int64, chunk Chunk, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "offset": offset},
+		bson.M{
+			"$push": bson.M{"chunks": chunk},
+			"$inc":  bson.M{"offset": chunk.Size},
+			"$set":  bson.M{"updated_at": now, "expires_at": now.Add(ttl)},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// Delete removes an upload record. Its chunks must be deleted separately.
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// ListExpired returns uploads whose expiry has passed.
+func (s *Store) ListExpired(ctx context.Context, now time.Time) ([]Upload, error) {
+	cur, err := s.c.Find(ctx, bson.M{"expires_at": bson.M{"$lt": now}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var uploads []Upload
+	if err := cur.All(ctx, &uploads); err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
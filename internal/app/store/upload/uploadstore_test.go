@@ -0,0 +1,71 @@
+package upload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_AppendChunk(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	u, err := store.Create(ctx, CreateInput{
+		UserID: primitive.NewObjectID(),
+		Name:   "video.mp4",
+		Size:   10,
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	ok, err := store.AppendChunk(ctx, u.ID, 0, Chunk{Path: "a", Size: 6}, time.Hour)
+	if err != nil || !ok {
+		t.Fatalf("AppendChunk(0) = %v, %v; want true, nil", ok, err)
+	}
+	// A retry of the same chunk no longer matches the offset
+	if ok, _ := store.AppendChunk(ctx, u.ID, 0, Chunk{Path: "b", Size: 6}, time.Hour); ok {
+		t.Error("AppendChunk() at a stale offset should not apply")
+	}
+	if ok, _ := store.AppendChunk(ctx, u.ID, 6, Chunk{Path: "c", Size: 4}, time.Hour); !ok {
+		t.Error("AppendChunk(6) should apply")
+	}
+
+	got, err := store.GetByID(ctx, u.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Offset != 10 || !got.Complete() {
+		t.Errorf("offset = %d, complete = %v; want 10, true", got.Offset, got.Complete())
+	}
+	if len(got.Chunks) != 2 || got.Chunks[0].Path != "a" || got.Chunks[1].Path != "c" {
+		t.Errorf("chunks = %+v, want a then c", got.Chunks)
+	}
+}
+
+func TestStore_ListExpired(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	expired, err := store.Create(ctx, CreateInput{UserID: primitive.NewObjectID(), Size: 1}, -time.Minute)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.Create(ctx, CreateInput{UserID: primitive.NewObjectID(), Size: 1}, time.Hour); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	list, err := store.ListExpired(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("ListExpired() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ID != expired.ID {
+		t.Errorf("ListExpired() = %d uploads, want only the expired one", len(list))
+	}
+}
@@ -50,14 +50,17 @@ func (f *Fetcher) FetchUser(ctx context.Context, userID string) *auth.SessionUse
 	// Fetch the user with projection for only needed fields
 	var u models.User
 	proj := options.FindOne().SetProjection(bson.M{
-		"_id":              1,
-		"full_name":        1,
-		"login_id":         1,
-		"login_id_ci":      1,
-		"auth_method":      1,
-		"role":             1,
-		"status":           1,
-		"theme_preference": 1,
+		"_id":                  1,
+		"full_name":            1,
+		"login_id":             1,
+		"login_id_ci":          1,
+		"auth_method":          1,
+		"role":                 1,
+		"status":               1,
+		"theme_preference":     1,
+		"timezone":             1,
+		"avatar_path":          1,
+		"sessions_valid_after": 1,
 	})
 
 	if err := f.users.FindOne(ctx, bson.M{"_id": oid}, proj).Decode(&u); err != nil {
@@ -65,8 +68,8 @@ func (f *Fetcher) FetchUser(ctx context.Context, userID string) *auth.SessionUse
 		return nil
 	}
 
-	// Check if user is disabled
-	if normalize.Status(u.Status) == "disabled" {
+	// Check if user is disabled, deleted, or still awaiting approval
+	if st := normalize.Status(u.Status); st == "disabled" || st == "pending" || st == "deleted" {
 		return nil
 	}
 
@@ -81,6 +84,11 @@ func (f *Fetcher) FetchUser(ctx context.Context, userID string) *auth.SessionUse
 		LoginID:         loginID,
 		Role:            normalize.Role(u.Role),
 		ThemePreference: u.ThemePreference,
+		Timezone:        u.Timezone,
+		AvatarPath:      u.AvatarPath,
+	}
+	if u.SessionsValidAfter != nil {
+		su.SessionsValidAfter = *u.SessionsValidAfter
 	}
 
 	return su
@@ -82,8 +82,12 @@ func (s *Store) GetByLoginIDAndAuthMethod(ctx context.Context, loginID, authMeth
 var (
 	// ErrDuplicateLoginID is returned when attempting to create a user with a login_id that already exists.
 	ErrDuplicateLoginID = errors.New("a user with this login ID already exists")
-	errBadRole          = errors.New("invalid role")
-	errBadStatus        = errors.New(`status must be "active"|"disabled"`)
+	// ErrLastMethod is returned when unlinking a user's only sign-in method.
+	ErrLastMethod = errors.New("the account has no other sign-in method")
+	// ErrGoogleAccountInUse is returned when linking a Google account that is linked to another user.
+	ErrGoogleAccountInUse = errors.New("this Google account is linked to another user")
+	errBadRole            = errors.New("invalid role")
+	errBadStatus          = errors.New(`status must be "active"|"disabled"|"pending"`)
 )
 
 // Create inserts a new user after normalizing & validating fields.
@@ -125,6 +129,9 @@ func (s *Store) Create(ctx context.Context, u models.User) (models.User, error)
 	now := time.Now()
 	u.CreatedAt = now
 	u.UpdatedAt = now
+	if u.PasswordHash != nil && u.PasswordChangedAt == nil {
+		u.PasswordChangedAt = &now
+	}
 
 	// Insert
 	if _, err := s.c.InsertOne(ctx, u); err != nil {
@@ -172,7 +179,7 @@ func (s *Store) Update(ctx context.Context, id primitive.ObjectID, upd UserUpdat
 
 	// Handle optional password reset
 	if upd.PasswordHash != nil {
-		set["password_hash"] = *upd.PasswordHash
+		setPasswordFields(set, *upd.PasswordHash)
 		if upd.PasswordTemp != nil {
 			set["password_temp"] = *upd.PasswordTemp
 		}
@@ -198,6 +205,130 @@ func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) (int64, error
 	return res.DeletedCount, nil
 }
 
+// SoftDelete marks a user deleted, remembering their status so Restore can
+// put it back. Returns mongo.ErrNoDocuments if the user doesn't exist or is
+// already deleted.
+func (s *Store) SoftDelete(ctx context.Context, id, deletedByID primitive.ObjectID, at time.Time) error {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": status.Deleted}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"restore_status": "$status",
+			"status":         status.Deleted,
+			"deleted_at":     at,
+			"deleted_by_id":  deletedByID,
+			"updated_at":     at,
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Restore returns a deleted user to the status they had when deleted, and
+// clears any deletion they had scheduled. Returns mongo.ErrNoDocuments if the user doesn't exist or isn't deleted.
+func (s *Store) Restore(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "status": status.Deleted},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"status":     bson.M{"$ifNull": bson.A{"$restore_status", status.Active}},
+				"updated_at": time.Now(),
+			}}},
+			{{Key: "$unset", Value: bson.A{"restore_status", "deleted_at", "deleted_by_id", "delete_at"}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// ScheduleDeletion schedules a user's own request to delete their account
+// for at. The user can cancel with CancelDeletion until then.
+func (s *Store) ScheduleDeletion(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"delete_at":  at,
+		"updated_at": time.Now(),
+	}})
+	return err
+}
+
+// CancelDeletion clears a user's scheduled deletion.
+func (s *Store) CancelDeletion(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"delete_at": ""},
+	})
+	return err
+}
+
+// ListDeletionDue returns users, not yet deleted, whose scheduled deletion
+// date is at or before now.
+func (s *Store) ListDeletionDue(ctx context.Context, now time.Time) ([]models.User, error) {
+	return s.Find(ctx, bson.M{
+		"status":    bson.M{"$ne": status.Deleted},
+		"delete_at": bson.M{"$lte": now},
+	})
+}
+
+// DeleteScheduled deletes a user whose scheduled deletion date is at or
+// before now, as SoftDelete does with the user as the one who deleted it,
+// and clears the schedule. It reports false if the user was already
+// deleted or canceled.
+func (s *Store) DeleteScheduled(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$ne": status.Deleted}, "delete_at": bson.M{"$lte": now}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"restore_status": "$status",
+				"status":         status.Deleted,
+				"deleted_at":     now,
+				"deleted_by_id":  id,
+				"updated_at":     now,
+			}}},
+			{{Key: "$unset", Value: bson.A{"delete_at"}}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// IsLastActiveAdmin reports whether u is an active admin and no other
+// active admin remains, so deleting the account would leave no one able
+// to administer the site.
+func (s *Store) IsLastActiveAdmin(ctx context.Context, u *models.User) (bool, error) {
+	if normalize.Role(u.Role) != models.RoleAdmin || normalize.Status(u.Status) != status.Active {
+		return false, nil
+	}
+	n, err := s.CountActiveAdmins(ctx)
+	if err != nil {
+		return false, err
+	}
+	return n <= 1, nil
+}
+
+// ListDeleted returns deleted users, most recently deleted first.
+func (s *Store) ListDeleted(ctx context.Context) ([]models.User, error) {
+	return s.Find(ctx, bson.M{"status": status.Deleted},
+		options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}}))
+}
+
+// ListDeletedBefore returns up to limit users deleted before cutoff,
+// oldest first.
+func (s *Store) ListDeletedBefore(ctx context.Context, cutoff time.Time, limit int64) ([]models.User, error) {
+	return s.Find(ctx, bson.M{"status": status.Deleted, "deleted_at": bson.M{"$lt": cutoff}},
+		options.Find().SetSort(bson.D{{Key: "deleted_at", Value: 1}}).SetLimit(limit))
+}
+
 // LoginIDExistsForOther checks if a login_id already exists for a user other than the given ID.
 func (s *Store) LoginIDExistsForOther(ctx context.Context, loginID string, excludeID primitive.ObjectID) (bool, error) {
 	err := s.c.FindOne(ctx, bson.M{
@@ -237,6 +368,28 @@ func (s *Store) Find(ctx context.Context, filter bson.M, opts ...*options.FindOp
 	return users, nil
 }
 
+// Each calls fn for every user matching the filter, in the order given by
+// opts, without holding them all in memory. It stops at the first error fn
+// returns and returns it.
+func (s *Store) Each(ctx context.Context, filter bson.M, fn func(models.User) error, opts ...*options.FindOptions) error {
+	cur, err := s.c.Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var u models.User
+		if err := cur.Decode(&u); err != nil {
+			return err
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
 // Count returns the number of users matching the given filter.
 func (s *Store) Count(ctx context.Context, filter bson.M) (int64, error) {
 	return s.c.CountDocuments(ctx, filter)
@@ -253,18 +406,241 @@ func (s *Store) UpdateThemePreference(ctx context.Context, id primitive.ObjectID
 	return err
 }
 
+// UpdateLocale updates the language a user's email is sent in.
+// An empty locale means the default language.
+func (s *Store) UpdateLocale(ctx context.Context, id primitive.ObjectID, locale string) error {
+	set := bson.M{
+		"locale":     locale,
+		"updated_at": time.Now(),
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// UpdateTimezone updates the timezone a user's times are shown in.
+// An empty timezone means UTC.
+func (s *Store) UpdateTimezone(ctx context.Context, id primitive.ObjectID, tz string) error {
+	set := bson.M{
+		"timezone":   tz,
+		"updated_at": time.Now(),
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// UpdateAvatar sets the path of a user's profile photo in file storage.
+// An empty path removes the photo.
+func (s *Store) UpdateAvatar(ctx context.Context, id primitive.ObjectID, path string) error {
+	update := bson.M{"$set": bson.M{"avatar_path": path, "updated_at": time.Now()}}
+	if path == "" {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"avatar_path": ""},
+		}
+	}
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
 // UpdatePassword updates a user's password hash and clears the temporary flag.
 // This is used when a user changes their own password (not a temp password reset).
 func (s *Store) UpdatePassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
 	set := bson.M{
-		"password_hash": passwordHash,
 		"password_temp": false,
 		"updated_at":    time.Now(),
 	}
+	setPasswordFields(set, passwordHash)
 	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
 	return err
 }
 
+// GetByGoogleID looks up the user a Google account is linked to.
+func (s *Store) GetByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+	if googleID == "" {
+		return nil, mongo.ErrNoDocuments
+	}
+	var u models.User
+	if err := s.c.FindOne(ctx, bson.M{"google_id": googleID}).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// SetGoogleID records the Google account a Google-auth user signs in with,
+// without changing their sign-in methods.
+func (s *Store) SetGoogleID(ctx context.Context, id primitive.ObjectID, googleID string) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"google_id": googleID, "updated_at": time.Now()}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrGoogleAccountInUse
+	}
+	return err
+}
+
+// LinkPassword sets a password for the user and connects password sign-in
+// to their account.
+func (s *Store) LinkPassword(ctx context.Context, id primitive.ObjectID, passwordHash string) error {
+	set := bson.M{
+		"password_temp": false,
+		"updated_at":    time.Now(),
+	}
+	setPasswordFields(set, passwordHash)
+	return s.link(ctx, id, "password", set)
+}
+
+// LinkGoogle connects a Google account to the user. It returns
+// ErrGoogleAccountInUse if the Google account is linked to another user.
+func (s *Store) LinkGoogle(ctx context.Context, id primitive.ObjectID, googleID string) error {
+	err := s.link(ctx, id, "google", bson.M{"google_id": googleID, "updated_at": time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrGoogleAccountInUse
+	}
+	return err
+}
+
+// link adds method to the user's linked sign-in methods along with set.
+func (s *Store) link(ctx context.Context, id primitive.ObjectID, method string, set bson.M) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":      set,
+		"$addToSet": bson.M{"linked_methods": method},
+	})
+	return err
+}
+
+// UnlinkMethod disconnects a sign-in method from u's account and clears its
+// credentials. Unlinking the primary auth_method makes the next linked
+// method primary. It returns ErrLastMethod if u has no other method, and
+// mongo.ErrNoDocuments if u's primary method changed since u was loaded.
+func (s *Store) UnlinkMethod(ctx context.Context, u *models.User, method string) error {
+	var remaining []string
+	for _, m := range u.Methods() {
+		if m != method {
+			remaining = append(remaining, m)
+		}
+	}
+	if len(remaining) == 0 {
+		return ErrLastMethod
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	pull := []string{method}
+	if u.AuthMethod == method {
+		set["auth_method"] = remaining[0]
+		pull = append(pull, remaining[0])
+	}
+	update := bson.M{
+		"$set":  set,
+		"$pull": bson.M{"linked_methods": bson.M{"$in": pull}},
+	}
+	switch method {
+	case "password":
+		update["$unset"] = bson.M{"password_hash": "", "password_temp": "", "password_changed_at": "", "password_expiry_warned_at": ""}
+	case "google":
+		update["$unset"] = bson.M{"google_id": ""}
+	}
+
+	res, err := s.c.UpdateOne(ctx, bson.M{"_id": u.ID, "auth_method": u.AuthMethod}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateLoginID // another account has this login ID and the new primary method
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// setPasswordFields adds a new password hash to an update, restarting the
+// password's age and clearing any expiry warning sent for the old one.
+func setPasswordFields(set bson.M, passwordHash string) {
+	set["password_hash"] = passwordHash
+	set["password_changed_at"] = time.Now()
+	set["password_expiry_warned_at"] = nil
+}
+
+// ListPasswordsChangedBefore returns active users with a password that was
+// last set before cutoff and who haven't been warned about its
+// expiry. Accounts created before password ages were recorded count from
+// their creation date.
+func (s *Store) ListPasswordsChangedBefore(ctx context.Context, cutoff time.Time) ([]models.User, error) {
+	return s.Find(ctx, bson.M{
+		"password_hash":             bson.M{"$ne": nil},
+		"status":                    status.Active,
+		"password_expiry_warned_at": nil,
+		"$or": []bson.M{
+			{"password_changed_at": bson.M{"$lt": cutoff}},
+			{"password_changed_at": nil, "created_at": bson.M{"$lt": cutoff}},
+		},
+	})
+}
+
+// MarkPasswordExpiryWarned records that a user was warned their password
+// is about to expire, so the warning isn't sent again.
+func (s *Store) MarkPasswordExpiryWarned(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"password_expiry_warned_at": time.Now()}})
+	return err
+}
+
+// ListDisablingBefore returns active users scheduled to be disabled before
+// cutoff who haven't been warned about it.
+func (s *Store) ListDisablingBefore(ctx context.Context, cutoff time.Time) ([]models.User, error) {
+	return s.Find(ctx, bson.M{
+		"status":            status.Active,
+		"disable_at":        bson.M{"$lt": cutoff},
+		"disable_warned_at": nil,
+	})
+}
+
+// MarkDisableWarned records that a user was warned their account is about
+// to be disabled, so the warning isn't sent again.
+func (s *Store) MarkDisableWarned(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"disable_warned_at": time.Now()}})
+	return err
+}
+
+// ListDisableDue returns active users whose scheduled disable date is at
+// or before now.
+func (s *Store) ListDisableDue(ctx context.Context, now time.Time) ([]models.User, error) {
+	return s.Find(ctx, bson.M{
+		"status":     status.Active,
+		"disable_at": bson.M{"$lte": now},
+	})
+}
+
+// DisableScheduled disables an active user whose scheduled disable date is
+// at or before now, and clears the schedule so re-enabling the account
+// doesn't disable it again. It reports false if the user was already
+// disabled or rescheduled.
+func (s *Store) DisableScheduled(ctx context.Context, id primitive.ObjectID, now time.Time) (bool, error) {
+	res, err := s.c.UpdateOne(ctx,
+		bson.M{"_id": id, "status": status.Active, "disable_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{
+			"status":            status.Disabled,
+			"disable_at":        nil,
+			"disable_warned_at": nil,
+			"updated_at":        now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// InvalidateSessions makes every session for the user issued before the
+// given time invalid. The user's next request from one of them signs them out.
+func (s *Store) InvalidateSessions(ctx context.Context, id primitive.ObjectID, before time.Time) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"sessions_valid_after": before}})
+	return err
+}
+
+// RecordLogin records when a user signed in.
+func (s *Store) RecordLogin(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_login_at": at}})
+	return err
+}
+
 // ExistsByLoginID checks if a user with the given login_id exists.
 func (s *Store) ExistsByLoginID(ctx context.Context, loginID string) (bool, error) {
 	count, err := s.c.CountDocuments(ctx, bson.M{
@@ -310,8 +686,10 @@ type CreateInput struct {
 	Email        string
 	AuthMethod   string
 	Role         string
+	Status       string // Empty means active
 	PasswordHash *string
 	PasswordTemp *bool
+	DisableAt    *time.Time // When the account is disabled (nil = never)
 }
 
 // CreateFromInput creates a new user from CreateInput.
@@ -320,6 +698,7 @@ func (s *Store) CreateFromInput(ctx context.Context, input CreateInput) (models.
 		FullName:   input.FullName,
 		AuthMethod: input.AuthMethod,
 		Role:       input.Role,
+		Status:     input.Status,
 	}
 
 	if input.LoginID != "" {
@@ -334,6 +713,9 @@ func (s *Store) CreateFromInput(ctx context.Context, input CreateInput) (models.
 	if input.PasswordTemp != nil {
 		u.PasswordTemp = input.PasswordTemp
 	}
+	if input.DisableAt != nil {
+		u.DisableAt = input.DisableAt
+	}
 
 	return s.Create(ctx, u)
 }
@@ -350,6 +732,7 @@ type UpdateInput struct {
 	PasswordHash    *string
 	PasswordTemp    *bool
 	ThemePreference *string
+	DisableAt       *time.Time // When the account is disabled; a zero time clears it
 }
 
 // UpdateFromInput updates a user using optional fields.
@@ -381,7 +764,7 @@ func (s *Store) UpdateFromInput(ctx context.Context, id primitive.ObjectID, inpu
 		set["status"] = *input.Status
 	}
 	if input.PasswordHash != nil {
-		set["password_hash"] = *input.PasswordHash
+		setPasswordFields(set, *input.PasswordHash)
 	}
 	if input.PasswordTemp != nil {
 		set["password_temp"] = *input.PasswordTemp
@@ -389,6 +772,14 @@ func (s *Store) UpdateFromInput(ctx context.Context, id primitive.ObjectID, inpu
 	if input.ThemePreference != nil {
 		set["theme_preference"] = *input.ThemePreference
 	}
+	if input.DisableAt != nil {
+		if input.DisableAt.IsZero() {
+			set["disable_at"] = nil
+		} else {
+			set["disable_at"] = *input.DisableAt
+		}
+		set["disable_warned_at"] = nil // warn again for the new date
+	}
 
 	_, err := s.c.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
 	if err != nil {
@@ -1,13 +1,17 @@
 package userstore
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dalemusser/stratasave/internal/domain/models"
 	"github.com/dalemusser/stratasave/internal/testutil"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
@@ -300,6 +304,74 @@ func TestStore_Delete_NotFound(t *testing.T) {
 	}
 }
 
+func TestStore_SoftDeleteAndRestore(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	loginID := "softdelete@example.com"
+	created, err := store.Create(ctx, models.User{
+		FullName:   "Soft Delete",
+		LoginID:    &loginID,
+		AuthMethod: "password",
+		Role:       "admin",
+		Status:     "disabled",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	adminID := primitive.NewObjectID()
+	deletedAt := time.Now().Add(-48 * time.Hour)
+	if err := store.SoftDelete(ctx, created.ID, adminID, deletedAt); err != nil {
+		t.Fatalf("SoftDelete() error = %v", err)
+	}
+	if err := store.SoftDelete(ctx, created.ID, adminID, deletedAt); err != mongo.ErrNoDocuments {
+		t.Errorf("SoftDelete() twice error = %v, want %v", err, mongo.ErrNoDocuments)
+	}
+
+	got, err := store.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Status != "deleted" || got.DeletedAt == nil || got.DeletedByID == nil || *got.DeletedByID != adminID {
+		t.Errorf("after SoftDelete() user = %+v, want deleted by %s", got, adminID.Hex())
+	}
+
+	deleted, err := store.ListDeleted(ctx)
+	if err != nil {
+		t.Fatalf("ListDeleted() error = %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != created.ID {
+		t.Errorf("ListDeleted() = %d users, want the deleted user", len(deleted))
+	}
+	old, err := store.ListDeletedBefore(ctx, time.Now().Add(-24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDeletedBefore() error = %v", err)
+	}
+	if len(old) != 1 {
+		t.Errorf("ListDeletedBefore(1 day ago) = %d users, want 1", len(old))
+	}
+	if old, _ := store.ListDeletedBefore(ctx, time.Now().Add(-72*time.Hour), 10); len(old) != 0 {
+		t.Errorf("ListDeletedBefore(3 days ago) = %d users, want 0", len(old))
+	}
+
+	if err := store.Restore(ctx, created.ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if err := store.Restore(ctx, created.ID); err != mongo.ErrNoDocuments {
+		t.Errorf("Restore() twice error = %v, want %v", err, mongo.ErrNoDocuments)
+	}
+	got, err = store.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Status != "disabled" || got.DeletedAt != nil || got.DeletedByID != nil || got.RestoreStatus != "" {
+		t.Errorf("after Restore() user = %+v, want disabled with delete fields cleared", got)
+	}
+}
+
 func TestStore_CountActiveAdmins(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -632,6 +704,98 @@ func TestStore_UpdateThemePreference(t *testing.T) {
 	}
 }
 
+func TestStore_RecordLogin(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	loginID := "recordlogin@example.com"
+	created, err := store.Create(ctx, models.User{
+		FullName:   "Record Login",
+		LoginID:    &loginID,
+		AuthMethod: "trust",
+		Role:       "developer",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.LastLoginAt != nil {
+		t.Fatalf("new user LastLoginAt = %v, want nil", created.LastLoginAt)
+	}
+
+	at := time.Now().Truncate(time.Millisecond)
+	if err := store.RecordLogin(ctx, created.ID, at); err != nil {
+		t.Fatalf("RecordLogin() error = %v", err)
+	}
+	got, err := store.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.LastLoginAt == nil || !got.LastLoginAt.Equal(at) {
+		t.Errorf("LastLoginAt = %v, want %v", got.LastLoginAt, at)
+	}
+}
+
+func TestStore_DisableScheduled(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	now := time.Now().Truncate(time.Millisecond)
+	disableAt := now.Add(48 * time.Hour)
+	created, err := store.CreateFromInput(ctx, CreateInput{
+		FullName:   "Seasonal Staff",
+		LoginID:    "seasonal@example.com",
+		AuthMethod: "trust",
+		Role:       "developer",
+		DisableAt:  &disableAt,
+	})
+	if err != nil {
+		t.Fatalf("CreateFromInput() error = %v", err)
+	}
+
+	warn, err := store.ListDisablingBefore(ctx, now.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("ListDisablingBefore() error = %v", err)
+	}
+	if len(warn) != 1 || warn[0].ID != created.ID {
+		t.Fatalf("ListDisablingBefore() = %d users, want the scheduled user", len(warn))
+	}
+	if err := store.MarkDisableWarned(ctx, created.ID); err != nil {
+		t.Fatalf("MarkDisableWarned() error = %v", err)
+	}
+	if warn, _ = store.ListDisablingBefore(ctx, now.Add(72*time.Hour)); len(warn) != 0 {
+		t.Errorf("ListDisablingBefore() after warning = %d users, want 0", len(warn))
+	}
+
+	// Not due yet
+	if due, _ := store.ListDisableDue(ctx, now); len(due) != 0 {
+		t.Errorf("ListDisableDue() before the date = %d users, want 0", len(due))
+	}
+	if ok, err := store.DisableScheduled(ctx, created.ID, now); err != nil || ok {
+		t.Errorf("DisableScheduled() before the date = %v, %v; want false, nil", ok, err)
+	}
+
+	later := disableAt.Add(time.Hour)
+	if due, _ := store.ListDisableDue(ctx, later); len(due) != 1 {
+		t.Errorf("ListDisableDue() after the date = %d users, want 1", len(due))
+	}
+	ok, err := store.DisableScheduled(ctx, created.ID, later)
+	if err != nil || !ok {
+		t.Fatalf("DisableScheduled() = %v, %v; want true, nil", ok, err)
+	}
+	got, err := store.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Status != "disabled" || got.DisableAt != nil || got.DisableWarnedAt != nil {
+		t.Errorf("after DisableScheduled user = status %q, disable_at %v, warned %v; want disabled with the schedule cleared",
+			got.Status, got.DisableAt, got.DisableWarnedAt)
+	}
+}
+
 func TestStore_UpdatePassword(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -675,6 +839,60 @@ func TestStore_UpdatePassword(t *testing.T) {
 	}
 }
 
+func TestStore_PasswordExpiryWarnings(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	loginID := "expiry@example.com"
+	passwordHash := "initial_hash"
+	created, err := store.Create(ctx, models.User{
+		FullName:     "Expiry User",
+		LoginID:      &loginID,
+		AuthMethod:   "password",
+		Role:         "admin",
+		PasswordHash: &passwordHash,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.PasswordChangedAt == nil {
+		t.Fatal("Create() should record when the password was set")
+	}
+
+	future := time.Now().Add(time.Hour)
+	users, err := store.ListPasswordsChangedBefore(ctx, future)
+	if err != nil {
+		t.Fatalf("ListPasswordsChangedBefore() error = %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("ListPasswordsChangedBefore() returned %d users, want 1", len(users))
+	}
+
+	if err := store.MarkPasswordExpiryWarned(ctx, created.ID); err != nil {
+		t.Fatalf("MarkPasswordExpiryWarned() error = %v", err)
+	}
+	if users, _ := store.ListPasswordsChangedBefore(ctx, future); len(users) != 0 {
+		t.Error("a warned user should not be listed again")
+	}
+
+	// A new password restarts the clock and clears the warning
+	if err := store.UpdatePassword(ctx, created.ID, "new_hash"); err != nil {
+		t.Fatalf("UpdatePassword() error = %v", err)
+	}
+	updated, err := store.GetByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if updated.PasswordExpiryWarnedAt != nil {
+		t.Error("UpdatePassword() should clear the expiry warning")
+	}
+	if updated.PasswordChangedAt == nil || updated.PasswordChangedAt.Before(created.PasswordChangedAt.Truncate(time.Millisecond)) {
+		t.Error("UpdatePassword() should update password_changed_at")
+	}
+}
+
 func TestStore_Find(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -733,6 +951,47 @@ func TestStore_Find(t *testing.T) {
 	}
 }
 
+func TestStore_Each(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	for _, name := range []string{"Each B", "Each A", "Each C"} {
+		loginID := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+		if _, err := store.Create(ctx, models.User{
+			FullName:   name,
+			LoginID:    &loginID,
+			AuthMethod: "trust",
+			Role:       "admin",
+		}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	var names []string
+	err := store.Each(ctx, bson.M{}, func(u models.User) error {
+		names = append(names, u.FullName)
+		return nil
+	}, options.Find().SetSort(bson.D{{Key: "full_name_ci", Value: 1}}))
+	if err != nil {
+		t.Fatalf("Each() error = %v", err)
+	}
+	if strings.Join(names, ",") != "Each A,Each B,Each C" {
+		t.Errorf("Each() visited %v, want Each A, Each B, Each C in order", names)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = store.Each(ctx, bson.M{}, func(models.User) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Each() = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
 func TestStore_Count(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -1191,6 +1450,29 @@ func TestFetcher_FetchUser_Disabled(t *testing.T) {
 	}
 }
 
+func TestFetcher_FetchUser_Deleted(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store := New(db)
+	fetcher := NewFetcher(db, zap.NewNop())
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	loginID := "deleted@example.com"
+	created, _ := store.Create(ctx, models.User{
+		FullName:   "Deleted User",
+		LoginID:    &loginID,
+		AuthMethod: "password",
+		Role:       "admin",
+	})
+	if err := store.SoftDelete(ctx, created.ID, primitive.NewObjectID(), time.Now()); err != nil {
+		t.Fatalf("SoftDelete() error = %v", err)
+	}
+
+	if fetcher.FetchUser(ctx, created.ID.Hex()) != nil {
+		t.Error("FetchUser() deleted user should return nil")
+	}
+}
+
 func TestFetcher_FetchUser_NoLoginID(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	store := New(db)
@@ -0,0 +1,448 @@
+// internal/app/store/webhooks/webhookstore.go
+package webhookstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Delivery status constants.
+const (
+	StatusQueued    = "queued"    // Waiting for its first delivery attempt
+	StatusRetrying  = "retrying"  // A delivery attempt failed; another is scheduled
+	StatusDelivered = "delivered" // The endpoint answered with a 2xx status
+	StatusFailed    = "failed"    // All delivery attempts failed
+)
+
+// Endpoint is a URL registered to receive webhook events.
+type Endpoint struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Name      string             `bson:"name"`
+	URL       string             `bson:"url"`
+	Secret    string             `bson:"secret"` // Signs each payload; shown to the admin when created or rotated
+	Events    []string           `bson:"events"`
+	Active    bool               `bson:"active"`
+	CreatedBy primitive.ObjectID `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint receives event.
+func (e Endpoint) Subscribes(event string) bool {
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event sent, or to be sent, to one endpoint.
+type Delivery struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	EndpointID    primitive.ObjectID `bson:"endpoint_id"`
+	Event         string             `bson:"event"`
+	Payload       string             `bson:"payload"` // JSON body, as sent
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	MaxAttempts   int                `bson:"max_attempts"`
+	ResponseCode  int                `bson:"response_code,omitempty"` // HTTP status of the last attempt
+	ResponseBody  string             `bson:"response_body,omitempty"` // Start of the last response body
+	LastError     string             `bson:"last_error,omitempty"`
+	JobID         primitive.ObjectID `bson:"job_id,omitempty"`          // Job delivering this event
+	NextAttemptAt *time.Time         `bson:"next_attempt_at,omitempty"` // Set while retrying
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+}
+
+var (
+	// ErrNotFound is returned when an endpoint or delivery is not found.
+	ErrNotFound = errors.New("webhook not found")
+	// ErrDuplicateName is returned when another endpoint has the same name.
+	ErrDuplicateName = errors.New("a webhook with this name already exists")
+)
+
+// Store provides access to the webhooks and webhook_deliveries collections.
+type Store struct {
+	endpoints  *mongo.Collection
+	deliveries *mongo.Collection
+}
+
+// New creates a new webhook store.
+func New(db *mongo.Database) *Store {
+	return &Store{
+		endpoints:  db.Collection("webhooks"),
+		deliveries: db.Collection("webhook_deliveries"),
+	}
+}
+
+// GenerateSecret returns a new random signing secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// CreateInput holds the fields for registering an endpoint.
+type CreateInput struct {
+	Name      string
+	URL       string
+	Events    []string
+	Active    bool
+	CreatedBy primitive.ObjectID
+}
+
+// Create registers an endpoint with a new secret.
+func (s *Store) Create(ctx context.Context, input CreateInput) (Endpoint, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return Endpoint{}, err
+	}
+	now := time.Now()
+	ep := Endpoint{
+		ID:        primitive.NewObjectID(),
+		Name:      input.Name,
+		URL:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		Active:    input.Active,
+		CreatedBy: input.CreatedBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.endpoints.InsertOne(ctx, ep); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return Endpoint{}, ErrDuplicateName
+		}
+		return Endpoint{}, err
+	}
+	return ep, nil
+}
+
+// GetByID retrieves an endpoint by ID.
+func (s *Store) GetByID(ctx context.Context, id primitive.ObjectID) (*Endpoint, error) {
+	var ep Endpoint
+	if err := s.endpoints.FindOne(ctx, bson.M{"_id": id}).Decode(&ep); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &ep, nil
+}
+
+// List returns all endpoints, sorted by name.
+func (s *Store) List(ctx context.Context) ([]Endpoint, error) {
+	return s.find(ctx, bson.M{})
+}
+
+// ListActive returns the endpoints that are receiving events.
+func (s *Store) ListActive(ctx context.Context) ([]Endpoint, error) {
+	return s.find(ctx, bson.M{"active": true})
+}
+
+func (s *Store) find(ctx context.Context, query bson.M) ([]Endpoint, error) {
+	cur, err := s.endpoints.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []Endpoint
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UpdateInput holds the editable fields of an endpoint.
+type UpdateInput struct {
+	Name   string
+	URL    string
+	Events []string
+	Active bool
+}
+
+// Update replaces an endpoint's editable fields.
+func (s *Store) Update(ctx context.Context, id primitive.ObjectID, input UpdateInput) error {
+	res, err := s.endpoints.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"name":       input.Name,
+		"url":        input.URL,
+		"events":     input.Events,
+		"active":     input.Active,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateName
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RotateSecret gives an endpoint a new secret and returns it. Deliveries
+// already queued are signed with the new secret when sent.
+func (s *Store) RotateSecret(ctx context.Context, id primitive.ObjectID) (string, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	res, err := s.endpoints.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"secret":     secret,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return "", err
+	}
+	if res.MatchedCount == 0 {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Delete removes an endpoint and its delivery history.
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.endpoints.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	_, err = s.deliveries.DeleteMany(ctx, bson.M{"endpoint_id": id})
+	return err
+}
+
+// DeliveryInput holds the fields for queuing a delivery.
+type DeliveryInput struct {
+	ID          primitive.ObjectID // Optional; generated if zero
+	EndpointID  primitive.ObjectID
+	Event       string
+	Payload     string
+	MaxAttempts int
+}
+
+// CreateDelivery stores a new queued delivery.
+func (s *Store) CreateDelivery(ctx context.Context, input DeliveryInput) (Delivery, error) {
+	maxAttempts := input.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	id := input.ID
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+	now := time.Now()
+	d := Delivery{
+		ID:          id,
+		EndpointID:  input.EndpointID,
+		Event:       input.Event,
+		Payload:     input.Payload,
+		Status:      StatusQueued,
+		MaxAttempts: maxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := s.deliveries.InsertOne(ctx, d); err != nil {
+		return Delivery{}, err
+	}
+	return d, nil
+}
+
+// SetDeliveryJobID records the job that sends a delivery.
+func (s *Store) SetDeliveryJobID(ctx context.Context, id, jobID primitive.ObjectID) error {
+	_, err := s.deliveries.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{"job_id": jobID, "updated_at": time.Now()},
+	})
+	return err
+}
+
+// GetDelivery retrieves a delivery by ID.
+func (s *Store) GetDelivery(ctx context.Context, id primitive.ObjectID) (*Delivery, error) {
+	var d Delivery
+	if err := s.deliveries.FindOne(ctx, bson.M{"_id": id}).Decode(&d); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// MarkDelivered records a successful delivery attempt.
+func (s *Store) MarkDelivered(ctx context.Context, id primitive.ObjectID, code int, body string) error {
+	now := time.Now()
+	_, err := s.deliveries.UpdateByID(ctx, id, bson.M{
+		"$set": bson.M{
+			"status":        StatusDelivered,
+			"response_code": code,
+			"response_body": body,
+			"delivered_at":  now,
+			"updated_at":    now,
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"next_attempt_at": "", "last_error": ""},
+	})
+	return err
+}
+
+// MarkAttemptFailed records a failed delivery attempt, with the response if
+// the endpoint answered (code is 0 if it didn't). If nextAttempt is nil
+// the delivery has no attempts left and is marked failed; otherwise it is
+// marked retrying with the time of the next attempt.
+func (s *Store) MarkAttemptFailed(ctx context.Context, id primitive.ObjectID, code int, body, errMsg string, nextAttempt *time.Time) error {
+	set := bson.M{
+		"status":        StatusFailed,
+		"response_code": code,
+		"response_body": body,
+		"last_error":    errMsg,
+		"updated_at":    time.Now(),
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"attempts": 1},
+	}
+	if nextAttempt != nil {
+		set["status"] = StatusRetrying
+		set["next_attempt_at"] = *nextAttempt
+	} else {
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+	_, err := s.deliveries.UpdateByID(ctx, id, update)
+	return err
+}
+
+// RequeueDelivery resets a failed delivery so it can be sent again.
+// Returns ErrNotFound if the delivery does not exist or has not failed.
+func (s *Store) RequeueDelivery(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.deliveries.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": StatusFailed,
+	}, bson.M{
+		"$set": bson.M{
+			"status":     StatusQueued,
+			"attempts":   0,
+			"updated_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeliveryListResult contains a page of deliveries with pagination info.
+type DeliveryListResult struct {
+	Deliveries []Delivery
+	TotalCount int64
+	Page       int
+	PageSize   int
+	TotalPages int
+}
+
+// ListDeliveries returns an endpoint's deliveries, newest first, optionally
+// only those with the given status. Payloads are left out; load a delivery
+// with GetDelivery to get it.
+func (s *Store) ListDeliveries(ctx context.Context, endpointID primitive.ObjectID, status string, page, pageSize int) (DeliveryListResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 25
+	}
+
+	query := bson.M{"endpoint_id": endpointID}
+	if status != "" {
+		query["status"] = status
+	}
+
+	total, err := s.deliveries.CountDocuments(ctx, query)
+	if err != nil {
+		return DeliveryListResult{}, err
+	}
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetProjection(bson.M{"payload": 0, "response_body": 0})
+
+	cur, err := s.deliveries.Find(ctx, query, opts)
+	if err != nil {
+		return DeliveryListResult{}, err
+	}
+	defer cur.Close(ctx)
+
+	var out []Delivery
+	if err := cur.All(ctx, &out); err != nil {
+		return DeliveryListResult{}, err
+	}
+
+	return DeliveryListResult{
+		Deliveries: out,
+		TotalCount: total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// CountDeliveriesByStatus returns the number of an endpoint's deliveries
+// in each status.
+func (s *Store) CountDeliveriesByStatus(ctx context.Context, endpointID primitive.ObjectID) (map[string]int64, error) {
+	cur, err := s.deliveries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"endpoint_id": endpointID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cur.Next(ctx) {
+		var row struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := cur.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.Status] = row.Count
+	}
+	return counts, cur.Err()
+}
+
+// DeleteDeliveredBefore removes successful deliveries older than cutoff.
+func (s *Store) DeleteDeliveredBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.deliveries.DeleteMany(ctx, bson.M{
+		"status":       StatusDelivered,
+		"delivered_at": bson.M{"$lt": cutoff},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
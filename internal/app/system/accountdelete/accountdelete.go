@@ -0,0 +1,119 @@
+// Package accountdelete deletes the accounts users asked to have deleted
+// from their profile page, once the grace period they were given to change
+// their mind is over.
+//
+// Deleting here is the same as an admin deleting the account: it is marked
+// deleted, can be restored by an admin, and is purged user_restore_days
+// later (see userpurge). The last active admin's account is never deleted;
+// their request is canceled instead.
+package accountdelete
+
+import (
+	"context"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Scheduler deletes accounts whose scheduled deletion date has arrived.
+type Scheduler struct {
+	users       *userstore.Store
+	sessions    *sessions.Store
+	auditLogger *auditlog.Logger
+	logger      *zap.Logger
+}
+
+// New creates a Scheduler.
+func New(db *mongo.Database, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		users:    userstore.New(db),
+		sessions: sessions.New(db),
+		logger:   logger,
+	}
+}
+
+// SetAuditLogger records the deletions in the audit log.
+func (s *Scheduler) SetAuditLogger(l *auditlog.Logger) {
+	s.auditLogger = l
+}
+
+// Jobs returns the background job that deletes accounts.
+func (s *Scheduler) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "account-scheduled-delete",
+		Interval: 1 * time.Hour,
+		Run:      s.run,
+	}}
+}
+
+// run deletes each account whose deletion date has arrived and closes its
+// sessions.
+func (s *Scheduler) run(ctx context.Context) error {
+	now := time.Now()
+	users, err := s.users.ListDeletionDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var deleted int
+	for i := range users {
+		u := &users[i]
+
+		// Admins may have been removed since the request was made
+		last, err := s.users.IsLastActiveAdmin(ctx, u)
+		if err != nil {
+			return err
+		}
+		if last {
+			if err := s.users.CancelDeletion(ctx, u.ID); err != nil {
+				return err
+			}
+			s.logger.Warn("canceled account deletion of the last active admin",
+				zap.String("user_id", u.ID.Hex()))
+			s.log(ctx, u, audit.CategoryAuth, audit.EventAccountDeletionCanceled, map[string]string{"reason": "last_admin"})
+			continue
+		}
+
+		ok, err := s.users.DeleteScheduled(ctx, u.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue // canceled or deleted since it was listed
+		}
+		deleted++
+
+		// Deleted users are refused on their next request; this just
+		// closes the tracked sessions so they don't show as active
+		if err := s.sessions.CloseByUser(ctx, u.ID, sessions.EndReasonDeleted); err != nil {
+			s.logger.Warn("failed to close sessions of deleted user",
+				zap.String("user_id", u.ID.Hex()), zap.Error(err))
+		}
+		s.log(ctx, u, audit.CategoryAdmin, audit.EventUserDeleted, map[string]string{"reason": "self_service"})
+	}
+
+	if deleted > 0 {
+		s.logger.Info("deleted accounts at their owners' request", zap.Int("users", deleted))
+	}
+	return nil
+}
+
+// log records an event about u's account, with u as the actor.
+func (s *Scheduler) log(ctx context.Context, u *models.User, category, eventType string, details map[string]string) {
+	id := u.ID
+	s.auditLogger.Log(ctx, audit.Event{
+		Category:  category,
+		EventType: eventType,
+		UserID:    &id,
+		ActorID:   &id,
+		Success:   true,
+		Details:   details,
+	})
+}
@@ -0,0 +1,234 @@
+// Package announcementschedule publishes and expires announcements on
+// their schedule, so banners appear and disappear at their publish and
+// expire times without an admin switching them on and off.
+//
+// Banners already honor the times when they are shown; this job sends the
+// announcement.published webhook when a scheduled announcement's publish
+// time arrives, and switches announcements off once they expire so the
+// list shows them as inactive.
+//
+// Announcements marked to be emailed are sent to every active user with an
+// email address once they are showing, whether an admin published them or
+// their publish time arrived. Each is emailed once, by an email_announcement
+// job on the bulk email queue that reports how many users it has reached.
+package announcementschedule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/announcement"
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/app/system/webhooks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// EmailJob is the job type that emails one announcement to every user.
+const EmailJob = "email_announcement"
+
+// Scheduler publishes due announcements, emails them to users, and
+// deactivates expired ones.
+type Scheduler struct {
+	announcements *announcement.Store
+	users         *userstore.Store
+	settings      *settingsstore.Store
+	jobs          *jobstore.Store
+	webhooks      *webhooks.Dispatcher // nil if webhooks are off
+	mail          *mailer.Mailer       // nil if email is off
+	baseURL       string
+	logger        *zap.Logger
+}
+
+// New creates a Scheduler. No webhooks are sent if d is nil, and no
+// announcements are emailed if mail is nil. baseURL is used to link emails
+// to the announcements page.
+func New(db *mongo.Database, d *webhooks.Dispatcher, mail *mailer.Mailer, baseURL string, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		announcements: announcement.New(db),
+		users:         userstore.New(db),
+		settings:      settingsstore.New(db),
+		jobs:          jobstore.New(db),
+		webhooks:      d,
+		mail:          mail,
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		logger:        logger,
+	}
+}
+
+// Jobs returns the background job that runs the schedule every minute.
+func (s *Scheduler) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "announcement-schedule",
+		Interval: time.Minute,
+		Schedule: "* * * * *",
+		Run:      func(ctx context.Context) error { return s.Run(ctx, time.Now()) },
+	}}
+}
+
+// Run publishes announcements whose publish time has arrived by now,
+// emails those marked to be emailed, then deactivates those whose expire
+// time has passed.
+func (s *Scheduler) Run(ctx context.Context, now time.Time) error {
+	due, err := s.announcements.ListPublishDue(ctx, now)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		ann := &due[i]
+		ok, err := s.announcements.MarkPublished(ctx, ann.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue // edited or published since it was listed
+		}
+		s.webhooks.Publish(webhooks.EventAnnouncementPublished, webhooks.AnnouncementData(ann))
+		s.logger.Info("published scheduled announcement",
+			zap.String("id", ann.ID.Hex()), zap.String("title", ann.Title))
+	}
+
+	if s.mail != nil {
+		if err := s.email(ctx, now); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.announcements.DeactivateExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		s.logger.Info("deactivated expired announcements", zap.Int64("announcements", n))
+	}
+	return nil
+}
+
+// email queues an email_announcement job for each announcement that is
+// due to be emailed.
+func (s *Scheduler) email(ctx context.Context, now time.Time) error {
+	due, err := s.announcements.ListEmailDue(ctx, now)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		ann := &due[i]
+		// Mark first so a failing mail server doesn't email users twice
+		ok, err := s.announcements.MarkEmailed(ctx, ann.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		// One attempt: a retry would email the users already reached again
+		job, err := s.jobs.Create(ctx, jobstore.CreateInput{
+			QueueName:   mailer.BulkQueue,
+			JobType:     EmailJob,
+			Payload:     map[string]any{"announcement_id": ann.ID.Hex()},
+			MaxAttempts: 1,
+		})
+		if err != nil {
+			return fmt.Errorf("enqueue announcement email job: %w", err)
+		}
+		s.logger.Info("queued announcement email",
+			zap.String("id", ann.ID.Hex()),
+			zap.String("job_id", job.ID.Hex()))
+	}
+	return nil
+}
+
+// Register adds the bulk email queue and the announcement email job
+// handler to r.
+func (s *Scheduler) Register(r *jobrunner.Runner) {
+	r.AddQueue(mailer.BulkQueue)
+	r.Register(EmailJob, s.handleEmail)
+}
+
+// handleEmail emails one announcement to every active user with an email
+// address, reporting progress after each batch.
+func (s *Scheduler) handleEmail(ctx context.Context, payload map[string]any) (map[string]any, error) {
+	if s.mail == nil {
+		return map[string]any{"skipped": "email is off"}, nil
+	}
+	idStr, _ := payload["announcement_id"].(string)
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid announcement_id %q", idStr)
+	}
+	ann, err := s.announcements.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load announcement: %w", err)
+	}
+
+	appName := models.DefaultSiteName
+	if st, err := s.settings.Get(ctx); err == nil && st.SiteName != "" {
+		appName = st.SiteName
+	}
+	emails, err := s.emails(ctx, ann, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	jobrunner.ReportStep(ctx, "Emailing users")
+	p, err := s.mail.SendBulk(ctx, emails, func(p mailer.BulkProgress) {
+		jobrunner.ReportProgress(ctx, int64(p.Done()), int64(p.Total))
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("emailed announcement to users",
+		zap.String("id", idStr),
+		zap.Int("recipients", p.Total),
+		zap.Int("failed", p.Failed))
+	if p.Failed > 0 {
+		return nil, fmt.Errorf("%d of %d announcement emails could not be queued", p.Failed, p.Total)
+	}
+	return map[string]any{"recipients": p.Total, "suppressed": p.Suppressed}, nil
+}
+
+// emails builds the announcement email for each active user with an email
+// address, in their language.
+func (s *Scheduler) emails(ctx context.Context, ann *announcement.Announcement, appName string) ([]mailer.Email, error) {
+	brand := s.mail.Brand(ctx)
+	var emails []mailer.Email
+	err := s.users.Each(ctx, bson.M{
+		"status": status.Active,
+		"email":  bson.M{"$nin": []any{nil, ""}},
+	}, func(u models.User) error {
+		text, html := mailer.AnnouncementDigestEmail(mailer.AnnouncementDigestEmailData{
+			Locale:   u.Locale,
+			Brand:    brand,
+			AppName:  appName,
+			UserName: u.FullName,
+			Announcements: []mailer.AnnouncementItem{
+				{Title: ann.Title, Content: ann.Content, Type: string(ann.Type)},
+			},
+			ViewAllURL: s.baseURL + "/my-announcements",
+		})
+		emails = append(emails, mailer.Email{
+			To:       *u.Email,
+			Subject:  "[" + appName + "] " + ann.Title,
+			Template: "announcement_digest",
+			UserID:   u.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing users to email announcement: %w", err)
+	}
+	return emails, nil
+}
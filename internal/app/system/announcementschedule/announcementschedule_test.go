@@ -0,0 +1,110 @@
+package announcementschedule
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/store/announcement"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type fakeQueue struct{ emails []mailer.Email }
+
+func (q *fakeQueue) Enqueue(ctx context.Context, email mailer.Email) error {
+	q.emails = append(q.emails, email)
+	return nil
+}
+
+// closedPort returns a local port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func failingMailer(t *testing.T) *mailer.Mailer {
+	return mailer.New(mailer.Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+}
+
+func TestHandleEmail_MailOff(t *testing.T) {
+	s := &Scheduler{logger: zap.NewNop()}
+	result, err := s.handleEmail(context.Background(), map[string]any{"announcement_id": "x"})
+	if err != nil || result["skipped"] == nil {
+		t.Errorf("handleEmail() = %v, %v; want skipped", result, err)
+	}
+}
+
+func TestHandleEmail_InvalidID(t *testing.T) {
+	s := &Scheduler{mail: failingMailer(t), logger: zap.NewNop()}
+	if _, err := s.handleEmail(context.Background(), map[string]any{"announcement_id": "not-an-id"}); err == nil {
+		t.Error("handleEmail() error = nil, want an invalid announcement_id error")
+	}
+}
+
+// setupAnnouncement stores an announcement and two active users with
+// email addresses, and returns the announcement's job payload.
+func setupAnnouncement(t *testing.T, db *mongo.Database, s *Scheduler) map[string]any {
+	t.Helper()
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	ann, err := s.announcements.Create(ctx, announcement.CreateInput{
+		Title:      "Maintenance",
+		Content:    "Down for an hour on Sunday.",
+		Type:       announcement.TypeInfo,
+		EmailUsers: true,
+		Active:     true,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	users := db.Collection("users")
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := users.InsertOne(ctx, bson.M{"full_name": email, "email": email, "status": status.Active}); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+	}
+	return map[string]any{"announcement_id": ann.ID.Hex()}
+}
+
+func TestHandleEmail_SendsToActiveUsers(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := &fakeQueue{}
+	m := failingMailer(t)
+	m.SetQueue(q)
+	s := New(db, nil, m, "https://example.com/", zap.NewNop())
+	payload := setupAnnouncement(t, db, s)
+
+	result, err := s.handleEmail(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("handleEmail() error = %v", err)
+	}
+	if len(q.emails) != 2 || result["recipients"] != 2 {
+		t.Errorf("queued %d emails, result %v; want 2 recipients", len(q.emails), result)
+	}
+}
+
+func TestHandleEmail_FailedSendsFailJob(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	// No queue and nothing listening, so every delivery fails
+	s := New(db, nil, failingMailer(t), "https://example.com", zap.NewNop())
+	payload := setupAnnouncement(t, db, s)
+
+	_, err := s.handleEmail(context.Background(), payload)
+	if err == nil || !strings.Contains(err.Error(), "2 of 2") {
+		t.Errorf("handleEmail() error = %v, want 2 of 2 emails failed", err)
+	}
+}
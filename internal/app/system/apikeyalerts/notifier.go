@@ -0,0 +1,269 @@
+// internal/app/system/apikeyalerts/notifier.go
+package apikeyalerts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	ledgerstore "github.com/dalemusser/stratasave/internal/app/store/ledger"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// errorAlertCooldown is the minimum time between error spike alerts for the
+// same key, so a sustained outage produces one email rather than one per check.
+const errorAlertCooldown = time.Hour
+
+// Config holds API key notification configuration.
+type Config struct {
+	// BaseURL is used to build links to keys in the admin console.
+	BaseURL string
+
+	// Recipients receive all notifications. If empty, every active admin
+	// with an email address is notified.
+	Recipients []string
+
+	// ExpiryWarning is how far ahead of a key's expiry the warning is sent.
+	ExpiryWarning time.Duration
+
+	// ErrorSpikeThreshold is the number of error responses for a single key
+	// within ErrorSpikeWindow that triggers an alert. Zero disables alerts.
+	ErrorSpikeThreshold int
+	ErrorSpikeWindow    time.Duration
+}
+
+// Notifier emails administrators about API key lifecycle events and
+// records error spikes in the audit log. A nil Notifier is valid and does
+// nothing.
+type Notifier struct {
+	keys     *apikeystore.Store
+	ledger   *ledgerstore.Store
+	users    *userstore.Store
+	settings *settingsstore.Store
+	mail     *mailer.Mailer
+	auditLog *auditlog.Logger
+	cfg      Config
+	logger   *zap.Logger
+}
+
+// New creates a Notifier. Either mail or auditLog may be nil: without mail
+// nothing is emailed, and without an audit logger error spikes are only
+// emailed. Returns nil if both are nil.
+func New(db *mongo.Database, mail *mailer.Mailer, auditLog *auditlog.Logger, cfg Config, logger *zap.Logger) *Notifier {
+	if mail == nil && auditLog == nil {
+		return nil
+	}
+	return &Notifier{
+		keys:     apikeystore.New(db),
+		ledger:   ledgerstore.New(db),
+		users:    userstore.New(db),
+		settings: settingsstore.New(db),
+		mail:     mail,
+		auditLog: auditLog,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// KeyCreated notifies that a key was created. Sending happens in the background.
+func (n *Notifier) KeyCreated(key apikeystore.APIKey, actorName string) {
+	if n == nil || n.mail == nil {
+		return
+	}
+	details := []string{"Created by: " + actorName}
+	if key.ExpiresAt != nil {
+		details = append(details, "Expires: "+key.ExpiresAt.UTC().Format("Jan 2, 2006"))
+	}
+	go n.send(key, "API Key Created",
+		fmt.Sprintf("A new API key named %q was created.", key.Name), details)
+}
+
+// KeyRevoked notifies that a key was revoked. Sending happens in the background.
+func (n *Notifier) KeyRevoked(key apikeystore.APIKey, actorName string) {
+	if n == nil || n.mail == nil {
+		return
+	}
+	go n.send(key, "API Key Revoked",
+		fmt.Sprintf("The API key named %q was revoked. Integrations using it will now receive 401 errors.", key.Name),
+		[]string{"Revoked by: " + actorName})
+}
+
+// Jobs returns the background jobs that check for expiring keys and error
+// spikes. Expiry warnings are only checked when they can be emailed.
+func (n *Notifier) Jobs() []tasks.Job {
+	if n == nil {
+		return nil
+	}
+	var jobs []tasks.Job
+	if n.mail != nil {
+		jobs = append(jobs, tasks.Job{
+			Name:     "api-key-expiry-check",
+			Interval: 1 * time.Hour,
+			Run:      n.checkExpiring,
+		})
+	}
+	if n.cfg.ErrorSpikeThreshold > 0 && n.cfg.ErrorSpikeWindow > 0 {
+		jobs = append(jobs, tasks.Job{
+			Name:     "api-key-error-spike-check",
+			Interval: n.cfg.ErrorSpikeWindow,
+			Run:      n.checkErrorSpikes,
+		})
+	}
+	return jobs
+}
+
+// checkExpiring sends one warning per key that expires within ExpiryWarning.
+func (n *Notifier) checkExpiring(ctx context.Context) error {
+	keys, err := n.keys.ListExpiringUnnotified(ctx, time.Now().Add(n.cfg.ExpiryWarning))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		// Mark first so a failing mail server doesn't cause repeated warnings
+		if err := n.keys.MarkExpiryNotified(ctx, key.ID); err != nil {
+			return err
+		}
+		heading := "API Key Expiring Soon"
+		message := fmt.Sprintf("The API key named %q will expire soon. Create a replacement and update your integrations before then.", key.Name)
+		if key.IsExpired() {
+			heading = "API Key Expired"
+			message = fmt.Sprintf("The API key named %q has expired. Integrations using it now receive 401 errors.", key.Name)
+		}
+		n.send(key, heading, message, []string{
+			"Expires: " + key.ExpiresAt.UTC().Format("Jan 2, 2006 15:04 MST"),
+		})
+	}
+	return nil
+}
+
+// checkErrorSpikes alerts on keys whose error count over the last window
+// reaches the threshold, recording each spike in the audit log. Errors are
+// counted from the request ledger.
+func (n *Notifier) checkErrorSpikes(ctx context.Context) error {
+	counts, err := n.ledger.CountErrorsByActor(ctx, "api_key", time.Now().Add(-n.cfg.ErrorSpikeWindow))
+	if err != nil {
+		return err
+	}
+	for keyID, count := range counts {
+		if count < int64(n.cfg.ErrorSpikeThreshold) {
+			continue
+		}
+		oid, err := primitive.ObjectIDFromHex(keyID)
+		if err != nil {
+			continue
+		}
+		claimed, err := n.keys.MarkErrorAlerted(ctx, oid, time.Now().Add(-errorAlertCooldown))
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue // alerted recently
+		}
+		key, err := n.keys.GetByID(ctx, oid)
+		if err != nil {
+			continue
+		}
+		n.auditLog.LogSystemSecurityEvent(ctx, nil, audit.EventAPIKeyErrorSpike, map[string]string{
+			"key_id":     key.ID.Hex(),
+			"key_name":   key.Name,
+			"key_prefix": key.KeyPrefix,
+			"errors":     strconv.FormatInt(count, 10),
+			"window":     n.cfg.ErrorSpikeWindow.String(),
+		})
+		if n.mail == nil {
+			continue
+		}
+		n.send(*key, "API Key Error Spike",
+			fmt.Sprintf("Requests using the API key named %q are failing at an unusual rate.", key.Name),
+			[]string{
+				fmt.Sprintf("Errors: %d in the last %s", count, n.cfg.ErrorSpikeWindow),
+				"Check the request ledger for details.",
+			})
+	}
+	return nil
+}
+
+// send emails a notification to all recipients.
+func (n *Notifier) send(key apikeystore.APIKey, heading, message string, details []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Medium())
+	defer cancel()
+
+	recipients := n.recipients(ctx)
+	if len(recipients) == 0 {
+		n.logger.Warn("api key notification not sent: no recipients",
+			zap.String("heading", heading),
+			zap.String("key_id", key.ID.Hex()))
+		return
+	}
+
+	appName := models.DefaultSiteName
+	if s, err := n.settings.Get(ctx); err == nil && s.SiteName != "" {
+		appName = s.SiteName
+	}
+
+	text, html := mailer.APIKeyAlertEmail(mailer.APIKeyAlertEmailData{
+		Brand:     n.mail.Brand(ctx),
+		AppName:   appName,
+		Heading:   heading,
+		Message:   message,
+		KeyName:   key.Name,
+		KeyPrefix: key.KeyPrefix,
+		Details:   details,
+		KeyURL:    strings.TrimRight(n.cfg.BaseURL, "/") + "/api-keys/" + key.ID.Hex(),
+	})
+	emails := make([]mailer.Email, len(recipients))
+	for i, to := range recipients {
+		emails[i] = mailer.Email{
+			To:       to,
+			Subject:  "[" + appName + "] " + heading + ": " + key.Name,
+			Template: "api_key_alert",
+			TextBody: text,
+			HTMLBody: html,
+		}
+	}
+	// Not bound to ctx: throttled sends to a long admin list can outlast it
+	p, _ := n.mail.SendBulk(context.Background(), emails, nil)
+	if p.Failed > 0 {
+		n.logger.Error("failed to send some api key notifications",
+			zap.String("heading", heading),
+			zap.Int("failed", p.Failed),
+			zap.Int("total", p.Total))
+	}
+}
+
+// recipients returns the configured recipients, or all active admin emails.
+func (n *Notifier) recipients(ctx context.Context) []string {
+	if len(n.cfg.Recipients) > 0 {
+		return n.cfg.Recipients
+	}
+	admins, err := n.users.Find(ctx, bson.M{
+		"role":   models.RoleAdmin,
+		"status": "active",
+		"email":  bson.M{"$nin": []any{nil, ""}},
+	})
+	if err != nil {
+		n.logger.Error("failed to list admins for api key notification", zap.Error(err))
+		return nil
+	}
+	var out []string
+	for _, u := range admins {
+		if u.Email != nil {
+			out = append(out, *u.Email)
+		}
+	}
+	return out
+}
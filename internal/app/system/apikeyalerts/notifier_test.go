@@ -0,0 +1,42 @@
+package apikeyalerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+)
+
+func TestNew_NothingToDo(t *testing.T) {
+	if n := New(nil, nil, nil, Config{}, nil); n != nil {
+		t.Errorf("New() without mail or audit logger = %v, want nil", n)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	spikes := Config{ErrorSpikeThreshold: 50, ErrorSpikeWindow: 5 * time.Minute}
+
+	tests := []struct {
+		name string
+		n    *Notifier
+		want []string
+	}{
+		{"nil notifier", nil, nil},
+		{"audit only", &Notifier{cfg: spikes}, []string{"api-key-error-spike-check"}},
+		{"mail without spike alerts", &Notifier{mail: &mailer.Mailer{}}, []string{"api-key-expiry-check"}},
+		{"mail and spike alerts", &Notifier{mail: &mailer.Mailer{}, cfg: spikes}, []string{"api-key-expiry-check", "api-key-error-spike-check"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := tt.n.Jobs()
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("Jobs() = %d jobs, want %v", len(jobs), tt.want)
+			}
+			for i, j := range jobs {
+				if j.Name != tt.want[i] {
+					t.Errorf("job %d = %q, want %q", i, j.Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package apisnippets writes code that calls the game APIs, for integrators
+// to copy from the API consoles: a cURL command, a Unity coroutine, and an
+// Unreal Engine HTTP request.
+package apisnippets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Request is an API call to write code for.
+type Request struct {
+	URL    string // Full endpoint URL
+	APIKey string // Sent as a bearer token
+	Body   []byte // JSON request body
+}
+
+// Snippet is the code for a request in one language.
+type Snippet struct {
+	ID    string // "curl", "unity", or "unreal"
+	Label string
+	Code  string
+}
+
+// Builder is what the api_request_builder template component shows.
+type Builder struct {
+	SnippetsURL string // Where the form is posted to get a View
+	Keys        []KeyOption
+}
+
+// View is what the api_snippets template component shows.
+type View struct {
+	Snippets   []Snippet
+	Error      string // Why no snippets could be written
+	PartialKey bool   // The API key in the snippets must be completed
+}
+
+// Generate returns the request as cURL, Unity, and Unreal code, in that
+// order.
+func Generate(req Request) []Snippet {
+	return []Snippet{
+		{ID: "curl", Label: "cURL", Code: Curl(req)},
+		{ID: "unity", Label: "Unity (C#)", Code: Unity(req)},
+		{ID: "unreal", Label: "Unreal (C++)", Code: Unreal(req)},
+	}
+}
+
+// Curl returns a cURL command for req. The body is indented for reading.
+func Curl(req Request) string {
+	body := req.Body
+	var indented bytes.Buffer
+	if json.Indent(&indented, req.Body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	return fmt.Sprintf("curl -X POST %s \\\n  -H %s \\\n  -H %s \\\n  -d %s",
+		shellQuote(req.URL),
+		shellQuote("Authorization: Bearer "+req.APIKey),
+		shellQuote("Content-Type: application/json"),
+		shellQuote(string(body)),
+	)
+}
+
+// Unity returns a coroutine that sends req with UnityWebRequest.
+func Unity(req Request) string {
+	return fmt.Sprintf(`// using System.Collections;
+// using System.Text;
+// using UnityEngine;
+// using UnityEngine.Networking;
+
+IEnumerator SendRequest()
+{
+    const string url = %s;
+    const string body = %s;
+
+    using (var request = new UnityWebRequest(url, "POST"))
+    {
+        request.uploadHandler = new UploadHandlerRaw(Encoding.UTF8.GetBytes(body));
+        request.downloadHandler = new DownloadHandlerBuffer();
+        request.SetRequestHeader("Content-Type", "application/json");
+        request.SetRequestHeader("Authorization", %s);
+
+        yield return request.SendWebRequest();
+
+        if (request.result == UnityWebRequest.Result.Success)
+            Debug.Log(request.downloadHandler.text);
+        else
+            Debug.LogError(request.responseCode + " " + request.error + ": " + request.downloadHandler.text);
+    }
+}`, cQuote(req.URL), cQuote(compact(req.Body)), cQuote("Bearer "+req.APIKey))
+}
+
+// Unreal returns code that sends req with the engine's HTTP module.
+func Unreal(req Request) string {
+	return fmt.Sprintf(`// #include "HttpModule.h"
+// #include "Interfaces/IHttpRequest.h"
+// #include "Interfaces/IHttpResponse.h"
+// Add "HTTP" to PublicDependencyModuleNames in your module's Build.cs.
+
+TSharedRef<IHttpRequest, ESPMode::ThreadSafe> Request = FHttpModule::Get().CreateRequest();
+Request->SetURL(TEXT(%s));
+Request->SetVerb(TEXT("POST"));
+Request->SetHeader(TEXT("Content-Type"), TEXT("application/json"));
+Request->SetHeader(TEXT("Authorization"), TEXT(%s));
+Request->SetContentAsString(TEXT(%s));
+Request->OnProcessRequestComplete().BindLambda(
+    [](FHttpRequestPtr Req, FHttpResponsePtr Response, bool bConnectedSuccessfully)
+    {
+        if (bConnectedSuccessfully && Response.IsValid())
+        {
+            UE_LOG(LogTemp, Log, TEXT("%%d %%s"), Response->GetResponseCode(), *Response->GetContentAsString());
+        }
+        else
+        {
+            UE_LOG(LogTemp, Error, TEXT("Request failed"));
+        }
+    });
+Request->ProcessRequest();`, cQuote(req.URL), cQuote("Bearer "+req.APIKey), cQuote(compact(req.Body)))
+}
+
+// compact returns body without insignificant whitespace, so it fits in a
+// one-line string literal.
+func compact(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return string(body)
+	}
+	return buf.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cQuote returns s as a string literal that C# and C++ read the same way.
+func cQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
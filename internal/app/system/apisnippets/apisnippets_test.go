@@ -0,0 +1,66 @@
+package apisnippets
+
+import (
+	"strings"
+	"testing"
+)
+
+var testRequest = Request{
+	URL:    "https://saves.example.com/api/v1/state/save",
+	APIKey: "sk_test",
+	Body:   []byte(`{"user_id":"o'brien","game":"quest","save_data":{"note":"say \"hi\"\\n"}}`),
+}
+
+func TestGenerate(t *testing.T) {
+	got := Generate(testRequest)
+	var ids []string
+	for _, s := range got {
+		ids = append(ids, s.ID)
+		if s.Code == "" || s.Label == "" {
+			t.Errorf("snippet %q has no code or label", s.ID)
+		}
+	}
+	if strings.Join(ids, ",") != "curl,unity,unreal" {
+		t.Errorf("Generate() ids = %v, want curl, unity, unreal", ids)
+	}
+}
+
+func TestCurl(t *testing.T) {
+	got := Curl(testRequest)
+	for _, want := range []string{
+		"curl -X POST 'https://saves.example.com/api/v1/state/save'",
+		"-H 'Authorization: Bearer sk_test'",
+		`"user_id": "o'\''brien"`, // Indented, with the quote escaped for the shell
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Curl() = %s\nwant it to contain %s", got, want)
+		}
+	}
+}
+
+func TestUnityAndUnreal(t *testing.T) {
+	body := `"{\"user_id\":\"o'brien\",\"game\":\"quest\",\"save_data\":{\"note\":\"say \\\"hi\\\"\\\\n\"}}"`
+	if got := Unity(testRequest); !strings.Contains(got, "const string body = "+body+";") ||
+		!strings.Contains(got, `SetRequestHeader("Authorization", "Bearer sk_test")`) {
+		t.Errorf("Unity() = %s", got)
+	}
+	if got := Unreal(testRequest); !strings.Contains(got, "SetContentAsString(TEXT("+body+"))") ||
+		!strings.Contains(got, `SetURL(TEXT("https://saves.example.com/api/v1/state/save"))`) {
+		t.Errorf("Unreal() = %s", got)
+	}
+}
+
+func TestCQuote(t *testing.T) {
+	tests := map[string]string{
+		`plain`:     `"plain"`,
+		`a "b" \c`:  `"a \"b\" \\c"`,
+		"tab\there": `"tab\there"`,
+		"bell\x07":  `"bell\u0007"`,
+		"café 🎮":    `"café 🎮"`,
+	}
+	for in, want := range tests {
+		if got := cQuote(in); got != want {
+			t.Errorf("cQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package apisnippets
+
+import (
+	"context"
+
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ConfiguredKey is the KeyOption value of the API key from the app config.
+const ConfiguredKey = "config"
+
+// placeholderKey stands in for a key the builder can't fill in.
+const placeholderKey = "YOUR_API_KEY"
+
+// KeyOption is an API key the request builder offers.
+type KeyOption struct {
+	Value string // ConfiguredKey or a managed key's ID
+	Label string
+}
+
+// KeyOptions lists the configured API key, if there is one, followed by the
+// active managed keys. store may be nil.
+func KeyOptions(ctx context.Context, configured string, store *apikeystore.Store) ([]KeyOption, error) {
+	var opts []KeyOption
+	if configured != "" {
+		opts = append(opts, KeyOption{Value: ConfiguredKey, Label: "Configured API key"})
+	}
+	if store == nil {
+		return opts, nil
+	}
+	keys, err := store.ListActive(ctx)
+	if err != nil {
+		return opts, err
+	}
+	for _, k := range keys {
+		if k.IsExpired() {
+			continue
+		}
+		opts = append(opts, KeyOption{Value: k.ID.Hex(), Label: k.Name + " (" + k.KeyPrefix + "...)"})
+	}
+	return opts, nil
+}
+
+// ResolveKey returns the key to put in snippets for a KeyOption value, and
+// whether it is complete. Managed keys are stored hashed, so only their
+// prefix can be filled in; the integrator pastes the rest.
+func ResolveKey(ctx context.Context, value, configured string, store *apikeystore.Store) (key string, complete bool) {
+	if value == ConfiguredKey && configured != "" {
+		return configured, true
+	}
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil || store == nil {
+		return placeholderKey, false
+	}
+	k, err := store.GetByID(ctx, id)
+	if err != nil || k.Status != apikeystore.StatusActive {
+		return placeholderKey, false
+	}
+	return k.KeyPrefix + "...", false
+}
@@ -0,0 +1,88 @@
+package apistats
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/apistats"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.uber.org/zap"
+)
+
+// Rollup keeps the API stats collection bounded. Buckets finer than a day
+// are kept for the retention period; after that each day's buckets are
+// rolled into a daily summary and deleted. Daily summaries are kept.
+type Rollup struct {
+	store     *apistats.Store
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// NewRollup creates a Rollup that compacts days older than retention.
+// Zero keeps every bucket.
+func NewRollup(store *apistats.Store, retention time.Duration, logger *zap.Logger) *Rollup {
+	return &Rollup{
+		store:     store,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Jobs returns the daily compaction job. Returns nil if retention is
+// disabled.
+func (r *Rollup) Jobs() []tasks.Job {
+	if r == nil || r.retention <= 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "api-stats-rollup",
+		Interval: 24 * time.Hour,
+		Schedule: "20 0 * * *",
+		Run:      r.Run,
+	}}
+}
+
+// Run compacts every day that ended before the retention period began,
+// oldest first. Compacted days have nothing left to roll up, so a run
+// that fails part way is finished by the next one.
+func (r *Rollup) Run(ctx context.Context) error {
+	keepFrom := cutoff(time.Now(), r.retention)
+
+	oldest, err := r.store.OldestIntraday(ctx, keepFrom)
+	if errors.Is(err, apistats.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var days int
+	var deleted int64
+	for day := apistats.TruncateToBucket(oldest, 24*time.Hour); day.Before(keepFrom); day = day.Add(24 * time.Hour) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := r.store.CompactDay(ctx, day)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			days++
+			deleted += n
+		}
+	}
+
+	if days > 0 {
+		r.logger.Info("rolled up API stats into daily summaries",
+			zap.Int("days", days),
+			zap.Int64("buckets_deleted", deleted))
+	}
+	return nil
+}
+
+// cutoff returns the start of the oldest day whose buckets are kept: the
+// day retention before now, so only whole days are compacted.
+func cutoff(now time.Time, retention time.Duration) time.Time {
+	return apistats.TruncateToBucket(now.Add(-retention), 24*time.Hour)
+}
@@ -0,0 +1,34 @@
+package apistats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCutoff(t *testing.T) {
+	now := time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		retention time.Duration
+		want      time.Time
+	}{
+		{24 * time.Hour, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{30 * 24 * time.Hour, time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC)},
+		{36 * time.Hour, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := cutoff(now, tt.retention); !got.Equal(tt.want) {
+			t.Errorf("cutoff(%v) = %v, want %v", tt.retention, got, tt.want)
+		}
+	}
+}
+
+func TestRollup_JobsDisabled(t *testing.T) {
+	if jobs := NewRollup(nil, 0, nil).Jobs(); jobs != nil {
+		t.Errorf("Jobs() with no retention = %v, want nil", jobs)
+	}
+	var r *Rollup
+	if jobs := r.Jobs(); jobs != nil {
+		t.Errorf("nil Rollup Jobs() = %v, want nil", jobs)
+	}
+}
@@ -0,0 +1,76 @@
+// Package apiversion provides middleware for versioned API routes.
+//
+// Game API endpoints are mounted under /api/v1, /api/v2, and so on. A new
+// version is added when a breaking change is made to a request or response,
+// so game builds that have already shipped keep working against the version
+// they were built for.
+//
+// The original unversioned paths (/api/state/*, /api/settings/*, /api/token)
+// and the legacy /save and /load paths are served as aliases of v1 and
+// marked deprecated with the Deprecation and Link response headers.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// API versions.
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Supported lists the API versions that are mounted, oldest first.
+var Supported = []string{V1, V2}
+
+// Header is the response header that reports which API version served the request.
+const Header = "X-API-Version"
+
+type ctxKey struct{}
+
+// Middleware records version in the request context and the X-API-Version
+// response header.
+func Middleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(Header, version)
+			w.Header().Add("Access-Control-Expose-Headers", Header+", Deprecation, Link")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, version)))
+		})
+	}
+}
+
+// Deprecated marks responses from a compatibility path as deprecated and
+// points clients at the versioned path that replaces it. successor is the
+// versioned prefix that replaces the mount point, e.g. "/api/v1/state" for
+// routes mounted at "/api/state".
+func Deprecated(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FromContext returns the API version serving the request. Requests that
+// did not pass through Middleware are treated as V1.
+func FromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(ctxKey{}).(string); ok && v != "" {
+		return v
+	}
+	return V1
+}
+
+// IsVersionedPath reports whether path is under a supported /api/{version}/ prefix.
+func IsVersionedPath(path string) bool {
+	for _, v := range Supported {
+		if strings.HasPrefix(path, "/api/"+v+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	var got string
+	h := Middleware(V2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v2/state/save", nil))
+
+	if got != V2 {
+		t.Errorf("FromContext() = %q, want %q", got, V2)
+	}
+	if rec.Header().Get(Header) != V2 {
+		t.Errorf("%s = %q, want %q", Header, rec.Header().Get(Header), V2)
+	}
+}
+
+func TestFromContext_Default(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/save", nil)
+	if got := FromContext(req.Context()); got != V1 {
+		t.Errorf("FromContext() = %q, want %q", got, V1)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	h := Deprecated("/api/v1/state")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/state/save", nil))
+
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Error("Deprecation header not set")
+	}
+	if want := `</api/v1/state>; rel="successor-version"`; rec.Header().Get("Link") != want {
+		t.Errorf("Link = %q, want %q", rec.Header().Get("Link"), want)
+	}
+}
+
+func TestIsVersionedPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/api/v1/state/save", true},
+		{"/api/v2/settings/load", true},
+		{"/api/v1/token", true},
+		{"/api/v9/state/save", false},
+		{"/api/state/save", false},
+		{"/api/v1", false},
+		{"/save", false},
+	}
+	for _, tt := range tests {
+		if got := IsVersionedPath(tt.path); got != tt.want {
+			t.Errorf("IsVersionedPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package auditalerts checks admins' saved audit log searches and emails
+// the owner when one matches too many events.
+//
+// A saved search is a saved filter for the audit log (feature "audit")
+// holding a category and event type. One with an alert is counted over a
+// rolling window every CheckInterval; when the count reaches the alert's
+// threshold its owner is emailed, at most once per window.
+package auditalerts
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	savedfilterstore "github.com/dalemusser/stratasave/internal/app/store/savedfilters"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Feature is the saved filter feature name for audit log searches.
+const Feature = "audit"
+
+// CheckInterval is how often saved searches with alerts are counted.
+const CheckInterval = 5 * time.Minute
+
+// Windows are the alert windows admins can choose from, in minutes.
+var Windows = []int{15, 60, 360, 1440}
+
+// ValidWindow reports whether mins is one of Windows.
+func ValidWindow(mins int) bool {
+	for _, w := range Windows {
+		if w == mins {
+			return true
+		}
+	}
+	return false
+}
+
+// WindowLabel describes an alert window, e.g. "1 hour".
+func WindowLabel(mins int) string {
+	switch {
+	case mins%60 != 0:
+		return fmt.Sprintf("%d minutes", mins)
+	case mins == 60:
+		return "1 hour"
+	default:
+		return fmt.Sprintf("%d hours", mins/60)
+	}
+}
+
+// Query returns the audit filter for a saved search's filters, counting
+// events from since onward.
+func Query(filters map[string]string, since time.Time) audit.QueryFilter {
+	return audit.QueryFilter{
+		Category:  filters["category"],
+		EventType: filters["event_type"],
+		StartTime: &since,
+	}
+}
+
+// Evaluator checks saved searches with alerts.
+type Evaluator struct {
+	filters  *savedfilterstore.Store
+	audit    *audit.Store
+	users    *userstore.Store
+	settings *settingsstore.Store
+	mail     *mailer.Mailer
+	baseURL  string
+	logger   *zap.Logger
+}
+
+// New creates an Evaluator. mail may be nil, in which case alerts are
+// logged but not emailed. baseURL is used to link alert emails to the
+// audit log.
+func New(db *mongo.Database, mail *mailer.Mailer, baseURL string, logger *zap.Logger) *Evaluator {
+	return &Evaluator{
+		filters:  savedfilterstore.New(db),
+		audit:    audit.New(db),
+		users:    userstore.New(db),
+		settings: settingsstore.New(db),
+		mail:     mail,
+		baseURL:  baseURL,
+		logger:   logger,
+	}
+}
+
+// Jobs returns the background task that checks saved search alerts.
+func (e *Evaluator) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "audit-search-alerts",
+		Interval: CheckInterval,
+		Run:      e.Check,
+	}}
+}
+
+// Check counts the matches for every saved search with an alert and
+// alerts the owners of those at or over their threshold. A search that
+// fails to count is logged and skipped.
+func (e *Evaluator) Check(ctx context.Context) error {
+	searches, err := e.filters.ListWithAlerts(ctx, Feature)
+	if err != nil {
+		return fmt.Errorf("listing saved audit searches: %w", err)
+	}
+
+	for _, s := range searches {
+		now := time.Now()
+		since := now.Add(-s.Alert.Window())
+		count, err := e.audit.CountByFilter(ctx, Query(s.Filters, since))
+		if err != nil {
+			e.logger.Error("failed to count saved audit search",
+				zap.String("search_id", s.ID.Hex()), zap.Error(err))
+			continue
+		}
+		if err := e.filters.RecordAlertCheck(ctx, s.ID, count, now); err != nil {
+			return err
+		}
+		if count < s.Alert.Threshold {
+			continue
+		}
+
+		// One alert per window, so a sustained spike sends one email
+		claimed, err := e.filters.ClaimAlert(ctx, s.ID, since, now)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			e.alert(ctx, s, count)
+		}
+	}
+	return nil
+}
+
+// alert emails a saved search's owner that it crossed its threshold.
+func (e *Evaluator) alert(ctx context.Context, s savedfilterstore.SavedFilter, count int64) {
+	window := WindowLabel(s.Alert.WindowMins)
+	e.logger.Warn("saved audit search crossed its alert threshold",
+		zap.String("search_id", s.ID.Hex()),
+		zap.String("name", s.Name),
+		zap.Int64("count", count),
+		zap.Int64("threshold", s.Alert.Threshold),
+		zap.String("window", window))
+	if e.mail == nil {
+		return
+	}
+
+	owner, err := e.users.GetByID(ctx, s.UserID)
+	if err != nil {
+		e.logger.Warn("audit search alert not sent: owner not found",
+			zap.String("search_id", s.ID.Hex()), zap.Error(err))
+		return
+	}
+	if owner.Role != models.RoleAdmin || owner.Status != "active" || owner.Email == nil || *owner.Email == "" {
+		e.logger.Warn("audit search alert not sent: owner can't receive it",
+			zap.String("search_id", s.ID.Hex()))
+		return
+	}
+
+	appName := models.DefaultSiteName
+	if st, err := e.settings.Get(ctx); err == nil && st.SiteName != "" {
+		appName = st.SiteName
+	}
+
+	heading := "Audit Search Alert: " + s.Name
+	details := []string{
+		fmt.Sprintf("Matching events: %d in the last %s", count, window),
+		fmt.Sprintf("Threshold: %d", s.Alert.Threshold),
+	}
+	if c := s.Filters["category"]; c != "" {
+		details = append(details, "Category: "+c)
+	}
+	if t := s.Filters["event_type"]; t != "" {
+		details = append(details, "Event type: "+t)
+	}
+
+	text, html := mailer.SecurityAlertEmail(mailer.SecurityAlertEmailData{
+		Locale:    owner.Locale,
+		Brand:     e.mail.Brand(ctx),
+		AppName:   appName,
+		Heading:   heading,
+		Message:   fmt.Sprintf("Your saved audit log search %q matched %d events in the last %s, reaching its alert threshold.", s.Name, count, window),
+		Details:   details,
+		ReviewURL: strings.TrimRight(e.baseURL, "/") + "/audit?" + reviewQuery(s.Filters),
+	})
+	err = e.mail.Send(mailer.Email{
+		To:       *owner.Email,
+		Subject:  "[" + appName + "] " + heading,
+		Template: "security_alert",
+		TextBody: text,
+		HTMLBody: html,
+		UserID:   owner.ID.Hex(),
+	})
+	if err != nil {
+		e.logger.Error("failed to send audit search alert",
+			zap.String("search_id", s.ID.Hex()), zap.Error(err))
+	}
+}
+
+// reviewQuery returns the audit log query string for a saved search.
+func reviewQuery(filters map[string]string) string {
+	q := url.Values{}
+	for _, k := range []string{"category", "event_type"} {
+		if v := filters[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	return q.Encode()
+}
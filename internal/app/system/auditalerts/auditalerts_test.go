@@ -0,0 +1,53 @@
+package auditalerts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowLabel(t *testing.T) {
+	tests := map[int]string{
+		15:   "15 minutes",
+		60:   "1 hour",
+		360:  "6 hours",
+		1440: "24 hours",
+		90:   "90 minutes",
+	}
+	for mins, want := range tests {
+		if got := WindowLabel(mins); got != want {
+			t.Errorf("WindowLabel(%d) = %q, want %q", mins, got, want)
+		}
+	}
+}
+
+func TestValidWindow(t *testing.T) {
+	for _, w := range Windows {
+		if !ValidWindow(w) {
+			t.Errorf("ValidWindow(%d) = false", w)
+		}
+	}
+	for _, w := range []int{0, -60, 30, 10080} {
+		if ValidWindow(w) {
+			t.Errorf("ValidWindow(%d) = true", w)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := Query(map[string]string{"category": "auth", "event_type": "login_failed_*"}, since)
+
+	if f.Category != "auth" || f.EventType != "login_failed_*" {
+		t.Errorf("Query() = %+v, want auth / login_failed_*", f)
+	}
+	if f.StartTime == nil || !f.StartTime.Equal(since) || f.EndTime != nil {
+		t.Errorf("Query() time range = %v to %v, want from %v", f.StartTime, f.EndTime, since)
+	}
+}
+
+func TestReviewQuery(t *testing.T) {
+	got := reviewQuery(map[string]string{"event_type": "login_failed_*", "category": "auth", "tz": "UTC"})
+	if want := "category=auth&event_type=login_failed_%2A"; got != want {
+		t.Errorf("reviewQuery() = %q, want %q", got, want)
+	}
+}
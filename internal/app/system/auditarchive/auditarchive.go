@@ -0,0 +1,240 @@
+// Package auditarchive enforces the audit log retention policy: audit
+// events older than the retention period are written to the storage
+// backend as gzip-compressed NDJSON and then deleted from MongoDB.
+//
+// A scheduled task queues an archive job on the "audit" queue, so each run
+// and what it archived show in the jobs UI. Each file is recorded in the
+// audit_archives collection before its events are deleted; the last
+// archived event anchors the hash chain for verification.
+package auditarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue archive runs are queued on.
+	QueueName = "audit"
+	// ArchiveJob is the job type that archives old audit events.
+	ArchiveJob = "archive_audit_logs"
+)
+
+const (
+	// Interval is how often an archive job is queued. Runs with nothing to
+	// archive are cheap, and a backlog drains a few batches an hour.
+	Interval = 1 * time.Hour
+
+	// batchSize is how many events go in one archive file.
+	batchSize = 10000
+
+	// maxBatches bounds how many files one run writes, so a large backlog
+	// is spread over several runs.
+	maxBatches = 20
+
+	// keyPrefix is where archive files are stored.
+	keyPrefix = "audit-archive/"
+)
+
+// Result is what one archive run did.
+type Result struct {
+	Files    int
+	Archived int64
+	Deleted  int64
+}
+
+// Map returns the result as a job result, shown in the jobs UI.
+func (r Result) Map() map[string]any {
+	return map[string]any{
+		"files":    r.Files,
+		"archived": r.Archived,
+		"deleted":  r.Deleted,
+	}
+}
+
+// Archiver moves audit events past their retention to storage.
+type Archiver struct {
+	audit     *audit.Store
+	jobs      *jobstore.Store
+	storage   storage.Store
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// New creates an Archiver. A zero retention keeps audit events forever.
+func New(db *mongo.Database, st storage.Store, retention time.Duration, logger *zap.Logger) *Archiver {
+	return &Archiver{
+		audit:     audit.New(db),
+		jobs:      jobstore.New(db),
+		storage:   st,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Register adds the audit queue and its job handler to r.
+func (a *Archiver) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(ArchiveJob, a.handleArchive)
+}
+
+// Jobs returns the background task that queues an archive run every
+// Interval, or nothing when audit events are kept forever.
+func (a *Archiver) Jobs() []tasks.Job {
+	if a.retention == 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "audit-log-archive",
+		Interval: Interval,
+		Run:      a.enqueue,
+	}}
+}
+
+// enqueue queues an archive run, unless one is already waiting or running.
+func (a *Archiver) enqueue(ctx context.Context) error {
+	stats, err := a.jobs.GetQueueStats(ctx, QueueName)
+	if err != nil {
+		return fmt.Errorf("reading audit queue: %w", err)
+	}
+	if stats.Pending > 0 || stats.Running > 0 {
+		return nil
+	}
+	// A failed run is picked up again by the next scheduled one
+	_, err = a.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     ArchiveJob,
+		MaxAttempts: 1,
+	})
+	return err
+}
+
+// handleArchive runs an archive job, returning what it did as its result.
+func (a *Archiver) handleArchive(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	res, err := a.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res.Map(), nil
+}
+
+// Run archives and deletes events older than the retention, a batch at a
+// time, until none are left or maxBatches files have been written. It
+// stops at the first failure, returning what was done before it.
+func (a *Archiver) Run(ctx context.Context) (Result, error) {
+	var res Result
+	if a.retention == 0 {
+		return res, nil
+	}
+	cutoff := time.Now().Add(-a.retention)
+
+	for res.Files < maxBatches {
+		events, err := a.audit.ArchivableBatch(ctx, cutoff, batchSize)
+		if err != nil {
+			return res, fmt.Errorf("reading audit events: %w", err)
+		}
+		if len(events) == 0 {
+			break
+		}
+		deleted, err := a.archive(ctx, events)
+		if err != nil {
+			return res, err
+		}
+		res.Files++
+		res.Archived += int64(len(events))
+		res.Deleted += deleted
+	}
+
+	if res.Files > 0 {
+		a.logger.Info("archived audit events",
+			zap.Int("files", res.Files),
+			zap.Int64("archived", res.Archived),
+			zap.Int64("deleted", res.Deleted))
+	}
+	return res, nil
+}
+
+// archive writes events to one archive file, records it, and deletes the
+// events. If the delete fails the events stay and are archived again by a
+// later run, so nothing is lost.
+func (a *Archiver) archive(ctx context.Context, events []audit.Event) (int64, error) {
+	data, err := Encode(events)
+	if err != nil {
+		return 0, fmt.Errorf("encoding audit archive: %w", err)
+	}
+
+	rec := audit.Archive{
+		ID:    primitive.NewObjectID(),
+		Count: len(events),
+		From:  events[0].CreatedAt,
+		To:    events[0].CreatedAt,
+	}
+	ids := make([]primitive.ObjectID, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+		if e.CreatedAt.Before(rec.From) {
+			rec.From = e.CreatedAt
+		}
+		if e.CreatedAt.After(rec.To) {
+			rec.To = e.CreatedAt
+		}
+		if e.Seq > 0 {
+			if rec.FirstSeq == 0 {
+				rec.FirstSeq = e.Seq
+			}
+			rec.LastSeq = e.Seq
+			rec.LastHash = e.Hash
+		}
+	}
+	rec.Key = ArchiveKey(rec.From, rec.ID)
+
+	if err := a.storage.PutBytes(ctx, rec.Key, data, &storage.PutOptions{ContentType: "application/gzip"}); err != nil {
+		return 0, fmt.Errorf("writing audit archive %s: %w", rec.Key, err)
+	}
+	if err := a.audit.RecordArchive(ctx, rec); err != nil {
+		return 0, fmt.Errorf("recording audit archive %s: %w", rec.Key, err)
+	}
+	deleted, err := a.audit.DeleteByIDs(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("deleting archived audit events: %w", err)
+	}
+	return deleted, nil
+}
+
+// ArchiveKey returns the storage path for an archive file whose oldest
+// event is from from, grouped by year and month.
+func ArchiveKey(from time.Time, id primitive.ObjectID) string {
+	from = from.UTC()
+	return fmt.Sprintf("%s%s/audit-%s-%s.ndjson.gz", keyPrefix, from.Format("2006/01"), from.Format("20060102T150405Z"), id.Hex())
+}
+
+// Encode returns events as gzip-compressed NDJSON, one event per line in
+// the same form as the audit log export and SIEM forwarding.
+func Encode(events []audit.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, e := range events {
+		if err := enc.Encode(auditforward.NewRecord(e)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
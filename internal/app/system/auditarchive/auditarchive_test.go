@@ -0,0 +1,86 @@
+package auditarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func TestEncode_RoundTrip(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []audit.Event{
+		{ID: primitive.NewObjectID(), CreatedAt: created, Category: audit.CategoryAuth, EventType: audit.EventLoginSuccess, Success: true, Seq: 1, Hash: "h1"},
+		{ID: primitive.NewObjectID(), CreatedAt: created.Add(time.Minute), Category: audit.CategoryAdmin, EventType: audit.EventUserUpdated, Success: true, Seq: 2, PrevHash: "h1", Hash: "h2"},
+	}
+
+	data, err := Encode(events)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+
+	var got []auditforward.Record
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var rec auditforward.Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("line %d: %v", len(got)+1, err)
+		}
+		got = append(got, rec)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+
+	if len(got) != len(events) {
+		t.Fatalf("decoded %d records, want %d", len(got), len(events))
+	}
+	for i, e := range events {
+		if got[i].ID != e.ID.Hex() || got[i].Seq != e.Seq || got[i].Hash != e.Hash || got[i].PrevHash != e.PrevHash {
+			t.Errorf("record %d = %+v, want event %s seq %d", i, got[i], e.ID.Hex(), e.Seq)
+		}
+		if !got[i].Time.Equal(e.CreatedAt) {
+			t.Errorf("record %d time = %v, want %v", i, got[i].Time, e.CreatedAt)
+		}
+	}
+}
+
+func TestArchiveKey(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	from := time.Date(2025, 3, 9, 14, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	want := "audit-archive/2025/03/audit-20250309T193000Z-65a1b2c3d4e5f60718293a4b.ndjson.gz"
+	if got := ArchiveKey(from, id); got != want {
+		t.Errorf("ArchiveKey() = %q, want %q", got, want)
+	}
+}
+
+func TestResult_Map(t *testing.T) {
+	m := Result{Files: 2, Archived: 15000, Deleted: 14999}.Map()
+	if m["files"] != 2 || m["archived"] != int64(15000) || m["deleted"] != int64(14999) {
+		t.Errorf("Map() = %v", m)
+	}
+}
+
+func TestJobs_KeepForever(t *testing.T) {
+	a := &Archiver{logger: zap.NewNop()}
+	if jobs := a.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() with no retention = %d jobs, want none", len(jobs))
+	}
+
+	a.retention = 90 * 24 * time.Hour
+	if jobs := a.Jobs(); len(jobs) != 1 || jobs[0].Interval != Interval {
+		t.Errorf("Jobs() with retention = %+v, want one job every %s", jobs, Interval)
+	}
+}
@@ -0,0 +1,417 @@
+// Package auditforward streams audit events to an external collector, so a
+// security team's SIEM can ingest them alongside other sources.
+//
+// Two destinations are supported:
+//
+//   - "syslog" sends each event as an RFC 5424 message whose body is the
+//     event as JSON, over UDP ("udp://host:514"), TCP ("tcp://host:514"),
+//     or TLS ("tls://host:6514"). Stream transports use octet-counting
+//     framing (RFC 6587).
+//   - "http" POSTs batches of events as newline-delimited JSON to a URL,
+//     such as a Splunk HEC raw endpoint or a Logstash or Elastic Agent
+//     HTTP input. An Authorization header is sent if one is configured.
+//
+// Events are queued in memory and sent from a background worker, so a slow
+// or unreachable collector never holds up a request. When the queue is
+// full, events are dropped (they are still in the audit log) and the drop
+// is logged.
+package auditforward
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"go.uber.org/zap"
+)
+
+// Supported destinations.
+const (
+	Syslog = "syslog"
+	HTTP   = "http"
+)
+
+const (
+	// queueSize is how many events may wait to be sent.
+	queueSize = 1000
+
+	// batchSize is the most events sent in one HTTP request.
+	batchSize = 100
+
+	// flushInterval is the longest an event waits for its batch to fill.
+	flushInterval = 2 * time.Second
+
+	// sendTimeout bounds one delivery attempt.
+	sendTimeout = 10 * time.Second
+
+	// sendAttempts is how many times a batch is tried before it is dropped.
+	sendAttempts = 3
+)
+
+// appName identifies the app in syslog messages.
+const appName = "stratasave"
+
+// Record is an audit event in the JSON form it is forwarded and exported
+// in.
+type Record struct {
+	ID            string            `json:"id"`
+	Time          time.Time         `json:"time"`
+	Category      string            `json:"category"`
+	EventType     string            `json:"event_type"`
+	UserID        string            `json:"user_id,omitempty"`
+	ActorID       string            `json:"actor_id,omitempty"`
+	IP            string            `json:"ip"`
+	UserAgent     string            `json:"user_agent,omitempty"`
+	RequestID     string            `json:"request_id,omitempty"` // Matches the request's ledger entry and log lines
+	Success       bool              `json:"success"`
+	FailureReason string            `json:"failure_reason,omitempty"`
+	Details       map[string]string `json:"details,omitempty"`
+	Changes       []audit.Change    `json:"changes,omitempty"`   // Fields an admin action changed, before and after
+	Seq           int64             `json:"seq,omitempty"`       // Position in the audit hash chain
+	PrevHash      string            `json:"prev_hash,omitempty"` // Hash of the event before, "" for the first
+	Hash          string            `json:"hash,omitempty"`      // Chain hash, for checking the stored log against
+}
+
+// NewRecord returns the record for an audit event.
+func NewRecord(e audit.Event) Record {
+	rec := Record{
+		ID:            e.ID.Hex(),
+		Time:          e.CreatedAt.UTC(),
+		Category:      e.Category,
+		EventType:     e.EventType,
+		IP:            e.IP,
+		UserAgent:     e.UserAgent,
+		RequestID:     e.RequestID,
+		Success:       e.Success,
+		FailureReason: e.FailureReason,
+		Details:       e.Details,
+		Changes:       e.Changes,
+		Seq:           e.Seq,
+		PrevHash:      e.PrevHash,
+		Hash:          e.Hash,
+	}
+	if e.UserID != nil {
+		rec.UserID = e.UserID.Hex()
+	}
+	if e.ActorID != nil {
+		rec.ActorID = e.ActorID.Hex()
+	}
+	return rec
+}
+
+// ValidKind reports whether kind names a supported destination.
+func ValidKind(kind string) bool {
+	return kind == Syslog || kind == HTTP
+}
+
+// sender delivers a batch of records to the collector.
+type sender interface {
+	send(ctx context.Context, recs []Record) error
+	close() error
+}
+
+// Forwarder queues audit events and sends them to the collector.
+type Forwarder struct {
+	sender  sender
+	events  chan Record
+	done    chan struct{}
+	dropped atomic.Int64
+	logger  *zap.Logger
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New creates a Forwarder and starts its worker. It returns nil
+// (forwarding disabled) when kind is empty. auth is sent as the
+// Authorization header of HTTP requests.
+func New(kind, address, auth string, logger *zap.Logger) (*Forwarder, error) {
+	if kind == "" {
+		return nil, nil
+	}
+	if !ValidKind(kind) {
+		return nil, fmt.Errorf("unknown audit forwarding destination %q", kind)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("audit forwarding to %q needs an address", kind)
+	}
+
+	var s sender
+	if kind == HTTP {
+		u, err := url.Parse(address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("audit forwarding address %q is not an http(s) URL", address)
+		}
+		s = &httpSender{url: address, auth: auth, client: &http.Client{Timeout: sendTimeout}}
+	} else {
+		network, addr, err := syslogAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		s = newSyslogSender(network, addr)
+	}
+
+	f := &Forwarder{
+		sender: s,
+		events: make(chan Record, queueSize),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+	go f.run()
+	return f, nil
+}
+
+// Forward queues an event to be sent. It never blocks: if the queue is
+// full the event is dropped.
+func (f *Forwarder) Forward(e audit.Event) {
+	if f == nil {
+		return
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.closed {
+		return
+	}
+	select {
+	case f.events <- NewRecord(e):
+	default:
+		f.dropped.Add(1)
+	}
+}
+
+// Close sends the events still queued and stops the worker, giving up when
+// ctx is done.
+func (f *Forwarder) Close(ctx context.Context) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		close(f.events)
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("audit forwarder did not drain: %w", ctx.Err())
+	}
+}
+
+// run sends queued events in batches until the queue is closed.
+func (f *Forwarder) run() {
+	defer close(f.done)
+	defer f.sender.close()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, batchSize)
+	flush := func() {
+		if n := f.dropped.Swap(0); n > 0 {
+			f.logger.Warn("audit forwarding queue full; events dropped", zap.Int64("dropped", n))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		f.deliver(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case rec, ok := <-f.events:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// deliver sends a batch, retrying with a short backoff before giving up.
+func (f *Forwarder) deliver(batch []Record) {
+	var err error
+	for attempt := 1; attempt <= sendAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = f.sender.send(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt < sendAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	f.logger.Error("failed to forward audit events", zap.Int("events", len(batch)), zap.Error(err))
+}
+
+// httpSender POSTs batches as newline-delimited JSON.
+type httpSender struct {
+	url    string
+	auth   string
+	client *http.Client
+}
+
+func (s *httpSender) send(ctx context.Context, recs []Record) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, rec := range recs {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.auth != "" {
+		req.Header.Set("Authorization", s.auth)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSender) close() error { return nil }
+
+// syslogSender writes RFC 5424 messages to a syslog collector, connecting
+// on first use and again after a write fails.
+type syslogSender struct {
+	network  string
+	addr     string
+	hostname string
+	conn     net.Conn
+}
+
+func newSyslogSender(network, addr string) *syslogSender {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &syslogSender{network: network, addr: addr, hostname: hostname}
+}
+
+func (s *syslogSender) send(ctx context.Context, recs []Record) error {
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+
+	for i, rec := range recs {
+		msg, err := formatSyslog(rec, s.hostname)
+		if err != nil {
+			return err
+		}
+		if s.network != "udp" {
+			msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			s.conn.Close()
+			s.conn = nil
+			// The whole batch is retried, so the collector may see the
+			// events before i twice
+			return fmt.Errorf("write to syslog after %d of %d events: %w", i, len(recs), err)
+		}
+	}
+	return nil
+}
+
+func (s *syslogSender) dial(ctx context.Context) (net.Conn, error) {
+	if s.network == "tls" {
+		d := tls.Dialer{}
+		return d.DialContext(ctx, "tcp", s.addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, s.network, s.addr)
+}
+
+func (s *syslogSender) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// Syslog facility and severities used for audit messages.
+const (
+	facilityAuthPriv = 10
+	severityWarning  = 4
+	severityInfo     = 6
+)
+
+// formatSyslog returns rec as an RFC 5424 message with the record as its
+// JSON body. Failed events are logged at warning severity, the rest at
+// info.
+func formatSyslog(rec Record, hostname string) ([]byte, error) {
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	severity := severityInfo
+	if !rec.Success {
+		severity = severityWarning
+	}
+	msgID := rec.EventType
+	if msgID == "" {
+		msgID = "-"
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		facilityAuthPriv*8+severity,
+		rec.Time.UTC().Format(time.RFC3339Nano),
+		hostname, appName, os.Getpid(), msgID)
+	return append([]byte(header), body...), nil
+}
+
+// syslogAddress splits a syslog address into the network to dial and the
+// host:port. A bare host:port means UDP.
+func syslogAddress(address string) (network, addr string, err error) {
+	network, addr, found := strings.Cut(address, "://")
+	if !found {
+		return "udp", address, nil
+	}
+	switch network {
+	case "udp", "tcp", "tls":
+		if addr == "" {
+			break
+		}
+		return network, addr, nil
+	}
+	return "", "", fmt.Errorf("syslog address %q must be udp://, tcp://, or tls:// host:port", address)
+}
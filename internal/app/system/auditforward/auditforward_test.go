@@ -0,0 +1,208 @@
+package auditforward
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func testEvent(eventType string, success bool) audit.Event {
+	userID := primitive.NewObjectID()
+	return audit.Event{
+		ID:        primitive.NewObjectID(),
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Category:  audit.CategoryAuth,
+		EventType: eventType,
+		UserID:    &userID,
+		IP:        "203.0.113.7",
+		Success:   success,
+		Details:   map[string]string{"method": "password"},
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		address string
+		wantNil bool
+		wantErr bool
+	}{
+		{"disabled", "", "", true, false},
+		{"unknown kind", "kafka", "host:9092", true, true},
+		{"missing address", HTTP, "", true, true},
+		{"http needs a url", HTTP, "collector:8088", true, true},
+		{"bad syslog scheme", Syslog, "ftp://host:514", true, true},
+		{"http", HTTP, "https://collector.example.com/ingest", false, false},
+		{"bare syslog address", Syslog, "127.0.0.1:514", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.kind, tt.address, "", zap.NewNop())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (f == nil) != tt.wantNil {
+				t.Fatalf("New() = %v, wantNil %v", f, tt.wantNil)
+			}
+			_ = f.Close(context.Background())
+		})
+	}
+}
+
+func TestSyslogAddress(t *testing.T) {
+	tests := []struct {
+		address     string
+		network     string
+		addr        string
+		expectError bool
+	}{
+		{"logs.example.com:514", "udp", "logs.example.com:514", false},
+		{"udp://logs.example.com:514", "udp", "logs.example.com:514", false},
+		{"tcp://logs.example.com:514", "tcp", "logs.example.com:514", false},
+		{"tls://logs.example.com:6514", "tls", "logs.example.com:6514", false},
+		{"tcp://", "", "", true},
+		{"http://logs.example.com", "", "", true},
+	}
+	for _, tt := range tests {
+		network, addr, err := syslogAddress(tt.address)
+		if (err != nil) != tt.expectError {
+			t.Errorf("syslogAddress(%q) error = %v, expectError %v", tt.address, err, tt.expectError)
+			continue
+		}
+		if network != tt.network || addr != tt.addr {
+			t.Errorf("syslogAddress(%q) = %q, %q; want %q, %q", tt.address, network, addr, tt.network, tt.addr)
+		}
+	}
+}
+
+func TestFormatSyslog(t *testing.T) {
+	rec := NewRecord(testEvent(audit.EventLoginFailedWrongPassword, false))
+	msg, err := formatSyslog(rec, "web1")
+	if err != nil {
+		t.Fatalf("formatSyslog() error = %v", err)
+	}
+
+	// authpriv (10) * 8 + warning (4)
+	prefix := "<84>1 2026-03-01T12:00:00Z web1 stratasave "
+	if !strings.HasPrefix(string(msg), prefix) {
+		t.Fatalf("message = %q, want prefix %q", msg, prefix)
+	}
+	header, body, ok := strings.Cut(string(msg), " - ")
+	if !ok || !strings.HasSuffix(header, " "+audit.EventLoginFailedWrongPassword) {
+		t.Fatalf("message = %q, want MSGID %q before the body", msg, audit.EventLoginFailedWrongPassword)
+	}
+
+	var got Record
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	if got.ID != rec.ID || got.EventType != rec.EventType || got.Details["method"] != "password" {
+		t.Errorf("body = %+v, want %+v", got, rec)
+	}
+
+	rec.Success = true
+	msg, _ = formatSyslog(rec, "web1")
+	if !strings.HasPrefix(string(msg), "<86>1 ") {
+		t.Errorf("successful event message = %q, want info severity <86>", msg)
+	}
+}
+
+func TestForwarder_HTTP(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		recs []Record
+		auth string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var rec Record
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				t.Errorf("line %q is not JSON: %v", sc.Text(), err)
+			}
+			recs = append(recs, rec)
+		}
+	}))
+	defer srv.Close()
+
+	f, err := New(HTTP, srv.URL, "Splunk secret", zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	f.Forward(testEvent(audit.EventLoginSuccess, true))
+	f.Forward(testEvent(audit.EventLogout, true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(recs) != 2 || recs[0].EventType != audit.EventLoginSuccess || recs[1].EventType != audit.EventLogout {
+		t.Errorf("collector received %+v, want login_success then logout", recs)
+	}
+	if auth != "Splunk secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Splunk secret")
+	}
+
+	// Events after Close are ignored rather than panicking
+	f.Forward(testEvent(audit.EventLoginSuccess, true))
+}
+
+func TestForwarder_SyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer pc.Close()
+
+	f, err := New(Syslog, "udp://"+pc.LocalAddr().String(), "", zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	f.Forward(testEvent(audit.EventLoginSuccess, true))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no syslog message received: %v", err)
+	}
+	if msg := string(buf[:n]); !strings.HasPrefix(msg, "<86>1 ") || !strings.Contains(msg, `"event_type":"login_success"`) {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestForwarder_Nil(t *testing.T) {
+	var f *Forwarder
+	f.Forward(testEvent(audit.EventLoginSuccess, true))
+	if err := f.Close(context.Background()); err != nil {
+		t.Errorf("Close() on nil Forwarder = %v", err)
+	}
+}
@@ -9,8 +9,12 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	"github.com/dalemusser/stratasave/internal/app/system/auditforward"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
@@ -28,9 +32,10 @@ type Config struct {
 // Logger provides convenience methods for logging audit events.
 // It logs to both MongoDB (via audit.Store) and structured logs (via zap).
 type Logger struct {
-	store  *audit.Store
-	zapLog *zap.Logger
-	config Config
+	store     *audit.Store
+	zapLog    *zap.Logger
+	config    Config
+	forwarder *auditforward.Forwarder // nil unless events are forwarded to a SIEM
 }
 
 // New creates a new audit Logger.
@@ -42,6 +47,12 @@ func New(store *audit.Store, zapLog *zap.Logger, config Config) *Logger {
 	}
 }
 
+// SetForwarder streams every logged event to an external collector as
+// well. Events in a category whose logging is "off" are not forwarded.
+func (l *Logger) SetForwarder(f *auditforward.Forwarder) {
+	l.forwarder = f
+}
+
 // getClientIP extracts the client IP from the request.
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header first (for reverse proxies)
@@ -66,6 +77,9 @@ func (l *Logger) logToZap(event audit.Event) {
 		zap.String("ip", event.IP),
 	}
 
+	if event.RequestID != "" {
+		fields = append(fields, zap.String("request_id", event.RequestID))
+	}
 	if event.UserID != nil {
 		fields = append(fields, zap.String("user_id", event.UserID.Hex()))
 	}
@@ -78,6 +92,9 @@ func (l *Logger) logToZap(event audit.Event) {
 	for k, v := range event.Details {
 		fields = append(fields, zap.String("detail_"+k, v))
 	}
+	if len(event.Changes) > 0 {
+		fields = append(fields, zap.Any("changes", event.Changes))
+	}
 
 	if event.Success {
 		l.zapLog.Info("audit event", fields...)
@@ -110,20 +127,50 @@ func (l *Logger) Log(ctx context.Context, event audit.Event) {
 		return
 	}
 
+	// Attribute anything done while impersonating to the admin as well
+	if u, ok := auth.UserFromContext(ctx); ok && u.IsImpersonated() {
+		details := make(map[string]string, len(event.Details)+1)
+		for k, v := range event.Details {
+			details[k] = v
+		}
+		details["impersonator_id"] = u.ImpersonatorID
+		event.Details = details
+	}
+
+	// Tie the event to the request it came from
+	if event.RequestID == "" {
+		event.RequestID = requestid.FromContext(ctx)
+	}
+
+	// Assign the ID and time here rather than in the store, so the zap
+	// and forwarded copies match the stored one
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
 	// Log to zap if configured
 	if setting == "all" || setting == "log" {
 		l.logToZap(event)
 	}
 
-	// Log to MongoDB if configured
+	// Log to MongoDB if configured. The stored event carries its place in
+	// the hash chain, which is forwarded so the SIEM holds a copy of it.
 	if setting == "all" || setting == "db" {
-		if err := l.store.Log(ctx, event); err != nil {
+		stored, err := l.store.Append(ctx, event)
+		if err != nil {
 			l.zapLog.Error("failed to store audit event",
 				zap.Error(err),
 				zap.String("event_type", event.EventType),
 			)
+		} else {
+			event = stored
 		}
 	}
+
+	l.forwarder.Forward(event)
 }
 
 // --- Authentication Events ---
@@ -225,6 +272,33 @@ func (l *Logger) PasswordChanged(ctx context.Context, r *http.Request, userID pr
 	})
 }
 
+// AuthMethodLinked logs a user connecting a sign-in method to their account.
+func (l *Logger) AuthMethodLinked(ctx context.Context, r *http.Request, userID primitive.ObjectID, method string) {
+	l.authMethodChanged(ctx, r, userID, audit.EventAuthMethodLinked, method)
+}
+
+// AuthMethodUnlinked logs a user disconnecting a sign-in method from their
+// account.
+func (l *Logger) AuthMethodUnlinked(ctx context.Context, r *http.Request, userID primitive.ObjectID, method string) {
+	l.authMethodChanged(ctx, r, userID, audit.EventAuthMethodUnlinked, method)
+}
+
+// authMethodChanged logs a change to a user's sign-in methods.
+func (l *Logger) authMethodChanged(ctx context.Context, r *http.Request, userID primitive.ObjectID, eventType, method string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAuth,
+		EventType: eventType,
+		UserID:    &userID,
+		ActorID:   &userID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"auth_method": method,
+		},
+	})
+}
+
 // VerificationCodeSent logs when a verification code is sent.
 func (l *Logger) VerificationCodeSent(ctx context.Context, r *http.Request, userID primitive.ObjectID, email string) {
 	l.Log(ctx, audit.Event{
@@ -284,6 +358,24 @@ func (l *Logger) MagicLinkUsed(ctx context.Context, r *http.Request, userID prim
 	})
 }
 
+// UserRegistered logs when someone creates their own account through public
+// signup. Status is "pending" when the account awaits admin approval.
+func (l *Logger) UserRegistered(ctx context.Context, r *http.Request, userID primitive.ObjectID, email, role, status string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAuth,
+		EventType: audit.EventUserRegistered,
+		UserID:    &userID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"email":  email,
+			"role":   role,
+			"status": status,
+		},
+	})
+}
+
 // --- Admin Events ---
 
 // UserCreated logs when an admin creates a user.
@@ -304,8 +396,8 @@ func (l *Logger) UserCreated(ctx context.Context, r *http.Request, actorID, targ
 	})
 }
 
-// UserUpdated logs when an admin updates a user.
-func (l *Logger) UserUpdated(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, actorRole, fieldsChanged string) {
+// UserUpdated logs when an admin updates a user, with the fields changed.
+func (l *Logger) UserUpdated(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, actorRole string, changes []audit.Change) {
 	l.Log(ctx, audit.Event{
 		Category:  audit.CategoryAdmin,
 		EventType: audit.EventUserUpdated,
@@ -315,9 +407,9 @@ func (l *Logger) UserUpdated(ctx context.Context, r *http.Request, actorID, targ
 		UserAgent: r.UserAgent(),
 		Success:   true,
 		Details: map[string]string{
-			"actor_role":     actorRole,
-			"fields_changed": fieldsChanged,
+			"actor_role": actorRole,
 		},
+		Changes: changes,
 	})
 }
 
@@ -370,8 +462,9 @@ func (l *Logger) UserDeleted(ctx context.Context, r *http.Request, actorID, targ
 	})
 }
 
-// SettingsUpdated logs when admin updates site settings.
-func (l *Logger) SettingsUpdated(ctx context.Context, r *http.Request, actorID primitive.ObjectID, actorRole, fieldsChanged string) {
+// SettingsUpdated logs when admin updates site settings, with the fields
+// changed.
+func (l *Logger) SettingsUpdated(ctx context.Context, r *http.Request, actorID primitive.ObjectID, actorRole string, changes []audit.Change) {
 	l.Log(ctx, audit.Event{
 		Category:  audit.CategoryAdmin,
 		EventType: audit.EventSettingsUpdated,
@@ -380,9 +473,9 @@ func (l *Logger) SettingsUpdated(ctx context.Context, r *http.Request, actorID p
 		UserAgent: r.UserAgent(),
 		Success:   true,
 		Details: map[string]string{
-			"actor_role":     actorRole,
-			"fields_changed": fieldsChanged,
+			"actor_role": actorRole,
 		},
+		Changes: changes,
 	})
 }
 
@@ -402,6 +495,74 @@ func (l *Logger) PageUpdated(ctx context.Context, r *http.Request, actorID primi
 	})
 }
 
+// ImpersonationStarted logs when an admin starts acting as another user.
+func (l *Logger) ImpersonationStarted(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, actorRole string, duration string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAdmin,
+		EventType: audit.EventImpersonationStarted,
+		UserID:    &targetUserID,
+		ActorID:   &actorID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"actor_role": actorRole,
+			"duration":   duration,
+		},
+	})
+}
+
+// ImpersonationEnded logs when an impersonation ends. Reason is "stopped"
+// when the admin ends it and "expired" when it times out.
+func (l *Logger) ImpersonationEnded(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, reason string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAdmin,
+		EventType: audit.EventImpersonationEnded,
+		UserID:    &targetUserID,
+		ActorID:   &actorID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"reason": reason,
+		},
+	})
+}
+
+// RegistrationApproved logs when an admin approves a self-registered account.
+func (l *Logger) RegistrationApproved(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, actorRole string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAdmin,
+		EventType: audit.EventRegistrationApproved,
+		UserID:    &targetUserID,
+		ActorID:   &actorID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"actor_role": actorRole,
+		},
+	})
+}
+
+// RegistrationRejected logs when an admin rejects a self-registered account.
+// The account is deleted, so the email is kept in the details.
+func (l *Logger) RegistrationRejected(ctx context.Context, r *http.Request, actorID, targetUserID primitive.ObjectID, actorRole, email string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategoryAdmin,
+		EventType: audit.EventRegistrationRejected,
+		UserID:    &targetUserID,
+		ActorID:   &actorID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Details: map[string]string{
+			"actor_role": actorRole,
+			"email":      email,
+		},
+	})
+}
+
 // --- Helper functions ---
 
 func boolToString(b bool) string {
@@ -429,6 +590,32 @@ func (l *Logger) LogAuthEvent(r *http.Request, userID *primitive.ObjectID, event
 	})
 }
 
+// LogSecurityEvent records a security alert raised by suspicious activity
+// detection. userID is the affected user, if there is one.
+func (l *Logger) LogSecurityEvent(r *http.Request, userID *primitive.ObjectID, eventType string, details map[string]string) {
+	l.Log(r.Context(), audit.Event{
+		Category:  audit.CategorySecurity,
+		EventType: eventType,
+		UserID:    userID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   false,
+		Details:   details,
+	})
+}
+
+// LogSystemSecurityEvent is LogSecurityEvent for alerts raised by
+// background checks, which have no request to take an IP from.
+func (l *Logger) LogSystemSecurityEvent(ctx context.Context, userID *primitive.ObjectID, eventType string, details map[string]string) {
+	l.Log(ctx, audit.Event{
+		Category:  audit.CategorySecurity,
+		EventType: eventType,
+		UserID:    userID,
+		Success:   false,
+		Details:   details,
+	})
+}
+
 // LogAdminEvent is a convenience method for logging admin events with flexible parameters.
 // Used by features that need a simpler interface.
 func (l *Logger) LogAdminEvent(r *http.Request, actorID, targetUserID *primitive.ObjectID, eventType string, details map[string]string) {
@@ -443,3 +630,18 @@ func (l *Logger) LogAdminEvent(r *http.Request, actorID, targetUserID *primitive
 		Details:   details,
 	})
 }
+
+// LogAdminChange is LogAdminEvent for edits, recording the fields changed
+// with their values before and after.
+func (l *Logger) LogAdminChange(r *http.Request, actorID, targetUserID *primitive.ObjectID, eventType string, changes []audit.Change) {
+	l.Log(r.Context(), audit.Event{
+		Category:  audit.CategoryAdmin,
+		EventType: eventType,
+		UserID:    targetUserID,
+		ActorID:   actorID,
+		IP:        getClientIP(r),
+		UserAgent: r.UserAgent(),
+		Success:   true,
+		Changes:   changes,
+	})
+}
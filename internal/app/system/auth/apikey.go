@@ -1,12 +1,85 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"github.com/dalemusser/stratasave/internal/app/system/network"
 	"go.uber.org/zap"
 )
 
+// Headers set on the request once a database-managed API key has been
+// validated. The ledger middleware reads these after the handler chain runs
+// to attribute the request to the key. Client-supplied values are always
+// stripped before authentication so they cannot be spoofed.
+const (
+	APIKeyIDHeader   = "X-API-Key-ID"
+	APIKeyNameHeader = "X-API-Key-Name"
+)
+
+// APIKeyPrincipal describes a database-managed API key that successfully
+// authenticated a request.
+type APIKeyPrincipal struct {
+	ID   string
+	Name string
+
+	// AllowedCIDRs restricts which client IPs may use the key.
+	// Empty means the key may be used from any IP.
+	AllowedCIDRs []string
+}
+
+// AllowsIP reports whether the key may be used from the given client IP.
+func (p *APIKeyPrincipal) AllowsIP(ip string) bool {
+	if len(p.AllowedCIDRs) == 0 {
+		return true
+	}
+	return network.IPInCIDRs(ip, p.AllowedCIDRs)
+}
+
+// APIKeyValidator validates database-managed API keys.
+// Implementations should return nil if the key is unknown, revoked, or any
+// other condition that should reject the request.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) *APIKeyPrincipal
+
+	// LookupAPIKey returns the key with the given ID, or nil if it is
+	// unknown, revoked, or expired. It checks that the key behind an API
+	// token is still usable.
+	LookupAPIKey(ctx context.Context, id string) *APIKeyPrincipal
+}
+
+// APIKeyAuthConfig configures APIKeyAuthWithConfig.
+type APIKeyAuthConfig struct {
+	// StaticKey is the single API key from configuration (api_key).
+	// Empty disables static key authentication.
+	StaticKey string
+
+	// Validator checks database-managed API keys (created at /api-keys).
+	// Nil disables database key authentication.
+	Validator APIKeyValidator
+
+	// Signature verifies HMAC-signed requests (X-Signature headers) as an
+	// alternative to a bearer key. Nil disables request signing.
+	Signature *SignatureVerifier
+
+	// Tokens verifies short-lived API tokens (JWTs) sent as bearer credentials.
+	// Token-authenticated requests carry the token's claims in the request
+	// context (see APITokenFromContext). Nil disables token authentication.
+	Tokens *TokenIssuer
+
+	// TrustedProxies lists the reverse proxies (CIDR ranges) whose
+	// X-Forwarded-For and X-Real-IP headers are believed when checking a
+	// key's IP allowlist. Empty means the allowlist is checked against the
+	// connection's own address.
+	TrustedProxies []string
+
+	// OnReject is called with a short reason whenever a request is rejected,
+	// allowing callers to record the reason (e.g., in the request ledger).
+	OnReject func(r *http.Request, reason string)
+}
+
 // APIKeyAuth returns middleware that validates API key authentication.
 //
 // The middleware checks for an API key in the Authorization header using
@@ -28,19 +101,76 @@ import (
 // If the API key is invalid or missing, returns 401 Unauthorized.
 // If the API key is not configured (empty), logs a warning and rejects all requests.
 func APIKeyAuth(validKey string, logger *zap.Logger) func(http.Handler) http.Handler {
-	if validKey == "" {
+	return APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: validKey}, logger)
+}
+
+// APIKeyAuthWithConfig returns middleware that accepts either the static
+// configured API key or a database-managed key checked by cfg.Validator.
+//
+// When cfg.Signature is set, requests carrying an X-Signature header are
+// authenticated by their HMAC signature instead (see SignRequest), and no
+// Authorization header is required.
+//
+// When cfg.Tokens is set, a bearer credential that is a JWT is verified as an
+// API token scoped to a single user and game (see TokenIssuer). A token
+// issued for a database-managed key stops working once that key is revoked
+// or expires.
+//
+// Database-managed keys may carry an IP allowlist. Requests using such a key
+// from an IP outside the allowlist are rejected with 403 Forbidden.
+// On success, the key's ID and name are set in the X-API-Key-ID and
+// X-API-Key-Name request headers for downstream attribution.
+func APIKeyAuthWithConfig(cfg APIKeyAuthConfig, logger *zap.Logger) func(http.Handler) http.Handler {
+	if cfg.StaticKey == "" && cfg.Validator == nil && cfg.Signature == nil && cfg.Tokens == nil {
 		logger.Warn("API key not configured - all API requests will be rejected")
 	}
 
+	reject := func(w http.ResponseWriter, r *http.Request, msg string, code int) {
+		if cfg.OnReject != nil {
+			cfg.OnReject(r, msg)
+		}
+		http.Error(w, msg, code)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Never trust attribution headers supplied by the client
+			r.Header.Del(APIKeyIDHeader)
+			r.Header.Del(APIKeyNameHeader)
+
 			// If no API key is configured, reject all requests
-			if validKey == "" {
+			if cfg.StaticKey == "" && cfg.Validator == nil && cfg.Signature == nil && cfg.Tokens == nil {
 				logger.Warn("API request rejected: API key not configured",
 					zap.String("path", r.URL.Path),
 					zap.String("remote_addr", r.RemoteAddr),
 				)
-				http.Error(w, "API authentication not configured", http.StatusUnauthorized)
+				reject(w, r, "API authentication not configured", http.StatusUnauthorized)
+				return
+			}
+
+			// Signed request - authenticated by HMAC instead of a bearer key
+			if r.Header.Get(SignatureHeader) != "" {
+				if cfg.Signature == nil {
+					logger.Debug("API request rejected: request signing not enabled",
+						zap.String("path", r.URL.Path),
+					)
+					reject(w, r, "Request signing is not enabled", http.StatusUnauthorized)
+					return
+				}
+				if err := cfg.Signature.Verify(w, r); err != nil {
+					logger.Warn("API request rejected: signature verification failed",
+						zap.String("path", r.URL.Path),
+						zap.String("remote_addr", r.RemoteAddr),
+						zap.Error(err),
+					)
+					status := http.StatusUnauthorized
+					if errors.Is(err, ErrSignatureBodySize) {
+						status = http.StatusRequestEntityTooLarge
+					}
+					reject(w, r, "Signature rejected: "+err.Error(), status)
+					return
+				}
+				next.ServeHTTP(w, r)
 				return
 			}
 
@@ -50,7 +180,7 @@ func APIKeyAuth(validKey string, logger *zap.Logger) func(http.Handler) http.Han
 				logger.Debug("API request rejected: missing Authorization header",
 					zap.String("path", r.URL.Path),
 				)
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				reject(w, r, "Missing Authorization header", http.StatusUnauthorized)
 				return
 			}
 
@@ -60,17 +190,74 @@ func APIKeyAuth(validKey string, logger *zap.Logger) func(http.Handler) http.Han
 				logger.Debug("API request rejected: invalid Authorization format",
 					zap.String("path", r.URL.Path),
 				)
-				http.Error(w, "Invalid Authorization format (expected: Bearer <api-key>)", http.StatusUnauthorized)
+				reject(w, r, "Invalid Authorization format (expected: Bearer <api-key>)", http.StatusUnauthorized)
 				return
 			}
 
 			providedKey := parts[1]
-			if providedKey != validKey {
+
+			// Static configured key - proceed
+			if cfg.StaticKey != "" && providedKey == cfg.StaticKey {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Short-lived API token
+			if cfg.Tokens != nil && looksLikeJWT(providedKey) {
+				claims, err := cfg.Tokens.Parse(providedKey)
+				if err != nil {
+					logger.Debug("API request rejected: invalid API token",
+						zap.String("path", r.URL.Path),
+						zap.Error(err),
+					)
+					reject(w, r, "Invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+				if claims.KeyID != "" && cfg.Validator != nil {
+					principal := cfg.Validator.LookupAPIKey(r.Context(), claims.KeyID)
+					if principal == nil {
+						logger.Warn("API request rejected: API token's key is revoked or expired",
+							zap.String("path", r.URL.Path),
+							zap.String("key_id", claims.KeyID),
+						)
+						reject(w, r, "Invalid or expired token", http.StatusUnauthorized)
+						return
+					}
+					r.Header.Set(APIKeyNameHeader, principal.Name)
+				}
+				if claims.KeyID != "" {
+					r.Header.Set(APIKeyIDHeader, claims.KeyID)
+				}
+				next.ServeHTTP(w, r.WithContext(withAPIToken(r.Context(), claims)))
+				return
+			}
+
+			// Database-managed key
+			var principal *APIKeyPrincipal
+			if cfg.Validator != nil {
+				principal = cfg.Validator.ValidateAPIKey(r.Context(), providedKey)
+			}
+			if principal == nil {
 				logger.Warn("API request rejected: invalid API key",
 					zap.String("path", r.URL.Path),
 					zap.String("remote_addr", r.RemoteAddr),
 				)
-				http.Error(w, "Invalid API key", http.StatusUnauthorized)
+				reject(w, r, "Invalid API key", http.StatusUnauthorized)
+				return
+			}
+
+			r.Header.Set(APIKeyIDHeader, principal.ID)
+			r.Header.Set(APIKeyNameHeader, principal.Name)
+
+			clientIP := network.TrustedClientIP(r, cfg.TrustedProxies)
+			if !principal.AllowsIP(clientIP) {
+				logger.Warn("API request rejected: client IP not in API key allowlist",
+					zap.String("path", r.URL.Path),
+					zap.String("key_id", principal.ID),
+					zap.String("key_name", principal.Name),
+					zap.String("client_ip", clientIP),
+				)
+				reject(w, r, "API key is not permitted from this IP address", http.StatusForbidden)
 				return
 			}
 
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type stubValidator struct{ principal *APIKeyPrincipal }
+
+func (v stubValidator) ValidateAPIKey(ctx context.Context, key string) *APIKeyPrincipal {
+	if key != "managed-key" {
+		return nil
+	}
+	return v.principal
+}
+
+func (v stubValidator) LookupAPIKey(ctx context.Context, id string) *APIKeyPrincipal {
+	if v.principal == nil || v.principal.ID != id {
+		return nil
+	}
+	return v.principal
+}
+
+func TestAPIKeyAuthWithConfig_AllowedCIDRs(t *testing.T) {
+	principal := &APIKeyPrincipal{ID: "k1", Name: "office", AllowedCIDRs: []string{"203.0.113.7/32"}}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		xForwardedFor  string
+		wantStatus     int
+	}{
+		{"direct from allowed IP", nil, "203.0.113.7:4000", "", http.StatusOK},
+		{"direct from other IP", nil, "198.51.100.9:4000", "", http.StatusForbidden},
+		{"spoofed X-Forwarded-For", nil, "198.51.100.9:4000", "203.0.113.7", http.StatusForbidden},
+		{"spoofed X-Forwarded-For through trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.1:4000", "203.0.113.7, 198.51.100.9", http.StatusForbidden},
+		{"allowed IP through trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.1:4000", "203.0.113.7", http.StatusOK},
+		{"X-Forwarded-For from untrusted proxy", []string{"10.0.0.0/8"}, "192.168.1.1:4000", "203.0.113.7", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := APIKeyAuthWithConfig(APIKeyAuthConfig{
+				Validator:      stubValidator{principal: principal},
+				TrustedProxies: tt.trustedProxies,
+			}, zap.NewNop())
+			req := httptest.NewRequest(http.MethodPost, "/api/state/load", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer managed-key")
+			if tt.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			mw(next).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthWithConfig_TokenKeyRevoked(t *testing.T) {
+	tokens := NewTokenIssuer("test-token-secret", time.Hour)
+	token, _, err := tokens.Issue("player123", "mygame", "k1")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		principal  *APIKeyPrincipal // The key as the validator has it; nil once revoked
+		wantStatus int
+	}{
+		{"key active", &APIKeyPrincipal{ID: "k1", Name: "studio"}, http.StatusOK},
+		{"key revoked", nil, http.StatusUnauthorized},
+		{"other key", &APIKeyPrincipal{ID: "k2", Name: "other"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := APIKeyAuthWithConfig(APIKeyAuthConfig{
+				Validator: stubValidator{principal: tt.principal},
+				Tokens:    tokens,
+			}, zap.NewNop())
+			req := httptest.NewRequest(http.MethodPost, "/api/state/load", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			mw(next).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
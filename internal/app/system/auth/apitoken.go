@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultAPITokenTTL is the default lifetime of issued API tokens.
+const DefaultAPITokenTTL = 15 * time.Minute
+
+// apiTokenIssuer and apiTokenAudience are stamped on every issued token and
+// required when parsing, so tokens from other systems sharing the secret
+// are not accepted.
+const (
+	apiTokenIssuer   = "stratasave"
+	apiTokenAudience = "stratasave-api"
+)
+
+// ErrInvalidAPIToken is returned when a token is malformed, has a bad
+// signature, or has expired.
+var ErrInvalidAPIToken = errors.New("invalid or expired token")
+
+// APITokenClaims are the claims carried by a short-lived API token.
+// A token is scoped to a single player (UserID) in a single Game.
+type APITokenClaims struct {
+	UserID string `json:"uid"`
+	Game   string `json:"game"`
+
+	// KeyID identifies the database-managed API key that requested the token,
+	// if any. Empty when the token was issued with the static key.
+	KeyID string `json:"kid,omitempty"`
+
+	jwt.RegisteredClaims
+}
+
+// Allows reports whether the token grants access to the given user and game.
+func (c *APITokenClaims) Allows(userID, game string) bool {
+	return c.UserID == userID && c.Game == game
+}
+
+// TokenIssuer issues and verifies HS256-signed JWTs scoped to a user and game.
+// Game servers exchange their API key for a token and hand it to the client,
+// so the long-lived key never ships in the client binary.
+type TokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenIssuer creates a token issuer with the given signing secret.
+// A ttl of zero or less uses DefaultAPITokenTTL.
+// Returns nil if secret is empty, which disables token issuance.
+func NewTokenIssuer(secret string, ttl time.Duration) *TokenIssuer {
+	if secret == "" {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultAPITokenTTL
+	}
+	return &TokenIssuer{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// TTL returns the lifetime of issued tokens.
+func (ti *TokenIssuer) TTL() time.Duration {
+	return ti.ttl
+}
+
+// Issue creates a signed token for the given user and game.
+// keyID is recorded in the token for attribution and may be empty.
+func (ti *TokenIssuer) Issue(userID, game, keyID string) (string, time.Time, error) {
+	now := ti.now()
+	expiresAt := now.Add(ti.ttl)
+	claims := APITokenClaims{
+		UserID: userID,
+		Game:   game,
+		KeyID:  keyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    apiTokenIssuer,
+			Audience:  jwt.ClaimStrings{apiTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ti.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Parse verifies a token's signature, issuer, audience, and expiry and
+// returns its claims.
+func (ti *TokenIssuer) Parse(token string) (*APITokenClaims, error) {
+	claims := &APITokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return ti.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(apiTokenIssuer),
+		jwt.WithAudience(apiTokenAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(ti.now),
+	)
+	if err != nil || claims.UserID == "" || claims.Game == "" {
+		return nil, ErrInvalidAPIToken
+	}
+	return claims, nil
+}
+
+// looksLikeJWT reports whether a bearer credential has the three-part
+// structure of a JWT. API keys never contain dots.
+func looksLikeJWT(s string) bool {
+	return strings.Count(s, ".") == 2
+}
+
+type apiTokenCtxKey struct{}
+
+// withAPIToken stores token claims in the context.
+func withAPIToken(ctx context.Context, claims *APITokenClaims) context.Context {
+	return context.WithValue(ctx, apiTokenCtxKey{}, claims)
+}
+
+// APITokenFromContext returns the claims of the API token that authenticated
+// the request, if the request used a token rather than an API key.
+// Handlers use this to restrict access to the token's user and game.
+func APITokenFromContext(ctx context.Context) (*APITokenClaims, bool) {
+	claims, ok := ctx.Value(apiTokenCtxKey{}).(*APITokenClaims)
+	return claims, ok && claims != nil
+}
+
+// APITokenAllows reports whether the request may access the given user and
+// game. Requests authenticated with an API key rather than a token may access
+// any user and game.
+func APITokenAllows(ctx context.Context, userID, game string) bool {
+	claims, ok := APITokenFromContext(ctx)
+	if !ok {
+		return true
+	}
+	return claims.Allows(userID, game)
+}
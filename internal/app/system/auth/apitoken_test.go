@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestTokenIssuer_IssueAndParse(t *testing.T) {
+	ti := NewTokenIssuer("test-token-secret", time.Minute)
+
+	token, expiresAt, err := ti.Issue("player123", "mygame", "key1")
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Errorf("expiresAt = %v, want future time", expiresAt)
+	}
+
+	claims, err := ti.Parse(token)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if claims.UserID != "player123" || claims.Game != "mygame" || claims.KeyID != "key1" {
+		t.Errorf("claims = %+v, want player123/mygame/key1", claims)
+	}
+	if !claims.Allows("player123", "mygame") {
+		t.Error("Allows() should be true for the token's user and game")
+	}
+	if claims.Allows("player123", "othergame") {
+		t.Error("Allows() should be false for a different game")
+	}
+}
+
+func TestTokenIssuer_ParseRejects(t *testing.T) {
+	ti := NewTokenIssuer("test-token-secret", time.Minute)
+
+	t.Run("wrong secret", func(t *testing.T) {
+		other := NewTokenIssuer("other-secret", time.Minute)
+		token, _, _ := other.Issue("player123", "mygame", "")
+		if _, err := ti.Parse(token); err != ErrInvalidAPIToken {
+			t.Errorf("Parse() error = %v, want %v", err, ErrInvalidAPIToken)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		past := NewTokenIssuer("test-token-secret", time.Minute)
+		past.now = func() time.Time { return time.Now().Add(-time.Hour) }
+		token, _, _ := past.Issue("player123", "mygame", "")
+		if _, err := ti.Parse(token); err != ErrInvalidAPIToken {
+			t.Errorf("Parse() error = %v, want %v", err, ErrInvalidAPIToken)
+		}
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		if _, err := ti.Parse("a.b.c"); err != ErrInvalidAPIToken {
+			t.Errorf("Parse() error = %v, want %v", err, ErrInvalidAPIToken)
+		}
+	})
+}
+
+func TestAPIKeyAuthWithConfig_Token(t *testing.T) {
+	logger := zap.NewNop()
+	ti := NewTokenIssuer("test-token-secret", time.Minute)
+	token, _, _ := ti.Issue("player123", "mygame", "")
+
+	var gotAllowed, gotOther bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAllowed = APITokenAllows(r.Context(), "player123", "mygame")
+		gotOther = APITokenAllows(r.Context(), "player456", "mygame")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("token accepted and scoped", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: "key", Tokens: ti}, logger)
+		req := httptest.NewRequest(http.MethodPost, "/api/state/load", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mw(next).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if !gotAllowed || gotOther {
+			t.Errorf("APITokenAllows() = %v/%v, want true/false", gotAllowed, gotOther)
+		}
+	})
+
+	t.Run("token rejected when tokens disabled", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: "key"}, logger)
+		req := httptest.NewRequest(http.MethodPost, "/api/state/load", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mw(next).ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("static key not scoped", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: "key", Tokens: ti}, logger)
+		req := httptest.NewRequest(http.MethodPost, "/api/state/load", nil)
+		req.Header.Set("Authorization", "Bearer key")
+		rec := httptest.NewRecorder()
+		mw(next).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if !gotOther {
+			t.Error("APITokenAllows() should be true for API key requests")
+		}
+	})
+}
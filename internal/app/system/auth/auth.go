@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/system/normalize"
@@ -60,6 +61,15 @@ type SessionManager struct {
 	name              string
 	userFetcher       UserFetcher
 	forbiddenRenderer ForbiddenRenderer
+
+	impersonationExpired ImpersonationHook
+
+	lifetimeMu     sync.RWMutex               // Guards lifetime and roleLifetimes, which can change on config reload
+	lifetime       SessionLifetime            // Default for roles not in roleLifetimes
+	roleLifetimes  map[string]SessionLifetime // Per-role overrides
+	sessionExpired SessionExpiredHook
+
+	reauthWindow time.Duration // How recently RequireRecentAuth needs a login or confirmation
 }
 
 // NewSessionManager creates a new SessionManager with the provided configuration.
@@ -124,9 +134,10 @@ func NewSessionManager(sessionKey, name, domain string, maxAge time.Duration, se
 		zap.String("domain", domain))
 
 	return &SessionManager{
-		store:  store,
-		logger: logger,
-		name:   name,
+		store:    store,
+		logger:   logger,
+		name:     name,
+		lifetime: SessionLifetime{Absolute: maxAge},
 	}, nil
 }
 
@@ -191,7 +202,17 @@ type SessionUser struct {
 	LoginID         string // User's login identifier
 	Role            string
 	ThemePreference string // light, dark, system (empty = system)
+	Timezone        string // IANA timezone ID for showing times (empty = UTC)
+	AvatarPath      string // Profile photo in file storage (empty = none)
 	Token           string // Session token for session management
+
+	// Sessions issued before this are no longer valid (see RotateSession)
+	SessionsValidAfter time.Time
+
+	// Set while an admin is impersonating this user
+	ImpersonatorID    string
+	ImpersonatorName  string
+	ImpersonationEnds time.Time
 }
 
 // UserID returns the user's ID as an ObjectID.
@@ -215,7 +236,13 @@ const currentUserKey ctxKey = "currentUser"
 
 // CurrentUser returns the user & "found?" flag from the request context.
 func CurrentUser(r *http.Request) (*SessionUser, bool) {
-	u, ok := r.Context().Value(currentUserKey).(*SessionUser)
+	return UserFromContext(r.Context())
+}
+
+// UserFromContext returns the user & "found?" flag from a request's context,
+// for code that is passed the context rather than the request.
+func UserFromContext(ctx context.Context) (*SessionUser, bool) {
+	u, ok := ctx.Value(currentUserKey).(*SessionUser)
 	return u, ok
 }
 
@@ -260,13 +287,38 @@ func (sm *SessionManager) LoadSessionUser(next http.Handler) http.Handler {
 			}
 		}
 
-		if isAuth, _ := sess.Values[isAuthKey].(bool); isAuth {
+		if isAuth, _ := sess.Values[isAuthKey].(bool); isAuth && sm.checkLifetime(w, r, sess) {
+			imp := sm.loadImpersonation(w, r, sess)
 			userID := getString(sess, userIDKey)
 			sessionToken := getString(sess, sessionTokenKey)
 
 			// If we have a UserFetcher, get fresh data from DB
 			if sm.userFetcher != nil && userID != "" {
 				u := sm.userFetcher.FetchUser(r.Context(), userID)
+				if u != nil && imp != nil {
+					// The admin must still be an active admin to keep impersonating
+					admin := sm.impersonator(r.Context(), imp)
+					if admin == nil {
+						sm.logger.Info("impersonation ended: admin not found, disabled, or no longer an admin",
+							zap.String("admin_id", imp.adminID),
+							zap.String("user_id", userID))
+						u = nil
+					} else if issuedBefore(sess, admin.SessionsValidAfter) {
+						sm.logger.Info("impersonation ended: admin's session was issued before a password or role change",
+							zap.String("admin_id", imp.adminID),
+							zap.String("user_id", userID))
+						u = nil
+					} else {
+						u.ImpersonatorID = admin.ID
+						u.ImpersonatorName = admin.Name
+						u.ImpersonationEnds = imp.ends
+					}
+				} else if u != nil && issuedBefore(sess, u.SessionsValidAfter) {
+					sm.logger.Info("session invalidated: issued before a password or role change",
+						zap.String("user_id", userID),
+						zap.String("path", r.URL.Path))
+					u = nil
+				}
 				if u != nil {
 					// User exists and is active - inject session token and inject into context
 					u.Token = sessionToken
@@ -278,6 +330,7 @@ func (sm *SessionManager) LoadSessionUser(next http.Handler) http.Handler {
 						zap.String("path", r.URL.Path))
 					sess.Values[isAuthKey] = false
 					delete(sess.Values, userIDKey)
+					clearImpersonation(sess)
 					_ = sess.Save(r, w) // Best effort to clear
 				}
 			} else if userID != "" {
@@ -289,6 +342,10 @@ func (sm *SessionManager) LoadSessionUser(next http.Handler) http.Handler {
 					Role:    getString(sess, userRole),
 					Token:   sessionToken,
 				}
+				if imp != nil {
+					u.ImpersonatorID = imp.adminID
+					u.ImpersonationEnds = imp.ends
+				}
 				r = withUser(r, u)
 			}
 		}
@@ -491,6 +548,11 @@ func (sm *SessionManager) CreateSession(w http.ResponseWriter, r *http.Request,
 	sess.Values[userIDKey] = userID.Hex()
 	sess.Values[userRole] = role
 	sess.Values[sessionTokenKey] = token
+	clearImpersonation(sess)
+	now := time.Now()
+	sm.startLifetime(sess, role, now)
+	sess.Values[authAtKey] = now.Unix()
+	sess.Values[issuedAtKey] = now.UnixMilli()
 
 	return sess.Save(r, w)
 }
@@ -525,6 +587,7 @@ func (sm *SessionManager) DestroySession(w http.ResponseWriter, r *http.Request)
 	delete(sess.Values, userName)
 	delete(sess.Values, userLoginID)
 	delete(sess.Values, userRole)
+	clearImpersonation(sess)
 
 	sess.Options.MaxAge = -1
 	_ = sess.Save(r, w)
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/normalize"
+	"github.com/gorilla/sessions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+/*─────────────────────────────────────────────────────────────────────────────*
+| Impersonation                                                               |
+*─────────────────────────────────────────────────────────────────────────────*/
+
+// While an admin impersonates a user, the session's user_id and user_role
+// belong to the impersonated user and the admin is remembered in these keys.
+// The session token is left alone, so session tracking and logout still
+// apply to the admin's own session.
+const (
+	impersonatorIDKey    = "impersonator_id"
+	impersonatorRoleKey  = "impersonator_role"
+	impersonationEndsKey = "impersonation_ends" // Unix seconds
+)
+
+// ErrNotImpersonating is returned by StopImpersonation when the session is
+// not impersonating anyone.
+var ErrNotImpersonating = errors.New("session is not impersonating a user")
+
+// ImpersonationHook is called when an impersonation ends because it expired.
+// adminID is the impersonating admin and userID the impersonated user.
+type ImpersonationHook func(r *http.Request, adminID, userID string)
+
+// SetImpersonationExpiredHook sets the callback run when LoadSessionUser
+// finds an expired impersonation and returns the session to the admin.
+func (sm *SessionManager) SetImpersonationExpiredHook(fn ImpersonationHook) {
+	sm.impersonationExpired = fn
+}
+
+// IsImpersonated reports whether an admin is acting as this user.
+func (u *SessionUser) IsImpersonated() bool {
+	return u.ImpersonatorID != ""
+}
+
+// StartImpersonation switches the session to act as the target user until
+// ttl elapses or StopImpersonation is called. admin is the signed-in user
+// starting the impersonation; callers check that they are allowed to.
+func (sm *SessionManager) StartImpersonation(w http.ResponseWriter, r *http.Request, admin *SessionUser, targetID primitive.ObjectID, targetRole string, ttl time.Duration) error {
+	sess, err := sm.store.Get(r, sm.name)
+	if err != nil {
+		return err
+	}
+
+	sess.Values[impersonatorIDKey] = admin.ID
+	sess.Values[impersonatorRoleKey] = admin.Role
+	sess.Values[impersonationEndsKey] = time.Now().Add(ttl).Unix()
+	sess.Values[userIDKey] = targetID.Hex()
+	sess.Values[userRole] = targetRole
+
+	return sess.Save(r, w)
+}
+
+// StopImpersonation returns the session to the impersonating admin and
+// returns the admin's user ID.
+func (sm *SessionManager) StopImpersonation(w http.ResponseWriter, r *http.Request) (string, error) {
+	sess, err := sm.store.Get(r, sm.name)
+	if err != nil {
+		return "", err
+	}
+
+	adminID := getString(sess, impersonatorIDKey)
+	if adminID == "" {
+		return "", ErrNotImpersonating
+	}
+	restoreImpersonator(sess)
+
+	return adminID, sess.Save(r, w)
+}
+
+// RequireNotImpersonating returns middleware that refuses requests made while
+// impersonating, for actions such as changing credentials that only the
+// account owner should take.
+func (sm *SessionManager) RequireNotImpersonating(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, ok := CurrentUser(r)
+		if !ok || !u.IsImpersonated() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Header.Get("HX-Request") == "true" {
+			w.Header().Set("HX-Refresh", "true")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if wantsHTML(r) && sm.forbiddenRenderer != nil {
+			sm.forbiddenRenderer(w, r, "This isn't available while you are impersonating another user.")
+			return
+		}
+
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+}
+
+// impersonation is the impersonation state read from a session.
+type impersonation struct {
+	adminID string
+	ends    time.Time
+}
+
+// loadImpersonation returns the session's impersonation state, or nil if it
+// isn't impersonating. An expired impersonation is ended here, before the
+// user is loaded, so the request runs as the admin.
+func (sm *SessionManager) loadImpersonation(w http.ResponseWriter, r *http.Request, sess *sessions.Session) *impersonation {
+	adminID := getString(sess, impersonatorIDKey)
+	if adminID == "" {
+		return nil
+	}
+
+	ends, _ := sess.Values[impersonationEndsKey].(int64)
+	imp := &impersonation{adminID: adminID, ends: time.Unix(ends, 0)}
+	if time.Now().Before(imp.ends) {
+		return imp
+	}
+
+	userID := getString(sess, userIDKey)
+	restoreImpersonator(sess)
+	_ = sess.Save(r, w) // Best effort; the expiry is checked again next request
+
+	sm.logger.Info("impersonation expired",
+		zap.String("admin_id", adminID),
+		zap.String("user_id", userID))
+	if sm.impersonationExpired != nil {
+		sm.impersonationExpired(r, adminID, userID)
+	}
+	return nil
+}
+
+// impersonator fetches the admin behind an impersonation. Returns nil if the
+// admin is no longer an active admin, in which case the session must end.
+func (sm *SessionManager) impersonator(ctx context.Context, imp *impersonation) *SessionUser {
+	admin := sm.userFetcher.FetchUser(ctx, imp.adminID)
+	if admin == nil || normalize.Role(admin.Role) != "admin" {
+		return nil
+	}
+	return admin
+}
+
+// restoreImpersonator puts the admin back as the session's user.
+func restoreImpersonator(sess *sessions.Session) {
+	sess.Values[userIDKey] = getString(sess, impersonatorIDKey)
+	sess.Values[userRole] = getString(sess, impersonatorRoleKey)
+	clearImpersonation(sess)
+}
+
+// clearImpersonation removes impersonation state from a session.
+func clearImpersonation(sess *sessions.Session) {
+	delete(sess.Values, impersonatorIDKey)
+	delete(sess.Values, impersonatorRoleKey)
+	delete(sess.Values, impersonationEndsKey)
+}
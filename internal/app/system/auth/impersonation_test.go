@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// fakeFetcher returns users from a map, keyed by ID.
+type fakeFetcher map[string]*SessionUser
+
+func (f fakeFetcher) FetchUser(ctx context.Context, userID string) *SessionUser {
+	u, ok := f[userID]
+	if !ok {
+		return nil
+	}
+	cp := *u
+	return &cp
+}
+
+type impersonationFixture struct {
+	sm      *SessionManager
+	admin   *SessionUser
+	target  *SessionUser
+	users   fakeFetcher
+	cookies []*http.Cookie
+}
+
+func newImpersonationFixture(t *testing.T) *impersonationFixture {
+	t.Helper()
+	sm, err := NewSessionManager("this-is-a-32-character-long-key!", "", "", time.Hour, false, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &impersonationFixture{
+		sm:     sm,
+		admin:  &SessionUser{ID: primitive.NewObjectID().Hex(), Name: "Ada Admin", Role: "admin"},
+		target: &SessionUser{ID: primitive.NewObjectID().Hex(), Name: "Dev User", Role: "developer"},
+	}
+	f.users = fakeFetcher{f.admin.ID: f.admin, f.target.ID: f.target}
+	sm.SetUserFetcher(f.users)
+
+	adminID, _ := primitive.ObjectIDFromHex(f.admin.ID)
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		if err := sm.CreateSession(w, r, adminID, "admin", "token-1"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return f
+}
+
+// do runs fn behind LoadSessionUser with the fixture's cookies, keeping any
+// cookie it sets.
+func (f *impersonationFixture) do(fn http.HandlerFunc) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range f.cookies {
+		req.AddCookie(c)
+	}
+	rr := httptest.NewRecorder()
+	f.sm.LoadSessionUser(fn).ServeHTTP(rr, req)
+	if set := rr.Result().Cookies(); len(set) > 0 {
+		f.cookies = set
+	}
+}
+
+// current returns the user LoadSessionUser puts in context.
+func (f *impersonationFixture) current() *SessionUser {
+	var u *SessionUser
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		u, _ = CurrentUser(r)
+	})
+	return u
+}
+
+func (f *impersonationFixture) start(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	targetID, _ := primitive.ObjectIDFromHex(f.target.ID)
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		admin, _ := CurrentUser(r)
+		if err := f.sm.StartImpersonation(w, r, admin, targetID, "developer", ttl); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestImpersonation_StartAndStop(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.start(t, time.Hour)
+
+	u := f.current()
+	if u == nil || u.ID != f.target.ID || u.Role != "developer" {
+		t.Fatalf("current user = %+v, want target", u)
+	}
+	if !u.IsImpersonated() || u.ImpersonatorID != f.admin.ID || u.ImpersonatorName != "Ada Admin" {
+		t.Errorf("impersonator = %q (%q), want admin", u.ImpersonatorID, u.ImpersonatorName)
+	}
+	if u.Token != "token-1" {
+		t.Errorf("token = %q, want the admin's session token kept", u.Token)
+	}
+
+	var adminID string
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if adminID, err = f.sm.StopImpersonation(w, r); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if adminID != f.admin.ID {
+		t.Errorf("StopImpersonation() = %q, want %q", adminID, f.admin.ID)
+	}
+	if u := f.current(); u == nil || u.ID != f.admin.ID || u.IsImpersonated() {
+		t.Errorf("after stop, current user = %+v, want admin", u)
+	}
+}
+
+func TestImpersonation_StopWhenNotImpersonating(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := f.sm.StopImpersonation(w, r); err != ErrNotImpersonating {
+			t.Errorf("StopImpersonation() error = %v, want ErrNotImpersonating", err)
+		}
+	})
+}
+
+func TestImpersonation_Expires(t *testing.T) {
+	f := newImpersonationFixture(t)
+	var hookAdmin, hookUser string
+	f.sm.SetImpersonationExpiredHook(func(r *http.Request, adminID, userID string) {
+		hookAdmin, hookUser = adminID, userID
+	})
+	f.start(t, -time.Second)
+
+	if u := f.current(); u == nil || u.ID != f.admin.ID || u.IsImpersonated() {
+		t.Fatalf("current user = %+v, want admin after expiry", u)
+	}
+	if hookAdmin != f.admin.ID || hookUser != f.target.ID {
+		t.Errorf("hook called with (%q, %q), want (admin, target)", hookAdmin, hookUser)
+	}
+}
+
+func TestImpersonation_EndsWhenAdminDemoted(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.start(t, time.Hour)
+
+	f.admin.Role = "developer"
+	if u := f.current(); u != nil {
+		t.Errorf("current user = %+v, want signed out", u)
+	}
+}
+
+func TestRequireNotImpersonating(t *testing.T) {
+	sm, _ := NewSessionManager("this-is-a-32-character-long-key!", "", "", time.Hour, false, zap.NewNop())
+	handler := sm.RequireNotImpersonating(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name string
+		user *SessionUser
+		want int
+	}{
+		{"owner", &SessionUser{ID: "u1", Role: "developer"}, http.StatusOK},
+		{"impersonated", &SessionUser{ID: "u1", Role: "developer", ImpersonatorID: "a1"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := WithTestUser(httptest.NewRequest(http.MethodPost, "/profile/password", nil), tt.user)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
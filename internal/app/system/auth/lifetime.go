@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"go.uber.org/zap"
+)
+
+/*─────────────────────────────────────────────────────────────────────────────*
+| Session lifetime                                                            |
+*─────────────────────────────────────────────────────────────────────────────*/
+
+// A session's limits are fixed when it is created, from the role the user
+// logged in with, and kept in the cookie. Changing a role's lifetime affects
+// new logins; existing sessions keep the limits they started with.
+const (
+	expiresAtKey   = "expires_at"   // Unix seconds; absolute end of the session
+	idleTimeoutKey = "idle_timeout" // Seconds without a request before the session ends (0 = none)
+	lastSeenKey    = "last_seen"    // Unix seconds of the last request
+)
+
+// lastSeenResolution is how stale last_seen may get before a request
+// re-saves the cookie, so every request doesn't send a new Set-Cookie.
+const lastSeenResolution = time.Minute
+
+// Session end reasons passed to a SessionExpiredHook. They match the
+// end_reason values recorded on tracked sessions.
+const (
+	EndReasonExpired  = "expired"  // Absolute lifetime reached
+	EndReasonInactive = "inactive" // Idle timeout reached
+)
+
+// SessionLifetime bounds how long a session lasts.
+type SessionLifetime struct {
+	Idle     time.Duration // End after this long without a request (0 = no idle limit)
+	Absolute time.Duration // End this long after login, however active
+}
+
+// SessionExpiredHook is called when LoadSessionUser ends a session that
+// outlived its lifetime. token is the session token of the tracked session.
+type SessionExpiredHook func(r *http.Request, userID, token, reason string)
+
+// SetSessionLifetimes sets the lifetime for sessions of each role. Roles not
+// in byRole use def; a zero Absolute in def keeps the session_max_age the
+// manager was created with.
+func (sm *SessionManager) SetSessionLifetimes(def SessionLifetime, byRole map[string]SessionLifetime) {
+	sm.lifetimeMu.Lock()
+	if def.Absolute <= 0 {
+		def.Absolute = sm.lifetime.Absolute
+	}
+	sm.lifetime = def
+	sm.roleLifetimes = byRole
+	sm.lifetimeMu.Unlock()
+
+	// The cookie and its signature must outlast the longest session; each
+	// session's own limits are enforced from the values it carries
+	longest := def.Absolute
+	for _, l := range byRole {
+		if l.Absolute > longest {
+			longest = l.Absolute
+		}
+	}
+	sm.store.Options.MaxAge = int(longest.Seconds())
+	for _, c := range sm.store.Codecs {
+		if sc, ok := c.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(int(longest.Seconds()))
+		}
+	}
+}
+
+// SetSessionExpiredHook sets the callback run when a session ends because it
+// reached its idle or absolute limit.
+func (sm *SessionManager) SetSessionExpiredHook(fn SessionExpiredHook) {
+	sm.sessionExpired = fn
+}
+
+// SetIdleTimeouts changes the idle timeout of new sessions, leaving the
+// absolute lifetimes as they are. Roles not in byRole use def. Unlike
+// SetSessionLifetimes it is safe to call while requests are being served.
+func (sm *SessionManager) SetIdleTimeouts(def time.Duration, byRole map[string]time.Duration) {
+	sm.lifetimeMu.Lock()
+	defer sm.lifetimeMu.Unlock()
+
+	sm.lifetime.Idle = def
+	roles := make(map[string]SessionLifetime, len(sm.roleLifetimes))
+	for role, l := range sm.roleLifetimes {
+		l.Idle = def
+		if d, ok := byRole[role]; ok {
+			l.Idle = d
+		}
+		roles[role] = l
+	}
+	sm.roleLifetimes = roles
+}
+
+// Lifetime returns the session lifetime for role.
+func (sm *SessionManager) Lifetime(role string) SessionLifetime {
+	sm.lifetimeMu.RLock()
+	defer sm.lifetimeMu.RUnlock()
+	if l, ok := sm.roleLifetimes[role]; ok && l.Absolute > 0 {
+		return l
+	}
+	return sm.lifetime
+}
+
+// startLifetime records the lifetime limits for a new session and sizes the
+// cookie to match.
+func (sm *SessionManager) startLifetime(sess *sessions.Session, role string, now time.Time) {
+	l := sm.Lifetime(role)
+	sess.Values[expiresAtKey] = now.Add(l.Absolute).Unix()
+	sess.Values[idleTimeoutKey] = int64(l.Idle.Seconds())
+	sess.Values[lastSeenKey] = now.Unix()
+
+	opts := *sess.Options
+	opts.MaxAge = int(l.Absolute.Seconds())
+	sess.Options = &opts
+}
+
+// checkLifetime ends the session if it has passed its absolute or idle limit
+// and reports whether it is still live. Otherwise it records the request as
+// activity. Sessions created before lifetimes were tracked start theirs now.
+func (sm *SessionManager) checkLifetime(w http.ResponseWriter, r *http.Request, sess *sessions.Session) bool {
+	now := time.Now()
+
+	expiresAt, ok := sess.Values[expiresAtKey].(int64)
+	if !ok {
+		role := getString(sess, impersonatorRoleKey)
+		if role == "" {
+			role = getString(sess, userRole)
+		}
+		sm.startLifetime(sess, role, now)
+		_ = sess.Save(r, w)
+		return true
+	}
+
+	reason := ""
+	idle, _ := sess.Values[idleTimeoutKey].(int64)
+	lastSeen, _ := sess.Values[lastSeenKey].(int64)
+	switch {
+	case now.Unix() >= expiresAt:
+		reason = EndReasonExpired
+	case idle > 0 && now.Sub(time.Unix(lastSeen, 0)) > time.Duration(idle)*time.Second:
+		reason = EndReasonInactive
+	}
+
+	if reason == "" {
+		if now.Sub(time.Unix(lastSeen, 0)) >= lastSeenResolution {
+			sess.Values[lastSeenKey] = now.Unix()
+			_ = sess.Save(r, w)
+		}
+		return true
+	}
+
+	// Report the person who logged in, not an impersonated user
+	userID := getString(sess, impersonatorIDKey)
+	if userID == "" {
+		userID = getString(sess, userIDKey)
+	}
+	sm.logger.Info("session ended: lifetime exceeded",
+		zap.String("user_id", userID),
+		zap.String("reason", reason),
+		zap.String("path", r.URL.Path))
+	if sm.sessionExpired != nil {
+		sm.sessionExpired(r, userID, getString(sess, sessionTokenKey), reason)
+	}
+
+	sess.Values[isAuthKey] = false
+	delete(sess.Values, userIDKey)
+	clearImpersonation(sess)
+	_ = sess.Save(r, w)
+	return false
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// edit changes the fixture's session values directly, without running
+// LoadSessionUser.
+func (f *impersonationFixture) edit(t *testing.T, fn func(values map[any]any)) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range f.cookies {
+		req.AddCookie(c)
+	}
+	sess, err := f.sm.store.Get(req, f.sm.name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn(sess.Values)
+	rr := httptest.NewRecorder()
+	if err := sessions.Save(req, rr); err != nil {
+		t.Fatal(err)
+	}
+	f.cookies = rr.Result().Cookies()
+}
+
+func TestLifetime_PerRole(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.sm.SetSessionLifetimes(SessionLifetime{Absolute: 24 * time.Hour}, map[string]SessionLifetime{
+		"admin": {Absolute: 2 * time.Hour, Idle: 15 * time.Minute},
+	})
+
+	if got := f.sm.Lifetime("admin"); got.Absolute != 2*time.Hour || got.Idle != 15*time.Minute {
+		t.Errorf("Lifetime(admin) = %+v", got)
+	}
+	if got := f.sm.Lifetime("developer"); got.Absolute != 24*time.Hour || got.Idle != 0 {
+		t.Errorf("Lifetime(developer) = %+v, want the default", got)
+	}
+
+	adminID, _ := primitive.ObjectIDFromHex(f.admin.ID)
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		if err := f.sm.CreateSession(w, r, adminID, "admin", "token-2"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if f.cookies[0].MaxAge != int((2 * time.Hour).Seconds()) {
+		t.Errorf("cookie MaxAge = %d, want the admin lifetime", f.cookies[0].MaxAge)
+	}
+
+	f.edit(t, func(v map[any]any) {
+		if exp := v[expiresAtKey].(int64); exp > time.Now().Add(2*time.Hour).Unix() {
+			t.Errorf("expires_at = %d, more than 2h away", exp)
+		}
+		if idle := v[idleTimeoutKey].(int64); idle != 15*60 {
+			t.Errorf("idle_timeout = %d, want 900", idle)
+		}
+	})
+	if u := f.current(); u == nil || u.ID != f.admin.ID {
+		t.Fatalf("current user = %+v, want admin", u)
+	}
+}
+
+func TestLifetime_AbsoluteExpiry(t *testing.T) {
+	f := newImpersonationFixture(t)
+	var hookUser, hookToken, hookReason string
+	f.sm.SetSessionExpiredHook(func(r *http.Request, userID, token, reason string) {
+		hookUser, hookToken, hookReason = userID, token, reason
+	})
+
+	f.edit(t, func(v map[any]any) {
+		v[expiresAtKey] = time.Now().Add(-time.Second).Unix()
+	})
+
+	if u := f.current(); u != nil {
+		t.Fatalf("current user = %+v, want none after expiry", u)
+	}
+	if hookUser != f.admin.ID || hookToken != "token-1" || hookReason != EndReasonExpired {
+		t.Errorf("hook called with (%q, %q, %q)", hookUser, hookToken, hookReason)
+	}
+}
+
+func TestLifetime_IdleExpiry(t *testing.T) {
+	f := newImpersonationFixture(t)
+	var hookReason string
+	f.sm.SetSessionExpiredHook(func(r *http.Request, userID, token, reason string) {
+		hookReason = reason
+	})
+
+	f.edit(t, func(v map[any]any) {
+		v[idleTimeoutKey] = int64(600)
+		v[lastSeenKey] = time.Now().Add(-11 * time.Minute).Unix()
+	})
+
+	if u := f.current(); u != nil {
+		t.Fatalf("current user = %+v, want none after idle timeout", u)
+	}
+	if hookReason != EndReasonInactive {
+		t.Errorf("reason = %q, want %q", hookReason, EndReasonInactive)
+	}
+}
+
+func TestLifetime_ActivityRefreshesLastSeen(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.edit(t, func(v map[any]any) {
+		v[idleTimeoutKey] = int64(600)
+		v[lastSeenKey] = time.Now().Add(-9 * time.Minute).Unix()
+	})
+
+	if u := f.current(); u == nil {
+		t.Fatal("session ended before its idle timeout")
+	}
+	f.edit(t, func(v map[any]any) {
+		if seen := v[lastSeenKey].(int64); time.Since(time.Unix(seen, 0)) > time.Minute {
+			t.Errorf("last_seen not refreshed: %v ago", time.Since(time.Unix(seen, 0)))
+		}
+	})
+}
+
+func TestLifetime_LegacySessionStartsNow(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.edit(t, func(v map[any]any) {
+		delete(v, expiresAtKey)
+		delete(v, idleTimeoutKey)
+		delete(v, lastSeenKey)
+	})
+
+	if u := f.current(); u == nil || u.ID != f.admin.ID {
+		t.Fatalf("current user = %+v, want admin", u)
+	}
+	f.edit(t, func(v map[any]any) {
+		if _, ok := v[expiresAtKey].(int64); !ok {
+			t.Error("expires_at not recorded for a session created before lifetimes")
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+/*─────────────────────────────────────────────────────────────────────────────*
+| Session rotation                                                            |
+*─────────────────────────────────────────────────────────────────────────────*/
+
+// issuedAtKey records when the session token was issued: at login, and each
+// time the session is rotated. LoadSessionUser rejects sessions issued before
+// the user's SessionsValidAfter, so a token copied before a password or role
+// change stops working after it.
+const issuedAtKey = "issued_at" // Unix milliseconds
+
+// RotateSession gives the current session a new token, issued at issuedAt,
+// keeping the user signed in. Pass the same issuedAt used to invalidate the
+// user's earlier sessions so this one stays valid.
+func (sm *SessionManager) RotateSession(w http.ResponseWriter, r *http.Request, token string, issuedAt time.Time) error {
+	sess, err := sm.store.Get(r, sm.name)
+	if err != nil {
+		return err
+	}
+	sess.Values[sessionTokenKey] = token
+	sess.Values[issuedAtKey] = issuedAt.UnixMilli()
+	return sess.Save(r, w)
+}
+
+// issuedBefore reports whether sess was issued before validAfter. Sessions
+// from before issue times were recorded count as issued at the epoch.
+func issuedBefore(sess *sessions.Session, validAfter time.Time) bool {
+	if validAfter.IsZero() {
+		return false
+	}
+	issuedAt, _ := sess.Values[issuedAtKey].(int64)
+	return issuedAt < validAfter.UnixMilli()
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRotateSession_InvalidatesEarlierSessions(t *testing.T) {
+	f := newImpersonationFixture(t)
+	stolen := f.cookies
+
+	// A password change invalidates sessions issued before it and rotates
+	// the current one
+	changedAt := time.Now().Add(time.Second)
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		f.admin.SessionsValidAfter = changedAt
+		if err := f.sm.RotateSession(w, r, "token-2", changedAt); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	u := f.current()
+	if u == nil || u.ID != f.admin.ID {
+		t.Fatalf("current user = %+v, want admin kept signed in", u)
+	}
+	if u.Token != "token-2" {
+		t.Errorf("token = %q, want rotated token", u.Token)
+	}
+
+	f.cookies = stolen
+	if u := f.current(); u != nil {
+		t.Errorf("current user with pre-change cookie = %+v, want signed out", u)
+	}
+}
+
+func TestRotateSession_ImpersonationEndsWhenAdminInvalidated(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.start(t, time.Hour)
+
+	f.admin.SessionsValidAfter = time.Now().Add(time.Second)
+	if u := f.current(); u != nil {
+		t.Errorf("current user = %+v, want signed out", u)
+	}
+}
+
+func TestIssuedBefore_NoValidAfter(t *testing.T) {
+	f := newImpersonationFixture(t)
+	if u := f.current(); u == nil {
+		t.Fatal("session without SessionsValidAfter should stay valid")
+	}
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newSignedRequest(secret string, ts int64, path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(SignatureTimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, SignRequest(secret, ts, http.MethodPost, path, []byte(body)))
+	return req
+}
+
+func TestNewSignatureVerifier_EmptySecret(t *testing.T) {
+	if v := NewSignatureVerifier("", time.Minute); v != nil {
+		t.Error("NewSignatureVerifier(\"\") should return nil")
+	}
+}
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	const secret = "test-signing-secret"
+	now := time.Unix(1700000000, 0)
+	v := NewSignatureVerifier(secret, 5*time.Minute)
+	v.now = func() time.Time { return now }
+
+	t.Run("valid signature", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Unix(), "/api/state/save", `{"game":"g1"}`)
+		if err := v.Verify(nil, req); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != `{"game":"g1"}` {
+			t.Errorf("body not restored, got %q", body)
+		}
+	})
+
+	t.Run("replayed signature", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Unix(), "/api/state/save", `{"game":"g1"}`)
+		if err := v.Verify(nil, req); err != ErrSignatureReplayed {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureReplayed)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		req := newSignedRequest("other-secret", now.Unix(), "/api/state/save", `{"game":"g2"}`)
+		if err := v.Verify(nil, req); err != ErrSignatureInvalid {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureInvalid)
+		}
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Unix(), "/api/state/save", `{"game":"g3"}`)
+		req.Body = io.NopCloser(strings.NewReader(`{"game":"evil"}`))
+		if err := v.Verify(nil, req); err != ErrSignatureInvalid {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureInvalid)
+		}
+	})
+
+	t.Run("different path", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Unix(), "/api/state/load", `{"game":"g4"}`)
+		req.URL.Path = "/api/state/save"
+		if err := v.Verify(nil, req); err != ErrSignatureInvalid {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureInvalid)
+		}
+	})
+
+	t.Run("expired timestamp", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Add(-10*time.Minute).Unix(), "/api/state/save", `{}`)
+		if err := v.Verify(nil, req); err != ErrSignatureExpired {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureExpired)
+		}
+	})
+
+	t.Run("missing timestamp", func(t *testing.T) {
+		req := newSignedRequest(secret, now.Unix(), "/api/state/save", `{}`)
+		req.Header.Del(SignatureTimestampHeader)
+		if err := v.Verify(nil, req); err != ErrSignatureTimestamp {
+			t.Errorf("Verify() error = %v, want %v", err, ErrSignatureTimestamp)
+		}
+	})
+}
+
+func TestAPIKeyAuthWithConfig_Signature(t *testing.T) {
+	const secret = "test-signing-secret"
+	logger := zap.NewNop()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("signed request accepted", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{Signature: NewSignatureVerifier(secret, time.Minute)}, logger)
+		req := newSignedRequest(secret, time.Now().Unix(), "/api/state/save", `{}`)
+		rec := httptest.NewRecorder()
+		mw(ok).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status = %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("body over the limit", func(t *testing.T) {
+		v := NewSignatureVerifier(secret, time.Minute)
+		v.SetMaxBody(16)
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{Signature: v}, logger)
+		req := newSignedRequest(secret, time.Now().Unix(), "/api/state/save", `{"save_data":"more than sixteen bytes"}`)
+		rec := httptest.NewRecorder()
+		mw(ok).ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("signing not enabled", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: "key"}, logger)
+		req := newSignedRequest(secret, time.Now().Unix(), "/api/state/save", `{}`)
+		rec := httptest.NewRecorder()
+		mw(ok).ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Status = %d, want %d", rec.Code, http.StatusUnauthorized)
+		}
+	})
+
+	t.Run("bearer key still accepted", func(t *testing.T) {
+		mw := APIKeyAuthWithConfig(APIKeyAuthConfig{StaticKey: "key", Signature: NewSignatureVerifier(secret, time.Minute)}, logger)
+		req := httptest.NewRequest(http.MethodPost, "/api/state/save", nil)
+		req.Header.Set("Authorization", "Bearer key")
+		rec := httptest.NewRecorder()
+		mw(ok).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Status = %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+/*─────────────────────────────────────────────────────────────────────────────*
+| Step-up re-authentication                                                   |
+*─────────────────────────────────────────────────────────────────────────────*/
+
+// authAtKey records when the user last proved who they are: at login, and
+// again each time they confirm their identity on the reauth page.
+const authAtKey = "auth_at" // Unix seconds
+
+// ReauthPath is the page where a signed-in user confirms their identity.
+const ReauthPath = "/reauth"
+
+// SetReauthWindow sets how recently a user must have authenticated for
+// RequireRecentAuth to let a request through. Zero turns the check off.
+func (sm *SessionManager) SetReauthWindow(d time.Duration) {
+	sm.reauthWindow = d
+}
+
+// ReauthWindow returns the window set by SetReauthWindow.
+func (sm *SessionManager) ReauthWindow() time.Duration {
+	return sm.reauthWindow
+}
+
+// MarkReauthenticated records that the signed-in user has just confirmed
+// their identity.
+func (sm *SessionManager) MarkReauthenticated(w http.ResponseWriter, r *http.Request) error {
+	sess, err := sm.store.Get(r, sm.name)
+	if err != nil {
+		return err
+	}
+	sess.Values[authAtKey] = time.Now().Unix()
+	return sess.Save(r, w)
+}
+
+// RecentlyAuthenticated reports whether the user behind r authenticated
+// within the reauth window.
+func (sm *SessionManager) RecentlyAuthenticated(r *http.Request) bool {
+	if sm.reauthWindow <= 0 {
+		return true
+	}
+	sess, err := sm.store.Get(r, sm.name)
+	if err != nil {
+		return false
+	}
+	authAt, ok := sess.Values[authAtKey].(int64)
+	if !ok {
+		return false
+	}
+	return time.Since(time.Unix(authAt, 0)) <= sm.reauthWindow
+}
+
+// RequireRecentAuth returns middleware for sensitive actions that sends the
+// user to confirm their identity unless they authenticated within the reauth
+// window. Use it after RequireRole or RequireSignedIn.
+//
+// A form post can't be replayed after the detour, so for anything but a GET
+// the user is returned to the page the form was on and submits it again.
+func (sm *SessionManager) RequireRecentAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sm.RecentlyAuthenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		target := ReauthPath + "?return=" + url.QueryEscape(reauthReturn(r))
+
+		if r.Header.Get("HX-Request") == "true" {
+			w.Header().Set("HX-Redirect", target)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if wantsHTML(r) {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+
+		http.Error(w, "reauthentication required", http.StatusUnauthorized)
+	})
+}
+
+// reauthReturn picks where to send the user once they have confirmed their
+// identity: the requested page for a full-page GET, otherwise the same-site
+// page the request came from.
+func reauthReturn(r *http.Request) string {
+	if r.Method == http.MethodGet && r.Header.Get("HX-Request") != "true" {
+		return currentURI(r)
+	}
+	from := r.Header.Get("HX-Current-URL")
+	if from == "" {
+		from = r.Referer()
+	}
+	if u, err := url.Parse(from); err == nil && u.Host == r.Host && u.Path != "" {
+		return u.RequestURI()
+	}
+	return "/"
+}
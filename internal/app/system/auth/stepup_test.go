@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// guarded runs a request to a RequireRecentAuth-protected handler with the
+// fixture's cookies and reports whether the handler ran.
+func (f *impersonationFixture) guarded(req *http.Request) (*httptest.ResponseRecorder, bool) {
+	for _, c := range f.cookies {
+		req.AddCookie(c)
+	}
+	ran := false
+	rr := httptest.NewRecorder()
+	f.sm.RequireRecentAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ran = true
+	})).ServeHTTP(rr, req)
+	return rr, ran
+}
+
+func TestRequireRecentAuth_FreshLogin(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.sm.SetReauthWindow(10 * time.Minute)
+
+	if _, ran := f.guarded(httptest.NewRequest(http.MethodPost, "/users/1/delete", nil)); !ran {
+		t.Error("a session just logged in should pass")
+	}
+}
+
+func TestRequireRecentAuth_Stale(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.sm.SetReauthWindow(10 * time.Minute)
+	f.edit(t, func(v map[any]any) {
+		v[authAtKey] = time.Now().Add(-11 * time.Minute).Unix()
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/users/1/delete", nil)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Referer", "http://example.com/users/1?tab=info")
+	rr, ran := f.guarded(req)
+	if ran {
+		t.Fatal("handler ran with a stale authentication")
+	}
+	want := ReauthPath + "?return=" + url.QueryEscape("/users/1?tab=info")
+	if rr.Code != http.StatusSeeOther || rr.Header().Get("Location") != want {
+		t.Errorf("got %d %q, want redirect to %q", rr.Code, rr.Header().Get("Location"), want)
+	}
+
+	// HTMX requests return to the page they were made from
+	req = httptest.NewRequest(http.MethodPost, "http://example.com/users/1/delete", nil)
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("HX-Current-URL", "http://example.com/users")
+	rr, _ = f.guarded(req)
+	if got := rr.Header().Get("HX-Redirect"); got != ReauthPath+"?return=%2Fusers" {
+		t.Errorf("HX-Redirect = %q", got)
+	}
+
+	// API callers get a plain 401
+	rr, _ = f.guarded(httptest.NewRequest(http.MethodPost, "/api/keys", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("API status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireRecentAuth_MarkReauthenticated(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.sm.SetReauthWindow(10 * time.Minute)
+	f.edit(t, func(v map[any]any) {
+		delete(v, authAtKey)
+	})
+
+	if _, ran := f.guarded(httptest.NewRequest(http.MethodGet, "/settings", nil)); ran {
+		t.Fatal("a session with no recorded authentication time should not pass")
+	}
+
+	f.do(func(w http.ResponseWriter, r *http.Request) {
+		if err := f.sm.MarkReauthenticated(w, r); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if _, ran := f.guarded(httptest.NewRequest(http.MethodGet, "/settings", nil)); !ran {
+		t.Error("handler did not run after confirming identity")
+	}
+}
+
+func TestRequireRecentAuth_Disabled(t *testing.T) {
+	f := newImpersonationFixture(t)
+	f.edit(t, func(v map[any]any) {
+		delete(v, authAtKey)
+	})
+
+	if _, ran := f.guarded(httptest.NewRequest(http.MethodGet, "/settings", nil)); !ran {
+		t.Error("a zero reauth window should not ask for confirmation")
+	}
+}
@@ -20,6 +20,7 @@ var (
 	ErrPasswordTooShort = errors.New("Password must be at least 6 characters.")
 	ErrPasswordTooLong  = errors.New("Password must be less than 128 characters.")
 	ErrPasswordCommon   = errors.New("This password is too common. Please choose a different one.")
+	ErrPasswordBreached = errors.New("This password has appeared in a data breach, so it isn't safe to use. Please choose a different one.")
 )
 
 // commonPasswords is a list of very common passwords that are blocked.
@@ -0,0 +1,179 @@
+// Package captcha verifies CAPTCHA challenges from hCaptcha, Google
+// reCAPTCHA (v2 checkbox), or Cloudflare Turnstile.
+//
+// All three providers share the same shape: the page loads the provider's
+// script and a widget element carrying the site key, the widget adds a
+// response token to the form, and the server posts that token with its
+// secret key to the provider's siteverify endpoint.
+//
+// Verification fails closed. A request whose token can't be checked is
+// rejected, since an attacker could otherwise skip the challenge simply by
+// leaving the token out.
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"go.uber.org/zap"
+)
+
+// Supported providers.
+const (
+	HCaptcha  = "hcaptcha"
+	ReCaptcha = "recaptcha"
+	Turnstile = "turnstile"
+)
+
+// provider describes how one CAPTCHA service is rendered and verified.
+type provider struct {
+	scriptURL     string // Script that renders the widget
+	widgetClass   string // Class the script looks for on the widget element
+	responseField string // Form field the widget fills with its token
+	verifyURL     string // siteverify endpoint
+}
+
+var providers = map[string]provider{
+	HCaptcha: {
+		scriptURL:     "https://js.hcaptcha.com/1/api.js",
+		widgetClass:   "h-captcha",
+		responseField: "h-captcha-response",
+		verifyURL:     "https://api.hcaptcha.com/siteverify",
+	},
+	ReCaptcha: {
+		scriptURL:     "https://www.google.com/recaptcha/api.js",
+		widgetClass:   "g-recaptcha",
+		responseField: "g-recaptcha-response",
+		verifyURL:     "https://www.google.com/recaptcha/api/siteverify",
+	},
+	Turnstile: {
+		scriptURL:     "https://challenges.cloudflare.com/turnstile/v0/api.js",
+		widgetClass:   "cf-turnstile",
+		responseField: "cf-turnstile-response",
+		verifyURL:     "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	},
+}
+
+// ValidProvider reports whether name is a supported provider.
+func ValidProvider(name string) bool {
+	_, ok := providers[name]
+	return ok
+}
+
+// Widget holds what a template needs to render the challenge. See the
+// "captcha" component in components.gohtml.
+type Widget struct {
+	ScriptURL string
+	Class     string
+	SiteKey   string
+}
+
+// Verifier checks CAPTCHA responses. A nil Verifier is valid: it renders no
+// widget and accepts every request.
+type Verifier struct {
+	provider provider
+	siteKey  string
+	secret   string
+	client   *http.Client
+	log      *zap.Logger
+}
+
+// New creates a Verifier for the named provider. It returns nil (CAPTCHA
+// disabled) when name is empty.
+func New(name, siteKey, secret string, timeout time.Duration, logger *zap.Logger) (*Verifier, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown captcha provider %q", name)
+	}
+	if siteKey == "" || secret == "" {
+		return nil, fmt.Errorf("captcha provider %q needs both a site key and a secret key", name)
+	}
+	return &Verifier{
+		provider: p,
+		siteKey:  siteKey,
+		secret:   secret,
+		client:   &http.Client{Timeout: timeout},
+		log:      logger,
+	}, nil
+}
+
+// Widget returns the data for rendering the challenge, or nil when CAPTCHA
+// is disabled.
+func (v *Verifier) Widget() *Widget {
+	if v == nil {
+		return nil
+	}
+	return &Widget{
+		ScriptURL: v.provider.scriptURL,
+		Class:     v.provider.widgetClass,
+		SiteKey:   v.siteKey,
+	}
+}
+
+// Verify reports whether the form posted in r carries a valid CAPTCHA
+// response. The form must already be parsed.
+func (v *Verifier) Verify(r *http.Request) bool {
+	if v == nil {
+		return true
+	}
+
+	token := strings.TrimSpace(r.PostFormValue(v.provider.responseField))
+	if token == "" {
+		return false
+	}
+
+	ok, err := v.siteverify(r.Context(), token, network.GetClientIP(r))
+	if err != nil {
+		v.log.Warn("captcha verification failed; rejecting request", zap.Error(err))
+		return false
+	}
+	return ok
+}
+
+// siteverify posts the token to the provider and returns its verdict.
+func (v *Verifier) siteverify(ctx context.Context, token, remoteIP string) (bool, error) {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.provider.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("siteverify returned %s", resp.Status)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("decode siteverify response: %w", err)
+	}
+	if !result.Success && len(result.ErrorCodes) > 0 {
+		v.log.Debug("captcha rejected", zap.Strings("error_codes", result.ErrorCodes))
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,123 @@
+package captcha
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestVerifier(t *testing.T, name string, handler http.HandlerFunc) *Verifier {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	v, err := New(name, "site-key", "secret-key", time.Second, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	v.provider.verifyURL = srv.URL
+	return v
+}
+
+func formRequest(field, token string) *http.Request {
+	form := url.Values{field: {token}}
+	req := httptest.NewRequest(http.MethodPost, "/login/password", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.ParseForm()
+	return req
+}
+
+func TestNew(t *testing.T) {
+	if v, err := New("", "", "", time.Second, zap.NewNop()); v != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v; want nil, nil", v, err)
+	}
+	if _, err := New("captchaco", "k", "s", time.Second, zap.NewNop()); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if _, err := New(Turnstile, "k", "", time.Second, zap.NewNop()); err == nil {
+		t.Error("expected error for missing secret")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		provider string
+		field    string
+	}{
+		{HCaptcha, "h-captcha-response"},
+		{ReCaptcha, "g-recaptcha-response"},
+		{Turnstile, "cf-turnstile-response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			var got url.Values
+			v := newTestVerifier(t, tt.provider, func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				got = r.PostForm
+				w.Write([]byte(`{"success": true}`))
+			})
+
+			if !v.Verify(formRequest(tt.field, "tok")) {
+				t.Fatal("Verify() = false, want true")
+			}
+			if got.Get("secret") != "secret-key" || got.Get("response") != "tok" || got.Get("remoteip") != "203.0.113.7" {
+				t.Errorf("siteverify form = %v", got)
+			}
+		})
+	}
+}
+
+func TestVerify_Rejected(t *testing.T) {
+	v := newTestVerifier(t, HCaptcha, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	})
+	if v.Verify(formRequest("h-captcha-response", "bad")) {
+		t.Error("Verify() = true for a rejected token")
+	}
+}
+
+func TestVerify_MissingToken(t *testing.T) {
+	called := false
+	v := newTestVerifier(t, Turnstile, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	if v.Verify(formRequest("other", "tok")) {
+		t.Error("Verify() = true with no token")
+	}
+	if called {
+		t.Error("siteverify should not be called without a token")
+	}
+}
+
+func TestVerify_FailsClosed(t *testing.T) {
+	v := newTestVerifier(t, ReCaptcha, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	if v.Verify(formRequest("g-recaptcha-response", "tok")) {
+		t.Error("Verify() = true on a server error, want false")
+	}
+}
+
+func TestNilVerifier(t *testing.T) {
+	var v *Verifier
+	if !v.Verify(formRequest("h-captcha-response", "")) {
+		t.Error("nil Verifier should accept every request")
+	}
+	if v.Widget() != nil {
+		t.Error("nil Verifier should render no widget")
+	}
+}
+
+func TestWidget(t *testing.T) {
+	v, _ := New(Turnstile, "site-key", "secret-key", time.Second, zap.NewNop())
+	w := v.Widget()
+	if w.Class != "cf-turnstile" || w.SiteKey != "site-key" || !strings.HasPrefix(w.ScriptURL, "https://challenges.cloudflare.com/") {
+		t.Errorf("Widget() = %+v", w)
+	}
+}
@@ -0,0 +1,244 @@
+// Package configreload re-reads configuration while the server runs and
+// applies the values that can change without a restart.
+//
+// Only the keys named as reloadable take effect; any other key that
+// changed is reported as needing a restart and keeps its running value,
+// so a reload never leaves the server half on old and half on new
+// settings for things like database or session keys.
+package configreload
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dalemusser/waffle/config"
+	"go.uber.org/zap"
+)
+
+// Triggers recorded on a Result.
+const (
+	TriggerAdmin  = "admin"
+	TriggerSignal = "signal"
+)
+
+// ErrUnavailable is returned by a nil Reloader.
+var ErrUnavailable = errors.New("configuration reload is not available")
+
+// Change describes one config key whose value changed on reload.
+type Change struct {
+	Key string
+	Old string // empty for secrets
+	New string // empty for secrets
+}
+
+// String returns the change for display, such as
+// "rate_limit_login_attempts: 5 → 10".
+func (c Change) String() string {
+	if c.Old == "" && c.New == "" {
+		return c.Key + " (value hidden)"
+	}
+	return c.Key + ": " + c.Old + " → " + c.New
+}
+
+// Result describes one reload.
+type Result struct {
+	At            time.Time
+	Trigger       string   // TriggerAdmin or TriggerSignal
+	Applied       []Change // reloadable keys now in effect
+	RestartNeeded []Change // changed keys that take effect only after a restart
+	Error         string   // why the config could not be read, if it could not
+}
+
+// Reloader re-reads config and applies the reloadable values.
+type Reloader struct {
+	reloadable map[string]bool
+	load       func() (config.AppConfigValues, error)
+	apply      func(config.AppConfigValues)
+	logger     *zap.Logger
+
+	mu      sync.Mutex
+	current config.AppConfigValues
+	last    *Result
+	signals chan os.Signal
+}
+
+// New creates a Reloader. current is the config the server started with,
+// load reads the config again, and apply puts new values into effect;
+// apply receives the running config with only the reloadable keys
+// changed.
+func New(current config.AppConfigValues, reloadable []string, load func() (config.AppConfigValues, error), apply func(config.AppConfigValues), logger *zap.Logger) *Reloader {
+	keys := make(map[string]bool, len(reloadable))
+	for _, k := range reloadable {
+		keys[k] = true
+	}
+	return &Reloader{
+		reloadable: keys,
+		load:       load,
+		apply:      apply,
+		logger:     logger,
+		current:    current,
+	}
+}
+
+// Reloadable returns the keys that a reload applies, sorted.
+func (rl *Reloader) Reloadable() []string {
+	if rl == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(rl.reloadable))
+	for k := range rl.reloadable {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reload reads the config again and applies the reloadable keys that
+// changed. The Result is also kept for Last.
+func (rl *Reloader) Reload(trigger string) (Result, error) {
+	if rl == nil {
+		return Result{}, ErrUnavailable
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	res := Result{At: time.Now(), Trigger: trigger}
+	fresh, err := rl.load()
+	if err != nil {
+		res.Error = err.Error()
+		rl.last = &res
+		rl.logger.Warn("config reload failed", zap.String("trigger", trigger), zap.Error(err))
+		return res, err
+	}
+
+	merged := make(config.AppConfigValues, len(rl.current))
+	for k, v := range rl.current {
+		merged[k] = v
+	}
+	for _, k := range changedKeys(rl.current, fresh) {
+		c := change(k, rl.current[k], fresh[k])
+		if rl.reloadable[k] {
+			merged[k] = fresh[k]
+			res.Applied = append(res.Applied, c)
+		} else {
+			res.RestartNeeded = append(res.RestartNeeded, c)
+		}
+	}
+
+	if len(res.Applied) > 0 {
+		rl.apply(merged)
+		rl.current = merged
+	}
+	rl.last = &res
+
+	rl.logger.Info("config reloaded",
+		zap.String("trigger", trigger),
+		zap.Strings("applied", changeKeys(res.Applied)),
+		zap.Strings("restart_needed", changeKeys(res.RestartNeeded)))
+	return res, nil
+}
+
+// Last returns the most recent reload, or nil if there has been none.
+func (rl *Reloader) Last() *Result {
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.last == nil {
+		return nil
+	}
+	res := *rl.last
+	return &res
+}
+
+// WatchSignals reloads the config each time the process receives SIGHUP,
+// until Stop is called.
+func (rl *Reloader) WatchSignals() {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.signals != nil {
+		return
+	}
+	rl.signals = make(chan os.Signal, 1)
+	signal.Notify(rl.signals, syscall.SIGHUP)
+	go func(ch chan os.Signal) {
+		for range ch {
+			_, _ = rl.Reload(TriggerSignal)
+		}
+	}(rl.signals)
+}
+
+// Stop stops watching for SIGHUP.
+func (rl *Reloader) Stop() {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.signals != nil {
+		signal.Stop(rl.signals)
+		close(rl.signals)
+		rl.signals = nil
+	}
+}
+
+// changedKeys returns the keys whose values differ between old and new,
+// sorted.
+func changedKeys(old, new config.AppConfigValues) []string {
+	var keys []string
+	for k, v := range new {
+		if fmt.Sprint(v) != fmt.Sprint(old[k]) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// change describes a changed key, hiding the values of secrets.
+func change(key string, old, new any) Change {
+	if isSecret(key) {
+		return Change{Key: key}
+	}
+	return Change{Key: key, Old: display(old), New: display(new)}
+}
+
+// display formats a config value, showing an empty one as "(empty)".
+func display(v any) string {
+	s := fmt.Sprint(v)
+	if v == nil || s == "" {
+		return "(empty)"
+	}
+	return s
+}
+
+// isSecret reports whether a key's value may hold a credential and must
+// not be shown.
+func isSecret(key string) bool {
+	k := strings.ToLower(key)
+	for _, word := range []string{"key", "secret", "pass", "token", "uri", "connection_string"} {
+		if strings.Contains(k, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func changeKeys(changes []Change) []string {
+	keys := make([]string, len(changes))
+	for i, c := range changes {
+		keys[i] = c.Key
+	}
+	return keys
+}
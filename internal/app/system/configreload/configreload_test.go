@@ -0,0 +1,81 @@
+package configreload
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dalemusser/waffle/config"
+	"go.uber.org/zap"
+)
+
+func TestReload(t *testing.T) {
+	start := config.AppConfigValues{
+		"rate_limit_login_attempts": 5,
+		"session_key":               "old-secret",
+		"mongo_database":            "stratasave",
+	}
+	fresh := config.AppConfigValues{
+		"rate_limit_login_attempts": 10,
+		"session_key":               "new-secret",
+		"mongo_database":            "stratasave",
+	}
+
+	var applied config.AppConfigValues
+	rl := New(start, []string{"rate_limit_login_attempts"},
+		func() (config.AppConfigValues, error) { return fresh, nil },
+		func(v config.AppConfigValues) { applied = v },
+		zap.NewNop())
+
+	res, err := rl.Reload(TriggerAdmin)
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(res.Applied) != 1 || res.Applied[0].String() != "rate_limit_login_attempts: 5 → 10" {
+		t.Errorf("Applied = %v", res.Applied)
+	}
+	if len(res.RestartNeeded) != 1 || res.RestartNeeded[0].String() != "session_key (value hidden)" {
+		t.Errorf("RestartNeeded = %v", res.RestartNeeded)
+	}
+	if applied.Int("rate_limit_login_attempts") != 10 {
+		t.Errorf("applied attempts = %v, want 10", applied["rate_limit_login_attempts"])
+	}
+	if applied.String("session_key") != "old-secret" {
+		t.Errorf("applied session_key = %q, want the running value", applied.String("session_key"))
+	}
+	if last := rl.Last(); last == nil || last.Trigger != TriggerAdmin {
+		t.Errorf("Last = %+v", last)
+	}
+
+	// Nothing changed since, so nothing is applied again.
+	applied = nil
+	res, _ = rl.Reload(TriggerSignal)
+	if len(res.Applied) != 0 || applied != nil {
+		t.Errorf("second reload applied %v", res.Applied)
+	}
+}
+
+func TestReload_LoadError(t *testing.T) {
+	rl := New(config.AppConfigValues{}, nil,
+		func() (config.AppConfigValues, error) { return nil, errors.New("bad yaml") },
+		func(config.AppConfigValues) { t.Error("apply called after a failed load") },
+		zap.NewNop())
+
+	if _, err := rl.Reload(TriggerAdmin); err == nil {
+		t.Fatal("expected error")
+	}
+	if last := rl.Last(); last == nil || last.Error != "bad yaml" {
+		t.Errorf("Last = %+v", last)
+	}
+}
+
+func TestNilReloader(t *testing.T) {
+	var rl *Reloader
+	if _, err := rl.Reload(TriggerAdmin); err != ErrUnavailable {
+		t.Errorf("Reload err = %v, want ErrUnavailable", err)
+	}
+	if rl.Last() != nil || rl.Reloadable() != nil {
+		t.Error("nil Reloader should report nothing")
+	}
+	rl.WatchSignals()
+	rl.Stop()
+}
@@ -0,0 +1,31 @@
+// Package csvutil provides helpers shared by the console's export
+// downloads: response headers that name the file, and a guard that stops
+// spreadsheets from running exported values as formulas.
+package csvutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// SetDownloadHeaders marks the response as a download named filename.
+func SetDownloadHeaders(w http.ResponseWriter, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, url.PathEscape(filename)))
+}
+
+// SafeField prevents CSV formula injection. Spreadsheets read a cell that
+// starts with =, +, -, or @ as a formula, and some also act on a leading
+// tab or carriage return, so such values are prefixed with a single quote
+// to be shown as text.
+func SafeField(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
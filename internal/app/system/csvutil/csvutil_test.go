@@ -0,0 +1,44 @@
+package csvutil
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSafeField(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"normal text", "normal text"},
+		{"=formula", "'=formula"},
+		{"+formula", "'+formula"},
+		{"-formula", "'-formula"},
+		{"@formula", "'@formula"},
+		{"\t=formula", "'\t=formula"},
+		{"\r=formula", "'\r=formula"},
+		{"a=b", "a=b"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := SafeField(tt.input)
+			if got != tt.expected {
+				t.Errorf("SafeField(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetDownloadHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SetDownloadHeaders(rec, "text/csv; charset=utf-8", "my report.csv")
+
+	if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got, want := rec.Header().Get("Content-Disposition"), `attachment; filename="my%20report.csv"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+}
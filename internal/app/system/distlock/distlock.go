@@ -0,0 +1,251 @@
+// Package distlock coordinates work between instances of the app running
+// against the same database.
+//
+// A Lease is a lock on a named resource that its holder extends while it
+// works; if the holder's instance stops, the lease runs out and another
+// instance can take it. An Elector keeps one instance as the leader for
+// work that should happen in only one place. Both are stored in the
+// locks collection.
+package distlock
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lockstore "github.com/dalemusser/stratasave/internal/app/store/locks"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// instanceID names this process in the locks it holds.
+var instanceID = newInstanceID()
+
+// newInstanceID returns the host name with a random suffix, so two
+// processes on one host are told apart.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "instance"
+	}
+	return host + "-" + uuid.New().String()[:8]
+}
+
+// Instance returns the ID this process holds locks under.
+func Instance() string {
+	return instanceID
+}
+
+// Locker takes locks on behalf of this instance.
+type Locker struct {
+	store  *lockstore.Store
+	owner  string
+	logger *zap.Logger
+}
+
+// New creates a Locker for this instance.
+func New(db *mongo.Database, logger *zap.Logger) *Locker {
+	return &Locker{
+		store:  lockstore.New(db),
+		owner:  instanceID,
+		logger: logger,
+	}
+}
+
+// Held returns the locks held by any instance, keyed by name.
+func (l *Locker) Held(ctx context.Context) (map[string]lockstore.Lock, error) {
+	return l.store.Held(ctx, time.Now())
+}
+
+// TryLock takes the named lock for ttl and keeps extending it until the
+// lease is released. It returns nil, without an error, if another instance
+// holds the lock.
+func (l *Locker) TryLock(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	start := time.Now()
+	ok, err := l.store.Acquire(ctx, name, l.owner, ttl)
+	if err != nil || !ok {
+		return nil, err
+	}
+	le := &Lease{
+		locker:  l,
+		name:    name,
+		ttl:     ttl,
+		expires: start.Add(ttl),
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	le.wg.Add(1)
+	go le.keepAlive()
+	return le, nil
+}
+
+// Lease is a held lock.
+type Lease struct {
+	locker  *Locker
+	name    string
+	ttl     time.Duration
+	expires time.Time
+
+	lost     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// Lost is closed if the lease runs out before it is released, because it
+// couldn't be extended. Work done under the lease should stop, since
+// another instance may now hold it.
+func (le *Lease) Lost() <-chan struct{} {
+	return le.lost
+}
+
+// Release stops extending the lease and gives up the lock.
+func (le *Lease) Release(ctx context.Context) error {
+	le.stopOnce.Do(func() { close(le.stop) })
+	le.wg.Wait()
+	return le.locker.store.Release(ctx, le.name, le.locker.owner)
+}
+
+// keepAlive extends the lease every third of its ttl until it is released
+// or lost.
+func (le *Lease) keepAlive() {
+	defer le.wg.Done()
+
+	ticker := time.NewTicker(le.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-le.stop:
+			return
+		case <-ticker.C:
+			start := time.Now()
+			ctx, cancel := context.WithTimeout(context.Background(), le.ttl/3)
+			ok, err := le.locker.store.Acquire(ctx, le.name, le.locker.owner, le.ttl)
+			cancel()
+			switch {
+			case err == nil && ok:
+				le.expires = start.Add(le.ttl)
+				continue
+			case err == nil:
+				le.locker.logger.Warn("lock taken by another instance", zap.String("lock", le.name))
+			case start.Before(le.expires):
+				// Try again on the next tick while the lease still holds
+				le.locker.logger.Warn("failed to extend lock", zap.String("lock", le.name), zap.Error(err))
+				continue
+			default:
+				le.locker.logger.Error("lock expired before it could be extended",
+					zap.String("lock", le.name), zap.Error(err))
+			}
+			close(le.lost)
+			return
+		}
+	}
+}
+
+// Elector keeps one instance as leader under a named lock. The leader
+// extends its lease while it runs and gives it up when stopped; the other
+// instances take over once it lapses.
+type Elector struct {
+	locker *Locker
+	name   string
+	ttl    time.Duration
+
+	leader  atomic.Bool
+	expires time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewElector creates an Elector for the named leadership. Call Start to
+// begin campaigning.
+func (l *Locker) NewElector(name string, ttl time.Duration) *Elector {
+	return &Elector{locker: l, name: name, ttl: ttl}
+}
+
+// IsLeader reports whether this instance is the leader. A nil Elector is
+// always the leader, for single-instance use without one.
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	return e.leader.Load()
+}
+
+// Start campaigns for leadership once, so IsLeader is settled when it
+// returns, then keeps campaigning in the background.
+func (e *Elector) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.campaign(ctx)
+	e.wg.Add(1)
+	go e.loop(ctx)
+}
+
+// Stop stops campaigning and gives up leadership if held.
+func (e *Elector) Stop(ctx context.Context) error {
+	if e.cancel == nil {
+		return nil
+	}
+	e.cancel()
+	e.wg.Wait()
+	if !e.leader.Swap(false) {
+		return nil
+	}
+	e.locker.logger.Info("gave up leadership", zap.String("lock", e.name))
+	return e.locker.store.Release(ctx, e.name, e.locker.owner)
+}
+
+// loop campaigns every third of the ttl until stopped.
+func (e *Elector) loop(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.campaign(ctx)
+		}
+	}
+}
+
+// campaign takes or extends the leader lock.
+func (e *Elector) campaign(ctx context.Context) {
+	start := time.Now()
+	acquireCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	ok, err := e.locker.store.Acquire(acquireCtx, e.name, e.locker.owner, e.ttl)
+	cancel()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		e.locker.logger.Warn("failed to campaign for leadership", zap.String("lock", e.name), zap.Error(err))
+		// Leadership lapses with the lease if it can't be extended
+		if e.leader.Load() && !start.Before(e.expires) {
+			e.setLeader(false)
+		}
+		return
+	}
+	if ok {
+		e.expires = start.Add(e.ttl)
+	}
+	e.setLeader(ok)
+}
+
+// setLeader records leadership, logging when it changes.
+func (e *Elector) setLeader(leader bool) {
+	if e.leader.Swap(leader) == leader {
+		return
+	}
+	if leader {
+		e.locker.logger.Info("became leader", zap.String("lock", e.name), zap.String("instance", e.locker.owner))
+	} else {
+		e.locker.logger.Warn("lost leadership", zap.String("lock", e.name))
+	}
+}
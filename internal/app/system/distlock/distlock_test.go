@@ -0,0 +1,83 @@
+package distlock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	lockstore "github.com/dalemusser/stratasave/internal/app/store/locks"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.uber.org/zap"
+)
+
+func TestInstance(t *testing.T) {
+	if Instance() == "" || !strings.Contains(Instance(), "-") {
+		t.Errorf("Instance() = %q, want host name and suffix", Instance())
+	}
+}
+
+func TestElector_Nil(t *testing.T) {
+	var e *Elector
+	if !e.IsLeader() {
+		t.Error("nil Elector should report leader")
+	}
+}
+
+func TestTryLock(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	a := &Locker{store: lockstore.New(db), owner: "host-a", logger: zap.NewNop()}
+	b := &Locker{store: lockstore.New(db), owner: "host-b", logger: zap.NewNop()}
+
+	lease, err := a.TryLock(ctx, "schedule:nightly", time.Minute)
+	if err != nil || lease == nil {
+		t.Fatalf("TryLock() = %v, %v; want a lease", lease, err)
+	}
+	if other, err := b.TryLock(ctx, "schedule:nightly", time.Minute); err != nil || other != nil {
+		t.Fatalf("TryLock() of a held lock = %v, %v; want nil", other, err)
+	}
+
+	held, err := b.Held(ctx)
+	if err != nil {
+		t.Fatalf("Held() error = %v", err)
+	}
+	if held["schedule:nightly"].Owner != "host-a" {
+		t.Errorf("Held() = %+v, want the lock held by host-a", held)
+	}
+
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	other, err := b.TryLock(ctx, "schedule:nightly", time.Minute)
+	if err != nil || other == nil {
+		t.Fatalf("TryLock() after release = %v, %v; want a lease", other, err)
+	}
+	_ = other.Release(ctx)
+}
+
+func TestElector(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	a := (&Locker{store: lockstore.New(db), owner: "host-a", logger: zap.NewNop()}).NewElector("leader", time.Minute)
+	b := (&Locker{store: lockstore.New(db), owner: "host-b", logger: zap.NewNop()}).NewElector("leader", time.Minute)
+
+	a.Start()
+	b.Start()
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("after Start: a leader=%v, b leader=%v; want only a", a.IsLeader(), b.IsLeader())
+	}
+
+	// Stopping the leader frees the lock for the next campaign
+	if err := a.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	b.campaign(ctx)
+	if !b.IsLeader() {
+		t.Error("b did not take over after a stopped")
+	}
+	_ = b.Stop(ctx)
+}
@@ -0,0 +1,189 @@
+// Package emailbounce parses bounce and complaint notifications posted by
+// email providers.
+//
+// Two formats are accepted:
+//
+//   - Amazon SES notifications delivered through SNS, including the SNS
+//     subscription confirmation sent when the endpoint is first subscribed.
+//
+//   - A generic JSON object, or array of objects, for other providers or
+//     relays:
+//
+//     {"type": "bounce", "email": "user@example.com", "bounce_type": "hard", "detail": "550 mailbox unavailable"}
+//     {"type": "complaint", "email": "user@example.com"}
+//
+// Only permanent (hard) bounces and complaints produce events; transient
+// bounces such as a full mailbox are ignored because later sends may succeed.
+package emailbounce
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	suppressionstore "github.com/dalemusser/stratasave/internal/app/store/suppression"
+)
+
+// Event is one address that should be suppressed.
+type Event struct {
+	Email  string
+	Reason string // suppressionstore.ReasonBounce or ReasonComplaint
+	Detail string
+}
+
+// Notification is the parsed content of a webhook request.
+type Notification struct {
+	Source string  // "ses" or "webhook"
+	Events []Event // Addresses to suppress; may be empty
+
+	// SubscribeURL is set for an SNS subscription confirmation. Visit it
+	// (after checking ValidSubscribeURL) to start receiving notifications.
+	SubscribeURL string
+}
+
+// ErrUnrecognized is returned when the body is not a known notification format.
+var ErrUnrecognized = errors.New("unrecognized notification format")
+
+// snsEnvelope is the outer message SNS posts to HTTP subscribers.
+type snsEnvelope struct {
+	Type         string `json:"Type"`
+	Message      string `json:"Message"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+// sesMessage is an SES bounce or complaint notification. SES uses
+// notificationType for identity notifications and eventType for
+// configuration set event publishing.
+type sesMessage struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// genericEvent is the provider-neutral format.
+type genericEvent struct {
+	Type       string `json:"type"`
+	Email      string `json:"email"`
+	BounceType string `json:"bounce_type"` // "hard" (default) or "soft"
+	Detail     string `json:"detail"`
+}
+
+// Parse parses a webhook request body.
+func Parse(body []byte) (Notification, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var events []genericEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			return Notification{}, ErrUnrecognized
+		}
+		return fromGeneric(events), nil
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return Notification{}, ErrUnrecognized
+	}
+
+	if _, ok := probe["Type"]; ok {
+		var env snsEnvelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			return Notification{}, ErrUnrecognized
+		}
+		return fromSNS(env)
+	}
+
+	if _, ok := probe["type"]; ok {
+		var ev genericEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			return Notification{}, ErrUnrecognized
+		}
+		return fromGeneric([]genericEvent{ev}), nil
+	}
+
+	return Notification{}, ErrUnrecognized
+}
+
+func fromSNS(env snsEnvelope) (Notification, error) {
+	n := Notification{Source: "ses"}
+	switch env.Type {
+	case "SubscriptionConfirmation":
+		n.SubscribeURL = env.SubscribeURL
+		return n, nil
+	case "Notification":
+	default:
+		// UnsubscribeConfirmation and anything else carry no events
+		return n, nil
+	}
+
+	var msg sesMessage
+	if err := json.Unmarshal([]byte(env.Message), &msg); err != nil {
+		return Notification{}, ErrUnrecognized
+	}
+
+	kind := msg.NotificationType
+	if kind == "" {
+		kind = msg.EventType
+	}
+	switch kind {
+	case "Bounce":
+		if msg.Bounce.BounceType != "Permanent" {
+			return n, nil
+		}
+		for _, r := range msg.Bounce.BouncedRecipients {
+			detail := r.DiagnosticCode
+			if detail == "" {
+				detail = msg.Bounce.BounceSubType
+			}
+			n.Events = append(n.Events, Event{Email: r.EmailAddress, Reason: suppressionstore.ReasonBounce, Detail: detail})
+		}
+	case "Complaint":
+		for _, r := range msg.Complaint.ComplainedRecipients {
+			n.Events = append(n.Events, Event{Email: r.EmailAddress, Reason: suppressionstore.ReasonComplaint, Detail: msg.Complaint.ComplaintFeedbackType})
+		}
+	}
+	return n, nil
+}
+
+func fromGeneric(events []genericEvent) Notification {
+	n := Notification{Source: "webhook"}
+	for _, ev := range events {
+		if strings.TrimSpace(ev.Email) == "" {
+			continue
+		}
+		switch strings.ToLower(ev.Type) {
+		case "bounce":
+			if strings.EqualFold(ev.BounceType, "soft") {
+				continue
+			}
+			n.Events = append(n.Events, Event{Email: ev.Email, Reason: suppressionstore.ReasonBounce, Detail: ev.Detail})
+		case "complaint":
+			n.Events = append(n.Events, Event{Email: ev.Email, Reason: suppressionstore.ReasonComplaint, Detail: ev.Detail})
+		}
+	}
+	return n
+}
+
+// ValidSubscribeURL reports whether u is an HTTPS SNS endpoint, so a
+// forged confirmation can't make the server fetch arbitrary URLs.
+func ValidSubscribeURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	host := parsed.Hostname()
+	return strings.HasPrefix(host, "sns.") && strings.HasSuffix(host, ".amazonaws.com")
+}
@@ -0,0 +1,128 @@
+package emailbounce
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	suppressionstore "github.com/dalemusser/stratasave/internal/app/store/suppression"
+)
+
+// snsBody wraps an SES message in an SNS notification envelope.
+func snsBody(t *testing.T, message string) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]string{"Type": "Notification", "Message": message})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParse_SESPermanentBounce(t *testing.T) {
+	body := snsBody(t, `{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bounceSubType":"General",
+		"bouncedRecipients":[{"emailAddress":"a@example.com","diagnosticCode":"550 5.1.1 user unknown"},{"emailAddress":"b@example.com"}]}}`)
+
+	n, err := Parse(body)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Event{
+		{Email: "a@example.com", Reason: suppressionstore.ReasonBounce, Detail: "550 5.1.1 user unknown"},
+		{Email: "b@example.com", Reason: suppressionstore.ReasonBounce, Detail: "General"},
+	}
+	if n.Source != "ses" || !reflect.DeepEqual(n.Events, want) {
+		t.Errorf("Parse() = %+v, want source ses and events %+v", n, want)
+	}
+}
+
+func TestParse_SESTransientBounceIgnored(t *testing.T) {
+	body := snsBody(t, `{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"a@example.com"}]}}`)
+
+	n, err := Parse(body)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(n.Events) != 0 {
+		t.Errorf("Events = %+v, want none", n.Events)
+	}
+}
+
+func TestParse_SESComplaintEventPublishing(t *testing.T) {
+	body := snsBody(t, `{"eventType":"Complaint","complaint":{"complaintFeedbackType":"abuse","complainedRecipients":[{"emailAddress":"c@example.com"}]}}`)
+
+	n, err := Parse(body)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Event{{Email: "c@example.com", Reason: suppressionstore.ReasonComplaint, Detail: "abuse"}}
+	if !reflect.DeepEqual(n.Events, want) {
+		t.Errorf("Events = %+v, want %+v", n.Events, want)
+	}
+}
+
+func TestParse_SNSSubscriptionConfirmation(t *testing.T) {
+	body := []byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=x"}`)
+
+	n, err := Parse(body)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if n.SubscribeURL == "" || len(n.Events) != 0 {
+		t.Errorf("Parse() = %+v, want SubscribeURL and no events", n)
+	}
+}
+
+func TestParse_Generic(t *testing.T) {
+	body := []byte(`[
+		{"type":"bounce","email":"hard@example.com","detail":"no such user"},
+		{"type":"bounce","email":"soft@example.com","bounce_type":"soft"},
+		{"type":"complaint","email":"spam@example.com"},
+		{"type":"delivered","email":"ok@example.com"},
+		{"type":"bounce","email":""}
+	]`)
+
+	n, err := Parse(body)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []Event{
+		{Email: "hard@example.com", Reason: suppressionstore.ReasonBounce, Detail: "no such user"},
+		{Email: "spam@example.com", Reason: suppressionstore.ReasonComplaint},
+	}
+	if n.Source != "webhook" || !reflect.DeepEqual(n.Events, want) {
+		t.Errorf("Parse() = %+v, want source webhook and events %+v", n, want)
+	}
+
+	single, err := Parse([]byte(`{"type":"complaint","email":"one@example.com"}`))
+	if err != nil || len(single.Events) != 1 {
+		t.Errorf("Parse(single) = %+v, %v; want one event", single, err)
+	}
+}
+
+func TestParse_Unrecognized(t *testing.T) {
+	for _, body := range []string{``, `not json`, `{"foo":"bar"}`, `[1,2]`} {
+		if _, err := Parse([]byte(body)); !errors.Is(err, ErrUnrecognized) {
+			t.Errorf("Parse(%q) error = %v, want ErrUnrecognized", body, err)
+		}
+	}
+}
+
+func TestValidSubscribeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", true},
+		{"http://sns.us-east-1.amazonaws.com/", false},
+		{"https://sns.us-east-1.amazonaws.com.evil.com/", false},
+		{"https://evil.com/sns.amazonaws.com", false},
+		{"https://s3.amazonaws.com/", false},
+		{"::not a url", false},
+	}
+	for _, tt := range tests {
+		if got := ValidSubscribeURL(tt.url); got != tt.want {
+			t.Errorf("ValidSubscribeURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
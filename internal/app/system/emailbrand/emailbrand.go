@@ -0,0 +1,57 @@
+// Package emailbrand supplies the mailer's email branding from the editable
+// site settings, so changing the logo, button color, or footer text on the
+// settings page restyles every email.
+package emailbrand
+
+import (
+	"context"
+	"strings"
+
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Provider implements mailer.BrandProvider.
+type Provider struct {
+	settings *settingsstore.Store
+	storage  storage.Store
+	baseURL  string
+}
+
+// New creates a provider. baseURL makes relative logo URLs (local file
+// storage) absolute so they load in email clients.
+func New(db *mongo.Database, fileStorage storage.Store, baseURL string) *Provider {
+	return &Provider{
+		settings: settingsstore.New(db),
+		storage:  fileStorage,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Brand returns the branding from the current site settings.
+func (p *Provider) Brand(ctx context.Context) (mailer.Brand, error) {
+	s, err := p.settings.Get(ctx)
+	if err != nil {
+		return mailer.Brand{}, err
+	}
+
+	b := mailer.Brand{
+		PrimaryColor: s.EmailPrimaryColor,
+		FooterText:   s.EmailFooterText,
+	}
+	if s.HasLogo() && p.storage != nil {
+		b.LogoURL = AbsoluteURL(p.baseURL, p.storage.URL(s.LogoPath))
+	}
+	return b, nil
+}
+
+// AbsoluteURL prefixes a root-relative URL with baseURL. Other URLs are
+// returned unchanged.
+func AbsoluteURL(baseURL, u string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return strings.TrimRight(baseURL, "/") + u
+	}
+	return u
+}
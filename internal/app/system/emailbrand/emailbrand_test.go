@@ -0,0 +1,19 @@
+package emailbrand
+
+import "testing"
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		base, u, want string
+	}{
+		{"https://example.com", "/files/logo.png", "https://example.com/files/logo.png"},
+		{"https://example.com/", "/files/logo.png", "https://example.com/files/logo.png"},
+		{"https://example.com", "https://cdn.example.com/logo.png", "https://cdn.example.com/logo.png"},
+		{"https://example.com", "//cdn.example.com/logo.png", "//cdn.example.com/logo.png"},
+	}
+	for _, tt := range tests {
+		if got := AbsoluteURL(tt.base, tt.u); got != tt.want {
+			t.Errorf("AbsoluteURL(%q, %q) = %q, want %q", tt.base, tt.u, got, tt.want)
+		}
+	}
+}
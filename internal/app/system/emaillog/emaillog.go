@@ -0,0 +1,74 @@
+// Package emaillog records every email delivery attempt in the email_log
+// collection, so support can check whether a particular email went out.
+//
+// Set a Log as the mailer's recorder. Each SMTP delivery (sent or failed)
+// and each email refused because the recipient is suppressed is recorded
+// with its template, recipient, and the Message-ID given to the provider.
+package emaillog
+
+import (
+	"context"
+	"time"
+
+	emaillogstore "github.com/dalemusser/stratasave/internal/app/store/emaillog"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Log implements mailer.Recorder.
+type Log struct {
+	store     *emaillogstore.Store
+	retention time.Duration
+	log       *zap.Logger
+}
+
+// New creates a delivery log. Entries older than retention are removed;
+// zero keeps them forever.
+func New(db *mongo.Database, retention time.Duration, logger *zap.Logger) *Log {
+	return &Log{
+		store:     emaillogstore.New(db),
+		retention: retention,
+		log:       logger,
+	}
+}
+
+// Record stores a delivery attempt.
+func (l *Log) Record(ctx context.Context, d mailer.Delivery) error {
+	input := emaillogstore.CreateInput{
+		To:        d.To,
+		Template:  d.Template,
+		Subject:   d.Subject,
+		Status:    d.Status,
+		MessageID: d.MessageID,
+		Error:     d.Error,
+	}
+	if id, err := primitive.ObjectIDFromHex(d.UserID); err == nil {
+		input.UserID = &id
+	}
+	return l.store.Create(ctx, input)
+}
+
+// Jobs returns the periodic tasks for the log: removing entries past the
+// retention period. Returns nil if retention is disabled.
+func (l *Log) Jobs() []tasks.Job {
+	if l == nil || l.retention <= 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "email-log-cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := l.store.DeleteBefore(ctx, time.Now().Add(-l.retention))
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				l.log.Info("removed old email log entries", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}}
+}
@@ -0,0 +1,278 @@
+// Package emailoutbox queues outbound email in MongoDB and delivers it from
+// the "email" job queue, retrying failed deliveries with backoff.
+//
+// Set an Outbox as the mailer's queue and every mailer.Send call is stored
+// in the email_outbox collection and delivered by a send_email job. Each
+// delivery attempt is recorded on the message so the admin console can show
+// what was sent, what is waiting for a retry, and what has given up.
+package emailoutbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	outboxstore "github.com/dalemusser/stratasave/internal/app/store/outbox"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue emails are delivered from.
+	QueueName = "email"
+	// JobType is the job type that delivers one outbox message.
+	JobType = "send_email"
+)
+
+// Config holds outbox settings.
+type Config struct {
+	// MaxAttempts is the number of delivery attempts before a message is
+	// marked failed.
+	MaxAttempts int
+
+	// RetryDelay and MaxRetryDelay are the job runner's retry backoff
+	// settings (see jobrunner.Backoff); they are used here only to show
+	// when the next attempt is due.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Retention is how long sent messages are kept. Zero keeps them forever.
+	// Failed messages are always kept so they can be retried.
+	Retention time.Duration
+}
+
+// Outbox stores emails and delivers them from the job queue.
+type Outbox struct {
+	store  *outboxstore.Store
+	jobs   *jobstore.Store
+	mailer *mailer.Mailer
+	cfg    Config
+	log    *zap.Logger
+}
+
+// New creates an outbox that delivers with m.
+func New(db *mongo.Database, m *mailer.Mailer, cfg Config, logger *zap.Logger) *Outbox {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	return &Outbox{
+		store:  outboxstore.New(db),
+		jobs:   jobstore.New(db),
+		mailer: m,
+		cfg:    cfg,
+		log:    logger,
+	}
+}
+
+// Enqueue stores an email and schedules its delivery. It implements
+// mailer.Queue. If the delivery job can't be created the stored message is
+// removed again, since the mailer then sends the email directly.
+func (o *Outbox) Enqueue(ctx context.Context, email mailer.Email) error {
+	msg, err := o.store.Create(ctx, outboxstore.CreateInput{
+		To:          email.To,
+		Subject:     email.Subject,
+		TextBody:    email.TextBody,
+		HTMLBody:    email.HTMLBody,
+		Attachments: toStoreAttachments(email.Attachments),
+		Template:    email.Template,
+		UserID:      email.UserID,
+		MaxAttempts: o.cfg.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("store outbox message: %w", err)
+	}
+	if err := o.schedule(ctx, msg); err != nil {
+		delCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if delErr := o.store.Delete(delCtx, msg.ID); delErr != nil {
+			o.log.Warn("failed to remove unscheduled email from outbox",
+				zap.String("message_id", msg.ID.Hex()),
+				zap.Error(delErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// Retry requeues a failed message with a fresh set of attempts.
+// Returns outboxstore.ErrNotFound if the message has not failed.
+func (o *Outbox) Retry(ctx context.Context, id primitive.ObjectID) error {
+	if err := o.store.Requeue(ctx, id); err != nil {
+		return err
+	}
+	msg, err := o.store.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return o.schedule(ctx, *msg)
+}
+
+// schedule creates the job that delivers msg.
+func (o *Outbox) schedule(ctx context.Context, msg outboxstore.Message) error {
+	job, err := o.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     JobType,
+		Payload:     map[string]any{"message_id": msg.ID.Hex()},
+		MaxAttempts: msg.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue email job: %w", err)
+	}
+	if err := o.store.SetJobID(ctx, msg.ID, job.ID); err != nil {
+		// The job still delivers the message; only the console link is lost
+		o.log.Warn("failed to record email job id",
+			zap.String("message_id", msg.ID.Hex()),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// Register adds the email queue and its job handler to r.
+func (o *Outbox) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(JobType, o.handle)
+}
+
+// handle delivers one outbox message. Returning an error lets the job
+// runner schedule the next attempt.
+func (o *Outbox) handle(ctx context.Context, payload map[string]any) (map[string]any, error) {
+	idStr, _ := payload["message_id"].(string)
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message_id %q", idStr)
+	}
+
+	msg, err := o.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load outbox message: %w", err)
+	}
+	if msg.Status == outboxstore.StatusSent {
+		// A stale job was re-queued after the message went out
+		return map[string]any{"skipped": "already sent"}, nil
+	}
+
+	// The address may have bounced since the message was queued; retrying
+	// wouldn't help, so fail the message without failing the job
+	if o.mailer.Suppressed(msg.To) {
+		o.mailer.RecordSuppressed(mailer.Email{To: msg.To, Subject: msg.Subject, Template: msg.Template, UserID: msg.UserID})
+		recCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := o.store.MarkAttemptFailed(recCtx, id, mailer.ErrSuppressed.Error(), nil); err != nil {
+			o.log.Error("failed to record suppressed email",
+				zap.String("message_id", idStr),
+				zap.Error(err))
+		}
+		return map[string]any{"skipped": "suppressed"}, nil
+	}
+
+	sendErr := o.mailer.Deliver(mailer.Email{
+		To:          msg.To,
+		Subject:     msg.Subject,
+		TextBody:    msg.TextBody,
+		HTMLBody:    msg.HTMLBody,
+		Attachments: toMailerAttachments(msg.Attachments),
+		Template:    msg.Template,
+		UserID:      msg.UserID,
+	})
+
+	// Use a fresh context so the result is recorded even if the job timed out
+	recCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if sendErr == nil {
+		if err := o.store.MarkSent(recCtx, id); err != nil {
+			o.log.Error("failed to mark email sent",
+				zap.String("message_id", idStr),
+				zap.Error(err))
+		}
+		return map[string]any{"to": msg.To}, nil
+	}
+
+	attempt := msg.Attempts + 1
+	next := NextAttempt(time.Now(), attempt, msg.MaxAttempts, o.cfg.RetryDelay, o.cfg.MaxRetryDelay)
+	if err := o.store.MarkAttemptFailed(recCtx, id, sendErr.Error(), next); err != nil {
+		o.log.Error("failed to record email delivery failure",
+			zap.String("message_id", idStr),
+			zap.Error(err))
+	}
+	if next == nil {
+		o.log.Error("email delivery failed, giving up",
+			zap.String("message_id", idStr),
+			zap.String("to", msg.To),
+			zap.Int("attempts", attempt),
+			zap.Error(sendErr))
+	}
+	return nil, sendErr
+}
+
+// toStoreAttachments converts mailer attachments for storage.
+func toStoreAttachments(atts []mailer.Attachment) []outboxstore.Attachment {
+	if len(atts) == 0 {
+		return nil
+	}
+	out := make([]outboxstore.Attachment, len(atts))
+	for i, a := range atts {
+		out[i] = outboxstore.Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Data:        a.Data,
+			ContentID:   a.ContentID,
+		}
+	}
+	return out
+}
+
+// toMailerAttachments converts stored attachments back for delivery.
+func toMailerAttachments(atts []outboxstore.Attachment) []mailer.Attachment {
+	if len(atts) == 0 {
+		return nil
+	}
+	out := make([]mailer.Attachment, len(atts))
+	for i, a := range atts {
+		out[i] = mailer.Attachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Data:        a.Data,
+			ContentID:   a.ContentID,
+		}
+	}
+	return out
+}
+
+// NextAttempt returns when the job runner will retry a delivery after the
+// given attempt failed, or nil if no attempts remain. It mirrors the
+// runner's backoff.
+func NextAttempt(now time.Time, attempt, maxAttempts int, retryDelay, maxRetryDelay time.Duration) *time.Time {
+	if attempt >= maxAttempts {
+		return nil
+	}
+	t := now.Add(jobrunner.Backoff(retryDelay, maxRetryDelay, attempt))
+	return &t
+}
+
+// Jobs returns the periodic tasks for the outbox: removing sent messages
+// past the retention period. Returns nil if retention is disabled.
+func (o *Outbox) Jobs() []tasks.Job {
+	if o == nil || o.cfg.Retention <= 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "email-outbox-cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := o.store.DeleteSentBefore(ctx, time.Now().Add(-o.cfg.Retention))
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				o.log.Info("removed sent emails from outbox", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}}
+}
@@ -0,0 +1,57 @@
+package emailoutbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	delay := 30 * time.Second
+	maxDelay := 5 * time.Minute
+
+	tests := []struct {
+		name    string
+		attempt int
+		max     int
+		want    time.Duration // offset from now; negative means no retry
+	}{
+		{"first failure", 1, 5, 30 * time.Second},
+		{"third failure", 3, 5, 2 * time.Minute},
+		{"capped", 6, 10, 5 * time.Minute},
+		{"last attempt", 5, 5, -1},
+		{"single attempt", 1, 1, -1},
+		{"past max", 6, 5, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextAttempt(now, tt.attempt, tt.max, delay, maxDelay)
+			if tt.want < 0 {
+				if got != nil {
+					t.Errorf("NextAttempt() = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("NextAttempt() = nil, want %v", now.Add(tt.want))
+			}
+			if !got.Equal(now.Add(tt.want)) {
+				t.Errorf("NextAttempt() = %v, want %v", *got, now.Add(tt.want))
+			}
+		})
+	}
+}
+
+func TestJobs_NilAndDisabled(t *testing.T) {
+	var o *Outbox
+	if jobs := o.Jobs(); jobs != nil {
+		t.Errorf("nil Outbox Jobs() = %v, want nil", jobs)
+	}
+	if jobs := (&Outbox{}).Jobs(); jobs != nil {
+		t.Errorf("Jobs() with no retention = %v, want nil", jobs)
+	}
+	if jobs := (&Outbox{cfg: Config{Retention: time.Hour}}).Jobs(); len(jobs) != 1 {
+		t.Errorf("Jobs() with retention returned %d jobs, want 1", len(jobs))
+	}
+}
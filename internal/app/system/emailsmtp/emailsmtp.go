@@ -0,0 +1,61 @@
+// Package emailsmtp supplies the mailer's SMTP server and sender from the
+// editable site settings, so changing them on the settings page takes
+// effect for the next email, on every instance, without a restart.
+package emailsmtp
+
+import (
+	"context"
+
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Provider implements mailer.SMTPProvider.
+type Provider struct {
+	settings *settingsstore.Store
+	box      *secretbox.Box
+}
+
+// New creates a provider. box opens the stored password; without one,
+// stored settings are ignored and the configured server is used.
+func New(db *mongo.Database, box *secretbox.Box) *Provider {
+	return &Provider{settings: settingsstore.New(db), box: box}
+}
+
+// SMTP returns the SMTP settings saved on the settings page, if any.
+func (p *Provider) SMTP(ctx context.Context) (mailer.SMTP, bool, error) {
+	if p.box == nil {
+		return mailer.SMTP{}, false, nil
+	}
+	s, err := p.settings.Get(ctx)
+	if err != nil {
+		return mailer.SMTP{}, false, err
+	}
+	if s.SMTP == nil {
+		return mailer.SMTP{}, false, nil
+	}
+	return Open(p.box, s.SMTP)
+}
+
+// Open returns stored SMTP settings with the password decrypted. It fails
+// if the password was sealed with a different key.
+func Open(box *secretbox.Box, s *models.SMTPSettings) (mailer.SMTP, bool, error) {
+	out := mailer.SMTP{
+		Host:     s.Host,
+		Port:     s.Port,
+		User:     s.User,
+		From:     s.From,
+		FromName: s.FromName,
+	}
+	if s.PassEncrypted != "" {
+		pass, err := box.Open(s.PassEncrypted)
+		if err != nil {
+			return mailer.SMTP{}, false, err
+		}
+		out.Pass = pass
+	}
+	return out, true, nil
+}
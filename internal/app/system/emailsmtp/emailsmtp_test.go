@@ -0,0 +1,27 @@
+package emailsmtp
+
+import (
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/secretbox"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+)
+
+func TestOpen(t *testing.T) {
+	box, _ := secretbox.New("test-key")
+	sealed, _ := box.Seal("hunter2")
+	stored := &models.SMTPSettings{Host: "smtp.example.com", Port: 587, User: "mailer", PassEncrypted: sealed, From: "hello@example.com", FromName: "Example"}
+
+	got, ok, err := Open(box, stored)
+	want := mailer.SMTP{Host: "smtp.example.com", Port: 587, User: "mailer", Pass: "hunter2", From: "hello@example.com", FromName: "Example"}
+	if err != nil || !ok || got != want {
+		t.Errorf("Open() = %+v, %v, %v, want %+v", got, ok, err, want)
+	}
+
+	// A password sealed under another key can't be used
+	other, _ := secretbox.New("other-key")
+	if _, ok, err := Open(other, stored); err == nil || ok {
+		t.Errorf("Open() with the wrong key = %v, %v, want an error", ok, err)
+	}
+}
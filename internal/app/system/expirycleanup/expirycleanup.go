@@ -0,0 +1,234 @@
+// Package expirycleanup deletes records that have outlived their use:
+// expired sessions, expired email verifications, used or expired password
+// resets, and login rate-limit records for attempts long past.
+//
+// A scheduled task queues a cleanup job on the "cleanup" queue, so each
+// run and the number of records it deleted show in the jobs UI. Deleted
+// counts are also exported as Prometheus metrics.
+package expirycleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue cleanup runs are queued on.
+	QueueName = "cleanup"
+	// CleanupJob is the job type that deletes expired records.
+	CleanupJob = "expired_records"
+)
+
+const (
+	// Interval is how often a cleanup job is queued.
+	Interval = 1 * time.Hour
+
+	// RateLimitRetention is how long a login rate-limit record is kept
+	// after its last attempt, unless the login is still locked out. It
+	// matches the TTL index on the collection.
+	RateLimitRetention = 24 * time.Hour
+)
+
+// Record kinds, used as job result keys and metric labels.
+const (
+	KindSessions           = "sessions"
+	KindEmailVerifications = "email_verifications"
+	KindPasswordResets     = "password_resets"
+	KindRateLimits         = "rate_limits"
+)
+
+var (
+	deletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stratasave_cleanup_deleted_total",
+			Help: "Expired records deleted by the cleanup job, by kind.",
+		},
+		[]string{"kind"},
+	)
+	runsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stratasave_cleanup_runs_total",
+			Help: "Cleanup job runs, by result (success or error).",
+		},
+		[]string{"result"},
+	)
+	lastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "stratasave_cleanup_last_success_timestamp_seconds",
+		Help: "Unix time the cleanup job last completed successfully.",
+	})
+)
+
+// Counts is how many records of each kind one cleanup run deleted.
+type Counts struct {
+	Sessions           int64
+	EmailVerifications int64
+	PasswordResets     int64
+	RateLimits         int64
+}
+
+// Total returns the number of records deleted.
+func (c Counts) Total() int64 {
+	return c.Sessions + c.EmailVerifications + c.PasswordResets + c.RateLimits
+}
+
+// Result returns the counts as a job result, shown in the jobs UI.
+func (c Counts) Result() map[string]any {
+	return map[string]any{
+		KindSessions:           c.Sessions,
+		KindEmailVerifications: c.EmailVerifications,
+		KindPasswordResets:     c.PasswordResets,
+		KindRateLimits:         c.RateLimits,
+		"total":                c.Total(),
+	}
+}
+
+// Cleaner deletes expired records.
+type Cleaner struct {
+	db     *mongo.Database
+	jobs   *jobstore.Store
+	logger *zap.Logger
+}
+
+// New creates a Cleaner and registers its Prometheus metrics.
+func New(db *mongo.Database, logger *zap.Logger) *Cleaner {
+	registerMetrics(logger)
+	return &Cleaner{
+		db:     db,
+		jobs:   jobstore.New(db),
+		logger: logger,
+	}
+}
+
+// registerMetrics adds the cleanup metrics to the default Prometheus
+// registry. Registering them again is harmless.
+func registerMetrics(logger *zap.Logger) {
+	for _, c := range []prometheus.Collector{deletedTotal, runsTotal, lastSuccess} {
+		if err := prometheus.Register(c); err != nil {
+			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+				logger.Error("failed to register cleanup metrics", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Register adds the cleanup queue and its job handler to r.
+func (c *Cleaner) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(CleanupJob, c.handleCleanup)
+}
+
+// Jobs returns the background task that queues a cleanup run every
+// Interval.
+func (c *Cleaner) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "expired-records-cleanup",
+		Interval: Interval,
+		Run:      c.enqueue,
+	}}
+}
+
+// enqueue queues a cleanup run, unless one is already waiting or running.
+func (c *Cleaner) enqueue(ctx context.Context) error {
+	stats, err := c.jobs.GetQueueStats(ctx, QueueName)
+	if err != nil {
+		return fmt.Errorf("reading cleanup queue: %w", err)
+	}
+	if stats.Pending > 0 || stats.Running > 0 {
+		return nil
+	}
+	// A failed run is picked up again by the next scheduled one
+	_, err = c.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     CleanupJob,
+		MaxAttempts: 1,
+	})
+	return err
+}
+
+// handleCleanup runs a cleanup job, returning the deleted counts as its
+// result.
+func (c *Cleaner) handleCleanup(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	counts, err := c.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return counts.Result(), nil
+}
+
+// Run deletes expired records of every kind and records the counts in the
+// metrics. It stops at the first kind that fails, returning what was
+// deleted before it.
+func (c *Cleaner) Run(ctx context.Context) (Counts, error) {
+	now := time.Now()
+
+	var counts Counts
+	steps := []struct {
+		kind   string
+		coll   string
+		filter bson.M
+		n      *int64
+	}{
+		{KindSessions, "sessions", expiredFilter(now), &counts.Sessions},
+		// Used verifications stay until they expire: the resend interval
+		// counts recently issued codes
+		{KindEmailVerifications, "email_verifications", expiredFilter(now), &counts.EmailVerifications},
+		{KindPasswordResets, "password_resets", passwordResetFilter(now), &counts.PasswordResets},
+		{KindRateLimits, "rate_limits", rateLimitFilter(now), &counts.RateLimits},
+	}
+
+	for _, s := range steps {
+		res, err := c.db.Collection(s.coll).DeleteMany(ctx, s.filter)
+		if err != nil {
+			runsTotal.WithLabelValues("error").Inc()
+			return counts, fmt.Errorf("deleting %s: %w", s.kind, err)
+		}
+		*s.n = res.DeletedCount
+		deletedTotal.WithLabelValues(s.kind).Add(float64(res.DeletedCount))
+	}
+
+	runsTotal.WithLabelValues("success").Inc()
+	lastSuccess.Set(float64(time.Now().Unix()))
+
+	if counts.Total() > 0 {
+		c.logger.Info("cleaned up expired records",
+			zap.Int64(KindSessions, counts.Sessions),
+			zap.Int64(KindEmailVerifications, counts.EmailVerifications),
+			zap.Int64(KindPasswordResets, counts.PasswordResets),
+			zap.Int64(KindRateLimits, counts.RateLimits))
+	}
+	return counts, nil
+}
+
+// expiredFilter matches records whose expires_at has passed.
+func expiredFilter(now time.Time) bson.M {
+	return bson.M{"expires_at": bson.M{"$lt": now}}
+}
+
+// passwordResetFilter matches reset tokens that are used or expired.
+func passwordResetFilter(now time.Time) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"used": true},
+		{"expires_at": bson.M{"$lt": now}},
+	}}
+}
+
+// rateLimitFilter matches rate-limit records with no attempt within
+// RateLimitRetention and no lockout still in force.
+func rateLimitFilter(now time.Time) bson.M {
+	return bson.M{
+		"last_attempt": bson.M{"$lt": now.Add(-RateLimitRetention)},
+		"$or": []bson.M{
+			{"locked_until": nil},
+			{"locked_until": bson.M{"$lt": now}},
+		},
+	}
+}
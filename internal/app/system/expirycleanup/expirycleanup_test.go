@@ -0,0 +1,46 @@
+package expirycleanup
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCounts_Result(t *testing.T) {
+	c := Counts{Sessions: 3, EmailVerifications: 2, PasswordResets: 1, RateLimits: 4}
+
+	if got := c.Total(); got != 10 {
+		t.Errorf("Total() = %d, want 10", got)
+	}
+
+	res := c.Result()
+	want := map[string]int64{
+		KindSessions:           3,
+		KindEmailVerifications: 2,
+		KindPasswordResets:     1,
+		KindRateLimits:         4,
+		"total":                10,
+	}
+	for k, v := range want {
+		if res[k] != v {
+			t.Errorf("Result()[%q] = %v, want %d", k, res[k], v)
+		}
+	}
+}
+
+func TestRateLimitFilter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f := rateLimitFilter(now)
+
+	last, ok := f["last_attempt"].(bson.M)
+	if !ok {
+		t.Fatalf("last_attempt filter missing: %v", f)
+	}
+	if got, want := last["$lt"], now.Add(-RateLimitRetention); got != want {
+		t.Errorf("last_attempt $lt = %v, want %v", got, want)
+	}
+	if _, ok := f["$or"].([]bson.M); !ok {
+		t.Errorf("expected locked_until $or clause, got %v", f["$or"])
+	}
+}
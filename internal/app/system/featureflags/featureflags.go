@@ -0,0 +1,246 @@
+// Package featureflags turns features on and off at runtime.
+//
+// Each flag has a definition in this package with its default setting. An
+// admin can override the default on the Feature Flags page, limiting the
+// flag to some environments ("dev", "prod") or rolling it out to a
+// percentage of users. Handlers ask Enabled or EnabledFor, or wrap routes
+// in Require, instead of reading a boolean fixed at startup.
+//
+// Settings are cached for a short time. A change made on one instance takes
+// effect there right away and on other instances within DefaultTTL.
+package featureflags
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	featureflagstore "github.com/dalemusser/stratasave/internal/app/store/featureflag"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Flag keys.
+const (
+	SelfRegistration = "self_registration"
+	TrustLogin       = "trust_login"
+	APIV2            = "api_v2"
+)
+
+// Environments lists the runtime environments a flag can be limited to.
+var Environments = []string{"dev", "prod"}
+
+// DefaultTTL is how long stored settings are cached.
+const DefaultTTL = 30 * time.Second
+
+// Definition describes a flag and its setting when no admin has changed it.
+type Definition struct {
+	Key         string
+	Name        string
+	Description string
+	Default     models.FeatureFlag
+	OnlyIn      []string // If set, the only environments the flag can be on in, whatever its setting
+}
+
+// Definitions lists every flag, in the order shown to admins.
+var Definitions = []Definition{
+	{
+		Key:         SelfRegistration,
+		Name:        "Self-registration",
+		Description: "The public signup form. Registration must also be turned on in Settings, and email must be configured.",
+		Default:     models.FeatureFlag{Enabled: true, Rollout: 100},
+	},
+	{
+		Key:         TrustLogin,
+		Name:        "Trust login",
+		Description: "Passwordless login and confirmation for trust-method accounts. For development only; it can't be turned on outside dev.",
+		Default:     models.FeatureFlag{Enabled: true, Environments: []string{"dev"}, Rollout: 100},
+		OnlyIn:      []string{"dev"},
+	},
+	{
+		Key:         APIV2,
+		Name:        "Game API v2",
+		Description: "The /api/v2 endpoints. When off, they answer 404 and games use /api/v1.",
+		Default:     models.FeatureFlag{Enabled: true, Rollout: 100},
+	},
+}
+
+// Lookup returns the definition of the flag with the given key.
+func Lookup(key string) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Evaluate reports whether a flag is on in env for subject, using stored
+// if an admin has set the flag and the definition's default otherwise.
+// A flag limited by its definition to some environments is off everywhere
+// else, whatever is stored. With a partial rollout, the same subject always
+// gets the same answer.
+func Evaluate(def Definition, stored *models.FeatureFlag, env, subject string) bool {
+	if len(def.OnlyIn) > 0 && !contains(def.OnlyIn, env) {
+		return false
+	}
+	f := def.Default
+	if stored != nil {
+		f = *stored
+	}
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Environments) > 0 && !contains(f.Environments, env) {
+		return false
+	}
+	if f.Rollout >= 100 {
+		return true
+	}
+	return bucket(def.Key, subject) < f.Rollout
+}
+
+// bucket places subject in one of 100 buckets for the flag. Hashing the key
+// with the subject gives each flag a different slice of users.
+func bucket(key, subject string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Flags evaluates flags against their stored settings.
+// A nil *Flags is valid and uses each flag's default with no environment,
+// so flags limited to an environment are off.
+type Flags struct {
+	store *featureflagstore.Store
+	env   string
+	ttl   time.Duration
+	log   *zap.Logger
+	now   func() time.Time
+
+	mu       sync.Mutex
+	stored   map[string]models.FeatureFlag
+	loadedAt time.Time
+}
+
+// New creates a Flags for the runtime environment env.
+func New(db *mongo.Database, env string, logger *zap.Logger) *Flags {
+	return &Flags{
+		store: featureflagstore.New(db),
+		env:   env,
+		ttl:   DefaultTTL,
+		log:   logger,
+		now:   time.Now,
+	}
+}
+
+// Env returns the runtime environment flags are evaluated in.
+func (f *Flags) Env() string {
+	if f == nil {
+		return ""
+	}
+	return f.env
+}
+
+// Stored returns the admin settings for every flag that has one, by key.
+func (f *Flags) Stored(ctx context.Context) map[string]models.FeatureFlag {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stored != nil && f.now().Sub(f.loadedAt) < f.ttl {
+		return f.stored
+	}
+	list, err := f.store.List(ctx)
+	if err != nil {
+		// Keep the last settings loaded, or the defaults, and try again
+		// on the next call
+		f.log.Warn("failed to load feature flags", zap.Error(err))
+		return f.stored
+	}
+	f.stored = make(map[string]models.FeatureFlag, len(list))
+	for _, flag := range list {
+		f.stored[flag.Key] = flag
+	}
+	f.loadedAt = f.now()
+	return f.stored
+}
+
+// Invalidate drops the cached settings, so a change is seen on the next call.
+func (f *Flags) Invalidate() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.stored = nil
+	f.mu.Unlock()
+}
+
+// Enabled reports whether the flag is on for subject, usually a user ID.
+// Unknown flags are off.
+func (f *Flags) Enabled(ctx context.Context, key, subject string) bool {
+	def, ok := Lookup(key)
+	if !ok {
+		return false
+	}
+	var stored *models.FeatureFlag
+	if flag, ok := f.Stored(ctx)[key]; ok {
+		stored = &flag
+	}
+	return Evaluate(def, stored, f.Env(), subject)
+}
+
+// EnabledFor reports whether the flag is on for the request: for the
+// signed-in user, or for the client's IP address if nobody is signed in.
+func (f *Flags) EnabledFor(r *http.Request, key string) bool {
+	return f.Enabled(r.Context(), key, Subject(r))
+}
+
+// Subject returns the identity partial rollouts are decided by.
+func Subject(r *http.Request) string {
+	if u, ok := auth.CurrentUser(r); ok {
+		return u.ID
+	}
+	return network.GetClientIP(r)
+}
+
+// Require answers 404 Not Found while the flag is off for the request.
+func (f *Flags) Require(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !f.EnabledFor(r, key) {
+				http.NotFound(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RolloutLabel describes a flag setting's reach, e.g. "25% of users".
+func RolloutLabel(flag models.FeatureFlag) string {
+	if !flag.Enabled {
+		return "Off"
+	}
+	if flag.Rollout >= 100 {
+		return "Everyone"
+	}
+	return strconv.Itoa(flag.Rollout) + "% of users"
+}
@@ -0,0 +1,116 @@
+package featureflags
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+)
+
+func TestEvaluate(t *testing.T) {
+	def := Definition{Key: "beta", Default: models.FeatureFlag{Enabled: true, Rollout: 100}}
+	prodOnly := &models.FeatureFlag{Enabled: true, Environments: []string{"prod"}, Rollout: 100}
+
+	tests := []struct {
+		name   string
+		def    Definition
+		stored *models.FeatureFlag
+		env    string
+		want   bool
+	}{
+		{"default on", def, nil, "prod", true},
+		{"turned off", def, &models.FeatureFlag{Enabled: false, Rollout: 100}, "prod", false},
+		{"in listed environment", def, prodOnly, "prod", true},
+		{"outside listed environments", def, prodOnly, "dev", false},
+		{"no users", def, &models.FeatureFlag{Enabled: true, Rollout: 0}, "prod", false},
+		{"only in dev", Definition{Key: "dev", Default: models.FeatureFlag{Enabled: true, Rollout: 100}, OnlyIn: []string{"dev"}}, nil, "prod", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Evaluate(tt.def, tt.stored, tt.env, "user-1"); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Rollout(t *testing.T) {
+	def := Definition{Key: "beta"}
+	stored := &models.FeatureFlag{Enabled: true, Rollout: 25}
+
+	on := 0
+	for i := 0; i < 10000; i++ {
+		subject := "user-" + strconv.Itoa(i)
+		got := Evaluate(def, stored, "prod", subject)
+		if got != Evaluate(def, stored, "prod", subject) {
+			t.Fatalf("subject %s got different answers", subject)
+		}
+		if got {
+			on++
+		}
+	}
+	if on < 2200 || on > 2800 {
+		t.Errorf("25%% rollout reached %d of 10000 subjects", on)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	for _, env := range Environments {
+		trust, _ := Lookup(TrustLogin)
+		if got, want := Evaluate(trust, nil, env, ""), env == "dev"; got != want {
+			t.Errorf("trust login in %s = %v, want %v", env, got, want)
+		}
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup found an undefined flag")
+	}
+}
+
+func TestTrustLogin_OnlyInDev(t *testing.T) {
+	trust, _ := Lookup(TrustLogin)
+	everywhere := &models.FeatureFlag{Enabled: true, Rollout: 100}
+
+	for _, env := range []string{"prod", "staging", ""} {
+		if Evaluate(trust, everywhere, env, "user-1") {
+			t.Errorf("trust login enabled for all environments is on in %q", env)
+		}
+	}
+	if !Evaluate(trust, everywhere, "dev", "user-1") {
+		t.Error("trust login enabled for all environments is off in dev")
+	}
+}
+
+func TestNilFlags(t *testing.T) {
+	var f *Flags
+	ctx := context.Background()
+	if !f.Enabled(ctx, SelfRegistration, "") {
+		t.Error("nil Flags should use the default for self-registration")
+	}
+	if f.Enabled(ctx, TrustLogin, "") {
+		t.Error("nil Flags should leave environment-limited flags off")
+	}
+	if f.Enabled(ctx, "missing", "") {
+		t.Error("unknown flags should be off")
+	}
+	f.Invalidate()
+}
+
+func TestRequire(t *testing.T) {
+	var f *Flags
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	f.Require(TrustLogin)(next).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("flag off: status = %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	f.Require(APIV2)(next).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("flag on: status = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,243 @@
+// Package healthcheck probes the services the app depends on besides
+// MongoDB, such as file storage and the SMTP server, for the readiness
+// endpoint and the admin status page.
+//
+// Probes can be slow or cost money (a storage probe writes an object), so
+// results are cached: a component is probed again only once its last
+// result is older than the checker's TTL, however often it is asked.
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Names of the components the app probes.
+const (
+	ComponentStorage = "storage"
+	ComponentSMTP    = "smtp"
+)
+
+// probeTimeout bounds each probe.
+const probeTimeout = 5 * time.Second
+
+// canaryPrefix is where storage probes write their canary objects.
+const canaryPrefix = ".health/"
+
+// Component is a service the app depends on.
+type Component struct {
+	Name     string
+	Critical bool // The app is not ready while it fails
+	Probe    func(ctx context.Context) error
+}
+
+// Status is the last probe result of a component.
+type Status struct {
+	Name      string
+	Critical  bool
+	OK        bool
+	Error     string        // Why the probe failed
+	Latency   time.Duration // How long the probe took
+	CheckedAt time.Time
+	LastOK    time.Time // Zero if the probe has never passed
+	Failures  int       // Probes failed in a row
+}
+
+// Checker probes components and caches the results.
+type Checker struct {
+	ttl    time.Duration
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	components []Component
+	results    map[string]*Status
+}
+
+// New creates a Checker that probes a component at most once per ttl.
+func New(ttl time.Duration, logger *zap.Logger) *Checker {
+	return &Checker{
+		ttl:     ttl,
+		logger:  logger,
+		results: make(map[string]*Status),
+	}
+}
+
+// Add registers a component. Call it during startup.
+func (c *Checker) Add(comp Component) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.components = append(c.components, comp)
+}
+
+// Check returns the status of every component in the order they were
+// added, probing those whose last result is older than the TTL. The
+// probes run at once.
+func (c *Checker) Check(ctx context.Context) []Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	components := c.components
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, comp := range components {
+		if !c.stale(comp.Name) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.probe(ctx, comp)
+		}()
+	}
+	wg.Wait()
+	return c.Last()
+}
+
+// Last returns the status of every component probed so far, without
+// probing.
+func (c *Checker) Last() []Status {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	statuses := make([]Status, 0, len(c.components))
+	for _, comp := range c.components {
+		if s, ok := c.results[comp.Name]; ok {
+			statuses = append(statuses, *s)
+		}
+	}
+	return statuses
+}
+
+// Ready reports whether every critical component passed its last probe.
+func Ready(statuses []Status) bool {
+	for _, s := range statuses {
+		if s.Critical && !s.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// stale reports whether a component's last result is older than the TTL.
+func (c *Checker) stale(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.results[name]
+	return !ok || time.Since(s.CheckedAt) >= c.ttl
+}
+
+// probe runs one component's probe and records the result. The probe
+// isn't cut short if the request that asked for it goes away, as other
+// callers share the result.
+func (c *Checker) probe(ctx context.Context, comp Component) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := comp.Probe(ctx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.results[comp.Name]
+	if !ok {
+		s = &Status{Name: comp.Name}
+		c.results[comp.Name] = s
+	}
+	wasOK := ok && s.OK
+	s.Critical = comp.Critical
+	s.OK = err == nil
+	s.Latency = latency
+	s.CheckedAt = start
+	if err != nil {
+		s.Error = err.Error()
+		s.Failures++
+		if wasOK || !ok {
+			c.logger.Warn("health probe failed",
+				zap.String("component", comp.Name),
+				zap.Bool("critical", comp.Critical),
+				zap.Error(err))
+		}
+		return
+	}
+	if ok && !wasOK {
+		c.logger.Info("health probe recovered",
+			zap.String("component", comp.Name),
+			zap.Int("failures", s.Failures))
+	}
+	s.Error = ""
+	s.LastOK = start
+	s.Failures = 0
+}
+
+// StorageProbe returns a probe that writes a small canary object to
+// store, reads it back, and deletes it.
+func StorageProbe(store storage.Store) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if store == nil {
+			return errors.New("file storage is not available")
+		}
+		path := canaryPrefix + uuid.NewString()
+		want := []byte("stratasave health probe " + time.Now().UTC().Format(time.RFC3339Nano))
+
+		if err := store.Put(ctx, path, bytes.NewReader(want), &storage.PutOptions{ContentType: "text/plain"}); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+		deleted := false
+		defer func() {
+			if !deleted {
+				// Don't leave the canary behind if the read failed or
+				// timed out
+				dctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), probeTimeout)
+				defer cancel()
+				_ = store.Delete(dctx, path)
+			}
+		}()
+
+		rc, err := store.Get(ctx, path)
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("read: %w", err)
+		}
+		if !bytes.Equal(got, want) {
+			return errors.New("read: content differs from what was written")
+		}
+		deleted = true
+		if err := store.Delete(ctx, path); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		return nil
+	}
+}
+
+// MailerProbe returns a probe that connects to the mailer's SMTP server
+// and waits for its greeting.
+func MailerProbe(m *mailer.Mailer) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if m == nil {
+			return errors.New("email is not configured")
+		}
+		return m.Ping(ctx)
+	}
+}
@@ -0,0 +1,92 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.uber.org/zap"
+)
+
+func TestChecker(t *testing.T) {
+	probes := 0
+	var fail error
+	c := New(time.Hour, zap.NewNop())
+	c.Add(Component{Name: "storage", Critical: true, Probe: func(context.Context) error {
+		probes++
+		return fail
+	}})
+
+	got := c.Check(context.Background())
+	if len(got) != 1 || !got[0].OK || got[0].LastOK.IsZero() {
+		t.Fatalf("Check = %+v", got)
+	}
+	if !Ready(got) {
+		t.Error("Ready = false with the component passing")
+	}
+
+	// Within the TTL the result is reused
+	c.Check(context.Background())
+	if probes != 1 {
+		t.Errorf("probes = %d, want 1", probes)
+	}
+
+	c.ttl = 0
+	fail = errors.New("bucket gone")
+	c.Check(context.Background())
+	got = c.Check(context.Background())
+	if got[0].OK || got[0].Error != "bucket gone" || got[0].Failures != 2 {
+		t.Errorf("after failures = %+v", got[0])
+	}
+	if got[0].LastOK.IsZero() {
+		t.Error("LastOK lost after a failure")
+	}
+	if Ready(got) {
+		t.Error("Ready = true with a critical component failing")
+	}
+
+	fail = nil
+	if got = c.Check(context.Background()); !got[0].OK || got[0].Failures != 0 || got[0].Error != "" {
+		t.Errorf("after recovery = %+v", got[0])
+	}
+}
+
+func TestReady_NonCritical(t *testing.T) {
+	if !Ready([]Status{{Name: "smtp", OK: false}}) {
+		t.Error("a failing non-critical component should not make the app not ready")
+	}
+}
+
+func TestNilChecker(t *testing.T) {
+	var c *Checker
+	c.Add(Component{Name: "storage"})
+	if c.Check(context.Background()) != nil || c.Last() != nil {
+		t.Error("nil Checker should report nothing")
+	}
+}
+
+func TestStorageProbe(t *testing.T) {
+	store := storage.NewMemory(storage.MemoryConfig{})
+	if err := StorageProbe(store)(context.Background()); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	res, err := store.List(context.Background(), canaryPrefix, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Objects) != 0 {
+		t.Errorf("probe left %d canary objects behind", len(res.Objects))
+	}
+
+	if err := StorageProbe(nil)(context.Background()); err == nil {
+		t.Error("probe of nil store: expected error")
+	}
+}
+
+func TestMailerProbe_NotConfigured(t *testing.T) {
+	if err := MailerProbe(nil)(context.Background()); err == nil {
+		t.Error("probe of nil mailer: expected error")
+	}
+}
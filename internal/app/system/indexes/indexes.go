@@ -31,6 +31,9 @@ func EnsureAll(ctx context.Context, db *mongo.Database) error {
 	if err := ensurePages(ctx, db); err != nil {
 		problems = append(problems, "pages: "+err.Error())
 	}
+	if err := ensurePageRevisions(ctx, db); err != nil {
+		problems = append(problems, "page_revisions: "+err.Error())
+	}
 	if err := ensureEmailVerifications(ctx, db); err != nil {
 		problems = append(problems, "email_verifications: "+err.Error())
 	}
@@ -40,9 +43,15 @@ func EnsureAll(ctx context.Context, db *mongo.Database) error {
 	if err := ensureSiteSettings(ctx, db); err != nil {
 		problems = append(problems, "site_settings: "+err.Error())
 	}
+	if err := ensureFeatureFlags(ctx, db); err != nil {
+		problems = append(problems, "feature_flags: "+err.Error())
+	}
 	if err := ensureAuditLogs(ctx, db); err != nil {
 		problems = append(problems, "audit_logs: "+err.Error())
 	}
+	if err := ensureAuditArchives(ctx, db); err != nil {
+		problems = append(problems, "audit_archives: "+err.Error())
+	}
 	if err := ensureSessions(ctx, db); err != nil {
 		problems = append(problems, "sessions: "+err.Error())
 	}
@@ -70,12 +79,72 @@ func EnsureAll(ctx context.Context, db *mongo.Database) error {
 	if err := ensureJobs(ctx, db); err != nil {
 		problems = append(problems, "jobs: "+err.Error())
 	}
+	if err := ensureScheduleRuns(ctx, db); err != nil {
+		problems = append(problems, "schedule_runs: "+err.Error())
+	}
 	if err := ensureDailyStats(ctx, db); err != nil {
 		problems = append(problems, "daily_stats: "+err.Error())
 	}
 	if err := ensureSavedFilters(ctx, db); err != nil {
 		problems = append(problems, "saved_filters: "+err.Error())
 	}
+	if err := ensureEmailOutbox(ctx, db); err != nil {
+		problems = append(problems, "email_outbox: "+err.Error())
+	}
+	if err := ensureEmailSuppressions(ctx, db); err != nil {
+		problems = append(problems, "email_suppressions: "+err.Error())
+	}
+	if err := ensureEmailLog(ctx, db); err != nil {
+		problems = append(problems, "email_log: "+err.Error())
+	}
+	if err := ensureKnownDevices(ctx, db); err != nil {
+		problems = append(problems, "known_devices: "+err.Error())
+	}
+	if err := ensureBackupCodes(ctx, db); err != nil {
+		problems = append(problems, "backup_codes: "+err.Error())
+	}
+	if err := ensureIPBlocks(ctx, db); err != nil {
+		problems = append(problems, "ip_blocks: "+err.Error())
+	}
+	if err := ensureLoginFailures(ctx, db); err != nil {
+		problems = append(problems, "login_failures: "+err.Error())
+	}
+	if err := ensureLoginLocations(ctx, db); err != nil {
+		problems = append(problems, "login_locations: "+err.Error())
+	}
+	if err := ensureFileUploads(ctx, db); err != nil {
+		problems = append(problems, "file_uploads: "+err.Error())
+	}
+	if err := ensureFileShares(ctx, db); err != nil {
+		problems = append(problems, "file_shares: "+err.Error())
+	}
+	if err := ensureFileBlobs(ctx, db); err != nil {
+		problems = append(problems, "file_blobs: "+err.Error())
+	}
+	if err := ensureFileAccess(ctx, db); err != nil {
+		problems = append(problems, "file_access: "+err.Error())
+	}
+	if err := ensureGroups(ctx, db); err != nil {
+		problems = append(problems, "groups: "+err.Error())
+	}
+	if err := ensureGroupMemberships(ctx, db); err != nil {
+		problems = append(problems, "group_memberships: "+err.Error())
+	}
+	if err := ensureGroupResourceAssignments(ctx, db); err != nil {
+		problems = append(problems, "group_resource_assignments: "+err.Error())
+	}
+	if err := ensureAnnouncementAcks(ctx, db); err != nil {
+		problems = append(problems, "announcement_acks: "+err.Error())
+	}
+	if err := ensureAnnouncementDismissals(ctx, db); err != nil {
+		problems = append(problems, "announcement_dismissals: "+err.Error())
+	}
+	if err := ensureWebhooks(ctx, db); err != nil {
+		problems = append(problems, "webhooks: "+err.Error())
+	}
+	if err := ensureWebhookDeliveries(ctx, db); err != nil {
+		problems = append(problems, "webhook_deliveries: "+err.Error())
+	}
 
 	if len(problems) > 0 {
 		return errors.New(strings.Join(problems, "; "))
@@ -101,6 +170,32 @@ func keySig(keys bson.D) string {
 	return strings.Join(parts, ", ")
 }
 
+// dropIndexIfExists drops an index that has been replaced by one with a
+// different key pattern.
+func dropIndexIfExists(ctx context.Context, coll *mongo.Collection, name string) error {
+	cur, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var idx existingIndex
+		if err := cur.Decode(&idx); err != nil {
+			continue
+		}
+		if idx.Name == name {
+			if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+				return fmt.Errorf("%s(%s): drop failed: %w", coll.Name(), name, err)
+			}
+			zap.L().Info("dropped replaced index",
+				zap.String("collection", coll.Name()),
+				zap.String("name", name))
+			return nil
+		}
+	}
+	return cur.Err()
+}
+
 func sameBoolPtr(a, b *bool) bool {
 	av := false
 	bv := false
@@ -298,6 +393,32 @@ func ensureUsers(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetName("idx_users_role_status_loginidci_id"),
 		},
+
+		// User list sorted by last login, and the never-logged-in filter
+		{
+			Keys: bson.D{
+				{Key: "last_login_at", Value: 1},
+				{Key: "full_name_ci", Value: 1},
+				{Key: "_id", Value: 1},
+			},
+			Options: options.Index().SetName("idx_users_lastlogin_fullnameci_id"),
+		},
+
+		// Scheduled deactivation: only users with a disable date are indexed
+		{
+			Keys: bson.D{
+				{Key: "disable_at", Value: 1},
+			},
+			Options: options.Index().SetSparse(true).SetName("idx_users_disableat"),
+		},
+
+		// A Google account can be linked to only one user
+		{
+			Keys: bson.D{
+				{Key: "google_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetSparse(true).SetName("uniq_users_google_id"),
+		},
 	})
 }
 
@@ -316,6 +437,32 @@ func ensurePages(ctx context.Context, db *mongo.Database) error {
 	})
 }
 
+func ensurePageRevisions(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("page_revisions")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One revision per version of a page; also serves the history,
+		// newest first
+		{
+			Keys: bson.D{
+				{Key: "slug", Value: 1},
+				{Key: "version", Value: -1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_page_revisions_slug_version"),
+		},
+	})
+}
+
+func ensureFeatureFlags(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("feature_flags")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One setting per flag
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_feature_flags_key"),
+		},
+	})
+}
+
 func ensureEmailVerifications(ctx context.Context, db *mongo.Database) error {
 	c := db.Collection("email_verifications")
 	return ensureIndexSet(ctx, c, []mongo.IndexModel{
@@ -345,6 +492,15 @@ func ensureEmailVerifications(ctx context.Context, db *mongo.Database) error {
 			Options: options.Index().
 				SetName("idx_emailverify_user"),
 		},
+		// Per-address lookup (code verification, resend interval)
+		{
+			Keys: bson.D{
+				{Key: "email", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().
+				SetName("idx_emailverify_email_created"),
+		},
 	})
 }
 
@@ -421,6 +577,27 @@ func ensureAuditLogs(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetName("idx_audit_actor_created"),
 		},
+		// Hash chain order; unique so concurrent writers can't share a link
+		{
+			Keys: bson.D{
+				{Key: "seq", Value: 1},
+			},
+			Options: options.Index().SetName("uniq_audit_seq").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"seq": bson.M{"$exists": true}}),
+		},
+	})
+}
+
+func ensureAuditArchives(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("audit_archives")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Last archived chain event, where verification starts
+		{
+			Keys: bson.D{
+				{Key: "last_seq", Value: -1},
+			},
+			Options: options.Index().SetName("idx_auditarchive_last_seq"),
+		},
 	})
 }
 
@@ -524,15 +701,36 @@ func ensureRateLimits(ctx context.Context, db *mongo.Database) error {
 
 func ensureFileFolders(ctx context.Context, db *mongo.Database) error {
 	c := db.Collection("file_folders")
+	// Replaced by uniq_folder_parent_name_trash, which lets a trashed folder
+	// keep its name
+	if err := dropIndexIfExists(ctx, c, "uniq_folder_parent_name"); err != nil {
+		return err
+	}
 	return ensureIndexSet(ctx, c, []mongo.IndexModel{
-		// Unique folder name within parent (prevents duplicate folder names)
+		// Unique folder name within parent (prevents duplicate folder names).
+		// Live folders have no deleted_at, so only they must be unique.
 		// This index also serves for listing folders by parent, sorted by name
 		{
 			Keys: bson.D{
 				{Key: "parent_id", Value: 1},
 				{Key: "name_ci", Value: 1},
+				{Key: "deleted_at", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_folder_parent_name_trash"),
+		},
+		// Trash listing and purge
+		{
+			Keys: bson.D{
+				{Key: "deleted_at", Value: 1},
 			},
-			Options: options.Index().SetUnique(true).SetName("uniq_folder_parent_name"),
+			Options: options.Index().SetSparse(true).SetName("idx_folder_deleted"),
+		},
+		// Restore/delete of everything trashed along with a folder
+		{
+			Keys: bson.D{
+				{Key: "trashed_with_id", Value: 1},
+			},
+			Options: options.Index().SetSparse(true).SetName("idx_folder_trashed_with"),
 		},
 		// List folders by parent, sorted by date
 		{
@@ -542,20 +740,53 @@ func ensureFileFolders(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetName("idx_folder_parent_created"),
 		},
+		// Library search across names and descriptions, names ranked higher.
+		// A collection can have only one text index
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().SetWeights(bson.D{
+				{Key: "name", Value: 5},
+				{Key: "description", Value: 1},
+			}).SetName("idx_folder_text"),
+		},
 	})
 }
 
 func ensureFiles(ctx context.Context, db *mongo.Database) error {
 	c := db.Collection("files")
+	// Replaced by uniq_file_folder_name_trash, which lets a trashed file keep
+	// its name
+	if err := dropIndexIfExists(ctx, c, "uniq_file_folder_name"); err != nil {
+		return err
+	}
 	return ensureIndexSet(ctx, c, []mongo.IndexModel{
-		// Unique filename within folder (prevents duplicate filenames)
+		// Unique filename within folder (prevents duplicate filenames).
+		// Live files have no deleted_at, so only they must be unique.
 		// This index also serves for listing files by folder, sorted by name
 		{
 			Keys: bson.D{
 				{Key: "folder_id", Value: 1},
 				{Key: "name_ci", Value: 1},
+				{Key: "deleted_at", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_file_folder_name_trash"),
+		},
+		// Trash listing and purge
+		{
+			Keys: bson.D{
+				{Key: "deleted_at", Value: 1},
+			},
+			Options: options.Index().SetSparse(true).SetName("idx_file_deleted"),
+		},
+		// Restore/delete of everything trashed along with a folder
+		{
+			Keys: bson.D{
+				{Key: "trashed_with_id", Value: 1},
 			},
-			Options: options.Index().SetUnique(true).SetName("uniq_file_folder_name"),
+			Options: options.Index().SetSparse(true).SetName("idx_file_trashed_with"),
 		},
 		// List files by folder, sorted by date
 		{
@@ -572,6 +803,25 @@ func ensureFiles(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetName("idx_file_content_type"),
 		},
+		// Filter files by tag, and list the tags in use
+		{
+			Keys: bson.D{
+				{Key: "tags", Value: 1},
+			},
+			Options: options.Index().SetName("idx_file_tags"),
+		},
+		// Library search across names and descriptions, names ranked higher.
+		// A collection can have only one text index
+		{
+			Keys: bson.D{
+				{Key: "name", Value: "text"},
+				{Key: "description", Value: "text"},
+			},
+			Options: options.Index().SetWeights(bson.D{
+				{Key: "name", Value: 5},
+				{Key: "description", Value: 1},
+			}).SetName("idx_file_text"),
+		},
 	})
 }
 
@@ -601,6 +851,23 @@ func ensureLedgerEntries(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetName("idx_ledger_actor"),
 		},
+		// Player queries (support looking up one player's requests)
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "game", Value: 1},
+				{Key: "started_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_ledger_player"),
+		},
+		// Game queries
+		{
+			Keys: bson.D{
+				{Key: "game", Value: 1},
+				{Key: "started_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_ledger_game"),
+		},
 		// Path queries
 		{
 			Keys: bson.D{
@@ -625,7 +892,68 @@ func ensureLedgerEntries(ctx context.Context, db *mongo.Database) error {
 			},
 			Options: options.Index().SetSparse(true).SetName("idx_ledger_error_class"),
 		},
+		// Removal of expired full capture bodies
+		{
+			Keys:    bson.D{{Key: "capture_expires_at", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("idx_ledger_capture_expires"),
+		},
+	})
+}
+
+// ledgerTTLIndex is the name of the TTL index that removes ledger entries.
+const ledgerTTLIndex = "idx_ledger_ttl"
+
+// EnsureLedgerTTL makes ledger_entries' TTL index remove entries ttl after
+// they started. The index is created, updated in place when ttl changes,
+// or dropped when ttl is zero (entries kept forever, or archived instead).
+func EnsureLedgerTTL(ctx context.Context, db *mongo.Database, ttl time.Duration) error {
+	c := db.Collection("ledger_entries")
+	seconds := int32(ttl / time.Second)
+
+	cur, err := c.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var existing []struct {
+		Name               string `bson:"name"`
+		ExpireAfterSeconds *int32 `bson:"expireAfterSeconds,omitempty"`
+	}
+	if err := cur.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	for _, idx := range existing {
+		if idx.Name != ledgerTTLIndex {
+			continue
+		}
+		switch {
+		case seconds <= 0:
+			zap.L().Info("dropping ledger TTL index")
+			_, err := c.Indexes().DropOne(ctx, ledgerTTLIndex)
+			return err
+		case idx.ExpireAfterSeconds != nil && *idx.ExpireAfterSeconds == seconds:
+			return nil
+		default:
+			zap.L().Info("updating ledger TTL index", zap.Duration("ttl", ttl))
+			return db.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: "ledger_entries"},
+				{Key: "index", Value: bson.D{
+					{Key: "name", Value: ledgerTTLIndex},
+					{Key: "expireAfterSeconds", Value: seconds},
+				}},
+			}).Err()
+		}
+	}
+
+	if seconds <= 0 {
+		return nil
+	}
+	zap.L().Info("creating ledger TTL index", zap.Duration("ttl", ttl))
+	_, err = c.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "started_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(seconds).SetName(ledgerTTLIndex),
 	})
+	return err
 }
 
 func ensureAPIKeys(ctx context.Context, db *mongo.Database) error {
@@ -712,6 +1040,25 @@ func ensureJobs(ctx context.Context, db *mongo.Database) error {
 	})
 }
 
+func ensureScheduleRuns(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("schedule_runs")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Run history per job, newest first
+		{
+			Keys: bson.D{
+				{Key: "job", Value: 1},
+				{Key: "started_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_schedule_run_job_started"),
+		},
+		// Retention cleanup
+		{
+			Keys:    bson.D{{Key: "started_at", Value: 1}},
+			Options: options.Index().SetName("idx_schedule_run_started"),
+		},
+	})
+}
+
 func ensureDailyStats(ctx context.Context, db *mongo.Database) error {
 	c := db.Collection("daily_stats")
 	return ensureIndexSet(ctx, c, []mongo.IndexModel{
@@ -757,3 +1104,362 @@ func ensureSavedFilters(ctx context.Context, db *mongo.Database) error {
 		},
 	})
 }
+
+func ensureEmailOutbox(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("email_outbox")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// List by status, newest first
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_outbox_status_created"),
+		},
+		// Recipient search
+		{
+			Keys: bson.D{
+				{Key: "to", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_outbox_to_created"),
+		},
+		// Retention cleanup of sent messages
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "sent_at", Value: 1},
+			},
+			Options: options.Index().SetName("idx_outbox_status_sent"),
+		},
+	})
+}
+
+func ensureEmailSuppressions(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("email_suppressions")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One entry per address; also serves the send-time lookup
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_suppression_email"),
+		},
+		// List by reason, most recent first
+		{
+			Keys: bson.D{
+				{Key: "reason", Value: 1},
+				{Key: "last_event_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_suppression_reason_last_event"),
+		},
+		// List all, most recent first
+		{
+			Keys:    bson.D{{Key: "last_event_at", Value: -1}},
+			Options: options.Index().SetName("idx_suppression_last_event"),
+		},
+	})
+}
+
+func ensureEmailLog(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("email_log")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// List all, newest first; also serves retention cleanup
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("idx_email_log_created"),
+		},
+		// Per-user history
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_email_log_user_created"),
+		},
+		// Recipient search
+		{
+			Keys: bson.D{
+				{Key: "to", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_email_log_to_created"),
+		},
+		// List by status, newest first
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_email_log_status_created"),
+		},
+	})
+}
+
+func ensureKnownDevices(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("known_devices")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One entry per user/device; also serves the login-time lookup
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "fingerprint", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_device_user_fingerprint"),
+		},
+	})
+}
+
+func ensureBackupCodes(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("backup_codes")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Redeem looks up a user's code by hash
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "code_hash", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_backup_code_user_hash"),
+		},
+	})
+}
+
+func ensureIPBlocks(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("ip_blocks")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One block per IP; also serves the login-time lookup
+		{
+			Keys: bson.D{
+				{Key: "ip", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_ip_block_ip"),
+		},
+		// TTL: remove blocks once they lapse
+		{
+			Keys: bson.D{
+				{Key: "blocked_until", Value: 1},
+			},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("idx_ip_block_ttl"),
+		},
+	})
+}
+
+func ensureLoginFailures(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("login_failures")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Accounts tried from an IP within the detection window
+		{
+			Keys: bson.D{
+				{Key: "ip", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_login_failures_ip_created"),
+		},
+		// TTL: failures only matter for a day
+		{
+			Keys: bson.D{
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetExpireAfterSeconds(86400).SetName("idx_login_failures_ttl"),
+		},
+	})
+}
+
+func ensureLoginLocations(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("login_locations")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// One location per user
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_login_location_user"),
+		},
+	})
+}
+
+func ensureFileUploads(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("file_uploads")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// The cleanup job finds uploads that stopped making progress
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("idx_file_upload_expires"),
+		},
+	})
+}
+
+func ensureFileShares(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("file_shares")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Public downloads look up a link by its token
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_file_share_token"),
+		},
+		// The manage modal lists a file's links, newest first
+		{
+			Keys: bson.D{
+				{Key: "file_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_file_share_file_created"),
+		},
+	})
+}
+
+func ensureFileBlobs(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("file_blobs")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Purging and copying a file find its content by storage path
+		{
+			Keys:    bson.D{{Key: "storage_path", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_file_blob_path"),
+		},
+	})
+}
+
+func ensureFileAccess(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("file_access")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// The access report lists a file's records, newest first
+		{
+			Keys: bson.D{
+				{Key: "file_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_file_access_file_created"),
+		},
+	})
+}
+
+func ensureGroups(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("groups")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Group names are unique ignoring case; the list sorts by name
+		{
+			Keys:    bson.D{{Key: "name_ci", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_groups_nameci"),
+		},
+	})
+}
+
+func ensureGroupMemberships(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("group_memberships")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// A user is in a group at most once; also serves a user's groups
+		{
+			Keys: bson.D{
+				{Key: "user_id", Value: 1},
+				{Key: "group_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_gm_user_group"),
+		},
+		// The group page lists leaders first, then members, in the order added
+		{
+			Keys: bson.D{
+				{Key: "group_id", Value: 1},
+				{Key: "role", Value: 1},
+				{Key: "created_at", Value: 1},
+			},
+			Options: options.Index().SetName("idx_gm_group_role_created"),
+		},
+	})
+}
+
+func ensureAnnouncementAcks(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("announcement_acks")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// A user acknowledges an announcement once; also serves the report
+		{
+			Keys: bson.D{
+				{Key: "announcement_id", Value: 1},
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_aa_announcement_user"),
+		},
+		// Banners look up which announcements the user has acknowledged
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_aa_user"),
+		},
+	})
+}
+
+func ensureAnnouncementDismissals(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("announcement_dismissals")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// A user dismisses an announcement once; also serves the counts
+		{
+			Keys: bson.D{
+				{Key: "announcement_id", Value: 1},
+				{Key: "user_id", Value: 1},
+			},
+			Options: options.Index().SetUnique(true).SetName("uniq_ad_announcement_user"),
+		},
+		// Banners look up which announcements the user has dismissed
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("idx_ad_user"),
+		},
+	})
+}
+
+func ensureGroupResourceAssignments(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("group_resource_assignments")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// The group page lists its assignments, newest first
+		{
+			Keys: bson.D{
+				{Key: "group_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_assign_group_created"),
+		},
+	})
+}
+
+func ensureWebhooks(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("webhooks")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Unique name per endpoint
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("uniq_webhook_name"),
+		},
+		// Active endpoints, loaded for each published event
+		{
+			Keys:    bson.D{{Key: "active", Value: 1}},
+			Options: options.Index().SetName("idx_webhook_active"),
+		},
+	})
+}
+
+func ensureWebhookDeliveries(ctx context.Context, db *mongo.Database) error {
+	c := db.Collection("webhook_deliveries")
+	return ensureIndexSet(ctx, c, []mongo.IndexModel{
+		// Delivery history per endpoint, newest first
+		{
+			Keys: bson.D{
+				{Key: "endpoint_id", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_webhook_delivery_endpoint_created"),
+		},
+		// History filtered by status, and counts per status
+		{
+			Keys: bson.D{
+				{Key: "endpoint_id", Value: 1},
+				{Key: "status", Value: 1},
+				{Key: "created_at", Value: -1},
+			},
+			Options: options.Index().SetName("idx_webhook_delivery_endpoint_status_created"),
+		},
+		// Retention cleanup of delivered webhooks
+		{
+			Keys: bson.D{
+				{Key: "status", Value: 1},
+				{Key: "delivered_at", Value: 1},
+			},
+			Options: options.Index().SetName("idx_webhook_delivery_status_delivered"),
+		},
+	})
+}
@@ -0,0 +1,171 @@
+// Package invitationexpiry follows up on invitations nobody has accepted.
+// Invitees are emailed a reminder before their invitation expires, and once
+// it expires the invitation is marked expired and the admin who sent it is
+// told, so they can resend or extend it from the invitations list.
+//
+// Expired invitations are kept until the invitation cleanup job removes
+// them, 30 days after they expire.
+package invitationexpiry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// dateFormat is how expiry times are written in emails.
+const dateFormat = "January 2, 2006 at 3:04 PM MST"
+
+// Scheduler reminds invitees and marks expired invitations.
+type Scheduler struct {
+	invitations *invitation.Store
+	users       *userstore.Store
+	settings    *settingsstore.Store
+	mail        *mailer.Mailer // nil if no one is emailed
+	reminder    time.Duration
+	baseURL     string
+	logger      *zap.Logger
+}
+
+// New creates a Scheduler that reminds invitees reminder ahead of expiry.
+// No one is emailed if mail is nil, and invitees aren't reminded if
+// reminder is zero or less. baseURL is used to build links in emails.
+func New(db *mongo.Database, mail *mailer.Mailer, reminder time.Duration, baseURL string, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		invitations: invitation.New(db, 0), // expiry is only used to create and extend
+		users:       userstore.New(db),
+		settings:    settingsstore.New(db),
+		mail:        mail,
+		reminder:    reminder,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		logger:      logger,
+	}
+}
+
+// Jobs returns the background job that sends reminders and marks expired
+// invitations.
+func (s *Scheduler) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "invitation-expiry",
+		Interval: 1 * time.Hour,
+		Schedule: "30 * * * *",
+		Run:      func(ctx context.Context) error { return s.Run(ctx, time.Now()) },
+	}}
+}
+
+// Run reminds invitees whose invitations expire within the reminder
+// period, then marks invitations that expired by now.
+func (s *Scheduler) Run(ctx context.Context, now time.Time) error {
+	appName := models.DefaultSiteName
+	if st, err := s.settings.Get(ctx); err == nil && st.SiteName != "" {
+		appName = st.SiteName
+	}
+
+	if s.mail != nil && s.reminder > 0 {
+		if err := s.remind(ctx, now, appName); err != nil {
+			return err
+		}
+	}
+	return s.expire(ctx, now, appName)
+}
+
+// remind sends one reminder per invitation that expires within the
+// reminder period.
+func (s *Scheduler) remind(ctx context.Context, now time.Time, appName string) error {
+	due, err := s.invitations.ListReminderDue(ctx, now, now.Add(s.reminder))
+	if err != nil {
+		return err
+	}
+	var reminded int
+	for i := range due {
+		inv := &due[i]
+		// Mark first so a failing mail server doesn't cause repeated reminders
+		ok, err := s.invitations.MarkReminded(ctx, inv.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		reminded++
+		if err := s.mail.Send(mailer.Email{
+			To:       inv.Email,
+			Subject:  "Reminder: Your invitation to " + appName + " expires soon",
+			Template: "invitation_reminder",
+			TextBody: "You were invited to join " + appName + ", and your invitation hasn't been used yet.\n\n" +
+				"Click the link below to set up your account:\n\n" +
+				s.baseURL + "/invite?token=" + inv.Token + "\n\n" +
+				"This invitation expires on " + inv.ExpiresAt.UTC().Format(dateFormat) + ".\n\n" +
+				"If you did not expect this invitation, you can safely ignore this email.",
+		}); err != nil {
+			s.logger.Warn("failed to send invitation reminder",
+				zap.String("invitation_id", inv.ID.Hex()), zap.Error(err))
+		}
+	}
+	if reminded > 0 {
+		s.logger.Info("sent invitation reminders", zap.Int("invitations", reminded))
+	}
+	return nil
+}
+
+// expire marks each invitation that expired by now and tells the admin who
+// sent it.
+func (s *Scheduler) expire(ctx context.Context, now time.Time, appName string) error {
+	due, err := s.invitations.ListExpireDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var expired int
+	for i := range due {
+		inv := &due[i]
+		ok, err := s.invitations.MarkExpired(ctx, inv.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue // used, revoked, or extended since it was listed
+		}
+		expired++
+		if s.mail != nil {
+			s.notifyInviter(ctx, inv, appName)
+		}
+	}
+
+	if expired > 0 {
+		s.logger.Info("marked invitations expired", zap.Int("invitations", expired))
+	}
+	return nil
+}
+
+// notifyInviter emails the admin who sent an invitation that it expired
+// without being accepted.
+func (s *Scheduler) notifyInviter(ctx context.Context, inv *invitation.Invitation, appName string) {
+	u, err := s.users.GetByID(ctx, inv.InvitedBy)
+	if err != nil || u.Email == nil || *u.Email == "" {
+		return // inviter gone or has no email address
+	}
+	if err := s.mail.Send(mailer.Email{
+		To:       *u.Email,
+		Subject:  fmt.Sprintf("[%s] Invitation to %s expired", appName, inv.Email),
+		Template: "invitation_expired",
+		UserID:   u.ID.Hex(),
+		TextBody: "The invitation you sent to " + inv.Email + " expired on " +
+			inv.ExpiresAt.UTC().Format(dateFormat) + " without being accepted.\n\n" +
+			"You can resend it, or extend it so the original link works again, from the Invitations page:\n\n" +
+			s.baseURL + "/invitations",
+	}); err != nil {
+		s.logger.Warn("failed to send invitation expired notice",
+			zap.String("invitation_id", inv.ID.Hex()), zap.Error(err))
+	}
+}
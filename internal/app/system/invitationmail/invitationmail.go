@@ -0,0 +1,179 @@
+// Package invitationmail writes invitation emails and sends bulk
+// invitations from the job queue. A send_invitations job emails a batch of
+// invitations and reports how many have gone out, so an admin can follow a
+// large bulk invitation in the jobs console.
+package invitationmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// SendJob is the job type that emails a batch of invitations.
+const SendJob = "send_invitations"
+
+// Email returns the email inviting inv's recipient. baseURL is used to
+// build the accept link, and defaultDays is the expiry shown for
+// invitations created without one.
+func Email(inv *invitation.Invitation, inviter, appName string, brand mailer.Brand, baseURL string, defaultDays int) mailer.Email {
+	days := inv.ExpiryDays
+	if days == 0 {
+		days = defaultDays
+	}
+	text, html := mailer.InvitationEmail(mailer.InvitationEmailData{
+		Brand:         brand,
+		AppName:       appName,
+		InviterName:   inviter,
+		RecipientName: inv.Email,
+		Role:          inv.Role,
+		AcceptURL:     strings.TrimRight(baseURL, "/") + "/invite?token=" + inv.Token,
+		ExpiresIn:     mailer.InvitationExpiresIn("", days),
+		Message:       inv.Message,
+	})
+	return mailer.Email{
+		To:       inv.Email,
+		Subject:  mailer.T("", "invitation.heading"),
+		Template: "invitation",
+		TextBody: text,
+		HTMLBody: html,
+	}
+}
+
+// Sender queues and sends bulk invitation emails.
+type Sender struct {
+	invitations *invitation.Store
+	settings    *settingsstore.Store
+	jobs        *jobstore.Store
+	mail        *mailer.Mailer
+	baseURL     string
+	defaultDays int
+	logger      *zap.Logger
+}
+
+// New creates a Sender that emails with mail. inviteExpiry is the default
+// invitation lifetime, shown for invitations created without their own.
+func New(db *mongo.Database, mail *mailer.Mailer, baseURL string, inviteExpiry time.Duration, logger *zap.Logger) *Sender {
+	return &Sender{
+		invitations: invitation.New(db, inviteExpiry),
+		settings:    settingsstore.New(db),
+		jobs:        jobstore.New(db),
+		mail:        mail,
+		baseURL:     baseURL,
+		defaultDays: max(int(inviteExpiry/(24*time.Hour)), 1),
+		logger:      logger,
+	}
+}
+
+// Queue schedules a job that emails the given invitations on behalf of
+// inviter. It returns the job's ID, for following its progress.
+func (s *Sender) Queue(ctx context.Context, ids []primitive.ObjectID, inviter string) (primitive.ObjectID, error) {
+	hexIDs := make([]string, len(ids))
+	for i, id := range ids {
+		hexIDs[i] = id.Hex()
+	}
+	// One attempt: a retry would email the invitees already reached again
+	job, err := s.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   mailer.BulkQueue,
+		JobType:     SendJob,
+		Payload:     map[string]any{"invitation_ids": hexIDs, "inviter": inviter},
+		MaxAttempts: 1,
+	})
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("enqueue invitation email job: %w", err)
+	}
+	return job.ID, nil
+}
+
+// Register adds the bulk email queue and the invitation job handler to r.
+func (s *Sender) Register(r *jobrunner.Runner) {
+	r.AddQueue(mailer.BulkQueue)
+	r.Register(SendJob, s.handle)
+}
+
+// handle emails the invitations in a send_invitations job, reporting
+// progress after each batch. Invitations revoked or used since they were
+// queued are skipped.
+func (s *Sender) handle(ctx context.Context, payload map[string]any) (map[string]any, error) {
+	if s.mail == nil {
+		return map[string]any{"skipped": "email is off"}, nil
+	}
+	inviter, _ := payload["inviter"].(string)
+
+	appName := models.DefaultSiteName
+	if st, err := s.settings.Get(ctx); err == nil && st.SiteName != "" {
+		appName = st.SiteName
+	}
+	brand := s.mail.Brand(ctx)
+
+	jobrunner.ReportStep(ctx, "Preparing emails")
+	var emails []mailer.Email
+	for _, idStr := range payloadStrings(payload["invitation_ids"]) {
+		id, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			continue
+		}
+		inv, err := s.invitations.GetByID(ctx, id)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load invitation: %w", err)
+		}
+		if inv.UsedAt != nil || inv.Revoked {
+			continue
+		}
+		emails = append(emails, Email(inv, inviter, appName, brand, s.baseURL, s.defaultDays))
+	}
+
+	jobrunner.ReportStep(ctx, "Emailing invitations")
+	p, err := s.mail.SendBulk(ctx, emails, func(p mailer.BulkProgress) {
+		jobrunner.ReportProgress(ctx, int64(p.Done()), int64(p.Total))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if p.Failed > 0 {
+		s.logger.Warn("some invitation emails were not sent",
+			zap.Int("failed", p.Failed),
+			zap.Int("total", p.Total))
+		return nil, fmt.Errorf("%d of %d invitation emails could not be sent", p.Failed, p.Total)
+	}
+	return map[string]any{"sent": p.Sent, "suppressed": p.Suppressed}, nil
+}
+
+// payloadStrings reads a list of strings from a job payload, which comes
+// back from MongoDB as a generic array.
+func payloadStrings(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case primitive.A:
+		return anyStrings(list)
+	case []any:
+		return anyStrings(list)
+	}
+	return nil
+}
+
+func anyStrings(list []any) []string {
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
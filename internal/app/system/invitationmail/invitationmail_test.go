@@ -0,0 +1,148 @@
+package invitationmail
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/invitation"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/testutil"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type fakeQueue struct{ emails []mailer.Email }
+
+func (q *fakeQueue) Enqueue(ctx context.Context, email mailer.Email) error {
+	q.emails = append(q.emails, email)
+	return nil
+}
+
+// closedPort returns a local port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestPayloadStrings(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want []string
+	}{
+		{"strings", []string{"a", "b"}, []string{"a", "b"}},
+		{"from MongoDB", primitive.A{"a", 7, "b"}, []string{"a", "b"}},
+		{"from JSON", []any{"a", nil}, []string{"a"}},
+		{"missing", nil, nil},
+		{"not a list", "a", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := payloadStrings(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payloadStrings(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmail(t *testing.T) {
+	inv := &invitation.Invitation{Email: "new@example.com", Token: "tok123", Role: "admin"}
+
+	e := Email(inv, "Sam", "Strata", mailer.Brand{}, "https://example.com/", 7)
+	if e.To != "new@example.com" || e.Template != "invitation" {
+		t.Errorf("Email() = to %q, template %q", e.To, e.Template)
+	}
+	if !strings.Contains(e.TextBody, "https://example.com/invite?token=tok123") {
+		t.Errorf("text body missing the accept URL without a doubled slash:\n%s", e.TextBody)
+	}
+	if !strings.Contains(e.TextBody, mailer.InvitationExpiresIn("", 7)) {
+		t.Errorf("text body should use the default expiry of 7 days:\n%s", e.TextBody)
+	}
+
+	inv.ExpiryDays = 3
+	e = Email(inv, "Sam", "Strata", mailer.Brand{}, "https://example.com", 7)
+	if !strings.Contains(e.TextBody, mailer.InvitationExpiresIn("", 3)) {
+		t.Errorf("text body should use the invitation's own expiry of 3 days:\n%s", e.TextBody)
+	}
+}
+
+func TestHandle_MailOff(t *testing.T) {
+	s := &Sender{logger: zap.NewNop()}
+	result, err := s.handle(context.Background(), map[string]any{"invitation_ids": []string{"x"}})
+	if err != nil || result["skipped"] == nil {
+		t.Errorf("handle() = %v, %v; want skipped", result, err)
+	}
+}
+
+func TestHandle_SkipsClosedInvitations(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	q := &fakeQueue{}
+	m := mailer.New(mailer.Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	m.SetQueue(q)
+	s := New(db, m, "https://example.com", 7*24*time.Hour, zap.NewNop())
+
+	var ids primitive.A
+	for _, email := range []string{"open@example.com", "revoked@example.com", "used@example.com"} {
+		inv, err := s.invitations.Create(ctx, invitation.CreateInput{Email: email, Role: "admin"})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		ids = append(ids, inv.ID.Hex())
+		switch email {
+		case "revoked@example.com":
+			if err := s.invitations.Revoke(ctx, inv.ID); err != nil {
+				t.Fatalf("Revoke() error = %v", err)
+			}
+		case "used@example.com":
+			if err := s.invitations.MarkUsed(ctx, inv.ID); err != nil {
+				t.Fatalf("MarkUsed() error = %v", err)
+			}
+		}
+	}
+	// An invitation deleted since the job was queued, and a bad ID
+	ids = append(ids, primitive.NewObjectID().Hex(), "not-an-id")
+
+	result, err := s.handle(ctx, map[string]any{"invitation_ids": ids, "inviter": "Sam"})
+	if err != nil {
+		t.Fatalf("handle() error = %v", err)
+	}
+	if len(q.emails) != 1 || q.emails[0].To != "open@example.com" {
+		t.Errorf("queued %+v, want one email to open@example.com", q.emails)
+	}
+	if result["sent"] != 1 {
+		t.Errorf("result = %v, want sent 1", result)
+	}
+}
+
+func TestHandle_FailedSendsFailJob(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	ctx, cancel := testutil.TestContext()
+	defer cancel()
+
+	// No queue and nothing listening, so every delivery fails
+	m := mailer.New(mailer.Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	s := New(db, m, "https://example.com", 7*24*time.Hour, zap.NewNop())
+
+	inv, err := s.invitations.Create(ctx, invitation.CreateInput{Email: "new@example.com", Role: "admin"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	_, err = s.handle(ctx, map[string]any{"invitation_ids": []string{inv.ID.Hex()}})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1") {
+		t.Errorf("handle() error = %v, want 1 of 1 emails failed", err)
+	}
+}
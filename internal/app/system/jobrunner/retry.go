@@ -0,0 +1,51 @@
+// internal/app/system/jobrunner/retry.go
+package jobrunner
+
+import (
+	"errors"
+	"time"
+)
+
+// Backoff returns how long to wait before retrying a job whose attempt-th
+// attempt failed: base, doubled for each earlier failure, and capped at
+// max. A max of zero leaves it uncapped.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if max > 0 && delay >= max {
+			return max
+		}
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// permanentError marks an error that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying. A handler that returns it
+// sends its job straight to the dead-letter queue, whatever attempts it
+// has left.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
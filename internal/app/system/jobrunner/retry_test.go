@@ -0,0 +1,53 @@
+package jobrunner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	base := 30 * time.Second
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+		want    time.Duration
+	}{
+		{0, time.Hour, 30 * time.Second},
+		{1, time.Hour, 30 * time.Second},
+		{2, time.Hour, time.Minute},
+		{4, time.Hour, 4 * time.Minute},
+		{8, time.Hour, time.Hour},
+		{100, time.Hour, time.Hour},
+		{4, 0, 4 * time.Minute},
+		{1, 10 * time.Second, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := Backoff(base, tt.max, tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%v, %v, %d) = %v, want %v", base, tt.max, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+
+	base := errors.New("invalid payload")
+	err := fmt.Errorf("handle job: %w", Permanent(base))
+	if !IsPermanent(err) {
+		t.Error("IsPermanent() = false for a wrapped permanent error")
+	}
+	if !errors.Is(err, base) {
+		t.Error("permanent error does not unwrap to its cause")
+	}
+	if err.Error() != "handle job: invalid payload" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if IsPermanent(base) {
+		t.Error("IsPermanent() = true for a plain error")
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/system/distlock"
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -24,10 +26,14 @@ type Config struct {
 	// PollInterval is how often to poll for new jobs.
 	PollInterval time.Duration
 
-	// RetryDelay is the base delay before retrying a failed job.
-	// Actual delay is RetryDelay * attempts (exponential backoff).
+	// RetryDelay is the base delay before retrying a failed job. It
+	// doubles with each failed attempt (see Backoff).
 	RetryDelay time.Duration
 
+	// MaxRetryDelay caps the delay between retries. Zero leaves it
+	// uncapped.
+	MaxRetryDelay time.Duration
+
 	// StaleJobThreshold is how long a job can be "running" before it's considered stale.
 	// Stale jobs are re-queued automatically.
 	StaleJobThreshold time.Duration
@@ -45,6 +51,7 @@ func DefaultConfig() Config {
 		WorkerCount:       3,
 		PollInterval:      time.Second,
 		RetryDelay:        5 * time.Second,
+		MaxRetryDelay:     time.Hour,
 		StaleJobThreshold: 5 * time.Minute,
 		CleanupInterval:   time.Hour,
 		JobRetention:      7 * 24 * time.Hour, // 7 days
@@ -59,6 +66,7 @@ type Runner struct {
 	logger   *zap.Logger
 
 	workerID   string
+	elector    *distlock.Elector // Leader election for cleanup; nil runs it here
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 	running    atomic.Int32
@@ -93,6 +101,12 @@ func (r *Runner) Register(jobType string, handler JobHandler) {
 	r.handlers[jobType] = handler
 }
 
+// SetElector limits cleanup to the leader instance, so with several
+// instances it runs in one place.
+func (r *Runner) SetElector(e *distlock.Elector) {
+	r.elector = e
+}
+
 // AddQueue registers a queue name for processing.
 func (r *Runner) AddQueue(queueName string) {
 	r.mu.Lock()
@@ -242,9 +256,14 @@ func (r *Runner) processNextJob(ctx context.Context, queueName, workerName strin
 		r.logger.Error("no handler registered for job type",
 			zap.String("job_type", job.JobType),
 			zap.String("job_id", job.ID.Hex()))
-		// Fail the job
+		// No attempt will succeed, so dead-letter the job
 		failCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		_ = r.store.Fail(failCtx, job.ID, fmt.Sprintf("no handler for job type: %s", job.JobType), r.config.RetryDelay)
+		_ = r.store.DeadLetter(failCtx, job.ID, jobstore.Failure{
+			Attempt:  job.Attempts,
+			Error:    fmt.Sprintf("no handler for job type: %s", job.JobType),
+			WorkerID: workerName,
+			FailedAt: time.Now(),
+		})
 		cancel()
 		return
 	}
@@ -258,13 +277,14 @@ func (r *Runner) processNextJob(ctx context.Context, queueName, workerName strin
 
 	// Create job context with timeout
 	jobCtx, jobCancel := context.WithTimeout(ctx, r.config.StaleJobThreshold)
+	jobCtx = context.WithValue(jobCtx, progressKey{}, &progressReporter{r: r, id: job.ID})
 	result, err := handler(jobCtx, job.Payload)
 	jobCancel()
 
 	duration := time.Since(start)
 
 	if err != nil {
-		retryDelay := r.config.RetryDelay * time.Duration(job.Attempts)
+		retryDelay := Backoff(r.config.RetryDelay, r.config.MaxRetryDelay, job.Attempts)
 
 		r.logger.Warn("job failed",
 			zap.String("job_id", job.ID.Hex()),
@@ -274,14 +294,37 @@ func (r *Runner) processNextJob(ctx context.Context, queueName, workerName strin
 			zap.Duration("duration", duration),
 			zap.Error(err))
 
-		// Mark job as failed
+		// Record the failure; the job is retried after its backoff unless
+		// it is out of attempts or the error is permanent
+		failure := jobstore.Failure{
+			Attempt:    job.Attempts,
+			Error:      err.Error(),
+			WorkerID:   workerName,
+			DurationMs: duration.Milliseconds(),
+			FailedAt:   time.Now(),
+		}
 		failCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		if failErr := r.store.Fail(failCtx, job.ID, err.Error(), retryDelay); failErr != nil {
+		dead := IsPermanent(err)
+		var failErr error
+		if dead {
+			failErr = r.store.DeadLetter(failCtx, job.ID, failure)
+		} else {
+			dead, failErr = r.store.Fail(failCtx, job.ID, failure, retryDelay)
+		}
+		cancel()
+		if failErr != nil {
 			r.logger.Error("failed to mark job as failed",
 				zap.String("job_id", job.ID.Hex()),
 				zap.Error(failErr))
+			return
+		}
+		if dead {
+			r.logger.Error("job moved to dead-letter queue",
+				zap.String("job_id", job.ID.Hex()),
+				zap.String("job_type", job.JobType),
+				zap.Int("attempts", job.Attempts),
+				zap.Error(err))
 		}
-		cancel()
 		return
 	}
 
@@ -320,8 +363,12 @@ func (r *Runner) cleanup(ctx context.Context) {
 	}
 }
 
-// runCleanup performs cleanup tasks.
+// runCleanup performs cleanup tasks when this instance is the leader.
 func (r *Runner) runCleanup(ctx context.Context) {
+	if !r.elector.IsLeader() {
+		return
+	}
+
 	// Cleanup stale running jobs
 	staleCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	count, err := r.store.CleanupStaleRunning(staleCtx, r.config.StaleJobThreshold)
@@ -359,6 +406,68 @@ func (r *Runner) EnqueueAt(ctx context.Context, queueName, jobType string, paylo
 	return r.store.EnqueueAt(ctx, queueName, jobType, payload, at)
 }
 
+// progressInterval is the least time between progress writes for a job.
+// Reports in between are kept and written with the next one.
+const progressInterval = time.Second
+
+// progressKey is the context key for a job's progressReporter.
+type progressKey struct{}
+
+// progressReporter records progress for the job being handled.
+type progressReporter struct {
+	r  *Runner
+	id primitive.ObjectID
+
+	mu       sync.Mutex
+	progress jobstore.Progress
+	saved    time.Time
+}
+
+// ReportProgress records how far the job handled with ctx has got, as
+// done out of total, for the jobs console. It does nothing outside a job
+// handler. Writes are limited to one a second, except the one that
+// reaches the total. Failures are logged; the job carries on.
+func ReportProgress(ctx context.Context, done, total int64) {
+	p, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.progress.Done, p.progress.Total = done, total
+	p.save(ctx, done >= total)
+}
+
+// ReportStep records the step the job handled with ctx is on, such as
+// "Uploading archive", for the jobs console. The count from
+// ReportProgress is kept. It does nothing outside a job handler.
+func ReportStep(ctx context.Context, step string) {
+	p, ok := ctx.Value(progressKey{}).(*progressReporter)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changed := p.progress.Step != step
+	p.progress.Step = step
+	p.save(ctx, changed)
+}
+
+// save writes the progress if force is set or progressInterval has passed
+// since the last write. The caller holds p.mu.
+func (p *progressReporter) save(ctx context.Context, force bool) {
+	now := time.Now()
+	if !force && now.Sub(p.saved) < progressInterval {
+		return
+	}
+	p.saved = now
+	if err := p.r.store.SetProgress(ctx, p.id, p.progress); err != nil && ctx.Err() == nil {
+		p.r.logger.Warn("failed to record job progress",
+			zap.String("job_id", p.id.Hex()),
+			zap.Error(err))
+	}
+}
+
 // Stats returns current runner statistics.
 type Stats struct {
 	WorkerID    string
@@ -0,0 +1,157 @@
+// internal/app/system/ledger/capture.go
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.uber.org/zap"
+)
+
+// MaskedValue replaces the value of every masked field and header.
+const MaskedValue = "[masked]"
+
+// DefaultMaskFields are the field names masked when none are configured.
+var DefaultMaskFields = []string{"password", "token", "secret", "api_key", "apikey"}
+
+// capturing reports whether path is one of the full capture routes.
+func (cfg Config) capturing(path string) bool {
+	for _, prefix := range cfg.CapturePaths {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskedName reports whether a field or header name contains one of the
+// mask fields, ignoring case. "token" masks "access_token" and "X-Token".
+func maskedName(name string, fields []string) bool {
+	name = strings.ToLower(name)
+	for _, f := range fields {
+		if f != "" && strings.Contains(name, strings.ToLower(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskBody returns body with the values of fields named in fields replaced
+// by MaskedValue. JSON bodies are masked at any depth and form bodies by
+// parameter name. A body that is neither, or that doesn't parse, is
+// returned unchanged, as is one with nothing to mask.
+func MaskBody(body []byte, contentType string, fields []string) string {
+	if len(fields) == 0 || len(body) == 0 {
+		return string(body)
+	}
+
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+		masked := false
+		for name, vals := range values {
+			if maskedName(name, fields) {
+				for i := range vals {
+					vals[i] = MaskedValue
+				}
+				masked = true
+			}
+		}
+		if !masked {
+			return string(body)
+		}
+		return values.Encode()
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if !strings.Contains(contentType, "json") && !bytes.HasPrefix(trimmed, []byte("{")) && !bytes.HasPrefix(trimmed, []byte("[")) {
+		return string(body)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return string(body)
+	}
+	if !maskJSON(doc, fields) {
+		return string(body)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return string(body)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// maskJSON masks matching keys in a decoded JSON value in place and
+// reports whether anything was masked.
+func maskJSON(v any, fields []string) bool {
+	masked := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if maskedName(k, fields) {
+				t[k] = MaskedValue
+				masked = true
+			} else if maskJSON(child, fields) {
+				masked = true
+			}
+		}
+	case []any:
+		for _, child := range t {
+			if maskJSON(child, fields) {
+				masked = true
+			}
+		}
+	}
+	return masked
+}
+
+// captureHeaders returns every request header, with credentials and
+// headers named like a mask field replaced by MaskedValue.
+func captureHeaders(h http.Header, fields []string) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, vals := range h {
+		switch {
+		case strings.EqualFold(name, "Authorization"),
+			strings.EqualFold(name, "Proxy-Authorization"),
+			strings.EqualFold(name, "Cookie"),
+			maskedName(name, fields):
+			headers[name] = MaskedValue
+		default:
+			headers[name] = strings.Join(vals, ", ")
+		}
+	}
+	return headers
+}
+
+// CaptureExpiryJob creates a job that removes the bodies of fully captured
+// ledger entries once their capture has expired.
+func CaptureExpiryJob(store *ledgerstore.Store, logger *zap.Logger) tasks.Job {
+	return tasks.Job{
+		Name:     "ledger-capture-expiry",
+		Interval: 15 * time.Minute,
+		Run: func(ctx context.Context) error {
+			n, err := store.ExpireCapturedBodies(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				logger.Info("removed expired ledger capture bodies",
+					zap.Int64("entries", n))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package ledger
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMaskBody(t *testing.T) {
+	fields := []string{"password", "token"}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "json top level",
+			body:        `{"user":"ann","password":"hunter2"}`,
+			contentType: "application/json",
+			want:        `{"password":"[masked]","user":"ann"}`,
+		},
+		{
+			name:        "json nested and case-insensitive",
+			body:        `{"data":[{"Access_Token":"abc","n":1.50}]}`,
+			contentType: "application/json; charset=utf-8",
+			want:        `{"data":[{"Access_Token":"[masked]","n":1.50}]}`,
+		},
+		{
+			name:        "json without content type",
+			body:        `{"token":"abc"}`,
+			contentType: "",
+			want:        `{"token":"[masked]"}`,
+		},
+		{
+			name:        "json with nothing to mask is unchanged",
+			body:        `{ "b": 1, "a": "<x>" }`,
+			contentType: "application/json",
+			want:        `{ "b": 1, "a": "<x>" }`,
+		},
+		{
+			name:        "form",
+			body:        "user=ann&password=hunter2",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "password=%5Bmasked%5D&user=ann",
+		},
+		{
+			name:        "invalid json is unchanged",
+			body:        `{"password":`,
+			contentType: "application/json",
+			want:        `{"password":`,
+		},
+		{
+			name:        "plain text is unchanged",
+			body:        "password=hunter2",
+			contentType: "text/plain",
+			want:        "password=hunter2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskBody([]byte(tt.body), tt.contentType, fields); got != tt.want {
+				t.Errorf("MaskBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if got := MaskBody([]byte(`{"password":"x"}`), "application/json", nil); got != `{"password":"x"}` {
+		t.Errorf("MaskBody() with no fields = %s, want body unchanged", got)
+	}
+}
+
+func TestCaptureHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer sk_live_abc")
+	h.Set("Cookie", "session=abc")
+	h.Set("X-Refresh-Token", "abc")
+	h.Set("Content-Type", "application/json")
+
+	got := captureHeaders(h, []string{"token"})
+	for _, name := range []string{"Authorization", "Cookie", "X-Refresh-Token"} {
+		if got[name] != MaskedValue {
+			t.Errorf("header %s = %q, want %q", name, got[name], MaskedValue)
+		}
+	}
+	if got["Content-Type"] != "application/json" {
+		t.Errorf("header Content-Type = %q, want application/json", got["Content-Type"])
+	}
+}
+
+func TestCapturing(t *testing.T) {
+	cfg := Config{CapturePaths: []string{"/api/v1/state/save", ""}}
+	if !cfg.capturing("/api/v1/state/save") {
+		t.Error("capturing(/api/v1/state/save) = false, want true")
+	}
+	if cfg.capturing("/api/v1/state/load") {
+		t.Error("capturing(/api/v1/state/load) = true, want false")
+	}
+	if (Config{}).capturing("/api/v1/state/save") {
+		t.Error("capturing() with no paths = true, want false")
+	}
+}
@@ -6,13 +6,16 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/dalemusser/stratasave/internal/app/store/ledger"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"github.com/dalemusser/stratasave/internal/app/system/requestid"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -59,6 +62,30 @@ type Config struct {
 	// OnlyErrors if true, only logs requests that result in errors (status >= 400).
 	// This is useful for capturing API errors without logging all successful requests.
 	OnlyErrors bool
+
+	// SampleSuccesses logs one in this many successful requests (status
+	// below 400) when OnlyErrors is set, so the ledger shows a baseline of
+	// normal traffic. Sampled entries record the rate in SampleRate. Zero
+	// logs errors only.
+	SampleSuccesses int
+
+	// CapturePaths lists path prefixes in full capture mode. Every request to
+	// them is logged, even with OnlyErrors set, along with its full request
+	// and response bodies and all of its request headers.
+	CapturePaths []string
+
+	// MaxCaptureBody is the largest request or response body kept in full
+	// capture; larger bodies are left out. Default: 1MB.
+	MaxCaptureBody int
+
+	// CaptureTTL is how long captured bodies are kept before CaptureExpiryJob
+	// removes them. Zero keeps them.
+	CaptureTTL time.Duration
+
+	// MaskFields names the body fields and headers whose values are replaced
+	// with MaskedValue before anything is stored. Names match without regard
+	// to case and anywhere in the field name.
+	MaskFields []string
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -68,6 +95,8 @@ func DefaultConfig(store *ledgerstore.Store, logger *zap.Logger) Config {
 		Logger:         logger,
 		MaxBodyPreview: 500,
 		MaxBodyOnError: 1024 * 1024, // 1MB
+		MaxCaptureBody: 1024 * 1024, // 1MB
+		MaskFields:     DefaultMaskFields,
 		HeadersToCapture: []string{
 			"Content-Type",
 			"Accept",
@@ -113,8 +142,12 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				}
 			}
 
-			// Generate request ID
-			requestID := uuid.New().String()
+			// Share the request ID set by the requestid middleware, so the
+			// entry matches the request's logs and audit events
+			requestID := requestid.FromContext(r.Context())
+			if requestID == "" {
+				requestID = uuid.New().String()
+			}
 
 			// Check for client-provided request ID
 			clientRequestID := r.Header.Get("X-Request-ID")
@@ -128,12 +161,21 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				phases: make(map[string]float64),
 			}
 
+			// Full capture keeps both bodies, masked, for routes being debugged
+			capture := cfg.capturing(path)
+			captureLimit := cfg.MaxCaptureBody
+			if captureLimit <= 0 {
+				captureLimit = 1024 * 1024
+			}
+
 			// Capture request body if needed
 			var bodyPreview string
 			var bodyFull string
+			var bodyCaptured string
 			var bodyHash string
 			var bodySize int64
-			if (cfg.MaxBodyPreview > 0 || cfg.MaxBodyOnError > 0) && r.Body != nil && r.ContentLength > 0 {
+			var player playerFields
+			if (cfg.MaxBodyPreview > 0 || cfg.MaxBodyOnError > 0 || capture) && r.Body != nil && r.ContentLength > 0 {
 				body, err := io.ReadAll(r.Body)
 				if err == nil {
 					bodySize = int64(len(body))
@@ -142,9 +184,15 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 						hash := sha256.Sum256(body)
 						bodyHash = hex.EncodeToString(hash[:])[:8]
 
+						// Note which player and game the request is about
+						player = parsePlayer(body)
+
+						// Mask sensitive fields before anything is kept
+						masked := MaskBody(body, r.Header.Get("Content-Type"), cfg.MaskFields)
+
 						// Capture preview (truncate if needed)
 						if cfg.MaxBodyPreview > 0 {
-							preview := string(body)
+							preview := masked
 							if len(preview) > cfg.MaxBodyPreview {
 								preview = preview[:cfg.MaxBodyPreview] + "..."
 							}
@@ -153,7 +201,11 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 
 						// Capture full body for potential error logging
 						if cfg.MaxBodyOnError > 0 && len(body) <= cfg.MaxBodyOnError {
-							bodyFull = string(body)
+							bodyFull = masked
+						}
+
+						if capture && len(body) <= captureLimit {
+							bodyCaptured = masked
 						}
 					}
 					// Restore body for handler
@@ -161,19 +213,24 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				}
 			}
 
-			// Capture headers
-			headers := make(map[string]string)
-			for _, name := range cfg.HeadersToCapture {
-				if value := r.Header.Get(name); value != "" {
-					// Redact sensitive values
-					if strings.EqualFold(name, "Authorization") {
-						if len(value) > 10 {
-							headers[name] = value[:10] + "..."
+			// Capture headers (all of them in full capture)
+			var headers map[string]string
+			if capture {
+				headers = captureHeaders(r.Header, cfg.MaskFields)
+			} else {
+				headers = make(map[string]string)
+				for _, name := range cfg.HeadersToCapture {
+					if value := r.Header.Get(name); value != "" {
+						// Redact sensitive values
+						if strings.EqualFold(name, "Authorization") {
+							if len(value) > 10 {
+								headers[name] = value[:10] + "..."
+							} else {
+								headers[name] = "[redacted]"
+							}
 						} else {
-							headers[name] = "[redacted]"
+							headers[name] = value
 						}
-					} else {
-						headers[name] = value
 					}
 				}
 			}
@@ -190,12 +247,11 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				actorName = user.Name
 			}
 
-			// Check for API key (set by API key middleware)
-			if apiKeyID := r.Header.Get("X-API-Key-ID"); apiKeyID != "" {
-				actorType = "api_key"
-				actorID = apiKeyID
-				actorName = r.Header.Get("X-API-Key-Name")
-			}
+			// API key attribution headers are set by the API key middleware,
+			// which runs inside this one. Drop any client-supplied values here
+			// and read the real ones once the handler chain has run.
+			r.Header.Del(auth.APIKeyIDHeader)
+			r.Header.Del(auth.APIKeyNameHeader)
 
 			// Create initial entry
 			entry := &ledgerstore.Entry{
@@ -210,6 +266,8 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				ActorType:          actorType,
 				ActorID:            actorID,
 				ActorName:          actorName,
+				UserID:             player.UserID,
+				Game:               player.Game,
 				RequestBodySize:    bodySize,
 				RequestBodyHash:    bodyHash,
 				RequestBodyPreview: bodyPreview,
@@ -228,6 +286,10 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				ResponseWriter: w,
 				statusCode:     http.StatusOK,
 			}
+			if capture {
+				wrapped.body = &bytes.Buffer{}
+				wrapped.bodyLimit = captureLimit
+			}
 
 			// Call next handler
 			next.ServeHTTP(wrapped, r)
@@ -236,6 +298,13 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 			endTime := time.Now()
 			timing.TotalMs = float64(endTime.Sub(startTime).Microseconds()) / 1000.0
 
+			// Attribute the request to the API key, if one authenticated it
+			if apiKeyID := r.Header.Get(auth.APIKeyIDHeader); apiKeyID != "" {
+				entry.ActorType = "api_key"
+				entry.ActorID = apiKeyID
+				entry.ActorName = r.Header.Get(auth.APIKeyNameHeader)
+			}
+
 			// Complete entry
 			entry.StatusCode = wrapped.statusCode
 			entry.ResponseSize = wrapped.bytesWritten
@@ -278,9 +347,32 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 				}
 			}
 
+			// Keep the full bodies of captured requests until the capture expires
+			if capture {
+				entry.Captured = true
+				if bodyCaptured != "" {
+					entry.RequestBody = bodyCaptured
+				}
+				if !wrapped.bodyTooLarge {
+					entry.ResponseBody = MaskBody(wrapped.body.Bytes(), w.Header().Get("Content-Type"), cfg.MaskFields)
+				}
+				if cfg.CaptureTTL > 0 {
+					expires := endTime.Add(cfg.CaptureTTL)
+					entry.CaptureExpiresAt = &expires
+				}
+			}
+
+			// With OnlyErrors, keep a sample of successful requests as a baseline
+			sampled := false
+			if cfg.OnlyErrors && !capture && wrapped.statusCode < 400 && sample(cfg.SampleSuccesses) {
+				sampled = true
+				entry.SampleRate = cfg.SampleSuccesses
+			}
+
 			// Store entry asynchronously to not block response
-			// If OnlyErrors is set, only store entries for error responses (status >= 400)
-			if !cfg.OnlyErrors || wrapped.statusCode >= 400 {
+			// If OnlyErrors is set, only store entries for error responses (status >= 400),
+			// unless the route is in full capture or the request was sampled
+			if !cfg.OnlyErrors || wrapped.statusCode >= 400 || capture || sampled {
 				go func() {
 					storeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 					defer cancel()
@@ -295,11 +387,48 @@ func Middleware(cfg Config) func(http.Handler) http.Handler {
 	}
 }
 
+// sample reports whether to keep a request logged at a rate of one in n.
+func sample(n int) bool {
+	return n == 1 || n > 1 && rand.IntN(n) == 0
+}
+
+// maxPlayerField bounds the user_id and game kept on an entry.
+const maxPlayerField = 200
+
+// playerFields are the request body fields the game APIs use to name the
+// player and game a request is about.
+type playerFields struct {
+	UserID string `json:"user_id"`
+	Game   string `json:"game"`
+}
+
+// parsePlayer returns the user_id and game from a JSON request body. Fields
+// that are missing or not strings are left empty.
+func parsePlayer(body []byte) playerFields {
+	var p playerFields
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		return p
+	}
+	// A type error on one field still fills the others
+	_ = json.Unmarshal(body, &p)
+	if len(p.UserID) > maxPlayerField {
+		p.UserID = p.UserID[:maxPlayerField]
+	}
+	if len(p.Game) > maxPlayerField {
+		p.Game = p.Game[:maxPlayerField]
+	}
+	return p
+}
+
 // responseWrapper wraps http.ResponseWriter to capture status code and bytes written.
+// In full capture it also keeps the response body, up to bodyLimit bytes.
 type responseWrapper struct {
 	http.ResponseWriter
 	statusCode   int
 	bytesWritten int64
+	body         *bytes.Buffer
+	bodyLimit    int
+	bodyTooLarge bool
 }
 
 func (rw *responseWrapper) WriteHeader(code int) {
@@ -310,6 +439,14 @@ func (rw *responseWrapper) WriteHeader(code int) {
 func (rw *responseWrapper) Write(b []byte) (int, error) {
 	n, err := rw.ResponseWriter.Write(b)
 	rw.bytesWritten += int64(n)
+	if rw.body != nil && !rw.bodyTooLarge {
+		if rw.body.Len()+n > rw.bodyLimit {
+			rw.bodyTooLarge = true
+			rw.body.Reset()
+		} else {
+			rw.body.Write(b[:n])
+		}
+	}
 	return n, err
 }
 
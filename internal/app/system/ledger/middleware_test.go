@@ -0,0 +1,59 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePlayer(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantUserID string
+		wantGame   string
+	}{
+		{"save request", `{"user_id":"player123","game":"mygame","save_data":{"level":3}}`, "player123", "mygame"},
+		{"leading whitespace", "\n  {\"game\":\"mygame\",\"user_id\":\"p1\"}", "p1", "mygame"},
+		{"missing game", `{"user_id":"p1"}`, "p1", ""},
+		{"non-string user_id", `{"user_id":42,"game":"mygame"}`, "", "mygame"},
+		{"invalid json", `{"user_id":"p1",`, "", ""},
+		{"array", `[{"user_id":"p1"}]`, "", ""},
+		{"empty", ``, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePlayer([]byte(tt.body))
+			if got.UserID != tt.wantUserID || got.Game != tt.wantGame {
+				t.Errorf("parsePlayer() = %+v, want user_id %q game %q", got, tt.wantUserID, tt.wantGame)
+			}
+		})
+	}
+
+	long := strings.Repeat("x", maxPlayerField+50)
+	if got := parsePlayer([]byte(`{"user_id":"` + long + `"}`)); len(got.UserID) != maxPlayerField {
+		t.Errorf("parsePlayer() kept %d bytes of a long user_id, want %d", len(got.UserID), maxPlayerField)
+	}
+}
+
+func TestSample(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if sample(0) {
+			t.Fatal("sample(0) = true, want false")
+		}
+		if !sample(1) {
+			t.Fatal("sample(1) = false, want true")
+		}
+	}
+
+	const n, runs = 10, 20000
+	kept := 0
+	for i := 0; i < runs; i++ {
+		if sample(n) {
+			kept++
+		}
+	}
+	// Expect about runs/n; allow a wide margin so the test isn't flaky
+	if want := runs / n; kept < want/2 || kept > want*2 {
+		t.Errorf("sample(%d) kept %d of %d, want about %d", n, kept, runs, want)
+	}
+}
@@ -0,0 +1,221 @@
+// Package ledgerarchive archives request ledger entries past their
+// retention: they are written to the storage backend as gzip-compressed
+// NDJSON and then deleted from MongoDB.
+//
+// Archiving replaces the TTL index that otherwise removes old entries. A
+// scheduled task queues an archive job on the "ledger" queue, so each run
+// and what it archived show in the jobs UI.
+package ledgerarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"time"
+
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/store/ledger"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue archive runs are queued on.
+	QueueName = "ledger"
+	// ArchiveJob is the job type that archives old ledger entries.
+	ArchiveJob = "archive_ledger_entries"
+)
+
+const (
+	// Interval is how often an archive job is queued.
+	Interval = 1 * time.Hour
+
+	// batchSize is how many entries go in one archive file.
+	batchSize = 5000
+
+	// maxBatches bounds how many files one run writes, so a large backlog
+	// is spread over several runs.
+	maxBatches = 40
+
+	// keyPrefix is where archive files are stored.
+	keyPrefix = "ledger-archive/"
+)
+
+// Result is what one archive run did.
+type Result struct {
+	Files    int
+	Archived int64
+	Deleted  int64
+}
+
+// Map returns the result as a job result, shown in the jobs UI.
+func (r Result) Map() map[string]any {
+	return map[string]any{
+		"files":    r.Files,
+		"archived": r.Archived,
+		"deleted":  r.Deleted,
+	}
+}
+
+// Archiver moves ledger entries past their retention to storage.
+type Archiver struct {
+	ledger    *ledgerstore.Store
+	jobs      *jobstore.Store
+	storage   storage.Store
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// New creates an Archiver. A zero retention turns archiving off; the TTL
+// index then governs how long entries are kept.
+func New(db *mongo.Database, st storage.Store, retention time.Duration, logger *zap.Logger) *Archiver {
+	return &Archiver{
+		ledger:    ledgerstore.New(db),
+		jobs:      jobstore.New(db),
+		storage:   st,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Register adds the ledger queue and its job handler to r.
+func (a *Archiver) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(ArchiveJob, a.handleArchive)
+}
+
+// Jobs returns the background task that queues an archive run every
+// Interval, or nothing when archiving is off.
+func (a *Archiver) Jobs() []tasks.Job {
+	if a.retention == 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "ledger-archive",
+		Interval: Interval,
+		Run:      a.enqueue,
+	}}
+}
+
+// enqueue queues an archive run, unless one is already waiting or running.
+func (a *Archiver) enqueue(ctx context.Context) error {
+	stats, err := a.jobs.GetQueueStats(ctx, QueueName)
+	if err != nil {
+		return fmt.Errorf("reading ledger queue: %w", err)
+	}
+	if stats.Pending > 0 || stats.Running > 0 {
+		return nil
+	}
+	// A failed run is picked up again by the next scheduled one
+	_, err = a.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     ArchiveJob,
+		MaxAttempts: 1,
+	})
+	return err
+}
+
+// handleArchive runs an archive job, returning what it did as its result.
+func (a *Archiver) handleArchive(ctx context.Context, _ map[string]any) (map[string]any, error) {
+	res, err := a.Run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res.Map(), nil
+}
+
+// Run archives and deletes entries older than the retention, a batch at a
+// time, until none are left or maxBatches files have been written. It
+// stops at the first failure, returning what was done before it.
+func (a *Archiver) Run(ctx context.Context) (Result, error) {
+	var res Result
+	if a.retention == 0 {
+		return res, nil
+	}
+	cutoff := time.Now().Add(-a.retention)
+
+	for res.Files < maxBatches {
+		entries, err := a.ledger.ArchivableBatch(ctx, cutoff, batchSize)
+		if err != nil {
+			return res, fmt.Errorf("reading ledger entries: %w", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+		deleted, err := a.archive(ctx, entries)
+		if err != nil {
+			return res, err
+		}
+		res.Files++
+		res.Archived += int64(len(entries))
+		res.Deleted += deleted
+	}
+
+	if res.Files > 0 {
+		a.logger.Info("archived ledger entries",
+			zap.Int("files", res.Files),
+			zap.Int64("archived", res.Archived),
+			zap.Int64("deleted", res.Deleted))
+	}
+	return res, nil
+}
+
+// archive writes entries to one archive file and deletes them. If the
+// delete fails the entries stay and are archived again by a later run, so
+// nothing is lost.
+func (a *Archiver) archive(ctx context.Context, entries []ledgerstore.Entry) (int64, error) {
+	data, err := Encode(entries)
+	if err != nil {
+		return 0, fmt.Errorf("encoding ledger archive: %w", err)
+	}
+
+	// Batches are read oldest first
+	key := ArchiveKey(entries[0].StartedAt, primitive.NewObjectID())
+	if err := a.storage.PutBytes(ctx, key, data, &storage.PutOptions{ContentType: "application/gzip"}); err != nil {
+		return 0, fmt.Errorf("writing ledger archive %s: %w", key, err)
+	}
+
+	ids := make([]primitive.ObjectID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	deleted, err := a.ledger.DeleteByIDs(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("deleting archived ledger entries: %w", err)
+	}
+	return deleted, nil
+}
+
+// ArchiveKey returns the storage path for an archive file whose oldest
+// entry started at from, grouped by year and month.
+func ArchiveKey(from time.Time, id primitive.ObjectID) string {
+	from = from.UTC()
+	return fmt.Sprintf("%s%s/ledger-%s-%s.ndjson.gz", keyPrefix, from.Format("2006/01"), from.Format("20060102T150405Z"), id.Hex())
+}
+
+// Encode returns entries as gzip-compressed NDJSON, one entry per line in
+// MongoDB relaxed Extended JSON, so an archive can be loaded back with
+// mongoimport.
+func Encode(entries []ledgerstore.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, e := range entries {
+		line, err := bson.MarshalExtJSON(e, false, false)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := zw.Write(append(line, '\n')); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package ledgerarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/ledger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+func TestEncode_RoundTrip(t *testing.T) {
+	started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []ledgerstore.Entry{
+		{ID: primitive.NewObjectID(), RequestID: "req-1", Method: "POST", Path: "/api/v1/state/save", StatusCode: 400, StartedAt: started},
+		{ID: primitive.NewObjectID(), RequestID: "req-2", Method: "POST", Path: "/api/v1/state/load", StatusCode: 500, StartedAt: started.Add(time.Minute),
+			Metadata: map[string]any{"game": "alpha"}},
+	}
+
+	data, err := Encode(entries)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+
+	var got []ledgerstore.Entry
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var e ledgerstore.Entry
+		if err := bson.UnmarshalExtJSON(sc.Bytes(), false, &e); err != nil {
+			t.Fatalf("line %d: %v", len(got)+1, err)
+		}
+		got = append(got, e)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+
+	if len(got) != len(entries) {
+		t.Fatalf("decoded %d entries, want %d", len(got), len(entries))
+	}
+	for i, e := range entries {
+		if got[i].ID != e.ID || got[i].RequestID != e.RequestID || got[i].Path != e.Path || got[i].StatusCode != e.StatusCode {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], e)
+		}
+		if !got[i].StartedAt.Equal(e.StartedAt) {
+			t.Errorf("entry %d started_at = %v, want %v", i, got[i].StartedAt, e.StartedAt)
+		}
+	}
+	if got[1].Metadata["game"] != "alpha" {
+		t.Errorf("entry 1 metadata = %v, want game alpha", got[1].Metadata)
+	}
+}
+
+func TestArchiveKey(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	from := time.Date(2025, 3, 9, 14, 30, 0, 0, time.FixedZone("EST", -5*3600))
+
+	want := "ledger-archive/2025/03/ledger-20250309T193000Z-65a1b2c3d4e5f60718293a4b.ndjson.gz"
+	if got := ArchiveKey(from, id); got != want {
+		t.Errorf("ArchiveKey() = %q, want %q", got, want)
+	}
+}
+
+func TestJobs_ArchivingOff(t *testing.T) {
+	a := &Archiver{logger: zap.NewNop()}
+	if jobs := a.Jobs(); len(jobs) != 0 {
+		t.Errorf("Jobs() with no retention = %d jobs, want none", len(jobs))
+	}
+	if res, err := a.Run(t.Context()); err != nil || res.Files != 0 {
+		t.Errorf("Run() with no retention = %+v, %v; want nothing done", res, err)
+	}
+
+	a.retention = 30 * 24 * time.Hour
+	if jobs := a.Jobs(); len(jobs) != 1 || jobs[0].Interval != Interval {
+		t.Errorf("Jobs() with retention = %+v, want one job every %s", jobs, Interval)
+	}
+}
@@ -0,0 +1,272 @@
+// Package librarytrash permanently deletes library files and folders that
+// have been in the trash too long, or that an admin deletes from the trash.
+//
+// Deleting a file or folder in the library only marks it as trashed (see
+// the file and folder stores), so it can be restored. Files keep their
+// stored content until they are purged here.
+//
+// Folders holding many files are deleted by a purge_folder job on the
+// "library" queue, so the request that asks for it doesn't time out.
+package librarytrash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/blob"
+	"github.com/dalemusser/stratasave/internal/app/store/file"
+	"github.com/dalemusser/stratasave/internal/app/store/fileaccess"
+	"github.com/dalemusser/stratasave/internal/app/store/folder"
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	"github.com/dalemusser/stratasave/internal/app/store/share"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue large folders are deleted from.
+	QueueName = "library"
+	// PurgeFolderJob is the job type that deletes one trashed folder.
+	PurgeFolderJob = "purge_folder"
+)
+
+const (
+	purgeBatch = 200 // Files purged per query
+
+	// A folder job that runs out of time is retried, carrying on from the
+	// files it has already deleted, so a huge folder gets several runs
+	purgeJobAttempts = 10
+)
+
+// Trash permanently deletes trashed library items.
+type Trash struct {
+	files     *file.Store
+	folders   *folder.Store
+	shares    *share.Store
+	access    *fileaccess.Store
+	blobs     *blob.Store
+	jobs      *jobstore.Store
+	storage   storage.Store
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// New creates a Trash that keeps items for retention before purging them.
+// A retention of zero or less keeps them until deleted by hand.
+func New(db *mongo.Database, st storage.Store, retention time.Duration, logger *zap.Logger) *Trash {
+	return &Trash{
+		files:     file.New(db),
+		folders:   folder.New(db),
+		shares:    share.New(db),
+		access:    fileaccess.New(db),
+		blobs:     blob.New(db),
+		jobs:      jobstore.New(db),
+		storage:   st,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Retention returns how long items stay in the trash (0 = until deleted).
+func (t *Trash) Retention() time.Duration {
+	if t.retention < 0 {
+		return 0
+	}
+	return t.retention
+}
+
+// DeleteFile permanently deletes a trashed file, its share links and
+// access records, and its stored content unless other files share it.
+func (t *Trash) DeleteFile(ctx context.Context, f *models.File) error {
+	remaining, err := t.blobs.Release(ctx, f.StoragePath)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("releasing %s: %w", f.StoragePath, err)
+	}
+	if remaining == 0 {
+		if err := t.storage.Delete(ctx, f.StoragePath); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("deleting %s from storage: %w", f.StoragePath, err)
+		}
+	}
+	if err := t.shares.DeleteByFile(ctx, f.ID); err != nil {
+		return fmt.Errorf("deleting share links: %w", err)
+	}
+	if err := t.access.DeleteByFile(ctx, f.ID); err != nil {
+		return fmt.Errorf("deleting access records: %w", err)
+	}
+	return t.files.Delete(ctx, f.ID)
+}
+
+// DeleteFolder permanently deletes a trashed folder, with the subfolders
+// and files trashed along with it.
+func (t *Trash) DeleteFolder(ctx context.Context, id primitive.ObjectID) error {
+	files, err := t.files.ListTrashedWith(ctx, id)
+	if err != nil {
+		return fmt.Errorf("listing files: %w", err)
+	}
+	for i := range files {
+		if err := t.DeleteFile(ctx, &files[i]); err != nil {
+			return err
+		}
+	}
+	return t.folders.DeleteTrashed(ctx, id)
+}
+
+// QueueDeleteFolder schedules a background job to permanently delete a
+// trashed folder, for folders with too many files to delete within a
+// request. It returns the job's ID, for following its progress.
+func (t *Trash) QueueDeleteFolder(ctx context.Context, id primitive.ObjectID) (primitive.ObjectID, error) {
+	files, err := t.files.CountTrashedWith(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("counting files: %w", err)
+	}
+	job, err := t.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     PurgeFolderJob,
+		Payload:     map[string]any{"folder_id": id.Hex(), "files": files},
+		MaxAttempts: purgeJobAttempts,
+	})
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("enqueue purge job: %w", err)
+	}
+	if err := t.folders.SetPurgeJob(ctx, id, job.ID); err != nil {
+		// The job still deletes the folder; only the trash page's notice is lost
+		t.logger.Warn("failed to record folder purge job",
+			zap.String("folder_id", id.Hex()),
+			zap.String("job_id", job.ID.Hex()),
+			zap.Error(err))
+	}
+	return job.ID, nil
+}
+
+// Deleting reports whether a background job is deleting a trashed folder.
+// A folder whose job failed or was cancelled can be restored or deleted
+// again.
+func (t *Trash) Deleting(ctx context.Context, f *models.Folder) (bool, error) {
+	if f.PurgeJobID == nil {
+		return false, nil
+	}
+	job, err := t.jobs.GetByID(ctx, *f.PurgeJobID)
+	if errors.Is(err, jobstore.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return job.Status == jobstore.StatusPending || job.Status == jobstore.StatusRunning, nil
+}
+
+// Register adds the library queue and its job handler to r.
+func (t *Trash) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(PurgeFolderJob, t.handlePurgeFolder)
+}
+
+// handlePurgeFolder permanently deletes a trashed folder, reporting how
+// many of its files are gone. A retry carries on with the files left.
+func (t *Trash) handlePurgeFolder(ctx context.Context, payload map[string]any) (map[string]any, error) {
+	idStr, _ := payload["folder_id"].(string)
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid folder_id %q", idStr)
+	}
+
+	files, err := t.files.ListTrashedWith(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("listing files: %w", err)
+	}
+	total := payloadInt(payload["files"])
+	done := total - int64(len(files)) // Deleted by earlier attempts
+	if done < 0 {
+		total, done = int64(len(files)), 0
+	}
+	jobrunner.ReportStep(ctx, "Deleting files")
+	jobrunner.ReportProgress(ctx, done, total)
+
+	for i := range files {
+		if err := t.DeleteFile(ctx, &files[i]); err != nil {
+			return nil, err
+		}
+		done++
+		jobrunner.ReportProgress(ctx, done, total)
+	}
+	jobrunner.ReportStep(ctx, "Deleting folders")
+	if err := t.folders.DeleteTrashed(ctx, id); err != nil {
+		return nil, fmt.Errorf("deleting folders: %w", err)
+	}
+	jobrunner.ReportProgress(ctx, total, total)
+
+	t.logger.Info("deleted library folder",
+		zap.String("folder_id", idStr),
+		zap.Int64("files", total))
+	return map[string]any{"files_deleted": total}, nil
+}
+
+// payloadInt reads a number from a job payload, which may come back from
+// MongoDB as any numeric type.
+func payloadInt(v any) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+// Jobs returns the background job that purges expired trash, or nothing if
+// items are kept until deleted by hand.
+func (t *Trash) Jobs() []tasks.Job {
+	if t.Retention() == 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "library-trash-purge",
+		Interval: 1 * time.Hour,
+		Run:      t.purge,
+	}}
+}
+
+// purge permanently deletes items trashed longer than the retention.
+func (t *Trash) purge(ctx context.Context) error {
+	cutoff := time.Now().Add(-t.retention)
+
+	var purgedFiles int
+	for {
+		files, err := t.files.ListTrashedBefore(ctx, cutoff, purgeBatch)
+		if err != nil {
+			return err
+		}
+		for i := range files {
+			if err := t.DeleteFile(ctx, &files[i]); err != nil {
+				return err
+			}
+		}
+		purgedFiles += len(files)
+		if len(files) < purgeBatch {
+			break
+		}
+	}
+
+	purgedFolders, err := t.folders.PurgeTrashedBefore(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	if purgedFiles > 0 || purgedFolders > 0 {
+		t.logger.Info("purged library trash",
+			zap.Int("files", purgedFiles),
+			zap.Int64("folders", purgedFolders))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// internal/app/system/mailer/brand.go
+package mailer
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultPrimaryColor is the button and link color used when no brand
+// color is set.
+const DefaultPrimaryColor = "#4f46e5"
+
+// hexColor matches the colors a brand may use, e.g. "#0a7" or "#00aa77".
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Brand holds the site branding applied to the shared email layout.
+// The zero value renders the default look.
+type Brand struct {
+	LogoURL      string // Absolute URL of the header logo; the app name is shown if empty
+	PrimaryColor string // Hex color for buttons and links, e.g. "#0f766e"
+	FooterText   string // Plain text appended to every email footer
+}
+
+// Color returns the primary color, or DefaultPrimaryColor if it is unset
+// or not a valid hex color.
+func (b Brand) Color() string {
+	if !IsValidColor(b.PrimaryColor) {
+		return DefaultPrimaryColor
+	}
+	return b.PrimaryColor
+}
+
+// IsValidColor reports whether c is a hex color a brand may use.
+func IsValidColor(c string) bool {
+	return hexColor.MatchString(c)
+}
+
+// BrandProvider supplies the current site branding, typically from
+// editable site settings.
+type BrandProvider interface {
+	Brand(ctx context.Context) (Brand, error)
+}
+
+// SetBrandProvider makes Brand return branding from p. Call it during
+// startup, before any email is sent.
+func (m *Mailer) SetBrandProvider(p BrandProvider) {
+	m.brand = p
+}
+
+// Brand returns the branding to set on email data. Without a provider, or
+// if the provider fails, the default branding is returned so the email can
+// still be sent.
+func (m *Mailer) Brand(ctx context.Context) Brand {
+	if m.brand == nil {
+		return Brand{}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	b, err := m.brand.Brand(ctx)
+	if err != nil {
+		m.log.Warn("failed to load email branding", zap.Error(err))
+		return Brand{}
+	}
+	return b
+}
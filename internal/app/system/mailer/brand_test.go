@@ -0,0 +1,162 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestBrandColor(t *testing.T) {
+	tests := []struct {
+		color, want string
+	}{
+		{"", DefaultPrimaryColor},
+		{"#0f766e", "#0f766e"},
+		{"#0a7", "#0a7"},
+		{"red", DefaultPrimaryColor},
+		{"#0f766e;background:url(x)", DefaultPrimaryColor},
+	}
+	for _, tt := range tests {
+		if got := (Brand{PrimaryColor: tt.color}).Color(); got != tt.want {
+			t.Errorf("Brand{%q}.Color() = %q, want %q", tt.color, got, tt.want)
+		}
+	}
+}
+
+func TestLayout_AppliesBrand(t *testing.T) {
+	_, html := AccountEnabledEmail(AccountEnabledEmailData{
+		Brand: Brand{
+			LogoURL:      "https://example.com/logo.png",
+			PrimaryColor: "#0f766e",
+			FooterText:   "Example University",
+		},
+		AppName:  "Strata",
+		UserName: "Ana",
+		LoginURL: "https://example.com/login",
+	})
+
+	for _, want := range []string{
+		`<img src="https://example.com/logo.png" alt="Strata"`,
+		"background-color: #0f766e",
+		"Example University",
+		"Your Strata account has been enabled",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML body missing %q", want)
+		}
+	}
+	if strings.Contains(html, DefaultPrimaryColor) {
+		t.Error("HTML body still uses the default color")
+	}
+}
+
+func TestLayout_DefaultBrand(t *testing.T) {
+	_, html := LoginCodeEmail(LoginCodeEmailData{AppName: "Strata", Code: "123456", MagicURL: "https://example.com/m"})
+
+	if !strings.Contains(html, `<h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">Strata</h1>`) {
+		t.Error("HTML body should show the app name when there is no logo")
+	}
+	if !strings.Contains(html, "background-color: "+DefaultPrimaryColor) {
+		t.Error("HTML body should use the default color")
+	}
+}
+
+// TestTemplatesExecute renders every email template directly so execution
+// errors, which the render functions ignore, fail the test.
+func TestTemplatesExecute(t *testing.T) {
+	brand := Brand{LogoURL: "https://example.com/logo.png", PrimaryColor: "#123456", FooterText: "Footer"}
+
+	run := func(name string, exec func(*bytes.Buffer) error) {
+		var buf bytes.Buffer
+		if err := exec(&buf); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if !strings.Contains(buf.String(), "Footer") {
+			t.Errorf("%s: footer text missing", name)
+		}
+	}
+	for _, locale := range []string{"", "es"} {
+		run("password_reset", func(b *bytes.Buffer) error {
+			return passwordResetHTMLTmpl.ExecuteTemplate(b, "layout", PasswordResetEmailData{Locale: locale, Brand: brand, ExpiryMin: 5})
+		})
+		run("login_code", func(b *bytes.Buffer) error {
+			return loginCodeHTMLTmpl.ExecuteTemplate(b, "layout", LoginCodeEmailData{Locale: locale, Brand: brand})
+		})
+		run("password_changed", func(b *bytes.Buffer) error {
+			return passwordChangedHTMLTmpl.ExecuteTemplate(b, "layout", PasswordChangedEmailData{Locale: locale, Brand: brand})
+		})
+		run("password_expiring", func(b *bytes.Buffer) error {
+			return passwordExpiringHTMLTmpl.ExecuteTemplate(b, "layout", PasswordExpiringEmailData{Locale: locale, Brand: brand})
+		})
+		run("welcome", func(b *bytes.Buffer) error {
+			return welcomeHTMLTmpl.ExecuteTemplate(b, "layout", WelcomeEmailData{Locale: locale, Brand: brand, OrgName: "Org"})
+		})
+		run("invitation", func(b *bytes.Buffer) error {
+			return invitationHTMLTmpl.ExecuteTemplate(b, "layout", InvitationEmailData{Locale: locale, Brand: brand, OrgName: "Org"})
+		})
+		run("account_disabled", func(b *bytes.Buffer) error {
+			return accountDisabledHTMLTmpl.ExecuteTemplate(b, "layout", AccountDisabledEmailData{Locale: locale, Brand: brand, Reason: "r", ContactEmail: "a@example.com"})
+		})
+		run("account_disabling", func(b *bytes.Buffer) error {
+			return accountDisablingHTMLTmpl.ExecuteTemplate(b, "layout", AccountDisablingEmailData{Locale: locale, Brand: brand})
+		})
+		run("account_enabled", func(b *bytes.Buffer) error {
+			return accountEnabledHTMLTmpl.ExecuteTemplate(b, "layout", AccountEnabledEmailData{Locale: locale, Brand: brand})
+		})
+		run("new_login", func(b *bytes.Buffer) error {
+			return newLoginHTMLTmpl.ExecuteTemplate(b, "layout", NewLoginEmailData{Locale: locale, Brand: brand, Location: "x"})
+		})
+		run("resource_assigned", func(b *bytes.Buffer) error {
+			return resourceAssignedHTMLTmpl.ExecuteTemplate(b, "layout", ResourceAssignedEmailData{Locale: locale, Brand: brand, VisibleFrom: "a", VisibleUntil: "b", Instructions: "i"})
+		})
+		run("material_assigned", func(b *bytes.Buffer) error {
+			return materialAssignedHTMLTmpl.ExecuteTemplate(b, "layout", MaterialAssignedEmailData{Locale: locale, Brand: brand, VisibleFrom: "a", Directions: "d"})
+		})
+		run("group_membership", func(b *bytes.Buffer) error {
+			return groupMembershipHTMLTmpl.ExecuteTemplate(b, "layout", GroupMembershipEmailData{Locale: locale, Brand: brand, OrgName: "Org"})
+		})
+		run("announcement_digest", func(b *bytes.Buffer) error {
+			return announcementDigestHTMLTmpl.ExecuteTemplate(b, "layout", AnnouncementDigestEmailData{Locale: locale, Brand: brand,
+				Announcements: []AnnouncementItem{{Title: "t", Content: "c", Type: "warning"}}})
+		})
+		run("api_key_alert", func(b *bytes.Buffer) error {
+			return apiKeyAlertHTMLTmpl.ExecuteTemplate(b, "layout", APIKeyAlertEmailData{Locale: locale, Brand: brand, Details: []string{"d"}})
+		})
+		run("security_alert", func(b *bytes.Buffer) error {
+			return securityAlertHTMLTmpl.ExecuteTemplate(b, "layout", SecurityAlertEmailData{Locale: locale, Brand: brand, Details: []string{"d"}})
+		})
+		run("report", func(b *bytes.Buffer) error {
+			return reportHTMLTmpl.ExecuteTemplate(b, "layout", ReportEmailData{Locale: locale, Brand: brand,
+				Sections: []ReportSection{{Title: "t", Rows: []ReportRow{{Label: "l", Value: "v"}}}}})
+		})
+	}
+}
+
+type fakeBrandProvider struct {
+	brand Brand
+	err   error
+}
+
+func (f fakeBrandProvider) Brand(context.Context) (Brand, error) { return f.brand, f.err }
+
+func TestMailerBrand(t *testing.T) {
+	m := New(Config{}, zap.NewNop())
+	if got := m.Brand(context.Background()); got != (Brand{}) {
+		t.Errorf("Brand() without provider = %+v, want zero", got)
+	}
+
+	want := Brand{PrimaryColor: "#123456"}
+	m.SetBrandProvider(fakeBrandProvider{brand: want})
+	if got := m.Brand(context.Background()); got != want {
+		t.Errorf("Brand() = %+v, want %+v", got, want)
+	}
+
+	m.SetBrandProvider(fakeBrandProvider{brand: want, err: errors.New("db down")})
+	if got := m.Brand(context.Background()); got != (Brand{}) {
+		t.Errorf("Brand() on provider error = %+v, want zero", got)
+	}
+}
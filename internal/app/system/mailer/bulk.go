@@ -0,0 +1,81 @@
+// internal/app/system/mailer/bulk.go
+package mailer
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// DefaultBatchSize is the SendBulk batch size used when Config.BatchSize
+// is not set.
+const DefaultBatchSize = 50
+
+// BulkQueue is the job queue that large sends, such as announcement
+// broadcasts and bulk invitations, run on so their progress shows in the
+// jobs console.
+const BulkQueue = "bulk_email"
+
+// BulkProgress reports how far a SendBulk call has got.
+type BulkProgress struct {
+	Total      int
+	Sent       int // Sent, or queued when the outbox is enabled
+	Suppressed int
+	Failed     int
+}
+
+// Done returns the number of emails processed so far.
+func (p BulkProgress) Done() int {
+	return p.Sent + p.Suppressed + p.Failed
+}
+
+// SendBulk sends emails one at a time through Send, in batches of the
+// configured batch size, calling progress (if not nil) after each batch.
+// Individual failures are counted rather than returned; the error is
+// non-nil only if ctx is cancelled, in which case the remaining emails are
+// not sent.
+//
+// Deliveries are throttled to the configured rate, so without a queue a
+// large send takes a while; call SendBulk from a goroutine or job rather
+// than a request handler.
+func (m *Mailer) SendBulk(ctx context.Context, emails []Email, progress func(BulkProgress)) (BulkProgress, error) {
+	p := BulkProgress{Total: len(emails)}
+	for start := 0; start < len(emails); start += m.batchSize {
+		end := min(start+m.batchSize, len(emails))
+		for _, email := range emails[start:end] {
+			if err := ctx.Err(); err != nil {
+				m.log.Warn("bulk email send cancelled",
+					zap.Int("done", p.Done()),
+					zap.Int("total", p.Total))
+				return p, err
+			}
+			switch err := m.Send(email); {
+			case err == nil:
+				p.Sent++
+			case errors.Is(err, ErrSuppressed):
+				p.Suppressed++
+			default:
+				p.Failed++
+			}
+		}
+		m.log.Info("bulk email batch sent",
+			zap.Int("done", p.Done()),
+			zap.Int("total", p.Total),
+			zap.Int("failed", p.Failed))
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return p, nil
+}
+
+// wait blocks until the rate limit allows another delivery.
+func (m *Mailer) wait() {
+	if m.limiter == nil {
+		return
+	}
+	// Background context: Deliver has no deadline of its own, and a send
+	// that has started should not be dropped while waiting its turn
+	_ = m.limiter.Wait(context.Background())
+}
@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func bulkEmails(n int) []Email {
+	emails := make([]Email, n)
+	for i := range emails {
+		emails[i] = Email{To: fmt.Sprintf("user%d@example.com", i), Subject: "Hello", TextBody: "Hi"}
+	}
+	return emails
+}
+
+func TestSendBulk_BatchesAndProgress(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com", BatchSize: 2}, zap.NewNop())
+	q := &fakeQueue{}
+	m.SetQueue(q)
+	m.SetSuppressor(&fakeSuppressor{suppressed: map[string]bool{"user1@example.com": true}})
+
+	var reports []BulkProgress
+	p, err := m.SendBulk(context.Background(), bulkEmails(5), func(p BulkProgress) {
+		reports = append(reports, p)
+	})
+	if err != nil {
+		t.Fatalf("SendBulk() error = %v, want nil", err)
+	}
+
+	want := []BulkProgress{
+		{Total: 5, Sent: 1, Suppressed: 1},
+		{Total: 5, Sent: 3, Suppressed: 1},
+		{Total: 5, Sent: 4, Suppressed: 1},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress = %+v, want %+v", reports, want)
+	}
+	if p != want[len(want)-1] {
+		t.Errorf("result = %+v, want %+v", p, want[len(want)-1])
+	}
+	if len(q.emails) != 4 {
+		t.Errorf("queued %d emails, want 4", len(q.emails))
+	}
+}
+
+func TestSendBulk_CountsFailures(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+
+	// No queue and nothing listening, so every delivery fails
+	p, err := m.SendBulk(context.Background(), bulkEmails(3), nil)
+	if err != nil {
+		t.Fatalf("SendBulk() error = %v, want nil", err)
+	}
+	if p.Failed != 3 || p.Done() != 3 {
+		t.Errorf("result = %+v, want 3 failed", p)
+	}
+}
+
+func TestSendBulk_Cancelled(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com", BatchSize: 1}, zap.NewNop())
+	q := &fakeQueue{}
+	m.SetQueue(q)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p, err := m.SendBulk(ctx, bulkEmails(3), func(p BulkProgress) {
+		if p.Done() == 1 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("SendBulk() error = %v, want context.Canceled", err)
+	}
+	if p.Sent != 1 || len(q.emails) != 1 {
+		t.Errorf("sent %d (queued %d), want 1", p.Sent, len(q.emails))
+	}
+}
+
+func TestDeliver_RateLimited(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com", RateLimit: 20}, zap.NewNop())
+
+	// The first delivery goes at once; the next two wait 50ms each
+	start := time.Now()
+	for range 3 {
+		_ = m.Deliver(Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi"})
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 deliveries took %v, want at least 100ms at 20/s", elapsed)
+	}
+}
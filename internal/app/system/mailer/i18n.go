@@ -0,0 +1,106 @@
+// internal/app/system/mailer/i18n.go
+package mailer
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+)
+
+// DefaultLocale is used when a recipient has no locale or an unsupported one.
+const DefaultLocale = "en"
+
+// Locale describes a language emails can be rendered in.
+type Locale struct {
+	Code string // e.g. "es"
+	Name string // Name in the language itself, e.g. "Español"
+}
+
+// Locales returns the supported email languages, default first.
+func Locales() []Locale {
+	return []Locale{
+		{Code: "en", Name: "English"},
+		{Code: "es", Name: "Español"},
+	}
+}
+
+// NormalizeLocale maps a locale such as "es-MX" or "ES_es" to a supported
+// catalog code, falling back to DefaultLocale.
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if _, ok := catalogs[locale]; ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// IsSupportedLocale reports whether locale has a catalog. The empty string
+// is accepted and means "use the default".
+func IsSupportedLocale(locale string) bool {
+	if locale == "" {
+		return true
+	}
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// T returns the message for key in locale, formatted with args. Missing
+// translations fall back to the default locale, then to the key itself.
+func T(locale, key string, args ...any) string {
+	msg, ok := lookup(locale, key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// lookup finds key in locale's catalog, then in the default catalog.
+func lookup(locale, key string) (string, bool) {
+	if msg, ok := catalogs[NormalizeLocale(locale)][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[DefaultLocale][key]
+	return msg, ok
+}
+
+// announcementType returns the label for an announcement type such as
+// "warning", or the type itself if the catalog has no label for it.
+func announcementType(locale, typ string) string {
+	if msg, ok := lookup(locale, "announcement.type."+typ); ok {
+		return msg
+	}
+	return typ
+}
+
+// tHTML is T for messages that contain markup. Catalog messages are trusted;
+// string arguments are escaped before they are substituted.
+func tHTML(locale, key string, args ...any) template.HTML {
+	escaped := make([]any, len(args))
+	for i, a := range args {
+		if s, ok := a.(string); ok {
+			escaped[i] = html.EscapeString(s)
+		} else {
+			escaped[i] = a
+		}
+	}
+	return template.HTML(T(locale, key, escaped...))
+}
+
+// htmlFuncs are available to every HTML email template:
+//
+//	{{t .Locale "key" args...}}   translated text (escaped)
+//	{{th .Locale "key" args...}}  translated text that contains markup
+//	{{lang .Locale}}              normalized locale for the lang attribute
+var htmlFuncs = template.FuncMap{
+	"t":                T,
+	"th":               tHTML,
+	"lang":             NormalizeLocale,
+	"announcementType": announcementType,
+}
@@ -0,0 +1,152 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "en"},
+		{"en", "en"},
+		{"es", "es"},
+		{"ES", "es"},
+		{"es-MX", "es"},
+		{"es_ES", "es"},
+		{"fr", "en"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLocale(tt.in); got != tt.want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestT_Fallback(t *testing.T) {
+	if got := T("es", "greeting", "Ana"); got != "Hola Ana:" {
+		t.Errorf("T(es, greeting) = %q", got)
+	}
+	if got := T("fr", "greeting", "Ana"); got != "Hello Ana," {
+		t.Errorf("T(fr, greeting) = %q, want English fallback", got)
+	}
+	if got := T("es", "no.such.key"); got != "no.such.key" {
+		t.Errorf("T(missing key) = %q, want the key", got)
+	}
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for locale, catalog := range catalogs {
+		for key := range messagesEN {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("locale %q is missing key %q", locale, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := messagesEN[key]; !ok {
+				t.Errorf("locale %q has key %q that English does not", locale, key)
+			}
+		}
+	}
+	for _, l := range Locales() {
+		if _, ok := catalogs[l.Code]; !ok {
+			t.Errorf("Locales() lists %q but it has no catalog", l.Code)
+		}
+	}
+}
+
+func TestPasswordResetEmail_Localized(t *testing.T) {
+	data := PasswordResetEmailData{AppName: "Strata", ResetURL: "https://example.com/reset", ExpiryMin: 15}
+
+	text, html := PasswordResetEmail(data)
+	if !strings.Contains(text, "This link will expire in 15 minutes.") {
+		t.Errorf("English text body = %q", text)
+	}
+	if !strings.Contains(html, `<html lang="en">`) || !strings.Contains(html, "Reset Your Password") {
+		t.Errorf("English HTML body missing expected content")
+	}
+
+	data.Locale = "es"
+	text, html = PasswordResetEmail(data)
+	if !strings.Contains(text, "Este enlace caducará en 15 minutos.") {
+		t.Errorf("Spanish text body = %q", text)
+	}
+	if !strings.Contains(html, `<html lang="es">`) || !strings.Contains(html, "Restablece tu contraseña") {
+		t.Errorf("Spanish HTML body missing expected content")
+	}
+	if !strings.Contains(html, "<strong>15 minutos</strong>") {
+		t.Errorf("Spanish HTML body should keep markup from the catalog")
+	}
+}
+
+func TestHTMLArgumentsEscaped(t *testing.T) {
+	_, html := WelcomeEmail(WelcomeEmailData{
+		Locale:   "es",
+		AppName:  "Strata",
+		UserName: "Ana",
+		OrgName:  "<script>x</script>",
+		LoginURL: "https://example.com/login",
+		Role:     "admin",
+	})
+	if strings.Contains(html, "<script>") {
+		t.Error("organization name was not escaped")
+	}
+	if !strings.Contains(html, "<strong>&lt;script&gt;x&lt;/script&gt;</strong>") {
+		t.Error("escaped organization name not found in HTML body")
+	}
+}
+
+func TestPasswordResetEmail_ByAdmin(t *testing.T) {
+	data := PasswordResetEmailData{AppName: "Strata", ResetURL: "https://example.com/reset", ExpiryMin: 15, ByAdmin: true}
+
+	text, html := PasswordResetEmail(data)
+	if !strings.Contains(text, "An administrator sent you a link") || strings.Contains(text, "You requested") {
+		t.Errorf("admin text body = %q", text)
+	}
+	if !strings.Contains(html, "An administrator sent you a link") {
+		t.Errorf("admin HTML body missing admin intro")
+	}
+}
+
+func TestLoginCodeEmail_NoLink(t *testing.T) {
+	text, html := LoginCodeEmail(LoginCodeEmailData{AppName: "Strata", Code: "123456"})
+	if !strings.Contains(text, "123456") || strings.Contains(text, "click here") {
+		t.Errorf("text body = %q, want the code and no link", text)
+	}
+	if !strings.Contains(html, "123456") || strings.Contains(html, "<a href=") {
+		t.Errorf("HTML body should show the code without a login button")
+	}
+
+	text, _ = LoginCodeEmail(LoginCodeEmailData{AppName: "Strata", Code: "123456", MagicURL: "https://example.com/m"})
+	if !strings.Contains(text, "https://example.com/m") {
+		t.Errorf("text body with a link = %q", text)
+	}
+}
+
+func TestInvitationEmail_Message(t *testing.T) {
+	data := InvitationEmailData{
+		AppName:     "Strata",
+		InviterName: "Sam",
+		Role:        "admin",
+		AcceptURL:   "https://example.com/invite?token=t",
+		ExpiresIn:   InvitationExpiresIn("es", 1),
+		Message:     "Bring <your> laptop",
+		Locale:      "es",
+	}
+	text, html := InvitationEmail(data)
+	if !strings.Contains(text, "Una nota de Sam:\nBring <your> laptop") {
+		t.Errorf("text body missing message:\n%s", text)
+	}
+	if !strings.Contains(text, "1 día") {
+		t.Errorf("text body missing localized expiry:\n%s", text)
+	}
+	if !strings.Contains(html, "Bring &lt;your&gt; laptop") {
+		t.Error("HTML body should include the escaped message")
+	}
+
+	data.Message = ""
+	if text, _ := InvitationEmail(data); strings.Contains(text, "Una nota") {
+		t.Error("text body should leave out the note heading without a message")
+	}
+}
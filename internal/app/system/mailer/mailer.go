@@ -2,26 +2,44 @@
 package mailer
 
 import (
-	"bytes"
-	"crypto/rand"
-	"encoding/hex"
+	"context"
+	"errors"
 	"fmt"
 	"net/smtp"
+	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 // Mailer sends emails via SMTP.
 type Mailer struct {
-	host     string
-	port     int
-	user     string
-	pass     string
-	from     string
-	fromName string
-	log      *zap.Logger
+	smtp       SMTP // configured server and sender; see SetSMTPProvider
+	log        *zap.Logger
+	queue      Queue
+	suppressor Suppressor
+	brand      BrandProvider
+	smtpSource SMTPProvider
+	recorder   Recorder
+	limiter    *rate.Limiter // nil when deliveries are not throttled
+	batchSize  int
 }
 
+// Queue stores emails for delivery by a background worker.
+type Queue interface {
+	Enqueue(ctx context.Context, email Email) error
+}
+
+// Suppressor reports addresses that must not receive email, such as those
+// that have hard-bounced or filed spam complaints.
+type Suppressor interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
+}
+
+// ErrSuppressed is returned by Send when the recipient is on the
+// suppression list.
+var ErrSuppressed = errors.New("recipient is on the email suppression list")
+
 // Config holds the configuration for creating a Mailer.
 type Config struct {
 	Host     string
@@ -30,108 +48,170 @@ type Config struct {
 	Pass     string
 	From     string
 	FromName string
+
+	// RateLimit caps deliveries per second across all senders, including
+	// outbox workers, so bursts aren't rejected by the provider. Zero or
+	// less means no limit.
+	RateLimit float64
+
+	// BatchSize is the number of emails SendBulk sends between progress
+	// reports (default: DefaultBatchSize).
+	BatchSize int
 }
 
 // New creates a new Mailer with the given configuration.
 func New(cfg Config, log *zap.Logger) *Mailer {
-	return &Mailer{
-		host:     cfg.Host,
-		port:     cfg.Port,
-		user:     cfg.User,
-		pass:     cfg.Pass,
-		from:     cfg.From,
-		fromName: cfg.FromName,
-		log:      log,
+	m := &Mailer{
+		smtp: SMTP{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			User:     cfg.User,
+			Pass:     cfg.Pass,
+			From:     cfg.From,
+			FromName: cfg.FromName,
+		},
+		log:       log,
+		batchSize: cfg.BatchSize,
+	}
+	if m.batchSize < 1 {
+		m.batchSize = DefaultBatchSize
+	}
+	if cfg.RateLimit > 0 {
+		// A burst of one spaces deliveries evenly
+		m.limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), 1)
 	}
+	return m
 }
 
-// FromName returns the configured sender display name.
-// This can be used as the application name in email templates.
+// FromName returns the sender display name, from the SMTP provider if it
+// has settings. This can be used as the application name in email templates.
 func (m *Mailer) FromName() string {
-	return m.fromName
+	return m.SMTP(context.Background()).FromName
 }
 
 // Email represents an email to be sent.
 type Email struct {
-	To       string
-	Subject  string
-	TextBody string
-	HTMLBody string
+	To          string
+	Subject     string
+	TextBody    string
+	HTMLBody    string
+	Attachments []Attachment
+
+	// Template and UserID identify the email in the delivery log and are
+	// not sent. Template is the template name, e.g. "password_reset";
+	// UserID is the recipient's user ID (hex) if they have an account.
+	Template string
+	UserID   string
 }
 
-// Send sends an email. If HTMLBody is provided, sends a multipart email with both
-// plain text and HTML versions.
+// SetQueue routes Send through q so emails are delivered in the background
+// and retried on failure. Call it during startup, before any email is sent.
+func (m *Mailer) SetQueue(q Queue) {
+	m.queue = q
+}
+
+// SetSuppressor makes Send refuse recipients that s reports as suppressed.
+// Call it during startup, before any email is sent.
+func (m *Mailer) SetSuppressor(s Suppressor) {
+	m.suppressor = s
+}
+
+// Suppressed reports whether to is on the suppression list. If the list
+// can't be checked the address is treated as not suppressed, so a database
+// problem doesn't stop sign-in emails.
+func (m *Mailer) Suppressed(to string) bool {
+	if m.suppressor == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	suppressed, err := m.suppressor.IsSuppressed(ctx, to)
+	if err != nil {
+		m.log.Warn("failed to check email suppression list",
+			zap.String("to", to),
+			zap.Error(err))
+		return false
+	}
+	return suppressed
+}
+
+// Send sends an email. Emails whose attachments exceed MaxAttachmentBytes
+// are refused with ErrAttachmentsTooLarge. Recipients on the suppression list are refused with
+// ErrSuppressed. When a queue is set the email is queued and Send returns
+// once it is stored; if queuing fails the email is delivered immediately
+// instead. Without a queue Send delivers immediately.
 func (m *Mailer) Send(email Email) error {
-	from := m.from
-	if m.fromName != "" {
-		from = fmt.Sprintf("%s <%s>", m.fromName, m.from)
+	if attachmentSize(email.Attachments) > MaxAttachmentBytes {
+		return ErrAttachmentsTooLarge
+	}
+	if m.Suppressed(email.To) {
+		m.log.Warn("not sending email to suppressed address",
+			zap.String("to", email.To),
+			zap.String("subject", email.Subject))
+		m.RecordSuppressed(email)
+		return ErrSuppressed
 	}
+	if m.queue != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := m.queue.Enqueue(ctx, email)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		m.log.Warn("failed to queue email, sending directly",
+			zap.String("to", email.To),
+			zap.String("subject", email.Subject),
+			zap.Error(err))
+	}
+	return m.Deliver(email)
+}
 
-	var msg bytes.Buffer
-
-	// Headers
-	msg.WriteString(fmt.Sprintf("From: %s\r\n", from))
-	msg.WriteString(fmt.Sprintf("To: %s\r\n", email.To))
-	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
-	msg.WriteString("MIME-Version: 1.0\r\n")
-
-	if email.HTMLBody != "" {
-		// Multipart email with both text and HTML
-		boundary := randomBoundary()
-		msg.WriteString(fmt.Sprintf("Content-Type: multipart/alternative; boundary=\"%s\"\r\n", boundary))
-		msg.WriteString("\r\n")
-
-		// Plain text part
-		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-		msg.WriteString("\r\n")
-		msg.WriteString(email.TextBody)
-		msg.WriteString("\r\n")
-
-		// HTML part
-		msg.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-		msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
-		msg.WriteString("\r\n")
-		msg.WriteString(email.HTMLBody)
-		msg.WriteString("\r\n")
-
-		// End boundary
-		msg.WriteString(fmt.Sprintf("--%s--\r\n", boundary))
-	} else {
-		// Plain text only
-		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
-		msg.WriteString("\r\n")
-		msg.WriteString(email.TextBody)
+// Deliver sends an email over SMTP, waiting first if the rate limit has been
+// reached. If HTMLBody is provided, sends a multipart email with both plain
+// text and HTML versions, plus any attachments.
+func (m *Mailer) Deliver(email Email) error {
+	return m.DeliverVia(m.SMTP(context.Background()), email)
+}
+
+// DeliverVia sends an email like Deliver, but through the given server and
+// sender rather than the current settings. It is used to test SMTP
+// settings before they are saved.
+func (m *Mailer) DeliverVia(s SMTP, email Email) error {
+	from := s.From
+	if s.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", s.FromName, s.From)
+	}
+
+	messageID := newMessageID(s.From)
+	msg, err := buildMessage(from, messageID, email)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
 	}
 
-	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	addr := fmt.Sprintf("%s:%d", s.Host, s.Port)
+
+	m.wait()
 
 	var auth smtp.Auth
-	if m.user != "" && m.pass != "" {
-		auth = smtp.PlainAuth("", m.user, m.pass, m.host)
+	if s.User != "" && s.Pass != "" {
+		auth = smtp.PlainAuth("", s.User, s.Pass, s.Host)
 	}
 
-	err := smtp.SendMail(addr, auth, m.from, []string{email.To}, msg.Bytes())
+	err = smtp.SendMail(addr, auth, s.From, []string{email.To}, msg)
 	if err != nil {
 		m.log.Error("failed to send email",
 			zap.String("to", email.To),
 			zap.String("subject", email.Subject),
 			zap.Error(err))
+		m.record(email, messageID, DeliveryFailed, err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
 
 	m.log.Info("email sent",
 		zap.String("to", email.To),
-		zap.String("subject", email.Subject))
+		zap.String("subject", email.Subject),
+		zap.String("message_id", messageID))
+	m.record(email, messageID, DeliverySent, nil)
 
 	return nil
 }
-
-// randomBoundary generates a random boundary string for multipart emails.
-func randomBoundary() string {
-	b := make([]byte, 16)
-	if _, err := rand.Read(b); err != nil {
-		panic("crypto/rand.Read failed: " + err.Error())
-	}
-	return "----=_Part_" + hex.EncodeToString(b)
-}
@@ -0,0 +1,154 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type fakeQueue struct {
+	emails []Email
+	err    error
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, email Email) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.emails = append(q.emails, email)
+	return nil
+}
+
+// closedPort returns a local port with nothing listening on it.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestSend_Queued(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	q := &fakeQueue{}
+	m.SetQueue(q)
+
+	email := Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi"}
+	if err := m.Send(email); err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+	if len(q.emails) != 1 || !reflect.DeepEqual(q.emails[0], email) {
+		t.Errorf("queued = %+v, want [%+v]", q.emails, email)
+	}
+}
+
+func TestSend_QueueErrorFallsBackToDelivery(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	m.SetQueue(&fakeQueue{err: errors.New("database unavailable")})
+
+	// Nothing is listening, so the direct delivery attempt must fail
+	if err := m.Send(Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi"}); err == nil {
+		t.Error("Send() error = nil, want SMTP error from direct delivery")
+	}
+}
+
+func TestSend_NoQueueDeliversDirectly(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+
+	if err := m.Send(Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi"}); err == nil {
+		t.Error("Send() error = nil, want SMTP error from direct delivery")
+	}
+}
+
+type fakeSuppressor struct {
+	suppressed map[string]bool
+	err        error
+}
+
+func (s *fakeSuppressor) IsSuppressed(ctx context.Context, email string) (bool, error) {
+	return s.suppressed[email], s.err
+}
+
+func TestSend_SuppressedRecipientRefused(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	q := &fakeQueue{}
+	m.SetQueue(q)
+	m.SetSuppressor(&fakeSuppressor{suppressed: map[string]bool{"bounced@example.com": true}})
+
+	err := m.Send(Email{To: "bounced@example.com", Subject: "Hello", TextBody: "Hi"})
+	if !errors.Is(err, ErrSuppressed) {
+		t.Errorf("Send() error = %v, want ErrSuppressed", err)
+	}
+	if len(q.emails) != 0 {
+		t.Errorf("queued %d emails to a suppressed address, want 0", len(q.emails))
+	}
+
+	if err := m.Send(Email{To: "ok@example.com", Subject: "Hello", TextBody: "Hi"}); err != nil {
+		t.Errorf("Send() to unsuppressed address error = %v", err)
+	}
+	if len(q.emails) != 1 {
+		t.Errorf("queued %d emails, want 1", len(q.emails))
+	}
+}
+
+func TestSuppressed_CheckErrorAllowsSend(t *testing.T) {
+	m := New(Config{}, zap.NewNop())
+	m.SetSuppressor(&fakeSuppressor{
+		suppressed: map[string]bool{"user@example.com": true},
+		err:        errors.New("database unavailable"),
+	})
+
+	if m.Suppressed("user@example.com") {
+		t.Error("Suppressed() = true when the list can't be checked, want false")
+	}
+}
+
+type fakeRecorder struct {
+	deliveries []Delivery
+}
+
+func (r *fakeRecorder) Record(ctx context.Context, d Delivery) error {
+	r.deliveries = append(r.deliveries, d)
+	return nil
+}
+
+func TestDeliver_RecordsFailure(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	rec := &fakeRecorder{}
+	m.SetRecorder(rec)
+
+	err := m.Deliver(Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi", Template: "welcome", UserID: "abc"})
+	if err == nil {
+		t.Fatal("Deliver() error = nil, want SMTP error")
+	}
+	if len(rec.deliveries) != 1 {
+		t.Fatalf("recorded %d deliveries, want 1", len(rec.deliveries))
+	}
+	d := rec.deliveries[0]
+	if d.Status != DeliveryFailed || d.Template != "welcome" || d.UserID != "abc" || d.Error == "" {
+		t.Errorf("recorded %+v", d)
+	}
+	if !strings.HasSuffix(d.MessageID, "@example.com") {
+		t.Errorf("MessageID = %q, want one in the sender's domain", d.MessageID)
+	}
+}
+
+func TestSend_RecordsSuppressed(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	rec := &fakeRecorder{}
+	m.SetRecorder(rec)
+	m.SetSuppressor(&fakeSuppressor{suppressed: map[string]bool{"bounced@example.com": true}})
+
+	_ = m.Send(Email{To: "bounced@example.com", Subject: "Hello", TextBody: "Hi"})
+	if len(rec.deliveries) != 1 || rec.deliveries[0].Status != DeliverySuppressed {
+		t.Errorf("recorded %+v, want one suppressed delivery", rec.deliveries)
+	}
+}
@@ -0,0 +1,244 @@
+// internal/app/system/mailer/message.go
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// MaxAttachmentBytes limits the combined size of an email's attachments.
+// Most providers reject messages over 10MB once base64 encoding is added.
+const MaxAttachmentBytes = 7 << 20
+
+// ErrAttachmentsTooLarge is returned by Send when the attachments exceed
+// MaxAttachmentBytes.
+var ErrAttachmentsTooLarge = errors.New("email attachments exceed the size limit")
+
+// Attachment is a file sent with an email.
+//
+// An attachment with a ContentID is an inline part of the HTML body rather
+// than a download: reference it from HTMLBody as src="cid:<ContentID>",
+// e.g. for a logo image.
+type Attachment struct {
+	Filename    string // e.g. "report.csv" or "invite.ics"
+	ContentType string // Detected from Filename if empty
+	Data        []byte
+	ContentID   string // Set for inline images
+}
+
+// contentType returns the attachment's MIME type.
+func (a Attachment) contentType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Filename)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// attachmentSize returns the combined size of the attachment data.
+func attachmentSize(atts []Attachment) int {
+	n := 0
+	for _, a := range atts {
+		n += len(a.Data)
+	}
+	return n
+}
+
+// buildMessage renders email as a MIME message with the given Message-ID
+// (without angle brackets; omitted if empty). The structure depends on
+// what the email carries:
+//
+//	multipart/mixed              when there are file attachments
+//	  multipart/related          when there are inline images
+//	    multipart/alternative    when there is an HTML body
+//	      text/plain
+//	      text/html
+//	    inline images
+//	  file attachments
+//
+// Levels that aren't needed are left out, so a plain text email is a single
+// text/plain part as before.
+func buildMessage(from, messageID string, email Email) ([]byte, error) {
+	var inline, files []Attachment
+	for _, a := range email.Attachments {
+		if a.ContentID != "" && email.HTMLBody != "" {
+			inline = append(inline, a)
+		} else {
+			files = append(files, a)
+		}
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", from))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", email.To))
+	msg.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", email.Subject)))
+	if messageID != "" {
+		msg.WriteString(fmt.Sprintf("Message-ID: <%s>\r\n", messageID))
+	}
+	msg.WriteString("MIME-Version: 1.0\r\n")
+
+	// Each level writes its Content-Type header into the enclosing part (or
+	// the message headers) and its body after it.
+	var err error
+	switch {
+	case len(files) > 0:
+		err = writeMultipart(&msg, "mixed", func(w *multipart.Writer) error {
+			if err := writePart(w, func(hdr textproto.MIMEHeader) (*bytes.Buffer, error) {
+				return bodyParts(email, inline, hdr)
+			}); err != nil {
+				return err
+			}
+			for _, a := range files {
+				if err := writeAttachment(w, a, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		var body *bytes.Buffer
+		hdr := textproto.MIMEHeader{}
+		body, err = bodyParts(email, inline, hdr)
+		if err == nil {
+			writeHeader(&msg, hdr)
+			msg.WriteString("\r\n")
+			msg.Write(body.Bytes())
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// bodyParts renders the text, HTML, and inline images, setting the
+// Content-Type of the result in hdr.
+func bodyParts(email Email, inline []Attachment, hdr textproto.MIMEHeader) (*bytes.Buffer, error) {
+	if len(inline) == 0 {
+		return textParts(email, hdr)
+	}
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	hdr.Set("Content-Type", fmt.Sprintf("multipart/related; boundary=%q", w.Boundary()))
+	if err := writePart(w, func(h textproto.MIMEHeader) (*bytes.Buffer, error) {
+		return textParts(email, h)
+	}); err != nil {
+		return nil, err
+	}
+	for _, a := range inline {
+		if err := writeAttachment(w, a, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// textParts renders the plain text body, or the text and HTML alternatives,
+// setting the Content-Type of the result in hdr.
+func textParts(email Email, hdr textproto.MIMEHeader) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	if email.HTMLBody == "" {
+		hdr.Set("Content-Type", "text/plain; charset=UTF-8")
+		buf.WriteString(email.TextBody)
+		return &buf, nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	hdr.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", w.Boundary()))
+	for _, p := range []struct{ ct, body string }{
+		{"text/plain; charset=UTF-8", email.TextBody},
+		{"text/html; charset=UTF-8", email.HTMLBody},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{"Content-Type": {p.ct}})
+		if err != nil {
+			return nil, err
+		}
+		pw.Write([]byte(p.body))
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// writeMultipart writes a multipart body of the given subtype to msg,
+// preceded by its Content-Type header and the blank line ending the headers.
+func writeMultipart(msg *bytes.Buffer, subtype string, parts func(*multipart.Writer) error) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/%s; boundary=%q\r\n\r\n", subtype, w.Boundary()))
+	if err := parts(w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	msg.Write(buf.Bytes())
+	return nil
+}
+
+// writePart adds a part to w whose headers and body are produced by render.
+func writePart(w *multipart.Writer, render func(textproto.MIMEHeader) (*bytes.Buffer, error)) error {
+	hdr := textproto.MIMEHeader{}
+	body, err := render(hdr)
+	if err != nil {
+		return err
+	}
+	pw, err := w.CreatePart(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(body.Bytes())
+	return err
+}
+
+// writeAttachment adds a base64-encoded attachment to w.
+func writeAttachment(w *multipart.Writer, a Attachment, inline bool) error {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	hdr := textproto.MIMEHeader{}
+	hdr.Set("Content-Type", a.contentType())
+	hdr.Set("Content-Transfer-Encoding", "base64")
+	hdr.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	if a.ContentID != "" {
+		hdr.Set("Content-ID", "<"+strings.Trim(a.ContentID, "<>")+">")
+	}
+	pw, err := w.CreatePart(hdr)
+	if err != nil {
+		return err
+	}
+
+	// Wrap at 76 characters as RFC 2045 requires
+	enc := base64.StdEncoding.EncodeToString(a.Data)
+	for len(enc) > 76 {
+		if _, err := pw.Write([]byte(enc[:76] + "\r\n")); err != nil {
+			return err
+		}
+		enc = enc[76:]
+	}
+	_, err = pw.Write([]byte(enc + "\r\n"))
+	return err
+}
+
+// writeHeader writes MIME headers to msg.
+func writeHeader(msg *bytes.Buffer, hdr textproto.MIMEHeader) {
+	for k, vs := range hdr {
+		for _, v := range vs {
+			msg.WriteString(k + ": " + v + "\r\n")
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// part is a parsed MIME part; parts is set for multipart content.
+type part struct {
+	mediaType string
+	header    map[string][]string
+	body      []byte
+	parts     []part
+}
+
+// readPart parses a MIME part, descending into multipart content.
+func readPart(t *testing.T, contentType string, header map[string][]string, body io.Reader) part {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse content type %q: %v", contentType, err)
+	}
+	p := part{mediaType: mediaType, header: header}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		p.body, _ = io.ReadAll(body)
+		return p
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		sub, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read %s part: %v", mediaType, err)
+		}
+		p.parts = append(p.parts, readPart(t, sub.Header.Get("Content-Type"), sub.Header, sub))
+	}
+	return p
+}
+
+// parseMessage builds email and parses the result.
+func parseMessage(t *testing.T, email Email) (*mail.Message, part) {
+	t.Helper()
+	raw, err := buildMessage("Strata <noreply@example.com>", "abc123@example.com", email)
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	return msg, readPart(t, msg.Header.Get("Content-Type"), msg.Header, msg.Body)
+}
+
+func mediaTypes(parts []part) []string {
+	var out []string
+	for _, p := range parts {
+		out = append(out, p.mediaType)
+	}
+	return out
+}
+
+func TestBuildMessage_PlainText(t *testing.T) {
+	msg, root := parseMessage(t, Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi there"})
+
+	if root.mediaType != "text/plain" {
+		t.Fatalf("Content-Type = %q, want text/plain", root.mediaType)
+	}
+	if string(root.body) != "Hi there" {
+		t.Errorf("body = %q, want %q", root.body, "Hi there")
+	}
+	if got := msg.Header.Get("To"); got != "user@example.com" {
+		t.Errorf("To = %q", got)
+	}
+	if got := msg.Header.Get("Message-ID"); got != "<abc123@example.com>" {
+		t.Errorf("Message-ID = %q, want <abc123@example.com>", got)
+	}
+}
+
+func TestBuildMessage_Alternative(t *testing.T) {
+	_, root := parseMessage(t, Email{To: "user@example.com", Subject: "Hello", TextBody: "Hi", HTMLBody: "<p>Hi</p>"})
+
+	if root.mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", root.mediaType)
+	}
+	if got := strings.Join(mediaTypes(root.parts), ","); got != "text/plain,text/html" {
+		t.Errorf("parts = %s, want text/plain,text/html", got)
+	}
+}
+
+func TestBuildMessage_Attachment(t *testing.T) {
+	data := bytes.Repeat([]byte("name,email\n"), 20)
+	_, root := parseMessage(t, Email{
+		To:       "user@example.com",
+		Subject:  "Report",
+		TextBody: "Attached.",
+		HTMLBody: "<p>Attached.</p>",
+		Attachments: []Attachment{
+			{Filename: "users report.csv", Data: data},
+		},
+	})
+
+	if root.mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", root.mediaType)
+	}
+	if got := strings.Join(mediaTypes(root.parts), ","); got != "multipart/alternative,text/csv" {
+		t.Fatalf("parts = %s, want multipart/alternative,text/csv", got)
+	}
+
+	att := root.parts[1]
+	disp, params, err := mime.ParseMediaType(att.header["Content-Disposition"][0])
+	if err != nil || disp != "attachment" || params["filename"] != "users report.csv" {
+		t.Errorf("Content-Disposition = %q", att.header["Content-Disposition"])
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(att.body)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line is %d characters, want at most 76", len(line))
+			break
+		}
+	}
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(att.body)))
+	if err != nil {
+		t.Fatalf("decode attachment: %v", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Error("decoded attachment does not match the original data")
+	}
+}
+
+func TestBuildMessage_InlineImage(t *testing.T) {
+	_, root := parseMessage(t, Email{
+		To:       "user@example.com",
+		Subject:  "Welcome",
+		TextBody: "Welcome",
+		HTMLBody: `<img src="cid:logo">`,
+		Attachments: []Attachment{
+			{Filename: "logo.png", Data: []byte("png"), ContentID: "logo"},
+			{Filename: "invite.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")},
+		},
+	})
+
+	if root.mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", root.mediaType)
+	}
+	if got := strings.Join(mediaTypes(root.parts), ","); got != "multipart/related,text/calendar" {
+		t.Fatalf("parts = %s, want multipart/related,text/calendar", got)
+	}
+	related := root.parts[0]
+	if got := strings.Join(mediaTypes(related.parts), ","); got != "multipart/alternative,image/png" {
+		t.Fatalf("related parts = %s, want multipart/alternative,image/png", got)
+	}
+	img := related.parts[1]
+	if got := img.header["Content-Id"]; len(got) != 1 || got[0] != "<logo>" {
+		t.Errorf("Content-ID = %v, want <logo>", got)
+	}
+	if disp, _, _ := mime.ParseMediaType(img.header["Content-Disposition"][0]); disp != "inline" {
+		t.Errorf("Content-Disposition = %q, want inline", disp)
+	}
+}
+
+func TestBuildMessage_EncodesSubject(t *testing.T) {
+	msg, _ := parseMessage(t, Email{To: "user@example.com", Subject: "Tu código de inicio de sesión", TextBody: "x"})
+
+	raw := msg.Header.Get("Subject")
+	if !strings.HasPrefix(raw, "=?UTF-8?q?") {
+		t.Errorf("Subject = %q, want a Q-encoded word", raw)
+	}
+	got, err := new(mime.WordDecoder).DecodeHeader(raw)
+	if err != nil || got != "Tu código de inicio de sesión" {
+		t.Errorf("decoded Subject = %q (err %v)", got, err)
+	}
+}
+
+func TestSend_AttachmentsTooLarge(t *testing.T) {
+	m := New(Config{Host: "127.0.0.1", Port: closedPort(t), From: "noreply@example.com"}, zap.NewNop())
+	q := &fakeQueue{}
+	m.SetQueue(q)
+
+	err := m.Send(Email{
+		To:          "user@example.com",
+		Subject:     "Export",
+		TextBody:    "Attached.",
+		Attachments: []Attachment{{Filename: "big.bin", Data: make([]byte, MaxAttachmentBytes+1)}},
+	})
+	if !errors.Is(err, ErrAttachmentsTooLarge) {
+		t.Errorf("Send() error = %v, want ErrAttachmentsTooLarge", err)
+	}
+	if len(q.emails) != 0 {
+		t.Error("oversized email should not be queued")
+	}
+}
@@ -0,0 +1,401 @@
+// internal/app/system/mailer/messages.go
+package mailer
+
+// catalogs holds the email strings for each supported locale, keyed by
+// message key. Messages are fmt format strings; keys ending in _html may
+// contain markup and are rendered with the "th" template function.
+//
+// To add a language, add a catalog here and an entry to Locales. Keys
+// missing from a catalog fall back to English.
+var catalogs = map[string]map[string]string{
+	"en": messagesEN,
+	"es": messagesES,
+}
+
+var messagesEN = map[string]string{
+	// Shared
+	"greeting":                      "Hello %s,",
+	"contact_admin":                 "If you have any questions, please contact your administrator.",
+	"button.log_in":                 "Log In",
+	"footer.link_fallback":          "If the button doesn't work, copy and paste this link into your browser:",
+	"footer.automated_notification": "This is an automated notification from %s.",
+	"footer.automated_message":      "This is an automated message from %s.",
+	"footer.security_notice":        "This is an automated security notification. Please do not reply to this email.",
+	"label.your_role":               "Your role:",
+	"label.reason":                  "Reason:",
+	"label.device":                  "Device:",
+	"label.ip_address":              "IP Address:",
+	"label.location":                "Location:",
+	"label.time":                    "Time:",
+	"label.key":                     "Key:",
+	"label.instructions":            "Instructions",
+	"label.directions":              "Directions",
+	"label.available_from":          "Available from: %s",
+	"label.available_until":         "Available until: %s",
+	"label.until":                   "Until: %s",
+	"text.access_here":              "Access it here:\n%s",
+
+	// Password reset
+	"password_reset.subject":     "Password Reset Request",
+	"password_reset.title":       "Password Reset",
+	"password_reset.heading":     "Reset Your Password",
+	"password_reset.intro":       "You requested a password reset for your account. Click the button below to create a new password.",
+	"password_reset.button":      "Reset Password",
+	"password_reset.expiry_html": "This link will expire in <strong>%d minutes</strong>.",
+	"password_reset.ignore":      "If you didn't request this password reset, you can safely ignore this email. Your password will remain unchanged.",
+	"password_reset.text": "You requested a password reset for your %s account.\n\n" +
+		"Click the link below to reset your password:\n\n%s\n\n" +
+		"This link will expire in %d minutes.\n\n" +
+		"If you did not request this, you can safely ignore this email.",
+	"password_reset.intro_admin":  "An administrator sent you a link to set a new password for your account. Click the button below to choose one.",
+	"password_reset.ignore_admin": "If you weren't expecting this, contact your administrator. Your password stays the same until you choose a new one.",
+	"password_reset.text_admin": "An administrator sent you a link to set a new password for your %s account.\n\n" +
+		"Click the link below to choose a new password:\n\n%s\n\n" +
+		"This link will expire in %d minutes.\n\n" +
+		"If you weren't expecting this, contact your administrator.",
+
+	// Login code
+	"login_code.subject":     "Your Login Code",
+	"login_code.title":       "Login Code",
+	"login_code.heading":     "Your Login Code",
+	"login_code.enter_code":  "Enter this code to log in to your account:",
+	"login_code.or_click":    "Or click the button below to log in automatically:",
+	"login_code.expiry_html": "This code will expire in <strong>10 minutes</strong>. If you didn't request this, you can safely ignore this email.",
+	"login_code.text": "Your %s login code is: %s\n\n" +
+		"Or click here to log in:\n%s\n\n" +
+		"This code will expire in 10 minutes.\n\n" +
+		"If you did not request this, you can safely ignore this email.",
+	"login_code.text_no_link": "Your %s login code is: %s\n\n" +
+		"This code will expire in 10 minutes.\n\n" +
+		"If you did not request this, you can safely ignore this email.",
+
+	// Password changed
+	"password_changed.subject":     "Your Password Has Been Changed",
+	"password_changed.title":       "Password Changed",
+	"password_changed.changed":     "Your %s password has been successfully changed.",
+	"password_changed.if_you_html": "<strong>If you made this change</strong>, you can safely ignore this email.",
+	"password_changed.if_not_html": "<strong>If you did NOT make this change</strong>, your account may have been compromised. Please reset your password immediately and review your recent account activity.",
+	"password_changed.button":      "Go to Login",
+	"password_changed.text": "Your %s password has been changed.\n\n" +
+		"If you made this change, you can safely ignore this email.\n\n" +
+		"If you did NOT make this change, your account may have been compromised. " +
+		"Please reset your password immediately by visiting:\n%s\n\n" +
+		"For security, we recommend you also review your recent account activity.",
+
+	// Password expiring
+	"password_expiring.subject":      "Your Password Will Expire Soon",
+	"password_expiring.title":        "Password Expiring",
+	"password_expiring.expires_html": "Your %s password will expire on <strong>%s</strong>.",
+	"password_expiring.change":       "Change it before then to avoid being asked for a new one the next time you log in.",
+	"password_expiring.button":       "Change Password",
+	"password_expiring.text": "Your %s password will expire on %s.\n\n" +
+		"Change it before then to avoid being asked for a new one the next time you log in:\n%s",
+
+	// Welcome
+	"welcome.subject":          "Welcome to %s",
+	"welcome.title":            "Welcome",
+	"welcome.heading":          "Welcome, %s!",
+	"welcome.created":          "Your account has been created.",
+	"welcome.created_org_html": "Your account has been created for <strong>%s</strong>.",
+	"welcome.get_started":      "Click the button below to log in and get started.",
+	"welcome.text.greeting":    "Welcome to %s, %s!",
+	"welcome.text.created":     "Your account has been created with the role of %s.",
+	"welcome.text.created_org": "Your account has been created for %s with the role of %s.",
+	"welcome.text.login":       "To get started, log in at:\n%s",
+
+	// Invitation
+	"invitation.title":            "Invitation",
+	"invitation.heading":          "You're Invited!",
+	"invitation.invited_html":     "<strong>%s</strong> has invited you to join %s.",
+	"invitation.invited_org_html": "<strong>%s</strong> has invited you to join %s as part of <strong>%s</strong>.",
+	"invitation.button":           "Accept Invitation",
+	"invitation.expires_html":     "This invitation will expire in <strong>%s</strong>.",
+	"invitation.ignore":           "If you did not expect this invitation, you can safely ignore this email.",
+	"invitation.text.invited":     "%s has invited you to join %s.",
+	"invitation.text.invited_org": "%s has invited you to join %s as part of %s.",
+	"invitation.text.role":        "You will have the role of %s.",
+	"invitation.text.accept":      "To accept this invitation, visit:\n%s",
+	"invitation.text.expires":     "This invitation will expire in %s.",
+	"invitation.message":          "A note from %s",
+	"invitation.one_day":          "1 day",
+	"invitation.days":             "%d days",
+
+	// Account disabled
+	"account_disabled.subject":            "Your %s account has been disabled",
+	"account_disabled.title":              "Account Disabled",
+	"account_disabled.by_admin":           "Your %s account has been disabled by an administrator.",
+	"account_disabled.contact":            "If you believe this was done in error, please contact your administrator.",
+	"account_disabled.contact_email_html": `If you believe this was done in error, please contact your administrator at <a href="mailto:%[1]s" style="color: %[2]s;">%[1]s</a>.`,
+	"account_disabled.text.disabled":      "Your %s account has been disabled.",
+	"account_disabled.text.contact_email": "If you believe this was done in error, please contact your administrator at %s.",
+	"account_disabled.reason_scheduled":   "The end date set for your account was reached.",
+
+	// Account disabling (scheduled deactivation warning)
+	"account_disabling.subject":   "Your %s account will be disabled soon",
+	"account_disabling.title":     "Account Ending Soon",
+	"account_disabling.date_html": "Your %s account is scheduled to be disabled on <strong>%s</strong>.",
+	"account_disabling.contact":   "If you need access after that date, please contact your administrator.",
+	"account_disabling.text": "Your %s account is scheduled to be disabled on %s.\n\n" +
+		"If you need access after that date, please contact your administrator.",
+
+	// Account enabled
+	"account_enabled.subject":      "Your %s account has been enabled",
+	"account_enabled.title":        "Account Enabled",
+	"account_enabled.great_news":   "Great news! Your %s account has been enabled. You can now log in and access your account.",
+	"account_enabled.text.enabled": "Your %s account has been enabled.",
+	"account_enabled.text.login":   "You can now log in at:\n%s",
+
+	// New login
+	"new_login.title":        "New Login Detected",
+	"new_login.detected":     "A new login to your %s account was detected.",
+	"new_login.if_you_html":  "<strong>If this was you</strong>, you can safely ignore this email.",
+	"new_login.if_not_html":  "<strong>If this was NOT you</strong>, please secure your account immediately by changing your password and reviewing your recent activity.",
+	"new_login.button":       "Review Account",
+	"new_login.text.details": "Details:",
+	"new_login.text.if_you":  "If this was you, you can safely ignore this email.",
+	"new_login.text.if_not": "If this was NOT you, please secure your account immediately by:\n" +
+		"1. Changing your password\n" +
+		"2. Reviewing your recent activity",
+	"new_login.text.visit": "Visit: %s",
+
+	// Resource assigned
+	"resource_assigned.title":         "New Resource Available",
+	"resource_assigned.assigned_html": "A new %s has been assigned to your group <strong>%s</strong>.",
+	"resource_assigned.button":        "Launch Resource",
+	"resource_assigned.text.assigned": "A new %s has been assigned to your group \"%s\".",
+	"resource_assigned.text.resource": "Resource: %s",
+
+	// Material assigned
+	"material_assigned.title":         "New Material Available",
+	"material_assigned.assigned":      "A new %s has been assigned to you.",
+	"material_assigned.button":        "Access Material",
+	"material_assigned.text.material": "Material: %s",
+
+	// Group membership
+	"group_membership.title":          "Added to Group",
+	"group_membership.added":          "You have been added to a group.",
+	"group_membership.added_org_html": "You have been added to a group in <strong>%s</strong>.",
+	"group_membership.role_html":      "Your role: <strong>%s</strong>",
+	"group_membership.button":         "View Group",
+	"group_membership.text.added":     "You have been added to the group \"%s\".",
+	"group_membership.text.added_org": "You have been added to the group \"%s\" in %s.",
+	"group_membership.text.view":      "View your group:\n%s",
+
+	// Announcement digest
+	"announcement_digest.title":         "Announcements",
+	"announcement_digest.heading":       "Latest Announcements",
+	"announcement_digest.intro":         "Hello %s, here are the latest announcements:",
+	"announcement_digest.button":        "View All Announcements",
+	"announcement_digest.text.intro":    "Here are the latest announcements from %s:",
+	"announcement_digest.text.view_all": "View all announcements:\n%s",
+	"announcement.type.info":            "info",
+	"announcement.type.warning":         "warning",
+	"announcement.type.critical":        "critical",
+
+	// API key alert
+	"api_key_alert.button":    "View API Key",
+	"api_key_alert.text.view": "View the key:\n%s",
+
+	// Security alert
+	"security_alert.button":    "Review Audit Log",
+	"security_alert.text.view": "Review the audit log:\n%s",
+
+	// Scheduled report
+	"report.period":    "Period: %s",
+	"report.button":    "Open Dashboard",
+	"report.text.view": "Open the dashboard:\n%s",
+}
+
+var messagesES = map[string]string{
+	// Shared
+	"greeting":                      "Hola %s:",
+	"contact_admin":                 "Si tienes alguna pregunta, comunícate con tu administrador.",
+	"button.log_in":                 "Iniciar sesión",
+	"footer.link_fallback":          "Si el botón no funciona, copia y pega este enlace en tu navegador:",
+	"footer.automated_notification": "Esta es una notificación automática de %s.",
+	"footer.automated_message":      "Este es un mensaje automático de %s.",
+	"footer.security_notice":        "Esta es una notificación de seguridad automática. No respondas a este correo.",
+	"label.your_role":               "Tu rol:",
+	"label.reason":                  "Motivo:",
+	"label.device":                  "Dispositivo:",
+	"label.ip_address":              "Dirección IP:",
+	"label.location":                "Ubicación:",
+	"label.time":                    "Hora:",
+	"label.key":                     "Clave:",
+	"label.instructions":            "Instrucciones",
+	"label.directions":              "Indicaciones",
+	"label.available_from":          "Disponible desde: %s",
+	"label.available_until":         "Disponible hasta: %s",
+	"label.until":                   "Hasta: %s",
+	"text.access_here":              "Accede aquí:\n%s",
+
+	// Password reset
+	"password_reset.subject":     "Solicitud de restablecimiento de contraseña",
+	"password_reset.title":       "Restablecer contraseña",
+	"password_reset.heading":     "Restablece tu contraseña",
+	"password_reset.intro":       "Solicitaste restablecer la contraseña de tu cuenta. Haz clic en el botón de abajo para crear una nueva contraseña.",
+	"password_reset.button":      "Restablecer contraseña",
+	"password_reset.expiry_html": "Este enlace caducará en <strong>%d minutos</strong>.",
+	"password_reset.ignore":      "Si no solicitaste este cambio, puedes ignorar este correo. Tu contraseña no cambiará.",
+	"password_reset.text": "Solicitaste restablecer la contraseña de tu cuenta de %s.\n\n" +
+		"Haz clic en el siguiente enlace para restablecer tu contraseña:\n\n%s\n\n" +
+		"Este enlace caducará en %d minutos.\n\n" +
+		"Si no lo solicitaste, puedes ignorar este correo.",
+	"password_reset.intro_admin":  "Un administrador te envió un enlace para establecer una nueva contraseña para tu cuenta. Haz clic en el botón de abajo para elegirla.",
+	"password_reset.ignore_admin": "Si no esperabas este correo, comunícate con tu administrador. Tu contraseña no cambiará hasta que elijas una nueva.",
+	"password_reset.text_admin": "Un administrador te envió un enlace para establecer una nueva contraseña para tu cuenta de %s.\n\n" +
+		"Haz clic en el siguiente enlace para elegir una nueva contraseña:\n\n%s\n\n" +
+		"Este enlace caducará en %d minutos.\n\n" +
+		"Si no esperabas este correo, comunícate con tu administrador.",
+
+	// Login code
+	"login_code.subject":     "Tu código de inicio de sesión",
+	"login_code.title":       "Código de inicio de sesión",
+	"login_code.heading":     "Tu código de inicio de sesión",
+	"login_code.enter_code":  "Introduce este código para iniciar sesión en tu cuenta:",
+	"login_code.or_click":    "O haz clic en el botón de abajo para iniciar sesión automáticamente:",
+	"login_code.expiry_html": "Este código caducará en <strong>10 minutos</strong>. Si no lo solicitaste, puedes ignorar este correo.",
+	"login_code.text": "Tu código de inicio de sesión de %s es: %s\n\n" +
+		"O haz clic aquí para iniciar sesión:\n%s\n\n" +
+		"Este código caducará en 10 minutos.\n\n" +
+		"Si no lo solicitaste, puedes ignorar este correo.",
+	"login_code.text_no_link": "Tu código de inicio de sesión de %s es: %s\n\n" +
+		"Este código caducará en 10 minutos.\n\n" +
+		"Si no lo solicitaste, puedes ignorar este correo.",
+
+	// Password changed
+	"password_changed.subject":     "Tu contraseña ha sido cambiada",
+	"password_changed.title":       "Contraseña cambiada",
+	"password_changed.changed":     "La contraseña de tu cuenta de %s se cambió correctamente.",
+	"password_changed.if_you_html": "<strong>Si hiciste este cambio</strong>, puedes ignorar este correo.",
+	"password_changed.if_not_html": "<strong>Si NO hiciste este cambio</strong>, es posible que tu cuenta esté comprometida. Restablece tu contraseña de inmediato y revisa la actividad reciente de tu cuenta.",
+	"password_changed.button":      "Ir a iniciar sesión",
+	"password_changed.text": "La contraseña de tu cuenta de %s ha sido cambiada.\n\n" +
+		"Si hiciste este cambio, puedes ignorar este correo.\n\n" +
+		"Si NO hiciste este cambio, es posible que tu cuenta esté comprometida. " +
+		"Restablece tu contraseña de inmediato en:\n%s\n\n" +
+		"Por seguridad, te recomendamos revisar también la actividad reciente de tu cuenta.",
+
+	// Password expiring
+	"password_expiring.subject":      "Tu contraseña caducará pronto",
+	"password_expiring.title":        "Contraseña por caducar",
+	"password_expiring.expires_html": "Tu contraseña de %s caducará el <strong>%s</strong>.",
+	"password_expiring.change":       "Cámbiala antes de esa fecha para que no se te pida una nueva la próxima vez que inicies sesión.",
+	"password_expiring.button":       "Cambiar contraseña",
+	"password_expiring.text": "Tu contraseña de %s caducará el %s.\n\n" +
+		"Cámbiala antes de esa fecha para que no se te pida una nueva la próxima vez que inicies sesión:\n%s",
+
+	// Welcome
+	"welcome.subject":          "Bienvenido a %s",
+	"welcome.title":            "Bienvenida",
+	"welcome.heading":          "¡Te damos la bienvenida, %s!",
+	"welcome.created":          "Se ha creado tu cuenta.",
+	"welcome.created_org_html": "Se ha creado tu cuenta para <strong>%s</strong>.",
+	"welcome.get_started":      "Haz clic en el botón de abajo para iniciar sesión y comenzar.",
+	"welcome.text.greeting":    "Te damos la bienvenida a %s, %s.",
+	"welcome.text.created":     "Se ha creado tu cuenta con el rol de %s.",
+	"welcome.text.created_org": "Se ha creado tu cuenta para %s con el rol de %s.",
+	"welcome.text.login":       "Para comenzar, inicia sesión en:\n%s",
+
+	// Invitation
+	"invitation.title":            "Invitación",
+	"invitation.heading":          "¡Estás invitado!",
+	"invitation.invited_html":     "<strong>%s</strong> te ha invitado a unirte a %s.",
+	"invitation.invited_org_html": "<strong>%s</strong> te ha invitado a unirte a %s como parte de <strong>%s</strong>.",
+	"invitation.button":           "Aceptar invitación",
+	"invitation.expires_html":     "Esta invitación caducará en <strong>%s</strong>.",
+	"invitation.ignore":           "Si no esperabas esta invitación, puedes ignorar este correo.",
+	"invitation.text.invited":     "%s te ha invitado a unirte a %s.",
+	"invitation.text.invited_org": "%s te ha invitado a unirte a %s como parte de %s.",
+	"invitation.text.role":        "Tendrás el rol de %s.",
+	"invitation.text.accept":      "Para aceptar esta invitación, visita:\n%s",
+	"invitation.text.expires":     "Esta invitación caducará en %s.",
+	"invitation.message":          "Una nota de %s",
+	"invitation.one_day":          "1 día",
+	"invitation.days":             "%d días",
+
+	// Account disabled
+	"account_disabled.subject":            "Tu cuenta de %s ha sido desactivada",
+	"account_disabled.title":              "Cuenta desactivada",
+	"account_disabled.by_admin":           "Un administrador ha desactivado tu cuenta de %s.",
+	"account_disabled.contact":            "Si crees que se trata de un error, comunícate con tu administrador.",
+	"account_disabled.contact_email_html": `Si crees que se trata de un error, comunícate con tu administrador en <a href="mailto:%[1]s" style="color: %[2]s;">%[1]s</a>.`,
+	"account_disabled.text.disabled":      "Tu cuenta de %s ha sido desactivada.",
+	"account_disabled.text.contact_email": "Si crees que se trata de un error, comunícate con tu administrador en %s.",
+	"account_disabled.reason_scheduled":   "Se alcanzó la fecha de finalización establecida para tu cuenta.",
+
+	// Account disabling (scheduled deactivation warning)
+	"account_disabling.subject":   "Tu cuenta de %s se desactivará pronto",
+	"account_disabling.title":     "Tu cuenta terminará pronto",
+	"account_disabling.date_html": "Tu cuenta de %s se desactivará el <strong>%s</strong>.",
+	"account_disabling.contact":   "Si necesitas acceso después de esa fecha, comunícate con tu administrador.",
+	"account_disabling.text": "Tu cuenta de %s se desactivará el %s.\n\n" +
+		"Si necesitas acceso después de esa fecha, comunícate con tu administrador.",
+
+	// Account enabled
+	"account_enabled.subject":      "Tu cuenta de %s ha sido activada",
+	"account_enabled.title":        "Cuenta activada",
+	"account_enabled.great_news":   "¡Buenas noticias! Tu cuenta de %s ha sido activada. Ya puedes iniciar sesión y acceder a tu cuenta.",
+	"account_enabled.text.enabled": "Tu cuenta de %s ha sido activada.",
+	"account_enabled.text.login":   "Ya puedes iniciar sesión en:\n%s",
+
+	// New login
+	"new_login.title":        "Nuevo inicio de sesión detectado",
+	"new_login.detected":     "Se detectó un nuevo inicio de sesión en tu cuenta de %s.",
+	"new_login.if_you_html":  "<strong>Si fuiste tú</strong>, puedes ignorar este correo.",
+	"new_login.if_not_html":  "<strong>Si NO fuiste tú</strong>, protege tu cuenta de inmediato cambiando tu contraseña y revisando tu actividad reciente.",
+	"new_login.button":       "Revisar cuenta",
+	"new_login.text.details": "Detalles:",
+	"new_login.text.if_you":  "Si fuiste tú, puedes ignorar este correo.",
+	"new_login.text.if_not": "Si NO fuiste tú, protege tu cuenta de inmediato:\n" +
+		"1. Cambia tu contraseña\n" +
+		"2. Revisa tu actividad reciente",
+	"new_login.text.visit": "Visita: %s",
+
+	// Resource assigned
+	"resource_assigned.title":         "Nuevo recurso disponible",
+	"resource_assigned.assigned_html": "Se ha asignado un nuevo %s a tu grupo <strong>%s</strong>.",
+	"resource_assigned.button":        "Abrir recurso",
+	"resource_assigned.text.assigned": "Se ha asignado un nuevo %s a tu grupo \"%s\".",
+	"resource_assigned.text.resource": "Recurso: %s",
+
+	// Material assigned
+	"material_assigned.title":         "Nuevo material disponible",
+	"material_assigned.assigned":      "Se te ha asignado un nuevo %s.",
+	"material_assigned.button":        "Ver material",
+	"material_assigned.text.material": "Material: %s",
+
+	// Group membership
+	"group_membership.title":          "Agregado a un grupo",
+	"group_membership.added":          "Te han agregado a un grupo.",
+	"group_membership.added_org_html": "Te han agregado a un grupo en <strong>%s</strong>.",
+	"group_membership.role_html":      "Tu rol: <strong>%s</strong>",
+	"group_membership.button":         "Ver grupo",
+	"group_membership.text.added":     "Te han agregado al grupo \"%s\".",
+	"group_membership.text.added_org": "Te han agregado al grupo \"%s\" en %s.",
+	"group_membership.text.view":      "Ver tu grupo:\n%s",
+
+	// Announcement digest
+	"announcement_digest.title":         "Anuncios",
+	"announcement_digest.heading":       "Últimos anuncios",
+	"announcement_digest.intro":         "Hola %s, estos son los últimos anuncios:",
+	"announcement_digest.button":        "Ver todos los anuncios",
+	"announcement_digest.text.intro":    "Estos son los últimos anuncios de %s:",
+	"announcement_digest.text.view_all": "Ver todos los anuncios:\n%s",
+	"announcement.type.info":            "información",
+	"announcement.type.warning":         "aviso",
+	"announcement.type.critical":        "crítico",
+
+	// API key alert
+	"api_key_alert.button":    "Ver clave de API",
+	"api_key_alert.text.view": "Ver la clave:\n%s",
+
+	// Security alert
+	"security_alert.button":    "Revisar el registro de auditoría",
+	"security_alert.text.view": "Revisa el registro de auditoría:\n%s",
+
+	// Scheduled report
+	"report.period":    "Periodo: %s",
+	"report.button":    "Abrir el panel",
+	"report.text.view": "Abre el panel:\n%s",
+}
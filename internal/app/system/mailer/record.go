@@ -0,0 +1,88 @@
+// internal/app/system/mailer/record.go
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Delivery statuses recorded in the delivery log.
+const (
+	DeliverySent       = "sent"       // Accepted by the SMTP server
+	DeliveryFailed     = "failed"     // The SMTP server refused it or couldn't be reached
+	DeliverySuppressed = "suppressed" // Not sent; the recipient is on the suppression list
+)
+
+// Delivery is one delivery attempt, as recorded in the delivery log.
+type Delivery struct {
+	To        string
+	UserID    string // Hex user ID, if known
+	Template  string
+	Subject   string
+	MessageID string // Message-ID header, without angle brackets; empty if not sent
+	Status    string
+	Error     string
+}
+
+// Recorder keeps a log of delivery attempts.
+type Recorder interface {
+	Record(ctx context.Context, d Delivery) error
+}
+
+// SetRecorder records every delivery attempt with r. Call it during startup,
+// before any email is sent.
+func (m *Mailer) SetRecorder(r Recorder) {
+	m.recorder = r
+}
+
+// RecordSuppressed records that email was not sent because the recipient is
+// suppressed.
+func (m *Mailer) RecordSuppressed(email Email) {
+	m.record(email, "", DeliverySuppressed, ErrSuppressed)
+}
+
+// record logs a delivery attempt. Failures are only logged so a database
+// problem never affects sending.
+func (m *Mailer) record(email Email, messageID, status string, sendErr error) {
+	if m.recorder == nil {
+		return
+	}
+	d := Delivery{
+		To:        email.To,
+		UserID:    email.UserID,
+		Template:  email.Template,
+		Subject:   email.Subject,
+		MessageID: messageID,
+		Status:    status,
+	}
+	if sendErr != nil {
+		d.Error = sendErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.recorder.Record(ctx, d); err != nil {
+		m.log.Warn("failed to record email delivery",
+			zap.String("to", email.To),
+			zap.Error(err))
+	}
+}
+
+// newMessageID returns a unique Message-ID in the sender's domain, without
+// angle brackets.
+func newMessageID(from string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand.Read failed: " + err.Error())
+	}
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		domain = from[i+1:]
+	}
+	return hex.EncodeToString(b) + "@" + domain
+}
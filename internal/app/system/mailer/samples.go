@@ -0,0 +1,219 @@
+// internal/app/system/mailer/samples.go
+package mailer
+
+// Sample is an email template rendered with placeholder data, used to
+// preview templates and send test messages from the settings page.
+type Sample struct {
+	Name  string // Identifier, e.g. "password_reset"
+	Label string // Display name, e.g. "Password reset"
+
+	render func(locale, appName string, brand Brand) Email
+}
+
+// samples lists every email template, in the order shown to admins.
+var samples = []Sample{
+	{Name: "password_reset", Label: "Password reset", render: func(locale, appName string, brand Brand) Email {
+		text, html := PasswordResetEmail(PasswordResetEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			ResetURL:  "https://example.com/reset?token=sample",
+			ExpiryMin: 60,
+		})
+		return Email{Subject: T(locale, "password_reset.subject"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "password_reset_admin", Label: "Password reset (sent by an admin)", render: func(locale, appName string, brand Brand) Email {
+		text, html := PasswordResetEmail(PasswordResetEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			ResetURL:  "https://example.com/reset?token=sample",
+			ExpiryMin: 60,
+			ByAdmin:   true,
+		})
+		return Email{Subject: T(locale, "password_reset.subject"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "login_code", Label: "Login code", render: func(locale, appName string, brand Brand) Email {
+		text, html := LoginCodeEmail(LoginCodeEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			Code:     "123456",
+			MagicURL: "https://example.com/login/magic?token=sample",
+		})
+		return Email{Subject: T(locale, "login_code.subject"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "password_changed", Label: "Password changed", render: func(locale, appName string, brand Brand) Email {
+		text, html := PasswordChangedEmail(PasswordChangedEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			LoginURL: "https://example.com/login",
+		})
+		return Email{Subject: T(locale, "password_changed.subject"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "password_expiring", Label: "Password expiring", render: func(locale, appName string, brand Brand) Email {
+		text, html := PasswordExpiringEmail(PasswordExpiringEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:  "Jordan Lee",
+			ExpiresOn: "March 14, 2026",
+			ChangeURL: "https://example.com/profile",
+		})
+		return Email{Subject: T(locale, "password_expiring.subject"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "welcome", Label: "Welcome", render: func(locale, appName string, brand Brand) Email {
+		text, html := WelcomeEmail(WelcomeEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName: "Jordan Lee",
+			LoginURL: "https://example.com/login",
+			Role:     "member",
+			OrgName:  "Example School",
+		})
+		return Email{Subject: T(locale, "welcome.subject", appName), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "invitation", Label: "Invitation", render: func(locale, appName string, brand Brand) Email {
+		text, html := InvitationEmail(InvitationEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			InviterName:   "Sam Rivera",
+			RecipientName: "Jordan Lee",
+			Role:          "member",
+			OrgName:       "Example School",
+			AcceptURL:     "https://example.com/invite?token=sample",
+			ExpiresIn:     InvitationExpiresIn(locale, 7),
+			Message:       "Welcome aboard! Your login gives you access to the shared project files.",
+		})
+		return Email{Subject: T(locale, "invitation.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "account_disabled", Label: "Account disabled", render: func(locale, appName string, brand Brand) Email {
+		text, html := AccountDisabledEmail(AccountDisabledEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:     "Jordan Lee",
+			Reason:       "Account review",
+			ContactEmail: "support@example.com",
+		})
+		return Email{Subject: T(locale, "account_disabled.subject", appName), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "account_disabling", Label: "Account disabling soon", render: func(locale, appName string, brand Brand) Email {
+		text, html := AccountDisablingEmail(AccountDisablingEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:  "Jordan Lee",
+			DisableOn: "March 14, 2026",
+		})
+		return Email{Subject: T(locale, "account_disabling.subject", appName), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "account_enabled", Label: "Account enabled", render: func(locale, appName string, brand Brand) Email {
+		text, html := AccountEnabledEmail(AccountEnabledEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName: "Jordan Lee",
+			LoginURL: "https://example.com/login",
+		})
+		return Email{Subject: T(locale, "account_enabled.subject", appName), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "new_login", Label: "New login", render: func(locale, appName string, brand Brand) Email {
+		text, html := NewLoginEmail(NewLoginEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:  "Jordan Lee",
+			Device:    "Chrome on Windows",
+			IPAddress: "203.0.113.42",
+			Location:  "Columbia, US",
+			LoginTime: "January 2, 2026 at 3:04 PM UTC",
+			LoginURL:  "https://example.com/login",
+		})
+		return Email{Subject: T(locale, "new_login.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "resource_assigned", Label: "Resource assigned", render: func(locale, appName string, brand Brand) Email {
+		text, html := ResourceAssignedEmail(ResourceAssignedEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:     "Jordan Lee",
+			ResourceName: "Sample Game",
+			ResourceType: "game",
+			GroupName:    "Period 3",
+			Instructions: "Complete the first two levels before Friday.",
+			LaunchURL:    "https://example.com/resources/sample",
+			VisibleFrom:  "January 5, 2026",
+			VisibleUntil: "January 30, 2026",
+		})
+		return Email{Subject: T(locale, "resource_assigned.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "material_assigned", Label: "Material assigned", render: func(locale, appName string, brand Brand) Email {
+		text, html := MaterialAssignedEmail(MaterialAssignedEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:     "Jordan Lee",
+			MaterialName: "Teacher Guide",
+			MaterialType: "guide",
+			Directions:   "Read before the first session.",
+			AccessURL:    "https://example.com/materials/sample",
+			VisibleFrom:  "January 5, 2026",
+		})
+		return Email{Subject: T(locale, "material_assigned.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "group_membership", Label: "Group membership", render: func(locale, appName string, brand Brand) Email {
+		text, html := GroupMembershipEmail(GroupMembershipEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName:  "Jordan Lee",
+			GroupName: "Period 3",
+			OrgName:   "Example School",
+			Role:      "member",
+			GroupURL:  "https://example.com/groups/sample",
+		})
+		return Email{Subject: T(locale, "group_membership.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "announcement_digest", Label: "Announcement digest", render: func(locale, appName string, brand Brand) Email {
+		text, html := AnnouncementDigestEmail(AnnouncementDigestEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			UserName: "Jordan Lee",
+			Announcements: []AnnouncementItem{
+				{Title: "Scheduled maintenance", Content: "The site will be unavailable Saturday from 2 to 4 AM.", Type: "warning"},
+				{Title: "New resources", Content: "Three new games have been added to the library.", Type: "info"},
+			},
+			ViewAllURL: "https://example.com/my-announcements",
+		})
+		return Email{Subject: T(locale, "announcement_digest.title"), TextBody: text, HTMLBody: html}
+	}},
+	{Name: "api_key_alert", Label: "API key alert", render: func(locale, appName string, brand Brand) Email {
+		text, html := APIKeyAlertEmail(APIKeyAlertEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			Heading:   "API Key Revoked",
+			Message:   "An API key was revoked and can no longer be used.",
+			KeyName:   "Sample Key",
+			KeyPrefix: "sk_live_ab12",
+			Details:   []string{"Revoked by: Sam Rivera"},
+			KeyURL:    "https://example.com/api-keys/sample",
+		})
+		return Email{Subject: "[" + appName + "] API Key Revoked: Sample Key", TextBody: text, HTMLBody: html}
+	}},
+	{Name: "security_alert", Label: "Security alert", render: func(locale, appName string, brand Brand) Email {
+		text, html := SecurityAlertEmail(SecurityAlertEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			Heading:   "Suspicious Login Activity",
+			Message:   "Failed logins for many accounts came from one IP address.",
+			Details:   []string{"IP address: 203.0.113.7", "Accounts tried: 12 in 15m", "Blocked until: Mar 1, 2026 14:30 UTC"},
+			ReviewURL: "https://example.com/audit?category=security",
+		})
+		return Email{Subject: "[" + appName + "] Suspicious Login Activity", TextBody: text, HTMLBody: html}
+	}},
+	{Name: "report", Label: "Scheduled report", render: func(locale, appName string, brand Brand) Email {
+		text, html := ReportEmail(ReportEmailData{
+			Locale: locale, Brand: brand, AppName: appName,
+			Heading: "Weekly Report",
+			Period:  "Mar 2 - Mar 8, 2026",
+			Sections: []ReportSection{
+				{Title: "API usage", Rows: []ReportRow{
+					{Label: "State API requests", Value: "12,480 (0.4% errors, avg 38 ms)"},
+					{Label: "Settings API requests", Value: "3,102 (0.1% errors, avg 21 ms)"},
+				}},
+				{Title: "New users", Rows: []ReportRow{{Label: "Created", Value: "14"}, {Label: "Total users", Value: "382"}}},
+			},
+			DashboardURL: "https://example.com/dashboard",
+		})
+		return Email{Subject: "[" + appName + "] Weekly Report", TextBody: text, HTMLBody: html}
+	}},
+}
+
+// Samples returns every email template that can be previewed.
+func Samples() []Sample {
+	return samples
+}
+
+// RenderSample renders the named template with placeholder data. The
+// returned Email has no recipient. ok is false if there is no such template.
+func RenderSample(name, locale, appName string, brand Brand) (email Email, ok bool) {
+	for _, s := range samples {
+		if s.Name == name {
+			return s.render(locale, appName, brand), true
+		}
+	}
+	return Email{}, false
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSample(t *testing.T) {
+	brand := Brand{FooterText: "Sample Footer"}
+	for _, s := range Samples() {
+		for _, locale := range []string{"en", "es"} {
+			email, ok := RenderSample(s.Name, locale, "Strata", brand)
+			if !ok {
+				t.Fatalf("RenderSample(%q) not found", s.Name)
+			}
+			if email.Subject == "" || email.TextBody == "" {
+				t.Errorf("%s/%s: empty subject or text body", s.Name, locale)
+			}
+			if !strings.Contains(email.HTMLBody, "Sample Footer") {
+				t.Errorf("%s/%s: HTML body missing brand footer", s.Name, locale)
+			}
+			if strings.Contains(email.Subject, "%!") || strings.Contains(email.TextBody, "%!") {
+				t.Errorf("%s/%s: formatting error in output", s.Name, locale)
+			}
+		}
+	}
+}
+
+func TestRenderSample_Unknown(t *testing.T) {
+	if _, ok := RenderSample("nope", "en", "Strata", Brand{}); ok {
+		t.Error("RenderSample(unknown) ok = true, want false")
+	}
+}
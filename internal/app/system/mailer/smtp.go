@@ -0,0 +1,91 @@
+// internal/app/system/mailer/smtp.go
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SMTP is the server emails are delivered through and the sender they
+// come from.
+type SMTP struct {
+	Host     string
+	Port     int
+	User     string
+	Pass     string
+	From     string
+	FromName string
+}
+
+// SMTPProvider supplies SMTP settings that replace the configured ones,
+// typically from editable site settings. It reports false when it has
+// none, so the configured settings are used.
+type SMTPProvider interface {
+	SMTP(ctx context.Context) (SMTP, bool, error)
+}
+
+// SetSMTPProvider makes every delivery use the settings from p when it has
+// some, so they can be changed without a restart. Call it during startup,
+// before any email is sent.
+func (m *Mailer) SetSMTPProvider(p SMTPProvider) {
+	m.smtpSource = p
+}
+
+// ConfiguredSMTP returns the SMTP settings the mailer was created with.
+func (m *Mailer) ConfiguredSMTP() SMTP {
+	return m.smtp
+}
+
+// SMTP returns the settings the next email will be delivered with: the
+// provider's if it has some, otherwise the configured ones. If the
+// provider fails, the configured settings are used so email still goes out.
+func (m *Mailer) SMTP(ctx context.Context) SMTP {
+	if m.smtpSource == nil {
+		return m.smtp
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	s, ok, err := m.smtpSource.SMTP(ctx)
+	if err != nil {
+		m.log.Warn("failed to load SMTP settings, using configured settings", zap.Error(err))
+		return m.smtp
+	}
+	if !ok {
+		return m.smtp
+	}
+	return s
+}
+
+// Ping checks that the SMTP server the next email would go through
+// answers. See PingSMTP.
+func (m *Mailer) Ping(ctx context.Context) error {
+	return PingSMTP(ctx, m.SMTP(ctx))
+}
+
+// PingSMTP connects to an SMTP server, waits for its greeting, and hangs
+// up. It does not log in.
+func PingSMTP(ctx context.Context, s SMTP) error {
+	if s.Host == "" {
+		return errors.New("no SMTP host is set")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	return client.Quit()
+}
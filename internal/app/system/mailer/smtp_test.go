@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type fakeSMTPProvider struct {
+	smtp SMTP
+	ok   bool
+	err  error
+}
+
+func (f fakeSMTPProvider) SMTP(context.Context) (SMTP, bool, error) { return f.smtp, f.ok, f.err }
+
+func TestMailerSMTP(t *testing.T) {
+	m := New(Config{Host: "localhost", Port: 1025, From: "noreply@example.com", FromName: "Strata"}, zap.NewNop())
+	configured := SMTP{Host: "localhost", Port: 1025, From: "noreply@example.com", FromName: "Strata"}
+	if got := m.SMTP(context.Background()); got != configured {
+		t.Errorf("SMTP() without provider = %+v, want %+v", got, configured)
+	}
+
+	saved := SMTP{Host: "smtp.example.com", Port: 587, User: "u", Pass: "p", From: "hello@example.com", FromName: "Example"}
+	m.SetSMTPProvider(fakeSMTPProvider{smtp: saved, ok: true})
+	if got := m.SMTP(context.Background()); got != saved {
+		t.Errorf("SMTP() = %+v, want the provider's %+v", got, saved)
+	}
+	if got := m.FromName(); got != "Example" {
+		t.Errorf("FromName() = %q, want the provider's", got)
+	}
+
+	m.SetSMTPProvider(fakeSMTPProvider{})
+	if got := m.SMTP(context.Background()); got != configured {
+		t.Errorf("SMTP() when provider has none = %+v, want configured", got)
+	}
+
+	m.SetSMTPProvider(fakeSMTPProvider{smtp: saved, ok: true, err: errors.New("db down")})
+	if got := m.SMTP(context.Background()); got != configured {
+		t.Errorf("SMTP() on provider error = %+v, want configured", got)
+	}
+	if got := m.ConfiguredSMTP(); got != configured {
+		t.Errorf("ConfiguredSMTP() = %+v, want %+v", got, configured)
+	}
+}
+
+func TestPingSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("220 test ESMTP\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "QUIT") {
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			}
+			conn.Write([]byte("250 ok\r\n"))
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := PingSMTP(ctx, SMTP{Host: "127.0.0.1", Port: addr.Port}); err != nil {
+		t.Errorf("PingSMTP: %v", err)
+	}
+
+	if err := PingSMTP(ctx, SMTP{}); err == nil {
+		t.Error("PingSMTP with no host: expected error")
+	}
+	ln.Close()
+	if err := PingSMTP(ctx, SMTP{Host: "127.0.0.1", Port: addr.Port}); err == nil {
+		t.Error("PingSMTP to a closed port: expected error")
+	}
+}
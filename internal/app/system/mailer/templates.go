@@ -3,29 +3,31 @@ package mailer
 
 import (
 	"bytes"
-	"html"
 	"html/template"
 )
 
 // PasswordResetEmailData contains the data for a password reset email.
 type PasswordResetEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName   string
 	ResetURL  string
 	ExpiryMin int
+	ByAdmin   bool // An admin sent the link, rather than the user asking for it
 }
 
 // PasswordResetEmail generates both plain text and HTML versions of a password reset email.
 func PasswordResetEmail(data PasswordResetEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "You requested a password reset for your " + data.AppName + " account.\n\n" +
-		"Click the link below to reset your password:\n\n" +
-		data.ResetURL + "\n\n" +
-		"This link will expire in " + itoa(data.ExpiryMin) + " minutes.\n\n" +
-		"If you did not request this, you can safely ignore this email."
+	key := "password_reset.text"
+	if data.ByAdmin {
+		key = "password_reset.text_admin"
+	}
+	textBody = T(data.Locale, key, data.AppName, data.ResetURL, data.ExpiryMin)
 
 	// HTML version
 	var buf bytes.Buffer
-	htmlTmpl.Execute(&buf, data)
+	passwordResetHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -33,47 +35,79 @@ func PasswordResetEmail(data PasswordResetEmailData) (textBody, htmlBody string)
 
 // LoginCodeEmailData contains the data for a login code email.
 type LoginCodeEmailData struct {
+	Locale   string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand    Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName  string
 	Code     string
-	MagicURL string
+	MagicURL string // Empty leaves the link out, as for codes that confirm a signed-in user
 }
 
 // PasswordChangedEmailData contains the data for a password changed confirmation email.
 type PasswordChangedEmailData struct {
+	Locale   string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand    Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName  string
 	LoginURL string
 }
 
-// WelcomeEmailData contains the data for a welcome email sent to new users.
-type WelcomeEmailData struct {
+// PasswordExpiringEmailData contains the data for a password expiry warning.
+type PasswordExpiringEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName   string
 	UserName  string
-	LoginURL  string
-	Role      string // e.g., "member", "leader", "admin"
-	OrgName   string // Organization name (optional)
+	ExpiresOn string // Formatted date
+	ChangeURL string // Where the password can be changed
+}
+
+// WelcomeEmailData contains the data for a welcome email sent to new users.
+type WelcomeEmailData struct {
+	Locale   string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand    Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName  string
+	UserName string
+	LoginURL string
+	Role     string // e.g., "member", "leader", "admin"
+	OrgName  string // Organization name (optional)
 }
 
 // InvitationEmailData contains the data for an invitation email.
 type InvitationEmailData struct {
+	Locale        string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand         Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName       string
 	InviterName   string
 	RecipientName string
 	Role          string
 	OrgName       string // Organization name (optional)
 	AcceptURL     string
-	ExpiresIn     string // e.g., "7 days"
+	ExpiresIn     string // e.g., "7 days"; see InvitationExpiresIn
+	Message       string // Personal note from the inviter (optional)
 }
 
 // AccountDisabledEmailData contains the data for an account disabled notification.
 type AccountDisabledEmailData struct {
-	AppName     string
-	UserName    string
-	Reason      string // Optional reason for disabling
+	Locale       string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand        Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName      string
+	UserName     string
+	Reason       string // Optional reason for disabling
 	ContactEmail string
 }
 
+// AccountDisablingEmailData contains the data for a scheduled deactivation warning.
+type AccountDisablingEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName   string
+	UserName  string
+	DisableOn string // Formatted date
+}
+
 // AccountEnabledEmailData contains the data for an account enabled notification.
 type AccountEnabledEmailData struct {
+	Locale   string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand    Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName  string
 	UserName string
 	LoginURL string
@@ -81,17 +115,21 @@ type AccountEnabledEmailData struct {
 
 // NewLoginEmailData contains the data for a new login security notification.
 type NewLoginEmailData struct {
-	AppName    string
-	UserName   string
-	Device     string // e.g., "Chrome on Windows"
-	IPAddress  string
-	Location   string // e.g., "New York, US" (optional)
-	LoginTime  string // Formatted timestamp
-	LoginURL   string
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName   string
+	UserName  string
+	Device    string // e.g., "Chrome on Windows"
+	IPAddress string
+	Location  string // e.g., "New York, US" (optional)
+	LoginTime string // Formatted timestamp
+	LoginURL  string
 }
 
 // ResourceAssignedEmailData contains the data for a resource assignment notification.
 type ResourceAssignedEmailData struct {
+	Locale       string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand        Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName      string
 	UserName     string
 	ResourceName string
@@ -105,6 +143,8 @@ type ResourceAssignedEmailData struct {
 
 // MaterialAssignedEmailData contains the data for a material assignment notification.
 type MaterialAssignedEmailData struct {
+	Locale       string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand        Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName      string
 	UserName     string
 	MaterialName string
@@ -117,6 +157,8 @@ type MaterialAssignedEmailData struct {
 
 // GroupMembershipEmailData contains the data for a group membership notification.
 type GroupMembershipEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName   string
 	UserName  string
 	GroupName string
@@ -134,23 +176,73 @@ type AnnouncementItem struct {
 
 // AnnouncementDigestEmailData contains the data for an announcement digest email.
 type AnnouncementDigestEmailData struct {
+	Locale        string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand         Brand  // Site branding, usually Mailer.Brand(ctx)
 	AppName       string
 	UserName      string
 	Announcements []AnnouncementItem
 	ViewAllURL    string
 }
 
+// APIKeyAlertEmailData contains the data for an API key lifecycle notification.
+type APIKeyAlertEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName   string
+	Heading   string // e.g., "API Key Revoked"
+	Message   string // One-sentence summary of what happened
+	KeyName   string
+	KeyPrefix string   // First characters of the key, for identification
+	Details   []string // Optional extra lines, e.g., "Revoked by: Jane Doe"
+	KeyURL    string   // Link to the key in the admin console
+}
+
+// SecurityAlertEmailData contains the data for a suspicious activity alert.
+type SecurityAlertEmailData struct {
+	Locale    string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand     Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName   string
+	Heading   string   // e.g., "Suspicious Login Activity"
+	Message   string   // One-sentence summary of what was detected
+	Details   []string // Extra lines, e.g., "IP address: 203.0.113.7"
+	ReviewURL string   // Link to the matching audit log entries
+}
+
+// ReportRow is one figure in a report section, e.g. "New users" and "12".
+type ReportRow struct {
+	Label string
+	Value string
+}
+
+// ReportSection is a titled group of figures in a scheduled report.
+type ReportSection struct {
+	Title string
+	Rows  []ReportRow
+}
+
+// ReportEmailData contains the data for a scheduled usage report.
+type ReportEmailData struct {
+	Locale       string // Recipient language, e.g. "es" (empty = DefaultLocale)
+	Brand        Brand  // Site branding, usually Mailer.Brand(ctx)
+	AppName      string
+	Heading      string // e.g., "Weekly Report"
+	Period       string // e.g., "Mar 2 - Mar 8, 2026"
+	Sections     []ReportSection
+	DashboardURL string // Link to the admin dashboard
+}
+
 // LoginCodeEmail generates both plain text and HTML versions of a login code email.
 func LoginCodeEmail(data LoginCodeEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Your " + data.AppName + " login code is: " + data.Code + "\n\n" +
-		"Or click here to log in:\n" + data.MagicURL + "\n\n" +
-		"This code will expire in 10 minutes.\n\n" +
-		"If you did not request this, you can safely ignore this email."
+	if data.MagicURL == "" {
+		textBody = T(data.Locale, "login_code.text_no_link", data.AppName, data.Code)
+	} else {
+		textBody = T(data.Locale, "login_code.text", data.AppName, data.Code, data.MagicURL)
+	}
 
 	// HTML version
 	var buf bytes.Buffer
-	loginCodeHTMLTmpl.Execute(&buf, data)
+	loginCodeHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -159,15 +251,25 @@ func LoginCodeEmail(data LoginCodeEmailData) (textBody, htmlBody string) {
 // PasswordChangedEmail generates both plain text and HTML versions of a password changed confirmation email.
 func PasswordChangedEmail(data PasswordChangedEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Your " + data.AppName + " password has been changed.\n\n" +
-		"If you made this change, you can safely ignore this email.\n\n" +
-		"If you did NOT make this change, your account may have been compromised. " +
-		"Please reset your password immediately by visiting:\n" + data.LoginURL + "\n\n" +
-		"For security, we recommend you also review your recent account activity."
+	textBody = T(data.Locale, "password_changed.text", data.AppName, data.LoginURL)
+
+	// HTML version
+	var buf bytes.Buffer
+	passwordChangedHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
+	htmlBody = buf.String()
+
+	return textBody, htmlBody
+}
+
+// PasswordExpiringEmail generates both plain text and HTML versions of a password expiry warning.
+func PasswordExpiringEmail(data PasswordExpiringEmailData) (textBody, htmlBody string) {
+	// Plain text version
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "password_expiring.text", data.AppName, data.ExpiresOn, data.ChangeURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	passwordChangedHTMLTmpl.Execute(&buf, data)
+	passwordExpiringHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -176,40 +278,54 @@ func PasswordChangedEmail(data PasswordChangedEmailData) (textBody, htmlBody str
 // WelcomeEmail generates both plain text and HTML versions of a welcome email.
 func WelcomeEmail(data WelcomeEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Welcome to " + data.AppName + ", " + data.UserName + "!\n\n" +
-		"Your account has been created"
+	textBody = T(data.Locale, "welcome.text.greeting", data.AppName, data.UserName) + "\n\n"
 	if data.OrgName != "" {
-		textBody += " for " + data.OrgName
+		textBody += T(data.Locale, "welcome.text.created_org", data.OrgName, data.Role)
+	} else {
+		textBody += T(data.Locale, "welcome.text.created", data.Role)
 	}
-	textBody += " with the role of " + data.Role + ".\n\n" +
-		"To get started, log in at:\n" + data.LoginURL + "\n\n" +
-		"If you have any questions, please contact your administrator."
+	textBody += "\n\n" +
+		T(data.Locale, "welcome.text.login", data.LoginURL) + "\n\n" +
+		T(data.Locale, "contact_admin")
 
 	// HTML version
 	var buf bytes.Buffer
-	welcomeHTMLTmpl.Execute(&buf, data)
+	welcomeHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
 }
 
+// InvitationExpiresIn returns how long an invitation lasts, in days, for
+// InvitationEmailData.ExpiresIn.
+func InvitationExpiresIn(locale string, days int) string {
+	if days == 1 {
+		return T(locale, "invitation.one_day")
+	}
+	return T(locale, "invitation.days", days)
+}
+
 // InvitationEmail generates both plain text and HTML versions of an invitation email.
 func InvitationEmail(data InvitationEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.RecipientName + ",\n\n" +
-		data.InviterName + " has invited you to join " + data.AppName
+	textBody = T(data.Locale, "greeting", data.RecipientName) + "\n\n"
 	if data.OrgName != "" {
-		textBody += " as part of " + data.OrgName
+		textBody += T(data.Locale, "invitation.text.invited_org", data.InviterName, data.AppName, data.OrgName)
+	} else {
+		textBody += T(data.Locale, "invitation.text.invited", data.InviterName, data.AppName)
 	}
-	textBody += ".\n\n" +
-		"You will have the role of " + data.Role + ".\n\n" +
-		"To accept this invitation, visit:\n" + data.AcceptURL + "\n\n" +
-		"This invitation will expire in " + data.ExpiresIn + ".\n\n" +
-		"If you did not expect this invitation, you can safely ignore this email."
+	if data.Message != "" {
+		textBody += "\n\n" + T(data.Locale, "invitation.message", data.InviterName) + ":\n" + data.Message
+	}
+	textBody += "\n\n" +
+		T(data.Locale, "invitation.text.role", data.Role) + "\n\n" +
+		T(data.Locale, "invitation.text.accept", data.AcceptURL) + "\n\n" +
+		T(data.Locale, "invitation.text.expires", data.ExpiresIn) + "\n\n" +
+		T(data.Locale, "invitation.ignore")
 
 	// HTML version
 	var buf bytes.Buffer
-	invitationHTMLTmpl.Execute(&buf, data)
+	invitationHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -218,20 +334,34 @@ func InvitationEmail(data InvitationEmailData) (textBody, htmlBody string) {
 // AccountDisabledEmail generates both plain text and HTML versions of an account disabled notification.
 func AccountDisabledEmail(data AccountDisabledEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"Your " + data.AppName + " account has been disabled.\n\n"
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "account_disabled.text.disabled", data.AppName) + "\n\n"
 	if data.Reason != "" {
-		textBody += "Reason: " + data.Reason + "\n\n"
+		textBody += T(data.Locale, "label.reason") + " " + data.Reason + "\n\n"
 	}
-	textBody += "If you believe this was done in error, please contact your administrator"
 	if data.ContactEmail != "" {
-		textBody += " at " + data.ContactEmail
+		textBody += T(data.Locale, "account_disabled.text.contact_email", data.ContactEmail)
+	} else {
+		textBody += T(data.Locale, "account_disabled.contact")
 	}
-	textBody += "."
 
 	// HTML version
 	var buf bytes.Buffer
-	accountDisabledHTMLTmpl.Execute(&buf, data)
+	accountDisabledHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
+	htmlBody = buf.String()
+
+	return textBody, htmlBody
+}
+
+// AccountDisablingEmail generates both plain text and HTML versions of a scheduled deactivation warning.
+func AccountDisablingEmail(data AccountDisablingEmailData) (textBody, htmlBody string) {
+	// Plain text version
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "account_disabling.text", data.AppName, data.DisableOn)
+
+	// HTML version
+	var buf bytes.Buffer
+	accountDisablingHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -240,14 +370,14 @@ func AccountDisabledEmail(data AccountDisabledEmailData) (textBody, htmlBody str
 // AccountEnabledEmail generates both plain text and HTML versions of an account enabled notification.
 func AccountEnabledEmail(data AccountEnabledEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"Your " + data.AppName + " account has been enabled.\n\n" +
-		"You can now log in at:\n" + data.LoginURL + "\n\n" +
-		"If you have any questions, please contact your administrator."
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "account_enabled.text.enabled", data.AppName) + "\n\n" +
+		T(data.Locale, "account_enabled.text.login", data.LoginURL) + "\n\n" +
+		T(data.Locale, "contact_admin")
 
 	// HTML version
 	var buf bytes.Buffer
-	accountEnabledHTMLTmpl.Execute(&buf, data)
+	accountEnabledHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -256,24 +386,22 @@ func AccountEnabledEmail(data AccountEnabledEmailData) (textBody, htmlBody strin
 // NewLoginEmail generates both plain text and HTML versions of a new login security notification.
 func NewLoginEmail(data NewLoginEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"A new login to your " + data.AppName + " account was detected.\n\n" +
-		"Details:\n" +
-		"  Device: " + data.Device + "\n" +
-		"  IP Address: " + data.IPAddress + "\n"
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "new_login.detected", data.AppName) + "\n\n" +
+		T(data.Locale, "new_login.text.details") + "\n" +
+		"  " + T(data.Locale, "label.device") + " " + data.Device + "\n" +
+		"  " + T(data.Locale, "label.ip_address") + " " + data.IPAddress + "\n"
 	if data.Location != "" {
-		textBody += "  Location: " + data.Location + "\n"
+		textBody += "  " + T(data.Locale, "label.location") + " " + data.Location + "\n"
 	}
-	textBody += "  Time: " + data.LoginTime + "\n\n" +
-		"If this was you, you can safely ignore this email.\n\n" +
-		"If this was NOT you, please secure your account immediately by:\n" +
-		"1. Changing your password\n" +
-		"2. Reviewing your recent activity\n\n" +
-		"Visit: " + data.LoginURL
+	textBody += "  " + T(data.Locale, "label.time") + " " + data.LoginTime + "\n\n" +
+		T(data.Locale, "new_login.text.if_you") + "\n\n" +
+		T(data.Locale, "new_login.text.if_not") + "\n\n" +
+		T(data.Locale, "new_login.text.visit", data.LoginURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	newLoginHTMLTmpl.Execute(&buf, data)
+	newLoginHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -282,23 +410,23 @@ func NewLoginEmail(data NewLoginEmailData) (textBody, htmlBody string) {
 // ResourceAssignedEmail generates both plain text and HTML versions of a resource assignment notification.
 func ResourceAssignedEmail(data ResourceAssignedEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"A new " + data.ResourceType + " has been assigned to your group \"" + data.GroupName + "\".\n\n" +
-		"Resource: " + data.ResourceName + "\n"
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "resource_assigned.text.assigned", data.ResourceType, data.GroupName) + "\n\n" +
+		T(data.Locale, "resource_assigned.text.resource", data.ResourceName) + "\n"
 	if data.VisibleFrom != "" {
-		textBody += "Available from: " + data.VisibleFrom + "\n"
+		textBody += T(data.Locale, "label.available_from", data.VisibleFrom) + "\n"
 	}
 	if data.VisibleUntil != "" {
-		textBody += "Available until: " + data.VisibleUntil + "\n"
+		textBody += T(data.Locale, "label.available_until", data.VisibleUntil) + "\n"
 	}
 	if data.Instructions != "" {
-		textBody += "\nInstructions:\n" + data.Instructions + "\n"
+		textBody += "\n" + T(data.Locale, "label.instructions") + ":\n" + data.Instructions + "\n"
 	}
-	textBody += "\nAccess it here:\n" + data.LaunchURL
+	textBody += "\n" + T(data.Locale, "text.access_here", data.LaunchURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	resourceAssignedHTMLTmpl.Execute(&buf, data)
+	resourceAssignedHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -307,23 +435,23 @@ func ResourceAssignedEmail(data ResourceAssignedEmailData) (textBody, htmlBody s
 // MaterialAssignedEmail generates both plain text and HTML versions of a material assignment notification.
 func MaterialAssignedEmail(data MaterialAssignedEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"A new " + data.MaterialType + " has been assigned to you.\n\n" +
-		"Material: " + data.MaterialName + "\n"
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "material_assigned.assigned", data.MaterialType) + "\n\n" +
+		T(data.Locale, "material_assigned.text.material", data.MaterialName) + "\n"
 	if data.VisibleFrom != "" {
-		textBody += "Available from: " + data.VisibleFrom + "\n"
+		textBody += T(data.Locale, "label.available_from", data.VisibleFrom) + "\n"
 	}
 	if data.VisibleUntil != "" {
-		textBody += "Available until: " + data.VisibleUntil + "\n"
+		textBody += T(data.Locale, "label.available_until", data.VisibleUntil) + "\n"
 	}
 	if data.Directions != "" {
-		textBody += "\nDirections:\n" + data.Directions + "\n"
+		textBody += "\n" + T(data.Locale, "label.directions") + ":\n" + data.Directions + "\n"
 	}
-	textBody += "\nAccess it here:\n" + data.AccessURL
+	textBody += "\n" + T(data.Locale, "text.access_here", data.AccessURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	materialAssignedHTMLTmpl.Execute(&buf, data)
+	materialAssignedHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -332,18 +460,19 @@ func MaterialAssignedEmail(data MaterialAssignedEmailData) (textBody, htmlBody s
 // GroupMembershipEmail generates both plain text and HTML versions of a group membership notification.
 func GroupMembershipEmail(data GroupMembershipEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"You have been added to the group \"" + data.GroupName + "\""
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n"
 	if data.OrgName != "" {
-		textBody += " in " + data.OrgName
+		textBody += T(data.Locale, "group_membership.text.added_org", data.GroupName, data.OrgName)
+	} else {
+		textBody += T(data.Locale, "group_membership.text.added", data.GroupName)
 	}
-	textBody += ".\n\n" +
-		"Your role: " + data.Role + "\n\n" +
-		"View your group:\n" + data.GroupURL
+	textBody += "\n\n" +
+		T(data.Locale, "label.your_role") + " " + data.Role + "\n\n" +
+		T(data.Locale, "group_membership.text.view", data.GroupURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	groupMembershipHTMLTmpl.Execute(&buf, data)
+	groupMembershipHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -352,17 +481,73 @@ func GroupMembershipEmail(data GroupMembershipEmailData) (textBody, htmlBody str
 // AnnouncementDigestEmail generates both plain text and HTML versions of an announcement digest email.
 func AnnouncementDigestEmail(data AnnouncementDigestEmailData) (textBody, htmlBody string) {
 	// Plain text version
-	textBody = "Hello " + data.UserName + ",\n\n" +
-		"Here are the latest announcements from " + data.AppName + ":\n\n"
+	textBody = T(data.Locale, "greeting", data.UserName) + "\n\n" +
+		T(data.Locale, "announcement_digest.text.intro", data.AppName) + "\n\n"
 	for i, a := range data.Announcements {
-		textBody += itoa(i+1) + ". [" + a.Type + "] " + a.Title + "\n"
+		textBody += itoa(i+1) + ". [" + announcementType(data.Locale, a.Type) + "] " + a.Title + "\n"
 		textBody += "   " + a.Content + "\n\n"
 	}
-	textBody += "View all announcements:\n" + data.ViewAllURL
+	textBody += T(data.Locale, "announcement_digest.text.view_all", data.ViewAllURL)
+
+	// HTML version
+	var buf bytes.Buffer
+	announcementDigestHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
+	htmlBody = buf.String()
+
+	return textBody, htmlBody
+}
+
+// APIKeyAlertEmail generates both plain text and HTML versions of an API key lifecycle notification.
+func APIKeyAlertEmail(data APIKeyAlertEmailData) (textBody, htmlBody string) {
+	// Plain text version
+	textBody = data.Message + "\n\n" +
+		T(data.Locale, "label.key") + " " + data.KeyName + " (" + data.KeyPrefix + "...)\n"
+	for _, d := range data.Details {
+		textBody += d + "\n"
+	}
+	textBody += "\n" + T(data.Locale, "api_key_alert.text.view", data.KeyURL)
+
+	// HTML version
+	var buf bytes.Buffer
+	apiKeyAlertHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
+	htmlBody = buf.String()
+
+	return textBody, htmlBody
+}
+
+// SecurityAlertEmail generates both plain text and HTML versions of a suspicious activity alert.
+func SecurityAlertEmail(data SecurityAlertEmailData) (textBody, htmlBody string) {
+	// Plain text version
+	textBody = data.Message + "\n\n"
+	for _, d := range data.Details {
+		textBody += d + "\n"
+	}
+	textBody += "\n" + T(data.Locale, "security_alert.text.view", data.ReviewURL)
+
+	// HTML version
+	var buf bytes.Buffer
+	securityAlertHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
+	htmlBody = buf.String()
+
+	return textBody, htmlBody
+}
+
+// ReportEmail generates both plain text and HTML versions of a scheduled usage report.
+func ReportEmail(data ReportEmailData) (textBody, htmlBody string) {
+	// Plain text version
+	textBody = data.Heading + "\n" + T(data.Locale, "report.period", data.Period) + "\n\n"
+	for _, sec := range data.Sections {
+		textBody += sec.Title + "\n"
+		for _, row := range sec.Rows {
+			textBody += "  " + row.Label + ": " + row.Value + "\n"
+		}
+		textBody += "\n"
+	}
+	textBody += T(data.Locale, "report.text.view", data.DashboardURL)
 
 	// HTML version
 	var buf bytes.Buffer
-	announcementDigestHTMLTmpl.Execute(&buf, data)
+	reportHTMLTmpl.ExecuteTemplate(&buf, "layout", data)
 	htmlBody = buf.String()
 
 	return textBody, htmlBody
@@ -390,15 +575,23 @@ func itoa(i int) string {
 	return string(b[n:])
 }
 
-var htmlTmpl = template.Must(template.New("password_reset").Funcs(template.FuncMap{
-	"safe": func(s string) template.HTML { return template.HTML(s) },
-	"esc":  html.EscapeString,
-}).Parse(`<!DOCTYPE html>
-<html>
+// layoutTmpl is the HTML shell shared by every email. Each email template
+// is a clone of it that defines three blocks:
+//
+//	title    the document title
+//	content  the message body
+//	footer   the small print at the bottom of the card
+//
+// Brand settings are applied here, so rebranding never touches the
+// individual emails: the logo replaces the app name in the header, the
+// primary color is used for buttons and links, and the footer text is
+// appended below each email's own footer.
+var layoutTmpl = template.Must(template.New("layout").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="{{lang .Locale}}">
 <head>
   <meta charset="UTF-8">
   <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Password Reset</title>
+  <title>{{template "title" .}}</title>
 </head>
 <body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
   <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
@@ -408,74 +601,76 @@ var htmlTmpl = template.Must(template.New("password_reset").Funcs(template.FuncM
           <!-- Header -->
           <tr>
             <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
+              {{if .Brand.LogoURL}}
+              <img src="{{.Brand.LogoURL}}" alt="{{.AppName}}" style="max-height: 48px; max-width: 240px; border: 0;">
+              {{else}}
               <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
+              {{end}}
             </td>
           </tr>
           <!-- Content -->
           <tr>
             <td style="padding: 32px;">
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">Reset Your Password</h2>
+{{template "content" .}}
+            </td>
+          </tr>
+          <!-- Footer -->
+          <tr>
+            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{template "footer" .}}
+              {{with .Brand.FooterText}}
+              <p style="margin: 12px 0 0 0; font-size: 12px; color: #a1a1aa; text-align: center;">{{.}}</p>
+              {{end}}
+            </td>
+          </tr>
+        </table>
+      </td>
+    </tr>
+  </table>
+</body>
+</html>`))
+
+// newEmailTemplate clones the layout and adds an email's title, content,
+// and footer blocks. Execute the result with ExecuteTemplate(w, "layout", data).
+func newEmailTemplate(name, blocks string) *template.Template {
+	return template.Must(template.Must(layoutTmpl.Clone()).New(name).Parse(blocks))
+}
+
+var passwordResetHTMLTmpl = newEmailTemplate("password_reset", `{{define "title"}}{{t .Locale "password_reset.title"}}{{end}}
+{{define "content"}}
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">{{t .Locale "password_reset.heading"}}</h2>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                You requested a password reset for your account. Click the button below to create a new password.
+                {{if .ByAdmin}}{{t .Locale "password_reset.intro_admin"}}{{else}}{{t .Locale "password_reset.intro"}}{{end}}
               </p>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 8px 0 24px 0;">
-                    <a href="{{.ResetURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Reset Password</a>
+                    <a href="{{.ResetURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "password_reset.button"}}</a>
                   </td>
                 </tr>
               </table>
               <p style="margin: 0 0 16px 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                This link will expire in <strong>{{.ExpiryMin}} minutes</strong>.
+                {{th .Locale "password_reset.expiry_html" .ExpiryMin}}
               </p>
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                If you didn't request this password reset, you can safely ignore this email. Your password will remain unchanged.
+                {{if .ByAdmin}}{{t .Locale "password_reset.ignore_admin"}}{{else}}{{t .Locale "password_reset.ignore"}}{{end}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0 0 8px 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                If the button doesn't work, copy and paste this link into your browser:
+                {{t .Locale "footer.link_fallback"}}
               </p>
-              <p style="margin: 0; font-size: 12px; color: #4f46e5; text-align: center; word-break: break-all;">
+              <p style="margin: 0; font-size: 12px; color: {{.Brand.Color}}; text-align: center; word-break: break-all;">
                 {{.ResetURL}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var loginCodeHTMLTmpl = template.Must(template.New("login_code").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Login Code</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">Your Login Code</h2>
+var loginCodeHTMLTmpl = newEmailTemplate("login_code", `{{define "title"}}{{t .Locale "login_code.title"}}{{end}}
+{{define "content"}}
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">{{t .Locale "login_code.heading"}}</h2>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Enter this code to log in to your account:
+                {{t .Locale "login_code.enter_code"}}
               </p>
               <!-- Code Box -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
@@ -485,61 +680,36 @@ var loginCodeHTMLTmpl = template.Must(template.New("login_code").Parse(`<!DOCTYP
                   </td>
                 </tr>
               </table>
+{{- if .MagicURL}}
               <p style="margin: 0 0 24px 0; font-size: 14px; line-height: 1.6; color: #71717a; text-align: center;">
-                Or click the button below to log in automatically:
+                {{t .Locale "login_code.or_click"}}
               </p>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.MagicURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Log In</a>
+                    <a href="{{.MagicURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "button.log_in"}}</a>
                   </td>
                 </tr>
               </table>
+{{- end}}
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                This code will expire in <strong>10 minutes</strong>. If you didn't request this, you can safely ignore this email.
+                {{th .Locale "login_code.expiry_html"}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
+{{- if .MagicURL}}
               <p style="margin: 0 0 8px 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                If the button doesn't work, copy and paste this link into your browser:
+                {{t .Locale "footer.link_fallback"}}
               </p>
-              <p style="margin: 0; font-size: 12px; color: #4f46e5; text-align: center; word-break: break-all;">
+              <p style="margin: 0; font-size: 12px; color: {{.Brand.Color}}; text-align: center; word-break: break-all;">
                 {{.MagicURL}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{- end}}
+{{end}}`)
 
-var passwordChangedHTMLTmpl = template.Must(template.New("password_changed").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Password Changed</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var passwordChangedHTMLTmpl = newEmailTemplate("password_changed", `{{define "title"}}{{t .Locale "password_changed.title"}}{{end}}
+{{define "content"}}
               <!-- Warning Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -548,64 +718,70 @@ var passwordChangedHTMLTmpl = template.Must(template.New("password_changed").Par
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Password Changed</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "password_changed.title"}}</h2>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Your {{.AppName}} password has been successfully changed.
+                {{t .Locale "password_changed.changed" .AppName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                <strong>If you made this change</strong>, you can safely ignore this email.
+                {{th .Locale "password_changed.if_you_html"}}
               </p>
               <div style="padding: 16px; background-color: #fef2f2; border-radius: 6px; border-left: 4px solid #ef4444; margin-bottom: 24px;">
                 <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #991b1b;">
-                  <strong>If you did NOT make this change</strong>, your account may have been compromised. Please reset your password immediately and review your recent account activity.
+                  {{th .Locale "password_changed.if_not_html"}}
                 </p>
               </div>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Go to Login</a>
+                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "password_changed.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated security notification. Please do not reply to this email.
+                {{t .Locale "footer.security_notice"}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var welcomeHTMLTmpl = template.Must(template.New("welcome").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Welcome</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var passwordExpiringHTMLTmpl = newEmailTemplate("password_expiring", `{{define "title"}}{{t .Locale "password_expiring.title"}}{{end}}
+{{define "content"}}
+              <!-- Clock Icon -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 0 0 16px 0;">
+                    <div style="display: inline-block; width: 48px; height: 48px; background-color: #fef3c7; border-radius: 50%; text-align: center; line-height: 48px; font-size: 24px;">&#9200;</div>
+                  </td>
+                </tr>
+              </table>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "password_expiring.title"}}</h2>
+              <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{t .Locale "greeting" .UserName}}
+              </p>
+              <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{th .Locale "password_expiring.expires_html" .AppName .ExpiresOn}}
+              </p>
+              <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{t .Locale "password_expiring.change"}}
+              </p>
+              <!-- Button -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 0 0 24px 0;">
+                    <a href="{{.ChangeURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "password_expiring.button"}}</a>
+                  </td>
+                </tr>
+              </table>
+{{end}}
+{{define "footer"}}
+              <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
+                {{t .Locale "footer.security_notice"}}
+              </p>
+{{end}}`)
+
+var welcomeHTMLTmpl = newEmailTemplate("welcome", `{{define "title"}}{{t .Locale "welcome.title"}}{{end}}
+{{define "content"}}
               <!-- Welcome Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -614,67 +790,38 @@ var welcomeHTMLTmpl = template.Must(template.New("welcome").Parse(`<!DOCTYPE htm
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Welcome, {{.UserName}}!</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "welcome.heading" .UserName}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Your account has been created{{if .OrgName}} for <strong>{{.OrgName}}</strong>{{end}}.
+                {{if .OrgName}}{{th .Locale "welcome.created_org_html" .OrgName}}{{else}}{{t .Locale "welcome.created"}}{{end}}
               </p>
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0; font-size: 14px; color: #52525b;">
-                  <strong>Your role:</strong> {{.Role}}
+                  <strong>{{t .Locale "label.your_role"}}</strong> {{.Role}}
                 </p>
               </div>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Click the button below to log in and get started.
+                {{t .Locale "welcome.get_started"}}
               </p>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Log In</a>
+                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "button.log_in"}}</a>
                   </td>
                 </tr>
               </table>
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                If you have any questions, please contact your administrator.
+                {{t .Locale "contact_admin"}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated message from {{.AppName}}.
+                {{t .Locale "footer.automated_message" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var invitationHTMLTmpl = template.Must(template.New("invitation").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Invitation</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var invitationHTMLTmpl = newEmailTemplate("invitation", `{{define "title"}}{{t .Locale "invitation.title"}}{{end}}
+{{define "content"}}
               <!-- Invitation Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -683,73 +830,50 @@ var invitationHTMLTmpl = template.Must(template.New("invitation").Parse(`<!DOCTY
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">You're Invited!</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "invitation.heading"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.RecipientName}},
+                {{t .Locale "greeting" .RecipientName}}
               </p>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                <strong>{{.InviterName}}</strong> has invited you to join {{.AppName}}{{if .OrgName}} as part of <strong>{{.OrgName}}</strong>{{end}}.
+                {{if .OrgName}}{{th .Locale "invitation.invited_org_html" .InviterName .AppName .OrgName}}{{else}}{{th .Locale "invitation.invited_html" .InviterName .AppName}}{{end}}
               </p>
+              {{if .Message}}
+              <div style="padding: 16px; background-color: #fffbeb; border-radius: 6px; border-left: 4px solid #f59e0b; margin-bottom: 16px;">
+                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; color: #92400e; text-transform: uppercase;">{{t .Locale "invitation.message" .InviterName}}</p>
+                <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #78350f; white-space: pre-line;">{{.Message}}</p>
+              </div>
+              {{end}}
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0; font-size: 14px; color: #52525b;">
-                  <strong>Your role:</strong> {{.Role}}
+                  <strong>{{t .Locale "label.your_role"}}</strong> {{.Role}}
                 </p>
               </div>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 16px 0;">
-                    <a href="{{.AcceptURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Accept Invitation</a>
+                    <a href="{{.AcceptURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "invitation.button"}}</a>
                   </td>
                 </tr>
               </table>
               <p style="margin: 0 0 16px 0; font-size: 14px; line-height: 1.6; color: #71717a; text-align: center;">
-                This invitation will expire in <strong>{{.ExpiresIn}}</strong>.
+                {{th .Locale "invitation.expires_html" .ExpiresIn}}
               </p>
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                If you did not expect this invitation, you can safely ignore this email.
+                {{t .Locale "invitation.ignore"}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0 0 8px 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                If the button doesn't work, copy and paste this link into your browser:
+                {{t .Locale "footer.link_fallback"}}
               </p>
-              <p style="margin: 0; font-size: 12px; color: #4f46e5; text-align: center; word-break: break-all;">
+              <p style="margin: 0; font-size: 12px; color: {{.Brand.Color}}; text-align: center; word-break: break-all;">
                 {{.AcceptURL}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var accountDisabledHTMLTmpl = template.Must(template.New("account_disabled").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Account Disabled</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var accountDisabledHTMLTmpl = newEmailTemplate("account_disabled", `{{define "title"}}{{t .Locale "account_disabled.title"}}{{end}}
+{{define "content"}}
               <!-- Disabled Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -758,61 +882,59 @@ var accountDisabledHTMLTmpl = template.Must(template.New("account_disabled").Par
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Account Disabled</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "account_disabled.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Your {{.AppName}} account has been disabled by an administrator.
+                {{t .Locale "account_disabled.by_admin" .AppName}}
               </p>
               {{if .Reason}}
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0; font-size: 14px; color: #52525b;">
-                  <strong>Reason:</strong> {{.Reason}}
+                  <strong>{{t .Locale "label.reason"}}</strong> {{.Reason}}
                 </p>
               </div>
               {{end}}
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                If you believe this was done in error, please contact your administrator{{if .ContactEmail}} at <a href="mailto:{{.ContactEmail}}" style="color: #4f46e5;">{{.ContactEmail}}</a>{{end}}.
+                {{if .ContactEmail}}{{th .Locale "account_disabled.contact_email_html" .ContactEmail .Brand.Color}}{{else}}{{t .Locale "account_disabled.contact"}}{{end}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var accountEnabledHTMLTmpl = template.Must(template.New("account_enabled").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Account Enabled</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var accountDisablingHTMLTmpl = newEmailTemplate("account_disabling", `{{define "title"}}{{t .Locale "account_disabling.title"}}{{end}}
+{{define "content"}}
+              <!-- Clock Icon -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 0 0 16px 0;">
+                    <div style="display: inline-block; width: 48px; height: 48px; background-color: #fef3c7; border-radius: 50%; text-align: center; line-height: 48px; font-size: 24px;">&#9200;</div>
+                  </td>
+                </tr>
+              </table>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "account_disabling.title"}}</h2>
+              <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{t .Locale "greeting" .UserName}}
+              </p>
+              <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{th .Locale "account_disabling.date_html" .AppName .DisableOn}}
+              </p>
+              <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
+                {{t .Locale "account_disabling.contact"}}
+              </p>
+{{end}}
+{{define "footer"}}
+              <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
+                {{t .Locale "footer.automated_notification" .AppName}}
+              </p>
+{{end}}`)
+
+var accountEnabledHTMLTmpl = newEmailTemplate("account_enabled", `{{define "title"}}{{t .Locale "account_enabled.title"}}{{end}}
+{{define "content"}}
               <!-- Enabled Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -821,62 +943,33 @@ var accountEnabledHTMLTmpl = template.Must(template.New("account_enabled").Parse
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Account Enabled</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "account_enabled.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Great news! Your {{.AppName}} account has been enabled. You can now log in and access your account.
+                {{t .Locale "account_enabled.great_news" .AppName}}
               </p>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Log In</a>
+                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "button.log_in"}}</a>
                   </td>
                 </tr>
               </table>
               <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #71717a;">
-                If you have any questions, please contact your administrator.
+                {{t .Locale "contact_admin"}}
               </p>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var newLoginHTMLTmpl = template.Must(template.New("new_login").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>New Login Detected</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var newLoginHTMLTmpl = newEmailTemplate("new_login", `{{define "title"}}{{t .Locale "new_login.title"}}{{end}}
+{{define "content"}}
               <!-- Security Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -885,89 +978,60 @@ var newLoginHTMLTmpl = template.Must(template.New("new_login").Parse(`<!DOCTYPE
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">New Login Detected</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "new_login.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                A new login to your {{.AppName}} account was detected.
+                {{t .Locale "new_login.detected" .AppName}}
               </p>
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                   <tr>
-                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>Device:</strong></td>
+                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>{{t .Locale "label.device"}}</strong></td>
                     <td style="padding: 4px 0; font-size: 14px; color: #52525b; text-align: right;">{{.Device}}</td>
                   </tr>
                   <tr>
-                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>IP Address:</strong></td>
+                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>{{t .Locale "label.ip_address"}}</strong></td>
                     <td style="padding: 4px 0; font-size: 14px; color: #52525b; text-align: right;">{{.IPAddress}}</td>
                   </tr>
                   {{if .Location}}
                   <tr>
-                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>Location:</strong></td>
+                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>{{t .Locale "label.location"}}</strong></td>
                     <td style="padding: 4px 0; font-size: 14px; color: #52525b; text-align: right;">{{.Location}}</td>
                   </tr>
                   {{end}}
                   <tr>
-                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>Time:</strong></td>
+                    <td style="padding: 4px 0; font-size: 14px; color: #52525b;"><strong>{{t .Locale "label.time"}}</strong></td>
                     <td style="padding: 4px 0; font-size: 14px; color: #52525b; text-align: right;">{{.LoginTime}}</td>
                   </tr>
                 </table>
               </div>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                <strong>If this was you</strong>, you can safely ignore this email.
+                {{th .Locale "new_login.if_you_html"}}
               </p>
               <div style="padding: 16px; background-color: #fef2f2; border-radius: 6px; border-left: 4px solid #ef4444; margin-bottom: 24px;">
                 <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #991b1b;">
-                  <strong>If this was NOT you</strong>, please secure your account immediately by changing your password and reviewing your recent activity.
+                  {{th .Locale "new_login.if_not_html"}}
                 </p>
               </div>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Review Account</a>
+                    <a href="{{.LoginURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "new_login.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated security notification. Please do not reply to this email.
+                {{t .Locale "footer.security_notice"}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var resourceAssignedHTMLTmpl = template.Must(template.New("resource_assigned").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>New Resource Available</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var resourceAssignedHTMLTmpl = newEmailTemplate("resource_assigned", `{{define "title"}}{{t .Locale "resource_assigned.title"}}{{end}}
+{{define "content"}}
               <!-- Resource Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -976,26 +1040,26 @@ var resourceAssignedHTMLTmpl = template.Must(template.New("resource_assigned").P
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">New Resource Available</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "resource_assigned.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                A new {{.ResourceType}} has been assigned to your group <strong>{{.GroupName}}</strong>.
+                {{th .Locale "resource_assigned.assigned_html" .ResourceType .GroupName}}
               </p>
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0 0 8px 0; font-size: 16px; font-weight: 600; color: #18181b;">{{.ResourceName}}</p>
                 {{if or .VisibleFrom .VisibleUntil}}
                 <p style="margin: 0; font-size: 14px; color: #71717a;">
-                  {{if .VisibleFrom}}Available from: {{.VisibleFrom}}{{end}}
+                  {{if .VisibleFrom}}{{t .Locale "label.available_from" .VisibleFrom}}{{end}}
                   {{if and .VisibleFrom .VisibleUntil}} · {{end}}
-                  {{if .VisibleUntil}}Until: {{.VisibleUntil}}{{end}}
+                  {{if .VisibleUntil}}{{t .Locale "label.until" .VisibleUntil}}{{end}}
                 </p>
                 {{end}}
               </div>
               {{if .Instructions}}
               <div style="padding: 16px; background-color: #fffbeb; border-radius: 6px; border-left: 4px solid #f59e0b; margin-bottom: 24px;">
-                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; color: #92400e; text-transform: uppercase;">Instructions</p>
+                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; color: #92400e; text-transform: uppercase;">{{t .Locale "label.instructions"}}</p>
                 <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #78350f;">{{.Instructions}}</p>
               </div>
               {{end}}
@@ -1003,48 +1067,19 @@ var resourceAssignedHTMLTmpl = template.Must(template.New("resource_assigned").P
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.LaunchURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Launch Resource</a>
+                    <a href="{{.LaunchURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "resource_assigned.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var materialAssignedHTMLTmpl = template.Must(template.New("material_assigned").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>New Material Available</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var materialAssignedHTMLTmpl = newEmailTemplate("material_assigned", `{{define "title"}}{{t .Locale "material_assigned.title"}}{{end}}
+{{define "content"}}
               <!-- Material Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -1053,26 +1088,26 @@ var materialAssignedHTMLTmpl = template.Must(template.New("material_assigned").P
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">New Material Available</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "material_assigned.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                A new {{.MaterialType}} has been assigned to you.
+                {{t .Locale "material_assigned.assigned" .MaterialType}}
               </p>
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0 0 8px 0; font-size: 16px; font-weight: 600; color: #18181b;">{{.MaterialName}}</p>
                 {{if or .VisibleFrom .VisibleUntil}}
                 <p style="margin: 0; font-size: 14px; color: #71717a;">
-                  {{if .VisibleFrom}}Available from: {{.VisibleFrom}}{{end}}
+                  {{if .VisibleFrom}}{{t .Locale "label.available_from" .VisibleFrom}}{{end}}
                   {{if and .VisibleFrom .VisibleUntil}} · {{end}}
-                  {{if .VisibleUntil}}Until: {{.VisibleUntil}}{{end}}
+                  {{if .VisibleUntil}}{{t .Locale "label.until" .VisibleUntil}}{{end}}
                 </p>
                 {{end}}
               </div>
               {{if .Directions}}
               <div style="padding: 16px; background-color: #fffbeb; border-radius: 6px; border-left: 4px solid #f59e0b; margin-bottom: 24px;">
-                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; color: #92400e; text-transform: uppercase;">Directions</p>
+                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; color: #92400e; text-transform: uppercase;">{{t .Locale "label.directions"}}</p>
                 <p style="margin: 0; font-size: 14px; line-height: 1.6; color: #78350f;">{{.Directions}}</p>
               </div>
               {{end}}
@@ -1080,48 +1115,19 @@ var materialAssignedHTMLTmpl = template.Must(template.New("material_assigned").P
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.AccessURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">Access Material</a>
+                    <a href="{{.AccessURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "material_assigned.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var groupMembershipHTMLTmpl = template.Must(template.New("group_membership").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Added to Group</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var groupMembershipHTMLTmpl = newEmailTemplate("group_membership", `{{define "title"}}{{t .Locale "group_membership.title"}}{{end}}
+{{define "content"}}
               <!-- Group Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -1130,65 +1136,36 @@ var groupMembershipHTMLTmpl = template.Must(template.New("group_membership").Par
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Added to Group</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "group_membership.title"}}</h2>
               <p style="margin: 0 0 16px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}},
+                {{t .Locale "greeting" .UserName}}
               </p>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                You have been added to a group{{if .OrgName}} in <strong>{{.OrgName}}</strong>{{end}}.
+                {{if .OrgName}}{{th .Locale "group_membership.added_org_html" .OrgName}}{{else}}{{t .Locale "group_membership.added"}}{{end}}
               </p>
               <div style="padding: 16px; background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
                 <p style="margin: 0 0 8px 0; font-size: 16px; font-weight: 600; color: #18181b;">{{.GroupName}}</p>
                 <p style="margin: 0; font-size: 14px; color: #71717a;">
-                  Your role: <strong>{{.Role}}</strong>
+                  {{th .Locale "group_membership.role_html" .Role}}
                 </p>
               </div>
               <!-- Button -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 0 0 24px 0;">
-                    <a href="{{.GroupURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">View Group</a>
+                    <a href="{{.GroupURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "group_membership.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
 
-var announcementDigestHTMLTmpl = template.Must(template.New("announcement_digest").Parse(`<!DOCTYPE html>
-<html>
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Announcements</title>
-</head>
-<body style="margin: 0; padding: 0; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif; background-color: #f4f4f5;">
-  <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5;">
-    <tr>
-      <td align="center" style="padding: 40px 20px;">
-        <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="max-width: 480px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,0.1);">
-          <!-- Header -->
-          <tr>
-            <td style="padding: 32px 32px 24px 32px; text-align: center; border-bottom: 1px solid #e4e4e7;">
-              <h1 style="margin: 0; font-size: 24px; font-weight: 600; color: #18181b;">{{.AppName}}</h1>
-            </td>
-          </tr>
-          <!-- Content -->
-          <tr>
-            <td style="padding: 32px;">
+var announcementDigestHTMLTmpl = newEmailTemplate("announcement_digest", `{{define "title"}}{{t .Locale "announcement_digest.title"}}{{end}}
+{{define "content"}}
               <!-- Announcement Icon -->
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
@@ -1197,13 +1174,13 @@ var announcementDigestHTMLTmpl = template.Must(template.New("announcement_digest
                   </td>
                 </tr>
               </table>
-              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">Latest Announcements</h2>
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b; text-align: center;">{{t .Locale "announcement_digest.heading"}}</h2>
               <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
-                Hello {{.UserName}}, here are the latest announcements:
+                {{t .Locale "announcement_digest.intro" .UserName}}
               </p>
               {{range .Announcements}}
               <div style="padding: 16px; margin-bottom: 16px; border-radius: 6px; {{if eq .Type "critical"}}background-color: #fef2f2; border-left: 4px solid #ef4444;{{else if eq .Type "warning"}}background-color: #fffbeb; border-left: 4px solid #f59e0b;{{else}}background-color: #f0f9ff; border-left: 4px solid #3b82f6;{{end}}">
-                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; text-transform: uppercase; {{if eq .Type "critical"}}color: #991b1b;{{else if eq .Type "warning"}}color: #92400e;{{else}}color: #1e40af;{{end}}">{{.Type}}</p>
+                <p style="margin: 0 0 4px 0; font-size: 12px; font-weight: 600; text-transform: uppercase; {{if eq .Type "critical"}}color: #991b1b;{{else if eq .Type "warning"}}color: #92400e;{{else}}color: #1e40af;{{end}}">{{announcementType $.Locale .Type}}</p>
                 <p style="margin: 0 0 8px 0; font-size: 15px; font-weight: 600; color: #18181b;">{{.Title}}</p>
                 <p style="margin: 0; font-size: 14px; line-height: 1.5; color: #52525b;">{{.Content}}</p>
               </div>
@@ -1212,23 +1189,109 @@ var announcementDigestHTMLTmpl = template.Must(template.New("announcement_digest
               <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
                 <tr>
                   <td align="center" style="padding: 8px 0 24px 0;">
-                    <a href="{{.ViewAllURL}}" style="display: inline-block; padding: 14px 32px; background-color: #4f46e5; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">View All Announcements</a>
+                    <a href="{{.ViewAllURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "announcement_digest.button"}}</a>
                   </td>
                 </tr>
               </table>
-            </td>
-          </tr>
-          <!-- Footer -->
-          <tr>
-            <td style="padding: 24px 32px; background-color: #fafafa; border-top: 1px solid #e4e4e7; border-radius: 0 0 8px 8px;">
+{{end}}
+{{define "footer"}}
               <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
-                This is an automated notification from {{.AppName}}.
+                {{t .Locale "footer.automated_notification" .AppName}}
               </p>
-            </td>
-          </tr>
-        </table>
-      </td>
-    </tr>
-  </table>
-</body>
-</html>`))
+{{end}}`)
+
+var apiKeyAlertHTMLTmpl = newEmailTemplate("api_key_alert", `{{define "title"}}{{.Heading}}{{end}}
+{{define "content"}}
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">{{.Heading}}</h2>
+              <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{.Message}}
+              </p>
+              <!-- Key Details -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
+                <tr>
+                  <td style="padding: 16px;">
+                    <p style="margin: 0 0 8px 0; font-size: 14px; color: #52525b;"><strong>{{t .Locale "label.key"}}</strong> {{.KeyName}} <code style="font-family: monospace;">{{.KeyPrefix}}...</code></p>
+                    {{range .Details}}
+                    <p style="margin: 0 0 8px 0; font-size: 14px; color: #52525b;">{{.}}</p>
+                    {{end}}
+                  </td>
+                </tr>
+              </table>
+              <!-- Button -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 8px 0 24px 0;">
+                    <a href="{{.KeyURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "api_key_alert.button"}}</a>
+                  </td>
+                </tr>
+              </table>
+{{end}}
+{{define "footer"}}
+              <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
+                {{t .Locale "footer.automated_notification" .AppName}}
+              </p>
+{{end}}`)
+
+var securityAlertHTMLTmpl = newEmailTemplate("security_alert", `{{define "title"}}{{.Heading}}{{end}}
+{{define "content"}}
+              <h2 style="margin: 0 0 16px 0; font-size: 20px; font-weight: 600; color: #18181b;">{{.Heading}}</h2>
+              <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{.Message}}
+              </p>
+              <!-- Alert Details -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
+                <tr>
+                  <td style="padding: 16px;">
+                    {{range .Details}}
+                    <p style="margin: 0 0 8px 0; font-size: 14px; color: #52525b;">{{.}}</p>
+                    {{end}}
+                  </td>
+                </tr>
+              </table>
+              <!-- Button -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 8px 0 24px 0;">
+                    <a href="{{.ReviewURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "security_alert.button"}}</a>
+                  </td>
+                </tr>
+              </table>
+{{end}}
+{{define "footer"}}
+              <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
+                {{t .Locale "footer.automated_notification" .AppName}}
+              </p>
+{{end}}`)
+
+var reportHTMLTmpl = newEmailTemplate("report", `{{define "title"}}{{.Heading}}{{end}}
+{{define "content"}}
+              <h2 style="margin: 0 0 8px 0; font-size: 20px; font-weight: 600; color: #18181b;">{{.Heading}}</h2>
+              <p style="margin: 0 0 24px 0; font-size: 15px; line-height: 1.6; color: #52525b;">
+                {{t .Locale "report.period" .Period}}
+              </p>
+              {{range .Sections}}
+              <!-- {{.Title}} -->
+              <p style="margin: 0 0 8px 0; font-size: 13px; font-weight: 600; text-transform: uppercase; color: #71717a;">{{.Title}}</p>
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0" style="background-color: #f4f4f5; border-radius: 6px; margin-bottom: 24px;">
+                {{range .Rows}}
+                <tr>
+                  <td style="padding: 8px 16px; font-size: 14px; color: #52525b;">{{.Label}}</td>
+                  <td style="padding: 8px 16px; font-size: 14px; font-weight: 600; color: #18181b; text-align: right;">{{.Value}}</td>
+                </tr>
+                {{end}}
+              </table>
+              {{end}}
+              <!-- Button -->
+              <table role="presentation" width="100%" cellspacing="0" cellpadding="0">
+                <tr>
+                  <td align="center" style="padding: 8px 0 24px 0;">
+                    <a href="{{.DashboardURL}}" style="display: inline-block; padding: 14px 32px; background-color: {{.Brand.Color}}; color: #ffffff; text-decoration: none; font-size: 15px; font-weight: 600; border-radius: 6px;">{{t .Locale "report.button"}}</a>
+                  </td>
+                </tr>
+              </table>
+{{end}}
+{{define "footer"}}
+              <p style="margin: 0; font-size: 12px; color: #a1a1aa; text-align: center;">
+                {{t .Locale "footer.automated_notification" .AppName}}
+              </p>
+{{end}}`)
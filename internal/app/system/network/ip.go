@@ -2,6 +2,8 @@
 package network
 
 import (
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 )
@@ -30,3 +32,105 @@ func GetClientIP(r *http.Request) string {
 	}
 	return r.RemoteAddr
 }
+
+// RemoteIP returns the IP address of the connection the request arrived
+// on, without its port. Unlike GetClientIP it ignores forwarding headers,
+// which any client can set.
+func RemoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// TrustedClientIP returns the client IP address for access decisions such
+// as allowlists. Forwarding headers are honored only when the connection
+// comes from one of trustedProxies (CIDR ranges, as returned by
+// ParseCIDRList). X-Forwarded-For is then read from the right, skipping
+// trusted proxies, so a client can't pick its address by sending the header
+// itself; X-Real-IP is used when there is no X-Forwarded-For.
+func TrustedClientIP(r *http.Request, trustedProxies []string) string {
+	remote := RemoteIP(r)
+	if !IPInCIDRs(remote, trustedProxies) {
+		return remote
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if !IPInCIDRs(hop, trustedProxies) {
+				return hop
+			}
+		}
+		return remote
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		return xri
+	}
+	return remote
+}
+
+// ParseCIDRList parses a list of CIDR ranges or bare IP addresses into
+// normalized CIDR strings. Bare IPv4 addresses become /32 ranges and bare IPv6
+// addresses become /128 ranges. Blank entries are skipped and duplicates are
+// removed. An error is returned for the first entry that cannot be parsed.
+func ParseCIDRList(entries []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var cidr string
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", entry)
+			}
+			cidr = ipNet.String()
+		} else {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", entry)
+			}
+			if ip.To4() != nil {
+				cidr = ip.String() + "/32"
+			} else {
+				cidr = ip.String() + "/128"
+			}
+		}
+
+		if !seen[cidr] {
+			seen[cidr] = true
+			out = append(out, cidr)
+		}
+	}
+	return out, nil
+}
+
+// IPInCIDRs reports whether ip falls within any of the given CIDR ranges.
+// The ip may include IPv6 brackets (as returned by GetClientIP for IPv6
+// RemoteAddr values). Unparseable IPs and CIDRs never match.
+func IPInCIDRs(ip string, cidrs []string) bool {
+	parsed := net.ParseIP(strings.Trim(strings.TrimSpace(ip), "[]"))
+	if parsed == nil {
+		return false
+	}
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
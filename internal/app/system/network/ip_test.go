@@ -107,3 +107,100 @@ func TestGetClientIP_ProxyChain(t *testing.T) {
 		t.Errorf("GetClientIP() = %q, want %q", ip, "203.0.113.195")
 	}
 }
+
+func TestParseCIDRList(t *testing.T) {
+	got, err := ParseCIDRList([]string{
+		"10.0.0.0/8",
+		" 203.0.113.7 ",
+		"",
+		"2001:db8::/32",
+		"::1",
+		"10.1.2.3/8", // normalizes to 10.0.0.0/8 (duplicate)
+	})
+	if err != nil {
+		t.Fatalf("ParseCIDRList() error = %v", err)
+	}
+
+	want := []string{"10.0.0.0/8", "203.0.113.7/32", "2001:db8::/32", "::1/128"}
+	if len(got) != len(want) {
+		t.Fatalf("ParseCIDRList() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseCIDRList()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCIDRList_Invalid(t *testing.T) {
+	for _, entry := range []string{"not-an-ip", "10.0.0.0/33", "300.1.1.1"} {
+		if _, err := ParseCIDRList([]string{entry}); err == nil {
+			t.Errorf("ParseCIDRList(%q) expected error, got nil", entry)
+		}
+	}
+}
+
+func TestIPInCIDRs(t *testing.T) {
+	cidrs := []string{"10.0.0.0/8", "203.0.113.7/32", "::1/128"}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.20.30.40", true},
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"[::1]", true},
+		{"::1", true},
+		{"192.168.1.1", false},
+		{"garbage", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IPInCIDRs(tt.ip, cidrs); got != tt.want {
+			t.Errorf("IPInCIDRs(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if IPInCIDRs("10.0.0.1", nil) {
+		t.Error("IPInCIDRs() with no ranges should not match")
+	}
+}
+
+func TestTrustedClientIP(t *testing.T) {
+	proxies := []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		xForwardedFor string
+		xRealIP       string
+		want          string
+	}{
+		{"untrusted connection ignores X-Forwarded-For", "198.51.100.9:4000", "203.0.113.7", "", "198.51.100.9"},
+		{"untrusted connection ignores X-Real-IP", "198.51.100.9:4000", "", "203.0.113.7", "198.51.100.9"},
+		{"trusted proxy", "10.0.0.1:4000", "203.0.113.7", "", "203.0.113.7"},
+		{"spoofed first hop is skipped", "10.0.0.1:4000", "203.0.113.7, 198.51.100.9", "", "198.51.100.9"},
+		{"trusted hops are skipped", "10.0.0.1:4000", "198.51.100.9, 10.0.0.2", "", "198.51.100.9"},
+		{"trusted proxy with X-Real-IP", "10.0.0.1:4000", "", "203.0.113.7", "203.0.113.7"},
+		{"trusted proxy without headers", "10.0.0.1:4000", "", "", "10.0.0.1"},
+		{"IPv6 connection", "[2001:db8::1]:4000", "203.0.113.7", "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+			if got := TrustedClientIP(req, proxies); got != tt.want {
+				t.Errorf("TrustedClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Package newdevice remembers the devices each user logs in from and sends
+// a security alert when a login comes from one the user hasn't used before.
+//
+// A device is a user agent on a network (see devicestore.Fingerprint). The
+// first device recorded for a user never triggers an alert, so existing
+// accounts don't all receive one on their next login.
+package newdevice
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	devicestore "github.com/dalemusser/stratasave/internal/app/store/devices"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/app/system/useragent"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Notifier checks logins against a user's known devices. A nil Notifier is
+// valid and does nothing.
+type Notifier struct {
+	devices  *devicestore.Store
+	settings *settingsstore.Store
+	users    *userstore.Store
+	mailer   *mailer.Mailer
+	audit    *auditlog.Logger
+	baseURL  string
+	log      *zap.Logger
+}
+
+// New creates a Notifier. mail may be nil, in which case devices are still
+// recorded and new ones audited, but no email is sent.
+func New(db *mongo.Database, mail *mailer.Mailer, audit *auditlog.Logger, baseURL string, logger *zap.Logger) *Notifier {
+	return &Notifier{
+		devices:  devicestore.New(db),
+		settings: settingsstore.New(db),
+		users:    userstore.New(db),
+		mailer:   mail,
+		audit:    audit,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		log:      logger,
+	}
+}
+
+// Check records the device behind r for userID, who has just logged in. If
+// the device is new to the user, the login is audited and, when the
+// notify_user_on_new_device setting is on, the user is emailed. Failures are
+// logged; they never block the login.
+func (n *Notifier) Check(r *http.Request, userID primitive.ObjectID) {
+	if n == nil {
+		return
+	}
+	ctx := r.Context()
+	ip := network.GetClientIP(r)
+
+	isNew, first, err := n.devices.Touch(ctx, userID, r.UserAgent(), ip)
+	if err != nil {
+		n.log.Warn("failed to record login device", zap.String("user_id", userID.Hex()), zap.Error(err))
+		return
+	}
+	if !isNew || first {
+		return
+	}
+
+	n.audit.LogAuthEvent(r, &userID, "new_device_login", true, "")
+
+	if n.mailer == nil {
+		return
+	}
+	settings, _ := n.settings.Get(ctx)
+	if settings == nil || !settings.NotifyUserOnNewDevice {
+		return
+	}
+	user, err := n.users.GetByID(ctx, userID)
+	if err != nil || user.Email == nil || *user.Email == "" {
+		return
+	}
+
+	siteName := settings.SiteName
+	if siteName == "" {
+		siteName = "Strata"
+	}
+	data := mailer.NewLoginEmailData{
+		Locale:    user.Locale,
+		Brand:     n.mailer.Brand(ctx),
+		AppName:   siteName,
+		UserName:  user.FullName,
+		Device:    useragent.Parse(r.UserAgent()).String(),
+		IPAddress: ip,
+		LoginTime: time.Now().UTC().Format("January 2, 2006 at 3:04 PM UTC"),
+		LoginURL:  n.baseURL + "/profile",
+	}
+	email := mailer.Email{
+		To:       *user.Email,
+		Subject:  mailer.T(user.Locale, "new_login.title"),
+		Template: "new_login",
+		UserID:   userID.Hex(),
+	}
+	go func() {
+		email.TextBody, email.HTMLBody = mailer.NewLoginEmail(data)
+		if err := n.mailer.Send(email); err != nil {
+			n.log.Warn("failed to send new device email", zap.String("user_id", userID.Hex()), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,13 @@
+package newdevice
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Check(httptest.NewRequest("GET", "/", nil), primitive.NewObjectID())
+}
@@ -0,0 +1,306 @@
+// internal/app/system/openapi/openapi.go
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Version is the OpenAPI specification version produced by this package.
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3 document. Only the parts of the specification
+// used by this application are modeled.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL the API is served from.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations in the UI.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations available on a path.
+type PathItem struct {
+	Get  *Operation `json:"get,omitempty"`
+	Post *Operation `json:"post,omitempty"`
+}
+
+// Operation describes a single API operation.
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// RequestBody describes a JSON request body.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response for one status code.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema for one content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Example              any                `json:"example,omitempty"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the security schemes an operation accepts.
+type SecurityRequirement map[string][]string
+
+// New creates an empty document.
+func New(title, description, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: make(map[string]*PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+}
+
+// AddTag adds a tag used to group operations.
+func (d *Document) AddTag(name, description string) {
+	d.Tags = append(d.Tags, Tag{Name: name, Description: description})
+}
+
+// Post registers a POST operation on path.
+func (d *Document) Post(path string, op *Operation) {
+	d.pathItem(path).Post = op
+}
+
+// Get registers a GET operation on path.
+func (d *Document) Get(path string, op *Operation) {
+	d.pathItem(path).Get = op
+}
+
+func (d *Document) pathItem(path string) *PathItem {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	return item
+}
+
+// Ref registers the schema for v's type under name in the document's
+// components and returns a reference to it. v is typically a zero value,
+// e.g. Ref("PlayerState", PlayerState{}).
+func (d *Document) Ref(name string, v any) *Schema {
+	if _, ok := d.Components.Schemas[name]; !ok {
+		d.Components.Schemas[name] = SchemaFor(v)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// JSONBody returns a required JSON request body with the given schema.
+func JSONBody(s *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: s}},
+	}
+}
+
+// JSONResponse returns a response with a JSON body. A nil schema produces
+// a response with no body.
+func JSONResponse(description string, s *Schema) *Response {
+	resp := &Response{Description: description}
+	if s != nil {
+		resp.Content = map[string]MediaType{"application/json": {Schema: s}}
+	}
+	return resp
+}
+
+// apiError is the body written by the API's JSON error responses.
+type apiError struct {
+	Error string `json:"error" openapi:"required,desc=Human-readable error message"`
+}
+
+// ErrorResponse returns a response whose body is the standard API error
+// object, {"error": "..."}.
+func (d *Document) ErrorResponse(description string) *Response {
+	return JSONResponse(description, d.Ref("Error", apiError{}))
+}
+
+// ArrayOf returns an array schema with the given item schema.
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	bsonMType    = reflect.TypeOf(bson.M{})
+)
+
+// SchemaFor builds a schema for v's type by reflection.
+//
+// Struct fields are named by their json tags; fields tagged "-" or
+// unexported are skipped. A field is listed as required when its openapi
+// tag contains "required", and the tag's "desc=" and "example=" parts set
+// the description and example:
+//
+//	UserID string `json:"user_id" openapi:"required,desc=Player identifier,example=player123"`
+//
+// Because commas separate tag parts, descriptions must not contain commas.
+func SchemaFor(v any) *Schema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Description: "ObjectID (24 hex characters)"}
+	case bsonMType:
+		return &Schema{Type: "object", AdditionalProperties: true}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaForType(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaForType(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := schemaForType(f.Type)
+		for _, part := range strings.Split(f.Tag.Get("openapi"), ",") {
+			switch {
+			case part == "required":
+				s.Required = append(s.Required, name)
+			case strings.HasPrefix(part, "desc="):
+				prop.Description = strings.TrimPrefix(part, "desc=")
+			case strings.HasPrefix(part, "example="):
+				prop.Example = exampleValue(prop.Type, strings.TrimPrefix(part, "example="))
+			}
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// exampleValue converts an example from a struct tag to the schema's type
+// so numeric examples are not rendered as strings.
+func exampleValue(typ, raw string) any {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(raw, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testItem struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name" openapi:"required,desc=Display name,example=alice"`
+	Count     int64              `json:"count" openapi:"example=3"`
+	Tags      []string           `json:"tags,omitempty"`
+	Data      bson.M             `json:"data"`
+	CreatedAt time.Time          `json:"created_at"`
+	Secret    string             `json:"-"`
+	internal  string
+}
+
+func TestSchemaFor_Struct(t *testing.T) {
+	s := SchemaFor(testItem{})
+
+	if s.Type != "object" {
+		t.Fatalf("Type = %q, want object", s.Type)
+	}
+	if len(s.Properties) != 6 {
+		t.Errorf("len(Properties) = %d, want 6", len(s.Properties))
+	}
+	if _, ok := s.Properties["Secret"]; ok {
+		t.Error("field tagged json:\"-\" should be skipped")
+	}
+	if len(s.Required) != 1 || s.Required[0] != "name" {
+		t.Errorf("Required = %v, want [name]", s.Required)
+	}
+
+	tests := []struct {
+		prop, typ, format string
+	}{
+		{"id", "string", ""},
+		{"name", "string", ""},
+		{"count", "integer", "int64"},
+		{"tags", "array", ""},
+		{"data", "object", ""},
+		{"created_at", "string", "date-time"},
+	}
+	for _, tt := range tests {
+		p := s.Properties[tt.prop]
+		if p == nil {
+			t.Errorf("missing property %q", tt.prop)
+			continue
+		}
+		if p.Type != tt.typ || p.Format != tt.format {
+			t.Errorf("%s: type/format = %q/%q, want %q/%q", tt.prop, p.Type, p.Format, tt.typ, tt.format)
+		}
+	}
+
+	if got := s.Properties["name"].Description; got != "Display name" {
+		t.Errorf("name description = %q", got)
+	}
+	if got := s.Properties["name"].Example; got != "alice" {
+		t.Errorf("name example = %v", got)
+	}
+	if got, ok := s.Properties["count"].Example.(int64); !ok || got != 3 {
+		t.Errorf("count example = %#v, want int64(3)", s.Properties["count"].Example)
+	}
+	if s.Properties["tags"].Items == nil || s.Properties["tags"].Items.Type != "string" {
+		t.Error("tags items should be strings")
+	}
+}
+
+func TestDocument_Ref(t *testing.T) {
+	doc := New("Test", "", "1.0.0")
+
+	ref := doc.Ref("Item", testItem{})
+	if ref.Ref != "#/components/schemas/Item" {
+		t.Errorf("Ref = %q", ref.Ref)
+	}
+	if doc.Components.Schemas["Item"] == nil {
+		t.Fatal("schema not registered in components")
+	}
+
+	// Registering again keeps the first schema
+	first := doc.Components.Schemas["Item"]
+	doc.Ref("Item", testItem{})
+	if doc.Components.Schemas["Item"] != first {
+		t.Error("Ref should not replace an existing schema")
+	}
+}
@@ -0,0 +1,144 @@
+// Package passwordexpiry enforces a maximum password age for password-auth
+// users. An expired password is treated like a temporary one: the user is
+// sent to change it right after logging in. Users are emailed a warning
+// before their password expires.
+//
+// A password's age counts from the user's password_changed_at, or from the
+// account's creation for passwords set before that field was recorded.
+package passwordexpiry
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Policy sets how long passwords last. The zero value never expires them.
+type Policy struct {
+	// MaxAge is how long a password can be used before it must be changed
+	// (0 = passwords don't expire).
+	MaxAge time.Duration
+
+	// Warning is how far ahead of expiry the user is emailed (0 = no email).
+	Warning time.Duration
+}
+
+// Enabled reports whether passwords expire.
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0
+}
+
+// ExpiresAt returns when the user's password expires. ok is false if the
+// policy is off or the user doesn't log in with a password.
+func (p Policy) ExpiresAt(u *models.User) (expires time.Time, ok bool) {
+	if !p.Enabled() || u == nil || !u.HasMethod("password") || u.PasswordHash == nil {
+		return time.Time{}, false
+	}
+	changed := u.CreatedAt
+	if u.PasswordChangedAt != nil {
+		changed = *u.PasswordChangedAt
+	}
+	return changed.Add(p.MaxAge), true
+}
+
+// Expired reports whether the user's password has expired as of now.
+func (p Policy) Expired(u *models.User, now time.Time) bool {
+	expires, ok := p.ExpiresAt(u)
+	return ok && !now.Before(expires)
+}
+
+// Notifier emails users whose passwords are about to expire. A nil
+// Notifier is valid and sends nothing.
+type Notifier struct {
+	users    *userstore.Store
+	settings *settingsstore.Store
+	mail     *mailer.Mailer
+	policy   Policy
+	baseURL  string
+	logger   *zap.Logger
+}
+
+// New creates a Notifier. Returns nil if mail is nil or the policy doesn't
+// expire passwords or send warnings.
+func New(db *mongo.Database, mail *mailer.Mailer, policy Policy, baseURL string, logger *zap.Logger) *Notifier {
+	if mail == nil || !policy.Enabled() || policy.Warning <= 0 {
+		return nil
+	}
+	return &Notifier{
+		users:    userstore.New(db),
+		settings: settingsstore.New(db),
+		mail:     mail,
+		policy:   policy,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		logger:   logger,
+	}
+}
+
+// Jobs returns the background job that sends expiry warnings.
+func (n *Notifier) Jobs() []tasks.Job {
+	if n == nil {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "password-expiry-warning",
+		Interval: 1 * time.Hour,
+		Run:      n.checkExpiring,
+	}}
+}
+
+// checkExpiring sends one warning per password that expires within the
+// policy's warning period. Passwords that have already expired are marked
+// without an email, since the user is asked to change them at login.
+func (n *Notifier) checkExpiring(ctx context.Context) error {
+	now := time.Now()
+	users, err := n.users.ListPasswordsChangedBefore(ctx, now.Add(n.policy.Warning-n.policy.MaxAge))
+	if err != nil {
+		return err
+	}
+
+	appName := models.DefaultSiteName
+	if s, err := n.settings.Get(ctx); err == nil && s.SiteName != "" {
+		appName = s.SiteName
+	}
+
+	for i := range users {
+		u := &users[i]
+		// Mark first so a failing mail server doesn't cause repeated warnings
+		if err := n.users.MarkPasswordExpiryWarned(ctx, u.ID); err != nil {
+			return err
+		}
+		if n.policy.Expired(u, now) || u.Email == nil || *u.Email == "" {
+			continue
+		}
+
+		expires, _ := n.policy.ExpiresAt(u)
+		text, html := mailer.PasswordExpiringEmail(mailer.PasswordExpiringEmailData{
+			Locale:    u.Locale,
+			Brand:     n.mail.Brand(ctx),
+			AppName:   appName,
+			UserName:  u.FullName,
+			ExpiresOn: expires.UTC().Format("January 2, 2006"),
+			ChangeURL: n.baseURL + "/profile",
+		})
+		if err := n.mail.Send(mailer.Email{
+			To:       *u.Email,
+			Subject:  mailer.T(u.Locale, "password_expiring.subject"),
+			Template: "password_expiring",
+			UserID:   u.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		}); err != nil {
+			n.logger.Warn("failed to send password expiry warning",
+				zap.String("user_id", u.ID.Hex()), zap.Error(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package passwordexpiry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/domain/models"
+)
+
+func TestPolicy_Expired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	hash := "hash"
+	at := func(d time.Duration) *time.Time {
+		ts := now.Add(-d)
+		return &ts
+	}
+	policy := Policy{MaxAge: 90 * 24 * time.Hour}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		user   models.User
+		want   bool
+	}{
+		{"recent change", policy, models.User{AuthMethod: "password", PasswordHash: &hash, PasswordChangedAt: at(24 * time.Hour)}, false},
+		{"old change", policy, models.User{AuthMethod: "password", PasswordHash: &hash, PasswordChangedAt: at(91 * 24 * time.Hour)}, true},
+		{"exactly max age", policy, models.User{AuthMethod: "password", PasswordHash: &hash, PasswordChangedAt: at(90 * 24 * time.Hour)}, true},
+		{"falls back to created", policy, models.User{AuthMethod: "password", PasswordHash: &hash, CreatedAt: *at(100 * 24 * time.Hour)}, true},
+		{"policy off", Policy{}, models.User{AuthMethod: "password", PasswordHash: &hash, PasswordChangedAt: at(1000 * 24 * time.Hour)}, false},
+		{"email auth", policy, models.User{AuthMethod: "email", CreatedAt: *at(1000 * 24 * time.Hour)}, false},
+		{"linked password", policy, models.User{AuthMethod: "google", LinkedMethods: []string{"password"}, PasswordHash: &hash, PasswordChangedAt: at(91 * 24 * time.Hour)}, true},
+		{"no password", policy, models.User{AuthMethod: "password", CreatedAt: *at(1000 * 24 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Expired(&tt.user, now); got != tt.want {
+			t.Errorf("%s: Expired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNew_NilWhenDisabled(t *testing.T) {
+	if n := New(nil, nil, Policy{MaxAge: time.Hour, Warning: time.Minute}, "", nil); n != nil {
+		t.Error("New() without a mailer should return nil")
+	}
+	var n *Notifier
+	if jobs := n.Jobs(); jobs != nil {
+		t.Errorf("nil Notifier Jobs() = %v, want nil", jobs)
+	}
+}
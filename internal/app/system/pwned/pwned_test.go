@@ -0,0 +1,107 @@
+package pwned
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+const passwordSuffix = "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+func newTestChecker(t *testing.T, handler http.HandlerFunc) *Checker {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := New(time.Second, zap.NewNop())
+	c.endpoint = srv.URL + "/range/"
+	return c
+}
+
+func TestIsBreached(t *testing.T) {
+	var gotPath, gotPadding string
+	c := newTestChecker(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotPadding = r.Header.Get("Add-Padding")
+		w.Write([]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" +
+			passwordSuffix + ":9545824\r\n" +
+			"00D4F6E8FA6EECAD2A3AA415EEC418D38EC:0\r\n"))
+	})
+
+	if !c.IsBreached(context.Background(), "password") {
+		t.Error("IsBreached(password) = false, want true")
+	}
+	if gotPath != "/range/5BAA6" {
+		t.Errorf("requested %q, want only the 5-character prefix", gotPath)
+	}
+	if gotPadding != "true" {
+		t.Errorf("Add-Padding = %q, want true", gotPadding)
+	}
+}
+
+func TestIsBreached_NotFound(t *testing.T) {
+	c := newTestChecker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n"))
+	})
+	if c.IsBreached(context.Background(), "password") {
+		t.Error("IsBreached() = true, want false")
+	}
+}
+
+func TestIsBreached_PaddingEntryIgnored(t *testing.T) {
+	c := newTestChecker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(passwordSuffix + ":0\r\n"))
+	})
+	if c.IsBreached(context.Background(), "password") {
+		t.Error("IsBreached() = true for a zero-count padding entry, want false")
+	}
+}
+
+func TestIsBreached_FailsOpen(t *testing.T) {
+	c := newTestChecker(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	if c.IsBreached(context.Background(), "password") {
+		t.Error("IsBreached() = true on a server error, want false")
+	}
+}
+
+func TestIsBreached_NilChecker(t *testing.T) {
+	var c *Checker
+	if c.IsBreached(context.Background(), "password") {
+		t.Error("nil Checker reported a breach")
+	}
+}
+
+func TestIsBreached_DoesNotLogPassword(t *testing.T) {
+	var logged strings.Builder
+	c := newTestChecker(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	c.log = zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(&logged),
+		zapcore.DebugLevel,
+	))
+
+	const password = "Tr0ub4dor&3"
+	sum := sha1.Sum([]byte(password))
+	prefix := strings.ToUpper(hex.EncodeToString(sum[:]))[:5]
+
+	c.IsBreached(context.Background(), password)
+	out := logged.String()
+	if out == "" {
+		t.Fatal("expected the failure to be logged")
+	}
+	if strings.Contains(out, password) || strings.Contains(strings.ToUpper(out), prefix) {
+		t.Errorf("log output leaked password data: %s", out)
+	}
+}
@@ -0,0 +1,345 @@
+// Package reports emails admins scheduled summaries of how the site is
+// being used.
+//
+// A weekly report covers Monday through Sunday and is sent early on
+// Monday; a monthly report covers the previous calendar month and is sent
+// on the 1st. Which reports are sent, what they include, and who receives
+// them are site settings.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/apistats"
+	filestore "github.com/dalemusser/stratasave/internal/app/store/file"
+	ledgerstore "github.com/dalemusser/stratasave/internal/app/store/ledger"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/status"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Report periods.
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// Report section names, stored in site settings.
+const (
+	SectionAPIUsage = "api_usage"
+	SectionNewUsers = "new_users"
+	SectionStorage  = "storage"
+	SectionErrors   = "errors"
+)
+
+// Section is a part of the report admins can choose to include.
+type Section struct {
+	Name  string
+	Label string
+}
+
+// Sections lists every report section, in the order they appear.
+var Sections = []Section{
+	{Name: SectionAPIUsage, Label: "API usage"},
+	{Name: SectionNewUsers, Label: "New users"},
+	{Name: SectionStorage, Label: "Storage growth"},
+	{Name: SectionErrors, Label: "Error counts"},
+}
+
+// ValidSection reports whether name is one of Sections.
+func ValidSection(name string) bool {
+	for _, s := range Sections {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Includes reports whether a report with the selected sections includes
+// the named one. No selection includes every section.
+func Includes(selected []string, name string) bool {
+	return len(selected) == 0 || slices.Contains(selected, name)
+}
+
+// PeriodRange returns the span [start, end) a report sent at now covers:
+// the previous Monday-to-Sunday week, or the previous calendar month.
+func PeriodRange(period string, now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == Monthly {
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -1, 0), end
+	}
+	weekday := int(today.Weekday())
+	if weekday == 0 {
+		weekday = 7 // Sunday ends the week
+	}
+	end = today.AddDate(0, 0, -(weekday - 1))
+	return end.AddDate(0, 0, -7), end
+}
+
+// apiTypes are the API operations reported on, with their labels.
+var apiTypes = []struct {
+	statType apistats.StatType
+	label    string
+}{
+	{apistats.StatTypeSaveState, "State saves"},
+	{apistats.StatTypeLoadState, "State loads"},
+	{apistats.StatTypeSaveSettings, "Settings saves"},
+	{apistats.StatTypeLoadSettings, "Settings loads"},
+}
+
+// Reporter builds and sends scheduled reports. A nil Reporter is valid
+// and sends nothing.
+type Reporter struct {
+	apiStats *apistats.Store
+	files    *filestore.Store
+	ledger   *ledgerstore.Store
+	users    *userstore.Store
+	settings *settingsstore.Store
+	mail     *mailer.Mailer
+	baseURL  string
+	logger   *zap.Logger
+}
+
+// New creates a Reporter. Returns nil if mail is nil, since reports are
+// only ever emailed. baseURL is used to link reports to the dashboard.
+func New(db *mongo.Database, mail *mailer.Mailer, baseURL string, logger *zap.Logger) *Reporter {
+	if mail == nil {
+		return nil
+	}
+	return &Reporter{
+		apiStats: apistats.New(db),
+		files:    filestore.New(db),
+		ledger:   ledgerstore.New(db),
+		users:    userstore.New(db),
+		settings: settingsstore.New(db),
+		mail:     mail,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		logger:   logger,
+	}
+}
+
+// Jobs returns the weekly and monthly report jobs. Each checks the site
+// settings when it runs, so turning a report on or off needs no restart.
+func (r *Reporter) Jobs() []tasks.Job {
+	if r == nil {
+		return nil
+	}
+	return []tasks.Job{
+		{
+			Name:     "weekly-report",
+			Interval: 7 * 24 * time.Hour,
+			Schedule: "0 7 * * 1",
+			Run:      func(ctx context.Context) error { return r.Send(ctx, Weekly, time.Now()) },
+		},
+		{
+			Name:     "monthly-report",
+			Interval: 30 * 24 * time.Hour,
+			Schedule: "0 7 1 * *",
+			Run:      func(ctx context.Context) error { return r.Send(ctx, Monthly, time.Now()) },
+		},
+	}
+}
+
+// Send emails the report for the period before now, if the site settings
+// turn it on.
+func (r *Reporter) Send(ctx context.Context, period string, now time.Time) error {
+	st, err := r.settings.Get(ctx)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return fmt.Errorf("loading site settings: %w", err)
+	}
+	if st == nil || !(period == Weekly && st.ReportWeekly || period == Monthly && st.ReportMonthly) {
+		return nil
+	}
+
+	recipients, err := r.recipients(ctx, st.ReportRecipients)
+	if err != nil {
+		return err
+	}
+	if len(recipients) == 0 {
+		r.logger.Warn("scheduled report not sent: no recipients", zap.String("period", period))
+		return nil
+	}
+
+	start, end := PeriodRange(period, now)
+	sections, err := r.Build(ctx, st.ReportSections, start, end)
+	if err != nil {
+		return err
+	}
+
+	appName := models.DefaultSiteName
+	if st.SiteName != "" {
+		appName = st.SiteName
+	}
+	heading := "Weekly Report"
+	if period == Monthly {
+		heading = "Monthly Report"
+	}
+
+	text, html := mailer.ReportEmail(mailer.ReportEmailData{
+		Brand:        r.mail.Brand(ctx),
+		AppName:      appName,
+		Heading:      heading,
+		Period:       PeriodLabel(start, end),
+		Sections:     sections,
+		DashboardURL: r.baseURL + "/dashboard",
+	})
+	emails := make([]mailer.Email, len(recipients))
+	for i, to := range recipients {
+		emails[i] = mailer.Email{
+			To:       to,
+			Subject:  "[" + appName + "] " + heading,
+			Template: "report",
+			TextBody: text,
+			HTMLBody: html,
+		}
+	}
+	p, err := r.mail.SendBulk(ctx, emails, nil)
+	if err != nil {
+		return err
+	}
+	if p.Failed > 0 {
+		return fmt.Errorf("sent %s report to %d of %d recipients", period, p.Total-p.Failed, p.Total)
+	}
+	r.logger.Info("sent scheduled report",
+		zap.String("period", period),
+		zap.Int("recipients", p.Total))
+	return nil
+}
+
+// Build gathers the selected sections of a report on [start, end).
+func (r *Reporter) Build(ctx context.Context, selected []string, start, end time.Time) ([]mailer.ReportSection, error) {
+	var sections []mailer.ReportSection
+
+	if Includes(selected, SectionAPIUsage) {
+		sec := mailer.ReportSection{Title: "API usage"}
+		for _, t := range apiTypes {
+			// Buckets are keyed by their start, so the last one begins before end
+			agg, err := r.apiStats.AggregateRange(ctx, t.statType, start, end.Add(-time.Nanosecond))
+			if err != nil {
+				return nil, fmt.Errorf("aggregating API stats: %w", err)
+			}
+			value := formatCount(agg.Requests) + " requests"
+			if agg.Requests > 0 {
+				value += fmt.Sprintf(" (%.1f%% errors, avg %.0f ms)", agg.ErrorRate(), agg.AvgMs())
+			}
+			sec.Rows = append(sec.Rows, mailer.ReportRow{Label: t.label, Value: value})
+		}
+		sections = append(sections, sec)
+	}
+
+	if Includes(selected, SectionNewUsers) {
+		created, err := r.users.Count(ctx, bson.M{"created_at": bson.M{"$gte": start, "$lt": end}})
+		if err != nil {
+			return nil, fmt.Errorf("counting new users: %w", err)
+		}
+		total, err := r.users.Count(ctx, bson.M{"status": bson.M{"$ne": status.Deleted}})
+		if err != nil {
+			return nil, fmt.Errorf("counting users: %w", err)
+		}
+		sections = append(sections, mailer.ReportSection{Title: "New users", Rows: []mailer.ReportRow{
+			{Label: "Created", Value: formatCount(created)},
+			{Label: "Total users", Value: formatCount(total)},
+		}})
+	}
+
+	if Includes(selected, SectionStorage) {
+		files, bytes, err := r.files.AddedBetween(ctx, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("summing uploads: %w", err)
+		}
+		usage, err := r.files.Usage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("summing storage: %w", err)
+		}
+		sections = append(sections, mailer.ReportSection{Title: "Storage growth", Rows: []mailer.ReportRow{
+			{Label: "Files uploaded", Value: formatCount(files) + " (" + formatBytes(bytes) + ")"},
+			{Label: "Library size", Value: formatCount(usage.Files) + " files (" + formatBytes(usage.Bytes) + ")"},
+			{Label: "Storage used", Value: formatBytes(usage.StoredBytes)},
+		}})
+	}
+
+	if Includes(selected, SectionErrors) {
+		counts, err := r.ledger.CountByStatus(ctx, start, end.Add(-time.Nanosecond))
+		if err != nil {
+			return nil, fmt.Errorf("counting errors: %w", err)
+		}
+		sections = append(sections, mailer.ReportSection{Title: "Error counts", Rows: []mailer.ReportRow{
+			{Label: "Client errors (4xx)", Value: formatCount(counts["4xx"])},
+			{Label: "Server errors (5xx)", Value: formatCount(counts["5xx"])},
+		}})
+	}
+
+	return sections, nil
+}
+
+// recipients returns the configured addresses, or every active admin's.
+func (r *Reporter) recipients(ctx context.Context, configured []string) ([]string, error) {
+	if len(configured) > 0 {
+		return configured, nil
+	}
+	admins, err := r.users.Find(ctx, bson.M{
+		"role":   models.RoleAdmin,
+		"status": status.Active,
+		"email":  bson.M{"$nin": []any{nil, ""}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing admins for report: %w", err)
+	}
+	var out []string
+	for _, u := range admins {
+		if u.Email != nil {
+			out = append(out, *u.Email)
+		}
+	}
+	return out, nil
+}
+
+// PeriodLabel describes [start, end) by its first and last days, e.g.
+// "Mar 2 - Mar 8, 2026".
+func PeriodLabel(start, end time.Time) string {
+	last := end.AddDate(0, 0, -1)
+	if start.Year() != last.Year() {
+		return start.Format("Jan 2, 2006") + " - " + last.Format("Jan 2, 2006")
+	}
+	return start.Format("Jan 2") + " - " + last.Format("Jan 2, 2006")
+}
+
+// formatCount formats n with thousands separators, e.g. "12,480".
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// formatBytes formats a byte count in binary units, e.g. "1.5 GiB".
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return strconv.FormatInt(b, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
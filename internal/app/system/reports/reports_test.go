@@ -0,0 +1,66 @@
+package reports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodRange(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name       string
+		period     string
+		now        time.Time
+		start, end time.Time
+	}{
+		{"weekly on Monday", Weekly, day(2026, 3, 9).Add(7 * time.Hour), day(2026, 3, 2), day(2026, 3, 9)},
+		{"weekly on Sunday", Weekly, day(2026, 3, 15).Add(23 * time.Hour), day(2026, 3, 2), day(2026, 3, 9)},
+		{"monthly on the 1st", Monthly, day(2026, 3, 1).Add(7 * time.Hour), day(2026, 2, 1), day(2026, 3, 1)},
+		{"monthly across a year", Monthly, day(2026, 1, 1).Add(7 * time.Hour), day(2025, 12, 1), day(2026, 1, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := PeriodRange(tt.period, tt.now)
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("PeriodRange() = %v - %v, want %v - %v", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestPeriodLabel(t *testing.T) {
+	start, end := PeriodRange(Weekly, time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC))
+	if got := PeriodLabel(start, end); got != "Mar 2 - Mar 8, 2026" {
+		t.Errorf("PeriodLabel() = %q", got)
+	}
+	start, end = PeriodRange(Weekly, time.Date(2026, 1, 5, 7, 0, 0, 0, time.UTC))
+	if got := PeriodLabel(start, end); got != "Dec 29, 2025 - Jan 4, 2026" {
+		t.Errorf("PeriodLabel() across years = %q", got)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	if !Includes(nil, SectionStorage) {
+		t.Error("Includes(nil) = false, want every section")
+	}
+	if Includes([]string{SectionAPIUsage}, SectionStorage) {
+		t.Error("Includes() = true for an unselected section")
+	}
+	if !ValidSection(SectionErrors) || ValidSection("nope") {
+		t.Error("ValidSection() wrong")
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -12480: "-12,480"} {
+		if got := formatCount(n); got != want {
+			t.Errorf("formatCount(%d) = %q, want %q", n, got, want)
+		}
+	}
+	for b, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 3 << 30: "3.0 GiB"} {
+		if got := formatBytes(b); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", b, got, want)
+		}
+	}
+}
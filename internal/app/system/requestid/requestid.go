@@ -0,0 +1,57 @@
+// Package requestid gives every request an ID that ties together its
+// ledger entry, audit events, log lines, and any API error response.
+//
+// The ID is always generated here rather than taken from the client, so it
+// is unique and safe to index; an X-Request-ID sent by the client is kept
+// separately by the ledger as the client request ID.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Header is the response header the request ID is returned in.
+const Header = "X-Request-ID"
+
+// ctxKey is the context key type for the request ID.
+type ctxKey struct{}
+
+// Middleware assigns the request an ID, stores it in the request context,
+// and returns it in the X-Request-ID response header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := uuid.New().String()
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// NewContext returns a copy of ctx carrying id as its request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID in ctx, or "" outside a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Field returns the request ID in ctx as a zap field, or zap.Skip() when
+// there is none, so it can be passed to any log call.
+func Field(ctx context.Context) zap.Field {
+	id := FromContext(ctx)
+	if id == "" {
+		return zap.Skip()
+	}
+	return zap.String("request_id", id)
+}
+
+// Logger returns logger with the request ID in ctx attached to every line.
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	return logger.With(Field(ctx))
+}
@@ -0,0 +1,60 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/state/load", nil)
+	req.Header.Set(Header, "client-supplied")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if _, err := uuid.Parse(seen); err != nil {
+		t.Fatalf("request ID = %q, want a generated UUID", seen)
+	}
+	if got := rec.Header().Get(Header); got != seen {
+		t.Errorf("%s header = %q, want %q", Header, got, seen)
+	}
+
+	first := seen
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen == first {
+		t.Errorf("second request reused ID %q", first)
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	if got := FromContext(context.Background()); got != "" {
+		t.Errorf("FromContext() = %q, want empty", got)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	Logger(NewContext(context.Background(), "abc"), logger).Info("with id")
+	Logger(context.Background(), logger).Info("without id")
+
+	entries := logs.All()
+	if got := entries[0].ContextMap()["request_id"]; got != "abc" {
+		t.Errorf("request_id = %v, want abc", got)
+	}
+	if _, ok := entries[1].ContextMap()["request_id"]; ok {
+		t.Error("request_id logged outside a request")
+	}
+}
@@ -0,0 +1,299 @@
+// Package resumable receives large uploads in chunks so an upload
+// interrupted by a flaky connection can continue where it stopped instead
+// of starting over.
+//
+// Each chunk is written to file storage as its own object under
+// "uploads/<upload id>/" and recorded in the file_uploads collection. When
+// the last chunk arrives, the chunks are streamed, in order, into the final
+// object. Uploads that stop making progress expire and are cleaned up by a
+// background job.
+//
+// With a storage backend that supports presigned URLs (S3), an upload can
+// instead go directly from the browser to the bucket. The server only
+// issues the URL and checks the object once the browser reports it done.
+package resumable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/upload"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// MaxChunkSize is the largest chunk accepted in one request.
+const MaxChunkSize = 16 << 20 // 16MB
+
+// ChunkSize is the chunk size browsers are asked to send.
+const ChunkSize = 8 << 20 // 8MB
+
+// expiry is how long an upload is kept without receiving a chunk.
+const expiry = 24 * time.Hour
+
+// presignExpiry is how long a direct upload URL can be used.
+const presignExpiry = 1 * time.Hour
+
+var (
+	// ErrNotFound is returned for an unknown or expired upload.
+	ErrNotFound = errors.New("upload not found")
+	// ErrTooLarge is returned when an upload exceeds the size limit.
+	ErrTooLarge = errors.New("upload too large")
+	// ErrChunkTooLarge is returned when one chunk exceeds MaxChunkSize.
+	ErrChunkTooLarge = errors.New("chunk too large")
+	// ErrOffsetMismatch is returned when a chunk doesn't start where the
+	// upload left off. The client should ask for the offset and resume.
+	ErrOffsetMismatch = errors.New("upload offset mismatch")
+)
+
+// Manager tracks resumable uploads.
+type Manager struct {
+	uploads *upload.Store
+	storage storage.Store
+	maxSize int64
+	logger  *zap.Logger
+}
+
+// New creates a Manager accepting uploads of up to maxSize bytes.
+func New(db *mongo.Database, st storage.Store, maxSize int64, logger *zap.Logger) *Manager {
+	return &Manager{
+		uploads: upload.New(db),
+		storage: st,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// MaxSize returns the largest upload accepted, in bytes.
+func (m *Manager) MaxSize() int64 {
+	return m.maxSize
+}
+
+// Expiry returns how long an upload is kept without progress.
+func (m *Manager) Expiry() time.Duration {
+	return expiry
+}
+
+// Create starts an upload.
+func (m *Manager) Create(ctx context.Context, input upload.CreateInput) (*upload.Upload, error) {
+	if input.Size > m.maxSize {
+		return nil, ErrTooLarge
+	}
+	return m.uploads.Create(ctx, input, expiry)
+}
+
+// CreateDirect starts a direct upload to input.StoragePath and returns a
+// presigned URL the browser can send the file to. The backend must support
+// presigned uploads (storage.ErrPresignNotSupported otherwise).
+func (m *Manager) CreateDirect(ctx context.Context, input upload.CreateInput) (*upload.Upload, *storage.PresignedUpload, error) {
+	if input.Size > m.maxSize {
+		return nil, nil, ErrTooLarge
+	}
+	presigned, err := m.storage.PresignedUploadURL(ctx, input.StoragePath, &storage.PresignUploadOptions{
+		Expires:     presignExpiry,
+		ContentType: input.ContentType,
+		MaxSize:     input.Size,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := m.uploads.Create(ctx, input, expiry)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, presigned, nil
+}
+
+// Get returns an upload started by userID. Uploads started by other users
+// are reported as not found.
+func (m *Manager) Get(ctx context.Context, id, userID primitive.ObjectID) (*upload.Upload, error) {
+	u, err := m.uploads.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.UserID != userID || time.Now().After(u.ExpiresAt) {
+		return nil, ErrNotFound
+	}
+	return u, nil
+}
+
+// WriteChunk stores body as the chunk starting at offset and returns the
+// upload's new offset.
+func (m *Manager) WriteChunk(ctx context.Context, u *upload.Upload, offset int64, body io.Reader) (int64, error) {
+	if offset != u.Offset {
+		return u.Offset, ErrOffsetMismatch
+	}
+
+	// Each attempt gets its own object, so a retried or concurrent request
+	// can't overwrite a chunk that has already been recorded
+	path := fmt.Sprintf("%s%020d-%s", chunkPrefix(u.ID), offset, uuid.New().String()[:8])
+	counter := &countingReader{r: io.LimitReader(body, MaxChunkSize+1)}
+	if err := m.storage.Put(ctx, path, counter, &storage.PutOptions{ContentType: "application/octet-stream"}); err != nil {
+		return u.Offset, err
+	}
+
+	size := counter.n
+	var err error
+	switch {
+	case size > MaxChunkSize:
+		err = ErrChunkTooLarge
+	case offset+size > u.Size:
+		err = ErrTooLarge
+	default:
+		var ok bool
+		ok, err = m.uploads.AppendChunk(ctx, u.ID, offset, upload.Chunk{Path: path, Size: size}, expiry)
+		if err == nil && !ok {
+			err = ErrOffsetMismatch
+		}
+	}
+	if err != nil {
+		_ = m.storage.Delete(ctx, path)
+		return u.Offset, err
+	}
+
+	u.Offset += size
+	u.Chunks = append(u.Chunks, upload.Chunk{Path: path, Size: size})
+	return u.Offset, nil
+}
+
+// Assemble joins a complete upload's chunks into a single object at dst,
+// stored with the upload's storage settings.
+func (m *Manager) Assemble(ctx context.Context, u *upload.Upload, dst string) error {
+	if !u.Complete() {
+		return fmt.Errorf("upload %s is incomplete: %d of %d bytes", u.ID.Hex(), u.Offset, u.Size)
+	}
+	r := &chunkReader{ctx: ctx, storage: m.storage, chunks: u.Chunks}
+	defer r.Close()
+	return m.storage.Put(ctx, dst, r, &storage.PutOptions{
+		ContentType:          u.ContentType,
+		ServerSideEncryption: u.Storage.Encryption,
+		StorageClass:         u.Storage.StorageClass,
+		CacheControl:         u.Storage.CacheControl,
+	})
+}
+
+// Open returns a reader over a complete upload's chunks, in order, for
+// checking the content before it is assembled.
+func (m *Manager) Open(ctx context.Context, u *upload.Upload) io.ReadCloser {
+	return &chunkReader{ctx: ctx, storage: m.storage, chunks: u.Chunks}
+}
+
+// Remove deletes an upload's chunks and its record, after it has been
+// assembled or abandoned. For an abandoned direct upload it also deletes
+// whatever reached the bucket.
+func (m *Manager) Remove(ctx context.Context, u *upload.Upload) error {
+	var paths []string
+	for _, c := range u.Chunks {
+		paths = append(paths, c.Path)
+	}
+	if u.Direct() {
+		paths = append(paths, u.StoragePath)
+	}
+	if len(paths) > 0 {
+		if _, err := m.storage.DeleteMany(ctx, paths); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return m.uploads.Delete(ctx, u.ID)
+}
+
+// Release deletes the record of a finished direct upload, leaving its
+// object in place as the library file.
+func (m *Manager) Release(ctx context.Context, u *upload.Upload) error {
+	return m.uploads.Delete(ctx, u.ID)
+}
+
+// Jobs returns the background job that removes expired uploads.
+func (m *Manager) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "upload-cleanup",
+		Interval: 1 * time.Hour,
+		Run:      m.removeExpired,
+	}}
+}
+
+// removeExpired deletes uploads that stopped making progress.
+func (m *Manager) removeExpired(ctx context.Context) error {
+	expired, err := m.uploads.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range expired {
+		if err := m.Remove(ctx, &expired[i]); err != nil {
+			return err
+		}
+	}
+	if len(expired) > 0 {
+		m.logger.Info("cleaned up expired uploads", zap.Int("deleted", len(expired)))
+	}
+	return nil
+}
+
+// chunkPrefix is the storage prefix for an upload's chunks.
+func chunkPrefix(id primitive.ObjectID) string {
+	return "uploads/" + id.Hex() + "/"
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// chunkReader reads a sequence of stored chunks as one stream, opening
+// each only when the previous one is exhausted.
+type chunkReader struct {
+	ctx     context.Context
+	storage storage.Store
+	chunks  []upload.Chunk
+	cur     io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.cur == nil {
+			if len(c.chunks) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := c.storage.Get(c.ctx, c.chunks[0].Path)
+			if err != nil {
+				return 0, err
+			}
+			c.cur = rc
+			c.chunks = c.chunks[1:]
+		}
+		n, err := c.cur.Read(p)
+		if err == io.EOF {
+			c.cur.Close()
+			c.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.cur != nil {
+		return c.cur.Close()
+	}
+	return nil
+}
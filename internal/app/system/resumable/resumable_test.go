@@ -0,0 +1,46 @@
+package resumable
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dalemusser/stratasave/internal/app/store/upload"
+	"github.com/dalemusser/waffle/pantry/storage"
+)
+
+func TestChunkReader_JoinsChunksInOrder(t *testing.T) {
+	ctx := context.Background()
+	st := storage.NewMemory(storage.MemoryConfig{})
+	parts := []string{"hello ", "", "resumable ", "world"}
+
+	var chunks []upload.Chunk
+	for i, p := range parts {
+		path := "uploads/test/" + string(rune('a'+i))
+		if err := st.PutBytes(ctx, path, []byte(p), nil); err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, upload.Chunk{Path: path, Size: int64(len(p))})
+	}
+
+	r := &chunkReader{ctx: ctx, storage: st, chunks: chunks}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if want := strings.Join(parts, ""); string(got) != want {
+		t.Errorf("joined = %q, want %q", got, want)
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	c := &countingReader{r: io.LimitReader(strings.NewReader("0123456789"), 4)}
+	if _, err := io.ReadAll(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.n != 4 {
+		t.Errorf("counted %d bytes, want 4", c.n)
+	}
+}
@@ -0,0 +1,215 @@
+// internal/app/system/scheduler/cron.go
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spec is a parsed schedule: either a five-field cron expression or a
+// fixed interval from "@every <duration>".
+//
+// Cron fields are minute (0-59), hour (0-23), day of month (1-31), month
+// (1-12 or JAN-DEC), and day of week (0-6 or SUN-SAT, 7 is also Sunday).
+// Each field takes "*", a value, a range "a-b", a list "a,b", and a step
+// "*/n" or "a-b/n". As in standard cron, when both day fields are
+// restricted a day matching either one matches. Times are in UTC.
+type Spec struct {
+	expr  string
+	every time.Duration
+
+	minute, hour, dom, month, dow uint64 // bit i set when value i matches
+	domStar, dowStar              bool
+}
+
+// descriptors are the shorthand expressions accepted in place of five
+// fields.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Parse parses a cron expression, a descriptor such as "@daily", or
+// "@every <duration>".
+func Parse(expr string) (Spec, error) {
+	expr = strings.TrimSpace(expr)
+	spec := Spec{expr: expr}
+
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return Spec{}, fmt.Errorf("schedule %q: %w", expr, err)
+		}
+		if d <= 0 {
+			return Spec{}, fmt.Errorf("schedule %q: interval must be positive", expr)
+		}
+		spec.every = d
+		return spec, nil
+	}
+
+	fieldsExpr := expr
+	if strings.HasPrefix(expr, "@") {
+		var ok bool
+		if fieldsExpr, ok = descriptors[strings.ToLower(expr)]; !ok {
+			return Spec{}, fmt.Errorf("schedule %q: unknown descriptor", expr)
+		}
+	}
+	fields := strings.Fields(fieldsExpr)
+	if len(fields) != 5 {
+		return Spec{}, fmt.Errorf("schedule %q: want 5 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	if spec.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return Spec{}, fmt.Errorf("schedule %q: minute: %w", expr, err)
+	}
+	if spec.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return Spec{}, fmt.Errorf("schedule %q: hour: %w", expr, err)
+	}
+	if spec.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return Spec{}, fmt.Errorf("schedule %q: day of month: %w", expr, err)
+	}
+	if spec.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return Spec{}, fmt.Errorf("schedule %q: month: %w", expr, err)
+	}
+	if spec.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return Spec{}, fmt.Errorf("schedule %q: day of week: %w", expr, err)
+	}
+	// 7 is another name for Sunday
+	if spec.dow&(1<<7) != 0 {
+		spec.dow = spec.dow&^(1<<7) | 1
+	}
+	spec.domStar = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	spec.dowStar = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	if spec.Next(time.Now()).IsZero() {
+		return Spec{}, fmt.Errorf("schedule %q: never matches", expr)
+	}
+	return spec, nil
+}
+
+// parseField parses one cron field into a bit set of the values it
+// matches.
+func parseField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step %q", stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = fieldValue(loStr, min, max, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = fieldValue(hiStr, min, max, names); err != nil {
+					return 0, err
+				}
+				if hi < lo {
+					return 0, fmt.Errorf("bad range %q", rng)
+				}
+			} else if hasStep {
+				// "5/15" means from 5 to the end in steps of 15
+				hi = max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// fieldValue parses a single number or name within [min, max].
+func fieldValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, min, max)
+	}
+	return v, nil
+}
+
+// String returns the expression the spec was parsed from.
+func (s Spec) String() string {
+	return s.expr
+}
+
+// Every returns the interval of an "@every" spec, or zero for a cron
+// expression.
+func (s Spec) Every() time.Duration {
+	return s.every
+}
+
+// Next returns the first time after t the spec matches, to the minute,
+// or the zero time if it never matches (such as "0 0 31 2 *").
+func (s Spec) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// Any schedule that matches at all does so within five years (leap days)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether t's day matches the day-of-month and
+// day-of-week fields.
+func (s Spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
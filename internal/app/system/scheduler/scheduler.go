@@ -0,0 +1,371 @@
+// Package scheduler runs recurring background jobs on cron schedules.
+//
+// Jobs are registered as tasks.Job values; a job with a Schedule runs on
+// that cron expression and one without runs every Interval. Schedule state
+// lives in MongoDB, so with several instances each slot is claimed by just
+// one of them, and a job can be paused or run by hand from the jobs
+// console. A run holds a distributed lock on its job for as long as it
+// runs, so a job never runs on two instances at once. Every run is
+// recorded in the run history.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	schedulestore "github.com/dalemusser/stratasave/internal/app/store/schedules"
+	"github.com/dalemusser/stratasave/internal/app/system/distlock"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// pollInterval is how often due jobs are looked for.
+	pollInterval = 15 * time.Second
+
+	// lockTTL is the lease on a running job's lock. The lease is extended
+	// while the job runs; if its instance stops mid-run, the job can run
+	// again elsewhere once the lease runs out.
+	lockTTL = 2 * time.Minute
+
+	// lockPrefix prefixes the lock names of scheduled jobs.
+	lockPrefix = "schedule:"
+)
+
+var (
+	// ErrUnknownJob is returned for a job that isn't registered.
+	ErrUnknownJob = errors.New("unknown scheduled job")
+	// ErrRunning is returned when a job is run by hand while it is running.
+	ErrRunning = errors.New("job is already running")
+	// ErrNotStarted is returned when a job is run before the scheduler starts.
+	ErrNotStarted = errors.New("scheduler not started")
+)
+
+// entry is a registered job with its parsed schedule.
+type entry struct {
+	job  tasks.Job
+	spec Spec
+}
+
+// Info describes a registered job.
+type Info struct {
+	Name string
+	Spec Spec
+}
+
+// Scheduler runs registered jobs when they are due.
+type Scheduler struct {
+	store     *schedulestore.Store
+	locker    *distlock.Locker
+	retention time.Duration
+	logger    *zap.Logger
+
+	jobs  map[string]entry
+	names []string // Registration order
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a Scheduler. Run history older than retention is deleted
+// daily; zero keeps it.
+func New(db *mongo.Database, retention time.Duration, logger *zap.Logger) *Scheduler {
+	s := &Scheduler{
+		store:     schedulestore.New(db),
+		locker:    distlock.New(db, logger),
+		retention: retention,
+		logger:    logger,
+		jobs:      make(map[string]entry),
+	}
+	if retention > 0 {
+		_ = s.Register(tasks.Job{
+			Name:     "schedule-run-cleanup",
+			Schedule: "30 4 * * *",
+			Run:      s.cleanupRuns,
+		})
+	}
+	return s
+}
+
+// Register adds a job. It fails if the job's schedule doesn't parse or
+// its name is taken.
+func (s *Scheduler) Register(job tasks.Job) error {
+	expr := job.Schedule
+	if expr == "" {
+		expr = "@every " + job.Interval.String()
+	}
+	spec, err := Parse(expr)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s: already registered", job.Name)
+	}
+	s.jobs[job.Name] = entry{job: job, spec: spec}
+	s.names = append(s.names, job.Name)
+	return nil
+}
+
+// Jobs returns the registered jobs, sorted by name.
+func (s *Scheduler) Jobs() []Info {
+	infos := make([]Info, 0, len(s.names))
+	for _, name := range s.names {
+		infos = append(infos, Info{Name: name, Spec: s.jobs[name].spec})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Lookup returns a registered job's description.
+func (s *Scheduler) Lookup(name string) (Info, bool) {
+	e, ok := s.jobs[name]
+	if !ok {
+		return Info{}, false
+	}
+	return Info{Name: name, Spec: e.spec}, true
+}
+
+// Start records the registered jobs and begins running them when due.
+// Jobs that run on an interval run as soon as they are first recorded;
+// cron jobs wait for their first slot. Call Stop to shut down.
+func (s *Scheduler) Start(ctx context.Context) error {
+	now := time.Now()
+	for _, name := range s.names {
+		e := s.jobs[name]
+		first := e.spec.Next(now)
+		if e.spec.Every() > 0 {
+			first = now
+		}
+		if err := s.store.Sync(ctx, name, e.spec.String(), first, e.spec.Next(now)); err != nil {
+			return fmt.Errorf("recording scheduled job %s: %w", name, err)
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(1)
+	go s.loop()
+
+	s.logger.Info("job scheduler started", zap.Int("job_count", len(s.names)))
+	return nil
+}
+
+// Stop stops scheduling and waits for running jobs to finish, or for ctx
+// to end. Jobs still running are cancelled.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("job scheduler stopped gracefully")
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("job scheduler shutdown timed out")
+		return ctx.Err()
+	}
+}
+
+// loop looks for due jobs every pollInterval until the scheduler stops.
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	s.runDue()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDue()
+		}
+	}
+}
+
+// runDue claims and starts each due job this instance has registered.
+func (s *Scheduler) runDue() {
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	names, err := s.store.Due(ctx, now)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Error("failed to read due jobs", zap.Error(err))
+		}
+		return
+	}
+	for _, name := range names {
+		e, ok := s.jobs[name]
+		if !ok {
+			continue // Registered by another version of the app
+		}
+		claimed, err := s.store.Claim(ctx, name, now, e.spec.Next(now))
+		if err != nil {
+			s.logger.Error("failed to claim job", zap.String("job", name), zap.Error(err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		lease, err := s.locker.TryLock(ctx, lockPrefix+name, lockTTL)
+		if err != nil {
+			s.logger.Error("failed to lock job", zap.String("job", name), zap.Error(err))
+			continue
+		}
+		if lease == nil {
+			// The slot is skipped rather than queued behind the run
+			s.logger.Info("job still running, skipping this run", zap.String("job", name))
+			continue
+		}
+		s.start(e, lease, schedulestore.TriggerSchedule, "")
+	}
+}
+
+// Trigger runs a job now, whether or not it is paused, recording by as
+// who ran it. The job runs in the background.
+func (s *Scheduler) Trigger(ctx context.Context, name, by string) error {
+	e, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if s.ctx == nil {
+		return ErrNotStarted
+	}
+	lease, err := s.locker.TryLock(ctx, lockPrefix+name, lockTTL)
+	if err != nil {
+		return err
+	}
+	if lease == nil {
+		return ErrRunning
+	}
+	s.logger.Info("job run by hand", zap.String("job", name), zap.String("by", by))
+	s.start(e, lease, schedulestore.TriggerManual, by)
+	return nil
+}
+
+// Running returns the jobs running on any instance, with the instance
+// each runs on.
+func (s *Scheduler) Running(ctx context.Context) (map[string]string, error) {
+	held, err := s.locker.Held(ctx)
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]string)
+	for lockName, l := range held {
+		if name, ok := strings.CutPrefix(lockName, lockPrefix); ok {
+			running[name] = l.Owner
+		}
+	}
+	return running, nil
+}
+
+// SetPaused pauses or resumes a job, recording by as who paused it.
+func (s *Scheduler) SetPaused(ctx context.Context, name string, paused bool, by string) error {
+	e, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	if err := s.store.SetPaused(ctx, name, paused, by, e.spec.Next(time.Now())); err != nil {
+		return err
+	}
+	s.logger.Info("job schedule changed",
+		zap.String("job", name),
+		zap.Bool("paused", paused),
+		zap.String("by", by))
+	return nil
+}
+
+// start runs a locked job in the background.
+func (s *Scheduler) start(e entry, lease *distlock.Lease, trigger, by string) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(e, lease, trigger, by)
+	}()
+}
+
+// run runs a job, then records the run and releases the job's lock. The
+// job is cancelled if the lock is lost, since another instance may then
+// start it.
+func (s *Scheduler) run(e entry, lease *distlock.Lease, trigger, by string) {
+	name := e.job.Name
+	start := time.Now()
+	s.logger.Debug("job starting", zap.String("job", name), zap.String("trigger", trigger))
+
+	jobCtx, cancel := context.WithCancel(s.ctx)
+	go func() {
+		select {
+		case <-lease.Lost():
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+	err := e.job.Run(jobCtx)
+	cancel()
+
+	run := schedulestore.Run{
+		Job:         name,
+		Trigger:     trigger,
+		TriggeredBy: by,
+		Instance:    distlock.Instance(),
+		StartedAt:   start,
+		FinishedAt:  time.Now(),
+		DurationMs:  time.Since(start).Milliseconds(),
+		Status:      schedulestore.StatusSucceeded,
+	}
+	switch {
+	case err != nil && s.ctx.Err() != nil:
+		// Don't log cancellation as an error during shutdown
+		run.Status = schedulestore.StatusCancelled
+		run.Error = err.Error()
+		s.logger.Debug("job cancelled during shutdown", zap.String("job", name))
+	case err != nil:
+		run.Status = schedulestore.StatusFailed
+		run.Error = err.Error()
+		s.logger.Error("job failed",
+			zap.String("job", name),
+			zap.Duration("duration", run.FinishedAt.Sub(start)),
+			zap.Error(err))
+	default:
+		s.logger.Debug("job completed",
+			zap.String("job", name),
+			zap.Duration("duration", run.FinishedAt.Sub(start)))
+	}
+
+	ctx, cancelFinish := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFinish()
+	if err := s.store.Finish(ctx, run); err != nil {
+		s.logger.Error("failed to record job run", zap.String("job", name), zap.Error(err))
+	}
+	if err := lease.Release(ctx); err != nil {
+		s.logger.Error("failed to unlock job", zap.String("job", name), zap.Error(err))
+	}
+}
+
+// cleanupRuns deletes run history older than the retention.
+func (s *Scheduler) cleanupRuns(ctx context.Context) error {
+	deleted, err := s.store.DeleteRunsBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.logger.Info("removed old scheduled job runs", zap.Int64("count", deleted))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.uber.org/zap"
+)
+
+func TestRegister(t *testing.T) {
+	s := &Scheduler{logger: zap.NewNop(), jobs: make(map[string]entry)}
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Register(tasks.Job{Name: "session-cleanup", Schedule: "*/5 * * * *", Run: noop}); err != nil {
+		t.Fatalf("Register() cron job error = %v", err)
+	}
+	if err := s.Register(tasks.Job{Name: "archive", Interval: time.Hour, Run: noop}); err != nil {
+		t.Fatalf("Register() interval job error = %v", err)
+	}
+	if err := s.Register(tasks.Job{Name: "archive", Interval: time.Hour, Run: noop}); err == nil {
+		t.Error("Register() of a duplicate name succeeded, want an error")
+	}
+	if err := s.Register(tasks.Job{Name: "broken", Schedule: "61 * * * *", Run: noop}); err == nil {
+		t.Error("Register() with a bad schedule succeeded, want an error")
+	}
+	if err := s.Register(tasks.Job{Name: "no-interval", Run: noop}); err == nil {
+		t.Error("Register() with no schedule or interval succeeded, want an error")
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 || jobs[0].Name != "archive" || jobs[1].Name != "session-cleanup" {
+		t.Fatalf("Jobs() = %+v, want archive and session-cleanup in name order", jobs)
+	}
+	if got := jobs[0].Spec.String(); got != "@every 1h0m0s" {
+		t.Errorf("interval job spec = %q, want @every 1h0m0s", got)
+	}
+	if _, ok := s.Lookup("broken"); ok {
+		t.Error("Lookup() found a job that failed to register")
+	}
+}
+
+func TestTrigger_NotStarted(t *testing.T) {
+	s := &Scheduler{logger: zap.NewNop(), jobs: make(map[string]entry)}
+	_ = s.Register(tasks.Job{Name: "archive", Interval: time.Hour, Run: func(context.Context) error { return nil }})
+
+	if err := s.Trigger(t.Context(), "missing", "ann"); err != ErrUnknownJob {
+		t.Errorf("Trigger() of an unknown job = %v, want ErrUnknownJob", err)
+	}
+	if err := s.Trigger(t.Context(), "archive", "ann"); err != ErrNotStarted {
+		t.Errorf("Trigger() before Start = %v, want ErrNotStarted", err)
+	}
+}
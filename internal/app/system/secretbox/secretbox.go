@@ -0,0 +1,71 @@
+// Package secretbox encrypts secrets, such as the SMTP password, that are
+// stored in the database with other editable settings.
+//
+// Values are sealed with AES-256-GCM under a key derived from the
+// settings_encryption_key config value, and stored as base64 text.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrNoKey is returned by New when no key is configured.
+var ErrNoKey = errors.New("secretbox: no encryption key configured")
+
+// ErrInvalid is returned by Open for values that weren't sealed with the
+// box's key, such as after the key is changed.
+var ErrInvalid = errors.New("secretbox: value can't be decrypted with this key")
+
+// Box seals and opens secrets with one key.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a box keyed by key, which may be any non-empty string; it is
+// hashed to the 32 bytes AES-256 needs.
+func New(key string) (*Box, error) {
+	if key == "" {
+		return nil, ErrNoKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("secretbox: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext. Sealing the same value twice gives different
+// results.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("secretbox: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value returned by Seal.
+func (b *Box) Open(sealed string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return "", ErrInvalid
+	}
+	nonce, ciphertext := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,45 @@
+package secretbox
+
+import "testing"
+
+func TestSealOpen(t *testing.T) {
+	box, err := New("test-key")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sealed, err := box.Seal("smtp-password")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if sealed == "smtp-password" {
+		t.Fatal("Seal() returned the plaintext")
+	}
+	again, _ := box.Seal("smtp-password")
+	if again == sealed {
+		t.Error("Seal() gave the same result twice")
+	}
+
+	got, err := box.Open(sealed)
+	if err != nil || got != "smtp-password" {
+		t.Errorf("Open() = %q, %v, want smtp-password", got, err)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	box, _ := New("test-key")
+	other, _ := New("other-key")
+	sealed, _ := other.Seal("secret")
+
+	for _, v := range []string{sealed, "not base64!", "", "YWJj"} {
+		if _, err := box.Open(v); err != ErrInvalid {
+			t.Errorf("Open(%q) error = %v, want ErrInvalid", v, err)
+		}
+	}
+}
+
+func TestNew_NoKey(t *testing.T) {
+	if _, err := New(""); err != ErrNoKey {
+		t.Errorf("New(\"\") error = %v, want ErrNoKey", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package sessionrotate invalidates a user's sessions after a change to
+// their privileges or credentials: a role change, a password reset or
+// change, or a new set of backup codes. Any session token issued before the
+// change, including one copied by an attacker, stops working.
+//
+// When the change is made by the user themselves, their current session is
+// rotated onto a new token rather than ended, so they stay signed in.
+package sessionrotate
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Rotator invalidates sessions. A nil Rotator is valid and does nothing.
+type Rotator struct {
+	users      *userstore.Store
+	sessions   *sessions.Store
+	sessionMgr *auth.SessionManager
+	logger     *zap.Logger
+}
+
+// New creates a Rotator.
+func New(db *mongo.Database, sessionMgr *auth.SessionManager, logger *zap.Logger) *Rotator {
+	return &Rotator{
+		users:      userstore.New(db),
+		sessions:   sessions.New(db),
+		sessionMgr: sessionMgr,
+		logger:     logger,
+	}
+}
+
+// Rotate invalidates every session userID has. If r is from userID's own
+// session (and not an impersonation), that session is given a new token in
+// both the cookie and the sessions collection and stays signed in; all
+// others are closed.
+func (rt *Rotator) Rotate(w http.ResponseWriter, r *http.Request, userID primitive.ObjectID) error {
+	if rt == nil {
+		return nil
+	}
+	ctx := r.Context()
+
+	// Millisecond precision matches what MongoDB stores
+	now := time.Now().Truncate(time.Millisecond)
+	if err := rt.users.InvalidateSessions(ctx, userID, now); err != nil {
+		return err
+	}
+
+	cur, ok := auth.CurrentUser(r)
+	if !ok || cur.UserID() != userID || cur.IsImpersonated() {
+		return rt.sessions.CloseByUser(ctx, userID, sessions.EndReasonRotated)
+	}
+
+	token, err := auth.GenerateSessionToken()
+	if err != nil {
+		return err
+	}
+	if err := rt.sessionMgr.RotateSession(w, r, token, now); err != nil {
+		return err
+	}
+	// Tracking is best effort, as at login
+	if err := rt.sessions.RotateToken(ctx, cur.SessionToken(), token); err != nil {
+		rt.logger.Warn("failed to rotate tracked session", zap.String("user_id", userID.Hex()), zap.Error(err))
+	}
+	return rt.sessions.CloseByUserExcept(ctx, userID, token, sessions.EndReasonRotated)
+}
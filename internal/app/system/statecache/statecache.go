@@ -0,0 +1,198 @@
+// Package statecache caches recent game state load responses in memory.
+//
+// Games that poll their saved state read the same few documents over and
+// over. The cache holds the encoded response for each user_id, game, and
+// limit, and the entry for a user and game is dropped whenever their states
+// change, so a load after a save always sees the new state.
+//
+// The cache is local to one process. With several instances behind a load
+// balancer, a save handled by one instance does not invalidate the others,
+// so they may serve the previous state until their entries expire; keep the
+// TTL short in that deployment.
+package statecache
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries is the default number of user/game pairs kept.
+const DefaultMaxEntries = 10000
+
+// Cache is an LRU cache of load responses with a fixed TTL.
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // key(userID, game) -> element holding *entry
+	lru     *list.List               // front = most recently used
+
+	// seq increases on every invalidation. Loads record it before reading
+	// the database and Set refuses to store a result if the key was
+	// invalidated after that point, so a load racing with a save can't
+	// cache the state from before the save.
+	seq uint64
+
+	// evictedSeq is the highest invalidation seq of any entry evicted from
+	// the LRU, used in place of the lost per-entry value.
+	evictedSeq uint64
+}
+
+type entry struct {
+	key           string
+	game          string
+	invalidatedAt uint64
+	responses     map[int64]cached // limit -> response
+}
+
+type cached struct {
+	body    []byte
+	expires time.Time
+}
+
+// New creates a cache. Returns nil if ttl is zero or less, which disables
+// caching. A maxEntries of zero or less uses DefaultMaxEntries.
+func New(ttl time.Duration, maxEntries int) *Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func key(userID, game string) string {
+	return strconv.Itoa(len(userID)) + ":" + userID + game
+}
+
+// Get returns the cached response for a load. When there is no fresh
+// response, it returns a sequence number to pass to Set once the response
+// has been read from the database.
+func (c *Cache) Get(userID, game string, limit int64) (body []byte, seq uint64, ok bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, found := c.entries[key(userID, game)]; found {
+		e := el.Value.(*entry)
+		if r, hit := e.responses[limit]; hit {
+			if c.now().Before(r.expires) {
+				c.lru.MoveToFront(el)
+				return r.body, c.seq, true
+			}
+			delete(e.responses, limit)
+		}
+	}
+	return nil, c.seq, false
+}
+
+// Set stores the response for a load. seq is the value returned by the Get
+// that missed; the response is discarded if the user's states changed since.
+func (c *Cache) Set(userID, game string, limit int64, seq uint64, body []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := key(userID, game)
+	el, found := c.entries[k]
+	if found {
+		if el.Value.(*entry).invalidatedAt > seq {
+			return
+		}
+		c.lru.MoveToFront(el)
+	} else {
+		if c.evictedSeq > seq {
+			return
+		}
+		el = c.lru.PushFront(&entry{key: k, game: game, responses: make(map[int64]cached)})
+		c.entries[k] = el
+		for c.lru.Len() > c.maxEntries {
+			c.evict(c.lru.Back())
+		}
+	}
+	el.Value.(*entry).responses[limit] = cached{body: body, expires: c.now().Add(c.ttl)}
+}
+
+// Invalidate drops the cached responses for a user and game. Call it after
+// any write to their states.
+func (c *Cache) Invalidate(userID, game string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	k := key(userID, game)
+	el, found := c.entries[k]
+	if !found {
+		// Keep a marker so an in-flight load for this key isn't cached
+		el = c.lru.PushFront(&entry{key: k, game: game, responses: make(map[int64]cached)})
+		c.entries[k] = el
+		for c.lru.Len() > c.maxEntries {
+			c.evict(c.lru.Back())
+		}
+	}
+	e := el.Value.(*entry)
+	e.invalidatedAt = c.seq
+	clear(e.responses)
+}
+
+// InvalidateGame drops the cached responses for every user of a game. Use it
+// when a write's user is not known.
+func (c *Cache) InvalidateGame(game string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	// Entries for this game are evicted outright; recording the seq as
+	// evicted stops in-flight loads for any key from being cached, which
+	// is conservative but rare
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*entry).game == game {
+			c.evict(el)
+		}
+		el = next
+	}
+	c.evictedSeq = c.seq
+}
+
+// evict removes an element. Caller must hold mu.
+func (c *Cache) evict(el *list.Element) {
+	e := el.Value.(*entry)
+	if e.invalidatedAt > c.evictedSeq {
+		c.evictedSeq = e.invalidatedAt
+	}
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+}
+
+// Len returns the number of user/game pairs held.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,130 @@
+package statecache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNew_Disabled(t *testing.T) {
+	c := New(0, 10)
+	if c != nil {
+		t.Fatal("New(0) should return nil")
+	}
+	// A nil cache is usable and never hits
+	_, seq, _ := c.Get("u", "g", 1)
+	c.Set("u", "g", 1, seq, []byte("x"))
+	if _, _, ok := c.Get("u", "g", 1); ok {
+		t.Error("nil cache should never hit")
+	}
+	c.Invalidate("u", "g")
+	c.InvalidateGame("g")
+}
+
+func TestCache_GetSet(t *testing.T) {
+	c := New(time.Minute, 10)
+
+	_, seq, ok := c.Get("u1", "g1", 1)
+	if ok {
+		t.Fatal("empty cache should miss")
+	}
+	c.Set("u1", "g1", 1, seq, []byte("one"))
+
+	body, _, ok := c.Get("u1", "g1", 1)
+	if !ok || string(body) != "one" {
+		t.Errorf("Get() = %q, %v; want \"one\", true", body, ok)
+	}
+	if _, _, ok := c.Get("u1", "g1", 3); ok {
+		t.Error("different limit should miss")
+	}
+	if _, _, ok := c.Get("u1", "g2", 1); ok {
+		t.Error("different game should miss")
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := New(time.Minute, 10)
+	c.now = func() time.Time { return now }
+
+	_, seq, _ := c.Get("u", "g", 1)
+	c.Set("u", "g", 1, seq, []byte("x"))
+
+	now = now.Add(2 * time.Minute)
+	if _, _, ok := c.Get("u", "g", 1); ok {
+		t.Error("expired response should miss")
+	}
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := New(time.Minute, 10)
+
+	_, seq, _ := c.Get("u", "g", 1)
+	c.Set("u", "g", 1, seq, []byte("old"))
+	c.Invalidate("u", "g")
+
+	if _, _, ok := c.Get("u", "g", 1); ok {
+		t.Error("invalidated response should miss")
+	}
+}
+
+func TestCache_SetAfterInvalidateIsDiscarded(t *testing.T) {
+	c := New(time.Minute, 10)
+
+	// Load misses and reads the database...
+	_, seq, _ := c.Get("u", "g", 1)
+	// ...while a save lands and invalidates...
+	c.Invalidate("u", "g")
+	// ...so the load's (now stale) result must not be cached
+	c.Set("u", "g", 1, seq, []byte("stale"))
+
+	if _, _, ok := c.Get("u", "g", 1); ok {
+		t.Error("result read before an invalidation should not be cached")
+	}
+}
+
+func TestCache_InvalidateGame(t *testing.T) {
+	c := New(time.Minute, 10)
+
+	_, seq, _ := c.Get("u1", "g1", 1)
+	c.Set("u1", "g1", 1, seq, []byte("a"))
+	c.Set("u2", "g1", 1, seq, []byte("b"))
+	c.Set("u1", "g2", 1, seq, []byte("c"))
+
+	c.InvalidateGame("g1")
+
+	if _, _, ok := c.Get("u1", "g1", 1); ok {
+		t.Error("u1/g1 should be invalidated")
+	}
+	if _, _, ok := c.Get("u2", "g1", 1); ok {
+		t.Error("u2/g1 should be invalidated")
+	}
+	if _, _, ok := c.Get("u1", "g2", 1); !ok {
+		t.Error("u1/g2 should still be cached")
+	}
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	c := New(time.Minute, 2)
+
+	_, seq, _ := c.Get("a", "g", 1)
+	c.Set("a", "g", 1, seq, []byte("a"))
+	c.Set("b", "g", 1, seq, []byte("b"))
+	c.Get("a", "g", 1) // a is now most recently used
+	c.Set("c", "g", 1, seq, []byte("c"))
+
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+	if _, _, ok := c.Get("b", "g", 1); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if _, _, ok := c.Get("a", "g", 1); !ok {
+		t.Error("recently used entry should be kept")
+	}
+}
+
+func TestKey_NoCollisions(t *testing.T) {
+	if key("ab", "c") == key("a", "bc") {
+		t.Error("keys for different user/game pairs collide")
+	}
+}
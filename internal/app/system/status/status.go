@@ -9,11 +9,15 @@ package status
 const (
 	Active   = "active"
 	Disabled = "disabled"
+	Pending  = "pending" // Self-registered, awaiting admin approval
+	Deleted  = "deleted" // Deleted by an admin; restorable until purged
 )
 
-// IsValid returns true if s is a recognized status value.
+// IsValid returns true if s is a status a user can be created or updated
+// with. Deleted is not one: users are deleted with the users store's
+// SoftDelete, which records when and by whom.
 func IsValid(s string) bool {
-	return s == Active || s == Disabled
+	return s == Active || s == Disabled || s == Pending
 }
 
 // Default returns the default status for new entities.
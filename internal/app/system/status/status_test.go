@@ -11,9 +11,10 @@ func TestIsValid(t *testing.T) {
 		{Disabled, true},
 		{"active", true},
 		{"disabled", true},
+		{Pending, true},
+		{Deleted, false},
 		{"ACTIVE", false},
 		{"DISABLED", false},
-		{"pending", false},
 		{"inactive", false},
 		{"", false},
 		{"unknown", false},
@@ -43,4 +44,10 @@ func TestConstants(t *testing.T) {
 	if Disabled != "disabled" {
 		t.Errorf("Disabled = %q, want 'disabled'", Disabled)
 	}
+	if Pending != "pending" {
+		t.Errorf("Pending = %q, want 'pending'", Pending)
+	}
+	if Deleted != "deleted" {
+		t.Errorf("Deleted = %q, want 'deleted'", Deleted)
+	}
 }
@@ -0,0 +1,367 @@
+// Package suspicious watches logins for signs of attack and raises
+// security alerts.
+//
+// Two rules are checked:
+//
+//   - Failed logins: failed logins for many different accounts from one IP
+//     address within a window, as in password spraying or credential
+//     stuffing. The IP can be blocked from logging in for a while.
+//   - Impossible travel: two logins by the same user from places too far
+//     apart to travel between in the time between them. Locations come
+//     from geolocation headers set by a CDN or proxy in front of the site,
+//     so this rule is off unless those headers are configured.
+//
+// Each alert is recorded in the audit log under the "security" category
+// and emailed to administrators.
+package suspicious
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/audit"
+	ipblockstore "github.com/dalemusser/stratasave/internal/app/store/ipblocks"
+	loginfailurestore "github.com/dalemusser/stratasave/internal/app/store/loginfailures"
+	loginlocationstore "github.com/dalemusser/stratasave/internal/app/store/loginlocations"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/auditlog"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/network"
+	"github.com/dalemusser/stratasave/internal/app/system/timeouts"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// Block reasons, stored on IP blocks.
+const (
+	ReasonFailedLogins = "failed_logins"
+)
+
+// earthRadiusKm is the mean radius of the Earth, for great-circle distances.
+const earthRadiusKm = 6371.0
+
+// minTravelDistanceKm is the shortest distance the impossible travel rule
+// considers. Geolocation is imprecise, so nearby logins are never flagged.
+const minTravelDistanceKm = 100.0
+
+// Config holds the detection rules. A zero value for a rule's threshold
+// turns that rule off.
+type Config struct {
+	// FailedLoginAccounts is how many different accounts must fail to log
+	// in from one IP within FailedLoginWindow to raise an alert.
+	FailedLoginAccounts int
+	FailedLoginWindow   time.Duration
+
+	// BlockDuration is how long an IP that trips the failed login rule is
+	// blocked from logging in. Zero only alerts.
+	BlockDuration time.Duration
+
+	// TravelSpeedKmh is the fastest believable travel speed between two
+	// logins by one user. Faster raises an alert.
+	TravelSpeedKmh float64
+
+	// LatitudeHeader and LongitudeHeader name the request headers the
+	// proxy puts the client's location in, e.g.
+	// "CloudFront-Viewer-Latitude". Both must be set for the impossible
+	// travel rule to run.
+	LatitudeHeader  string
+	LongitudeHeader string
+
+	// Recipients receive alert emails. If empty, every active admin with
+	// an email address is notified.
+	Recipients []string
+
+	// BaseURL is used to link alert emails to the audit log.
+	BaseURL string
+}
+
+// Detector applies the detection rules to logins. A nil Detector is valid
+// and detects nothing.
+type Detector struct {
+	blocks    *ipblockstore.Store
+	failures  *loginfailurestore.Store
+	locations *loginlocationstore.Store
+	users     *userstore.Store
+	settings  *settingsstore.Store
+	mail      *mailer.Mailer
+	audit     *auditlog.Logger
+	cfg       Config
+	logger    *zap.Logger
+}
+
+// New creates a Detector. It returns nil if every rule is off. mail may be
+// nil, in which case alerts are audited but not emailed.
+func New(db *mongo.Database, mail *mailer.Mailer, audit *auditlog.Logger, cfg Config, logger *zap.Logger) *Detector {
+	d := &Detector{
+		blocks:    ipblockstore.New(db),
+		failures:  loginfailurestore.New(db),
+		locations: loginlocationstore.New(db),
+		users:     userstore.New(db),
+		settings:  settingsstore.New(db),
+		mail:      mail,
+		audit:     audit,
+		cfg:       cfg,
+		logger:    logger,
+	}
+	if !d.failedLoginsEnabled() && !d.travelEnabled() {
+		return nil
+	}
+	return d
+}
+
+func (d *Detector) failedLoginsEnabled() bool {
+	return d.cfg.FailedLoginAccounts > 0 && d.cfg.FailedLoginWindow > 0
+}
+
+func (d *Detector) travelEnabled() bool {
+	return d.cfg.TravelSpeedKmh > 0 && d.cfg.LatitudeHeader != "" && d.cfg.LongitudeHeader != ""
+}
+
+// Blocked reports whether the client behind r is blocked from logging in,
+// and until when. Lookup errors are logged and let the login through.
+func (d *Detector) Blocked(r *http.Request) (bool, time.Time) {
+	if d == nil {
+		return false, time.Time{}
+	}
+	b, err := d.blocks.Active(r.Context(), network.GetClientIP(r))
+	if err != nil {
+		d.logger.Warn("failed to check ip block", zap.Error(err))
+		return false, time.Time{}
+	}
+	if b == nil {
+		return false, time.Time{}
+	}
+	return true, b.BlockedUntil
+}
+
+// LoginFailed records a failed login for loginID and raises an alert when
+// the client's IP has now failed for too many accounts. Failures are
+// logged; they never change the login response.
+func (d *Detector) LoginFailed(r *http.Request, loginID string) {
+	if d == nil || !d.failedLoginsEnabled() || strings.TrimSpace(loginID) == "" {
+		return
+	}
+	ctx := r.Context()
+	ip := network.GetClientIP(r)
+
+	if err := d.failures.Record(ctx, ip, loginID); err != nil {
+		d.logger.Warn("failed to record login failure", zap.String("ip", ip), zap.Error(err))
+		return
+	}
+	accounts, err := d.failures.Accounts(ctx, ip, time.Now().Add(-d.cfg.FailedLoginWindow))
+	if err != nil {
+		d.logger.Warn("failed to count login failures", zap.String("ip", ip), zap.Error(err))
+		return
+	}
+	if len(accounts) < d.cfg.FailedLoginAccounts {
+		return
+	}
+
+	// Start counting afresh, so the same attempts don't alert again
+	if err := d.failures.ClearIP(ctx, ip); err != nil {
+		d.logger.Warn("failed to clear login failures", zap.String("ip", ip), zap.Error(err))
+	}
+
+	details := map[string]string{
+		"accounts": strconv.Itoa(len(accounts)),
+		"window":   d.cfg.FailedLoginWindow.String(),
+	}
+	lines := []string{
+		"IP address: " + ip,
+		fmt.Sprintf("Accounts tried: %d in %s", len(accounts), d.cfg.FailedLoginWindow),
+	}
+
+	if d.cfg.BlockDuration > 0 {
+		until := time.Now().Add(d.cfg.BlockDuration)
+		if err := d.blocks.Block(ctx, ip, ReasonFailedLogins, until); err != nil {
+			d.logger.Error("failed to block ip", zap.String("ip", ip), zap.Error(err))
+		} else {
+			details["blocked_until"] = until.UTC().Format(time.RFC3339)
+			lines = append(lines, "Blocked until: "+until.UTC().Format("Jan 2, 2006 15:04 MST"))
+			d.audit.LogSecurityEvent(r, nil, audit.EventIPBlocked, map[string]string{
+				"reason":        ReasonFailedLogins,
+				"blocked_until": details["blocked_until"],
+			})
+		}
+	}
+
+	d.audit.LogSecurityEvent(r, nil, audit.EventSuspiciousFailedLogins, details)
+	go d.alert("Suspicious Login Activity",
+		"Failed logins for many different accounts came from one IP address.",
+		lines, audit.EventSuspiciousFailedLogins)
+}
+
+// LoginSucceeded checks a successful login by userID against the user's
+// last known location, raising an alert if they couldn't have travelled
+// between the two in time. Failures are logged; they never block the login.
+func (d *Detector) LoginSucceeded(r *http.Request, userID primitive.ObjectID) {
+	if d == nil || !d.travelEnabled() {
+		return
+	}
+	lat, lon, ok := d.location(r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	now := time.Now().UTC()
+	ip := network.GetClientIP(r)
+
+	prev, err := d.locations.Last(ctx, userID)
+	if err != nil {
+		d.logger.Warn("failed to load last login location", zap.String("user_id", userID.Hex()), zap.Error(err))
+		return
+	}
+	if err := d.locations.Set(ctx, loginlocationstore.Location{
+		UserID: userID, Latitude: lat, Longitude: lon, IP: ip, At: now,
+	}); err != nil {
+		d.logger.Warn("failed to record login location", zap.String("user_id", userID.Hex()), zap.Error(err))
+	}
+	if prev == nil {
+		return
+	}
+
+	distance := DistanceKm(prev.Latitude, prev.Longitude, lat, lon)
+	elapsed := now.Sub(prev.At)
+	if !Impossible(distance, elapsed, d.cfg.TravelSpeedKmh) {
+		return
+	}
+
+	details := map[string]string{
+		"distance_km": strconv.Itoa(int(distance)),
+		"elapsed":     elapsed.Round(time.Minute).String(),
+		"previous_ip": prev.IP,
+	}
+	d.audit.LogSecurityEvent(r, &userID, audit.EventImpossibleTravel, details)
+
+	name := userID.Hex()
+	if u, err := d.users.GetByID(ctx, userID); err == nil {
+		name = u.FullName
+	}
+	go d.alert("Impossible Travel Detected",
+		fmt.Sprintf("%s logged in from two places too far apart to travel between in the time between the logins.", name),
+		[]string{
+			"Distance: " + strconv.Itoa(int(distance)) + " km",
+			"Time between logins: " + elapsed.Round(time.Minute).String(),
+			"Previous IP address: " + prev.IP,
+			"New IP address: " + ip,
+		}, audit.EventImpossibleTravel)
+}
+
+// location reads the client's coordinates from the geolocation headers.
+func (d *Detector) location(r *http.Request) (lat, lon float64, ok bool) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(r.Header.Get(d.cfg.LatitudeHeader)), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(r.Header.Get(d.cfg.LongitudeHeader)), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// DistanceKm returns the great-circle distance between two points, in
+// kilometres.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Impossible reports whether covering distanceKm in elapsed would take a
+// speed above maxKmh. Short distances are never impossible, since
+// geolocation is only accurate to a city or so.
+func Impossible(distanceKm float64, elapsed time.Duration, maxKmh float64) bool {
+	if distanceKm < minTravelDistanceKm {
+		return false
+	}
+	if elapsed <= 0 {
+		return true
+	}
+	return distanceKm/elapsed.Hours() > maxKmh
+}
+
+// alert emails an alert to the recipients. It is meant to run in the
+// background.
+func (d *Detector) alert(heading, message string, details []string, eventType string) {
+	if d.mail == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeouts.Medium())
+	defer cancel()
+
+	recipients := d.recipients(ctx)
+	if len(recipients) == 0 {
+		d.logger.Warn("security alert not sent: no recipients", zap.String("heading", heading))
+		return
+	}
+
+	appName := models.DefaultSiteName
+	if s, err := d.settings.Get(ctx); err == nil && s.SiteName != "" {
+		appName = s.SiteName
+	}
+
+	text, html := mailer.SecurityAlertEmail(mailer.SecurityAlertEmailData{
+		Brand:   d.mail.Brand(ctx),
+		AppName: appName,
+		Heading: heading,
+		Message: message,
+		Details: details,
+		ReviewURL: strings.TrimRight(d.cfg.BaseURL, "/") + "/audit?category=" + audit.CategorySecurity +
+			"&event_type=" + url.QueryEscape(eventType),
+	})
+	emails := make([]mailer.Email, len(recipients))
+	for i, to := range recipients {
+		emails[i] = mailer.Email{
+			To:       to,
+			Subject:  "[" + appName + "] " + heading,
+			Template: "security_alert",
+			TextBody: text,
+			HTMLBody: html,
+		}
+	}
+	// Not bound to ctx: throttled sends to a long admin list can outlast it
+	p, _ := d.mail.SendBulk(context.Background(), emails, nil)
+	if p.Failed > 0 {
+		d.logger.Error("failed to send some security alerts",
+			zap.String("heading", heading),
+			zap.Int("failed", p.Failed),
+			zap.Int("total", p.Total))
+	}
+}
+
+// recipients returns the configured recipients, or all active admin emails.
+func (d *Detector) recipients(ctx context.Context) []string {
+	if len(d.cfg.Recipients) > 0 {
+		return d.cfg.Recipients
+	}
+	admins, err := d.users.Find(ctx, bson.M{
+		"role":   models.RoleAdmin,
+		"status": "active",
+		"email":  bson.M{"$nin": []any{nil, ""}},
+	})
+	if err != nil {
+		d.logger.Error("failed to list admins for security alert", zap.Error(err))
+		return nil
+	}
+	var out []string
+	for _, u := range admins {
+		if u.Email != nil {
+			out = append(out, *u.Email)
+		}
+	}
+	return out
+}
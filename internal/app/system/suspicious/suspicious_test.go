@@ -0,0 +1,112 @@
+package suspicious
+
+import (
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same point", 40.0, -74.0, 40.0, -74.0, 0},
+		{"new york to london", 40.7128, -74.0060, 51.5074, -0.1278, 5570},
+		{"across the date line", 0, 179.5, 0, -179.5, 111},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DistanceKm(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			if math.Abs(got-tt.want) > 10 {
+				t.Errorf("DistanceKm() = %.0f, want about %.0f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpossible(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance float64
+		elapsed  time.Duration
+		want     bool
+	}{
+		{"nearby at once", 50, 0, false},
+		{"far at once", 5000, 0, true},
+		{"far in an hour", 5000, time.Hour, true},
+		{"far in a day", 5000, 24 * time.Hour, false},
+		{"just over the speed", 1010, time.Hour, true},
+		{"just under the speed", 990, time.Hour, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Impossible(tt.distance, tt.elapsed, 1000); got != tt.want {
+				t.Errorf("Impossible(%v, %v, 1000) = %v, want %v", tt.distance, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	d := &Detector{cfg: Config{LatitudeHeader: "X-Lat", LongitudeHeader: "X-Lon"}}
+
+	r := httptest.NewRequest("POST", "/login", nil)
+	r.Header.Set("X-Lat", " 51.5 ")
+	r.Header.Set("X-Lon", "-0.12")
+	lat, lon, ok := d.location(r)
+	if !ok || lat != 51.5 || lon != -0.12 {
+		t.Errorf("location() = %v, %v, %v; want 51.5, -0.12, true", lat, lon, ok)
+	}
+
+	r.Header.Set("X-Lat", "95")
+	if _, _, ok := d.location(r); ok {
+		t.Error("location() accepted an out-of-range latitude")
+	}
+
+	r.Header.Del("X-Lat")
+	if _, _, ok := d.location(r); ok {
+		t.Error("location() accepted a missing header")
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	var d *Detector
+	r := httptest.NewRequest("POST", "/login", nil)
+
+	if blocked, _ := d.Blocked(r); blocked {
+		t.Error("nil Detector reported a block")
+	}
+	d.LoginFailed(r, "someone@example.com")
+	d.LoginSucceeded(r, primitive.NewObjectID())
+}
+
+func TestRulesEnabled(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          Config
+		failedLogins bool
+		travel       bool
+	}{
+		{"all off", Config{}, false, false},
+		{"failed logins", Config{FailedLoginAccounts: 10, FailedLoginWindow: 15 * time.Minute}, true, false},
+		{"failed logins without window", Config{FailedLoginAccounts: 10}, false, false},
+		{"travel", Config{TravelSpeedKmh: 1000, LatitudeHeader: "X-Lat", LongitudeHeader: "X-Lon"}, false, true},
+		{"travel without headers", Config{TravelSpeedKmh: 1000}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Detector{cfg: tt.cfg}
+			if got := d.failedLoginsEnabled(); got != tt.failedLogins {
+				t.Errorf("failedLoginsEnabled() = %v, want %v", got, tt.failedLogins)
+			}
+			if got := d.travelEnabled(); got != tt.travel {
+				t.Errorf("travelEnabled() = %v, want %v", got, tt.travel)
+			}
+		})
+	}
+}
@@ -10,33 +10,12 @@ import (
 	"go.uber.org/zap"
 )
 
-// SessionCleanupJob creates a job that removes expired sessions from the database.
-func SessionCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
-	return Job{
-		Name:     "session-cleanup",
-		Interval: 1 * time.Hour,
-		Run: func(ctx context.Context) error {
-			coll := db.Collection("sessions")
-			result, err := coll.DeleteMany(ctx, bson.M{
-				"expires_at": bson.M{"$lt": time.Now()},
-			})
-			if err != nil {
-				return err
-			}
-			if result.DeletedCount > 0 {
-				logger.Info("cleaned up expired sessions",
-					zap.Int64("deleted", result.DeletedCount))
-			}
-			return nil
-		},
-	}
-}
-
 // InvitationCleanupJob creates a job that removes expired and used invitations.
 func InvitationCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
 	return Job{
 		Name:     "invitation-cleanup",
 		Interval: 6 * time.Hour,
+		Schedule: "15 */6 * * *",
 		Run: func(ctx context.Context) error {
 			coll := db.Collection("invitations")
 
@@ -60,33 +39,12 @@ func InvitationCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
 	}
 }
 
-// PasswordResetCleanupJob creates a job that removes expired password reset tokens.
-func PasswordResetCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
-	return Job{
-		Name:     "password-reset-cleanup",
-		Interval: 1 * time.Hour,
-		Run: func(ctx context.Context) error {
-			coll := db.Collection("password_resets")
-			result, err := coll.DeleteMany(ctx, bson.M{
-				"expires_at": bson.M{"$lt": time.Now()},
-			})
-			if err != nil {
-				return err
-			}
-			if result.DeletedCount > 0 {
-				logger.Info("cleaned up expired password reset tokens",
-					zap.Int64("deleted", result.DeletedCount))
-			}
-			return nil
-		},
-	}
-}
-
 // OAuthStateCleanupJob creates a job that removes expired OAuth state tokens.
 func OAuthStateCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
 	return Job{
 		Name:     "oauth-state-cleanup",
 		Interval: 1 * time.Hour,
+		Schedule: "5 * * * *",
 		Run: func(ctx context.Context) error {
 			coll := db.Collection("oauth_states")
 			result, err := coll.DeleteMany(ctx, bson.M{
@@ -104,28 +62,6 @@ func OAuthStateCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
 	}
 }
 
-// EmailVerificationCleanupJob creates a job that removes expired email verification tokens.
-func EmailVerificationCleanupJob(db *mongo.Database, logger *zap.Logger) Job {
-	return Job{
-		Name:     "email-verification-cleanup",
-		Interval: 1 * time.Hour,
-		Run: func(ctx context.Context) error {
-			coll := db.Collection("email_verifications")
-			result, err := coll.DeleteMany(ctx, bson.M{
-				"expires_at": bson.M{"$lt": time.Now()},
-			})
-			if err != nil {
-				return err
-			}
-			if result.DeletedCount > 0 {
-				logger.Info("cleaned up expired email verification tokens",
-					zap.Int64("deleted", result.DeletedCount))
-			}
-			return nil
-		},
-	}
-}
-
 // InactiveSessionCleanupJob creates a job that closes sessions inactive for longer than
 // the specified threshold. This marks sessions as ended (with end_reason="inactive")
 // rather than deleting them, preserving session history for auditing.
@@ -133,6 +69,7 @@ func InactiveSessionCleanupJob(db *mongo.Database, logger *zap.Logger, threshold
 	return Job{
 		Name:     "inactive-session-cleanup",
 		Interval: 5 * time.Minute,
+		Schedule: "*/5 * * * *",
 		Run: func(ctx context.Context) error {
 			coll := db.Collection("sessions")
 			cutoff := time.Now().Add(-threshold)
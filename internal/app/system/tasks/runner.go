@@ -14,6 +14,7 @@ import (
 type Job struct {
 	Name     string
 	Interval time.Duration
+	Schedule string // Cron expression for the scheduler; when set, used instead of Interval
 	Run      func(ctx context.Context) error
 }
 
@@ -0,0 +1,61 @@
+// Package useragent turns browser user agent strings into the browser and
+// operating system they describe, for showing devices to users and admins.
+package useragent
+
+import "strings"
+
+// Info is the browser and operating system a user agent describes. Either
+// may be empty when it isn't recognized.
+type Info struct {
+	Browser string // e.g. "Chrome", "Safari"
+	OS      string // e.g. "Windows", "iPhone"
+}
+
+// Parse extracts the browser and operating system from a user agent.
+func Parse(userAgent string) Info {
+	ua := strings.ToLower(userAgent)
+
+	var info Info
+	switch {
+	case strings.Contains(ua, "edg/") || strings.Contains(ua, "edge"):
+		info.Browser = "Edge"
+	case strings.Contains(ua, "firefox") || strings.Contains(ua, "fxios"):
+		info.Browser = "Firefox"
+	case strings.Contains(ua, "chrome") || strings.Contains(ua, "crios"):
+		info.Browser = "Chrome"
+	case strings.Contains(ua, "safari"):
+		info.Browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(ua, "iphone"):
+		info.OS = "iPhone"
+	case strings.Contains(ua, "ipad"):
+		info.OS = "iPad"
+	case strings.Contains(ua, "android"):
+		info.OS = "Android"
+	case strings.Contains(ua, "windows"):
+		info.OS = "Windows"
+	case strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os"):
+		info.OS = "Mac"
+	case strings.Contains(ua, "cros"):
+		info.OS = "ChromeOS"
+	case strings.Contains(ua, "linux"):
+		info.OS = "Linux"
+	}
+
+	return info
+}
+
+// String returns a short description such as "Chrome on Windows".
+func (i Info) String() string {
+	switch {
+	case i.Browser != "" && i.OS != "":
+		return i.Browser + " on " + i.OS
+	case i.Browser != "":
+		return i.Browser
+	case i.OS != "":
+		return i.OS
+	}
+	return "Unknown device"
+}
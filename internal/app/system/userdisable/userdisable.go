@@ -0,0 +1,172 @@
+// Package userdisable disables user accounts on the date an admin scheduled
+// for them, for contractors and seasonal staff whose access should end on a
+// known day. Users are emailed a warning before the date and a notice when
+// their account is disabled.
+//
+// Disabling clears the schedule, so an admin can enable the account again
+// without it being disabled on the next check.
+package userdisable
+
+import (
+	"context"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/sessions"
+	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/mailer"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// dateFormat is how the disable date is written in emails.
+const dateFormat = "January 2, 2006"
+
+// Scheduler disables users whose scheduled disable date has arrived.
+type Scheduler struct {
+	users    *userstore.Store
+	sessions *sessions.Store
+	settings *settingsstore.Store
+	mail     *mailer.Mailer // nil if users aren't emailed
+	warning  time.Duration
+	logger   *zap.Logger
+}
+
+// New creates a Scheduler that warns users warning ahead of their disable
+// date. Users aren't emailed if mail is nil, and aren't warned if warning
+// is zero or less.
+func New(db *mongo.Database, mail *mailer.Mailer, warning time.Duration, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		users:    userstore.New(db),
+		sessions: sessions.New(db),
+		settings: settingsstore.New(db),
+		mail:     mail,
+		warning:  warning,
+		logger:   logger,
+	}
+}
+
+// Jobs returns the background job that sends warnings and disables users.
+func (s *Scheduler) Jobs() []tasks.Job {
+	return []tasks.Job{{
+		Name:     "user-scheduled-disable",
+		Interval: 1 * time.Hour,
+		Run:      s.run,
+	}}
+}
+
+// run warns users whose disable date is near, then disables those whose
+// date has arrived.
+func (s *Scheduler) run(ctx context.Context) error {
+	now := time.Now()
+
+	appName := models.DefaultSiteName
+	if st, err := s.settings.Get(ctx); err == nil && st.SiteName != "" {
+		appName = st.SiteName
+	}
+
+	if s.mail != nil && s.warning > 0 {
+		if err := s.warn(ctx, now, appName); err != nil {
+			return err
+		}
+	}
+	return s.disable(ctx, now, appName)
+}
+
+// warn sends one warning per user whose disable date is within the warning
+// period. Users whose date has already passed are marked without an email,
+// since they are about to be disabled.
+func (s *Scheduler) warn(ctx context.Context, now time.Time, appName string) error {
+	users, err := s.users.ListDisablingBefore(ctx, now.Add(s.warning))
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		u := &users[i]
+		// Mark first so a failing mail server doesn't cause repeated warnings
+		if err := s.users.MarkDisableWarned(ctx, u.ID); err != nil {
+			return err
+		}
+		if !u.DisableAt.After(now) || u.Email == nil || *u.Email == "" {
+			continue
+		}
+
+		text, html := mailer.AccountDisablingEmail(mailer.AccountDisablingEmailData{
+			Locale:    u.Locale,
+			Brand:     s.mail.Brand(ctx),
+			AppName:   appName,
+			UserName:  u.FullName,
+			DisableOn: u.DisableAt.UTC().Format(dateFormat),
+		})
+		if err := s.mail.Send(mailer.Email{
+			To:       *u.Email,
+			Subject:  mailer.T(u.Locale, "account_disabling.subject", appName),
+			Template: "account_disabling",
+			UserID:   u.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		}); err != nil {
+			s.logger.Warn("failed to send account disabling warning",
+				zap.String("user_id", u.ID.Hex()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// disable disables each user whose disable date has arrived, signs them
+// out, and emails them that their account is disabled.
+func (s *Scheduler) disable(ctx context.Context, now time.Time, appName string) error {
+	users, err := s.users.ListDisableDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	var disabled int
+	for i := range users {
+		u := &users[i]
+		ok, err := s.users.DisableScheduled(ctx, u.ID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue // rescheduled or disabled since it was listed
+		}
+		disabled++
+
+		// Disabled users are refused on their next request; this just
+		// closes the tracked sessions so they don't show as active
+		if err := s.sessions.CloseByUser(ctx, u.ID, sessions.EndReasonDisabled); err != nil {
+			s.logger.Warn("failed to close sessions of disabled user",
+				zap.String("user_id", u.ID.Hex()), zap.Error(err))
+		}
+
+		if s.mail == nil || u.Email == nil || *u.Email == "" {
+			continue
+		}
+		text, html := mailer.AccountDisabledEmail(mailer.AccountDisabledEmailData{
+			Locale:   u.Locale,
+			Brand:    s.mail.Brand(ctx),
+			AppName:  appName,
+			UserName: u.FullName,
+			Reason:   mailer.T(u.Locale, "account_disabled.reason_scheduled"),
+		})
+		if err := s.mail.Send(mailer.Email{
+			To:       *u.Email,
+			Subject:  mailer.T(u.Locale, "account_disabled.subject", appName),
+			Template: "account_disabled",
+			UserID:   u.ID.Hex(),
+			TextBody: text,
+			HTMLBody: html,
+		}); err != nil {
+			s.logger.Warn("failed to send account disabled notice",
+				zap.String("user_id", u.ID.Hex()), zap.Error(err))
+		}
+	}
+
+	if disabled > 0 {
+		s.logger.Info("disabled users on their scheduled date", zap.Int("users", disabled))
+	}
+	return nil
+}
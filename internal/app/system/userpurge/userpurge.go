@@ -0,0 +1,138 @@
+// Package userpurge permanently deletes user accounts that have been
+// deleted longer than the restore window, or that an admin deletes for good.
+//
+// Deleting a user in system users only marks the account deleted (see the
+// users store's SoftDelete), so it keeps its place in the audit log and can
+// be restored. The account, its group memberships, and its announcement
+// acknowledgements and dismissals, and their profile photo are removed here.
+package userpurge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/store/announcementack"
+	"github.com/dalemusser/stratasave/internal/app/store/announcementdismissal"
+	"github.com/dalemusser/stratasave/internal/app/store/groupmember"
+	userstore "github.com/dalemusser/stratasave/internal/app/store/users"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"github.com/dalemusser/waffle/pantry/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const purgeBatch = 200 // Users purged per query
+
+// Purger permanently deletes deleted users.
+type Purger struct {
+	users     *userstore.Store
+	members   *groupmember.Store
+	acks      *announcementack.Store
+	dismissed *announcementdismissal.Store
+	files     storage.Store
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// New creates a Purger that keeps deleted users restorable for retention
+// before purging them. A retention of zero or less keeps them until
+// deleted by hand.
+func New(db *mongo.Database, retention time.Duration, logger *zap.Logger) *Purger {
+	return &Purger{
+		users:     userstore.New(db),
+		members:   groupmember.New(db),
+		acks:      announcementack.New(db),
+		dismissed: announcementdismissal.New(db),
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// SetFileStorage sets the storage profile photos are deleted from. With
+// none set, photos are left in place.
+func (p *Purger) SetFileStorage(store storage.Store) {
+	p.files = store
+}
+
+// Retention returns how long deleted users can be restored (0 = until
+// deleted by hand).
+func (p *Purger) Retention() time.Duration {
+	if p.retention < 0 {
+		return 0
+	}
+	return p.retention
+}
+
+// Purge permanently deletes a user, removes them from their groups, and
+// deletes their announcement acknowledgements and dismissals and their
+// profile photo.
+func (p *Purger) Purge(ctx context.Context, id primitive.ObjectID) error {
+	avatarPath := ""
+	if p.files != nil {
+		if u, err := p.users.GetByID(ctx, id); err == nil {
+			avatarPath = u.AvatarPath
+		}
+	}
+	if err := p.members.DeleteByUser(ctx, id); err != nil {
+		return fmt.Errorf("removing group memberships: %w", err)
+	}
+	if err := p.acks.DeleteByUser(ctx, id); err != nil {
+		return fmt.Errorf("removing announcement acknowledgements: %w", err)
+	}
+	if err := p.dismissed.DeleteByUser(ctx, id); err != nil {
+		return fmt.Errorf("removing announcement dismissals: %w", err)
+	}
+	if _, err := p.users.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting user: %w", err)
+	}
+	if avatarPath != "" {
+		// The account is gone either way; a failure only leaves the file behind
+		if err := p.files.Delete(ctx, avatarPath); err != nil {
+			p.logger.Warn("failed to delete profile photo of purged user",
+				zap.String("user_id", id.Hex()), zap.String("path", avatarPath), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// Jobs returns the background job that purges users deleted longer than
+// the retention, or none when they are kept until deleted by hand.
+func (p *Purger) Jobs() []tasks.Job {
+	if p.Retention() == 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "user-purge",
+		Interval: 1 * time.Hour,
+		Run:      p.purge,
+	}}
+}
+
+// purge permanently deletes users deleted longer than the retention.
+func (p *Purger) purge(ctx context.Context) error {
+	cutoff := time.Now().Add(-p.retention)
+
+	var purged int
+	for {
+		users, err := p.users.ListDeletedBefore(ctx, cutoff, purgeBatch)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := p.Purge(ctx, u.ID); err != nil {
+				return err
+			}
+		}
+		purged += len(users)
+		if len(users) < purgeBatch {
+			break
+		}
+	}
+
+	if purged > 0 {
+		p.logger.Info("purged deleted users", zap.Int("users", purged))
+	}
+	return nil
+}
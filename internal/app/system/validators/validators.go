@@ -165,6 +165,10 @@ func usersSchema() bson.M {
 				"role":         bson.M{"enum": bson.A{"admin", "developer"}},
 				"status":       bson.M{"enum": bson.A{"active", "disabled"}},
 				"auth_method":  bson.M{"enum": bson.A{"google", "email", "password", "trust"}},
+				"linked_methods": bson.M{
+					"bsonType": "array",
+					"items":    bson.M{"enum": bson.A{"google", "email", "password", "trust"}},
+				},
 			},
 		},
 	}
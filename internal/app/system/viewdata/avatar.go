@@ -0,0 +1,54 @@
+package viewdata
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Avatar is a user's profile photo, for the "avatar" template component.
+// Users without a photo are shown their initials instead.
+type Avatar struct {
+	URL  string // Empty if the user has no photo
+	Name string // The user's full name
+}
+
+// NewAvatar returns the avatar for a user with the given name and stored
+// photo path (empty if they have none).
+func NewAvatar(name, path string) Avatar {
+	return Avatar{URL: AvatarURL(path), Name: name}
+}
+
+// AvatarURL returns the URL of a stored profile photo, or "" if there is
+// no photo or no file storage.
+func AvatarURL(path string) string {
+	if path == "" || storageProvider == nil {
+		return ""
+	}
+	return storageProvider.URL(path)
+}
+
+// Initials returns the first letters of the first and last words of the
+// name, such as "JD" for "Jane Q. Doe".
+func (a Avatar) Initials() string {
+	words := strings.Fields(a.Name)
+	if len(words) == 0 {
+		return "?"
+	}
+	initials := firstLetter(words[0])
+	if len(words) > 1 {
+		initials += firstLetter(words[len(words)-1])
+	}
+	return initials
+}
+
+// firstLetter returns the first rune of s, upper-cased.
+func firstLetter(s string) string {
+	r, _ := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r))
+}
+
+// UserAvatar returns the signed-in user's avatar.
+func (vm BaseVM) UserAvatar() Avatar {
+	return Avatar{URL: vm.AvatarURL, Name: vm.UserName}
+}
@@ -0,0 +1,59 @@
+package viewdata
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+)
+
+// locations caches loaded timezones by ID.
+var locations sync.Map // map[string]*time.Location
+
+// LoadLocation returns the timezone with the given ID, or UTC if the ID is
+// empty or unknown.
+func LoadLocation(id string) *time.Location {
+	if id == "" {
+		return time.UTC
+	}
+	if loc, ok := locations.Load(id); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(id)
+	if err != nil {
+		return time.UTC
+	}
+	locations.Store(id, loc)
+	return loc
+}
+
+// Location returns the signed-in user's timezone from their profile, or
+// UTC if they haven't chosen one or aren't signed in.
+func Location(r *http.Request) *time.Location {
+	if user, ok := auth.CurrentUser(r); ok {
+		return LoadLocation(user.Timezone)
+	}
+	return time.UTC
+}
+
+// FormatTime formats t in the signed-in user's timezone. The zero time
+// formats as "".
+func FormatTime(r *http.Request, t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location(r)).Format(layout)
+}
+
+// LocalTime formats t in the user's timezone, for templates:
+//
+//	{{ $.LocalTime .CreatedAt "Jan 2, 2006 3:04 PM" }}
+//
+// The zero time formats as "".
+func (vm BaseVM) LocalTime(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(LoadLocation(vm.Timezone)).Format(layout)
+}
@@ -0,0 +1,45 @@
+package viewdata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dalemusser/stratasave/internal/app/system/auth"
+)
+
+func TestLoadLocation(t *testing.T) {
+	if got := LoadLocation(""); got != time.UTC {
+		t.Errorf(`LoadLocation("") = %v, want UTC`, got)
+	}
+	if got := LoadLocation("Not/A_Zone"); got != time.UTC {
+		t.Errorf("LoadLocation(unknown) = %v, want UTC", got)
+	}
+	if got := LoadLocation("America/Chicago"); got.String() != "America/Chicago" {
+		t.Errorf("LoadLocation() = %v, want America/Chicago", got)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2026, 1, 15, 3, 30, 0, 0, time.UTC)
+	const layout = "Jan 2, 2006 3:04 PM"
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := FormatTime(req, ts, layout); got != "Jan 15, 2026 3:30 AM" {
+		t.Errorf("signed out: FormatTime() = %q, want UTC", got)
+	}
+
+	req = auth.WithTestUser(req, &auth.SessionUser{ID: "abc", Timezone: "America/Chicago"})
+	if got := FormatTime(req, ts, layout); got != "Jan 14, 2026 9:30 PM" {
+		t.Errorf("FormatTime() = %q, want the previous evening in Chicago", got)
+	}
+	if got := FormatTime(req, time.Time{}, layout); got != "" {
+		t.Errorf("FormatTime(zero) = %q, want empty", got)
+	}
+
+	vm := BaseVM{Timezone: "Asia/Tokyo"}
+	if got := vm.LocalTime(ts, layout); got != "Jan 15, 2026 12:30 PM" {
+		t.Errorf("LocalTime() = %q, want Tokyo time", got)
+	}
+}
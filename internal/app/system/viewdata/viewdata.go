@@ -8,7 +8,9 @@ package viewdata
 import (
 	"context"
 	"html/template"
+	"math"
 	"net/http"
+	"time"
 
 	settingsstore "github.com/dalemusser/stratasave/internal/app/store/settings"
 	"github.com/dalemusser/stratasave/internal/app/system/auth"
@@ -19,6 +21,7 @@ import (
 	"github.com/dalemusser/waffle/pantry/httpnav"
 	"github.com/dalemusser/waffle/pantry/storage"
 	"github.com/gorilla/csrf"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -29,6 +32,13 @@ type AnnouncementVM struct {
 	Content     string
 	Type        string // info, warning, critical
 	Dismissible bool
+	RequireAck  bool // the user must acknowledge it; shown until they do
+}
+
+// NavLinkVM is a link added to the site menu, such as a custom page.
+type NavLinkVM struct {
+	Label string
+	URL   string
 }
 
 // BaseVM contains common fields for all view models.
@@ -57,7 +67,14 @@ type BaseVM struct {
 	LoginID         string // User's login identifier (for per-user tracking)
 	Role            string
 	UserName        string
+	AvatarURL       string // Profile photo (empty = show initials)
 	ThemePreference string // light, dark, system (empty = system)
+	Timezone        string // Timezone ID for LocalTime (empty = UTC)
+
+	// Impersonation banner (set while an admin is acting as this user)
+	Impersonating     bool
+	ImpersonatorName  string
+	ImpersonationEnds time.Time
 
 	// Page context
 	Title       string
@@ -69,20 +86,30 @@ type BaseVM struct {
 
 	// Announcements for banner display
 	Announcements []AnnouncementVM
+
+	// Custom pages linked in the site menu
+	NavLinks []NavLinkVM
 }
 
-// storageProvider is set by Init and used to generate logo URLs.
+// storageProvider is set by Init and used to generate logo and avatar URLs.
 var storageProvider storage.Store
 
 // globalDB is set by Init and used by New() to load settings.
 var globalDB *mongo.Database
 
-// AnnouncementLoader is a function that loads active announcements.
-// This is set by bootstrap to avoid circular dependencies.
-type AnnouncementLoader func(ctx context.Context) []AnnouncementVM
+// AnnouncementLoader is a function that loads the active announcements to
+// show the given user. This is set by bootstrap to avoid circular
+// dependencies.
+type AnnouncementLoader func(ctx context.Context, userID primitive.ObjectID) []AnnouncementVM
 
 var announcementLoader AnnouncementLoader
 
+// NavLinkLoader is a function that loads the custom pages linked in the
+// site menu. This is set by bootstrap to avoid circular dependencies.
+type NavLinkLoader func(ctx context.Context) []NavLinkVM
+
+var navLinkLoader NavLinkLoader
+
 // Init sets the storage provider and database for viewdata.
 // Call this once at startup from bootstrap.
 func Init(store storage.Store, db *mongo.Database) {
@@ -96,6 +123,12 @@ func SetAnnouncementLoader(loader AnnouncementLoader) {
 	announcementLoader = loader
 }
 
+// SetNavLinkLoader sets the function used to load the site menu's custom
+// page links. Call this once at startup from bootstrap.
+func SetNavLinkLoader(loader NavLinkLoader) {
+	navLinkLoader = loader
+}
+
 // NewBaseVM creates a fully populated BaseVM for a page.
 // This is the preferred way to create a BaseVM for embedding in view models.
 //
@@ -124,6 +157,11 @@ func NewBaseVM(r *http.Request, db *mongo.Database, title, backDefault string) B
 	if signedIn {
 		if user, ok := auth.CurrentUser(r); ok {
 			vm.LoginID = user.LoginID
+			vm.AvatarURL = AvatarURL(user.AvatarPath)
+			vm.Timezone = user.Timezone
+			vm.Impersonating = user.IsImpersonated()
+			vm.ImpersonatorName = user.ImpersonatorName
+			vm.ImpersonationEnds = user.ImpersonationEnds
 		}
 	}
 
@@ -148,12 +186,22 @@ func NewBaseVM(r *http.Request, db *mongo.Database, title, backDefault string) B
 
 	// Load active announcements only if logged in and loader is configured
 	if signedIn && announcementLoader != nil {
-		vm.Announcements = announcementLoader(r.Context())
+		vm.Announcements = announcementLoader(r.Context(), userID)
+	}
+
+	if navLinkLoader != nil {
+		vm.NavLinks = navLinkLoader(r.Context())
 	}
 
 	return vm
 }
 
+// ImpersonationMinutesLeft returns the minutes, rounded up, until the
+// current impersonation ends.
+func (vm BaseVM) ImpersonationMinutesLeft() int {
+	return int(math.Ceil(time.Until(vm.ImpersonationEnds).Minutes()))
+}
+
 // GetSiteName returns the site name from settings, or the default if not available.
 func GetSiteName(ctx context.Context, db *mongo.Database) string {
 	if db == nil {
@@ -202,6 +250,11 @@ func New(r *http.Request) BaseVM {
 	if signedIn {
 		if user, ok := auth.CurrentUser(r); ok {
 			vm.LoginID = user.LoginID
+			vm.AvatarURL = AvatarURL(user.AvatarPath)
+			vm.Timezone = user.Timezone
+			vm.Impersonating = user.IsImpersonated()
+			vm.ImpersonatorName = user.ImpersonatorName
+			vm.ImpersonationEnds = user.ImpersonationEnds
 		}
 	}
 
@@ -227,7 +280,11 @@ func New(r *http.Request) BaseVM {
 
 	// Load active announcements only if logged in and loader is configured
 	if signedIn && announcementLoader != nil {
-		vm.Announcements = announcementLoader(r.Context())
+		vm.Announcements = announcementLoader(r.Context(), userID)
+	}
+
+	if navLinkLoader != nil {
+		vm.NavLinks = navLinkLoader(r.Context())
 	}
 
 	return vm
@@ -0,0 +1,221 @@
+// Package virusscan checks uploaded content for malware before it becomes
+// a library file.
+//
+// Two scanners are supported:
+//
+//   - "clamav" streams the content to a clamd daemon with the INSTREAM
+//     command, over TCP ("tcp://host:3310" or "host:3310") or a Unix socket
+//     ("unix:///var/run/clamav/clamd.ctl").
+//   - "http" POSTs the content to a scanning API, which must answer 200 with
+//     {"infected": bool, "signature": "name of what was found"}. A bearer
+//     token is sent if one is configured.
+//
+// A scanner that can't be reached is an error. Whether the upload is then
+// rejected or accepted unscanned is up to the caller (see FailOpen).
+package virusscan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supported scanners.
+const (
+	ClamAV = "clamav"
+	HTTP   = "http"
+)
+
+// Scan statuses recorded on library files.
+const (
+	StatusClean     = "clean"     // Scanned, nothing found
+	StatusUnscanned = "unscanned" // Accepted while the scanner was unavailable
+)
+
+// timeout bounds one scan, including sending the content.
+const timeout = 5 * time.Minute
+
+// chunkSize is how much content is sent to clamd per INSTREAM chunk.
+const chunkSize = 64 << 10
+
+// Result is a scanner's verdict on some content.
+type Result struct {
+	Infected  bool
+	Signature string // What was found, if infected
+}
+
+// ValidKind reports whether kind names a supported scanner.
+func ValidKind(kind string) bool {
+	return kind == ClamAV || kind == HTTP
+}
+
+// Scanner scans content with a configured scanner.
+type Scanner struct {
+	kind     string
+	address  string
+	token    string
+	failOpen bool
+	client   *http.Client
+}
+
+// New creates a Scanner. It returns nil (scanning disabled) when kind is
+// empty. failOpen accepts uploads, marked unscanned, while the scanner is
+// unavailable.
+func New(kind, address, token string, failOpen bool) (*Scanner, error) {
+	if kind == "" {
+		return nil, nil
+	}
+	if !ValidKind(kind) {
+		return nil, fmt.Errorf("unknown virus scanner %q", kind)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("virus scanner %q needs an address", kind)
+	}
+	return &Scanner{
+		kind:     kind,
+		address:  address,
+		token:    token,
+		failOpen: failOpen,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// FailOpen reports whether uploads are accepted while the scanner is
+// unavailable.
+func (s *Scanner) FailOpen() bool {
+	return s.failOpen
+}
+
+// Scan reads r to the end and returns the scanner's verdict.
+func (s *Scanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if s.kind == HTTP {
+		return s.scanHTTP(ctx, r)
+	}
+	return s.scanClamAV(ctx, r)
+}
+
+// scanClamAV streams r to clamd with the INSTREAM command.
+func (s *Scanner) scanClamAV(ctx context.Context, r io.Reader) (Result, error) {
+	network, addr := clamdAddress(s.address)
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	w := bufio.NewWriterSize(conn, chunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return Result{}, fmt.Errorf("send to clamd: %w", err)
+	}
+	buf := make([]byte, chunkSize)
+	var size [4]byte
+	for {
+		n, rerr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return Result{}, fmt.Errorf("send to clamd: %w", err)
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return Result{}, fmt.Errorf("send to clamd: %w", err)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			return Result{}, fmt.Errorf("read content: %w", rerr)
+		}
+	}
+	// A zero-length chunk ends the stream
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return Result{}, fmt.Errorf("send to clamd: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return Result{}, fmt.Errorf("send to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Result{}, fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseClamdReply(reply)
+}
+
+// clamdAddress splits a clamd address into a network and address for
+// net.Dial.
+func clamdAddress(address string) (string, string) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return "unix", strings.TrimPrefix(address, "unix://")
+	case strings.HasPrefix(address, "tcp://"):
+		return "tcp", strings.TrimPrefix(address, "tcp://")
+	case strings.HasPrefix(address, "/"):
+		return "unix", address
+	default:
+		return "tcp", address
+	}
+}
+
+// parseClamdReply interprets clamd's answer to INSTREAM, such as
+// "stream: OK" or "stream: Eicar-Test-Signature FOUND".
+func parseClamdReply(reply string) (Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	case reply == "":
+		return Result{}, errors.New("clamd closed the connection without a reply")
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// scanHTTP posts r to a scanning API.
+func (s *Scanner) scanHTTP(ctx context.Context, r io.Reader) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.address, r)
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("scanning API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("scanning API returned %s", resp.Status)
+	}
+	var verdict struct {
+		Infected  bool   `json:"infected"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return Result{}, fmt.Errorf("decode scanning API response: %w", err)
+	}
+	return Result{Infected: verdict.Infected, Signature: verdict.Signature}, nil
+}
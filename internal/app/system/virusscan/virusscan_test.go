@@ -0,0 +1,166 @@
+package virusscan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeClamd accepts one INSTREAM session, reassembles the streamed content,
+// and answers with reply(content).
+func fakeClamd(t *testing.T, reply func(content string) string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		br := bufio.NewReader(conn)
+		cmd, err := br.ReadString('\x00')
+		if err != nil || cmd != "zINSTREAM\x00" {
+			return
+		}
+		var content strings.Builder
+		var size [4]byte
+		for {
+			if _, err := io.ReadFull(br, size[:]); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size[:])
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, br, int64(n)); err != nil {
+				return
+			}
+		}
+		conn.Write([]byte(reply(content.String()) + "\x00"))
+	}()
+	return "tcp://" + ln.Addr().String()
+}
+
+func TestNew(t *testing.T) {
+	if s, err := New("", "", "", false); s != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v; want nil, nil", s, err)
+	}
+	if _, err := New("mcafee", "localhost:3310", "", false); err == nil {
+		t.Error("expected error for unknown scanner")
+	}
+	if _, err := New(ClamAV, "", "", false); err == nil {
+		t.Error("expected error for missing address")
+	}
+	s, err := New(HTTP, "http://scanner.local/scan", "tok", true)
+	if err != nil || s == nil {
+		t.Fatalf("New(http) = %v, %v", s, err)
+	}
+	if !s.FailOpen() {
+		t.Error("FailOpen() = false, want true")
+	}
+}
+
+func TestClamdAddress(t *testing.T) {
+	tests := []struct {
+		in, network, addr string
+	}{
+		{"tcp://clamd:3310", "tcp", "clamd:3310"},
+		{"clamd:3310", "tcp", "clamd:3310"},
+		{"unix:///run/clamd.ctl", "unix", "/run/clamd.ctl"},
+		{"/run/clamd.ctl", "unix", "/run/clamd.ctl"},
+	}
+	for _, tt := range tests {
+		network, addr := clamdAddress(tt.in)
+		if network != tt.network || addr != tt.addr {
+			t.Errorf("clamdAddress(%q) = %q, %q; want %q, %q", tt.in, network, addr, tt.network, tt.addr)
+		}
+	}
+}
+
+func TestParseClamdReply(t *testing.T) {
+	if res, err := parseClamdReply("stream: OK\x00"); err != nil || res.Infected {
+		t.Errorf("OK reply = %+v, %v", res, err)
+	}
+	res, err := parseClamdReply("stream: Eicar-Test-Signature FOUND\x00")
+	if err != nil || !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Errorf("FOUND reply = %+v, %v", res, err)
+	}
+	if _, err := parseClamdReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Error("expected error for ERROR reply")
+	}
+	if _, err := parseClamdReply(""); err == nil {
+		t.Error("expected error for empty reply")
+	}
+}
+
+func TestScan_ClamAV(t *testing.T) {
+	body := strings.Repeat("x", chunkSize+100) + "EICAR"
+	addr := fakeClamd(t, func(content string) string {
+		if content != body {
+			return "stream: content mismatch ERROR"
+		}
+		return "stream: Eicar-Test-Signature FOUND"
+	})
+	s, _ := New(ClamAV, addr, "", false)
+
+	res, err := s.Scan(context.Background(), strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if !res.Infected || res.Signature != "Eicar-Test-Signature" {
+		t.Errorf("Scan() = %+v, want infected", res)
+	}
+}
+
+func TestScan_ClamAVUnavailable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s, _ := New(ClamAV, addr, "", false)
+	if _, err := s.Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Error("expected error when clamd is unreachable")
+	}
+}
+
+func TestScan_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(data), "EICAR") {
+			w.Write([]byte(`{"infected":true,"signature":"EICAR"}`))
+			return
+		}
+		w.Write([]byte(`{"infected":false}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	s, _ := New(HTTP, srv.URL, "tok", false)
+	res, err := s.Scan(context.Background(), strings.NewReader("hello"))
+	if err != nil || res.Infected {
+		t.Errorf("clean Scan() = %+v, %v", res, err)
+	}
+	res, err = s.Scan(context.Background(), strings.NewReader("EICAR"))
+	if err != nil || !res.Infected || res.Signature != "EICAR" {
+		t.Errorf("infected Scan() = %+v, %v", res, err)
+	}
+
+	bad, _ := New(HTTP, srv.URL, "wrong", false)
+	if _, err := bad.Scan(context.Background(), strings.NewReader("hello")); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+}
@@ -0,0 +1,80 @@
+// internal/app/system/webhooks/data.go
+package webhooks
+
+import (
+	"time"
+
+	announcementstore "github.com/dalemusser/stratasave/internal/app/store/announcement"
+	apikeystore "github.com/dalemusser/stratasave/internal/app/store/apikeys"
+	"github.com/dalemusser/stratasave/internal/domain/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserData returns the event data for a new user. source says how the
+// account was made: "admin", "import", "invitation", or "registration".
+// Secrets such as password hashes are never included.
+func UserData(u *models.User, source string) map[string]any {
+	return map[string]any{
+		"id":          u.ID.Hex(),
+		"full_name":   u.FullName,
+		"login_id":    deref(u.LoginID),
+		"email":       deref(u.Email),
+		"auth_method": u.AuthMethod,
+		"role":        u.Role,
+		"status":      u.Status,
+		"source":      source,
+	}
+}
+
+// SaveData returns the event data for a new save. The save data itself is
+// left out; receivers load it through the API if they need it.
+func SaveData(id primitive.ObjectID, userID, game string, timestamp time.Time) map[string]any {
+	return map[string]any{
+		"id":        id.Hex(),
+		"user_id":   userID,
+		"game":      game,
+		"timestamp": timestamp.UTC(),
+	}
+}
+
+// AnnouncementData returns the event data for a published announcement.
+func AnnouncementData(a *announcementstore.Announcement) map[string]any {
+	data := map[string]any{
+		"id":          a.ID.Hex(),
+		"title":       a.Title,
+		"content":     a.Content,
+		"type":        string(a.Type),
+		"dismissible": a.Dismissible,
+	}
+	if a.StartsAt != nil {
+		data["starts_at"] = a.StartsAt.UTC()
+	}
+	if a.EndsAt != nil {
+		data["ends_at"] = a.EndsAt.UTC()
+	}
+	return data
+}
+
+// APIKeyData returns the event data for a revoked API key. Only the key's
+// display prefix is included, never its hash.
+func APIKeyData(k *apikeystore.APIKey) map[string]any {
+	data := map[string]any{
+		"id":         k.ID.Hex(),
+		"name":       k.Name,
+		"key_prefix": k.KeyPrefix,
+	}
+	if k.RevokedAt != nil {
+		data["revoked_at"] = k.RevokedAt.UTC()
+	}
+	if !k.RevokedBy.IsZero() {
+		data["revoked_by"] = k.RevokedBy.Hex()
+	}
+	return data
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
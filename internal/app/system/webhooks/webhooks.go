@@ -0,0 +1,429 @@
+// Package webhooks sends platform events to admin-registered HTTP endpoints
+// and records each delivery.
+//
+// Publish stores one delivery per active endpoint subscribed to the event
+// and delivers it from the "webhooks" job queue, retrying failed attempts
+// with backoff. Each request is a JSON envelope signed with the endpoint's
+// secret:
+//
+//	X-Webhook-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// where timestamp is the X-Webhook-Timestamp header (Unix seconds).
+// Receivers should recompute the signature and reject old timestamps.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	jobstore "github.com/dalemusser/stratasave/internal/app/store/jobs"
+	webhookstore "github.com/dalemusser/stratasave/internal/app/store/webhooks"
+	"github.com/dalemusser/stratasave/internal/app/system/jobrunner"
+	"github.com/dalemusser/stratasave/internal/app/system/tasks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const (
+	// QueueName is the job queue webhooks are delivered from.
+	QueueName = "webhooks"
+	// JobType is the job type that sends one delivery.
+	JobType = "deliver_webhook"
+)
+
+// Events endpoints can subscribe to.
+const (
+	EventUserCreated           = "user.created"
+	EventSaveCreated           = "save.created"
+	EventAnnouncementPublished = "announcement.published"
+	EventAPIKeyRevoked         = "apikey.revoked"
+
+	// EventPing is sent by the console's "Send test" button. Every endpoint
+	// receives it; it can't be subscribed to.
+	EventPing = "ping"
+)
+
+// Events lists the subscribable events in display order.
+var Events = []string{
+	EventUserCreated,
+	EventSaveCreated,
+	EventAnnouncementPublished,
+	EventAPIKeyRevoked,
+}
+
+// ValidEvent reports whether event is one of Events.
+func ValidEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Request headers.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponseBody is how much of an endpoint's response is kept.
+const maxResponseBody = 1024
+
+// endpointCacheTTL is how long the active endpoints are cached between
+// publishes. The console calls Invalidate after every change.
+const endpointCacheTTL = 30 * time.Second
+
+// Sign returns the signature header value for body sent at timestamp
+// (Unix seconds).
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Envelope is the JSON body of every delivery.
+type Envelope struct {
+	ID        string         `json:"id"` // Delivery ID; the same on every attempt
+	Event     string         `json:"event"`
+	CreatedAt time.Time      `json:"created_at"`
+	Data      map[string]any `json:"data"`
+}
+
+// Config holds webhook delivery settings.
+type Config struct {
+	// MaxAttempts is the number of delivery attempts before a delivery is
+	// marked failed.
+	MaxAttempts int
+
+	// RetryDelay and MaxRetryDelay are the job runner's retry backoff
+	// settings, used to show when the next attempt is due.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// Timeout bounds each delivery request.
+	Timeout time.Duration
+
+	// Retention is how long successful deliveries are kept. Zero keeps
+	// them forever. Failed deliveries are always kept so they can be
+	// retried.
+	Retention time.Duration
+}
+
+// Dispatcher publishes events and delivers them from the job queue.
+type Dispatcher struct {
+	store  *webhookstore.Store
+	jobs   *jobstore.Store
+	client *http.Client
+	cfg    Config
+	log    *zap.Logger
+
+	mu        sync.Mutex
+	endpoints []webhookstore.Endpoint
+	loadedAt  time.Time
+}
+
+// New creates a Dispatcher.
+func New(db *mongo.Database, cfg Config, logger *zap.Logger) *Dispatcher {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &Dispatcher{
+		store:  webhookstore.New(db),
+		jobs:   jobstore.New(db),
+		client: newClient(cfg.Timeout),
+		cfg:    cfg,
+		log:    logger,
+	}
+}
+
+// newClient returns the HTTP client deliveries are sent with.
+func newClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		// A redirect would resend the signed body somewhere the admin
+		// didn't register; report it as a failed attempt instead
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Invalidate drops the cached endpoint list so the next event sees
+// changes made in the console.
+func (d *Dispatcher) Invalidate() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.endpoints = nil
+	d.loadedAt = time.Time{}
+	d.mu.Unlock()
+}
+
+// Publish queues event for every active endpoint subscribed to it. It
+// returns immediately; failures are logged, never returned, so a webhook
+// problem can't fail the request that raised the event. Safe to call on a
+// nil Dispatcher.
+func (d *Dispatcher) Publish(event string, data map[string]any) {
+	if d == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := d.publish(ctx, event, data); err != nil {
+			d.log.Error("failed to publish webhook event",
+				zap.String("event", event),
+				zap.Error(err))
+		}
+	}()
+}
+
+func (d *Dispatcher) publish(ctx context.Context, event string, data map[string]any) error {
+	endpoints, err := d.activeEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, ep := range endpoints {
+		if !ep.Subscribes(event) {
+			continue
+		}
+		if _, err := d.enqueue(ctx, ep.ID, event, data); err != nil {
+			errs = append(errs, fmt.Errorf("endpoint %s: %w", ep.ID.Hex(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// activeEndpoints returns the active endpoints, cached for endpointCacheTTL.
+func (d *Dispatcher) activeEndpoints(ctx context.Context) ([]webhookstore.Endpoint, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.loadedAt.IsZero() && time.Since(d.loadedAt) < endpointCacheTTL {
+		return d.endpoints, nil
+	}
+	endpoints, err := d.store.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook endpoints: %w", err)
+	}
+	d.endpoints = endpoints
+	d.loadedAt = time.Now()
+	return endpoints, nil
+}
+
+// SendTest queues a ping to an endpoint, whether or not it is active.
+func (d *Dispatcher) SendTest(ctx context.Context, endpointID primitive.ObjectID) (webhookstore.Delivery, error) {
+	return d.enqueue(ctx, endpointID, EventPing, map[string]any{"message": "Test event sent from the admin console."})
+}
+
+// enqueue stores a delivery of event to one endpoint and schedules it.
+func (d *Dispatcher) enqueue(ctx context.Context, endpointID primitive.ObjectID, event string, data map[string]any) (webhookstore.Delivery, error) {
+	id := primitive.NewObjectID()
+	body, err := json.Marshal(Envelope{
+		ID:        id.Hex(),
+		Event:     event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		return webhookstore.Delivery{}, fmt.Errorf("encode webhook payload: %w", err)
+	}
+	del, err := d.store.CreateDelivery(ctx, webhookstore.DeliveryInput{
+		ID:          id,
+		EndpointID:  endpointID,
+		Event:       event,
+		Payload:     string(body),
+		MaxAttempts: d.cfg.MaxAttempts,
+	})
+	if err != nil {
+		return webhookstore.Delivery{}, fmt.Errorf("store webhook delivery: %w", err)
+	}
+	return del, d.schedule(ctx, del)
+}
+
+// Retry requeues a failed delivery with a fresh set of attempts.
+// Returns webhookstore.ErrNotFound if the delivery has not failed.
+func (d *Dispatcher) Retry(ctx context.Context, id primitive.ObjectID) error {
+	if err := d.store.RequeueDelivery(ctx, id); err != nil {
+		return err
+	}
+	del, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+	return d.schedule(ctx, *del)
+}
+
+// schedule creates the job that sends del.
+func (d *Dispatcher) schedule(ctx context.Context, del webhookstore.Delivery) error {
+	job, err := d.jobs.Create(ctx, jobstore.CreateInput{
+		QueueName:   QueueName,
+		JobType:     JobType,
+		Payload:     map[string]any{"delivery_id": del.ID.Hex()},
+		MaxAttempts: del.MaxAttempts,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue webhook job: %w", err)
+	}
+	if err := d.store.SetDeliveryJobID(ctx, del.ID, job.ID); err != nil {
+		// The job still sends the delivery; only the console link is lost
+		d.log.Warn("failed to record webhook job id",
+			zap.String("delivery_id", del.ID.Hex()),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// Register adds the webhooks queue and its job handler to r.
+func (d *Dispatcher) Register(r *jobrunner.Runner) {
+	r.AddQueue(QueueName)
+	r.Register(JobType, d.handle)
+}
+
+// handle sends one delivery. Returning an error lets the job runner
+// schedule the next attempt.
+func (d *Dispatcher) handle(ctx context.Context, payload map[string]any) (map[string]any, error) {
+	idStr, _ := payload["delivery_id"].(string)
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delivery_id %q", idStr)
+	}
+
+	del, err := d.store.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("load webhook delivery: %w", err)
+	}
+	if del.Status == webhookstore.StatusDelivered {
+		// A stale job was re-queued after the delivery succeeded
+		return map[string]any{"skipped": "already delivered"}, nil
+	}
+
+	// Use a fresh context so the result is recorded even if the job timed out
+	recCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ep, err := d.store.GetByID(ctx, del.EndpointID)
+	if errors.Is(err, webhookstore.ErrNotFound) || (err == nil && !ep.Active && del.Event != EventPing) {
+		// Retrying wouldn't help, so fail the delivery without failing the job
+		if err := d.store.MarkAttemptFailed(recCtx, id, 0, "", "endpoint deleted or disabled", nil); err != nil {
+			d.log.Error("failed to record skipped webhook delivery",
+				zap.String("delivery_id", idStr),
+				zap.Error(err))
+		}
+		return map[string]any{"skipped": "endpoint unavailable"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load webhook endpoint: %w", err)
+	}
+
+	code, body, sendErr := d.send(ctx, ep, del)
+	if sendErr == nil {
+		if err := d.store.MarkDelivered(recCtx, id, code, body); err != nil {
+			d.log.Error("failed to mark webhook delivered",
+				zap.String("delivery_id", idStr),
+				zap.Error(err))
+		}
+		return map[string]any{"url": ep.URL, "status": code}, nil
+	}
+
+	attempt := del.Attempts + 1
+	next := NextAttempt(time.Now(), attempt, del.MaxAttempts, d.cfg.RetryDelay, d.cfg.MaxRetryDelay)
+	if err := d.store.MarkAttemptFailed(recCtx, id, code, body, sendErr.Error(), next); err != nil {
+		d.log.Error("failed to record webhook delivery failure",
+			zap.String("delivery_id", idStr),
+			zap.Error(err))
+	}
+	if next == nil {
+		d.log.Warn("webhook delivery failed, giving up",
+			zap.String("delivery_id", idStr),
+			zap.String("url", ep.URL),
+			zap.String("event", del.Event),
+			zap.Int("attempts", attempt),
+			zap.Error(sendErr))
+	}
+	return nil, sendErr
+}
+
+// send posts a delivery to its endpoint, returning the response status and
+// the start of the response body. A non-2xx status is an error.
+func (d *Dispatcher) send(ctx context.Context, ep *webhookstore.Endpoint, del *webhookstore.Delivery) (int, string, error) {
+	body := []byte(del.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, strings.NewReader(del.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "StrataSave-Webhooks/1")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, del.ID.Hex())
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(ep.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
+
+// NextAttempt returns when the job runner will retry a delivery after the
+// given attempt failed, or nil if no attempts remain. It mirrors the
+// runner's backoff.
+func NextAttempt(now time.Time, attempt, maxAttempts int, retryDelay, maxRetryDelay time.Duration) *time.Time {
+	if attempt >= maxAttempts {
+		return nil
+	}
+	t := now.Add(jobrunner.Backoff(retryDelay, maxRetryDelay, attempt))
+	return &t
+}
+
+// Jobs returns the periodic tasks for webhooks: removing successful
+// deliveries past the retention period. Returns nil if retention is
+// disabled.
+func (d *Dispatcher) Jobs() []tasks.Job {
+	if d == nil || d.cfg.Retention <= 0 {
+		return nil
+	}
+	return []tasks.Job{{
+		Name:     "webhook-delivery-cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			deleted, err := d.store.DeleteDeliveredBefore(ctx, time.Now().Add(-d.cfg.Retention))
+			if err != nil {
+				return err
+			}
+			if deleted > 0 {
+				d.log.Info("removed delivered webhooks", zap.Int64("count", deleted))
+			}
+			return nil
+		},
+	}}
+}
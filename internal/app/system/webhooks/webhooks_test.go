@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	webhookstore "github.com/dalemusser/stratasave/internal/app/store/webhooks"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '1700000000.{"a":1}' | openssl dgst -sha256 -hmac secret
+	const want = "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	got := Sign("secret", "1700000000", []byte(`{"a":1}`))
+	if got != want {
+		t.Fatalf("Sign() = %q, want %q", got, want)
+	}
+	if Sign("other", "1700000000", []byte(`{"a":1}`)) == got {
+		t.Error("Sign() ignores the secret")
+	}
+	if Sign("secret", "1700000001", []byte(`{"a":1}`)) == got {
+		t.Error("Sign() ignores the timestamp")
+	}
+}
+
+func TestValidEvent(t *testing.T) {
+	for _, e := range Events {
+		if !ValidEvent(e) {
+			t.Errorf("ValidEvent(%q) = false, want true", e)
+		}
+	}
+	for _, e := range []string{"", EventPing, "user.deleted", "USER.CREATED"} {
+		if ValidEvent(e) {
+			t.Errorf("ValidEvent(%q) = true, want false", e)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var gotSig, gotTS, gotEvent string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(HeaderSignature)
+		gotTS = r.Header.Get(HeaderTimestamp)
+		gotEvent = r.Header.Get(HeaderEvent)
+		gotBody, _ = io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("thanks"))
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{client: newClient(5 * time.Second)}
+	del := &webhookstore.Delivery{
+		ID:      primitive.NewObjectID(),
+		Event:   EventUserCreated,
+		Payload: `{"event":"user.created"}`,
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantErr  bool
+	}{
+		{"success", "/ok", http.StatusOK, false},
+		{"server error", "/fail", http.StatusInternalServerError, true},
+		{"redirect not followed", "/redirect", http.StatusFound, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &webhookstore.Endpoint{URL: srv.URL + tt.path, Secret: "whsec_test"}
+			code, _, err := d.send(t.Context(), ep, del)
+			if code != tt.wantCode {
+				t.Errorf("send() code = %d, want %d", code, tt.wantCode)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("send() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotEvent != EventUserCreated {
+				t.Errorf("%s = %q, want %q", HeaderEvent, gotEvent, EventUserCreated)
+			}
+			if want := Sign("whsec_test", gotTS, gotBody); gotSig != want {
+				t.Errorf("%s = %q, want %q", HeaderSignature, gotSig, want)
+			}
+		})
+	}
+}
+
+func TestPublish_NilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Publish(EventUserCreated, nil) // must not panic
+	d.Invalidate()
+	if jobs := d.Jobs(); jobs != nil {
+		t.Errorf("nil Dispatcher Jobs() = %v, want nil", jobs)
+	}
+}
@@ -30,6 +30,17 @@ func IsValidAuthMethod(value string) bool {
 	return false
 }
 
+// AuthMethodLabel returns the display label for an auth method value, or
+// the value itself if it isn't a known method.
+func AuthMethodLabel(value string) string {
+	for _, m := range AllAuthMethods {
+		if m.Value == value {
+			return m.Label
+		}
+	}
+	return value
+}
+
 // AllAuthMethodValues returns all auth method values as a slice.
 func AllAuthMethodValues() []string {
 	values := make([]string, len(AllAuthMethods))
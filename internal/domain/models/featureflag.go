@@ -0,0 +1,22 @@
+// internal/domain/models/featureflag.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FeatureFlag is an admin's setting for one feature flag. Flags without a
+// stored setting use the default in their definition (see the featureflags
+// package).
+type FeatureFlag struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	Key           string              `bson:"key"`
+	Enabled       bool                `bson:"enabled"`
+	Environments  []string            `bson:"environments,omitempty"` // Environments the flag is on in, e.g. "prod"; empty means all
+	Rollout       int                 `bson:"rollout"`                // Percent of users (0-100) the flag is on for
+	UpdatedAt     time.Time           `bson:"updated_at"`
+	UpdatedByID   *primitive.ObjectID `bson:"updated_by_id,omitempty"`
+	UpdatedByName string              `bson:"updated_by_name,omitempty"`
+}
@@ -16,9 +16,30 @@ type File struct {
 	Size        int64               `bson:"size"`                // File size in bytes
 	ContentType string              `bson:"content_type"`        // MIME type
 	Description string              `bson:"description,omitempty"`
+	Tags        []string            `bson:"tags,omitempty"`        // Lowercase labels for categories that cut across folders
+	SHA256      string              `bson:"sha256,omitempty"`      // Hex digest of the content; files with the same digest share storage
+	ScanStatus  string              `bson:"scan_status,omitempty"` // "clean" or "unscanned"; empty if scanning was off
 	CreatedAt   time.Time           `bson:"created_at"`
 	UpdatedAt   time.Time           `bson:"updated_at"`
 	CreatedByID primitive.ObjectID  `bson:"created_by_id"`
+
+	// Access counts; each view or download is also kept in file_access
+	ViewCount      int64      `bson:"view_count,omitempty"`
+	DownloadCount  int64      `bson:"download_count,omitempty"` // Includes share-link downloads
+	LastAccessedAt *time.Time `bson:"last_accessed_at,omitempty"`
+
+	// Trash
+	DeletedAt     *time.Time          `bson:"deleted_at,omitempty"`      // Set while the file is in the trash
+	TrashedWithID *primitive.ObjectID `bson:"trashed_with_id,omitempty"` // Folder whose trashing took this file along
+
+	// Integrity; set when the content last failed its SHA-256 check as it
+	// was served, and cleared when it next passes
+	IntegrityFailedAt *time.Time `bson:"integrity_failed_at,omitempty"`
+}
+
+// InTrash returns true if the file has been deleted but not yet purged.
+func (f *File) InTrash() bool {
+	return f.DeletedAt != nil
 }
 
 // IsInRoot returns true if the file is at the root level (not in any folder).
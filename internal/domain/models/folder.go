@@ -13,9 +13,20 @@ type Folder struct {
 	NameCI      string              `bson:"name_ci"`             // Case-insensitive for sorting/search
 	ParentID    *primitive.ObjectID `bson:"parent_id,omitempty"` // nil = root folder
 	Description string              `bson:"description,omitempty"`
+	QuotaBytes  int64               `bson:"quota_bytes,omitempty"` // Most bytes the folder and its subfolders may hold (0 = no limit)
 	CreatedAt   time.Time           `bson:"created_at"`
 	UpdatedAt   time.Time           `bson:"updated_at"`
 	CreatedByID primitive.ObjectID  `bson:"created_by_id"`
+
+	// Trash
+	DeletedAt     *time.Time          `bson:"deleted_at,omitempty"`      // Set while the folder is in the trash
+	TrashedWithID *primitive.ObjectID `bson:"trashed_with_id,omitempty"` // Ancestor whose trashing took this folder along
+	PurgeJobID    *primitive.ObjectID `bson:"purge_job_id,omitempty"`    // Background job deleting the folder for good
+}
+
+// InTrash returns true if the folder has been deleted but not yet purged.
+func (f *Folder) InTrash() bool {
+	return f.DeletedAt != nil
 }
 
 // IsRoot returns true if the folder is at the root level.
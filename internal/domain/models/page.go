@@ -2,24 +2,60 @@
 package models
 
 import (
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Page represents editable content pages like About, Contact, Terms of Service, and Privacy Policy.
+// Title and Content are the published page; edits can be saved as a Draft
+// first, which visitors don't see until it is published.
 type Page struct {
 	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Slug    string             `bson:"slug" json:"slug"`       // URL slug: "about", "contact", "terms", "privacy"
 	Title   string             `bson:"title" json:"title"`     // Display title
 	Content string             `bson:"content" json:"content"` // HTML content from TipTap editor
 
+	// Draft holds unpublished edits, if any
+	Draft *PageDraft `bson:"draft,omitempty" json:"draft,omitempty"`
+
+	// Custom pages are created by admins at their own slugs, such as
+	// "docs/getting-started", and can be linked from the site navigation
+	Custom    bool   `bson:"custom,omitempty" json:"custom,omitempty"`
+	ShowInNav bool   `bson:"show_in_nav,omitempty" json:"show_in_nav,omitempty"` // Link the page in the site menu
+	NavLabel  string `bson:"nav_label,omitempty" json:"nav_label,omitempty"`     // Menu text (default: the title)
+	NavOrder  int    `bson:"nav_order,omitempty" json:"nav_order,omitempty"`     // Menu position, lowest first
+
 	// Audit fields
 	UpdatedAt     *time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 	UpdatedByID   *primitive.ObjectID `bson:"updated_by_id,omitempty" json:"updated_by_id,omitempty"`
 	UpdatedByName string              `bson:"updated_by_name,omitempty" json:"updated_by_name,omitempty"`
 }
 
+// Published reports whether the page has been published, as opposed to a
+// custom page that so far only has a draft.
+func (p *Page) Published() bool {
+	return p.Title != "" || p.Content != ""
+}
+
+// Label returns the page's text for the site menu.
+func (p *Page) Label() string {
+	if p.NavLabel != "" {
+		return p.NavLabel
+	}
+	return p.Title
+}
+
+// PageDraft is an unpublished edit of a page.
+type PageDraft struct {
+	Title         string              `bson:"title" json:"title"`
+	Content       string              `bson:"content" json:"content"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+	UpdatedByID   *primitive.ObjectID `bson:"updated_by_id,omitempty" json:"updated_by_id,omitempty"`
+	UpdatedByName string              `bson:"updated_by_name,omitempty" json:"updated_by_name,omitempty"`
+}
+
 // Page slugs
 const (
 	PageSlugAbout   = "about"
@@ -28,7 +64,7 @@ const (
 	PageSlugPrivacy = "privacy"
 )
 
-// AllPageSlugs returns all valid page slugs.
+// AllPageSlugs returns the slugs of the built-in pages.
 func AllPageSlugs() []string {
 	return []string{
 		PageSlugAbout,
@@ -38,7 +74,7 @@ func AllPageSlugs() []string {
 	}
 }
 
-// IsValidPageSlug checks if a slug is valid.
+// IsValidPageSlug checks if a slug is one of the built-in pages.
 func IsValidPageSlug(slug string) bool {
 	for _, s := range AllPageSlugs() {
 		if s == slug {
@@ -47,3 +83,16 @@ func IsValidPageSlug(slug string) bool {
 	}
 	return false
 }
+
+// MaxCustomPageSlugLength is the longest slug a custom page can have.
+const MaxCustomPageSlugLength = 100
+
+// customPageSlugPattern is one or more segments of lowercase letters,
+// digits and single hyphens, separated by slashes.
+var customPageSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*(/[a-z0-9]+(-[a-z0-9]+)*)*$`)
+
+// IsValidCustomPageSlug checks if a slug is well formed for a custom page,
+// such as "docs/getting-started". It doesn't check the slug is free.
+func IsValidCustomPageSlug(slug string) bool {
+	return len(slug) <= MaxCustomPageSlugLength && customPageSlugPattern.MatchString(slug)
+}
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -25,17 +26,44 @@ type SiteSettings struct {
 	// Footer
 	FooterHTML string `bson:"footer_html,omitempty" json:"footer_html,omitempty"` // Custom HTML for footer
 
+	// Email branding (the header logo is the site logo)
+	EmailPrimaryColor string `bson:"email_primary_color,omitempty" json:"email_primary_color,omitempty"` // Hex color for email buttons and links
+	EmailFooterText   string `bson:"email_footer_text,omitempty" json:"email_footer_text,omitempty"`     // Plain text added to every email footer
+
+	// Outgoing email server, replacing the mail_smtp_* and mail_from*
+	// config values when set
+	SMTP *SMTPSettings `bson:"smtp,omitempty" json:"smtp,omitempty"`
+
 	// Authentication
 	// EnabledAuthMethods is the list of auth methods enabled for this site.
 	// If empty/nil, all methods from AllAuthMethods are enabled (default).
 	EnabledAuthMethods []string `bson:"enabled_auth_methods,omitempty" json:"enabled_auth_methods,omitempty"`
 
+	// Self-service registration
+	// Disabled by default; accounts are otherwise created by admins or invitations.
+	RegistrationEnabled          bool   `bson:"registration_enabled" json:"registration_enabled"`                     // Show the public signup form at /register
+	RegistrationRole             string `bson:"registration_role,omitempty" json:"registration_role,omitempty"`       // Role given to new accounts (default: developer)
+	RegistrationRequiresApproval bool   `bson:"registration_requires_approval" json:"registration_requires_approval"` // New accounts wait for an admin to approve them
+
+	// Allowed email domains
+	// Restricts invitation acceptance and self-registration to addresses at
+	// these domains, such as "example.com". Empty allows any domain.
+	AllowedEmailDomains []string `bson:"allowed_email_domains,omitempty" json:"allowed_email_domains,omitempty"`
+
 	// Email Notification Settings
 	// All disabled by default (opt-in)
-	NotifyUserOnCreate  bool `bson:"notify_user_on_create" json:"notify_user_on_create"`   // Send welcome email when admin creates user
-	NotifyUserOnDisable bool `bson:"notify_user_on_disable" json:"notify_user_on_disable"` // Send notification when account disabled
-	NotifyUserOnEnable  bool `bson:"notify_user_on_enable" json:"notify_user_on_enable"`   // Send notification when account enabled
-	NotifyUserOnWelcome bool `bson:"notify_user_on_welcome" json:"notify_user_on_welcome"` // Send welcome email after invitation accepted
+	NotifyUserOnCreate    bool `bson:"notify_user_on_create" json:"notify_user_on_create"`         // Send welcome email when admin creates user
+	NotifyUserOnDisable   bool `bson:"notify_user_on_disable" json:"notify_user_on_disable"`       // Send notification when account disabled
+	NotifyUserOnEnable    bool `bson:"notify_user_on_enable" json:"notify_user_on_enable"`         // Send notification when account enabled
+	NotifyUserOnWelcome   bool `bson:"notify_user_on_welcome" json:"notify_user_on_welcome"`       // Send welcome email after invitation accepted
+	NotifyUserOnNewDevice bool `bson:"notify_user_on_new_device" json:"notify_user_on_new_device"` // Send security alert on login from an unrecognized device
+
+	// Scheduled reports
+	// Disabled by default. Sent to ReportRecipients, or every active admin if empty.
+	ReportWeekly     bool     `bson:"report_weekly" json:"report_weekly"`                             // Email a report on the previous week every Monday
+	ReportMonthly    bool     `bson:"report_monthly" json:"report_monthly"`                           // Email a report on the previous month on the 1st
+	ReportSections   []string `bson:"report_sections,omitempty" json:"report_sections,omitempty"`     // Sections to include (empty = all)
+	ReportRecipients []string `bson:"report_recipients,omitempty" json:"report_recipients,omitempty"` // Addresses to send reports to
 
 	// Audit fields
 	UpdatedAt     *time.Time          `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
@@ -43,6 +71,16 @@ type SiteSettings struct {
 	UpdatedByName string              `bson:"updated_by_name,omitempty" json:"updated_by_name,omitempty"`
 }
 
+// SMTPSettings is the SMTP server and sender set on the settings page.
+type SMTPSettings struct {
+	Host          string `bson:"host" json:"host"`
+	Port          int    `bson:"port" json:"port"`
+	User          string `bson:"user,omitempty" json:"user,omitempty"`
+	PassEncrypted string `bson:"pass_encrypted,omitempty" json:"-"` // Password sealed with settings_encryption_key
+	From          string `bson:"from" json:"from"`
+	FromName      string `bson:"from_name,omitempty" json:"from_name,omitempty"`
+}
+
 // HasLogo returns true if a logo has been uploaded.
 func (s *SiteSettings) HasLogo() bool {
 	return s.LogoPath != ""
@@ -82,6 +120,50 @@ func (s *SiteSettings) IsAuthMethodEnabled(method string) bool {
 	return false
 }
 
+// DefaultRegistrationRole returns the role assigned to self-registered users.
+// Falls back to the developer role if none is configured or the configured
+// role isn't allowed for self-registration.
+func (s *SiteSettings) DefaultRegistrationRole() string {
+	for _, r := range RegistrationRoles() {
+		if r == s.RegistrationRole {
+			return r
+		}
+	}
+	return RoleDeveloper
+}
+
+// EmailDomainAllowed reports whether an email address may be used to accept
+// an invitation or register. The domain must match an allowed domain
+// exactly, ignoring case; subdomains must be listed separately.
+func (s *SiteSettings) EmailDomainAllowed(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	for _, d := range s.AllowedEmailDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistrationRoles returns the roles that may be assigned to self-registered
+// users. Admin is never offered: public signup must not grant full access.
+func RegistrationRoles() []string {
+	var roles []string
+	for _, r := range AllRoles() {
+		if r != RoleAdmin {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
 // DefaultSiteName is the default site name used when settings don't exist.
 const DefaultSiteName = "StrataSave"
 
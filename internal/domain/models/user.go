@@ -6,6 +6,7 @@ package models
 //   - LoginID / loginID / login_id: The human-readable string users type to log in
 
 import (
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,6 +19,7 @@ import (
 //   - LoginIDCI: Case/diacritic-insensitive version for matching (folded)
 //   - Email: Contact email (optional, stored lowercase)
 //   - AuthMethod: google, email, password, trust
+//   - LinkedMethods: other sign-in methods the user connected to the account
 type User struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	FullName   string             `bson:"full_name" json:"full_name"`
@@ -29,21 +31,72 @@ type User struct {
 	Email     *string `bson:"email" json:"email"`             // Contact email (lowercase, optional)
 	AuthMethod string `bson:"auth_method" json:"auth_method"` // google, email, password, trust
 
+	// Connected accounts: sign-in methods linked besides AuthMethod (see Methods)
+	LinkedMethods []string `bson:"linked_methods,omitempty" json:"linked_methods,omitempty"`
+	GoogleID      string   `bson:"google_id,omitempty" json:"-"` // Google account ID, set once the user signs in with Google
+
 	// Password auth fields
 	PasswordHash *string `bson:"password_hash,omitempty" json:"-"` // bcrypt hash (never in JSON)
 	PasswordTemp *bool   `bson:"password_temp,omitempty" json:"-"` // true if must change on next login
 
+	// Password age (see the password_max_age setting)
+	PasswordChangedAt      *time.Time `bson:"password_changed_at,omitempty" json:"-"`       // When the password was last set
+	PasswordExpiryWarnedAt *time.Time `bson:"password_expiry_warned_at,omitempty" json:"-"` // When the expiry warning was sent (cleared on change)
+
+	// When the user last signed in (not set for accounts that never have)
+	LastLoginAt *time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+
+	// Sessions issued before this are rejected; set on password, role, and backup code changes
+	SessionsValidAfter *time.Time `bson:"sessions_valid_after,omitempty" json:"-"`
+
 	// Role and status
 	Role   string `bson:"role" json:"role"`                      // admin (extensible: add more roles as needed)
-	Status string `bson:"status,omitempty" json:"status,omitempty"` // active, disabled
+	Status string `bson:"status,omitempty" json:"status,omitempty"` // active, disabled, pending, deleted
+
+	// Soft delete (see the user_restore_days setting); cleared on restore
+	DeletedAt     *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedByID   *primitive.ObjectID `bson:"deleted_by_id,omitempty" json:"-"`
+	RestoreStatus string              `bson:"restore_status,omitempty" json:"-"` // Status to return to on restore
+
+	// Scheduled deactivation (contractors, seasonal staff); cleared when it happens
+	DisableAt       *time.Time `bson:"disable_at,omitempty" json:"disable_at,omitempty"` // When the account is disabled
+	DisableWarnedAt *time.Time `bson:"disable_warned_at,omitempty" json:"-"`             // When the warning was sent (cleared on change)
+
+	// Self-service deletion; the account is deleted on this date unless the user cancels
+	DeleteAt *time.Time `bson:"delete_at,omitempty" json:"delete_at,omitempty"`
 
 	// User preferences
 	ThemePreference string `bson:"theme_preference,omitempty" json:"theme_preference,omitempty"` // light, dark, system (empty = system)
+	Locale          string `bson:"locale,omitempty" json:"locale,omitempty"`                     // Email language, e.g. "es" (empty = default)
+	Timezone        string `bson:"timezone,omitempty" json:"timezone,omitempty"`                 // IANA ID, e.g. "America/Chicago" (empty = UTC)
+
+	// Profile photo, a square JPEG in file storage (empty = show initials)
+	AvatarPath string `bson:"avatar_path,omitempty" json:"-"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// Methods returns every sign-in method linked to the account, the primary
+// AuthMethod first.
+func (u *User) Methods() []string {
+	methods := make([]string, 0, 1+len(u.LinkedMethods))
+	if u.AuthMethod != "" {
+		methods = append(methods, u.AuthMethod)
+	}
+	for _, m := range u.LinkedMethods {
+		if !slices.Contains(methods, m) {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// HasMethod reports whether the user can sign in with method.
+func (u *User) HasMethod(method string) bool {
+	return method != "" && (u.AuthMethod == method || slices.Contains(u.LinkedMethods, method))
+}
+
 // User roles
 const (
 	RoleAdmin     = "admin"